@@ -88,6 +88,9 @@ type requirements struct {
 	Repo    string
 	Branch  string
 	Request *github.BranchProtectionRequest
+	// Ruleset is set to update a ruleset instead of the legacy branch
+	// protection. Branch is empty for the rulesets of the repo.
+	Ruleset *rulesetUpdate
 }
 
 // Errors holds a list of errors, including a method to concurrently append.
@@ -147,6 +150,7 @@ func main() {
 	p.protect()
 	close(p.updates)
 	errors := <-p.done
+	p.reportUnmanagedContexts()
 	if n := len(errors); n > 0 {
 		for i, err := range errors {
 			logrus.WithError(err).Error(i)
@@ -165,6 +169,11 @@ type client interface {
 	ListAppInstallationsForOrg(org string) ([]github.AppInstallation, error)
 	ListCollaborators(org, repo string) ([]github.User, error)
 	ListRepoTeams(org, repo string) ([]github.Team, error)
+	ListRepoRulesets(org, repo string) ([]github.Ruleset, error)
+	GetRepoRuleset(org, repo string, id int) (*github.Ruleset, error)
+	CreateRepoRuleset(org, repo string, ruleset github.Ruleset) error
+	UpdateRepoRuleset(org, repo string, id int, ruleset github.Ruleset) error
+	DeleteRepoRuleset(org, repo string, id int) error
 }
 
 type protector struct {
@@ -177,10 +186,25 @@ type protector struct {
 	verifyRestrictions     bool
 	enableAppsRestrictions bool
 	enabled                func(org, repo string) bool
+	unmanagedContexts      []unmanagedContexts
+}
+
+// unmanagedContexts are the contexts required on a branch that are not managed by Prow.
+type unmanagedContexts struct {
+	Org      string
+	Repo     string
+	Branch   string
+	Contexts []string
+	Policy   config.UnmanagedContextsPolicy
 }
 
 func (p *protector) configureBranches() {
 	for u := range p.updates {
+		if u.Ruleset != nil {
+			p.configureRuleset(u)
+			continue
+		}
+
 		if u.Request == nil {
 			if err := p.client.RemoveBranchProtection(u.Org, u.Repo, u.Branch); err != nil {
 				p.errors.add(fmt.Errorf("remove %s/%s=%s protection failed: %w", u.Org, u.Repo, u.Branch, err))
@@ -195,6 +219,23 @@ func (p *protector) configureBranches() {
 	p.done <- p.errors.errs
 }
 
+func (p *protector) configureRuleset(u requirements) {
+	switch {
+	case u.Ruleset.Ruleset == nil:
+		if err := p.client.DeleteRepoRuleset(u.Org, u.Repo, u.Ruleset.ID); err != nil {
+			p.errors.add(fmt.Errorf("delete %s/%s ruleset %d failed: %w", u.Org, u.Repo, u.Ruleset.ID, err))
+		}
+	case u.Ruleset.ID == 0:
+		if err := p.client.CreateRepoRuleset(u.Org, u.Repo, *u.Ruleset.Ruleset); err != nil {
+			p.errors.add(fmt.Errorf("create %s/%s ruleset %q failed: %w", u.Org, u.Repo, u.Ruleset.Ruleset.Name, err))
+		}
+	default:
+		if err := p.client.UpdateRepoRuleset(u.Org, u.Repo, u.Ruleset.ID, *u.Ruleset.Ruleset); err != nil {
+			p.errors.add(fmt.Errorf("update %s/%s ruleset %q failed: %w", u.Org, u.Repo, u.Ruleset.Ruleset.Name, err))
+		}
+	}
+}
+
 // protect protects branches specified in the presubmit and branch-protection config sections.
 func (p *protector) protect() {
 	bp := p.cfg.BranchProtection
@@ -325,6 +366,13 @@ func (p *protector) UpdateRepo(orgName string, repoName string, repo config.Repo
 		}
 	}
 
+	var rulesets *repoRulesets
+	if usesRulesets(repo) {
+		if rulesets, err = p.listRulesets(orgName, repoName); err != nil {
+			return fmt.Errorf("list rulesets: %w", err)
+		}
+	}
+
 	branches := map[string]github.Branch{}
 	for _, onlyProtected := range []bool{false, true} { // put true second so b.Protected is set correctly
 		bs, err := p.client.GetBranches(orgName, repoName, onlyProtected)
@@ -332,6 +380,9 @@ func (p *protector) UpdateRepo(orgName string, repoName string, repo config.Repo
 			return fmt.Errorf("list branches: %w", err)
 		}
 		for _, b := range bs {
+			if rulesets != nil {
+				rulesets.branches.Insert(b.Name)
+			}
 			_, ok := repo.Branches[b.Name]
 			if !ok && branchInclusions != nil && branchInclusions.MatchString(b.Name) {
 				branches[b.Name] = b
@@ -371,11 +422,21 @@ func (p *protector) UpdateRepo(orgName string, repoName string, repo config.Repo
 	for bn, githubBranch := range branches {
 		if branch, err := repo.GetBranch(bn); err != nil {
 			errs = append(errs, fmt.Errorf("get %s: %w", bn, err))
-		} else if err = p.UpdateBranch(orgName, repoName, bn, *branch, githubBranch.Protected, apps, collaborators, teams); err != nil {
+		} else if err = p.UpdateBranch(orgName, repoName, bn, *branch, githubBranch.Protected, apps, collaborators, teams, rulesets); err != nil {
 			errs = append(errs, fmt.Errorf("update %s from protected=%t: %w", bn, githubBranch.Protected, err))
 		}
 	}
 
+	if rulesets != nil {
+		if err := p.updatePushRuleset(rulesets, repo.Policy); err != nil {
+			errs = append(errs, fmt.Errorf("update push ruleset: %w", err))
+		}
+		// Don't remove the rulesets of branches that failed to update.
+		if len(errs) == 0 {
+			p.removeStaleRulesets(rulesets)
+		}
+	}
+
 	return utilerrors.NewAggregate(errs)
 }
 
@@ -452,7 +513,7 @@ func validateRestrictions(org, repo string, bp *github.BranchProtectionRequest,
 }
 
 // UpdateBranch updates the branch with the specified configuration
-func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch config.Branch, protected bool, authorizedApps, authorizedCollaborators, authorizedTeams []string) error {
+func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch config.Branch, protected bool, authorizedApps, authorizedCollaborators, authorizedTeams []string, rulesets *repoRulesets) error {
 	if branch.Unmanaged != nil && *branch.Unmanaged {
 		return nil
 	}
@@ -463,6 +524,13 @@ func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch
 	if bp == nil || bp.Protect == nil {
 		return nil
 	}
+	if rulesets != nil && bp.UsesRuleset() {
+		return p.updateBranchRuleset(rulesets, branchName, *bp)
+	}
+	if rulesets != nil {
+		// The branch is protected by its legacy protection again.
+		rulesets.managed.Insert(branchRulesetName(branchName))
+	}
 	if !protected && !*bp.Protect {
 		logrus.Infof("%s/%s=%s: already unprotected", orgName, repo, branchName)
 		return nil
@@ -502,6 +570,10 @@ func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch
 		return fmt.Errorf("get current branch protection: %w", err)
 	}
 
+	if req != nil && currentBP != nil {
+		p.handleUnmanagedContexts(orgName, repo, branchName, bp.RequiredStatusChecks.Unmanaged(), currentBP.RequiredStatusChecks, req)
+	}
+
 	if equalBranchProtections(currentBP, req) {
 		logrus.Debugf("%s/%s=%s: current branch protection matches policy, skipping", orgName, repo, branchName)
 		return nil
@@ -516,6 +588,72 @@ func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch
 	return nil
 }
 
+// handleUnmanagedContexts records the contexts that are currently required on the branch but
+// would be removed by the request, and adds them back to the request unless they are stripped.
+func (p *protector) handleUnmanagedContexts(orgName, repo, branchName string, policy config.UnmanagedContextsPolicy, current *github.RequiredStatusChecks, req *github.BranchProtectionRequest) {
+	if current == nil {
+		return
+	}
+	requested := sets.New[string]()
+	if req.RequiredStatusChecks != nil {
+		requested.Insert(req.RequiredStatusChecks.Contexts...)
+	}
+	unmanaged := sets.New[string](current.Contexts...).Difference(requested)
+	if unmanaged.Len() == 0 {
+		return
+	}
+	p.unmanagedContexts = append(p.unmanagedContexts, unmanagedContexts{
+		Org:      orgName,
+		Repo:     repo,
+		Branch:   branchName,
+		Contexts: sets.List(unmanaged),
+		Policy:   policy,
+	})
+
+	log := logrus.WithFields(logrus.Fields{
+		"org":      orgName,
+		"repo":     repo,
+		"branch":   branchName,
+		"contexts": sets.List(unmanaged),
+	})
+	switch policy {
+	case config.UnmanagedContextsStrip:
+		log.Info("Removing required contexts that are not managed by Prow.")
+		return
+	case config.UnmanagedContextsWarn:
+		log.Warn("Preserving required contexts that are not managed by Prow. Add them to the branch protection config or set unmanaged_contexts to preserve.")
+	default:
+		log.Debug("Preserving required contexts that are not managed by Prow.")
+	}
+	if req.RequiredStatusChecks == nil {
+		req.RequiredStatusChecks = &github.RequiredStatusChecks{Strict: current.Strict}
+	}
+	req.RequiredStatusChecks.Contexts = sets.List(requested.Union(unmanaged))
+}
+
+// reportUnmanagedContexts logs every context that is required on GitHub but not managed by Prow,
+// together with the branches it is required on.
+func (p *protector) reportUnmanagedContexts() {
+	branches := map[string][]string{}
+	for _, u := range p.unmanagedContexts {
+		for _, context := range u.Contexts {
+			branches[context] = append(branches[context], fmt.Sprintf("%s/%s=%s (%s)", u.Org, u.Repo, u.Branch, u.Policy))
+		}
+	}
+	contexts := make([]string, 0, len(branches))
+	for context := range branches {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+	for _, context := range contexts {
+		logrus.WithFields(logrus.Fields{
+			"context":  context,
+			"branches": branches[context],
+		}).Info("Found required context that is not managed by Prow.")
+	}
+	logrus.Infof("Found %d required contexts not managed by Prow on %d branches.", len(contexts), len(p.unmanagedContexts))
+}
+
 func equalBranchProtections(state *github.BranchProtection, request *github.BranchProtectionRequest) bool {
 	switch {
 	case state == nil && request == nil:
@@ -89,6 +89,10 @@ type fakeClient struct {
 	appInstallations  []github.AppInstallation
 	collaborators     []github.User
 	teams             []github.Team
+	rulesets          map[string][]github.Ruleset
+	createdRulesets   map[string][]github.Ruleset
+	updatedRulesets   map[int]github.Ruleset
+	deletedRulesets   []int
 }
 
 func (c fakeClient) GetRepo(org string, repo string) (github.FullRepo, error) {
@@ -177,6 +181,46 @@ func (c *fakeClient) ListRepoTeams(org, repo string) ([]github.Team, error) {
 	return c.teams, nil
 }
 
+func (c *fakeClient) ListRepoRulesets(org, repo string) ([]github.Ruleset, error) {
+	var rulesets []github.Ruleset
+	for _, r := range c.rulesets[org+"/"+repo] {
+		// Rules are not listed.
+		r.Rules = nil
+		rulesets = append(rulesets, r)
+	}
+	return rulesets, nil
+}
+
+func (c *fakeClient) GetRepoRuleset(org, repo string, id int) (*github.Ruleset, error) {
+	for _, r := range c.rulesets[org+"/"+repo] {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown ruleset %d", id)
+}
+
+func (c *fakeClient) CreateRepoRuleset(org, repo string, ruleset github.Ruleset) error {
+	if c.createdRulesets == nil {
+		c.createdRulesets = map[string][]github.Ruleset{}
+	}
+	c.createdRulesets[org+"/"+repo] = append(c.createdRulesets[org+"/"+repo], ruleset)
+	return nil
+}
+
+func (c *fakeClient) UpdateRepoRuleset(org, repo string, id int, ruleset github.Ruleset) error {
+	if c.updatedRulesets == nil {
+		c.updatedRulesets = map[int]github.Ruleset{}
+	}
+	c.updatedRulesets[id] = ruleset
+	return nil
+}
+
+func (c *fakeClient) DeleteRepoRuleset(org, repo string, id int) error {
+	c.deletedRulesets = append(c.deletedRulesets, id)
+	return nil
+}
+
 func TestConfigureBranches(t *testing.T) {
 	yes := true
 
@@ -302,6 +346,7 @@ func TestProtect(t *testing.T) {
 		skipVerifyRestrictions bool
 		enableAppsRestrictions bool
 		errors                 int
+		expectedUnmanaged      []unmanagedContexts
 
 		enabled func(org, repo string) bool
 	}{
@@ -1093,6 +1138,9 @@ branch-protection:
 					},
 				},
 			},
+			expectedUnmanaged: []unmanagedContexts{
+				{Org: "kubernetes", Repo: "test-infra", Branch: "master", Contexts: []string{"config-presubmit"}, Policy: config.UnmanagedContextsStrip},
+			},
 		},
 		{
 			name:     "excluded branches are not protected",
@@ -1514,6 +1562,135 @@ branch-protection:
 `,
 			errors: 1,
 		},
+		{
+			name:     "unmanaged contexts are stripped by default",
+			branches: []string{"org/repo=master"},
+			config: `
+branch-protection:
+  protect: true
+  required_status_checks:
+    contexts:
+    - prow-context
+  orgs:
+    org:
+`,
+			branchProtections: map[string]github.BranchProtection{
+				"org/repo=master": {
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Contexts: []string{"other-ci", "prow-context"},
+					},
+				},
+			},
+			expected: []requirements{
+				{
+					Org:    "org",
+					Repo:   "repo",
+					Branch: "master",
+					Request: &github.BranchProtectionRequest{
+						EnforceAdmins: &no,
+						RequiredStatusChecks: &github.RequiredStatusChecks{
+							Contexts: []string{"prow-context"},
+						},
+					},
+				},
+			},
+			expectedUnmanaged: []unmanagedContexts{
+				{Org: "org", Repo: "repo", Branch: "master", Contexts: []string{"other-ci"}, Policy: config.UnmanagedContextsStrip},
+			},
+		},
+		{
+			name:     "unmanaged contexts are preserved per repo",
+			branches: []string{"org/repo=master", "org/other=master"},
+			config: `
+branch-protection:
+  protect: true
+  required_status_checks:
+    contexts:
+    - prow-context
+  orgs:
+    org:
+      repos:
+        repo:
+          required_status_checks:
+            unmanaged_contexts: preserve
+`,
+			branchProtections: map[string]github.BranchProtection{
+				"org/repo=master": {
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Contexts: []string{"other-ci"},
+					},
+				},
+				"org/other=master": {
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Contexts: []string{"other-ci"},
+					},
+				},
+			},
+			expected: []requirements{
+				{
+					Org:    "org",
+					Repo:   "repo",
+					Branch: "master",
+					Request: &github.BranchProtectionRequest{
+						EnforceAdmins: &no,
+						RequiredStatusChecks: &github.RequiredStatusChecks{
+							Contexts: []string{"other-ci", "prow-context"},
+						},
+					},
+				},
+				{
+					Org:    "org",
+					Repo:   "other",
+					Branch: "master",
+					Request: &github.BranchProtectionRequest{
+						EnforceAdmins: &no,
+						RequiredStatusChecks: &github.RequiredStatusChecks{
+							Contexts: []string{"prow-context"},
+						},
+					},
+				},
+			},
+			expectedUnmanaged: []unmanagedContexts{
+				{Org: "org", Repo: "other", Branch: "master", Contexts: []string{"other-ci"}, Policy: config.UnmanagedContextsStrip},
+				{Org: "org", Repo: "repo", Branch: "master", Contexts: []string{"other-ci"}, Policy: config.UnmanagedContextsPreserve},
+			},
+		},
+		{
+			name:     "unmanaged contexts are kept when prow does not require any contexts",
+			branches: []string{"org/repo=master"},
+			config: `
+branch-protection:
+  protect: true
+  required_status_checks:
+    unmanaged_contexts: warn
+  orgs:
+    org:
+`,
+			branchProtections: map[string]github.BranchProtection{
+				"org/repo=master": {
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Strict:   true,
+						Contexts: []string{"other-ci"},
+					},
+				},
+			},
+			expectedUnmanaged: []unmanagedContexts{
+				{Org: "org", Repo: "repo", Branch: "master", Contexts: []string{"other-ci"}, Policy: config.UnmanagedContextsWarn},
+			},
+			expected: []requirements{
+				{
+					Org:    "org",
+					Repo:   "repo",
+					Branch: "master",
+					Request: &github.BranchProtectionRequest{
+						EnforceAdmins: &no,
+						RequiredStatusChecks: &github.RequiredStatusChecks{
+							Contexts: []string{"other-ci"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1602,6 +1779,12 @@ branch-protection:
 					}
 				}
 			}
+			sort.Slice(p.unmanagedContexts, func(i, j int) bool {
+				return p.unmanagedContexts[i].Repo < p.unmanagedContexts[j].Repo
+			})
+			if diff := cmp.Diff(tc.expectedUnmanaged, p.unmanagedContexts); diff != "" {
+				t.Errorf("unexpected unmanaged contexts (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -0,0 +1,393 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	// branchRulesetPrefix prefixes the name of the ruleset protecting a branch.
+	branchRulesetPrefix = "prow branch "
+	// pushRulesetName is the name of the push ruleset of a repo.
+	pushRulesetName = "prow push rules"
+
+	// githubActionsAppID is the ID of the GitHub Actions app, which reports
+	// the workflow checks.
+	githubActionsAppID = 15368
+)
+
+// rulesetUpdate creates, updates or deletes a ruleset.
+type rulesetUpdate struct {
+	// ID of the current ruleset, 0 to create it.
+	ID int
+	// Ruleset is the desired ruleset, nil to delete the current one.
+	Ruleset *github.Ruleset
+}
+
+// repoRulesets tracks the rulesets managed by branchprotector in a repo.
+type repoRulesets struct {
+	org, repo string
+	// current rulesets by name, without their rules.
+	current map[string]github.Ruleset
+	// branches that exist in the repo.
+	branches sets.Set[string]
+	// managed are the names of the rulesets of the branches that were
+	// configured, desired those of the rulesets that are still wanted.
+	managed, desired sets.Set[string]
+}
+
+func branchRulesetName(branch string) string {
+	return branchRulesetPrefix + branch
+}
+
+// usesRulesets returns true if the repo or any of its branches configure
+// rulesets, which are then reconciled.
+func usesRulesets(repo config.Repo) bool {
+	if repo.Policy.Ruleset != nil {
+		return true
+	}
+	for _, branch := range repo.Branches {
+		if branch.Policy.Ruleset != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// listRulesets returns the rulesets of the repo that are managed by
+// branchprotector.
+func (p *protector) listRulesets(org, repo string) (*repoRulesets, error) {
+	rulesets, err := p.client.ListRepoRulesets(org, repo)
+	if err != nil {
+		return nil, err
+	}
+	rs := &repoRulesets{
+		org:      org,
+		repo:     repo,
+		current:  map[string]github.Ruleset{},
+		branches: sets.New[string](),
+		managed:  sets.New[string](),
+		desired:  sets.New[string](),
+	}
+	for _, r := range rulesets {
+		if r.SourceType != "" && r.SourceType != "Repository" {
+			continue
+		}
+		if r.Name == pushRulesetName || strings.HasPrefix(r.Name, branchRulesetPrefix) {
+			rs.current[r.Name] = r
+		}
+	}
+	return rs, nil
+}
+
+// updateBranchRuleset protects the branch with a ruleset rendered from the
+// policy, and removes its legacy branch protection.
+func (p *protector) updateBranchRuleset(rulesets *repoRulesets, branch string, policy config.Policy) error {
+	name := branchRulesetName(branch)
+	rulesets.managed.Insert(name)
+
+	if *policy.Protect {
+		desired, err := makeBranchRuleset(branch, policy)
+		if err != nil {
+			return err
+		}
+		rulesets.desired.Insert(name)
+		if err := p.reconcileRuleset(rulesets, branch, desired); err != nil {
+			return err
+		}
+	}
+
+	currentBP, err := p.client.GetBranchProtection(rulesets.org, rulesets.repo, url.QueryEscape(branch))
+	if err != nil {
+		return fmt.Errorf("get current branch protection: %w", err)
+	}
+	if currentBP != nil {
+		logrus.Infof("%s/%s=%s: removing legacy branch protection replaced by a ruleset", rulesets.org, rulesets.repo, branch)
+		p.updates <- requirements{
+			Org:    rulesets.org,
+			Repo:   rulesets.repo,
+			Branch: branch,
+		}
+	}
+	return nil
+}
+
+// updatePushRuleset reconciles the push ruleset of the repo.
+func (p *protector) updatePushRuleset(rulesets *repoRulesets, policy config.Policy) error {
+	if !policy.UsesRuleset() || policy.Ruleset.Push == nil {
+		if policy.Managed() {
+			rulesets.managed.Insert(pushRulesetName)
+		}
+		return nil
+	}
+	rulesets.managed.Insert(pushRulesetName)
+	rulesets.desired.Insert(pushRulesetName)
+	return p.reconcileRuleset(rulesets, "", makePushRuleset(*policy.Ruleset))
+}
+
+// reconcileRuleset updates the ruleset if it differs from the desired one,
+// logging the difference.
+func (p *protector) reconcileRuleset(rulesets *repoRulesets, branch string, desired *github.Ruleset) error {
+	var current *github.Ruleset
+	var id int
+	if r, ok := rulesets.current[desired.Name]; ok {
+		var err error
+		if current, err = p.client.GetRepoRuleset(rulesets.org, rulesets.repo, r.ID); err != nil {
+			return fmt.Errorf("get ruleset %q: %w", desired.Name, err)
+		}
+		id = r.ID
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"org":     rulesets.org,
+		"repo":    rulesets.repo,
+		"ruleset": desired.Name,
+	})
+	if equalRulesets(current, desired) {
+		log.Debug("Current ruleset matches policy, skipping.")
+		return nil
+	}
+	log.Infof("Ruleset differs from policy (-current +desired):\n%s", cmp.Diff(normalizeRuleset(current), normalizeRuleset(desired)))
+	p.updates <- requirements{
+		Org:     rulesets.org,
+		Repo:    rulesets.repo,
+		Branch:  branch,
+		Ruleset: &rulesetUpdate{ID: id, Ruleset: desired},
+	}
+	return nil
+}
+
+// removeStaleRulesets deletes the rulesets of configured branches that are
+// no longer wanted, and those of branches that were deleted.
+func (p *protector) removeStaleRulesets(rulesets *repoRulesets) {
+	names := sets.List(sets.KeySet(rulesets.current))
+	for _, name := range names {
+		if rulesets.desired.Has(name) {
+			continue
+		}
+		branch := strings.TrimPrefix(name, branchRulesetPrefix)
+		if !rulesets.managed.Has(name) && (name == pushRulesetName || rulesets.branches.Has(branch)) {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"org":     rulesets.org,
+			"repo":    rulesets.repo,
+			"ruleset": name,
+		}).Info("Removing ruleset that is no longer configured.")
+		p.updates <- requirements{
+			Org:     rulesets.org,
+			Repo:    rulesets.repo,
+			Ruleset: &rulesetUpdate{ID: rulesets.current[name].ID},
+		}
+	}
+}
+
+// makeBranchRuleset renders a branch protection policy into the ruleset
+// protecting the branch.
+func makeBranchRuleset(branch string, policy config.Policy) (*github.Ruleset, error) {
+	switch {
+	case policy.Restrictions != nil:
+		return nil, errors.New("restrictions can't be enforced by rulesets, use bypass_actors and restrict_updates instead")
+	case policy.RequiredPullRequestReviews != nil && policy.RequiredPullRequestReviews.DismissalRestrictions != nil:
+		return nil, errors.New("dismissal_restrictions can't be enforced by rulesets")
+	case policy.RequiredPullRequestReviews != nil && policy.RequiredPullRequestReviews.BypassRestrictions != nil:
+		return nil, errors.New("bypass_pull_request_allowances can't be enforced by rulesets, use bypass_actors instead")
+	}
+
+	ruleset := newRuleset(branchRulesetName(branch), "branch", *policy.Ruleset)
+	ruleset.Conditions = &github.RulesetConditions{
+		RefName: &github.RulesetRefNameCondition{
+			Include: []string{"refs/heads/" + branch},
+			Exclude: []string{},
+		},
+	}
+	if !makeBool(policy.AllowDeletions) {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{Type: "deletion"})
+	}
+	if !makeBool(policy.AllowForcePushes) {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{Type: "non_fast_forward"})
+	}
+	if makeBool(policy.RequiredLinearHistory) {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{Type: "required_linear_history"})
+	}
+	if makeBool(policy.Ruleset.RestrictUpdates) {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{Type: "update"})
+	}
+	if reviews := makeReviews(policy.RequiredPullRequestReviews); reviews != nil {
+		no := false
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{
+			Type: "pull_request",
+			Parameters: &github.RulesetRuleParameters{
+				RequiredApprovingReviewCount:   &reviews.RequiredApprovingReviewCount,
+				DismissStaleReviewsOnPush:      &reviews.DismissStaleReviews,
+				RequireCodeOwnerReview:         &reviews.RequireCodeOwnerReviews,
+				RequireLastPushApproval:        &no,
+				RequiredReviewThreadResolution: &no,
+			},
+		})
+	}
+	if checks := makeRulesetChecks(policy.RequiredStatusChecks, policy.Ruleset.WorkflowChecks); checks != nil {
+		ruleset.Rules = append(ruleset.Rules, *checks)
+	}
+	return ruleset, nil
+}
+
+// makePushRuleset renders the push rules into the push ruleset of a repo.
+func makePushRuleset(policy config.RulesetPolicy) *github.Ruleset {
+	ruleset := newRuleset(pushRulesetName, "push", policy)
+	push := policy.Push
+	if len(push.RestrictedFilePaths) > 0 {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{
+			Type:       "file_path_restriction",
+			Parameters: &github.RulesetRuleParameters{RestrictedFilePaths: sets.List(sets.New[string](push.RestrictedFilePaths...))},
+		})
+	}
+	if len(push.RestrictedFileExtensions) > 0 {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{
+			Type:       "file_extension_restriction",
+			Parameters: &github.RulesetRuleParameters{RestrictedFileExtensions: sets.List(sets.New[string](push.RestrictedFileExtensions...))},
+		})
+	}
+	if push.MaxFilePathLength != nil {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{
+			Type:       "max_file_path_length",
+			Parameters: &github.RulesetRuleParameters{MaxFilePathLength: push.MaxFilePathLength},
+		})
+	}
+	if push.MaxFileSize != nil {
+		ruleset.Rules = append(ruleset.Rules, github.RulesetRule{
+			Type:       "max_file_size",
+			Parameters: &github.RulesetRuleParameters{MaxFileSize: push.MaxFileSize},
+		})
+	}
+	return ruleset
+}
+
+// newRuleset returns a ruleset without rules, enforced for everyone but the
+// bypass actors of the policy.
+func newRuleset(name, target string, policy config.RulesetPolicy) *github.Ruleset {
+	enforcement := policy.Enforcement
+	if enforcement == "" {
+		enforcement = config.RulesetEnforcementActive
+	}
+	ruleset := &github.Ruleset{
+		Name:         name,
+		Target:       target,
+		Enforcement:  string(enforcement),
+		BypassActors: []github.RulesetBypassActor{},
+		Rules:        []github.RulesetRule{},
+	}
+	for _, actor := range policy.BypassActors {
+		a := github.RulesetBypassActor{ActorType: actor.ActorType, BypassMode: actor.BypassMode}
+		switch actor.ActorType {
+		case "OrganizationAdmin":
+			// GitHub identifies org admins as actor 1.
+			id := 1
+			a.ActorID = &id
+		case "DeployKey":
+		default:
+			id := actor.ActorID
+			a.ActorID = &id
+		}
+		if a.BypassMode == "" {
+			a.BypassMode = "always"
+		}
+		ruleset.BypassActors = append(ruleset.BypassActors, a)
+	}
+	return ruleset
+}
+
+// makeRulesetChecks renders the required contexts and workflow checks into a
+// required_status_checks rule.
+//
+// Returns nil when neither are set.
+func makeRulesetChecks(cp *config.ContextPolicy, workflowChecks []string) *github.RulesetRule {
+	if cp == nil && len(workflowChecks) == 0 {
+		return nil
+	}
+	checks := []github.RulesetStatusCheck{}
+	var strict bool
+	if cp != nil {
+		for _, context := range sets.List(sets.New[string](cp.Contexts...)) {
+			checks = append(checks, github.RulesetStatusCheck{Context: context})
+		}
+		strict = makeBool(cp.Strict)
+	}
+	for _, context := range sets.List(sets.New[string](workflowChecks...)) {
+		id := githubActionsAppID
+		checks = append(checks, github.RulesetStatusCheck{Context: context, IntegrationID: &id})
+	}
+	return &github.RulesetRule{
+		Type: "required_status_checks",
+		Parameters: &github.RulesetRuleParameters{
+			RequiredStatusChecks:             checks,
+			StrictRequiredStatusChecksPolicy: &strict,
+		},
+	}
+}
+
+// normalizeRuleset returns a copy of the ruleset without the fields that are
+// not managed, with its lists sorted.
+func normalizeRuleset(r *github.Ruleset) *github.Ruleset {
+	if r == nil {
+		return nil
+	}
+	n := *r
+	n.ID = 0
+	n.SourceType = ""
+	n.BypassActors = append([]github.RulesetBypassActor{}, r.BypassActors...)
+	sort.Slice(n.BypassActors, func(i, j int) bool {
+		a, b := n.BypassActors[i], n.BypassActors[j]
+		if a.ActorType != b.ActorType {
+			return a.ActorType < b.ActorType
+		}
+		return a.ActorID != nil && (b.ActorID == nil || *a.ActorID < *b.ActorID)
+	})
+	n.Rules = nil
+	for _, rule := range r.Rules {
+		if rule.Parameters != nil {
+			params := *rule.Parameters
+			params.RequiredStatusChecks = append([]github.RulesetStatusCheck{}, params.RequiredStatusChecks...)
+			sort.Slice(params.RequiredStatusChecks, func(i, j int) bool {
+				return params.RequiredStatusChecks[i].Context < params.RequiredStatusChecks[j].Context
+			})
+			params.RestrictedFilePaths = sets.List(sets.New[string](params.RestrictedFilePaths...))
+			params.RestrictedFileExtensions = sets.List(sets.New[string](params.RestrictedFileExtensions...))
+			rule.Parameters = &params
+		}
+		n.Rules = append(n.Rules, rule)
+	}
+	sort.Slice(n.Rules, func(i, j int) bool { return n.Rules[i].Type < n.Rules[j].Type })
+	return &n
+}
+
+func equalRulesets(state, desired *github.Ruleset) bool {
+	return apiequality.Semantic.DeepEqual(normalizeRuleset(state), normalizeRuleset(desired))
+}
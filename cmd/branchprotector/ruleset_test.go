@@ -0,0 +1,377 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestProtectRulesets(t *testing.T) {
+	masterRuleset := func() *github.Ruleset {
+		return &github.Ruleset{
+			Name:        "prow branch master",
+			Target:      "branch",
+			Enforcement: "active",
+			BypassActors: []github.RulesetBypassActor{
+				{ActorID: intPtr(42), ActorType: "Team", BypassMode: "pull_request"},
+				{ActorID: intPtr(1), ActorType: "OrganizationAdmin", BypassMode: "always"},
+			},
+			Conditions: &github.RulesetConditions{
+				RefName: &github.RulesetRefNameCondition{Include: []string{"refs/heads/master"}, Exclude: []string{}},
+			},
+			Rules: []github.RulesetRule{
+				{Type: "deletion"},
+				{Type: "non_fast_forward"},
+				{
+					Type: "pull_request",
+					Parameters: &github.RulesetRuleParameters{
+						RequiredApprovingReviewCount:   intPtr(1),
+						DismissStaleReviewsOnPush:      boolPtr(false),
+						RequireCodeOwnerReview:         boolPtr(true),
+						RequireLastPushApproval:        boolPtr(false),
+						RequiredReviewThreadResolution: boolPtr(false),
+					},
+				},
+				{
+					Type: "required_status_checks",
+					Parameters: &github.RulesetRuleParameters{
+						RequiredStatusChecks: []github.RulesetStatusCheck{
+							{Context: "pull-test"},
+							{Context: "build", IntegrationID: intPtr(githubActionsAppID)},
+						},
+						StrictRequiredStatusChecksPolicy: boolPtr(false),
+					},
+				},
+			},
+		}
+	}
+	rulesetConfig := `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          required_status_checks:
+            contexts:
+            - pull-test
+          required_pull_request_reviews:
+            required_approving_review_count: 1
+            require_code_owner_reviews: true
+          ruleset:
+            enabled: true
+            bypass_actors:
+            - actor_type: Team
+              actor_id: 42
+              bypass_mode: pull_request
+            - actor_type: OrganizationAdmin
+            workflow_checks:
+            - build
+`
+
+	testCases := []struct {
+		name              string
+		config            string
+		branches          []string
+		rulesets          []github.Ruleset
+		branchProtections map[string]github.BranchProtection
+		expected          []requirements
+		expectedErrors    int
+	}{
+		{
+			name:     "ruleset is created and the legacy protection removed",
+			config:   rulesetConfig,
+			branches: []string{"master"},
+			branchProtections: map[string]github.BranchProtection{
+				"org/repo=master": {},
+			},
+			expected: []requirements{
+				{Org: "org", Repo: "repo", Branch: "master", Ruleset: &rulesetUpdate{Ruleset: masterRuleset()}},
+				{Org: "org", Repo: "repo", Branch: "master"},
+			},
+		},
+		{
+			name:     "ruleset matching the policy is not updated",
+			config:   rulesetConfig,
+			branches: []string{"master"},
+			rulesets: func() []github.Ruleset {
+				r := masterRuleset()
+				r.ID = 7
+				r.SourceType = "Repository"
+				// The order of the rules and bypass actors doesn't matter.
+				r.Rules[0], r.Rules[3] = r.Rules[3], r.Rules[0]
+				r.BypassActors[0], r.BypassActors[1] = r.BypassActors[1], r.BypassActors[0]
+				return []github.Ruleset{*r}
+			}(),
+		},
+		{
+			name:     "ruleset differing from the policy is updated",
+			config:   rulesetConfig,
+			branches: []string{"master"},
+			rulesets: func() []github.Ruleset {
+				r := masterRuleset()
+				r.ID = 7
+				r.Enforcement = "evaluate"
+				return []github.Ruleset{*r}
+			}(),
+			expected: []requirements{
+				{Org: "org", Repo: "repo", Branch: "master", Ruleset: &rulesetUpdate{ID: 7, Ruleset: masterRuleset()}},
+			},
+		},
+		{
+			name: "rulesets of unprotected and deleted branches are removed",
+			config: `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          ruleset:
+            enabled: true
+          branches:
+            master:
+              protect: false
+`,
+			branches: []string{"master"},
+			rulesets: []github.Ruleset{
+				{ID: 7, Name: "prow branch master"},
+				{ID: 8, Name: "prow branch release-1.0"},
+				{ID: 9, Name: "not managed by prow"},
+			},
+			expected: []requirements{
+				{Org: "org", Repo: "repo", Ruleset: &rulesetUpdate{ID: 7}},
+				{Org: "org", Repo: "repo", Ruleset: &rulesetUpdate{ID: 8}},
+			},
+		},
+		{
+			name: "disabled ruleset falls back to the legacy protection",
+			config: `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          ruleset:
+            enabled: false
+`,
+			branches: []string{"master"},
+			rulesets: []github.Ruleset{{ID: 7, Name: "prow branch master"}},
+			expected: []requirements{
+				{Org: "org", Repo: "repo", Branch: "master", Request: &github.BranchProtectionRequest{EnforceAdmins: boolPtr(false)}},
+				{Org: "org", Repo: "repo", Ruleset: &rulesetUpdate{ID: 7}},
+			},
+		},
+		{
+			name: "rulesets of unmanaged branches are kept",
+			config: `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          ruleset:
+            enabled: true
+          branches:
+            master:
+              unmanaged: true
+`,
+			branches: []string{"master"},
+			rulesets: []github.Ruleset{{ID: 7, Name: "prow branch master"}},
+		},
+		{
+			name: "push ruleset is created",
+			config: `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          ruleset:
+            enabled: true
+            push:
+              restricted_file_extensions:
+              - "*.exe"
+              max_file_size: 10
+`,
+			branches: []string{"master"},
+			expected: []requirements{
+				{Org: "org", Repo: "repo", Ruleset: &rulesetUpdate{Ruleset: &github.Ruleset{
+					Name:         "prow push rules",
+					Target:       "push",
+					Enforcement:  "active",
+					BypassActors: []github.RulesetBypassActor{},
+					Rules: []github.RulesetRule{
+						{Type: "file_extension_restriction", Parameters: &github.RulesetRuleParameters{RestrictedFileExtensions: []string{"*.exe"}}},
+						{Type: "max_file_size", Parameters: &github.RulesetRuleParameters{MaxFileSize: intPtr(10)}},
+					},
+				}}},
+			},
+		},
+		{
+			name: "restrictions can't be enforced by rulesets",
+			config: `
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          protect: true
+          restrictions:
+            users:
+            - bob
+          ruleset:
+            enabled: true
+`,
+			branches:       []string{"master"},
+			rulesets:       []github.Ruleset{{ID: 7, Name: "prow branch master"}},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg config.Config
+			if err := yaml.Unmarshal([]byte(tc.config), &cfg); err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+			var branches []github.Branch
+			for _, b := range tc.branches {
+				branches = append(branches, github.Branch{Name: b})
+			}
+			fc := fakeClient{
+				repos:             map[string][]github.Repo{"org": {{Name: "repo", FullName: "org/repo"}}},
+				branches:          map[string][]github.Branch{"org/repo": branches},
+				branchProtections: tc.branchProtections,
+				rulesets:          map[string][]github.Ruleset{"org/repo": tc.rulesets},
+			}
+			p := protector{
+				client:         &fc,
+				cfg:            &cfg,
+				errors:         Errors{},
+				updates:        make(chan requirements),
+				done:           make(chan []error),
+				completedRepos: make(map[string]bool),
+				enabled:        func(org, repo string) bool { return true },
+			}
+			go func() {
+				p.protect()
+				close(p.updates)
+			}()
+
+			var actual []requirements
+			for r := range p.updates {
+				actual = append(actual, r)
+			}
+			if n := len(p.errors.errs); n != tc.expectedErrors {
+				t.Errorf("expected %d errors, got %d: %v", tc.expectedErrors, n, p.errors.errs)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(rulesetUpdate{})); diff != "" {
+				t.Errorf("unexpected updates (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigureRulesets(t *testing.T) {
+	ruleset := github.Ruleset{Name: "prow branch master"}
+	fc := fakeClient{}
+	p := protector{
+		client:  &fc,
+		updates: make(chan requirements),
+		done:    make(chan []error),
+	}
+	go p.configureBranches()
+	p.updates <- requirements{Org: "org", Repo: "repo", Branch: "master", Ruleset: &rulesetUpdate{Ruleset: &ruleset}}
+	p.updates <- requirements{Org: "org", Repo: "repo", Branch: "master", Ruleset: &rulesetUpdate{ID: 7, Ruleset: &ruleset}}
+	p.updates <- requirements{Org: "org", Repo: "repo", Ruleset: &rulesetUpdate{ID: 8}}
+	close(p.updates)
+	if errs := <-p.done; len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if diff := cmp.Diff(map[string][]github.Ruleset{"org/repo": {ruleset}}, fc.createdRulesets); diff != "" {
+		t.Errorf("unexpected created rulesets (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[int]github.Ruleset{7: ruleset}, fc.updatedRulesets); diff != "" {
+		t.Errorf("unexpected updated rulesets (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{8}, fc.deletedRulesets); diff != "" {
+		t.Errorf("unexpected deleted rulesets (-want +got):\n%s", diff)
+	}
+	if len(fc.updated) != 0 || len(fc.deleted) != 0 {
+		t.Errorf("expected the legacy branch protection not to change, got updated %v and deleted %v", fc.updated, fc.deleted)
+	}
+}
+
+func TestEqualRulesets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		state    *github.Ruleset
+		desired  *github.Ruleset
+		expected bool
+	}{
+		{
+			name:     "both nil",
+			expected: true,
+		},
+		{
+			name:    "missing ruleset",
+			desired: &github.Ruleset{Name: "prow branch master"},
+		},
+		{
+			name: "status checks in a different order",
+			state: &github.Ruleset{ID: 7, SourceType: "Repository", Rules: []github.RulesetRule{{
+				Type:       "required_status_checks",
+				Parameters: &github.RulesetRuleParameters{RequiredStatusChecks: []github.RulesetStatusCheck{{Context: "b"}, {Context: "a"}}},
+			}}},
+			desired: &github.Ruleset{Rules: []github.RulesetRule{{
+				Type:       "required_status_checks",
+				Parameters: &github.RulesetRuleParameters{RequiredStatusChecks: []github.RulesetStatusCheck{{Context: "a"}, {Context: "b"}}},
+			}}},
+			expected: true,
+		},
+		{
+			name:    "different rules",
+			state:   &github.Ruleset{Rules: []github.RulesetRule{{Type: "deletion"}}},
+			desired: &github.Ruleset{Rules: []github.RulesetRule{{Type: "deletion"}, {Type: "non_fast_forward"}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := equalRulesets(tc.state, tc.desired); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
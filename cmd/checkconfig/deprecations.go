@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// deprecation describes a deprecated configuration field and, if the
+// replacement is equivalent, how to migrate to it mechanically.
+type deprecation struct {
+	// path is the path of the field. "[]" matches any item of a list, "*"
+	// matches any key of a map and a leading "**" matches any prefix.
+	path []string
+	// since is the version of Prow that deprecated the field, if it's
+	// known. Fields without it are reported for every version.
+	since string
+	// message explains what to use instead.
+	message string
+	// applies restricts the deprecation to some values of the field.
+	applies func(value *yaml3.Node) bool
+	// migrate replaces the field in its parent mapping. It returns false
+	// if the field can't be migrated, for instance because the replacement
+	// is set already.
+	migrate func(parent *yaml3.Node, index int) bool
+}
+
+var deprecations = []deprecation{
+	{
+		path:    []string{"deck", "spyglass", "viewers"},
+		message: "configure the lenses with deck.spyglass.lenses instead",
+	},
+	{
+		path:    []string{"tide", "pr_status_base_url"},
+		since:   "v20200103",
+		message: "use tide.pr_status_base_urls with the '*' key instead",
+		migrate: migrateToDefaultKey("pr_status_base_urls"),
+	},
+	{
+		path:    []string{"plank", "report_template"},
+		since:   "v20200325",
+		message: "use plank.report_templates with the '*' key instead",
+		migrate: migrateToDefaultKey("report_templates"),
+	},
+	{
+		path:    []string{"**", "gcs_configuration", "bucket"},
+		message: "buckets without a scheme are assumed to be GCS buckets, add the gs:// prefix",
+		applies: func(value *yaml3.Node) bool {
+			return value.Kind == yaml3.ScalarNode && value.Value != "" && !strings.Contains(value.Value, "://")
+		},
+		migrate: func(parent *yaml3.Node, index int) bool {
+			parent.Content[index+1].Value = "gs://" + parent.Content[index+1].Value
+			return true
+		},
+	},
+	{
+		path:    []string{"periodics", "[]", "interval"},
+		message: "use cron, or minimum_interval to wait for the previous run to complete",
+	},
+}
+
+// prowVersion matches the date part of a Prow version like v20240805-37a08f946.
+var prowVersion = regexp.MustCompile(`^v(\d{8})`)
+
+// deprecatedIn determines whether the field is deprecated in the given
+// version of Prow. Every deprecation applies when the version is unknown.
+func (d deprecation) deprecatedIn(version string) bool {
+	target := prowVersion.FindStringSubmatch(version)
+	since := prowVersion.FindStringSubmatch(d.since)
+	if target == nil || since == nil {
+		return true
+	}
+	return target[1] >= since[1]
+}
+
+func (d deprecation) matches(path []string) bool {
+	pattern := d.path
+	if len(pattern) > 0 && pattern[0] == "**" {
+		pattern = pattern[1:]
+		if len(path) < len(pattern) {
+			return false
+		}
+		path = path[len(path)-len(pattern):]
+	}
+	if len(path) != len(pattern) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// migrateToDefaultKey replaces a field with a map of the given name that
+// holds the value of the field under the '*' key.
+func migrateToDefaultKey(name string) func(parent *yaml3.Node, index int) bool {
+	return func(parent *yaml3.Node, index int) bool {
+		if mappingValue(parent, name) != nil {
+			return false
+		}
+		value := parent.Content[index+1]
+		parent.Content[index].Value = name
+		parent.Content[index+1] = &yaml3.Node{
+			Kind: yaml3.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml3.Node{
+				{Kind: yaml3.ScalarNode, Tag: "!!str", Value: "*", Style: yaml3.SingleQuotedStyle},
+				value,
+			},
+		}
+		return true
+	}
+}
+
+func mappingValue(mapping *yaml3.Node, key string) *yaml3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deprecatedField is a use of a deprecated field in a config file.
+type deprecatedField struct {
+	file     string
+	line     int
+	path     []string
+	rule     deprecation
+	migrated bool
+}
+
+func (f deprecatedField) Error() string {
+	suggestion := f.rule.message
+	if f.migrated {
+		suggestion += " (can be migrated automatically)"
+	}
+	deprecated := "deprecated"
+	if f.rule.since != "" {
+		deprecated += " since " + f.rule.since
+	}
+	return fmt.Sprintf("%s:%d: %s is %s: %s", f.file, f.line, strings.Join(f.path, "."), deprecated, suggestion)
+}
+
+// findDeprecatedFields reports the deprecated fields of the given document
+// and migrates them in place where possible.
+func findDeprecatedFields(file string, doc *yaml3.Node, version string) []deprecatedField {
+	var found []deprecatedField
+	var walk func(node *yaml3.Node, path []string)
+	walk = func(node *yaml3.Node, path []string) {
+		switch node.Kind {
+		case yaml3.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml3.SequenceNode:
+			for _, child := range node.Content {
+				walk(child, append(path, "[]"))
+			}
+		case yaml3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				fieldPath := append(append([]string{}, path...), key.Value)
+				walk(value, fieldPath)
+				for _, rule := range deprecations {
+					if !rule.matches(fieldPath) || !rule.deprecatedIn(version) {
+						continue
+					}
+					if rule.applies != nil && !rule.applies(value) {
+						continue
+					}
+					field := deprecatedField{file: file, line: key.Line, path: fieldPath, rule: rule}
+					if rule.migrate != nil {
+						field.migrated = rule.migrate(node, i)
+					}
+					found = append(found, field)
+				}
+			}
+		}
+	}
+	walk(doc, nil)
+	return found
+}
+
+// validateDeprecatedFields reports deprecated fields in the given config
+// files. If migratedConfigDir is set, the files that have fields that can
+// be migrated mechanically are written to it with the migrations applied,
+// under their original path.
+func validateDeprecatedFields(paths []string, version, migratedConfigDir string) error {
+	var errs []error
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		var doc yaml3.Node
+		if err := yaml3.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		migrated := false
+		for _, field := range findDeprecatedFields(path, &doc, version) {
+			errs = append(errs, field)
+			migrated = migrated || field.migrated
+		}
+		if !migrated || migratedConfigDir == "" {
+			continue
+		}
+		var buf bytes.Buffer
+		encoder := yaml3.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return fmt.Errorf("error marshalling migrated %s: %w", path, err)
+		}
+		out := filepath.Join(migratedConfigDir, path)
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return fmt.Errorf("error creating directory for %s: %w", out, err)
+		}
+		if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("error writing migrated %s: %w", path, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// configFiles returns the config files checkconfig loads: the Prow config,
+// the job configs and the supplemental Prow configs.
+func (o *options) configFiles() ([]string, error) {
+	paths := []string{o.config.ConfigPath}
+	yamlFiles := func(dir string, suffix string) error {
+		return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if strings.HasSuffix(path, suffix) || (suffix == "" && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml")) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+	if o.config.JobConfigPath != "" {
+		if err := yamlFiles(o.config.JobConfigPath, ""); err != nil {
+			return nil, fmt.Errorf("error listing job configs: %w", err)
+		}
+	}
+	for _, dir := range o.config.SupplementalProwConfigDirs.Strings() {
+		if err := yamlFiles(dir, o.config.SupplementalProwConfigsFileNameSuffix); err != nil {
+			return nil, fmt.Errorf("error listing supplemental prow configs: %w", err)
+		}
+	}
+	return paths, nil
+}
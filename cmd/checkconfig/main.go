@@ -82,6 +82,12 @@ type options struct {
 	expensive              bool
 	includeDefaultWarnings bool
 
+	targetProwVersion string
+	migratedConfigDir string
+	printResolvedJob  string
+	printPluginsFor   string
+	exportSchemaDir   string
+
 	github  flagutil.GitHubOptions
 	storage flagutil.StorageClientOptions
 }
@@ -123,6 +129,7 @@ const (
 	validateLabelWarning                           = "validate-label"
 	requiredJobAnnotationsWarning                  = "required-job-annotations"
 	periodicDefaultCloneWarning                    = "periodic-default-clone-config"
+	deprecatedFieldsWarning                        = "deprecated-fields"
 
 	defaultHourlyTokens = 3000
 	defaultAllowedBurst = 100
@@ -160,6 +167,7 @@ var optionalWarnings = []string{
 	// https://github.com/kubernetes/test-infra/pull/21075#issuecomment-862550510
 	unknownFieldsAllWarning,
 	validateGitHubAppInstallationWarning,
+	deprecatedFieldsWarning,
 }
 
 var throttlerDefaults = flagutil.ThrottlerDefaults(defaultHourlyTokens, defaultAllowedBurst)
@@ -174,6 +182,10 @@ func getAllWarnings() []string {
 }
 
 func (o *options) DefaultAndValidate() error {
+	if o.exportSchemaDir != "" {
+		// Exporting the schemas doesn't need any config.
+		return nil
+	}
 	allWarnings := getAllWarnings()
 	for _, validate := range []interface{ Validate(bool) error }{&o.config, &o.pluginsConfig, &o.storage} {
 		if err := validate.Validate(false); err != nil {
@@ -184,6 +196,14 @@ func (o *options) DefaultAndValidate() error {
 	if o.prowYAMLPath != "" && o.prowYAMLRepoName == "" {
 		return errors.New("--prow-yaml-repo-path requires --prow-yaml-repo-name to be set")
 	}
+	if o.printPluginsFor != "" {
+		if o.pluginsConfig.PluginConfigPath == "" {
+			return errors.New("--print-effective-plugins requires --plugin-config to be set")
+		}
+		if org, repo, found := strings.Cut(o.printPluginsFor, "/"); !found || org == "" || repo == "" {
+			return fmt.Errorf("--print-effective-plugins must be an org/repo, got %q", o.printPluginsFor)
+		}
+	}
 	for _, warning := range o.warnings.Strings() {
 		found := false
 		for _, registeredWarning := range allWarnings {
@@ -218,6 +238,11 @@ func (o *options) gatherOptions(flag *flag.FlagSet, args []string) error {
 	flag.BoolVar(&o.expensive, "expensive-checks", false, "If set, additional expensive warnings will be enabled")
 	flag.BoolVar(&o.strict, "strict", false, "If set, consider all warnings as errors.")
 	flag.BoolVar(&o.includeDefaultWarnings, "include-default-warnings", false, "If set force inclusion of default warning set. Normally this is inferred based on a lack of '--warnings' flags.")
+	flag.StringVar(&o.targetProwVersion, "target-prow-version", "", "Version of Prow the config is checked against, like v20240805-37a08f946. Only fields deprecated in this version are reported by the deprecated-fields warning. Omit to report all deprecated fields.")
+	flag.StringVar(&o.printResolvedJob, "print-resolved-job", "", "If set, the jobs with this name are printed with all defaults of the config applied, like job_defaults and default_decoration_configs, instead of checking the config.")
+	flag.StringVar(&o.printPluginsFor, "print-effective-plugins", "", "If set to an org/repo, the plugin config that applies to the repo is printed, with the config of its org and its repo_overrides merged, instead of checking the config.")
+	flag.StringVar(&o.exportSchemaDir, "export-schema", "", "If set, the JSON Schemas of the Prow config, job config, plugin config and .prow.yaml are written to this directory, for editors and CI to validate config files with, instead of checking the config.")
+	flag.StringVar(&o.migratedConfigDir, "migrated-config-dir", "", "If set, config files with deprecated fields that can be migrated mechanically are written to this directory with the migrations applied. Implies --warnings=deprecated-fields.")
 	o.github.AddCustomizedFlags(flag, throttlerDefaults)
 	o.github.AllowAnonymous = true
 	o.config.AddFlags(flag)
@@ -240,6 +265,35 @@ func main() {
 		logrus.Fatalf("Error parsing options - %v", err)
 	}
 
+	if o.exportSchemaDir != "" {
+		if err := exportSchemas(o.exportSchemaDir); err != nil {
+			logrus.WithError(err).Fatal("Failed to export the config schemas")
+		}
+		return
+	}
+
+	if o.printResolvedJob != "" {
+		configAgent, err := o.config.ConfigAgent()
+		if err != nil {
+			logrus.WithError(err).Fatal("Error loading prow config")
+		}
+		if err := printResolvedJobs(os.Stdout, configAgent.Config(), o.printResolvedJob); err != nil {
+			logrus.WithError(err).Fatal("Failed to print the resolved job")
+		}
+		return
+	}
+
+	if o.printPluginsFor != "" {
+		pluginAgent, err := o.pluginsConfig.PluginAgent()
+		if err != nil {
+			logrus.WithError(err).Fatal("Error loading Prow plugin config")
+		}
+		if err := printEffectivePlugins(os.Stdout, pluginAgent.Config(), o.printPluginsFor); err != nil {
+			logrus.WithError(err).Fatal("Failed to print the effective plugin config")
+		}
+		return
+	}
+
 	if err := validate(o); err != nil {
 		switch e := err.(type) {
 		case utilerrors.Aggregate:
@@ -265,6 +319,9 @@ func validate(o options) error {
 	if o.github.AppID != "" && o.github.AppPrivateKeyPath != "" {
 		o.warnings.Add(validateGitHubAppInstallationWarning)
 	}
+	if o.migratedConfigDir != "" {
+		o.warnings.Add(deprecatedFieldsWarning)
+	}
 
 	configAgent, err := o.config.ConfigAgent()
 	if err != nil {
@@ -449,6 +506,16 @@ func validate(o options) error {
 		}
 	}
 
+	if o.warningEnabled(deprecatedFieldsWarning) {
+		paths, err := o.configFiles()
+		if err != nil {
+			return err
+		}
+		if err := validateDeprecatedFields(paths, o.targetProwVersion, o.migratedConfigDir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// validate rerun commands match presubmit job triggering regex
 	for _, presubmits := range cfg.JobConfig.PresubmitsStatic {
 		for _, p := range presubmits {
@@ -1237,6 +1304,13 @@ func validateJobCluster(job config.JobBase, statuses map[string]plank.ClusterSta
 		if status != plank.ClusterStatusReachable {
 			logrus.Warnf("Job configuration for %q specifies cluster %q which cannot be reached from Plank. Status: %q", job.Name, job.Cluster, status)
 		}
+		if job.RerunOverrides != nil {
+			for _, cluster := range job.RerunOverrides.Clusters {
+				if _, ok := statuses[cluster]; !ok {
+					return fmt.Errorf("job configuration for %q allows reruns in unknown cluster %q", job.Name, cluster)
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -1891,6 +1891,25 @@ func TestValidateClusterField(t *testing.T) {
 			clusterStatusFile: fmt.Sprintf(`{"default": %q, "build1": %q, "build2": %q}`, plank.ClusterStatusReachable, plank.ClusterStatusReachable, plank.ClusterStatusError),
 			expectedError:     "org1/repo1: job configuration for \"my-job\" specifies unknown 'cluster' value \"build3\"",
 		},
+		{
+			name: "rerun overrides fail validation with unrecognized cluster",
+			cfg: &config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{BuildClusterStatusFile: "gs://my-bucket/build-cluster-status.json"},
+				},
+				JobConfig: config.JobConfig{
+					PresubmitsStatic: map[string][]config.Presubmit{
+						"org1/repo1": {
+							{
+								JobBase: config.JobBase{
+									Name:           "my-job",
+									Cluster:        "build1",
+									RerunOverrides: &prowapi.RerunOverrides{Clusters: []string{"build2", "build3"}},
+								},
+							}}}}},
+			clusterStatusFile: fmt.Sprintf(`{"default": %q, "build1": %q, "build2": %q}`, plank.ClusterStatusReachable, plank.ClusterStatusReachable, plank.ClusterStatusError),
+			expectedError:     "org1/repo1: job configuration for \"my-job\" allows reruns in unknown cluster \"build3\"",
+		},
 		{
 			name: "cluster validation skipped if status file does not exist yet",
 			cfg: &config.Config{
@@ -2480,3 +2499,122 @@ func TestValidateRequiredJobAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDeprecatedFields(t *testing.T) {
+	prowConfig := `plank:
+  # The default report template.
+  report_template: "Tests done"
+  default_decoration_configs:
+    org/repo:
+      timeout: 1h
+    '*':
+      gcs_configuration:
+        bucket: my-bucket
+tide:
+  pr_status_base_url: https://prow.example.com/pr
+  pr_status_base_urls:
+    '*': https://prow.example.com/pr
+`
+	jobConfig := `periodics:
+- name: job
+  interval: 1h
+  decoration_config:
+    gcs_configuration:
+      bucket: s3://my-bucket
+`
+	testCases := []struct {
+		name             string
+		version          string
+		expectedWarnings []string
+		expectedConfig   string
+	}{
+		{
+			name:    "all deprecations",
+			version: "",
+			expectedWarnings: []string{
+				"config.yaml:3: plank.report_template is deprecated since v20200325: use plank.report_templates with the '*' key instead (can be migrated automatically)",
+				"config.yaml:9: plank.default_decoration_configs.*.gcs_configuration.bucket is deprecated: buckets without a scheme are assumed to be GCS buckets, add the gs:// prefix (can be migrated automatically)",
+				"config.yaml:11: tide.pr_status_base_url is deprecated since v20200103: use tide.pr_status_base_urls with the '*' key instead",
+				"jobs/job.yaml:3: periodics.[].interval is deprecated: use cron, or minimum_interval to wait for the previous run to complete",
+			},
+			expectedConfig: `plank:
+  # The default report template.
+  report_templates:
+    '*': "Tests done"
+  default_decoration_configs:
+    org/repo:
+      timeout: 1h
+    '*':
+      gcs_configuration:
+        bucket: gs://my-bucket
+tide:
+  pr_status_base_url: https://prow.example.com/pr
+  pr_status_base_urls:
+    '*': https://prow.example.com/pr
+`,
+		},
+		{
+			name:    "old prow version",
+			version: "v20200201-abcdef",
+			expectedWarnings: []string{
+				"config.yaml:9: plank.default_decoration_configs.*.gcs_configuration.bucket is deprecated: buckets without a scheme are assumed to be GCS buckets, add the gs:// prefix (can be migrated automatically)",
+				"config.yaml:11: tide.pr_status_base_url is deprecated since v20200103: use tide.pr_status_base_urls with the '*' key instead",
+				"jobs/job.yaml:3: periodics.[].interval is deprecated: use cron, or minimum_interval to wait for the previous run to complete",
+			},
+			expectedConfig: `plank:
+  # The default report template.
+  report_template: "Tests done"
+  default_decoration_configs:
+    org/repo:
+      timeout: 1h
+    '*':
+      gcs_configuration:
+        bucket: gs://my-bucket
+tide:
+  pr_status_base_url: https://prow.example.com/pr
+  pr_status_base_urls:
+    '*': https://prow.example.com/pr
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for path, content := range map[string]string{"config.yaml": prowConfig, "jobs/job.yaml": jobConfig} {
+				if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			o := options{config: configflagutil.ConfigOptions{ConfigPath: filepath.Join(dir, "config.yaml"), JobConfigPath: filepath.Join(dir, "jobs")}}
+			paths, err := o.configFiles()
+			if err != nil {
+				t.Fatalf("failed to list config files: %v", err)
+			}
+
+			migratedDir := t.TempDir()
+			err = validateDeprecatedFields(paths, tc.version, migratedDir)
+			var warnings []string
+			if agg, ok := err.(utilerrors.Aggregate); ok {
+				for _, e := range agg.Errors() {
+					warnings = append(warnings, strings.TrimPrefix(e.Error(), dir+"/"))
+				}
+			}
+			if diff := cmp.Diff(tc.expectedWarnings, warnings); diff != "" {
+				t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+			}
+			migrated, err := os.ReadFile(filepath.Join(migratedDir, o.config.ConfigPath))
+			if err != nil {
+				t.Fatalf("failed to read migrated config: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedConfig, string(migrated)); diff != "" {
+				t.Errorf("unexpected migrated config (-want +got):\n%s", diff)
+			}
+			if _, err := os.Stat(filepath.Join(migratedDir, o.config.JobConfigPath, "job.yaml")); !os.IsNotExist(err) {
+				t.Errorf("expected the job config without migrations not to be written, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// printResolvedJobs writes the jobs with the name as they run, with all
+// defaults of the config applied, as YAML documents.
+func printResolvedJobs(out io.Writer, cfg *config.Config, name string) error {
+	type resolvedJob struct {
+		kind string
+		repo string
+		job  interface{}
+	}
+	var jobs []resolvedJob
+	for repo, presubmits := range cfg.PresubmitsStatic {
+		for _, job := range presubmits {
+			if job.Name == name {
+				jobs = append(jobs, resolvedJob{kind: "presubmit", repo: repo, job: job})
+			}
+		}
+	}
+	for repo, postsubmits := range cfg.PostsubmitsStatic {
+		for _, job := range postsubmits {
+			if job.Name == name {
+				jobs = append(jobs, resolvedJob{kind: "postsubmit", repo: repo, job: job})
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if job.Name == name {
+			jobs = append(jobs, resolvedJob{kind: "periodic", job: job})
+		}
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no job named %q is configured", name)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].kind != jobs[j].kind {
+			return jobs[i].kind < jobs[j].kind
+		}
+		return jobs[i].repo < jobs[j].repo
+	})
+	for i, job := range jobs {
+		raw, err := yaml.Marshal(job.job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s: %w", job.kind, name, err)
+		}
+		if i > 0 {
+			fmt.Fprintln(out, "---")
+		}
+		if job.repo != "" {
+			fmt.Fprintf(out, "# %s of %s\n", job.kind, job.repo)
+		} else {
+			fmt.Fprintf(out, "# %s\n", job.kind)
+		}
+		if _, err := out.Write(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printEffectivePlugins writes the plugin config that applies to the repo as
+// YAML, with comments telling where each section comes from.
+func printEffectivePlugins(out io.Writer, pcfg *plugins.Configuration, orgRepo string) error {
+	org, repo, _ := strings.Cut(orgRepo, "/")
+	effective := pcfg.EffectiveConfigFor(org, repo)
+	raw, err := yaml.Marshal(effective)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the plugin config of %s: %w", orgRepo, err)
+	}
+	fmt.Fprintf(out, "# plugin config of %s\n", orgRepo)
+	for _, section := range sets.List(sets.KeySet(effective.Sources)) {
+		fmt.Fprintf(out, "# %s: from %s\n", section, effective.Sources[section])
+	}
+	_, err = out.Write(raw)
+	return err
+}
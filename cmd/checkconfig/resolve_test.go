@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func TestPrintResolvedJobs(t *testing.T) {
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "unit", Cluster: "default"}}},
+				"org/other": {
+					{JobBase: config.JobBase{Name: "unit", Cluster: "build"}},
+					{JobBase: config.JobBase{Name: "e2e"}},
+				},
+			},
+			Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "unit", Cluster: "default"}}},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		job       string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name: "all jobs with the name are printed",
+			job:  "unit",
+			expected: `# periodic
+cluster: default
+name: unit
+---
+# presubmit of org/other
+always_run: false
+cluster: build
+name: unit
+---
+# presubmit of org/repo
+always_run: false
+cluster: default
+name: unit
+`,
+		},
+		{
+			name:      "unknown job",
+			job:       "lint",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := printResolvedJobs(&out, cfg, tc.job)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrintEffectivePlugins(t *testing.T) {
+	pcfg := &plugins.Configuration{
+		Plugins: plugins.Plugins{
+			"org":      {Plugins: []string{"lgtm"}},
+			"org/repo": {Plugins: []string{"approve"}},
+		},
+		Lgtm: []plugins.Lgtm{
+			{Repos: []string{"org"}, ReviewActsAsLgtm: true},
+			{Repos: []string{"org/repo"}, StickyLgtmTeam: "team"},
+		},
+		RepoOverrides: map[string]plugins.RepoOverride{
+			"org/repo": {Fields: map[string]json.RawMessage{"lgtm": []byte(`{"trusted_team_for_sticky_lgtm":"team"}`)}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		orgRepo  string
+		expected string
+	}{
+		{
+			name:    "repo with overrides",
+			orgRepo: "org/repo",
+			expected: `# plugin config of org/repo
+# lgtm: from org, with repo_overrides.org/repo
+lgtm:
+  repos:
+  - org/repo
+  trusted_team_for_sticky_lgtm: team
+plugins:
+- lgtm
+- approve
+`,
+		},
+		{
+			name:    "repo of the org",
+			orgRepo: "org/other",
+			expected: `# plugin config of org/other
+# lgtm: from org
+lgtm:
+  repos:
+  - org
+  review_acts_as_lgtm: true
+plugins:
+- lgtm
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printEffectivePlugins(&out, pcfg, tc.orgRepo); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config/schema"
+)
+
+// exportSchemas writes the JSON Schemas of all config files into the dir.
+func exportSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, kind := range schema.Kinds {
+		raw, err := schema.Raw(kind)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, kind.FileName())
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		logrus.WithField("path", path).Infof("Wrote the schema of the %s.", kind)
+	}
+	return nil
+}
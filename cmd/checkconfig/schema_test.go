@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/schema"
+)
+
+func TestExportSchemas(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schemas")
+	if err := exportSchemas(dir); err != nil {
+		t.Fatalf("failed to export schemas: %v", err)
+	}
+	for _, kind := range schema.Kinds {
+		raw, err := os.ReadFile(filepath.Join(dir, kind.FileName()))
+		if err != nil {
+			t.Fatalf("failed to read the schema of %s: %v", kind, err)
+		}
+		var s schema.Schema
+		if err := json.Unmarshal(raw, &s); err != nil {
+			t.Errorf("the schema of %s is invalid: %v", kind, err)
+		}
+	}
+}
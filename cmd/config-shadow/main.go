@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// config-shadow previews the effect of a change to the Prow config. It loads
+// the proposed config of a config repo PR next to the live config, compares
+// the jobs, simulates the periodics horologium would trigger and evaluates
+// the Tide queries against GitHub, and posts the behavioral changes as a
+// comment on the PR. It never mutates anything but that comment.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+)
+
+type options struct {
+	// config is the proposed config.
+	config                         configflagutil.ConfigOptions
+	liveConfigPath                 string
+	liveJobConfigPath              string
+	liveSupplementalProwConfigDirs flagutil.Strings
+
+	org    string
+	repo   string
+	pull   int
+	window time.Duration
+	tide   bool
+
+	confirm bool
+	github  flagutil.GitHubOptions
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	o.config.AddFlags(fs)
+	fs.StringVar(&o.liveConfigPath, "live-config-path", "", "Path to the live prow config, usually a checkout of the base branch of the PR.")
+	fs.StringVar(&o.liveJobConfigPath, "live-job-config-path", "", "Path to the live job config.")
+	fs.Var(&o.liveSupplementalProwConfigDirs, "live-supplemental-prow-config-dir", "An additional directory from which to load the live prow config. Can be passed multiple times.")
+	fs.StringVar(&o.org, "org", "", "Org of the config repo PR. Defaults to the refs of the job.")
+	fs.StringVar(&o.repo, "repo", "", "Repo of the config repo PR. Defaults to the refs of the job.")
+	fs.IntVar(&o.pull, "pull", 0, "Number of the config repo PR. Defaults to the refs of the job.")
+	fs.DurationVar(&o.window, "window", 24*time.Hour, "Window in which the runs of periodics are simulated.")
+	fs.BoolVar(&o.tide, "tide", true, "Evaluate the Tide queries against GitHub.")
+	fs.BoolVar(&o.confirm, "confirm", false, "Post the summary on the PR if set, otherwise only log it.")
+	o.github.AddFlags(fs)
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	if err := o.config.Validate(!o.confirm); err != nil {
+		return err
+	}
+	if o.liveConfigPath == "" {
+		return errors.New("--live-config-path is mandatory")
+	}
+	if o.window <= 0 {
+		return errors.New("--window must be positive")
+	}
+	if o.pull == 0 {
+		if spec, err := downwardapi.ResolveSpecFromEnv(); err == nil && spec.Refs != nil && len(spec.Refs.Pulls) == 1 {
+			o.org, o.repo, o.pull = spec.Refs.Org, spec.Refs.Repo, spec.Refs.Pulls[0].Number
+		}
+	}
+	if o.confirm && (o.org == "" || o.repo == "" || o.pull == 0) {
+		return errors.New("--org, --repo and --pull are required with --confirm outside of a presubmit")
+	}
+	return o.github.Validate(!o.confirm)
+}
+
+type githubClient interface {
+	searchClient
+	BotUserChecker() (func(candidate string) bool, error)
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
+	CreateComment(org, repo string, number int, comment string) error
+	EditComment(org, repo string, id int, comment string) error
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	live, err := config.Load(o.liveConfigPath, o.liveJobConfigPath, o.liveSupplementalProwConfigDirs.Strings(), o.config.SupplementalProwConfigsFileNameSuffix)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the live config.")
+	}
+	githubClient, err := o.github.GitHubClient(!o.confirm)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting GitHub client.")
+	}
+
+	r := &report{}
+	proposed, err := config.Load(o.config.ConfigPath, o.config.JobConfigPath, o.config.SupplementalProwConfigDirs.Strings(), o.config.SupplementalProwConfigsFileNameSuffix)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	} else if err := r.compare(githubClient, live, proposed, time.Now(), o.window, o.tide); err != nil {
+		logrus.WithError(err).Fatal("Failed to compare the configs.")
+	}
+
+	summary := r.markdown(o.window)
+	logrus.Info(summary)
+	if o.confirm {
+		if err := postSummary(githubClient, o.org, o.repo, o.pull, summary); err != nil {
+			logrus.WithError(err).Fatal("Failed to post the summary.")
+		}
+	}
+	if len(r.Errors) > 0 {
+		logrus.Fatal("The proposed config is invalid.")
+	}
+}
+
+// compare fills the report with the differences between the configs.
+func (r *report) compare(client searchClient, live, proposed *config.Config, now time.Time, window time.Duration, tide bool) error {
+	var err error
+	if r.Jobs, err = diffJobs(live, proposed); err != nil {
+		return fmt.Errorf("compare jobs: %w", err)
+	}
+	if r.Periodics, err = simulatePeriodics(live, proposed, now, window); err != nil {
+		return fmt.Errorf("simulate periodics: %w", err)
+	}
+	if tide {
+		r.Tide, r.TideErrors = simulateTide(client, live, proposed)
+	}
+	return nil
+}
+
+// postSummary creates the summary comment or updates the one posted for a
+// previous version of the PR.
+func postSummary(client githubClient, org, repo string, number int, summary string) error {
+	isBot, err := client.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("get bot user: %w", err)
+	}
+	comments, err := client.ListIssueComments(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("list comments: %w", err)
+	}
+	for _, comment := range comments {
+		if isBot(comment.User.Login) && strings.HasPrefix(comment.Body, commentMarker) {
+			return client.EditComment(org, repo, comment.ID, summary)
+		}
+	}
+	return client.CreateComment(org, repo, number, summary)
+}
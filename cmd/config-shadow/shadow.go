@@ -0,0 +1,406 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	cron "gopkg.in/robfig/cron.v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// maxListed is the number of items listed per section of the summary, so
+// that large changes don't exceed the size limit of comments.
+const maxListed = 20
+
+// jobChange describes how a job differs between the live and the proposed
+// config.
+type jobChange struct {
+	Type    string
+	OrgRepo string
+	Name    string
+	// Added and Removed are set if the job only exists in one config,
+	// otherwise Fields are the changed fields of the job.
+	Added   bool
+	Removed bool
+	Fields  []string
+}
+
+// periodicChange describes how the schedule of a periodic changes during
+// the simulated window.
+type periodicChange struct {
+	Name string
+	// Before and After are the number of runs horologium would trigger in
+	// the window, -1 if the periodic doesn't exist in the config.
+	Before int
+	After  int
+	// Next is the first run after the proposed config is applied.
+	Next time.Time
+}
+
+// tideChange is a pull request that enters or leaves the merge pool.
+type tideChange struct {
+	URL   string
+	Title string
+	// Added is true if the PR matches the proposed queries but not the
+	// live ones.
+	Added bool
+}
+
+type report struct {
+	Errors    []string
+	Jobs      []jobChange
+	Periodics []periodicChange
+	Tide      []tideChange
+	// TideErrors are the queries that couldn't be evaluated.
+	TideErrors []string
+}
+
+func (r *report) empty() bool {
+	return len(r.Errors) == 0 && len(r.Jobs) == 0 && len(r.Periodics) == 0 && len(r.Tide) == 0 && len(r.TideErrors) == 0
+}
+
+// diffJobs compares the presubmits, postsubmits and periodics of the
+// configs.
+func diffJobs(live, proposed *config.Config) ([]jobChange, error) {
+	before, err := jobsByKey(live)
+	if err != nil {
+		return nil, err
+	}
+	after, err := jobsByKey(proposed)
+	if err != nil {
+		return nil, err
+	}
+	var changes []jobChange
+	for key, job := range after {
+		old, ok := before[key]
+		if !ok {
+			changes = append(changes, jobChange{Type: key.jobType, OrgRepo: key.orgRepo, Name: key.name, Added: true})
+			continue
+		}
+		if fields := changedFields(old, job); len(fields) > 0 {
+			changes = append(changes, jobChange{Type: key.jobType, OrgRepo: key.orgRepo, Name: key.name, Fields: fields})
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, jobChange{Type: key.jobType, OrgRepo: key.orgRepo, Name: key.name, Removed: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.OrgRepo != b.OrgRepo {
+			return a.OrgRepo < b.OrgRepo
+		}
+		return a.Name < b.Name
+	})
+	return changes, nil
+}
+
+type jobKey struct {
+	jobType, orgRepo, name string
+}
+
+// jobsByKey returns the jobs of the config as generic maps, so jobs can be
+// compared field by field.
+func jobsByKey(c *config.Config) (map[jobKey]map[string]interface{}, error) {
+	jobs := map[jobKey]map[string]interface{}{}
+	add := func(key jobKey, job interface{}) error {
+		raw, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("marshal %s job %s: %w", key.jobType, key.name, err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return fmt.Errorf("unmarshal %s job %s: %w", key.jobType, key.name, err)
+		}
+		jobs[key] = fields
+		return nil
+	}
+	for orgRepo, presubmits := range c.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			if err := add(jobKey{"presubmit", orgRepo, presubmit.Name}, presubmit); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for orgRepo, postsubmits := range c.PostsubmitsStatic {
+		for _, postsubmit := range postsubmits {
+			if err := add(jobKey{"postsubmit", orgRepo, postsubmit.Name}, postsubmit); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, periodic := range c.Periodics {
+		if err := add(jobKey{"periodic", "", periodic.Name}, periodic); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+func changedFields(before, after map[string]interface{}) []string {
+	fields := sets.New[string]()
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			fields.Insert(field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fields.Insert(field)
+		}
+	}
+	return sets.List(fields)
+}
+
+// simulatePeriodics counts the runs horologium would trigger for every
+// periodic in the window starting at now, and returns the periodics for
+// which the count changes.
+func simulatePeriodics(live, proposed *config.Config, now time.Time, window time.Duration) ([]periodicChange, error) {
+	before := map[string]config.Periodic{}
+	for _, periodic := range live.Periodics {
+		before[periodic.Name] = periodic
+	}
+	after := map[string]config.Periodic{}
+	for _, periodic := range proposed.Periodics {
+		after[periodic.Name] = periodic
+	}
+	var changes []periodicChange
+	for _, name := range sets.List(sets.KeySet(before).Union(sets.KeySet(after))) {
+		change := periodicChange{Name: name, Before: -1, After: -1}
+		if periodic, ok := before[name]; ok {
+			runs, _, err := scheduledRuns(periodic, now, window)
+			if err != nil {
+				return nil, fmt.Errorf("live periodic %s: %w", name, err)
+			}
+			change.Before = runs
+		}
+		if periodic, ok := after[name]; ok {
+			runs, next, err := scheduledRuns(periodic, now, window)
+			if err != nil {
+				return nil, fmt.Errorf("proposed periodic %s: %w", name, err)
+			}
+			change.After, change.Next = runs, next
+			if change.Before == -1 {
+				// Horologium triggers periodics without a previous run right
+				// away.
+				change.Next = now
+			}
+		}
+		if change.Before != change.After {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// scheduledRuns returns the number of runs of the periodic in the window
+// and the first of them. Periodics with a minimum interval are assumed to
+// finish immediately, so the number is an upper bound.
+func scheduledRuns(periodic config.Periodic, now time.Time, window time.Duration) (int, time.Time, error) {
+	end := now.Add(window)
+	if periodic.Cron == "" {
+		interval := periodic.GetInterval()
+		if periodic.MinimumInterval != "" {
+			interval = periodic.GetMinimumInterval()
+		}
+		if interval <= 0 {
+			return 0, time.Time{}, errors.New("no interval")
+		}
+		return int(window / interval), now.Add(interval), nil
+	}
+	schedule, err := cron.Parse("TZ=UTC " + periodic.Cron)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid cron %q: %w", periodic.Cron, err)
+	}
+	var runs int
+	var first time.Time
+	for next := schedule.Next(now); !next.IsZero() && !next.After(end); next = schedule.Next(next) {
+		if runs == 0 {
+			first = next
+		}
+		runs++
+	}
+	return runs, first, nil
+}
+
+type searchClient interface {
+	FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error)
+}
+
+// simulateTide runs the Tide queries of the orgs whose queries change
+// against GitHub and returns the pull requests that enter or leave the
+// merge pool. Queries that fail are returned as errors, so the other
+// changes are still reported.
+func simulateTide(client searchClient, live, proposed *config.Config) ([]tideChange, []string) {
+	before, after := orgQueries(live), orgQueries(proposed)
+	var changes []tideChange
+	var errs []string
+	results := map[string]map[string]github.Issue{}
+	pool := func(org string, queries sets.Set[string]) map[string]github.Issue {
+		prs := map[string]github.Issue{}
+		for _, query := range sets.List(queries) {
+			key := org + "\x00" + query
+			if _, ok := results[key]; !ok {
+				issues, err := client.FindIssuesWithOrg(org, query, "", false)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("query %q in %s: %v", query, org, err))
+				}
+				results[key] = map[string]github.Issue{}
+				for _, issue := range issues {
+					results[key][issue.HTMLURL] = issue
+				}
+			}
+			for url, issue := range results[key] {
+				prs[url] = issue
+			}
+		}
+		return prs
+	}
+	for _, org := range sets.List(sets.KeySet(before).Union(sets.KeySet(after))) {
+		if before[org].Equal(after[org]) {
+			continue
+		}
+		old, current := pool(org, before[org]), pool(org, after[org])
+		for url, issue := range current {
+			if _, ok := old[url]; !ok {
+				changes = append(changes, tideChange{URL: url, Title: issue.Title, Added: true})
+			}
+		}
+		for url, issue := range old {
+			if _, ok := current[url]; !ok {
+				changes = append(changes, tideChange{URL: url, Title: issue.Title})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].URL < changes[j].URL })
+	return changes, errs
+}
+
+func orgQueries(c *config.Config) map[string]sets.Set[string] {
+	queries := map[string]sets.Set[string]{}
+	for i := range c.Tide.Queries {
+		for org, query := range c.Tide.Queries[i].OrgQueries() {
+			if queries[org] == nil {
+				queries[org] = sets.New[string]()
+			}
+			queries[org].Insert(query)
+		}
+	}
+	return queries
+}
+
+// commentMarker identifies the summary comment, so it's updated instead of
+// posting a new comment every time the config changes.
+const commentMarker = "<!-- config-shadow -->"
+
+// markdown renders the report as a comment.
+func (r *report) markdown(window time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n### Config shadow\n\n", commentMarker)
+	if r.empty() {
+		b.WriteString("This change doesn't change the behavior of Prow.\n")
+		return b.String()
+	}
+	if len(r.Errors) > 0 {
+		b.WriteString("#### The proposed config is invalid\n\n")
+		for _, err := range r.Errors {
+			fmt.Fprintf(&b, "```\n%s\n```\n", err)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Jobs) > 0 {
+		b.WriteString("#### Jobs\n\n| Type | Repo | Job | Change |\n| --- | --- | --- | --- |\n")
+		for i, job := range r.Jobs {
+			if i == maxListed {
+				fmt.Fprintf(&b, "\n...and %d more jobs.\n", len(r.Jobs)-maxListed)
+				break
+			}
+			change := "changes " + strings.Join(wrap(job.Fields, "`"), ", ")
+			switch {
+			case job.Added:
+				change = "added"
+			case job.Removed:
+				change = "removed"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s |\n", job.Type, job.OrgRepo, job.Name, change)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Periodics) > 0 {
+		fmt.Fprintf(&b, "#### Periodics\n\nRuns triggered by horologium in the next %s:\n\n| Periodic | Live | Proposed | First run |\n| --- | --- | --- | --- |\n", window)
+		for i, periodic := range r.Periodics {
+			if i == maxListed {
+				fmt.Fprintf(&b, "\n...and %d more periodics.\n", len(r.Periodics)-maxListed)
+				break
+			}
+			next := "-"
+			if !periodic.Next.IsZero() {
+				next = periodic.Next.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", periodic.Name, runs(periodic.Before), runs(periodic.After), next)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Tide) > 0 || len(r.TideErrors) > 0 {
+		b.WriteString("#### Tide\n\n")
+		for i, pr := range r.Tide {
+			if i == maxListed {
+				fmt.Fprintf(&b, "\n...and %d more pull requests.\n", len(r.Tide)-maxListed)
+				break
+			}
+			verb := "leaves"
+			if pr.Added {
+				verb = "enters"
+			}
+			fmt.Fprintf(&b, "- [%s](%s) %s the merge pool\n", pr.Title, pr.URL, verb)
+		}
+		for _, err := range r.TideErrors {
+			fmt.Fprintf(&b, "- could not evaluate %s\n", err)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func runs(n int) string {
+	if n < 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+func wrap(items []string, with string) []string {
+	wrapped := make([]string, 0, len(items))
+	for _, item := range items {
+		wrapped = append(wrapped, with+item+with)
+	}
+	return wrapped
+}
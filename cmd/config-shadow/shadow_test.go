@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+)
+
+func TestDiffJobs(t *testing.T) {
+	live := &config.Config{JobConfig: config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo": {
+				{JobBase: config.JobBase{Name: "pull-unit"}, AlwaysRun: true},
+				{JobBase: config.JobBase{Name: "pull-e2e"}},
+			},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "ci-nightly"}, Cron: "0 0 * * *"}},
+	}}
+	proposed := &config.Config{JobConfig: config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo": {
+				{JobBase: config.JobBase{Name: "pull-unit", MaxConcurrency: 2}, Optional: true},
+				{JobBase: config.JobBase{Name: "pull-e2e", SourcePath: "moved.yaml"}},
+			},
+		},
+		PostsubmitsStatic: map[string][]config.Postsubmit{
+			"org/repo": {{JobBase: config.JobBase{Name: "post-push"}}},
+		},
+	}}
+
+	changes, err := diffJobs(live, proposed)
+	if err != nil {
+		t.Fatalf("failed to diff jobs: %v", err)
+	}
+	expected := []jobChange{
+		{Type: "periodic", Name: "ci-nightly", Removed: true},
+		{Type: "postsubmit", OrgRepo: "org/repo", Name: "post-push", Added: true},
+		{Type: "presubmit", OrgRepo: "org/repo", Name: "pull-unit", Fields: []string{"always_run", "max_concurrency", "optional"}},
+	}
+	if diff := cmp.Diff(expected, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+func TestSimulatePeriodics(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	hourly := config.Periodic{JobBase: config.JobBase{Name: "ci-hourly"}, Interval: "1h"}
+	hourly.SetInterval(time.Hour)
+	faster := config.Periodic{JobBase: config.JobBase{Name: "ci-hourly"}, Interval: "30m"}
+	faster.SetInterval(30 * time.Minute)
+	live := &config.Config{JobConfig: config.JobConfig{Periodics: []config.Periodic{
+		hourly,
+		{JobBase: config.JobBase{Name: "ci-nightly"}, Cron: "0 0 * * *"},
+		{JobBase: config.JobBase{Name: "ci-removed"}, Cron: "0 * * * *"},
+	}}}
+	proposed := &config.Config{JobConfig: config.JobConfig{Periodics: []config.Periodic{
+		faster,
+		{JobBase: config.JobBase{Name: "ci-nightly"}, Cron: "0 12 * * *"},
+		{JobBase: config.JobBase{Name: "ci-weekdays"}, Cron: "0 6 * * 1-5"},
+	}}}
+
+	changes, err := simulatePeriodics(live, proposed, now, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to simulate periodics: %v", err)
+	}
+	expected := []periodicChange{
+		{Name: "ci-hourly", Before: 24, After: 48, Next: now.Add(30 * time.Minute)},
+		{Name: "ci-removed", Before: 24, After: -1},
+		{Name: "ci-weekdays", Before: -1, After: 1, Next: now},
+	}
+	if diff := cmp.Diff(expected, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+type fakeSearchClient map[string][]github.Issue
+
+func (f fakeSearchClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error) {
+	return f[org+":"+query], nil
+}
+
+func TestSimulateTide(t *testing.T) {
+	live := &config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{TideGitHubConfig: config.TideGitHubConfig{Queries: config.TideQueries{
+		{Repos: []string{"org/repo"}, Labels: []string{"lgtm"}},
+		{Repos: []string{"other/repo"}, Labels: []string{"lgtm"}},
+	}}}}}
+	proposed := &config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{TideGitHubConfig: config.TideGitHubConfig{Queries: config.TideQueries{
+		{Repos: []string{"org/repo"}, Labels: []string{"lgtm"}, MissingLabels: []string{"hold"}},
+		{Repos: []string{"other/repo"}, Labels: []string{"lgtm"}},
+	}}}}}
+	lgtm, hold := github.Issue{HTMLURL: "https://github.com/org/repo/pull/1", Title: "ready"}, github.Issue{HTMLURL: "https://github.com/org/repo/pull/2", Title: "held"}
+	client := fakeSearchClient{
+		"org:" + live.Tide.Queries[0].OrgQueries()["org"]:     {lgtm, hold},
+		"org:" + proposed.Tide.Queries[0].OrgQueries()["org"]: {lgtm},
+		// The queries of other don't change, so they aren't evaluated.
+		"other:" + live.Tide.Queries[1].OrgQueries()["other"]: {{HTMLURL: "https://github.com/other/repo/pull/1"}},
+	}
+
+	changes, errs := simulateTide(client, live, proposed)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	expected := []tideChange{{URL: hold.HTMLURL, Title: "held"}}
+	if diff := cmp.Diff(expected, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+func TestPostSummary(t *testing.T) {
+	client := fakegithub.NewFakeClient()
+	client.IssueComments[1] = []github.IssueComment{{ID: 1, Body: commentMarker + " old", User: github.User{Login: "someone"}}}
+	if err := postSummary(client, "org", "repo", 1, commentMarker+" first"); err != nil {
+		t.Fatalf("failed to post summary: %v", err)
+	}
+	if err := postSummary(client, "org", "repo", 1, commentMarker+" second"); err != nil {
+		t.Fatalf("failed to post summary: %v", err)
+	}
+	if diff := cmp.Diff([]string{"org/repo#1:" + commentMarker + " first"}, client.IssueCommentsAdded); diff != "" {
+		t.Errorf("unexpected added comments (-want +got):\n%s", diff)
+	}
+	if len(client.IssueCommentsEdited) != 1 || !strings.HasSuffix(client.IssueCommentsEdited[0], " second") {
+		t.Errorf("expected the comment of the bot to be edited, got %v", client.IssueCommentsEdited)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// config-snapshotter periodically snapshots the effective Prow config and
+// plugins config, as loaded by the components, to object storage. prowctl
+// diffs the snapshots and rolls the config back to one of them.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/configsnapshot"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+var lastSnapshot = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "config_snapshotter_last_snapshot_timestamp_seconds",
+	Help: "Time of the last successful check of the config, whether it was snapshotted or unchanged.",
+})
+
+func init() {
+	prometheus.MustRegister(lastSnapshot)
+}
+
+type options struct {
+	config          configflagutil.ConfigOptions
+	pluginsConfig   pluginsflagutil.PluginOptions
+	storage         prowflagutil.StorageClientOptions
+	instrumentation prowflagutil.InstrumentationOptions
+
+	snapshotPath string
+	interval     time.Duration
+	oneshot      bool
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	o := options{config: configflagutil.ConfigOptions{ConfigPath: "/etc/config/config.yaml"}}
+	fs.StringVar(&o.snapshotPath, "snapshot-path", "", "The gs:// or s3:// path to keep the snapshots under.")
+	fs.DurationVar(&o.interval, "interval", time.Hour, "How often to snapshot the config. Unchanged configs aren't snapshotted again.")
+	fs.BoolVar(&o.oneshot, "oneshot", false, "Snapshot the config once and exit.")
+	o.pluginsConfig.AddFlags(fs)
+	for _, group := range []prowflagutil.OptionGroup{&o.config, &o.storage, &o.instrumentation} {
+		group.AddFlags(fs)
+	}
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	for _, group := range []prowflagutil.OptionGroup{&o.config, &o.storage, &o.instrumentation} {
+		if err := group.Validate(false); err != nil {
+			return err
+		}
+	}
+	if err := configsnapshot.ValidatePath(o.snapshotPath); err != nil {
+		return fmt.Errorf("--snapshot-path: %w", err)
+	}
+	if o.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	return nil
+}
+
+type snapshotter struct {
+	config config.Getter
+	// plugins is nil if the plugins config isn't snapshotted.
+	plugins func() *plugins.Configuration
+	store   *configsnapshot.Store
+	now     func() time.Time
+}
+
+// snapshot saves the current config if it differs from the latest snapshot.
+func (s *snapshotter) snapshot(ctx context.Context) error {
+	var pluginsCfg *plugins.Configuration
+	if s.plugins != nil {
+		pluginsCfg = s.plugins()
+	}
+	snapshot, err := configsnapshot.Take(s.config(), pluginsCfg, s.now())
+	if err != nil {
+		return err
+	}
+	latest, err := s.store.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	if !snapshot.Changed(latest) {
+		logrus.WithField("snapshot", latest.ID).Debug("Config is unchanged since the latest snapshot.")
+		return nil
+	}
+	if err := s.store.Save(ctx, snapshot); err != nil {
+		return err
+	}
+	log := logrus.WithField("snapshot", snapshot.ID)
+	if !snapshot.Restorable {
+		log = log.WithField("restore-error", snapshot.RestoreError)
+	}
+	log.WithField("restorable", snapshot.Restorable).Info("Snapshotted the config.")
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+	s := &snapshotter{config: configAgent.Config, now: time.Now}
+	if o.pluginsConfig.PluginConfigPath != "" {
+		pluginAgent, err := o.pluginsConfig.PluginAgent()
+		if err != nil {
+			logrus.WithError(err).Fatal("Error starting plugins config agent.")
+		}
+		s.plugins = pluginAgent.Config
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error creating opener.")
+	}
+	s.store = configsnapshot.NewStore(opener, o.snapshotPath)
+
+	if o.oneshot {
+		if err := s.snapshot(context.Background()); err != nil {
+			logrus.WithError(err).Fatal("Error snapshotting the config.")
+		}
+		return
+	}
+
+	defer interrupts.WaitForGracefulShutdown()
+	pprof.Instrument(o.instrumentation)
+	metrics.ExposeMetrics("config-snapshotter", configAgent.Config().PushGateway, o.instrumentation.MetricsPort)
+	interrupts.TickLiteral(func() {
+		if err := s.snapshot(interrupts.Context()); err != nil {
+			logrus.WithError(err).Error("Error snapshotting the config.")
+			return
+		}
+		lastSnapshot.SetToCurrentTime()
+	}, o.interval)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr bool
+	}{
+		{
+			name: "snapshot to GCS",
+			args: []string{"--snapshot-path=gs://bucket/config-snapshots"},
+		},
+		{
+			name:        "snapshot path is required",
+			expectedErr: true,
+		},
+		{
+			name:        "snapshot path must have a bucket",
+			args:        []string{"--snapshot-path=config-snapshots"},
+			expectedErr: true,
+		},
+		{
+			name:        "interval must be positive",
+			args:        []string{"--snapshot-path=s3://bucket/config-snapshots", "--interval=0s"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := gatherOptions(flag.NewFlagSet("config-snapshotter", flag.ContinueOnError), tc.args...)
+			if err := o.Validate(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -35,15 +36,20 @@ import (
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/config/secret"
 	"sigs.k8s.io/prow/pkg/crier"
+	execreporter "sigs.k8s.io/prow/pkg/crier/reporters/exec"
 	gcsreporter "sigs.k8s.io/prow/pkg/crier/reporters/gcs"
 	k8sgcsreporter "sigs.k8s.io/prow/pkg/crier/reporters/gcs/kubernetes"
 	gerritreporter "sigs.k8s.io/prow/pkg/crier/reporters/gerrit"
 	githubreporter "sigs.k8s.io/prow/pkg/crier/reporters/github"
+	githubchecksreporter "sigs.k8s.io/prow/pkg/crier/reporters/githubchecks"
 	pubsubreporter "sigs.k8s.io/prow/pkg/crier/reporters/pubsub"
 	resultstorereporter "sigs.k8s.io/prow/pkg/crier/reporters/resultstore"
 	slackreporter "sigs.k8s.io/prow/pkg/crier/reporters/slack"
+	sloreporter "sigs.k8s.io/prow/pkg/crier/reporters/slo"
 	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
 	"sigs.k8s.io/prow/pkg/interrupts"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/metrics"
@@ -62,10 +68,15 @@ type options struct {
 	gerritWorkers         int
 	pubsubWorkers         int
 	githubWorkers         int
+	githubChecksWorkers   int
 	slackWorkers          int
 	blobStorageWorkers    int
 	k8sBlobStorageWorkers int
 	resultStoreWorkers    int
+	execWorkers           int
+	sloWorkers            int
+
+	githubStatusBatchPeriod time.Duration
 
 	slackTokenFile            string
 	additionalSlackTokenFiles slackclient.HostsFlag
@@ -83,7 +94,7 @@ type options struct {
 }
 
 func (o *options) validate() error {
-	if o.gerritWorkers+o.pubsubWorkers+o.githubWorkers+o.slackWorkers+o.blobStorageWorkers+o.k8sBlobStorageWorkers+o.resultStoreWorkers <= 0 {
+	if o.gerritWorkers+o.pubsubWorkers+o.githubWorkers+o.githubChecksWorkers+o.slackWorkers+o.blobStorageWorkers+o.k8sBlobStorageWorkers+o.resultStoreWorkers+o.execWorkers+o.sloWorkers <= 0 {
 		return errors.New("crier need to have at least one report worker to start")
 	}
 
@@ -100,12 +111,16 @@ func (o *options) validate() error {
 		}
 	}
 
-	if o.githubWorkers > 0 {
+	if o.githubWorkers+o.githubChecksWorkers > 0 {
 		if err := o.github.Validate(o.dryrun); err != nil {
 			return err
 		}
 	}
 
+	if o.githubStatusBatchPeriod < 0 {
+		return errors.New("--github-status-batch-period must not be negative")
+	}
+
 	if o.slackWorkers > 0 {
 		if o.slackTokenFile == "" && len(o.additionalSlackTokenFiles) == 0 {
 			return errors.New("one of --slack-token-file or --additional-slack-token-files must be set")
@@ -126,6 +141,8 @@ func (o *options) parseArgs(fs *flag.FlagSet, args []string) error {
 	fs.IntVar(&o.gerritWorkers, "gerrit-workers", 0, "Number of gerrit report workers (0 means disabled)")
 	fs.IntVar(&o.pubsubWorkers, "pubsub-workers", 0, "Number of pubsub report workers (0 means disabled)")
 	fs.IntVar(&o.githubWorkers, "github-workers", 0, "Number of github report workers (0 means disabled)")
+	fs.IntVar(&o.githubChecksWorkers, "github-checks-workers", 0, "Number of workers reporting jobs as GitHub check runs with annotations for failed tests, used instead of the status contexts of --github-workers (0 means disabled)")
+	fs.DurationVar(&o.githubStatusBatchPeriod, "github-status-batch-period", 0, "If set, GitHub status updates are queued and sent in batches with this period, coalescing updates for the same SHA and context and backing off when rate limited (0 means status updates are sent right away)")
 	fs.IntVar(&o.slackWorkers, "slack-workers", 0, "Number of Slack report workers (0 means disabled)")
 	fs.Var(&o.additionalSlackTokenFiles, "additional-slack-token-files", "Map of additional slack token files. example: --additional-slack-token-files=foo=/etc/foo-slack-tokens/token, repeat flag for each host")
 	fs.IntVar(&o.blobStorageWorkers, "blob-storage-workers", 0, "Number of blob storage report workers (0 means disabled)")
@@ -134,6 +151,8 @@ func (o *options) parseArgs(fs *flag.FlagSet, args []string) error {
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to a Slack token file")
 	fs.StringVar(&o.reportAgent, "report-agent", "", "Only report specified agent - empty means report to all agents (effective for github and Slack only)")
 	fs.IntVar(&o.resultStoreWorkers, "resultstore-workers", 0, "Number of ResultStore report workers (0 means disabled)")
+	fs.IntVar(&o.execWorkers, "exec-workers", 0, "Number of workers running the commands of exec_reporter_configs (0 means disabled)")
+	fs.IntVar(&o.sloWorkers, "slo-workers", 0, "Number of workers tracking the SLOs of jobs (0 means disabled)")
 	fs.BoolVar(&o.resultstoreArtifactsDirOnly, "resultstore-artifacts-dir-only", false, "Report the artifacts/ dir instead of subtree files (testing)")
 
 	// TODO(krzyzacy): implement dryrun for gerrit/pubsub
@@ -253,33 +272,66 @@ func main() {
 		}
 	}
 
-	if o.githubWorkers > 0 {
+	if o.execWorkers > 0 {
+		hasReporter = true
+		if err := crier.New(mgr, execreporter.NewReporter(cfg, o.dryrun), o.execWorkers, o.githubEnablement.EnablementChecker()); err != nil {
+			logrus.WithError(err).Fatal("failed to construct exec reporter controller")
+		}
+	}
+
+	if o.sloWorkers > 0 {
+		hasReporter = true
+		if err := crier.New(mgr, sloreporter.NewReporter(cfg), o.sloWorkers, o.githubEnablement.EnablementChecker()); err != nil {
+			logrus.WithError(err).Fatal("failed to construct SLO reporter controller")
+		}
+	}
+
+	var githubClient github.Client
+	if o.githubWorkers+o.githubChecksWorkers > 0 {
 		if o.github.TokenPath != "" {
 			if err := secret.Add(o.github.TokenPath); err != nil {
 				logrus.WithError(err).Fatal("Error reading GitHub credentials")
 			}
 		}
 
-		githubClient, err := o.github.GitHubClient(o.dryrun)
+		githubClient, err = o.github.GitHubClient(o.dryrun)
 		if err != nil {
 			logrus.WithError(err).Fatal("Error getting GitHub client.")
 		}
+	}
 
+	if o.githubWorkers > 0 {
 		hasReporter = true
-		githubReporter := githubreporter.NewReporter(githubClient, cfg, prowapi.ProwJobAgent(o.reportAgent), mgr.GetCache())
+		var reportClient report.GitHubClient = githubClient
+		if o.githubStatusBatchPeriod > 0 {
+			statusQueue := githubreporter.NewStatusQueue(githubClient, o.githubStatusBatchPeriod)
+			if err := mgr.Add(statusQueue); err != nil {
+				logrus.WithError(err).Fatal("failed to add github status queue to manager")
+			}
+			reportClient = statusQueue
+		}
+		githubReporter := githubreporter.NewReporter(reportClient, cfg, prowapi.ProwJobAgent(o.reportAgent), mgr.GetCache())
 		if err := crier.New(mgr, githubReporter, o.githubWorkers, o.githubEnablement.EnablementChecker()); err != nil {
 			logrus.WithError(err).Fatal("failed to construct github reporter controller")
 		}
 	}
 
 	var opener io.Opener
-	if o.blobStorageWorkers+o.k8sBlobStorageWorkers+o.resultStoreWorkers > 0 {
+	if o.blobStorageWorkers+o.k8sBlobStorageWorkers+o.resultStoreWorkers+o.githubChecksWorkers > 0 {
 		opener, err = o.storage.StorageClient(context.Background())
 		if err != nil {
 			logrus.WithError(err).Fatal("Error creating opener")
 		}
 	}
 
+	if o.githubChecksWorkers > 0 {
+		hasReporter = true
+		checksReporter := githubchecksreporter.NewReporter(githubClient, cfg, opener, prowapi.ProwJobAgent(o.reportAgent))
+		if err := crier.New(mgr, checksReporter, o.githubChecksWorkers, o.githubEnablement.EnablementChecker()); err != nil {
+			logrus.WithError(err).Fatal("failed to construct github checks reporter controller")
+		}
+	}
+
 	if o.blobStorageWorkers > 0 || o.k8sBlobStorageWorkers > 0 {
 		hasReporter = true
 		if o.blobStorageWorkers > 0 {
@@ -104,6 +104,42 @@ func TestOptions(t *testing.T) {
 			name: "pubsub workers set to negative, rejects",
 			args: []string{"--pubsub-workers=-3", "--config-path=foo"},
 		},
+		//Exec Reporter
+		{
+			name: "exec workers, sets workers",
+			args: []string{"--exec-workers=2", "--config-path=foo"},
+			expected: &options{
+				config: configflagutil.ConfigOptions{
+					ConfigPathFlagName:                    "config-path",
+					JobConfigPathFlagName:                 "job-config-path",
+					ConfigPath:                            "foo",
+					SupplementalProwConfigsFileNameSuffix: "_prowconfig.yaml",
+					InRepoConfigCacheSize:                 200,
+				},
+				execWorkers:            2,
+				github:                 defaultGitHubOptions,
+				k8sReportFraction:      1.0,
+				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
+			},
+		},
+		//SLO Reporter
+		{
+			name: "slo workers, sets workers",
+			args: []string{"--slo-workers=1", "--config-path=foo"},
+			expected: &options{
+				config: configflagutil.ConfigOptions{
+					ConfigPathFlagName:                    "config-path",
+					JobConfigPathFlagName:                 "job-config-path",
+					ConfigPath:                            "foo",
+					SupplementalProwConfigsFileNameSuffix: "_prowconfig.yaml",
+					InRepoConfigCacheSize:                 200,
+				},
+				sloWorkers:             1,
+				github:                 defaultGitHubOptions,
+				k8sReportFraction:      1.0,
+				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
+			},
+		},
 		//Slack Reporter
 		{
 			name: "slack workers, sets workers",
@@ -236,10 +272,11 @@ This unit testing covers only the public portions
 */
 func TestGitHubOptions(t *testing.T) {
 	cases := []struct {
-		name              string
-		args              []string
-		expectedWorkers   int
-		expectedTokenPath string
+		name                  string
+		args                  []string
+		expectedWorkers       int
+		expectedChecksWorkers int
+		expectedTokenPath     string
 	}{
 		{
 			name:              "github workers, only support single worker",
@@ -247,6 +284,12 @@ func TestGitHubOptions(t *testing.T) {
 			expectedWorkers:   5,
 			expectedTokenPath: "tkpath",
 		},
+		{
+			name:                  "github checks workers",
+			args:                  []string{"--github-checks-workers=3", "--github-token-path=tkpath", "--config-path=foo"},
+			expectedChecksWorkers: 3,
+			expectedTokenPath:     "tkpath",
+		},
 	}
 
 	for _, tc := range cases {
@@ -261,6 +304,10 @@ func TestGitHubOptions(t *testing.T) {
 			t.Errorf("%s: worker mismatch: actual %d != expected %d",
 				tc.name, actual.githubWorkers, tc.expectedWorkers)
 		}
+		if actual.githubChecksWorkers != tc.expectedChecksWorkers {
+			t.Errorf("%s: checks worker mismatch: actual %d != expected %d",
+				tc.name, actual.githubChecksWorkers, tc.expectedChecksWorkers)
+		}
 		if actual.github.TokenPath != tc.expectedTokenPath {
 			t.Errorf("%s: path mismatch: actual %s != expected %s",
 				tc.name, actual.github.TokenPath, tc.expectedTokenPath)
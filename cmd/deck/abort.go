@@ -29,10 +29,11 @@ import (
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	prowv1 "sigs.k8s.io/prow/pkg/client/clientset/versioned/typed/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/oidcauth"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
 
-func handleAbort(prowJobClient prowv1.ProwJobInterface, cfg authCfgGetter, goa *githuboauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+func handleAbort(prowJobClient prowv1.ProwJobInterface, cfg authCfgGetter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.TODO()
 		name := r.URL.Query().Get("prowjob")
@@ -52,15 +53,18 @@ func handleAbort(prowJobClient prowv1.ProwJobInterface, cfg authCfgGetter, goa *
 		}
 		switch r.Method {
 		case http.MethodPost:
-			if pj.Status.State != prowapi.TriggeredState && pj.Status.State != prowapi.PendingState {
+			if pj.Status.State != prowapi.HeldState && pj.Status.State != prowapi.WaitingState && pj.Status.State != prowapi.TriggeredState && pj.Status.State != prowapi.PendingState {
 				http.Error(w, fmt.Sprintf("Cannot abort job with state: %q.", pj.Status.State), http.StatusBadRequest)
 				l.Debug("Cannot abort job with state.")
 				return
 			}
 			// Using same permission validation as rerun, could be future work to add validation
 			// unique to Abort
-			allowed, user, err, code := isAllowedToRerun(r, cfg, goa, ghc, *pj, cli, pluginAgent, l)
+			allowed, user, err, code := isAllowedToRerun(r, cfg, goa, oa, ghc, *pj, cli, pluginAgent, l)
 			if err != nil {
+				if code == http.StatusUnauthorized {
+					setLoginURL(w, oa)
+				}
 				http.Error(w, fmt.Sprintf("Could not verify if allowed to abort: %v.", err), code)
 				l.WithError(err).Debug("Could not verify if allowed to abort.")
 				return
@@ -75,6 +79,10 @@ func handleAbort(prowJobClient prowv1.ProwJobInterface, cfg authCfgGetter, goa *
 			var abortDescription string
 			if len(user) > 0 {
 				abortDescription = fmt.Sprintf("%v successfully aborted %v.", user, name)
+				if pj.Annotations == nil {
+					pj.Annotations = map[string]string{}
+				}
+				pj.Annotations[prowapi.AbortedByAnnotation] = user
 			} else {
 				abortDescription = fmt.Sprintf("Successfully aborted %v.", name)
 			}
@@ -185,7 +185,7 @@ func TestAbort(t *testing.T) {
 			rc := fakegithub.NewFakeClient()
 			rc.OrgMembers = map[string][]string{"org": {"org-member"}}
 			pca := plugins.NewFakeConfigAgent()
-			handler := handleAbort(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), authCfgGetter, goa, ghc, rc, &pca, logrus.WithField("handler", "/abort"))
+			handler := handleAbort(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), authCfgGetter, goa, nil, ghc, rc, &pca, logrus.WithField("handler", "/abort"))
 			handler.ServeHTTP(rr, req)
 			if rr.Code != tc.httpCode {
 				t.Fatalf("Bad error code: %d", rr.Code)
@@ -209,6 +209,13 @@ func TestAbort(t *testing.T) {
 				if pj.Status.Description != expectedDescription {
 					t.Errorf("Wrong description, expected \"%v\", got \"%v\"", expectedDescription, pj.Status.Description)
 				}
+				expectedAbortedBy := tc.login
+				if tc.allowAnyone {
+					expectedAbortedBy = ""
+				}
+				if abortedBy := pj.Annotations[prowapi.AbortedByAnnotation]; abortedBy != expectedAbortedBy {
+					t.Errorf("Expected the job to be aborted by %q, got %q", expectedAbortedBy, abortedBy)
+				}
 			}
 		})
 	}
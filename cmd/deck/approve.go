@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/client/clientset/versioned/typed/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/oidcauth"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// handleApprove approves a held job that requires a manual approval. Users
+// allowed to rerun the job are allowed to approve it, as long as its rerun
+// auth config doesn't allow anyone: approvals are recorded with the login of
+// their user.
+func handleApprove(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, acfg authCfgGetter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.TODO()
+		name := r.URL.Query().Get("prowjob")
+		l := log.WithField("prowjob", name)
+		if name == "" {
+			http.Error(w, "Request did not provide the 'prowjob' query parameter.", http.StatusBadRequest)
+			return
+		}
+		pj, err := prowJobClient.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ProwJob not found: %v.", err), http.StatusNotFound)
+			if !kerrors.IsNotFound(err) {
+				// admins only care about errors other than not found
+				l.WithError(err).Warning("ProwJob not found.")
+			}
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			if pj.Status.State != prowapi.HeldState {
+				http.Error(w, fmt.Sprintf("Cannot approve job with state: %q.", pj.Status.State), http.StatusBadRequest)
+				l.Debug("Cannot approve job with state.")
+				return
+			}
+			allowed, user, err, code := isAllowedToRerun(r, acfg, goa, oa, ghc, *pj, cli, pluginAgent, l)
+			if err != nil {
+				if code == http.StatusUnauthorized {
+					setLoginURL(w, oa)
+				}
+				http.Error(w, fmt.Sprintf("Could not verify if allowed to approve: %v.", err), code)
+				l.WithError(err).Debug("Could not verify if allowed to approve.")
+				return
+			}
+			l = l.WithFields(logrus.Fields{"allowed": allowed, "user": user})
+			l.Info("Attempted approval")
+			if !allowed {
+				http.Error(w, "You don't have permission to approve this job.", http.StatusUnauthorized)
+				l.Debug("You don't have permission to approve this job.")
+				return
+			}
+			if user == "" {
+				// allow_anyone skips the login, so there is nobody to record.
+				http.Error(w, "Jobs that anyone is allowed to rerun cannot be approved.", http.StatusForbidden)
+				l.Debug("Jobs that anyone is allowed to rerun cannot be approved.")
+				return
+			}
+			pjutil.Approve(pj, user, pjutil.RequireScheduling(cfg().Scheduler.Enabled))
+			jsonPJ, err := json.Marshal(pj)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error marshal source job: %v.", err), http.StatusInternalServerError)
+				l.WithError(err).Errorf("Error marshal source job.")
+				return
+			}
+			pj, err := prowJobClient.Patch(ctx, pj.Name, ktypes.MergePatchType, jsonPJ, metav1.PatchOptions{})
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Could not patch approved job: %v.", err), http.StatusInternalServerError)
+				l.WithError(err).Errorf("Could not patch approved job.")
+				return
+			}
+			l.Infof("%v approved %v.", user, name)
+			if _, err = w.Write([]byte("Job successfully approved.")); err != nil {
+				l.WithError(err).Debug(fmt.Sprintf("Error writing to approve response for %v.", pj.Name))
+			}
+			return
+		default:
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func TestApprove(t *testing.T) {
+	testCases := []struct {
+		name          string
+		login         string
+		allowAnyone   bool
+		jobState      prowapi.ProwJobState
+		scheduler     bool
+		httpCode      int
+		httpMethod    string
+		expectedState prowapi.ProwJobState
+	}{
+		{
+			name:          "approve held job",
+			login:         "authorized",
+			jobState:      prowapi.HeldState,
+			httpCode:      http.StatusOK,
+			httpMethod:    http.MethodPost,
+			expectedState: prowapi.TriggeredState,
+		},
+		{
+			name:          "approve held job with the scheduler enabled",
+			login:         "authorized",
+			jobState:      prowapi.HeldState,
+			scheduler:     true,
+			httpCode:      http.StatusOK,
+			httpMethod:    http.MethodPost,
+			expectedState: prowapi.SchedulingState,
+		},
+		{
+			name:       "attempt to approve a triggered job",
+			login:      "authorized",
+			jobState:   prowapi.TriggeredState,
+			httpCode:   http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:       "user not authorized to approve job",
+			login:      "random-dude",
+			jobState:   prowapi.HeldState,
+			httpCode:   http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:        "jobs anyone can rerun cannot be approved",
+			login:       "random-dude",
+			allowAnyone: true,
+			jobState:    prowapi.HeldState,
+			httpCode:    http.StatusForbidden,
+			httpMethod:  http.MethodPost,
+		},
+		{
+			name:       "bad verb",
+			login:      "authorized",
+			jobState:   prowapi.HeldState,
+			httpCode:   http.StatusMethodNotAllowed,
+			httpMethod: http.MethodGet,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeProwJobClient := fake.NewSimpleClientset(&prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "wowsuch",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:                    "deploy",
+					Type:                   prowapi.PostsubmitJob,
+					Refs:                   &prowapi.Refs{Org: "org", Repo: "repo"},
+					RequiresManualApproval: true,
+				},
+				Status: prowapi.ProwJobStatus{
+					State: tc.jobState,
+				},
+			})
+			authCfgGetter := func(refs *prowapi.ProwJobSpec) *prowapi.RerunAuthConfig {
+				return &prowapi.RerunAuthConfig{
+					AllowAnyone: tc.allowAnyone,
+					GitHubUsers: []string{"authorized"},
+				}
+			}
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Scheduler: config.Scheduler{Enabled: tc.scheduler}}}
+			}
+
+			req, err := http.NewRequest(tc.httpMethod, "/approve?prowjob=wowsuch", nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			req.AddCookie(&http.Cookie{
+				Name:    "github_login",
+				Value:   tc.login,
+				Path:    "/",
+				Expires: time.Now().Add(time.Hour * 24 * 30),
+				Secure:  true,
+			})
+			mockCookieStore := sessions.NewCookieStore([]byte("secret-key"))
+			session, err := sessions.GetRegistry(req).Get(mockCookieStore, "access-token-session")
+			if err != nil {
+				t.Fatalf("Error making access token session: %v", err)
+			}
+			session.Values["access-token"] = &oauth2.Token{AccessToken: "validtoken"}
+
+			rr := httptest.NewRecorder()
+			goa := githuboauth.NewAgent(&githuboauth.Config{CookieStore: mockCookieStore}, &logrus.Entry{})
+			ghc := &fakeAuthenticatedUserIdentifier{login: tc.login}
+			pca := plugins.NewFakeConfigAgent()
+			handler := handleApprove(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), authCfgGetter, goa, nil, ghc, fakegithub.NewFakeClient(), &pca, logrus.WithField("handler", "/approve"))
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.httpCode {
+				t.Fatalf("Bad error code: %d", rr.Code)
+			}
+
+			pj, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").Get(context.TODO(), "wowsuch", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Job not found: %v", err)
+			}
+			if tc.httpCode != http.StatusOK {
+				if pj.Status.State != tc.jobState {
+					t.Errorf("Expected the state to be left as %q, got %q", tc.jobState, pj.Status.State)
+				}
+				return
+			}
+			if pj.Status.State != tc.expectedState {
+				t.Errorf("Wrong state, expected %q, got %q", tc.expectedState, pj.Status.State)
+			}
+			if approver := pj.Annotations[prowapi.ApprovedByAnnotation]; approver != tc.login {
+				t.Errorf("Expected the approver to be %q, got %q", tc.login, approver)
+			}
+		})
+	}
+}
@@ -18,15 +18,23 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"html/template"
-
+	"net/url"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
 
+// badgeMaxAge is how long browsers and image proxies such as GitHub's camo
+// may cache a badge.
+const badgeMaxAge = time.Minute
+
 var svg = `<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20">
 <linearGradient id="a" x2="0" y2="100%">
   <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
@@ -73,6 +81,8 @@ func makeShield(subject, status, color string) []byte {
 	switch color {
 	case "brightgreen":
 		p.Color = "#4c1"
+	case "yellow":
+		p.Color = "#dfb317"
 	case "red":
 		p.Color = "#e05d44"
 	default:
@@ -83,23 +93,77 @@ func makeShield(subject, status, color string) []byte {
 	return buf.Bytes()
 }
 
-// pickLatestJobs returns the most recent run of each job matching the selector,
-// which is comma-separated list of globs, for example "ci-ti-*,ci-other".
-// jobs will be sorted by StartTime in reverse order to display recent jobs first
-func pickLatestJobs(jobs []prowapi.ProwJob, selector string) []prowapi.ProwJob {
+// badgeQuery selects the jobs a badge is rendered for.
+type badgeQuery struct {
+	// jobs is a comma-separated list of globs, for example "ci-ti-*,ci-other".
+	jobs string
+	// branch restricts the badge to runs against this base branch.
+	branch string
+	// window, if set, makes the badge show the pass rate of the runs that
+	// completed within it instead of the latest status. rawWindow is the
+	// window as given in the query, to label the badge with.
+	window    time.Duration
+	rawWindow string
+}
+
+// parseBadgeQuery reads the jobs (or job), branch and window query parameters.
+func parseBadgeQuery(query url.Values) (badgeQuery, error) {
+	q := badgeQuery{
+		jobs:      query.Get("jobs"),
+		branch:    query.Get("branch"),
+		rawWindow: query.Get("window"),
+	}
+	if q.jobs == "" {
+		q.jobs = query.Get("job")
+	}
+	if q.jobs == "" {
+		return q, errors.New("missing jobs query parameter")
+	}
+	if q.rawWindow != "" {
+		window, err := parseBadgeWindow(q.rawWindow)
+		if err != nil {
+			return q, fmt.Errorf("invalid window %q: %w", q.rawWindow, err)
+		}
+		q.window = window
+	}
+	return q, nil
+}
+
+// parseBadgeWindow parses a duration that may also be given in days, e.g. 7d.
+func parseBadgeWindow(s string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if window, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if window <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return window, nil
+}
+
+// selectBadgeJobs returns the runs of the jobs matching the query, sorted by
+// StartTime in reverse order to have recent runs first.
+func selectBadgeJobs(jobs []prowapi.ProwJob, q badgeQuery) []prowapi.ProwJob {
 	sort.Slice(jobs, func(i, j int) bool {
 		return jobs[j].Status.StartTime.Before(&jobs[i].Status.StartTime)
 	})
 	var out []prowapi.ProwJob
-	have := make(map[string]bool)
-	want := strings.Split(selector, ",")
+	want := strings.Split(q.jobs, ",")
 	for _, job := range jobs {
-		if have[job.Spec.Job] {
-			continue // already have the latest result for this job
+		if q.branch != "" && baseRef(job.Spec) != q.branch {
+			continue
 		}
 		for _, pat := range want {
 			if match, _ := filepath.Match(pat, job.Spec.Job); match {
-				have[job.Spec.Job] = true
 				out = append(out, job)
 				break
 			}
@@ -108,6 +172,91 @@ func pickLatestJobs(jobs []prowapi.ProwJob, selector string) []prowapi.ProwJob {
 	return out
 }
 
+// baseRef returns the branch the job runs against, which is the base ref of
+// the first extra ref for periodics.
+func baseRef(spec prowapi.ProwJobSpec) string {
+	if spec.Refs != nil {
+		return spec.Refs.BaseRef
+	}
+	if len(spec.ExtraRefs) > 0 {
+		return spec.ExtraRefs[0].BaseRef
+	}
+	return ""
+}
+
+// pickLatestJobs returns the most recent run of each job matching the selector,
+// which is comma-separated list of globs, for example "ci-ti-*,ci-other".
+// jobs will be sorted by StartTime in reverse order to display recent jobs first
+func pickLatestJobs(jobs []prowapi.ProwJob, selector string) []prowapi.ProwJob {
+	return latestRuns(selectBadgeJobs(jobs, badgeQuery{jobs: selector}))
+}
+
+// latestRuns keeps the first run of each job of runs sorted by recency.
+func latestRuns(runs []prowapi.ProwJob) []prowapi.ProwJob {
+	var out []prowapi.ProwJob
+	have := make(map[string]bool)
+	for _, job := range runs {
+		if have[job.Spec.Job] {
+			continue // already have the latest result for this job
+		}
+		have[job.Spec.Job] = true
+		out = append(out, job)
+	}
+	return out
+}
+
+// badgeFor returns the subject, status and color of the badge for the query.
+func badgeFor(jobs []prowapi.ProwJob, q badgeQuery, now time.Time) (string, string, string) {
+	runs := selectBadgeJobs(jobs, q)
+	if q.window == 0 {
+		status, color, _ := renderBadge(latestRuns(runs))
+		return "build", status, color
+	}
+	status, color := passRate(runs, now.Add(-q.window))
+	return fmt.Sprintf("build (%s)", q.rawWindow), status, color
+}
+
+// passRate summarizes the share of runs completed since the given time that
+// passed. Aborted runs are not counted.
+func passRate(runs []prowapi.ProwJob, since time.Time) (string, string) {
+	var passed, total int
+	for _, run := range runs {
+		if run.Status.CompletionTime == nil || run.Status.CompletionTime.Time.Before(since) {
+			continue
+		}
+		switch run.Status.State {
+		case prowapi.SuccessState:
+			passed++
+		case prowapi.FailureState, prowapi.ErrorState:
+		default:
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return "no results", "darkgrey"
+	}
+	rate := 100 * passed / total
+	color := "red"
+	switch {
+	case rate >= 95:
+		color = "brightgreen"
+	case rate >= 80:
+		color = "yellow"
+	}
+	return fmt.Sprintf("%d%% passing", rate), color
+}
+
+// shieldsEndpoint is the response format of shields.io endpoint badges, see
+// https://shields.io/badges/endpoint-badge.
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	CacheSeconds  int    `json:"cacheSeconds,omitempty"`
+}
+
 func renderBadge(jobs []prowapi.ProwJob) (string, string, []byte) {
 	color := "brightgreen"
 	status := "passing"
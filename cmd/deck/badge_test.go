@@ -18,9 +18,12 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
@@ -82,3 +85,129 @@ func TestRenderBadge(t *testing.T) {
 		}
 	}
 }
+
+func TestParseBadgeQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		query     string
+		expected  badgeQuery
+		expectErr bool
+	}{
+		{
+			name:     "jobs",
+			query:    "jobs=ci-*,post-test",
+			expected: badgeQuery{jobs: "ci-*,post-test"},
+		},
+		{
+			name:     "single job with branch",
+			query:    "job=post-test&branch=release-1.0",
+			expected: badgeQuery{jobs: "post-test", branch: "release-1.0"},
+		},
+		{
+			name:     "window in days",
+			query:    "job=post-test&window=7d",
+			expected: badgeQuery{jobs: "post-test", window: 7 * 24 * time.Hour, rawWindow: "7d"},
+		},
+		{
+			name:     "window as duration",
+			query:    "job=post-test&window=12h",
+			expected: badgeQuery{jobs: "post-test", window: 12 * time.Hour, rawWindow: "12h"},
+		},
+		{
+			name:      "missing jobs",
+			query:     "branch=main",
+			expectErr: true,
+		},
+		{
+			name:      "invalid window",
+			query:     "job=post-test&window=-1d",
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := parseBadgeQuery(values)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, q, cmp.AllowUnexported(badgeQuery{})); diff != "" {
+				t.Errorf("unexpected query (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBadgeFor(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	run := func(job, branch string, state prowapi.ProwJobState, age time.Duration) prowapi.ProwJob {
+		started := metav1.NewTime(now.Add(-age - time.Minute))
+		completed := metav1.NewTime(now.Add(-age))
+		pj := prowapi.ProwJob{
+			Spec:   prowapi.ProwJobSpec{Job: job, Refs: &prowapi.Refs{BaseRef: branch}},
+			Status: prowapi.ProwJobStatus{State: state, StartTime: started, CompletionTime: &completed},
+		}
+		if state == prowapi.PendingState {
+			pj.Status.CompletionTime = nil
+		}
+		return pj
+	}
+	jobs := []prowapi.ProwJob{
+		run("post-test", "main", prowapi.SuccessState, time.Hour),
+		run("post-test", "main", prowapi.FailureState, 2*time.Hour),
+		run("post-test", "main", prowapi.SuccessState, 3*time.Hour),
+		run("post-test", "main", prowapi.SuccessState, 4*time.Hour),
+		run("post-test", "main", prowapi.AbortedState, 5*time.Hour),
+		run("post-test", "main", prowapi.FailureState, 48*time.Hour),
+		run("post-test", "release-1.0", prowapi.FailureState, 30*time.Minute),
+		run("post-test", "release-1.0", prowapi.PendingState, 0),
+	}
+	for _, tc := range []struct {
+		name            string
+		query           badgeQuery
+		expectedSubject string
+		expectedStatus  string
+		expectedColor   string
+	}{
+		{
+			name:            "latest run of any branch",
+			query:           badgeQuery{jobs: "post-test"},
+			expectedSubject: "build",
+			expectedStatus:  "passing",
+			expectedColor:   "brightgreen",
+		},
+		{
+			name:            "latest run of a branch",
+			query:           badgeQuery{jobs: "post-test", branch: "main"},
+			expectedSubject: "build",
+			expectedStatus:  "passing",
+			expectedColor:   "brightgreen",
+		},
+		{
+			name:            "pass rate of a branch",
+			query:           badgeQuery{jobs: "post-test", branch: "main", window: 24 * time.Hour, rawWindow: "1d"},
+			expectedSubject: "build (1d)",
+			expectedStatus:  "75% passing",
+			expectedColor:   "red",
+		},
+		{
+			name:            "pass rate without runs in the window",
+			query:           badgeQuery{jobs: "post-test", branch: "release-1.0", window: time.Minute, rawWindow: "1m"},
+			expectedSubject: "build (1m)",
+			expectedStatus:  "no results",
+			expectedColor:   "darkgrey",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			subject, status, color := badgeFor(jobs, tc.query, now)
+			if diff := cmp.Diff([]string{tc.expectedSubject, tc.expectedStatus, tc.expectedColor}, []string{subject, status, color}); diff != "" {
+				t.Errorf("unexpected badge (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
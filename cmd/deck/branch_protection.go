@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	// tideStatusContext is the context Tide reports on the PRs it considers.
+	tideStatusContext = "tide"
+	// branchProtectionTTL is how long the branch protections and repos read
+	// from GitHub are reused, so that reloading the page doesn't use up the
+	// API tokens.
+	branchProtectionTTL = 5 * time.Minute
+)
+
+type branchProtectionClient interface {
+	GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error)
+	GetRepo(owner, name string) (github.FullRepo, error)
+}
+
+type cachedBranchProtection struct {
+	protection *github.BranchProtection
+	read       time.Time
+}
+
+type cachedRepo struct {
+	repo github.FullRepo
+	read time.Time
+}
+
+// cachingBranchProtectionClient reuses the branch protections and repos
+// read from GitHub for branchProtectionTTL. Errors aren't cached.
+type cachingBranchProtectionClient struct {
+	ghc branchProtectionClient
+	now func() time.Time
+
+	lock        sync.Mutex
+	protections map[string]cachedBranchProtection
+	repos       map[string]cachedRepo
+}
+
+func newCachingBranchProtectionClient(ghc branchProtectionClient) *cachingBranchProtectionClient {
+	return &cachingBranchProtectionClient{
+		ghc:         ghc,
+		now:         time.Now,
+		protections: map[string]cachedBranchProtection{},
+		repos:       map[string]cachedRepo{},
+	}
+}
+
+func (c *cachingBranchProtectionClient) GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error) {
+	key := org + "/" + repo + "=" + branch
+	c.lock.Lock()
+	cached, ok := c.protections[key]
+	c.lock.Unlock()
+	if ok && c.now().Sub(cached.read) < branchProtectionTTL {
+		return cached.protection, nil
+	}
+	protection, err := c.ghc.GetBranchProtection(org, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k, v := range c.protections {
+		if c.now().Sub(v.read) >= branchProtectionTTL {
+			delete(c.protections, k)
+		}
+	}
+	c.protections[key] = cachedBranchProtection{protection: protection, read: c.now()}
+	return protection, nil
+}
+
+func (c *cachingBranchProtectionClient) GetRepo(owner, name string) (github.FullRepo, error) {
+	key := owner + "/" + name
+	c.lock.Lock()
+	cached, ok := c.repos[key]
+	c.lock.Unlock()
+	if ok && c.now().Sub(cached.read) < branchProtectionTTL {
+		return cached.repo, nil
+	}
+	repo, err := c.ghc.GetRepo(owner, name)
+	if err != nil {
+		return repo, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k, v := range c.repos {
+		if c.now().Sub(v.read) >= branchProtectionTTL {
+			delete(c.repos, k)
+		}
+	}
+	c.repos[key] = cachedRepo{repo: repo, read: c.now()}
+	return repo, nil
+}
+
+// branchProtectionTemplate is the data rendered by branch-protection.html.
+type branchProtectionTemplate struct {
+	Org        string
+	Repo       string
+	Branches   []branchProtectionDiagnostic
+	Mismatched int
+}
+
+// branchProtectionDiagnostic is the result of cross-checking the branch
+// protection of a single branch on GitHub against what Prow produces and
+// what Tide requires for it.
+type branchProtectionDiagnostic struct {
+	Org    string
+	Repo   string
+	Branch string
+	// Protected is true if the branch is protected on GitHub.
+	Protected bool
+	// TideManaged is true if any Tide query merges into the branch.
+	TideManaged bool
+	// GitHubRequired are the contexts required by the branch protection.
+	GitHubRequired []string
+	// TideRequired are the contexts Tide requires before merging.
+	TideRequired []string
+	// NeverProduced are contexts required on GitHub that neither a presubmit
+	// nor Tide report, and which are not listed in the Tide context options.
+	// PRs can never be merged while they are required.
+	NeverProduced []string
+	// Conditional are contexts required on GitHub that are only reported on
+	// some PRs, because the job is optional or only runs conditionally.
+	Conditional []string
+	// NotEnforced are contexts Tide requires but GitHub does not, so they
+	// can be bypassed by merging manually.
+	NotEnforced []string
+	// Error is set if the branch could not be checked.
+	Error string
+}
+
+// Mismatched returns true if the branch has any mismatches or could not
+// be checked.
+func (d branchProtectionDiagnostic) Mismatched() bool {
+	return d.Error != "" || len(d.NeverProduced) > 0 || len(d.Conditional) > 0 || len(d.NotEnforced) > 0
+}
+
+// handleBranchProtection handles requests to cross-check branch protection
+// on GitHub against the configured presubmits and Tide queries. Only the
+// repos and branches configured in Prow can be checked, hidden repos only
+// by a deck showing hidden jobs, and the responses of GitHub are cached by
+// the client passed in.
+// The url must look like this:
+//
+// /branch-protection?org=<org>[&repo=<repo>][&branch=<branch>]
+func handleBranchProtection(o options, cfg config.Getter, ghc branchProtectionClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		tmpl, err := getBranchProtectionDiagnostics(r.URL, cfg(), ghc, o.hiddenOnly, o.showHidden)
+		if err != nil {
+			msg := fmt.Sprintf("failed to check branch protection: %v", err)
+			log.WithField("url", r.URL.String()).Info(msg)
+			http.Error(w, msg, httpStatusForError(err))
+			return
+		}
+		handleSimpleTemplate(o, cfg, "branch-protection.html", tmpl)(w, r)
+	}
+}
+
+func getBranchProtectionDiagnostics(u *url.URL, cfg *config.Config, ghc branchProtectionClient, hiddenOnly, showHidden bool) (branchProtectionTemplate, error) {
+	org := u.Query().Get("org")
+	if org == "" {
+		return branchProtectionTemplate{}, httpError{error: errors.New("no org specified"), statusCode: http.StatusBadRequest}
+	}
+	tmpl := branchProtectionTemplate{Org: org, Repo: u.Query().Get("repo")}
+
+	repos := configuredRepos(cfg, org, hiddenOnly, showHidden)
+	if tmpl.Repo != "" {
+		// Hidden repos are reported like unknown ones, so that their
+		// existence isn't revealed.
+		if !sets.New(repos...).Has(tmpl.Repo) {
+			return branchProtectionTemplate{}, httpError{error: fmt.Errorf("%s/%s isn't configured in Prow", org, tmpl.Repo), statusCode: http.StatusNotFound}
+		}
+		repos = []string{tmpl.Repo}
+	}
+	queries := cfg.Tide.Queries.QueryMap()
+	for _, repo := range repos {
+		var branches []string
+		if branch := u.Query().Get("branch"); branch != "" {
+			if !tideMerges(queries, org, repo, branch) && !sets.New(configuredBranches(cfg, queries, org, repo)...).Has(branch) {
+				return branchProtectionTemplate{}, httpError{error: fmt.Errorf("branch %s of %s/%s isn't configured in Prow", branch, org, repo), statusCode: http.StatusNotFound}
+			}
+			branches = []string{branch}
+		} else {
+			branches = configuredBranches(cfg, queries, org, repo)
+		}
+		if len(branches) == 0 {
+			fullRepo, err := ghc.GetRepo(org, repo)
+			if err != nil {
+				tmpl.Branches = append(tmpl.Branches, branchProtectionDiagnostic{
+					Org:   org,
+					Repo:  repo,
+					Error: fmt.Sprintf("failed to get default branch: %v", err),
+				})
+				continue
+			}
+			branches = []string{fullRepo.DefaultBranch}
+		}
+		for _, branch := range branches {
+			protection, err := ghc.GetBranchProtection(org, repo, branch)
+			if err != nil {
+				tmpl.Branches = append(tmpl.Branches, branchProtectionDiagnostic{
+					Org:    org,
+					Repo:   repo,
+					Branch: branch,
+					Error:  fmt.Sprintf("failed to get branch protection: %v", err),
+				})
+				continue
+			}
+			tmpl.Branches = append(tmpl.Branches, diagnoseBranchProtection(cfg, queries, org, repo, branch, protection))
+		}
+	}
+	for _, branch := range tmpl.Branches {
+		if branch.Mismatched() {
+			tmpl.Mismatched++
+		}
+	}
+	return tmpl, nil
+}
+
+// configuredRepos returns the repos of the org that have presubmits, are
+// merged by Tide or have a branch protection policy. Like for the Tide
+// pages, repos in deck.hidden_repos are only returned if hidden jobs are
+// shown and the others only unless only hidden jobs are shown. The repos of
+// restricted tenants are never returned.
+func configuredRepos(cfg *config.Config, org string, hiddenOnly, showHidden bool) []string {
+	repos := sets.New[string]()
+	for orgRepo := range cfg.PresubmitsStatic {
+		if repoOrg, repo, found := strings.Cut(orgRepo, "/"); found && repoOrg == org {
+			repos.Insert(repo)
+		}
+	}
+	for _, query := range cfg.Tide.Queries {
+		for _, orgRepo := range query.Repos {
+			if repoOrg, repo, found := strings.Cut(orgRepo, "/"); found && repoOrg == org {
+				repos.Insert(repo)
+			}
+		}
+	}
+	for repo := range cfg.BranchProtection.Orgs[org].Repos {
+		repos.Insert(repo)
+	}
+	restricted := restrictedTenants(cfg)
+	for _, repo := range sets.List(repos) {
+		hidden := matches(org+"/"+repo, cfg.Deck.HiddenRepos)
+		if (hidden && !hiddenOnly && !showHidden) || (!hidden && hiddenOnly) ||
+			slices.ContainsFunc(restricted, func(tenant config.DeckTenant) bool { return tenant.HasRepo(org + "/" + repo) }) {
+			repos.Delete(repo)
+		}
+	}
+	return sets.List(repos)
+}
+
+// configuredBranches returns the branches of the repo that have a branch
+// protection policy or are explicitly included in a Tide query.
+func configuredBranches(cfg *config.Config, queries *config.QueryMap, org, repo string) []string {
+	branches := sets.New[string]()
+	for branch := range cfg.BranchProtection.Orgs[org].Repos[repo].Branches {
+		branches.Insert(branch)
+	}
+	for _, query := range queries.ForRepo(config.OrgRepo{Org: org, Repo: repo}) {
+		branches.Insert(query.IncludedBranches...)
+	}
+	return sets.List(branches)
+}
+
+// tideMerges returns true if any of the Tide queries merges into the branch.
+func tideMerges(queries *config.QueryMap, org, repo, branch string) bool {
+	for _, query := range queries.ForRepo(config.OrgRepo{Org: org, Repo: repo}) {
+		if sets.New[string](query.ExcludedBranches...).Has(branch) {
+			continue
+		}
+		if len(query.IncludedBranches) > 0 && !sets.New[string](query.IncludedBranches...).Has(branch) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// diagnoseBranchProtection cross-checks the protection of a branch on GitHub
+// with the statically configured presubmits and the Tide context policy.
+// Presubmits from inrepoconfig can only be resolved for a given PR and are
+// not considered.
+func diagnoseBranchProtection(cfg *config.Config, queries *config.QueryMap, org, repo, branch string, protection *github.BranchProtection) branchProtectionDiagnostic {
+	diagnostic := branchProtectionDiagnostic{
+		Org:         org,
+		Repo:        repo,
+		Branch:      branch,
+		Protected:   protection != nil,
+		TideManaged: tideMerges(queries, org, repo, branch),
+	}
+	githubRequired := sets.New[string]()
+	if protection != nil && protection.RequiredStatusChecks != nil {
+		githubRequired.Insert(protection.RequiredStatusChecks.Contexts...)
+	}
+
+	presubmits := cfg.GetPresubmitsStatic(org + "/" + repo)
+	options := config.ParseTideContextPolicyOptions(org, repo, branch, cfg.Tide.ContextOptions)
+	var requireManuallyTriggeredJobs *bool
+	tideRequired := sets.New[string](options.RequiredContexts...)
+	if options.FromBranchProtection != nil && *options.FromBranchProtection {
+		policy, err := cfg.GetBranchProtection(org, repo, branch, presubmits)
+		if err != nil {
+			diagnostic.Error = fmt.Sprintf("invalid branch protection policy: %v", err)
+		} else if policy != nil {
+			requireManuallyTriggeredJobs = policy.RequireManuallyTriggeredJobs
+			if policy.Protect != nil && *policy.Protect && policy.RequiredStatusChecks != nil {
+				tideRequired.Insert(policy.RequiredStatusChecks.Contexts...)
+			}
+		}
+	}
+	prowRequired, prowRequiredIfPresent, prowOptional := config.BranchRequirements(branch, presubmits, requireManuallyTriggeredJobs)
+	tideRequired.Insert(prowRequired...)
+
+	known := sets.New[string](prowRequired...).Insert(prowRequiredIfPresent...).Insert(prowOptional...).
+		Insert(options.RequiredContexts...).Insert(options.RequiredIfPresentContexts...).Insert(options.OptionalContexts...)
+	if diagnostic.TideManaged {
+		known.Insert(tideStatusContext)
+	}
+	sometimes := sets.New[string](prowRequiredIfPresent...).Insert(prowOptional...).Delete(prowRequired...)
+
+	diagnostic.GitHubRequired = sets.List(githubRequired)
+	diagnostic.TideRequired = sets.List(tideRequired)
+	diagnostic.NeverProduced = sets.List(githubRequired.Difference(known))
+	diagnostic.Conditional = sets.List(githubRequired.Intersection(sometimes))
+	diagnostic.NotEnforced = sets.List(tideRequired.Difference(githubRequired))
+	return diagnostic
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+)
+
+func branchProtectionTestConfig() *config.Config {
+	presubmit := func(name string, alwaysRun, optional bool, runIfChanged string) config.Presubmit {
+		return config.Presubmit{
+			JobBase:             config.JobBase{Name: name},
+			AlwaysRun:           alwaysRun,
+			Optional:            optional,
+			Reporter:            config.Reporter{Context: name},
+			RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: runIfChanged},
+		}
+	}
+	return &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo": {
+					presubmit("unit", true, false, ""),
+					presubmit("lint", true, true, ""),
+					presubmit("e2e", false, false, "^api/"),
+					presubmit("manual", false, false, ""),
+				},
+				"org/other": {
+					presubmit("unit", true, false, ""),
+				},
+				"another-org/repo": {
+					presubmit("unit", true, false, ""),
+				},
+			},
+		},
+		ProwConfig: config.ProwConfig{
+			Tide: config.Tide{
+				TideGitHubConfig: config.TideGitHubConfig{
+					Queries: config.TideQueries{
+						{Repos: []string{"org/repo"}, ExcludedBranches: []string{"legacy"}},
+						{Repos: []string{"org/merged"}, IncludedBranches: []string{"main", "release-1.0"}},
+					},
+					ContextOptions: config.TideContextPolicyOptions{
+						TideContextPolicy: config.TideContextPolicy{
+							RequiredContexts: []string{"cla"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiagnoseBranchProtection(t *testing.T) {
+	testCases := []struct {
+		name       string
+		repo       string
+		branch     string
+		protection *github.BranchProtection
+		expected   branchProtectionDiagnostic
+	}{
+		{
+			name:   "branch protection matches",
+			repo:   "repo",
+			branch: "main",
+			protection: &github.BranchProtection{
+				RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"cla", "tide", "unit"}},
+			},
+			expected: branchProtectionDiagnostic{
+				Org:            "org",
+				Repo:           "repo",
+				Branch:         "main",
+				Protected:      true,
+				TideManaged:    true,
+				GitHubRequired: []string{"cla", "tide", "unit"},
+				TideRequired:   []string{"cla", "unit"},
+			},
+		},
+		{
+			name:   "contexts required on GitHub but never produced",
+			repo:   "repo",
+			branch: "main",
+			protection: &github.BranchProtection{
+				RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"cla", "removed-job", "unit"}},
+			},
+			expected: branchProtectionDiagnostic{
+				Org:            "org",
+				Repo:           "repo",
+				Branch:         "main",
+				Protected:      true,
+				TideManaged:    true,
+				GitHubRequired: []string{"cla", "removed-job", "unit"},
+				TideRequired:   []string{"cla", "unit"},
+				NeverProduced:  []string{"removed-job"},
+			},
+		},
+		{
+			name:   "optional and conditional contexts required on GitHub",
+			repo:   "repo",
+			branch: "main",
+			protection: &github.BranchProtection{
+				RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"cla", "e2e", "lint", "manual", "unit"}},
+			},
+			expected: branchProtectionDiagnostic{
+				Org:            "org",
+				Repo:           "repo",
+				Branch:         "main",
+				Protected:      true,
+				TideManaged:    true,
+				GitHubRequired: []string{"cla", "e2e", "lint", "manual", "unit"},
+				TideRequired:   []string{"cla", "unit"},
+				Conditional:    []string{"e2e", "lint", "manual"},
+			},
+		},
+		{
+			name:   "unprotected branch does not enforce required contexts",
+			repo:   "repo",
+			branch: "main",
+			expected: branchProtectionDiagnostic{
+				Org:          "org",
+				Repo:         "repo",
+				Branch:       "main",
+				TideManaged:  true,
+				TideRequired: []string{"cla", "unit"},
+				NotEnforced:  []string{"cla", "unit"},
+			},
+		},
+		{
+			name:   "tide context is not produced on excluded branches",
+			repo:   "repo",
+			branch: "legacy",
+			protection: &github.BranchProtection{
+				RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"cla", "tide", "unit"}},
+			},
+			expected: branchProtectionDiagnostic{
+				Org:            "org",
+				Repo:           "repo",
+				Branch:         "legacy",
+				Protected:      true,
+				GitHubRequired: []string{"cla", "tide", "unit"},
+				TideRequired:   []string{"cla", "unit"},
+				NeverProduced:  []string{"tide"},
+			},
+		},
+	}
+
+	cfg := branchProtectionTestConfig()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := diagnoseBranchProtection(cfg, cfg.Tide.Queries.QueryMap(), "org", tc.repo, tc.branch, tc.protection)
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected diagnostic (-want +got):\n%s", diff)
+			}
+			if expected := len(tc.expected.NeverProduced)+len(tc.expected.Conditional)+len(tc.expected.NotEnforced) > 0; actual.Mismatched() != expected {
+				t.Errorf("expected mismatched to be %t, got %t", expected, actual.Mismatched())
+			}
+		})
+	}
+}
+
+func TestGetBranchProtectionDiagnostics(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		hiddenOnly bool
+		showHidden bool
+		expected   []string
+		expStatus  int
+	}{
+		{
+			name:      "org is required",
+			query:     "repo=repo",
+			expStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "all configured repos and branches of the org",
+			query:    "org=org",
+			expected: []string{"org/merged=main", "org/merged=release-1.0", "org/repo=master"},
+		},
+		{
+			name:     "single repo",
+			query:    "org=org&repo=merged",
+			expected: []string{"org/merged=main", "org/merged=release-1.0"},
+		},
+		{
+			name:     "single branch",
+			query:    "org=org&repo=repo&branch=release-1.0",
+			expected: []string{"org/repo=release-1.0"},
+		},
+		{
+			name:      "repo that isn't configured",
+			query:     "org=org&repo=unknown",
+			expStatus: http.StatusNotFound,
+		},
+		{
+			name:      "branch that isn't configured",
+			query:     "org=org&repo=merged&branch=dev",
+			expStatus: http.StatusNotFound,
+		},
+		{
+			name:      "hidden repo",
+			query:     "org=org&repo=other",
+			expStatus: http.StatusNotFound,
+		},
+		{
+			name:     "hidden org",
+			query:    "org=another-org",
+			expected: nil,
+		},
+		{
+			name:       "hidden repo with hidden jobs shown",
+			query:      "org=org&repo=other",
+			showHidden: true,
+			expected:   []string{"org/other=master"},
+		},
+		{
+			name:       "only hidden repos",
+			query:      "org=org",
+			hiddenOnly: true,
+			expected:   []string{"org/other=master"},
+		},
+	}
+
+	cfg := branchProtectionTestConfig()
+	cfg.Deck.HiddenRepos = []string{"org/other", "another-org"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := fakegithub.NewFakeClient()
+			ghc.BranchProtections = map[string]*github.BranchProtection{
+				"org/repo=master": {RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"unit"}}},
+			}
+			u, err := url.Parse("/branch-protection?" + tc.query)
+			if err != nil {
+				t.Fatalf("failed to parse URL: %v", err)
+			}
+			tmpl, err := getBranchProtectionDiagnostics(u, cfg, ghc, tc.hiddenOnly, tc.showHidden)
+			if (err != nil) != (tc.expStatus != 0) {
+				t.Fatalf("expected error with status %d, got %v", tc.expStatus, err)
+			}
+			if err != nil && httpStatusForError(err) != tc.expStatus {
+				t.Errorf("expected status %d, got %d", tc.expStatus, httpStatusForError(err))
+			}
+			var actual []string
+			for _, branch := range tmpl.Branches {
+				actual = append(actual, branch.Org+"/"+branch.Repo+"="+branch.Branch)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected branches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfiguredReposLeaveOutRestrictedTenants(t *testing.T) {
+	cfg := branchProtectionTestConfig()
+	cfg.Deck.Tenants = []config.DeckTenant{
+		{Name: "restricted", Repos: []string{"org/other"}, Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+		{Name: "public", Repos: []string{"org/merged"}},
+	}
+	if diff := cmp.Diff([]string{"merged", "repo"}, configuredRepos(cfg, "org", false, false)); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+}
+
+type countingBranchProtectionClient struct {
+	*fakegithub.FakeClient
+	calls int
+	err   error
+}
+
+func (c *countingBranchProtectionClient) GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.FakeClient.GetBranchProtection(org, repo, branch)
+}
+
+func TestCachingBranchProtectionClient(t *testing.T) {
+	ghc := &countingBranchProtectionClient{FakeClient: fakegithub.NewFakeClient()}
+	ghc.BranchProtections = map[string]*github.BranchProtection{
+		"org/repo=master": {RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"unit"}}},
+	}
+	now := time.Now()
+	client := newCachingBranchProtectionClient(ghc)
+	client.now = func() time.Time { return now }
+
+	get := func(expectedCalls int) {
+		t.Helper()
+		if _, err := client.GetBranchProtection("org", "repo", "master"); err != nil {
+			t.Fatalf("failed to get the branch protection: %v", err)
+		}
+		if ghc.calls != expectedCalls {
+			t.Errorf("expected %d calls to GitHub, got %d", expectedCalls, ghc.calls)
+		}
+	}
+	get(1)
+	now = now.Add(branchProtectionTTL - time.Second)
+	get(1)
+	now = now.Add(time.Second)
+	get(2)
+
+	ghc.err = errors.New("rate limited")
+	if _, err := client.GetBranchProtection("org", "repo", "main"); err == nil {
+		t.Fatal("expected the error of GitHub to be returned")
+	}
+	ghc.err = nil
+	if _, err := client.GetBranchProtection("org", "repo", "main"); err != nil {
+		t.Fatalf("expected the error not to be cached, got %v", err)
+	}
+	if ghc.calls != 4 {
+		t.Errorf("expected 4 calls to GitHub, got %d", ghc.calls)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+)
+
+// concurrencyBudgetsTemplate is the data rendered by concurrency-budgets.html.
+type concurrencyBudgetsTemplate struct {
+	Budgets []config.ConcurrencyBudgetUsage
+}
+
+// handleConcurrencyBudgets shows how many of the jobs allowed by the
+// concurrency budgets of every org and repo are running.
+func handleConcurrencyBudgets(o options, cfg config.Getter, ja *jobs.JobAgent) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		tmpl := concurrencyBudgetsTemplate{Budgets: cfg().Plank.ConcurrencyBudgetUsage(ja.ProwJobs())}
+		handleSimpleTemplate(o, cfg, "concurrency-budgets.html", tmpl)(w, r)
+	}
+}
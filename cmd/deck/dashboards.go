@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+)
+
+// dashboardRun is a run of a job, shown as a cell of the grid of a
+// dashboard.
+type dashboardRun struct {
+	BuildID string
+	State   prowapi.ProwJobState
+	Started time.Time
+	URL     string
+}
+
+// dashboardJob is a row of the grid of a dashboard, with the most recent
+// runs of the job first.
+type dashboardJob struct {
+	Name string
+	Runs []dashboardRun
+}
+
+// dashboardGrid is a dashboard with the recent runs of its jobs.
+type dashboardGrid struct {
+	Name        string
+	Description string
+	Jobs        []dashboardJob
+}
+
+// dashboardSummary is a dashboard in the list of dashboards, with how many
+// of its jobs passed and failed their last completed run.
+type dashboardSummary struct {
+	Name        string
+	Description string
+	Jobs        int
+	Passing     int
+	Failing     int
+}
+
+// dashboardsTemplate is the data rendered by dashboards.html. Either the
+// list of dashboards or a single dashboard is set.
+type dashboardsTemplate struct {
+	Dashboards []dashboardSummary
+	Dashboard  *dashboardGrid
+}
+
+// buildDashboard returns the grid of the dashboard from the configured
+// periodics and their ProwJobs.
+func buildDashboard(dashboard config.Dashboard, periodics []config.Periodic, pjs []prowapi.ProwJob) dashboardGrid {
+	grid := dashboardGrid{Name: dashboard.Name, Description: dashboard.Description}
+	runs := map[string][]dashboardRun{}
+	for _, periodic := range periodics {
+		if dashboard.HasJob(periodic.Name) {
+			runs[periodic.Name] = nil
+		}
+	}
+	for _, pj := range pjs {
+		if pj.Spec.Type != prowapi.PeriodicJob {
+			continue
+		}
+		if _, ok := runs[pj.Spec.Job]; !ok {
+			continue
+		}
+		runs[pj.Spec.Job] = append(runs[pj.Spec.Job], dashboardRun{
+			BuildID: pj.Status.BuildID,
+			State:   pj.Status.State,
+			Started: pj.Status.StartTime.Time,
+			URL:     pj.Status.URL,
+		})
+	}
+	for name, jobRuns := range runs {
+		sort.SliceStable(jobRuns, func(i, j int) bool { return jobRuns[i].Started.After(jobRuns[j].Started) })
+		if dashboard.MaxRuns > 0 && len(jobRuns) > dashboard.MaxRuns {
+			jobRuns = jobRuns[:dashboard.MaxRuns]
+		}
+		grid.Jobs = append(grid.Jobs, dashboardJob{Name: name, Runs: jobRuns})
+	}
+	sort.Slice(grid.Jobs, func(i, j int) bool { return grid.Jobs[i].Name < grid.Jobs[j].Name })
+	return grid
+}
+
+// summarize counts the jobs of the dashboard by the result of their last
+// completed run.
+func (g dashboardGrid) summarize() dashboardSummary {
+	summary := dashboardSummary{Name: g.Name, Description: g.Description, Jobs: len(g.Jobs)}
+	for _, job := range g.Jobs {
+	runs:
+		for _, run := range job.Runs {
+			switch run.State {
+			case prowapi.SuccessState:
+				summary.Passing++
+				break runs
+			case prowapi.FailureState, prowapi.ErrorState:
+				summary.Failing++
+				break runs
+			}
+		}
+	}
+	return summary
+}
+
+// handleDashboards shows the configured dashboards, or the grid of the
+// dashboard given by the dashboard query parameter. The jobs of restricted
+// tenants are left out.
+//
+// /dashboards[?dashboard=<name>]
+func handleDashboards(o options, cfg config.Getter, ja *jobs.JobAgent) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		c := cfg()
+		restricted := restrictedTenants(c)
+		periodics := filterRestrictedPeriodics(restricted, c.AllPeriodics())
+		pjs := filterRestrictedProwJobs(restricted, ja.ProwJobs())
+		var tmpl dashboardsTemplate
+		if name := r.URL.Query().Get("dashboard"); name != "" {
+			for _, dashboard := range c.Deck.Dashboards {
+				if dashboard.Name == name {
+					grid := buildDashboard(dashboard, periodics, pjs)
+					tmpl.Dashboard = &grid
+					break
+				}
+			}
+			if tmpl.Dashboard == nil {
+				http.Error(w, fmt.Sprintf("dashboard %q not found", name), http.StatusNotFound)
+				return
+			}
+		} else {
+			for _, dashboard := range c.Deck.Dashboards {
+				tmpl.Dashboards = append(tmpl.Dashboards, buildDashboard(dashboard, periodics, pjs).summarize())
+			}
+		}
+		handleSimpleTemplate(o, cfg, "dashboards.html", tmpl)(w, r)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestBuildDashboard(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	run := func(job string, typ prowapi.ProwJobType, build int, state prowapi.ProwJobState) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{Type: typ, Job: job},
+			Status: prowapi.ProwJobStatus{
+				BuildID:   string(rune('0' + build)),
+				State:     state,
+				StartTime: metav1.NewTime(start.Add(time.Duration(build) * time.Hour)),
+				URL:       "https://prow.example.com/view/" + job,
+			},
+		}
+	}
+	periodics := []config.Periodic{
+		{JobBase: config.JobBase{Name: "ci-e2e-aws"}},
+		{JobBase: config.JobBase{Name: "ci-e2e-gce"}},
+		{JobBase: config.JobBase{Name: "ci-e2e-new"}},
+		{JobBase: config.JobBase{Name: "ci-unit"}},
+	}
+	pjs := []prowapi.ProwJob{
+		run("ci-e2e-aws", prowapi.PeriodicJob, 1, prowapi.FailureState),
+		run("ci-e2e-aws", prowapi.PeriodicJob, 3, prowapi.SuccessState),
+		run("ci-e2e-aws", prowapi.PeriodicJob, 2, prowapi.SuccessState),
+		run("ci-e2e-gce", prowapi.PeriodicJob, 1, prowapi.FailureState),
+		run("ci-e2e-gce", prowapi.PeriodicJob, 2, prowapi.PendingState),
+		run("ci-e2e-gce", prowapi.PostsubmitJob, 3, prowapi.SuccessState),
+		run("ci-unit", prowapi.PeriodicJob, 1, prowapi.SuccessState),
+	}
+	dashboard := config.Dashboard{Name: "e2e", Description: "End to end tests", Jobs: []string{"ci-e2e-*"}, MaxRuns: 2}
+
+	grid := buildDashboard(dashboard, periodics, pjs)
+	expected := dashboardGrid{
+		Name:        "e2e",
+		Description: "End to end tests",
+		Jobs: []dashboardJob{
+			{Name: "ci-e2e-aws", Runs: []dashboardRun{
+				{BuildID: "3", State: prowapi.SuccessState, Started: start.Add(3 * time.Hour), URL: "https://prow.example.com/view/ci-e2e-aws"},
+				{BuildID: "2", State: prowapi.SuccessState, Started: start.Add(2 * time.Hour), URL: "https://prow.example.com/view/ci-e2e-aws"},
+			}},
+			{Name: "ci-e2e-gce", Runs: []dashboardRun{
+				{BuildID: "2", State: prowapi.PendingState, Started: start.Add(2 * time.Hour), URL: "https://prow.example.com/view/ci-e2e-gce"},
+				{BuildID: "1", State: prowapi.FailureState, Started: start.Add(time.Hour), URL: "https://prow.example.com/view/ci-e2e-gce"},
+			}},
+			{Name: "ci-e2e-new"},
+		},
+	}
+	if diff := cmp.Diff(expected, grid); diff != "" {
+		t.Errorf("unexpected dashboard (-want +got):\n%s", diff)
+	}
+
+	expectedSummary := dashboardSummary{Name: "e2e", Description: "End to end tests", Jobs: 3, Passing: 1, Failing: 1}
+	if diff := cmp.Diff(expectedSummary, grid.summarize()); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// staleJobConfigShard is a job config shard whose files can't be read.
+type staleJobConfigShard struct {
+	Shard string
+	Error string
+}
+
+// jobConfigShardsTemplate is the data rendered by job-config-shards.html.
+type jobConfigShardsTemplate struct {
+	Sharded bool
+	Stale   []staleJobConfigShard
+}
+
+// handleJobConfigShards shows the job config shards for which the last
+// version that could be read is used, and why they can't be read.
+func handleJobConfigShards(o options, cfg config.Getter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		c := cfg()
+		tmpl := jobConfigShardsTemplate{Sharded: c.ShardJobConfig}
+		for shard, err := range c.StaleShards {
+			tmpl.Stale = append(tmpl.Stale, staleJobConfigShard{Shard: shard, Error: err})
+		}
+		sort.Slice(tmpl.Stale, func(i, j int) bool { return tmpl.Stale[i].Shard < tmpl.Stale[j].Shard })
+		handleSimpleTemplate(o, cfg, "job-config-shards.html", tmpl)(w, r)
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/cache"
+	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+const (
+	// defaultStatsRuns is the number of most recent runs aggregated unless
+	// the runs parameter is given.
+	defaultStatsRuns = 500
+	maxStatsRuns     = 5000
+
+	defaultStatsPageSize = 50
+	maxStatsPageSize     = 500
+
+	// buildIDsTTL is how long the listed build IDs of a job are reused.
+	// Listing is the slow part for jobs with thousands of runs.
+	buildIDsTTL = 2 * time.Minute
+	// buildCacheSize is the number of finished builds whose data is cached.
+	// Finished builds don't change, so they are only evicted for space.
+	buildCacheSize = 100000
+	// statsConcurrency is the number of builds read in parallel.
+	statsConcurrency = 32
+)
+
+// JobHistoryStats is the aggregated history of a job.
+type JobHistoryStats struct {
+	Name string
+	// TotalRuns is the number of runs of the job in the bucket.
+	TotalRuns int
+	// Summary aggregates the most recent runs.
+	Summary JobHistoryAggregate
+	// Trend aggregates the same runs per day, oldest first.
+	Trend []JobHistoryTrendPoint
+	// Builds are the runs on the page, most recent first.
+	Builds   []buildData
+	Page     int
+	PageSize int
+	Pages    int
+}
+
+// JobHistoryAggregate summarizes a set of runs. Durations are only
+// aggregated for finished runs.
+type JobHistoryAggregate struct {
+	Runs    int
+	Passed  int
+	Failed  int
+	Aborted int
+	Pending int
+	// PassRate is the share of passed runs of the passed and failed runs,
+	// or 0 if there are none.
+	PassRate    float64
+	DurationP50 time.Duration
+	DurationP90 time.Duration
+	DurationP99 time.Duration
+}
+
+// JobHistoryTrendPoint aggregates the runs that started on a day (UTC).
+type JobHistoryTrendPoint struct {
+	Date string
+	JobHistoryAggregate
+}
+
+func aggregateBuilds(builds []buildData) JobHistoryAggregate {
+	agg := JobHistoryAggregate{Runs: len(builds)}
+	var durations []time.Duration
+	for _, b := range builds {
+		switch strings.ToUpper(b.Result) {
+		case "SUCCESS":
+			agg.Passed++
+		case "FAILURE", "ERROR":
+			agg.Failed++
+		case "ABORTED":
+			agg.Aborted++
+			continue
+		default:
+			agg.Pending++
+			continue
+		}
+		durations = append(durations, b.Duration)
+	}
+	if finished := agg.Passed + agg.Failed; finished > 0 {
+		agg.PassRate = float64(agg.Passed) / float64(finished)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	agg.DurationP50 = percentile(durations, 50)
+	agg.DurationP90 = percentile(durations, 90)
+	agg.DurationP99 = percentile(durations, 99)
+	return agg
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func trendOf(builds []buildData) []JobHistoryTrendPoint {
+	byDay := map[string][]buildData{}
+	for _, b := range builds {
+		if b.Started.IsZero() {
+			continue
+		}
+		day := b.Started.UTC().Format("2006-01-02")
+		byDay[day] = append(byDay[day], b)
+	}
+	trend := make([]JobHistoryTrendPoint, 0, len(byDay))
+	for day, builds := range byDay {
+		trend = append(trend, JobHistoryTrendPoint{Date: day, JobHistoryAggregate: aggregateBuilds(builds)})
+	}
+	sort.Slice(trend, func(i, j int) bool { return trend[i].Date < trend[j].Date })
+	return trend
+}
+
+type cachedBuildIDs struct {
+	ids    []uint64
+	listed time.Time
+}
+
+// jobHistoryAggregator serves the aggregated history of jobs. It caches the
+// listed build IDs of jobs for a short time and the data of finished builds
+// for as long as there is room, so only new runs are read on refresh.
+type jobHistoryAggregator struct {
+	cfg    config.Getter
+	opener pkgio.Opener
+	now    func() time.Time
+
+	builds *cache.LRUCache
+
+	lock     sync.Mutex
+	buildIDs map[string]cachedBuildIDs
+}
+
+func newJobHistoryAggregator(cfg config.Getter, opener pkgio.Opener) (*jobHistoryAggregator, error) {
+	builds, err := cache.NewLRUCache(buildCacheSize, cache.Callbacks{})
+	if err != nil {
+		return nil, err
+	}
+	return &jobHistoryAggregator{
+		cfg:      cfg,
+		opener:   opener,
+		now:      time.Now,
+		builds:   builds,
+		buildIDs: map[string]cachedBuildIDs{},
+	}, nil
+}
+
+func (a *jobHistoryAggregator) listBuildIDs(ctx context.Context, bucket blobStorageBucket, root string) ([]uint64, error) {
+	key := bucket.storageProvider + "://" + bucket.name + "/" + root
+	a.lock.Lock()
+	cached, ok := a.buildIDs[key]
+	a.lock.Unlock()
+	if ok && a.now().Sub(cached.listed) < buildIDsTTL {
+		return cached.ids, nil
+	}
+
+	// Don't spend an unbound amount of time finding a potentially huge history
+	listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	ids, err := bucket.listBuildIDs(listCtx, root)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("failed to get build ids: %w", err)
+	}
+	sort.Sort(sort.Reverse(uint64slice(ids)))
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for k, v := range a.buildIDs {
+		if a.now().Sub(v.listed) >= buildIDsTTL {
+			delete(a.buildIDs, k)
+		}
+	}
+	a.buildIDs[key] = cachedBuildIDs{ids: ids, listed: a.now()}
+	return ids, nil
+}
+
+// build returns the data of a build. The data of finished builds is cached.
+func (a *jobHistoryAggregator) build(ctx context.Context, bucket blobStorageBucket, root string, buildID uint64) buildData {
+	id := strconv.FormatUint(buildID, 10)
+	key := bucket.storageProvider + "://" + bucket.name + "/" + root + "/" + id
+	value, _, _ := a.builds.GetOrAdd(key, func() (interface{}, error) {
+		dir, err := bucket.getPath(ctx, root, id, "")
+		if err != nil {
+			if !pkgio.IsNotExist(err) {
+				logrus.WithError(err).WithField("build-id", buildID).Warning("Failed to get path")
+			}
+			return buildData{ID: id, Result: "UNKNOWN"}, nil
+		}
+		b, err := getBuildData(ctx, bucket, dir)
+		if err != nil && !pkgio.IsNotExist(err) {
+			logrus.WithError(err).WithField("build-id", buildID).Warning("Build information incomplete.")
+		}
+		b.ID = id
+		b.Result = strings.ToUpper(b.Result)
+		b.SpyglassLink = path.Join(spyglassPrefix, bucket.storageProvider, bucket.name, dir)
+		return b, nil
+	})
+	b, _ := value.(buildData)
+	if b.Result == "PENDING" || b.Result == "UNKNOWN" {
+		// The build isn't finished (or its data isn't uploaded yet), so it
+		// has to be read again next time.
+		a.builds.Lock()
+		a.builds.Remove(key)
+		a.builds.Unlock()
+	}
+	return b
+}
+
+// buildsOf returns the data of the builds in the order of the IDs.
+func (a *jobHistoryAggregator) buildsOf(ctx context.Context, bucket blobStorageBucket, root string, ids []uint64) []buildData {
+	builds := make([]buildData, len(ids))
+	sem := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id uint64) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			builds[i] = a.build(ctx, bucket, root, id)
+			builds[i].index = i
+		}(i, id)
+	}
+	wg.Wait()
+	return builds
+}
+
+// stats aggregates the history of the job at the URL, which has the same
+// path as the job history page, with the page, per_page and runs query
+// parameters.
+func (a *jobHistoryAggregator) stats(ctx context.Context, u *url.URL) (JobHistoryStats, error) {
+	stats := JobHistoryStats{}
+	storageProvider, bucketName, root, _, err := parseJobHistURL(u)
+	if err != nil {
+		return stats, fmt.Errorf("invalid url %s: %w", u.String(), err)
+	}
+	query := u.Query()
+	runs, err := intParam(query, "runs", defaultStatsRuns, maxStatsRuns)
+	if err != nil {
+		return stats, err
+	}
+	stats.Page, err = intParam(query, "page", 1, 0)
+	if err != nil {
+		return stats, err
+	}
+	stats.PageSize, err = intParam(query, "per_page", defaultStatsPageSize, maxStatsPageSize)
+	if err != nil {
+		return stats, err
+	}
+
+	if bucketAlias, exists := a.cfg().Deck.Spyglass.BucketAliases[bucketName]; exists {
+		bucketName = bucketAlias
+	}
+	bucket, err := newBlobStorageBucket(bucketName, storageProvider, a.cfg(), a.opener)
+	if err != nil {
+		return stats, err
+	}
+	stats.Name = root
+
+	ids, err := a.listBuildIDs(ctx, bucket, root)
+	if err != nil {
+		return stats, err
+	}
+	stats.TotalRuns = len(ids)
+	stats.Pages = (len(ids) + stats.PageSize - 1) / stats.PageSize
+
+	recent := ids
+	if len(recent) > runs {
+		recent = recent[:runs]
+	}
+	aggregated := a.buildsOf(ctx, bucket, root, recent)
+	stats.Summary = aggregateBuilds(aggregated)
+	stats.Trend = trendOf(aggregated)
+
+	start := (stats.Page - 1) * stats.PageSize
+	if start >= len(ids) {
+		stats.Builds = []buildData{}
+		return stats, nil
+	}
+	end := start + stats.PageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+	if end <= len(aggregated) {
+		stats.Builds = aggregated[start:end]
+	} else {
+		stats.Builds = a.buildsOf(ctx, bucket, root, ids[start:end])
+	}
+	return stats, nil
+}
+
+// intParam parses a positive integer query parameter, capped at max unless
+// max is 0.
+func intParam(query url.Values, name string, def, max int) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, httpError{error: fmt.Errorf("invalid value for %s: %q", name, raw), statusCode: http.StatusBadRequest}
+	}
+	if max > 0 && n > max {
+		n = max
+	}
+	return n, nil
+}
+
+// handleJobHistoryStats serves the aggregated history of a job as JSON. The
+// path is the one of the job history page with the /job-history-stats/
+// prefix, e.g.:
+//
+// - /job-history-stats/gs/kubernetes-jenkins/logs/ci-kubernetes-e2e-prow-canary?runs=1000&page=2
+func handleJobHistoryStats(cfg config.Getter, a *jobHistoryAggregator, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		u := *r.URL
+		u.Path = "/job-history/" + strings.TrimPrefix(u.Path, "/job-history-stats/")
+		if err := checkJobHistoryTenant(r, cfg(), &u); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		stats, err := a.stats(r.Context(), &u)
+		if err != nil {
+			msg := fmt.Sprintf("failed to get job history stats: %v", err)
+			if shouldLogHTTPErrors(err) {
+				log.WithField("url", r.URL.String()).WithError(err).Warn(msg)
+			} else {
+				log.WithField("url", r.URL.String()).WithError(err).Debug(msg)
+			}
+			http.Error(w, msg, httpStatusForError(err))
+			return
+		}
+		raw, err := json.Marshal(stats)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling job history stats.")
+			http.Error(w, "Error marshaling job history stats.", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, string(raw))
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+func TestAggregateBuilds(t *testing.T) {
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	builds := []buildData{
+		{Result: "SUCCESS", Started: day, Duration: time.Minute},
+		{Result: "SUCCESS", Started: day.Add(time.Hour), Duration: 3 * time.Minute},
+		{Result: "FAILURE", Started: day.Add(24 * time.Hour), Duration: 2 * time.Minute},
+		{Result: "ABORTED", Started: day.Add(24 * time.Hour), Duration: time.Second},
+		{Result: "PENDING", Started: day.Add(25 * time.Hour)},
+	}
+
+	expected := JobHistoryAggregate{
+		Runs:        5,
+		Passed:      2,
+		Failed:      1,
+		Aborted:     1,
+		Pending:     1,
+		PassRate:    2.0 / 3,
+		DurationP50: 2 * time.Minute,
+		DurationP90: 3 * time.Minute,
+		DurationP99: 3 * time.Minute,
+	}
+	if diff := cmp.Diff(expected, aggregateBuilds(builds)); diff != "" {
+		t.Errorf("unexpected aggregate (-want +got):\n%s", diff)
+	}
+
+	expectedTrend := []JobHistoryTrendPoint{
+		{Date: "2024-05-01", JobHistoryAggregate: JobHistoryAggregate{Runs: 2, Passed: 2, PassRate: 1, DurationP50: time.Minute, DurationP90: 3 * time.Minute, DurationP99: 3 * time.Minute}},
+		{Date: "2024-05-02", JobHistoryAggregate: JobHistoryAggregate{Runs: 3, Failed: 1, Aborted: 1, Pending: 1, DurationP50: 2 * time.Minute, DurationP90: 2 * time.Minute, DurationP99: 2 * time.Minute}},
+	}
+	if diff := cmp.Diff(expectedTrend, trendOf(builds)); diff != "" {
+		t.Errorf("unexpected trend (-want +got):\n%s", diff)
+	}
+}
+
+func TestJobHistoryStats(t *testing.T) {
+	const root = "logs/ci-job"
+	var objects []fakestorage.Object
+	for i := 1; i <= 5; i++ {
+		started := 1700000000 + i*86400
+		objects = append(objects, fakestorage.Object{
+			BucketName: "bucket",
+			Name:       fmt.Sprintf("%s/%d/started.json", root, i),
+			Content:    []byte(fmt.Sprintf(`{"timestamp": %d}`, started)),
+		})
+		if i == 5 {
+			// The latest run is still pending.
+			continue
+		}
+		result := "SUCCESS"
+		if i == 2 {
+			result = "FAILURE"
+		}
+		objects = append(objects, fakestorage.Object{
+			BucketName: "bucket",
+			Name:       fmt.Sprintf("%s/%d/finished.json", root, i),
+			Content:    []byte(fmt.Sprintf(`{"timestamp": %d, "result": %q}`, started+i*60, result)),
+		})
+	}
+	gcsServer := fakestorage.NewServer(objects)
+	defer gcsServer.Stop()
+
+	skip := true
+	ca := &config.Agent{}
+	ca.Set(&config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{SkipStoragePathValidation: &skip}}})
+	aggregator, err := newJobHistoryAggregator(ca.Config, io.NewGCSOpener(gcsServer.Client()))
+	if err != nil {
+		t.Fatalf("failed to create aggregator: %v", err)
+	}
+
+	u, _ := url.Parse("https://prow.k8s.io/job-history/gs/bucket/logs/ci-job?runs=4&per_page=2&page=2")
+	stats, err := aggregator.stats(context.Background(), u)
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	if stats.TotalRuns != 5 || stats.Pages != 3 || stats.Page != 2 {
+		t.Errorf("expected page 2 of 3 of 5 runs, got page %d of %d of %d runs", stats.Page, stats.Pages, stats.TotalRuns)
+	}
+	expectedSummary := JobHistoryAggregate{
+		Runs:        4,
+		Passed:      2,
+		Failed:      1,
+		Pending:     1,
+		PassRate:    2.0 / 3,
+		DurationP50: 3 * time.Minute,
+		DurationP90: 4 * time.Minute,
+		DurationP99: 4 * time.Minute,
+	}
+	if diff := cmp.Diff(expectedSummary, stats.Summary); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+	if len(stats.Trend) != 4 {
+		t.Errorf("expected a trend point per day, got %d", len(stats.Trend))
+	}
+	var ids []string
+	for _, b := range stats.Builds {
+		ids = append(ids, b.ID)
+	}
+	if diff := cmp.Diff([]string{"3", "2"}, ids); diff != "" {
+		t.Errorf("unexpected builds on the page (-want +got):\n%s", diff)
+	}
+	if expected := "/view/gs/bucket/logs/ci-job/3"; stats.Builds[0].SpyglassLink != expected {
+		t.Errorf("expected spyglass link %s, got %s", expected, stats.Builds[0].SpyglassLink)
+	}
+
+	// Finished builds are cached, pending ones are read again.
+	aggregator.builds.Lock()
+	cached := aggregator.builds.Len()
+	aggregator.builds.Unlock()
+	if cached != 3 {
+		t.Errorf("expected the 3 finished builds to be cached, got %d", cached)
+	}
+}
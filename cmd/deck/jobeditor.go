@@ -0,0 +1,366 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/schema"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/oidcauth"
+)
+
+// maxJobConfigFileSize is the size of the job config files the job editor
+// accepts at most.
+const maxJobConfigFileSize = 1 << 20
+
+// jobEditorClient is the part of the GitHub client the job editor needs.
+type jobEditorClient interface {
+	github.RerunClient
+	GetRef(org, repo, ref string) (string, error)
+	GetFile(org, repo, filepath, commit string) ([]byte, error)
+	CreateRef(org, repo, ref, SHA string) error
+	UpdateFile(org, repo, branch, filepath, message string, content []byte) (string, error)
+	CreatePullRequest(org, repo, title, body, head, base string, canModify bool) (int, error)
+}
+
+// jobEditorTemplate is the data rendered by job-editor.html.
+type jobEditorTemplate struct {
+	Job     string
+	Repo    string
+	Branch  string
+	Path    string
+	URL     string
+	BaseSHA string
+	Content string
+	Error   string
+}
+
+// jobEditorProposal is the body of the requests proposing a change.
+type jobEditorProposal struct {
+	// BaseSHA is the commit the file was edited at.
+	BaseSHA string `json:"base_sha"`
+	// Content is the edited file.
+	Content string `json:"content"`
+	// Title is the title of the PR, defaulting to Update <job>.
+	Title string `json:"title,omitempty"`
+	// Description is added to the body of the PR.
+	Description string `json:"description,omitempty"`
+}
+
+// jobEditorValidation is the response to the requests validating a change.
+type jobEditorValidation struct {
+	Errors []string `json:"errors"`
+}
+
+// jobEditorPR is the response to the requests proposing a change.
+type jobEditorPR struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// findJob returns the job with the name.
+func findJob(cfg *config.Config, name string) (config.JobBase, bool) {
+	for _, job := range cfg.AllStaticPresubmits(nil) {
+		if job.Name == name {
+			return job.JobBase, true
+		}
+	}
+	for _, job := range cfg.AllStaticPostsubmits(nil) {
+		if job.Name == name {
+			return job.JobBase, true
+		}
+	}
+	for _, job := range cfg.AllPeriodics() {
+		if job.Name == name {
+			return job.JobBase, true
+		}
+	}
+	return config.JobBase{}, false
+}
+
+// jobEditorFile returns the job editor config and the path in its repo of
+// the file the job is defined in, or an HTTP status and error.
+func jobEditorFile(cfg *config.Config, jobConfigPath, name string) (*config.JobEditor, config.JobBase, string, int, error) {
+	editor := cfg.Deck.JobEditor
+	if editor == nil {
+		return nil, config.JobBase{}, "", http.StatusNotFound, errors.New("The job editor is not configured.")
+	}
+	if name == "" {
+		return nil, config.JobBase{}, "", http.StatusBadRequest, errors.New("Request did not provide the 'job' query parameter.")
+	}
+	job, found := findJob(cfg, name)
+	if !found {
+		return nil, config.JobBase{}, "", http.StatusNotFound, fmt.Errorf("No job is named %q.", name)
+	}
+	path, err := editor.RepoPath(jobConfigPath, job)
+	if err != nil {
+		return nil, config.JobBase{}, "", http.StatusBadRequest, fmt.Errorf("The job can't be edited: %v.", err)
+	}
+	return editor, job, path, http.StatusOK, nil
+}
+
+// authorizeJobEditor responds with an error unless the user of the request
+// is among the editors, and returns how the PRs name them. Users who aren't
+// logged in are redirected to the login page if redirect is set.
+func authorizeJobEditor(w http.ResponseWriter, r *http.Request, redirect bool, editor *config.JobEditor, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli github.RerunClient) (string, bool) {
+	user, code, err := jobEditor(r, editor, goa, oa, ghc, cli)
+	if err != nil {
+		if code == http.StatusUnauthorized {
+			if redirect {
+				http.Redirect(w, r, loginURL(oa)+"?dest="+url.QueryEscape(strings.TrimPrefix(r.URL.RequestURI(), "/")), http.StatusFound)
+				return "", false
+			}
+			setLoginURL(w, oa)
+		}
+		http.Error(w, fmt.Sprintf("Could not verify if allowed to edit jobs: %v.", err), code)
+		return "", false
+	}
+	if user == "" {
+		http.Error(w, "You don't have permission to edit jobs.", http.StatusForbidden)
+		return "", false
+	}
+	return user, true
+}
+
+// handleJobEditor shows the file a job is defined in, as it is on the branch
+// of the job config repo, in an editor validating the changes as they are
+// typed. Only editors can open it.
+//
+// /job-editor?job=<name>
+func handleJobEditor(o options, cfg config.Getter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli jobEditorClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		c := cfg()
+		if c.Deck.JobEditor == nil {
+			http.Error(w, "The job editor is not configured.", http.StatusNotFound)
+			return
+		}
+		if _, ok := authorizeJobEditor(w, r, true, c.Deck.JobEditor, goa, oa, ghc, cli); !ok {
+			return
+		}
+		tmpl := jobEditorTemplate{Job: r.URL.Query().Get("job")}
+		if tmpl.Job != "" {
+			editor, _, path, code, err := jobEditorFile(c, o.config.JobConfigPath, tmpl.Job)
+			if err != nil {
+				http.Error(w, err.Error(), code)
+				return
+			}
+			org, repo, _ := config.SplitRepoName(editor.Repo)
+			tmpl.Repo, tmpl.Branch, tmpl.Path = editor.Repo, editor.Branch, path
+			tmpl.URL = fmt.Sprintf("https://%s/%s/blob/%s/%s", o.github.Host, editor.Repo, editor.Branch, path)
+			l := log.WithFields(logrus.Fields{"job": tmpl.Job, "path": path})
+			if tmpl.BaseSHA, err = cli.GetRef(org, repo, "heads/"+editor.Branch); err != nil {
+				l.WithError(err).Warn("Failed to get the branch of the job config.")
+				tmpl.Error = fmt.Sprintf("Failed to get the %s branch of %s.", editor.Branch, editor.Repo)
+			} else if content, err := cli.GetFile(org, repo, path, tmpl.BaseSHA); err != nil {
+				l.WithError(err).Warn("Failed to get the job config file.")
+				tmpl.Error = fmt.Sprintf("Failed to get %s from %s.", path, editor.Repo)
+			} else {
+				tmpl.Content = string(content)
+			}
+		}
+		handleSimpleTemplate(o, cfg, "job-editor.html", tmpl)(w, r)
+	}
+}
+
+// handleJobEditorValidate validates the edited file of a job against the
+// schema of the job config and like checkconfig, as if it replaced the file
+// the job is defined in. Only editors can validate files.
+//
+// POST /api/job-editor/validate?job=<name> with the content of the file
+func handleJobEditorValidate(o options, cfg config.Getter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli github.RerunClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		c := cfg()
+		editor, job, _, code, err := jobEditorFile(c, o.config.JobConfigPath, r.URL.Query().Get("job"))
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		if _, ok := authorizeJobEditor(w, r, false, editor, goa, oa, ghc, cli); !ok {
+			return
+		}
+		content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJobConfigFileSize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read the file: %v.", err), http.StatusBadRequest)
+			return
+		}
+		validation := jobEditorValidation{Errors: validateJobConfigFile(c, job, content)}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(validation); err != nil {
+			log.WithError(err).Debug("Failed to write the validation.")
+		}
+	}
+}
+
+// validateJobConfigFile returns the problems of the edited file of the job.
+func validateJobConfigFile(cfg *config.Config, job config.JobBase, content []byte) []string {
+	errs := []string{}
+	for _, err := range []error{schema.ValidateYAML(schema.JobConfig, content), cfg.ValidateJobConfigFile(job.SourcePath, content)} {
+		if err == nil {
+			continue
+		}
+		var agg utilerrors.Aggregate
+		if errors.As(err, &agg) {
+			for _, err := range agg.Errors() {
+				errs = append(errs, err.Error())
+			}
+		} else {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// handleJobEditorPropose opens a PR changing the file a job is defined in to
+// the edited file, once it passes validation. The PR is opened by the bot
+// for users who are editors.
+//
+// POST /api/job-editor/propose?job=<name> with a jobEditorProposal
+func handleJobEditorPropose(o options, cfg config.Getter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli jobEditorClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		c := cfg()
+		editor, job, path, code, err := jobEditorFile(c, o.config.JobConfigPath, r.URL.Query().Get("job"))
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		l := log.WithFields(logrus.Fields{"job": job.Name, "path": path})
+
+		user, ok := authorizeJobEditor(w, r, false, editor, goa, oa, ghc, cli)
+		if !ok {
+			return
+		}
+		l = l.WithField("user", user)
+
+		var proposal jobEditorProposal
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobConfigFileSize)).Decode(&proposal); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v.", err), http.StatusBadRequest)
+			return
+		}
+		if proposal.BaseSHA == "" {
+			http.Error(w, "The commit the file was edited at is missing.", http.StatusBadRequest)
+			return
+		}
+		content := []byte(proposal.Content)
+		if errs := validateJobConfigFile(c, job, content); len(errs) > 0 {
+			http.Error(w, fmt.Sprintf("The file is invalid:\n%s", strings.Join(errs, "\n")), http.StatusBadRequest)
+			return
+		}
+
+		org, repo, _ := config.SplitRepoName(editor.Repo)
+		current, err := cli.GetFile(org, repo, path, proposal.BaseSHA)
+		if err != nil {
+			l.WithError(err).Warn("Failed to get the job config file.")
+			http.Error(w, fmt.Sprintf("Failed to get %s at %s.", path, proposal.BaseSHA), http.StatusBadGateway)
+			return
+		}
+		if bytes.Equal(current, content) {
+			http.Error(w, "The file is unchanged.", http.StatusBadRequest)
+			return
+		}
+		title := proposal.Title
+		if title == "" {
+			title = fmt.Sprintf("Update %s", job.Name)
+		}
+		body := fmt.Sprintf("Proposed by %s from the job editor of Deck.", user)
+		if proposal.Description != "" {
+			body = proposal.Description + "\n\n" + body
+		}
+		// The branch starts at the commit the file was edited at, so that the
+		// PR shows conflicts with changes made to it in the meantime.
+		branch := fmt.Sprintf("job-editor/%s-%d", job.Name, time.Now().Unix())
+		if err := cli.CreateRef(org, repo, "heads/"+branch, proposal.BaseSHA); err != nil {
+			l.WithError(err).Warn("Failed to create the branch of the change.")
+			http.Error(w, "Failed to create the branch of the change.", http.StatusBadGateway)
+			return
+		}
+		if _, err := cli.UpdateFile(org, repo, branch, path, title, content); err != nil {
+			l.WithError(err).Warn("Failed to commit the change.")
+			http.Error(w, "Failed to commit the change.", http.StatusBadGateway)
+			return
+		}
+		number, err := cli.CreatePullRequest(org, repo, title, body, branch, editor.Branch, true)
+		if err != nil {
+			l.WithError(err).Warn("Failed to open the PR.")
+			http.Error(w, "Failed to open the PR.", http.StatusBadGateway)
+			return
+		}
+		pr := jobEditorPR{Number: number, URL: fmt.Sprintf("https://%s/%s/pull/%d", o.github.Host, editor.Repo, number)}
+		l.WithField("pr", number).Info("Opened a PR for the edited job.")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(pr); err != nil {
+			l.WithError(err).Debug("Failed to write the PR.")
+		}
+	}
+}
+
+// jobEditor returns how the PRs name the user of the request if they are
+// among the editors, or an empty string. Editors need to log in even if
+// anyone is allowed, so that the PRs tell who proposed them.
+func jobEditor(r *http.Request, editor *config.JobEditor, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli github.RerunClient) (string, int, error) {
+	if identity := oidcIdentity(r, oa); identity != nil {
+		if !editor.Editors.IsAuthorizedGroups(identity.Groups) {
+			return "", http.StatusOK, nil
+		}
+		return identity.User, http.StatusOK, nil
+	}
+	if goa == nil {
+		if oa != nil {
+			return "", http.StatusUnauthorized, errors.New("not logged in")
+		}
+		return "", http.StatusInternalServerError, errors.New("GitHub oauth or OIDC must be configured to edit jobs")
+	}
+	login, err := goa.GetLogin(r, ghc)
+	if err != nil {
+		return "", http.StatusUnauthorized, fmt.Errorf("not logged in: %w", err)
+	}
+	// Teams given by ID or slug are looked up in the org of the job config.
+	org, _, _ := config.SplitRepoName(editor.Repo)
+	allowed, err := editor.Editors.IsAuthorized(org, login, cli)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	if !allowed {
+		return "", http.StatusOK, nil
+	}
+	return "@" + login, http.StatusOK, nil
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+)
+
+const jobEditorFileContent = `presubmits:
+  org/repo:
+  - name: pull-unit
+    spec:
+      containers:
+      - image: alpine
+`
+
+// loadJobEditorConfig loads a config whose job config has the file of
+// pull-unit, for the job editor to edit.
+func loadJobEditorConfig(t *testing.T, editor *config.JobEditor) (*config.Config, string) {
+	dir := t.TempDir()
+	prowConfigPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(prowConfigPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobConfigPath := filepath.Join(dir, "jobs")
+	if err := os.MkdirAll(filepath.Join(jobConfigPath, "org"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(jobConfigPath, "org", "repo.yaml"), []byte(jobEditorFileContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(prowConfigPath, jobConfigPath, nil, "")
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	cfg.Deck.JobEditor = editor
+	return cfg, jobConfigPath
+}
+
+// loggedIn returns a GitHub OAuth agent for which the request is logged in.
+func loggedIn(t *testing.T, req *http.Request) *githuboauth.Agent {
+	mockCookieStore := sessions.NewCookieStore([]byte("secret-key"))
+	session, err := sessions.GetRegistry(req).Get(mockCookieStore, "access-token-session")
+	if err != nil {
+		t.Fatalf("Error making access token session: %v", err)
+	}
+	session.Values["access-token"] = &oauth2.Token{AccessToken: "validtoken"}
+	return githuboauth.NewAgent(&githuboauth.Config{CookieStore: mockCookieStore}, logrus.NewEntry(logrus.StandardLogger()))
+}
+
+func TestJobEditorPage(t *testing.T) {
+	testCases := []struct {
+		name         string
+		loggedIn     bool
+		login        string
+		expectedCode int
+	}{
+		{
+			name:         "editor",
+			loggedIn:     true,
+			login:        "editor",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "user who isn't an editor",
+			loggedIn:     true,
+			login:        "random-dude",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "user who isn't logged in",
+			expectedCode: http.StatusFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, jobConfigPath := loadJobEditorConfig(t, &config.JobEditor{Repo: "org/config", Branch: "main", JobConfigPath: "jobs", Editors: &prowapi.RerunAuthConfig{GitHubUsers: []string{"editor"}}})
+			o := options{config: configflagutil.ConfigOptions{JobConfigPath: jobConfigPath}, github: flagutil.GitHubOptions{Host: "github.com"}, templateFilesLocation: "template"}
+			ghc := fakegithub.NewFakeClient()
+			ghc.RemoteFiles["jobs/org/repo.yaml"] = map[string]string{fakegithub.TestRef: jobEditorFileContent}
+
+			req := httptest.NewRequest(http.MethodGet, "/job-editor?job=pull-unit", nil)
+			goa := githuboauth.NewAgent(&githuboauth.Config{CookieStore: sessions.NewCookieStore([]byte("secret-key"))}, logrus.NewEntry(logrus.StandardLogger()))
+			if tc.loggedIn {
+				goa = loggedIn(t, req)
+			}
+			rr := httptest.NewRecorder()
+			handleJobEditor(o, func() *config.Config { return cfg }, goa, nil, &fakeAuthenticatedUserIdentifier{login: tc.login}, ghc, logrus.WithField("handler", "/job-editor")).ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode == http.StatusOK && !strings.Contains(rr.Body.String(), "pull-unit") {
+				t.Errorf("expected the page to show the file of the job, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestJobEditorValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		query        string
+		content      string
+		login        string
+		expectedCode int
+		// expectedErrors are substrings of the expected errors.
+		expectedErrors []string
+	}{
+		{
+			name:           "valid file",
+			query:          "job=pull-unit",
+			content:        strings.Replace(jobEditorFileContent, "alpine", "alpine:3", 1),
+			expectedCode:   http.StatusOK,
+			expectedErrors: []string{},
+		},
+		{
+			name:         "schema violation",
+			query:        "job=pull-unit",
+			content:      strings.Replace(jobEditorFileContent, "- name: pull-unit", "- name: pull-unit\n    always_run: yes please", 1),
+			expectedCode: http.StatusOK,
+			expectedErrors: []string{
+				"presubmits.org/repo[0].always_run",
+				"failed to parse the job config",
+			},
+		},
+		{
+			name:         "unknown job",
+			query:        "job=pull-other",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "job is required",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "user who isn't an editor",
+			query:        "job=pull-unit",
+			content:      jobEditorFileContent,
+			login:        "random-dude",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, jobConfigPath := loadJobEditorConfig(t, &config.JobEditor{Repo: "org/config", Branch: "main", JobConfigPath: "jobs", Editors: &prowapi.RerunAuthConfig{GitHubUsers: []string{"editor"}}})
+			o := options{config: configflagutil.ConfigOptions{JobConfigPath: jobConfigPath}}
+			req := httptest.NewRequest(http.MethodPost, "/api/job-editor/validate?"+tc.query, strings.NewReader(tc.content))
+			login := tc.login
+			if login == "" {
+				login = "editor"
+			}
+			rr := httptest.NewRecorder()
+			handleJobEditorValidate(o, func() *config.Config { return cfg }, loggedIn(t, req), nil, &fakeAuthenticatedUserIdentifier{login: login}, fakegithub.NewFakeClient(), logrus.WithField("handler", "/api/job-editor/validate")).ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var validation jobEditorValidation
+			if err := json.Unmarshal(rr.Body.Bytes(), &validation); err != nil {
+				t.Fatalf("failed to parse the response: %v", err)
+			}
+			if len(validation.Errors) != len(tc.expectedErrors) {
+				t.Fatalf("expected %d errors, got %v", len(tc.expectedErrors), validation.Errors)
+			}
+			for i, expected := range tc.expectedErrors {
+				if !strings.Contains(validation.Errors[i], expected) {
+					t.Errorf("expected error %d to contain %q, got %q", i, expected, validation.Errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJobEditorPropose(t *testing.T) {
+	edited := strings.Replace(jobEditorFileContent, "alpine", "alpine:3", 1)
+	body := func(content string) string {
+		raw, _ := json.Marshal(jobEditorProposal{BaseSHA: fakegithub.TestRef, Content: content, Description: "Pin the image."})
+		return string(raw)
+	}
+	testCases := []struct {
+		name         string
+		unconfigured bool
+		query        string
+		body         string
+		login        string
+		expectedCode int
+		expectedPR   bool
+	}{
+		{
+			name:         "propose a change",
+			query:        "job=pull-unit",
+			body:         body(edited),
+			login:        "editor",
+			expectedCode: http.StatusCreated,
+			expectedPR:   true,
+		},
+		{
+			name:         "not configured",
+			unconfigured: true,
+			query:        "job=pull-unit",
+			body:         body(edited),
+			login:        "editor",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown job",
+			query:        "job=pull-other",
+			body:         body(edited),
+			login:        "editor",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "user who isn't an editor",
+			query:        "job=pull-unit",
+			body:         body(edited),
+			login:        "random-dude",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid file",
+			query:        "job=pull-unit",
+			body:         body(strings.Replace(jobEditorFileContent, "spec:", "spce:", 1)),
+			login:        "editor",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unchanged file",
+			query:        "job=pull-unit",
+			body:         body(jobEditorFileContent),
+			login:        "editor",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var editor *config.JobEditor
+			if !tc.unconfigured {
+				editor = &config.JobEditor{Repo: "org/config", Branch: "main", JobConfigPath: "jobs", Editors: &prowapi.RerunAuthConfig{GitHubUsers: []string{"editor"}}}
+			}
+			cfg, jobConfigPath := loadJobEditorConfig(t, editor)
+			o := options{config: configflagutil.ConfigOptions{JobConfigPath: jobConfigPath}, github: flagutil.GitHubOptions{Host: "github.com"}}
+			ghc := fakegithub.NewFakeClient()
+			ghc.RemoteFiles["jobs/org/repo.yaml"] = map[string]string{fakegithub.TestRef: jobEditorFileContent}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/job-editor/propose?"+tc.query, bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			handler := handleJobEditorPropose(o, func() *config.Config { return cfg }, loggedIn(t, req), nil, &fakeAuthenticatedUserIdentifier{login: tc.login}, ghc, logrus.WithField("handler", "/api/job-editor/propose"))
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if !tc.expectedPR {
+				if len(ghc.RefsCreated) != 0 || len(ghc.PullRequests) != 0 {
+					t.Errorf("expected no PR, got refs %v and PRs %v", ghc.RefsCreated, ghc.PullRequests)
+				}
+				return
+			}
+
+			var response jobEditorPR
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse the response: %v", err)
+			}
+			pr, ok := ghc.PullRequests[response.Number]
+			if !ok {
+				t.Fatalf("expected PR %d to be opened, got %v", response.Number, ghc.PullRequests)
+			}
+			if expected := "https://github.com/org/config/pull/0"; response.URL != expected {
+				t.Errorf("expected the URL %s, got %s", expected, response.URL)
+			}
+			if pr.Title != "Update pull-unit" || pr.Body != "Pin the image.\n\nProposed by @editor from the job editor of Deck." || pr.Base.Ref != "main" {
+				t.Errorf("unexpected PR: %q, %q against %s", pr.Title, pr.Body, pr.Base.Ref)
+			}
+			if len(ghc.RefsCreated) != 1 || ghc.RefsCreated[0].Ref != "heads/"+pr.Head.Ref || ghc.RefsCreated[0].SHA != fakegithub.TestRef {
+				t.Errorf("expected the branch %s to be created at %s, got %v", pr.Head.Ref, fakegithub.TestRef, ghc.RefsCreated)
+			}
+			if !strings.HasPrefix(pr.Head.Ref, "job-editor/pull-unit-") {
+				t.Errorf("unexpected branch %s", pr.Head.Ref)
+			}
+			if content := ghc.RemoteFiles["jobs/org/repo.yaml"][pr.Head.Ref]; content != edited {
+				t.Errorf("expected the edited file to be committed to the branch, got %q", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+	spyglassapi "sigs.k8s.io/prow/pkg/spyglass/api"
+)
+
+const lensRegistryUpdatePeriod = time.Minute
+
+// lensRegistrySource lists the lenses of a lens registry.
+type lensRegistrySource interface {
+	Lenses(ctx context.Context, registryURL string) ([]spyglassapi.LensRegistration, error)
+}
+
+// httpLensRegistrySource fetches the lenses from the registry endpoint.
+type httpLensRegistrySource struct {
+	client *http.Client
+}
+
+func (s *httpLensRegistrySource) Lenses(ctx context.Context, registryURL string) ([]spyglassapi.LensRegistration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("response has status code %d", resp.StatusCode)
+	}
+	var registrations []spyglassapi.LensRegistration
+	if err := json.NewDecoder(resp.Body).Decode(&registrations); err != nil {
+		return nil, fmt.Errorf("error decoding lens registrations: %w", err)
+	}
+	return registrations, nil
+}
+
+// lensRegistryAgent periodically discovers the lenses of the lens registries
+// and adds them to the spyglass config.
+type lensRegistryAgent struct {
+	log    *logrus.Entry
+	cfg    config.Getter
+	source lensRegistrySource
+
+	sync.Mutex
+	// lenses are the lenses discovered from each registry URL. The lenses of
+	// a registry that can't be reached are kept until it can be again.
+	lenses map[string][]config.LensFileConfig
+	// generation changes whenever the discovered lenses do.
+	generation int
+
+	// base, baseGeneration and merged cache the last config returned by
+	// config.
+	base           *config.Config
+	baseGeneration int
+	merged         *config.Config
+}
+
+func (a *lensRegistryAgent) start() {
+	go func() {
+		for {
+			start := time.Now()
+			a.update()
+			time.Sleep(time.Until(start.Add(lensRegistryUpdatePeriod)))
+		}
+	}()
+}
+
+func (a *lensRegistryAgent) update() {
+	registries := a.cfg().Deck.Spyglass.LensRegistries
+	discovered := map[string][]config.LensFileConfig{}
+	for _, registry := range registries {
+		log := a.log.WithField("registry", registry.URL)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		registrations, err := a.source.Lenses(ctx, registry.URL)
+		cancel()
+		if err != nil {
+			log.WithError(err).Warn("Failed to discover the lenses of the registry.")
+			a.Lock()
+			discovered[registry.URL] = a.lenses[registry.URL]
+			a.Unlock()
+			continue
+		}
+		for _, registration := range registrations {
+			lens, err := registeredLens(registry, registration)
+			if err != nil {
+				log.WithError(err).WithField("lens", registration.Name).Warn("Ignoring invalid lens.")
+				continue
+			}
+			discovered[registry.URL] = append(discovered[registry.URL], lens)
+		}
+	}
+	a.Lock()
+	defer a.Unlock()
+	a.lenses = discovered
+	a.generation++
+}
+
+// registeredLens converts the registration of a lens to its config, resolving
+// its endpoints against the registry URL and capping its limits with the
+// limits of the registry.
+func registeredLens(registry config.LensRegistry, registration spyglassapi.LensRegistration) (config.LensFileConfig, error) {
+	if registration.ProtocolVersion != spyglassapi.ProtocolVersionV1 {
+		return config.LensFileConfig{}, fmt.Errorf("unsupported protocol version %q", registration.ProtocolVersion)
+	}
+	if registration.Name == "" {
+		return config.LensFileConfig{}, errors.New("the lens has no name")
+	}
+	if len(registration.RequiredFiles) == 0 {
+		return config.LensFileConfig{}, errors.New("the lens requires no files")
+	}
+	for _, file := range append(append([]string{}, registration.RequiredFiles...), registration.OptionalFiles...) {
+		if _, err := regexp.Compile(file); err != nil {
+			return config.LensFileConfig{}, fmt.Errorf("invalid file regex %q: %w", file, err)
+		}
+	}
+	base, err := url.Parse(registry.URL)
+	if err != nil {
+		return config.LensFileConfig{}, err
+	}
+	endpoint, err := base.Parse(registration.Endpoint)
+	if err != nil {
+		return config.LensFileConfig{}, fmt.Errorf("invalid endpoint %q: %w", registration.Endpoint, err)
+	}
+	var staticRoot string
+	if registration.StaticRoot != "" {
+		parsed, err := base.Parse(registration.StaticRoot)
+		if err != nil {
+			return config.LensFileConfig{}, fmt.Errorf("invalid static root %q: %w", registration.StaticRoot, err)
+		}
+		staticRoot = parsed.String()
+	}
+
+	maxArtifactBytes := registry.MaxArtifactBytes
+	if limit := registration.Limits.MaxArtifactBytes; limit > 0 && limit < maxArtifactBytes {
+		maxArtifactBytes = limit
+	}
+	timeout := registry.Timeout.Duration
+	if limit := time.Duration(registration.Limits.TimeoutSeconds) * time.Second; limit > 0 && limit < timeout {
+		timeout = limit
+	}
+	priority, hideTitle := registration.Priority, registration.HideTitle
+	return config.LensFileConfig{
+		RequiredFiles: registration.RequiredFiles,
+		OptionalFiles: registration.OptionalFiles,
+		Lens:          config.LensConfig{Name: registration.Name},
+		RemoteConfig: &config.LensRemoteConfig{
+			Endpoint:         endpoint.String(),
+			ParsedEndpoint:   endpoint,
+			StaticRoot:       staticRoot,
+			Title:            registration.Title,
+			Priority:         &priority,
+			HideTitle:        &hideTitle,
+			ProtocolVersion:  registration.ProtocolVersion,
+			MaxArtifactBytes: maxArtifactBytes,
+			Timeout:          &metav1.Duration{Duration: timeout},
+		},
+	}, nil
+}
+
+// config returns the current config with the discovered lenses of its
+// registries added to the spyglass lenses.
+func (a *lensRegistryAgent) config() *config.Config {
+	c := a.cfg()
+	a.Lock()
+	defer a.Unlock()
+	if c == a.base && a.generation == a.baseGeneration {
+		return a.merged
+	}
+	var discovered []config.LensFileConfig
+	for _, registry := range c.Deck.Spyglass.LensRegistries {
+		discovered = append(discovered, a.lenses[registry.URL]...)
+	}
+	a.base, a.baseGeneration = c, a.generation
+	a.merged = withDiscoveredLenses(c, discovered)
+	return a.merged
+}
+
+// withDiscoveredLenses returns a copy of the config with the discovered
+// lenses added, except for those whose name is taken by an earlier lens.
+func withDiscoveredLenses(c *config.Config, discovered []config.LensFileConfig) *config.Config {
+	if len(discovered) == 0 {
+		return c
+	}
+	merged := *c
+	spyglass := &merged.Deck.Spyglass
+	spyglass.Lenses = append([]config.LensFileConfig{}, c.Deck.Spyglass.Lenses...)
+	spyglass.RegexCache = make(map[string]*regexp.Regexp, len(c.Deck.Spyglass.RegexCache))
+	for k, v := range c.Deck.Spyglass.RegexCache {
+		spyglass.RegexCache[k] = v
+	}
+	names := map[string]bool{}
+	for _, lens := range spyglass.Lenses {
+		names[lens.Lens.Name] = true
+	}
+	for _, lens := range discovered {
+		if names[lens.Lens.Name] {
+			continue
+		}
+		names[lens.Lens.Name] = true
+		spyglass.Lenses = append(spyglass.Lenses, lens)
+		for _, file := range append(append([]string{}, lens.RequiredFiles...), lens.OptionalFiles...) {
+			if _, ok := spyglass.RegexCache[file]; !ok {
+				// The regexes were validated when the lens was discovered.
+				spyglass.RegexCache[file] = regexp.MustCompile(file)
+			}
+		}
+	}
+	return &merged
+}
+
+// lensManifest reads the artifacts pushed to a lens with protocol v1, in
+// order, until maxBytes are read in total. Artifacts beyond the budget are
+// pushed truncated, so the lens still knows they exist.
+func lensManifest(artifacts []spyglassapi.Artifact, maxBytes, sizeLimit int64) []spyglassapi.ManifestArtifact {
+	manifest := make([]spyglassapi.ManifestArtifact, 0, len(artifacts))
+	remaining := maxBytes
+	for _, artifact := range artifacts {
+		log := logrus.WithField("artifact", artifact.JobPath())
+		size, err := artifact.Size()
+		if err != nil {
+			log.WithError(err).Debug("Failed to get the size of the artifact, not pushing it.")
+			continue
+		}
+		entry := spyglassapi.ManifestArtifact{Name: artifact.JobPath(), Link: artifact.CanonicalLink(), Size: size}
+		n := size
+		if n > remaining {
+			n = remaining
+		}
+		if sizeLimit > 0 && n > sizeLimit {
+			n = sizeLimit
+		}
+		if n > 0 {
+			content, err := artifact.ReadAtMost(n)
+			if err != nil && !errors.Is(err, io.EOF) {
+				log.WithError(err).Debug("Failed to read the artifact, pushing it without content.")
+			} else {
+				entry.Content = content
+				remaining -= int64(len(content))
+			}
+		}
+		manifest = append(manifest, entry)
+	}
+	return manifest
+}
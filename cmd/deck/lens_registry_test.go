@@ -0,0 +1,300 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass"
+	spyglassapi "sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func TestRegisteredLens(t *testing.T) {
+	registry := config.LensRegistry{URL: "https://lenses.example.com/lenses", MaxArtifactBytes: 1000, Timeout: &metav1.Duration{Duration: 30 * time.Second}}
+	uintPtr := func(u uint) *uint { return &u }
+	boolPtr := func(b bool) *bool { return &b }
+	testCases := []struct {
+		name         string
+		registration spyglassapi.LensRegistration
+		expected     config.LensFileConfig
+		expectedErr  bool
+	}{
+		{
+			name: "relative endpoints and smaller limits",
+			registration: spyglassapi.LensRegistration{
+				Name: "echo", Title: "Echo", Priority: 3, RequiredFiles: []string{"build-log.txt"},
+				Endpoint: "lenses/echo", StaticRoot: "static/echo/", ProtocolVersion: "v1",
+				Limits: spyglassapi.LensLimits{MaxArtifactBytes: 100, TimeoutSeconds: 10},
+			},
+			expected: config.LensFileConfig{
+				RequiredFiles: []string{"build-log.txt"},
+				Lens:          config.LensConfig{Name: "echo"},
+				RemoteConfig: &config.LensRemoteConfig{
+					Endpoint:         "https://lenses.example.com/lenses/echo",
+					StaticRoot:       "https://lenses.example.com/static/echo/",
+					Title:            "Echo",
+					Priority:         uintPtr(3),
+					HideTitle:        boolPtr(false),
+					ProtocolVersion:  "v1",
+					MaxArtifactBytes: 100,
+					Timeout:          &metav1.Duration{Duration: 10 * time.Second},
+				},
+			},
+		},
+		{
+			name: "absolute endpoint and limits capped by the registry",
+			registration: spyglassapi.LensRegistration{
+				Name: "echo", RequiredFiles: []string{"build-log.txt"},
+				Endpoint: "http://other.example.com/echo", ProtocolVersion: "v1",
+				Limits: spyglassapi.LensLimits{MaxArtifactBytes: 5000, TimeoutSeconds: 60},
+			},
+			expected: config.LensFileConfig{
+				RequiredFiles: []string{"build-log.txt"},
+				Lens:          config.LensConfig{Name: "echo"},
+				RemoteConfig: &config.LensRemoteConfig{
+					Endpoint:         "http://other.example.com/echo",
+					Priority:         uintPtr(0),
+					HideTitle:        boolPtr(false),
+					ProtocolVersion:  "v1",
+					MaxArtifactBytes: 1000,
+					Timeout:          &metav1.Duration{Duration: 30 * time.Second},
+				},
+			},
+		},
+		{
+			name:         "unsupported protocol version",
+			registration: spyglassapi.LensRegistration{Name: "echo", RequiredFiles: []string{"build-log.txt"}, Endpoint: "echo", ProtocolVersion: "v2"},
+			expectedErr:  true,
+		},
+		{
+			name:         "no required files",
+			registration: spyglassapi.LensRegistration{Name: "echo", Endpoint: "echo", ProtocolVersion: "v1"},
+			expectedErr:  true,
+		},
+		{
+			name:         "invalid regex",
+			registration: spyglassapi.LensRegistration{Name: "echo", RequiredFiles: []string{"("}, Endpoint: "echo", ProtocolVersion: "v1"},
+			expectedErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := registeredLens(registry, tc.registration)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.IgnoreFields(config.LensRemoteConfig{}, "ParsedEndpoint")); diff != "" {
+				t.Errorf("unexpected lens (-want +got):\n%s", diff)
+			}
+			if !tc.expectedErr && actual.RemoteConfig.ParsedEndpoint.String() != tc.expected.RemoteConfig.Endpoint {
+				t.Errorf("expected parsed endpoint %s, got %s", tc.expected.RemoteConfig.Endpoint, actual.RemoteConfig.ParsedEndpoint)
+			}
+		})
+	}
+}
+
+type fakeLensRegistrySource struct {
+	registrations map[string][]spyglassapi.LensRegistration
+}
+
+func (s *fakeLensRegistrySource) Lenses(_ context.Context, registryURL string) ([]spyglassapi.LensRegistration, error) {
+	registrations, ok := s.registrations[registryURL]
+	if !ok {
+		return nil, errors.New("unreachable")
+	}
+	return registrations, nil
+}
+
+func TestLensRegistryAgent(t *testing.T) {
+	registration := func(name, file string) spyglassapi.LensRegistration {
+		return spyglassapi.LensRegistration{Name: name, RequiredFiles: []string{file}, Endpoint: name, ProtocolVersion: "v1"}
+	}
+	cfg := &config.Config{}
+	cfg.Deck.Spyglass.Lenses = []config.LensFileConfig{{RequiredFiles: []string{"build-log.txt"}, Lens: config.LensConfig{Name: "buildlog"}}}
+	cfg.Deck.Spyglass.RegexCache = map[string]*regexp.Regexp{"build-log.txt": regexp.MustCompile("build-log.txt")}
+	timeout := &metav1.Duration{Duration: time.Minute}
+	cfg.Deck.Spyglass.LensRegistries = []config.LensRegistry{
+		{URL: "http://a.example.com/lenses", MaxArtifactBytes: 100, Timeout: timeout},
+		{URL: "http://b.example.com/lenses", MaxArtifactBytes: 100, Timeout: timeout},
+	}
+	source := &fakeLensRegistrySource{registrations: map[string][]spyglassapi.LensRegistration{
+		"http://a.example.com/lenses": {registration("buildlog", "other.txt"), registration("echo", "echo.txt"), registration("invalid", "(")},
+		"http://b.example.com/lenses": {registration("echo", "other.txt"), registration("tree", "tree.json")},
+	}}
+	agent := &lensRegistryAgent{log: logrus.WithField("agent", "lens-registry"), cfg: func() *config.Config { return cfg }, source: source}
+
+	if actual := agent.config(); actual != cfg {
+		t.Error("expected the config to be unchanged before any lens was discovered")
+	}
+
+	lensNames := func(c *config.Config) []string {
+		var names []string
+		for _, lens := range c.Deck.Spyglass.Lenses {
+			names = append(names, lens.Lens.Name+":"+lens.RequiredFiles[0])
+		}
+		return names
+	}
+	agent.update()
+	merged := agent.config()
+	if diff := cmp.Diff([]string{"buildlog:build-log.txt", "echo:echo.txt", "tree:tree.json"}, lensNames(merged)); diff != "" {
+		t.Errorf("unexpected lenses (-want +got):\n%s", diff)
+	}
+	for _, file := range []string{"build-log.txt", "echo.txt", "tree.json"} {
+		if merged.Deck.Spyglass.RegexCache[file] == nil {
+			t.Errorf("expected the regex of %s to be cached", file)
+		}
+	}
+	if len(cfg.Deck.Spyglass.Lenses) != 1 || len(cfg.Deck.Spyglass.RegexCache) != 1 {
+		t.Error("expected the base config to be unchanged")
+	}
+	if agent.config() != merged {
+		t.Error("expected the merged config to be cached")
+	}
+
+	// Lenses of unreachable registries are kept.
+	delete(source.registrations, "http://a.example.com/lenses")
+	agent.update()
+	if diff := cmp.Diff([]string{"buildlog:build-log.txt", "echo:echo.txt", "tree:tree.json"}, lensNames(agent.config())); diff != "" {
+		t.Errorf("unexpected lenses after a registry became unreachable (-want +got):\n%s", diff)
+	}
+
+	// Lenses of registries that are no longer configured are dropped.
+	cfg = &config.Config{}
+	cfg.Deck.Spyglass.LensRegistries = []config.LensRegistry{{URL: "http://b.example.com/lenses", MaxArtifactBytes: 100, Timeout: timeout}}
+	agent.update()
+	if diff := cmp.Diff([]string{"echo:other.txt", "tree:tree.json"}, lensNames(agent.config())); diff != "" {
+		t.Errorf("unexpected lenses after a registry was removed (-want +got):\n%s", diff)
+	}
+}
+
+func TestLensManifest(t *testing.T) {
+	link := "https://storage.example.com/build-log.txt"
+	artifacts := []spyglassapi.Artifact{
+		&fake.Artifact{Path: "build-log.txt", Content: []byte("0123456789"), Link: &link},
+		&fake.Artifact{Path: "junit.xml", Content: []byte("abcdefghij")},
+		&fake.Artifact{Path: "last.txt", Content: []byte("xyz")},
+	}
+	testCases := []struct {
+		name      string
+		maxBytes  int64
+		sizeLimit int64
+		expected  []spyglassapi.ManifestArtifact
+	}{
+		{
+			name:     "everything fits",
+			maxBytes: 100,
+			expected: []spyglassapi.ManifestArtifact{
+				{Name: "build-log.txt", Link: link, Size: 10, Content: []byte("0123456789")},
+				{Name: "junit.xml", Link: fake.NotFound, Size: 10, Content: []byte("abcdefghij")},
+				{Name: "last.txt", Link: fake.NotFound, Size: 3, Content: []byte("xyz")},
+			},
+		},
+		{
+			name:     "artifacts beyond the budget are truncated",
+			maxBytes: 15,
+			expected: []spyglassapi.ManifestArtifact{
+				{Name: "build-log.txt", Link: link, Size: 10, Content: []byte("0123456789")},
+				{Name: "junit.xml", Link: fake.NotFound, Size: 10, Content: []byte("abcde")},
+				{Name: "last.txt", Link: fake.NotFound, Size: 3},
+			},
+		},
+		{
+			name:      "artifacts are capped by the size limit",
+			maxBytes:  100,
+			sizeLimit: 4,
+			expected: []spyglassapi.ManifestArtifact{
+				{Name: "build-log.txt", Link: link, Size: 10, Content: []byte("0123")},
+				{Name: "junit.xml", Link: fake.NotFound, Size: 10, Content: []byte("abcd")},
+				{Name: "last.txt", Link: fake.NotFound, Size: 3, Content: []byte("xyz")},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, lensManifest(artifacts, tc.maxBytes, tc.sizeLimit)); diff != "" {
+				t.Errorf("unexpected manifest (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeLensArtifactFetcher []spyglassapi.Artifact
+
+func (f fakeLensArtifactFetcher) FetchArtifacts(context.Context, string, string, int64, []string) ([]spyglassapi.Artifact, error) {
+	return f, nil
+}
+
+func TestHandleRemoteLensV1(t *testing.T) {
+	var received spyglassapi.LensRequest
+	lensServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &received); err != nil {
+			t.Errorf("failed to parse the lens request: %v", err)
+		}
+		w.Write([]byte("rendered"))
+	}))
+	defer lensServer.Close()
+	endpoint, _ := url.Parse(lensServer.URL + "/lenses/echo")
+	lens := config.LensFileConfig{
+		Lens: config.LensConfig{Name: "echo"},
+		RemoteConfig: &config.LensRemoteConfig{
+			Endpoint:         endpoint.String(),
+			ParsedEndpoint:   endpoint,
+			StaticRoot:       "https://lenses.example.com/static/echo/",
+			ProtocolVersion:  "v1",
+			MaxArtifactBytes: 4,
+			Timeout:          &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	fetcher := fakeLensArtifactFetcher{&fake.Artifact{Path: "build-log.txt", Content: []byte("hello")}}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/spyglass/lens/echo/rerender", bytes.NewBufferString("data"))
+	handleRemoteLens(lens, rr, req, "rerender", spyglass.LensRequest{Source: "gs/bucket/logs/job/1", Artifacts: []string{"build-log.txt"}}, fetcher, 100)
+	if rr.Code != http.StatusOK || rr.Body.String() != "rendered" {
+		t.Fatalf("expected the lens to render, got %d: %s", rr.Code, rr.Body.String())
+	}
+	expected := spyglassapi.LensRequest{
+		Action:          spyglassapi.RequestActionRerender,
+		Data:            "data",
+		ResourceRoot:    "https://lenses.example.com/static/echo/",
+		Artifacts:       []string{"build-log.txt"},
+		ArtifactSource:  "gs/bucket/logs/job/1",
+		ProtocolVersion: "v1",
+		Manifest:        []spyglassapi.ManifestArtifact{{Name: "build-log.txt", Link: fake.NotFound, Size: 5, Content: []byte("hell")}},
+	}
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected lens request (-want +got):\n%s", diff)
+	}
+}
@@ -45,6 +45,8 @@ import (
 	"github.com/gorilla/sessions"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"golang.org/x/oauth2"
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -74,6 +76,8 @@ import (
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/oidcauth"
+	pipelineset "sigs.k8s.io/prow/pkg/pipeline/clientset/versioned"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/pluginhelp"
 	"sigs.k8s.io/prow/pkg/plugins"
@@ -88,12 +92,14 @@ import (
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/buildlog"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/coverage"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/explain"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/html"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/junit"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/links"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/metadata"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/podinfo"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/restcoverage"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/runenv"
 )
 
 // Omittable ProwJob fields.
@@ -125,6 +131,7 @@ type options struct {
 	oauthURL              string
 	githubOAuthConfigFile string
 	cookieSecretFile      string
+	oidcConfigFile        string
 	redirectHTTPTo        string
 	hiddenOnly            bool
 	pregeneratedData      string
@@ -158,6 +165,10 @@ func (o *options) Validate() error {
 		}
 	}
 
+	if o.oidcConfigFile != "" && o.cookieSecretFile == "" {
+		return errors.New("an OIDC config file was provided but required flag --cookie-secret was unset")
+	}
+
 	if (o.hiddenOnly && o.showHidden) || (o.tenantIDs.Strings() != nil && (o.hiddenOnly || o.showHidden)) {
 		return errors.New("'--hidden-only', '--tenant-id', and '--show-hidden' are mutually exclusive, 'hidden-only' shows only hidden job, '--tenant-id' shows all jobs with matching ID and 'show-hidden' shows both hidden and non-hidden jobs")
 	}
@@ -171,6 +182,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.StringVar(&o.oauthURL, "oauth-url", "", "Path to deck user dashboard endpoint.")
 	fs.StringVar(&o.githubOAuthConfigFile, "github-oauth-config-file", "/etc/github/secret", "Path to the file containing the GitHub App Client secret.")
 	fs.StringVar(&o.cookieSecretFile, "cookie-secret", "", "Path to the file containing the cookie secret key.")
+	fs.StringVar(&o.oidcConfigFile, "oidc-config-file", "", "Path to the file containing the OIDC provider config, enabling OIDC login for rerunning and aborting jobs.")
 	// use when behind a load balancer
 	fs.StringVar(&o.redirectHTTPTo, "redirect-http-to", "", "Host to redirect http->https to based on x-forwarded-proto == http.")
 	// use when behind an oauth proxy
@@ -220,18 +232,37 @@ func init() {
 
 var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicking the root
 	l(""),
+	l("api",
+		l("job-editor",
+			l("propose"),
+			l("validate")),
+		l("ok-to-test"),
+		l("quarantine")),
+	l("badge.json"),
 	l("badge.svg"),
+	l("branch-protection"),
 	l("command-help"),
+	l("concurrency-budgets"),
 	l("config"),
+	l("dashboards"),
 	l("data.js"),
 	l("favicon.ico"),
 	l("github-login",
 		l("redirect")),
 	l("github-link"),
 	l("git-provider-link"),
+	l("job-config-shards"),
+	l("job-editor"),
 	l("job-history",
 		v("job")),
+	l("job-history-stats",
+		v("job")),
 	l("log"),
+	l("oidc-login",
+		l("redirect")),
+	l("oidc-logout"),
+	l("ok-to-test"),
+	l("oncall.js"),
 	l("plugin-config"),
 	l("plugin-help"),
 	l("plugins"),
@@ -240,6 +271,7 @@ var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicking th
 	l("pr-history"),
 	l("prowjob"),
 	l("prowjobs.js"),
+	l("quarantine"),
 	l("rerun"),
 	l("spyglass",
 		l("static",
@@ -320,6 +352,7 @@ func main() {
 	mux.Handle("/tide", gziphandler.GzipHandler(handleSimpleTemplate(o, cfg, "tide.html", nil)))
 	mux.Handle("/tide-history", gziphandler.GzipHandler(handleSimpleTemplate(o, cfg, "tide-history.html", nil)))
 	mux.Handle("/plugins", gziphandler.GzipHandler(handleSimpleTemplate(o, cfg, "plugins.html", nil)))
+	mux.Handle("/job-config-shards", gziphandler.GzipHandler(handleJobConfigShards(o, cfg)))
 
 	runLocal := o.pregeneratedData != ""
 
@@ -328,6 +361,7 @@ func main() {
 	var githubClient deckGitHubClient
 	var gitClient git.ClientFactory
 	var podLogClients map[string]jobs.PodLogClient
+	var pipelineClients map[string]jobs.PipelineClient
 	if runLocal {
 		localDataHandler := staticHandlerFromDir(o.pregeneratedData)
 		fallbackHandler = localDataHandler.ServeHTTP
@@ -420,6 +454,30 @@ func main() {
 		for clusterContext, client := range buildClusterClients {
 			podLogClients[clusterContext] = &podLogClient{client: client}
 		}
+
+		// The TaskRuns of jobs with the tekton-pipeline agent are read from
+		// the build clusters, along with the logs of their pods.
+		buildClusterConfigs, err := o.kubernetes.KnownClusters(false)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error getting build cluster configs.")
+		}
+		buildClusterCoreClients, err := o.kubernetes.BuildClusterCoreV1Clients(false)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error getting Kubernetes client.")
+		}
+		pipelineClients = make(map[string]jobs.PipelineClient)
+		for clusterContext, coreClient := range buildClusterCoreClients {
+			restCfg, ok := buildClusterConfigs[clusterContext]
+			if !ok {
+				continue
+			}
+			tektonClient, err := pipelineset.NewForConfig(&restCfg)
+			if err != nil {
+				logrus.WithError(err).Warningf("Failed to create the pipeline client of cluster %s.", clusterContext)
+				continue
+			}
+			pipelineClients[clusterContext] = &pipelineClient{tekton: tektonClient, core: coreClient}
+		}
 	}
 
 	authCfgGetter := func(jobSpec *prowapi.ProwJobSpec) *prowapi.RerunAuthConfig {
@@ -440,15 +498,27 @@ func main() {
 		indexHandler(w, r)
 	})
 
-	ja := jobs.NewJobAgent(context.Background(), pjListingClient, o.hiddenOnly, o.showHidden, o.tenantIDs.Strings(), podLogClients, cfg)
-	ja.Start()
+	ja := jobs.NewJobAgent(context.Background(), pjListingClient, o.hiddenOnly, o.showHidden, o.tenantIDs.Strings(), podLogClients, cfg).WithPipelineClients(pipelineClients)
+	ja.StartWithTrigger(configAgent.Notify())
 
 	// setup prod only handlers. These handlers can work with runlocal as long
 	// as ja is properly mocked, more specifically pjListingClient inside ja
-	mux.Handle("/data.js", gziphandler.GzipHandler(handleData(ja, logrus.WithField("handler", "/data.js"))))
-	mux.Handle("/prowjobs.js", gziphandler.GzipHandler(handleProwJobs(ja, logrus.WithField("handler", "/prowjobs.js"))))
-	mux.Handle("/badge.svg", gziphandler.GzipHandler(handleBadge(ja)))
+	mux.Handle("/data.js", gziphandler.GzipHandler(handleData(cfg, ja, logrus.WithField("handler", "/data.js"))))
+	mux.Handle("/prowjobs.js", gziphandler.GzipHandler(handleProwJobs(cfg, ja, logrus.WithField("handler", "/prowjobs.js"))))
+	mux.Handle("/badge.svg", gziphandler.GzipHandler(handleBadge(cfg, ja, false)))
+	mux.Handle("/badge.json", gziphandler.GzipHandler(handleBadge(cfg, ja, true)))
+	mux.Handle("/concurrency-budgets", gziphandler.GzipHandler(handleConcurrencyBudgets(o, cfg, ja)))
+	mux.Handle("/dashboards", gziphandler.GzipHandler(handleDashboards(o, cfg, ja)))
 	mux.Handle("/log", gziphandler.GzipHandler(handleLog(ja, logrus.WithField("handler", "/log"))))
+	mux.Handle("/pipeline-status", gziphandler.GzipHandler(handlePipelineStatus(ja, logrus.WithField("handler", "/pipeline-status"))))
+
+	oa := &onCallAgent{
+		log:    logrus.WithField("agent", "oncall"),
+		cfg:    cfg,
+		source: &urlOnCallSource{client: &http.Client{Timeout: time.Minute}},
+	}
+	oa.start()
+	mux.Handle("/oncall.js", gziphandler.GzipHandler(handleOnCall(oa, logrus.WithField("handler", "/oncall.js"))))
 
 	if o.spyglass {
 		initSpyglass(cfg, o, mux, ja, githubClient, gitClient)
@@ -531,6 +601,25 @@ func (c *podLogClient) GetLogs(name, container string) ([]byte, error) {
 	return stdio.ReadAll(reader)
 }
 
+type pipelineClient struct {
+	tekton pipelineset.Interface
+	core   corev1.CoreV1Interface
+}
+
+func (c *pipelineClient) ListTaskRuns(namespace, pipelineRun string) ([]pipelinev1.TaskRun, error) {
+	taskRuns, err := c.tekton.TektonV1().TaskRuns(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: pipeline.PipelineRunLabelKey + "=" + pipelineRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return taskRuns.Items, nil
+}
+
+func (c *pipelineClient) GetLogs(namespace, pod, container string) ([]byte, error) {
+	return (&podLogClient{client: c.core.Pods(namespace)}).GetLogs(pod, container)
+}
+
 type pjListingClientWrapper struct {
 	reader ctrlruntimeclient.Reader
 }
@@ -586,12 +675,17 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		go func() {
 			ta.start()
 			mux.Handle("/tide.js", gziphandler.GzipHandler(handleTidePools(cfg, ta, logrus.WithField("handler", "/tide.js"))))
-			mux.Handle("/tide-history.js", gziphandler.GzipHandler(handleTideHistory(ta, logrus.WithField("handler", "/tide-history.js"))))
+			mux.Handle("/tide-history.js", gziphandler.GzipHandler(handleTideHistory(cfg, ta, logrus.WithField("handler", "/tide-history.js"))))
 		}()
 	}
 
 	secure := !o.allowInsecure
 
+	// Cross-checking branch protection needs to read it from GitHub
+	if githubClient != nil {
+		mux.Handle("/branch-protection", gziphandler.GzipHandler(handleBranchProtection(o, cfg, newCachingBranchProtectionClient(githubClient), logrus.WithField("handler", "/branch-protection"))))
+	}
+
 	// Handles link to github
 	mux.HandleFunc("/github-link", HandleGitHubLink(o.github.Host, secure))
 	mux.HandleFunc("/git-provider-link", HandleGitProviderLink(o.github.Host, secure))
@@ -654,8 +748,69 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		mux.Handle("/github-login/redirect", goa.HandleRedirect(oauthClient, githuboauth.NewAuthenticatedUserIdentifier(&o.github), secure))
 	}
 
-	mux.Handle("/rerun", gziphandler.GzipHandler(handleRerun(cfg, prowJobClient, o.rerunCreatesJob, authCfgGetter, goa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/rerun"))))
-	mux.Handle("/abort", gziphandler.GzipHandler(handleAbort(prowJobClient, authCfgGetter, goa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/abort"))))
+	// Enable OIDC login if an OIDC config file is provided.
+	var oa *oidcauth.Agent
+	if o.oidcConfigFile != "" {
+		oidcConfigRaw, err := loadToken(o.oidcConfigFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not read OIDC config file.")
+		}
+		var oidcConfig oidcauth.Config
+		if err := yaml.Unmarshal(oidcConfigRaw, &oidcConfig); err != nil {
+			logrus.WithError(err).Fatal("Error unmarshalling OIDC config.")
+		}
+		if err := oidcConfig.Validate(); err != nil {
+			logrus.WithError(err).Fatal("Error invalid OIDC config.")
+		}
+
+		cookieSecretRaw, err := loadToken(o.cookieSecretFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not read cookie secret file.")
+		}
+		decodedSecret, err := base64.StdEncoding.DecodeString(string(cookieSecretRaw))
+		if err != nil {
+			logrus.WithError(err).Fatal("Error decoding cookie secret")
+		}
+		if len(decodedSecret) == 0 {
+			logrus.Fatal("Cookie secret should not be empty")
+		}
+
+		oa, err = oidcauth.NewAgent(context.Background(), oidcConfig, sessions.NewCookieStore(decodedSecret), nil, logrus.WithField("client", "oidcauth"))
+		if err != nil {
+			logrus.WithError(err).Fatal("Error setting up OIDC login.")
+		}
+		// Handles login request.
+		mux.Handle("/oidc-login", oa.HandleLogin(secure))
+		// Handles redirect from the OIDC provider.
+		mux.Handle("/oidc-login/redirect", oa.HandleRedirect(secure))
+		mux.Handle("/oidc-logout", oa.HandleLogout())
+	}
+
+	mux.Handle(tenantPathPrefix, handleTenant(cfg, mux, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, logrus.WithField("handler", tenantPathPrefix)))
+	mux.Handle("/rerun", gziphandler.GzipHandler(handleRerun(cfg, prowJobClient, o.rerunCreatesJob, authCfgGetter, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/rerun"))))
+	mux.Handle("/abort", gziphandler.GzipHandler(handleAbort(prowJobClient, authCfgGetter, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/abort"))))
+	mux.Handle("/approve", gziphandler.GzipHandler(handleApprove(cfg, prowJobClient, authCfgGetter, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/approve"))))
+
+	opener, err := io.NewOpener(context.TODO(), o.storage.GCSCredentialsFile, o.storage.S3CredentialsFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error creating opener")
+	}
+	mux.Handle("/quarantine", gziphandler.GzipHandler(handleQuarantine(o, cfg, opener, logrus.WithField("handler", "/quarantine"))))
+	mux.Handle("/api/quarantine", gziphandler.GzipHandler(handleQuarantineAPI(cfg, opener, authCfgGetter, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/api/quarantine"))))
+	if goa != nil && githubClient != nil {
+		commenter := func(accessToken string) (interface {
+			CreateComment(org, repo string, number int, comment string) error
+		}, error) {
+			return o.github.GitHubClientWithAccessToken(accessToken)
+		}
+		mux.Handle("/ok-to-test", gziphandler.GzipHandler(handleOkToTest(o, cfg, githubClient, pluginAgent, logrus.WithField("handler", "/ok-to-test"))))
+		mux.Handle("/api/ok-to-test", gziphandler.GzipHandler(handleOkToTestAPI(cfg, goa, commenter, logrus.WithField("handler", "/api/ok-to-test"))))
+	}
+	if githubClient != nil {
+		mux.Handle("/job-editor", gziphandler.GzipHandler(handleJobEditor(o, cfg, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, logrus.WithField("handler", "/job-editor"))))
+		mux.Handle("/api/job-editor/validate", gziphandler.GzipHandler(handleJobEditorValidate(o, cfg, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, logrus.WithField("handler", "/api/job-editor/validate"))))
+		mux.Handle("/api/job-editor/propose", gziphandler.GzipHandler(handleJobEditorPropose(o, cfg, goa, oa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, logrus.WithField("handler", "/api/job-editor/propose"))))
+	}
 
 	// optionally inject http->https redirect handler when behind loadbalancer
 	if o.redirectHTTPTo != "" {
@@ -689,15 +844,32 @@ func initSpyglass(cfg config.Getter, o options, mux *http.ServeMux, ja *jobs.Job
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating opener")
 	}
+	// Lenses discovered from the lens registries are added to the config
+	// used by spyglass. The local lenses are only ever configured.
+	localCfg := cfg
+	lra := &lensRegistryAgent{
+		log:    logrus.WithField("agent", "lens-registry"),
+		cfg:    cfg,
+		source: &httpLensRegistrySource{client: &http.Client{Timeout: time.Minute}},
+	}
+	lra.start()
+	cfg = lra.config
 	sg := spyglass.New(ctx, ja, cfg, opener, o.gcsCookieAuth)
 	sg.Start()
 
 	mux.Handle("/spyglass/static/", http.StripPrefix("/spyglass/static", staticHandlerFromDir(o.spyglassFilesLocation)))
 	mux.Handle("/spyglass/lens/", gziphandler.GzipHandler(http.StripPrefix("/spyglass/lens/", handleArtifactView(o, sg, cfg))))
+	mux.Handle("/spyglass/artifacts/", gziphandler.GzipHandler(handleArtifactDir(sg, cfg, logrus.WithField("handler", "/spyglass/artifacts"))))
 	mux.Handle("/view/", gziphandler.GzipHandler(handleRequestJobViews(sg, cfg, o, logrus.WithField("handler", "/view"))))
+	mux.Handle("/permalink", handlePermalink(sg, cfg, logrus.WithField("handler", "/permalink")))
 	mux.Handle("/job-history/", gziphandler.GzipHandler(handleJobHistory(o, cfg, opener, logrus.WithField("handler", "/job-history"))))
+	aggregator, err := newJobHistoryAggregator(cfg, opener)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error creating job history aggregator")
+	}
+	mux.Handle("/job-history-stats/", gziphandler.GzipHandler(handleJobHistoryStats(cfg, aggregator, logrus.WithField("handler", "/job-history-stats"))))
 	mux.Handle("/pr-history/", gziphandler.GzipHandler(handlePRHistory(o, cfg, opener, gitHubClient, gitClient, logrus.WithField("handler", "/pr-history"))))
-	if err := initLocalLensHandler(cfg, o, sg); err != nil {
+	if err := initLocalLensHandler(localCfg, o, sg); err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize local lens handler")
 	}
 }
@@ -777,10 +949,15 @@ func handleNotCached(next http.Handler) http.HandlerFunc {
 	}
 }
 
-func handleProwJobs(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+func handleProwJobs(cfg config.Getter, ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
 		jobs := ja.ProwJobs()
+		if tenant := requestTenant(r); tenant != nil {
+			jobs = filterTenantProwJobs(tenant, jobs)
+		} else {
+			jobs = filterRestrictedProwJobs(restrictedTenants(cfg()), jobs)
+		}
 		omit := r.URL.Query().Get("omit")
 
 		if set := sets.New[string](strings.Split(omit, ",")...); set.Len() > 0 {
@@ -824,10 +1001,10 @@ func handleProwJobs(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
 	}
 }
 
-func handleData(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+func handleData(cfg config.Getter, ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
-		jobs := ja.Jobs()
+		jobs := filterRestrictedJobs(restrictedTenants(cfg()), ja.Jobs(), ja.ProwJobs())
 		jd, err := json.Marshal(jobs)
 		if err != nil {
 			log.WithError(err).Error("Error marshaling jobs.")
@@ -841,25 +1018,48 @@ func handleData(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
 // The url must look like this, where `jobs` is a comma-separated
 // list of globs:
 //
-// /badge.svg?jobs=<glob>[,<glob2>]
+// /badge.svg?jobs=<glob>[,<glob2>][&branch=<branch>][&window=<duration>]
+//
+// `job` can be used instead of `jobs`. With `branch`, only runs against
+// that base branch are considered. With `window`, e.g. 24h or 7d, the
+// badge shows the pass rate of the runs completed within the window
+// instead of the status of the latest runs. /badge.json serves the same
+// badge in the format of shields.io endpoint badges.
 //
 // Examples:
 // - /badge.svg?jobs=pull-kubernetes-bazel-build
 // - /badge.svg?jobs=pull-kubernetes-*
 // - /badge.svg?jobs=pull-kubernetes-e2e*,pull-kubernetes-*,pull-kubernetes-integration-*
-func handleBadge(ja *jobs.JobAgent) http.HandlerFunc {
+// - /badge.svg?job=post-test-infra-push&branch=main&window=7d
+func handleBadge(cfg config.Getter, ja *jobs.JobAgent, asJSON bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		setHeadersNoCaching(w)
-		wantJobs := r.URL.Query().Get("jobs")
-		if wantJobs == "" {
-			http.Error(w, "missing jobs query parameter", http.StatusBadRequest)
+		q, err := parseBadgeQuery(r.URL.Query())
+		if err != nil {
+			setHeadersNoCaching(w)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeMaxAge.Seconds())))
+
+		subject, status, color := badgeFor(filterRestrictedProwJobs(restrictedTenants(cfg()), ja.ProwJobs()), q, time.Now())
+		if asJSON {
+			b, err := json.Marshal(shieldsEndpoint{
+				SchemaVersion: 1,
+				Label:         subject,
+				Message:       status,
+				Color:         color,
+				CacheSeconds:  int(badgeMaxAge.Seconds()),
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(b)
 			return
 		}
 		w.Header().Set("Content-Type", "image/svg+xml")
-
-		allJobs := ja.ProwJobs()
-		_, _, svg := renderBadge(pickLatestJobs(allJobs, wantJobs))
-		w.Write(svg)
+		w.Write(makeShield(subject, status, color))
 	}
 }
 
@@ -886,6 +1086,10 @@ func handleBadge(ja *jobs.JobAgent) http.HandlerFunc {
 func handleJobHistory(o options, cfg config.Getter, opener io.Opener, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
+		if err := checkJobHistoryTenant(r, cfg(), r.URL); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
 		tmpl, err := getJobHistory(r.Context(), r.URL, cfg, opener)
 		if err != nil {
 			msg := fmt.Sprintf("failed to get job history: %v", err)
@@ -963,6 +1167,47 @@ func handleRequestJobViews(sg *spyglass.Spyglass, cfg config.Getter, o options,
 	}
 }
 
+// handleArtifactDir lists a directory of the artifacts of a job as JSON, so
+// that the artifact tree can be expanded one directory at a time:
+//
+// /spyglass/artifacts/<key-type>/<key>?dir=<dir>&sort=<name|size|updated>
+func handleArtifactDir(sg *spyglass.Spyglass, cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		src := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/spyglass/artifacts/"), "/")
+		if err := validateStoragePath(cfg, src); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to process request: %v", err), httpStatusForError(err))
+			return
+		}
+		realPath, err := sg.ResolveSymlink(src)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error when resolving real path %s: %v", src, err), httpStatusForError(err))
+			return
+		}
+		entries, err := sg.ListArtifactDir(r.Context(), realPath, r.URL.Query().Get("dir"), r.URL.Query().Get("sort"))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, spyglass.ErrInvalidArtifactQuery) {
+				status = http.StatusBadRequest
+			}
+			msg := fmt.Sprintf("error listing artifacts: %v", err)
+			if shouldLogHTTPErrors(err) {
+				log.WithError(err).Debug(msg)
+			}
+			http.Error(w, msg, status)
+			return
+		}
+		b, err := json.Marshal(entries)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling artifact listing.")
+			http.Error(w, "error marshaling artifact listing", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, string(b))
+	}
+}
+
 // renderSpyglass returns a pre-rendered Spyglass page from the given source string
 func renderSpyglass(ctx context.Context, sg *spyglass.Spyglass, cfg config.Getter, src string, o options, csrfToken string, log *logrus.Entry) (string, error) {
 	renderStart := time.Now()
@@ -981,11 +1226,19 @@ func renderSpyglass(ctx context.Context, sg *spyglass.Spyglass, cfg config.Gette
 		log.Infof("found no artifacts for %s", src)
 	}
 
+	runOrg, runRepo, err := sg.RunToRepo(ctx, src)
+	if err != nil {
+		log.WithError(err).Debugf("Couldn't determine the repo of %q, using the lenses of all repos.", src)
+	}
+
 	regexCache := cfg().Deck.Spyglass.RegexCache
 	lensCache := map[int][]string{}
 	var lensIndexes []int
 lensesLoop:
 	for i, lfc := range cfg().Deck.Spyglass.Lenses {
+		if !cfg().Deck.Spyglass.LensEnabled(lfc.Lens.Name, runOrg, runRepo) {
+			continue
+		}
 		matches := sets.Set[string]{}
 		for _, re := range lfc.RequiredFiles {
 			found := false
@@ -1156,7 +1409,7 @@ lensesLoop:
 	}
 	t := template.New("spyglass.html")
 
-	if _, err := prepareBaseTemplate(o, cfg, csrfToken, t); err != nil {
+	if _, err := prepareBaseTemplate(o, cfg, csrfToken, "", t); err != nil {
 		return "", fmt.Errorf("error preparing base template: %w", err)
 	}
 	t, err = t.ParseFiles(path.Join(o.templateFilesLocation, "spyglass.html"))
@@ -1209,12 +1462,14 @@ func handleArtifactView(o options, sg *spyglass.Spyglass, cfg config.Getter) htt
 		resource := pathSegments[1]
 
 		var lens *config.LensFileConfig
-		for _, configLens := range cfg().Deck.Spyglass.Lenses {
+		lensIndex := -1
+		for i, configLens := range cfg().Deck.Spyglass.Lenses {
 			if configLens.Lens.Name == lensName {
 
 				// Directly followed by break, so this is ok
 				// nolint: exportloopref
 				lens = &configLens
+				lensIndex = i
 				break
 			}
 		}
@@ -1234,11 +1489,32 @@ func handleArtifactView(o options, sg *spyglass.Spyglass, cfg config.Getter) htt
 			return
 		}
 
-		handleRemoteLens(*lens, w, r, resource, request)
+		// The same lens can be configured several times for different files.
+		if request.Index >= 0 && request.Index < len(cfg().Deck.Spyglass.Lenses) && cfg().Deck.Spyglass.Lenses[request.Index].Lens.Name == lensName {
+			lensIndex = request.Index
+		}
+		org, repo, err := sg.RunToRepo(r.Context(), request.Source)
+		if err != nil {
+			logrus.WithError(err).WithField("src", request.Source).Debug("Couldn't determine the repo of the run, using the lens config of all repos.")
+		}
+		lensConfig, err := cfg().Deck.Spyglass.LensConfig(lensIndex, org, repo)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to resolve the lens config: %v", err), http.StatusInternalServerError)
+			return
+		}
+		resolved := *lens
+		resolved.Lens.Config = lensConfig
+
+		handleRemoteLens(resolved, w, r, resource, request, sg, cfg().Deck.Spyglass.SizeLimit)
 	}
 }
 
-func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http.Request, resource string, request spyglass.LensRequest) {
+// lensArtifactFetcher fetches the artifacts pushed to lenses.
+type lensArtifactFetcher interface {
+	FetchArtifacts(ctx context.Context, src string, podName string, sizeLimit int64, artifactNames []string) ([]spyglassapi.Artifact, error)
+}
+
+func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http.Request, resource string, request spyglass.LensRequest, fetcher lensArtifactFetcher, sizeLimit int64) {
 	var requestType spyglassapi.RequestAction
 	switch resource {
 	case "iframe":
@@ -1271,6 +1547,23 @@ func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http
 		ArtifactSource: request.Source,
 		LensIndex:      request.Index,
 	}
+	if lens.RemoteConfig.StaticRoot != "" {
+		lensRequest.ResourceRoot = lens.RemoteConfig.StaticRoot
+	}
+	if timeout := lens.RemoteConfig.Timeout; timeout != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout.Duration)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+	if lens.RemoteConfig.ProtocolVersion == spyglassapi.ProtocolVersionV1 {
+		artifacts, err := fetcher.FetchArtifacts(r.Context(), request.Source, "", sizeLimit, request.Artifacts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to retrieve expected artifacts: %v", err), http.StatusInternalServerError)
+			return
+		}
+		lensRequest.ProtocolVersion = spyglassapi.ProtocolVersionV1
+		lensRequest.Manifest = lensManifest(artifacts, lens.RemoteConfig.MaxArtifactBytes, sizeLimit)
+	}
 	serializedRequest, err := json.Marshal(lensRequest)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to marshal request to lens backend: %v", err), http.StatusInternalServerError)
@@ -1290,6 +1583,10 @@ func handleTidePools(cfg config.Getter, ta *tideAgent, log *logrus.Entry) http.H
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
 		queryConfigs := ta.filterQueries(cfg().Tide.Queries)
+		tenant := requestTenant(r)
+		if tenant != nil {
+			queryConfigs = filterTenantQueries(tenant, queryConfigs)
+		}
 		queries := make([]string, 0, len(queryConfigs))
 		for _, qc := range queryConfigs {
 			queries = append(queries, qc.Query())
@@ -1303,6 +1600,11 @@ func handleTidePools(cfg config.Getter, ta *tideAgent, log *logrus.Entry) http.H
 		for _, pool := range pools {
 			poolsForDeck = append(poolsForDeck, *tide.PoolToPoolForDeck(&pool))
 		}
+		if tenant != nil {
+			poolsForDeck = filterTenantPools(tenant, poolsForDeck)
+		} else {
+			poolsForDeck = filterRestrictedPools(restrictedTenants(cfg()), poolsForDeck)
+		}
 		payload := tidePools{
 			Queries:     queries,
 			TideQueries: queryConfigs,
@@ -1317,13 +1619,18 @@ func handleTidePools(cfg config.Getter, ta *tideAgent, log *logrus.Entry) http.H
 	}
 }
 
-func handleTideHistory(ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
+func handleTideHistory(cfg config.Getter, ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
 
 		ta.Lock()
 		history := ta.history
 		ta.Unlock()
+		if tenant := requestTenant(r); tenant != nil {
+			history = filterTenantHistory(tenant, history)
+		} else {
+			history = filterRestrictedHistory(restrictedTenants(cfg()), history)
+		}
 
 		payload := tideHistory{
 			History: history,
@@ -1399,6 +1706,39 @@ func handleLog(lc logClient, log *logrus.Entry) http.HandlerFunc {
 	}
 }
 
+type pipelineStatusClient interface {
+	GetPipelineStatus(job, id string) (*jobs.PipelineStatus, error)
+}
+
+// handlePipelineStatus serves the live status of the TaskRuns and steps of a
+// job with the tekton-pipeline agent. The log of each step is served by /log
+// with the log of the step as the container.
+func handlePipelineStatus(pc pipelineStatusClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		job := r.URL.Query().Get("job")
+		id := r.URL.Query().Get("id")
+		logger := log.WithFields(logrus.Fields{"job": job, "id": id})
+		if err := validateLogRequest(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, err := pc.GetPipelineStatus(job, id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Pipeline status not found: %v", err), http.StatusNotFound)
+			logger.WithError(err).Info("Pipeline status not found.")
+			return
+		}
+		b, err := json.Marshal(status)
+		if err != nil {
+			logger.WithError(err).Error("Error marshaling pipeline status.")
+			http.Error(w, "Error marshaling pipeline status.", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
 func validateLogRequest(r *http.Request) error {
 	job := r.URL.Query().Get("job")
 	id := r.URL.Query().Get("id")
@@ -1574,6 +1914,11 @@ type deckGitHubClient interface {
 	GetPullRequest(org, repo string, number int) (*prowgithub.PullRequest, error)
 	GetRef(org, repo, ref string) (string, error)
 	BotUserChecker() (func(candidate string) bool, error)
+	GetUserPermission(org, repo, user string) (string, error)
+	GetCombinedStatus(org, repo, ref string) (*prowgithub.CombinedStatus, error)
+	branchProtectionClient
+	okToTestClient
+	jobEditorClient
 }
 
 func spglassConfigDefaulting(c *config.Config) error {
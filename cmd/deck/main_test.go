@@ -329,7 +329,7 @@ func TestHandleProwJobs(t *testing.T) {
 	fakeJa := jobs.NewJobAgent(context.Background(), kc, false, true, []string{}, map[string]jobs.PodLogClient{}, fca{}.Config)
 	fakeJa.Start()
 
-	handler := handleProwJobs(fakeJa, logrus.WithField("handler", "/prowjobs.js"))
+	handler := handleProwJobs(fca{}.Config, fakeJa, logrus.WithField("handler", "/prowjobs.js"))
 	req, err := http.NewRequest(http.MethodGet, "/prowjobs.js?omit=annotations,labels,decoration_config,pod_spec", nil)
 	if err != nil {
 		t.Fatalf("Error making request: %v", err)
@@ -539,7 +539,7 @@ func TestTideHistory(t *testing.T) {
 		t.Fatalf("Expected tideAgent history:\n%#v\n,but got:\n%#v\n", testHist, ta.history)
 	}
 
-	handler := handleTideHistory(&ta, logrus.WithField("handler", "/tide-history.js"))
+	handler := handleTideHistory(ta.cfg, &ta, logrus.WithField("handler", "/tide-history.js"))
 	req, err := http.NewRequest(http.MethodGet, "/tide-history.js", nil)
 	if err != nil {
 		t.Fatalf("Error making request: %v", err)
@@ -0,0 +1,290 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const (
+	// okToTestStatsWindow is how far back approved PRs count towards the
+	// time-to-ok-to-test stats.
+	okToTestStatsWindow = 30 * 24 * time.Hour
+	// okToTestStatsSample is how many of the latest approved PRs the stats
+	// are computed from, as their label events are listed one PR at a time.
+	okToTestStatsSample = 50
+)
+
+// okToTestClient is the part of the GitHub client the ok-to-test view needs.
+type okToTestClient interface {
+	FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error)
+	ListIssueEvents(org, repo string, num int) ([]github.ListedIssueEvent, error)
+}
+
+// okToTestTemplate is the data rendered by ok-to-test.html.
+type okToTestTemplate struct {
+	Org     string
+	Pending []pendingOkToTest
+	Stats   okToTestStats
+	Trust   []repoTrust
+}
+
+// pendingOkToTest is a PR of an untrusted author that awaits /ok-to-test.
+type pendingOkToTest struct {
+	Repo    string
+	Number  int
+	Title   string
+	URL     string
+	Author  string
+	Created time.Time
+	Waiting time.Duration
+}
+
+// okToTestStats are the times PRs of the org waited for /ok-to-test.
+type okToTestStats struct {
+	Approved int
+	Median   time.Duration
+	P90      time.Duration
+	Error    string
+}
+
+// repoTrust is the trigger policy deciding whose PRs are trusted in a repo.
+type repoTrust struct {
+	Repo           string
+	TrustedOrg     string
+	TrustedApps    []string
+	OnlyOrgMembers bool
+	IgnoreOkToTest bool
+}
+
+// handleOkToTest lists the PRs of untrusted authors in the org that await
+// /ok-to-test, with the trust policy of their repos and how long PRs waited
+// for /ok-to-test recently.
+//
+// /ok-to-test?org=<org>
+func handleOkToTest(o options, cfg config.Getter, ghc okToTestClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		tmpl := okToTestTemplate{Org: r.URL.Query().Get("org")}
+		if tmpl.Org != "" {
+			if !sets.New(configuredOrgs(cfg())...).Has(tmpl.Org) {
+				http.Error(w, fmt.Sprintf("Prow isn't configured for the org %q.", tmpl.Org), http.StatusNotFound)
+				return
+			}
+			var err error
+			tmpl, err = getOkToTest(tmpl.Org, ghc, pluginAgent.Config(), time.Now())
+			if err != nil {
+				log.WithError(err).WithField("org", tmpl.Org).Warn("Failed to list the PRs awaiting ok-to-test.")
+				http.Error(w, "Failed to list the PRs awaiting ok-to-test.", http.StatusInternalServerError)
+				return
+			}
+		}
+		handleSimpleTemplate(o, cfg, "ok-to-test.html", tmpl)(w, r)
+	}
+}
+
+// configuredOrgs returns the orgs of the repos Prow knows.
+func configuredOrgs(cfg *config.Config) []string {
+	orgs := sets.New[string]()
+	for repo := range cfg.AllRepos {
+		if org, _, found := strings.Cut(repo, "/"); found {
+			orgs.Insert(org)
+		}
+	}
+	return sets.List(orgs)
+}
+
+func getOkToTest(org string, ghc okToTestClient, pluginsCfg *plugins.Configuration, now time.Time) (okToTestTemplate, error) {
+	tmpl := okToTestTemplate{Org: org}
+	issues, err := ghc.FindIssuesWithOrg(org, fmt.Sprintf("is:pr is:open archived:false org:%s label:%s", org, labels.NeedsOkToTest), "created", true)
+	if err != nil {
+		return tmpl, err
+	}
+	repos := sets.New[string]()
+	for _, issue := range issues {
+		repo, err := repoOfPullRequestURL(issue.HTMLURL)
+		if err != nil {
+			logrus.WithError(err).WithField("url", issue.HTMLURL).Debug("Skipping PR of an unknown repo.")
+			continue
+		}
+		repos.Insert(repo)
+		tmpl.Pending = append(tmpl.Pending, pendingOkToTest{
+			Repo:    repo,
+			Number:  issue.Number,
+			Title:   issue.Title,
+			URL:     issue.HTMLURL,
+			Author:  issue.User.Login,
+			Created: issue.CreatedAt,
+			Waiting: now.Sub(issue.CreatedAt).Truncate(time.Minute),
+		})
+	}
+	for _, repo := range sets.List(repos) {
+		_, name, _ := strings.Cut(repo, "/")
+		trigger := pluginsCfg.TriggerFor(org, name)
+		tmpl.Trust = append(tmpl.Trust, repoTrust{
+			Repo:           repo,
+			TrustedOrg:     trigger.TrustedOrg,
+			TrustedApps:    trigger.TrustedApps,
+			OnlyOrgMembers: trigger.OnlyOrgMembers,
+			IgnoreOkToTest: trigger.IgnoreOkToTest,
+		})
+	}
+	tmpl.Stats = getOkToTestStats(org, ghc, now)
+	return tmpl, nil
+}
+
+// getOkToTestStats measures how long the latest PRs of the org that got
+// /ok-to-test waited for it, from their creation to the first time they
+// were labeled ok-to-test.
+func getOkToTestStats(org string, ghc okToTestClient, now time.Time) okToTestStats {
+	var stats okToTestStats
+	since := now.Add(-okToTestStatsWindow).Format("2006-01-02")
+	issues, err := ghc.FindIssuesWithOrg(org, fmt.Sprintf("is:pr archived:false org:%s label:%s created:>=%s", org, labels.OkToTest, since), "created", false)
+	if err != nil {
+		stats.Error = fmt.Sprintf("failed to search the approved PRs: %v", err)
+		return stats
+	}
+	if len(issues) > okToTestStatsSample {
+		issues = issues[:okToTestStatsSample]
+	}
+	var waits []time.Duration
+	for _, issue := range issues {
+		repo, err := repoOfPullRequestURL(issue.HTMLURL)
+		if err != nil {
+			continue
+		}
+		repoOrg, name, _ := strings.Cut(repo, "/")
+		events, err := ghc.ListIssueEvents(repoOrg, name, issue.Number)
+		if err != nil {
+			stats.Error = fmt.Sprintf("failed to list the events of %s#%d: %v", repo, issue.Number, err)
+			continue
+		}
+		for _, event := range events {
+			if event.Event == github.IssueActionLabeled && event.Label.Name == labels.OkToTest {
+				waits = append(waits, event.CreatedAt.Sub(issue.CreatedAt))
+				break
+			}
+		}
+	}
+	stats.Approved = len(waits)
+	if len(waits) == 0 {
+		return stats
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	stats.Median = waits[len(waits)/2].Truncate(time.Minute)
+	stats.P90 = waits[(len(waits)*9)/10].Truncate(time.Minute)
+	return stats
+}
+
+// repoOfPullRequestURL returns the org/repo of a PR from its HTML URL, like
+// https://github.com/org/repo/pull/1.
+func repoOfPullRequestURL(htmlURL string) (string, error) {
+	u, err := url.Parse(htmlURL)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] != "pull" {
+		return "", fmt.Errorf("not the URL of a pull request: %s", htmlURL)
+	}
+	return parts[0] + "/" + parts[1], nil
+}
+
+// okToTestCommenter posts comments under the identity of the user of an
+// access token.
+type okToTestCommenter func(accessToken string) (interface {
+	CreateComment(org, repo string, number int, comment string) error
+}, error)
+
+// handleOkToTestAPI comments /ok-to-test on a PR under the identity of the
+// GitHub user logged into Deck, so trigger only accepts it from users it
+// trusts.
+//
+// POST /api/ok-to-test?repo=<org>/<repo>&number=<number>
+func handleOkToTestAPI(cfg config.Getter, goa *githuboauth.Agent, commenter okToTestCommenter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		if goa == nil {
+			http.Error(w, "GitHub login isn't configured.", http.StatusNotFound)
+			return
+		}
+		org, repo, number, err := parseOkToTestRequest(r.URL.Query(), cfg())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l := log.WithFields(logrus.Fields{"org": org, "repo": repo, "pr": number})
+		accessToken, err := goa.GetAccessToken(r)
+		if err != nil {
+			setLoginURL(w, nil)
+			http.Error(w, "Log in with GitHub to approve PRs.", http.StatusUnauthorized)
+			return
+		}
+		client, err := commenter(accessToken)
+		if err != nil {
+			l.WithError(err).Warn("Failed to create a GitHub client for the user.")
+			http.Error(w, "Failed to create a GitHub client.", http.StatusInternalServerError)
+			return
+		}
+		if err := client.CreateComment(org, repo, number, "/ok-to-test"); err != nil {
+			l.WithError(err).Info("Failed to comment /ok-to-test.")
+			http.Error(w, fmt.Sprintf("Failed to comment /ok-to-test: %v.", err), http.StatusBadGateway)
+			return
+		}
+		l.Info("Commented /ok-to-test for the logged in user.")
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func parseOkToTestRequest(query url.Values, cfg *config.Config) (string, string, int, error) {
+	fullRepo := query.Get("repo")
+	if fullRepo == "" {
+		return "", "", 0, errors.New("Request did not provide the 'repo' query parameter.")
+	}
+	org, repo, err := config.SplitRepoName(fullRepo)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if !sets.New(configuredOrgs(cfg)...).Has(org) {
+		return "", "", 0, fmt.Errorf("Prow isn't configured for the org %q.", org)
+	}
+	number, err := strconv.Atoi(query.Get("number"))
+	if err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("Invalid PR number %q.", query.Get("number"))
+	}
+	return org, repo, number, nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+type fakeOkToTestClient struct {
+	pending  []github.Issue
+	approved []github.Issue
+	events   map[int][]github.ListedIssueEvent
+}
+
+func (f *fakeOkToTestClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error) {
+	if strings.Contains(query, "label:"+labels.NeedsOkToTest) {
+		return f.pending, nil
+	}
+	return f.approved, nil
+}
+
+func (f *fakeOkToTestClient) ListIssueEvents(org, repo string, num int) ([]github.ListedIssueEvent, error) {
+	return f.events[num], nil
+}
+
+func TestGetOkToTest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pr := func(repo string, number int, author string, created time.Time) github.Issue {
+		return github.Issue{
+			Number:    number,
+			Title:     "Fix things",
+			HTMLURL:   fmt.Sprintf("https://github.com/%s/pull/%d", repo, number),
+			User:      github.User{Login: author},
+			CreatedAt: created,
+		}
+	}
+	labeled := func(label string, at time.Time) github.ListedIssueEvent {
+		return github.ListedIssueEvent{Event: github.IssueActionLabeled, Label: github.Label{Name: label}, CreatedAt: at}
+	}
+	ghc := &fakeOkToTestClient{
+		pending: []github.Issue{
+			pr("org/repo", 1, "newcomer", now.Add(-90*time.Minute)),
+			pr("org/other", 2, "stranger", now.Add(-time.Hour)),
+			{Number: 3, HTMLURL: "https://github.com/org/repo/issues/3"},
+		},
+		approved: []github.Issue{
+			pr("org/repo", 4, "a", now.Add(-48*time.Hour)),
+			pr("org/repo", 5, "b", now.Add(-48*time.Hour)),
+			pr("org/repo", 6, "c", now.Add(-48*time.Hour)),
+		},
+		events: map[int][]github.ListedIssueEvent{
+			4: {labeled(labels.NeedsOkToTest, now.Add(-48*time.Hour)), labeled(labels.OkToTest, now.Add(-47*time.Hour))},
+			5: {labeled(labels.OkToTest, now.Add(-46*time.Hour)), labeled(labels.OkToTest, now.Add(-time.Hour))},
+			6: {labeled(labels.OkToTest, now.Add(-38*time.Hour))},
+		},
+	}
+	pluginsCfg := &plugins.Configuration{Triggers: []plugins.Trigger{
+		{Repos: []string{"org"}, OnlyOrgMembers: true},
+		{Repos: []string{"org/other"}, TrustedApps: []string{"dependabot"}},
+	}}
+
+	got, err := getOkToTest("org", ghc, pluginsCfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := okToTestTemplate{
+		Org: "org",
+		Pending: []pendingOkToTest{
+			{Repo: "org/repo", Number: 1, Title: "Fix things", URL: "https://github.com/org/repo/pull/1", Author: "newcomer", Created: now.Add(-90 * time.Minute), Waiting: 90 * time.Minute},
+			{Repo: "org/other", Number: 2, Title: "Fix things", URL: "https://github.com/org/other/pull/2", Author: "stranger", Created: now.Add(-time.Hour), Waiting: time.Hour},
+		},
+		Stats: okToTestStats{Approved: 3, Median: 2 * time.Hour, P90: 10 * time.Hour},
+		Trust: []repoTrust{
+			{Repo: "org/other", TrustedApps: []string{"dependabot"}},
+			{Repo: "org/repo", OnlyOrgMembers: true},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestRepoOfPullRequestURL(t *testing.T) {
+	testCases := []struct {
+		url         string
+		expected    string
+		expectedErr bool
+	}{
+		{url: "https://github.com/org/repo/pull/1", expected: "org/repo"},
+		{url: "https://ghe.example.com/org/repo/pull/12/", expected: "org/repo"},
+		{url: "https://github.com/org/repo/issues/1", expectedErr: true},
+		{url: "https://github.com/org/repo", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			got, err := repoOfPullRequestURL(tc.url)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestOkToTestAPI(t *testing.T) {
+	testCases := []struct {
+		name             string
+		method           string
+		query            string
+		loggedIn         bool
+		expectedCode     int
+		expectedComments []string
+	}{
+		{
+			name:             "approve a PR",
+			method:           http.MethodPost,
+			query:            "repo=org/repo&number=1",
+			loggedIn:         true,
+			expectedCode:     http.StatusCreated,
+			expectedComments: []string{"/ok-to-test"},
+		},
+		{
+			name:         "not logged in",
+			method:       http.MethodPost,
+			query:        "repo=org/repo&number=1",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "unknown org",
+			method:       http.MethodPost,
+			query:        "repo=other/repo&number=1",
+			loggedIn:     true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid number",
+			method:       http.MethodPost,
+			query:        "repo=org/repo&number=abc",
+			loggedIn:     true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GET isn't allowed",
+			method:       http.MethodGet,
+			query:        "repo=org/repo&number=1",
+			loggedIn:     true,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{JobConfig: config.JobConfig{AllRepos: sets.New("org/repo")}}
+			req := httptest.NewRequest(tc.method, "/api/ok-to-test?"+tc.query, nil)
+			mockCookieStore := sessions.NewCookieStore([]byte("secret-key"))
+			if tc.loggedIn {
+				session, err := sessions.GetRegistry(req).Get(mockCookieStore, "access-token-session")
+				if err != nil {
+					t.Fatalf("Error making access token session: %v", err)
+				}
+				session.Values["access-token"] = &oauth2.Token{AccessToken: "validtoken"}
+			}
+			goa := githuboauth.NewAgent(&githuboauth.Config{CookieStore: mockCookieStore}, logrus.NewEntry(logrus.StandardLogger()))
+			ghc := fakegithub.NewFakeClient()
+			var usedToken string
+			commenter := func(accessToken string) (interface {
+				CreateComment(org, repo string, number int, comment string) error
+			}, error) {
+				usedToken = accessToken
+				return ghc, nil
+			}
+
+			rr := httptest.NewRecorder()
+			handleOkToTestAPI(func() *config.Config { return cfg }, goa, commenter, logrus.WithField("handler", "/api/ok-to-test")).ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			var comments []string
+			for _, comment := range ghc.IssueComments[1] {
+				comments = append(comments, comment.Body)
+			}
+			if diff := cmp.Diff(tc.expectedComments, comments); diff != "" {
+				t.Errorf("unexpected comments (-want +got):\n%s", diff)
+			}
+			if tc.expectedComments != nil && usedToken != "validtoken" {
+				t.Errorf("expected the comment to use the token of the user, got %q", usedToken)
+			}
+		})
+	}
+}
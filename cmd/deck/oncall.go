@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// onCallSource returns the logins on call for every team.
+type onCallSource interface {
+	OnCall(ctx context.Context, cfg *config.OnCall) (map[string][]string, error)
+}
+
+// urlOnCallSource fetches the on-call from the URL of the config.
+type urlOnCallSource struct {
+	client *http.Client
+}
+
+func (s *urlOnCallSource) OnCall(ctx context.Context, cfg *config.OnCall) (map[string][]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("response has status code %d", resp.StatusCode)
+	}
+	var teams map[string][]string
+	if err := json.NewDecoder(resp.Body).Decode(&teams); err != nil {
+		return nil, fmt.Errorf("error decoding on-call: %w", err)
+	}
+	return teams, nil
+}
+
+// onCall is the on-call served to the frontend.
+type onCall struct {
+	// Teams maps team names to the logins on call.
+	Teams map[string][]string
+	// RepoTeams maps org or org/repo to the team on call for the repos.
+	RepoTeams map[string]string
+}
+
+// onCallAgent periodically fetches the on-call if it's configured.
+type onCallAgent struct {
+	log    *logrus.Entry
+	cfg    config.Getter
+	source onCallSource
+
+	sync.Mutex
+	teams map[string][]string
+}
+
+func (oa *onCallAgent) start() {
+	go func() {
+		for {
+			start := time.Now()
+			period := 5 * time.Minute
+			if cfg := oa.cfg().Deck.OnCall; cfg != nil {
+				period = cfg.UpdatePeriod.Duration
+				if err := oa.update(cfg); err != nil {
+					oa.log.WithError(err).Warn("Updating on-call.")
+				}
+			}
+			time.Sleep(time.Until(start.Add(period)))
+		}
+	}()
+}
+
+func (oa *onCallAgent) update(cfg *config.OnCall) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	teams, err := oa.source.OnCall(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	oa.Lock()
+	defer oa.Unlock()
+	oa.teams = teams
+	return nil
+}
+
+// onCall returns the current on-call, or nil if it isn't configured.
+func (oa *onCallAgent) onCall() *onCall {
+	cfg := oa.cfg().Deck.OnCall
+	if cfg == nil {
+		return nil
+	}
+	oa.Lock()
+	defer oa.Unlock()
+	return &onCall{Teams: oa.teams, RepoTeams: cfg.Teams}
+}
+
+func handleOnCall(oa *onCallAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		pd, err := json.Marshal(oa.onCall())
+		if err != nil {
+			log.WithError(err).Error("Error marshaling payload.")
+			pd = []byte("null")
+		}
+		writeJSONResponse(w, r, pd)
+	}
+}
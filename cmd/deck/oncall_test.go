@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestHandleOnCall(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ci": ["alice"], "infra": ["bob", "carol"]}`))
+	}))
+	defer source.Close()
+
+	testCases := []struct {
+		name     string
+		onCall   *config.OnCall
+		expected string
+	}{
+		{
+			name:     "not configured",
+			expected: "var oncallData = null;",
+		},
+		{
+			name:     "configured",
+			onCall:   &config.OnCall{URL: source.URL, Teams: map[string]string{"*": "ci", "org/infra": "infra"}},
+			expected: `var oncallData = {"Teams":{"ci":["alice"],"infra":["bob","carol"]},"RepoTeams":{"*":"ci","org/infra":"infra"}};`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{OnCall: tc.onCall}}}
+			oa := &onCallAgent{
+				log:    logrus.WithField("agent", "oncall"),
+				cfg:    func() *config.Config { return cfg },
+				source: &urlOnCallSource{client: source.Client()},
+			}
+			if tc.onCall != nil {
+				if err := oa.update(tc.onCall); err != nil {
+					t.Fatalf("failed to update on-call: %v", err)
+				}
+			}
+			rr := httptest.NewRecorder()
+			handleOnCall(oa, logrus.WithField("handler", "/oncall.js")).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/oncall.js?var=oncallData", nil))
+			if rr.Body.String() != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, rr.Body.String())
+			}
+		})
+	}
+}
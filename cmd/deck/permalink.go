@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+// permalink is a link to a build or an artifact of a build that doesn't
+// depend on where the artifacts are stored.
+type permalink struct {
+	job, build string
+	// artifact is the path of the artifact in the build, or empty for
+	// the build.
+	artifact string
+	// expiry is how long the signed URL of the artifact is valid for, or
+	// zero if the URL shouldn't be signed.
+	expiry time.Duration
+}
+
+func parsePermalink(query url.Values, maxExpiry time.Duration) (permalink, error) {
+	link := permalink{job: query.Get("job"), build: query.Get("build")}
+	if link.job == "" || link.build == "" || strings.Contains(link.job, "/") || strings.Contains(link.build, "/") {
+		return link, errors.New("job and build are required")
+	}
+	if artifact := query.Get("path"); artifact != "" {
+		link.artifact = path.Clean(strings.TrimPrefix(artifact, "/"))
+		if link.artifact == ".." || strings.HasPrefix(link.artifact, "../") {
+			return link, fmt.Errorf("invalid path %q", artifact)
+		}
+	}
+	if expires := query.Get("expires"); expires != "" {
+		expiry, err := time.ParseDuration(expires)
+		if err != nil || expiry <= 0 {
+			return link, fmt.Errorf("invalid expires %q", expires)
+		}
+		switch {
+		case link.artifact == "":
+			return link, errors.New("only artifact URLs can be signed")
+		case maxExpiry == 0:
+			return link, errors.New("signing artifact URLs is disabled")
+		case expiry > maxExpiry:
+			return link, fmt.Errorf("expires can be at most %s", maxExpiry)
+		}
+		link.expiry = expiry
+	}
+	return link, nil
+}
+
+// handlePermalink redirects to the Spyglass page of a build, or to an
+// artifact of the build if a path is given. Artifact URLs are signed if
+// expires is given, so they can be shared with users who can't access the
+// bucket.
+func handlePermalink(sg *spyglass.Spyglass, cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		var maxExpiry time.Duration
+		if expiry := cfg().Deck.Spyglass.MaxSignedURLExpiry; expiry != nil {
+			maxExpiry = expiry.Duration
+		}
+		link, err := parsePermalink(r.URL.Query(), maxExpiry)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log := log.WithFields(logrus.Fields{"job": link.job, "build": link.build, "path": link.artifact})
+
+		buildPath, err := sg.ResolveBuild(link.job, link.build)
+		if err != nil {
+			log.WithError(err).Debug("Couldn't resolve permalink.")
+			http.Error(w, fmt.Sprintf("Build not found: %v", err), http.StatusNotFound)
+			return
+		}
+		if link.artifact == "" {
+			provider, key, _ := strings.Cut(buildPath, "://")
+			http.Redirect(w, r, fmt.Sprintf("/view/%s/%s", provider, key), http.StatusFound)
+			return
+		}
+
+		artifactURL, err := sg.SignArtifactURL(r.Context(), buildPath+"/"+link.artifact, link.expiry)
+		if err != nil {
+			log.WithError(err).Warn("Couldn't sign artifact URL.")
+			http.Error(w, "Couldn't create a URL for the artifact", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, artifactURL, http.StatusFound)
+	}
+}
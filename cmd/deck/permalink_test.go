@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParsePermalink(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		maxExpiry time.Duration
+		expected  permalink
+		expectErr bool
+	}{
+		{
+			name:     "build",
+			query:    "job=periodic&build=123",
+			expected: permalink{job: "periodic", build: "123"},
+		},
+		{
+			name:     "artifact",
+			query:    "job=periodic&build=123&path=/artifacts/./junit.xml",
+			expected: permalink{job: "periodic", build: "123", artifact: "artifacts/junit.xml"},
+		},
+		{
+			name:      "signed artifact",
+			query:     "job=periodic&build=123&path=build-log.txt&expires=1h",
+			maxExpiry: 24 * time.Hour,
+			expected:  permalink{job: "periodic", build: "123", artifact: "build-log.txt", expiry: time.Hour},
+		},
+		{
+			name:      "missing build",
+			query:     "job=periodic",
+			expectErr: true,
+		},
+		{
+			name:      "path outside of the build",
+			query:     "job=periodic&build=123&path=artifacts/../../124/build-log.txt",
+			expectErr: true,
+		},
+		{
+			name:      "signing is disabled",
+			query:     "job=periodic&build=123&path=build-log.txt&expires=1h",
+			expectErr: true,
+		},
+		{
+			name:      "expiry is too long",
+			query:     "job=periodic&build=123&path=build-log.txt&expires=48h",
+			maxExpiry: 24 * time.Hour,
+			expectErr: true,
+		},
+		{
+			name:      "build URL can't be signed",
+			query:     "job=periodic&build=123&expires=1h",
+			maxExpiry: 24 * time.Hour,
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("invalid query: %v", err)
+			}
+			actual, err := parsePermalink(query, tc.maxExpiry)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && actual != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
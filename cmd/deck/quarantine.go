@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/oidcauth"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/quarantine"
+)
+
+// quarantineEntry is a quarantined test shown by quarantine.html.
+type quarantineEntry struct {
+	prowapi.QuarantinedTest
+	// FromConfig is true for tests quarantined in the default decoration
+	// config of the repo, which can't be changed from Deck.
+	FromConfig bool
+	Expired    bool
+}
+
+// quarantineTemplate is the data rendered by quarantine.html.
+type quarantineTemplate struct {
+	Repo    string
+	Entries []quarantineEntry
+	MaxDays int
+}
+
+// quarantineRequest is the body of a POST to /api/quarantine.
+type quarantineRequest struct {
+	Pattern string    `json:"pattern"`
+	Reason  string    `json:"reason"`
+	Expires time.Time `json:"expires"`
+}
+
+// handleQuarantine shows the tests quarantined for the repo given by the repo
+// query parameter, both in the config and from Deck.
+//
+// /quarantine[?repo=<org>/<repo>]
+func handleQuarantine(o options, cfg config.Getter, opener io.Opener, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		c := cfg()
+		if c.Quarantine == nil {
+			http.Error(w, "Quarantining tests from Deck is not configured.", http.StatusNotFound)
+			return
+		}
+		tmpl := quarantineTemplate{Repo: r.URL.Query().Get("repo"), MaxDays: int(c.Quarantine.MaxDuration.Duration / (24 * time.Hour))}
+		if tmpl.Repo != "" {
+			org, repo, err := config.SplitRepoName(tmpl.Repo)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			stored, err := quarantine.NewStore(opener, c.Quarantine.StoragePath).Get(r.Context(), org, repo)
+			if err != nil {
+				log.WithError(err).Warn("Failed to read the quarantined tests.")
+				http.Error(w, "Failed to read the quarantined tests.", http.StatusInternalServerError)
+				return
+			}
+			tmpl.Entries = quarantineEntries(c.Plank.GuessDefaultDecorationConfig(tmpl.Repo, ""), stored, time.Now())
+		}
+		handleSimpleTemplate(o, cfg, "quarantine.html", tmpl)(w, r)
+	}
+}
+
+// quarantineEntries lists the tests quarantined in the config first, then
+// the ones quarantined from Deck, each sorted by their expiry.
+func quarantineEntries(dc *prowapi.DecorationConfig, stored []prowapi.QuarantinedTest, now time.Time) []quarantineEntry {
+	var entries []quarantineEntry
+	if dc != nil {
+		for _, test := range dc.QuarantinedTests {
+			entries = append(entries, quarantineEntry{QuarantinedTest: test, FromConfig: true, Expired: test.Expired(now)})
+		}
+	}
+	for _, test := range stored {
+		entries = append(entries, quarantineEntry{QuarantinedTest: test, Expired: test.Expired(now)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].FromConfig != entries[j].FromConfig {
+			return entries[i].FromConfig
+		}
+		return entries[i].Expires.Before(&entries[j].Expires)
+	})
+	return entries
+}
+
+// handleQuarantineAPI lists, adds and removes the tests quarantined from Deck
+// for the repo given by the repo query parameter. Only users allowed to rerun
+// the jobs of the repo can change them.
+//
+// GET /api/quarantine?repo=<org>/<repo>
+// POST /api/quarantine?repo=<org>/<repo> with a quarantineRequest
+// DELETE /api/quarantine?repo=<org>/<repo>&pattern=<pattern>
+func handleQuarantineAPI(cfg config.Getter, opener io.Opener, acfg authCfgGetter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		c := cfg()
+		if c.Quarantine == nil {
+			http.Error(w, "Quarantining tests from Deck is not configured.", http.StatusNotFound)
+			return
+		}
+		fullRepo := r.URL.Query().Get("repo")
+		if fullRepo == "" {
+			http.Error(w, "Request did not provide the 'repo' query parameter.", http.StatusBadRequest)
+			return
+		}
+		org, repo, err := config.SplitRepoName(fullRepo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l := log.WithField("repo", fullRepo)
+		store := quarantine.NewStore(opener, c.Quarantine.StoragePath)
+
+		if r.Method == http.MethodGet {
+			tests, err := store.Get(r.Context(), org, repo)
+			if err != nil {
+				l.WithError(err).Warn("Failed to read the quarantined tests.")
+				http.Error(w, "Failed to read the quarantined tests.", http.StatusInternalServerError)
+				return
+			}
+			if tests == nil {
+				tests = []prowapi.QuarantinedTest{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(tests); err != nil {
+				l.WithError(err).Debug("Failed to write the quarantined tests.")
+			}
+			return
+		}
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Quarantining the tests of a repo takes the same permission as
+		// rerunning its jobs.
+		pj := prowapi.ProwJob{Spec: prowapi.ProwJobSpec{Type: prowapi.PostsubmitJob, Refs: &prowapi.Refs{Org: org, Repo: repo}}}
+		allowed, user, err, code := isAllowedToRerun(r, acfg, goa, oa, ghc, pj, cli, pluginAgent, l)
+		if err != nil {
+			if code == http.StatusUnauthorized {
+				setLoginURL(w, oa)
+			}
+			http.Error(w, fmt.Sprintf("Could not verify if allowed to quarantine tests: %v.", err), code)
+			return
+		}
+		if !allowed {
+			http.Error(w, "You don't have permission to quarantine the tests of this repo.", http.StatusUnauthorized)
+			return
+		}
+		l = l.WithField("user", user)
+
+		switch r.Method {
+		case http.MethodPost:
+			var req quarantineRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid request: %v.", err), http.StatusBadRequest)
+				return
+			}
+			now := time.Now()
+			if !req.Expires.After(now) {
+				http.Error(w, "The quarantine must expire in the future.", http.StatusBadRequest)
+				return
+			}
+			if max := c.Quarantine.MaxDuration.Duration; req.Expires.Sub(now) > max {
+				http.Error(w, fmt.Sprintf("Tests can be quarantined for %s at most.", max), http.StatusBadRequest)
+				return
+			}
+			test := prowapi.QuarantinedTest{Pattern: req.Pattern, Reason: req.Reason, Expires: metav1.NewTime(req.Expires), Author: user}
+			if err := test.Validate(); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid quarantine: %v.", err), http.StatusBadRequest)
+				return
+			}
+			if err := store.Add(r.Context(), org, repo, test); err != nil {
+				l.WithError(err).Warn("Failed to quarantine the test.")
+				http.Error(w, "Failed to quarantine the test.", http.StatusInternalServerError)
+				return
+			}
+			l.WithField("pattern", test.Pattern).WithField("expires", test.Expires).Info("Quarantined test.")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			pattern := r.URL.Query().Get("pattern")
+			removed, err := store.Remove(r.Context(), org, repo, pattern)
+			if err != nil {
+				l.WithError(err).Warn("Failed to lift the quarantine of the test.")
+				http.Error(w, "Failed to lift the quarantine of the test.", http.StatusInternalServerError)
+				return
+			}
+			if !removed {
+				http.Error(w, fmt.Sprintf("No test of %s is quarantined with the pattern %q.", fullRepo, pattern), http.StatusNotFound)
+				return
+			}
+			l.WithField("pattern", pattern).Info("Lifted the quarantine of the test.")
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/io/fakeopener"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/quarantine"
+)
+
+func TestQuarantineAPI(t *testing.T) {
+	now := time.Now()
+	existing := prowapi.QuarantinedTest{Pattern: "TestExisting", Expires: metav1.NewTime(now.Add(time.Hour).Truncate(time.Second)), Author: "alice"}
+	body := func(pattern string, expires time.Time) string {
+		raw, _ := json.Marshal(quarantineRequest{Pattern: pattern, Reason: "flaky", Expires: expires})
+		return string(raw)
+	}
+	testCases := []struct {
+		name          string
+		unconfigured  bool
+		method        string
+		query         string
+		body          string
+		login         string
+		expectedCode  int
+		expectedTests []string
+	}{
+		{
+			name:         "not configured",
+			unconfigured: true,
+			method:       http.MethodGet,
+			query:        "repo=org/repo",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "repo is required",
+			method:       http.MethodGet,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:          "list quarantined tests",
+			method:        http.MethodGet,
+			query:         "repo=org/repo",
+			expectedCode:  http.StatusOK,
+			expectedTests: []string{"TestExisting"},
+		},
+		{
+			name:          "quarantine a test",
+			method:        http.MethodPost,
+			query:         "repo=org/repo",
+			body:          body("TestFlaky", now.Add(24*time.Hour)),
+			login:         "authorized",
+			expectedCode:  http.StatusCreated,
+			expectedTests: []string{"TestExisting", "TestFlaky"},
+		},
+		{
+			name:          "unauthorized user can't quarantine a test",
+			method:        http.MethodPost,
+			query:         "repo=org/repo",
+			body:          body("TestFlaky", now.Add(24*time.Hour)),
+			login:         "random-dude",
+			expectedCode:  http.StatusUnauthorized,
+			expectedTests: []string{"TestExisting"},
+		},
+		{
+			name:          "quarantine must expire in the future",
+			method:        http.MethodPost,
+			query:         "repo=org/repo",
+			body:          body("TestFlaky", now.Add(-time.Hour)),
+			login:         "authorized",
+			expectedCode:  http.StatusBadRequest,
+			expectedTests: []string{"TestExisting"},
+		},
+		{
+			name:          "quarantine can't exceed the max duration",
+			method:        http.MethodPost,
+			query:         "repo=org/repo",
+			body:          body("TestFlaky", now.Add(8*24*time.Hour)),
+			login:         "authorized",
+			expectedCode:  http.StatusBadRequest,
+			expectedTests: []string{"TestExisting"},
+		},
+		{
+			name:          "invalid pattern",
+			method:        http.MethodPost,
+			query:         "repo=org/repo",
+			body:          body("TestFlaky(", now.Add(time.Hour)),
+			login:         "authorized",
+			expectedCode:  http.StatusBadRequest,
+			expectedTests: []string{"TestExisting"},
+		},
+		{
+			name:         "lift a quarantine",
+			method:       http.MethodDelete,
+			query:        "repo=org/repo&pattern=TestExisting",
+			login:        "authorized",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "lift a quarantine of a test that isn't quarantined",
+			method:        http.MethodDelete,
+			query:         "repo=org/repo&pattern=TestOther",
+			login:         "authorized",
+			expectedCode:  http.StatusNotFound,
+			expectedTests: []string{"TestExisting"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			if !tc.unconfigured {
+				cfg.Quarantine = &config.Quarantine{StoragePath: "gs://bucket/quarantine", MaxDuration: &metav1.Duration{Duration: 7 * 24 * time.Hour}}
+			}
+			opener := &fakeopener.FakeOpener{}
+			store := quarantine.NewStore(opener, "gs://bucket/quarantine")
+			if err := store.Add(context.Background(), "org", "repo", existing); err != nil {
+				t.Fatalf("failed to add the existing test: %v", err)
+			}
+			authCfgGetter := func(*prowapi.ProwJobSpec) *prowapi.RerunAuthConfig {
+				return &prowapi.RerunAuthConfig{GitHubUsers: []string{"authorized"}}
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/quarantine?"+tc.query, bytes.NewBufferString(tc.body))
+			mockCookieStore := sessions.NewCookieStore([]byte("secret-key"))
+			session, err := sessions.GetRegistry(req).Get(mockCookieStore, "access-token-session")
+			if err != nil {
+				t.Fatalf("Error making access token session: %v", err)
+			}
+			session.Values["access-token"] = &oauth2.Token{AccessToken: "validtoken"}
+			goa := githuboauth.NewAgent(&githuboauth.Config{CookieStore: mockCookieStore}, logrus.NewEntry(logrus.StandardLogger()))
+			ghc := &fakeAuthenticatedUserIdentifier{login: tc.login}
+			pca := plugins.NewFakeConfigAgent()
+
+			rr := httptest.NewRecorder()
+			handler := handleQuarantineAPI(func() *config.Config { return cfg }, opener, authCfgGetter, goa, nil, ghc, fakegithub.NewFakeClient(), &pca, logrus.WithField("handler", "/api/quarantine"))
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.unconfigured || tc.expectedCode == http.StatusBadRequest && tc.query == "" {
+				return
+			}
+
+			tests, err := store.Get(context.Background(), "org", "repo")
+			if err != nil {
+				t.Fatalf("failed to get the tests: %v", err)
+			}
+			var patterns []string
+			for _, test := range tests {
+				patterns = append(patterns, test.Pattern)
+				if test.Pattern == "TestFlaky" && test.Author != tc.login {
+					t.Errorf("expected TestFlaky to be quarantined by %s, got %s", tc.login, test.Author)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedTests, patterns); diff != "" {
+				t.Errorf("unexpected quarantined tests (-want +got):\n%s", diff)
+			}
+			if tc.method == http.MethodGet {
+				var listed []prowapi.QuarantinedTest
+				if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+					t.Fatalf("failed to parse the response: %v", err)
+				}
+				if diff := cmp.Diff([]prowapi.QuarantinedTest{existing}, listed); diff != "" {
+					t.Errorf("unexpected listed tests (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestQuarantineEntries(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) metav1.Time { return metav1.NewTime(now.Add(d)) }
+	dc := &prowapi.DecorationConfig{QuarantinedTests: []prowapi.QuarantinedTest{{Pattern: "TestConfig", Expires: at(time.Hour)}}}
+	stored := []prowapi.QuarantinedTest{
+		{Pattern: "TestLater", Expires: at(2 * time.Hour)},
+		{Pattern: "TestExpired", Expires: at(-time.Hour)},
+	}
+	expected := []quarantineEntry{
+		{QuarantinedTest: prowapi.QuarantinedTest{Pattern: "TestConfig", Expires: at(time.Hour)}, FromConfig: true},
+		{QuarantinedTest: prowapi.QuarantinedTest{Pattern: "TestExpired", Expires: at(-time.Hour)}, Expired: true},
+		{QuarantinedTest: prowapi.QuarantinedTest{Pattern: "TestLater", Expires: at(2 * time.Hour)}},
+	}
+	if diff := cmp.Diff(expected, quarantineEntries(dc, stored, now)); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
@@ -18,11 +18,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -34,6 +38,7 @@ import (
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/githuboauth"
 	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/oidcauth"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/plugins"
 	"sigs.k8s.io/prow/pkg/plugins/trigger"
@@ -208,7 +213,7 @@ func canTriggerJob(user string, pj prowapi.ProwJob, cfg *prowapi.RerunAuthConfig
 	return false, nil
 }
 
-func isAllowedToRerun(r *http.Request, acfg authCfgGetter, goa *githuboauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, pj prowapi.ProwJob, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) (bool, string, error, int) {
+func isAllowedToRerun(r *http.Request, acfg authCfgGetter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, pj prowapi.ProwJob, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) (bool, string, error, int) {
 	authConfig := acfg(&pj.Spec)
 	var allowed bool
 	var login string
@@ -216,8 +221,16 @@ func isAllowedToRerun(r *http.Request, acfg authCfgGetter, goa *githuboauth.Agen
 		// Skip getting the users login via GH oauth if anyone is allowed to rerun
 		// jobs so that GH oauth doesn't need to be set up for private Prows.
 		allowed = true
+	} else if identity := oidcIdentity(r, oa); identity != nil {
+		// Users logged in with OIDC are authorized by their groups only.
+		log.WithField("user", identity.User).WithField("groups", identity.Groups).Debug("Authorizing OIDC user.")
+		allowed = authConfig.IsAuthorizedGroups(identity.Groups) || pj.Spec.RerunAuthConfig.IsAuthorizedGroups(identity.Groups)
+		login = identity.User
 	} else {
 		if goa == nil {
+			if oa != nil {
+				return allowed, "", errors.New("Not logged in."), http.StatusUnauthorized
+			}
 			return allowed, "", errors.New("GitHub oauth must be configured to rerun jobs unless 'allow_anyone: true' is specified."), http.StatusInternalServerError
 		}
 		var err error
@@ -234,6 +247,152 @@ func isAllowedToRerun(r *http.Request, acfg authCfgGetter, goa *githuboauth.Agen
 	return allowed, login, nil, http.StatusOK
 }
 
+// oidcIdentity returns the user logged in with OIDC, if OIDC is configured
+// and the user is.
+func oidcIdentity(r *http.Request, oa *oidcauth.Agent) *oidcauth.Identity {
+	if oa == nil {
+		return nil
+	}
+	identity, err := oa.Identity(r)
+	if err != nil {
+		return nil
+	}
+	return identity
+}
+
+// setLoginURL tells the front-end where to send users who need to log in
+// before rerunning or aborting jobs. OIDC is preferred when configured, as
+// its users may have no GitHub account.
+func setLoginURL(w http.ResponseWriter, oa *oidcauth.Agent) {
+	w.Header().Set("X-Login-URL", loginURL(oa))
+}
+
+func loginURL(oa *oidcauth.Agent) string {
+	if oa != nil {
+		return "/oidc-login"
+	}
+	return "/github-login"
+}
+
+// rerunOverrides are the fields of a job a user overrides when they rerun it.
+// They are recorded in the RerunOverridesAnnotation of the new job.
+type rerunOverrides struct {
+	Env                map[string]string `json:"env,omitempty"`
+	Cluster            string            `json:"cluster,omitempty"`
+	Timeout            string            `json:"timeout,omitempty"`
+	ArtifactBucket     string            `json:"artifact_bucket,omitempty"`
+	ArtifactPathPrefix string            `json:"artifact_path_prefix,omitempty"`
+	User               string            `json:"user,omitempty"`
+}
+
+// parseRerunOverrides reads the overrides from the env, cluster, timeout,
+// artifact_bucket and artifact_path_prefix parameters of the request.
+// Environment variables are given as NAME=value. It returns nil if the
+// request overrides nothing.
+func parseRerunOverrides(r *http.Request) (*rerunOverrides, error) {
+	form := r.URL.Query()
+	if r.Body != nil {
+		if err := r.ParseForm(); err != nil {
+			return nil, fmt.Errorf("failed to parse the request: %w", err)
+		}
+		form = r.Form
+	}
+	overrides := &rerunOverrides{
+		Cluster:            form.Get("cluster"),
+		Timeout:            form.Get("timeout"),
+		ArtifactBucket:     form.Get("artifact_bucket"),
+		ArtifactPathPrefix: form.Get("artifact_path_prefix"),
+	}
+	for _, env := range form["env"] {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("environment variable %q is not of the form NAME=value", env)
+		}
+		if overrides.Env == nil {
+			overrides.Env = map[string]string{}
+		}
+		overrides.Env[name] = value
+	}
+	if len(overrides.Env) == 0 && overrides.Cluster == "" && overrides.Timeout == "" && overrides.ArtifactBucket == "" && overrides.ArtifactPathPrefix == "" {
+		return nil, nil
+	}
+	return overrides, nil
+}
+
+// applyRerunOverrides overrides the fields of the job, if its RerunOverrides
+// allow all of them. The artifact destination must instead be allowed by
+// plank.allowed_artifact_destinations, as it is for Gangway and mkpj.
+func applyRerunOverrides(pj *prowapi.ProwJob, overrides *rerunOverrides, plank config.Plank) error {
+	allowed := pj.Spec.RerunOverrides
+	if allowed == nil {
+		allowed = &prowapi.RerunOverrides{}
+	}
+	var errs []error
+	if len(overrides.Env) > 0 {
+		if pj.Spec.PodSpec == nil {
+			errs = append(errs, errors.New("environment variables can only be overridden for jobs with a pod spec"))
+		}
+		allowedEnv := sets.New(allowed.Env...)
+		for _, name := range sets.List(sets.KeySet(overrides.Env)) {
+			if !allowedEnv.Has(name) {
+				errs = append(errs, fmt.Errorf("environment variable %s may not be overridden", name))
+			}
+		}
+	}
+	if overrides.Cluster != "" && !sets.New(allowed.Clusters...).Has(overrides.Cluster) {
+		errs = append(errs, fmt.Errorf("the job may not be rerun in cluster %s", overrides.Cluster))
+	}
+	var timeout time.Duration
+	if overrides.Timeout != "" {
+		var err error
+		if !allowed.Timeout {
+			errs = append(errs, errors.New("the timeout of the job may not be overridden"))
+		} else if pj.Spec.DecorationConfig == nil {
+			errs = append(errs, errors.New("the timeout can only be overridden for decorated jobs"))
+		} else if timeout, err = time.ParseDuration(overrides.Timeout); err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("invalid timeout %q", overrides.Timeout))
+		}
+	}
+	if overrides.ArtifactPathPrefix != "" && overrides.ArtifactBucket == "" {
+		errs = append(errs, errors.New("the artifact path prefix requires an artifact bucket"))
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	// The spec may be shared with the config or the job that is rerun, so
+	// the overridden parts are copied first.
+	if len(overrides.Env) > 0 {
+		pj.Spec.PodSpec = pj.Spec.PodSpec.DeepCopy()
+		for i := range pj.Spec.PodSpec.Containers {
+			container := &pj.Spec.PodSpec.Containers[i]
+			for _, name := range sets.List(sets.KeySet(overrides.Env)) {
+				overridden := false
+				for j := range container.Env {
+					if container.Env[j].Name == name {
+						container.Env[j] = coreapi.EnvVar{Name: name, Value: overrides.Env[name]}
+						overridden = true
+					}
+				}
+				if !overridden {
+					container.Env = append(container.Env, coreapi.EnvVar{Name: name, Value: overrides.Env[name]})
+				}
+			}
+		}
+	}
+	if overrides.Cluster != "" {
+		pj.Spec.Cluster = overrides.Cluster
+	}
+	if timeout > 0 {
+		pj.Spec.DecorationConfig = pj.Spec.DecorationConfig.DeepCopy()
+		pj.Spec.DecorationConfig.Timeout = &prowapi.Duration{Duration: timeout}
+	}
+	if overrides.ArtifactBucket != "" {
+		return plank.ApplyArtifactDestination(&pj.Spec, prowapi.ArtifactDestination{Bucket: overrides.ArtifactBucket, PathPrefix: overrides.ArtifactPathPrefix})
+	}
+	return nil
+}
+
 // Valid value for query parameter mode in rerun route
 const (
 	LATEST = "latest"
@@ -242,7 +401,7 @@ const (
 // handleRerun triggers a rerun of the given job if that features is enabled, it receives a
 // POST request, and the user has the necessary permissions. Otherwise, it writes the config
 // for a new job but does not trigger it.
-func handleRerun(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, createProwJob bool, acfg authCfgGetter, goa *githuboauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+func handleRerun(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, createProwJob bool, acfg authCfgGetter, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Query().Get("prowjob")
 		mode := r.URL.Query().Get("mode")
@@ -295,6 +454,15 @@ func handleRerun(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, creat
 			newPJ = pjutil.NewProwJob(pj.Spec, pj.ObjectMeta.Labels, pj.ObjectMeta.Annotations, pjutil.RequireScheduling(enableScheduling))
 		}
 		l = l.WithField("job", newPJ.Spec.Job)
+		overrides, err := parseRerunOverrides(r)
+		if err == nil && overrides != nil {
+			err = applyRerunOverrides(&newPJ, overrides, cfg().Plank)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Could not override the fields of the job: %v", err), http.StatusBadRequest)
+			l.WithError(err).Debug("Could not override the fields of the job.")
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			handleSerialize(w, "prowjob", newPJ, l)
@@ -303,8 +471,11 @@ func handleRerun(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, creat
 				http.Error(w, "Direct rerun feature is not enabled. Enable with the '--rerun-creates-job' flag.", http.StatusMethodNotAllowed)
 				return
 			}
-			allowed, user, err, code := isAllowedToRerun(r, acfg, goa, ghc, newPJ, cli, pluginAgent, l)
+			allowed, user, err, code := isAllowedToRerun(r, acfg, goa, oa, ghc, newPJ, cli, pluginAgent, l)
 			if err != nil {
+				if code == http.StatusUnauthorized {
+					setLoginURL(w, oa)
+				}
 				http.Error(w, fmt.Sprintf("Could not verify if allowed to rerun: %v.", err), code)
 				l.WithError(err).Debug("Could not verify if allowed to rerun.")
 			}
@@ -323,6 +494,20 @@ func handleRerun(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, creat
 				rerunDescription = fmt.Sprintf("Successfully reran %v.", name)
 			}
 			newPJ.Status.Description = rerunDescription
+			if overrides != nil {
+				overrides.User = user
+				b, err := json.Marshal(overrides)
+				if err != nil {
+					l.WithError(err).Error("Error marshaling the overrides.")
+					http.Error(w, fmt.Sprintf("Error marshaling the overrides: %v", err), http.StatusInternalServerError)
+					return
+				}
+				if newPJ.Annotations == nil {
+					newPJ.Annotations = map[string]string{}
+				}
+				newPJ.Annotations[prowapi.RerunOverridesAnnotation] = string(b)
+				l = l.WithField("overrides", string(b))
+			}
 			created, err := prowJobClient.Create(context.TODO(), &newPJ, metav1.CreateOptions{})
 			if err != nil {
 				l.WithError(err).Error("Error creating job.")
@@ -21,6 +21,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/gorilla/sessions"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
+	coreapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
@@ -273,7 +276,7 @@ func TestRerun(t *testing.T) {
 			cfg := func() *config.Config {
 				return &config.Config{ProwConfig: config.ProwConfig{Scheduler: config.Scheduler{Enabled: tc.enableScheduling}}}
 			}
-			handler := handleRerun(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, goa, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
+			handler := handleRerun(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, goa, nil, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
 			handler.ServeHTTP(rr, req)
 			if rr.Code != tc.httpCode {
 				t.Fatalf("Bad error code: %d", rr.Code)
@@ -563,7 +566,7 @@ func TestLatestRerun(t *testing.T) {
 				cfg.Scheduler.Enabled = tc.enableScheduling
 				return cfg
 			}
-			handler := handleRerun(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, goa, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
+			handler := handleRerun(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, goa, nil, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
 			handler.ServeHTTP(rr, req)
 			if rr.Code != tc.httpCode {
 				t.Fatalf("Bad error code: %d", rr.Code)
@@ -709,3 +712,185 @@ func TestCanTriggerJob(t *testing.T) {
 		}
 	}
 }
+
+func TestRerunOverrides(t *testing.T) {
+	testCases := []struct {
+		name     string
+		form     url.Values
+		httpCode int
+
+		expectedEnv        []coreapi.EnvVar
+		expectedCluster    string
+		expectedTimeout    time.Duration
+		expectedGCS        *prowapi.GCSConfiguration
+		expectedAnnotation string
+	}{
+		{
+			name:            "no overrides",
+			httpCode:        http.StatusOK,
+			expectedEnv:     []coreapi.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}},
+			expectedCluster: "default",
+			expectedTimeout: time.Hour,
+			expectedGCS:     &prowapi.GCSConfiguration{Bucket: "ci", PathStrategy: prowapi.PathStrategyExplicit},
+		},
+		{
+			name:               "allowed overrides",
+			form:               url.Values{"env": {"FOO=overridden", "NEW=new"}, "cluster": {"other"}, "timeout": {"3h"}},
+			httpCode:           http.StatusOK,
+			expectedEnv:        []coreapi.EnvVar{{Name: "FOO", Value: "overridden"}, {Name: "BAR", Value: "bar"}, {Name: "NEW", Value: "new"}},
+			expectedCluster:    "other",
+			expectedTimeout:    3 * time.Hour,
+			expectedGCS:        &prowapi.GCSConfiguration{Bucket: "ci", PathStrategy: prowapi.PathStrategyExplicit},
+			expectedAnnotation: `{"env":{"FOO":"overridden","NEW":"new"},"cluster":"other","timeout":"3h","user":"authorized"}`,
+		},
+		{
+			name:               "allowed artifact destination",
+			form:               url.Values{"artifact_bucket": {"gs://experiments"}, "artifact_path_prefix": {"release/v1"}},
+			httpCode:           http.StatusOK,
+			expectedEnv:        []coreapi.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}},
+			expectedCluster:    "default",
+			expectedTimeout:    time.Hour,
+			expectedGCS:        &prowapi.GCSConfiguration{Bucket: "gs://experiments", PathPrefix: "release/v1", PathStrategy: prowapi.PathStrategyExplicit},
+			expectedAnnotation: `{"artifact_bucket":"gs://experiments","artifact_path_prefix":"release/v1","user":"authorized"}`,
+		},
+		{
+			name:     "artifact destination that isn't allowed",
+			form:     url.Values{"artifact_bucket": {"gs://experiments"}, "artifact_path_prefix": {"other"}},
+			httpCode: http.StatusBadRequest,
+		},
+		{
+			name:     "artifact path prefix without a bucket",
+			form:     url.Values{"artifact_path_prefix": {"release/v1"}},
+			httpCode: http.StatusBadRequest,
+		},
+		{
+			name:     "environment variable that may not be overridden",
+			form:     url.Values{"env": {"BAR=overridden"}},
+			httpCode: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed environment variable",
+			form:     url.Values{"env": {"FOO"}},
+			httpCode: http.StatusBadRequest,
+		},
+		{
+			name:     "cluster the job may not be rerun in",
+			form:     url.Values{"cluster": {"trusted"}},
+			httpCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid timeout",
+			form:     url.Values{"timeout": {"-1h"}},
+			httpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeProwJobClient := fake.NewSimpleClientset(&prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "wowsuch",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:     "whoa",
+					Type:    prowapi.PeriodicJob,
+					Cluster: "default",
+					PodSpec: &coreapi.PodSpec{Containers: []coreapi.Container{{
+						Env: []coreapi.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}},
+					}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:          &prowapi.Duration{Duration: time.Hour},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "ci", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+					RerunAuthConfig: &prowapi.RerunAuthConfig{GitHubUsers: []string{"authorized"}},
+					RerunOverrides: &prowapi.RerunOverrides{
+						Env:      []string{"FOO", "NEW"},
+						Clusters: []string{"other"},
+						Timeout:  true,
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.SuccessState,
+				},
+			})
+			authCfgGetter := func(refs *prowapi.ProwJobSpec) *prowapi.RerunAuthConfig {
+				return &prowapi.RerunAuthConfig{}
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/rerun?prowjob=wowsuch", strings.NewReader(tc.form.Encode()))
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(&http.Cookie{
+				Name:    "github_login",
+				Value:   "authorized",
+				Path:    "/",
+				Expires: time.Now().Add(time.Hour * 24 * 30),
+				Secure:  true,
+			})
+			mockCookieStore := sessions.NewCookieStore([]byte("secret-key"))
+			session, err := sessions.GetRegistry(req).Get(mockCookieStore, "access-token-session")
+			if err != nil {
+				t.Fatalf("Error making access token session: %v", err)
+			}
+			session.Values["access-token"] = &oauth2.Token{AccessToken: "validtoken"}
+
+			rr := httptest.NewRecorder()
+			goa := githuboauth.NewAgent(&githuboauth.Config{CookieStore: mockCookieStore}, &logrus.Entry{})
+			ghc := &fakeAuthenticatedUserIdentifier{login: "authorized"}
+			pca := plugins.NewFakeConfigAgent()
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Plank: config.Plank{
+					AllowedArtifactDestinations: []config.AllowedArtifactDestination{{Bucket: "gs://experiments", PathPrefixes: []string{"release"}}},
+				}}}
+			}
+			handler := handleRerun(cfg, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), true, authCfgGetter, goa, nil, ghc, fakegithub.NewFakeClient(), &pca, logrus.WithField("handler", "/rerun"))
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.httpCode {
+				t.Fatalf("Bad error code: %d: %s", rr.Code, rr.Body.String())
+			}
+
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list prowjobs: %v", err)
+			}
+			if tc.httpCode != http.StatusOK {
+				if numPJs := len(pjs.Items); numPJs != 1 {
+					t.Errorf("expected no prowjob to be created, got %d prowjobs", numPJs)
+				}
+				return
+			}
+			if numPJs := len(pjs.Items); numPJs != 2 {
+				t.Fatalf("expected to get two prowjobs, got %d", numPJs)
+			}
+			for _, pj := range pjs.Items {
+				if pj.Name == "wowsuch" {
+					if diff := cmp.Diff([]coreapi.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}}, pj.Spec.PodSpec.Containers[0].Env); diff != "" {
+						t.Errorf("the rerun job was changed (-want +got):\n%s", diff)
+					}
+					if bucket := pj.Spec.DecorationConfig.GCSConfiguration.Bucket; bucket != "ci" {
+						t.Errorf("the bucket of the rerun job was changed to %q", bucket)
+					}
+					continue
+				}
+				if diff := cmp.Diff(tc.expectedEnv, pj.Spec.PodSpec.Containers[0].Env); diff != "" {
+					t.Errorf("unexpected env (-want +got):\n%s", diff)
+				}
+				if pj.Spec.Cluster != tc.expectedCluster {
+					t.Errorf("expected cluster %q, got %q", tc.expectedCluster, pj.Spec.Cluster)
+				}
+				if timeout := pj.Spec.DecorationConfig.Timeout.Duration; timeout != tc.expectedTimeout {
+					t.Errorf("expected timeout %s, got %s", tc.expectedTimeout, timeout)
+				}
+				if diff := cmp.Diff(tc.expectedGCS, pj.Spec.DecorationConfig.GCSConfiguration); diff != "" {
+					t.Errorf("unexpected GCS configuration (-want +got):\n%s", diff)
+				}
+				if annotation := pj.Annotations[prowapi.RerunOverridesAnnotation]; annotation != tc.expectedAnnotation {
+					t.Errorf("expected overrides annotation %q, got %q", tc.expectedAnnotation, annotation)
+				}
+			}
+		})
+	}
+}
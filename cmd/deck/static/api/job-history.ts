@@ -0,0 +1,39 @@
+import {Refs} from "./prow";
+
+export interface Build {
+  SpyglassLink: string;
+  ID: string;
+  Started: string;
+  // Duration is in nanoseconds.
+  Duration: number;
+  Result: string;
+  Refs?: Refs;
+}
+
+export interface Aggregate {
+  Runs: number;
+  Passed: number;
+  Failed: number;
+  Aborted: number;
+  Pending: number;
+  PassRate: number;
+  // Durations are in nanoseconds.
+  DurationP50: number;
+  DurationP90: number;
+  DurationP99: number;
+}
+
+export interface TrendPoint extends Aggregate {
+  Date: string;
+}
+
+export interface JobHistoryStats {
+  Name: string;
+  TotalRuns: number;
+  Summary: Aggregate;
+  Trend: TrendPoint[] | null;
+  Builds: Build[] | null;
+  Page: number;
+  PageSize: number;
+  Pages: number;
+}
@@ -0,0 +1,6 @@
+export interface OnCall {
+  // Teams maps team names to the logins on call.
+  Teams: {[team: string]: string[]} | null;
+  // RepoTeams maps org or org/repo to the team on call for the repos.
+  RepoTeams: {[repo: string]: string} | null;
+}
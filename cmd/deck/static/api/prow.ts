@@ -1,5 +1,5 @@
 export type ProwJobType = "presubmit" | "postsubmit" | "batch" | "periodic";
-export type ProwJobState = "triggered" | "pending" | "success" | "failure" | "aborted" | "error" | "unknown" | "";
+export type ProwJobState = "held" | "waiting" | "triggered" | "pending" | "success" | "failure" | "aborted" | "error" | "unknown" | "";
 export type ProwJobAgent = "kubernetes" | "jenkins" | "tekton-pipeline";
 
 // Pull describes a pull request at a particular point in time.
@@ -106,10 +106,19 @@ export interface ProwJobSpec {
   decoration_config?: object;
   reporter_config?: object;
   rerun_auth_config?: object;
+  rerun_overrides?: RerunOverrides;
   hidden?: boolean;
   prowjob_default?: object;
 }
 
+// RerunOverrides lists the fields of a job users may override when they rerun it.
+// RerunOverrides mirrors the RerunOverrides struct defined in prow/apis/prowjobs/v1/types.go.
+export interface RerunOverrides {
+  env?: string[];
+  clusters?: string[];
+  timeout?: boolean;
+}
+
 // ProwJobStatus provides runtime metadata, such as when it finished, whether it is running, etc.
 // ProwJobStatus mirrors the ProwJobStatus struct defined in prow/apis/prowjobs/v1/types.go.
 export interface ProwJobStatus {
@@ -122,6 +131,15 @@ export interface ProwJobStatus {
   build_id?: string;
   jenkins_build_id?: string;
   prev_report_states?: { [key: string]: ProwJobState };
+  pod_pending_reason?: PodPendingReason;
+}
+
+// PodPendingReason describes why the pod of a ProwJob is stuck pending.
+export interface PodPendingReason {
+  reason: string;
+  message?: string;
+  container?: string;
+  lastTransitionTime?: string;
 }
 
 // PodSpec is a description of a pod.
@@ -1,5 +1,6 @@
 import {ProwJobState} from "../api/prow";
 import {showAlert, showToast, State} from "./common";
+import {relativeURL} from "./urls";
 
 export function createAbortProwJobIcon(modal: HTMLElement, parentEl: Element, job: string, state: ProwJobState, prowjob: string, csrfToken: string): HTMLElement {
   const url = `${location.protocol}//${location.host}/abort?prowjob=${prowjob}`;
@@ -23,7 +24,7 @@ export function createAbortProwJobIcon(modal: HTMLElement, parentEl: Element, jo
       closeModal();
     }
   };
-  if (state !== State.TRIGGERED && state !== State.PENDING) {
+  if (state !== State.HELD && state !== State.WAITING && state !== State.TRIGGERED && state !== State.PENDING) {
     abortButton.innerHTML = `<i class="icon-button material-icons" title="Can't abort job in ${state} state" style="color: lightgray">cancel</i>`;
     abortButton.disabled = true;
   }
@@ -59,6 +60,10 @@ export function createAbortProwJobIcon(modal: HTMLElement, parentEl: Element, jo
           },
           method: 'post',
         });
+        const loginURL = result.headers.get('X-Login-URL');
+        if (result.status === 401 && loginURL) {
+          window.location.href = `${window.location.origin}${loginURL}?dest=${relativeURL()}`;
+        }
         const data = await result.text();
         if (result.status >= 400) {
           showAlert(data);
@@ -0,0 +1,61 @@
+import {showAlert, showToast} from "./common";
+import {relativeURL} from "./urls";
+
+export function createApproveProwJobIcon(modal: HTMLElement, parentEl: Element, job: string, prowjob: string, csrfToken: string): HTMLElement {
+  const url = `${location.protocol}//${location.host}/approve?prowjob=${prowjob}`;
+  const approveButton = document.createElement('button');
+  approveButton.classList.add('mdl-button', 'mdl-js-button', 'mdl-button--icon');
+  approveButton.innerHTML = '<i class="icon-button material-icons" title="Approve this job" style="color: gray">check_circle</i>';
+
+  const closeModal = (): void => {
+    modal.style.display = "none";
+    // Resets modal content. If removed, elements will be concatenated, causing duplicates.
+    parentEl.classList.remove('abort-content', 'rerun-content');
+    parentEl.innerHTML = '';
+  };
+  approveButton.onclick = async () => {
+    modal.style.display = 'block';
+    // The approve modal looks like the abort one.
+    parentEl.classList.add('abort-content');
+    parentEl.innerHTML = `
+      <h2 class="abortModal-title">Approve ProwJob</h2>
+      <p class="abortModal-description">Would you like to approve <b>${job}</b> and let it run?</p>
+    `;
+
+    const buttonDiv = document.createElement('div');
+    buttonDiv.classList.add('abortModal-buttonDiv');
+    const confirmApproveButton = document.createElement('a');
+    confirmApproveButton.innerHTML = "<button class='mdl-button mdl-js-button mdl-button--raised mdl-button--colored'>Confirm</button>";
+    const cancelApproveButton = document.createElement('a');
+    cancelApproveButton.innerHTML = "<button class='mdl-button mdl-js-button mdl-button--raised mdl-color--red mdl-button--colored'>Cancel</button>";
+    buttonDiv.appendChild(confirmApproveButton);
+    buttonDiv.appendChild(cancelApproveButton);
+    parentEl.appendChild(buttonDiv);
+
+    confirmApproveButton.onclick = async () => {
+      try {
+        const result = await fetch(url, {
+          headers: {
+            'Content-type': 'application/x-www-form-urlencoded; charset=UTF-8',
+            'X-CSRF-Token': csrfToken,
+          },
+          method: 'post',
+        });
+        const loginURL = result.headers.get('X-Login-URL');
+        if (result.status === 401 && loginURL) {
+          window.location.href = `${window.location.origin}${loginURL}?dest=${relativeURL()}`;
+        }
+        const data = await result.text();
+        if (result.status >= 400) {
+          showAlert(data);
+        } else {
+          showToast(data);
+        }
+      } catch (e) {
+        showAlert(`Could not send request to approve job: ${e}`);
+      }
+    };
+    cancelApproveButton.onclick = closeModal;
+  };
+  return approveButton;
+}
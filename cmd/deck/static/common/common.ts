@@ -1,4 +1,5 @@
 import moment from "moment";
+import {OnCall} from "../api/oncall";
 import {ProwJobState, Pull} from "../api/prow";
 
 // This file likes namespaces, so stick with it for now.
@@ -6,6 +7,8 @@ import {ProwJobState, Pull} from "../api/prow";
 
 // State enum describes different state a job can be in
 export enum State {
+  HELD = 'held',
+  WAITING = 'waiting',
   TRIGGERED = 'triggered',
   PENDING = 'pending',
   SUCCESS = 'success',
@@ -47,7 +50,7 @@ export namespace cell {
     return c;
   }
 
-  export function state(s: ProwJobState): HTMLTableDataCellElement {
+  export function state(s: ProwJobState, detail?: string): HTMLTableDataCellElement {
     const c = document.createElement("td");
     if (!s) {
       c.appendChild(document.createTextNode(""));
@@ -58,6 +61,12 @@ export namespace cell {
     displayState = displayState[0].toUpperCase() + displayState.slice(1);
     let displayIcon = "";
     switch (s) {
+      case State.HELD:
+        displayIcon = "front_hand";
+        break;
+      case State.WAITING:
+        displayIcon = "pause_circle";
+        break;
       case State.TRIGGERED:
         displayIcon = "schedule";
         break;
@@ -81,7 +90,7 @@ export namespace cell {
     stateIndicator.classList.add("material-icons", "state", s);
     stateIndicator.innerText = displayIcon;
     c.appendChild(stateIndicator);
-    c.title = displayState;
+    c.title = detail ? `${displayState}: ${detail}` : displayState;
 
     return c;
   }
@@ -210,6 +219,43 @@ export namespace tidehistory {
   }
 }
 
+export namespace oncall {
+  // logins returns who is on call for the CI of the repo. Jobs owned by a
+  // team use the on-call of that team instead.
+  export function logins(data: OnCall | null, orgRepo: string, team = ""): string[] {
+    if (!data || !data.Teams) {
+      return [];
+    }
+    if (!team && data.RepoTeams) {
+      const org = orgRepo.split("/")[0];
+      for (const key of [orgRepo, org, "*"]) {
+        if (data.RepoTeams[key]) {
+          team = data.RepoTeams[key];
+          break;
+        }
+      }
+    }
+    return (team && data.Teams[team]) || [];
+  }
+
+  export function create(onCall: string[]): HTMLElement {
+    const el = document.createElement("span");
+    el.classList.add("oncall");
+    el.title = "Contact them if the failure is caused by the CI infrastructure";
+    el.appendChild(document.createTextNode("CI on-call: "));
+    onCall.forEach((login, i) => {
+      if (i > 0) {
+        el.appendChild(document.createTextNode(", "));
+      }
+      const a = document.createElement("a");
+      a.href = `/github-link?dest=${encodeURIComponent(login)}`;
+      a.textContent = login;
+      el.appendChild(a);
+    });
+    return el;
+  }
+}
+
 export function getCookieByName(name: string): string {
   if (!document.cookie) {
     return "";
@@ -285,3 +331,13 @@ export function formatDuration(seconds: number): string {
   }
   return parts.join('');
 }
+
+export function formatBytes(bytes: number): string {
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB'];
+  let i = 0;
+  while (bytes >= 1024 && i < units.length - 1) {
+    bytes /= 1024;
+    i++;
+  }
+  return `${i === 0 ? bytes : bytes.toFixed(1)} ${units[i]}`;
+}
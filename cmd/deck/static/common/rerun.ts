@@ -1,7 +1,72 @@
+import {RerunOverrides} from "../api/prow";
 import {copyToClipboard, icon, showAlert, showToast} from "./common";
 import {relativeURL} from "./urls";
 
-export function createRerunProwJobIcon(modal: HTMLElement, parentEl: Element, prowjob: string, showRerunButton: boolean, csrfToken: string): HTMLElement {
+// createOverrideInputs adds inputs for the fields of the job that may be
+// overridden when rerunning it, and returns a function reading them into the
+// parameters of the rerun request.
+function createOverrideInputs(parentEl: Element, overrides: RerunOverrides): () => URLSearchParams {
+  const container = document.createElement("div");
+  container.className = "rerunModal-overrides";
+  const title = document.createElement("h4");
+  title.innerText = "Overrides";
+  container.appendChild(title);
+  const addInput = (label: string, input: HTMLInputElement | HTMLSelectElement): void => {
+    const row = document.createElement("label");
+    row.className = "rerunModal-overrideRow";
+    const text = document.createElement("span");
+    text.innerText = label;
+    row.appendChild(text);
+    row.appendChild(input);
+    container.appendChild(row);
+  };
+
+  const envInputs = new Map<string, HTMLInputElement>();
+  for (const name of overrides.env || []) {
+    const input = document.createElement("input");
+    input.type = "text";
+    input.placeholder = "unchanged";
+    envInputs.set(name, input);
+    addInput(name, input);
+  }
+  let clusterSelect: HTMLSelectElement | undefined;
+  if (overrides.clusters && overrides.clusters.length > 0) {
+    clusterSelect = document.createElement("select");
+    for (const cluster of ["", ...overrides.clusters]) {
+      const option = document.createElement("option");
+      option.value = cluster;
+      option.innerText = cluster || "unchanged";
+      clusterSelect.appendChild(option);
+    }
+    addInput("Cluster", clusterSelect);
+  }
+  let timeoutInput: HTMLInputElement | undefined;
+  if (overrides.timeout) {
+    timeoutInput = document.createElement("input");
+    timeoutInput.type = "text";
+    timeoutInput.placeholder = "unchanged, e.g. 2h30m";
+    addInput("Timeout", timeoutInput);
+  }
+  parentEl.appendChild(container);
+
+  return () => {
+    const params = new URLSearchParams();
+    envInputs.forEach((input, name) => {
+      if (input.value !== "") {
+        params.append("env", `${name}=${input.value}`);
+      }
+    });
+    if (clusterSelect && clusterSelect.value !== "") {
+      params.set("cluster", clusterSelect.value);
+    }
+    if (timeoutInput && timeoutInput.value !== "") {
+      params.set("timeout", timeoutInput.value);
+    }
+    return params;
+  };
+}
+
+export function createRerunProwJobIcon(modal: HTMLElement, parentEl: Element, prowjob: string, showRerunButton: boolean, csrfToken: string, overrides?: RerunOverrides): HTMLElement {
   const LATEST_JOB = 'latest';
   const ORIGINAL_JOB = 'original';
   const inrepoconfigURL = 'https://docs.prow.k8s.io/docs/inrepoconfig/';
@@ -125,6 +190,10 @@ export function createRerunProwJobIcon(modal: HTMLElement, parentEl: Element, pr
     });
 
     if (showRerunButton) {
+      let overrideParams = (): URLSearchParams => new URLSearchParams();
+      if (overrides && ((overrides.env || []).length > 0 || (overrides.clusters || []).length > 0 || overrides.timeout)) {
+        overrideParams = createOverrideInputs(parentEl, overrides);
+      }
       const runButton = document.createElement('a');
       runButton.innerHTML = "<button class='mdl-button mdl-js-button mdl-button--raised mdl-button--colored'>Rerun</button>";
       runButton.onclick = async () => {
@@ -139,9 +208,11 @@ export function createRerunProwJobIcon(modal: HTMLElement, parentEl: Element, pr
               "X-CSRF-Token": csrfToken,
             },
             method: 'post',
+            body: overrideParams(),
           });
           if (result.status === 401) {
-            window.location.href = `${window.location.origin  }/github-login?dest=${relativeURL({rerun: "gh_redirect"})}`;
+            const loginURL = result.headers.get("X-Login-URL") || "/github-login";
+            window.location.href = `${window.location.origin  }${loginURL}?dest=${relativeURL({rerun: "gh_redirect"})}`;
           }
           const data = await result.text();
           if (result.status >= 400) {
@@ -1,4 +1,5 @@
 import moment from "moment";
+import {JobHistoryStats, TrendPoint} from "../api/job-history";
 import {cell, formatDuration} from '../common/common';
 
 declare const allBuilds: any;
@@ -49,4 +50,67 @@ window.onload = (): void => {
 
     tbody.appendChild(tr);
   }
+
+  loadTrends();
 };
+
+// loadTrends fetches the aggregated history of the job and draws the pass
+// rate and the median duration per day.
+function loadTrends(): void {
+  const url = window.location.pathname.replace(/\/job-history\//, "/job-history-stats/");
+  fetch(url).then((resp) => {
+    if (!resp.ok) {
+      throw new Error(`${resp.status} ${resp.statusText}`);
+    }
+    return resp.json();
+  }).then((stats: JobHistoryStats) => {
+    const summary = document.getElementById("trends-summary")!;
+    const s = stats.Summary;
+    summary.textContent = `${s.Runs} most recent runs: ${(s.PassRate * 100).toFixed(1)}% passed, ` +
+      `duration p50 ${formatDuration(s.DurationP50 / 1e9)}, p90 ${formatDuration(s.DurationP90 / 1e9)}, ` +
+      `p99 ${formatDuration(s.DurationP99 / 1e9)}`;
+    if (stats.Trend && stats.Trend.length > 0) {
+      document.getElementById("trends-chart")!.appendChild(drawTrend(stats.Trend));
+    }
+  }).catch((err) => {
+    document.getElementById("trends-summary")!.textContent = `Failed to load trends: ${err}`;
+  });
+}
+
+const svgNS = "http://www.w3.org/2000/svg";
+
+function svgElement(name: string, attrs: {[key: string]: string | number}): SVGElement {
+  const el = document.createElementNS(svgNS, name);
+  for (const key of Object.keys(attrs)) {
+    el.setAttribute(key, String(attrs[key]));
+  }
+  return el;
+}
+
+// drawTrend draws a bar per day whose height is the pass rate, and a line
+// for the median duration relative to the longest median.
+function drawTrend(trend: TrendPoint[]): SVGElement {
+  const height = 120;
+  const barWidth = Math.max(4, Math.floor(1000 / trend.length));
+  const svg = svgElement("svg", {width: barWidth * trend.length, height});
+  const maxDuration = Math.max(...trend.map((p) => p.DurationP50)) || 1;
+  const points: string[] = [];
+  trend.forEach((point, i) => {
+    const finished = point.Passed + point.Failed;
+    const barHeight = finished > 0 ? Math.round(point.PassRate * height) : 0;
+    const bar = svgElement("rect", {
+      "x": i * barWidth,
+      "y": height - barHeight,
+      "width": barWidth - 1,
+      "height": barHeight,
+      "class": point.PassRate >= 0.9 ? "trend-pass" : "trend-fail",
+    });
+    const title = document.createElementNS(svgNS, "title");
+    title.textContent = `${point.Date}: ${point.Passed}/${finished} passed, median ${formatDuration(point.DurationP50 / 1e9)}`;
+    bar.appendChild(title);
+    svg.appendChild(bar);
+    points.push(`${i * barWidth + barWidth / 2},${height - Math.round(point.DurationP50 / maxDuration * (height - 2)) - 1}`);
+  });
+  svg.appendChild(svgElement("polyline", {"points": points.join(" "), "class": "trend-duration"}));
+  return svg;
+}
@@ -1,13 +1,15 @@
 import dialogPolyfill from "dialog-polyfill";
 
 import {Context} from '../api/github';
+import {OnCall} from '../api/oncall';
 import {Label, PullRequest, UserData} from '../api/pr';
 import {ProwJob, ProwJobList, ProwJobState} from '../api/prow';
 import {Blocker, TideData, TidePool, TideQuery as ITideQuery} from '../api/tide';
-import {getCookieByName, tidehistory} from '../common/common';
+import {getCookieByName, oncall, tidehistory} from '../common/common';
 import {parseQuery, relativeURL} from "../common/urls";
 
 declare const tideData: TideData;
+declare const oncallData: OnCall | null;
 declare const allBuilds: ProwJobList;
 declare const csrfToken: string;
 
@@ -19,6 +21,8 @@ interface UnifiedContext {
   state: UnifiedState;
   discrepancy: string | null;
   url?: string;
+  // oncall is who to contact if the required job fails because of the CI.
+  oncall?: string[];
 }
 
 interface ProcessedLabel {
@@ -330,9 +334,10 @@ function createSearchCard(): HTMLElement {
 /**
  * GetFullPRContexts gathers build jobs and pr contexts. It firstly takes
  * all pr contexts and only replaces contexts that have existing Prow Jobs. Tide
- * context will be omitted from the list.
+ * context will be omitted from the list. Required Prow Jobs get the CI on-call
+ * of the repo.
  */
-function getFullPRContext(builds: ProwJob[], contexts: Context[]): UnifiedContext[] {
+function getFullPRContext(builds: ProwJob[], contexts: Context[], orgRepo: string): UnifiedContext[] {
   const contextMap: Map<string, UnifiedContext> = new Map();
   if (contexts) {
     for (const context of contexts) {
@@ -350,6 +355,9 @@ function getFullPRContext(builds: ProwJob[], contexts: Context[]): UnifiedContex
 
   for (const build of builds) {
     const {
+      metadata: {
+        labels = {},
+      },
       spec: {
         context = "",
       },
@@ -368,10 +376,15 @@ function getFullPRContext(builds: ProwJob[], contexts: Context[]): UnifiedContex
         discrepancy = "GitHub context and Prow Job states mismatch";
       }
     }
+    let onCall: string[] | undefined;
+    if (labels["prow.k8s.io/is-optional"] !== "true") {
+      onCall = oncall.logins(oncallData, orgRepo, labels["prow.k8s.io/owner-team"]);
+    }
     contextMap.set(context, {
       context,
       description,
       discrepancy,
+      oncall: onCall,
       state,
       url,
     });
@@ -427,7 +440,7 @@ function loadPrStatus(prData: UserData): void {
       }
     }
     const githubContexts = prWithContext.Contexts;
-    const contexts = getFullPRContext(builds, githubContexts);
+    const contexts = getFullPRContext(builds, githubContexts, pr.Repository.NameWithOwner);
     const validQueries: TideQuery[] = [];
     for (const query of tideQueries) {
       if (query.matchPr(pr)) {
@@ -627,6 +640,9 @@ function createContextList(contexts: UnifiedContext[], itemStyle: string[] = [])
       itemDesc.style.fontSize = "14px";
       elCon.appendChild(itemDesc);
     }
+    if (context.state === "failure" && context.oncall && context.oncall.length > 0) {
+      elCon.appendChild(oncall.create(context.oncall));
+    }
     container.appendChild(elCon);
   });
   return container;
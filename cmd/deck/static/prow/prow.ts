@@ -1,7 +1,9 @@
 import moment from "moment";
-import {ProwJob, ProwJobList, ProwJobState, ProwJobType, Pull} from "../api/prow";
+import {OnCall} from "../api/oncall";
+import {PodPendingReason, ProwJob, ProwJobList, ProwJobState, ProwJobType, Pull, RerunOverrides} from "../api/prow";
 import {createAbortProwJobIcon} from "../common/abort";
-import {cell, formatDuration, icon} from "../common/common";
+import {createApproveProwJobIcon} from "../common/approve";
+import {cell, formatDuration, icon, oncall} from "../common/common";
 import {createRerunProwJobIcon} from "../common/rerun";
 import {getParameterByName} from "../common/urls";
 import {FuzzySearch} from './fuzzy-search';
@@ -11,6 +13,7 @@ declare const allBuilds: ProwJobList;
 declare const spyglass: boolean;
 declare const rerunCreatesJob: boolean;
 declare const csrfToken: string;
+declare const oncallData: OnCall | null;
 
 function genShortRefKey(baseRef: string, pulls: Pull[] = []) {
   return [baseRef, ...pulls.map((p) => p.number)].filter((n) => n).join(",");
@@ -513,7 +516,7 @@ function redraw(fz: FuzzySearch, pushState = true): void {
         refs: {repo_link = "", base_sha = "", base_link = "", pulls = [], base_ref = ""} = {},
         pod_spec,
       },
-      status: {startTime, completionTime = "", state = "", pod_name, build_id = "", url = ""},
+      status: {startTime, completionTime = "", state = "", pod_name, build_id = "", url = "", pod_pending_reason},
     } = build;
 
     let buildUrl = url;
@@ -609,11 +612,11 @@ function redraw(fz: FuzzySearch, pushState = true): void {
     displayedJob++;
     const r = document.createElement("tr");
     // State column
-    r.appendChild(cell.state(state));
+    r.appendChild(cell.state(state, podPendingReasonSummary(state, pod_pending_reason)));
     // Log column
     r.appendChild(createLogCell(build, buildUrl));
     // Rerun column
-    r.appendChild(createRerunCell(modal, modalContent, prowJobName));
+    r.appendChild(createRerunCell(modal, modalContent, prowJobName, build.spec.rerun_overrides));
     // Abort column
     r.appendChild(createAbortCell(modal, modalContent, job, state, prowJobName));
     // Job Yaml column
@@ -709,6 +712,7 @@ function redraw(fz: FuzzySearch, pushState = true): void {
   jobSummary.innerHTML = `Success rate over time: ${success}`;
   const jobCount = document.getElementById("job-count")!;
   jobCount.textContent = `Showing ${displayedJob}/${totalJob} jobs`;
+  drawOnCall(repoSel);
   drawJobBar(totalJob, jobCountMap);
 
   // if we aren't filtering the output, cap the histogram y axis to 2 hours because it
@@ -727,15 +731,45 @@ function redraw(fz: FuzzySearch, pushState = true): void {
   componentHandler.upgradeDom();
 }
 
+/**
+ * Shows who is on call for the CI of the selected repo, or for every repo if
+ * none is selected.
+ */
+function drawOnCall(repoSel: string): void {
+  const container = document.getElementById("oncall")!;
+  container.innerHTML = "";
+  const onCall = oncall.logins(oncallData, repoSel);
+  if (onCall.length > 0) {
+    container.appendChild(oncall.create(onCall));
+  }
+}
+
+function podPendingReasonSummary(state: ProwJobState, reason?: PodPendingReason): string | undefined {
+  if (state !== "pending" || !reason) {
+    return undefined;
+  }
+  let summary = reason.reason;
+  if (reason.container) {
+    summary += ` (container ${reason.container})`;
+  }
+  if (reason.message) {
+    summary += `: ${reason.message}`;
+  }
+  return summary;
+}
+
 function createAbortCell(modal: HTMLElement, modalContent: Element, job: string, state: ProwJobState, prowjob: string): HTMLTableCellElement {
   const c = document.createElement("td");
+  if (state === "held") {
+    c.appendChild(createApproveProwJobIcon(modal, modalContent, job, prowjob, csrfToken));
+  }
   c.appendChild(createAbortProwJobIcon(modal, modalContent, job, state, prowjob, csrfToken));
   return c;
 }
 
-function createRerunCell(modal: HTMLElement, rerunElement: Element, prowjob: string): HTMLTableDataCellElement {
+function createRerunCell(modal: HTMLElement, rerunElement: Element, prowjob: string, overrides?: RerunOverrides): HTMLTableDataCellElement {
   const c = document.createElement("td");
-  c.appendChild(createRerunProwJobIcon(modal, rerunElement, prowjob, rerunCreatesJob, csrfToken));
+  c.appendChild(createRerunProwJobIcon(modal, rerunElement, prowjob, rerunCreatesJob, csrfToken, overrides));
   return c;
 }
 
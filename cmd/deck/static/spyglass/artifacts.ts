@@ -0,0 +1,91 @@
+import moment from "moment";
+import {formatBytes} from "../common/common";
+
+// ArtifactEntry is a file or directory in a listing of the artifacts of a
+// job, as served by /spyglass/artifacts/.
+interface ArtifactEntry {
+  name: string;
+  is_dir?: boolean;
+  size: number;
+  objects: number;
+  updated: string;
+}
+
+async function listArtifactDir(src: string, dir: string, sort: string): Promise<ArtifactEntry[]> {
+  const params = new URLSearchParams({dir, sort});
+  const resp = await fetch(`/spyglass/artifacts/${src}?${params.toString()}`, {credentials: 'same-origin'});
+  if (!resp.ok) {
+    throw new Error(await resp.text());
+  }
+  return await resp.json() as ArtifactEntry[];
+}
+
+function createEntryRow(src: string, artifactsLink: string, dir: string, sort: string, entry: ArtifactEntry): HTMLElement {
+  const row = document.createElement('div');
+  row.className = 'artifacts-entry';
+
+  const line = document.createElement('div');
+  line.className = 'artifacts-line';
+  const icon = document.createElement('i');
+  icon.className = 'material-icons';
+  icon.innerText = entry.is_dir ? 'chevron_right' : 'description';
+  line.appendChild(icon);
+
+  const name = document.createElement(!entry.is_dir && artifactsLink ? 'a' : 'span');
+  name.className = 'artifacts-name';
+  name.innerText = entry.name;
+  if (name instanceof HTMLAnchorElement) {
+    name.href = artifactsLink + dir + entry.name;
+  }
+  line.appendChild(name);
+
+  const size = document.createElement('span');
+  size.className = 'artifacts-size';
+  size.innerText = formatBytes(entry.size);
+  line.appendChild(size);
+
+  const objects = document.createElement('span');
+  objects.className = 'artifacts-objects';
+  objects.innerText = entry.is_dir ? `${entry.objects} ${entry.objects === 1 ? 'file' : 'files'}` : '';
+  line.appendChild(objects);
+
+  const updated = document.createElement('span');
+  updated.className = 'artifacts-updated';
+  const when = moment(entry.updated);
+  updated.innerText = when.isValid() && when.year() > 1 ? when.format('MMM DD YYYY, HH:mm:ss') : '';
+  line.appendChild(updated);
+  row.appendChild(line);
+
+  if (entry.is_dir) {
+    // The contents of directories are only listed once they are expanded.
+    const children = document.createElement('div');
+    children.className = 'artifacts-dir hidden';
+    row.appendChild(children);
+    let loaded = false;
+    line.classList.add('expandable');
+    line.addEventListener('click', () => {
+      const expanded = children.classList.toggle('hidden');
+      icon.innerText = expanded ? 'chevron_right' : 'expand_more';
+      if (!loaded) {
+        loaded = true;
+        loadArtifactDir(src, artifactsLink, dir + entry.name, sort, children);
+      }
+    });
+  }
+  return row;
+}
+
+export async function loadArtifactDir(src: string, artifactsLink: string, dir: string, sort: string, container: HTMLElement): Promise<void> {
+  container.innerText = 'Loading...';
+  let entries: ArtifactEntry[];
+  try {
+    entries = await listArtifactDir(src, dir, sort);
+  } catch (err) {
+    container.innerText = `Failed to list artifacts: ${err}`;
+    return;
+  }
+  container.innerText = entries.length === 0 ? 'No artifacts.' : '';
+  for (const entry of entries) {
+    container.appendChild(createEntryRow(src, artifactsLink, dir, sort, entry));
+  }
+}
@@ -2,6 +2,7 @@ import {ProwJobState} from "../api/prow";
 import {createAbortProwJobIcon} from "../common/abort";
 import {createRerunProwJobIcon} from "../common/rerun";
 import {getParameterByName} from "../common/urls";
+import {loadArtifactDir} from "./artifacts";
 import {isTransitMessage, serialiseHashes} from "./common";
 
 declare const src: string;
@@ -12,6 +13,7 @@ declare const rerunCreatesJob: boolean;
 declare const prowJob: string;
 declare const prowJobName: string;
 declare const prowJobState: ProwJobState;
+declare const artifactsLink: string;
 
 // Loads views for this job
 function loadLenses(): void {
@@ -168,10 +170,20 @@ window.addEventListener('hashchange', (e) => {
 // We can't use DOMContentLoaded here or we end up with a bunch of flickering. This appears to be MDL's fault.
 window.addEventListener('load', () => {
   loadLenses();
+  loadArtifacts();
   handleRerunButton();
   handleAbortButton();
 });
 
+// Lists the artifacts of this job one directory at a time, starting with the
+// top level, and lists them again whenever the sort order changes.
+function loadArtifacts(): void {
+  const tree = document.getElementById('artifacts-tree')!;
+  const sort = document.querySelector<HTMLSelectElement>('#artifacts-sort')!;
+  sort.addEventListener('change', () => loadArtifactDir(src, artifactsLink, '', sort.value, tree));
+  loadArtifactDir(src, artifactsLink, '', sort.value, tree);
+}
+
 function handleRerunButton() {
   // In case prowJob is unavailable, the rerun button shouldn't be shown
   if (!prowJobName) {
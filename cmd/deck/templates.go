@@ -62,7 +62,7 @@ func getConcreteSectionFunction(o options) func() baseTemplateSections {
 	}
 }
 
-func prepareBaseTemplate(o options, cfg config.Getter, csrfToken string, t *template.Template) (*template.Template, error) {
+func prepareBaseTemplate(o options, cfg config.Getter, csrfToken, tenantPath string, t *template.Template) (*template.Template, error) {
 	return t.Funcs(map[string]interface{}{
 		"settings":         makeBaseTemplateSettings,
 		"branding":         getConcreteBrandingFunction(cfg),
@@ -74,13 +74,18 @@ func prepareBaseTemplate(o options, cfg config.Getter, csrfToken string, t *temp
 		"deckVersion":      func() string { return version.Version },
 		"googleAnalytics":  func() string { return cfg().Deck.GoogleAnalytics },
 		"csrfToken":        func() string { return csrfToken },
+		"tenantPath":       func() string { return tenantPath },
+		"staleJobConfig":   func() bool { return len(cfg().StaleShards) > 0 },
+		"dashboards":       func() bool { return len(cfg().Deck.Dashboards) > 0 },
+		"quarantine":       func() bool { return cfg().Quarantine != nil },
+		"okToTest":         func() bool { return o.oauthURL != "" && (o.github.TokenPath != "" || o.github.AppID != "") },
 	}).ParseFiles(path.Join(o.templateFilesLocation, "base.html"))
 }
 
 func handleSimpleTemplate(o options, cfg config.Getter, templateName string, param interface{}) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		t := template.New(templateName) // the name matters, and must match the filename.
-		if _, err := prepareBaseTemplate(o, cfg, csrf.Token(r), t); err != nil {
+		if _, err := prepareBaseTemplate(o, cfg, csrf.Token(r), tenantPath(r), t); err != nil {
 			logrus.WithError(err).Error("error preparing base template")
 			http.Error(w, "error preparing base template", http.StatusInternalServerError)
 			return
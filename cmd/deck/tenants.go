@@ -0,0 +1,347 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/oidcauth"
+	"sigs.k8s.io/prow/pkg/tide"
+	"sigs.k8s.io/prow/pkg/tide/history"
+)
+
+const tenantPathPrefix = "/t/"
+
+// tenantPages are the pages, and the data they load with relative URLs, that
+// can be scoped to a tenant.
+var tenantPages = map[string]bool{
+	"/":                true,
+	"/prowjobs.js":     true,
+	"/tide":            true,
+	"/tide.js":         true,
+	"/tide-history":    true,
+	"/tide-history.js": true,
+}
+
+type tenantContextKey struct{}
+
+// requestTenant returns the tenant the request is scoped to, if any.
+func requestTenant(r *http.Request) *config.DeckTenant {
+	tenant, _ := r.Context().Value(tenantContextKey{}).(*config.DeckTenant)
+	return tenant
+}
+
+// tenantPath returns the path under which the pages are scoped to the tenant
+// of the request, or an empty string.
+func tenantPath(r *http.Request) string {
+	if tenant := requestTenant(r); tenant != nil {
+		return tenantPathPrefix + tenant.Name
+	}
+	return ""
+}
+
+// handleTenant serves /t/<tenant>/<page> with the page of the mux, scoped to
+// the tenant, after checking that the user may view it.
+func handleTenant(cfg config.Getter, mux http.Handler, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, page, found := strings.Cut(strings.TrimPrefix(r.URL.Path, tenantPathPrefix), "/")
+		if !found {
+			// The pages load their data relative to the directory they are in.
+			http.Redirect(w, r, tenantPathPrefix+name+"/", http.StatusMovedPermanently)
+			return
+		}
+		page = "/" + page
+		tenant := cfg().Deck.GetTenant(name)
+		if tenant == nil {
+			http.Error(w, fmt.Sprintf("Tenant %q doesn't exist.", name), http.StatusNotFound)
+			return
+		}
+		if !tenantPages[page] && !strings.HasPrefix(page, "/job-history/") && !strings.HasPrefix(page, "/job-history-stats/") {
+			http.Error(w, fmt.Sprintf("%s can't be viewed by tenant.", page), http.StatusNotFound)
+			return
+		}
+		l := log.WithField("tenant", name)
+		allowed, status, err := canViewTenant(r, tenant, goa, oa, ghc, cli, l)
+		if err != nil {
+			if status == http.StatusUnauthorized {
+				http.Redirect(w, r, loginURL(oa)+"?dest="+url.QueryEscape(strings.TrimPrefix(r.URL.RequestURI(), "/")), http.StatusFound)
+				return
+			}
+			l.WithError(err).Warn("Failed to authorize the viewer of a tenant.")
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if !allowed {
+			http.Error(w, fmt.Sprintf("You are not allowed to view tenant %q.", name), http.StatusForbidden)
+			return
+		}
+
+		scoped := r.Clone(context.WithValue(r.Context(), tenantContextKey{}, tenant))
+		scoped.URL.Path = page
+		scoped.URL.RawPath = ""
+		mux.ServeHTTP(w, scoped)
+	}
+}
+
+// canViewTenant tells whether the user of the request is among the viewers
+// of the tenant. Tenants without viewers can be viewed by anyone.
+func canViewTenant(r *http.Request, tenant *config.DeckTenant, goa *githuboauth.Agent, oa *oidcauth.Agent, ghc githuboauth.AuthenticatedUserIdentifier, cli deckGitHubClient, log *logrus.Entry) (bool, int, error) {
+	if !tenant.Restricted() {
+		return true, http.StatusOK, nil
+	}
+	if identity := oidcIdentity(r, oa); identity != nil {
+		return tenant.Viewers.IsAuthorizedGroups(identity.Groups), http.StatusOK, nil
+	}
+	if goa == nil {
+		if oa != nil {
+			return false, http.StatusUnauthorized, fmt.Errorf("not logged in")
+		}
+		return false, http.StatusInternalServerError, fmt.Errorf("GitHub oauth or OIDC must be configured to restrict the viewers of tenant %q", tenant.Name)
+	}
+	login, err := goa.GetLogin(r, ghc)
+	if err != nil {
+		return false, http.StatusUnauthorized, fmt.Errorf("not logged in: %w", err)
+	}
+	// Teams given by ID or slug are looked up in the first org of the tenant.
+	var org string
+	if len(tenant.Repos) > 0 {
+		org = strings.Split(tenant.Repos[0], "/")[0]
+	}
+	allowed, err := tenant.Viewers.IsAuthorized(org, login, cli)
+	if err != nil {
+		return false, http.StatusInternalServerError, err
+	}
+	log.WithField("user", login).WithField("allowed", allowed).Debug("Authorized the viewer of a tenant.")
+	return allowed, http.StatusOK, nil
+}
+
+// restrictedTenants returns the tenants whose data is left out of the views
+// that aren't scoped to a tenant.
+func restrictedTenants(cfg *config.Config) []config.DeckTenant {
+	var restricted []config.DeckTenant
+	for _, tenant := range cfg.Deck.Tenants {
+		if tenant.Restricted() {
+			restricted = append(restricted, tenant)
+		}
+	}
+	return restricted
+}
+
+func jobRefs(pj prowapi.ProwJob) *prowapi.Refs {
+	if pj.Spec.Refs != nil {
+		return pj.Spec.Refs
+	}
+	if len(pj.Spec.ExtraRefs) > 0 {
+		return &pj.Spec.ExtraRefs[0]
+	}
+	return nil
+}
+
+func filterTenantProwJobs(tenant *config.DeckTenant, pjs []prowapi.ProwJob) []prowapi.ProwJob {
+	filtered := make([]prowapi.ProwJob, 0, len(pjs))
+	for _, pj := range pjs {
+		if tenant.HasJob(pj.Spec.Job, jobRefs(pj)) {
+			filtered = append(filtered, pj)
+		}
+	}
+	return filtered
+}
+
+func filterTenantPools(tenant *config.DeckTenant, pools []tide.PoolForDeck) []tide.PoolForDeck {
+	var filtered []tide.PoolForDeck
+	for _, pool := range pools {
+		if tenant.HasRepo(pool.Org + "/" + pool.Repo) {
+			filtered = append(filtered, pool)
+		}
+	}
+	return filtered
+}
+
+// filterRestrictedProwJobs drops the ProwJobs of the restricted tenants.
+func filterRestrictedProwJobs(restricted []config.DeckTenant, pjs []prowapi.ProwJob) []prowapi.ProwJob {
+	if len(restricted) == 0 {
+		return pjs
+	}
+	filtered := make([]prowapi.ProwJob, 0, len(pjs))
+	for _, pj := range pjs {
+		if !slices.ContainsFunc(restricted, func(tenant config.DeckTenant) bool { return tenant.HasJob(pj.Spec.Job, jobRefs(pj)) }) {
+			filtered = append(filtered, pj)
+		}
+	}
+	return filtered
+}
+
+// filterRestrictedJobs drops the jobs of the restricted tenants. The jobs
+// don't keep the extra refs of their ProwJob, so they are matched by the
+// name of the ProwJob to the ProwJobs kept by filterRestrictedProwJobs.
+func filterRestrictedJobs(restricted []config.DeckTenant, js []jobs.Job, pjs []prowapi.ProwJob) []jobs.Job {
+	if len(restricted) == 0 {
+		return js
+	}
+	kept := map[string]bool{}
+	for _, pj := range filterRestrictedProwJobs(restricted, pjs) {
+		kept[pj.Name] = true
+	}
+	filtered := make([]jobs.Job, 0, len(js))
+	for _, j := range js {
+		if kept[j.ProwJob] {
+			filtered = append(filtered, j)
+		}
+	}
+	return filtered
+}
+
+// filterRestrictedPeriodics drops the periodics of the restricted tenants.
+func filterRestrictedPeriodics(restricted []config.DeckTenant, periodics []config.Periodic) []config.Periodic {
+	if len(restricted) == 0 {
+		return periodics
+	}
+	var filtered []config.Periodic
+	for _, periodic := range periodics {
+		var refs *prowapi.Refs
+		if len(periodic.ExtraRefs) > 0 {
+			refs = &periodic.ExtraRefs[0]
+		}
+		if !slices.ContainsFunc(restricted, func(tenant config.DeckTenant) bool { return tenant.HasJob(periodic.Name, refs) }) {
+			filtered = append(filtered, periodic)
+		}
+	}
+	return filtered
+}
+
+// filterRestrictedPools drops the pools of repos of the restricted tenants.
+func filterRestrictedPools(restricted []config.DeckTenant, pools []tide.PoolForDeck) []tide.PoolForDeck {
+	if len(restricted) == 0 {
+		return pools
+	}
+	var filtered []tide.PoolForDeck
+	for _, pool := range pools {
+		if !slices.ContainsFunc(restricted, func(tenant config.DeckTenant) bool { return tenant.HasRepo(pool.Org + "/" + pool.Repo) }) {
+			filtered = append(filtered, pool)
+		}
+	}
+	return filtered
+}
+
+// filterTenantQueries keeps the queries that match PRs of repos of the tenant.
+func filterTenantQueries(tenant *config.DeckTenant, queries []config.TideQuery) []config.TideQuery {
+	var filtered []config.TideQuery
+	for _, query := range queries {
+		matched := false
+		for _, repo := range query.Repos {
+			matched = matched || tenant.HasRepo(repo)
+		}
+		for _, org := range query.Orgs {
+			for _, repo := range tenant.Repos {
+				matched = matched || strings.Split(repo, "/")[0] == org
+			}
+		}
+		if matched {
+			filtered = append(filtered, query)
+		}
+	}
+	return filtered
+}
+
+func filterTenantHistory(tenant *config.DeckTenant, hist map[string][]history.Record) map[string][]history.Record {
+	filtered := make(map[string][]history.Record, len(hist))
+	for pool, records := range hist {
+		if tenant.HasRepo(strings.Split(pool, ":")[0]) {
+			filtered[pool] = records
+		}
+	}
+	return filtered
+}
+
+// filterRestrictedHistory drops the history of pools of repos of the
+// restricted tenants.
+func filterRestrictedHistory(restricted []config.DeckTenant, hist map[string][]history.Record) map[string][]history.Record {
+	if len(restricted) == 0 {
+		return hist
+	}
+	filtered := make(map[string][]history.Record, len(hist))
+	for pool, records := range hist {
+		repo := strings.Split(pool, ":")[0]
+		if !slices.ContainsFunc(restricted, func(tenant config.DeckTenant) bool { return tenant.HasRepo(repo) }) {
+			filtered[pool] = records
+		}
+	}
+	return filtered
+}
+
+// checkJobHistoryTenant returns an error if the job of the job history URL
+// can't be viewed with the request: under a tenant only the jobs of the
+// tenant can, and elsewhere none of the jobs of the restricted tenants.
+func checkJobHistoryTenant(r *http.Request, cfg *config.Config, u *url.URL) error {
+	if tenant := requestTenant(r); tenant != nil {
+		if !tenantHasJobHistory(tenant, cfg, u) {
+			return httpError{error: fmt.Errorf("the job isn't one of tenant %q", tenant.Name), statusCode: http.StatusNotFound}
+		}
+		return nil
+	}
+	for _, restricted := range restrictedTenants(cfg) {
+		if tenantHasJobHistory(&restricted, cfg, u) {
+			return httpError{error: fmt.Errorf("the job can only be viewed under %s%s/", tenantPathPrefix, restricted.Name), statusCode: http.StatusNotFound}
+		}
+	}
+	return nil
+}
+
+// tenantHasJobHistory tells whether the job of the job history URL belongs to
+// the tenant, going by the repos it is configured for.
+func tenantHasJobHistory(tenant *config.DeckTenant, cfg *config.Config, u *url.URL) bool {
+	_, _, root, _, err := parseJobHistURL(u)
+	if err != nil {
+		return false
+	}
+	name := path.Base(root)
+	if tenant.HasJob(name, nil) {
+		return true
+	}
+	for repo, presubmits := range cfg.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			if presubmit.Name == name && tenant.HasRepo(repo) {
+				return true
+			}
+		}
+	}
+	for repo, postsubmits := range cfg.PostsubmitsStatic {
+		for _, postsubmit := range postsubmits {
+			if postsubmit.Name == name && tenant.HasRepo(repo) {
+				return true
+			}
+		}
+	}
+	for _, periodic := range cfg.Periodics {
+		if periodic.Name == name && len(periodic.ExtraRefs) > 0 && tenant.HasJob(name, &periodic.ExtraRefs[0]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+	"sigs.k8s.io/prow/pkg/tide"
+	"sigs.k8s.io/prow/pkg/tide/history"
+)
+
+func TestHandleTenant(t *testing.T) {
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Tenants: []config.DeckTenant{
+			{Name: "payments", Repos: []string{"org/payments"}},
+			{Name: "secret", Repos: []string{"org/secret"}, Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+		}}}}
+	}
+	testCases := []struct {
+		name             string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+		expectedPage     string
+	}{
+		{
+			name:           "page is served scoped to the tenant",
+			path:           "/t/payments/prowjobs.js?var=allBuilds",
+			expectedStatus: http.StatusOK,
+			expectedPage:   "/prowjobs.js payments",
+		},
+		{
+			name:           "index page",
+			path:           "/t/payments/",
+			expectedStatus: http.StatusOK,
+			expectedPage:   "/ payments",
+		},
+		{
+			name:             "tenant without a trailing slash is redirected",
+			path:             "/t/payments",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/t/payments/",
+		},
+		{
+			name:           "unknown tenant",
+			path:           "/t/billing/",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "page that can't be scoped",
+			path:           "/t/payments/plugins",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "viewers can't be checked without a login",
+			path:           "/t/secret/tide",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var page string
+			mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page = r.URL.Path + " " + requestTenant(r).Name
+			})
+			handler := handleTenant(cfg, mux, nil, nil, nil, nil, logrus.WithField("handler", tenantPathPrefix))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if location := rr.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("expected location %q, got %q", tc.expectedLocation, location)
+			}
+			if page != tc.expectedPage {
+				t.Errorf("expected page %q, got %q", tc.expectedPage, page)
+			}
+		})
+	}
+}
+
+func TestFilterTenant(t *testing.T) {
+	tenant := &config.DeckTenant{Name: "payments", Repos: []string{"payments", "org/billing"}, Jobs: []string{"ci-payments-*"}}
+
+	pjs := []prowapi.ProwJob{
+		{Spec: prowapi.ProwJobSpec{Job: "pull-billing", Refs: &prowapi.Refs{Org: "org", Repo: "billing"}}},
+		{Spec: prowapi.ProwJobSpec{Job: "pull-other", Refs: &prowapi.Refs{Org: "org", Repo: "other"}}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-api", ExtraRefs: []prowapi.Refs{{Org: "payments", Repo: "api"}}}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-payments-cleanup"}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-cleanup"}},
+	}
+	var jobs []string
+	for _, pj := range filterTenantProwJobs(tenant, pjs) {
+		jobs = append(jobs, pj.Spec.Job)
+	}
+	if diff := cmp.Diff([]string{"pull-billing", "ci-api", "ci-payments-cleanup"}, jobs); diff != "" {
+		t.Errorf("unexpected jobs (-want +got): %s", diff)
+	}
+
+	queries := []config.TideQuery{
+		{Repos: []string{"org/billing", "org/other"}, Labels: []string{"a"}},
+		{Orgs: []string{"payments"}, Labels: []string{"b"}},
+		{Orgs: []string{"org"}, Labels: []string{"c"}},
+		{Repos: []string{"other/repo"}, Labels: []string{"d"}},
+	}
+	var labels []string
+	for _, query := range filterTenantQueries(tenant, queries) {
+		labels = append(labels, query.Labels...)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, labels); diff != "" {
+		t.Errorf("unexpected queries (-want +got): %s", diff)
+	}
+
+	hist := map[string][]history.Record{
+		"org/billing:main":  {{Action: "MERGE"}},
+		"org/other:main":    {{Action: "MERGE"}},
+		"payments/api:main": {{Action: "TRIGGER"}},
+	}
+	var pools []string
+	for pool := range filterTenantHistory(tenant, hist) {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+	if diff := cmp.Diff([]string{"org/billing:main", "payments/api:main"}, pools); diff != "" {
+		t.Errorf("unexpected history (-want +got): %s", diff)
+	}
+}
+
+func TestFilterRestricted(t *testing.T) {
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Tenants: []config.DeckTenant{
+		{Name: "payments", Repos: []string{"payments", "org/billing"}, Jobs: []string{"ci-payments-*"}, Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+		{Name: "public", Repos: []string{"org/docs"}},
+		{Name: "open", Repos: []string{"org/site"}, Viewers: &prowapi.RerunAuthConfig{AllowAnyone: true}},
+	}}}}
+	restricted := restrictedTenants(cfg)
+	if len(restricted) != 1 || restricted[0].Name != "payments" {
+		t.Fatalf("expected only tenant payments to be restricted, got %v", restricted)
+	}
+
+	pjs := []prowapi.ProwJob{
+		{Spec: prowapi.ProwJobSpec{Job: "pull-billing", Refs: &prowapi.Refs{Org: "org", Repo: "billing"}}},
+		{Spec: prowapi.ProwJobSpec{Job: "pull-docs", Refs: &prowapi.Refs{Org: "org", Repo: "docs"}}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-api", ExtraRefs: []prowapi.Refs{{Org: "payments", Repo: "api"}}}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-payments-cleanup"}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-cleanup"}},
+	}
+	var pjNames []string
+	for _, pj := range filterRestrictedProwJobs(restricted, pjs) {
+		pjNames = append(pjNames, pj.Spec.Job)
+	}
+	if diff := cmp.Diff([]string{"pull-docs", "ci-cleanup"}, pjNames); diff != "" {
+		t.Errorf("unexpected jobs (-want +got): %s", diff)
+	}
+
+	pools := []tide.PoolForDeck{{Org: "org", Repo: "billing"}, {Org: "org", Repo: "docs"}, {Org: "payments", Repo: "api"}}
+	var repos []string
+	for _, pool := range filterRestrictedPools(restricted, pools) {
+		repos = append(repos, pool.Org+"/"+pool.Repo)
+	}
+	if diff := cmp.Diff([]string{"org/docs"}, repos); diff != "" {
+		t.Errorf("unexpected pools (-want +got): %s", diff)
+	}
+
+	hist := map[string][]history.Record{
+		"org/billing:main":  {{Action: "MERGE"}},
+		"org/docs:main":     {{Action: "MERGE"}},
+		"payments/api:main": {{Action: "TRIGGER"}},
+	}
+	var histPools []string
+	for pool := range filterRestrictedHistory(restricted, hist) {
+		histPools = append(histPools, pool)
+	}
+	if diff := cmp.Diff([]string{"org/docs:main"}, histPools); diff != "" {
+		t.Errorf("unexpected history (-want +got): %s", diff)
+	}
+
+	pjs[2].Name = "api"
+	pjs[4].Name = "cleanup"
+	js := []jobs.Job{{Job: "ci-api", ProwJob: "api"}, {Job: "ci-cleanup", ProwJob: "cleanup"}, {Job: "ci-new", ProwJob: "new"}}
+	var jobNames []string
+	for _, j := range filterRestrictedJobs(restricted, js, pjs) {
+		jobNames = append(jobNames, j.Job)
+	}
+	if diff := cmp.Diff([]string{"ci-cleanup"}, jobNames); diff != "" {
+		t.Errorf("unexpected jobs (-want +got): %s", diff)
+	}
+
+	periodics := []config.Periodic{
+		{JobBase: config.JobBase{Name: "ci-api", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "payments", Repo: "api"}}}}},
+		{JobBase: config.JobBase{Name: "ci-payments-cleanup"}},
+		{JobBase: config.JobBase{Name: "ci-cleanup"}},
+	}
+	var periodicNames []string
+	for _, periodic := range filterRestrictedPeriodics(restricted, periodics) {
+		periodicNames = append(periodicNames, periodic.Name)
+	}
+	if diff := cmp.Diff([]string{"ci-cleanup"}, periodicNames); diff != "" {
+		t.Errorf("unexpected periodics (-want +got): %s", diff)
+	}
+}
+
+func TestViewsLeaveOutRestrictedTenants(t *testing.T) {
+	cfg := func() *config.Config {
+		return &config.Config{
+			JobConfig: config.JobConfig{Periodics: []config.Periodic{
+				{JobBase: config.JobBase{Name: "ci-secret", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "secret"}}}}},
+				{JobBase: config.JobBase{Name: "ci-docs"}},
+			}},
+			ProwConfig: config.ProwConfig{Deck: config.Deck{
+				Tenants: []config.DeckTenant{
+					{Name: "secret", Repos: []string{"org/secret"}, Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+				},
+				Dashboards: []config.Dashboard{{Name: "ci", Jobs: []string{"ci-*"}}},
+			}},
+		}
+	}
+	pjs := fkc{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret"},
+			Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "ci-secret", ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "secret"}}},
+			Status:     prowapi.ProwJobStatus{State: prowapi.FailureState, BuildID: "secret-build"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "docs"},
+			Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "ci-docs"},
+			Status:     prowapi.ProwJobStatus{State: prowapi.SuccessState, BuildID: "docs-build"},
+		},
+	}
+	ja := jobs.NewJobAgent(context.Background(), pjs, false, true, []string{}, map[string]jobs.PodLogClient{}, cfg)
+	ja.Start()
+	o := options{templateFilesLocation: "template"}
+
+	testCases := []struct {
+		name     string
+		handler  http.HandlerFunc
+		path     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "jobs",
+			handler:  handleData(cfg, ja, logrus.WithField("handler", "/data.js")),
+			path:     "/data.js",
+			status:   http.StatusOK,
+			expected: "ci-docs",
+		},
+		{
+			name:     "badge",
+			handler:  handleBadge(cfg, ja, true),
+			path:     "/badge.json?jobs=ci-*",
+			status:   http.StatusOK,
+			expected: "passing",
+		},
+		{
+			name:     "dashboard",
+			handler:  handleDashboards(o, cfg, ja),
+			path:     "/dashboards?dashboard=ci",
+			status:   http.StatusOK,
+			expected: "ci-docs",
+		},
+		{
+			name:    "job history stats",
+			handler: handleJobHistoryStats(cfg, nil, logrus.WithField("handler", "/job-history-stats/")),
+			path:    "/job-history-stats/gs/bucket/logs/ci-secret",
+			status:  http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rr.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, rr.Code, rr.Body.String())
+			}
+			body := rr.Body.String()
+			if !strings.Contains(body, tc.expected) {
+				t.Errorf("expected %q in the response, got %s", tc.expected, body)
+			}
+			if strings.Contains(body, "secret-build") || strings.Contains(body, "\"secret\"") {
+				t.Errorf("response contains the job of a restricted tenant: %s", body)
+			}
+		})
+	}
+}
+
+func TestJobHistoryOfRestrictedTenant(t *testing.T) {
+	cfg := func() *config.Config {
+		return &config.Config{
+			JobConfig: config.JobConfig{PresubmitsStatic: map[string][]config.Presubmit{
+				"org/secret": {{JobBase: config.JobBase{Name: "pull-secret"}}},
+			}},
+			ProwConfig: config.ProwConfig{Deck: config.Deck{Tenants: []config.DeckTenant{
+				{Name: "secret", Repos: []string{"org/secret"}, Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+			}}},
+		}
+	}
+	handler := handleJobHistory(options{}, cfg, nil, logrus.WithField("handler", "/job-history/"))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/job-history/gs/bucket/pr-logs/directory/pull-secret", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d: %s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestTenantHasJobHistory(t *testing.T) {
+	tenant := &config.DeckTenant{Name: "payments", Repos: []string{"org/billing"}, Jobs: []string{"ci-payments-*"}}
+	cfg := &config.Config{JobConfig: config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/billing": {{JobBase: config.JobBase{Name: "pull-billing"}}},
+			"org/other":   {{JobBase: config.JobBase{Name: "pull-other"}}},
+		},
+		Periodics: []config.Periodic{
+			{JobBase: config.JobBase{Name: "ci-billing", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "billing"}}}}},
+		},
+	}}
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "/job-history/gs/bucket/pr-logs/directory/pull-billing", expected: true},
+		{path: "/job-history/gs/bucket/pr-logs/directory/pull-other"},
+		{path: "/job-history/gs/bucket/logs/ci-billing", expected: true},
+		{path: "/job-history/gs/bucket/logs/ci-payments-cleanup", expected: true},
+		{path: "/job-history/gs/bucket/logs/ci-unknown"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if actual := tenantHasJobHistory(tenant, cfg, &url.URL{Path: tc.path}); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -102,7 +102,11 @@ func main() {
 	go informerFactory.Start(interrupts.Context().Done())
 
 	registry := mustRegister("exporter", pjLister)
-	registry.MustRegister(prowjobs.NewProwJobLifecycleHistogramVec(informerFactory.Prow().V1().ProwJobs().Informer()))
+	registry.MustRegister(
+		prowjobs.NewProwJobLifecycleHistogramVec(informerFactory.Prow().V1().ProwJobs().Informer()),
+		prowjobs.NewWebhookLatencyHistogramVec(informerFactory.Prow().V1().ProwJobs().Informer()),
+		prowjobs.NewEventLatencyHistogramVec(informerFactory.Prow().V1().ProwJobs().Informer()),
+	)
 
 	// Expose prometheus metrics
 	metrics.ExposeMetricsWithRegistry("exporter", cfg().PushGateway, o.instrumentationOptions.MetricsPort, registry, nil)
@@ -40,6 +40,7 @@ type options struct {
 
 	dryRun                 bool
 	github                 prowflagutil.GitHubOptions
+	gitSigning             prowflagutil.GitSigningOptions
 	labels                 prowflagutil.Strings
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	logLevel               string
@@ -48,11 +49,12 @@ type options struct {
 	prowAssignments   bool
 	allowAll          bool
 	issueOnConflict   bool
+	prOnConflict      bool
 	labelPrefix       string
 }
 
 func (o *options) Validate() error {
-	for idx, group := range []flagutil.OptionGroup{&o.github} {
+	for idx, group := range []flagutil.OptionGroup{&o.github, &o.gitSigning} {
 		if err := group.Validate(o.dryRun); err != nil {
 			return fmt.Errorf("%d: %w", idx, err)
 		}
@@ -72,8 +74,9 @@ func gatherOptions() options {
 	fs.BoolVar(&o.prowAssignments, "use-prow-assignments", true, "Use prow commands to assign cherrypicked PRs.")
 	fs.BoolVar(&o.allowAll, "allow-all", false, "Allow anybody to use automated cherrypicks by skipping GitHub organization membership checks.")
 	fs.BoolVar(&o.issueOnConflict, "create-issue-on-conflict", false, "Create a GitHub issue and assign it to the requestor on cherrypick conflict.")
+	fs.BoolVar(&o.prOnConflict, "create-pr-on-conflict", false, "On cherrypick conflict, push the conflicting changes with their conflict markers and open a held PR with instructions on how to resolve them.")
 	fs.StringVar(&o.labelPrefix, "label-prefix", defaultLabelPrefix, "Set a custom label prefix.")
-	for _, group := range []flagutil.OptionGroup{&o.github, &o.instrumentationOptions} {
+	for _, group := range []flagutil.OptionGroup{&o.github, &o.gitSigning, &o.instrumentationOptions} {
 		group.AddFlags(fs)
 	}
 	fs.Parse(os.Args[1:])
@@ -102,7 +105,11 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Error getting GitHub client.")
 	}
-	gitClient, err := o.github.GitClientFactory("", nil, o.dryRun, false)
+	signing, err := o.gitSigning.ClientFactoryOpt()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error setting up commit signing.")
+	}
+	gitClient, err := o.github.GitClientFactory("", nil, o.dryRun, false, signing)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error getting Git client.")
 	}
@@ -121,6 +128,10 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Error getting bot name.")
 	}
+	// Signed commits are created with the identity the signing key belongs to.
+	if o.gitSigning.CommitterEmail != "" {
+		email = o.gitSigning.CommitterEmail
+	}
 	repos, err := githubClient.GetRepos(botUser.Login, true)
 	if err != nil {
 		log.WithError(err).Fatal("Error listing bot repositories.")
@@ -130,6 +141,7 @@ func main() {
 		tokenGenerator: secret.GetTokenGenerator(o.webhookSecretFile),
 		botUser:        botUser,
 		email:          email,
+		gitName:        o.gitSigning.CommitterName,
 
 		gc:  gitClient,
 		ghc: githubClient,
@@ -139,6 +151,7 @@ func main() {
 		prowAssignments: o.prowAssignments,
 		allowAll:        o.allowAll,
 		issueOnConflict: o.issueOnConflict,
+		prOnConflict:    o.prOnConflict,
 		labelPrefix:     o.labelPrefix,
 
 		bare:     &http.Client{},
@@ -69,7 +69,7 @@ type githubClient interface {
 // HelpProvider construct the pluginhelp.PluginHelp for this plugin.
 func HelpProvider(_ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
 	pluginHelp := &pluginhelp.PluginHelp{
-		Description: `The cherrypick plugin is used for cherrypicking PRs across branches. For every successful cherrypick invocation a new PR is opened against the target branch and assigned to the requestor. If the parent PR contains a release note, it is copied to the cherrypick PR.`,
+		Description: `The cherrypick plugin is used for cherrypicking PRs across branches. For every successful cherrypick invocation a new PR is opened against the target branch and assigned to the requestor. If the parent PR contains a release note, it is copied to the cherrypick PR. Depending on its configuration, cherrypicks that conflict with the target branch are opened on hold with their conflict markers, together with instructions on how to resolve them.`,
 	}
 	pluginHelp.AddCommand(pluginhelp.Command{
 		Usage:       "/cherrypick [branch]",
@@ -88,6 +88,8 @@ type Server struct {
 	tokenGenerator func() []byte
 	botUser        *github.UserData
 	email          string
+	// gitName overrides the login of the bot as the name of the committer.
+	gitName string
 
 	gc git.ClientFactory
 	// Used for unit testing
@@ -103,6 +105,8 @@ type Server struct {
 	allowAll bool
 	// Create an issue on cherrypick conflict.
 	issueOnConflict bool
+	// Open a PR with conflict markers on cherrypick conflict.
+	prOnConflict bool
 	// Set a custom label prefix.
 	labelPrefix string
 
@@ -450,7 +454,11 @@ func (s *Server) handle(logger *logrus.Entry, requestor string, comment *github.
 		return s.createComment(logger, org, repo, num, comment, fmt.Sprintf("Failed to get PR patch from GitHub. This PR will need to be manually cherrypicked.\n<details><summary>Error message</summary>%v</details>", err))
 	}
 
-	if err := r.Config("user.name", s.botUser.Login); err != nil {
+	name := s.gitName
+	if name == "" {
+		name = s.botUser.Login
+	}
+	if err := r.Config("user.name", name); err != nil {
 		return fmt.Errorf("failed to configure git user: %w", err)
 	}
 	email := s.email
@@ -490,7 +498,18 @@ func (s *Server) handle(logger *logrus.Entry, requestor string, comment *github.
 	title = fmt.Sprintf("%s%s", titleTargetBranchIndicator, omitBaseBranchFromTitle(title, baseBranch))
 
 	// Apply the patch.
-	if err := r.Am(localPath); err != nil {
+	var conflicts []string
+	amErr := r.Am(localPath)
+	if amErr != nil && s.prOnConflict {
+		logger.WithError(amErr).Info("failed to apply PR on top of target branch, applying it with conflict markers")
+		var err error
+		if conflicts, err = r.AmWithConflicts(localPath); err != nil {
+			logger.WithError(err).Warn("failed to apply PR with conflict markers")
+		} else {
+			amErr = nil
+		}
+	}
+	if err := amErr; err != nil {
 		errs := []error{fmt.Errorf("failed to `git am`: %w", err)}
 		logger.WithError(err).Warn("failed to apply PR on top of target branch")
 		resp := fmt.Sprintf("#%d failed to apply on top of branch %q:\n```\n%v\n```", num, targetBranch, err)
@@ -526,6 +545,9 @@ func (s *Server) handle(logger *logrus.Entry, requestor string, comment *github.
 	} else {
 		cherryPickBody = cherrypicker.CreateCherrypickBody(num, "", releaseNoteFromParentPR(body), chainBranches)
 	}
+	if len(conflicts) > 0 {
+		cherryPickBody = fmt.Sprintf("%s\n\n%s", cherryPickBody, conflictInstructions(s.botUser.Login, forkName, newBranch, conflicts))
+	}
 	head := fmt.Sprintf("%s:%s", s.botUser.Login, newBranch)
 	createdNum, err := s.ghc.CreatePullRequest(org, repo, title, cherryPickBody, head, targetBranch, true)
 	if err != nil {
@@ -535,6 +557,9 @@ func (s *Server) handle(logger *logrus.Entry, requestor string, comment *github.
 	}
 	*logger = *logger.WithField("new_pull_request_number", createdNum)
 	resp := fmt.Sprintf("new pull request created: #%d", createdNum)
+	if len(conflicts) > 0 {
+		resp = fmt.Sprintf("#%d conflicts with branch %q in %d file(s). New pull request created with the conflict markers: #%d\n\nIt is on hold until the conflicts are resolved, see its description for how to resolve them.", num, targetBranch, len(conflicts), createdNum)
+	}
 	logger.Info("new pull request created")
 	if err := s.createComment(logger, org, repo, num, comment, resp); err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
@@ -556,6 +581,32 @@ func (s *Server) handle(logger *logrus.Entry, requestor string, comment *github.
 	return nil
 }
 
+// conflictInstructions describes the conflicts of a cherrypick PR and how to
+// resolve them. The PR is held, so that the conflict markers aren't merged.
+func conflictInstructions(botLogin, forkName, branch string, conflicts []string) string {
+	var files strings.Builder
+	for _, file := range conflicts {
+		fmt.Fprintf(&files, "- `%s`\n", file)
+	}
+	remote := fmt.Sprintf("https://github.com/%s/%s.git", botLogin, forkName)
+	return fmt.Sprintf(`**This cherry-pick conflicts with the target branch.** The conflicting changes were committed with their conflict markers in these files:
+
+%s
+To resolve the conflicts, fetch this branch, replace the conflict markers with the intended changes and push the result to this PR:
+
+`+"```"+`
+git fetch %s %s
+git checkout -b %s FETCH_HEAD
+# resolve the conflicts
+git commit -a -m "Resolve cherry-pick conflicts"
+git push %s HEAD:%s
+`+"```"+`
+
+Remove the hold once the conflicts are resolved.
+
+/hold`, files.String(), remote, branch, branch, remote, branch)
+}
+
 // omitBaseBranchFromTitle returns the title without the base branch's
 // indicator, if there is one. We do this to avoid long cherry-pick titles when
 // doing a backport of a backport.
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -357,6 +358,117 @@ func testCherryPickIC(clients localgit.Clients, t *testing.T) {
 	}
 }
 
+func TestCherryPickConflictV2(t *testing.T) {
+	t.Parallel()
+	testCherryPickConflict(localgit.NewV2, t)
+}
+
+func testCherryPickConflict(clients localgit.Clients, t *testing.T) {
+	testCases := []struct {
+		name            string
+		prOnConflict    bool
+		expectedPR      bool
+		expectedComment string
+	}{
+		{
+			name:            "conflicting cherrypick fails",
+			expectedComment: `failed to apply on top of branch "stage"`,
+		},
+		{
+			name:            "conflicting cherrypick is opened with conflict markers",
+			prOnConflict:    true,
+			expectedPR:      true,
+			expectedComment: `conflicts with branch "stage" in 1 file(s). New pull request created with the conflict markers: #1`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			iNumber := fakePR.GetPRNumber()
+			lg, c := makeFakeRepoWithCommit(clients, t)
+			if err := lg.CheckoutNewBranch("foo", "bar", "stage"); err != nil {
+				t.Fatalf("Checking out pull branch: %v", err)
+			}
+			if err := lg.AddCommit("foo", "bar", map[string][]byte{"bar.go": []byte(`// Package bar does an interesting thing.
+package bar
+
+// Foo does a thing.
+func Foo(wow int) int {
+	return 43 + wow
+}
+`)}); err != nil {
+				t.Fatalf("Adding conflicting commit: %v", err)
+			}
+
+			ghc := &fghc{
+				pr: &github.PullRequest{
+					Base:   github.PullRequestBranch{Ref: "master"},
+					Merged: true,
+					Title:  "This is a fix for X",
+					Body:   body,
+				},
+				isMember: true,
+				patch:    patch,
+			}
+			ic := github.IssueCommentEvent{
+				Action: github.IssueCommentActionCreated,
+				Repo: github.Repo{
+					Owner:    github.User{Login: "foo"},
+					Name:     "bar",
+					FullName: "foo/bar",
+				},
+				Issue: github.Issue{
+					Number:      iNumber,
+					State:       "closed",
+					PullRequest: &struct{}{},
+				},
+				Comment: github.IssueComment{
+					User: github.User{Login: "wiseguy"},
+					Body: "/cherrypick stage",
+				},
+			}
+
+			s := &Server{
+				botUser:        &github.UserData{Login: "ci-robot", Email: "ci-robot@users.noreply.github.com"},
+				gc:             c,
+				push:           func(forkName, newBranch string, force bool) error { return nil },
+				ghc:            ghc,
+				tokenGenerator: func() []byte { return []byte("sha=abcdefg") },
+				log:            logrus.StandardLogger().WithField("client", "cherrypicker"),
+				repos:          []github.Repo{{Fork: true, FullName: "ci-robot/bar"}},
+
+				prOnConflict: tc.prOnConflict,
+			}
+
+			err := s.handleIssueComment(logrus.NewEntry(logrus.StandardLogger()), ic)
+			if tc.expectedPR && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ghc.comments) != 1 || !strings.Contains(ghc.comments[0], tc.expectedComment) {
+				t.Errorf("expected a comment containing %q, got %q", tc.expectedComment, ghc.comments)
+			}
+			if !tc.expectedPR {
+				if len(ghc.prs) != 0 {
+					t.Errorf("expected no PR, got %d", len(ghc.prs))
+				}
+				return
+			}
+			if len(ghc.prs) != 1 {
+				t.Fatalf("expected one PR, got %d", len(ghc.prs))
+			}
+			branch := fmt.Sprintf(cherryPickBranchFmt, iNumber, "stage")
+			for _, expected := range []string{
+				"- `bar.go`",
+				fmt.Sprintf("git fetch https://github.com/ci-robot/bar.git %s", branch),
+				"\n/hold",
+			} {
+				if !strings.Contains(ghc.prs[0].Body, expected) {
+					t.Errorf("expected PR body to contain %q, got %q", expected, ghc.prs[0].Body)
+				}
+			}
+		})
+	}
+}
+
 func TestCherryPickPRV2(t *testing.T) {
 	t.Parallel()
 	testCherryPickPR(localgit.NewV2, t)
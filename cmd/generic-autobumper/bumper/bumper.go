@@ -32,6 +32,7 @@ import (
 
 	"sigs.k8s.io/prow/cmd/generic-autobumper/updater"
 	"sigs.k8s.io/prow/pkg/config/secret"
+	gitv2 "sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
 )
 
@@ -65,6 +66,11 @@ type Options struct {
 	SkipPullRequest bool `json:"skipPullRequest"`
 	// Whether to signoff the commits.
 	Signoff bool `json:"signoff"`
+	// The path to the unencrypted private key to sign the commits with. The commits are created with GitName and GitEmail
+	// (or the Gerrit author and email) as committer, so the key must belong to that identity. Commits are unsigned if unset.
+	SigningKey string `json:"signingKey"`
+	// The format of the signing key, "ssh" or "openpgp". Defaults to "ssh".
+	SigningFormat string `json:"signingFormat"`
 	// Information needed to do a gerrit bump. Do not include if doing github bump
 	Gerrit *Gerrit `json:"gerrit"`
 	// The name used in the address when creating remote. This should be the same name as the fork. If fork does not exist this will be the name of the fork that is created.
@@ -175,6 +181,11 @@ func validateOptions(o *Options) error {
 			return fmt.Errorf("GerritCookieFile is required when skipPullRequest is false and Gerrit is true")
 		}
 	}
+	switch gitv2.SigningFormat(o.SigningFormat) {
+	case "", gitv2.SigningFormatSSH, gitv2.SigningFormatOpenPGP:
+	default:
+		return fmt.Errorf("signingFormat must be %q or %q", gitv2.SigningFormatSSH, gitv2.SigningFormatOpenPGP)
+	}
 	if !o.SkipPullRequest {
 		if o.HeadBranchName == "" {
 			o.HeadBranchName = defaultHeadBranchName
@@ -229,6 +240,11 @@ func processGitHub(ctx context.Context, o *Options, prh PRHandler) error {
 			o.GitEmail = user.Email
 		}
 	}
+	cleanup, err := configureSigning(o, o.GitName, o.GitEmail, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
 	// Make change, commit and push
 	var anyChange bool
@@ -276,6 +292,42 @@ func processGitHub(ctx context.Context, o *Options, prh PRHandler) error {
 	return nil
 }
 
+// configureSigning makes git sign the commits created in the current repo with
+// the key at o.SigningKey, committed by name <email>. The returned function
+// removes the key material handed to git.
+func configureSigning(o *Options, name, email string, stdout, stderr io.Writer) (func(), error) {
+	if o.SigningKey == "" {
+		return func() {}, nil
+	}
+	if err := secret.Add(o.SigningKey); err != nil {
+		return nil, fmt.Errorf("load signing key: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "autobump-signing")
+	if err != nil {
+		return nil, fmt.Errorf("create signing dir: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.WithError(err).Warn("Failed to remove the signing dir.")
+		}
+	}
+	signer, err := gitv2.NewCommitSigner(gitv2.CommitSigningOpts{
+		Format:    gitv2.SigningFormat(o.SigningFormat),
+		Key:       secret.GetTokenGenerator(o.SigningKey),
+		Committer: func() (string, string, error) { return name, email, nil },
+	}, dir)
+	if err == nil {
+		err = signer.Configure(func(key, value string) error {
+			return Call(stdout, stderr, gitCmd, []string{"config", key, value})
+		})
+	}
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("configure commit signing: %w", err)
+	}
+	return cleanup, nil
+}
+
 func processGerrit(ctx context.Context, o *Options, prh PRHandler) error {
 	stdout := HideSecretsWriter{Delegate: os.Stdout, Censor: secret.Censor}
 	stderr := HideSecretsWriter{Delegate: os.Stderr, Censor: secret.Censor}
@@ -289,6 +341,11 @@ func processGerrit(ctx context.Context, o *Options, prh PRHandler) error {
 	if err := Call(stdout, stderr, gitCmd, []string{"config", "user.email", o.Gerrit.Email}); err != nil {
 		return fmt.Errorf("unable to set password: %w", err)
 	}
+	cleanup, err := configureSigning(o, o.Gerrit.Author, o.Gerrit.Email, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 	if err := Call(stdout, stderr, gitCmd, []string{"remote", "add", "upstream", o.Gerrit.HostRepo}); err != nil {
 		return fmt.Errorf("unable to add upstream remote: %w", err)
 	}
@@ -29,6 +29,8 @@ import (
 func TestValidateOptions(t *testing.T) {
 	emptyStr := ""
 	trueVar := true
+	openpgp := "openpgp"
+	x509 := "x509"
 	cases := []struct {
 		name                string
 		githubToken         *string
@@ -42,6 +44,7 @@ func TestValidateOptions(t *testing.T) {
 		remoteName          *string
 		skipPullRequest     *bool
 		signoff             *bool
+		signingFormat       *string
 		err                 bool
 		upstreamBaseChanged bool
 	}{
@@ -93,6 +96,15 @@ func TestValidateOptions(t *testing.T) {
 			gerritPRIdentifier: &emptyStr,
 			err:                true,
 		},
+		{
+			name:          "signingFormat can be openpgp",
+			signingFormat: &openpgp,
+		},
+		{
+			name:          "signingFormat must be supported",
+			signingFormat: &x509,
+			err:           true,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -121,6 +133,9 @@ func TestValidateOptions(t *testing.T) {
 			if tc.signoff != nil {
 				defaultOption.Signoff = *tc.signoff
 			}
+			if tc.signingFormat != nil {
+				defaultOption.SigningFormat = *tc.signingFormat
+			}
 			if tc.githubToken != nil {
 				defaultOption.GitHubToken = *tc.githubToken
 			}
@@ -428,8 +428,9 @@ func (c *client) batchOnboardNewTokenForRepos() []error {
 // cleanup will do necessary cleanups after the token and webhook updates are done.
 func (c *client) cleanup() error {
 	// Prune old tokens from current config.
+	now := time.Now()
 	for repoName := range c.currentHMACMap {
-		c.pruneOldTokens(repoName)
+		c.pruneOldTokens(repoName, now)
 	}
 	// Update the secret.
 	if err := c.updateHMACTokenSecret(); err != nil {
@@ -459,19 +460,39 @@ func (c *client) updateHMACTokenSecret() error {
 	return nil
 }
 
-// pruneOldTokens removes all but most recent token from token config.
-func (c *client) pruneOldTokens(repo string) {
+// pruneOldTokens removes all but most recent token from token config. With a
+// rotation overlap, older tokens are kept until the overlap ends instead, so
+// that webhooks signed with them before the rotation still validate.
+func (c *client) pruneOldTokens(repo string, now time.Time) {
 	tokens := c.currentHMACMap[repo]
 	if len(tokens) <= 1 {
 		logrus.WithField("repo", repo).Debugf("Token size is %d, no need to prune", len(tokens))
 		return
 	}
 
-	logrus.WithField("repo", repo).Debugf("Token size is %d, prune to 1", len(tokens))
 	sort.SliceStable(tokens, func(i, j int) bool {
 		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
 	})
-	c.currentHMACMap[repo] = tokens[:1]
+	overlap := c.newHMACConfig.RotationOverlap
+	if overlap == nil || overlap.Duration <= 0 {
+		logrus.WithField("repo", repo).Debugf("Token size is %d, prune to 1", len(tokens))
+		c.currentHMACMap[repo] = tokens[:1]
+		return
+	}
+
+	kept := github.HMACsForRepo{tokens[0]}
+	for _, token := range tokens[1:] {
+		if token.ExpiresAt == nil {
+			expiresAt := now.Add(overlap.Duration)
+			token.ExpiresAt = &expiresAt
+		}
+		if token.Expired(now) {
+			continue
+		}
+		kept = append(kept, token)
+	}
+	logrus.WithField("repo", repo).Debugf("Token size is %d, prune to %d", len(tokens), len(kept))
+	c.currentHMACMap[repo] = kept
 }
 
 // generateNewHMACToken generates a hex encoded crypto random string of length 40.
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/prow/cmd/hmac/fakeghhook"
@@ -128,9 +129,13 @@ func TestPruneOldTokens(t *testing.T) {
 	time2, _ := time.Parse(time.RFC3339, "2020-02-05T19:07:08+00:00")
 	time3, _ := time.Parse(time.RFC3339, "2020-03-05T19:07:08+00:00")
 
+	now := time3.Add(time.Hour)
+	overlapEnd := now.Add(24 * time.Hour)
+
 	cases := []struct {
 		name     string
 		current  map[string]github.HMACsForRepo
+		overlap  *metav1.Duration
 		repo     string
 		expected map[string]github.HMACsForRepo
 	}{
@@ -186,6 +191,72 @@ func TestPruneOldTokens(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with a rotation overlap, replaced hmacs expire at the end of the overlap",
+			current: map[string]github.HMACsForRepo{
+				"org1/repo1": []github.HMACToken{
+					{
+						Value:     "rand-val1",
+						CreatedAt: time1,
+					},
+					{
+						Value:     "rand-val2",
+						CreatedAt: time2,
+					},
+				},
+			},
+			overlap: &metav1.Duration{Duration: 24 * time.Hour},
+			repo:    "org1/repo1",
+			expected: map[string]github.HMACsForRepo{
+				"org1/repo1": []github.HMACToken{
+					{
+						Value:     "rand-val2",
+						CreatedAt: time2,
+					},
+					{
+						Value:     "rand-val1",
+						CreatedAt: time1,
+						ExpiresAt: &overlapEnd,
+					},
+				},
+			},
+		},
+		{
+			name: "with a rotation overlap, expired hmacs are pruned",
+			current: map[string]github.HMACsForRepo{
+				"org1/repo1": []github.HMACToken{
+					{
+						Value:     "rand-val1",
+						CreatedAt: time1,
+						ExpiresAt: &time3,
+					},
+					{
+						Value:     "rand-val2",
+						CreatedAt: time2,
+						ExpiresAt: &overlapEnd,
+					},
+					{
+						Value:     "rand-val3",
+						CreatedAt: time3,
+					},
+				},
+			},
+			overlap: &metav1.Duration{Duration: 24 * time.Hour},
+			repo:    "org1/repo1",
+			expected: map[string]github.HMACsForRepo{
+				"org1/repo1": []github.HMACToken{
+					{
+						Value:     "rand-val3",
+						CreatedAt: time3,
+					},
+					{
+						Value:     "rand-val2",
+						CreatedAt: time2,
+						ExpiresAt: &overlapEnd,
+					},
+				},
+			},
+		},
 		{
 			name: "nothing will be changed if the repo is not in the map",
 			current: map[string]github.HMACsForRepo{
@@ -210,8 +281,8 @@ func TestPruneOldTokens(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := &client{currentHMACMap: tc.current}
-			c.pruneOldTokens(tc.repo)
+			c := &client{currentHMACMap: tc.current, newHMACConfig: config.ManagedWebhooks{RotationOverlap: tc.overlap}}
+			c.pruneOldTokens(tc.repo, now)
 			if !reflect.DeepEqual(tc.expected, c.currentHMACMap) {
 				t.Errorf("%#v != expected %#v", c.currentHMACMap, tc.expected)
 			}
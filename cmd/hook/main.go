@@ -17,9 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -46,6 +49,7 @@ import (
 	bzplugin "sigs.k8s.io/prow/pkg/plugins/bugzilla"
 	"sigs.k8s.io/prow/pkg/plugins/jira"
 	"sigs.k8s.io/prow/pkg/plugins/ownersconfig"
+	"sigs.k8s.io/prow/pkg/plugins/trigger"
 	"sigs.k8s.io/prow/pkg/repoowners"
 	"sigs.k8s.io/prow/pkg/slack"
 
@@ -74,6 +78,10 @@ type options struct {
 
 	webhookSecretFile string
 	slackTokenFile    string
+
+	redeliveryInterval    time.Duration
+	redeliveryWindow      time.Duration
+	redeliveryMaxAttempts int
 }
 
 func (o *options) Validate() error {
@@ -83,6 +91,18 @@ func (o *options) Validate() error {
 		}
 	}
 
+	if o.redeliveryInterval > 0 {
+		if o.github.AppID == "" {
+			return errors.New("--webhook-redelivery-interval requires GitHub App authentication with --github-app-id")
+		}
+		if o.redeliveryWindow <= 0 {
+			return errors.New("--webhook-redelivery-window must be positive")
+		}
+		if o.redeliveryMaxAttempts < 2 {
+			return errors.New("--webhook-redelivery-max-attempts must be at least 2")
+		}
+	}
+
 	return nil
 }
 
@@ -100,6 +120,9 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 
 	fs.StringVar(&o.webhookSecretFile, "hmac-secret-file", "/etc/webhook/hmac", "Path to the file containing the GitHub HMAC secret.")
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to the file containing the Slack token to use.")
+	fs.DurationVar(&o.redeliveryInterval, "webhook-redelivery-interval", 0, "How often to look for webhooks of the GitHub App that failed to be delivered, for example while hook was down, and ask GitHub to redeliver them. Disabled if 0. Requires GitHub App authentication.")
+	fs.DurationVar(&o.redeliveryWindow, "webhook-redelivery-window", 6*time.Hour, "How old failed webhook deliveries can be to be redelivered.")
+	fs.IntVar(&o.redeliveryMaxAttempts, "webhook-redelivery-max-attempts", 3, "How many times a webhook is delivered at most, including the first delivery.")
 	fs.Parse(args)
 	return o
 }
@@ -134,9 +157,7 @@ func main() {
 		tokens = append(tokens, o.slackTokenFile)
 	}
 
-	if o.bugzilla.ApiKeyPath != "" {
-		tokens = append(tokens, o.bugzilla.ApiKeyPath)
-	}
+	tokens = append(tokens, o.bugzilla.ApiKeyPaths()...)
 
 	if err := secret.Add(tokens...); err != nil {
 		logrus.WithError(err).Fatal("Error starting secrets agent.")
@@ -157,12 +178,14 @@ func main() {
 	}
 
 	var bugzillaClient bugzilla.Client
+	var bugzillaClients map[string]bugzilla.Client
 	if orgs, repos, _ := pluginAgent.Config().EnabledReposForPlugin(bzplugin.PluginName); orgs != nil || repos != nil {
 		client, err := o.bugzilla.BugzillaClient()
 		if err != nil {
 			logrus.WithError(err).Fatal("Error getting Bugzilla client.")
 		}
 		bugzillaClient = client
+		bugzillaClients = o.bugzilla.BugzillaClients()
 	} else {
 		// we want something non-nil here with good no-op behavior,
 		// so the test fake is a cheap way to do that
@@ -222,6 +245,11 @@ func main() {
 		return pluginAgent.Config().OwnersFilenames(org, repo)
 	}
 	ownersClient := repoowners.NewClient(gitClient, githubClient, mdYAMLEnabled, skipCollaborators, ownersDirDenylist, resolver)
+	configChanges := make(chan config.Delta)
+	configAgent.Subscribe(configChanges)
+	interrupts.Run(func(ctx context.Context) {
+		clearOwnersCacheOnConfigChange(ctx, configChanges, ownersClient)
+	})
 
 	clientAgent := &plugins.ClientAgent{
 		GitHubClient:              githubClient,
@@ -232,6 +260,7 @@ func main() {
 		SlackClient:               slackClient,
 		OwnersClient:              ownersClient,
 		BugzillaClient:            bugzillaClient,
+		BugzillaClients:           bugzillaClients,
 		JiraClient:                jiraClient,
 	}
 
@@ -258,6 +287,13 @@ func main() {
 		}
 	})
 
+	if o.redeliveryInterval > 0 {
+		poller := hook.NewRedeliveryPoller(githubClient, o.redeliveryWindow, o.redeliveryMaxAttempts)
+		interrupts.TickLiteral(func() {
+			poller.Poll(time.Now())
+		}, o.redeliveryInterval)
+	}
+
 	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
 
 	hookMux := http.NewServeMux()
@@ -272,7 +308,34 @@ func main() {
 
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: hookMux}
 
+	// Explain the trust policies of trigger from /trust-policy on the health
+	// port, as it reads the permissions and memberships of PR authors.
+	health.Handle("/trust-policy", trigger.NewTrustPolicyExplainer(pluginAgent.Config, githubClient))
+	// Serve the rotation status of the hmac tokens from /hmac-status on the
+	// health port, as it tells which scopes webhooks are accepted for.
+	health.Handle("/hmac-status", http.HandlerFunc(server.ServeHMACStatus))
 	health.ServeReady()
 
 	interrupts.ListenAndServe(httpServer, o.gracePeriod)
 }
+
+type ownersCache interface {
+	ClearCache()
+}
+
+// clearOwnersCacheOnConfigChange clears the cached OWNERS whenever the
+// OWNERS dir denylist changes, as cached OWNERS are otherwise only reloaded
+// when the OWNERS files change.
+func clearOwnersCacheOnConfigChange(ctx context.Context, changes <-chan config.Delta, cache ownersCache) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delta := <-changes:
+			if !reflect.DeepEqual(delta.Before.OwnersDirDenylist, delta.After.OwnersDirDenylist) {
+				logrus.Info("The OWNERS dir denylist changed, clearing the OWNERS cache.")
+				cache.ClearCache()
+			}
+		}
+	}
+}
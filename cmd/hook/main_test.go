@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"reflect"
 	"testing"
@@ -25,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
 	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
@@ -68,6 +70,13 @@ func Test_gatherOptions(t *testing.T) {
 				o.pluginsConfig.PluginConfigPath = "/random/value"
 			},
 		},
+		{
+			name: "webhook redelivery requires app auth",
+			args: map[string]string{
+				"--webhook-redelivery-interval": "5m",
+			},
+			err: true,
+		},
 		{
 			name: "explicitly set --webhook-path",
 			args: map[string]string{
@@ -99,6 +108,8 @@ func Test_gatherOptions(t *testing.T) {
 				gracePeriod:            180 * time.Second,
 				webhookSecretFile:      "/etc/webhook/hmac",
 				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
+				redeliveryWindow:       6 * time.Hour,
+				redeliveryMaxAttempts:  3,
 			}
 			expectedfs := flag.NewFlagSet("fake-flags", flag.PanicOnError)
 			expected.github.AddFlags(expectedfs)
@@ -135,3 +146,34 @@ func Test_gatherOptions(t *testing.T) {
 		})
 	}
 }
+
+type fakeOwnersCache struct {
+	cleared int
+}
+
+func (f *fakeOwnersCache) ClearCache() {
+	f.cleared++
+}
+
+func TestClearOwnersCacheOnConfigChange(t *testing.T) {
+	denylist := func(dirs ...string) config.ProwConfig {
+		return config.ProwConfig{OwnersDirDenylist: &config.OwnersDirDenylist{Default: dirs}}
+	}
+	changes := make(chan config.Delta)
+	cache := &fakeOwnersCache{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		clearOwnersCacheOnConfigChange(ctx, changes, cache)
+		close(done)
+	}()
+
+	changes <- config.Delta{Before: config.Config{ProwConfig: denylist("vendor")}, After: config.Config{ProwConfig: denylist("vendor")}}
+	changes <- config.Delta{Before: config.Config{ProwConfig: denylist("vendor")}, After: config.Config{ProwConfig: denylist("vendor", "third_party")}}
+	cancel()
+	<-done
+
+	if cache.cleared != 1 {
+		t.Errorf("expected the cache to be cleared once, was cleared %d times", cache.cleared)
+	}
+}
@@ -63,8 +63,10 @@ import (
 	_ "sigs.k8s.io/prow/pkg/plugins/stage"
 	_ "sigs.k8s.io/prow/pkg/plugins/testfreeze"
 	_ "sigs.k8s.io/prow/pkg/plugins/transfer-issue"
+	_ "sigs.k8s.io/prow/pkg/plugins/triage"
 	_ "sigs.k8s.io/prow/pkg/plugins/trick-or-treat"
 	_ "sigs.k8s.io/prow/pkg/plugins/trigger"
+	_ "sigs.k8s.io/prow/pkg/plugins/unsupported-branches"
 	_ "sigs.k8s.io/prow/pkg/plugins/updateconfig"
 	_ "sigs.k8s.io/prow/pkg/plugins/verify-owners"
 	_ "sigs.k8s.io/prow/pkg/plugins/welcome"
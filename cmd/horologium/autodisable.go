@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// slackClient is used to notify the owners of periodics that are about to be
+// disabled.
+type slackClient interface {
+	WriteMessage(text, channel string) error
+}
+
+// isAutoDisabled returns true if horologium stopped scheduling the periodic
+// the given job is the latest run of.
+func isAutoDisabled(latest prowapi.ProwJob) bool {
+	_, disabled := latest.Annotations[kube.AutoDisabledAnnotation]
+	return disabled
+}
+
+// failingSince returns the start time of the oldest failed run after the
+// latest successful one, along with the number of failed runs since then.
+// Aborted runs are ignored, as they say nothing about the health of the job.
+func failingSince(runs []prowapi.ProwJob) (time.Time, int) {
+	var lastSuccess time.Time
+	for _, run := range runs {
+		if run.Status.State == prowapi.SuccessState && run.Status.StartTime.After(lastSuccess) {
+			lastSuccess = run.Status.StartTime.Time
+		}
+	}
+
+	var since time.Time
+	var failures int
+	for _, run := range runs {
+		if run.Status.State != prowapi.FailureState && run.Status.State != prowapi.ErrorState {
+			continue
+		}
+		if !run.Status.StartTime.After(lastSuccess) {
+			continue
+		}
+		failures++
+		if since.IsZero() || run.Status.StartTime.Time.Before(since) {
+			since = run.Status.StartTime.Time
+		}
+	}
+	return since, failures
+}
+
+// autoDisablePeriodics notifies the owners of periodics that have not
+// succeeded for the configured time and, once the grace period is over,
+// marks their latest run so that sync stops scheduling them.
+func autoDisablePeriodics(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, slack slackClient, now time.Time) error {
+	policy := cfg.Horologium.AutoDisable
+	if policy == nil {
+		return nil
+	}
+
+	jobs := &prowapi.ProwJobList{}
+	if err := prowJobClient.List(context.TODO(), jobs, ctrlruntimeclient.InNamespace(cfg.ProwJobNamespace)); err != nil {
+		return fmt.Errorf("error listing prow jobs: %w", err)
+	}
+	runs := map[string][]prowapi.ProwJob{}
+	for _, job := range jobs.Items {
+		if job.Spec.Type == prowapi.PeriodicJob {
+			runs[job.Spec.Job] = append(runs[job.Spec.Job], job)
+		}
+	}
+
+	var errs []error
+	for _, p := range cfg.Periodics {
+		var latest *prowapi.ProwJob
+		for i := range runs[p.Name] {
+			if latest == nil || runs[p.Name][i].Status.StartTime.After(latest.Status.StartTime.Time) {
+				latest = &runs[p.Name][i]
+			}
+		}
+		// Only act on completed runs, a run in progress may still succeed.
+		if latest == nil || !latest.Complete() || isAutoDisabled(*latest) {
+			continue
+		}
+
+		since, failures := failingSince(runs[p.Name])
+		if since.IsZero() || now.Sub(since) < policy.FailingFor.Duration || failures < policy.MinimumRuns {
+			continue
+		}
+		logger := logrus.WithFields(logrus.Fields{
+			"job":           p.Name,
+			"failing-since": since,
+			"failures":      failures,
+		})
+
+		notified, found := latest.Annotations[kube.AutoDisableNotifiedAnnotation]
+		if !found {
+			disableAt := now.Add(policy.GracePeriod.Duration)
+			if err := notifyOwner(p, slack, since, failures, disableAt); err != nil {
+				// Try again on the next tick rather than starting the grace
+				// period without the owner knowing about it.
+				errs = append(errs, fmt.Errorf("failed to notify the owner of %s: %w", p.Name, err))
+				continue
+			}
+			logger.WithField("disable-at", disableAt).Info("Notified owner about periodic to be disabled.")
+			if err := annotate(prowJobClient, latest, kube.AutoDisableNotifiedAnnotation, now); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		notifiedAt, err := time.Parse(time.RFC3339, notified)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s annotation on %s: %w", kube.AutoDisableNotifiedAnnotation, latest.Name, err))
+			continue
+		}
+		if now.Sub(notifiedAt) < policy.GracePeriod.Duration {
+			continue
+		}
+		logger.Warn("Disabling periodic that keeps failing.")
+		if err := annotate(prowJobClient, latest, kube.AutoDisabledAnnotation, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to auto-disable %d periodics: %v", len(errs), errs)
+	}
+	return nil
+}
+
+// notifyOwner tells the owner of the periodic that it will be disabled. If
+// the periodic has no Slack channel or no Slack client is configured, the
+// notification is only logged.
+func notifyOwner(p config.Periodic, slack slackClient, since time.Time, failures int, disableAt time.Time) error {
+	msg := fmt.Sprintf("Periodic job `%s` has not succeeded since %s (%d failed runs). "+
+		"It will no longer be scheduled after %s unless it succeeds before then.",
+		p.Name, since.UTC().Format(time.RFC3339), failures, disableAt.UTC().Format(time.RFC3339))
+	if p.Owner == nil || p.Owner.SlackChannel == "" || slack == nil {
+		logrus.WithField("job", p.Name).Warn("Cannot notify the owner of a periodic to be disabled: " + msg)
+		return nil
+	}
+	if p.Owner.EscalationURL != "" {
+		msg += fmt.Sprintf(" See %s for how to escalate.", p.Owner.EscalationURL)
+	}
+	return slack.WriteMessage(msg, p.Owner.SlackChannel)
+}
+
+func annotate(prowJobClient ctrlruntimeclient.Client, pj *prowapi.ProwJob, key string, now time.Time) error {
+	original := pj.DeepCopy()
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	pj.Annotations[key] = now.UTC().Format(time.RFC3339)
+	if err := prowJobClient.Patch(context.TODO(), pj, ctrlruntimeclient.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to annotate %s with %s: %w", pj.Name, key, err)
+	}
+	return nil
+}
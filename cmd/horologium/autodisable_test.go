@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+type fakeSlack struct {
+	err      error
+	messages map[string][]string
+}
+
+func (f *fakeSlack) WriteMessage(text, channel string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.messages == nil {
+		f.messages = map[string][]string{}
+	}
+	f.messages[channel] = append(f.messages[channel], text)
+	return nil
+}
+
+func TestAutoDisablePeriodics(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	notifiedAt := func(ago time.Duration) map[string]string {
+		return map[string]string{kube.AutoDisableNotifiedAnnotation: now.Add(-ago).Format(time.RFC3339)}
+	}
+	run := func(idx int, state prowapi.ProwJobState, startedAgo time.Duration, annotations map[string]string) *prowapi.ProwJob {
+		pj := &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("run-%d", idx),
+				Namespace:   "prowjobs",
+				Annotations: annotations,
+			},
+			Spec: prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "j"},
+			Status: prowapi.ProwJobStatus{
+				State:     state,
+				StartTime: metav1.NewTime(now.Add(-startedAgo)),
+			},
+		}
+		if state != prowapi.PendingState {
+			completed := metav1.NewTime(now.Add(-startedAgo + time.Minute))
+			pj.Status.CompletionTime = &completed
+		}
+		return pj
+	}
+	failingRuns := func(latest ...*prowapi.ProwJob) []ctrlruntimeclient.Object {
+		objs := []ctrlruntimeclient.Object{
+			run(0, prowapi.SuccessState, 100*time.Hour, nil),
+			run(1, prowapi.FailureState, 96*time.Hour, nil),
+			run(2, prowapi.AbortedState, 72*time.Hour, nil),
+			run(3, prowapi.ErrorState, 48*time.Hour, nil),
+		}
+		for _, pj := range latest {
+			objs = append(objs, pj)
+		}
+		return objs
+	}
+
+	testCases := []struct {
+		name             string
+		jobs             []ctrlruntimeclient.Object
+		owner            *config.JobOwner
+		slackErr         error
+		expectErr        bool
+		expectMessages   int
+		expectAnnotation map[string]string
+	}{
+		{
+			name: "periodic that recently succeeded is left alone",
+			jobs: failingRuns(run(4, prowapi.SuccessState, time.Hour, nil)),
+		},
+		{
+			name: "periodic failing for too short a time is left alone",
+			jobs: []ctrlruntimeclient.Object{
+				run(0, prowapi.SuccessState, 50*time.Hour, nil),
+				run(1, prowapi.FailureState, 48*time.Hour, nil),
+				run(2, prowapi.FailureState, 24*time.Hour, nil),
+				run(4, prowapi.FailureState, time.Hour, nil),
+			},
+		},
+		{
+			name: "periodic without enough failures is left alone",
+			jobs: []ctrlruntimeclient.Object{
+				run(1, prowapi.FailureState, 96*time.Hour, nil),
+				run(4, prowapi.FailureState, time.Hour, nil),
+			},
+		},
+		{
+			name: "running periodic is left alone",
+			jobs: failingRuns(run(4, prowapi.PendingState, time.Hour, nil)),
+		},
+		{
+			name:             "failing periodic owner is notified",
+			jobs:             failingRuns(run(4, prowapi.FailureState, time.Hour, nil)),
+			owner:            &config.JobOwner{Team: "team", SlackChannel: "team-alerts"},
+			expectMessages:   1,
+			expectAnnotation: notifiedAt(0),
+		},
+		{
+			name:             "failing periodic without owner channel is still marked as notified",
+			jobs:             failingRuns(run(4, prowapi.FailureState, time.Hour, nil)),
+			expectAnnotation: notifiedAt(0),
+		},
+		{
+			name:      "failing notification is retried",
+			jobs:      failingRuns(run(4, prowapi.FailureState, time.Hour, nil)),
+			owner:     &config.JobOwner{Team: "team", SlackChannel: "team-alerts"},
+			slackErr:  errors.New("injected error"),
+			expectErr: true,
+		},
+		{
+			name:             "periodic within grace period is not disabled",
+			jobs:             failingRuns(run(4, prowapi.FailureState, time.Hour, notifiedAt(23*time.Hour))),
+			owner:            &config.JobOwner{Team: "team", SlackChannel: "team-alerts"},
+			expectAnnotation: notifiedAt(23 * time.Hour),
+		},
+		{
+			name:  "periodic past grace period is disabled",
+			jobs:  failingRuns(run(4, prowapi.FailureState, time.Hour, notifiedAt(25*time.Hour))),
+			owner: &config.JobOwner{Team: "team", SlackChannel: "team-alerts"},
+			expectAnnotation: map[string]string{
+				kube.AutoDisableNotifiedAnnotation: now.Add(-25 * time.Hour).Format(time.RFC3339),
+				kube.AutoDisabledAnnotation:        now.Format(time.RFC3339),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				ProwConfig: config.ProwConfig{
+					ProwJobNamespace: "prowjobs",
+					Horologium: config.Horologium{
+						AutoDisable: &config.PeriodicAutoDisable{},
+					},
+				},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "j", Owner: tc.owner}}},
+				},
+			}
+			if err := cfg.Horologium.AutoDisable.DefaultAndValidate(7 * 24 * time.Hour); err != nil {
+				t.Fatalf("failed to default config: %v", err)
+			}
+			client := newCreateTrackingClient(tc.jobs)
+			slack := &fakeSlack{err: tc.slackErr}
+
+			err := autoDisablePeriodics(client, cfg, slack, now)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+			if n := len(slack.messages["team-alerts"]); n != tc.expectMessages {
+				t.Errorf("expected %d messages, got %d: %v", tc.expectMessages, n, slack.messages)
+			}
+
+			latest := tc.jobs[len(tc.jobs)-1].(*prowapi.ProwJob)
+			actual := &prowapi.ProwJob{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(latest), actual); err != nil {
+				t.Fatalf("failed to get latest run: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectAnnotation, actual.Annotations); diff != "" {
+				t.Errorf("unexpected annotations on latest run (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSyncAutoDisabled(t *testing.T) {
+	now := time.Now()
+	notified := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	testCases := []struct {
+		name              string
+		state             prowapi.ProwJobState
+		annotations       map[string]string
+		expectStart       bool
+		expectAnnotations map[string]string
+	}{
+		{
+			name:        "disabled periodic is not triggered",
+			state:       prowapi.FailureState,
+			annotations: map[string]string{kube.AutoDisabledAnnotation: notified},
+		},
+		{
+			name:              "notification is carried over to the next run",
+			state:             prowapi.FailureState,
+			annotations:       map[string]string{kube.AutoDisableNotifiedAnnotation: notified},
+			expectStart:       true,
+			expectAnnotations: map[string]string{"keep": "me", kube.AutoDisableNotifiedAnnotation: notified},
+		},
+		{
+			name:              "notification is dropped after a success",
+			state:             prowapi.SuccessState,
+			annotations:       map[string]string{kube.AutoDisableNotifiedAnnotation: notified},
+			expectStart:       true,
+			expectAnnotations: map[string]string{"keep": "me"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{
+				ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "j", Annotations: map[string]string{"keep": "me"}}}},
+				},
+			}
+			cfg.Periodics[0].SetInterval(time.Minute)
+			completed := metav1.NewTime(now.Add(-30 * time.Minute))
+			client := newCreateTrackingClient([]ctrlruntimeclient.Object{&prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "previous", Namespace: "prowjobs", Annotations: tc.annotations},
+				Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "j"},
+				Status: prowapi.ProwJobStatus{
+					State:          tc.state,
+					StartTime:      metav1.NewTime(now.Add(-time.Hour)),
+					CompletionTime: &completed,
+				},
+			}})
+
+			if err := sync(client, &cfg, &fakeCron{}, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.sawCreate != tc.expectStart {
+				t.Fatalf("expected a new run: %t, got: %t", tc.expectStart, client.sawCreate)
+			}
+			if !tc.expectStart {
+				return
+			}
+			actual := client.created[0].GetAnnotations()
+			delete(actual, kube.ContextAnnotation)
+			delete(actual, kube.ProwJobAnnotation)
+			if diff := cmp.Diff(tc.expectAnnotations, actual); diff != "" {
+				t.Errorf("unexpected annotations on new run (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
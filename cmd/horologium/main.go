@@ -31,15 +31,18 @@ import (
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/secret"
 	"sigs.k8s.io/prow/pkg/cron"
 	pkgflagutil "sigs.k8s.io/prow/pkg/flagutil"
 	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
 	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/metrics"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+	slackclient "sigs.k8s.io/prow/pkg/slack"
 )
 
 const (
@@ -53,12 +56,14 @@ type options struct {
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	controllerManager      prowflagutil.ControllerManagerOptions
 	dryRun                 bool
+	slackTokenFile         string
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
 
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether or not to make mutating API calls to Kubernetes.")
+	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to a Slack token file used to notify the owners of periodics that are about to be auto-disabled.")
 	o.config.AddFlags(fs)
 	o.kubernetes.AddFlags(fs)
 	o.instrumentationOptions.AddFlags(fs)
@@ -132,12 +137,27 @@ func main() {
 
 	metrics.ExposeMetrics("horologium", configAgent.Config().PushGateway, o.instrumentationOptions.MetricsPort)
 
+	var slack slackClient
+	if o.slackTokenFile != "" {
+		if err := secret.Add(o.slackTokenFile); err != nil {
+			logrus.WithError(err).Fatal("Could not read slack token.")
+		}
+		if o.dryRun {
+			slack = slackclient.NewFakeClient()
+		} else {
+			slack = slackclient.NewClient(secret.GetTokenGenerator(o.slackTokenFile))
+		}
+	}
+
 	tickInterval := defaultTickInterval
 	if configAgent.Config().Horologium.TickInterval != nil {
 		tickInterval = configAgent.Config().Horologium.TickInterval.Duration
 	}
 	interrupts.TickLiteral(func() {
 		start := time.Now()
+		if err := autoDisablePeriodics(cluster.GetClient(), configAgent.Config(), slack, start); err != nil {
+			logrus.WithError(err).Error("Error auto-disabling failing periodic jobs.")
+		}
 		if err := sync(cluster.GetClient(), configAgent.Config(), cr, start); err != nil {
 			logrus.WithError(err).Error("Error syncing periodic jobs.")
 		}
@@ -174,7 +194,13 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 			"previous-found": previousFound,
 		})
 
+		if previousFound && isAutoDisabled(j) {
+			logger.Debug("Skipping periodic disabled for failing continuously.")
+			continue
+		}
+
 		var shouldTrigger = false
+		var catchUp []time.Time
 		switch {
 		case p.Cron == "": // no cron expression is set, we use interval to trigger
 			if j.Complete() {
@@ -186,6 +212,18 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 				}
 				shouldTrigger = now.Sub(intervalRef) > intervalDuration
 			}
+		case p.CatchUp.Enabled() && previousFound:
+			// The windows missed since the previous run include the latest
+			// one, so this replaces the trigger of the cron.
+			windows, err := missedWindows(p, j, now)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if j.Complete() {
+				catchUp = windows
+			}
+			shouldTrigger = len(catchUp) > 0
 		case cronTriggers.Has(p.Name):
 			shouldTrigger = j.Complete()
 		default:
@@ -207,17 +245,42 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 			}).Debug("Trigger time has not yet been reached.")
 		}
 		if !previousFound || shouldTrigger {
-			prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), p.Labels, p.Annotations,
-				pjutil.RequireScheduling(cfg.Scheduler.Enabled))
-			prowJob.Namespace = cfg.ProwJobNamespace
-			logger.WithFields(logrus.Fields{
-				"should-trigger": shouldTrigger,
-				"previous-found": previousFound,
-			}).WithFields(
-				pjutil.ProwJobFields(&prowJob),
-			).Info("Triggering new run.")
-			if err := prowJobClient.Create(context.TODO(), &prowJob); err != nil {
-				errs = append(errs, err)
+			annotations := p.Annotations
+			// Carry over the pending notification so that the grace period
+			// keeps running until the periodic succeeds again.
+			if notified, ok := j.Annotations[kube.AutoDisableNotifiedAnnotation]; ok && previousFound && j.Status.State != prowapi.SuccessState {
+				annotations = make(map[string]string, len(p.Annotations)+1)
+				for k, v := range p.Annotations {
+					annotations[k] = v
+				}
+				annotations[kube.AutoDisableNotifiedAnnotation] = notified
+			}
+			runs := catchUp
+			if len(runs) == 0 {
+				runs = []time.Time{{}}
+			}
+			for _, window := range runs {
+				runAnnotations := annotations
+				if !window.IsZero() {
+					runAnnotations = make(map[string]string, len(annotations)+1)
+					for k, v := range annotations {
+						runAnnotations[k] = v
+					}
+					runAnnotations[kube.CatchUpWindowAnnotation] = window.Format(time.RFC3339)
+				}
+				prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), p.Labels, runAnnotations,
+					pjutil.RequireScheduling(cfg.Scheduler.Enabled))
+				prowJob.Namespace = cfg.ProwJobNamespace
+				logger.WithFields(logrus.Fields{
+					"should-trigger": shouldTrigger,
+					"previous-found": previousFound,
+					"catch-up":       len(catchUp),
+				}).WithFields(
+					pjutil.ProwJobFields(&prowJob),
+				).Info("Triggering new run.")
+				if err := prowJobClient.Create(context.TODO(), &prowJob); err != nil {
+					errs = append(errs, err)
+				}
 			}
 		}
 	}
@@ -227,3 +290,18 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 	}
 	return nil
 }
+
+// missedWindows returns the cron windows of the periodic that passed since
+// its previous run was created, as many as its catch-up policy runs.
+func missedWindows(p config.Periodic, previous prowapi.ProwJob, now time.Time) ([]time.Time, error) {
+	keep := 1
+	if p.CatchUp.Policy == config.CatchUpRunAll {
+		keep = p.CatchUp.MaxRuns
+	}
+	missed, windows, err := cron.MissedWindows(p.Cron, previous.Status.StartTime.Time, now, keep)
+	if err != nil {
+		return nil, fmt.Errorf("periodic %s: %w", p.Name, err)
+	}
+	runs := p.CatchUp.Runs(missed)
+	return windows[len(windows)-runs:], nil
+}
@@ -20,9 +20,11 @@ import (
 	"context"
 	"flag"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,6 +35,7 @@ import (
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/kube"
 )
 
 type fakeCron struct {
@@ -356,6 +359,85 @@ func TestSyncCron(t *testing.T) {
 	}
 }
 
+func TestSyncCatchUp(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	nightly := func(day int) time.Time {
+		return time.Date(2024, 1, day, 2, 0, 0, 0, time.UTC)
+	}
+	testcases := []struct {
+		name             string
+		catchUp          *config.CatchUp
+		previousStart    time.Time
+		previousComplete bool
+		expectedWindows  []string
+	}{
+		{
+			name:             "run once catches up on the latest missed window",
+			catchUp:          &config.CatchUp{Policy: config.CatchUpRunOnce},
+			previousStart:    nightly(7).Add(5 * time.Second),
+			previousComplete: true,
+			expectedWindows:  []string{"2024-01-10T02:00:00Z"},
+		},
+		{
+			name:             "run all catches up on every missed window",
+			catchUp:          &config.CatchUp{Policy: config.CatchUpRunAll, MaxRuns: 5},
+			previousStart:    nightly(7).Add(5 * time.Second),
+			previousComplete: true,
+			expectedWindows:  []string{"2024-01-08T02:00:00Z", "2024-01-09T02:00:00Z", "2024-01-10T02:00:00Z"},
+		},
+		{
+			name:             "run all catches up on the latest max runs windows",
+			catchUp:          &config.CatchUp{Policy: config.CatchUpRunAll, MaxRuns: 2},
+			previousStart:    nightly(7).Add(5 * time.Second),
+			previousComplete: true,
+			expectedWindows:  []string{"2024-01-09T02:00:00Z", "2024-01-10T02:00:00Z"},
+		},
+		{
+			name:          "previous run still running",
+			catchUp:       &config.CatchUp{Policy: config.CatchUpRunAll, MaxRuns: 5},
+			previousStart: nightly(7).Add(5 * time.Second),
+		},
+		{
+			name:             "no window missed since the previous run",
+			catchUp:          &config.CatchUp{Policy: config.CatchUpRunOnce},
+			previousStart:    nightly(10).Add(5 * time.Second),
+			previousComplete: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{
+				ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "j"}, Cron: "0 2 * * *", CatchUp: tc.catchUp}},
+				},
+			}
+			previous := &prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "previous", Namespace: "prowjobs"},
+				Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "j"},
+				Status:     prowapi.ProwJobStatus{StartTime: metav1.NewTime(tc.previousStart)},
+			}
+			if tc.previousComplete {
+				complete := metav1.NewTime(tc.previousStart.Add(time.Hour))
+				previous.Status.CompletionTime = &complete
+			}
+			fakeProwJobClient := newCreateTrackingClient([]client.Object{previous})
+			// The fake cron triggers the periodic, which catching up replaces.
+			if err := sync(fakeProwJobClient, &cfg, &fakeCron{}, now); err != nil {
+				t.Fatalf("didn't expect error: %v", err)
+			}
+			var windows []string
+			for _, obj := range fakeProwJobClient.created {
+				windows = append(windows, obj.GetAnnotations()[kube.CatchUpWindowAnnotation])
+			}
+			sort.Strings(windows)
+			if diff := cmp.Diff(tc.expectedWindows, windows); diff != "" {
+				t.Errorf("unexpected windows caught up on (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestFlags(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// image-prepuller pulls the images of upcoming jobs on all nodes of the build
+// clusters ahead of time, so that jobs using large images do not have to wait
+// for them to be pulled when they start.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+	_ "sigs.k8s.io/prow/pkg/version"
+)
+
+type options struct {
+	runOnce                bool
+	config                 configflagutil.ConfigOptions
+	dryRun                 bool
+	kubernetes             flagutil.KubernetesOptions
+	instrumentationOptions flagutil.InstrumentationOptions
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	o := options{}
+	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
+
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether or not to make mutating API calls to Kubernetes.")
+
+	o.config.AddFlags(fs)
+	o.kubernetes.AddFlags(fs)
+	o.instrumentationOptions.AddFlags(fs)
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	if err := o.kubernetes.Validate(o.dryRun); err != nil {
+		return err
+	}
+
+	if err := o.config.Validate(o.dryRun); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	defer interrupts.WaitForGracefulShutdown()
+
+	pprof.Instrument(o.instrumentationOptions)
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+	cfg := configAgent.Config
+	o.kubernetes.SetDisabledClusters(sets.New[string](cfg().DisabledClusters...))
+
+	metrics.ExposeMetrics("image-prepuller", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
+
+	ctrlruntimelog.SetLogger(zap.New(zap.JSONEncoder()))
+
+	infrastructureClusterConfig, err := o.kubernetes.InfrastructureClusterConfig(o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting config for infastructure cluster")
+	}
+
+	// The watch apimachinery doesn't support restarts, so just exit the binary if a kubeconfig changes
+	// to make the kubelet restart us.
+	if err := o.kubernetes.AddKubeconfigChangeCallback(func() {
+		logrus.Info("Kubeconfig changed, exiting to trigger a restart")
+		interrupts.Terminate()
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to register kubeconfig change callback")
+	}
+
+	opts := manager.Options{
+		Cache: cache.Options{
+			DefaultNamespaces: map[string]cache.Config{
+				cfg().ProwJobNamespace: {},
+			},
+		},
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+		LeaderElection:                true,
+		LeaderElectionNamespace:       configAgent.Config().ProwJobNamespace,
+		LeaderElectionID:              "prow-image-prepuller-leaderlock",
+		LeaderElectionReleaseOnCancel: true,
+	}
+	mgr, err := manager.New(infrastructureClusterConfig, opts)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error creating manager")
+	}
+
+	// The startup authorization check only covers test pods, which we never
+	// touch. The get, list, watch, create and update verbs on daemonsets
+	// that are needed instead are documented for the build cluster RBAC.
+	buildClusters, err := o.kubernetes.BuildClusters(o.dryRun,
+		nil,
+		// The watch apimachinery doesn't support restarts, so just exit the
+		// binary if a build cluster can be connected later.
+		func() {
+			logrus.Info("Build cluster that failed to connect initially now worked, exiting to trigger a restart.")
+			interrupts.Terminate()
+		},
+		cfg().PodNamespace,
+	)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to construct build clusters. Is there a bad entry in the kubeconfig secret?")
+	}
+
+	buildClusterClients := map[string]ctrlruntimeclient.Client{}
+	for clusterName, cluster := range buildClusters {
+		if err := mgr.Add(cluster); err != nil {
+			logrus.WithError(err).Fatal("Failed to add build cluster manager")
+		}
+		buildClusterClients[clusterName] = cluster.GetClient()
+	}
+
+	c := controller{
+		ctx:           context.Background(),
+		logger:        logrus.NewEntry(logrus.StandardLogger()),
+		prowJobClient: mgr.GetClient(),
+		buildClients:  buildClusterClients,
+		config:        cfg,
+		runOnce:       o.runOnce,
+	}
+	if err := mgr.Add(&c); err != nil {
+		logrus.WithError(err).Fatal("failed to add controller to manager")
+	}
+	if err := mgr.Start(interrupts.Context()); err != nil {
+		logrus.WithError(err).Fatal("failed to start manager")
+	}
+	logrus.Info("Manager ended gracefully")
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+const (
+	// daemonSetName is the name of the DaemonSet managed in every build
+	// cluster.
+	daemonSetName = "prow-image-prepuller"
+	// prepulledImagesDir is where the pre-pull containers copy the entrypoint
+	// binary to, it only exists to give them something to do.
+	prepulledImagesDir = "/tools/prepulled"
+)
+
+type controller struct {
+	ctx           context.Context
+	logger        *logrus.Entry
+	prowJobClient ctrlruntimeclient.Client
+	buildClients  map[string]ctrlruntimeclient.Client
+	config        config.Getter
+	runOnce       bool
+}
+
+func (c *controller) Start(ctx context.Context) error {
+	runChan := make(chan struct{})
+
+	// We want to be able to dynamically adjust to changed config values, hence we cant use a time.Ticker
+	go func() {
+		for {
+			runChan <- struct{}{}
+			time.Sleep(c.config().ImagePrePuller.ResyncPeriod.Duration)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("stop signal received, quitting")
+			return nil
+		case <-runChan:
+			start := time.Now()
+			if err := c.sync(start); err != nil {
+				c.logger.WithError(err).Error("Error syncing pre-pulled images.")
+			}
+			c.logger.Infof("Sync time: %v", time.Since(start))
+			if c.runOnce {
+				return nil
+			}
+		}
+	}
+}
+
+// sync makes sure the DaemonSet in every build cluster pre-pulls the images
+// that are most likely to be needed by upcoming jobs.
+func (c *controller) sync(now time.Time) error {
+	cfg := c.config()
+	pjs := &prowapi.ProwJobList{}
+	if err := c.prowJobClient.List(c.ctx, pjs, ctrlruntimeclient.InNamespace(cfg.ProwJobNamespace)); err != nil {
+		return fmt.Errorf("error listing prow jobs: %w", err)
+	}
+	images := rankImages(cfg, pjs.Items, now)
+
+	excluded := sets.New[string](cfg.ImagePrePuller.ExcludeClusters...)
+	var errs []error
+	for cluster, client := range c.buildClients {
+		if excluded.Has(cluster) {
+			continue
+		}
+		log := c.logger.WithField("cluster", cluster)
+		dc := cfg.Plank.GuessDefaultDecorationConfig("", cluster)
+		if dc == nil || dc.UtilityImages == nil || dc.UtilityImages.Entrypoint == "" {
+			log.Warn("No entrypoint image is configured for the cluster, not pre-pulling images.")
+			continue
+		}
+		desired := desiredDaemonSet(cfg, dc.UtilityImages.Entrypoint, images[cluster])
+		if err := c.reconcileDaemonSet(client, desired); err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster, err))
+			continue
+		}
+		log.WithField("images", len(images[cluster])).Debug("Synced pre-pulled images.")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to sync %d build clusters: %v", len(errs), errs)
+	}
+	return nil
+}
+
+func (c *controller) reconcileDaemonSet(client ctrlruntimeclient.Client, desired *appsv1.DaemonSet) error {
+	existing := &appsv1.DaemonSet{}
+	err := client.Get(c.ctx, ctrlruntimeclient.ObjectKeyFromObject(desired), existing)
+	if kerrors.IsNotFound(err) {
+		if err := client.Create(c.ctx, desired); err != nil {
+			return fmt.Errorf("failed to create daemonset: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get daemonset: %w", err)
+	}
+	// The API server defaults a lot of fields, so only the fields we set are
+	// compared.
+	if equality.Semantic.DeepDerivative(desired.Spec, existing.Spec) && equality.Semantic.DeepDerivative(desired.Labels, existing.Labels) {
+		return nil
+	}
+	existing.Labels = desired.Labels
+	existing.Spec = desired.Spec
+	if err := client.Update(c.ctx, existing); err != nil {
+		return fmt.Errorf("failed to update daemonset: %w", err)
+	}
+	return nil
+}
+
+// rankImages returns the images to pre-pull for every build cluster. Images
+// are ranked by how many ProwJobs used them within the recent jobs window,
+// with images referenced by the job config getting an additional vote so
+// that they are pre-pulled before their first run.
+func rankImages(cfg *config.Config, pjs []prowapi.ProwJob, now time.Time) map[string][]string {
+	votes := map[string]map[string]int{}
+	vote := func(cluster string, spec *corev1.PodSpec) {
+		if spec == nil {
+			return
+		}
+		if votes[cluster] == nil {
+			votes[cluster] = map[string]int{}
+		}
+		for _, container := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+			if container.Image != "" {
+				votes[cluster][container.Image]++
+			}
+		}
+	}
+
+	for _, job := range cfg.AllStaticPresubmits(nil) {
+		vote(job.Cluster, job.Spec)
+	}
+	for _, job := range cfg.AllStaticPostsubmits(nil) {
+		vote(job.Cluster, job.Spec)
+	}
+	for _, job := range cfg.AllPeriodics() {
+		vote(job.Cluster, job.Spec)
+	}
+
+	cutoff := now.Add(-cfg.ImagePrePuller.RecentJobsWindow.Duration)
+	for _, pj := range pjs {
+		if pj.Spec.Agent != prowapi.KubernetesAgent || pj.CreationTimestamp.Time.Before(cutoff) {
+			continue
+		}
+		vote(pj.ClusterAlias(), pj.Spec.PodSpec)
+		if dc := pj.Spec.DecorationConfig; dc != nil && dc.UtilityImages != nil {
+			vote(pj.ClusterAlias(), &corev1.PodSpec{InitContainers: []corev1.Container{
+				{Image: dc.UtilityImages.CloneRefs},
+				{Image: dc.UtilityImages.InitUpload},
+				{Image: dc.UtilityImages.Entrypoint},
+				{Image: dc.UtilityImages.Sidecar},
+			}})
+		}
+	}
+
+	ranked := map[string][]string{}
+	for cluster, clusterVotes := range votes {
+		var images []string
+		for image := range clusterVotes {
+			images = append(images, image)
+		}
+		sort.Slice(images, func(i, j int) bool {
+			if clusterVotes[images[i]] != clusterVotes[images[j]] {
+				return clusterVotes[images[i]] > clusterVotes[images[j]]
+			}
+			return images[i] < images[j]
+		})
+		if len(images) > cfg.ImagePrePuller.MaxImages {
+			images = images[:cfg.ImagePrePuller.MaxImages]
+		}
+		// Keep the pod template stable when only the ranking changes, so
+		// that the DaemonSet is only rolled out when the set of images does.
+		sort.Strings(images)
+		ranked[cluster] = images
+	}
+	return ranked
+}
+
+// desiredDaemonSet builds a DaemonSet whose pods pull the given images on
+// every node. As the images may not contain any binary we could run, the
+// entrypoint binary is first copied to a shared volume and every pre-pull
+// container runs it in copy mode, which exits immediately.
+func desiredDaemonSet(cfg *config.Config, entrypointImage string, images []string) *appsv1.DaemonSet {
+	labels := map[string]string{
+		kube.CreatedByProw: "true",
+		"app":              daemonSetName,
+	}
+	toolsMount := corev1.VolumeMount{Name: "tools", MountPath: "/tools"}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+		},
+	}
+
+	initContainers := []corev1.Container{{
+		Name:         "place-entrypoint",
+		Image:        entrypointImage,
+		Args:         []string{"--copy-mode-only"},
+		VolumeMounts: []corev1.VolumeMount{toolsMount},
+		Resources:    resources,
+	}}
+	for i, image := range images {
+		initContainers = append(initContainers, corev1.Container{
+			Name:            fmt.Sprintf("prepull-%d", i),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/tools/entrypoint"},
+			Args:            []string{"--copy-mode-only", "--copy-destination=" + prepulledImagesDir},
+			VolumeMounts:    []corev1.VolumeMount{toolsMount},
+			Resources:       resources,
+		})
+	}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      daemonSetName,
+			Namespace: cfg.PodNamespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": daemonSetName}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					InitContainers: initContainers,
+					Containers: []corev1.Container{{
+						Name:      "pause",
+						Image:     cfg.ImagePrePuller.PauseImage,
+						Resources: resources,
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "tools",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+					NodeSelector: cfg.ImagePrePuller.NodeSelector,
+					Tolerations:  cfg.ImagePrePuller.Tolerations,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func newTestConfig(maxImages int) *config.Config {
+	return &config.Config{
+		ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "prowjobs",
+			PodNamespace:     "test-pods",
+			ImagePrePuller: config.ImagePrePuller{
+				RecentJobsWindow: &metav1.Duration{Duration: time.Hour},
+				MaxImages:        maxImages,
+				PauseImage:       config.DefaultImagePrePullerPauseImage,
+				ExcludeClusters:  []string{"excluded"},
+			},
+			Plank: config.Plank{
+				DefaultDecorationConfigs: []*config.DefaultDecorationConfigEntry{{
+					Config: &prowapi.DecorationConfig{
+						UtilityImages: &prowapi.UtilityImages{Entrypoint: "entrypoint:v1"},
+					},
+				}},
+			},
+		},
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{{
+				JobBase: config.JobBase{
+					Name:    "periodic",
+					Cluster: "default",
+					Spec:    &corev1.PodSpec{Containers: []corev1.Container{{Image: "configured:v1"}}},
+				},
+			}},
+		},
+	}
+}
+
+func newProwJob(name, cluster, image string, created time.Time) prowapi.ProwJob {
+	return prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "prowjobs",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: prowapi.ProwJobSpec{
+			Agent:   prowapi.KubernetesAgent,
+			Cluster: cluster,
+			PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{Image: image}}},
+		},
+	}
+}
+
+func TestRankImages(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name      string
+		maxImages int
+		pjs       []prowapi.ProwJob
+		expected  map[string][]string
+	}{
+		{
+			name:      "configured images are pre-pulled before their first run",
+			maxImages: 10,
+			expected:  map[string][]string{"default": {"configured:v1"}},
+		},
+		{
+			name:      "most used images win and are sorted by name",
+			maxImages: 2,
+			pjs: []prowapi.ProwJob{
+				newProwJob("a", "default", "rare:v1", now),
+				newProwJob("b", "default", "popular:v1", now),
+				newProwJob("c", "default", "popular:v1", now),
+				newProwJob("d", "default", "popular:v1", now),
+				newProwJob("e", "default", "common:v1", now),
+				newProwJob("f", "default", "common:v1", now),
+			},
+			expected: map[string][]string{"default": {"common:v1", "popular:v1"}},
+		},
+		{
+			name:      "old jobs are ignored",
+			maxImages: 10,
+			pjs: []prowapi.ProwJob{
+				newProwJob("a", "default", "old:v1", now.Add(-2*time.Hour)),
+			},
+			expected: map[string][]string{"default": {"configured:v1"}},
+		},
+		{
+			name:      "images are grouped by build cluster",
+			maxImages: 10,
+			pjs: []prowapi.ProwJob{
+				newProwJob("a", "other", "other:v1", now),
+			},
+			expected: map[string][]string{
+				"default": {"configured:v1"},
+				"other":   {"other:v1"},
+			},
+		},
+		{
+			name:      "utility images of decorated jobs are pre-pulled",
+			maxImages: 10,
+			pjs: func() []prowapi.ProwJob {
+				pj := newProwJob("a", "other", "other:v1", now)
+				pj.Spec.DecorationConfig = &prowapi.DecorationConfig{UtilityImages: &prowapi.UtilityImages{
+					CloneRefs:  "clonerefs:v1",
+					InitUpload: "initupload:v1",
+					Entrypoint: "entrypoint:v1",
+					Sidecar:    "sidecar:v1",
+				}}
+				return []prowapi.ProwJob{pj}
+			}(),
+			expected: map[string][]string{
+				"default": {"configured:v1"},
+				"other":   {"clonerefs:v1", "entrypoint:v1", "initupload:v1", "other:v1", "sidecar:v1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := rankImages(newTestConfig(tc.maxImages), tc.pjs, now)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected images (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	now := time.Now()
+	cfg := newTestConfig(10)
+	pj := newProwJob("a", "default", "recent:v1", now)
+
+	outdated := desiredDaemonSet(cfg, "entrypoint:v1", []string{"outdated:v1"})
+	defaultClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(outdated).Build()
+	newClient := fakectrlruntimeclient.NewClientBuilder().Build()
+	excludedClient := fakectrlruntimeclient.NewClientBuilder().Build()
+
+	c := controller{
+		ctx:           context.Background(),
+		logger:        logrus.NewEntry(logrus.StandardLogger()),
+		prowJobClient: fakectrlruntimeclient.NewClientBuilder().WithObjects(&pj).Build(),
+		buildClients: map[string]ctrlruntimeclient.Client{
+			"default":  defaultClient,
+			"new":      newClient,
+			"excluded": excludedClient,
+		},
+		config: func() *config.Config { return cfg },
+	}
+	if err := c.sync(now); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	images := func(client ctrlruntimeclient.Client) []string {
+		ds := &appsv1.DaemonSet{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "test-pods", Name: daemonSetName}, ds); err != nil {
+			t.Fatalf("failed to get daemonset: %v", err)
+		}
+		var images []string
+		for _, container := range ds.Spec.Template.Spec.InitContainers {
+			images = append(images, container.Image)
+		}
+		return images
+	}
+
+	if diff := cmp.Diff([]string{"entrypoint:v1", "configured:v1", "recent:v1"}, images(defaultClient)); diff != "" {
+		t.Errorf("unexpected images in updated daemonset (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"entrypoint:v1"}, images(newClient)); diff != "" {
+		t.Errorf("unexpected images in created daemonset (-want +got):\n%s", diff)
+	}
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := excludedClient.List(context.Background(), daemonSets); err != nil {
+		t.Fatalf("failed to list daemonsets: %v", err)
+	}
+	if len(daemonSets.Items) != 0 {
+		t.Errorf("expected no daemonset in excluded cluster, got %d", len(daemonSets.Items))
+	}
+}
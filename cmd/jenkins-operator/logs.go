@@ -29,7 +29,11 @@ import (
 
 var reJenkinsJobURL = regexp.MustCompile(`^(/?job)/([A-Za-z0-9-._]([A-Za-z0-9-._/]*[A-Za-z0-9-_])?)/(\d+)/consoleText$`)
 
-func handleLog(jc *jenkins.Client) http.HandlerFunc {
+// masterClient returns the client for a Jenkins job on the master with the
+// given name, or on the default master if it's empty.
+type masterClient func(master, job string) (*jenkins.Client, error)
+
+func handleLog(clientFor masterClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -53,6 +57,14 @@ func handleLog(jc *jenkins.Client) http.HandlerFunc {
 			return
 		}
 
+		// Logs of builds on other masters than the default
+		// one are requested with the name of the master.
+		jc, err := clientFor(r.URL.Query().Get("master"), reJenkinsJobURL.FindStringSubmatch(r.URL.Path)[2])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unknown Jenkins master: %v", err), http.StatusNotFound)
+			return
+		}
+
 		log, err := jc.GetSkipMetrics(realPath)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Log not found: %v", err), http.StatusNotFound)
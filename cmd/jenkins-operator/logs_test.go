@@ -16,7 +16,15 @@ limitations under the License.
 
 package main
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/jenkins"
+)
 
 func Test_getRealJenkinsLogPath(t *testing.T) {
 	tests := []struct {
@@ -59,3 +67,62 @@ func Test_getRealJenkinsLogPath(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleLogMasters(t *testing.T) {
+	servers := map[string]*httptest.Server{}
+	for _, master := range []string{"", "team"} {
+		master := master
+		servers[master] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(master + ":" + r.URL.Path))
+		}))
+		defer servers[master].Close()
+	}
+	clientFor := func(master, job string) (*jenkins.Client, error) {
+		server, ok := servers[master]
+		if !ok {
+			return nil, errors.New("unknown master")
+		}
+		return jenkins.NewClient(server.URL, false, nil, &jenkins.AuthConfig{}, nil, nil)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "default master",
+			path:       "/job/folder/the-job/1/consoleText",
+			wantStatus: http.StatusOK,
+			wantBody:   ":/job/folder/job/the-job/1/consoleText",
+		},
+		{
+			name:       "named master",
+			path:       "/job/folder/the-job/1/consoleText?master=team",
+			wantStatus: http.StatusOK,
+			wantBody:   "team:/job/folder/job/the-job/1/consoleText",
+		},
+		{
+			name:       "unknown master",
+			path:       "/job/folder/the-job/1/consoleText?master=other",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handleLog(clientFor).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handleLog() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(rr.Body)
+			if string(body) != tt.wantBody {
+				t.Errorf("handleLog() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -207,7 +207,7 @@ func main() {
 	// Serve Jenkins logs here and proxy deck to use this endpoint
 	// instead of baking agent-specific logic in deck
 	logMux := http.NewServeMux()
-	logMux.Handle("/", gziphandler.GzipHandler(handleLog(jc)))
+	logMux.Handle("/", gziphandler.GzipHandler(handleLog(c.MasterClient)))
 	server := &http.Server{Addr: ":8080", Handler: logMux}
 	interrupts.ListenAndServe(server, 5*time.Second)
 
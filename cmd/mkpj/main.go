@@ -17,10 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
@@ -47,12 +53,23 @@ type options struct {
 	pullHeadRef string
 	org         string
 	repo        string
+	prURL       string
+	interactive bool
+
+	artifactBucket     string
+	artifactPathPrefix string
 
 	github       prowflagutil.GitHubOptions
 	githubClient githubClient
 	pullRequest  *github.PullRequest
 }
 
+// inRepo returns true if the job of the repo can be selected, which is any
+// job unless the repo is known from a PR URL.
+func (o *options) inRepo(org, repo string) bool {
+	return o.org == "" || (o.org == org && o.repo == repo)
+}
+
 func (o *options) genJobSpec(conf *config.Config) (config.JobBase, prowapi.ProwJobSpec) {
 	for fullRepoName, ps := range conf.PresubmitsStatic {
 		org, repo, err := config.SplitRepoName(fullRepoName)
@@ -60,6 +77,9 @@ func (o *options) genJobSpec(conf *config.Config) (config.JobBase, prowapi.ProwJ
 			logrus.WithError(err).Warnf("Invalid repo name %s.", fullRepoName)
 			continue
 		}
+		if !o.inRepo(org, repo) {
+			continue
+		}
 		for _, p := range ps {
 			if p.Name == o.jobName {
 				return p.JobBase, pjutil.PresubmitSpec(p, prowapi.Refs{
@@ -83,6 +103,9 @@ func (o *options) genJobSpec(conf *config.Config) (config.JobBase, prowapi.ProwJ
 			logrus.WithError(err).Warnf("Invalid repo name %s.", fullRepoName)
 			continue
 		}
+		if !o.inRepo(org, repo) {
+			continue
+		}
 		for _, p := range ps {
 			if p.Name == o.jobName {
 				return p.JobBase, pjutil.PostsubmitSpec(p, prowapi.Refs{
@@ -136,9 +159,111 @@ func (o *options) defaultPR(pjs *prowapi.ProwJobSpec) error {
 		}
 		pjs.Refs.Pulls[0].SHA = pr.Head.SHA
 	}
+	if pjs.Refs.Pulls[0].HeadRef == "" && o.pullRequest != nil {
+		pjs.Refs.Pulls[0].HeadRef = o.pullRequest.Head.Ref
+	}
 	return nil
 }
 
+// parsePRURL returns the org, repo and number of a pull request from its URL,
+// e.g. https://github.com/org/repo/pull/123.
+func parsePRURL(prURL string) (string, string, int, error) {
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid PR URL %q: %w", prURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", 0, fmt.Errorf("invalid PR URL %q: expected a http(s) URL", prURL)
+	}
+	// Links to the files or commits of the PR work as well.
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] == "" || parts[1] == "" || parts[2] != "pull" {
+		return "", "", 0, fmt.Errorf("invalid PR URL %q: expected a URL like https://github.com/org/repo/pull/123", prURL)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("invalid PR URL %q: %q is not a PR number", prURL, parts[3])
+	}
+	return parts[0], parts[1], number, nil
+}
+
+// candidateJobs returns the names of the jobs that can be selected in
+// interactive mode. When the PR is known, these are the presubmits of its
+// repo that can run against its base branch, otherwise all jobs of the repo.
+func (o *options) candidateJobs(conf *config.Config) ([]string, error) {
+	var baseRef string
+	if o.pullNumber != 0 {
+		pr, err := o.getPullRequest()
+		if err != nil {
+			return nil, err
+		}
+		baseRef = pr.Base.Ref
+	}
+	var names []string
+	for fullRepoName, ps := range conf.PresubmitsStatic {
+		org, repo, err := config.SplitRepoName(fullRepoName)
+		if err != nil || !o.inRepo(org, repo) {
+			continue
+		}
+		for _, p := range ps {
+			if baseRef == "" || p.CouldRun(baseRef) {
+				names = append(names, p.Name)
+			}
+		}
+	}
+	if o.pullNumber == 0 {
+		for fullRepoName, ps := range conf.PostsubmitsStatic {
+			org, repo, err := config.SplitRepoName(fullRepoName)
+			if err != nil || !o.inRepo(org, repo) {
+				continue
+			}
+			for _, p := range ps {
+				names = append(names, p.Name)
+			}
+		}
+		if o.org == "" {
+			for _, p := range conf.Periodics {
+				names = append(names, p.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// selectJob lists the candidate jobs and prompts for the job to run.
+func (o *options) selectJob(conf *config.Config, in io.Reader, out io.Writer) error {
+	names, err := o.candidateJobs(conf)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("no jobs found")
+	}
+	for i, name := range names {
+		fmt.Fprintf(out, "%3d) %s\n", i+1, name)
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "Job to run (number or name): ")
+		if !scanner.Scan() {
+			return errors.New("no job selected")
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(names) {
+			o.jobName = names[i-1]
+			return nil
+		}
+		for _, name := range names {
+			if name == answer {
+				o.jobName = name
+				return nil
+			}
+		}
+		fmt.Fprintf(out, "%q is not one of the listed jobs.\n", answer)
+	}
+}
+
 func (o *options) defaultBaseRef(pjs *prowapi.ProwJobSpec) error {
 	if pjs.Refs.BaseRef == "" {
 		if o.pullNumber != 0 {
@@ -176,11 +301,24 @@ type githubClient interface {
 	GetRef(org, repo, ref string) (string, error)
 }
 
+// Validate validates the options and resolves the org, repo and PR number
+// from --pr-url.
 func (o *options) Validate() error {
-	if o.jobName == "" {
+	if o.jobName == "" && !o.interactive {
 		return errors.New("required flag --job was unset")
 	}
 
+	if o.prURL != "" {
+		org, repo, number, err := parsePRURL(o.prURL)
+		if err != nil {
+			return err
+		}
+		if o.pullNumber != 0 && o.pullNumber != number {
+			return fmt.Errorf("--pull-number=%d conflicts with --pr-url=%s", o.pullNumber, o.prURL)
+		}
+		o.org, o.repo, o.pullNumber = org, repo, number
+	}
+
 	if err := o.config.Validate(false); err != nil {
 		return err
 	}
@@ -195,6 +333,10 @@ func (o *options) Validate() error {
 		}
 	}
 
+	if o.artifactPathPrefix != "" && o.artifactBucket == "" {
+		return errors.New("--artifact-path-prefix requires --artifact-bucket")
+	}
+
 	return nil
 }
 
@@ -208,8 +350,12 @@ func gatherOptions() options {
 	fs.StringVar(&o.pullSha, "pull-sha", "", "Git pull SHA under test")
 	fs.StringVar(&o.pullAuthor, "pull-author", "", "Git pull author under test")
 	fs.StringVar(&o.pullHeadRef, "pull-head-ref", "", "Git branch name of the proposed change")
+	fs.StringVar(&o.prURL, "pr-url", "", "URL of the pull request under test, e.g. https://github.com/org/repo/pull/123. The base and head of the PR are resolved through GitHub.")
+	fs.BoolVar(&o.interactive, "interactive", false, "Prompt for the job to run when --job is unset, listing the presubmits of the PR of --pr-url or all jobs.")
 	fs.BoolVar(&o.triggerJob, "trigger-job", false, "Submit the job to Prow and wait for results")
 	fs.BoolVar(&o.failWithJob, "fail-with-job", false, "Exit with a non-zero exit code if the triggered job fails")
+	fs.StringVar(&o.artifactBucket, "artifact-bucket", "", "Upload the artifacts of the run to this bucket instead of that of the job, e.g. gs://experiments. Must be allowed by plank.allowed_artifact_destinations.")
+	fs.StringVar(&o.artifactPathPrefix, "artifact-path-prefix", "", "Path prefix of the artifacts of the run in --artifact-bucket.")
 	o.config.AddFlags(fs)
 	o.kubeOptions.AddFlags(fs)
 	o.github.AddFlags(fs)
@@ -235,6 +381,11 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to get GitHub client")
 	}
+	if o.jobName == "" {
+		if err := o.selectJob(conf, os.Stdin, os.Stderr); err != nil {
+			logrus.WithError(err).Fatal("Failed to select a job")
+		}
+	}
 	job, pjs := o.genJobSpec(conf)
 	if job.Name == "" {
 		logrus.Fatalf("Job %s not found.", o.jobName)
@@ -251,6 +402,11 @@ func main() {
 			logrus.WithError(err).Fatal("Failed to default base ref")
 		}
 	}
+	if o.artifactBucket != "" {
+		if err := conf.Plank.ApplyArtifactDestination(&pjs, prowapi.ArtifactDestination{Bucket: o.artifactBucket, PathPrefix: o.artifactPathPrefix}); err != nil {
+			logrus.WithError(err).Fatal("Failed to set the artifact destination")
+		}
+	}
 	pj := pjutil.NewProwJob(pjs, job.Labels, job.Annotations, pjutil.RequireScheduling(conf.Scheduler.Enabled))
 	if !o.triggerJob {
 		b, err := yaml.Marshal(&pj)
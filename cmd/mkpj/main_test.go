@@ -17,9 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/github/fakegithub"
@@ -53,6 +58,51 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "missing job in interactive mode",
+			input: options{
+				interactive: true,
+				config:      configflagutil.ConfigOptions{ConfigPath: "somewhere"},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "PR URL",
+			input: options{
+				jobName: "job",
+				prURL:   "https://github.com/org/repo/pull/123",
+				config:  configflagutil.ConfigOptions{ConfigPath: "somewhere"},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "invalid PR URL",
+			input: options{
+				jobName: "job",
+				prURL:   "https://github.com/org/repo/issues/123",
+				config:  configflagutil.ConfigOptions{ConfigPath: "somewhere"},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "PR URL conflicts with pull number",
+			input: options{
+				jobName:    "job",
+				prURL:      "https://github.com/org/repo/pull/123",
+				pullNumber: 124,
+				config:     configflagutil.ConfigOptions{ConfigPath: "somewhere"},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "artifact path prefix without bucket",
+			input: options{
+				jobName:            "job",
+				artifactPathPrefix: "experiments",
+				config:             configflagutil.ConfigOptions{ConfigPath: "somewhere"},
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -72,7 +122,7 @@ func TestDefaultPR(t *testing.T) {
 	fakeGitHubClient := fakegithub.NewFakeClient()
 	fakeGitHubClient.PullRequests = map[int]*github.PullRequest{2: {
 		User: github.User{Login: author},
-		Head: github.PullRequestBranch{SHA: sha},
+		Head: github.PullRequestBranch{SHA: sha, Ref: "feature"},
 	}}
 	o := &options{pullNumber: 2, githubClient: fakeGitHubClient}
 	pjs := &prowapi.ProwJobSpec{Refs: &prowapi.Refs{Pulls: []prowapi.Pull{{Number: 2}}}}
@@ -85,6 +135,154 @@ func TestDefaultPR(t *testing.T) {
 	if pjs.Refs.Pulls[0].SHA != sha {
 		t.Errorf("Expectged sha to get defaulted to %s but got %s", sha, pjs.Refs.Pulls[0].SHA)
 	}
+	if pjs.Refs.Pulls[0].HeadRef != "feature" {
+		t.Errorf("Expected head ref to get defaulted to feature but got %s", pjs.Refs.Pulls[0].HeadRef)
+	}
+}
+
+func TestParsePRURL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		org, repo   string
+		number      int
+		expectedErr bool
+	}{
+		{
+			name:   "PR URL",
+			url:    "https://github.com/kubernetes/test-infra/pull/123",
+			org:    "kubernetes",
+			repo:   "test-infra",
+			number: 123,
+		},
+		{
+			name:   "link to the files of the PR on GitHub Enterprise",
+			url:    "https://github.example.com/org/repo/pull/7/files",
+			org:    "org",
+			repo:   "repo",
+			number: 7,
+		},
+		{
+			name:        "issue URL",
+			url:         "https://github.com/org/repo/issues/7",
+			expectedErr: true,
+		},
+		{
+			name:        "no PR number",
+			url:         "https://github.com/org/repo/pull/",
+			expectedErr: true,
+		},
+		{
+			name:        "not a URL",
+			url:         "org/repo#7",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			org, repo, number, err := parsePRURL(tc.url)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if org != tc.org || repo != tc.repo || number != tc.number {
+				t.Errorf("expected %s/%s#%d, got %s/%s#%d", tc.org, tc.repo, tc.number, org, repo, number)
+			}
+		})
+	}
+}
+
+func TestGenJobSpecFromPRURL(t *testing.T) {
+	conf := &config.Config{JobConfig: config.JobConfig{PresubmitsStatic: map[string][]config.Presubmit{
+		"org/other": {{JobBase: config.JobBase{Name: "unit"}}},
+		"org/repo":  {{JobBase: config.JobBase{Name: "unit"}}},
+	}}}
+	o := &options{jobName: "unit", prURL: "https://github.com/org/repo/pull/2", config: configflagutil.ConfigOptions{ConfigPath: "somewhere"}}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("unexpected error validating options: %v", err)
+	}
+	_, pjs := o.genJobSpec(conf)
+	if pjs.Refs == nil || pjs.Refs.Org != "org" || pjs.Refs.Repo != "repo" || pjs.Refs.Pulls[0].Number != 2 {
+		t.Errorf("expected the job to run against org/repo#2, got %+v", pjs.Refs)
+	}
+}
+
+func TestSelectJob(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "unit"}},
+		{JobBase: config.JobBase{Name: "lint"}},
+		{JobBase: config.JobBase{Name: "release-only"}, Brancher: config.Brancher{Branches: []string{"release-1.0"}}},
+	}
+	if err := config.SetPresubmitRegexes(presubmits); err != nil {
+		t.Fatalf("failed to set presubmit regexes: %v", err)
+	}
+	conf := &config.Config{JobConfig: config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo":  presubmits,
+			"org/other": {{JobBase: config.JobBase{Name: "other"}}},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "periodic"}}},
+	}}
+
+	testCases := []struct {
+		name          string
+		withPR        bool
+		input         string
+		expectedJobs  []string
+		expectedJob   string
+		expectedError bool
+	}{
+		{
+			name:         "presubmits that can run against the base branch of the PR",
+			withPR:       true,
+			input:        "2\n",
+			expectedJobs: []string{"lint", "unit"},
+			expectedJob:  "unit",
+		},
+		{
+			name:         "all jobs without a PR",
+			input:        "periodic\n",
+			expectedJobs: []string{"lint", "other", "periodic", "release-only", "unit"},
+			expectedJob:  "periodic",
+		},
+		{
+			name:         "invalid answers prompt again",
+			withPR:       true,
+			input:        "5\nrelease-only\n1\n",
+			expectedJobs: []string{"lint", "unit"},
+			expectedJob:  "lint",
+		},
+		{
+			name:          "no answer",
+			withPR:        true,
+			expectedJobs:  []string{"lint", "unit"},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{}
+			if tc.withPR {
+				fakeGitHubClient := fakegithub.NewFakeClient()
+				fakeGitHubClient.PullRequests = map[int]*github.PullRequest{2: {Base: github.PullRequestBranch{Ref: "main"}}}
+				o = &options{org: "org", repo: "repo", pullNumber: 2, githubClient: fakeGitHubClient}
+			}
+			jobs, err := o.candidateJobs(conf)
+			if err != nil {
+				t.Fatalf("unexpected error listing jobs: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedJobs, jobs); diff != "" {
+				t.Errorf("unexpected jobs (-want +got):\n%s", diff)
+			}
+			var out bytes.Buffer
+			err = o.selectJob(conf, strings.NewReader(tc.input), &out)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("expected error: %t, got %v", tc.expectedError, err)
+			}
+			if o.jobName != tc.expectedJob {
+				t.Errorf("expected job %q to be selected, got %q", tc.expectedJob, o.jobName)
+			}
+		})
+	}
 }
 
 func TestDefaultBaseRef(t *testing.T) {
@@ -168,6 +168,7 @@ func main() {
 	mr := moonraker.Moonraker{
 		ConfigAgent:       configAgent,
 		InRepoConfigCache: cacheGetter,
+		GitClient:         gitClient,
 	}
 
 	// If the main config changes (an update to the ConfigMap holding the main
@@ -185,6 +186,9 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathPing), mr.ServePing)
 	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathGetInrepoconfig), mr.ServeGetInrepoconfig)
+	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathResolve), mr.ServeResolve)
+	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathCache), mr.ServeCache)
+	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathCacheInvalidate), mr.ServeCacheInvalidate)
 	server := &http.Server{
 		Addr:    ":" + strconv.Itoa(o.port),
 		Handler: mux,
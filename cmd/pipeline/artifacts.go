@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	untypedcorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	prowjobv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/sidecar"
+)
+
+const (
+	// artifactsWorkspace is the workspace the tasks of decorated jobs write
+	// their artifacts to, like the artifacts dir of the pods of other jobs.
+	artifactsWorkspace = "prow-artifacts"
+	// artifactsMountPath is where the upload task mounts the workspace, so
+	// its files are uploaded under artifacts/ like the sidecar does.
+	artifactsMountPath = "/logs/artifacts"
+	// artifactsVolumeSize is the size of the volume of the workspace unless
+	// the pipeline run binds the workspace itself.
+	artifactsVolumeSize = "1Gi"
+
+	startedTaskName = "prow-started"
+	uploadTaskName  = "prow-upload"
+	statusParam     = "status"
+)
+
+// withArtifacts wires the artifacts of a decorated job into its pipeline
+// run: the run gets an artifacts workspace, a task uploading started.json
+// when the run starts and a finally task uploading the artifacts and
+// finished.json once the other tasks are done, so the results of the job
+// land in its bucket in the same layout as those of pod jobs.
+//
+// Pipelines the run refers to can't be changed, so only runs with a
+// pipeline spec of their own are wired.
+func withArtifacts(p *pipelinev1.PipelineRun, pj prowjobv1.ProwJob, encodedJobSpec string) error {
+	dc := pj.Spec.DecorationConfig
+	if dc == nil || dc.GCSConfiguration == nil || dc.UtilityImages == nil || p.Spec.PipelineSpec == nil {
+		return nil
+	}
+	spec := p.Spec.PipelineSpec
+	for _, task := range append(append([]pipelinev1.PipelineTask{}, spec.Tasks...), spec.Finally...) {
+		if task.Name == startedTaskName || task.Name == uploadTaskName {
+			return fmt.Errorf("the pipeline of a decorated job may not have a task named %q", task.Name)
+		}
+	}
+
+	volumes, mounts, gcsOptions := decorate.BlobStorageOptions(*dc, false)
+	initUpload, err := decorate.InitUpload(dc, gcsOptions, mounts, nil, nil, encodedJobSpec)
+	if err != nil {
+		return err
+	}
+	gcsOptions.Items = []string{artifactsMountPath}
+	sidecarConfigEnv, err := sidecar.Encode(sidecar.Options{
+		GcsOptions:     &gcsOptions,
+		PipelineStatus: fmt.Sprintf("$(params.%s)", statusParam),
+	})
+	if err != nil {
+		return fmt.Errorf("encode sidecar options: %w", err)
+	}
+	upload := untypedcorev1.Container{
+		Name:  "sidecar",
+		Image: dc.UtilityImages.Sidecar,
+		Env: decorate.KubeEnv(map[string]string{
+			sidecar.JSONConfigEnvVar: sidecarConfigEnv,
+			downwardapi.JobSpecEnv:   encodedJobSpec,
+		}),
+		VolumeMounts: mounts,
+	}
+	if dc.Resources != nil && dc.Resources.Sidecar != nil {
+		upload.Resources = *dc.Resources.Sidecar
+	}
+
+	spec.Tasks = append(spec.Tasks, pipelinev1.PipelineTask{
+		Name: startedTaskName,
+		TaskSpec: &pipelinev1.EmbeddedTask{TaskSpec: pipelinev1.TaskSpec{
+			Steps:   []pipelinev1.Step{step(*initUpload)},
+			Volumes: volumes,
+		}},
+	})
+	spec.Finally = append(spec.Finally, pipelinev1.PipelineTask{
+		Name: uploadTaskName,
+		Params: pipelinev1.Params{{
+			Name:  statusParam,
+			Value: *pipelinev1.NewStructuredValues("$(tasks.status)"),
+		}},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{{Name: artifactsWorkspace, Workspace: artifactsWorkspace}},
+		TaskSpec: &pipelinev1.EmbeddedTask{TaskSpec: pipelinev1.TaskSpec{
+			Params:     pipelinev1.ParamSpecs{{Name: statusParam, Type: pipelinev1.ParamTypeString}},
+			Workspaces: []pipelinev1.WorkspaceDeclaration{{Name: artifactsWorkspace, MountPath: artifactsMountPath}},
+			Steps:      []pipelinev1.Step{step(upload)},
+			Volumes:    volumes,
+		}},
+	})
+
+	declared := false
+	for _, workspace := range spec.Workspaces {
+		declared = declared || workspace.Name == artifactsWorkspace
+	}
+	if !declared {
+		spec.Workspaces = append(spec.Workspaces, pipelinev1.PipelineWorkspaceDeclaration{
+			Name:        artifactsWorkspace,
+			Description: "Files written here are uploaded as the artifacts of the job.",
+		})
+	}
+	for _, workspace := range p.Spec.Workspaces {
+		if workspace.Name == artifactsWorkspace {
+			return nil
+		}
+	}
+	p.Spec.Workspaces = append(p.Spec.Workspaces, pipelinev1.WorkspaceBinding{
+		Name: artifactsWorkspace,
+		VolumeClaimTemplate: &untypedcorev1.PersistentVolumeClaim{
+			Spec: untypedcorev1.PersistentVolumeClaimSpec{
+				AccessModes: []untypedcorev1.PersistentVolumeAccessMode{untypedcorev1.ReadWriteOnce},
+				Resources: untypedcorev1.VolumeResourceRequirements{
+					Requests: untypedcorev1.ResourceList{untypedcorev1.ResourceStorage: resource.MustParse(artifactsVolumeSize)},
+				},
+			},
+		},
+	})
+	return nil
+}
+
+// step turns a container of the pod utilities into a step of a task.
+func step(c untypedcorev1.Container) pipelinev1.Step {
+	return pipelinev1.Step{
+		Name:             c.Name,
+		Image:            c.Image,
+		Env:              c.Env,
+		VolumeMounts:     c.VolumeMounts,
+		ComputeResources: c.Resources,
+	}
+}
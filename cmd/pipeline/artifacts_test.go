@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	untypedcorev1 "k8s.io/api/core/v1"
+
+	prowjobv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/sidecar"
+)
+
+func TestWithArtifacts(t *testing.T) {
+	credentials := "gcs-credentials"
+	decorated := prowjobv1.ProwJob{Spec: prowjobv1.ProwJobSpec{
+		DecorationConfig: &prowjobv1.DecorationConfig{
+			GCSConfiguration:     &prowjobv1.GCSConfiguration{Bucket: "results", PathStrategy: prowjobv1.PathStrategyExplicit},
+			GCSCredentialsSecret: &credentials,
+			UtilityImages:        &prowjobv1.UtilityImages{InitUpload: "initupload:v1", Sidecar: "sidecar:v1"},
+		},
+	}}
+	testTask := pipelinev1.PipelineTask{Name: "test", TaskRef: &pipelinev1.TaskRef{Name: "make-test"}}
+
+	testCases := []struct {
+		name     string
+		pj       prowjobv1.ProwJob
+		run      pipelinev1.PipelineRunSpec
+		expected func(*testing.T, pipelinev1.PipelineRunSpec)
+		err      bool
+	}{
+		{
+			name: "undecorated job is left alone",
+			run:  pipelinev1.PipelineRunSpec{PipelineSpec: &pipelinev1.PipelineSpec{Tasks: []pipelinev1.PipelineTask{testTask}}},
+			expected: func(t *testing.T, spec pipelinev1.PipelineRunSpec) {
+				if diff := cmp.Diff([]pipelinev1.PipelineTask{testTask}, spec.PipelineSpec.Tasks); diff != "" {
+					t.Errorf("unexpected tasks (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			name: "referenced pipeline is left alone",
+			pj:   decorated,
+			run:  pipelinev1.PipelineRunSpec{PipelineRef: &pipelinev1.PipelineRef{Name: "shared"}},
+			expected: func(t *testing.T, spec pipelinev1.PipelineRunSpec) {
+				if len(spec.Workspaces) != 0 {
+					t.Errorf("expected no workspaces, got %v", spec.Workspaces)
+				}
+			},
+		},
+		{
+			name: "decorated job uploads its started.json, artifacts and finished.json",
+			pj:   decorated,
+			run:  pipelinev1.PipelineRunSpec{PipelineSpec: &pipelinev1.PipelineSpec{Tasks: []pipelinev1.PipelineTask{testTask}}},
+			expected: func(t *testing.T, spec pipelinev1.PipelineRunSpec) {
+				tasks := spec.PipelineSpec.Tasks
+				if len(tasks) != 2 || tasks[1].Name != startedTaskName || tasks[1].TaskSpec.Steps[0].Image != "initupload:v1" {
+					t.Errorf("expected the %s task to run initupload, got %+v", startedTaskName, tasks)
+				}
+				if len(spec.PipelineSpec.Finally) != 1 {
+					t.Fatalf("expected one finally task, got %d", len(spec.PipelineSpec.Finally))
+				}
+				upload := spec.PipelineSpec.Finally[0]
+				if diff := cmp.Diff(pipelinev1.Params{{Name: statusParam, Value: *pipelinev1.NewStructuredValues("$(tasks.status)")}}, upload.Params); diff != "" {
+					t.Errorf("unexpected params of the upload task (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]pipelinev1.WorkspaceDeclaration{{Name: artifactsWorkspace, MountPath: artifactsMountPath}}, upload.TaskSpec.Workspaces); diff != "" {
+					t.Errorf("unexpected workspaces of the upload task (-want +got):\n%s", diff)
+				}
+				if len(upload.TaskSpec.Volumes) != 1 || upload.TaskSpec.Volumes[0].Secret.SecretName != credentials {
+					t.Errorf("expected the upload task to mount the credentials, got %+v", upload.TaskSpec.Volumes)
+				}
+				var options sidecar.Options
+				for _, env := range upload.TaskSpec.Steps[0].Env {
+					if env.Name == sidecar.JSONConfigEnvVar {
+						if err := json.Unmarshal([]byte(env.Value), &options); err != nil {
+							t.Fatalf("failed to decode sidecar options: %v", err)
+						}
+					}
+				}
+				if options.PipelineStatus != "$(params.status)" {
+					t.Errorf("expected sidecar to get the status of the pipeline, got %q", options.PipelineStatus)
+				}
+				if diff := cmp.Diff([]string{artifactsMountPath}, options.GcsOptions.Items); diff != "" {
+					t.Errorf("unexpected uploaded items (-want +got):\n%s", diff)
+				}
+				if len(spec.PipelineSpec.Workspaces) != 1 || spec.PipelineSpec.Workspaces[0].Name != artifactsWorkspace {
+					t.Errorf("expected the pipeline to declare the %s workspace, got %+v", artifactsWorkspace, spec.PipelineSpec.Workspaces)
+				}
+				if len(spec.Workspaces) != 1 || spec.Workspaces[0].VolumeClaimTemplate == nil {
+					t.Errorf("expected the run to bind the %s workspace to a volume, got %+v", artifactsWorkspace, spec.Workspaces)
+				}
+			},
+		},
+		{
+			name: "workspace bound by the run is kept",
+			pj:   decorated,
+			run: pipelinev1.PipelineRunSpec{
+				PipelineSpec: &pipelinev1.PipelineSpec{
+					Tasks:      []pipelinev1.PipelineTask{testTask},
+					Workspaces: []pipelinev1.PipelineWorkspaceDeclaration{{Name: artifactsWorkspace}},
+				},
+				Workspaces: []pipelinev1.WorkspaceBinding{{Name: artifactsWorkspace, EmptyDir: &untypedcorev1.EmptyDirVolumeSource{}}},
+			},
+			expected: func(t *testing.T, spec pipelinev1.PipelineRunSpec) {
+				if diff := cmp.Diff([]pipelinev1.PipelineWorkspaceDeclaration{{Name: artifactsWorkspace}}, spec.PipelineSpec.Workspaces); diff != "" {
+					t.Errorf("unexpected declared workspaces (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]pipelinev1.WorkspaceBinding{{Name: artifactsWorkspace, EmptyDir: &untypedcorev1.EmptyDirVolumeSource{}}}, spec.Workspaces); diff != "" {
+					t.Errorf("unexpected bound workspaces (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			name: "task named like the upload task is rejected",
+			pj:   decorated,
+			run:  pipelinev1.PipelineRunSpec{PipelineSpec: &pipelinev1.PipelineSpec{Finally: []pipelinev1.PipelineTask{{Name: uploadTaskName}}}},
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run := &pipelinev1.PipelineRun{Spec: tc.run}
+			err := withArtifacts(run, tc.pj, "{}")
+			if tc.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tc.expected(t, run.Spec)
+		})
+	}
+}
@@ -20,6 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,6 +34,7 @@ import (
 	prowjobinfov1 "sigs.k8s.io/prow/pkg/client/informers/externalversions/prowjobs/v1"
 	prowjoblisters "sigs.k8s.io/prow/pkg/client/listers/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
@@ -52,6 +56,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"knative.dev/pkg/apis"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -63,6 +68,7 @@ type controller struct {
 	pjc       prowjobset.Interface
 	pipelines map[string]pipelineConfig
 	totURL    string
+	git       git.ClientFactory
 
 	pjLister   prowjoblisters.ProwJobLister
 	pjInformer cache.SharedIndexInformer
@@ -82,6 +88,7 @@ type controllerOptions struct {
 	pji             prowjobinfov1.ProwJobInformer
 	pipelineConfigs map[string]pipelineConfig
 	totURL          string
+	git             git.ClientFactory
 	prowConfig      config.Getter
 	rl              workqueue.RateLimitingInterface
 }
@@ -146,6 +153,7 @@ func newController(opts controllerOptions) (*controller, error) {
 		workqueue:  opts.rl,
 		recorder:   recorder,
 		totURL:     opts.totURL,
+		git:        opts.git,
 	}
 
 	logrus.Info("Setting up event handlers")
@@ -278,6 +286,7 @@ type reconciler interface {
 	deletePipelineRun(context, namespace, name string) error
 	createPipelineRun(context, namespace string, b *pipelinev1.PipelineRun) (*pipelinev1.PipelineRun, error)
 	pipelineID(prowjobv1.ProwJob) (string, string, error)
+	readInRepoPipeline(refs prowjobv1.Refs, revision, path string) ([]byte, error)
 	now() metav1.Time
 }
 
@@ -357,6 +366,65 @@ func (c *controller) createPipelineRun(pContext, namespace string, p *pipelinev1
 	return p, errOut
 }
 
+// readInRepoPipeline reads the file at path of the repo at the revision.
+func (c *controller) readInRepoPipeline(refs prowjobv1.Refs, revision, path string) ([]byte, error) {
+	if c.git == nil {
+		return nil, errors.New("no git client to read in-repo pipelines with")
+	}
+	repo, err := c.git.ClientFor(refs.Org, refs.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("clone %s/%s: %w", refs.Org, refs.Repo, err)
+	}
+	defer func() {
+		if err := repo.Clean(); err != nil {
+			logrus.WithError(err).Warnf("Failed to clean up the clone of %s/%s.", refs.Org, refs.Repo)
+		}
+	}()
+	if err := repo.FetchRef(revision); err != nil {
+		return nil, err
+	}
+	if err := repo.Checkout("FETCH_HEAD"); err != nil {
+		return nil, err
+	}
+	return readRepoFile(repo.Directory(), path)
+}
+
+// readRepoFile reads the regular file at the slash-separated path of the
+// checkout in dir. The checkout is controlled by the tested PR, so symlinks
+// and paths that lead out of the checkout are refused rather than followed.
+func readRepoFile(dir, path string) ([]byte, error) {
+	file := filepath.Join(dir, filepath.FromSlash(path))
+	if !withinDir(dir, file) {
+		return nil, fmt.Errorf("%w: %s is outside of the repo", errInvalidInRepoPipeline, path)
+	}
+	info, err := os.Lstat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s is not a regular file", errInvalidInRepoPipeline, path)
+	}
+	// The directories of the path may still be symlinks.
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return nil, err
+	}
+	if !withinDir(root, resolved) {
+		return nil, fmt.Errorf("%w: %s is outside of the repo", errInvalidInRepoPipeline, path)
+	}
+	return os.ReadFile(resolved)
+}
+
+// withinDir tells whether the path is below the directory.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func (c *controller) now() metav1.Time {
 	return metav1.Now()
 }
@@ -509,6 +577,12 @@ func reconcile(c reconciler, key string) error {
 			}
 		}
 		return nil
+	case pj.Status.State == prowjobv1.WaitingState:
+		logrus.Debugf("Waiting for the scheduling gates of %s", key)
+		return nil
+	case pj.Status.State == prowjobv1.HeldState:
+		logrus.Debugf("Waiting for the approval of %s", key)
+		return nil
 	case wantPipelineRun && !pj.Spec.HasPipelineRunSpec():
 		return fmt.Errorf("nil PipelineRunSpec in ProwJob/%s", key)
 	case wantPipelineRun && !havePipelineRun && !cancelledState(pj.Status.State):
@@ -519,7 +593,18 @@ func reconcile(c reconciler, key string) error {
 		newpj.Status.BuildID = id
 		newpj.Status.URL = url
 		newPipelineRun = true
-		pipelineRun, err := makePipelineRun(*newpj)
+		var inRepoPipeline *pipelinev1.PipelineSpec
+		if inRepo := pj.Spec.TektonPipelineRunSpec.GetInRepoPipeline(); inRepo != nil {
+			inRepoPipeline, err = resolveInRepoPipeline(c, *pj, *inRepo)
+			if errors.Is(err, errInvalidInRepoPipeline) || errors.Is(err, fs.ErrNotExist) {
+				// Reading the pipeline again won't help, unlike failing to fetch it.
+				return updateProwJobState(c, key, newPipelineRun, pj, newpj, prowjobv1.ErrorState, fmt.Sprintf("in-repo pipeline: %v", err))
+			}
+			if err != nil {
+				return fmt.Errorf("read in-repo pipeline: %w", err)
+			}
+		}
+		pipelineRun, err := makePipelineRun(*newpj, inRepoPipeline)
 		if err != nil {
 			return fmt.Errorf("error preparing resources: %w", err)
 		}
@@ -650,6 +735,46 @@ func pipelineMeta(name string, pj prowjobv1.ProwJob) metav1.ObjectMeta {
 	}
 }
 
+var errInvalidInRepoPipeline = errors.New("invalid pipeline")
+
+// resolveInRepoPipeline reads the Pipeline a job stores in its tested repo.
+func resolveInRepoPipeline(c reconciler, pj prowjobv1.ProwJob, inRepo prowjobv1.InRepoPipeline) (*pipelinev1.PipelineSpec, error) {
+	if pj.Spec.Refs == nil {
+		return nil, fmt.Errorf("%w: the job has no repo to read %s from", errInvalidInRepoPipeline, inRepo.Path)
+	}
+	revision := inRepo.Ref
+	if revision == "" {
+		revision = testedRevision(*pj.Spec.Refs)
+	}
+	raw, err := c.readInRepoPipeline(*pj.Spec.Refs, revision, inRepo.Path)
+	if err != nil {
+		return nil, err
+	}
+	var pipeline pipelinev1.Pipeline
+	if err := yaml.UnmarshalStrict(raw, &pipeline); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errInvalidInRepoPipeline, inRepo.Path, err)
+	}
+	if pipeline.APIVersion != pipelinev1.SchemeGroupVersion.String() || pipeline.Kind != "Pipeline" {
+		return nil, fmt.Errorf("%w: %s holds a %s %s, not a %s Pipeline", errInvalidInRepoPipeline, inRepo.Path, pipeline.APIVersion, pipeline.Kind, pipelinev1.SchemeGroupVersion)
+	}
+	return &pipeline.Spec, nil
+}
+
+// testedRevision returns the revision of the refs a job tests.
+func testedRevision(refs prowjobv1.Refs) string {
+	switch {
+	case len(refs.Pulls) > 0:
+		if refs.Pulls[0].SHA != "" {
+			return refs.Pulls[0].SHA
+		}
+		return fmt.Sprintf("pull/%d/head", refs.Pulls[0].Number)
+	case refs.BaseSHA != "":
+		return refs.BaseSHA
+	default:
+		return refs.BaseRef
+	}
+}
+
 // makePipelineGitTask creates a pipeline git resource from prow job
 func makePipelineGitTask(name string, refs prowjobv1.Refs, pj prowjobv1.ProwJob) pipelinev1.PipelineTask {
 	// Pick source URL
@@ -663,20 +788,7 @@ func makePipelineGitTask(name string, refs prowjobv1.Refs, pj prowjobv1.ProwJob)
 		sourceURL = fmt.Sprintf("https://github.com/%s/%s.git", refs.Org, refs.Repo)
 	}
 
-	// Pick revision
-	var revision string
-	switch {
-	case len(refs.Pulls) > 0:
-		if refs.Pulls[0].SHA != "" {
-			revision = refs.Pulls[0].SHA
-		} else {
-			revision = fmt.Sprintf("pull/%d/head", refs.Pulls[0].Number)
-		}
-	case refs.BaseSHA != "":
-		revision = refs.BaseSHA
-	default:
-		revision = refs.BaseRef
-	}
+	revision := testedRevision(refs)
 
 	return pipelinev1.PipelineTask{
 		TaskRef: &pipelinev1.TaskRef{
@@ -695,8 +807,9 @@ func makePipelineGitTask(name string, refs prowjobv1.Refs, pj prowjobv1.ProwJob)
 	}
 }
 
-// makePipelineRun creates a pipeline run from prow job
-func makePipelineRun(pj prowjobv1.ProwJob) (*pipelinev1.PipelineRun, error) {
+// makePipelineRun creates a pipeline run from prow job, running the
+// inRepoPipeline read from the tested repo if it is set.
+func makePipelineRun(pj prowjobv1.ProwJob, inRepoPipeline *pipelinev1.PipelineSpec) (*pipelinev1.PipelineRun, error) {
 	// First validate.
 	spec, err := pj.Spec.GetPipelineRunSpec()
 	if err != nil {
@@ -705,6 +818,11 @@ func makePipelineRun(pj prowjobv1.ProwJob) (*pipelinev1.PipelineRun, error) {
 	if spec == nil {
 		return nil, errors.New("no PipelineSpec defined")
 	}
+	if inRepoPipeline != nil {
+		spec = spec.DeepCopy()
+		spec.PipelineRef = nil
+		spec.PipelineSpec = inRepoPipeline.DeepCopy()
+	}
 	buildID := pj.Status.BuildID
 	if buildID == "" {
 		return nil, errors.New("empty BuildID in status")
@@ -734,6 +852,19 @@ func makePipelineRun(pj prowjobv1.ProwJob) (*pipelinev1.PipelineRun, error) {
 			},
 		})
 	}
+	if inRepoPipeline != nil {
+		// Declare the params of the refs, so that the tasks of the in-repo
+		// pipeline can use them without repeating them in every file.
+		declared := sets.New[string]()
+		for _, param := range p.Spec.PipelineSpec.Params {
+			declared.Insert(param.Name)
+		}
+		for _, key := range sets.List(sets.KeySet[string](env)) {
+			if !declared.Has(key) {
+				p.Spec.PipelineSpec.Params = append(p.Spec.PipelineSpec.Params, pipelinev1.ParamSpec{Name: key, Type: pipelinev1.ParamTypeString})
+			}
+		}
+	}
 
 	if p.Spec.PipelineSpec != nil {
 		for i, task := range p.Spec.PipelineSpec.Tasks {
@@ -759,5 +890,9 @@ func makePipelineRun(pj prowjobv1.ProwJob) (*pipelinev1.PipelineRun, error) {
 		}
 	}
 
+	if err := withArtifacts(&p, pj, env[downwardapi.JobSpecEnv]); err != nil {
+		return nil, err
+	}
+
 	return &p, nil
 }
@@ -20,6 +20,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -57,6 +60,8 @@ type fakeReconciler struct {
 	jobs      map[string]prowjobv1.ProwJob
 	pipelines map[string]pipelinev1.PipelineRun
 	nows      metav1.Time
+	// inRepoFiles are the files of repos keyed by org/repo@revision:path.
+	inRepoFiles map[string]string
 }
 
 func (r *fakeReconciler) now() metav1.Time {
@@ -153,6 +158,14 @@ func (r *fakeReconciler) pipelineID(pj prowjobv1.ProwJob) (string, string, error
 	return pipelineID, "", nil
 }
 
+func (r *fakeReconciler) readInRepoPipeline(refs prowjobv1.Refs, revision, path string) ([]byte, error) {
+	content, ok := r.inRepoFiles[fmt.Sprintf("%s/%s@%s:%s", refs.Org, refs.Repo, revision, path)]
+	if !ok {
+		return nil, fmt.Errorf("open %s: %w", path, fs.ErrNotExist)
+	}
+	return []byte(content), nil
+}
+
 func (r *fakeReconciler) cancelPipelineRun(context string, pr *pipelinev1.PipelineRun) error {
 	pr.Spec.Status = pipelinev1.PipelineRunSpecStatusCancelledRunFinally
 	return nil
@@ -262,6 +275,7 @@ func TestReconcile(t *testing.T) {
 		expectedJob         func(prowjobv1.ProwJob, pipelinev1.PipelineRun) prowjobv1.ProwJob
 		expectedPipelineRun func(prowjobv1.ProwJob, pipelinev1.PipelineRun) pipelinev1.PipelineRun
 		duplicateStartTime  *metav1.Time
+		inRepoFiles         map[string]string
 		err                 bool
 	}{
 		{
@@ -287,7 +301,7 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedPipelineRun: func(pj prowjobv1.ProwJob, _ pipelinev1.PipelineRun) pipelinev1.PipelineRun {
 				pj.Spec.Type = prowjobv1.PeriodicJob
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -364,7 +378,7 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedPipelineRun: func(pj prowjobv1.ProwJob, _ pipelinev1.PipelineRun) pipelinev1.PipelineRun {
 				pj.Spec.Type = prowjobv1.PeriodicJob
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -398,6 +412,20 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedJob: noJobChange,
 		},
+		{
+			name: "do not create pipeline run for waiting prowjob",
+			observedJob: &prowjobv1.ProwJob{
+				Spec: prowjobv1.ProwJobSpec{
+					Agent:           prowjobv1.TektonAgent,
+					PipelineRunSpec: &pipelineSpec,
+					SchedulingGates: []string{"change-approval"},
+				},
+				Status: prowjobv1.ProwJobStatus{
+					State: prowjobv1.WaitingState,
+				},
+			},
+			expectedJob: noJobChange,
+		},
 		{
 			name: "do not create pipeline run for aborted prowjob",
 			observedJob: &prowjobv1.ProwJob{
@@ -419,7 +447,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Type = prowjobv1.PeriodicJob
 				pj.Spec.PipelineRunSpec = &pipelinev1.PipelineRunSpec{}
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -433,7 +461,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Type = prowjobv1.PeriodicJob
 				pj.Spec.PipelineRunSpec = &pipelinev1.PipelineRunSpec{}
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				p.DeletionTimestamp = &now
 				if err != nil {
 					panic(err)
@@ -449,7 +477,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Type = prowjobv1.PeriodicJob
 				pj.Spec.PipelineRunSpec = &pipelinev1.PipelineRunSpec{}
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -483,7 +511,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -516,7 +544,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -553,7 +581,7 @@ func TestReconcile(t *testing.T) {
 					},
 				}
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -584,7 +612,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -622,7 +650,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -662,7 +690,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -709,7 +737,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -730,7 +758,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Type = prowjobv1.PeriodicJob
 				pj.Spec.PipelineRunSpec = &pipelinev1.PipelineRunSpec{}
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -758,6 +786,63 @@ func TestReconcile(t *testing.T) {
 				return pj
 			},
 		},
+		{
+			name: "new prow job creates pipeline from the pipeline in its repo",
+			observedJob: &prowjobv1.ProwJob{
+				Spec: prowjobv1.ProwJobSpec{
+					Agent: prowjobv1.TektonAgent,
+					Refs:  &prowjobv1.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"},
+					TektonPipelineRunSpec: &prowjobv1.TektonPipelineRunSpec{
+						InRepoPipeline: &prowjobv1.InRepoPipeline{Path: ".tekton/pipeline.yaml"},
+					},
+				},
+				Status: prowjobv1.ProwJobStatus{
+					BuildID: pipelineID,
+				},
+			},
+			inRepoFiles: map[string]string{
+				"org/repo@abc:.tekton/pipeline.yaml": inRepoPipelineYAML,
+			},
+			expectedJob: func(pj prowjobv1.ProwJob, _ pipelinev1.PipelineRun) prowjobv1.ProwJob {
+				pj.Status = prowjobv1.ProwJobStatus{
+					StartTime:   now,
+					PendingTime: &now,
+					State:       prowjobv1.PendingState,
+					Description: descScheduling,
+					BuildID:     pipelineID,
+				}
+				return pj
+			},
+			expectedPipelineRun: func(pj prowjobv1.ProwJob, _ pipelinev1.PipelineRun) pipelinev1.PipelineRun {
+				p, err := makePipelineRun(pj, &inRepoPipelineSpec)
+				if err != nil {
+					panic(err)
+				}
+				return *p
+			},
+		},
+		{
+			name: "set prow job in error state when its repo has no pipeline",
+			observedJob: &prowjobv1.ProwJob{
+				Spec: prowjobv1.ProwJobSpec{
+					Agent: prowjobv1.TektonAgent,
+					Refs:  &prowjobv1.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"},
+					TektonPipelineRunSpec: &prowjobv1.TektonPipelineRunSpec{
+						InRepoPipeline: &prowjobv1.InRepoPipeline{Path: ".tekton/pipeline.yaml"},
+					},
+				},
+			},
+			expectedJob: func(pj prowjobv1.ProwJob, _ pipelinev1.PipelineRun) prowjobv1.ProwJob {
+				pj.Status = prowjobv1.ProwJobStatus{
+					BuildID:        pipelineID,
+					StartTime:      now,
+					CompletionTime: &now,
+					State:          prowjobv1.ErrorState,
+					Description:    "in-repo pipeline: open .tekton/pipeline.yaml: file does not exist",
+				}
+				return pj
+			},
+		},
 		{
 			name: "error when pipelinerunspec is nil",
 			err:  true,
@@ -791,7 +876,7 @@ func TestReconcile(t *testing.T) {
 				pj.Spec.Agent = prowjobv1.TektonAgent
 				pj.Spec.PipelineRunSpec = &pipelineSpec
 				pj.Status.BuildID = pipelineID
-				p, err := makePipelineRun(pj)
+				p, err := makePipelineRun(pj, nil)
 				if err != nil {
 					panic(err)
 				}
@@ -817,9 +902,10 @@ func TestReconcile(t *testing.T) {
 				tc.context = kube.DefaultClusterAlias
 			}
 			r := &fakeReconciler{
-				jobs:      map[string]prowjobv1.ProwJob{},
-				pipelines: map[string]pipelinev1.PipelineRun{},
-				nows:      now,
+				jobs:        map[string]prowjobv1.ProwJob{},
+				pipelines:   map[string]pipelinev1.PipelineRun{},
+				nows:        now,
+				inRepoFiles: tc.inRepoFiles,
 			}
 
 			jk := toKey(fakePJCtx, fakePJNS, name)
@@ -874,6 +960,198 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+const inRepoPipelineYAML = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: test
+spec:
+  params:
+  - name: PULL_BASE_SHA
+    type: string
+  tasks:
+  - name: test
+    taskRef:
+      name: make-test
+`
+
+var inRepoPipelineSpec = pipelinev1.PipelineSpec{
+	Params: pipelinev1.ParamSpecs{{Name: "PULL_BASE_SHA", Type: pipelinev1.ParamTypeString}},
+	Tasks:  []pipelinev1.PipelineTask{{Name: "test", TaskRef: &pipelinev1.TaskRef{Name: "make-test"}}},
+}
+
+func TestResolveInRepoPipeline(t *testing.T) {
+	cases := []struct {
+		name     string
+		refs     *prowjobv1.Refs
+		inRepo   prowjobv1.InRepoPipeline
+		files    map[string]string
+		expected *pipelinev1.PipelineSpec
+		invalid  bool
+		err      bool
+	}{
+		{
+			name:     "read at the head of the pull of a presubmit",
+			refs:     &prowjobv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowjobv1.Pull{{Number: 1, SHA: "head"}}},
+			inRepo:   prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			files:    map[string]string{"org/repo@head:pipeline.yaml": inRepoPipelineYAML},
+			expected: &inRepoPipelineSpec,
+		},
+		{
+			name:     "read at the base of a postsubmit",
+			refs:     &prowjobv1.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "base"},
+			inRepo:   prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			files:    map[string]string{"org/repo@base:pipeline.yaml": inRepoPipelineYAML},
+			expected: &inRepoPipelineSpec,
+		},
+		{
+			name:     "read at the configured ref",
+			refs:     &prowjobv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowjobv1.Pull{{Number: 1, SHA: "head"}}},
+			inRepo:   prowjobv1.InRepoPipeline{Path: "pipeline.yaml", Ref: "main"},
+			files:    map[string]string{"org/repo@main:pipeline.yaml": inRepoPipelineYAML},
+			expected: &inRepoPipelineSpec,
+		},
+		{
+			name:    "jobs without refs are invalid",
+			inRepo:  prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			invalid: true,
+		},
+		{
+			name:   "missing file",
+			refs:   &prowjobv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"},
+			inRepo: prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			err:    true,
+		},
+		{
+			name:    "files that aren't pipelines are invalid",
+			refs:    &prowjobv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"},
+			inRepo:  prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			files:   map[string]string{"org/repo@base:pipeline.yaml": "apiVersion: tekton.dev/v1\nkind: Task\n"},
+			invalid: true,
+		},
+		{
+			name:    "malformed pipelines are invalid",
+			refs:    &prowjobv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"},
+			inRepo:  prowjobv1.InRepoPipeline{Path: "pipeline.yaml"},
+			files:   map[string]string{"org/repo@base:pipeline.yaml": "apiVersion: tekton.dev/v1\nkind: Pipeline\nspec:\n  taskz: []\n"},
+			invalid: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &fakeReconciler{inRepoFiles: tc.files}
+			pj := prowjobv1.ProwJob{Spec: prowjobv1.ProwJobSpec{Refs: tc.refs}}
+			actual, err := resolveInRepoPipeline(r, pj, tc.inRepo)
+			if invalid := errors.Is(err, errInvalidInRepoPipeline); invalid != tc.invalid {
+				t.Fatalf("expected invalid %t, got %v", tc.invalid, err)
+			}
+			if (err != nil) != (tc.err || tc.invalid) {
+				t.Fatalf("expected error %t, got %v", tc.err || tc.invalid, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected pipeline (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadRepoFile(t *testing.T) {
+	tmp := t.TempDir()
+	outside := filepath.Join(tmp, "outside")
+	dir := filepath.Join(tmp, "repo")
+	for _, d := range []string{outside, filepath.Join(dir, ".tekton")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "token"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".tekton", "pipeline.yaml"), []byte(inRepoPipelineYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"token":         filepath.Join(outside, "token"),
+		"pipeline.yaml": ".tekton/pipeline.yaml",
+		"tokens":        outside,
+		"tekton":        ".tekton",
+		".tekton/etc":   "../../outside",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		path     string
+		expected string
+		invalid  bool
+		notExist bool
+	}{
+		{
+			name:     "file in the repo",
+			path:     ".tekton/pipeline.yaml",
+			expected: inRepoPipelineYAML,
+		},
+		{
+			name:     "file in a directory linked within the repo",
+			path:     "tekton/pipeline.yaml",
+			expected: inRepoPipelineYAML,
+		},
+		{
+			name:    "symlink to a file outside of the repo",
+			path:    "token",
+			invalid: true,
+		},
+		{
+			name:    "symlink to a file in the repo",
+			path:    "pipeline.yaml",
+			invalid: true,
+		},
+		{
+			name:    "file in a directory linked outside of the repo",
+			path:    "tokens/token",
+			invalid: true,
+		},
+		{
+			name:    "file in a nested directory linked outside of the repo",
+			path:    ".tekton/etc/token",
+			invalid: true,
+		},
+		{
+			name:    "path out of the repo",
+			path:    "../token",
+			invalid: true,
+		},
+		{
+			name:    "directory",
+			path:    ".tekton",
+			invalid: true,
+		},
+		{
+			name:     "missing file",
+			path:     "missing.yaml",
+			notExist: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := readRepoFile(dir, tc.path)
+			if invalid := errors.Is(err, errInvalidInRepoPipeline); invalid != tc.invalid {
+				t.Fatalf("expected invalid %t, got %v", tc.invalid, err)
+			}
+			if notExist := errors.Is(err, fs.ErrNotExist); notExist != tc.notExist {
+				t.Fatalf("expected not exist %t, got %v", tc.notExist, err)
+			}
+			if err == nil && string(actual) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, string(actual))
+			}
+		})
+	}
+}
+
 func TestPipelineMeta(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -913,10 +1191,11 @@ func TestPipelineMeta(t *testing.T) {
 
 func TestMakeResourcesBeta1(t *testing.T) {
 	cases := []struct {
-		name        string
-		job         func(prowjobv1.ProwJob) prowjobv1.ProwJob
-		pipelineRun func(pipelinev1.PipelineRun) pipelinev1.PipelineRun
-		err         bool
+		name           string
+		job            func(prowjobv1.ProwJob) prowjobv1.ProwJob
+		inRepoPipeline *pipelinev1.PipelineSpec
+		pipelineRun    func(pipelinev1.PipelineRun) pipelinev1.PipelineRun
+		err            bool
 	}{
 		{
 			name: "reject empty prow job",
@@ -1004,6 +1283,36 @@ func TestMakeResourcesBeta1(t *testing.T) {
 				return pr
 			},
 		},
+		{
+			name: "run the in-repo pipeline with the params of the job declared",
+			job: func(pj prowjobv1.ProwJob) prowjobv1.ProwJob {
+				pj.Spec.TektonPipelineRunSpec.V1Beta1.PipelineRef = &pipelinev1.PipelineRef{Name: "ignored"}
+				return pj
+			},
+			inRepoPipeline: &pipelinev1.PipelineSpec{
+				Params: pipelinev1.ParamSpecs{{Name: "JOB_NAME", Type: pipelinev1.ParamTypeString, Default: pipelinev1.NewStructuredValues("default")}},
+				Tasks: []pipelinev1.PipelineTask{
+					{Name: "test", TaskRef: &pipelinev1.TaskRef{Name: "make-test"}},
+				},
+			},
+			pipelineRun: func(pr pipelinev1.PipelineRun) pipelinev1.PipelineRun {
+				pr.Spec.PipelineRef = nil
+				pr.Spec.PipelineSpec = &pipelinev1.PipelineSpec{
+					Params: pipelinev1.ParamSpecs{
+						{Name: "JOB_NAME", Type: pipelinev1.ParamTypeString, Default: pipelinev1.NewStructuredValues("default")},
+						{Name: "BUILD_ID", Type: pipelinev1.ParamTypeString},
+						{Name: "CI", Type: pipelinev1.ParamTypeString},
+						{Name: "JOB_SPEC", Type: pipelinev1.ParamTypeString},
+						{Name: "JOB_TYPE", Type: pipelinev1.ParamTypeString},
+						{Name: "PROW_JOB_ID", Type: pipelinev1.ParamTypeString},
+					},
+					Tasks: []pipelinev1.PipelineTask{
+						{Name: "test", TaskRef: &pipelinev1.TaskRef{Name: "make-test"}},
+					},
+				}
+				return pr
+			},
+		},
 		{
 			name: "do not override unrelated git resources",
 			job: func(pj prowjobv1.ProwJob) prowjobv1.ProwJob {
@@ -1035,7 +1344,7 @@ func TestMakeResourcesBeta1(t *testing.T) {
 				pj = tc.job(pj)
 			}
 
-			actualRun, err := makePipelineRun(pj)
+			actualRun, err := makePipelineRun(pj, tc.inRepoPipeline)
 			if err != nil {
 				if !tc.err {
 					t.Errorf("unexpected error: %v", err)
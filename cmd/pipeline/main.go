@@ -46,6 +46,7 @@ import (
 type options struct {
 	allContexts            bool
 	config                 configflagutil.ConfigOptions
+	github                 prowflagutil.GitHubOptions
 	kubernetes             prowflagutil.KubernetesOptions
 	totURL                 string
 	instrumentationOptions prowflagutil.InstrumentationOptions
@@ -63,6 +64,7 @@ func (o *options) parse(flags *flag.FlagSet, args []string) error {
 	o.config.ConfigPathFlagName = "config"
 	flags.BoolVar(&o.allContexts, "all-contexts", false, "Monitor all cluster contexts, not just default")
 	flags.StringVar(&o.totURL, "tot-url", "", "Tot URL")
+	o.github.AddFlags(flags)
 	o.kubernetes.AddFlags(flags)
 	o.instrumentationOptions.AddFlags(flags)
 	o.config.AddFlags(flags)
@@ -72,6 +74,9 @@ func (o *options) parse(flags *flag.FlagSet, args []string) error {
 	if err := o.config.Validate(false); err != nil {
 		return err
 	}
+	if err := o.github.Validate(false); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -163,12 +168,25 @@ func main() {
 		pipelineConfigs[context] = *bc
 	}
 
+	// The git client reads the pipelines jobs store in their repos. It is
+	// anonymous unless GitHub credentials are configured.
+	gitClient, err := o.github.GitClientFactory("", nil, false, false)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting Git client.")
+	}
+	interrupts.OnInterrupt(func() {
+		if err := gitClient.Clean(); err != nil {
+			logrus.WithError(err).Error("Could not clean up git client cache.")
+		}
+	})
+
 	opts := controllerOptions{
 		kc:              kc,
 		pjc:             pjc,
 		pji:             pjif.Prow().V1().ProwJobs(),
 		pipelineConfigs: pipelineConfigs,
 		totURL:          o.totURL,
+		git:             gitClient,
 		prowConfig:      configAgent.Config,
 		rl:              kube.RateLimiter(controllerName),
 	}
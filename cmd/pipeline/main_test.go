@@ -26,6 +26,8 @@ import (
 )
 
 func TestOptions(t *testing.T) {
+	var defaultGitHubOptions prowflagutil.GitHubOptions
+	defaultGitHubOptions.AddFlags(flag.NewFlagSet("", flag.ContinueOnError))
 	cases := []struct {
 		name     string
 		args     []string
@@ -46,6 +48,7 @@ func TestOptions(t *testing.T) {
 				SupplementalProwConfigsFileNameSuffix: "_prowconfig.yaml",
 				InRepoConfigCacheSize:                 200,
 			},
+			github:                 defaultGitHubOptions,
 			instrumentationOptions: prowflagutil.DefaultInstrumentationOptions(),
 		},
 	}, {
@@ -61,6 +64,7 @@ func TestOptions(t *testing.T) {
 				SupplementalProwConfigsFileNameSuffix: "_prowconfig.yaml",
 				InRepoConfigCacheSize:                 200,
 			},
+			github:                 defaultGitHubOptions,
 			instrumentationOptions: prowflagutil.DefaultInstrumentationOptions(),
 		},
 	}}
@@ -36,7 +36,9 @@ import (
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/pjutil/pprof"
 	"sigs.k8s.io/prow/pkg/scheduler"
+	"sigs.k8s.io/prow/pkg/schedulinggates"
 
+	"sigs.k8s.io/prow/pkg/artifactretention"
 	"sigs.k8s.io/prow/pkg/flagutil"
 	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
@@ -49,7 +51,7 @@ import (
 	_ "sigs.k8s.io/prow/pkg/version"
 )
 
-var allControllers = sets.New(plank.ControllerName, scheduler.ControllerName)
+var allControllers = sets.New(plank.ControllerName, scheduler.ControllerName, schedulinggates.ControllerName, artifactretention.ControllerName)
 
 type options struct {
 	totURL string
@@ -216,6 +218,18 @@ func main() {
 		}
 	}
 
+	if enabledControllersSet.Has(schedulinggates.ControllerName) {
+		if err := schedulinggates.Add(mgr, cfg, 1); err != nil {
+			logrus.WithError(err).Fatal("Failed to add scheduling gates controller to manager")
+		}
+	}
+
+	if enabledControllersSet.Has(artifactretention.ControllerName) {
+		if err := artifactretention.Add(mgr, cfg, opener); err != nil {
+			logrus.WithError(err).Fatal("Failed to add artifact retention to manager")
+		}
+	}
+
 	// Expose prometheus metrics
 	metrics.ExposeMetrics("plank", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
 	// Serve readiness endpoint
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/testimpact"
+)
+
+const (
+	impactGroup     = "impact"
+	generateCommand = "generate"
+)
+
+type impactOptions struct {
+	root   string
+	goPkgs prowflagutil.Strings
+	bazel  prowflagutil.Strings
+	always prowflagutil.Strings
+	output string
+
+	runner testimpact.Runner
+}
+
+func gatherImpactOptions(fs *flag.FlagSet, command string, args ...string) (impactOptions, error) {
+	o := impactOptions{runner: testimpact.ExecRunner}
+	if command != generateCommand {
+		return o, fmt.Errorf("unknown command %q, must be %s", command, generateCommand)
+	}
+	fs.StringVar(&o.root, "root", ".", "Root of the repo to generate the test impact mapping of.")
+	fs.Var(&o.goPkgs, "go", "Job and the Go packages it tests, like pull-foo=./cmd/foo/...,./pkg/foo/..., to map the job to the files of the packages they depend on. Can be passed multiple times.")
+	fs.Var(&o.bazel, "bazel", "Job and the Bazel targets it tests, like pull-foo=//cmd/foo/...,//pkg/foo:go_test, to map the job to the files of the targets they depend on. Can be passed multiple times.")
+	fs.Var(&o.always, "always", "Pattern of files that affect every job, like go.mod or WORKSPACE. Can be passed multiple times.")
+	fs.StringVar(&o.output, "output", "", "File to write the mapping to. Defaults to the standard output.")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if fs.NArg() != 0 {
+		return o, fmt.Errorf("%s takes no arguments", generateCommand)
+	}
+	return o, nil
+}
+
+// parseJobDeps parses job=dep1,dep2 flag values.
+func parseJobDeps(values []string) (map[string][]string, error) {
+	deps := map[string][]string{}
+	for _, value := range values {
+		job, list, ok := strings.Cut(value, "=")
+		if !ok || job == "" || list == "" {
+			return nil, fmt.Errorf("%q is not of the form job=dep1,dep2", value)
+		}
+		deps[job] = append(deps[job], strings.Split(list, ",")...)
+	}
+	return deps, nil
+}
+
+func (o *impactOptions) Validate() error {
+	if len(o.goPkgs.Strings()) == 0 && len(o.bazel.Strings()) == 0 {
+		return errors.New("at least one of --go and --bazel must be set")
+	}
+	for _, values := range [][]string{o.goPkgs.Strings(), o.bazel.Strings()} {
+		if _, err := parseJobDeps(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *impactOptions) run(_ context.Context, out io.Writer) error {
+	goPkgs, _ := parseJobDeps(o.goPkgs.Strings())
+	targets, _ := parseJobDeps(o.bazel.Strings())
+	m := testimpact.Mapping{Always: o.always.Strings(), Jobs: map[string][]string{}}
+	for job, pkgs := range goPkgs {
+		patterns, err := testimpact.GoDeps(o.runner, o.root, pkgs)
+		if err != nil {
+			return fmt.Errorf("failed to get the dependencies of %s: %w", job, err)
+		}
+		m.Jobs[job] = append(m.Jobs[job], patterns...)
+	}
+	for job, jobTargets := range targets {
+		patterns, err := testimpact.BazelDeps(o.runner, o.root, jobTargets)
+		if err != nil {
+			return fmt.Errorf("failed to get the dependencies of %s: %w", job, err)
+		}
+		m.Jobs[job] = append(m.Jobs[job], patterns...)
+	}
+	raw, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the mapping: %w", err)
+	}
+	if o.output == "" {
+		_, err := out.Write(raw)
+		return err
+	}
+	if err := os.WriteFile(o.output, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", o.output, err)
+	}
+	fmt.Fprintf(out, "Wrote the dependencies of %d jobs to %s.\n", len(m.Jobs), o.output)
+	return nil
+}
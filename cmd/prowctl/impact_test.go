@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImpactGenerate(t *testing.T) {
+	o, err := gatherImpactOptions(flag.NewFlagSet("test", flag.ContinueOnError), generateCommand,
+		"--root=/repo", "--go=pull-foo=./cmd/foo", "--bazel=pull-bar=//cmd/bar", "--always=go.mod")
+	if err != nil {
+		t.Fatalf("failed to gather options: %v", err)
+	}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("invalid options: %v", err)
+	}
+	o.runner = func(dir, name string, args ...string) ([]byte, error) {
+		if name == "go" {
+			return []byte("/repo/cmd/foo\n/repo/pkg/foo\n"), nil
+		}
+		return []byte("//cmd/bar:main.go\n//cmd/bar:BUILD.bazel\n"), nil
+	}
+	out := &bytes.Buffer{}
+	if err := o.run(context.Background(), out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `always:
+- go.mod
+jobs:
+  pull-bar:
+  - cmd/bar/BUILD.bazel
+  - cmd/bar/main.go
+  pull-foo:
+  - cmd/foo/*
+  - pkg/foo/*
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected mapping (-want +got): %s", diff)
+	}
+}
+
+func TestImpactValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr bool
+	}{
+		{
+			name: "go packages",
+			args: []string{"--go=pull-foo=./pkg/foo/...,./cmd/foo"},
+		},
+		{
+			name:        "no jobs",
+			args:        []string{"--always=go.mod"},
+			expectedErr: true,
+		},
+		{
+			name:        "job without targets",
+			args:        []string{"--bazel=pull-foo"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := gatherImpactOptions(flag.NewFlagSet("test", flag.ContinueOnError), generateCommand, tc.args...)
+			if err != nil {
+				t.Fatalf("failed to gather options: %v", err)
+			}
+			if err := o.Validate(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/configsnapshot"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+)
+
+const (
+	lifecycleGroup = "lifecycle"
+	applyCommand   = "apply"
+)
+
+type lifecycleOptions struct {
+	config             configflagutil.ConfigOptions
+	gcsCredentialsFile string
+	buckets            prowflagutil.Strings
+	confirm            bool
+}
+
+func gatherLifecycleOptions(fs *flag.FlagSet, command string, args ...string) (lifecycleOptions, error) {
+	o := lifecycleOptions{}
+	if command != applyCommand {
+		return o, fmt.Errorf("unknown command %q, must be %s", command, applyCommand)
+	}
+	o.config.AddFlags(fs)
+	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored. Application default credentials are used if unset.")
+	fs.Var(&o.buckets, "bucket", "Only configure this bucket, like gs://bucket. Can be passed multiple times. All buckets with a storage_retention are configured if unset.")
+	fs.BoolVar(&o.confirm, "confirm", false, "Update the lifecycle of the buckets. Without it, only the changes are shown.")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if fs.NArg() != 0 {
+		return o, fmt.Errorf("%s takes no arguments", applyCommand)
+	}
+	return o, nil
+}
+
+func (o *lifecycleOptions) Validate() error {
+	if o.config.ConfigPath == "" {
+		return errors.New("--config-path must be set")
+	}
+	return o.config.ValidateConfigOptional()
+}
+
+func (o *lifecycleOptions) run(ctx context.Context, out io.Writer) error {
+	cfg, err := config.Load(o.config.ConfigPath, o.config.JobConfigPath, o.config.SupplementalProwConfigDirs.Strings(), o.config.SupplementalProwConfigsFileNameSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to load the config: %w", err)
+	}
+	var opts []option.ClientOption
+	if o.gcsCredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(o.gcsCredentialsFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	defer client.Close()
+	return applyLifecycles(ctx, &gcsBucketLifecycles{client: client}, cfg.StorageRetention, sets.New(o.buckets.Strings()...), o.confirm, out)
+}
+
+// bucketLifecycles reads and updates the lifecycle of buckets, by name.
+type bucketLifecycles interface {
+	Lifecycle(ctx context.Context, bucket string) (storage.Lifecycle, error)
+	SetLifecycle(ctx context.Context, bucket string, lifecycle storage.Lifecycle) error
+}
+
+type gcsBucketLifecycles struct {
+	client *storage.Client
+}
+
+func (g *gcsBucketLifecycles) Lifecycle(ctx context.Context, bucket string) (storage.Lifecycle, error) {
+	attrs, err := g.client.Bucket(bucket).Attrs(ctx)
+	if err != nil {
+		return storage.Lifecycle{}, err
+	}
+	return attrs.Lifecycle, nil
+}
+
+func (g *gcsBucketLifecycles) SetLifecycle(ctx context.Context, bucket string, lifecycle storage.Lifecycle) error {
+	_, err := g.client.Bucket(bucket).Update(ctx, storage.BucketAttrsToUpdate{Lifecycle: &lifecycle})
+	return err
+}
+
+// lifecycles returns the lifecycle of every bucket with storage retentions,
+// keyed by the bucket like gs://bucket.
+func lifecycles(retentions []config.StorageRetention) map[string]storage.Lifecycle {
+	byBucket := map[string]storage.Lifecycle{}
+	for _, retention := range retentions {
+		lifecycle := byBucket[retention.Bucket]
+		var prefixes []string
+		if len(retention.Prefixes) > 0 {
+			prefixes = sets.List(sets.New(retention.Prefixes...))
+		}
+		if retention.ColdStorageAfterDays > 0 {
+			lifecycle.Rules = append(lifecycle.Rules, storage.LifecycleRule{
+				Action:    storage.LifecycleAction{Type: storage.SetStorageClassAction, StorageClass: retention.ColdStorageClass},
+				Condition: storage.LifecycleCondition{AgeInDays: int64(retention.ColdStorageAfterDays), MatchesPrefix: prefixes},
+			})
+		}
+		if retention.DeleteAfterDays > 0 {
+			lifecycle.Rules = append(lifecycle.Rules, storage.LifecycleRule{
+				Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+				Condition: storage.LifecycleCondition{AgeInDays: int64(retention.DeleteAfterDays), MatchesPrefix: prefixes},
+			})
+		}
+		byBucket[retention.Bucket] = lifecycle
+	}
+	return byBucket
+}
+
+// formatLifecycle renders the rules of the lifecycle one per line, sorted so
+// that the order of the rules doesn't matter.
+func formatLifecycle(lifecycle storage.Lifecycle) string {
+	var lines []string
+	for _, rule := range lifecycle.Rules {
+		line := rule.Action.Type
+		if rule.Action.StorageClass != "" {
+			line += " to " + rule.Action.StorageClass
+		}
+		condition := rule.Condition
+		line += fmt.Sprintf(" after %d days", condition.AgeInDays)
+		if len(condition.MatchesPrefix) > 0 {
+			line += ": " + strings.Join(condition.MatchesPrefix, ", ")
+		} else {
+			line += ": all objects"
+		}
+		// Rules not created from storage retentions can have other
+		// conditions, which must not make them look like managed ones.
+		condition.AgeInDays, condition.MatchesPrefix = 0, nil
+		if !reflect.DeepEqual(condition, storage.LifecycleCondition{}) {
+			line += fmt.Sprintf(" (%+v)", condition)
+		}
+		lines = append(lines, line+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+// applyLifecycles shows how the lifecycles of the buckets change to match
+// the storage retentions, and updates them if confirm is set. The storage
+// retentions are authoritative for their buckets, so other lifecycle rules
+// of the buckets are removed.
+func applyLifecycles(ctx context.Context, client bucketLifecycles, retentions []config.StorageRetention, only sets.Set[string], confirm bool, out io.Writer) error {
+	desired := lifecycles(retentions)
+	if unknown := only.Difference(sets.KeySet(desired)); unknown.Len() > 0 {
+		return fmt.Errorf("no storage_retention is configured for %s", strings.Join(sets.List(unknown), ", "))
+	}
+	var changes int
+	for _, bucket := range sets.List(sets.KeySet(desired)) {
+		if only.Len() > 0 && !only.Has(bucket) {
+			continue
+		}
+		name := strings.TrimPrefix(bucket, "gs://")
+		current, err := client.Lifecycle(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get the lifecycle of %s: %w", bucket, err)
+		}
+		before, after := formatLifecycle(current), formatLifecycle(desired[bucket])
+		if before == after {
+			fmt.Fprintf(out, "The lifecycle of %s is up to date.\n", bucket)
+			continue
+		}
+		changes++
+		diff, err := configsnapshot.UnifiedDiff(bucket, bucket, []byte(before), []byte(after))
+		if err != nil {
+			return fmt.Errorf("failed to diff the lifecycle of %s: %w", bucket, err)
+		}
+		if _, err := io.WriteString(out, diff); err != nil {
+			return err
+		}
+		if !confirm {
+			continue
+		}
+		if err := client.SetLifecycle(ctx, name, desired[bucket]); err != nil {
+			return fmt.Errorf("failed to update the lifecycle of %s: %w", bucket, err)
+		}
+		fmt.Fprintf(out, "Updated the lifecycle of %s.\n", bucket)
+	}
+	if changes > 0 && !confirm {
+		fmt.Fprintln(out, "Run again with --confirm to update the lifecycles.")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+type fakeBucketLifecycles map[string]storage.Lifecycle
+
+func (f fakeBucketLifecycles) Lifecycle(_ context.Context, bucket string) (storage.Lifecycle, error) {
+	lifecycle, ok := f[bucket]
+	if !ok {
+		return storage.Lifecycle{}, errors.New("bucket doesn't exist")
+	}
+	return lifecycle, nil
+}
+
+func (f fakeBucketLifecycles) SetLifecycle(_ context.Context, bucket string, lifecycle storage.Lifecycle) error {
+	f[bucket] = lifecycle
+	return nil
+}
+
+func TestApplyLifecycles(t *testing.T) {
+	retentions := []config.StorageRetention{
+		{Bucket: "gs://artifacts", Prefixes: []string{"pr-logs/", "logs/"}, ColdStorageAfterDays: 30, ColdStorageClass: "COLDLINE", DeleteAfterDays: 365},
+		{Bucket: "gs://artifacts", Prefixes: []string{"tmp/"}, DeleteAfterDays: 7},
+		{Bucket: "gs://cache", DeleteAfterDays: 14},
+	}
+	artifactsLifecycle := storage.Lifecycle{Rules: []storage.LifecycleRule{
+		{
+			Action:    storage.LifecycleAction{Type: storage.SetStorageClassAction, StorageClass: "COLDLINE"},
+			Condition: storage.LifecycleCondition{AgeInDays: 30, MatchesPrefix: []string{"logs/", "pr-logs/"}},
+		},
+		{
+			Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+			Condition: storage.LifecycleCondition{AgeInDays: 365, MatchesPrefix: []string{"logs/", "pr-logs/"}},
+		},
+		{
+			Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+			Condition: storage.LifecycleCondition{AgeInDays: 7, MatchesPrefix: []string{"tmp/"}},
+		},
+	}}
+	cacheLifecycle := storage.Lifecycle{Rules: []storage.LifecycleRule{{
+		Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+		Condition: storage.LifecycleCondition{AgeInDays: 14},
+	}}}
+
+	testCases := []struct {
+		name      string
+		buckets   fakeBucketLifecycles
+		only      []string
+		confirm   bool
+		expected  fakeBucketLifecycles
+		output    []string
+		expectErr bool
+	}{
+		{
+			name:     "changes are only shown without confirm",
+			buckets:  fakeBucketLifecycles{"artifacts": {}, "cache": cacheLifecycle},
+			expected: fakeBucketLifecycles{"artifacts": {}, "cache": cacheLifecycle},
+			output: []string{
+				"+Delete after 7 days: tmp/\n",
+				"+SetStorageClass to COLDLINE after 30 days: logs/, pr-logs/\n",
+				"The lifecycle of gs://cache is up to date.\n",
+				"Run again with --confirm",
+			},
+		},
+		{
+			name:     "lifecycles are updated with confirm",
+			buckets:  fakeBucketLifecycles{"artifacts": {}, "cache": {}},
+			confirm:  true,
+			expected: fakeBucketLifecycles{"artifacts": artifactsLifecycle, "cache": cacheLifecycle},
+			output: []string{
+				"Updated the lifecycle of gs://artifacts.\n",
+				"Updated the lifecycle of gs://cache.\n",
+			},
+		},
+		{
+			name: "other rules are removed",
+			buckets: fakeBucketLifecycles{"cache": {Rules: []storage.LifecycleRule{{
+				Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+				Condition: storage.LifecycleCondition{AgeInDays: 14, MatchesSuffix: []string{".tar"}},
+			}}}},
+			only:     []string{"gs://cache"},
+			confirm:  true,
+			expected: fakeBucketLifecycles{"cache": cacheLifecycle},
+			output:   []string{"-Delete after 14 days: all objects ("},
+		},
+		{
+			name:      "unknown bucket",
+			buckets:   fakeBucketLifecycles{},
+			only:      []string{"gs://unknown"},
+			expected:  fakeBucketLifecycles{},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := applyLifecycles(context.Background(), tc.buckets, retentions, sets.New(tc.only...), tc.confirm, &out)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, tc.buckets); diff != "" {
+				t.Errorf("unexpected lifecycles (-want +got):\n%s", diff)
+			}
+			for _, expected := range tc.output {
+				if !bytes.Contains(out.Bytes(), []byte(expected)) {
+					t.Errorf("expected the output to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// prowctl is a command line tool for Prow operators. Its snapshot commands
+// list and diff the config snapshots taken by config-snapshotter and roll
+// the config back to one of them. Its lifecycle command configures the
+// lifecycle rules of buckets from the storage retentions of the config. Its
+// artifacts command migrates the artifacts of past builds to another bucket.
+// Its impact command generates the mapping of presubmits to the files they
+// depend on that trigger uses to skip unaffected presubmits.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // support gcp users in .kube/config
+
+	"sigs.k8s.io/prow/pkg/logrusutil"
+)
+
+const usage = `Usage: prowctl snapshot <command> [flags] [arguments]
+       prowctl lifecycle <command> [flags]
+       prowctl artifacts <command> [flags]
+       prowctl impact <command> [flags]
+
+Snapshot commands:
+  list               List the config snapshots, latest first.
+  diff <from> [<to>] Diff two snapshots. Without <to>, diff against the config
+                     of --config-path if set, otherwise against the latest
+                     snapshot.
+  rollback <id>      Write the config and plugins config of the snapshot to
+                     their ConfigMaps. Only shows the changes unless
+                     --confirm is set.
+
+Lifecycle commands:
+  apply              Configure the lifecycle rules of the buckets with a
+                     storage_retention in the config of --config-path. Only
+                     shows the changes unless --confirm is set.
+
+Artifacts commands:
+  migrate            Copy the artifacts of --from to --to, keeping their
+                     paths, and delete them from --from if --move is set.
+                     Only shows what would be migrated unless --confirm is
+                     set.
+
+Impact commands:
+  generate           Map the jobs of --go and --bazel to the files in the
+                     repo that the packages or targets they test depend on,
+                     for the test_impact_mapping of trigger.
+
+Run prowctl <snapshot|lifecycle|artifacts|impact> <command> --help for the flags of a command.
+`
+
+type command interface {
+	Validate() error
+	run(ctx context.Context, out io.Writer) error
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	group, name := os.Args[1], os.Args[2]
+	fs := flag.NewFlagSet("prowctl "+group+" "+name, flag.ExitOnError)
+	var cmd command
+	var err error
+	switch group {
+	case "snapshot":
+		var o options
+		o, err = gatherOptions(fs, name, os.Args[3:]...)
+		cmd = &o
+	case lifecycleGroup:
+		var o lifecycleOptions
+		o, err = gatherLifecycleOptions(fs, name, os.Args[3:]...)
+		cmd = &o
+	case artifactsGroup:
+		var o migrateOptions
+		o, err = gatherMigrateOptions(fs, name, os.Args[3:]...)
+		cmd = &o
+	case impactGroup:
+		var o impactOptions
+		o, err = gatherImpactOptions(fs, name, os.Args[3:]...)
+		cmd = &o
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err == nil {
+		err = cmd.Validate()
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	if err := cmd.run(context.Background(), os.Stdout); err != nil {
+		logrus.WithError(err).Fatalf("Failed to %s.", name)
+	}
+}
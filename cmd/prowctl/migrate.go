@@ -0,0 +1,345 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+const (
+	artifactsGroup = "artifacts"
+	migrateCommand = "migrate"
+)
+
+type migrateOptions struct {
+	from        string
+	to          string
+	prefixes    prowflagutil.Strings
+	move        bool
+	concurrency int
+	aliasFile   string
+	confirm     bool
+
+	storage prowflagutil.StorageClientOptions
+}
+
+func gatherMigrateOptions(fs *flag.FlagSet, command string, args ...string) (migrateOptions, error) {
+	o := migrateOptions{}
+	if command != migrateCommand {
+		return o, fmt.Errorf("unknown command %q, must be %s", command, migrateCommand)
+	}
+	fs.StringVar(&o.from, "from", "", "Bucket to migrate the artifacts from, like gs://old-bucket.")
+	fs.StringVar(&o.to, "to", "", "Bucket to migrate the artifacts to, like gs://new-bucket or s3://new-bucket. The artifacts keep their paths.")
+	fs.Var(&o.prefixes, "prefix", "Only migrate the artifacts under this prefix, like pr-logs/. Can be passed multiple times. All artifacts are migrated if unset.")
+	fs.BoolVar(&o.move, "move", false, "Delete the artifacts from the source bucket once they are copied.")
+	fs.IntVar(&o.concurrency, "concurrency", 16, "Number of artifacts that are copied at the same time.")
+	fs.StringVar(&o.aliasFile, "alias-file", "", "If set, the alias of the source bucket for the destination bucket is added to the deck.spyglass.bucket_aliases of this YAML file, creating it if needed, so that Deck resolves the links to the migrated builds.")
+	fs.BoolVar(&o.confirm, "confirm", false, "Migrate the artifacts. Without it, only what would be migrated is shown.")
+	o.storage.AddFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if fs.NArg() != 0 {
+		return o, fmt.Errorf("%s takes no arguments", migrateCommand)
+	}
+	return o, nil
+}
+
+func (o *migrateOptions) Validate() error {
+	if o.from == "" || o.to == "" {
+		return errors.New("--from and --to must be set")
+	}
+	for _, bucket := range []string{o.from, o.to} {
+		if _, _, rest, err := providers.ParseStoragePath(bucket); err != nil {
+			return err
+		} else if strings.Trim(rest, "/") != "" {
+			return fmt.Errorf("%s must be a bucket without a path, use --prefix to migrate parts of it", bucket)
+		}
+	}
+	if strings.TrimSuffix(o.from, "/") == strings.TrimSuffix(o.to, "/") {
+		return errors.New("--from and --to must be different buckets")
+	}
+	if o.concurrency < 1 {
+		return errors.New("--concurrency must be positive")
+	}
+	return o.storage.Validate(false)
+}
+
+func (o *migrateOptions) run(ctx context.Context, out io.Writer) error {
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create the storage client: %w", err)
+	}
+	m := &migration{
+		storage:     opener,
+		from:        strings.TrimSuffix(o.from, "/"),
+		to:          strings.TrimSuffix(o.to, "/"),
+		prefixes:    o.prefixes.Strings(),
+		move:        o.move,
+		concurrency: o.concurrency,
+		confirm:     o.confirm,
+		out:         out,
+	}
+	if err := m.run(ctx); err != nil {
+		return err
+	}
+	if o.aliasFile != "" && o.confirm {
+		return writeBucketAlias(o.aliasFile, m.from, m.to, out)
+	}
+	return nil
+}
+
+// migrationStorage is the part of pkgio.Opener a migration uses.
+type migrationStorage interface {
+	Iterator(ctx context.Context, prefix, delimiter string) (pkgio.ObjectIterator, error)
+	Reader(ctx context.Context, path string) (io.ReadCloser, error)
+	Writer(ctx context.Context, path string, opts ...pkgio.WriterOptions) (io.WriteCloser, error)
+	Attributes(ctx context.Context, path string) (pkgio.Attributes, error)
+	Delete(ctx context.Context, path string) error
+}
+
+// migration copies or moves the artifacts between two buckets, keeping their
+// paths so that the layout of jobs and builds stays the same.
+type migration struct {
+	storage     migrationStorage
+	from, to    string
+	prefixes    []string
+	move        bool
+	concurrency int
+	confirm     bool
+	out         io.Writer
+
+	lock            sync.Mutex
+	copied, skipped int
+	bytes           int64
+	failed          []string
+}
+
+func (m *migration) run(ctx context.Context) error {
+	prefixes := m.prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	objects := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < m.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range objects {
+				if err := m.migrate(ctx, object); err != nil {
+					logrus.WithError(err).WithField("object", object).Warn("Failed to migrate an artifact.")
+					m.lock.Lock()
+					m.failed = append(m.failed, object)
+					m.lock.Unlock()
+				}
+			}
+		}()
+	}
+	var listErr error
+	for _, prefix := range prefixes {
+		if listErr = m.list(ctx, prefix, objects); listErr != nil {
+			break
+		}
+	}
+	close(objects)
+	wg.Wait()
+
+	verb := "copied"
+	if m.move {
+		verb = "moved"
+	}
+	if !m.confirm {
+		fmt.Fprintf(m.out, "%d artifacts (%d bytes) would be %s from %s to %s, %d already exist there.\n", m.copied, m.bytes, verb, m.from, m.to, m.skipped)
+		fmt.Fprintln(m.out, "Run again with --confirm to migrate them.")
+	} else {
+		fmt.Fprintf(m.out, "%s %d artifacts (%d bytes) from %s to %s, %d already existed there.\n", strings.ToUpper(verb[:1])+verb[1:], m.copied, m.bytes, m.from, m.to, m.skipped)
+	}
+	if listErr != nil {
+		return listErr
+	}
+	if len(m.failed) > 0 {
+		return fmt.Errorf("failed to migrate %d artifacts, run again to retry them: %s", len(m.failed), strings.Join(m.failed, ", "))
+	}
+	return nil
+}
+
+// list sends the objects under the prefix, relative to the bucket.
+func (m *migration) list(ctx context.Context, prefix string, objects chan<- string) error {
+	it, err := m.storage.Iterator(ctx, m.from+"/"+prefix, "")
+	if err != nil {
+		return fmt.Errorf("failed to list %s/%s: %w", m.from, prefix, err)
+	}
+	for {
+		attrs, err := it.Next(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s/%s: %w", m.from, prefix, err)
+		}
+		if attrs.IsDir {
+			continue
+		}
+		select {
+		case objects <- attrs.Name:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// migrate copies the object unless it already exists in the destination,
+// which it then only can if a previous migration copied it completely, as
+// objects only become visible once they are written.
+func (m *migration) migrate(ctx context.Context, object string) error {
+	src, dst := m.from+"/"+object, m.to+"/"+object
+	attrs, err := m.storage.Attributes(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to get the attributes: %w", err)
+	}
+	exists := true
+	if _, err := m.storage.Attributes(ctx, dst); pkgio.IsNotExist(err) {
+		exists = false
+	} else if err != nil {
+		return fmt.Errorf("failed to check whether %s exists: %w", dst, err)
+	}
+
+	m.lock.Lock()
+	if exists {
+		m.skipped++
+	} else {
+		m.copied++
+		m.bytes += attrs.Size
+	}
+	m.lock.Unlock()
+	if !m.confirm {
+		return nil
+	}
+	if !exists {
+		if err := m.copy(ctx, src, dst, attrs); err != nil {
+			return err
+		}
+	}
+	if m.move {
+		if err := m.storage.Delete(ctx, src); err != nil {
+			return fmt.Errorf("failed to delete the source: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *migration) copy(ctx context.Context, src, dst string, attrs pkgio.Attributes) (err error) {
+	reader, err := m.storage.Reader(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to open the source: %w", err)
+	}
+	defer reader.Close()
+	opts := pkgio.WriterOptions{Metadata: attrs.Metadata}
+	if attrs.ContentType != "" {
+		opts.ContentType = &attrs.ContentType
+	}
+	if attrs.ContentEncoding != "" {
+		opts.ContentEncoding = &attrs.ContentEncoding
+	}
+	writer, err := m.storage.Writer(ctx, dst, opts)
+	if err != nil {
+		return fmt.Errorf("failed to open the destination: %w", err)
+	}
+	var w io.Writer = writer
+	var gzipWriter *gzip.Writer
+	if attrs.ContentEncoding == "gzip" && strings.HasPrefix(src, providers.GS+"://") {
+		// GCS transparently decompresses gzip encoded objects when they are
+		// read, so they need to be compressed again.
+		gzipWriter = gzip.NewWriter(writer)
+		w = gzipWriter
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			writer.Close()
+			return fmt.Errorf("failed to compress: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write the destination: %w", err)
+	}
+	return nil
+}
+
+// writeBucketAlias adds the alias of the bucket migrated from for the bucket
+// migrated to to the deck.spyglass.bucket_aliases of the YAML file.
+func writeBucketAlias(path, from, to string, out io.Writer) error {
+	fromProvider, fromBucket, _, _ := providers.ParseStoragePath(from)
+	toProvider, toBucket, _, _ := providers.ParseStoragePath(to)
+	if fromProvider != toProvider {
+		fmt.Fprintf(out, "Deck can't alias buckets of different storage providers, so no alias was written to %s.\n", path)
+		return nil
+	}
+	type aliases struct {
+		Deck struct {
+			Spyglass struct {
+				BucketAliases map[string]string `json:"bucket_aliases"`
+			} `json:"spyglass"`
+		} `json:"deck"`
+	}
+	var a aliases
+	if raw, err := os.ReadFile(path); err == nil {
+		if err := yaml.Unmarshal(raw, &a); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if a.Deck.Spyglass.BucketAliases == nil {
+		a.Deck.Spyglass.BucketAliases = map[string]string{}
+	}
+	a.Deck.Spyglass.BucketAliases[fromBucket] = toBucket
+	// Aliases of buckets migrated earlier point to the new bucket now.
+	for alias, bucket := range a.Deck.Spyglass.BucketAliases {
+		if bucket == fromBucket {
+			a.Deck.Spyglass.BucketAliases[alias] = toBucket
+		}
+	}
+	raw, err := yaml.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the bucket aliases: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(out, "Added the alias %s for %s to %s. Merge it into the deck.spyglass.bucket_aliases of the Prow config.\n", fromBucket, toBucket, path)
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+type fakeObject struct {
+	content string
+	attrs   pkgio.Attributes
+}
+
+// fakeStorage stores the objects by their full paths, like s3://bucket/path.
+type fakeStorage struct {
+	lock    sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeIterator []pkgio.ObjectAttributes
+
+func (f *fakeIterator) Next(_ context.Context) (pkgio.ObjectAttributes, error) {
+	if len(*f) == 0 {
+		return pkgio.ObjectAttributes{}, io.EOF
+	}
+	next := (*f)[0]
+	*f = (*f)[1:]
+	return next, nil
+}
+
+func (f *fakeStorage) Iterator(_ context.Context, prefix, _ string) (pkgio.ObjectIterator, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	bucket := prefix[:strings.Index(prefix[len("s3://"):], "/")+len("s3://")]
+	var it fakeIterator
+	for path := range f.objects {
+		if strings.HasPrefix(path, prefix) {
+			it = append(it, pkgio.ObjectAttributes{Name: strings.TrimPrefix(path, bucket+"/")})
+		}
+	}
+	sort.Slice(it, func(i, j int) bool { return it[i].Name < it[j].Name })
+	return &it, nil
+}
+
+func (f *fakeStorage) Reader(_ context.Context, path string) (io.ReadCloser, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	object, ok := f.objects[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(object.content)), nil
+}
+
+type fakeWriter struct {
+	bytes.Buffer
+	storage *fakeStorage
+	path    string
+	attrs   pkgio.Attributes
+}
+
+func (w *fakeWriter) Close() error {
+	w.storage.lock.Lock()
+	defer w.storage.lock.Unlock()
+	w.attrs.Size = int64(w.Len())
+	w.storage.objects[w.path] = fakeObject{content: w.String(), attrs: w.attrs}
+	return nil
+}
+
+func (f *fakeStorage) Writer(_ context.Context, path string, opts ...pkgio.WriterOptions) (io.WriteCloser, error) {
+	var o pkgio.WriterOptions
+	for _, opt := range opts {
+		opt.Apply(&o)
+	}
+	w := &fakeWriter{storage: f, path: path, attrs: pkgio.Attributes{Metadata: o.Metadata}}
+	if o.ContentType != nil {
+		w.attrs.ContentType = *o.ContentType
+	}
+	if o.ContentEncoding != nil {
+		w.attrs.ContentEncoding = *o.ContentEncoding
+	}
+	return w, nil
+}
+
+func (f *fakeStorage) Attributes(_ context.Context, path string) (pkgio.Attributes, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	object, ok := f.objects[path]
+	if !ok {
+		return pkgio.Attributes{}, os.ErrNotExist
+	}
+	return object.attrs, nil
+}
+
+func (f *fakeStorage) Delete(_ context.Context, path string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.objects, path)
+	return nil
+}
+
+func object(content, contentType string) fakeObject {
+	return fakeObject{content: content, attrs: pkgio.Attributes{ContentType: contentType, Size: int64(len(content))}}
+}
+
+func TestMigrate(t *testing.T) {
+	existing := func() map[string]fakeObject {
+		return map[string]fakeObject{
+			"s3://old/logs/ci-foo/1/build-log.txt":       object("log", "text/plain"),
+			"s3://old/logs/ci-foo/1/finished.json":       object("{}", "application/json"),
+			"s3://old/pr-logs/pull/1/foo/2/started.json": object("{}", "application/json"),
+			"s3://old/tmp/cache":                         object("cache", ""),
+			"s3://new/logs/ci-foo/1/finished.json":       object("{}", "application/json"),
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		prefixes []string
+		move     bool
+		confirm  bool
+		expected []string
+		output   string
+	}{
+		{
+			name:     "without --confirm nothing is migrated",
+			prefixes: []string{"logs/", "pr-logs/"},
+			move:     true,
+			expected: []string{
+				"s3://new/logs/ci-foo/1/finished.json",
+				"s3://old/logs/ci-foo/1/build-log.txt",
+				"s3://old/logs/ci-foo/1/finished.json",
+				"s3://old/pr-logs/pull/1/foo/2/started.json",
+				"s3://old/tmp/cache",
+			},
+			output: "2 artifacts (5 bytes) would be moved from s3://old to s3://new, 1 already exist there.\nRun again with --confirm to migrate them.\n",
+		},
+		{
+			name:     "artifacts under the prefixes are copied",
+			prefixes: []string{"logs/", "pr-logs/"},
+			confirm:  true,
+			expected: []string{
+				"s3://new/logs/ci-foo/1/build-log.txt",
+				"s3://new/logs/ci-foo/1/finished.json",
+				"s3://new/pr-logs/pull/1/foo/2/started.json",
+				"s3://old/logs/ci-foo/1/build-log.txt",
+				"s3://old/logs/ci-foo/1/finished.json",
+				"s3://old/pr-logs/pull/1/foo/2/started.json",
+				"s3://old/tmp/cache",
+			},
+			output: "Copied 2 artifacts (5 bytes) from s3://old to s3://new, 1 already existed there.\n",
+		},
+		{
+			name:    "all artifacts are moved",
+			move:    true,
+			confirm: true,
+			expected: []string{
+				"s3://new/logs/ci-foo/1/build-log.txt",
+				"s3://new/logs/ci-foo/1/finished.json",
+				"s3://new/pr-logs/pull/1/foo/2/started.json",
+				"s3://new/tmp/cache",
+			},
+			output: "Moved 3 artifacts (10 bytes) from s3://old to s3://new, 1 already existed there.\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &fakeStorage{objects: existing()}
+			out := &bytes.Buffer{}
+			m := &migration{
+				storage:     storage,
+				from:        "s3://old",
+				to:          "s3://new",
+				prefixes:    tc.prefixes,
+				move:        tc.move,
+				concurrency: 2,
+				confirm:     tc.confirm,
+				out:         out,
+			}
+			if err := m.run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual []string
+			for path := range storage.objects {
+				actual = append(actual, path)
+			}
+			sort.Strings(actual)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected objects (-want +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.output, out.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got): %s", diff)
+			}
+			if copied, ok := storage.objects["s3://new/logs/ci-foo/1/build-log.txt"]; ok {
+				if diff := cmp.Diff(object("log", "text/plain"), copied, cmp.AllowUnexported(fakeObject{})); diff != "" {
+					t.Errorf("the copy differs from the original (-want +got): %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBucketAlias(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing string
+		from, to string
+		expected string
+	}{
+		{
+			name:     "new file",
+			from:     "gs://old",
+			to:       "gs://new",
+			expected: "deck:\n  spyglass:\n    bucket_aliases:\n      old: new\n",
+		},
+		{
+			name:     "aliases of earlier migrations are updated",
+			existing: "deck:\n  spyglass:\n    bucket_aliases:\n      older: old\n",
+			from:     "gs://old",
+			to:       "gs://new",
+			expected: "deck:\n  spyglass:\n    bucket_aliases:\n      old: new\n      older: new\n",
+		},
+		{
+			name:     "buckets of different providers can't be aliased",
+			existing: "deck: {}\n",
+			from:     "gs://old",
+			to:       "s3://new",
+			expected: "deck: {}\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "aliases.yaml")
+			if tc.existing != "" {
+				if err := os.WriteFile(path, []byte(tc.existing), 0644); err != nil {
+					t.Fatalf("failed to write the file: %v", err)
+				}
+			}
+			if err := writeBucketAlias(path, tc.from, tc.to, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read the file: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(actual)); diff != "" {
+				t.Errorf("unexpected file (-want +got): %s", diff)
+			}
+		})
+	}
+}
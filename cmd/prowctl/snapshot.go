@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/configsnapshot"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const (
+	listCommand     = "list"
+	diffCommand     = "diff"
+	rollbackCommand = "rollback"
+)
+
+type options struct {
+	command string
+	args    []string
+
+	snapshotPath string
+	storage      prowflagutil.StorageClientOptions
+
+	// config and pluginsConfig are the live config diff compares against.
+	config        configflagutil.ConfigOptions
+	pluginsConfig pluginsflagutil.PluginOptions
+
+	kubernetes       prowflagutil.KubernetesOptions
+	namespace        string
+	configMap        string
+	configKey        string
+	pluginsConfigMap string
+	pluginsKey       string
+	clearConfigMaps  prowflagutil.Strings
+	confirm          bool
+}
+
+func gatherOptions(fs *flag.FlagSet, command string, args ...string) (options, error) {
+	o := options{command: command}
+	fs.StringVar(&o.snapshotPath, "snapshot-path", "", "The gs:// or s3:// path config-snapshotter keeps the snapshots under.")
+	o.storage.AddFlags(fs)
+	switch command {
+	case listCommand:
+	case diffCommand:
+		o.config.AddFlags(fs)
+		o.pluginsConfig.AddFlags(fs)
+	case rollbackCommand:
+		o.kubernetes.AddFlags(fs)
+		fs.StringVar(&o.namespace, "namespace", "default", "Namespace of the ConfigMaps.")
+		fs.StringVar(&o.configMap, "config-configmap", "config", "Name of the ConfigMap holding the Prow config.")
+		fs.StringVar(&o.configKey, "config-key", configsnapshot.ConfigFile, "Key of the Prow config in its ConfigMap.")
+		fs.StringVar(&o.pluginsConfigMap, "plugins-configmap", "plugins", "Name of the ConfigMap holding the plugins config.")
+		fs.StringVar(&o.pluginsKey, "plugins-key", configsnapshot.PluginsFile, "Key of the plugins config in its ConfigMap.")
+		fs.Var(&o.clearConfigMaps, "clear-configmap", "Name of a ConfigMap to empty, like those of the job configs and supplemental configs that the merged config of the snapshot already holds. Can be passed multiple times.")
+		fs.BoolVar(&o.confirm, "confirm", false, "Update the ConfigMaps. Without it, only the changes are shown.")
+	default:
+		return o, fmt.Errorf("unknown command %q, must be one of %s, %s and %s", command, listCommand, diffCommand, rollbackCommand)
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	o.args = fs.Args()
+	return o, nil
+}
+
+func (o *options) Validate() error {
+	if err := configsnapshot.ValidatePath(o.snapshotPath); err != nil {
+		return fmt.Errorf("--snapshot-path: %w", err)
+	}
+	if err := o.storage.Validate(false); err != nil {
+		return err
+	}
+	switch o.command {
+	case listCommand:
+		if len(o.args) != 0 {
+			return fmt.Errorf("%s takes no arguments", listCommand)
+		}
+	case diffCommand:
+		if len(o.args) < 1 || len(o.args) > 2 {
+			return fmt.Errorf("%s takes one or two snapshot IDs", diffCommand)
+		}
+		if err := o.config.ValidateConfigOptional(); err != nil {
+			return err
+		}
+	case rollbackCommand:
+		if len(o.args) != 1 {
+			return fmt.Errorf("%s takes one snapshot ID", rollbackCommand)
+		}
+		if err := o.kubernetes.Validate(false); err != nil {
+			return err
+		}
+		if o.configMap == "" || o.configKey == "" {
+			return errors.New("--config-configmap and --config-key must be set")
+		}
+	}
+	return nil
+}
+
+func (o *options) run(ctx context.Context, out io.Writer) error {
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create opener: %w", err)
+	}
+	store := configsnapshot.NewStore(opener, o.snapshotPath)
+	switch o.command {
+	case listCommand:
+		return list(ctx, store, out)
+	case diffCommand:
+		return o.diff(ctx, store, out)
+	default:
+		snapshot, err := store.Get(ctx, o.args[0])
+		if err != nil {
+			return err
+		}
+		client, err := o.kubernetes.InfrastructureClusterClient(false)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		return o.rollback(ctx, client.CoreV1().ConfigMaps(o.namespace), snapshot, out)
+	}
+}
+
+func list(ctx context.Context, store *configsnapshot.Store, out io.Writer) error {
+	snapshots, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tVERSION\tRESTORABLE")
+	for _, snapshot := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", snapshot.ID, snapshot.Created.Format(time.RFC3339), snapshot.ConfigVersion, snapshot.Restorable)
+	}
+	return w.Flush()
+}
+
+func (o *options) diff(ctx context.Context, store *configsnapshot.Store, out io.Writer) error {
+	from, err := store.Get(ctx, o.args[0])
+	if err != nil {
+		return err
+	}
+	var to *configsnapshot.Snapshot
+	switch {
+	case len(o.args) == 2:
+		to, err = store.Get(ctx, o.args[1])
+	case o.config.ConfigPath != "":
+		to, err = o.live()
+	default:
+		var latest *configsnapshot.Metadata
+		if latest, err = store.Latest(ctx); err == nil {
+			if latest == nil {
+				return errors.New("there are no snapshots")
+			}
+			to, err = store.Get(ctx, latest.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	diff, err := configsnapshot.Diff(from, to)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, diff)
+	return err
+}
+
+// live snapshots the config of --config-path and --plugin-config.
+func (o *options) live() (*configsnapshot.Snapshot, error) {
+	cfg, err := config.Load(o.config.ConfigPath, o.config.JobConfigPath, o.config.SupplementalProwConfigDirs.Strings(), o.config.SupplementalProwConfigsFileNameSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the config: %w", err)
+	}
+	var pluginsCfg *plugins.Configuration
+	if o.pluginsConfig.PluginConfigPath != "" {
+		agent := &plugins.ConfigAgent{}
+		if err := agent.Load(o.pluginsConfig.PluginConfigPath, o.pluginsConfig.SupplementalPluginsConfigDirs.Strings(), o.pluginsConfig.SupplementalPluginsConfigsFileNameSuffix, false, false); err != nil {
+			return nil, fmt.Errorf("failed to load the plugins config: %w", err)
+		}
+		pluginsCfg = agent.Config()
+	}
+	snapshot, err := configsnapshot.Take(cfg, pluginsCfg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ID = "live"
+	return snapshot, nil
+}
+
+// configMapUpdate sets the key of a ConfigMap, or empties the ConfigMap if
+// the key is unset.
+type configMapUpdate struct {
+	name    string
+	key     string
+	content []byte
+}
+
+// rollback shows how the ConfigMaps change to restore the snapshot, and
+// updates them if --confirm is set.
+func (o *options) rollback(ctx context.Context, client corev1.ConfigMapInterface, snapshot *configsnapshot.Snapshot, out io.Writer) error {
+	if !snapshot.Restorable {
+		return fmt.Errorf("snapshot %s can't be restored: %s", snapshot.ID, snapshot.RestoreError)
+	}
+	updates := []configMapUpdate{{name: o.configMap, key: o.configKey, content: snapshot.Config}}
+	if snapshot.Plugins != nil && o.pluginsConfigMap != "" {
+		updates = append(updates, configMapUpdate{name: o.pluginsConfigMap, key: o.pluginsKey, content: snapshot.Plugins})
+	}
+	for _, name := range o.clearConfigMaps.Strings() {
+		updates = append(updates, configMapUpdate{name: name})
+	}
+
+	for _, update := range updates {
+		cm, err := client.Get(ctx, update.name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get ConfigMap %s/%s: %w", o.namespace, update.name, err)
+		}
+		before, after := map[string]string{}, map[string]string{}
+		for key, value := range cm.Data {
+			before[key] = value
+			after[key] = value
+		}
+		for key, value := range cm.BinaryData {
+			before[key] = string(value)
+			after[key] = string(value)
+		}
+		if update.key == "" {
+			after = map[string]string{}
+			cm.Data, cm.BinaryData = nil, nil
+		} else {
+			after[update.key] = string(update.content)
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+			cm.Data[update.key] = string(update.content)
+			delete(cm.BinaryData, update.key)
+		}
+		changed, err := diffConfigMap(out, o.namespace+"/"+update.name, before, after)
+		if err != nil {
+			return err
+		}
+		if !changed || !o.confirm {
+			continue
+		}
+		if _, err := client.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update ConfigMap %s/%s: %w", o.namespace, update.name, err)
+		}
+		fmt.Fprintf(out, "Updated ConfigMap %s/%s.\n", o.namespace, update.name)
+	}
+	if !o.confirm {
+		fmt.Fprintf(out, "Run again with --confirm to roll back to snapshot %s.\n", snapshot.ID)
+	}
+	return nil
+}
+
+// diffConfigMap writes the unified diff of the keys of the ConfigMap and
+// returns whether any changed.
+func diffConfigMap(out io.Writer, name string, before, after map[string]string) (bool, error) {
+	keys := sets.KeySet(before).Union(sets.KeySet(after))
+	var changed bool
+	for _, key := range sets.List(keys) {
+		if before[key] == after[key] {
+			continue
+		}
+		changed = true
+		diff, err := configsnapshot.UnifiedDiff(name+"/"+key, name+"/"+key, []byte(before[key]), []byte(after[key]))
+		if err != nil {
+			return false, fmt.Errorf("failed to diff %s/%s: %w", name, key, err)
+		}
+		if _, err := io.WriteString(out, diff); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
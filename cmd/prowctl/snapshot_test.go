@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/prow/pkg/configsnapshot"
+)
+
+func TestOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		command     string
+		args        []string
+		expectedErr bool
+	}{
+		{
+			name:    "list",
+			command: "list",
+			args:    []string{"--snapshot-path=gs://bucket/snapshots"},
+		},
+		{
+			name:        "unknown command",
+			command:     "restore",
+			args:        []string{"--snapshot-path=gs://bucket/snapshots"},
+			expectedErr: true,
+		},
+		{
+			name:        "snapshot path is required",
+			command:     "list",
+			expectedErr: true,
+		},
+		{
+			name:    "diff against the live config",
+			command: "diff",
+			args:    []string{"--snapshot-path=gs://bucket/snapshots", "--config-path=/etc/config/config.yaml", "20240601-120000"},
+		},
+		{
+			name:        "diff without snapshot",
+			command:     "diff",
+			args:        []string{"--snapshot-path=gs://bucket/snapshots"},
+			expectedErr: true,
+		},
+		{
+			name:    "rollback",
+			command: "rollback",
+			args:    []string{"--snapshot-path=s3://bucket/snapshots", "--clear-configmap=job-config", "--confirm", "20240601-120000"},
+		},
+		{
+			name:        "rollback to two snapshots",
+			command:     "rollback",
+			args:        []string{"--snapshot-path=gs://bucket/snapshots", "20240601-120000", "20240601-130000"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := gatherOptions(flag.NewFlagSet("prowctl", flag.ContinueOnError), tc.command, tc.args...)
+			if err == nil {
+				err = o.Validate()
+			}
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	snapshot := &configsnapshot.Snapshot{
+		Metadata: configsnapshot.Metadata{ID: "20240601-120000", Restorable: true},
+		Config:   []byte("prowjob_namespace: prow\n"),
+		Plugins:  []byte("plugins: {}\n"),
+	}
+	configMaps := func() []corev1.ConfigMap {
+		return []corev1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"}, Data: map[string]string{"config.yaml": "prowjob_namespace: broken\n"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "job-config", Namespace: "default"}, Data: map[string]string{"jobs.yaml": "periodics: []\n"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "plugins", Namespace: "default"}, BinaryData: map[string][]byte{"plugins.yaml": []byte("gzipped")}},
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		snapshot           *configsnapshot.Snapshot
+		confirm            bool
+		expectedConfigMaps []corev1.ConfigMap
+		expectedOutput     []string
+		expectedErr        string
+	}{
+		{
+			name:               "without confirm only the changes are shown",
+			snapshot:           snapshot,
+			expectedConfigMaps: configMaps(),
+			expectedOutput: []string{
+				"-prowjob_namespace: broken\n+prowjob_namespace: prow\n",
+				"--- default/plugins/plugins.yaml",
+				"-periodics: []\n",
+				"Run again with --confirm to roll back to snapshot 20240601-120000.",
+			},
+		},
+		{
+			name:     "rollback",
+			snapshot: snapshot,
+			confirm:  true,
+			expectedConfigMaps: []corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"}, Data: map[string]string{"config.yaml": "prowjob_namespace: prow\n"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "job-config", Namespace: "default"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "plugins", Namespace: "default"}, Data: map[string]string{"plugins.yaml": "plugins: {}\n"}, BinaryData: map[string][]byte{}},
+			},
+			expectedOutput: []string{
+				"Updated ConfigMap default/config.",
+				"Updated ConfigMap default/plugins.",
+				"Updated ConfigMap default/job-config.",
+			},
+		},
+		{
+			name:               "snapshot isn't restorable",
+			snapshot:           &configsnapshot.Snapshot{Metadata: configsnapshot.Metadata{ID: "20240601-120000", RestoreError: "config: invalid"}},
+			confirm:            true,
+			expectedConfigMaps: configMaps(),
+			expectedErr:        "snapshot 20240601-120000 can't be restored: config: invalid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewSimpleClientset()
+			for _, cm := range configMaps() {
+				if _, err := client.CoreV1().ConfigMaps("default").Create(ctx, &cm, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			o := &options{
+				namespace:        "default",
+				configMap:        "config",
+				configKey:        "config.yaml",
+				pluginsConfigMap: "plugins",
+				pluginsKey:       "plugins.yaml",
+				confirm:          tc.confirm,
+			}
+			o.clearConfigMaps.Set("job-config")
+
+			var out strings.Builder
+			err := o.rollback(ctx, client.CoreV1().ConfigMaps("default"), tc.snapshot, &out)
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			for _, expected := range tc.expectedOutput {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+			actual, err := client.CoreV1().ConfigMaps("default").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectedConfigMaps, actual.Items); diff != "" {
+				t.Errorf("unexpected ConfigMaps (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// cleaner garbage-collects one kind of the objects besides pods that are
+// created for ProwJobs in the build clusters.
+type cleaner struct {
+	// newList returns an empty list of the objects of the kind.
+	newList func() ctrlruntimeclient.ObjectList
+}
+
+// cleaners are the cleaners of the sinker.resources of the config.
+var cleaners = map[string]cleaner{
+	"pipelineruns": {newList: func() ctrlruntimeclient.ObjectList {
+		// The PipelineRuns are listed unstructured so that sinker works with
+		// build clusters that don't have Tekton installed.
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "tekton.dev", Version: "v1", Kind: "PipelineRunList"})
+		return list
+	}},
+	"configmaps": {newList: func() ctrlruntimeclient.ObjectList { return &corev1api.ConfigMapList{} }},
+	"secrets":    {newList: func() ctrlruntimeclient.ObjectList { return &corev1api.SecretList{} }},
+}
+
+// resourceReason keys the metrics of the cleaned objects.
+type resourceReason struct {
+	resource, reason string
+}
+
+// cleanResources deletes the objects of the sinker.resources in the cluster
+// when the pods of their ProwJobs would be deleted: once they are older than
+// max_pod_age or their ProwJob finished longer than terminated_pod_ttl ago,
+// and as soon as their ProwJob is gone. Objects of ProwJobs that are still
+// running are kept, as their agent would create them again.
+func (c *controller) cleanResources(log *logrus.Entry, client ctrlruntimeclient.Client, pjMap map[string]*prowapi.ProwJob, isFinished sets.Set[string], m *sinkerReconciliationMetrics) {
+	maxAge := c.config().Sinker.MaxPodAge.Duration
+	terminatedTTL := c.config().Sinker.TerminatedPodTTL.Duration
+	for _, resource := range c.config().Sinker.Resources {
+		cleaner, ok := cleaners[resource]
+		if !ok {
+			continue
+		}
+		log := log.WithField("resource", resource)
+		list := cleaner.newList()
+		if err := client.List(c.ctx, list, ctrlruntimeclient.MatchingLabels{kube.CreatedByProw: "true"}, ctrlruntimeclient.InNamespace(c.config().PodNamespace)); err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Debug("The cluster doesn't serve the resource.")
+			} else {
+				log.WithError(err).Error("Error listing objects.")
+			}
+			continue
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			log.WithError(err).Error("Error extracting objects.")
+			continue
+		}
+		for _, o := range objects {
+			obj, ok := o.(ctrlruntimeclient.Object)
+			if !ok {
+				continue
+			}
+			// Only objects that tell their ProwJob are cleaned, their names
+			// don't need to match it like those of pods.
+			prowJobName, ok := obj.GetLabels()[kube.ProwJobIDLabel]
+			if !ok {
+				continue
+			}
+			log := log.WithFields(logrus.Fields{"pj": prowJobName, "name": obj.GetName()})
+
+			var reason string
+			terminationTime := time.Time{}
+			if pj, ok := pjMap[prowJobName]; ok && pj.Complete() {
+				terminationTime = pj.Status.CompletionTime.Time
+			}
+			switch {
+			case time.Since(obj.GetCreationTimestamp().Time) > maxAge:
+				reason = reasonPodAged
+			case !terminationTime.IsZero() && time.Since(terminationTime) > terminatedTTL:
+				reason = reasonPodTTLed
+			}
+			if !isFinished.Has(prowJobName) {
+				reason = ""
+			}
+			if c.isOrphaned(log, obj, prowJobName) {
+				reason = reasonPodOrphaned
+			}
+			if reason == "" {
+				continue
+			}
+
+			if err := client.Delete(c.ctx, obj); err == nil {
+				log.WithField("reason", reason).Info("Deleted object of a ProwJob.")
+				m.resourcesRemoved[resourceReason{resource, reason}]++
+			} else if k8serrors.IsNotFound(err) {
+				log.WithError(err).Info("Could not delete missing object.")
+			} else {
+				log.WithError(err).Error("Error deleting object.")
+				m.resourceRemovalErrors[resourceReason{resource, string(k8serrors.ReasonForError(err))}]++
+			}
+		}
+	}
+}
@@ -256,6 +256,8 @@ type sinkerReconciliationMetrics struct {
 	prowJobsCreated        int
 	prowJobsCleaned        map[string]int
 	prowJobsCleaningErrors map[string]int
+	resourcesRemoved       map[resourceReason]int
+	resourceRemovalErrors  map[resourceReason]int
 }
 
 // Prometheus Metrics
@@ -269,6 +271,8 @@ var (
 		prowJobsCleaned        *prometheus.GaugeVec
 		prowJobsCleaningErrors *prometheus.GaugeVec
 		jobConfigMapSize       *prometheus.GaugeVec
+		resourcesRemoved       *prometheus.GaugeVec
+		resourceRemovalErrors  *prometheus.GaugeVec
 	}{
 		podsCreated: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "sinker_pods_existing",
@@ -312,6 +316,20 @@ var (
 		}, []string{
 			"name",
 		}),
+		resourcesRemoved: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sinker_resources_removed",
+			Help: "Number of objects besides pods removed in each sinker cleaning.",
+		}, []string{
+			"resource",
+			"reason",
+		}),
+		resourceRemovalErrors: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sinker_resource_removal_errors",
+			Help: "Number of errors which occurred in each sinker cleaning of objects besides pods.",
+		}, []string{
+			"resource",
+			"reason",
+		}),
 	}
 )
 
@@ -324,6 +342,8 @@ func init() {
 	prometheus.MustRegister(sinkerMetrics.prowJobsCleaned)
 	prometheus.MustRegister(sinkerMetrics.prowJobsCleaningErrors)
 	prometheus.MustRegister(sinkerMetrics.jobConfigMapSize)
+	prometheus.MustRegister(sinkerMetrics.resourcesRemoved)
+	prometheus.MustRegister(sinkerMetrics.resourceRemovalErrors)
 }
 
 func (m *sinkerReconciliationMetrics) getTimeUsed() time.Duration {
@@ -337,7 +357,9 @@ func (c *controller) clean() {
 		podsRemoved:            map[string]int{},
 		podRemovalErrors:       map[string]int{},
 		prowJobsCleaned:        map[string]int{},
-		prowJobsCleaningErrors: map[string]int{}}
+		prowJobsCleaningErrors: map[string]int{},
+		resourcesRemoved:       map[resourceReason]int{},
+		resourceRemovalErrors:  map[resourceReason]int{}}
 
 	// Clean up old prow jobs first.
 	prowJobs := &prowapi.ProwJobList{}
@@ -471,7 +493,7 @@ func (c *controller) clean() {
 				clean = false
 			}
 
-			if c.isPodOrphaned(log, &pod, podJobName) {
+			if c.isOrphaned(log, &pod, podJobName) {
 				// prowjob has gone, we want to clean orphan pods regardless of the state
 				reason = reasonPodOrphaned
 				clean = true
@@ -481,8 +503,17 @@ func (c *controller) clean() {
 				continue
 			}
 
+			if lease, ok := kube.PodLeaseOf(&pod); ok && lease.Active(time.Now()) {
+				// Plank is updating the job based on the pod, deleting it now
+				// might leave the job without a pod.
+				log.WithFields(logrus.Fields{"pod": pod.Name, "holder": lease.Holder}).Debug("Pod is leased, not deleting it.")
+				continue
+			}
+
 			c.deletePod(log, &pod, reason, client, &metrics)
 		}
+
+		c.cleanResources(c.logger.WithField("cluster", cluster), client, pjMap, isFinished, &metrics)
 	}
 
 	metrics.finishedAt = time.Now()
@@ -501,6 +532,12 @@ func (c *controller) clean() {
 	for k, v := range metrics.prowJobsCleaningErrors {
 		sinkerMetrics.prowJobsCleaningErrors.WithLabelValues(k).Set(float64(v))
 	}
+	for k, v := range metrics.resourcesRemoved {
+		sinkerMetrics.resourcesRemoved.WithLabelValues(k.resource, k.reason).Set(float64(v))
+	}
+	for k, v := range metrics.resourceRemovalErrors {
+		sinkerMetrics.resourceRemovalErrors.WithLabelValues(k.resource, k.reason).Set(float64(v))
+	}
 	c.logger.Info("Sinker reconciliation complete.")
 }
 
@@ -518,24 +555,34 @@ func (c *controller) cleanupKubernetesFinalizer(pod *corev1api.Pod, client ctrlr
 
 func (c *controller) deletePod(log *logrus.Entry, pod *corev1api.Pod, reason string, client ctrlruntimeclient.Client, m *sinkerReconciliationMetrics) {
 	name := pod.Name
+	var opts []ctrlruntimeclient.DeleteOption
+	if pod.ResourceVersion != "" {
+		// Plank leases the pod while it updates the job, which changes the
+		// pod, so the pod is only deleted if it wasn't leased since it was
+		// listed.
+		opts = append(opts, ctrlruntimeclient.Preconditions{ResourceVersion: &pod.ResourceVersion})
+	}
 	// Delete old finished or orphan pods. Don't quit if we fail to delete one.
-	if err := client.Delete(c.ctx, pod); err == nil {
+	if err := client.Delete(c.ctx, pod, opts...); err == nil {
 		log.WithFields(logrus.Fields{"pod": name, "reason": reason}).Info("Deleted old completed pod.")
 		m.podsRemoved[reason]++
 	} else {
 		m.podRemovalErrors[string(k8serrors.ReasonForError(err))]++
 		if k8serrors.IsNotFound(err) {
 			log.WithField("pod", name).WithError(err).Info("Could not delete missing pod.")
+		} else if k8serrors.IsConflict(err) {
+			log.WithField("pod", name).WithError(err).Info("Pod changed since it was listed, retrying in the next sync.")
 		} else {
 			log.WithField("pod", name).WithError(err).Error("Error deleting pod.")
 		}
 	}
 }
 
-func (c *controller) isPodOrphaned(log *logrus.Entry, pod *corev1api.Pod, prowJobName string) bool {
+func (c *controller) isOrphaned(log *logrus.Entry, obj metav1.Object, prowJobName string) bool {
 	// ProwJobs are cached and the cache may lag a bit behind, so never considers
-	// pods that are less than 30 seconds old as orphaned
-	if !pod.CreationTimestamp.Before(&metav1.Time{Time: time.Now().Add(-30 * time.Second)}) {
+	// objects that are less than 30 seconds old as orphaned
+	creationTimestamp := obj.GetCreationTimestamp()
+	if !creationTimestamp.Before(&metav1.Time{Time: time.Now().Add(-30 * time.Second)}) {
 		return false
 	}
 
@@ -32,6 +32,7 @@ import (
 	"github.com/sirupsen/logrus"
 	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -163,6 +164,40 @@ func TestClean(t *testing.T) {
 				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
 			},
 		},
+		&corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "job-complete-pod-leased",
+				Namespace: "ns",
+				Labels: map[string]string{
+					kube.CreatedByProw:  "true",
+					kube.ProwJobIDLabel: "job-complete",
+				},
+				Annotations: map[string]string{
+					kube.PodLeaseAnnotation: kube.PodLease{Holder: "plank", Expires: time.Now().Add(time.Minute)}.String(),
+				},
+			},
+			Status: corev1api.PodStatus{
+				Phase:     corev1api.PodSucceeded,
+				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
+			},
+		},
+		&corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "job-complete-pod-lease-expired",
+				Namespace: "ns",
+				Labels: map[string]string{
+					kube.CreatedByProw:  "true",
+					kube.ProwJobIDLabel: "job-complete",
+				},
+				Annotations: map[string]string{
+					kube.PodLeaseAnnotation: kube.PodLease{Holder: "plank", Expires: time.Now().Add(-time.Minute)}.String(),
+				},
+			},
+			Status: corev1api.PodStatus{
+				Phase:     corev1api.PodSucceeded,
+				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
+			},
+		},
 		&corev1api.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "job-complete-pod-pending",
@@ -472,6 +507,7 @@ func TestClean(t *testing.T) {
 	}
 	deletedPods := sets.New[string](
 		"job-complete-pod-failed",
+		"job-complete-pod-lease-expired",
 		"job-complete-pod-pending",
 		"job-complete-pod-succeeded",
 		"job-unknown-pod-failed",
@@ -976,6 +1012,41 @@ func TestDeletePodToleratesNotFound(t *testing.T) {
 	}
 }
 
+func TestDeletePodRequiresUnchangedPod(t *testing.T) {
+	m := &sinkerReconciliationMetrics{
+		podsRemoved:      map[string]int{},
+		podRemovalErrors: map[string]int{},
+	}
+	c := &controller{config: newFakeConfigAgent(newDefaultFakeSinkerConfig()).Config}
+	l := logrus.NewEntry(logrus.New())
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "existing",
+			Namespace: "ns",
+		},
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(pod).Build()
+	listed := &corev1api.Pod{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(pod), listed); err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	// Plank leases the pod after sinker listed it.
+	leased := listed.DeepCopy()
+	leased.Annotations = map[string]string{kube.PodLeaseAnnotation: kube.PodLease{Holder: "plank", Expires: time.Now().Add(time.Minute)}.String()}
+	if err := client.Update(context.Background(), leased); err != nil {
+		t.Fatalf("failed to lease pod: %v", err)
+	}
+
+	c.deletePod(l, listed, "reason", client, m)
+
+	if n := m.podRemovalErrors[string(metav1.StatusReasonConflict)]; n != 1 {
+		t.Errorf("Expected 1 conflict, got %v", m.podRemovalErrors)
+	}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(pod), &corev1api.Pod{}); err != nil {
+		t.Errorf("Expected the pod to be kept, got %v", err)
+	}
+}
+
 type podClientWrapper struct {
 	t *testing.T
 	ctrlruntimeclient.Client
@@ -1127,3 +1198,103 @@ func TestGetConfigMapDirs(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestCleanResources(t *testing.T) {
+	object := func(kind, name, prowJob string, age time.Duration) *unstructured.Unstructured {
+		o := &unstructured.Unstructured{}
+		o.SetAPIVersion("v1")
+		if kind == "PipelineRun" {
+			o.SetAPIVersion("tekton.dev/v1")
+		}
+		o.SetKind(kind)
+		o.SetName(name)
+		o.SetNamespace("ns")
+		o.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		labels := map[string]string{kube.CreatedByProw: "true"}
+		if prowJob != "" {
+			labels[kube.ProwJobIDLabel] = prowJob
+		}
+		o.SetLabels(labels)
+		return o
+	}
+	prowJobs := []runtime.Object{
+		&prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "finished-long-ago", Namespace: "ns"},
+			Status: prowv1.ProwJobStatus{
+				State:          prowv1.SuccessState,
+				StartTime:      metav1.NewTime(time.Now().Add(-time.Hour)),
+				CompletionTime: startTime(time.Now().Add(-terminatedPodTTL).Add(-time.Second)),
+			},
+		},
+		&prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "finished-recently", Namespace: "ns"},
+			Status: prowv1.ProwJobStatus{
+				State:          prowv1.SuccessState,
+				StartTime:      metav1.NewTime(time.Now().Add(-time.Hour)),
+				CompletionTime: startTime(time.Now().Add(-time.Second)),
+			},
+		},
+		&prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "ns"},
+			Status: prowv1.ProwJobStatus{
+				State:     prowv1.PendingState,
+				StartTime: metav1.NewTime(time.Now().Add(-maxPodAge).Add(-time.Hour)),
+			},
+		},
+	}
+	objects := []runtime.Object{
+		object("PipelineRun", "ttled", "finished-long-ago", time.Hour),
+		object("PipelineRun", "recent", "finished-recently", time.Hour),
+		object("PipelineRun", "running", "running", maxPodAge+time.Hour),
+		object("PipelineRun", "orphaned", "deleted", time.Hour),
+		object("PipelineRun", "unlabeled", "", maxPodAge+time.Hour),
+		object("Secret", "secret-ttled", "finished-long-ago", time.Hour),
+		object("ConfigMap", "configmap-ttled", "finished-long-ago", time.Hour),
+	}
+	testCases := []struct {
+		name      string
+		resources []string
+		expected  sets.Set[string]
+	}{
+		{
+			name:     "only pods are cleaned by default",
+			expected: sets.New[string](),
+		},
+		{
+			name:      "pipelineruns",
+			resources: []string{"pipelineruns"},
+			expected:  sets.New[string]("PipelineRun/ttled", "PipelineRun/orphaned"),
+		},
+		{
+			name:      "secrets and configmaps",
+			resources: []string{"secrets", "configmaps"},
+			expected:  sets.New[string]("Secret/secret-ttled", "ConfigMap/configmap-ttled"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			podClient := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(objects...).Build()
+			sinkerConfig := newDefaultFakeSinkerConfig()
+			sinkerConfig.Resources = tc.resources
+			c := controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("component", "sinker"),
+				prowJobClient: fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(prowJobs...).Build(),
+				podClients:    map[string]ctrlruntimeclient.Client{"default": podClient},
+				config:        newFakeConfigAgent(sinkerConfig).Config,
+			}
+			c.clean()
+
+			deleted := sets.New[string]()
+			for _, o := range objects {
+				obj := o.(*unstructured.Unstructured)
+				remaining := &unstructured.Unstructured{}
+				remaining.SetGroupVersionKind(obj.GroupVersionKind())
+				if err := podClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: obj.GetName()}, remaining); err != nil {
+					deleted.Insert(obj.GetKind() + "/" + obj.GetName())
+				}
+			}
+			assertSetsEqual(tc.expected, deleted, t, "did not delete correct objects")
+		})
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// testgrid-configurator generates TestGrid configuration from the testgrid-*
+// annotations of the jobs in the Prow config, validates it and keeps it in
+// sync at the location the TestGrid updater reads it from.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tgconf "github.com/GoogleCloudPlatform/testgrid/config"
+	"github.com/GoogleCloudPlatform/testgrid/config/yamlcfg"
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+	"sigs.k8s.io/prow/pkg/testgridconfig"
+)
+
+type options struct {
+	config          configflagutil.ConfigOptions
+	storage         prowflagutil.StorageClientOptions
+	instrumentation prowflagutil.InstrumentationOptions
+
+	// yamlPaths are TestGrid YAML files, or directories of them, with the
+	// dashboards and test groups jobs are added to.
+	yamlPaths   prowflagutil.Strings
+	defaultPath string
+	// output is where the TestGrid config proto is written. Can be
+	// /local/path, gs://path/to/object or s3://path/to/object. If unset, the
+	// generated config is only validated.
+	output   string
+	oneshot  bool
+	interval time.Duration
+
+	defaultDashboards       prowflagutil.Strings
+	createMissingDashboards bool
+	prowJobURLPrefix        string
+	updateDescription       bool
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	o := options{config: configflagutil.ConfigOptions{ConfigPath: "/etc/config/config.yaml"}}
+	fs.Var(&o.yamlPaths, "yaml", "TestGrid YAML file or directory with the dashboards and test groups that jobs are added to. Can be passed multiple times.")
+	fs.StringVar(&o.defaultPath, "default", "", "TestGrid YAML file with the default test group and dashboard tab.")
+	fs.StringVar(&o.output, "output", "", "The /local/path, gs://path/to/object or s3://path/to/object to write the TestGrid config proto to. If unset, the config is only validated.")
+	fs.BoolVar(&o.oneshot, "oneshot", false, "Generate the config once and exit instead of keeping it in sync.")
+	fs.DurationVar(&o.interval, "interval", 5*time.Minute, "How often to regenerate the config.")
+	fs.Var(&o.defaultDashboards, "default-dashboard", "<job type>=<dashboard> adding every job of the type to the dashboard, like periodic=periodics. Can be passed multiple times.")
+	fs.BoolVar(&o.createMissingDashboards, "create-missing-dashboards", false, "Create the dashboards that jobs are annotated with instead of failing if they are not in the TestGrid YAML.")
+	fs.StringVar(&o.prowJobURLPrefix, "prowjob-url-prefix", "", "URL prefix the file names of job configs are appended to, to link tabs to the config of their job.")
+	fs.BoolVar(&o.updateDescription, "update-description", false, "Add the type and repo of jobs to the description of their tabs.")
+	for _, group := range []prowflagutil.OptionGroup{&o.config, &o.storage, &o.instrumentation} {
+		group.AddFlags(fs)
+	}
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	for _, group := range []prowflagutil.OptionGroup{&o.config, &o.storage, &o.instrumentation} {
+		if err := group.Validate(false); err != nil {
+			return err
+		}
+	}
+	if !o.oneshot && o.output == "" {
+		return errors.New("--output is required unless --oneshot is set")
+	}
+	if o.interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	if _, err := o.parseDefaultDashboards(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *options) parseDefaultDashboards() (map[prowapi.ProwJobType]string, error) {
+	dashboards := map[prowapi.ProwJobType]string{}
+	for _, value := range o.defaultDashboards.Strings() {
+		jobType, dashboard, ok := strings.Cut(value, "=")
+		switch prowapi.ProwJobType(jobType) {
+		case prowapi.PresubmitJob, prowapi.PostsubmitJob, prowapi.PeriodicJob:
+		default:
+			ok = false
+		}
+		if !ok || dashboard == "" {
+			return nil, fmt.Errorf("--default-dashboard=%s must be presubmit, postsubmit or periodic=<dashboard>", value)
+		}
+		dashboards[prowapi.ProwJobType(jobType)] = dashboard
+	}
+	return dashboards, nil
+}
+
+type configurator struct {
+	options   options
+	generator testgridconfig.Options
+	config    config.Getter
+	opener    io.Opener
+	// last is the config written last, not to write it again if unchanged.
+	last []byte
+}
+
+// sync generates the TestGrid config and writes it if it changed.
+func (c *configurator) sync(ctx context.Context) error {
+	base, err := yamlcfg.ReadConfig(c.options.yamlPaths.Strings(), c.options.defaultPath, true)
+	if err != nil {
+		return fmt.Errorf("failed to read TestGrid YAML: %w", err)
+	}
+	generated, err := testgridconfig.Generate(&base, c.config(), c.generator)
+	if err != nil {
+		return err
+	}
+	if c.options.output == "" {
+		logrus.WithField("test-groups", len(generated.TestGroups)).WithField("dashboards", len(generated.Dashboards)).Info("TestGrid config is valid.")
+		return nil
+	}
+	content, err := tgconf.MarshalBytes(generated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal TestGrid config: %w", err)
+	}
+	if bytes.Equal(content, c.last) {
+		return nil
+	}
+	log := logrus.WithField("output", c.options.output)
+	if err := io.WriteContent(ctx, log, c.opener, c.options.output, content); err != nil {
+		return fmt.Errorf("failed to write TestGrid config: %w", err)
+	}
+	c.last = content
+	log.WithField("test-groups", len(generated.TestGroups)).WithField("dashboards", len(generated.Dashboards)).Info("Wrote TestGrid config.")
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	defaultDashboards, _ := o.parseDefaultDashboards()
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+
+	c := &configurator{
+		options: o,
+		generator: testgridconfig.Options{
+			DefaultDashboards:       defaultDashboards,
+			CreateMissingDashboards: o.createMissingDashboards,
+			ProwJobURLPrefix:        o.prowJobURLPrefix,
+			UpdateDescription:       o.updateDescription,
+		},
+		config: configAgent.Config,
+	}
+	if o.defaultPath != "" {
+		raw, err := os.ReadFile(o.defaultPath)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error reading TestGrid defaults.")
+		}
+		if c.generator.Defaults, err = yamlcfg.LoadDefaults(raw); err != nil {
+			logrus.WithError(err).Fatal("Error parsing TestGrid defaults.")
+		}
+	}
+	if o.output != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if c.opener, err = o.storage.StorageClient(ctx); err != nil {
+			logrus.WithError(err).Fatal("Error creating opener.")
+		}
+	}
+
+	if o.oneshot {
+		if err := c.sync(context.Background()); err != nil {
+			logrus.WithError(err).Fatal("Error generating TestGrid config.")
+		}
+		return
+	}
+
+	defer interrupts.WaitForGracefulShutdown()
+	pprof.Instrument(o.instrumentation)
+	interrupts.TickLiteral(func() {
+		if err := c.sync(context.Background()); err != nil {
+			logrus.WithError(err).Error("Error generating TestGrid config.")
+		}
+	}, o.interval)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestOptions(t *testing.T) {
+	testCases := []struct {
+		name              string
+		args              []string
+		expectedErr       bool
+		expectedDashboard map[prowapi.ProwJobType]string
+	}{
+		{
+			name:              "validate only",
+			args:              []string{"--oneshot"},
+			expectedDashboard: map[prowapi.ProwJobType]string{},
+		},
+		{
+			name:        "output is required to keep the config in sync",
+			args:        []string{},
+			expectedErr: true,
+		},
+		{
+			name:              "default dashboards",
+			args:              []string{"--output=gs://bucket/config", "--default-dashboard=periodic=periodics", "--default-dashboard=postsubmit=postsubmits"},
+			expectedDashboard: map[prowapi.ProwJobType]string{prowapi.PeriodicJob: "periodics", prowapi.PostsubmitJob: "postsubmits"},
+		},
+		{
+			name:        "invalid job type",
+			args:        []string{"--oneshot", "--default-dashboard=batch=batches"},
+			expectedErr: true,
+		},
+		{
+			name:        "missing dashboard",
+			args:        []string{"--oneshot", "--default-dashboard=periodic="},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := gatherOptions(flag.NewFlagSet("testgrid-configurator", flag.ContinueOnError), tc.args...)
+			err := o.Validate()
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			dashboards, _ := o.parseDefaultDashboards()
+			if diff := cmp.Diff(tc.expectedDashboard, dashboards); diff != "" {
+				t.Errorf("unexpected default dashboards (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
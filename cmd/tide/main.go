@@ -71,6 +71,9 @@ type options struct {
 	// a) the gcs credentials can write to this bucket
 	// b) the default acls do not expose any private info
 	historyURI string
+	// historyArchiveURI is the prefix Tide archives its action history under,
+	// with one object per day. Can be /local/path, gs://path or s3://path.
+	historyArchiveURI string
 
 	// statusURI where Tide store status update state.
 	// Can be a /local/path, gs://path/to/object or s3://path/to/object.
@@ -118,6 +121,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.IntVar(&o.statusThrottle, "status-hourly-tokens", 400, "The maximum number of tokens per hour to be used by the status controller.")
 	fs.IntVar(&o.maxRecordsPerPool, "max-records-per-pool", 1000, "The maximum number of history records stored for an individual Tide pool.")
 	fs.StringVar(&o.historyURI, "history-uri", "", "The /local/path,gs://path/to/object or s3://path/to/object to store tide action history. GCS writes will use the default object ACL for the bucket")
+	fs.StringVar(&o.historyArchiveURI, "history-archive-uri", "", "The /local/path, gs://path or s3://path to archive tide action history under, with one object per day. The archive is kept queryable for tide.history_retention and served on /tide-history.")
 	fs.StringVar(&o.statusURI, "status-path", "", "The /local/path, gs://path/to/object or s3://path/to/object to store status controller state. GCS writes will use the default object ACL for the bucket.")
 	// Gerrit-related flags
 	fs.StringVar(&o.cookiefilePath, "cookiefile", "", "Path to git http.cookiefile; leave empty for anonymous access or if you are using GitHub")
@@ -254,6 +258,14 @@ func main() {
 		logrus.Fatal("Timed out waiting for cachesync")
 	}
 
+	if o.historyArchiveURI != "" {
+		if err := c.History().EnableArchive(o.historyArchiveURI, func() time.Duration {
+			return cfg().Tide.HistoryRetention.Duration
+		}); err != nil {
+			logrus.WithError(err).Fatal("Error enabling the action history archive.")
+		}
+	}
+
 	interrupts.OnInterrupt(func() {
 		c.Shutdown()
 		if err := gitClient.Clean(); err != nil {
@@ -265,6 +277,7 @@ func main() {
 	controllerMux := http.NewServeMux()
 	controllerMux.Handle("/", c)
 	controllerMux.Handle("/history", c.History())
+	controllerMux.Handle("/tide-history", c.History().QueryHandler())
 	server := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: controllerMux}
 
 	// Push metrics to the configured prometheus pushgateway endpoint or serve them
@@ -280,12 +293,15 @@ func main() {
 	interrupts.ListenAndServe(server, 10*time.Second)
 
 	// run the controller, but only after one sync period expires after our first run
+	configChanges := configAgent.Notify()
 	time.Sleep(time.Until(start.Add(cfg().Tide.SyncPeriod.Duration)))
-	interrupts.Tick(func() {
+	// sync right away when the config changes, so that changed queries and
+	// merge settings take effect without waiting for the next sync period
+	interrupts.TickWithTrigger(func() {
 		sync(c)
 	}, func() time.Duration {
 		return cfg().Tide.SyncPeriod.Duration
-	})
+	}, configChanges)
 }
 
 func sync(c *tide.Controller) {
@@ -25,6 +25,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/schema"
 	"sigs.k8s.io/prow/pkg/genyaml"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
@@ -82,6 +83,34 @@ func (g *genConfig) gen(rootDir string) error {
 	return nil
 }
 
+// genSchemas generates the JSON Schemas of the config files, which are
+// embedded into the schema package.
+func genSchemas(rootDir string) error {
+	var inputFiles []string
+	for _, goGlob := range schema.DocSources {
+		ifs, err := filepath.Glob(path.Join(rootDir, goGlob))
+		if err != nil {
+			return fmt.Errorf("filepath glob: %w", err)
+		}
+		inputFiles = append(inputFiles, ifs...)
+	}
+
+	commentMap, err := genyaml.NewCommentMap(nil, inputFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to construct commentMap: %w", err)
+	}
+	for _, kind := range schema.Kinds {
+		raw, err := schema.Marshal(kind, commentMap)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path.Join(rootDir, "pkg/config/schema", kind.FileName()), raw, 0644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+	}
+	return nil
+}
+
 func main() {
 	rootDir := flag.String("root-dir", defaultRootDir, "Repo root dir.")
 	flag.Parse()
@@ -92,4 +121,8 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	if err := genSchemas(*rootDir); err != nil {
+		logrus.WithError(err).Error("Failed generating the config schemas.")
+		os.Exit(1)
+	}
 }
@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -79,6 +80,7 @@ func init() {
 }
 
 type options struct {
+	arch              string
 	dockerRepo        string
 	prowImageListFile string
 	images            flagutil.Strings
@@ -132,7 +134,9 @@ func runCmd(additionalEnv []string, cmd string, args ...string) (string, error)
 }
 
 type imageDef struct {
-	Dir            string `json:"dir"`
+	Dir string `json:"dir"`
+	// Arch is the platform to build the image for, all of them for "all",
+	// or a comma-separated list of platforms for a multi-arch image.
 	Arch           string `json:"arch"`
 	remainingRetry int
 }
@@ -172,8 +176,9 @@ func allTags(arch string) ([]string, error) {
 	}
 
 	var allTags = baseTags
+	platforms := strings.Split(arch, ",")
 	for _, otherArch := range otherArches {
-		if arch != allArch && arch != otherArch {
+		if arch != allArch && !slices.Contains(platforms, otherArch) {
 			continue
 		}
 		for _, base := range baseTags {
@@ -236,10 +241,13 @@ func buildAndPush(id *imageDef, dockerRepos []string, push bool) error {
 		logger.WithField("duration", time.Since(start).String()).Info("Duration of image building.")
 	}(logger, start)
 	// So far only supports certain arch
-	isSupportedArch := (id.Arch == defaultArch || id.Arch == allArch)
-	for _, otherArch := range otherArches {
-		if id.Arch == otherArch {
-			isSupportedArch = true
+	isSupportedArch := id.Arch == allArch
+	if !isSupportedArch {
+		isSupportedArch = true
+		for _, platform := range strings.Split(id.Arch, ",") {
+			if platform != defaultArch && !slices.Contains(otherArches, platform) {
+				isSupportedArch = false
+			}
 		}
 	}
 	if !isSupportedArch {
@@ -287,6 +295,7 @@ func main() {
 	flag.IntVar(&o.workers, "workers", defaultWorkersCount, "Number of workers in parallel")
 	flag.BoolVar(&o.push, "push", false, "whether push or not")
 	flag.IntVar(&o.maxRetry, "retry", defaultRetry, "Number of times retrying for each image")
+	flag.StringVar(&o.arch, "arch", "", "Platforms to build all images for instead of their arch in --prow-images-file, e.g. all or linux/amd64,linux/arm64")
 	flag.Parse()
 
 	if !o.push && o.dockerRepo == "" {
@@ -355,6 +364,9 @@ func main() {
 			continue
 		}
 		id.remainingRetry = o.maxRetry
+		if o.arch != "" {
+			id.Arch = o.arch
+		}
 		if id.Arch == "" {
 			id.Arch = defaultArch
 		}
@@ -229,6 +229,20 @@ func TestAllTags(t *testing.T) {
 				"ko-20220222-a1b2c3d4-ppc64le",
 			},
 		},
+		{
+			name: "list",
+			arch: "linux/amd64,linux/arm64",
+			want: []string{
+				"latest",
+				"latest-root",
+				"20220222-a1b2c3d4",
+				"ko-20220222-a1b2c3d4",
+				"latest-arm64",
+				"latest-root-arm64",
+				"20220222-a1b2c3d4-arm64",
+				"ko-20220222-a1b2c3d4-arm64",
+			},
+		},
 		{
 			// Not supported arches are caught in the invoker of this function,
 			// not here.
@@ -391,6 +405,30 @@ func TestBuildAndPush(t *testing.T) {
 				"./cmd/awesome",
 			},
 		},
+		{
+			name: "list",
+			id: imageDef{
+				Dir:  "cmd/awesome",
+				Arch: "linux/amd64,linux/arm64",
+			},
+			koDockerRepo: "local.test",
+			push:         true,
+			want: []string{
+				"publish",
+				"--push=true",
+				"--tags=latest",
+				"--tags=latest-root",
+				"--tags=20220222-a1b2c3d4",
+				"--tags=ko-20220222-a1b2c3d4",
+				"--tags=latest-arm64",
+				"--tags=latest-root-arm64",
+				"--tags=20220222-a1b2c3d4-arm64",
+				"--tags=ko-20220222-a1b2c3d4-arm64",
+				"--base-import-paths",
+				"--platform=linux/amd64,linux/arm64",
+				"./cmd/awesome",
+			},
+		},
 		{
 			name: "unsupported-arch",
 			id: imageDef{
@@ -400,6 +438,15 @@ func TestBuildAndPush(t *testing.T) {
 			koDockerRepo: "local.test",
 			wantErr:      true,
 		},
+		{
+			name: "unsupported-arch-in-list",
+			id: imageDef{
+				Dir:  "cmd/awesome",
+				Arch: "linux/amd64,not/supported",
+			},
+			koDockerRepo: "local.test",
+			wantErr:      true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -22,13 +22,19 @@ import (
 	"fmt"
 	"mime"
 	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	prowgithub "sigs.k8s.io/prow/pkg/github"
 )
@@ -55,6 +61,12 @@ type ProwJobState string
 const (
 	// SchedulingState means the job has been created and it is waiting to be scheduled.
 	SchedulingState ProwJobState = "scheduling"
+	// HeldState means the job has been created but requires a manual approval
+	// before it can go on.
+	HeldState ProwJobState = "held"
+	// WaitingState means the job has been created but some of its scheduling
+	// gates have not been cleared yet.
+	WaitingState ProwJobState = "waiting"
 	// TriggeredState means the job has been scheduled but it is not running yet.
 	TriggeredState ProwJobState = "triggered"
 	// PendingState means the job is currently running and we are waiting for it to finish.
@@ -72,6 +84,8 @@ const (
 // GetAllProwJobStates returns all possible job states.
 func GetAllProwJobStates() []ProwJobState {
 	return []ProwJobState{
+		HeldState,
+		WaitingState,
 		TriggeredState,
 		PendingState,
 		SuccessState,
@@ -92,6 +106,23 @@ const (
 	TektonAgent = "tekton-pipeline"
 )
 
+// ClearedSchedulingGatesAnnotation lists the scheduling gates of a ProwJob
+// that have been cleared, separated by commas.
+const ClearedSchedulingGatesAnnotation = "prow.k8s.io/cleared-scheduling-gates"
+
+// ApprovedByAnnotation is the user who approved a ProwJob that requires a
+// manual approval.
+const ApprovedByAnnotation = "prow.k8s.io/approved-by"
+
+// AbortedByAnnotation is the user who aborted a ProwJob. It is copied to the
+// pod of the job before it is deleted, so that sidecar can record it in the
+// finished.json of the job.
+const AbortedByAnnotation = "prow.k8s.io/aborted-by"
+
+// RerunOverridesAnnotation records, as JSON, the fields a user overrode when
+// they reran a ProwJob through Deck and who they are.
+const RerunOverridesAnnotation = "prow.k8s.io/rerun-overrides"
+
 const (
 	// DefaultClusterAlias specifies the default cluster key to schedule jobs.
 	DefaultClusterAlias = "default"
@@ -182,6 +213,15 @@ type ProwJobSpec struct {
 	// If this field is unspecified or false, a new pod will be created to replace
 	// the evicted one.
 	ErrorOnEviction bool `json:"error_on_eviction,omitempty"`
+	// SchedulingGates hold the job in the waiting state until every gate
+	// is cleared, e.g. by an external approval system through Gangway.
+	// Cleared gates are listed in the prow.k8s.io/cleared-scheduling-gates
+	// annotation.
+	SchedulingGates []string `json:"scheduling_gates,omitempty"`
+	// RequiresManualApproval holds the job in the held state until an
+	// authorized user approves it through Deck. The approver is recorded in
+	// the prow.k8s.io/approved-by annotation.
+	RequiresManualApproval bool `json:"requires_manual_approval,omitempty"`
 
 	// PodSpec provides the basis for running the test under
 	// a Kubernetes agent
@@ -208,11 +248,20 @@ type ProwJobSpec struct {
 	// decorating PodSpecs that users provide
 	DecorationConfig *DecorationConfig `json:"decoration_config,omitempty"`
 
+	// ArtifactDestination records that the artifacts of this run were
+	// requested to be uploaded to a bucket or path prefix other than those
+	// of its job, to keep ad-hoc runs apart from the regular history of the
+	// job. It is applied to DecorationConfig when the ProwJob is created.
+	ArtifactDestination *ArtifactDestination `json:"artifact_destination,omitempty"`
+
 	// ReporterConfig holds reporter-specific configuration
 	ReporterConfig *ReporterConfig `json:"reporter_config,omitempty"`
 
 	// RerunAuthConfig holds information about which users can rerun the job
 	RerunAuthConfig *RerunAuthConfig `json:"rerun_auth_config,omitempty"`
+	// RerunOverrides lists the fields of the job users may override when they
+	// rerun it through Deck.
+	RerunOverrides *RerunOverrides `json:"rerun_overrides,omitempty"`
 
 	// Hidden specifies if the Job is considered hidden.
 	// Hidden jobs are only shown by deck instances that have the
@@ -235,8 +284,17 @@ type ProwJobSpec struct {
 	JobQueueName string `json:"job_queue_name,omitempty"`
 }
 
+// ArtifactDestination is where the artifacts of a run are uploaded.
+type ArtifactDestination struct {
+	// Bucket is the bucket to upload to, like gs://bucket or s3://bucket.
+	// Buckets without a scheme are GCS buckets.
+	Bucket string `json:"bucket"`
+	// PathPrefix is prepended to the paths of the artifacts in the bucket.
+	PathPrefix string `json:"path_prefix,omitempty"`
+}
+
 func (pjs ProwJobSpec) HasPipelineRunSpec() bool {
-	if pjs.TektonPipelineRunSpec != nil && pjs.TektonPipelineRunSpec.V1Beta1 != nil {
+	if pjs.TektonPipelineRunSpec != nil && (pjs.TektonPipelineRunSpec.V1Beta1 != nil || pjs.TektonPipelineRunSpec.InRepoPipeline != nil) {
 		return true
 	}
 	if pjs.PipelineRunSpec != nil {
@@ -253,6 +311,9 @@ func (pjs ProwJobSpec) GetPipelineRunSpec() (*pipelinev1.PipelineRunSpec, error)
 	if found == nil && pjs.PipelineRunSpec != nil {
 		found = pjs.PipelineRunSpec
 	}
+	if found == nil && pjs.TektonPipelineRunSpec != nil && pjs.TektonPipelineRunSpec.InRepoPipeline != nil {
+		found = &pipelinev1.PipelineRunSpec{}
+	}
 	if found == nil {
 		return nil, errors.New("pipeline run spec not found")
 	}
@@ -280,6 +341,9 @@ type RerunAuthConfig struct {
 	GitHubUsers []string `json:"github_users,omitempty"`
 	// GitHubOrgs contains names of GitHub organizations whose members can rerun the job
 	GitHubOrgs []string `json:"github_orgs,omitempty"`
+	// OIDCGroups contains names of groups, as given by the OIDC provider Deck
+	// authenticates users with, whose members can rerun the job
+	OIDCGroups []string `json:"oidc_groups,omitempty"`
 }
 
 // IsSpecifiedUser returns true if AllowAnyone is set to true or if the given user is
@@ -330,13 +394,32 @@ func (rac *RerunAuthConfig) IsAuthorized(org, user string, cli prowgithub.RerunC
 	return false, nil
 }
 
+// IsAuthorizedGroups returns true if AllowAnyone is set to true or if any of the
+// given groups of a user authenticated with OIDC is a permitted OIDCGroup
+func (rac *RerunAuthConfig) IsAuthorizedGroups(groups []string) bool {
+	if rac == nil {
+		return false
+	}
+	if rac.AllowAnyone {
+		return true
+	}
+	for _, allowed := range rac.OIDCGroups {
+		for _, group := range groups {
+			if group == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Validate validates the RerunAuthConfig fields.
 func (rac *RerunAuthConfig) Validate() error {
 	if rac == nil {
 		return nil
 	}
 
-	hasAllowList := len(rac.GitHubUsers) > 0 || len(rac.GitHubTeamIDs) > 0 || len(rac.GitHubTeamSlugs) > 0 || len(rac.GitHubOrgs) > 0
+	hasAllowList := len(rac.GitHubUsers) > 0 || len(rac.GitHubTeamIDs) > 0 || len(rac.GitHubTeamSlugs) > 0 || len(rac.GitHubOrgs) > 0 || len(rac.OIDCGroups) > 0
 
 	// If an allowlist is specified, the user probably does not intend for anyone to be able to rerun any job.
 	if rac.AllowAnyone && hasAllowList {
@@ -355,8 +438,138 @@ func (rac *RerunAuthConfig) IsAllowAnyone() bool {
 	return rac.AllowAnyone
 }
 
+// RerunOverrides lists the fields of a job users allowed to rerun it may
+// override when they do.
+type RerunOverrides struct {
+	// Env lists the names of the environment variables of the containers of
+	// the job that may be overridden.
+	Env []string `json:"env,omitempty"`
+	// Clusters lists the build clusters the job may be rerun in.
+	Clusters []string `json:"clusters,omitempty"`
+	// Timeout allows overriding the timeout of the job, if it is decorated.
+	Timeout bool `json:"timeout,omitempty"`
+}
+
 type ReporterConfig struct {
-	Slack *SlackReporterConfig `json:"slack,omitempty"`
+	Slack  *SlackReporterConfig  `json:"slack,omitempty"`
+	Gerrit *GerritReporterConfig `json:"gerrit,omitempty"`
+	GitHub *GitHubReporterConfig `json:"github,omitempty"`
+}
+
+// GitHubReporterConfig configures how the results of a job are reported to
+// GitHub.
+type GitHubReporterConfig struct {
+	// CheckRun reports the results of the job as a check run of the commit
+	// instead of a status context. Unlike status contexts, check runs can
+	// conclude as neutral, cancelled or skipped, and tell optional jobs
+	// apart. Requires Prow to authenticate to GitHub as a GitHub App.
+	CheckRun bool `json:"check_run,omitempty"`
+	// FailureConclusion is the conclusion of the check run of the job when
+	// it fails, either failure or neutral. Neutral conclusions don't block
+	// merging. Defaults to neutral for optional presubmits and to failure
+	// otherwise.
+	FailureConclusion string `json:"failure_conclusion,omitempty"`
+}
+
+// ReportsCheckRun returns whether the results of the job are reported as
+// a check run.
+func (g *GitHubReporterConfig) ReportsCheckRun() bool {
+	return g != nil && g.CheckRun
+}
+
+// Validate ensures the failure conclusion is supported.
+func (g *GitHubReporterConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+	switch g.FailureConclusion {
+	case "", "failure", "neutral":
+	default:
+		return fmt.Errorf("failure_conclusion must be failure or neutral, got %q", g.FailureConclusion)
+	}
+	if g.FailureConclusion != "" && !g.CheckRun {
+		return errors.New("failure_conclusion requires check_run")
+	}
+	return nil
+}
+
+// GerritReporterConfig configures how the results of a job are voted on in
+// Gerrit.
+type GerritReporterConfig struct {
+	// Label is the label the results of the job are voted on, like
+	// Verified. It overrides the prow.k8s.io/gerrit-report-label label of
+	// the job. The results of the jobs of a change that vote on the same
+	// label are reported together.
+	Label string `json:"label,omitempty"`
+	// Vote can be set to false to only comment the results of the job
+	// without voting on a label.
+	Vote *bool `json:"vote,omitempty"`
+	// SuccessVote is the vote when all jobs passed. Defaults to +1.
+	SuccessVote *int `json:"success_vote,omitempty"`
+	// FailureVote is the vote when a presubmit failed. Defaults to -1.
+	// Postsubmits and merged changes are never voted below 0.
+	FailureVote *int `json:"failure_vote,omitempty"`
+	// AggregateLabel is an additional label, like Prow-Verified, that's
+	// voted on once all jobs of the change with the same aggregate label
+	// finished, with the combined result of the jobs. A submit requirement
+	// on the label then requires all of the jobs to pass, regardless of the
+	// labels they vote on themselves.
+	AggregateLabel string `json:"aggregate_label,omitempty"`
+	// Hashtags are added to the change when a presubmit fails and removed
+	// once the presubmits reported together pass again.
+	Hashtags []string `json:"hashtags,omitempty"`
+	// AttentionSet adds the uploader of the patchset to the attention set
+	// of the change when a presubmit fails and removes them once the
+	// presubmits reported together pass again.
+	AttentionSet bool `json:"attention_set,omitempty"`
+}
+
+// ShouldVote returns whether the results of the job are voted on.
+func (g *GerritReporterConfig) ShouldVote() bool {
+	return g == nil || g.Vote == nil || *g.Vote
+}
+
+// Votes returns the votes for passing and failing results.
+func (g *GerritReporterConfig) Votes() (success, failure int) {
+	success, failure = 1, -1
+	if g == nil {
+		return success, failure
+	}
+	if g.SuccessVote != nil {
+		success = *g.SuccessVote
+	}
+	if g.FailureVote != nil {
+		failure = *g.FailureVote
+	}
+	return success, failure
+}
+
+// Validate ensures the votes are in range and the labels are valid.
+func (g *GerritReporterConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+	if !g.ShouldVote() && g.Label != "" {
+		return fmt.Errorf("label %q is set but vote is false", g.Label)
+	}
+	if success, failure := g.Votes(); success <= 0 || failure >= 0 {
+		return fmt.Errorf("success_vote must be positive and failure_vote negative, got %d and %d", success, failure)
+	}
+	for _, label := range []string{g.Label, g.AggregateLabel} {
+		if errs := validation.IsValidLabelValue(label); len(errs) > 0 {
+			return fmt.Errorf("gerrit label %q is invalid: %s", label, strings.Join(errs, ", "))
+		}
+	}
+	if g.AggregateLabel != "" && g.AggregateLabel == g.Label {
+		return fmt.Errorf("aggregate_label must differ from label %q", g.Label)
+	}
+	for _, hashtag := range g.Hashtags {
+		// Gerrit strips leading '#' and rejects hashtags containing commas.
+		if strings.TrimLeft(strings.TrimSpace(hashtag), "#") == "" || strings.Contains(hashtag, ",") {
+			return fmt.Errorf("hashtag %q is invalid: it must not be empty or contain commas", hashtag)
+		}
+	}
+	return nil
 }
 
 type SlackReporterConfig struct {
@@ -502,6 +715,13 @@ type DecorationConfig struct {
 	// BloblessFetch tells Prow to avoid fetching objects when cloning using
 	// the --filter=blob:none flag.
 	BloblessFetch *bool `json:"blobless_fetch,omitempty"`
+	// ShallowSince tells Prow to only fetch the history of the base ref
+	// after the given date, using the --shallow-since flag of git fetch.
+	// It accepts any date git understands, like 2024-01-01 or "3 months ago".
+	ShallowSince *string `json:"shallow_since,omitempty"`
+	// LFSFetch tells Prow to fetch the Git LFS objects of the checked out
+	// state after cloning. Without it, LFS files are left as pointers.
+	LFSFetch *bool `json:"lfs_fetch,omitempty"`
 	// SkipCloning determines if we should clone source code in the
 	// initcontainers for jobs that specify refs
 	SkipCloning *bool `json:"skip_cloning,omitempty"`
@@ -519,6 +739,10 @@ type DecorationConfig struct {
 	// GitHubAppPrivateKeySecret is a Kubernetes secret that contains the GitHub App private key,
 	// which is going to be used for fetching a private repository.
 	GitHubAppPrivateKeySecret *GitHubAppPrivateKeySecret `json:"github_app_private_key_secret,omitempty"`
+	// Vault configures how entrypoint reads the vault:// secret references
+	// in the environment of the test containers. References to secrets
+	// encrypted with Cloud KMS (kms://) need no configuration.
+	Vault *VaultConfig `json:"vault,omitempty"`
 
 	// CensorSecrets enables censoring output logs and artifacts.
 	CensorSecrets *bool `json:"censor_secrets,omitempty"`
@@ -553,6 +777,15 @@ type DecorationConfig struct {
 	// PodUnscheduledTimeout defines how long the controller will wait to abort a prowjob
 	// stuck in an unscheduled state. Specific for OrgRepo or Cluster. If not set, it has a fallback inside plank field.
 	PodUnscheduledTimeout *metav1.Duration `json:"pod_unscheduled_timeout,omitempty"`
+	// HangTimeout enables heartbeats of the test processes and defines how
+	// long a test process may go without writing any output before the
+	// controller aborts the job as hung, instead of waiting for the timeout
+	// of the job.
+	HangTimeout *metav1.Duration `json:"hang_timeout,omitempty"`
+	// RecordEnvironment makes the entrypoint write the command, working
+	// directory and environment of the test processes to the artifacts,
+	// with the values of variables read from secrets redacted.
+	RecordEnvironment *bool `json:"record_environment,omitempty"`
 
 	// RunAsUser defines UID for process in all containers running in a Pod.
 	// This field will not override the existing ProwJob's PodSecurityContext.
@@ -567,6 +800,161 @@ type DecorationConfig struct {
 	// This field will not override the existing ProwJob's PodSecurityContext.
 	// Equivalent to PodSecurityContext's FsGroup
 	FsGroup *int64 `json:"fs_group,omitempty"`
+
+	// Caches are directories of the test containers that are restored from
+	// the blob storage before the test starts and saved after it passed, so
+	// dependencies don't have to be downloaded or built on every run.
+	// Caches are restored by clonerefs, so they're ignored for jobs that
+	// don't clone any refs.
+	Caches []Cache `json:"caches,omitempty"`
+
+	// ArtifactRetention limits how long the artifacts of the builds of the
+	// job are kept in the blob storage. The artifact-retention controller of
+	// the prow-controller-manager deletes the builds that expired.
+	ArtifactRetention *ArtifactRetention `json:"artifact_retention,omitempty"`
+
+	// QuarantinedTests are tests whose failures don't fail the job. When the
+	// test process fails, entrypoint reads the junit files of the artifacts
+	// and exits successfully if every failed test is quarantined. The
+	// failures are still recorded in the junit files and in the metadata of
+	// the job. Tests quarantined from Deck are added when the pod is
+	// created.
+	QuarantinedTests []QuarantinedTest `json:"quarantined_tests,omitempty"`
+}
+
+// QuarantinedTest is a test whose failures are not blocking until it expires.
+type QuarantinedTest struct {
+	// Pattern is a regular expression matching the whole name of the test,
+	// either its name or its class name and name joined by a dot.
+	Pattern string `json:"pattern"`
+	// Reason explains why the test is quarantined, like a link to the issue
+	// about its flakes.
+	Reason string `json:"reason,omitempty"`
+	// Expires is when the failures of the test block the job again.
+	Expires metav1.Time `json:"expires"`
+	// Author is who quarantined the test from Deck.
+	Author string `json:"author,omitempty"`
+}
+
+// Expired returns true if the quarantine expired at the time.
+func (q QuarantinedTest) Expired(now time.Time) bool {
+	return !now.Before(q.Expires.Time)
+}
+
+// Validate ensures the pattern compiles and the quarantine expires.
+func (q QuarantinedTest) Validate() error {
+	if q.Pattern == "" {
+		return errors.New("pattern must be set")
+	}
+	if _, err := regexp.Compile(q.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", q.Pattern, err)
+	}
+	if q.Expires.IsZero() {
+		return fmt.Errorf("quarantine of %q must expire", q.Pattern)
+	}
+	return nil
+}
+
+// ArtifactRetention configures which builds of a job keep their artifacts.
+// A build expires once it's older than MaxAge or once KeepLast newer builds
+// of the job exist, whichever comes first.
+type ArtifactRetention struct {
+	// MaxAge is how long the artifacts of a build are kept after it started,
+	// as a duration like "720h" or a number of days like "30d".
+	MaxAge string `json:"max_age,omitempty"`
+	// KeepLast is the number of most recent builds of the job whose
+	// artifacts are kept.
+	KeepLast int `json:"keep_last,omitempty"`
+}
+
+// MaxAgeDuration returns the MaxAge as a duration, or zero if it's not set.
+func (r *ArtifactRetention) MaxAgeDuration() (time.Duration, error) {
+	if r.MaxAge == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(r.MaxAge, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days %q: %w", r.MaxAge, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(r.MaxAge)
+}
+
+// Validate ensures the retention expires builds.
+func (r *ArtifactRetention) Validate() error {
+	maxAge, err := r.MaxAgeDuration()
+	if err != nil {
+		return fmt.Errorf("invalid max_age: %w", err)
+	}
+	if maxAge < 0 || r.KeepLast < 0 {
+		return errors.New("max_age and keep_last must not be negative")
+	}
+	if maxAge == 0 && r.KeepLast == 0 {
+		return errors.New("max_age or keep_last must be set")
+	}
+	return nil
+}
+
+// Cache is a set of directories that is stored in the blob storage under a
+// key. The cache is restored if an object with the key exists, otherwise it's
+// saved under the key once the test passed.
+type Cache struct {
+	// Name identifies the cache in the job and in the blob storage. Jobs that
+	// use a cache with the same name and key share the cache.
+	Name string `json:"name"`
+	// Paths are the absolute paths of the cached directories in the test
+	// containers, like /root/go/pkg/mod. Each path is an emptyDir volume.
+	Paths []string `json:"paths"`
+	// Key is a Go template for the key of the cache. The template is executed
+	// with the .Job, .Org, .Repo and .BaseRef of the job and can call
+	// hashFiles with paths relative to the repo of the job to include the
+	// contents of files in the key, like
+	// "{{.Org}}-{{.Repo}}-{{hashFiles "go.sum"}}".
+	Key string `json:"key"`
+}
+
+// VaultConfig configures the access to HashiCorp Vault.
+type VaultConfig struct {
+	// Address is the URL of the Vault server.
+	Address string `json:"address"`
+	// AuthMount is the path the Kubernetes auth method is mounted at.
+	// Defaults to kubernetes.
+	AuthMount string `json:"auth_mount,omitempty"`
+	// Role is the role entrypoint logs in as with the service account
+	// token of the pod.
+	Role string `json:"role"`
+}
+
+// cacheNameMaxLength leaves room to name the volumes of the cache after it.
+const cacheNameMaxLength = 40
+
+// Validate ensures the cache can be restored and saved.
+func (c *Cache) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is not specified")
+	}
+	if errs := validation.IsDNS1123Label(c.Name); len(errs) > 0 || len(c.Name) > cacheNameMaxLength {
+		return fmt.Errorf("name %q must be a lowercase RFC 1123 label of at most %d characters", c.Name, cacheNameMaxLength)
+	}
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("cache %q has no paths", c.Name)
+	}
+	for _, p := range c.Paths {
+		if !path.IsAbs(p) || path.Clean(p) == "/" {
+			return fmt.Errorf("path %q of cache %q must be an absolute path other than /", p, c.Name)
+		}
+	}
+	if c.Key == "" {
+		return fmt.Errorf("cache %q has no key", c.Name)
+	}
+	// The functions are implemented where the key is executed, only their
+	// names are needed to parse it.
+	if _, err := template.New(c.Name).Funcs(template.FuncMap{"hashFiles": func(...string) string { return "" }}).Parse(c.Key); err != nil {
+		return fmt.Errorf("key of cache %q is invalid: %w", c.Name, err)
+	}
+	return nil
 }
 
 type CensoringOptions struct {
@@ -801,6 +1189,9 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 	if merged.GitHubAppPrivateKeySecret == nil {
 		merged.GitHubAppPrivateKeySecret = def.GitHubAppPrivateKeySecret
 	}
+	if merged.Vault == nil {
+		merged.Vault = def.Vault
+	}
 	if merged.CensorSecrets == nil {
 		merged.CensorSecrets = def.CensorSecrets
 	}
@@ -829,6 +1220,14 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 		merged.PodUnscheduledTimeout = def.PodUnscheduledTimeout
 	}
 
+	if merged.HangTimeout == nil {
+		merged.HangTimeout = def.HangTimeout
+	}
+
+	if merged.RecordEnvironment == nil {
+		merged.RecordEnvironment = def.RecordEnvironment
+	}
+
 	if merged.RunAsUser == nil {
 		merged.RunAsUser = def.RunAsUser
 	}
@@ -844,9 +1243,24 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 	if merged.BloblessFetch == nil {
 		merged.BloblessFetch = def.BloblessFetch
 	}
+	if merged.ShallowSince == nil {
+		merged.ShallowSince = def.ShallowSince
+	}
+	if merged.LFSFetch == nil {
+		merged.LFSFetch = def.LFSFetch
+	}
 	if merged.SchedulingOptions == nil {
 		merged.SchedulingOptions = def.SchedulingOptions
 	}
+	if len(merged.Caches) == 0 {
+		merged.Caches = def.Caches
+	}
+	if merged.ArtifactRetention == nil {
+		merged.ArtifactRetention = def.ArtifactRetention
+	}
+	if len(merged.QuarantinedTests) == 0 {
+		merged.QuarantinedTests = def.QuarantinedTests
+	}
 	return &merged
 }
 
@@ -885,6 +1299,32 @@ func (d *DecorationConfig) Validate() error {
 	if d.OauthTokenSecret != nil && len(d.SSHKeySecrets) > 0 {
 		return errors.New("both OAuth token and SSH key secrets are specified")
 	}
+	if d.HangTimeout != nil && d.HangTimeout.Duration <= 0 {
+		return errors.New("hang timeout must be positive")
+	}
+	if d.Vault != nil && (d.Vault.Address == "" || d.Vault.Role == "") {
+		return errors.New("vault configuration must specify the address and role")
+	}
+	names := map[string]bool{}
+	for i := range d.Caches {
+		if err := d.Caches[i].Validate(); err != nil {
+			return fmt.Errorf("cache %d is invalid: %w", i, err)
+		}
+		if names[d.Caches[i].Name] {
+			return fmt.Errorf("cache %q is specified more than once", d.Caches[i].Name)
+		}
+		names[d.Caches[i].Name] = true
+	}
+	if d.ArtifactRetention != nil {
+		if err := d.ArtifactRetention.Validate(); err != nil {
+			return fmt.Errorf("artifact retention is invalid: %w", err)
+		}
+	}
+	for i := range d.QuarantinedTests {
+		if err := d.QuarantinedTests[i].Validate(); err != nil {
+			return fmt.Errorf("quarantined test %d is invalid: %w", i, err)
+		}
+	}
 	return nil
 }
 
@@ -978,6 +1418,21 @@ type GCSConfiguration struct {
 	// Example: "txt", "json"
 	// Use "*" for all
 	CompressFileTypes []string `json:"compress_file_types,omitempty"`
+	// UploadConcurrency is how many objects are uploaded at once.
+	// Defaults to 4.
+	UploadConcurrency int `json:"upload_concurrency,omitempty"`
+	// MaxUploadBytesPerSecond caps the bandwidth used by all uploads of a
+	// pod utility together. Unlimited if unset.
+	MaxUploadBytesPerSecond int64 `json:"max_upload_bytes_per_second,omitempty"`
+	// MultipartUploadThreshold is the size in bytes from which files are
+	// uploaded to GCS in parts concurrently, which are then composed into
+	// the object. Failed parts are retried without uploading the others
+	// again. Files that are compressed before the upload are never split.
+	// Disabled if unset.
+	MultipartUploadThreshold int64 `json:"multipart_upload_threshold,omitempty"`
+	// MultipartUploadPartSize is the size in bytes of the parts. Defaults to
+	// 32 MiB, and is grown so that no file has more than 32 parts.
+	MultipartUploadPartSize int64 `json:"multipart_upload_part_size,omitempty"`
 }
 
 // ApplyDefault applies the defaults for GCSConfiguration decorations. If a field has a zero value,
@@ -1030,6 +1485,18 @@ func (g *GCSConfiguration) ApplyDefault(def *GCSConfiguration) *GCSConfiguration
 	if merged.CompressFileTypes == nil {
 		merged.CompressFileTypes = def.CompressFileTypes
 	}
+	if merged.UploadConcurrency == 0 {
+		merged.UploadConcurrency = def.UploadConcurrency
+	}
+	if merged.MaxUploadBytesPerSecond == 0 {
+		merged.MaxUploadBytesPerSecond = def.MaxUploadBytesPerSecond
+	}
+	if merged.MultipartUploadThreshold == 0 {
+		merged.MultipartUploadThreshold = def.MultipartUploadThreshold
+	}
+	if merged.MultipartUploadPartSize == 0 {
+		merged.MultipartUploadPartSize = def.MultipartUploadPartSize
+	}
 	return &merged
 }
 
@@ -1049,6 +1516,9 @@ func (g *GCSConfiguration) Validate() error {
 	if g.PathStrategy != PathStrategyExplicit && (g.DefaultOrg == "" || g.DefaultRepo == "") {
 		return fmt.Errorf("default org and repo must be provided for GCS strategy %q", g.PathStrategy)
 	}
+	if g.UploadConcurrency < 0 || g.MaxUploadBytesPerSecond < 0 || g.MultipartUploadThreshold < 0 || g.MultipartUploadPartSize < 0 {
+		return errors.New("upload_concurrency, max_upload_bytes_per_second, multipart_upload_threshold and multipart_upload_part_size can't be negative")
+	}
 	return nil
 }
 
@@ -1126,6 +1596,106 @@ type ProwJobStatus struct {
 	// PrevReportStates stores the previous reported prowjob state per reporter
 	// So crier won't make duplicated report attempt
 	PrevReportStates map[string]ProwJobState `json:"prev_report_states,omitempty"`
+
+	// PodPendingReason is set by plank while the pod for this job is stuck
+	// in the Pending phase and explains why it has not started yet. It is
+	// cleared once the pod leaves the Pending phase.
+	PodPendingReason *PodPendingReason `json:"pod_pending_reason,omitempty"`
+
+	// Queue is set by plank while the job is triggered but held back by
+	// max_concurrency, a job queue or a concurrency budget, and tells
+	// where the job waits. It is cleared once the job starts.
+	Queue *QueueStatus `json:"queue,omitempty"`
+}
+
+// QueueStatus describes where a triggered job waits for its turn to start.
+type QueueStatus struct {
+	// Limit names what holds the job back, like "job queue gpu".
+	Limit string `json:"limit"`
+	// Position is the place of the job among the jobs waiting for the
+	// limit, starting at 1 for the job that starts next.
+	Position int `json:"position"`
+	// Length is the number of jobs waiting for the limit.
+	Length int `json:"length"`
+	// EstimatedStartTime is when the job is expected to start, estimated
+	// from the durations of previous runs. Unset without previous runs.
+	EstimatedStartTime *metav1.Time `json:"estimated_start_time,omitempty"`
+	// UpdateTime is when plank last updated the queue status.
+	UpdateTime metav1.Time `json:"update_time"`
+	// ReportedBy lists the crier reporters that reported this position.
+	ReportedBy []string `json:"reported_by,omitempty"`
+}
+
+// SamePosition tells whether both queue statuses tell the same position
+// and start time to the minute.
+func (q *QueueStatus) SamePosition(other *QueueStatus) bool {
+	if q == nil || other == nil {
+		return q == other
+	}
+	if q.Limit != other.Limit || q.Position != other.Position || q.Length != other.Length {
+		return false
+	}
+	if q.EstimatedStartTime == nil || other.EstimatedStartTime == nil {
+		return q.EstimatedStartTime == other.EstimatedStartTime
+	}
+	return q.EstimatedStartTime.Truncate(time.Minute).Equal(other.EstimatedStartTime.Truncate(time.Minute))
+}
+
+// Describe returns a short human readable summary of the queue status.
+func (q *QueueStatus) Describe(now time.Time) string {
+	summary := fmt.Sprintf("Queued: %d of %d waiting for %s", q.Position, q.Length, q.Limit)
+	if q.EstimatedStartTime != nil {
+		if eta := q.EstimatedStartTime.Sub(now).Round(time.Minute); eta >= time.Minute {
+			summary += fmt.Sprintf(", expected to start in ~%s", strings.TrimSuffix(eta.String(), "0s"))
+		} else {
+			summary += ", expected to start soon"
+		}
+	}
+	return summary + "."
+}
+
+// PodPendingReasonType classifies why a pod has not started running yet.
+type PodPendingReasonType string
+
+// Various reasons for a pod to be stuck pending.
+const (
+	// PodUnschedulable means the scheduler could not find a node for the pod.
+	PodUnschedulable PodPendingReasonType = "Unschedulable"
+	// PodQuotaExceeded means a resource quota prevents the pod from starting.
+	PodQuotaExceeded PodPendingReasonType = "QuotaExceeded"
+	// PodImagePullBackOff means the image of one of the containers can not be pulled.
+	PodImagePullBackOff PodPendingReasonType = "ImagePullBackOff"
+	// PodContainerConfigError means one of the containers can not be created
+	// because of its configuration, e.g. a missing secret or config map.
+	PodContainerConfigError PodPendingReasonType = "ContainerConfigError"
+)
+
+// PodPendingReason describes why the pod of a ProwJob is stuck pending.
+type PodPendingReason struct {
+	// Reason is a machine readable classification of the problem.
+	Reason PodPendingReasonType `json:"reason"`
+	// Message is the human readable explanation provided by Kubernetes.
+	Message string `json:"message,omitempty"`
+	// Container is the name of the affected container, if the reason
+	// is specific to a single container.
+	Container string `json:"container,omitempty"`
+	// LastTransitionTime is when the reason was first observed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// String returns a short human readable summary of the pending reason.
+func (r *PodPendingReason) String() string {
+	if r == nil {
+		return ""
+	}
+	summary := string(r.Reason)
+	if r.Container != "" {
+		summary = fmt.Sprintf("%s (container %s)", summary, r.Container)
+	}
+	if r.Message != "" {
+		summary = fmt.Sprintf("%s: %s", summary, r.Message)
+	}
+	return summary
 }
 
 // Complete returns true if the prow job has finished
@@ -1140,6 +1710,25 @@ func (j *ProwJob) SetComplete() {
 	*j.Status.CompletionTime = metav1.Now()
 }
 
+// AwaitingApproval returns whether the job requires a manual approval that
+// it has not been given yet.
+func (j *ProwJob) AwaitingApproval() bool {
+	return j.Spec.RequiresManualApproval && j.Annotations[ApprovedByAnnotation] == ""
+}
+
+// RemainingSchedulingGates returns the scheduling gates of the job that
+// have not been cleared yet.
+func (j *ProwJob) RemainingSchedulingGates() []string {
+	cleared := strings.Split(j.Annotations[ClearedSchedulingGatesAnnotation], ",")
+	var remaining []string
+	for _, gate := range j.Spec.SchedulingGates {
+		if !slices.Contains(cleared, gate) {
+			remaining = append(remaining, gate)
+		}
+	}
+	return remaining
+}
+
 // ClusterAlias specifies the key in the clusters map to use.
 //
 // This allows scheduling a prow job somewhere aside from the default build cluster.
@@ -1219,6 +1808,13 @@ type Refs struct {
 	// using the --filter=blob:none flag. If unspecified, defaults to
 	// DecorationConfig.BloblessFetch.
 	BloblessFetch *bool `json:"blobless_fetch,omitempty"`
+	// ShallowSince tells prow to only fetch the history of the base ref
+	// after the given date. If unspecified, defaults to
+	// DecorationConfig.ShallowSince.
+	ShallowSince string `json:"shallow_since,omitempty"`
+	// LFSFetch tells prow to fetch the Git LFS objects of the repository
+	// after cloning. If unspecified, defaults to DecorationConfig.LFSFetch.
+	LFSFetch *bool `json:"lfs_fetch,omitempty"`
 }
 
 func (r Refs) String() string {
@@ -1262,6 +1858,30 @@ type TektonPipelineRunSpec struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
 	V1Beta1 *pipelinev1.PipelineRunSpec `json:"v1beta1,omitempty"`
+	// InRepoPipeline reads the Pipeline to run from a file of the tested
+	// repo instead of referencing one installed in the build cluster. The
+	// params, workspaces and timeouts of V1Beta1 still apply to the run.
+	InRepoPipeline *InRepoPipeline `json:"in_repo_pipeline,omitempty"`
+}
+
+// GetInRepoPipeline returns where the Pipeline of the job is stored in the
+// tested repo, or nil if the job doesn't read it from the repo.
+func (s *TektonPipelineRunSpec) GetInRepoPipeline() *InRepoPipeline {
+	if s == nil {
+		return nil
+	}
+	return s.InRepoPipeline
+}
+
+// InRepoPipeline is a Pipeline definition stored in the tested repo.
+type InRepoPipeline struct {
+	// Path is the path of the YAML file holding a tekton.dev/v1 Pipeline,
+	// relative to the root of the repo.
+	Path string `json:"path"`
+	// Ref is the git ref the file is read at, like main. Defaults to the
+	// tested revision: the head of the first pull of presubmits, or the
+	// base of postsubmits.
+	Ref string `json:"ref,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
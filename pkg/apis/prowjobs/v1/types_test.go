@@ -26,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func pStr(str string) *string {
@@ -564,6 +565,11 @@ func TestRerunAuthConfigValidate(t *testing.T) {
 			config:      &RerunAuthConfig{AllowAnyone: true, GitHubOrgs: []string{"istio"}},
 			errExpected: true,
 		},
+		{
+			name:        "allow any and has OIDC groups",
+			config:      &RerunAuthConfig{AllowAnyone: true, OIDCGroups: []string{"prow-admins"}},
+			errExpected: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -576,6 +582,163 @@ func TestRerunAuthConfigValidate(t *testing.T) {
 	}
 }
 
+func TestGerritReporterConfigValidate(t *testing.T) {
+	vote := func(v int) *int { return &v }
+	no := false
+	var testCases = []struct {
+		name        string
+		config      *GerritReporterConfig
+		errExpected bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:   "custom label and votes",
+			config: &GerritReporterConfig{Label: "Verified", SuccessVote: vote(2), FailureVote: vote(-2), AggregateLabel: "Prow-Verified"},
+		},
+		{
+			name:   "no vote",
+			config: &GerritReporterConfig{Vote: &no},
+		},
+		{
+			name:        "label without vote",
+			config:      &GerritReporterConfig{Label: "Verified", Vote: &no},
+			errExpected: true,
+		},
+		{
+			name:        "negative success vote",
+			config:      &GerritReporterConfig{SuccessVote: vote(-1)},
+			errExpected: true,
+		},
+		{
+			name:        "zero failure vote",
+			config:      &GerritReporterConfig{FailureVote: vote(0)},
+			errExpected: true,
+		},
+		{
+			name:        "invalid label",
+			config:      &GerritReporterConfig{Label: "Code Review"},
+			errExpected: true,
+		},
+		{
+			name:        "aggregate label is the label",
+			config:      &GerritReporterConfig{Label: "Verified", AggregateLabel: "Verified"},
+			errExpected: true,
+		},
+		{
+			name:   "hashtags and attention set",
+			config: &GerritReporterConfig{Hashtags: []string{"ci-failed", "#needs-fix"}, AttentionSet: true},
+		},
+		{
+			name:        "empty hashtag",
+			config:      &GerritReporterConfig{Hashtags: []string{"#"}},
+			errExpected: true,
+		},
+		{
+			name:        "hashtag with comma",
+			config:      &GerritReporterConfig{Hashtags: []string{"ci,failed"}},
+			errExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.config.Validate(); (err != nil) != tc.errExpected {
+				t.Errorf("Expected error %v, got %v", tc.errExpected, err)
+			}
+		})
+	}
+}
+
+func TestArtifactRetentionValidate(t *testing.T) {
+	var testCases = []struct {
+		name           string
+		retention      ArtifactRetention
+		expectedMaxAge time.Duration
+		errExpected    bool
+	}{
+		{
+			name:           "days",
+			retention:      ArtifactRetention{MaxAge: "30d"},
+			expectedMaxAge: 30 * 24 * time.Hour,
+		},
+		{
+			name:           "duration",
+			retention:      ArtifactRetention{MaxAge: "36h"},
+			expectedMaxAge: 36 * time.Hour,
+		},
+		{
+			name:      "keep last builds",
+			retention: ArtifactRetention{KeepLast: 10},
+		},
+		{
+			name:        "nothing expires",
+			retention:   ArtifactRetention{},
+			errExpected: true,
+		},
+		{
+			name:        "invalid days",
+			retention:   ArtifactRetention{MaxAge: "1.5d"},
+			errExpected: true,
+		},
+		{
+			name:           "negative keep last",
+			retention:      ArtifactRetention{MaxAge: "1d", KeepLast: -1},
+			expectedMaxAge: 24 * time.Hour,
+			errExpected:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.retention.Validate(); (err != nil) != tc.errExpected {
+				t.Errorf("Expected error %v, got %v", tc.errExpected, err)
+			}
+			if maxAge, _ := tc.retention.MaxAgeDuration(); maxAge != tc.expectedMaxAge {
+				t.Errorf("Expected max age %v, got %v", tc.expectedMaxAge, maxAge)
+			}
+		})
+	}
+}
+
+func TestQuarantinedTestValidate(t *testing.T) {
+	expires := metav1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	var testCases = []struct {
+		name        string
+		test        QuarantinedTest
+		errExpected bool
+	}{
+		{
+			name: "valid",
+			test: QuarantinedTest{Pattern: `TestFlaky/.*`, Expires: expires},
+		},
+		{
+			name:        "no pattern",
+			test:        QuarantinedTest{Expires: expires},
+			errExpected: true,
+		},
+		{
+			name:        "invalid pattern",
+			test:        QuarantinedTest{Pattern: `TestFlaky(`, Expires: expires},
+			errExpected: true,
+		},
+		{
+			name:        "never expires",
+			test:        QuarantinedTest{Pattern: `TestFlaky`},
+			errExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.test.Validate(); (err != nil) != tc.errExpected {
+				t.Errorf("Expected error %v, got %v", tc.errExpected, err)
+			}
+		})
+	}
+}
+
 func TestRerunAuthConfigIsAuthorized(t *testing.T) {
 	var testCases = []struct {
 		name       string
@@ -619,6 +782,53 @@ func TestRerunAuthConfigIsAuthorized(t *testing.T) {
 	}
 }
 
+func TestRerunAuthConfigIsAuthorizedGroups(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		groups     []string
+		config     *RerunAuthConfig
+		authorized bool
+	}{
+		{
+			name:       "authorized - AllowAnyone is true",
+			config:     &RerunAuthConfig{AllowAnyone: true},
+			authorized: true,
+		},
+		{
+			name:       "authorized - one of the groups in OIDCGroups",
+			groups:     []string{"developers", "prow-admins"},
+			config:     &RerunAuthConfig{OIDCGroups: []string{"prow-admins"}},
+			authorized: true,
+		},
+		{
+			name:       "unauthorized - none of the groups in OIDCGroups",
+			groups:     []string{"developers"},
+			config:     &RerunAuthConfig{OIDCGroups: []string{"prow-admins"}},
+			authorized: false,
+		},
+		{
+			name:       "unauthorized - GitHub users are not groups",
+			groups:     []string{"gumby"},
+			config:     &RerunAuthConfig{GitHubUsers: []string{"gumby"}},
+			authorized: false,
+		},
+		{
+			name:       "unauthorized - RerunAuthConfig is nil",
+			groups:     []string{"prow-admins"},
+			config:     nil,
+			authorized: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.config.IsAuthorizedGroups(tc.groups); actual != tc.authorized {
+				t.Errorf("Expected %v, got %v", tc.authorized, actual)
+			}
+		})
+	}
+}
+
 func TestRerunAuthConfigIsAllowAnyone(t *testing.T) {
 	var testCases = []struct {
 		name     string
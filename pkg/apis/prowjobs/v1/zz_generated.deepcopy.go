@@ -30,6 +30,59 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactDestination) DeepCopyInto(out *ArtifactDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactDestination.
+func (in *ArtifactDestination) DeepCopy() *ArtifactDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactRetention) DeepCopyInto(out *ArtifactRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactRetention.
+func (in *ArtifactRetention) DeepCopy() *ArtifactRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cache) DeepCopyInto(out *Cache) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cache.
+func (in *Cache) DeepCopy() *Cache {
+	if in == nil {
+		return nil
+	}
+	out := new(Cache)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CensoringOptions) DeepCopyInto(out *CensoringOptions) {
 	*out = *in
@@ -124,6 +177,16 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ShallowSince != nil {
+		in, out := &in.ShallowSince, &out.ShallowSince
+		*out = new(string)
+		**out = **in
+	}
+	if in.LFSFetch != nil {
+		in, out := &in.LFSFetch, &out.LFSFetch
+		*out = new(bool)
+		**out = **in
+	}
 	if in.SkipCloning != nil {
 		in, out := &in.SkipCloning, &out.SkipCloning
 		*out = new(bool)
@@ -149,6 +212,11 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(GitHubAppPrivateKeySecret)
 		**out = **in
 	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultConfig)
+		**out = **in
+	}
 	if in.CensorSecrets != nil {
 		in, out := &in.CensorSecrets, &out.CensorSecrets
 		*out = new(bool)
@@ -194,6 +262,16 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.HangTimeout != nil {
+		in, out := &in.HangTimeout, &out.HangTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RecordEnvironment != nil {
+		in, out := &in.RecordEnvironment, &out.RecordEnvironment
+		*out = new(bool)
+		**out = **in
+	}
 	if in.RunAsUser != nil {
 		in, out := &in.RunAsUser, &out.RunAsUser
 		*out = new(int64)
@@ -209,6 +287,25 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Caches != nil {
+		in, out := &in.Caches, &out.Caches
+		*out = make([]Cache, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ArtifactRetention != nil {
+		in, out := &in.ArtifactRetention, &out.ArtifactRetention
+		*out = new(ArtifactRetention)
+		**out = **in
+	}
+	if in.QuarantinedTests != nil {
+		in, out := &in.QuarantinedTests, &out.QuarantinedTests
+		*out = make([]QuarantinedTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -266,6 +363,42 @@ func (in *GCSConfiguration) DeepCopy() *GCSConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GerritReporterConfig) DeepCopyInto(out *GerritReporterConfig) {
+	*out = *in
+	if in.Vote != nil {
+		in, out := &in.Vote, &out.Vote
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SuccessVote != nil {
+		in, out := &in.SuccessVote, &out.SuccessVote
+		*out = new(int)
+		**out = **in
+	}
+	if in.FailureVote != nil {
+		in, out := &in.FailureVote, &out.FailureVote
+		*out = new(int)
+		**out = **in
+	}
+	if in.Hashtags != nil {
+		in, out := &in.Hashtags, &out.Hashtags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GerritReporterConfig.
+func (in *GerritReporterConfig) DeepCopy() *GerritReporterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GerritReporterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubAppPrivateKeySecret) DeepCopyInto(out *GitHubAppPrivateKeySecret) {
 	*out = *in
@@ -282,6 +415,22 @@ func (in *GitHubAppPrivateKeySecret) DeepCopy() *GitHubAppPrivateKeySecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHubReporterConfig) DeepCopyInto(out *GitHubReporterConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHubReporterConfig.
+func (in *GitHubReporterConfig) DeepCopy() *GitHubReporterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHubReporterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubTeamSlug) DeepCopyInto(out *GitHubTeamSlug) {
 	*out = *in
@@ -298,6 +447,22 @@ func (in *GitHubTeamSlug) DeepCopy() *GitHubTeamSlug {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InRepoPipeline) DeepCopyInto(out *InRepoPipeline) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InRepoPipeline.
+func (in *InRepoPipeline) DeepCopy() *InRepoPipeline {
+	if in == nil {
+		return nil
+	}
+	out := new(InRepoPipeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JenkinsSpec) DeepCopyInto(out *JenkinsSpec) {
 	*out = *in
@@ -330,6 +495,23 @@ func (in *OauthTokenSecret) DeepCopy() *OauthTokenSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodPendingReason) DeepCopyInto(out *PodPendingReason) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodPendingReason.
+func (in *PodPendingReason) DeepCopy() *PodPendingReason {
+	if in == nil {
+		return nil
+	}
+	out := new(PodPendingReason)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProwJob) DeepCopyInto(out *ProwJob) {
 	*out = *in
@@ -427,6 +609,11 @@ func (in *ProwJobSpec) DeepCopyInto(out *ProwJobSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SchedulingGates != nil {
+		in, out := &in.SchedulingGates, &out.SchedulingGates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.PodSpec != nil {
 		in, out := &in.PodSpec, &out.PodSpec
 		*out = new(corev1.PodSpec)
@@ -452,6 +639,11 @@ func (in *ProwJobSpec) DeepCopyInto(out *ProwJobSpec) {
 		*out = new(DecorationConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ArtifactDestination != nil {
+		in, out := &in.ArtifactDestination, &out.ArtifactDestination
+		*out = new(ArtifactDestination)
+		**out = **in
+	}
 	if in.ReporterConfig != nil {
 		in, out := &in.ReporterConfig, &out.ReporterConfig
 		*out = new(ReporterConfig)
@@ -462,6 +654,11 @@ func (in *ProwJobSpec) DeepCopyInto(out *ProwJobSpec) {
 		*out = new(RerunAuthConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RerunOverrides != nil {
+		in, out := &in.RerunOverrides, &out.RerunOverrides
+		*out = new(RerunOverrides)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ProwJobDefault != nil {
 		in, out := &in.ProwJobDefault, &out.ProwJobDefault
 		*out = new(ProwJobDefault)
@@ -499,6 +696,16 @@ func (in *ProwJobStatus) DeepCopyInto(out *ProwJobStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.PodPendingReason != nil {
+		in, out := &in.PodPendingReason, &out.PodPendingReason
+		*out = new(PodPendingReason)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Queue != nil {
+		in, out := &in.Queue, &out.Queue
+		*out = new(QueueStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -549,6 +756,49 @@ func (in *Pull) DeepCopy() *Pull {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarantinedTest) DeepCopyInto(out *QuarantinedTest) {
+	*out = *in
+	in.Expires.DeepCopyInto(&out.Expires)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarantinedTest.
+func (in *QuarantinedTest) DeepCopy() *QuarantinedTest {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarantinedTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueueStatus) DeepCopyInto(out *QueueStatus) {
+	*out = *in
+	if in.EstimatedStartTime != nil {
+		in, out := &in.EstimatedStartTime, &out.EstimatedStartTime
+		*out = (*in).DeepCopy()
+	}
+	in.UpdateTime.DeepCopyInto(&out.UpdateTime)
+	if in.ReportedBy != nil {
+		in, out := &in.ReportedBy, &out.ReportedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueueStatus.
+func (in *QueueStatus) DeepCopy() *QueueStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QueueStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Refs) DeepCopyInto(out *Refs) {
 	*out = *in
@@ -562,6 +812,11 @@ func (in *Refs) DeepCopyInto(out *Refs) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.LFSFetch != nil {
+		in, out := &in.LFSFetch, &out.LFSFetch
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -583,6 +838,16 @@ func (in *ReporterConfig) DeepCopyInto(out *ReporterConfig) {
 		*out = new(SlackReporterConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Gerrit != nil {
+		in, out := &in.Gerrit, &out.Gerrit
+		*out = new(GerritReporterConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitHub != nil {
+		in, out := &in.GitHub, &out.GitHub
+		*out = new(GitHubReporterConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -619,6 +884,11 @@ func (in *RerunAuthConfig) DeepCopyInto(out *RerunAuthConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.OIDCGroups != nil {
+		in, out := &in.OIDCGroups, &out.OIDCGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -632,6 +902,32 @@ func (in *RerunAuthConfig) DeepCopy() *RerunAuthConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RerunOverrides) DeepCopyInto(out *RerunOverrides) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RerunOverrides.
+func (in *RerunOverrides) DeepCopy() *RerunOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(RerunOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
@@ -746,6 +1042,11 @@ func (in *TektonPipelineRunSpec) DeepCopyInto(out *TektonPipelineRunSpec) {
 		*out = new(pipelinev1.PipelineRunSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.InRepoPipeline != nil {
+		in, out := &in.InRepoPipeline, &out.InRepoPipeline
+		*out = new(InRepoPipeline)
+		**out = **in
+	}
 	return
 }
 
@@ -774,3 +1075,19 @@ func (in *UtilityImages) DeepCopy() *UtilityImages {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConfig) DeepCopyInto(out *VaultConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConfig.
+func (in *VaultConfig) DeepCopy() *VaultConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConfig)
+	in.DeepCopyInto(out)
+	return out
+}
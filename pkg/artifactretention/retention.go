@@ -0,0 +1,389 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package artifactretention deletes the artifacts of the builds of jobs
+// that expired according to the artifact retention of their decoration
+// config.
+package artifactretention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdio "io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+)
+
+const ControllerName = "artifact-retention"
+
+// syncInterval is how often the builds of all jobs are checked. Artifacts
+// usually expire after days, so there's no point in checking more often.
+const syncInterval = time.Hour
+
+var (
+	deletedBuilds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "artifact_retention_deleted_builds_total",
+		Help: "Number of builds whose artifacts were deleted because they expired, by job.",
+	}, []string{
+		"job",
+	})
+	deletedObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "artifact_retention_deleted_objects_total",
+		Help: "Number of artifacts deleted because their build expired, by job.",
+	}, []string{
+		"job",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(deletedBuilds)
+	prometheus.MustRegister(deletedObjects)
+}
+
+// opener is the part of io.Opener the controller needs.
+type opener interface {
+	Reader(ctx context.Context, path string) (io.ReadCloser, error)
+	Iterator(ctx context.Context, prefix, delimiter string) (io.ObjectIterator, error)
+	Delete(ctx context.Context, path string) error
+}
+
+// Add adds the controller to the manager.
+func Add(mgr controllerruntime.Manager, cfg config.Getter, opener io.Opener) error {
+	c := &controller{
+		config: cfg,
+		opener: opener,
+		log:    logrus.WithField("controller", ControllerName),
+		now:    time.Now,
+	}
+	if err := mgr.Add(manager.RunnableFunc(c.run)); err != nil {
+		return fmt.Errorf("failed to add %s runnable to manager: %w", ControllerName, err)
+	}
+	return nil
+}
+
+type controller struct {
+	config config.Getter
+	opener opener
+	log    *logrus.Entry
+	now    func() time.Time
+}
+
+func (c *controller) run(ctx context.Context) error {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.sync(ctx); err != nil {
+			c.log.WithError(err).Error("Failed to delete expired artifacts.")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// retainedJob is a job whose artifacts expire.
+type retainedJob struct {
+	name string
+	// root is the storage path under which the builds of the job have a
+	// directory each. For presubmits, these are the batch builds.
+	root string
+	// aliases is the storage path under which presubmits store a file per
+	// build that contains the path of the build.
+	aliases string
+	// pulls is the storage path under which presubmits store the builds of
+	// pull requests, which aliases must point into.
+	pulls     string
+	retention prowv1.ArtifactRetention
+}
+
+// retainedJobs returns the static jobs whose decoration config has an
+// artifact retention.
+func retainedJobs(cfg *config.Config) ([]retainedJob, error) {
+	var jobs []retainedJob
+	var errs []error
+	add := func(name string, dc *prowv1.DecorationConfig, presubmit bool) {
+		if dc == nil || dc.ArtifactRetention == nil || dc.GCSConfiguration == nil || dc.GCSConfiguration.Bucket == "" {
+			return
+		}
+		storagePath := func(p ...string) string {
+			storagePath, err := providers.StoragePath(dc.GCSConfiguration.Bucket, path.Join(append([]string{dc.GCSConfiguration.PathPrefix}, p...)...)+"/")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("job %s: %w", name, err))
+			}
+			return storagePath
+		}
+		job := retainedJob{name: name, retention: *dc.ArtifactRetention}
+		if presubmit {
+			job.root = storagePath(gcs.PRLogs, "pull", "batch", name)
+			job.aliases = storagePath(gcs.PRLogs, "directory", name)
+			job.pulls = storagePath(gcs.PRLogs, "pull")
+		} else {
+			job.root = storagePath(gcs.NonPRLogs, name)
+		}
+		if job.root != "" {
+			jobs = append(jobs, job)
+		}
+	}
+	for _, p := range cfg.AllStaticPresubmits(nil) {
+		add(p.Name, p.DecorationConfig, true)
+	}
+	for _, p := range cfg.AllStaticPostsubmits(nil) {
+		add(p.Name, p.DecorationConfig, false)
+	}
+	for _, p := range cfg.AllPeriodics() {
+		add(p.Name, p.DecorationConfig, false)
+	}
+	return jobs, utilerrors.NewAggregate(errs)
+}
+
+// build is a build of a job whose artifacts are stored under dir.
+type build struct {
+	id  int64
+	dir string
+	// alias is the file that points presubmits to dir, if any.
+	alias string
+}
+
+func (c *controller) sync(ctx context.Context) error {
+	jobs, err := retainedJobs(c.config())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, job := range jobs {
+		if err := c.syncJob(ctx, job); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) syncJob(ctx context.Context, job retainedJob) error {
+	log := c.log.WithField("job", job.name)
+	maxAge, err := job.retention.MaxAgeDuration()
+	if err != nil {
+		return err
+	}
+	builds, err := c.builds(ctx, job)
+	if err != nil {
+		return err
+	}
+	// Build IDs grow over time, so builds are sorted from the newest to the
+	// oldest.
+	sort.Slice(builds, func(i, j int) bool { return builds[i].id > builds[j].id })
+
+	var expired []build
+	if job.retention.KeepLast > 0 && len(builds) > job.retention.KeepLast {
+		expired = append(expired, builds[job.retention.KeepLast:]...)
+		builds = builds[:job.retention.KeepLast]
+	}
+	if maxAge > 0 {
+		// Walk from the oldest build until the first one that didn't
+		// expire, as all newer builds didn't either.
+		cutoff := c.now().Add(-maxAge)
+		for i := len(builds) - 1; i >= 0; i-- {
+			started, err := c.started(ctx, builds[i].dir)
+			if err != nil {
+				if io.IsNotExist(err) {
+					// Builds that didn't start yet or never will are
+					// left alone, as their age is unknown.
+					continue
+				}
+				return fmt.Errorf("failed to read start of build %d: %w", builds[i].id, err)
+			}
+			if started.After(cutoff) {
+				break
+			}
+			expired = append(expired, builds[i])
+		}
+	}
+
+	var errs []error
+	for _, b := range expired {
+		deleted, err := c.deleteBuild(ctx, b)
+		deletedObjects.WithLabelValues(job.name).Add(float64(deleted))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete build %d: %w", b.id, err))
+			continue
+		}
+		deletedBuilds.WithLabelValues(job.name).Inc()
+		log.WithFields(logrus.Fields{"build": b.id, "objects": deleted}).Info("Deleted artifacts of expired build.")
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// builds lists the builds of the job.
+func (c *controller) builds(ctx context.Context, job retainedJob) ([]build, error) {
+	var builds []build
+	err := c.iterate(ctx, job.root, "/", func(attrs io.ObjectAttributes) error {
+		if !attrs.IsDir {
+			// Like latest-build.txt.
+			return nil
+		}
+		id, err := strconv.ParseInt(path.Base(attrs.Name), 10, 64)
+		if err != nil {
+			return nil
+		}
+		builds = append(builds, build{id: id, dir: objectPath(job.root, attrs.Name)})
+		return nil
+	})
+	if err != nil || job.aliases == "" {
+		return builds, err
+	}
+	err = c.iterate(ctx, job.aliases, "/", func(attrs io.ObjectAttributes) error {
+		id, err := strconv.ParseInt(strings.TrimSuffix(attrs.ObjName, ".txt"), 10, 64)
+		if attrs.IsDir || err != nil {
+			return nil
+		}
+		alias := objectPath(job.aliases, attrs.Name)
+		content, err := c.read(ctx, alias)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", alias, err)
+		}
+		dir, err := aliasedDir(job, id, string(content))
+		if err != nil {
+			// Aliases are written by the jobs, so one pointing elsewhere
+			// could get the builds of other jobs deleted.
+			c.log.WithFields(logrus.Fields{"job": job.name, "alias": alias}).WithError(err).Warn("Skipping alias that doesn't point to a build of the job.")
+			return nil
+		}
+		builds = append(builds, build{id: id, dir: dir, alias: alias})
+		return nil
+	})
+	return builds, err
+}
+
+// aliasedDir returns the directory of the build the content of an alias
+// points to. It must be in the bucket of the job, under the pull requests
+// of its path prefix, and named after the build ID of the alias.
+func aliasedDir(job retainedJob, id int64, content string) (string, error) {
+	provider, bucket, dir, err := providers.ParseStoragePath(strings.TrimSpace(content))
+	if err != nil {
+		return "", err
+	}
+	pullsProvider, pullsBucket, pulls, err := providers.ParseStoragePath(job.pulls)
+	if err != nil {
+		return "", err
+	}
+	if provider != pullsProvider || bucket != pullsBucket {
+		return "", fmt.Errorf("the build is in %s://%s, not in the bucket of the job", provider, bucket)
+	}
+	dir = path.Clean(dir)
+	if !strings.HasPrefix(dir, pulls) {
+		return "", fmt.Errorf("the build %s isn't under %s", dir, pulls)
+	}
+	if path.Base(dir) != strconv.FormatInt(id, 10) {
+		return "", fmt.Errorf("the build %s isn't build %d", dir, id)
+	}
+	return fmt.Sprintf("%s://%s/%s/", provider, bucket, dir), nil
+}
+
+// objectPath turns the name of an object in the bucket of root, which is
+// relative to the bucket, into its storage path.
+func objectPath(root, name string) string {
+	provider, bucket, _, err := providers.ParseStoragePath(root)
+	if err != nil {
+		return name
+	}
+	return fmt.Sprintf("%s://%s/%s", provider, bucket, name)
+}
+
+func (c *controller) iterate(ctx context.Context, prefix, delimiter string, f func(io.ObjectAttributes) error) error {
+	it, err := c.opener.Iterator(ctx, prefix, delimiter)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	for {
+		attrs, err := it.Next(ctx)
+		if errors.Is(err, stdio.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		if err := f(attrs); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *controller) read(ctx context.Context, p string) ([]byte, error) {
+	r, err := c.opener.Reader(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return stdio.ReadAll(r)
+}
+
+// started returns when the build in dir started.
+func (c *controller) started(ctx context.Context, dir string) (time.Time, error) {
+	raw, err := c.read(ctx, dir+prowv1.StartedStatusFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var started metadata.Started
+	if err := json.Unmarshal(raw, &started); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse %s: %w", prowv1.StartedStatusFile, err)
+	}
+	return time.Unix(started.Timestamp, 0), nil
+}
+
+// deleteBuild deletes the artifacts of the build and returns how many
+// objects it deleted. The alias of presubmits is deleted last, so that
+// builds that fail to be deleted are found again on the next sync.
+func (c *controller) deleteBuild(ctx context.Context, b build) (int, error) {
+	var deleted int
+	err := c.iterate(ctx, b.dir, "", func(attrs io.ObjectAttributes) error {
+		if attrs.IsDir {
+			return nil
+		}
+		if err := c.opener.Delete(ctx, objectPath(b.dir, attrs.Name)); err != nil && !io.IsNotExist(err) {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+	if b.alias != "" {
+		if err := c.opener.Delete(ctx, b.alias); err != nil && !io.IsNotExist(err) {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactretention
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	stdio "io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+// fakeOpener stores objects under their full storage path.
+type fakeOpener struct {
+	objects map[string]string
+}
+
+func (f *fakeOpener) Reader(_ context.Context, path string) (io.ReadCloser, error) {
+	content, ok := f.objects[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return stdio.NopCloser(bytes.NewBufferString(content)), nil
+}
+
+func (f *fakeOpener) Delete(_ context.Context, path string) error {
+	if _, ok := f.objects[path]; !ok {
+		return os.ErrNotExist
+	}
+	delete(f.objects, path)
+	return nil
+}
+
+type fakeIterator []io.ObjectAttributes
+
+func (it *fakeIterator) Next(context.Context) (io.ObjectAttributes, error) {
+	if len(*it) == 0 {
+		return io.ObjectAttributes{}, stdio.EOF
+	}
+	attrs := (*it)[0]
+	*it = (*it)[1:]
+	return attrs, nil
+}
+
+func (f *fakeOpener) Iterator(_ context.Context, prefix, delimiter string) (io.ObjectIterator, error) {
+	bucket := prefix[:strings.Index(prefix[len("gs://"):], "/")+len("gs://")+1]
+	var it fakeIterator
+	dirs := sets.New[string]()
+	for path := range f.objects {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(path, bucket)
+		rest := strings.TrimPrefix(path, prefix)
+		if delimiter != "" && strings.Contains(rest, delimiter) {
+			dirs.Insert(strings.TrimPrefix(prefix, bucket) + rest[:strings.Index(rest, delimiter)+1])
+			continue
+		}
+		it = append(it, io.ObjectAttributes{Name: name, ObjName: name[strings.LastIndex(name, "/")+1:]})
+	}
+	for _, dir := range sets.List(dirs) {
+		it = append(it, io.ObjectAttributes{Name: dir, IsDir: true})
+	}
+	sort.Slice(it, func(i, j int) bool { return it[i].Name < it[j].Name })
+	return &it, nil
+}
+
+func TestSync(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	started := func(daysAgo int) string {
+		return fmt.Sprintf(`{"timestamp": %d}`, now.Add(-time.Duration(daysAgo)*24*time.Hour).Unix())
+	}
+	// Builds 1 to 4 of the periodic started 40, 31, 20 and 1 days ago.
+	objects := func() map[string]string {
+		return map[string]string{
+			"gs://bucket/logs/periodic/latest-build.txt":    "4",
+			"gs://bucket/logs/periodic/1/started.json":      started(40),
+			"gs://bucket/logs/periodic/1/build-log.txt":     "log",
+			"gs://bucket/logs/periodic/2/started.json":      started(31),
+			"gs://bucket/logs/periodic/2/artifacts/a/b.txt": "artifact",
+			"gs://bucket/logs/periodic/3/started.json":      started(20),
+			"gs://bucket/logs/periodic/4/started.json":      started(1),
+			"gs://bucket/logs/periodic/5/prowjob.json":      "{}",
+
+			"gs://bucket/pr-logs/directory/presubmit/10.txt":                "gs://bucket/pr-logs/pull/org_repo/1/presubmit/10",
+			"gs://bucket/pr-logs/pull/org_repo/1/presubmit/10/started.json": started(40),
+			"gs://bucket/pr-logs/directory/presubmit/12.txt":                "gs://bucket/pr-logs/pull/org_repo/2/presubmit/12",
+			"gs://bucket/pr-logs/pull/org_repo/2/presubmit/12/started.json": started(1),
+			"gs://bucket/pr-logs/pull/batch/presubmit/11/started.json":      started(35),
+
+			"gs://bucket/logs/other/1/started.json": started(40),
+		}
+	}
+	decoration := func(retention prowv1.ArtifactRetention) *prowv1.DecorationConfig {
+		return &prowv1.DecorationConfig{
+			GCSConfiguration:  &prowv1.GCSConfiguration{Bucket: "bucket"},
+			ArtifactRetention: &retention,
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		retention       prowv1.ArtifactRetention
+		expectedDeleted []string
+	}{
+		{
+			name:      "builds older than the max age are deleted",
+			retention: prowv1.ArtifactRetention{MaxAge: "30d"},
+			expectedDeleted: []string{
+				"gs://bucket/logs/periodic/1/build-log.txt",
+				"gs://bucket/logs/periodic/1/started.json",
+				"gs://bucket/logs/periodic/2/artifacts/a/b.txt",
+				"gs://bucket/logs/periodic/2/started.json",
+				"gs://bucket/pr-logs/directory/presubmit/10.txt",
+				"gs://bucket/pr-logs/pull/batch/presubmit/11/started.json",
+				"gs://bucket/pr-logs/pull/org_repo/1/presubmit/10/started.json",
+			},
+		},
+		{
+			name:      "only the last builds are kept",
+			retention: prowv1.ArtifactRetention{KeepLast: 2},
+			expectedDeleted: []string{
+				"gs://bucket/logs/periodic/1/build-log.txt",
+				"gs://bucket/logs/periodic/1/started.json",
+				"gs://bucket/logs/periodic/2/artifacts/a/b.txt",
+				"gs://bucket/logs/periodic/2/started.json",
+				"gs://bucket/logs/periodic/3/started.json",
+				"gs://bucket/pr-logs/directory/presubmit/10.txt",
+				"gs://bucket/pr-logs/pull/org_repo/1/presubmit/10/started.json",
+			},
+		},
+		{
+			name:      "builds beyond either limit are deleted",
+			retention: prowv1.ArtifactRetention{MaxAge: "720h", KeepLast: 4},
+			expectedDeleted: []string{
+				"gs://bucket/logs/periodic/1/build-log.txt",
+				"gs://bucket/logs/periodic/1/started.json",
+				"gs://bucket/logs/periodic/2/artifacts/a/b.txt",
+				"gs://bucket/logs/periodic/2/started.json",
+				"gs://bucket/pr-logs/directory/presubmit/10.txt",
+				"gs://bucket/pr-logs/pull/batch/presubmit/11/started.json",
+				"gs://bucket/pr-logs/pull/org_repo/1/presubmit/10/started.json",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{JobConfig: config.JobConfig{
+				PresubmitsStatic: map[string][]config.Presubmit{
+					"org/repo": {{JobBase: config.JobBase{Name: "presubmit", UtilityConfig: config.UtilityConfig{DecorationConfig: decoration(tc.retention)}}}},
+				},
+				Periodics: []config.Periodic{
+					{JobBase: config.JobBase{Name: "periodic", UtilityConfig: config.UtilityConfig{DecorationConfig: decoration(tc.retention)}}},
+					{JobBase: config.JobBase{Name: "other", UtilityConfig: config.UtilityConfig{DecorationConfig: &prowv1.DecorationConfig{GCSConfiguration: &prowv1.GCSConfiguration{Bucket: "bucket"}}}}},
+				},
+			}}
+			opener := &fakeOpener{objects: objects()}
+			c := &controller{
+				config: func() *config.Config { return cfg },
+				opener: opener,
+				log:    logrus.WithField("test", t.Name()),
+				now:    func() time.Time { return now },
+			}
+			if err := c.sync(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var deleted []string
+			for path := range objects() {
+				if _, ok := opener.objects[path]; !ok {
+					deleted = append(deleted, path)
+				}
+			}
+			sort.Strings(deleted)
+			if diff := cmp.Diff(tc.expectedDeleted, deleted); diff != "" {
+				t.Errorf("unexpected deleted objects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSyncSkipsBadAliases(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	started := fmt.Sprintf(`{"timestamp": %d}`, now.Add(-40*24*time.Hour).Unix())
+	objects := map[string]string{
+		// Aliases pointing outside the builds of pull requests, into another
+		// bucket or to another build.
+		"gs://bucket/pr-logs/directory/presubmit/1.txt":                      "gs://bucket/logs/periodic/1",
+		"gs://bucket/pr-logs/directory/presubmit/2.txt":                      "gs://bucket/pr-logs/pull/../../logs/periodic/2",
+		"gs://bucket/pr-logs/directory/presubmit/3.txt":                      "gs://other-bucket/pr-logs/pull/org_repo/1/presubmit/3",
+		"gs://bucket/pr-logs/directory/presubmit/4.txt":                      "gs://bucket/pr-logs/pull/org_repo/1/presubmit/5",
+		"gs://bucket/pr-logs/directory/presubmit/6.txt":                      "gs://bucket/pr-logs/pull/org_repo/1/presubmit/6",
+		"gs://bucket/logs/periodic/1/started.json":                           started,
+		"gs://bucket/logs/periodic/2/started.json":                           started,
+		"gs://other-bucket/pr-logs/pull/org_repo/1/presubmit/3/started.json": started,
+		"gs://bucket/pr-logs/pull/org_repo/1/presubmit/5/started.json":       started,
+		"gs://bucket/pr-logs/pull/org_repo/1/presubmit/6/started.json":       started,
+	}
+	cfg := &config.Config{JobConfig: config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo": {{JobBase: config.JobBase{Name: "presubmit", UtilityConfig: config.UtilityConfig{DecorationConfig: &prowv1.DecorationConfig{
+				GCSConfiguration:  &prowv1.GCSConfiguration{Bucket: "bucket"},
+				ArtifactRetention: &prowv1.ArtifactRetention{MaxAge: "30d"},
+			}}}}},
+		},
+	}}
+	opener := &fakeOpener{objects: map[string]string{}}
+	for path, content := range objects {
+		opener.objects[path] = content
+	}
+	c := &controller{
+		config: func() *config.Config { return cfg },
+		opener: opener,
+		log:    logrus.WithField("test", t.Name()),
+		now:    func() time.Time { return now },
+	}
+	if err := c.sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var deleted []string
+	for path := range objects {
+		if _, ok := opener.objects[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(deleted)
+	expectedDeleted := []string{
+		"gs://bucket/pr-logs/directory/presubmit/6.txt",
+		"gs://bucket/pr-logs/pull/org_repo/1/presubmit/6/started.json",
+	}
+	if diff := cmp.Diff(expectedDeleted, deleted); diff != "" {
+		t.Errorf("unexpected deleted objects (-want +got):\n%s", diff)
+	}
+}
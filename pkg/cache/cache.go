@@ -19,6 +19,7 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/golang-lru/simplelru"
 	"github.com/sirupsen/logrus"
@@ -92,12 +93,18 @@ type Promise struct {
 	valConstructionPending chan struct{}
 	val                    interface{}
 	err                    error
+
+	// added and hits are for introspection, hits is protected by the lock of
+	// the LRUCache.
+	added time.Time
+	hits  int
 }
 
 func newPromise(valConstructor ValConstructor) *Promise {
 	return &Promise{
 		valConstructor:         valConstructor,
 		valConstructionPending: make(chan struct{}),
+		added:                  time.Now(),
 	}
 }
 
@@ -155,6 +162,9 @@ func (lruCache *LRUCache) GetOrAdd(
 	maybePromise, promisePending := lruCache.Get(key)
 
 	if promisePending {
+		if p, isPromise := maybePromise.(*Promise); isPromise {
+			p.hits++
+		}
 		// A promise exists, BUT the wrapped value inside it (p.val) might
 		// not be written to yet by the thread that is actually resolving the
 		// promise.
@@ -275,3 +285,52 @@ func (lruCache *LRUCache) GetOrAdd(
 
 	return promise.val, ok, promise.err
 }
+
+// Entry describes an entry of an LRUCache.
+type Entry struct {
+	Key interface{}
+	// Added is when the value of the entry started being constructed.
+	Added time.Time
+	// Hits is how many lookups found the entry.
+	Hits int
+	// Pending is whether the value is still being constructed.
+	Pending bool
+}
+
+// Entries returns the entries of the cache, from the least to the most
+// recently used, without affecting their recency.
+func (lruCache *LRUCache) Entries() []Entry {
+	lruCache.Lock()
+	defer lruCache.Unlock()
+	var entries []Entry
+	for _, key := range lruCache.Keys() {
+		maybePromise, ok := lruCache.Peek(key)
+		if !ok {
+			continue
+		}
+		entry := Entry{Key: key}
+		if promise, isPromise := maybePromise.(*Promise); isPromise {
+			entry.Added = promise.added
+			entry.Hits = promise.hits
+			select {
+			case <-promise.valConstructionPending:
+			default:
+				entry.Pending = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Invalidate removes the entry of the key from the cache, so that the next
+// lookup constructs its value again. It returns whether the key was cached.
+func (lruCache *LRUCache) Invalidate(key interface{}) bool {
+	lruCache.Lock()
+	removed := lruCache.Remove(key)
+	lruCache.Unlock()
+	if removed && lruCache.callbacks.ManualEvictionsCallback != nil {
+		lruCache.callbacks.ManualEvictionsCallback(key)
+	}
+	return removed
+}
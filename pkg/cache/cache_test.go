@@ -546,3 +546,48 @@ func TestCallbacks(t *testing.T) {
 		})
 	}
 }
+
+func TestEntriesAndInvalidate(t *testing.T) {
+	manualEvictions := 0
+	lruCache, err := NewLRUCache(3, Callbacks{ManualEvictionsCallback: func(key interface{}) { manualEvictions++ }})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	constructor := func() (interface{}, error) { return "val", nil }
+	for _, key := range []string{"a", "b", "a", "a"} {
+		if _, _, err := lruCache.GetOrAdd(key, constructor); err != nil {
+			t.Fatalf("failed to add %q: %v", key, err)
+		}
+	}
+
+	entries := lruCache.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	// "b" was used less recently than "a".
+	for i, expected := range []Entry{{Key: "b", Hits: 0}, {Key: "a", Hits: 2}} {
+		if entries[i].Key != expected.Key || entries[i].Hits != expected.Hits || entries[i].Pending {
+			t.Errorf("expected entry %d to be %+v, got %+v", i, expected, entries[i])
+		}
+		if entries[i].Added.IsZero() {
+			t.Errorf("expected entry %d to have the time it was added", i)
+		}
+	}
+	// Listing the entries doesn't make "b" the most recently used.
+	if entries := lruCache.Entries(); entries[0].Key != "b" {
+		t.Errorf("expected listing to keep the order of the entries, got %+v", entries)
+	}
+
+	if !lruCache.Invalidate("a") {
+		t.Error("expected \"a\" to be invalidated")
+	}
+	if lruCache.Invalidate("c") {
+		t.Error("expected \"c\" not to be cached")
+	}
+	if manualEvictions != 1 {
+		t.Errorf("expected 1 manual eviction, got %d", manualEvictions)
+	}
+	if entries := lruCache.Entries(); len(entries) != 1 || entries[0].Key != "b" {
+		t.Errorf("expected only \"b\" to be left, got %+v", entries)
+	}
+}
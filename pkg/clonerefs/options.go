@@ -27,6 +27,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/cache"
 	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 )
 
@@ -73,6 +74,10 @@ type Options struct {
 	GitHubAppID             string   `json:"github_app_id,omitempty"`
 	GitHubAppPrivateKeyFile string   `json:"github_app_private_key_file,omitempty"`
 
+	// Caches configures the caches that are restored once
+	// the refs are cloned. Optional.
+	Caches *cache.Options `json:"caches,omitempty"`
+
 	// used to hold flag values
 	refs      gitRefs
 	clonePath orgRepoFormat
@@ -124,6 +129,12 @@ func (o *Options) Validate() error {
 		return errors.New("no GitHub App ID specified")
 	}
 
+	if o.Caches != nil {
+		if err := o.Caches.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
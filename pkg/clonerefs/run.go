@@ -17,6 +17,7 @@ limitations under the License.
 package clonerefs
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/rsa"
 	"encoding/json"
@@ -35,7 +36,10 @@ import (
 	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 )
 
-var cloneFunc = clone.Run
+var (
+	cloneFunc    = clone.Run
+	diagnoseFunc = clone.Diagnose
+)
 
 func (o *Options) createRecords() []clone.Record {
 	var rec clone.Record
@@ -138,7 +142,11 @@ func (o *Options) createRecords() []clone.Record {
 		go func() {
 			defer wg.Done()
 			for ref := range input {
-				output <- cloneFunc(ref, o.SrcRoot, o.GitUserName, o.GitUserEmail, o.CookiePath, env, userGenerator, tokenGenerator)
+				record := cloneFunc(ref, o.SrcRoot, o.GitUserName, o.GitUserEmail, o.CookiePath, env, userGenerator, tokenGenerator)
+				if record.Failed {
+					record.Diagnostics = diagnose(ref)
+				}
+				output <- record
 			}
 		}()
 	}
@@ -158,6 +166,23 @@ func (o *Options) createRecords() []clone.Record {
 	return results
 }
 
+// diagnose checks the network to the git host of refs that failed to
+// clone, so network problems can be told apart from problems with the repo.
+func diagnose(refs prowapi.Refs) *clone.Diagnostics {
+	diagnostics := diagnoseFunc(context.Background(), refs)
+	l := logrus.WithFields(logrus.Fields{"remote": diagnostics.Remote, "host": diagnostics.Host})
+	for name, check := range map[string]*clone.Check{"dns": diagnostics.DNS, "tcp": diagnostics.TCP, "tls": diagnostics.TLS, "http": diagnostics.HTTP} {
+		if check != nil {
+			l = l.WithField(name, check.Result+check.Error)
+		}
+	}
+	if diagnostics.Error != "" {
+		l = l.WithField("error", diagnostics.Error)
+	}
+	l.Info("Diagnosed the network to the git host.")
+	return diagnostics
+}
+
 // Run clones the configured refs
 func (o Options) Run() error {
 	results := o.createRecords()
@@ -177,6 +202,14 @@ func (o Options) Run() error {
 		}
 	}
 
+	// A cache that can't be restored only slows the test down, so don't
+	// fail the job because of it.
+	if o.Caches != nil {
+		if err := o.Caches.Restore(context.Background(), o.GitRefs[0], clone.PathForRefs(o.SrcRoot, o.GitRefs[0])); err != nil {
+			logrus.WithError(err).Warn("Failed to restore caches.")
+		}
+	}
+
 	if o.Fail && failed > 0 {
 		return fmt.Errorf("%d clone records failed", failed)
 	}
@@ -17,6 +17,7 @@ limitations under the License.
 package clonerefs
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -117,6 +118,12 @@ func TestRun(t *testing.T) {
 		return clone.Record{}
 	}
 	defer func() { cloneFunc = cloneFuncOld }()
+	diagnoseFuncOld := diagnoseFunc
+	diagnoseFunc = func(_ context.Context, refs prowapi.Refs) *clone.Diagnostics {
+		t.Errorf("unexpected diagnosis of %s/%s, no clone failed", refs.Org, refs.Repo)
+		return &clone.Diagnostics{}
+	}
+	defer func() { diagnoseFunc = diagnoseFuncOld }()
 
 	testcases := []struct {
 		name           string
@@ -352,6 +359,43 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunDiagnosesFailedClones(t *testing.T) {
+	cloneFuncOld, diagnoseFuncOld := cloneFunc, diagnoseFunc
+	defer func() { cloneFunc, diagnoseFunc = cloneFuncOld, diagnoseFuncOld }()
+	cloneFunc = func(refs prowapi.Refs, _, _, _, _ string, _ []string, _ github.UserGenerator, _ github.TokenGenerator) clone.Record {
+		return clone.Record{Refs: refs, Failed: refs.Repo == "broken"}
+	}
+	diagnoseFunc = func(_ context.Context, refs prowapi.Refs) *clone.Diagnostics {
+		return &clone.Diagnostics{Remote: refs.Repo, DNS: &clone.Check{Error: "no such host"}}
+	}
+
+	dir := t.TempDir()
+	opts := Options{
+		SrcRoot: dir,
+		Log:     filepath.Join(dir, "log.txt"),
+		GitRefs: []prowapi.Refs{{Org: "org", Repo: "broken"}, {Org: "org", Repo: "fine"}},
+	}
+	if err := opts.Run(); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+	raw, err := os.ReadFile(opts.Log)
+	if err != nil {
+		t.Fatalf("failed to read clone records: %v", err)
+	}
+	var records []clone.Record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		t.Fatalf("failed to unmarshal clone records: %v", err)
+	}
+	for _, record := range records {
+		switch diagnosed := record.Diagnostics != nil; {
+		case record.Failed && !diagnosed:
+			t.Errorf("expected failed record of %q to be diagnosed", record.Refs.Repo)
+		case !record.Failed && diagnosed:
+			t.Errorf("expected record of %q not to be diagnosed", record.Refs.Repo)
+		}
+	}
+}
+
 func TestNeedsGlobalCookiePath(t *testing.T) {
 	cases := []struct {
 		name       string
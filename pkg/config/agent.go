@@ -44,6 +44,12 @@ type Agent struct {
 	mut           sync.RWMutex // do not export Lock, etc methods
 	c             *Config
 	subscriptions []DeltaChan
+	notifications []chan struct{}
+
+	// loadMut serializes loads, so that the job config shards that were
+	// read are committed by the load that read them.
+	loadMut sync.Mutex
+	shards  jobConfigShards
 }
 
 // IsConfigMapMount determines whether the provided directory is a configmap mounted directory
@@ -202,7 +208,7 @@ func ListCMsAndDirs(path string) (cms sets.Set[string], dirs sets.Set[string], e
 
 func watchConfigs(ca *Agent, prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) error {
 	cmEventFunc := func() error {
-		c, err := Load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+		c, err := ca.load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 		if err != nil {
 			return err
 		}
@@ -211,7 +217,7 @@ func watchConfigs(ca *Agent, prowConfig, jobConfig string, supplementalProwConfi
 	}
 	// We may need to add more directories to be watched
 	dirsEventFunc := func(w *fsnotify.Watcher) error {
-		c, err := Load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+		c, err := ca.load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 		if err != nil {
 			return err
 		}
@@ -268,6 +274,14 @@ func watchConfigs(ca *Agent, prowConfig, jobConfig string, supplementalProwConfi
 			}
 		}
 	}
+	for _, supplementalProwConfigDir := range supplementalProwConfigDirs {
+		supplementalCMs, supplementalDirs, err := ListCMsAndDirs(supplementalProwConfigDir)
+		if err != nil {
+			return err
+		}
+		cms = cms.Union(supplementalCMs)
+		dirs = dirs.Union(supplementalDirs)
+	}
 	// The prow config is always a single file
 	if prowIsCMMounted, err := IsConfigMapMount(filepath.Dir(prowConfig)); err != nil {
 		return err
@@ -299,15 +313,21 @@ func watchConfigs(ca *Agent, prowConfig, jobConfig string, supplementalProwConfi
 
 // StartWatch will begin watching the config files at the provided paths. If the
 // first load fails, Start will return the error and abort. Future load failures
-// will log the failure message but continue attempting to load.
+// will log the failure message but continue attempting to load. If the files
+// cannot be watched, StartWatch falls back to polling them like Start.
 // This function will replace Start in a future release.
 func (ca *Agent) StartWatch(prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) error {
-	c, err := Load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	c, err := ca.load(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 	if err != nil {
 		return err
 	}
 	ca.Set(c)
-	watchConfigs(ca, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	if err := watchConfigs(ca, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...); err != nil {
+		logrus.WithField("prowConfig", prowConfig).
+			WithField("jobConfig", jobConfig).
+			WithError(err).Warn("Failed to watch the config files, polling them instead.")
+		go ca.poll(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	}
 	return nil
 }
 
@@ -339,43 +359,61 @@ func lastConfigModTime(prowConfig, jobConfig string) (time.Time, error) {
 // fails, Start will return the error and abort. Future load failures will log
 // the failure message but continue attempting to load.
 func (ca *Agent) Start(prowConfig, jobConfig string, additionalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) error {
-	lastModTime, err := lastConfigModTime(prowConfig, jobConfig)
-	if err != nil {
-		lastModTime = time.Time{}
-	}
-	c, err := Load(prowConfig, jobConfig, additionalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	c, err := ca.load(prowConfig, jobConfig, additionalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 	if err != nil {
 		return err
 	}
 	ca.Set(c)
-	go func() {
-		// Rarely, if two changes happen in the same second, mtime will
-		// be the same for the second change, and an mtime-based check would
-		// fail. Reload periodically just in case.
-		skips := 0
-		for range time.Tick(1 * time.Second) {
-			if skips < 600 {
-				recentModTime, err := lastConfigModTime(prowConfig, jobConfig)
-				if err != nil {
-					continue
-				}
-				if !recentModTime.After(lastModTime) {
-					skips++
-					continue // file hasn't been modified
-				}
-				lastModTime = recentModTime
+	go ca.poll(prowConfig, jobConfig, additionalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	return nil
+}
+
+// poll reloads the config whenever the modification time of the config files
+// changes, and every ten minutes regardless.
+func (ca *Agent) poll(prowConfig, jobConfig string, additionalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) {
+	lastModTime, err := lastConfigModTime(prowConfig, jobConfig)
+	if err != nil {
+		lastModTime = time.Time{}
+	}
+	// Rarely, if two changes happen in the same second, mtime will
+	// be the same for the second change, and an mtime-based check would
+	// fail. Reload periodically just in case.
+	skips := 0
+	for range time.Tick(1 * time.Second) {
+		if skips < 600 {
+			recentModTime, err := lastConfigModTime(prowConfig, jobConfig)
+			if err != nil {
+				continue
 			}
-			if c, err := Load(prowConfig, jobConfig, additionalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...); err != nil {
-				logrus.WithField("prowConfig", prowConfig).
-					WithField("jobConfig", jobConfig).
-					WithError(err).Error("Error loading config.")
-			} else {
-				skips = 0
-				ca.Set(c)
+			if !recentModTime.After(lastModTime) {
+				skips++
+				continue // file hasn't been modified
 			}
+			lastModTime = recentModTime
 		}
-	}()
-	return nil
+		if c, err := ca.load(prowConfig, jobConfig, additionalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...); err != nil {
+			logrus.WithField("prowConfig", prowConfig).
+				WithField("jobConfig", jobConfig).
+				WithError(err).Error("Error loading config.")
+		} else {
+			skips = 0
+			ca.Set(c)
+		}
+	}
+}
+
+// load loads the config like Load, but reads the job config by shard if the
+// config enables it, replacing the shards that can't be read with the last
+// version the Agent loaded.
+func (ca *Agent) load(prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) (*Config, error) {
+	ca.loadMut.Lock()
+	defer ca.loadMut.Unlock()
+	c, err := loadWithYamlOpts(nil, &ca.shards, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	if err != nil {
+		return nil, err
+	}
+	ca.shards.commit()
+	return c, nil
 }
 
 // Subscribe registers the channel for messages on config reload.
@@ -388,6 +426,19 @@ func (ca *Agent) Subscribe(subscription DeltaChan) {
 	ca.subscriptions = append(ca.subscriptions, subscription)
 }
 
+// Notify returns a channel that receives a value whenever a new configuration
+// is loaded, so that components can react to config changes right away
+// instead of on their next resync. Unlike Subscribe, notifications never
+// block the Agent: while the receiver is busy, further changes are coalesced
+// into the single pending notification. Use Config to get the new config.
+func (ca *Agent) Notify() <-chan struct{} {
+	ca.mut.Lock()
+	defer ca.mut.Unlock()
+	notification := make(chan struct{}, 1)
+	ca.notifications = append(ca.notifications, notification)
+	return notification
+}
+
 // Getter returns the current Config in a thread-safe manner.
 type Getter func() *Config
 
@@ -421,6 +472,12 @@ func (ca *Agent) Set(c *Config) {
 			}
 		}(subscription)
 	}
+	for _, notification := range ca.notifications {
+		select {
+		case notification <- struct{}{}:
+		default: // a notification is pending already
+		}
+	}
 }
 
 // SetWithoutBroadcast sets the config, but does not broadcast the event to
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+)
+
+func TestAgentNotify(t *testing.T) {
+	ca := &Agent{}
+	first, second := ca.Notify(), ca.Notify()
+
+	select {
+	case <-first:
+		t.Fatal("expected no notification before the config is set")
+	default:
+	}
+
+	// Changes are coalesced while the subscriber is busy.
+	ca.Set(&Config{ProwConfig: ProwConfig{ProwJobNamespace: "one"}})
+	ca.Set(&Config{ProwConfig: ProwConfig{ProwJobNamespace: "two"}})
+	for name, notification := range map[string]<-chan struct{}{"first": first, "second": second} {
+		select {
+		case <-notification:
+		default:
+			t.Errorf("expected the %s subscriber to be notified", name)
+		}
+		select {
+		case <-notification:
+			t.Errorf("expected the %s subscriber to be notified only once", name)
+		default:
+		}
+	}
+	if ns := ca.Config().ProwJobNamespace; ns != "two" {
+		t.Errorf("expected the latest config to be set, got namespace %q", ns)
+	}
+
+	ca.SetWithoutBroadcast(&Config{})
+	select {
+	case <-first:
+		t.Error("expected no notification when setting the config without broadcast")
+	default:
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// AllowedArtifactDestination allows runs to upload their artifacts to a
+// bucket other than that of their job.
+type AllowedArtifactDestination struct {
+	// Bucket is the bucket runs may upload to, like gs://bucket or
+	// s3://bucket. Buckets without a scheme are GCS buckets.
+	Bucket string `json:"bucket"`
+	// PathPrefixes restricts the path prefixes runs may use in the bucket to
+	// these and the paths below them. Defaults to any path prefix.
+	PathPrefixes []string `json:"path_prefixes,omitempty"`
+}
+
+func (a AllowedArtifactDestination) allows(dest prowapi.ArtifactDestination) bool {
+	if normalizeBucket(a.Bucket) != normalizeBucket(dest.Bucket) {
+		return false
+	}
+	if len(a.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range a.PathPrefixes {
+		prefix = strings.Trim(prefix, "/")
+		if dest.PathPrefix == prefix || strings.HasPrefix(dest.PathPrefix, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBucket adds the gs:// scheme to buckets without one.
+func normalizeBucket(bucket string) string {
+	if !strings.Contains(bucket, "://") {
+		return "gs://" + bucket
+	}
+	return bucket
+}
+
+// validateArtifactDestination checks that the path prefix of the destination
+// can't escape it.
+func validateArtifactDestination(dest prowapi.ArtifactDestination) error {
+	if dest.Bucket == "" {
+		return errors.New("bucket must be set")
+	}
+	if dest.PathPrefix != "" && (path.Clean(dest.PathPrefix) != dest.PathPrefix || strings.HasPrefix(dest.PathPrefix, "/") || strings.HasPrefix(dest.PathPrefix, "..")) {
+		return fmt.Errorf("path prefix %q must be a clean relative path", dest.PathPrefix)
+	}
+	return nil
+}
+
+func (p Plank) validateAllowedArtifactDestinations() error {
+	for i, allowed := range p.AllowedArtifactDestinations {
+		if err := validateArtifactDestination(prowapi.ArtifactDestination{Bucket: allowed.Bucket}); err != nil {
+			return fmt.Errorf("allowed_artifact_destinations[%d]: %w", i, err)
+		}
+		for _, prefix := range allowed.PathPrefixes {
+			if err := validateArtifactDestination(prowapi.ArtifactDestination{Bucket: allowed.Bucket, PathPrefix: strings.Trim(prefix, "/")}); err != nil {
+				return fmt.Errorf("allowed_artifact_destinations[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyArtifactDestination makes the run of the spec upload its artifacts to
+// the destination instead of the bucket and path prefix of its job. The
+// destination must be allowed by allowed_artifact_destinations and the job
+// must be decorated.
+func (p Plank) ApplyArtifactDestination(spec *prowapi.ProwJobSpec, dest prowapi.ArtifactDestination) error {
+	if err := validateArtifactDestination(dest); err != nil {
+		return fmt.Errorf("invalid artifact destination: %w", err)
+	}
+	var allowed bool
+	for _, a := range p.AllowedArtifactDestinations {
+		if a.allows(dest) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("artifact destination %s is not allowed by plank.allowed_artifact_destinations", strings.TrimSuffix(dest.Bucket+"/"+dest.PathPrefix, "/"))
+	}
+	if spec.DecorationConfig == nil || spec.DecorationConfig.GCSConfiguration == nil {
+		return errors.New("the artifact destination can only be set for decorated jobs")
+	}
+	spec.DecorationConfig = spec.DecorationConfig.DeepCopy()
+	spec.DecorationConfig.GCSConfiguration.Bucket = dest.Bucket
+	spec.DecorationConfig.GCSConfiguration.PathPrefix = dest.PathPrefix
+	spec.ArtifactDestination = &dest
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestApplyArtifactDestination(t *testing.T) {
+	plank := Plank{AllowedArtifactDestinations: []AllowedArtifactDestination{
+		{Bucket: "experiments"},
+		{Bucket: "s3://releases", PathPrefixes: []string{"/builds/"}},
+	}}
+	decorated := func() prowapi.ProwJobSpec {
+		return prowapi.ProwJobSpec{DecorationConfig: &prowapi.DecorationConfig{
+			GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "ci", PathPrefix: "jobs", PathStrategy: prowapi.PathStrategyExplicit},
+		}}
+	}
+
+	testCases := []struct {
+		name        string
+		spec        prowapi.ProwJobSpec
+		dest        prowapi.ArtifactDestination
+		expected    *prowapi.GCSConfiguration
+		expectedErr string
+	}{
+		{
+			name:     "any path prefix in the bucket",
+			spec:     decorated(),
+			dest:     prowapi.ArtifactDestination{Bucket: "gs://experiments", PathPrefix: "alice/flakes"},
+			expected: &prowapi.GCSConfiguration{Bucket: "gs://experiments", PathPrefix: "alice/flakes", PathStrategy: prowapi.PathStrategyExplicit},
+		},
+		{
+			name:     "allowed path prefix",
+			spec:     decorated(),
+			dest:     prowapi.ArtifactDestination{Bucket: "s3://releases", PathPrefix: "builds/v1.2.3"},
+			expected: &prowapi.GCSConfiguration{Bucket: "s3://releases", PathPrefix: "builds/v1.2.3", PathStrategy: prowapi.PathStrategyExplicit},
+		},
+		{
+			name:        "path prefix outside the allowed ones",
+			spec:        decorated(),
+			dest:        prowapi.ArtifactDestination{Bucket: "s3://releases", PathPrefix: "builds-old"},
+			expectedErr: "artifact destination s3://releases/builds-old is not allowed by plank.allowed_artifact_destinations",
+		},
+		{
+			name:        "escaping path prefix",
+			spec:        decorated(),
+			dest:        prowapi.ArtifactDestination{Bucket: "s3://releases", PathPrefix: "builds/../secrets"},
+			expectedErr: `invalid artifact destination: path prefix "builds/../secrets" must be a clean relative path`,
+		},
+		{
+			name:        "bucket not allowed",
+			spec:        decorated(),
+			dest:        prowapi.ArtifactDestination{Bucket: "gs://ci-private"},
+			expectedErr: "artifact destination gs://ci-private is not allowed by plank.allowed_artifact_destinations",
+		},
+		{
+			name:        "undecorated job",
+			dest:        prowapi.ArtifactDestination{Bucket: "experiments"},
+			expectedErr: "the artifact destination can only be set for decorated jobs",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobDecoration := tc.spec.DecorationConfig
+			err := plank.ApplyArtifactDestination(&tc.spec, tc.dest)
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, tc.spec.DecorationConfig.GCSConfiguration); diff != "" {
+				t.Errorf("unexpected GCS configuration (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(&tc.dest, tc.spec.ArtifactDestination); diff != "" {
+				t.Errorf("unexpected recorded artifact destination (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(decorated().DecorationConfig, jobDecoration); diff != "" {
+				t.Errorf("decoration config of the job was modified (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateAllowedArtifactDestinations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		allowed     []AllowedArtifactDestination
+		expectedErr string
+	}{
+		{
+			name:    "valid",
+			allowed: []AllowedArtifactDestination{{Bucket: "gs://experiments", PathPrefixes: []string{"team/"}}},
+		},
+		{
+			name:        "missing bucket",
+			allowed:     []AllowedArtifactDestination{{PathPrefixes: []string{"team"}}},
+			expectedErr: "allowed_artifact_destinations[0]: bucket must be set",
+		},
+		{
+			name:        "unclean path prefix",
+			allowed:     []AllowedArtifactDestination{{Bucket: "experiments", PathPrefixes: []string{"team//runs"}}},
+			expectedErr: `allowed_artifact_destinations[0]: path prefix "team//runs" must be a clean relative path`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Plank{AllowedArtifactDestinations: tc.allowed}.validateAllowedArtifactDestinations()
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -57,6 +58,9 @@ type Policy struct {
 	// Include specifies a set of regular expressions which identify branches
 	// that should be included from the protection policy, mutually exclusive with Exclude
 	Include []string `json:"include,omitempty"`
+	// Ruleset protects branches with repository rulesets instead of the legacy
+	// branch protection.
+	Ruleset *RulesetPolicy `json:"ruleset,omitempty"`
 }
 
 // Managed returns true if Unmanaged is false in the policy
@@ -77,6 +81,32 @@ type ContextPolicy struct {
 	Contexts []string `json:"contexts,omitempty"`
 	// Strict overrides whether new commits in the base branch require updating the PR if set
 	Strict *bool `json:"strict,omitempty"`
+	// UnmanagedContexts configures what happens to contexts that are required on GitHub,
+	// but neither listed in Contexts nor produced by Prow jobs, e.g. contexts of other CI systems.
+	// Can be "strip" (default) to remove them, "preserve" to keep them or "warn" to keep them and
+	// log a warning. Overrides the parent policy if set.
+	UnmanagedContexts UnmanagedContextsPolicy `json:"unmanaged_contexts,omitempty"`
+}
+
+// UnmanagedContextsPolicy determines how required contexts that are not managed by Prow are handled.
+type UnmanagedContextsPolicy string
+
+const (
+	// UnmanagedContextsStrip removes contexts that are not managed by Prow from the required contexts.
+	UnmanagedContextsStrip UnmanagedContextsPolicy = "strip"
+	// UnmanagedContextsPreserve keeps contexts that are not managed by Prow in the required contexts.
+	UnmanagedContextsPreserve UnmanagedContextsPolicy = "preserve"
+	// UnmanagedContextsWarn keeps contexts that are not managed by Prow in the required contexts and
+	// logs a warning, so that they can be added to the config.
+	UnmanagedContextsWarn UnmanagedContextsPolicy = "warn"
+)
+
+// Unmanaged returns the policy for unmanaged contexts, defaulting to strip them.
+func (cp *ContextPolicy) Unmanaged() UnmanagedContextsPolicy {
+	if cp == nil || cp.UnmanagedContexts == "" {
+		return UnmanagedContextsStrip
+	}
+	return cp.UnmanagedContexts
 }
 
 // ReviewPolicy specifies github approval/review criteria.
@@ -117,6 +147,77 @@ type Restrictions struct {
 	Teams []string `json:"teams,omitempty"`
 }
 
+// RulesetPolicy configures the repository rulesets that protect branches
+// instead of the legacy branch protection. The other fields of the policy
+// turn into the rules of the ruleset of each branch.
+// Any nil values inherit the policy from the parent, lists are appended to
+// parent lists.
+type RulesetPolicy struct {
+	// Enabled overrides whether branches are protected with a ruleset if set.
+	// The legacy protection of these branches is removed.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Enforcement of the rulesets, one of active (default), evaluate or disabled.
+	Enforcement RulesetEnforcement `json:"enforcement,omitempty"`
+	// BypassActors are allowed to bypass the rules of the ruleset.
+	BypassActors []RulesetBypassActor `json:"bypass_actors,omitempty"`
+	// WorkflowChecks are contexts that must be reported by GitHub Actions
+	// workflows to merge, in addition to the required status checks.
+	WorkflowChecks []string `json:"workflow_checks,omitempty"`
+	// RestrictUpdates overrides whether only bypass actors can push to the
+	// branch if set.
+	RestrictUpdates *bool `json:"restrict_updates,omitempty"`
+	// Push configures the push ruleset of the repo, which restricts the files
+	// pushed to any branch. It can't be set for a branch.
+	Push *PushRules `json:"push,omitempty"`
+}
+
+// RulesetEnforcement determines whether the rules of a ruleset are enforced.
+type RulesetEnforcement string
+
+const (
+	// RulesetEnforcementActive enforces the rules.
+	RulesetEnforcementActive RulesetEnforcement = "active"
+	// RulesetEnforcementEvaluate only reports which pushes and merges would
+	// violate the rules.
+	RulesetEnforcementEvaluate RulesetEnforcement = "evaluate"
+	// RulesetEnforcementDisabled disables the ruleset.
+	RulesetEnforcementDisabled RulesetEnforcement = "disabled"
+)
+
+// RulesetBypassActor can bypass the rules of a ruleset.
+type RulesetBypassActor struct {
+	// ActorType is one of Integration, OrganizationAdmin, RepositoryRole, Team
+	// or DeployKey.
+	ActorType string `json:"actor_type"`
+	// ActorID is the ID of the app, repository role or team. It is not set
+	// for OrganizationAdmin and DeployKey.
+	ActorID int `json:"actor_id,omitempty"`
+	// BypassMode is always (default) or pull_request, to only bypass the
+	// rules when merging pull requests.
+	BypassMode string `json:"bypass_mode,omitempty"`
+}
+
+// PushRules restrict the files that can be pushed to a repo.
+// Lists are appended to parent lists, other values override the parent if
+// set.
+type PushRules struct {
+	// RestrictedFilePaths are the file paths that can't be pushed, as
+	// fnmatch patterns.
+	RestrictedFilePaths []string `json:"restricted_file_paths,omitempty"`
+	// RestrictedFileExtensions are the file extensions that can't be pushed,
+	// like "*.exe".
+	RestrictedFileExtensions []string `json:"restricted_file_extensions,omitempty"`
+	// MaxFilePathLength is the longest file path that can be pushed.
+	MaxFilePathLength *int `json:"max_file_path_length,omitempty"`
+	// MaxFileSize is the size of the largest file that can be pushed, in MB.
+	MaxFileSize *int `json:"max_file_size,omitempty"`
+}
+
+// UsesRuleset returns true if the branch is protected with a ruleset.
+func (p Policy) UsesRuleset() bool {
+	return p.Ruleset != nil && p.Ruleset.Enabled != nil && *p.Ruleset.Enabled
+}
+
 // selectInt returns the child if set, else parent
 func selectInt(parent, child *int) *int {
 	if child != nil {
@@ -154,9 +255,18 @@ func mergeContextPolicy(parent, child *ContextPolicy) *ContextPolicy {
 		return child
 	}
 	return &ContextPolicy{
-		Contexts: unionStrings(parent.Contexts, child.Contexts),
-		Strict:   selectBool(parent.Strict, child.Strict),
+		Contexts:          unionStrings(parent.Contexts, child.Contexts),
+		Strict:            selectBool(parent.Strict, child.Strict),
+		UnmanagedContexts: selectUnmanagedContexts(parent.UnmanagedContexts, child.UnmanagedContexts),
+	}
+}
+
+// selectUnmanagedContexts returns the child argument if set, otherwise the parent
+func selectUnmanagedContexts(parent, child UnmanagedContextsPolicy) UnmanagedContextsPolicy {
+	if child != "" {
+		return child
 	}
+	return parent
 }
 
 func mergeReviewPolicy(parent, child *ReviewPolicy) *ReviewPolicy {
@@ -215,6 +325,59 @@ func mergeRestrictions(parent, child *Restrictions) *Restrictions {
 	}
 }
 
+func mergeRulesetPolicy(parent, child *RulesetPolicy) *RulesetPolicy {
+	if child == nil {
+		return parent
+	}
+	if parent == nil {
+		return child
+	}
+	enforcement := parent.Enforcement
+	if child.Enforcement != "" {
+		enforcement = child.Enforcement
+	}
+	return &RulesetPolicy{
+		Enabled:         selectBool(parent.Enabled, child.Enabled),
+		Enforcement:     enforcement,
+		BypassActors:    unionBypassActors(parent.BypassActors, child.BypassActors),
+		WorkflowChecks:  unionStrings(parent.WorkflowChecks, child.WorkflowChecks),
+		RestrictUpdates: selectBool(parent.RestrictUpdates, child.RestrictUpdates),
+		Push:            mergePushRules(parent.Push, child.Push),
+	}
+}
+
+// unionBypassActors merges the parent and child actors together
+func unionBypassActors(parent, child []RulesetBypassActor) []RulesetBypassActor {
+	if child == nil {
+		return parent
+	}
+	if parent == nil {
+		return child
+	}
+	actors := append([]RulesetBypassActor{}, parent...)
+	for _, actor := range child {
+		if !slices.Contains(actors, actor) {
+			actors = append(actors, actor)
+		}
+	}
+	return actors
+}
+
+func mergePushRules(parent, child *PushRules) *PushRules {
+	if child == nil {
+		return parent
+	}
+	if parent == nil {
+		return child
+	}
+	return &PushRules{
+		RestrictedFilePaths:      unionStrings(parent.RestrictedFilePaths, child.RestrictedFilePaths),
+		RestrictedFileExtensions: unionStrings(parent.RestrictedFileExtensions, child.RestrictedFileExtensions),
+		MaxFilePathLength:        selectInt(parent.MaxFilePathLength, child.MaxFilePathLength),
+		MaxFileSize:              selectInt(parent.MaxFileSize, child.MaxFileSize),
+	}
+}
+
 // Apply returns a policy that merges the child into the parent
 func (p Policy) Apply(child Policy) Policy {
 	return Policy{
@@ -230,6 +393,7 @@ func (p Policy) Apply(child Policy) Policy {
 		RequiredPullRequestReviews:   mergeReviewPolicy(p.RequiredPullRequestReviews, child.RequiredPullRequestReviews),
 		Exclude:                      unionStrings(p.Exclude, child.Exclude),
 		Include:                      unionStrings(p.Include, child.Include),
+		Ruleset:                      mergeRulesetPolicy(p.Ruleset, child.Ruleset),
 	}
 }
 
@@ -253,6 +417,100 @@ type BranchProtection struct {
 	ProtectReposWithOptionalJobs *bool `json:"protect_repos_with_optional_jobs,omitempty"`
 }
 
+// validateUnmanagedContexts ensures that all policies for unmanaged contexts are known.
+func (bp BranchProtection) validateUnmanagedContexts() error {
+	validate := func(name string, p Policy) error {
+		if p.RequiredStatusChecks == nil {
+			return nil
+		}
+		switch p.RequiredStatusChecks.UnmanagedContexts {
+		case "", UnmanagedContextsStrip, UnmanagedContextsPreserve, UnmanagedContextsWarn:
+			return nil
+		}
+		return fmt.Errorf("%s: invalid unmanaged_contexts %q, must be one of %q, %q or %q", name, p.RequiredStatusChecks.UnmanagedContexts,
+			UnmanagedContextsStrip, UnmanagedContextsPreserve, UnmanagedContextsWarn)
+	}
+	var errs []error
+	if err := validate("branch-protection", bp.Policy); err != nil {
+		errs = append(errs, err)
+	}
+	for orgName, org := range bp.Orgs {
+		if err := validate(orgName, org.Policy); err != nil {
+			errs = append(errs, err)
+		}
+		for repoName, repo := range org.Repos {
+			if err := validate(orgName+"/"+repoName, repo.Policy); err != nil {
+				errs = append(errs, err)
+			}
+			for branchName, branch := range repo.Branches {
+				if err := validate(orgName+"/"+repoName+"="+branchName, branch.Policy); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateRulesets ensures that the ruleset policies can be turned into
+// rulesets.
+func (bp BranchProtection) validateRulesets() error {
+	validate := func(name string, p Policy, branch bool) error {
+		if p.Ruleset == nil {
+			return nil
+		}
+		switch p.Ruleset.Enforcement {
+		case "", RulesetEnforcementActive, RulesetEnforcementEvaluate, RulesetEnforcementDisabled:
+		default:
+			return fmt.Errorf("%s: invalid ruleset enforcement %q, must be one of %q, %q or %q", name, p.Ruleset.Enforcement,
+				RulesetEnforcementActive, RulesetEnforcementEvaluate, RulesetEnforcementDisabled)
+		}
+		for _, actor := range p.Ruleset.BypassActors {
+			switch actor.ActorType {
+			case "Integration", "RepositoryRole", "Team":
+				if actor.ActorID == 0 {
+					return fmt.Errorf("%s: ruleset bypass actor of type %s needs an actor_id", name, actor.ActorType)
+				}
+			case "OrganizationAdmin", "DeployKey":
+				if actor.ActorID != 0 {
+					return fmt.Errorf("%s: ruleset bypass actor of type %s can't have an actor_id", name, actor.ActorType)
+				}
+			default:
+				return fmt.Errorf("%s: invalid ruleset bypass actor type %q", name, actor.ActorType)
+			}
+			switch actor.BypassMode {
+			case "", "always", "pull_request":
+			default:
+				return fmt.Errorf("%s: invalid ruleset bypass mode %q, must be always or pull_request", name, actor.BypassMode)
+			}
+		}
+		if branch && p.Ruleset.Push != nil {
+			return fmt.Errorf("%s: ruleset push rules apply to the whole repo and can't be set for a branch", name)
+		}
+		return nil
+	}
+	var errs []error
+	if err := validate("branch-protection", bp.Policy, false); err != nil {
+		errs = append(errs, err)
+	}
+	for orgName, org := range bp.Orgs {
+		if err := validate(orgName, org.Policy, false); err != nil {
+			errs = append(errs, err)
+		}
+		for repoName, repo := range org.Repos {
+			if err := validate(orgName+"/"+repoName, repo.Policy, false); err != nil {
+				errs = append(errs, err)
+			}
+			for branchName, branch := range repo.Branches {
+				if err := validate(orgName+"/"+repoName+"="+branchName, branch.Policy, true); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 func isPolicySet(p Policy) bool {
 	return !apiequality.Semantic.DeepEqual(p, Policy{})
 }
@@ -225,6 +225,35 @@ func TestApply(test *testing.T) {
 				},
 			},
 		},
+		{
+			name: "merge ruleset",
+			parent: Policy{
+				Ruleset: &RulesetPolicy{
+					Enabled:        &t,
+					BypassActors:   []RulesetBypassActor{{ActorType: "OrganizationAdmin"}},
+					WorkflowChecks: []string{"build"},
+					Push:           &PushRules{RestrictedFileExtensions: []string{"*.exe"}},
+				},
+			},
+			child: Policy{
+				Ruleset: &RulesetPolicy{
+					Enforcement:     RulesetEnforcementEvaluate,
+					BypassActors:    []RulesetBypassActor{{ActorType: "OrganizationAdmin"}, {ActorType: "Team", ActorID: 42}},
+					RestrictUpdates: &t,
+					Push:            &PushRules{RestrictedFileExtensions: []string{"*.dll"}},
+				},
+			},
+			expected: Policy{
+				Ruleset: &RulesetPolicy{
+					Enabled:         &t,
+					Enforcement:     RulesetEnforcementEvaluate,
+					BypassActors:    []RulesetBypassActor{{ActorType: "OrganizationAdmin"}, {ActorType: "Team", ActorID: 42}},
+					WorkflowChecks:  []string{"build"},
+					RestrictUpdates: &t,
+					Push:            &PushRules{RestrictedFileExtensions: []string{"*.dll", "*.exe"}},
+				},
+			},
+		},
 		{
 			name: "merge struct",
 			parent: Policy{
@@ -244,6 +273,26 @@ func TestApply(test *testing.T) {
 				},
 			},
 		},
+		{
+			name: "child overrides unmanaged contexts",
+			parent: Policy{
+				RequiredStatusChecks: &ContextPolicy{
+					Contexts:          []string{"hi"},
+					UnmanagedContexts: UnmanagedContextsWarn,
+				},
+			},
+			child: Policy{
+				RequiredStatusChecks: &ContextPolicy{
+					UnmanagedContexts: UnmanagedContextsPreserve,
+				},
+			},
+			expected: Policy{
+				RequiredStatusChecks: &ContextPolicy{
+					Contexts:          []string{"hi"},
+					UnmanagedContexts: UnmanagedContextsPreserve,
+				},
+			},
+		},
 		{
 			name: "nil child struct",
 			parent: Policy{
@@ -1338,3 +1387,110 @@ func TestUnprotectedBranches(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUnmanagedContexts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      UnmanagedContextsPolicy
+		expectedErr bool
+	}{
+		{name: "unset"},
+		{name: "strip", policy: UnmanagedContextsStrip},
+		{name: "preserve", policy: UnmanagedContextsPreserve},
+		{name: "warn", policy: UnmanagedContextsWarn},
+		{name: "unknown", policy: "keep", expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bp := BranchProtection{
+				Orgs: map[string]Org{
+					"org": {
+						Repos: map[string]Repo{
+							"repo": {
+								Branches: map[string]Branch{
+									"master": {Policy{RequiredStatusChecks: &ContextPolicy{UnmanagedContexts: tc.policy}}},
+								},
+							},
+						},
+					},
+				},
+			}
+			if err := bp.validateUnmanagedContexts(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRulesets(t *testing.T) {
+	testCases := []struct {
+		name        string
+		repo        *RulesetPolicy
+		branch      *RulesetPolicy
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			repo: &RulesetPolicy{
+				Enforcement: RulesetEnforcementEvaluate,
+				BypassActors: []RulesetBypassActor{
+					{ActorType: "OrganizationAdmin"},
+					{ActorType: "Team", ActorID: 42, BypassMode: "pull_request"},
+				},
+				Push: &PushRules{RestrictedFileExtensions: []string{"*.exe"}},
+			},
+		},
+		{
+			name:        "unknown enforcement",
+			repo:        &RulesetPolicy{Enforcement: "enforced"},
+			expectedErr: `org/repo: invalid ruleset enforcement "enforced", must be one of "active", "evaluate" or "disabled"`,
+		},
+		{
+			name:        "team without ID",
+			repo:        &RulesetPolicy{BypassActors: []RulesetBypassActor{{ActorType: "Team"}}},
+			expectedErr: "org/repo: ruleset bypass actor of type Team needs an actor_id",
+		},
+		{
+			name:        "deploy key with ID",
+			repo:        &RulesetPolicy{BypassActors: []RulesetBypassActor{{ActorType: "DeployKey", ActorID: 1}}},
+			expectedErr: "org/repo: ruleset bypass actor of type DeployKey can't have an actor_id",
+		},
+		{
+			name:        "unknown bypass mode",
+			repo:        &RulesetPolicy{BypassActors: []RulesetBypassActor{{ActorType: "OrganizationAdmin", BypassMode: "never"}}},
+			expectedErr: `org/repo: invalid ruleset bypass mode "never", must be always or pull_request`,
+		},
+		{
+			name:        "push rules for a branch",
+			branch:      &RulesetPolicy{Push: &PushRules{MaxFileSize: new(int)}},
+			expectedErr: "org/repo=master: ruleset push rules apply to the whole repo and can't be set for a branch",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bp := BranchProtection{
+				Orgs: map[string]Org{
+					"org": {
+						Repos: map[string]Repo{
+							"repo": {
+								Policy: Policy{Ruleset: tc.repo},
+								Branches: map[string]Branch{
+									"master": {Policy{Ruleset: tc.branch}},
+								},
+							},
+						},
+					},
+				},
+			}
+			var errMsg string
+			if err := bp.validateRulesets(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KafkaSASLPlain authenticates to Kafka with SASL/PLAIN.
+	KafkaSASLPlain = "PLAIN"
+	// KafkaSASLSCRAMSHA256 authenticates to Kafka with SASL/SCRAM-SHA-256.
+	KafkaSASLSCRAMSHA256 = "SCRAM-SHA-256"
+	// KafkaSASLSCRAMSHA512 authenticates to Kafka with SASL/SCRAM-SHA-512.
+	KafkaSASLSCRAMSHA512 = "SCRAM-SHA-512"
+
+	defaultKafkaConsumerGroup = "prow-sub"
+)
+
+// KafkaTrigger configures Kafka topics that sub creates ProwJobs from. The
+// messages have the same payload as Pub/Sub messages, and their headers
+// take the place of the attributes of Pub/Sub messages.
+type KafkaTrigger struct {
+	// Brokers are the addresses of the bootstrap brokers, like
+	// kafka-0.example.com:9092.
+	Brokers []string `json:"brokers"`
+	// Topics are the topics to consume.
+	Topics []string `json:"topics"`
+	// ConsumerGroup is the consumer group sub joins, and that the offsets of
+	// handled messages are committed for. Defaults to prow-sub.
+	ConsumerGroup   string   `json:"consumer_group,omitempty"`
+	AllowedClusters []string `json:"allowed_clusters"`
+	// IdempotencyWindow makes messages that request a job that was created
+	// within the window be dropped. See PubSubTrigger.IdempotencyWindow.
+	IdempotencyWindow *metav1.Duration `json:"idempotency_window,omitempty"`
+	// SASL authenticates sub to the brokers.
+	SASL *KafkaSASL `json:"sasl,omitempty"`
+	// TLS connects to the brokers over TLS.
+	TLS *BrokerTLS `json:"tls,omitempty"`
+}
+
+// KafkaSASL configures the SASL authentication to Kafka brokers.
+type KafkaSASL struct {
+	// Mechanism is one of PLAIN, SCRAM-SHA-256 and SCRAM-SHA-512.
+	Mechanism string `json:"mechanism"`
+	// UsernameFile is the path to the file holding the username.
+	UsernameFile string `json:"username_file"`
+	// PasswordFile is the path to the file holding the password.
+	PasswordFile string `json:"password_file"`
+}
+
+// AMQPTrigger configures AMQP 0-9-1 queues, like those of RabbitMQ, that sub
+// creates ProwJobs from. The messages have the same payload as Pub/Sub
+// messages, and their headers take the place of the attributes of Pub/Sub
+// messages.
+type AMQPTrigger struct {
+	// URL is the URL of the broker, like amqps://rabbitmq.example.com/vhost.
+	// It must not hold credentials, use UsernameFile and PasswordFile.
+	URL string `json:"url"`
+	// UsernameFile is the path to the file holding the username to
+	// authenticate with. Defaults to the guest user of RabbitMQ.
+	UsernameFile string `json:"username_file,omitempty"`
+	// PasswordFile is the path to the file holding the password.
+	PasswordFile string `json:"password_file,omitempty"`
+	// Queues are the queues to consume.
+	Queues []string `json:"queues"`
+	// Prefetch is the max number of messages of a queue being processed,
+	// default is 10.
+	Prefetch        int      `json:"prefetch,omitempty"`
+	AllowedClusters []string `json:"allowed_clusters"`
+	// IdempotencyWindow makes messages that request a job that was created
+	// within the window be dropped. See PubSubTrigger.IdempotencyWindow.
+	IdempotencyWindow *metav1.Duration `json:"idempotency_window,omitempty"`
+	// TLS configures the TLS connection of amqps URLs.
+	TLS *BrokerTLS `json:"tls,omitempty"`
+}
+
+// BrokerTLS configures TLS connections to message brokers.
+type BrokerTLS struct {
+	// CAFile is the path to the CA bundle verifying the brokers. Defaults to
+	// the system roots.
+	CAFile string `json:"ca_file,omitempty"`
+	// CertFile and KeyFile are the paths to the client certificate and key
+	// authenticating sub, if the brokers require one.
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// InsecureSkipVerify disables the verification of the brokers.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+func (t *BrokerTLS) validate() error {
+	if t != nil && (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
+
+func validateIdempotencyWindow(window *metav1.Duration) error {
+	if window != nil && window.Duration <= 0 {
+		return errors.New("idempotency_window must be positive")
+	}
+	return nil
+}
+
+func (t *KafkaTrigger) defaultAndValidate() error {
+	if len(t.Brokers) == 0 {
+		return errors.New("brokers must be set")
+	}
+	if len(t.Topics) == 0 {
+		return errors.New("topics must be set")
+	}
+	if t.ConsumerGroup == "" {
+		t.ConsumerGroup = defaultKafkaConsumerGroup
+	}
+	if err := validateIdempotencyWindow(t.IdempotencyWindow); err != nil {
+		return err
+	}
+	if t.SASL != nil {
+		switch t.SASL.Mechanism {
+		case KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512:
+		default:
+			return fmt.Errorf("unsupported sasl.mechanism %q, must be one of %s, %s and %s", t.SASL.Mechanism, KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512)
+		}
+		if t.SASL.UsernameFile == "" || t.SASL.PasswordFile == "" {
+			return errors.New("sasl.username_file and sasl.password_file must be set")
+		}
+	}
+	return t.TLS.validate()
+}
+
+func (t *AMQPTrigger) defaultAndValidate() error {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "amqp" && u.Scheme != "amqps" {
+		return fmt.Errorf("url %q must use the amqp or amqps scheme", t.URL)
+	}
+	if u.User != nil {
+		return errors.New("url must not hold credentials, use username_file and password_file instead")
+	}
+	if (t.UsernameFile == "") != (t.PasswordFile == "") {
+		return errors.New("username_file and password_file must be set together")
+	}
+	if len(t.Queues) == 0 {
+		return errors.New("queues must be set")
+	}
+	if t.Prefetch < 0 {
+		return errors.New("prefetch must not be negative")
+	}
+	if t.Prefetch == 0 {
+		t.Prefetch = defaultMaxOutstandingMessages
+	}
+	if err := validateIdempotencyWindow(t.IdempotencyWindow); err != nil {
+		return err
+	}
+	if t.TLS != nil && u.Scheme != "amqps" {
+		return errors.New("tls requires an amqps url")
+	}
+	return t.TLS.validate()
+}
@@ -365,6 +365,62 @@ func (cache *InRepoConfigCache) GetProwYAMLWithoutDefaults(identifier, baseBranc
 	return prowYAML, nil
 }
 
+// InRepoConfigCacheEntry describes an entry of the InRepoConfigCache.
+type InRepoConfigCacheEntry struct {
+	Key CacheKeyParts `json:"key"`
+	// Added is when the ProwYAML of the entry started being read from the
+	// repo.
+	Added time.Time `json:"added"`
+	// Hits is how many lookups found the entry.
+	Hits int `json:"hits"`
+	// Pending is whether the ProwYAML is still being read from the repo.
+	Pending bool `json:"pending,omitempty"`
+}
+
+// Entries returns the entries of the cache, from the least to the most
+// recently used.
+func (cache *InRepoConfigCache) Entries() []InRepoConfigCacheEntry {
+	var entries []InRepoConfigCacheEntry
+	for _, entry := range cache.LRUCache.Entries() {
+		cacheKey, ok := entry.Key.(CacheKey)
+		if !ok {
+			continue
+		}
+		kp, err := cacheKey.toCacheKeyParts()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, InRepoConfigCacheEntry{Key: kp, Added: entry.Added, Hits: entry.Hits, Pending: entry.Pending})
+	}
+	return entries
+}
+
+// Invalidate removes the entry of the key from the cache, so that the next
+// lookup reads the ProwYAML from the repo again. It returns whether the key
+// was cached.
+func (cache *InRepoConfigCache) Invalidate(keyParts CacheKeyParts) (bool, error) {
+	key, err := keyParts.CacheKey()
+	if err != nil {
+		return false, fmt.Errorf("converting CacheKeyParts to CacheKey: %v", err)
+	}
+	return cache.LRUCache.Invalidate(key), nil
+}
+
+// InvalidateRepo removes all entries of the repo from the cache and returns
+// how many there were.
+func (cache *InRepoConfigCache) InvalidateRepo(identifier string) int {
+	var invalidated int
+	for _, entry := range cache.Entries() {
+		if entry.Key.Identifier != identifier {
+			continue
+		}
+		if removed, err := cache.Invalidate(entry.Key); err == nil && removed {
+			invalidated++
+		}
+	}
+	return invalidated
+}
+
 // GetInRepoConfig just wraps around GetProwYAML().
 func (cache *InRepoConfigCache) GetInRepoConfig(identifier, baseBranch string, baseSHAGetter RefGetter, headSHAGetters ...RefGetter) (*ProwYAML, error) {
 	return cache.GetProwYAML(identifier, baseBranch, baseSHAGetter, headSHAGetters...)
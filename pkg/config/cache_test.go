@@ -26,6 +26,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	v1 "k8s.io/api/core/v1"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/cache"
 	"sigs.k8s.io/prow/pkg/git/v2"
 )
 
@@ -861,3 +862,39 @@ func TestGetProwYAMLCachedAndDefaulted(t *testing.T) {
 	}
 
 }
+
+func TestInRepoConfigCacheInvalidate(t *testing.T) {
+	lruCache, err := cache.NewLRUCache(10, cache.Callbacks{})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	irc := &InRepoConfigCache{LRUCache: lruCache}
+	keys := []CacheKeyParts{
+		{Identifier: "org/repo", BaseSHA: "ba5e"},
+		{Identifier: "org/repo", BaseSHA: "ba5e", HeadSHAs: []string{"abcd"}},
+		{Identifier: "org/other", BaseSHA: "ba5e"},
+	}
+	for _, kp := range keys {
+		if _, err := irc.get(kp, func() (interface{}, error) { return &ProwYAML{}, nil }); err != nil {
+			t.Fatalf("failed to add %v: %v", kp, err)
+		}
+	}
+
+	var cached []CacheKeyParts
+	for _, entry := range irc.Entries() {
+		cached = append(cached, entry.Key)
+	}
+	if diff := cmp.Diff(keys, cached); diff != "" {
+		t.Errorf("unexpected cached keys (-want +got):\n%s", diff)
+	}
+
+	if invalidated, err := irc.Invalidate(keys[2]); err != nil || !invalidated {
+		t.Errorf("expected %v to be invalidated, got %t, %v", keys[2], invalidated, err)
+	}
+	if invalidated := irc.InvalidateRepo("org/repo"); invalidated != 2 {
+		t.Errorf("expected 2 keys of org/repo to be invalidated, got %d", invalidated)
+	}
+	if entries := irc.Entries(); len(entries) != 0 {
+		t.Errorf("expected the cache to be empty, got %+v", entries)
+	}
+}
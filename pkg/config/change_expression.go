@@ -0,0 +1,270 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// +k8s:deepcopy-gen=true
+
+// ChangeExpression is a boolean expression that is evaluated against the
+// files changed by a pull request to decide whether a job should run. Exactly
+// one of its fields must be set. Globs are matched against the full path of
+// the changed files: `*` and `?` do not match `/`, `**` matches any number of
+// directories, and `{a,b}` matches either alternative.
+//
+// For example, to run when anything under api/ changes unless only markdown
+// files were changed:
+//
+//	run_if_changed_expression:
+//	  all_of:
+//	  - any_changed: ["api/**"]
+//	  - not:
+//	      only_changed: ["**/*.md"]
+type ChangeExpression struct {
+	// AnyChanged is true if any of the changed files matches one of the globs.
+	AnyChanged []string `json:"any_changed,omitempty"`
+	// OnlyChanged is true if all of the changed files match one of the globs.
+	OnlyChanged []string `json:"only_changed,omitempty"`
+	// MinChangedFiles is true if at least this many files were changed.
+	MinChangedFiles *int `json:"min_changed_files,omitempty"`
+	// MaxChangedFiles is true if at most this many files were changed.
+	MaxChangedFiles *int `json:"max_changed_files,omitempty"`
+	// AllOf is true if all of the nested expressions are true.
+	AllOf []ChangeExpression `json:"all_of,omitempty"`
+	// AnyOf is true if any of the nested expressions is true.
+	AnyOf []ChangeExpression `json:"any_of,omitempty"`
+	// Not is true if the nested expression is false.
+	Not *ChangeExpression `json:"not,omitempty"`
+
+	re *CopyableRegexp // from AnyChanged xor OnlyChanged
+}
+
+// compile validates the expression and compiles its globs. It must be called
+// before the expression is evaluated.
+func (e *ChangeExpression) compile() error {
+	var set []string
+	if len(e.AnyChanged) > 0 {
+		set = append(set, "any_changed")
+	}
+	if len(e.OnlyChanged) > 0 {
+		set = append(set, "only_changed")
+	}
+	if e.MinChangedFiles != nil {
+		set = append(set, "min_changed_files")
+	}
+	if e.MaxChangedFiles != nil {
+		set = append(set, "max_changed_files")
+	}
+	if len(e.AllOf) > 0 {
+		set = append(set, "all_of")
+	}
+	if len(e.AnyOf) > 0 {
+		set = append(set, "any_of")
+	}
+	if e.Not != nil {
+		set = append(set, "not")
+	}
+	if len(set) != 1 {
+		return fmt.Errorf("exactly one of any_changed, only_changed, min_changed_files, max_changed_files, all_of, any_of or not must be set, got %v", set)
+	}
+
+	switch {
+	case len(e.AnyChanged) > 0 || len(e.OnlyChanged) > 0:
+		globs := append(append([]string{}, e.AnyChanged...), e.OnlyChanged...)
+		var expressions []string
+		for _, glob := range globs {
+			expression, err := globToRegexp(glob)
+			if err != nil {
+				return fmt.Errorf("invalid glob %q: %w", glob, err)
+			}
+			expressions = append(expressions, expression)
+		}
+		re, err := regexp.Compile(`^(?:` + strings.Join(expressions, `|`) + `)$`)
+		if err != nil {
+			return fmt.Errorf("could not compile globs %v: %w", globs, err)
+		}
+		e.re = &CopyableRegexp{re}
+	case e.MinChangedFiles != nil && *e.MinChangedFiles < 0:
+		return errors.New("min_changed_files must not be negative")
+	case e.MaxChangedFiles != nil && *e.MaxChangedFiles < 0:
+		return errors.New("max_changed_files must not be negative")
+	case e.Not != nil:
+		return e.Not.compile()
+	}
+	for i := range e.AllOf {
+		if err := e.AllOf[i].compile(); err != nil {
+			return fmt.Errorf("all_of[%d]: %w", i, err)
+		}
+	}
+	for i := range e.AnyOf {
+		if err := e.AnyOf[i].compile(); err != nil {
+			return fmt.Errorf("any_of[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// clearCompiled removes the compiled globs from the expression.
+func (e *ChangeExpression) clearCompiled() {
+	e.re = nil
+	if e.Not != nil {
+		e.Not.clearCompiled()
+	}
+	for i := range e.AllOf {
+		e.AllOf[i].clearCompiled()
+	}
+	for i := range e.AnyOf {
+		e.AnyOf[i].clearCompiled()
+	}
+}
+
+// Matches evaluates the expression against the changed files.
+func (e *ChangeExpression) Matches(changes []string) bool {
+	switch {
+	case len(e.AnyChanged) > 0:
+		for _, change := range changes {
+			if e.re.MatchString(change) {
+				return true
+			}
+		}
+		return false
+	case len(e.OnlyChanged) > 0:
+		for _, change := range changes {
+			if !e.re.MatchString(change) {
+				return false
+			}
+		}
+		return true
+	case e.MinChangedFiles != nil:
+		return len(changes) >= *e.MinChangedFiles
+	case e.MaxChangedFiles != nil:
+		return len(changes) <= *e.MaxChangedFiles
+	case len(e.AllOf) > 0:
+		for i := range e.AllOf {
+			if !e.AllOf[i].Matches(changes) {
+				return false
+			}
+		}
+		return true
+	case len(e.AnyOf) > 0:
+		for i := range e.AnyOf {
+			if e.AnyOf[i].Matches(changes) {
+				return true
+			}
+		}
+		return false
+	case e.Not != nil:
+		return !e.Not.Matches(changes)
+	}
+	return false
+}
+
+// String returns a human-readable representation of the expression.
+func (e *ChangeExpression) String() string {
+	if e == nil {
+		return ""
+	}
+	nested := func(op string, expressions []ChangeExpression) string {
+		var parts []string
+		for i := range expressions {
+			parts = append(parts, expressions[i].String())
+		}
+		return fmt.Sprintf("%s(%s)", op, strings.Join(parts, ", "))
+	}
+	switch {
+	case len(e.AnyChanged) > 0:
+		return fmt.Sprintf("any_changed(%s)", strings.Join(e.AnyChanged, ", "))
+	case len(e.OnlyChanged) > 0:
+		return fmt.Sprintf("only_changed(%s)", strings.Join(e.OnlyChanged, ", "))
+	case e.MinChangedFiles != nil:
+		return fmt.Sprintf("min_changed_files(%d)", *e.MinChangedFiles)
+	case e.MaxChangedFiles != nil:
+		return fmt.Sprintf("max_changed_files(%d)", *e.MaxChangedFiles)
+	case len(e.AllOf) > 0:
+		return nested("all_of", e.AllOf)
+	case len(e.AnyOf) > 0:
+		return nested("any_of", e.AnyOf)
+	case e.Not != nil:
+		return fmt.Sprintf("not(%s)", e.Not)
+	}
+	return ""
+}
+
+// globToRegexp translates a glob into an unanchored regular expression.
+func globToRegexp(glob string) (string, error) {
+	if glob == "" {
+		return "", errors.New("glob must not be empty")
+	}
+	var out strings.Builder
+	inAlternation := false
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				out.WriteString(`(?:.*/)?`)
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				out.WriteString(`.*`)
+				i++
+			} else {
+				out.WriteString(`[^/]*`)
+			}
+		case '?':
+			out.WriteString(`[^/]`)
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", errors.New("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			out.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			if inAlternation {
+				return "", errors.New("nested alternations are not supported")
+			}
+			inAlternation = true
+			out.WriteString(`(?:`)
+		case '}':
+			if !inAlternation {
+				return "", errors.New("unmatched '}'")
+			}
+			inAlternation = false
+			out.WriteString(`)`)
+		case ',':
+			if inAlternation {
+				out.WriteString(`|`)
+			} else {
+				out.WriteString(`,`)
+			}
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if inAlternation {
+		return "", errors.New("unterminated alternation")
+	}
+	return out.String(), nil
+}
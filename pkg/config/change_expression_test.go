@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestChangeExpressionMatches(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		changes    []string
+		expected   bool
+	}{
+		{
+			name:       "any_changed matches a nested file",
+			expression: `any_changed: ["api/**"]`,
+			changes:    []string{"README.md", "api/v1/types.go"},
+			expected:   true,
+		},
+		{
+			name:       "any_changed does not match a sibling directory",
+			expression: `any_changed: ["api/**"]`,
+			changes:    []string{"apis/v1/types.go"},
+		},
+		{
+			name:       "single star does not cross directories",
+			expression: `any_changed: ["*.go"]`,
+			changes:    []string{"pkg/main.go"},
+		},
+		{
+			name:       "double star matches the top level directory too",
+			expression: `any_changed: ["**/*.go"]`,
+			changes:    []string{"main.go"},
+			expected:   true,
+		},
+		{
+			name:       "alternation and character classes",
+			expression: `any_changed: ["docs/*.{md,adoc}", "hack/[!a-m]*.sh"]`,
+			changes:    []string{"hack/verify.sh"},
+			expected:   true,
+		},
+		{
+			name:       "only_changed requires all files to match",
+			expression: `only_changed: ["**/*.md", "OWNERS"]`,
+			changes:    []string{"docs/README.md", "OWNERS", "main.go"},
+		},
+		{
+			name:       "only_changed with all files matching",
+			expression: `only_changed: ["**/*.md", "OWNERS"]`,
+			changes:    []string{"docs/README.md", "OWNERS"},
+			expected:   true,
+		},
+		{
+			name:       "size thresholds",
+			expression: `all_of: [{min_changed_files: 2}, {max_changed_files: 3}]`,
+			changes:    []string{"a", "b", "c"},
+			expected:   true,
+		},
+		{
+			name:       "size threshold exceeded",
+			expression: `max_changed_files: 2`,
+			changes:    []string{"a", "b", "c"},
+		},
+		{
+			name:       "api changes that are not only docs",
+			expression: `all_of: [{any_changed: ["api/**"]}, {not: {only_changed: ["**/*.md"]}}]`,
+			changes:    []string{"api/README.md", "api/v1/types.go"},
+			expected:   true,
+		},
+		{
+			name:       "api changes that are only docs",
+			expression: `all_of: [{any_changed: ["api/**"]}, {not: {only_changed: ["**/*.md"]}}]`,
+			changes:    []string{"api/README.md"},
+		},
+		{
+			name:       "any_of",
+			expression: `any_of: [{any_changed: ["api/**"]}, {any_changed: ["go.mod"]}]`,
+			changes:    []string{"go.mod"},
+			expected:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var expression ChangeExpression
+			if err := yaml.Unmarshal([]byte(tc.expression), &expression); err != nil {
+				t.Fatalf("failed to unmarshal expression: %v", err)
+			}
+			if err := expression.compile(); err != nil {
+				t.Fatalf("failed to compile expression: %v", err)
+			}
+			if actual := expression.Matches(tc.changes); actual != tc.expected {
+				t.Errorf("expected %s to match %v: %t, got %t", expression.String(), tc.changes, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestChangeExpressionCompile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+	}{
+		{
+			name:       "empty expression",
+			expression: `{}`,
+		},
+		{
+			name:       "multiple operators in one node",
+			expression: `{any_changed: ["a"], only_changed: ["b"]}`,
+		},
+		{
+			name:       "invalid nested expression",
+			expression: `all_of: [{any_changed: ["a"]}, {not: {}}]`,
+		},
+		{
+			name:       "unterminated character class",
+			expression: `any_changed: ["[abc"]`,
+		},
+		{
+			name:       "unterminated alternation",
+			expression: `any_changed: ["{a,b"]`,
+		},
+		{
+			name:       "negative threshold",
+			expression: `min_changed_files: -1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var expression ChangeExpression
+			if err := yaml.Unmarshal([]byte(tc.expression), &expression); err != nil {
+				t.Fatalf("failed to unmarshal expression: %v", err)
+			}
+			if err := expression.compile(); err == nil {
+				t.Errorf("expected %s to be rejected", tc.expression)
+			}
+		})
+	}
+}
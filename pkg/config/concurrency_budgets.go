@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// ConcurrencyBudgetUsage is how many ProwJobs of an org or repo are running
+// and waiting for the budget to start.
+type ConcurrencyBudgetUsage struct {
+	// Budget is the key of the budget, either "org" or "org/repo".
+	Budget string
+	// Limit is the configured budget.
+	Limit int
+	// Pending is the number of jobs that are running.
+	Pending int
+	// Triggered is the number of jobs waiting to be started.
+	Triggered int
+}
+
+// ConcurrencyBudgetKeys returns the keys of the budgets that apply to the
+// ProwJob, the org budget first. Jobs without refs are not subject to any
+// budget.
+func (p Plank) ConcurrencyBudgetKeys(spec prowapi.ProwJobSpec) []string {
+	if len(p.ConcurrencyBudgets) == 0 {
+		return nil
+	}
+	refs := spec.Refs
+	if refs == nil && len(spec.ExtraRefs) > 0 {
+		refs = &spec.ExtraRefs[0]
+	}
+	if refs == nil {
+		return nil
+	}
+	var keys []string
+	for _, key := range []string{refs.Org, refs.Org + "/" + refs.Repo} {
+		if _, ok := p.ConcurrencyBudgets[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ConcurrencyBudgetUsage returns the usage of every configured budget by the
+// not yet completed ProwJobs of the Kubernetes agent, sorted by budget.
+func (p Plank) ConcurrencyBudgetUsage(pjs []prowapi.ProwJob) []ConcurrencyBudgetUsage {
+	usage := map[string]*ConcurrencyBudgetUsage{}
+	for budget, limit := range p.ConcurrencyBudgets {
+		usage[budget] = &ConcurrencyBudgetUsage{Budget: budget, Limit: limit}
+	}
+	for _, pj := range pjs {
+		if pj.Spec.Agent != prowapi.KubernetesAgent {
+			continue
+		}
+		for _, key := range p.ConcurrencyBudgetKeys(pj.Spec) {
+			switch pj.Status.State {
+			case prowapi.PendingState:
+				usage[key].Pending++
+			case prowapi.TriggeredState:
+				usage[key].Triggered++
+			}
+		}
+	}
+	var result []ConcurrencyBudgetUsage
+	for _, budget := range sets.List(sets.KeySet(usage)) {
+		result = append(result, *usage[budget])
+	}
+	return result
+}
+
+func (p Plank) validateConcurrencyBudgets() error {
+	for key, limit := range p.ConcurrencyBudgets {
+		if key == "" {
+			return errors.New("concurrency_budgets: the org or org/repo of a budget must not be empty")
+		}
+		if limit < 0 {
+			return fmt.Errorf("concurrency_budgets: budget %d of %s must not be negative", limit, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestConcurrencyBudgetUsage(t *testing.T) {
+	pj := func(agent prowapi.ProwJobAgent, state prowapi.ProwJobState, org, repo string) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Agent: agent,
+				Refs:  &prowapi.Refs{Org: org, Repo: repo},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+	}
+	plank := Plank{ConcurrencyBudgets: map[string]int{"org": 10, "org/repo": 2, "idle": 1}}
+	pjs := []prowapi.ProwJob{
+		pj(prowapi.KubernetesAgent, prowapi.PendingState, "org", "repo"),
+		pj(prowapi.KubernetesAgent, prowapi.TriggeredState, "org", "repo"),
+		pj(prowapi.KubernetesAgent, prowapi.PendingState, "org", "other"),
+		pj(prowapi.KubernetesAgent, prowapi.SuccessState, "org", "repo"),
+		pj(prowapi.JenkinsAgent, prowapi.PendingState, "org", "repo"),
+		pj(prowapi.KubernetesAgent, prowapi.PendingState, "other-org", "repo"),
+		{Spec: prowapi.ProwJobSpec{Agent: prowapi.KubernetesAgent}, Status: prowapi.ProwJobStatus{State: prowapi.PendingState}},
+	}
+	expected := []ConcurrencyBudgetUsage{
+		{Budget: "idle", Limit: 1},
+		{Budget: "org", Limit: 10, Pending: 2, Triggered: 1},
+		{Budget: "org/repo", Limit: 2, Pending: 1, Triggered: 1},
+	}
+	if diff := cmp.Diff(expected, plank.ConcurrencyBudgetUsage(pjs)); diff != "" {
+		t.Errorf("unexpected usage (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateConcurrencyBudgets(t *testing.T) {
+	testCases := []struct {
+		name    string
+		budgets map[string]int
+		expErr  bool
+	}{
+		{
+			name:    "valid budgets",
+			budgets: map[string]int{"org": 10, "org/repo": 0, "https://gerrit.example.com/project": 5},
+		},
+		{
+			name:    "negative budget",
+			budgets: map[string]int{"org": -1},
+			expErr:  true,
+		},
+		{
+			name:    "empty key",
+			budgets: map[string]int{"": 1},
+			expErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Plank{ConcurrencyBudgets: tc.budgets}.validateConcurrencyBudgets()
+			if (err != nil) != tc.expErr {
+				t.Errorf("expected error: %t, got %v", tc.expErr, err)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -59,6 +60,7 @@ import (
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/secretutil"
 )
 
 const (
@@ -118,6 +120,11 @@ type JobConfig struct {
 	// for which a tide query is configured.
 	AllRepos sets.Set[string] `json:"-"`
 
+	// StaleShards holds the errors of the job config shards whose files
+	// can't be read, for which the last version that could be read is used.
+	// It is only set if ShardJobConfig is.
+	StaleShards map[string]string `json:"-"`
+
 	// ProwYAMLGetterWithDefaults is the function to get a ProwYAML with
 	// defaults based on the rest of the Config. Tests should provide their own
 	// implementation.
@@ -152,6 +159,14 @@ type ProwConfig struct {
 	SlackReporterConfigs SlackReporterConfigs `json:"slack_reporter_configs,omitempty"`
 	InRepoConfig         InRepoConfig         `json:"in_repo_config"`
 
+	// ExecReporterConfigs are the commands crier's exec reporter runs when
+	// jobs reach the configured states.
+	ExecReporterConfigs []ExecReporter `json:"exec_reporter_configs,omitempty"`
+
+	// SLOReporter configures crier's SLO reporter, which tracks the jobs
+	// that declare an slo.
+	SLOReporter *SLOReporter `json:"slo_reporter,omitempty"`
+
 	// Gangway contains configurations needed by the the Prow API server of the
 	// same name. It encodes an allowlist of API clients and what kinds of Prow
 	// Jobs they are authorized to trigger.
@@ -162,6 +177,13 @@ type ProwConfig struct {
 	// Moonraker.
 	Moonraker Moonraker `json:"moonraker,omitempty"`
 
+	// ImagePrePuller contains configuration for the optional image-prepuller
+	// component.
+	ImagePrePuller ImagePrePuller `json:"image_prepuller,omitempty"`
+
+	// StatusReconciler contains configuration for the status-reconciler.
+	StatusReconciler StatusReconciler `json:"status_reconciler,omitempty"`
+
 	// Scheduler contains configuration for the additional scheduler.
 	// It has to be explicitly enabled.
 	Scheduler Scheduler `json:"scheduler,omitempty"`
@@ -204,6 +226,13 @@ type ProwConfig struct {
 	// can be used to restrict build cluster on a topic.
 	PubSubTriggers PubSubTriggers `json:"pubsub_triggers,omitempty"`
 
+	// KafkaTriggers defines Kafka topics that sub creates ProwJobs from.
+	KafkaTriggers []KafkaTrigger `json:"kafka_triggers,omitempty"`
+
+	// AMQPTriggers defines AMQP queues, like those of RabbitMQ, that sub
+	// creates ProwJobs from.
+	AMQPTriggers []AMQPTrigger `json:"amqp_triggers,omitempty"`
+
 	// GitHubOptions allows users to control how prow applications display GitHub website links.
 	GitHubOptions GitHubOptions `json:"github,omitempty"`
 
@@ -220,6 +249,21 @@ type ProwConfig struct {
 	// non-global Hmac token.
 	ManagedWebhooks ManagedWebhooks `json:"managed_webhooks,omitempty"`
 
+	// ShardJobConfig makes the jobs of every top-level directory of the job
+	// config directory, usually the jobs of one org, load independently.
+	// When the files of a directory can't be read, for example because of a
+	// syntax error, components keep the last version of its jobs that could
+	// be read while the jobs of other directories are reloaded. Errors that
+	// are only found when validating the whole config, like jobs with the
+	// same name in different directories, still stop the config from being
+	// reloaded.
+	ShardJobConfig bool `json:"shard_job_config,omitempty"`
+
+	// ProwJobMetadataPropagation controls which labels and annotations of
+	// ProwJobs are copied to their pods, to the metadata of their artifacts
+	// and to their reports.
+	ProwJobMetadataPropagation MetadataPropagation `json:"prowjob_metadata_propagation,omitempty"`
+
 	// ProwJobDefaultEntries holds a list of defaults for specific values
 	// Each entry in the slice specifies Repo and CLuster regexp filter fields to
 	// match against the jobs and a corresponding ProwJobDefault . All entries that
@@ -230,6 +274,27 @@ type ProwConfig struct {
 	// DisabledClusters holds a list of disabled build cluster names. The same context names will be ignored while
 	// Prow components load the kubeconfig files.
 	DisabledClusters []string `json:"disabled_clusters,omitempty"`
+
+	// Tenants own parts of the job config. The jobs in the files of a tenant
+	// can only reference the repos, clusters and secrets of the tenant.
+	Tenants []Tenant `json:"tenants,omitempty"`
+
+	// Quarantine lets maintainers quarantine flaky tests from Deck.
+	Quarantine *Quarantine `json:"quarantine,omitempty"`
+
+	// JobDefaults are defaults of the resources, scheduling and decoration
+	// of jobs, keyed by "*" for all jobs, "org" or "org/repo". The defaults
+	// of an org override those for all jobs and the defaults of a repo
+	// override those of its org, field by field. Fields set by the job
+	// itself always take precedence. Periodics use the repo of their first
+	// extra ref. Use the --print-resolved-job flag of checkconfig to see the
+	// result for a job.
+	JobDefaults map[string]JobDefaults `json:"job_defaults,omitempty"`
+
+	// StorageRetention declares how long the objects in the buckets of Prow
+	// are kept, per prefix. Run prowctl lifecycle apply to configure the
+	// lifecycle rules of the buckets accordingly.
+	StorageRetention []StorageRetention `json:"storage_retention,omitempty"`
 }
 
 type InRepoConfig struct {
@@ -241,6 +306,15 @@ type InRepoConfig struct {
 	// a given repo. All clusters that are allowed for the specific repo, its org or
 	// globally can be used.
 	AllowedClusters map[string][]string `json:"allowed_clusters,omitempty"`
+	// PartialLoading describes whether only the files of the .prow directory
+	// that are relevant to the files changed by a PR are parsed. Files
+	// directly in the .prow directory are always parsed, while files in its
+	// subdirectories, like .prow/pkg/foo/presubmits.yaml, are only parsed if
+	// the PR changes files under the directory they mirror, like pkg/foo/, or
+	// the file itself. This can be set globally, per org or per repo using
+	// '*', 'org' or 'org/repo' as key. The narrowest match always takes
+	// precedence.
+	PartialLoading map[string]*bool `json:"partial_loading,omitempty"`
 }
 
 func SplitRepoName(fullRepoName string) (string, string, error) {
@@ -267,6 +341,17 @@ func (c *Config) InRepoConfigEnabled(identifier string) bool {
 	return false
 }
 
+// InRepoConfigPartialLoading returns whether only the in-repo config files
+// relevant to the changes of a PR are loaded for a given repository.
+func (c *Config) InRepoConfigPartialLoading(identifier string) bool {
+	for _, key := range keysForIdentifier(identifier) {
+		if c.InRepoConfig.PartialLoading[key] != nil {
+			return *c.InRepoConfig.PartialLoading[key]
+		}
+	}
+	return false
+}
+
 // InRepoConfigAllowsCluster determines if a given cluster may be used for a given repository
 // Assumes that config will not include http:// or https://
 func (c *Config) InRepoConfigAllowsCluster(clusterName, identifier string) bool {
@@ -708,6 +793,116 @@ type Plank struct {
 	// limit. An example use case would be easier scheduling of jobs using boskos resources.
 	// This mechanism is separate from ProwJob's MaxConcurrency setting.
 	JobQueueCapacities map[string]int `json:"job_queue_capacities,omitempty"`
+
+	// ConcurrencyBudgets limits how many ProwJobs of an org ("org") or a repo
+	// ("org/repo") run at the same time, jobs beyond the budget stay
+	// triggered. Jobs of a repo are subject to both the budget of the repo
+	// and the budget of its org. Setting a budget to 0 blocks all jobs of the
+	// org or repo. The usage of every budget is exposed as the
+	// prowjobs_concurrency_budget_usage metric.
+	ConcurrencyBudgets map[string]int `json:"concurrency_budgets,omitempty"`
+
+	// QueueStatusInterval is how often plank updates the queue position and
+	// estimated start time of jobs held back by max_concurrency, a job queue
+	// or a concurrency budget. The GitHub reporter of crier reports them on
+	// the status of the jobs. Disabled when unset.
+	QueueStatusInterval *metav1.Duration `json:"queue_status_interval,omitempty"`
+
+	// JobClasses maps the names of job classes to the nodes the pods of jobs
+	// in that class are scheduled on. Jobs opt into a class with job_class,
+	// e.g. to schedule heavy e2e jobs on a node pool of big machines while
+	// unit tests pack onto small ones. The class is also added to the
+	// prow.k8s.io/job-class label and the pending jobs per class are exposed
+	// as the prowjobs_pending_by_job_class metric, which can drive the
+	// autoscaling of the node pools.
+	JobClasses map[string]JobClass `json:"job_classes,omitempty"`
+
+	// StartupBacklog makes plank process the ProwJobs that exist when it
+	// starts in a safe order instead of an arbitrary one, so that a backlog
+	// built up during an outage doesn't starve urgent work. Aborted and
+	// pending jobs are cleaned up and finished first, which frees their
+	// pods and concurrency slots, and triggered jobs are started afterwards.
+	// The jobs left in the backlog are exposed as the plank_startup_backlog
+	// metric until it drains.
+	StartupBacklog *StartupBacklog `json:"startup_backlog,omitempty"`
+
+	// AllowedArtifactDestinations are the buckets and path prefixes that
+	// single runs created with gangway or mkpj may upload their artifacts to
+	// instead of those of their job, e.g. to keep ad-hoc experiments and
+	// release builds apart from the regular history of the job. The
+	// credentials of the jobs must be able to write to them.
+	AllowedArtifactDestinations []AllowedArtifactDestination `json:"allowed_artifact_destinations,omitempty"`
+
+	// UtilityImageVersions pins the tag of the clonerefs, initupload,
+	// entrypoint and sidecar images per build cluster, and can roll new
+	// utility images out to a share of the jobs first. Pinned tags replace
+	// the tag of the images of the decoration config of jobs.
+	UtilityImageVersions *UtilityImageVersions `json:"utility_image_versions,omitempty"`
+
+	// BuildClusterArchitectures maps the aliases of build clusters to the
+	// CPU architecture of their nodes, e.g. arm64. The "*" entry applies to
+	// clusters without one. Plank pins the utility images of jobs running on
+	// a known architecture, either from this map or from the
+	// kubernetes.io/arch node selector of the job, to the digest of the
+	// image built for it, so multi-arch utility images work on any cluster.
+	BuildClusterArchitectures map[string]string `json:"build_cluster_architectures,omitempty"`
+}
+
+const (
+	// StartupBacklogNewestFirst starts the newest triggered jobs first.
+	StartupBacklogNewestFirst = "newest_first"
+	// StartupBacklogOldestFirst starts the oldest triggered jobs first.
+	StartupBacklogOldestFirst = "oldest_first"
+)
+
+// StartupBacklog configures how plank processes the ProwJobs that exist when
+// it starts.
+type StartupBacklog struct {
+	// TriggeredOrder is the order in which the triggered jobs of the backlog
+	// are started, either "newest_first" or "oldest_first". Defaults to
+	// "newest_first", as the newest jobs usually test the latest changes
+	// while older ones are often obsolete.
+	TriggeredOrder string `json:"triggered_order,omitempty"`
+}
+
+// JobClass describes the nodes the pods of a class of jobs are scheduled on.
+type JobClass struct {
+	// NodeSelector is merged into the node selector of the pods. Keys set by
+	// the job itself take precedence.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations are added to the tolerations of the pods, usually to allow
+	// them onto the tainted nodes of a dedicated node pool.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+}
+
+// apply merges the node selector and tolerations of the class into spec.
+// It is idempotent, so that defaulting a job twice does not duplicate
+// tolerations.
+func (jc JobClass) apply(spec *v1.PodSpec) {
+	if spec == nil {
+		return
+	}
+	for k, v := range jc.NodeSelector {
+		if _, set := spec.NodeSelector[k]; set {
+			continue
+		}
+		if spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		spec.NodeSelector[k] = v
+	}
+	for _, toleration := range jc.Tolerations {
+		found := false
+		for _, existing := range spec.Tolerations {
+			if existing.MatchToleration(&toleration) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			spec.Tolerations = append(spec.Tolerations, toleration)
+		}
+	}
 }
 
 type ProwJobDefaultEntry struct {
@@ -996,6 +1191,10 @@ type GerritOrgRepoConfig struct {
 	// Filters are used for limiting the scope of querying the Gerrit server.
 	// Currently supports branches and excluded branches.
 	Filters *GerritQueryFilter `json:"filters,omitempty"`
+	// TopicTesting makes presubmits of changes that are part of a topic also
+	// check out the other open changes of the topic as extra refs, so that
+	// changes spanning multiple repos are tested together.
+	TopicTesting bool `json:"topic_testing,omitempty"`
 }
 
 type GerritQueryFilter struct {
@@ -1036,11 +1235,67 @@ func (goc *GerritOrgRepoConfigs) OptOutHelpRepos() map[string]sets.Set[string] {
 	return res
 }
 
+// TopicTestingEnabled returns true if changes of the repo are tested together
+// with the other changes of their topic.
+func (goc *GerritOrgRepoConfigs) TopicTestingEnabled(org, repo string) bool {
+	if goc == nil {
+		return false
+	}
+	for _, orgConfig := range *goc {
+		if orgConfig.TopicTesting && orgConfig.Org == org && sets.New[string](orgConfig.Repos...).Has(repo) {
+			return true
+		}
+	}
+	return false
+}
+
 // Horologium is config for the Horologium.
 type Horologium struct {
 	// TickInterval is the interval in which we check if new jobs need to be
 	// created. Defaults to one minute.
 	TickInterval *metav1.Duration `json:"tick_interval,omitempty"`
+	// AutoDisable configures Horologium to stop scheduling periodics that
+	// have not succeeded for a long time. Disabled if unset.
+	AutoDisable *PeriodicAutoDisable `json:"auto_disable,omitempty"`
+}
+
+// PeriodicAutoDisable configures the automatic disabling of periodics that
+// keep failing. Once a periodic qualifies, its owner (see the owner field of
+// the job) is notified and, unless the periodic succeeds during the grace
+// period, Horologium stops scheduling it. Decisions are based on the ProwJobs
+// that still exist, so failing_for must be shorter than sinker's
+// max_prowjob_age.
+type PeriodicAutoDisable struct {
+	// FailingFor is how long a periodic must have been failing without a
+	// single successful run before its owner is notified. Defaults to 72h.
+	FailingFor *metav1.Duration `json:"failing_for,omitempty"`
+	// GracePeriod is how long after notifying the owner Horologium waits
+	// before it stops scheduling the periodic. Defaults to 24h.
+	GracePeriod *metav1.Duration `json:"grace_period,omitempty"`
+	// MinimumRuns is the minimum number of failed runs within failing_for
+	// for a periodic to qualify. Defaults to 3.
+	MinimumRuns int `json:"minimum_runs,omitempty"`
+}
+
+// DefaultAndValidate defaults and validates the auto disable config. The
+// maxProwJobAge is the age at which sinker garbage collects ProwJobs.
+func (a *PeriodicAutoDisable) DefaultAndValidate(maxProwJobAge time.Duration) error {
+	if a.FailingFor == nil {
+		a.FailingFor = &metav1.Duration{Duration: 72 * time.Hour}
+	}
+	if a.GracePeriod == nil {
+		a.GracePeriod = &metav1.Duration{Duration: 24 * time.Hour}
+	}
+	if a.MinimumRuns == 0 {
+		a.MinimumRuns = 3
+	}
+	if a.FailingFor.Duration <= 0 || a.GracePeriod.Duration < 0 || a.MinimumRuns < 0 {
+		return errors.New("failing_for must be positive, grace_period and minimum_runs must not be negative")
+	}
+	if a.FailingFor.Duration >= maxProwJobAge {
+		return fmt.Errorf("failing_for (%s) must be shorter than sinker's max_prowjob_age (%s)", a.FailingFor.Duration, maxProwJobAge)
+	}
+	return nil
 }
 
 // JenkinsOperator is config for the jenkins-operator controller.
@@ -1057,6 +1312,131 @@ type JenkinsOperator struct {
 	// LabelSelector is used so different jenkins-operator replicas
 	// can use their own configuration.
 	LabelSelector labels.Selector `json:"-"`
+	// Masters are Jenkins masters that builds are routed to instead of the
+	// master given with --jenkins-url, by the labels or repo of the ProwJob.
+	Masters []JenkinsMaster `json:"masters,omitempty"`
+}
+
+// JenkinsMaster is a Jenkins master the jenkins-operator starts builds on.
+type JenkinsMaster struct {
+	// Name identifies the master. It's recorded in the
+	// prow.k8s.io/jenkins-master annotation of the ProwJobs routed to it.
+	Name string `json:"name"`
+	// URL is the URL of the master.
+	URL string `json:"url"`
+	// LabelSelectorString routes the ProwJobs that match it to the master.
+	// Label selectors take precedence over Repos.
+	LabelSelectorString string `json:"label_selector,omitempty"`
+	// LabelSelector is compiled from LabelSelectorString at load time.
+	LabelSelector labels.Selector `json:"-"`
+	// Repos routes the ProwJobs of these orgs or org/repos to the master.
+	// Repos take precedence over orgs.
+	Repos []string `json:"repos,omitempty"`
+	// JenkinsCredentials are the credentials for the master.
+	JenkinsCredentials `json:",inline"`
+	// Folders are Jenkins folders that need other credentials than the
+	// master. Jobs in folders are named like folder/job.
+	Folders []JenkinsFolder `json:"folders,omitempty"`
+}
+
+// JenkinsCredentials are credentials for a Jenkins master. The files need
+// to be mounted in the jenkins-operator.
+type JenkinsCredentials struct {
+	// User is the user for basic auth, with the API token in TokenFile.
+	User      string `json:"user,omitempty"`
+	TokenFile string `json:"token_file,omitempty"`
+	// BearerTokenFile is the file of the bearer token, mutually exclusive
+	// with TokenFile.
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+	// CSRFProtect requests a CSRF protection token from Jenkins.
+	CSRFProtect bool `json:"csrf_protect,omitempty"`
+}
+
+// JenkinsFolder configures the credentials for the jobs of a Jenkins folder.
+type JenkinsFolder struct {
+	// Path is the path of the folder, like team or team/subfolder.
+	Path               string `json:"path"`
+	JenkinsCredentials `json:",inline"`
+}
+
+// MasterFor returns the Jenkins master configured for the ProwJob, or nil if
+// the ProwJob runs on the default master.
+func (o JenkinsOperator) MasterFor(pj *prowapi.ProwJob) *JenkinsMaster {
+	for i, master := range o.Masters {
+		if master.LabelSelector != nil && master.LabelSelectorString != "" && master.LabelSelector.Matches(labels.Set(pj.Labels)) {
+			return &o.Masters[i]
+		}
+	}
+	if pj.Spec.Refs == nil {
+		return nil
+	}
+	for _, key := range []string{pj.Spec.Refs.OrgRepoString(), pj.Spec.Refs.Org} {
+		for i, master := range o.Masters {
+			if sets.New[string](master.Repos...).Has(key) {
+				return &o.Masters[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Master returns the Jenkins master with the given name.
+func (o JenkinsOperator) Master(name string) (*JenkinsMaster, error) {
+	for i, master := range o.Masters {
+		if master.Name == name {
+			return &o.Masters[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no Jenkins master named %q is configured", name)
+}
+
+// CredentialsFor returns the credentials for the Jenkins job with the given
+// name, from the folder with the longest matching path.
+func (m JenkinsMaster) CredentialsFor(job string) (string, JenkinsCredentials) {
+	folder, credentials := "", m.JenkinsCredentials
+	for _, f := range m.Folders {
+		path := strings.Trim(f.Path, "/")
+		if strings.HasPrefix(strings.Trim(job, "/"), path+"/") && len(path) > len(folder) {
+			folder, credentials = path, f.JenkinsCredentials
+		}
+	}
+	return folder, credentials
+}
+
+func (c JenkinsCredentials) validate() error {
+	if c.TokenFile != "" && c.BearerTokenFile != "" {
+		return errors.New("token_file and bearer_token_file are mutually exclusive")
+	}
+	if c.TokenFile != "" && c.User == "" {
+		return errors.New("token_file requires a user")
+	}
+	return nil
+}
+
+func (m *JenkinsMaster) validate() error {
+	if m.Name == "" {
+		return errors.New("name must be set")
+	}
+	if _, err := url.ParseRequestURI(m.URL); err != nil {
+		return fmt.Errorf("invalid url %q: %w", m.URL, err)
+	}
+	sel, err := labels.Parse(m.LabelSelectorString)
+	if err != nil {
+		return fmt.Errorf("invalid label_selector: %w", err)
+	}
+	m.LabelSelector = sel
+	if err := m.JenkinsCredentials.validate(); err != nil {
+		return err
+	}
+	for _, folder := range m.Folders {
+		if strings.Trim(folder.Path, "/") == "" {
+			return errors.New("folders need a path")
+		}
+		if err := folder.JenkinsCredentials.validate(); err != nil {
+			return fmt.Errorf("folder %q: %w", folder.Path, err)
+		}
+	}
+	return nil
 }
 
 // GitHubReporter holds the config for report behavior in github.
@@ -1092,8 +1472,19 @@ type Sinker struct {
 	TerminatedPodTTL *metav1.Duration `json:"terminated_pod_ttl,omitempty"`
 	// ExcludeClusters are build clusters that don't want to be managed by sinker.
 	ExcludeClusters []string `json:"exclude_clusters,omitempty"`
+	// Resources are the kinds of objects besides pods that sinker
+	// garbage-collects in the build clusters, like the PipelineRuns of the
+	// pipeline agent: pipelineruns, configmaps or secrets. Objects with the
+	// created-by-prow label are cleaned like the pods of their ProwJob, given
+	// by their prow.k8s.io/id label. Sinker needs permission to list, watch
+	// and delete them.
+	Resources []string `json:"resources,omitempty"`
 }
 
+// SinkerResources are the kinds of objects sinker can garbage-collect
+// besides pods.
+var SinkerResources = []string{"pipelineruns", "configmaps", "secrets"}
+
 // LensConfig names a specific lens, and optionally provides some configuration for it.
 type LensConfig struct {
 	// Name is the name of the lens.
@@ -1127,7 +1518,8 @@ type LensRemoteConfig struct {
 	Endpoint string `json:"endpoint"`
 	// The parsed endpoint.
 	ParsedEndpoint *url.URL `json:"-"`
-	// The endpoint for static resources.
+	// The endpoint for static resources, which browsers load them from.
+	// Defaults to Deck's /spyglass/static/<lens name>/.
 	StaticRoot string `json:"static_root"`
 	// The human-readable title for the lens.
 	Title string `json:"title"`
@@ -1135,7 +1527,21 @@ type LensRemoteConfig struct {
 	Priority *uint `json:"priority"`
 	// HideTitle defines if we will keep showing the title after lens loads.
 	HideTitle *bool `json:"hide_title"`
-}
+	// ProtocolVersion is the version of the lens serving protocol the lens
+	// understands. With v1, Deck pushes the requested artifacts to the lens,
+	// so it doesn't need access to their storage. Unset by default, which
+	// leaves fetching the artifacts to the lens.
+	ProtocolVersion string `json:"protocol_version,omitempty"`
+	// MaxArtifactBytes is how many bytes of artifacts are pushed to the lens
+	// per request with protocol v1. Defaults to 10 MiB.
+	MaxArtifactBytes int64 `json:"max_artifact_bytes,omitempty"`
+	// Timeout is how long the lens may take to respond. Unlimited if unset.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// LensProtocolVersionV1 is the version of the lens serving protocol in which
+// Deck pushes the artifacts to the lens.
+const LensProtocolVersionV1 = "v1"
 
 // Spyglass holds config for Spyglass.
 type Spyglass struct {
@@ -1187,6 +1593,19 @@ type Spyglass struct {
 	// Keys represent aliases and their values are the authoritative
 	// bucket names they will be substituted with
 	BucketAliases map[string]string `json:"bucket_aliases,omitempty"`
+	// RepoLenses configures the lenses for the jobs of some orgs or repos.
+	// Use `org/repo`, `org` or `*` as a key. All entries that match a job
+	// are applied, from the least to the most specific one.
+	RepoLenses map[string]SpyglassRepoLenses `json:"repo_lenses,omitempty"`
+	// MaxSignedURLExpiry allows /permalink to sign artifact URLs that are valid
+	// for up to this long, to share artifacts with users who can't access the
+	// bucket. Permalinks can't sign URLs if it's unset. It can't be longer
+	// than 168h (7 days).
+	MaxSignedURLExpiry *metav1.Duration `json:"max_signed_url_expiry,omitempty"`
+	// LensRegistries are lens servers whose lenses are discovered from
+	// their registry endpoint, in addition to the configured Lenses. A
+	// discovered lens is ignored if a lens with the same name is configured.
+	LensRegistries []LensRegistry `json:"lens_registries,omitempty"`
 }
 
 type GCSBrowserPrefixes map[string]string
@@ -1233,6 +1652,20 @@ type Deck struct {
 	Branding *Branding `json:"branding,omitempty"`
 	// GoogleAnalytics, if specified, include a Google Analytics tracking code on each page.
 	GoogleAnalytics string `json:"google_analytics,omitempty"`
+	// OnCall, if specified, makes Deck show who is on call for the CI of
+	// a repo next to its failing jobs.
+	OnCall *OnCall `json:"oncall,omitempty"`
+	// Dashboards group periodics into dashboards that Deck shows on
+	// /dashboards as a grid of the results of their recent runs, as a
+	// lightweight alternative to TestGrid.
+	Dashboards []Dashboard `json:"dashboards,omitempty"`
+	// Tenants are the teams sharing this Prow. Deck serves views of the
+	// status, Tide and job history pages under /t/<name>/ that only show
+	// the jobs and PRs of the repos of a tenant.
+	Tenants []DeckTenant `json:"tenants,omitempty"`
+	// JobEditor, if specified, lets users edit the file a job is defined in
+	// from Deck and propose the change as a PR to the repo of the job config.
+	JobEditor *JobEditor `json:"job_editor,omitempty"`
 	// RerunAuthConfigs is not deprecated but DefaultRerunAuthConfigs should be used in favor.
 	// It remains a part of Deck for the purposes of backwards compatibility.
 	// RerunAuthConfigs is a map of configs that specify who is able to trigger job reruns. The field
@@ -1279,6 +1712,65 @@ func (d *Deck) Validate() error {
 		}
 	}
 
+	if d.OnCall != nil {
+		if d.OnCall.URL == "" {
+			return errors.New("deck.oncall.url is required")
+		}
+		if d.OnCall.UpdatePeriod != nil && d.OnCall.UpdatePeriod.Duration <= 0 {
+			return errors.New("deck.oncall.update_period must be positive")
+		}
+	}
+
+	dashboards := sets.New[string]()
+	for i, dashboard := range d.Dashboards {
+		if dashboard.Name == "" {
+			return fmt.Errorf("deck.dashboards[%d].name is required", i)
+		}
+		if dashboards.Has(dashboard.Name) {
+			return fmt.Errorf("deck.dashboards: duplicate dashboard %q", dashboard.Name)
+		}
+		dashboards.Insert(dashboard.Name)
+		if len(dashboard.Jobs) == 0 {
+			return fmt.Errorf("deck.dashboards[%d].jobs: dashboard %q has no jobs", i, dashboard.Name)
+		}
+		for _, glob := range dashboard.Jobs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("deck.dashboards[%d].jobs: invalid glob %q: %w", i, glob, err)
+			}
+		}
+		if dashboard.MaxRuns < 0 {
+			return fmt.Errorf("deck.dashboards[%d].max_runs must not be negative", i)
+		}
+	}
+
+	tenants := sets.New[string]()
+	for i, tenant := range d.Tenants {
+		if !tenantNameRegex.MatchString(tenant.Name) {
+			return fmt.Errorf("deck.tenants[%d].name: %q must consist of lower case alphanumeric characters or '-'", i, tenant.Name)
+		}
+		if tenants.Has(tenant.Name) {
+			return fmt.Errorf("deck.tenants: duplicate tenant %q", tenant.Name)
+		}
+		tenants.Insert(tenant.Name)
+		if len(tenant.Repos) == 0 && len(tenant.Jobs) == 0 {
+			return fmt.Errorf("deck.tenants[%d]: tenant %q has neither repos nor jobs", i, tenant.Name)
+		}
+		for _, glob := range tenant.Jobs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("deck.tenants[%d].jobs: invalid glob %q: %w", i, glob, err)
+			}
+		}
+		if err := tenant.Viewers.Validate(); err != nil {
+			return fmt.Errorf("deck.tenants[%d].viewers: %w", i, err)
+		}
+	}
+
+	if d.JobEditor != nil {
+		if err := d.JobEditor.Validate(); err != nil {
+			return fmt.Errorf("deck.job_editor.%w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -1387,6 +1879,104 @@ type ExternalAgentLog struct {
 	URLTemplate *template.Template `json:"-"`
 }
 
+// OnCall configures where Deck finds who is on call for the CI of the
+// repos.
+type OnCall struct {
+	// URL returns the current on-call of every team as a JSON object that
+	// maps the team names to the logins on call, like
+	// {"ci": ["alice"], "infra": ["bob", "carol"]}.
+	URL string `json:"url"`
+	// Teams maps org or org/repo to the team on call for the CI of the
+	// repos. "*" matches every repo. Jobs whose owner has a team show the
+	// on-call of that team instead.
+	Teams map[string]string `json:"teams,omitempty"`
+	// UpdatePeriod specifies how often Deck fetches the on-call. Defaults
+	// to 5m.
+	UpdatePeriod *metav1.Duration `json:"update_period,omitempty"`
+}
+
+// Dashboard is a group of periodics shown together by Deck.
+type Dashboard struct {
+	// Name identifies the dashboard in the URL of its page.
+	Name string `json:"name"`
+	// Description is shown above the grid of the dashboard.
+	Description string `json:"description,omitempty"`
+	// Jobs are the globs of the names of the periodics on the dashboard,
+	// like "ci-kubernetes-e2e-*".
+	Jobs []string `json:"jobs"`
+	// MaxRuns is how many of the most recent runs of every job are shown.
+	// Defaults to 20.
+	MaxRuns int `json:"max_runs,omitempty"`
+}
+
+// HasJob tells whether the periodic is on the dashboard.
+func (d Dashboard) HasJob(name string) bool {
+	for _, glob := range d.Jobs {
+		if matched, _ := filepath.Match(glob, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+var tenantNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// DeckTenant is a team whose jobs and PRs Deck shows on their own.
+type DeckTenant struct {
+	// Name identifies the tenant in the URL of its views, like /t/<name>/.
+	Name string `json:"name"`
+	// Repos are the orgs and org/repos of the tenant.
+	Repos []string `json:"repos,omitempty"`
+	// Jobs are the globs of the names of further jobs of the tenant, like
+	// periodics that don't clone any of its repos.
+	Jobs []string `json:"jobs,omitempty"`
+	// Viewers, if set, restricts the views of the tenant to the users it
+	// authorizes, who log in with GitHub OAuth or OIDC. The jobs, Tide pools
+	// and job history of a restricted tenant are left out of the views of
+	// Deck that aren't scoped to a tenant.
+	Viewers *prowapi.RerunAuthConfig `json:"viewers,omitempty"`
+}
+
+// Restricted tells whether only some users may view the tenant.
+func (t DeckTenant) Restricted() bool {
+	return t.Viewers != nil && !t.Viewers.IsAllowAnyone()
+}
+
+// HasRepo tells whether the org/repo belongs to the tenant.
+func (t DeckTenant) HasRepo(orgRepo string) bool {
+	org := strings.Split(orgRepo, "/")[0]
+	for _, repo := range t.Repos {
+		if repo == orgRepo || repo == org {
+			return true
+		}
+	}
+	return false
+}
+
+// HasJob tells whether the job, which clones the refs if any, belongs to
+// the tenant.
+func (t DeckTenant) HasJob(name string, refs *prowapi.Refs) bool {
+	if refs != nil && t.HasRepo(refs.OrgRepoString()) {
+		return true
+	}
+	for _, glob := range t.Jobs {
+		if matched, _ := filepath.Match(glob, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTenant returns the tenant with the name, if there is one.
+func (d *Deck) GetTenant(name string) *DeckTenant {
+	for i := range d.Tenants {
+		if d.Tenants[i].Name == name {
+			return &d.Tenants[i]
+		}
+	}
+	return nil
+}
+
 // Branding holds branding configuration for deck.
 type Branding struct {
 	// Logo is the location of the logo that will be loaded in deck.
@@ -1533,6 +2123,12 @@ type PubSubTrigger struct {
 	AllowedClusters []string `json:"allowed_clusters"`
 	// MaxOutstandingMessages is the max number of messaged being processed, default is 10.
 	MaxOutstandingMessages int `json:"max_outstanding_messages"`
+	// IdempotencyWindow makes messages that request a job that was created
+	// within the window be dropped, so redelivered messages don't create
+	// duplicate ProwJobs. Messages are identified by their
+	// prow.k8s.io/pubsub.IdempotencyKey attribute, or by their payload if
+	// they don't have it. Unset disables the check.
+	IdempotencyWindow *metav1.Duration `json:"idempotency_window,omitempty"`
 }
 
 // GitHubOptions allows users to control how prow applications display GitHub website links.
@@ -1561,6 +2157,11 @@ type ManagedWebhooks struct {
 	// will be left pending.
 	AutoAcceptInvitation bool                          `json:"auto_accept_invitation"`
 	OrgRepoConfig        map[string]ManagedWebhookInfo `json:"org_repo_config,omitempty"`
+	// RotationOverlap is how long the tokens replaced by a rotation keep
+	// validating webhooks, so that hook accepts the webhooks GitHub signed
+	// with them while the webhooks are updated. Replaced tokens are removed
+	// right away if unset.
+	RotationOverlap *metav1.Duration `json:"rotation_overlap,omitempty"`
 }
 
 // SlackReporter represents the config for the Slack reporter. The channel can be overridden
@@ -1634,25 +2235,120 @@ func (cfg *SlackReporter) DefaultAndValidate() error {
 	return nil
 }
 
+// ExecReporter configures a command run by crier's exec reporter when jobs
+// reach one of the given states. The command gets the ProwJob as JSON on stdin
+// and a minimal environment, but runs with the privileges of crier.
+type ExecReporter struct {
+	// Name identifies the command in logs. It must be unique.
+	Name string `json:"name"`
+	// Command is the executable and its arguments. It isn't run in a shell.
+	Command []string `json:"command"`
+	// JobStatesToReport are the states the command is run for.
+	JobStatesToReport []prowapi.ProwJobState `json:"job_states_to_report"`
+	// JobTypesToReport restricts the command to jobs of these types.
+	// Defaults to all types.
+	JobTypesToReport []prowapi.ProwJobType `json:"job_types_to_report,omitempty"`
+	// Jobs restricts the command to jobs whose name matches one of these
+	// shell patterns. Defaults to all jobs.
+	Jobs []string `json:"jobs,omitempty"`
+	// Env are extra environment variables set for the command.
+	Env map[string]string `json:"env,omitempty"`
+	// Timeout after which the command is killed. Defaults to 1m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// MaxConcurrency is how many instances of the command may run at once.
+	// Reports beyond it are retried later. Defaults to 1.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+}
+
+// ShouldReport returns whether the command is run for the job in its current
+// state.
+func (cfg *ExecReporter) ShouldReport(pj *prowapi.ProwJob) bool {
+	if !slices.Contains(cfg.JobStatesToReport, pj.Status.State) {
+		return false
+	}
+	if len(cfg.JobTypesToReport) > 0 && !slices.Contains(cfg.JobTypesToReport, pj.Spec.Type) {
+		return false
+	}
+	if len(cfg.Jobs) == 0 {
+		return true
+	}
+	for _, pattern := range cfg.Jobs {
+		if matched, _ := filepath.Match(pattern, pj.Spec.Job); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultAndValidate defaults the timeout and concurrency of the command and
+// validates it.
+func (cfg *ExecReporter) DefaultAndValidate() error {
+	if cfg.Name == "" {
+		return errors.New("name must be set")
+	}
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return errors.New("command must be set")
+	}
+	if len(cfg.JobStatesToReport) == 0 {
+		return errors.New("job_states_to_report must be set")
+	}
+	for _, pattern := range cfg.Jobs {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid jobs pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.Timeout == nil {
+		cfg.Timeout = &metav1.Duration{Duration: time.Minute}
+	} else if cfg.Timeout.Duration <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if cfg.MaxConcurrency == 0 {
+		cfg.MaxConcurrency = 1
+	} else if cfg.MaxConcurrency < 0 {
+		return errors.New("max_concurrency must not be negative")
+	}
+	return nil
+}
+
+// parseCommitTemplates parses the templates for the title and body of merge
+// commits. Unset templates are nil.
+func parseCommitTemplates(title, body string) (titleTemplate, bodyTemplate *template.Template, err error) {
+	if title != "" {
+		if titleTemplate, err = template.New("CommitTitle").Parse(title); err != nil {
+			return nil, nil, fmt.Errorf("parsing template for commit title: %w", err)
+		}
+	}
+
+	if body != "" {
+		if bodyTemplate, err = template.New("CommitBody").Parse(body); err != nil {
+			return nil, nil, fmt.Errorf("parsing template for commit body: %w", err)
+		}
+	}
+
+	return titleTemplate, bodyTemplate, nil
+}
+
 // Load loads and parses the config at path.
 func Load(prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) (c *Config, err error) {
-	return loadWithYamlOpts(nil, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	return loadWithYamlOpts(nil, nil, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 }
 
 // LoadStrict loads and parses the config at path.
 // Unlike Load it unmarshalls yaml with strict parsing.
 func LoadStrict(prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) (c *Config, err error) {
-	return loadWithYamlOpts([]yaml.JSONOpt{yaml.DisallowUnknownFields}, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
+	return loadWithYamlOpts([]yaml.JSONOpt{yaml.DisallowUnknownFields}, nil, prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, additionals...)
 }
 
-func loadWithYamlOpts(yamlOpts []yaml.JSONOpt, prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) (c *Config, err error) {
+// loadWithYamlOpts loads the config. If shards is set, the job config is read
+// by shard if the config enables it.
+func loadWithYamlOpts(yamlOpts []yaml.JSONOpt, shards *jobConfigShards, prowConfig, jobConfig string, supplementalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, additionals ...func(*Config) error) (c *Config, err error) {
 	// we never want config loading to take down the prow components.
 	defer func() {
 		if r := recover(); r != nil {
 			c, err = nil, fmt.Errorf("panic loading config: %v\n%s", r, string(debug.Stack()))
 		}
 	}()
-	c, err = loadConfig(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, yamlOpts...)
+	c, err = loadConfig(prowConfig, jobConfig, supplementalProwConfigDirs, supplementalProwConfigsFileNameSuffix, shards, yamlOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1665,6 +2361,9 @@ func loadWithYamlOpts(yamlOpts []yaml.JSONOpt, prowConfig, jobConfig string, sup
 	if err := c.ValidateJobConfig(); err != nil {
 		return nil, err
 	}
+	if err := c.validateTenantJobs(jobConfig); err != nil {
+		return nil, fmt.Errorf("validating tenant jobs: %w", err)
+	}
 
 	for _, additional := range additionals {
 		if err := additional(c); err != nil {
@@ -1690,26 +2389,53 @@ func ReadJobConfig(jobConfig string, yamlOpts ...yaml.JSONOpt) (JobConfig, error
 		return jc, nil
 	}
 
-	prowIgnore, err := gitignore.NewRepositoryWithFile(jobConfig, ProwIgnoreFileName)
+	jcs, errs, err := readJobConfigFiles(jobConfig, func(string) string { return "" }, yamlOpts...)
 	if err != nil {
-		return JobConfig{}, fmt.Errorf("failed to create `%s` parser: %w", ProwIgnoreFileName, err)
+		return JobConfig{}, err
 	}
+	if err := utilerrors.NewAggregate(errs[""]); err != nil {
+		return JobConfig{}, err
+	}
+	return jcs[""], nil
+}
+
+// readJobConfigFiles reads the job config files in the jobConfig directory
+// and merges them into the JobConfig of the shard each file belongs to.
+// Files that can't be read or merged don't stop the others from being read,
+// their errors are returned by shard.
+func readJobConfigFiles(jobConfig string, shardOf func(path string) string, yamlOpts ...yaml.JSONOpt) (map[string]JobConfig, map[string][]error, error) {
 	// we need to ensure all config files have unique basenames,
 	// since updateconfig plugin will use basename as a key in the configmap.
 	uniqueBasenames := sets.Set[string]{}
 
 	jobConfigCount := 0
 	allStart := time.Now()
-	jc := JobConfig{}
-	var errs []error
-	err = filepath.Walk(jobConfig, func(path string, info os.FileInfo, err error) error {
+	jcs := map[string]JobConfig{}
+	errs := map[string][]error{}
+	// The job config directory may be a link that is swapped to a new
+	// directory, like the current bundle of a synced job config. Walk the
+	// directory it points to now, so that all the files come from the same
+	// version, while the paths of the jobs keep going through the link.
+	root, err := filepath.EvalSymlinks(jobConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve the job config path: %w", err)
+	}
+	prowIgnore, err := gitignore.NewRepositoryWithFile(root, ProwIgnoreFileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create `%s` parser: %w", ProwIgnoreFileName, err)
+	}
+	err = filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		path := jobConfig
+		if rel, relErr := filepath.Rel(root, file); relErr == nil && rel != "." {
+			path = filepath.Join(jobConfig, rel)
+		}
 		if err != nil {
 			logrus.WithError(err).Errorf("walking path %q.", path)
 			// bad file should not stop us from parsing the directory.
 			return nil
 		}
 
-		if strings.HasPrefix(info.Name(), "..") {
+		if file != root && strings.HasPrefix(info.Name(), "..") {
 			// kubernetes volumes also include files we
 			// should not look be looking into for keys.
 			if info.IsDir() {
@@ -1721,7 +2447,7 @@ func ReadJobConfig(jobConfig string, yamlOpts ...yaml.JSONOpt) (JobConfig, error
 			return nil
 		}
 		// Use 'Match' directly because 'Ignore' and 'Include' don't work properly for repositories.
-		match := prowIgnore.Match(path)
+		match := prowIgnore.Match(file)
 		if match != nil && match.Ignore() {
 			return nil
 		}
@@ -1730,39 +2456,40 @@ func ReadJobConfig(jobConfig string, yamlOpts ...yaml.JSONOpt) (JobConfig, error
 			return nil
 		}
 
+		shard := shardOf(path)
 		base := filepath.Base(path)
 		if uniqueBasenames.Has(base) {
-			errs = append(errs, fmt.Errorf("duplicated basename is not allowed: %s", base))
+			errs[shard] = append(errs[shard], fmt.Errorf("duplicated basename is not allowed: %s", base))
 			return nil
 		}
 		uniqueBasenames.Insert(base)
 
 		fileStart := time.Now()
 		var subConfig JobConfig
-		if err := yamlToConfig(path, &subConfig, yamlOpts...); err != nil {
-			errs = append(errs, err)
+		if err := readYAMLConfig(file, path, &subConfig, yamlOpts...); err != nil {
+			errs[shard] = append(errs[shard], err)
 			return nil
 		}
-		jc, err = mergeJobConfigs(jc, subConfig)
+		jc, err := mergeJobConfigs(jcs[shard], subConfig)
 		if err == nil {
 			logrus.WithField("jobConfig", path).WithField("duration", time.Since(fileStart)).Traceln("config loaded")
+			jcs[shard] = jc
 			jobConfigCount++
 		} else {
-			errs = append(errs, err)
+			errs[shard] = append(errs[shard], err)
 		}
 		return nil
 	})
-	err = utilerrors.NewAggregate(append(errs, err))
 	if err != nil {
-		return JobConfig{}, err
+		return nil, nil, err
 	}
-	logrus.WithField("count", jobConfigCount).WithField("duration", time.Since(allStart)).Traceln("jobConfigs loaded successfully")
+	logrus.WithField("count", jobConfigCount).WithField("duration", time.Since(allStart)).Traceln("jobConfigs loaded")
 
-	return jc, nil
+	return jcs, errs, nil
 }
 
 // loadConfig loads one or multiple config files and returns a config object.
-func loadConfig(prowConfig, jobConfig string, additionalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, yamlOpts ...yaml.JSONOpt) (*Config, error) {
+func loadConfig(prowConfig, jobConfig string, additionalProwConfigDirs []string, supplementalProwConfigsFileNameSuffix string, shards *jobConfigShards, yamlOpts ...yaml.JSONOpt) (*Config, error) {
 	stat, err := os.Stat(prowConfig)
 	if err != nil {
 		return nil, err
@@ -1882,6 +2609,19 @@ func loadConfig(prowConfig, jobConfig string, additionalProwConfigDirs []string,
 		if trigger.MaxOutstandingMessages == 0 {
 			nc.PubSubTriggers[i].MaxOutstandingMessages = defaultMaxOutstandingMessages
 		}
+		if trigger.IdempotencyWindow != nil && trigger.IdempotencyWindow.Duration <= 0 {
+			return nil, fmt.Errorf("idempotency_window of the pubsub trigger for project %q must be positive", trigger.Project)
+		}
+	}
+	for i := range nc.KafkaTriggers {
+		if err := nc.KafkaTriggers[i].defaultAndValidate(); err != nil {
+			return nil, fmt.Errorf("kafka_triggers[%d]: %w", i, err)
+		}
+	}
+	for i := range nc.AMQPTriggers {
+		if err := nc.AMQPTriggers[i].defaultAndValidate(); err != nil {
+			return nil, fmt.Errorf("amqp_triggers[%d]: %w", i, err)
+		}
 	}
 
 	// TODO(krzyzacy): temporary allow empty jobconfig
@@ -1890,20 +2630,33 @@ func loadConfig(prowConfig, jobConfig string, additionalProwConfigDirs []string,
 		return &nc, nil
 	}
 
-	jc, err := ReadJobConfig(jobConfig, yamlOpts...)
+	var jc JobConfig
+	var staleShards map[string]string
+	if shards != nil && nc.ShardJobConfig {
+		jc, staleShards, err = shards.read(jobConfig, yamlOpts...)
+	} else {
+		jc, err = ReadJobConfig(jobConfig, yamlOpts...)
+	}
 	if err != nil {
 		return nil, err
 	}
 	if err := nc.mergeJobConfig(jc); err != nil {
 		return nil, err
 	}
+	nc.StaleShards = staleShards
 
 	return &nc, nil
 }
 
 // yamlToConfig converts a yaml file into a Config object.
 func yamlToConfig(path string, nc interface{}, opts ...yaml.JSONOpt) error {
-	b, err := ReadFileMaybeGZIP(path)
+	return readYAMLConfig(path, path, nc, opts...)
+}
+
+// readYAMLConfig is like yamlToConfig, but reads the config from file while
+// reporting it, and setting the source path of its jobs, as path.
+func readYAMLConfig(file, path string, nc interface{}, opts ...yaml.JSONOpt) error {
+	b, err := ReadFileMaybeGZIP(file)
 	if err != nil {
 		return fmt.Errorf("error reading %s: %w", path, err)
 	}
@@ -2085,6 +2838,7 @@ func defaultPresubmits(presubmits []Presubmit, additionalPresets []Preset, c *Co
 	c.defaultPresubmitFields(presubmits)
 	var errs []error
 	for idx, ps := range presubmits {
+		c.applyJobDefaults(&presubmits[idx].JobBase, repo)
 		setPresubmitDecorationDefaults(c, &presubmits[idx], repo)
 		setPresubmitProwJobDefaults(c, &presubmits[idx], repo)
 		if err := resolvePresets(ps.Name, ps.Labels, ps.Spec, append(c.Presets, additionalPresets...)); err != nil {
@@ -2103,6 +2857,7 @@ func defaultPostsubmits(postsubmits []Postsubmit, additionalPresets []Preset, c
 	c.defaultPostsubmitFields(postsubmits)
 	var errs []error
 	for idx, ps := range postsubmits {
+		c.applyJobDefaults(&postsubmits[idx].JobBase, repo)
 		setPostsubmitDecorationDefaults(c, &postsubmits[idx], repo)
 		setPostsubmitProwJobDefaults(c, &postsubmits[idx], repo)
 		if err := resolvePresets(ps.Name, ps.Labels, ps.Spec, append(c.Presets, additionalPresets...)); err != nil {
@@ -2118,6 +2873,11 @@ func defaultPostsubmits(postsubmits []Postsubmit, additionalPresets []Preset, c
 // DefaultPeriodic defaults (mutates) a single Periodic.
 func (c *Config) DefaultPeriodic(periodic *Periodic) error {
 	c.defaultPeriodicFields(periodic)
+	var repo string
+	if len(periodic.UtilityConfig.ExtraRefs) > 0 {
+		repo = fmt.Sprintf("%s/%s", periodic.UtilityConfig.ExtraRefs[0].Org, periodic.UtilityConfig.ExtraRefs[0].Repo)
+	}
+	c.applyJobDefaults(&periodic.JobBase, repo)
 	setPeriodicDecorationDefaults(c, periodic)
 	setPeriodicProwJobDefaults(c, periodic)
 	return resolvePresets(periodic.Name, periodic.Labels, periodic.Spec, c.Presets)
@@ -2166,6 +2926,16 @@ func (c *Config) validateComponentConfig() error {
 			return fmt.Errorf(`invalid value for Planks job_url_prefix_config["%s"]: %v`, k, err)
 		}
 	}
+	for name := range c.Plank.JobClasses {
+		if errs := validation.IsValidLabelValue(name); len(errs) != 0 || name == "" {
+			return fmt.Errorf("invalid name %q in plank.job_classes, it must be a non-empty label value: %v", name, errs)
+		}
+	}
+	for _, resource := range c.Sinker.Resources {
+		if !slices.Contains(SinkerResources, resource) {
+			return fmt.Errorf("invalid resource %q in sinker.resources, it must be one of %s", resource, strings.Join(SinkerResources, ", "))
+		}
+	}
 	if c.Gerrit.DeckURL != "" {
 		if _, err := url.Parse(c.Gerrit.DeckURL); err != nil {
 			return fmt.Errorf("invalid value for gerrit.deck_url: %v", err)
@@ -2173,15 +2943,19 @@ func (c *Config) validateComponentConfig() error {
 	}
 
 	var validationErrs []error
+	if c.ManagedWebhooks.RotationOverlap != nil && c.ManagedWebhooks.RotationOverlap.Duration < 0 {
+		validationErrs = append(validationErrs, fmt.Errorf("managed_webhooks.rotation_overlap must not be negative, not %s", c.ManagedWebhooks.RotationOverlap.Duration))
+	}
+
 	if c.ManagedWebhooks.OrgRepoConfig != nil {
 		for repoName, repoValue := range c.ManagedWebhooks.OrgRepoConfig {
 			if repoValue.TokenCreatedAfter.After(time.Now()) {
 				validationErrs = append(validationErrs, fmt.Errorf("token_created_after %s can be no later than current time for repo/org %s", repoValue.TokenCreatedAfter, repoName))
 			}
 		}
-		if len(validationErrs) > 0 {
-			return utilerrors.NewAggregate(validationErrs)
-		}
+	}
+	if len(validationErrs) > 0 {
+		return utilerrors.NewAggregate(validationErrs)
 	}
 
 	if c.SlackReporterConfigs != nil {
@@ -2193,6 +2967,22 @@ func (c *Config) validateComponentConfig() error {
 		}
 	}
 
+	execReporterNames := sets.New[string]()
+	for i := range c.ExecReporterConfigs {
+		config := &c.ExecReporterConfigs[i]
+		if err := config.DefaultAndValidate(); err != nil {
+			return fmt.Errorf("failed to validate exec reporter config %q: %w", config.Name, err)
+		}
+		if execReporterNames.Has(config.Name) {
+			return fmt.Errorf("exec reporter config %q is configured more than once", config.Name)
+		}
+		execReporterNames.Insert(config.Name)
+	}
+
+	if err := c.SLOReporter.validate(); err != nil {
+		return fmt.Errorf("slo_reporter: %w", err)
+	}
+
 	if err := c.Deck.FinalizeDefaultRerunAuthConfigs(); err != nil {
 		return err
 	}
@@ -2250,10 +3040,28 @@ func (c Config) validateJobBase(v JobBase, jobType prowapi.ProwJobType) error {
 		if err != nil {
 			return err
 		}
-		if err := ValidatePipelineRunSpec(jobType, v.ExtraRefs, pipelineRunSpec); err != nil {
+		if inRepo := v.TektonPipelineRunSpec.GetInRepoPipeline(); inRepo != nil {
+			if err := validateInRepoPipeline(jobType, inRepo, pipelineRunSpec); err != nil {
+				return fmt.Errorf("tekton_pipeline_run_spec.in_repo_pipeline: %w", err)
+			}
+		} else if err := ValidatePipelineRunSpec(jobType, v.ExtraRefs, pipelineRunSpec); err != nil {
 			return err
 		}
 	}
+	if err := validateJobOwner(v.Owner); err != nil {
+		return err
+	}
+	if err := validateJobSLO(v.SLO); err != nil {
+		return fmt.Errorf("slo: %w", err)
+	}
+	if v.ReporterConfig != nil {
+		if err := v.ReporterConfig.Gerrit.Validate(); err != nil {
+			return fmt.Errorf("reporter_config.gerrit: %w", err)
+		}
+		if err := v.ReporterConfig.GitHub.Validate(); err != nil {
+			return fmt.Errorf("reporter_config.github: %w", err)
+		}
+	}
 	if err := validateLabels(v.Labels); err != nil {
 		return err
 	}
@@ -2264,6 +3072,12 @@ func (c Config) validateJobBase(v JobBase, jobType prowapi.ProwJobType) error {
 	if err := validateJobQueueName(v.JobQueueName, validJobQueueNames); err != nil {
 		return err
 	}
+	if err := validateSchedulingGates(v.SchedulingGates); err != nil {
+		return fmt.Errorf("scheduling_gates: %w", err)
+	}
+	if _, ok := c.Plank.JobClasses[v.JobClass]; v.JobClass != "" && !ok {
+		return fmt.Errorf("invalid job class %s", v.JobClass)
+	}
 	if v.Spec == nil || len(v.Spec.Containers) == 0 {
 		return nil // jenkins jobs have no spec.
 	}
@@ -2424,6 +3238,9 @@ func (c Config) validatePeriodics(periodics []Periodic) error {
 				errs = append(errs, fmt.Errorf("invalid cron string %s in periodic %s: %w", p.Cron, p.Name, err))
 			}
 		}
+		if err := validateCatchUp(p); err != nil {
+			errs = append(errs, fmt.Errorf("invalid catch_up in periodic %s: %w", p.Name, err))
+		}
 
 		// Set the interval on the periodic jobs. It doesn't make sense to do this
 		// for child jobs.
@@ -2448,6 +3265,28 @@ func (c Config) validatePeriodics(periodics []Periodic) error {
 	return utilerrors.NewAggregate(errs)
 }
 
+func validateCatchUp(p Periodic) error {
+	if p.CatchUp == nil {
+		return nil
+	}
+	switch p.CatchUp.Policy {
+	case CatchUpSkip, CatchUpRunOnce:
+		if p.CatchUp.MaxRuns != 0 {
+			return fmt.Errorf("max_runs is only valid with policy %s", CatchUpRunAll)
+		}
+	case CatchUpRunAll:
+		if p.CatchUp.MaxRuns <= 0 {
+			return fmt.Errorf("max_runs must be positive with policy %s", CatchUpRunAll)
+		}
+	default:
+		return fmt.Errorf("policy must be one of %s, %s or %s, got %q", CatchUpSkip, CatchUpRunOnce, CatchUpRunAll, p.CatchUp.Policy)
+	}
+	if p.Cron == "" {
+		return errors.New("only periodics with cron can catch up on missed runs")
+	}
+	return nil
+}
+
 // ValidateJobConfig validates if all the jobspecs/presets are valid
 // if you are mutating the jobs, please add it to finalizeJobConfig above.
 func (c *Config) ValidateJobConfig() error {
@@ -2483,6 +3322,58 @@ func parseProwConfig(c *Config) error {
 		return fmt.Errorf("validating plank config: %w", err)
 	}
 
+	if err := c.Plank.validateConcurrencyBudgets(); err != nil {
+		return fmt.Errorf("validating plank config: %w", err)
+	}
+
+	if c.Plank.QueueStatusInterval != nil && c.Plank.QueueStatusInterval.Duration <= 0 {
+		return fmt.Errorf("plank.queue_status_interval must be positive, got %s", c.Plank.QueueStatusInterval.Duration)
+	}
+
+	if c.Plank.UtilityImageVersions != nil {
+		if err := c.Plank.UtilityImageVersions.validate(); err != nil {
+			return fmt.Errorf("plank.utility_image_versions: %w", err)
+		}
+	}
+
+	for cluster, arch := range c.Plank.BuildClusterArchitectures {
+		if !architectureRegex.MatchString(arch) {
+			return fmt.Errorf("plank.build_cluster_architectures: invalid architecture %q for cluster %q", arch, cluster)
+		}
+	}
+
+	if err := c.Plank.validateAllowedArtifactDestinations(); err != nil {
+		return fmt.Errorf("validating plank config: %w", err)
+	}
+
+	if backlog := c.Plank.StartupBacklog; backlog != nil {
+		switch backlog.TriggeredOrder {
+		case "":
+			backlog.TriggeredOrder = StartupBacklogNewestFirst
+		case StartupBacklogNewestFirst, StartupBacklogOldestFirst:
+		default:
+			return fmt.Errorf("validating plank config: startup_backlog.triggered_order must be %q or %q, not %q", StartupBacklogNewestFirst, StartupBacklogOldestFirst, backlog.TriggeredOrder)
+		}
+	}
+
+	if err := c.ProwJobMetadataPropagation.validate(); err != nil {
+		return err
+	}
+
+	if c.Quarantine != nil {
+		if err := c.Quarantine.DefaultAndValidate(); err != nil {
+			return fmt.Errorf("validating quarantine config: %w", err)
+		}
+	}
+
+	if err := c.validateJobDefaults(); err != nil {
+		return err
+	}
+
+	if err := c.defaultAndValidateStorageRetention(); err != nil {
+		return err
+	}
+
 	if c.Plank.PodPendingTimeout == nil {
 		c.Plank.PodPendingTimeout = &metav1.Duration{Duration: 10 * time.Minute}
 	}
@@ -2499,6 +3390,10 @@ func parseProwConfig(c *Config) error {
 		return fmt.Errorf("validating gerrit config: %w", err)
 	}
 
+	if err := validateTenants(c.Tenants); err != nil {
+		return fmt.Errorf("validating tenants: %w", err)
+	}
+
 	if c.Tide.Gerrit != nil {
 		if c.Tide.Gerrit.RateLimit == 0 {
 			c.Tide.Gerrit.RateLimit = 5
@@ -2545,6 +3440,17 @@ func parseProwConfig(c *Config) error {
 		if len(c.JenkinsOperators) == 1 && c.JenkinsOperators[0].LabelSelectorString != "" {
 			return errors.New("label_selector is invalid when used for a single jenkins-operator")
 		}
+		masters := sets.New[string]()
+		for j := range c.JenkinsOperators[i].Masters {
+			master := &c.JenkinsOperators[i].Masters[j]
+			if err := master.validate(); err != nil {
+				return fmt.Errorf("invalid jenkins_operators.masters[%d]: %w", j, err)
+			}
+			if masters.Has(master.Name) {
+				return fmt.Errorf("duplicate Jenkins master %q", master.Name)
+			}
+			masters.Insert(master.Name)
+		}
 	}
 
 	for i, agentToTmpl := range c.Deck.ExternalAgentLogs {
@@ -2566,6 +3472,20 @@ func parseProwConfig(c *Config) error {
 		c.Deck.TideUpdatePeriod = &metav1.Duration{Duration: time.Second * 10}
 	}
 
+	for i := range c.Deck.Dashboards {
+		if c.Deck.Dashboards[i].MaxRuns == 0 {
+			c.Deck.Dashboards[i].MaxRuns = 20
+		}
+	}
+
+	if c.Deck.JobEditor != nil && c.Deck.JobEditor.Branch == "" {
+		c.Deck.JobEditor.Branch = "main"
+	}
+
+	if c.Deck.OnCall != nil && c.Deck.OnCall.UpdatePeriod == nil {
+		c.Deck.OnCall.UpdatePeriod = &metav1.Duration{Duration: 5 * time.Minute}
+	}
+
 	if c.Deck.Spyglass.SizeLimit == 0 {
 		c.Deck.Spyglass.SizeLimit = 100e6
 	} else if c.Deck.Spyglass.SizeLimit <= 0 {
@@ -2621,6 +3541,16 @@ func parseProwConfig(c *Config) error {
 		}
 	}
 
+	if expiry := c.Deck.Spyglass.MaxSignedURLExpiry; expiry != nil && (expiry.Duration <= 0 || expiry.Duration > 7*24*time.Hour) {
+		return fmt.Errorf("deck.spyglass.max_signed_url_expiry must be positive and at most 168h, got %s", expiry.Duration)
+	}
+	if err := c.Deck.Spyglass.validateRepoLenses(); err != nil {
+		return err
+	}
+	if err := c.Deck.Spyglass.defaultAndValidateRemoteLenses(); err != nil {
+		return err
+	}
+
 	if c.Deck.Spyglass.GCSBrowserPrefixesByRepo == nil {
 		c.Deck.Spyglass.GCSBrowserPrefixesByRepo = make(map[string]string)
 	}
@@ -2665,6 +3595,12 @@ func parseProwConfig(c *Config) error {
 		c.Sinker.MaxPodAge = &metav1.Duration{Duration: 24 * time.Hour}
 	}
 
+	if c.Horologium.AutoDisable != nil {
+		if err := c.Horologium.AutoDisable.DefaultAndValidate(c.Sinker.MaxProwJobAge.Duration); err != nil {
+			return fmt.Errorf("validating horologium.auto_disable config: %w", err)
+		}
+	}
+
 	if c.Sinker.TerminatedPodTTL == nil {
 		c.Sinker.TerminatedPodTTL = &metav1.Duration{Duration: c.Sinker.MaxPodAge.Duration}
 	}
@@ -2677,6 +3613,13 @@ func parseProwConfig(c *Config) error {
 		c.Tide.StatusUpdatePeriod = c.Tide.SyncPeriod
 	}
 
+	if c.Tide.HistoryRetention == nil {
+		c.Tide.HistoryRetention = &metav1.Duration{Duration: 30 * 24 * time.Hour}
+	}
+	if c.Tide.HistoryRetention.Duration <= 0 {
+		return fmt.Errorf("tide has invalid history_retention (%s), it needs to be positive", c.Tide.HistoryRetention.Duration)
+	}
+
 	if c.Tide.MaxGoroutines == 0 {
 		c.Tide.MaxGoroutines = 20
 	}
@@ -2684,6 +3627,15 @@ func parseProwConfig(c *Config) error {
 		return fmt.Errorf("tide has invalid max_goroutines (%d), it needs to be a positive number", c.Tide.MaxGoroutines)
 	}
 
+	for orgRepo, limits := range c.Tide.StaleResultsMap {
+		if limits.MaxAge != nil && limits.MaxAge.Duration <= 0 {
+			return fmt.Errorf("tide has invalid stale_results.max_age (%s) for %q, it needs to be positive", limits.MaxAge.Duration, orgRepo)
+		}
+		if limits.MaxCommitsBehind < 0 {
+			return fmt.Errorf("tide has invalid stale_results.max_commits_behind (%d) for %q, it can't be negative", limits.MaxCommitsBehind, orgRepo)
+		}
+	}
+
 	if len(c.Tide.TargetURLs) > 0 && c.Tide.TargetURL != "" {
 		return fmt.Errorf("tide.target_url and tide.target_urls are mutually exclusive")
 	}
@@ -2719,24 +3671,20 @@ func parseProwConfig(c *Config) error {
 	}
 
 	for name, templates := range c.Tide.MergeTemplate {
-		if templates.TitleTemplate != "" {
-			titleTemplate, err := template.New("CommitTitle").Parse(templates.TitleTemplate)
-
-			if err != nil {
-				return fmt.Errorf("parsing template for commit title: %w", err)
-			}
-
-			templates.Title = titleTemplate
+		var err error
+		if templates.Title, templates.Body, err = parseCommitTemplates(templates.TitleTemplate, templates.BodyTemplate); err != nil {
+			return err
 		}
-
-		if templates.BodyTemplate != "" {
-			bodyTemplate, err := template.New("CommitBody").Parse(templates.BodyTemplate)
-
-			if err != nil {
-				return fmt.Errorf("parsing template for commit body: %w", err)
+		for _, override := range []struct {
+			method   types.PullRequestMergeType
+			template *TideCommitTemplate
+		}{{types.MergeMerge, templates.Merge}, {types.MergeSquash, templates.Squash}} {
+			if override.template == nil {
+				continue
+			}
+			if override.template.Title, override.template.Body, err = parseCommitTemplates(override.template.TitleTemplate, override.template.BodyTemplate); err != nil {
+				return fmt.Errorf("%s: %w", override.method, err)
 			}
-
-			templates.Body = bodyTemplate
 		}
 
 		c.Tide.MergeTemplate[name] = templates
@@ -2791,12 +3739,28 @@ func parseProwConfig(c *Config) error {
 		return fmt.Errorf("Forbidden to set both Policy.Include and Policy.Exclude, Please use either Include or Exclude!")
 	}
 
+	if err := c.BranchProtection.validateUnmanagedContexts(); err != nil {
+		return err
+	}
+
+	if err := c.BranchProtection.validateRulesets(); err != nil {
+		return err
+	}
+
 	// Avoid using a Moonraker client timeout of infinity (default behavior of
 	// https://pkg.go.dev/net/http#Client) by setting a default value.
 	if c.Moonraker.ClientTimeout == nil {
 		c.Moonraker.ClientTimeout = &metav1.Duration{Duration: DefaultMoonrakerClientTimeout}
 	}
 
+	if err := c.ImagePrePuller.DefaultAndValidate(); err != nil {
+		return fmt.Errorf("validating image_prepuller config: %w", err)
+	}
+
+	if err := c.StatusReconciler.Validate(); err != nil {
+		return fmt.Errorf("validating status_reconciler config: %w", err)
+	}
+
 	return nil
 }
 
@@ -2864,6 +3828,25 @@ func validateAnnotation(a map[string]string) error {
 	return nil
 }
 
+func validateJobOwner(owner *JobOwner) error {
+	if owner == nil {
+		return nil
+	}
+	if errs := validation.IsValidLabelValue(owner.Team); len(errs) != 0 {
+		return fmt.Errorf("owner.team %q is not a valid label value: %v", owner.Team, errs)
+	}
+	if owner.EscalationURL != "" {
+		u, err := url.Parse(owner.EscalationURL)
+		if err != nil {
+			return fmt.Errorf("owner.escalation_url %q is not a valid URL: %w", owner.EscalationURL, err)
+		}
+		if u.Scheme != schemeHTTP && u.Scheme != schemeHTTPS {
+			return fmt.Errorf("owner.escalation_url %q must use http or https", owner.EscalationURL)
+		}
+	}
+	return nil
+}
+
 func validateJobQueueName(name string, validNames sets.Set[string]) error {
 	if name != "" && !validNames.Has(name) {
 		return fmt.Errorf("invalid job queue name %s", name)
@@ -2871,6 +3854,22 @@ func validateJobQueueName(name string, validNames sets.Set[string]) error {
 	return nil
 }
 
+func validateSchedulingGates(gates []string) error {
+	seen := sets.New[string]()
+	for _, gate := range gates {
+		switch {
+		case gate == "":
+			return errors.New("gate names must not be empty")
+		case strings.ContainsAny(gate, ", "):
+			return fmt.Errorf("gate %q must not contain commas or spaces", gate)
+		case seen.Has(gate):
+			return fmt.Errorf("gate %q is listed more than once", gate)
+		}
+		seen.Insert(gate)
+	}
+	return nil
+}
+
 func validateAgent(v JobBase, podNamespace string) error {
 	k := string(prowapi.KubernetesAgent)
 	j := string(prowapi.JenkinsAgent)
@@ -2933,6 +3932,25 @@ func resolvePresets(name string, labels map[string]string, spec *v1.PodSpec, pre
 
 var ReProwExtraRef = regexp.MustCompile(`PROW_EXTRA_GIT_REF_(\d+)`)
 
+// validateInRepoPipeline validates a Pipeline read from the tested repo. The
+// tasks using the refs of the job are only known once the Pipeline is read,
+// so ValidatePipelineRunSpec runs when the run is created instead.
+func validateInRepoPipeline(jobType prowapi.ProwJobType, inRepo *prowapi.InRepoPipeline, spec *pipelinev1.PipelineRunSpec) error {
+	if jobType == prowapi.PeriodicJob {
+		return errors.New("periodic jobs have no tested repo to read the pipeline from")
+	}
+	if inRepo.Path == "" {
+		return errors.New("path is required")
+	}
+	if path.IsAbs(inRepo.Path) || path.Clean(inRepo.Path) != inRepo.Path || strings.HasPrefix(inRepo.Path, "../") {
+		return fmt.Errorf("path %q must be a clean path relative to the root of the repo", inRepo.Path)
+	}
+	if spec.PipelineRef != nil || spec.PipelineSpec != nil {
+		return errors.New("can't be used with a pipelineRef or pipelineSpec")
+	}
+	return nil
+}
+
 func ValidatePipelineRunSpec(jobType prowapi.ProwJobType, extraRefs []prowapi.Refs, spec *pipelinev1.PipelineRunSpec) error {
 	if spec == nil {
 		return nil
@@ -3028,6 +4046,12 @@ func validatePodSpec(jobType prowapi.ProwJobType, spec *v1.PodSpec, decorationCo
 					errs = append(errs, fmt.Errorf("env %s is reserved", env.Name))
 				}
 			}
+
+			if secretutil.IsReference(env.Value) {
+				if err := validateSecretReference(env.Value, decorationConfig); err != nil {
+					errs = append(errs, fmt.Errorf("env %s: %w", env.Name, err))
+				}
+			}
 		}
 	}
 
@@ -3061,6 +4085,21 @@ func validatePodSpec(jobType prowapi.ProwJobType, spec *v1.PodSpec, decorationCo
 	return utilerrors.NewAggregate(errs)
 }
 
+// validateSecretReference ensures that entrypoint can resolve the secret
+// reference in the value of an environment variable.
+func validateSecretReference(value string, decorationConfig *prowapi.DecorationConfig) error {
+	if _, err := secretutil.ParseReference(value); err != nil {
+		return fmt.Errorf("invalid secret reference: %w", err)
+	}
+	if decorationConfig == nil {
+		return errors.New("secret references are only resolved for decorated jobs")
+	}
+	if strings.HasPrefix(value, secretutil.VaultScheme) && decorationConfig.Vault == nil {
+		return errors.New("Vault secret references require the vault decoration config")
+	}
+	return nil
+}
+
 func validateAlwaysRun(job Postsubmit) error {
 	if job.AlwaysRun != nil && *job.AlwaysRun {
 		if job.RunIfChanged != "" {
@@ -3073,6 +4112,9 @@ func validateAlwaysRun(job Postsubmit) error {
 	if job.RunIfChanged != "" && job.SkipIfOnlyChanged != "" {
 		return fmt.Errorf("job %s declares run_if_changed and skip_if_only_changed, which are mutually exclusive", job.Name)
 	}
+	if err := validateRunIfChangedExpression(job.Name, job.AlwaysRun != nil && *job.AlwaysRun, job.RegexpChangeMatcher); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -3088,6 +4130,9 @@ func validateTriggering(job Presubmit) error {
 	if job.RunIfChanged != "" && job.SkipIfOnlyChanged != "" {
 		return fmt.Errorf("job %s declares run_if_changed and skip_if_only_changed, which are mutually exclusive", job.Name)
 	}
+	if err := validateRunIfChangedExpression(job.Name, job.AlwaysRun, job.RegexpChangeMatcher); err != nil {
+		return err
+	}
 
 	if (job.Trigger != "" && job.RerunCommand == "") || (job.Trigger == "" && job.RerunCommand != "") {
 		return fmt.Errorf("either both of job.Trigger and job.RerunCommand must be set, wasnt the case for job %q", job.Name)
@@ -3096,6 +4141,19 @@ func validateTriggering(job Presubmit) error {
 	return nil
 }
 
+func validateRunIfChangedExpression(name string, alwaysRun bool, cm RegexpChangeMatcher) error {
+	if cm.RunIfChangedExpression == nil {
+		return nil
+	}
+	if alwaysRun {
+		return fmt.Errorf("job %s is set to always run but also declares run_if_changed_expression, which are mutually exclusive", name)
+	}
+	if cm.RunIfChanged != "" || cm.SkipIfOnlyChanged != "" {
+		return fmt.Errorf("job %s declares run_if_changed_expression and run_if_changed or skip_if_only_changed, which are mutually exclusive", name)
+	}
+	return nil
+}
+
 func validateReporting(j JobBase, r Reporter) error {
 	if !r.SkipReport && r.Context == "" {
 		return errors.New("job is set to report but has no context configured")
@@ -3189,6 +4247,52 @@ func (c *ProwConfig) defaultJobBase(base *JobBase) {
 	if base.Cluster == "" {
 		base.Cluster = kube.DefaultClusterAlias
 	}
+	if base.Owner != nil {
+		base.Labels = mergeStringMaps(base.Labels, base.Owner.Labels())
+		base.Annotations = mergeStringMaps(base.Annotations, base.Owner.Annotations())
+	}
+	if base.ReporterConfig != nil && base.ReporterConfig.Gerrit != nil {
+		base.Labels = mergeStringMaps(base.Labels, gerritReporterLabels(base.ReporterConfig.Gerrit))
+	}
+	if base.JobClass != "" {
+		base.Labels = mergeStringMaps(base.Labels, map[string]string{kube.JobClassLabel: base.JobClass})
+		if class, ok := c.Plank.JobClasses[base.JobClass]; ok {
+			class.apply(base.Spec)
+		}
+	}
+}
+
+// gerritReporterLabels returns the labels the gerrit reporter uses to find
+// the jobs whose results are voted on together.
+func gerritReporterLabels(gerrit *prowapi.GerritReporterConfig) map[string]string {
+	labels := map[string]string{}
+	switch {
+	case !gerrit.ShouldVote():
+		labels[kube.GerritReportLabel] = ""
+	case gerrit.Label != "":
+		labels[kube.GerritReportLabel] = gerrit.Label
+	}
+	if gerrit.AggregateLabel != "" {
+		labels[kube.GerritAggregateReportLabel] = gerrit.AggregateLabel
+	}
+	return labels
+}
+
+// mergeStringMaps returns a new map holding the entries of base overlaid
+// with the entries of overrides. It returns base if there is nothing to merge
+// so that unset maps stay unset.
+func mergeStringMaps(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
 }
 
 func (c *ProwConfig) defaultPresubmitFields(js []Presubmit) {
@@ -3281,6 +4385,11 @@ func setChangeRegexes(cm RegexpChangeMatcher) (RegexpChangeMatcher, error) {
 		}
 		cm.reChanges = &CopyableRegexp{re}
 	}
+	if cm.RunIfChangedExpression != nil {
+		if err := cm.RunIfChangedExpression.compile(); err != nil {
+			return cm, fmt.Errorf("invalid run_if_changed_expression: %w", err)
+		}
+	}
 	return cm, nil
 }
 
@@ -159,6 +159,68 @@ func TestDefaultJobBase(t *testing.T) {
 				j.Cluster = kube.DefaultClusterAlias
 			},
 		},
+		{
+			name: "owner is propagated to labels and annotations",
+			base: func(j *JobBase) {
+				j.Labels = map[string]string{"foo": "bar"}
+				j.Owner = &JobOwner{Team: "sig-testing", SlackChannel: "#sig-testing", EscalationURL: "https://example.com"}
+			},
+			expected: func(j *JobBase) {
+				j.Labels = map[string]string{"foo": "bar", kube.OwnerTeamLabel: "sig-testing"}
+				j.Annotations = map[string]string{
+					kube.OwnerSlackChannelAnnotation:  "#sig-testing",
+					kube.OwnerEscalationURLAnnotation: "https://example.com",
+				}
+			},
+		},
+		{
+			name: "job class is applied to the pod spec",
+			config: ProwConfig{
+				Plank: Plank{JobClasses: map[string]JobClass{
+					"e2e": {
+						NodeSelector: map[string]string{"pool": "big", "arch": "amd64"},
+						Tolerations:  []v1.Toleration{{Key: "dedicated", Value: "e2e", Effect: v1.TaintEffectNoSchedule}},
+					},
+				}},
+			},
+			base: func(j *JobBase) {
+				j.JobClass = "e2e"
+				j.Spec = &v1.PodSpec{
+					NodeSelector: map[string]string{"arch": "arm64"},
+					Tolerations:  []v1.Toleration{{Key: "other", Effect: v1.TaintEffectNoExecute}},
+				}
+			},
+			expected: func(j *JobBase) {
+				j.Labels = map[string]string{kube.JobClassLabel: "e2e"}
+				j.Spec = &v1.PodSpec{
+					NodeSelector: map[string]string{"pool": "big", "arch": "arm64"},
+					Tolerations: []v1.Toleration{
+						{Key: "other", Effect: v1.TaintEffectNoExecute},
+						{Key: "dedicated", Value: "e2e", Effect: v1.TaintEffectNoSchedule},
+					},
+				}
+			},
+		},
+		{
+			name: "job class is applied only once",
+			config: ProwConfig{
+				Plank: Plank{JobClasses: map[string]JobClass{
+					"e2e": {Tolerations: []v1.Toleration{{Key: "dedicated", Value: "e2e", Effect: v1.TaintEffectNoSchedule}}},
+				}},
+			},
+			base: func(j *JobBase) {
+				j.JobClass = "e2e"
+				j.Spec = &v1.PodSpec{
+					Tolerations: []v1.Toleration{{Key: "dedicated", Value: "e2e", Effect: v1.TaintEffectNoSchedule}},
+				}
+			},
+			expected: func(j *JobBase) {
+				j.Labels = map[string]string{kube.JobClassLabel: "e2e"}
+				j.Spec = &v1.PodSpec{
+					Tolerations: []v1.Toleration{{Key: "dedicated", Value: "e2e", Effect: v1.TaintEffectNoSchedule}},
+				}
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1631,6 +1693,37 @@ func TestValidatePodSpec(t *testing.T) {
 				s.Containers[0].Env = append(s.Containers[0].Env, v1.EnvVar{Name: "foo", Value: "baz"})
 			},
 		},
+		{
+			name:             "accept secret references",
+			decorationConfig: &prowapi.DecorationConfig{Vault: &prowapi.VaultConfig{Address: "https://vault", Role: "ci"}},
+			spec: func(s *v1.PodSpec) {
+				s.Containers[0].Env = append(s.Containers[0].Env,
+					v1.EnvVar{Name: "TOKEN", Value: "vault://secret/data/ci#token"},
+					v1.EnvVar{Name: "KEY", Value: "kms://projects/p/locations/global/keyRings/ci/cryptoKeys/jobs:c2VjcmV0"},
+				)
+			},
+			pass: true,
+		},
+		{
+			name:             "reject invalid secret reference",
+			decorationConfig: &prowapi.DecorationConfig{},
+			spec: func(s *v1.PodSpec) {
+				s.Containers[0].Env = append(s.Containers[0].Env, v1.EnvVar{Name: "KEY", Value: "kms://projects/p/keyRings/ci:c2VjcmV0"})
+			},
+		},
+		{
+			name:             "reject Vault reference without vault config",
+			decorationConfig: &prowapi.DecorationConfig{},
+			spec: func(s *v1.PodSpec) {
+				s.Containers[0].Env = append(s.Containers[0].Env, v1.EnvVar{Name: "TOKEN", Value: "vault://secret/data/ci#token"})
+			},
+		},
+		{
+			name: "reject secret reference in undecorated job",
+			spec: func(s *v1.PodSpec) {
+				s.Containers[0].Env = append(s.Containers[0].Env, v1.EnvVar{Name: "KEY", Value: "kms://projects/p/locations/global/keyRings/ci/cryptoKeys/jobs:c2VjcmV0"})
+			},
+		},
 		{
 			name: "reject duplicate volume",
 			spec: func(s *v1.PodSpec) {
@@ -1793,6 +1886,71 @@ func TestValidatePipelineRunSpec(t *testing.T) {
 	}
 }
 
+func TestValidateInRepoPipeline(t *testing.T) {
+	cases := []struct {
+		name    string
+		jobType prowapi.ProwJobType
+		inRepo  prowapi.InRepoPipeline
+		spec    pipelinev1.PipelineRunSpec
+		pass    bool
+	}{
+		{
+			name:    "presubmit",
+			jobType: prowapi.PresubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: ".tekton/pipeline.yaml"},
+			spec:    pipelinev1.PipelineRunSpec{Params: pipelinev1.Params{{Name: "a"}}},
+			pass:    true,
+		},
+		{
+			name:    "postsubmit at a ref",
+			jobType: prowapi.PostsubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "pipeline.yaml", Ref: "main"},
+			pass:    true,
+		},
+		{
+			name:    "reject periodics",
+			jobType: prowapi.PeriodicJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "pipeline.yaml"},
+		},
+		{
+			name:    "reject a missing path",
+			jobType: prowapi.PresubmitJob,
+		},
+		{
+			name:    "reject absolute paths",
+			jobType: prowapi.PresubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "/pipeline.yaml"},
+		},
+		{
+			name:    "reject paths out of the repo",
+			jobType: prowapi.PresubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "../pipeline.yaml"},
+		},
+		{
+			name:    "reject unclean paths",
+			jobType: prowapi.PresubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "./.tekton//pipeline.yaml"},
+		},
+		{
+			name:    "reject a pipeline ref",
+			jobType: prowapi.PresubmitJob,
+			inRepo:  prowapi.InRepoPipeline{Path: "pipeline.yaml"},
+			spec:    pipelinev1.PipelineRunSpec{PipelineRef: &pipelinev1.PipelineRef{Name: "p"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			switch err := validateInRepoPipeline(tc.jobType, &tc.inRepo, &tc.spec); {
+			case err == nil && !tc.pass:
+				t.Error("validation failed to raise an error")
+			case err != nil && tc.pass:
+				t.Errorf("validation should have passed, got: %v", err)
+			}
+		})
+	}
+}
+
 func TestValidateDecoration(t *testing.T) {
 	defCfg := prowapi.DecorationConfig{
 		UtilityImages: &prowapi.UtilityImages{
@@ -1845,6 +2003,17 @@ func TestValidateDecoration(t *testing.T) {
 			name:   "reject container that has no cmd, no args",
 			config: &defCfg,
 		},
+		{
+			name: "reject negative hang timeout",
+			config: func() *prowapi.DecorationConfig {
+				c := defCfg.DeepCopy()
+				c.HangTimeout = &metav1.Duration{Duration: -time.Minute}
+				return c
+			}(),
+			container: v1.Container{
+				Command: []string{"hello", "world"},
+			},
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -2039,7 +2208,7 @@ func TestValidateJobBase(t *testing.T) {
 	}
 	cfg := Config{
 		ProwConfig: ProwConfig{
-			Plank:        Plank{JobQueueCapacities: map[string]int{"queue": 0}},
+			Plank:        Plank{JobQueueCapacities: map[string]int{"queue": 0}, JobClasses: map[string]JobClass{"e2e": {}}},
 			PodNamespace: "target-namespace",
 		},
 	}
@@ -2174,6 +2343,98 @@ func TestValidateJobBase(t *testing.T) {
 			},
 			pass: false,
 		},
+		{
+			name: "valid scheduling gates",
+			base: JobBase{
+				Name:            "name",
+				SchedulingGates: []string{"change-approval", "release-window"},
+			},
+			pass: true,
+		},
+		{
+			name: "scheduling gate with a comma",
+			base: JobBase{
+				Name:            "name",
+				SchedulingGates: []string{"change,approval"},
+			},
+			pass: false,
+		},
+		{
+			name: "duplicate scheduling gates",
+			base: JobBase{
+				Name:            "name",
+				SchedulingGates: []string{"change-approval", "change-approval"},
+			},
+			pass: false,
+		},
+		{
+			name: "valid job class",
+			base: JobBase{
+				Name:     "name",
+				JobClass: "e2e",
+			},
+			pass: true,
+		},
+		{
+			name: "invalid job class",
+			base: JobBase{
+				Name:     "name",
+				JobClass: "invalid-class",
+			},
+			pass: false,
+		},
+		{
+			name: "valid owner",
+			base: JobBase{
+				Name: "name",
+				Owner: &JobOwner{
+					Team:          "sig-testing",
+					SlackChannel:  "#sig-testing",
+					EscalationURL: "https://example.com/oncall",
+				},
+			},
+			pass: true,
+		},
+		{
+			name: "owner team must be a valid label value",
+			base: JobBase{
+				Name:  "name",
+				Owner: &JobOwner{Team: "sig testing"},
+			},
+			pass: false,
+		},
+		{
+			name: "owner escalation url must be http or https",
+			base: JobBase{
+				Name:  "name",
+				Owner: &JobOwner{EscalationURL: "mailto:oncall@example.com"},
+			},
+			pass: false,
+		},
+		{
+			name: "valid slo",
+			base: JobBase{
+				Name: "name",
+				SLO:  &JobSLO{TargetPassRate: 0.95, MaxDuration: &metav1.Duration{Duration: time.Hour}},
+			},
+			pass: true,
+		},
+		{
+			name: "slo needs a target pass rate or a max duration",
+			base: JobBase{
+				Name: "name",
+				SLO:  &JobSLO{Window: &metav1.Duration{Duration: time.Hour}},
+			},
+			pass: false,
+		},
+		{
+			name: "slo target pass rate must be at most 1",
+			base: JobBase{
+				Name: "name",
+				SLO:  &JobSLO{TargetPassRate: 95},
+			},
+			pass: false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -2216,6 +2477,71 @@ func TestValidateDeck(t *testing.T) {
 			deck:        Deck{SkipStoragePathValidation: &boolTrue, AdditionalAllowedBuckets: []string{"hello", "world"}},
 			expectedErr: "skip_storage_path_validation is enabled",
 		},
+		{
+			name:        "dashboards are valid",
+			deck:        Deck{Dashboards: []Dashboard{{Name: "e2e", Jobs: []string{"ci-*-e2e"}}, {Name: "unit", Jobs: []string{"ci-unit"}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "dashboards with the same name => error",
+			deck:        Deck{Dashboards: []Dashboard{{Name: "e2e", Jobs: []string{"ci-*-e2e"}}, {Name: "e2e", Jobs: []string{"ci-unit"}}}},
+			expectedErr: "duplicate dashboard",
+		},
+		{
+			name:        "dashboard without jobs => error",
+			deck:        Deck{Dashboards: []Dashboard{{Name: "e2e"}}},
+			expectedErr: "has no jobs",
+		},
+		{
+			name:        "dashboard with an invalid glob => error",
+			deck:        Deck{Dashboards: []Dashboard{{Name: "e2e", Jobs: []string{"ci-[e2e"}}}},
+			expectedErr: "invalid glob",
+		},
+		{
+			name:        "tenants are valid",
+			deck:        Deck{Tenants: []DeckTenant{{Name: "payments", Repos: []string{"org/payments"}}, {Name: "infra", Jobs: []string{"ci-infra-*"}, Viewers: &prowapi.RerunAuthConfig{GitHubOrgs: []string{"org"}}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "tenant with an invalid name => error",
+			deck:        Deck{Tenants: []DeckTenant{{Name: "Payments/EU", Repos: []string{"org/payments"}}}},
+			expectedErr: "must consist of lower case alphanumeric characters",
+		},
+		{
+			name:        "tenants with the same name => error",
+			deck:        Deck{Tenants: []DeckTenant{{Name: "payments", Repos: []string{"org/payments"}}, {Name: "payments", Repos: []string{"org/billing"}}}},
+			expectedErr: "duplicate tenant",
+		},
+		{
+			name:        "tenant without repos or jobs => error",
+			deck:        Deck{Tenants: []DeckTenant{{Name: "payments"}}},
+			expectedErr: "has neither repos nor jobs",
+		},
+		{
+			name:        "tenant with invalid viewers => error",
+			deck:        Deck{Tenants: []DeckTenant{{Name: "payments", Repos: []string{"org"}, Viewers: &prowapi.RerunAuthConfig{AllowAnyone: true, GitHubUsers: []string{"alice"}}}}},
+			expectedErr: "allow anyone is set to true",
+		},
+		{
+			name:        "job editor is valid",
+			deck:        Deck{JobEditor: &JobEditor{Repo: "org/config", JobConfigPath: "config/jobs", Editors: &prowapi.RerunAuthConfig{GitHubOrgs: []string{"org"}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "job editor with an invalid repo => error",
+			deck:        Deck{JobEditor: &JobEditor{Repo: "config", JobConfigPath: "config/jobs", Editors: &prowapi.RerunAuthConfig{GitHubOrgs: []string{"org"}}}},
+			expectedErr: "deck.job_editor.repo",
+		},
+		{
+			name:        "job editor with a path outside of the repo => error",
+			deck:        Deck{JobEditor: &JobEditor{Repo: "org/config", JobConfigPath: "../jobs", Editors: &prowapi.RerunAuthConfig{GitHubOrgs: []string{"org"}}}},
+			expectedErr: "deck.job_editor.job_config_path",
+		},
+		{
+			name:        "job editor without editors => error",
+			deck:        Deck{JobEditor: &JobEditor{Repo: "org/config", JobConfigPath: "config/jobs"}},
+			expectedErr: "deck.job_editor.editors must be set",
+		},
 	}
 
 	for _, tc := range cases {
@@ -3692,6 +4018,59 @@ bar_jobs.yaml`,
 	}
 }
 
+func TestLoadJobConfigThroughLink(t *testing.T) {
+	dir := t.TempDir()
+	prowConfig := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(prowConfig, []byte("pod_namespace: test-pods\n"), 0666); err != nil {
+		t.Fatalf("fail to write prow config: %v", err)
+	}
+	writeVersion := func(version, job string) string {
+		versionDir := filepath.Join(dir, version)
+		if err := os.MkdirAll(filepath.Join(versionDir, "org"), 0777); err != nil {
+			t.Fatalf("fail to make job config dir: %v", err)
+		}
+		content := fmt.Sprintf(`periodics:
+- name: %s
+  interval: 1h
+  spec:
+    containers:
+    - image: my-image:latest
+      command: ["do-the-thing"]`, job)
+		if err := os.WriteFile(filepath.Join(versionDir, "org", "jobs.yaml"), []byte(content), 0666); err != nil {
+			t.Fatalf("fail to write job config: %v", err)
+		}
+		return versionDir
+	}
+	// Like the current link of a synced job config bundle, which is swapped
+	// to each new bundle.
+	jobConfig := filepath.Join(dir, "current")
+	link := func(target string) {
+		tmp := jobConfig + ".tmp"
+		if err := os.Symlink(target, tmp); err != nil {
+			t.Fatalf("fail to link job config: %v", err)
+		}
+		if err := os.Rename(tmp, jobConfig); err != nil {
+			t.Fatalf("fail to swap job config link: %v", err)
+		}
+	}
+
+	for _, version := range []string{"v1", "v2"} {
+		job := "job-" + version
+		link(writeVersion(version, job))
+		cfg, err := Load(prowConfig, jobConfig, nil, "")
+		if err != nil {
+			t.Fatalf("%s: unexpected error loading the config: %v", version, err)
+		}
+		periodics := cfg.AllPeriodics()
+		if len(periodics) != 1 || periodics[0].Name != job {
+			t.Fatalf("%s: expected to load only job %s, got %+v", version, job, periodics)
+		}
+		if expected := filepath.Join(jobConfig, "org", "jobs.yaml"); periodics[0].SourcePath != expected {
+			t.Errorf("%s: expected the job to be sourced from %s, got %s", version, expected, periodics[0].SourcePath)
+		}
+	}
+}
+
 func TestBrancher_Intersects(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -4433,6 +4812,38 @@ tide:
 				},
 			},
 		},
+		{
+			name: "merge method templates",
+			prowConfig: `
+tide:
+  merge_commit_template:
+    kubernetes/ingress:
+      title: "{{ .Title }}"
+      squash:
+        body: "{{ .Body }}"
+`,
+			expect: map[string]TideMergeCommitTemplate{
+				"kubernetes/ingress": {
+					TitleTemplate: "{{ .Title }}",
+					Title:         template.Must(template.New("CommitTitle").Parse("{{ .Title }}")),
+					Squash: &TideCommitTemplate{
+						BodyTemplate: "{{ .Body }}",
+						Body:         template.Must(template.New("CommitBody").Parse("{{ .Body }}")),
+					},
+				},
+			},
+		},
+		{
+			name: "malformed merge method template",
+			prowConfig: `
+tide:
+  merge_commit_template:
+    kubernetes/ingress:
+      merge:
+        title: "{{ .Title"
+`,
+			expectError: true,
+		},
 		{
 			name: "malformed title template",
 			prowConfig: `
@@ -4610,6 +5021,16 @@ func TestValidateComponentConfig(t *testing.T) {
 				JobURLPrefixConfig: map[string]string{"*": "https:// my-prow"}}}},
 			errExpected: true,
 		},
+		{
+			name:        "Sinker resources, no err",
+			config:      &Config{ProwConfig: ProwConfig{Sinker: Sinker{Resources: []string{"pipelineruns", "secrets"}}}},
+			errExpected: false,
+		},
+		{
+			name:        "Unknown sinker resource, err",
+			config:      &Config{ProwConfig: ProwConfig{Sinker: Sinker{Resources: []string{"deployments"}}}},
+			errExpected: true,
+		},
 		{
 			name: "Org config, valid URLs, no err",
 			config: &Config{ProwConfig: ProwConfig{Plank: Plank{
@@ -4880,6 +5301,90 @@ func TestSlackReporterValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestExecReporterValidation(t *testing.T) {
+	valid := func() ExecReporter {
+		return ExecReporter{Name: "page", Command: []string{"page"}, JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState}}
+	}
+	testCases := []struct {
+		name        string
+		configs     func() []ExecReporter
+		expected    []ExecReporter
+		expectedErr string
+	}{
+		{
+			name:    "timeout and concurrency are defaulted",
+			configs: func() []ExecReporter { return []ExecReporter{valid()} },
+			expected: []ExecReporter{{
+				Name:              "page",
+				Command:           []string{"page"},
+				JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState},
+				Timeout:           &metav1.Duration{Duration: time.Minute},
+				MaxConcurrency:    1,
+			}},
+		},
+		{
+			name: "no command",
+			configs: func() []ExecReporter {
+				cfg := valid()
+				cfg.Command = nil
+				return []ExecReporter{cfg}
+			},
+			expectedErr: `failed to validate exec reporter config "page": command must be set`,
+		},
+		{
+			name: "no states",
+			configs: func() []ExecReporter {
+				cfg := valid()
+				cfg.JobStatesToReport = nil
+				return []ExecReporter{cfg}
+			},
+			expectedErr: `failed to validate exec reporter config "page": job_states_to_report must be set`,
+		},
+		{
+			name: "invalid job pattern",
+			configs: func() []ExecReporter {
+				cfg := valid()
+				cfg.Jobs = []string{"ci-["}
+				return []ExecReporter{cfg}
+			},
+			expectedErr: `failed to validate exec reporter config "page": invalid jobs pattern "ci-[": syntax error in pattern`,
+		},
+		{
+			name: "negative timeout",
+			configs: func() []ExecReporter {
+				cfg := valid()
+				cfg.Timeout = &metav1.Duration{Duration: -time.Second}
+				return []ExecReporter{cfg}
+			},
+			expectedErr: `failed to validate exec reporter config "page": timeout must be positive`,
+		},
+		{
+			name:        "duplicate name",
+			configs:     func() []ExecReporter { return []ExecReporter{valid(), valid()} },
+			expectedErr: `exec reporter config "page" is configured more than once`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{ProwConfig: ProwConfig{ExecReporterConfigs: tc.configs()}}
+			err := cfg.validateComponentConfig()
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tc.expected, cfg.ExecReporterConfigs); diff != "" {
+					t.Errorf("unexpected configs (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
 func TestManagedHmacEntityValidation(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -4915,6 +5420,27 @@ func TestManagedHmacEntityValidation(t *testing.T) {
 			}},
 			shouldFail: true,
 		},
+		{
+			name: "Config with a negative rotation overlap",
+			prowConfig: Config{ProwConfig: ProwConfig{
+				ManagedWebhooks: ManagedWebhooks{
+					RotationOverlap: &metav1.Duration{Duration: -time.Hour},
+					OrgRepoConfig: map[string]ManagedWebhookInfo{
+						"foo/bar": {TokenCreatedAfter: time.Now()},
+					},
+				},
+			}},
+			shouldFail: true,
+		},
+		{
+			name: "Config with a rotation overlap",
+			prowConfig: Config{ProwConfig: ProwConfig{
+				ManagedWebhooks: ManagedWebhooks{
+					RotationOverlap: &metav1.Duration{Duration: time.Hour},
+				},
+			}},
+			shouldFail: false,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -4964,6 +5490,34 @@ func TestValidateTriggering(t *testing.T) {
 			},
 			errExpected: false,
 		},
+		{
+			name: "run_if_changed_expression and always_run set, err",
+			presubmit: Presubmit{
+				AlwaysRun: true,
+				RegexpChangeMatcher: RegexpChangeMatcher{
+					RunIfChangedExpression: &ChangeExpression{AnyChanged: []string{"api/**"}},
+				},
+			},
+			errExpected: true,
+		},
+		{
+			name: "run_if_changed_expression and run_if_changed set, err",
+			presubmit: Presubmit{
+				RegexpChangeMatcher: RegexpChangeMatcher{
+					RunIfChanged:           "^api/",
+					RunIfChangedExpression: &ChangeExpression{AnyChanged: []string{"api/**"}},
+				},
+			},
+			errExpected: true,
+		},
+		{
+			name: "run_if_changed_expression set alone, no err",
+			presubmit: Presubmit{
+				RegexpChangeMatcher: RegexpChangeMatcher{
+					RunIfChangedExpression: &ChangeExpression{AnyChanged: []string{"api/**"}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -7659,6 +8213,41 @@ func TestInRepoConfigEnabled(t *testing.T) {
 	}
 }
 
+func TestInRepoConfigPartialLoading(t *testing.T) {
+	c := &Config{
+		ProwConfig: ProwConfig{
+			InRepoConfig: InRepoConfig{
+				PartialLoading: map[string]*bool{
+					"*":             ptr.To(true),
+					"org":           ptr.To(false),
+					"org/monorepo":  ptr.To(true),
+					"host-name/foo": ptr.To(false),
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		identifier string
+		expected   bool
+	}{
+		{identifier: "other/repo", expected: true},
+		{identifier: "org/repo", expected: false},
+		{identifier: "org/monorepo", expected: true},
+		{identifier: "https://host-name/foo", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.identifier, func(t *testing.T) {
+			if result := c.InRepoConfigPartialLoading(tc.identifier); result != tc.expected {
+				t.Errorf("Expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+	if (&Config{}).InRepoConfigPartialLoading("org/repo") {
+		t.Error("Expected partial loading to be disabled by default")
+	}
+}
+
 func TestGetProwYAMLDoesNotCallRefGettersWhenInrepoconfigIsDisabled(t *testing.T) {
 	t.Parallel()
 
@@ -7976,6 +8565,62 @@ func TestDefaultAndValidateReportTemplate(t *testing.T) {
 	}
 }
 
+func TestPeriodicAutoDisableDefaultAndValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      PeriodicAutoDisable
+		expected    PeriodicAutoDisable
+		expectedErr bool
+	}{
+		{
+			name: "defaults are applied",
+			expected: PeriodicAutoDisable{
+				FailingFor:  &metav1.Duration{Duration: 72 * time.Hour},
+				GracePeriod: &metav1.Duration{Duration: 24 * time.Hour},
+				MinimumRuns: 3,
+			},
+		},
+		{
+			name: "explicit values are kept",
+			config: PeriodicAutoDisable{
+				FailingFor:  &metav1.Duration{Duration: 48 * time.Hour},
+				GracePeriod: &metav1.Duration{},
+				MinimumRuns: 10,
+			},
+			expected: PeriodicAutoDisable{
+				FailingFor:  &metav1.Duration{Duration: 48 * time.Hour},
+				GracePeriod: &metav1.Duration{},
+				MinimumRuns: 10,
+			},
+		},
+		{
+			name:        "failing_for must be shorter than max_prowjob_age",
+			config:      PeriodicAutoDisable{FailingFor: &metav1.Duration{Duration: 7 * 24 * time.Hour}},
+			expectedErr: true,
+		},
+		{
+			name:        "negative grace_period is rejected",
+			config:      PeriodicAutoDisable{GracePeriod: &metav1.Duration{Duration: -time.Hour}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.DefaultAndValidate(7 * 24 * time.Hour)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, tc.config); diff != "" {
+				t.Errorf("unexpected config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestValidatePresubmits(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -8212,6 +8857,36 @@ func TestValidatePeriodics(t *testing.T) {
 			},
 			expectedError: "cannot parse duration for a: time: invalid duration \"hello\"",
 		},
+		{
+			name: "Catch up requires cron",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Interval: "6h", CatchUp: &CatchUp{Policy: CatchUpRunOnce}},
+			},
+			expectedError: "invalid catch_up in periodic a: only periodics with cron can catch up on missed runs",
+		},
+		{
+			name: "Catch up all requires max runs",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "0 2 * * *", CatchUp: &CatchUp{Policy: CatchUpRunAll}},
+			},
+			expectedError: "invalid catch_up in periodic a: max_runs must be positive with policy run_all",
+		},
+		{
+			name: "Invalid catch up policy",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "0 2 * * *", CatchUp: &CatchUp{Policy: "all"}},
+			},
+			expectedError: "invalid catch_up in periodic a: policy must be one of skip, run_once or run_all, got \"all\"",
+		},
+		{
+			name: "Valid catch up",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "0 2 * * *", CatchUp: &CatchUp{Policy: CatchUpRunAll, MaxRuns: 3}},
+			},
+			expected: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "0 2 * * *", CatchUp: &CatchUp{Policy: CatchUpRunAll, MaxRuns: 3}},
+			},
+		},
 		{
 			name: "Sets interval",
 			periodics: []Periodic{
@@ -8398,6 +9073,11 @@ github_reporter:
   - presubmit
   - postsubmit
 horologium: {}
+image_prepuller:
+  max_images: 10
+  pause_image: registry.k8s.io/pause:3.9
+  recent_jobs_window: 24h0m0s
+  resync_period: 10m0s
 in_repo_config:
   allowed_clusters:
     '*':
@@ -8414,6 +9094,7 @@ plank:
   pod_running_timeout: 48h0m0s
   pod_unscheduled_timeout: 5m0s
 pod_namespace: default
+prowjob_metadata_propagation: {}
 prowjob_namespace: default
 push_gateway:
   interval: 1m0s
@@ -8425,8 +9106,10 @@ sinker:
   resync_period: 1h0m0s
   terminated_pod_ttl: 24h0m0s
 status_error_link: https://github.com/kubernetes/test-infra/issues
+status_reconciler: {}
 tide:
   context_options: {}
+  history_retention: 720h0m0s
   max_goroutines: 20
   status_update_period: 1m0s
   sync_period: 1m0s
@@ -8482,6 +9165,11 @@ github_reporter:
   - presubmit
   - postsubmit
 horologium: {}
+image_prepuller:
+  max_images: 10
+  pause_image: registry.k8s.io/pause:3.9
+  recent_jobs_window: 24h0m0s
+  resync_period: 10m0s
 in_repo_config:
   allowed_clusters:
     '*':
@@ -8498,6 +9186,7 @@ plank:
   pod_running_timeout: 48h0m0s
   pod_unscheduled_timeout: 5m0s
 pod_namespace: default
+prowjob_metadata_propagation: {}
 prowjob_namespace: default
 push_gateway:
   interval: 1m0s
@@ -8509,8 +9198,10 @@ sinker:
   resync_period: 1h0m0s
   terminated_pod_ttl: 24h0m0s
 status_error_link: https://github.com/kubernetes/test-infra/issues
+status_reconciler: {}
 tide:
   context_options: {}
+  history_retention: 720h0m0s
   max_goroutines: 20
   merge_method:
     foo/bar: squash
@@ -8559,6 +9250,11 @@ github_reporter:
   - presubmit
   - postsubmit
 horologium: {}
+image_prepuller:
+  max_images: 10
+  pause_image: registry.k8s.io/pause:3.9
+  recent_jobs_window: 24h0m0s
+  resync_period: 10m0s
 in_repo_config:
   allowed_clusters:
     '*':
@@ -8575,6 +9271,7 @@ plank:
   pod_running_timeout: 48h0m0s
   pod_unscheduled_timeout: 5m0s
 pod_namespace: default
+prowjob_metadata_propagation: {}
 prowjob_namespace: default
 push_gateway:
   interval: 1m0s
@@ -8586,8 +9283,10 @@ sinker:
   resync_period: 1h0m0s
   terminated_pod_ttl: 24h0m0s
 status_error_link: https://github.com/kubernetes/test-infra/issues
+status_reconciler: {}
 tide:
   context_options: {}
+  history_retention: 720h0m0s
   max_goroutines: 20
   queries:
   - labels:
@@ -8641,6 +9340,11 @@ github_reporter:
   - presubmit
   - postsubmit
 horologium: {}
+image_prepuller:
+  max_images: 10
+  pause_image: registry.k8s.io/pause:3.9
+  recent_jobs_window: 24h0m0s
+  resync_period: 10m0s
 in_repo_config:
   allowed_clusters:
     '*':
@@ -8657,6 +9361,7 @@ plank:
   pod_running_timeout: 48h0m0s
   pod_unscheduled_timeout: 5m0s
 pod_namespace: default
+prowjob_metadata_propagation: {}
 prowjob_namespace: default
 push_gateway:
   interval: 1m0s
@@ -8680,8 +9385,10 @@ slack_reporter_configs:
     channel: '#other-channel'
     report_template: Job {{.Spec.Job}} ended with state {{.Status.State}}.
 status_error_link: https://github.com/kubernetes/test-infra/issues
+status_reconciler: {}
 tide:
   context_options: {}
+  history_retention: 720h0m0s
   max_goroutines: 20
   status_update_period: 1m0s
   sync_period: 1m0s
@@ -9567,3 +10274,204 @@ func TestSplitRepoName(t *testing.T) {
 		})
 	}
 }
+
+func TestJenkinsMasterFor(t *testing.T) {
+	operator := JenkinsOperator{
+		Masters: []JenkinsMaster{
+			{Name: "org", Repos: []string{"org"}},
+			{Name: "repo", Repos: []string{"org/repo"}},
+			{Name: "labeled", LabelSelectorString: "master=labeled", LabelSelector: labels.SelectorFromSet(labels.Set{"master": "labeled"})},
+		},
+	}
+	testCases := []struct {
+		name     string
+		pj       prowapi.ProwJob
+		expected string
+	}{
+		{
+			name: "org",
+			pj:   prowapi.ProwJob{Spec: prowapi.ProwJobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "other"}}},
+
+			expected: "org",
+		},
+		{
+			name: "repo takes precedence over org",
+			pj:   prowapi.ProwJob{Spec: prowapi.ProwJobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}},
+
+			expected: "repo",
+		},
+		{
+			name: "labels take precedence over repo",
+			pj: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"master": "labeled"}},
+				Spec:       prowapi.ProwJobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo"}},
+			},
+
+			expected: "labeled",
+		},
+		{
+			name: "other org runs on the default master",
+			pj:   prowapi.ProwJob{Spec: prowapi.ProwJobSpec{Refs: &prowapi.Refs{Org: "other", Repo: "repo"}}},
+		},
+		{
+			name: "periodic without refs runs on the default master",
+			pj:   prowapi.ProwJob{Spec: prowapi.ProwJobSpec{Type: prowapi.PeriodicJob}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual string
+			if master := operator.MasterFor(&tc.pj); master != nil {
+				actual = master.Name
+			}
+			if actual != tc.expected {
+				t.Errorf("expected master %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJenkinsMasterCredentialsFor(t *testing.T) {
+	master := JenkinsMaster{
+		JenkinsCredentials: JenkinsCredentials{User: "prow", TokenFile: "/etc/jenkins/token"},
+		Folders: []JenkinsFolder{
+			{Path: "team", JenkinsCredentials: JenkinsCredentials{User: "team", TokenFile: "/etc/team/token"}},
+			{Path: "/team/secure/", JenkinsCredentials: JenkinsCredentials{BearerTokenFile: "/etc/secure/token"}},
+		},
+	}
+	testCases := []struct {
+		job                 string
+		expectedFolder      string
+		expectedCredentials JenkinsCredentials
+	}{
+		{
+			job:                 "job",
+			expectedCredentials: master.JenkinsCredentials,
+		},
+		{
+			job:                 "team/job",
+			expectedFolder:      "team",
+			expectedCredentials: JenkinsCredentials{User: "team", TokenFile: "/etc/team/token"},
+		},
+		{
+			job:                 "team/secure/job",
+			expectedFolder:      "team/secure",
+			expectedCredentials: JenkinsCredentials{BearerTokenFile: "/etc/secure/token"},
+		},
+		{
+			job:                 "teams/job",
+			expectedCredentials: master.JenkinsCredentials,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.job, func(t *testing.T) {
+			folder, credentials := master.CredentialsFor(tc.job)
+			if folder != tc.expectedFolder {
+				t.Errorf("expected folder %q, got %q", tc.expectedFolder, folder)
+			}
+			if diff := cmp.Diff(tc.expectedCredentials, credentials); diff != "" {
+				t.Errorf("unexpected credentials (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateJenkinsMasters(t *testing.T) {
+	testCases := []struct {
+		name        string
+		masters     []JenkinsMaster
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			masters: []JenkinsMaster{
+				{Name: "a", URL: "https://a.example.com", LabelSelectorString: "master=a"},
+				{Name: "b", URL: "https://b.example.com", Folders: []JenkinsFolder{{Path: "team", JenkinsCredentials: JenkinsCredentials{BearerTokenFile: "/token"}}}},
+			},
+		},
+		{
+			name:        "missing name",
+			masters:     []JenkinsMaster{{URL: "https://a.example.com"}},
+			expectedErr: "invalid jenkins_operators.masters[0]: name must be set",
+		},
+		{
+			name:        "invalid url",
+			masters:     []JenkinsMaster{{Name: "a", URL: "a.example.com"}},
+			expectedErr: `invalid jenkins_operators.masters[0]: invalid url "a.example.com": parse "a.example.com": invalid URI for request`,
+		},
+		{
+			name: "duplicate name",
+			masters: []JenkinsMaster{
+				{Name: "a", URL: "https://a.example.com"},
+				{Name: "a", URL: "https://b.example.com"},
+			},
+			expectedErr: `duplicate Jenkins master "a"`,
+		},
+		{
+			name:        "token and bearer token",
+			masters:     []JenkinsMaster{{Name: "a", URL: "https://a.example.com", JenkinsCredentials: JenkinsCredentials{User: "u", TokenFile: "/token", BearerTokenFile: "/bearer"}}},
+			expectedErr: "invalid jenkins_operators.masters[0]: token_file and bearer_token_file are mutually exclusive",
+		},
+		{
+			name:        "folder token without user",
+			masters:     []JenkinsMaster{{Name: "a", URL: "https://a.example.com", Folders: []JenkinsFolder{{Path: "team", JenkinsCredentials: JenkinsCredentials{TokenFile: "/token"}}}}},
+			expectedErr: `invalid jenkins_operators.masters[0]: folder "team": token_file requires a user`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{ProwConfig: ProwConfig{JenkinsOperators: []JenkinsOperator{{Masters: tc.masters}}}}
+			var errMsg string
+			if err := parseProwConfig(c); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestParseStartupBacklog(t *testing.T) {
+	testCases := []struct {
+		name        string
+		backlog     *StartupBacklog
+		expected    *StartupBacklog
+		expectedErr string
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:     "newest first by default",
+			backlog:  &StartupBacklog{},
+			expected: &StartupBacklog{TriggeredOrder: StartupBacklogNewestFirst},
+		},
+		{
+			name:     "oldest first",
+			backlog:  &StartupBacklog{TriggeredOrder: StartupBacklogOldestFirst},
+			expected: &StartupBacklog{TriggeredOrder: StartupBacklogOldestFirst},
+		},
+		{
+			name:        "unknown order",
+			backlog:     &StartupBacklog{TriggeredOrder: "random"},
+			expected:    &StartupBacklog{TriggeredOrder: "random"},
+			expectedErr: `validating plank config: startup_backlog.triggered_order must be "newest_first" or "oldest_first", not "random"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{ProwConfig: ProwConfig{Plank: Plank{StartupBacklog: tc.backlog}}}
+			var errMsg string
+			if err := parseProwConfig(c); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			if diff := cmp.Diff(tc.expected, c.Plank.StartupBacklog); diff != "" {
+				t.Errorf("unexpected startup backlog (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultImagePrePullerPauseImage is the image used to keep the pre-pull
+	// pods running once all images were pulled.
+	DefaultImagePrePullerPauseImage = "registry.k8s.io/pause:3.9"
+)
+
+// ImagePrePuller is config for the image-prepuller component, which pulls the
+// images of upcoming jobs on all nodes of the build clusters ahead of time.
+type ImagePrePuller struct {
+	// ResyncPeriod is how often the pre-pulled images are recomputed.
+	// Defaults to 10 minutes.
+	ResyncPeriod *metav1.Duration `json:"resync_period,omitempty"`
+	// RecentJobsWindow is how far back ProwJobs are taken into account when
+	// ranking images by usage. Defaults to one day.
+	RecentJobsWindow *metav1.Duration `json:"recent_jobs_window,omitempty"`
+	// MaxImages is the maximum number of images pre-pulled on every node of a
+	// build cluster. Defaults to 10.
+	MaxImages int `json:"max_images,omitempty"`
+	// PauseImage is the image of the container that keeps the pre-pull pods
+	// running. Defaults to registry.k8s.io/pause:3.9.
+	PauseImage string `json:"pause_image,omitempty"`
+	// ExcludeClusters are build clusters images are not pre-pulled on.
+	ExcludeClusters []string `json:"exclude_clusters,omitempty"`
+	// NodeSelector restricts the nodes images are pre-pulled on.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations are added to the pre-pull pods, e.g. to pre-pull images on
+	// tainted nodes dedicated to tests.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// DefaultAndValidate defaults and validates the image-prepuller config.
+func (p *ImagePrePuller) DefaultAndValidate() error {
+	if p.ResyncPeriod == nil {
+		p.ResyncPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+	}
+	if p.RecentJobsWindow == nil {
+		p.RecentJobsWindow = &metav1.Duration{Duration: 24 * time.Hour}
+	}
+	if p.MaxImages == 0 {
+		p.MaxImages = 10
+	}
+	if p.PauseImage == "" {
+		p.PauseImage = DefaultImagePrePullerPauseImage
+	}
+	if p.ResyncPeriod.Duration <= 0 || p.RecentJobsWindow.Duration <= 0 {
+		return errors.New("resync_period and recent_jobs_window must be positive")
+	}
+	if p.MaxImages < 0 {
+		return errors.New("max_images must not be negative")
+	}
+	return nil
+}
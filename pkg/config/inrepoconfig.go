@@ -17,6 +17,8 @@ limitations under the License.
 package config
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +33,7 @@ import (
 	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/prow/pkg/cache"
 	gerritsource "sigs.k8s.io/prow/pkg/gerrit/source"
 
 	"sigs.k8s.io/prow/pkg/git/types"
@@ -75,7 +78,17 @@ var inrepoconfigMetrics = struct {
 	}),
 }
 
+// prowYAMLFragmentsSize is the number of parsed in-repo config files that are
+// cached by ReadProwYAMLForChanges.
+const prowYAMLFragmentsSize = 5000
+
+var prowYAMLFragments *cache.LRUCache
+
 func init() {
+	var err error
+	if prowYAMLFragments, err = cache.NewLRUCache(prowYAMLFragmentsSize, cache.Callbacks{}); err != nil {
+		logrus.WithError(err).Fatal("Failed to create the cache of in-repo config files.")
+	}
 	prometheus.MustRegister(inrepoconfigMetrics.gitCloneDuration)
 	prometheus.MustRegister(inrepoconfigMetrics.gitOtherDuration)
 }
@@ -188,16 +201,45 @@ func prowYAMLGetter(
 		return nil, fmt.Errorf("failed to merge: %w", err)
 	}
 
+	if len(headSHAs) > 0 && c.InRepoConfigPartialLoading(identifier) {
+		changes, err := repo.Diff("HEAD", baseSHA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+		return ReadProwYAMLForChanges(log, repo.Directory(), changes, false)
+	}
+
 	return ReadProwYAML(log, repo.Directory(), false)
 }
 
 // ReadProwYAML parses the .prow.yaml file or .prow directory, no commit checkout or defaulting is included.
 func ReadProwYAML(log *logrus.Entry, dir string, strict bool) (*ProwYAML, error) {
-	prowYAML := &ProwYAML{}
-	var opts []yaml.JSONOpt
-	if strict {
-		opts = append(opts, yaml.DisallowUnknownFields)
+	return readProwYAML(log, dir, nil, func(content []byte) (*ProwYAML, error) {
+		return unmarshalProwYAML(content, strict)
+	})
+}
+
+// ReadProwYAMLForChanges is like ReadProwYAML, but only parses the files of
+// the .prow directory that are relevant to the changed files (repo relative
+// paths). Files directly in the .prow directory are always relevant, while
+// files in its subdirectories are only relevant if a file under the directory
+// they mirror, or the file itself, changed. For example .prow/pkg/foo/jobs.yaml
+// is relevant to changes of pkg/foo/bar.go. Parsed files are cached by their
+// Git blob SHA, so unchanged files are parsed only once.
+func ReadProwYAMLForChanges(log *logrus.Entry, dir string, changes []string, strict bool) (*ProwYAML, error) {
+	relevant := func(file string) bool {
+		return prowYAMLFileRelevant(file, changes)
 	}
+	return readProwYAML(log, dir, relevant, func(content []byte) (*ProwYAML, error) {
+		return cachedUnmarshalProwYAML(content, strict)
+	})
+}
+
+// readProwYAML reads the .prow.yaml file or the files of the .prow directory
+// that are relevant, all of them if relevant is nil, and parses them with
+// unmarshal.
+func readProwYAML(log *logrus.Entry, dir string, relevant func(file string) bool, unmarshal func(content []byte) (*ProwYAML, error)) (*ProwYAML, error) {
+	prowYAML := &ProwYAML{}
 
 	prowYAMLDirPath := path.Join(dir, inRepoConfigDirName)
 	log.Debugf("Attempting to read config files under %q.", prowYAMLDirPath)
@@ -224,13 +266,23 @@ func ReadProwYAML(log *logrus.Entry, dir string, strict bool) (*ProwYAML, error)
 				if match != nil && match.Ignore() {
 					return nil
 				}
+				if relevant != nil {
+					file, err := filepath.Rel(dir, p)
+					if err != nil {
+						return err
+					}
+					if !relevant(filepath.ToSlash(file)) {
+						log.Debugf("Skipping YAML file %q not relevant to the changes", p)
+						return nil
+					}
+				}
 				log.Debugf("Reading YAML file %q", p)
 				bytes, err := os.ReadFile(p)
 				if err != nil {
 					return err
 				}
-				partialProwYAML := &ProwYAML{}
-				if err := yaml.Unmarshal(bytes, partialProwYAML, opts...); err != nil {
+				partialProwYAML, err := unmarshal(bytes)
+				if err != nil {
 					return fmt.Errorf("failed to unmarshal %q: %w", p, err)
 				}
 				prowYAML = mergeProwYAML(prowYAML, partialProwYAML)
@@ -251,7 +303,7 @@ func ReadProwYAML(log *logrus.Entry, dir string, strict bool) (*ProwYAML, error)
 			if err != nil {
 				return nil, fmt.Errorf("failed to read %q: %w", prowYAMLDirPath, err)
 			}
-			if err := yaml.Unmarshal(bytes, prowYAML, opts...); err != nil {
+			if prowYAML, err = unmarshal(bytes); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal %q: %w", prowYAMLDirPath, err)
 			}
 		} else {
@@ -263,6 +315,65 @@ func ReadProwYAML(log *logrus.Entry, dir string, strict bool) (*ProwYAML, error)
 	return prowYAML, nil
 }
 
+func unmarshalProwYAML(content []byte, strict bool) (*ProwYAML, error) {
+	var opts []yaml.JSONOpt
+	if strict {
+		opts = append(opts, yaml.DisallowUnknownFields)
+	}
+	prowYAML := &ProwYAML{}
+	if err := yaml.Unmarshal(content, prowYAML, opts...); err != nil {
+		return nil, err
+	}
+	return prowYAML, nil
+}
+
+// prowYAMLFragmentKey identifies a parsed in-repo config file in the
+// prowYAMLFragments cache.
+type prowYAMLFragmentKey struct {
+	blobSHA string
+	strict  bool
+}
+
+// cachedUnmarshalProwYAML is like unmarshalProwYAML, but caches the parsed
+// files by their Git blob SHA. The result is a copy that can be modified.
+func cachedUnmarshalProwYAML(content []byte, strict bool) (*ProwYAML, error) {
+	key := prowYAMLFragmentKey{blobSHA: gitBlobSHA(content), strict: strict}
+	val, _, err := prowYAMLFragments.GetOrAdd(key, func() (interface{}, error) {
+		return unmarshalProwYAML(content, strict)
+	})
+	if err != nil {
+		return nil, err
+	}
+	prowYAML, ok := val.(*ProwYAML)
+	if !ok {
+		return nil, fmt.Errorf("programmer error: expected value type '*config.ProwYAML', got '%T'", val)
+	}
+	return prowYAML.DeepCopy(), nil
+}
+
+// gitBlobSHA returns the SHA Git identifies the content as a blob with.
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// prowYAMLFileRelevant returns whether the file of the .prow directory is
+// relevant to the changed files, see ReadProwYAMLForChanges.
+func prowYAMLFileRelevant(file string, changes []string) bool {
+	mirrored := path.Dir(strings.TrimPrefix(file, inRepoConfigDirName+"/"))
+	if mirrored == "." {
+		return true
+	}
+	for _, change := range changes {
+		if change == file || strings.HasPrefix(change, mirrored+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // prowYAMLGetterWithDefaults is like prowYAMLGetter, but additionally sets
 // defaults by calling DefaultAndValidateProwYAML.
 func prowYAMLGetterWithDefaults(
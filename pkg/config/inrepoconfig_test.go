@@ -24,6 +24,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/prow/pkg/git/localgit"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/kube"
@@ -527,6 +529,64 @@ postsubmits: [{"name": "oli", "spec": {"containers": [{}]}}]`),
 				return nil
 			},
 		},
+		{
+			name: "Partial loading only reads the files relevant to the changes",
+			baseContent: map[string][]byte{
+				".prow/base.yaml":        []byte(`presubmits: [{"name": "hans", "spec": {"containers": [{}]}}]`),
+				".prow/pkg/foo/foo.yaml": []byte(`presubmits: [{"name": "kurt", "spec": {"containers": [{}]}}]`),
+				".prow/pkg/bar/bar.yaml": []byte(`presubmits: [{"name": "otto", "spec": {"containers": [{}]}}]`),
+			},
+			headContent: map[string][]byte{
+				"pkg/foo/main.go": []byte(`package main`),
+			},
+			config: &Config{
+				ProwConfig: ProwConfig{
+					InRepoConfig: InRepoConfig{
+						AllowedClusters: map[string][]string{"*": {kube.DefaultClusterAlias}},
+						PartialLoading:  map[string]*bool{"org/repo": ptr.To(true)},
+					},
+				},
+			},
+			validate: func(p *ProwYAML, err error) error {
+				if err != nil {
+					return fmt.Errorf("unexpected error: %w", err)
+				}
+				if n := len(p.Presubmits); n != 2 ||
+					p.Presubmits[0].Name != "hans" ||
+					p.Presubmits[1].Name != "kurt" {
+					return fmt.Errorf(`expected exactly two presubmit with name "hans" and "kurt", got %v`, p.Presubmits)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Partial loading reads changed files",
+			baseContent: map[string][]byte{
+				".prow/base.yaml": []byte(`presubmits: [{"name": "hans", "spec": {"containers": [{}]}}]`),
+			},
+			headContent: map[string][]byte{
+				".prow/pkg/foo/foo.yaml": []byte(`presubmits: [{"name": "kurt", "spec": {"containers": [{}]}}]`),
+			},
+			config: &Config{
+				ProwConfig: ProwConfig{
+					InRepoConfig: InRepoConfig{
+						AllowedClusters: map[string][]string{"*": {kube.DefaultClusterAlias}},
+						PartialLoading:  map[string]*bool{"*": ptr.To(true)},
+					},
+				},
+			},
+			validate: func(p *ProwYAML, err error) error {
+				if err != nil {
+					return fmt.Errorf("unexpected error: %w", err)
+				}
+				if n := len(p.Presubmits); n != 2 ||
+					p.Presubmits[0].Name != "hans" ||
+					p.Presubmits[1].Name != "kurt" {
+					return fmt.Errorf(`expected exactly two presubmit with name "hans" and "kurt", got %v`, p.Presubmits)
+				}
+				return nil
+			},
+		},
 		{
 			name: "Basic happy path (presubmits, gerrit repo)",
 			baseContent: map[string][]byte{
@@ -743,3 +803,98 @@ func TestInRepoConfigClean(t *testing.T) {
 		t.Fatalf("%s should have been deleted", f)
 	}
 }
+
+func TestProwYAMLFileRelevant(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		changes  []string
+		expected bool
+	}{
+		{
+			name:     "file in the .prow directory is always relevant",
+			file:     ".prow/jobs.yaml",
+			expected: true,
+		},
+		{
+			name:     "change under the mirrored directory",
+			file:     ".prow/pkg/foo/jobs.yaml",
+			changes:  []string{"README.md", "pkg/foo/bar/main.go"},
+			expected: true,
+		},
+		{
+			name:     "file itself changed",
+			file:     ".prow/pkg/foo/jobs.yaml",
+			changes:  []string{".prow/pkg/foo/jobs.yaml"},
+			expected: true,
+		},
+		{
+			name:    "change in a directory with the same prefix",
+			file:    ".prow/pkg/foo/jobs.yaml",
+			changes: []string{"pkg/foobar/main.go", "pkg/main.go"},
+		},
+		{
+			name: "no changes",
+			file: ".prow/pkg/foo/jobs.yaml",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := prowYAMLFileRelevant(tc.file, tc.changes); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadProwYAMLForChanges(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".prow/base.yaml":        `presubmits: [{"name": "hans"}]`,
+		".prow/pkg/foo/foo.yaml": `presubmits: [{"name": "kurt"}]`,
+		".prow/pkg/bar/bar.yaml": `presubmits: [{"name": "otto"}]`,
+	}
+	for file, content := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(dir, file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := logrus.WithField("test", t.Name())
+
+	for i := 0; i < 2; i++ {
+		p, err := ReadProwYAMLForChanges(log, dir, []string{"pkg/foo/main.go"}, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var names []string
+		for _, presubmit := range p.Presubmits {
+			names = append(names, presubmit.Name)
+		}
+		if diff := cmp.Diff([]string{"hans", "kurt"}, names); diff != "" {
+			t.Fatalf("presubmits differ (-want +got):\n%s", diff)
+		}
+		// Modifying the result mustn't modify the cached files.
+		p.Presubmits[0].Name = "modified"
+	}
+
+	if _, err := ReadProwYAMLForChanges(log, dir, []string{"pkg/bar/main.go"}, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path.Join(dir, ".prow/pkg/bar/bar.yaml"), []byte(`presubmits: [{"name": "otto", "unknown": true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProwYAMLForChanges(log, dir, []string{"pkg/bar/main.go"}, true); err == nil {
+		t.Error("expected an error for the changed file with an unknown field")
+	}
+}
+
+func TestGitBlobSHA(t *testing.T) {
+	// The SHA of `printf 'hello\n' | git hash-object --stdin`.
+	if sha, expected := gitBlobSHA([]byte("hello\n")), "ce013625030ba8dba906f756967f9e9ca394464a"; sha != expected {
+		t.Errorf("expected %s, got %s", expected, sha)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// JobDefaults are defaults of the runtime of jobs. The defaults for all jobs,
+// an org and a repo are layered in that order, so that the more specific
+// ones override the less specific ones field by field. The fields set by a
+// job itself, or by its job class, always take precedence.
+type JobDefaults struct {
+	// Resources are the default requests and limits of the containers of
+	// the jobs, per resource. Resources requested or limited by a container
+	// aren't changed.
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+	// NodeSelector is merged into the node selector of the pods. Keys set by
+	// the job take precedence.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations are added to the tolerations of the pods. More specific
+	// defaults add their tolerations to those of less specific ones.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// Decorate is the default of decorate, taking precedence over
+	// decorate_all_jobs.
+	Decorate *bool `json:"decorate,omitempty"`
+	// DecorationConfig is the default decoration config of decorated jobs,
+	// including their timeout and grace period. It takes precedence over
+	// the default_decoration_configs of plank.
+	DecorationConfig *prowapi.DecorationConfig `json:"decoration_config,omitempty"`
+}
+
+// overlay returns the defaults with the fields set in more specific defaults
+// taking precedence.
+func (d JobDefaults) overlay(more JobDefaults) JobDefaults {
+	merged := JobDefaults{
+		NodeSelector:     mergeStringMaps(d.NodeSelector, more.NodeSelector),
+		Tolerations:      d.Tolerations,
+		Decorate:         d.Decorate,
+		DecorationConfig: more.DecorationConfig.ApplyDefault(d.DecorationConfig),
+	}
+	if more.Decorate != nil {
+		merged.Decorate = more.Decorate
+	}
+	if len(more.Tolerations) > 0 {
+		merged.Tolerations = append(append([]v1.Toleration{}, d.Tolerations...), more.Tolerations...)
+	}
+	switch {
+	case d.Resources == nil:
+		merged.Resources = more.Resources
+	case more.Resources == nil:
+		merged.Resources = d.Resources
+	default:
+		merged.Resources = &v1.ResourceRequirements{
+			Requests: mergeResourceLists(d.Resources.Requests, more.Resources.Requests),
+			Limits:   mergeResourceLists(d.Resources.Limits, more.Resources.Limits),
+		}
+	}
+	return merged
+}
+
+func mergeResourceLists(base, overrides v1.ResourceList) v1.ResourceList {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(v1.ResourceList, len(base)+len(overrides))
+	for name, quantity := range base {
+		merged[name] = quantity
+	}
+	for name, quantity := range overrides {
+		merged[name] = quantity
+	}
+	return merged
+}
+
+// JobDefaultsFor returns the job defaults of the repo, given as "org/repo",
+// layered from the defaults for all jobs to those of the repo. It returns nil
+// if no defaults apply.
+func (c *ProwConfig) JobDefaultsFor(repo string) *JobDefaults {
+	keys := []string{"*"}
+	if org, _, found := strings.Cut(repo, "/"); repo != "" {
+		keys = append(keys, org)
+		if found {
+			keys = append(keys, repo)
+		}
+	}
+	var resolved *JobDefaults
+	for _, key := range keys {
+		defaults, ok := c.JobDefaults[key]
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = &JobDefaults{}
+		}
+		*resolved = resolved.overlay(defaults)
+	}
+	return resolved
+}
+
+// applyJobDefaults applies the job defaults of the repo to the job. It runs
+// after the job class is applied and before the default decoration config
+// of plank is merged.
+func (c *Config) applyJobDefaults(base *JobBase, repo string) {
+	defaults := c.JobDefaultsFor(repo)
+	if defaults == nil {
+		return
+	}
+	if base.Decorate == nil && defaults.Decorate != nil {
+		decorate := *defaults.Decorate
+		base.Decorate = &decorate
+	}
+	decorated := c.DecorateAllJobs
+	if base.Decorate != nil {
+		decorated = *base.Decorate
+	}
+	if decorated && defaults.DecorationConfig != nil {
+		base.DecorationConfig = base.DecorationConfig.ApplyDefault(defaults.DecorationConfig)
+	}
+	if base.Spec == nil {
+		return
+	}
+	JobClass{NodeSelector: defaults.NodeSelector, Tolerations: defaults.Tolerations}.apply(base.Spec)
+	if defaults.Resources != nil {
+		for i := range base.Spec.Containers {
+			container := &base.Spec.Containers[i]
+			container.Resources.Requests = defaultResourceList(container.Resources.Requests, defaults.Resources.Requests)
+			container.Resources.Limits = defaultResourceList(container.Resources.Limits, defaults.Resources.Limits)
+		}
+	}
+}
+
+// defaultResourceList adds the defaults for the resources that aren't in the
+// list.
+func defaultResourceList(list, defaults v1.ResourceList) v1.ResourceList {
+	for name, quantity := range defaults {
+		if _, set := list[name]; set {
+			continue
+		}
+		if list == nil {
+			list = v1.ResourceList{}
+		}
+		list[name] = quantity.DeepCopy()
+	}
+	return list
+}
+
+func (c *ProwConfig) validateJobDefaults() error {
+	for key, defaults := range c.JobDefaults {
+		if key != "*" {
+			if parts := strings.Split(key, "/"); len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+				return fmt.Errorf("job_defaults: invalid key %q, expected \"*\", an org or an org/repo", key)
+			}
+		}
+		if defaults.Resources != nil {
+			for name, limit := range defaults.Resources.Limits {
+				if request, ok := defaults.Resources.Requests[name]; ok && request.Cmp(limit) > 0 {
+					return fmt.Errorf("job_defaults[%q]: the request of %s exceeds its limit", key, name)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestJobDefaultsFor(t *testing.T) {
+	jobDefaults := map[string]JobDefaults{
+		"*": {
+			Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			NodeSelector: map[string]string{"pool": "default", "arch": "amd64"},
+			Tolerations:  []v1.Toleration{{Key: "shared"}},
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout: &prowapi.Duration{Duration: time.Hour},
+			},
+		},
+		"org": {
+			NodeSelector: map[string]string{"pool": "org"},
+			Decorate:     ptr.To(true),
+			DecorationConfig: &prowapi.DecorationConfig{
+				GracePeriod: &prowapi.Duration{Duration: time.Minute},
+			},
+		},
+		"org/repo": {
+			Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+			},
+			Tolerations: []v1.Toleration{{Key: "dedicated"}},
+			Decorate:    ptr.To(false),
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout: &prowapi.Duration{Duration: 2 * time.Hour},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		jobDefaults map[string]JobDefaults
+		repo        string
+		expected    *JobDefaults
+	}{
+		{
+			name: "no defaults",
+			repo: "org/repo",
+		},
+		{
+			name:        "no defaults for the repo",
+			jobDefaults: map[string]JobDefaults{"other": {Decorate: ptr.To(true)}},
+			repo:        "org/repo",
+		},
+		{
+			name:        "periodic without a repo only gets the global defaults",
+			jobDefaults: jobDefaults,
+			expected: &JobDefaults{
+				Resources: &v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				NodeSelector:     map[string]string{"pool": "default", "arch": "amd64"},
+				Tolerations:      []v1.Toleration{{Key: "shared"}},
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: time.Hour}},
+			},
+		},
+		{
+			name:        "org defaults override the global ones",
+			jobDefaults: jobDefaults,
+			repo:        "org/other",
+			expected: &JobDefaults{
+				Resources: &v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				NodeSelector: map[string]string{"pool": "org", "arch": "amd64"},
+				Tolerations:  []v1.Toleration{{Key: "shared"}},
+				Decorate:     ptr.To(true),
+				DecorationConfig: &prowapi.DecorationConfig{
+					Timeout:     &prowapi.Duration{Duration: time.Hour},
+					GracePeriod: &prowapi.Duration{Duration: time.Minute},
+				},
+			},
+		},
+		{
+			name:        "repo defaults override the org and global ones",
+			jobDefaults: jobDefaults,
+			repo:        "org/repo",
+			expected: &JobDefaults{
+				Resources: &v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				NodeSelector: map[string]string{"pool": "org", "arch": "amd64"},
+				Tolerations:  []v1.Toleration{{Key: "shared"}, {Key: "dedicated"}},
+				Decorate:     ptr.To(false),
+				DecorationConfig: &prowapi.DecorationConfig{
+					Timeout:     &prowapi.Duration{Duration: 2 * time.Hour},
+					GracePeriod: &prowapi.Duration{Duration: time.Minute},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ProwConfig{JobDefaults: tc.jobDefaults}
+			if diff := cmp.Diff(tc.expected, c.JobDefaultsFor(tc.repo)); diff != "" {
+				t.Errorf("unexpected job defaults (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyJobDefaults(t *testing.T) {
+	jobDefaults := map[string]JobDefaults{
+		"org": {
+			Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+			},
+			NodeSelector: map[string]string{"pool": "default"},
+			Tolerations:  []v1.Toleration{{Key: "shared"}},
+			Decorate:     ptr.To(true),
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Hour},
+				GracePeriod: &prowapi.Duration{Duration: time.Minute},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		decorateAllJobs bool
+		jobDefaults     map[string]JobDefaults
+		job             JobBase
+		expected        JobBase
+	}{
+		{
+			name:     "no defaults",
+			job:      JobBase{Spec: &v1.PodSpec{Containers: []v1.Container{{}}}},
+			expected: JobBase{Spec: &v1.PodSpec{Containers: []v1.Container{{}}}},
+		},
+		{
+			name:        "defaults are applied to an unset job",
+			jobDefaults: jobDefaults,
+			job:         JobBase{Spec: &v1.PodSpec{Containers: []v1.Container{{}}}},
+			expected: JobBase{
+				UtilityConfig: UtilityConfig{
+					Decorate: ptr.To(true),
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Hour},
+						GracePeriod: &prowapi.Duration{Duration: time.Minute},
+					},
+				},
+				Spec: &v1.PodSpec{
+					NodeSelector: map[string]string{"pool": "default"},
+					Tolerations:  []v1.Toleration{{Key: "shared"}},
+					Containers: []v1.Container{{
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+							Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+					}},
+				},
+			},
+		},
+		{
+			name:        "fields set by the job take precedence",
+			jobDefaults: jobDefaults,
+			job: JobBase{
+				UtilityConfig: UtilityConfig{
+					Decorate:         ptr.To(true),
+					DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: 3 * time.Hour}},
+				},
+				Spec: &v1.PodSpec{
+					NodeSelector: map[string]string{"pool": "gpu"},
+					Containers: []v1.Container{{
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+						},
+					}},
+				},
+			},
+			expected: JobBase{
+				UtilityConfig: UtilityConfig{
+					Decorate: ptr.To(true),
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: 3 * time.Hour},
+						GracePeriod: &prowapi.Duration{Duration: time.Minute},
+					},
+				},
+				Spec: &v1.PodSpec{
+					NodeSelector: map[string]string{"pool": "gpu"},
+					Tolerations:  []v1.Toleration{{Key: "shared"}},
+					Containers: []v1.Container{{
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+							Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+					}},
+				},
+			},
+		},
+		{
+			name:        "undecorated jobs don't get the decoration config",
+			jobDefaults: jobDefaults,
+			job:         JobBase{UtilityConfig: UtilityConfig{Decorate: ptr.To(false)}},
+			expected:    JobBase{UtilityConfig: UtilityConfig{Decorate: ptr.To(false)}},
+		},
+		{
+			name:            "decorate_all_jobs decorates jobs without a decorate default",
+			decorateAllJobs: true,
+			jobDefaults: map[string]JobDefaults{
+				"*": {DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: time.Hour}}},
+			},
+			expected: JobBase{
+				UtilityConfig: UtilityConfig{
+					DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: time.Hour}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				JobConfig:  JobConfig{DecorateAllJobs: tc.decorateAllJobs},
+				ProwConfig: ProwConfig{JobDefaults: tc.jobDefaults},
+			}
+			job := tc.job
+			c.applyJobDefaults(&job, "org/repo")
+			if diff := cmp.Diff(tc.expected, job); diff != "" {
+				t.Errorf("unexpected job (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateJobDefaults(t *testing.T) {
+	testCases := []struct {
+		name        string
+		jobDefaults map[string]JobDefaults
+		expectErr   bool
+	}{
+		{
+			name: "valid keys",
+			jobDefaults: map[string]JobDefaults{
+				"*":        {},
+				"org":      {},
+				"org/repo": {},
+			},
+		},
+		{
+			name:        "too many slashes",
+			jobDefaults: map[string]JobDefaults{"org/repo/branch": {}},
+			expectErr:   true,
+		},
+		{
+			name:        "missing repo",
+			jobDefaults: map[string]JobDefaults{"org/": {}},
+			expectErr:   true,
+		},
+		{
+			name: "request exceeding its limit",
+			jobDefaults: map[string]JobDefaults{"*": {Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+				Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			}}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ProwConfig{JobDefaults: tc.jobDefaults}
+			if err := c.validateJobDefaults(); (err != nil) != tc.expectErr {
+				t.Errorf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jobbundle syncs the job config from a bundle published at an HTTPS
+// URL or as an OCI artifact, so that many Prow instances can load a job
+// config that is built centrally.
+//
+// A bundle is a gzipped tarball of job config files, signed with an ed25519
+// key. The signature is the base64 encoded signature of the tarball, served
+// at the URL of the bundle with a .sig suffix, or set as the SignatureAnnotation
+// of the manifest of an OCI artifact whose single layer is the tarball.
+// Bundles are ordered by the newest modification time of their entries, and
+// a bundle older than the synced one is rejected. The synced bundle is only
+// known while the Syncer runs, so after a restart any validly signed bundle
+// is accepted unless it is older than the minimum creation time given to
+// NewSyncer.
+package jobbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureAnnotation is the annotation of the manifest of an OCI artifact
+	// that holds the signature of its bundle.
+	SignatureAnnotation = "prow.k8s.io/job-config-signature"
+
+	ociPrefix = "oci://"
+
+	// currentLink is the symlink to the directory of the bundle that was
+	// synced last, swapped atomically like the ..data link of ConfigMaps.
+	currentLink = "current"
+
+	// maxBundleSize limits the size of a bundle, compressed or not.
+	maxBundleSize = 100 << 20
+)
+
+// Syncer syncs a job config bundle into a local directory.
+type Syncer struct {
+	source    string
+	publicKey ed25519.PublicKey
+	dir       string
+	client    *http.Client
+	options   []remote.Option
+	logger    *logrus.Entry
+
+	// digest is the digest of the bundle that was synced last.
+	digest string
+	// created is the newest modification time of the entries of the bundle
+	// that was synced last, or the minimum creation time before the first
+	// sync. Older bundles are rejected, so that an older signed bundle can't
+	// be replayed while the Syncer runs.
+	created time.Time
+	// previous is the directory of the bundle that was synced before the
+	// current one. It is kept until the next bundle is synced, as loads that
+	// resolved the current link before the swap may still read it.
+	previous string
+}
+
+// NewSyncer returns a Syncer of the bundle at the source, an https:// URL or
+// an oci:// reference, whose signature is verified with the ed25519 public
+// key in PEM at publicKeyPath. Bundles are extracted in dir. Bundles created
+// before minCreated are rejected, which protects from replays across restarts
+// if it is set to the creation time of the bundle in use.
+func NewSyncer(source, publicKeyPath, dir string, minCreated time.Time) (*Syncer, error) {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, ociPrefix) {
+		return nil, fmt.Errorf("the job config source %q is neither an https:// URL nor an oci:// reference", source)
+	}
+	if strings.HasPrefix(source, ociPrefix) {
+		if _, err := name.ParseReference(strings.TrimPrefix(source, ociPrefix)); err != nil {
+			return nil, fmt.Errorf("failed to parse the OCI reference of the job config source: %w", err)
+		}
+	}
+	publicKey, err := loadPublicKey(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{
+		source:    source,
+		publicKey: publicKey,
+		dir:       dir,
+		client:    http.DefaultClient,
+		options:   []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+		logger:    logrus.WithField("job-config-source", source),
+		created:   minCreated,
+	}, nil
+}
+
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public key of the job config: %w", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("the public key of the job config at %s is not in PEM", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of the job config: %w", err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key of the job config is a %T, not an ed25519 key", key)
+	}
+	return publicKey, nil
+}
+
+// Path is the path of the synced job config, to load it from.
+func (s *Syncer) Path() string {
+	return filepath.Join(s.dir, currentLink)
+}
+
+// Sync fetches the bundle and, if it changed since the last sync, its
+// signature is valid and it isn't older than the synced one, replaces the
+// synced job config with it. The synced job config is left as it is on
+// errors.
+func (s *Syncer) Sync(ctx context.Context) error {
+	bundle, signature, err := s.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the job config bundle: %w", err)
+	}
+	sum := sha256.Sum256(bundle)
+	digest := hex.EncodeToString(sum[:])
+	if digest == s.digest {
+		return nil
+	}
+	if !ed25519.Verify(s.publicKey, bundle, signature) {
+		return errors.New("the signature of the job config bundle is invalid")
+	}
+
+	bundleDir, err := os.MkdirTemp(s.dir, "bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create the directory of the job config bundle: %w", err)
+	}
+	created, err := extract(bundle, bundleDir)
+	if err != nil {
+		os.RemoveAll(bundleDir)
+		return fmt.Errorf("failed to extract the job config bundle: %w", err)
+	}
+	if created.Before(s.created) {
+		os.RemoveAll(bundleDir)
+		if s.digest == "" {
+			return fmt.Errorf("the job config bundle was created at %s, before the minimum creation time %s", created.UTC().Format(time.RFC3339), s.created.UTC().Format(time.RFC3339))
+		}
+		return fmt.Errorf("the job config bundle was created at %s, before the synced one from %s", created.UTC().Format(time.RFC3339), s.created.UTC().Format(time.RFC3339))
+	}
+	current, _ := os.Readlink(s.Path())
+	if err := s.swap(filepath.Base(bundleDir)); err != nil {
+		os.RemoveAll(bundleDir)
+		return err
+	}
+	// Loads that started before the swap may still read the bundle that was
+	// current until now, so only the one before it is removed.
+	if s.previous != "" {
+		if err := os.RemoveAll(filepath.Join(s.dir, s.previous)); err != nil {
+			s.logger.WithError(err).Warn("Failed to remove an old job config bundle.")
+		}
+	}
+	s.previous = current
+	s.digest, s.created = digest, created
+	s.logger.WithFields(logrus.Fields{"digest": digest, "created": created}).Info("Synced the job config bundle.")
+	return nil
+}
+
+// swap points the current link to the directory atomically, so that the job
+// config is never loaded half extracted.
+func (s *Syncer) swap(bundleDir string) error {
+	link := filepath.Join(s.dir, "."+currentLink+"-"+bundleDir)
+	if err := os.Symlink(bundleDir, link); err != nil {
+		return fmt.Errorf("failed to link the job config bundle: %w", err)
+	}
+	if err := os.Rename(link, s.Path()); err != nil {
+		os.Remove(link)
+		return fmt.Errorf("failed to link the job config bundle: %w", err)
+	}
+	return nil
+}
+
+func (s *Syncer) fetch(ctx context.Context) ([]byte, []byte, error) {
+	if strings.HasPrefix(s.source, ociPrefix) {
+		return s.fetchOCI(ctx)
+	}
+	bundle, err := s.get(ctx, s.source)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := s.get(ctx, s.source+".sig")
+	if err != nil {
+		return nil, nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the signature: %w", err)
+	}
+	return bundle, decoded, nil
+}
+
+func (s *Syncer) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return readLimited(resp.Body)
+}
+
+func (s *Syncer) fetchOCI(ctx context.Context) ([]byte, []byte, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(s.source, ociPrefix))
+	if err != nil {
+		return nil, nil, err
+	}
+	image, err := remote.Image(ref, append([]remote.Option{remote.WithContext(ctx)}, s.options...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := image.Manifest()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(manifest.Layers) != 1 {
+		return nil, nil, fmt.Errorf("the OCI artifact has %d layers, expected the bundle as its single layer", len(manifest.Layers))
+	}
+	signature, err := base64.StdEncoding.DecodeString(manifest.Annotations[SignatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return nil, nil, fmt.Errorf("the OCI artifact has no valid %s annotation", SignatureAnnotation)
+	}
+	layer, err := image.LayerByDigest(manifest.Layers[0].Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	compressed, err := layer.Compressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer compressed.Close()
+	bundle, err := readLimited(compressed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bundle, signature, nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxBundleSize {
+		return nil, fmt.Errorf("the bundle is larger than %d bytes", maxBundleSize)
+	}
+	return raw, nil
+}
+
+// extract writes the regular files and directories of the bundle in dir and
+// returns the newest modification time of its entries, which is signed with
+// the bundle and orders bundles. Other kinds of entries, like symlinks, and
+// paths out of dir are rejected.
+func extract(bundle []byte, dir string) (time.Time, error) {
+	var created time.Time
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		return created, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(io.LimitReader(gz, maxBundleSize))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return created, nil
+		}
+		if err != nil {
+			return created, err
+		}
+		if header.ModTime.After(created) {
+			created = header.ModTime
+		}
+		path := filepath.Clean(header.Name)
+		if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return created, fmt.Errorf("the bundle has a path out of its directory: %s", header.Name)
+		}
+		target := filepath.Join(dir, path)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return created, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return created, err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return created, err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return created, err
+			}
+		default:
+			return created, fmt.Errorf("the bundle has %s, which is neither a regular file nor a directory", header.Name)
+		}
+	}
+}
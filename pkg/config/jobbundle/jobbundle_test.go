@@ -0,0 +1,370 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+type entry struct {
+	name     string
+	content  string
+	typeflag byte
+	modTime  time.Time
+}
+
+func makeBundle(t *testing.T, entries ...entry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: typeflag, ModTime: e.modTime}
+		if typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeKey generates a key pair and writes the public key in dir.
+func writeKey(t *testing.T, dir string) (ed25519.PrivateKey, string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "key.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, path
+}
+
+func readSynced(t *testing.T, s *Syncer, file string) string {
+	raw, err := os.ReadFile(filepath.Join(s.Path(), file))
+	if err != nil {
+		t.Fatalf("failed to read the synced %s: %v", file, err)
+	}
+	return string(raw)
+}
+
+func TestSyncHTTPS(t *testing.T) {
+	keyDir := t.TempDir()
+	privateKey, publicKeyPath := writeKey(t, keyDir)
+	_, otherKeyPath := writeKey(t, t.TempDir())
+
+	valid := makeBundle(t, entry{name: "org/repo.yaml", content: "presubmits: {}"}, entry{name: "periodics.yaml", content: "periodics: []"})
+	testCases := []struct {
+		name          string
+		bundle        []byte
+		publicKeyPath string
+		expectedErr   string
+		expectedFiles map[string]string
+	}{
+		{
+			name:          "valid bundle",
+			bundle:        valid,
+			publicKeyPath: publicKeyPath,
+			expectedFiles: map[string]string{"org/repo.yaml": "presubmits: {}", "periodics.yaml": "periodics: []"},
+		},
+		{
+			name:          "bundle signed with another key",
+			bundle:        valid,
+			publicKeyPath: otherKeyPath,
+			expectedErr:   "signature of the job config bundle is invalid",
+		},
+		{
+			name:          "path out of the bundle",
+			bundle:        makeBundle(t, entry{name: "../escape.yaml", content: "periodics: []"}),
+			publicKeyPath: publicKeyPath,
+			expectedErr:   "path out of its directory",
+		},
+		{
+			name:          "symlink",
+			bundle:        makeBundle(t, entry{name: "link.yaml", typeflag: tar.TypeSymlink}),
+			publicKeyPath: publicKeyPath,
+			expectedErr:   "neither a regular file nor a directory",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/jobs.tar.gz":
+					w.Write(tc.bundle)
+				case "/jobs.tar.gz.sig":
+					w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, tc.bundle))))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			s, err := NewSyncer(server.URL+"/jobs.tar.gz", tc.publicKeyPath, dir, time.Time{})
+			if err != nil {
+				t.Fatalf("failed to create the syncer: %v", err)
+			}
+			s.client = server.Client()
+			err = s.Sync(context.Background())
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+					t.Errorf("expected nothing to be synced, got %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for file, expected := range tc.expectedFiles {
+				if content := readSynced(t, s, file); content != expected {
+					t.Errorf("expected %s to be %q, got %q", file, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncReplacesBundle(t *testing.T) {
+	privateKey, publicKeyPath := writeKey(t, t.TempDir())
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	bundleAt := func(content string, modTime time.Time) []byte {
+		return makeBundle(t, entry{name: "jobs.yaml", content: content, modTime: modTime})
+	}
+	bundle := bundleAt("v1", created)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, bundle))))
+			return
+		}
+		w.Write(bundle)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, err := NewSyncer(server.URL+"/jobs.tar.gz", publicKeyPath, dir, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to create the syncer: %v", err)
+	}
+	s.client = server.Client()
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	first, _ := os.Readlink(s.Path())
+
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("failed to sync the same bundle: %v", err)
+	}
+	if unchanged, _ := os.Readlink(s.Path()); unchanged != first {
+		t.Errorf("expected the same bundle not to be extracted again, got %s after %s", unchanged, first)
+	}
+
+	bundle = bundleAt("v2", created.Add(time.Hour))
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("failed to sync the new bundle: %v", err)
+	}
+	if content := readSynced(t, s, "jobs.yaml"); content != "v2" {
+		t.Errorf("expected the new bundle to be synced, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, first, "jobs.yaml")); err != nil {
+		t.Errorf("expected the previous bundle to be kept for the loads still reading it, got %v", err)
+	}
+	second, _ := os.Readlink(s.Path())
+
+	bundle = bundleAt("v3", created.Add(2*time.Hour))
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("failed to sync the newer bundle: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, first)); !os.IsNotExist(err) {
+		t.Errorf("expected the bundle before the previous one to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, second)); err != nil {
+		t.Errorf("expected the previous bundle to be kept, got %v", err)
+	}
+
+	bundle = bundleAt("v1", created)
+	if err := s.Sync(context.Background()); err == nil || !strings.Contains(err.Error(), "before the synced one") {
+		t.Errorf("expected the older bundle to be rejected, got %v", err)
+	}
+	if content := readSynced(t, s, "jobs.yaml"); content != "v3" {
+		t.Errorf("expected the newer bundle to stay synced, got %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("expected the directory to only have the current link, the current and the previous bundles, got %v", entries)
+	}
+}
+
+func TestSyncRejectsBundleBeforeMinCreated(t *testing.T) {
+	privateKey, publicKeyPath := writeKey(t, t.TempDir())
+	minCreated := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	bundle := makeBundle(t, entry{name: "jobs.yaml", content: "v1", modTime: minCreated.Add(-time.Hour)})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, bundle))))
+			return
+		}
+		w.Write(bundle)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, err := NewSyncer(server.URL+"/jobs.tar.gz", publicKeyPath, dir, minCreated)
+	if err != nil {
+		t.Fatalf("failed to create the syncer: %v", err)
+	}
+	s.client = server.Client()
+	if err := s.Sync(context.Background()); err == nil || !strings.Contains(err.Error(), "before the minimum creation time") {
+		t.Errorf("expected the bundle to be rejected, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected nothing to be synced, got %v", entries)
+	}
+
+	bundle = makeBundle(t, entry{name: "jobs.yaml", content: "v2", modTime: minCreated})
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("failed to sync the bundle created at the minimum creation time: %v", err)
+	}
+	if content := readSynced(t, s, "jobs.yaml"); content != "v2" {
+		t.Errorf("expected the bundle to be synced, got %q", content)
+	}
+}
+
+func TestSyncOCI(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	privateKey, publicKeyPath := writeKey(t, t.TempDir())
+
+	bundle := makeBundle(t, entry{name: "jobs.yaml", content: "periodics: []"})
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr string
+	}{
+		{
+			name:        "signed artifact",
+			annotations: map[string]string{SignatureAnnotation: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, bundle))},
+		},
+		{
+			name:        "unsigned artifact",
+			expectedErr: "no valid " + SignatureAnnotation,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			image, err := mutate.AppendLayers(empty.Image, static.NewLayer(bundle, types.OCILayer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			image = mutate.Annotations(image, tc.annotations).(v1.Image)
+			ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/config/jobs:" + string(rune('a'+i)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := remote.Write(ref, image); err != nil {
+				t.Fatalf("failed to push the artifact: %v", err)
+			}
+
+			s, err := NewSyncer("oci://"+ref.String(), publicKeyPath, t.TempDir(), time.Time{})
+			if err != nil {
+				t.Fatalf("failed to create the syncer: %v", err)
+			}
+			s.options = nil
+			err = s.Sync(context.Background())
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if content := readSynced(t, s, "jobs.yaml"); content != "periodics: []" {
+				t.Errorf("unexpected synced content %q", content)
+			}
+		})
+	}
+}
+
+func TestNewSyncer(t *testing.T) {
+	_, publicKeyPath := writeKey(t, t.TempDir())
+	testCases := []struct {
+		name        string
+		source      string
+		expectedErr bool
+	}{
+		{name: "https URL", source: "https://example.com/jobs.tar.gz"},
+		{name: "OCI reference", source: "oci://registry.example.com/config/jobs:latest"},
+		{name: "plain HTTP URL", source: "http://example.com/jobs.tar.gz", expectedErr: true},
+		{name: "invalid OCI reference", source: "oci://registry.example.com/Config:", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSyncer(tc.source, publicKeyPath, t.TempDir(), time.Time{}); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
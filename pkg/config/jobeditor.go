@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// JobEditor configures the Deck page that edits the file a job is defined
+// in and proposes the change as a PR to the repo of the job config.
+type JobEditor struct {
+	// Repo is the org/repo the job config is checked into.
+	Repo string `json:"repo"`
+	// Branch is the branch of the repo the job config is deployed from,
+	// which the PRs are opened against. Defaults to main.
+	Branch string `json:"branch,omitempty"`
+	// JobConfigPath is the directory of the repo that Deck loads the job
+	// config from with --job-config-path, like config/jobs.
+	JobConfigPath string `json:"job_config_path"`
+	// Editors are the users allowed to propose changes, who log in with
+	// GitHub OAuth or OIDC. The PRs are opened by the bot, so they still
+	// need to be reviewed like any other change of the job config.
+	Editors *prowapi.RerunAuthConfig `json:"editors"`
+}
+
+// Validate validates the job editor config.
+func (e *JobEditor) Validate() error {
+	if _, _, err := SplitRepoName(e.Repo); err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+	if !filepath.IsLocal(e.JobConfigPath) || path.Clean(e.JobConfigPath) != e.JobConfigPath {
+		return fmt.Errorf("job_config_path: %q must be a clean relative path in the repo", e.JobConfigPath)
+	}
+	if e.Editors == nil {
+		return errors.New("editors must be set")
+	}
+	if err := e.Editors.Validate(); err != nil {
+		return fmt.Errorf("editors: %w", err)
+	}
+	return nil
+}
+
+// RepoPath returns the path in the repo of the file the job is defined in,
+// given the local --job-config-path it was loaded from.
+func (e *JobEditor) RepoPath(jobConfig string, base JobBase) (string, error) {
+	if base.SourcePath == "" || jobConfig == "" {
+		return "", errors.New("the job isn't loaded from the job config")
+	}
+	rel, err := filepath.Rel(jobConfig, base.SourcePath)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", fmt.Errorf("the job is defined in %s, outside of the job config", base.SourcePath)
+	}
+	if rel == "." {
+		// The job config is a single file.
+		rel = filepath.Base(base.SourcePath)
+	}
+	return path.Join(e.JobConfigPath, filepath.ToSlash(rel)), nil
+}
+
+// ValidateJobConfigFile checks the content of a file of the job config as if
+// it replaced the file at sourcePath, so that its jobs are also validated
+// against the jobs of the other files, like for duplicate names. The config
+// isn't modified.
+func (c *Config) ValidateJobConfigFile(sourcePath string, raw []byte) error {
+	var jc JobConfig
+	if err := yaml.UnmarshalStrict(raw, &jc); err != nil {
+		return fmt.Errorf("failed to parse the job config: %w", err)
+	}
+
+	nc := *c
+	nc.PresubmitsStatic = map[string][]Presubmit{}
+	nc.PostsubmitsStatic = map[string][]Postsubmit{}
+	nc.Periodics = nil
+	nc.Presets = nil
+	// Presets aren't tracked by file, so those of the file replace the ones
+	// sharing their labels.
+	edited := sets.New[string]()
+	for _, preset := range jc.Presets {
+		for label, val := range preset.Labels {
+			edited.Insert(label + ":" + val)
+		}
+	}
+	for _, preset := range c.Presets {
+		replaced := false
+		for label, val := range preset.Labels {
+			replaced = replaced || edited.Has(label+":"+val)
+		}
+		if !replaced {
+			nc.Presets = append(nc.Presets, preset)
+		}
+	}
+	for repo, jobs := range c.PresubmitsStatic {
+		for _, job := range jobs {
+			if job.SourcePath != sourcePath {
+				nc.PresubmitsStatic[repo] = append(nc.PresubmitsStatic[repo], job)
+			}
+		}
+	}
+	for repo, jobs := range c.PostsubmitsStatic {
+		for _, job := range jobs {
+			if job.SourcePath != sourcePath {
+				nc.PostsubmitsStatic[repo] = append(nc.PostsubmitsStatic[repo], job)
+			}
+		}
+	}
+	for _, job := range c.Periodics {
+		if job.SourcePath != sourcePath {
+			nc.Periodics = append(nc.Periodics, job)
+		}
+	}
+
+	// Only the jobs of the file are defaulted, the others already are.
+	presets := jc.Presets
+	jc.Presets = nil
+	if err := nc.mergeJobConfig(JobConfig{Presets: presets}); err != nil {
+		return err
+	}
+	var errs []error
+	for repo := range jc.PresubmitsStatic {
+		for i := range jc.PresubmitsStatic[repo] {
+			jc.PresubmitsStatic[repo][i].SourcePath = sourcePath
+		}
+		if err := defaultPresubmits(jc.PresubmitsStatic[repo], nil, &nc, repo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for repo := range jc.PostsubmitsStatic {
+		for i := range jc.PostsubmitsStatic[repo] {
+			jc.PostsubmitsStatic[repo][i].SourcePath = sourcePath
+		}
+		if err := defaultPostsubmits(jc.PostsubmitsStatic[repo], nil, &nc, repo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := range jc.Periodics {
+		jc.Periodics[i].SourcePath = sourcePath
+		if err := nc.DefaultPeriodic(&jc.Periodics[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+	if err := nc.mergeJobConfig(jc); err != nil {
+		return err
+	}
+	return nc.ValidateJobConfig()
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobEditorRepoPath(t *testing.T) {
+	editor := &JobEditor{Repo: "org/config", JobConfigPath: "config/jobs"}
+	testCases := []struct {
+		name        string
+		jobConfig   string
+		sourcePath  string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:       "file of a directory",
+			jobConfig:  "/etc/job-config",
+			sourcePath: "/etc/job-config/org/repo/repo-presubmits.yaml",
+			expected:   "config/jobs/org/repo/repo-presubmits.yaml",
+		},
+		{
+			name:       "single file",
+			jobConfig:  "/etc/job-config/jobs.yaml",
+			sourcePath: "/etc/job-config/jobs.yaml",
+			expected:   "config/jobs/jobs.yaml",
+		},
+		{
+			name:        "file outside of the job config",
+			jobConfig:   "/etc/job-config",
+			sourcePath:  "/etc/config/config.yaml",
+			expectedErr: true,
+		},
+		{
+			name:        "no job config",
+			sourcePath:  "/etc/config/config.yaml",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := editor.RepoPath(tc.jobConfig, JobBase{SourcePath: tc.sourcePath})
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if path != tc.expected {
+				t.Errorf("expected path %q, got %q", tc.expected, path)
+			}
+		})
+	}
+}
+
+func TestValidateJobConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	prowConfigPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(prowConfigPath, []byte("presets:\n- labels:\n    preset-a: \"true\"\n  env:\n  - name: A\n    value: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobConfigPath := filepath.Join(dir, "jobs")
+	if err := os.MkdirAll(jobConfigPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.yaml": `presubmits:
+  org/repo:
+  - name: pull-a
+    labels:
+      preset-a: "true"
+    spec:
+      containers:
+      - image: alpine
+`,
+		"b.yaml": `presubmits:
+  org/repo:
+  - name: pull-b
+    spec:
+      containers:
+      - image: alpine
+periodics:
+- name: periodic-b
+  interval: 1h
+  spec:
+    containers:
+    - image: alpine
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(jobConfigPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := Load(prowConfigPath, jobConfigPath, nil, "")
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		file        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:    "unchanged file",
+			file:    "a.yaml",
+			content: files["a.yaml"],
+		},
+		{
+			name:    "renamed job",
+			file:    "a.yaml",
+			content: strings.Replace(files["a.yaml"], "pull-a", "pull-a-renamed", 1),
+		},
+		{
+			name:    "removed periodic",
+			file:    "b.yaml",
+			content: files["b.yaml"][:strings.Index(files["b.yaml"], "periodics:")],
+		},
+		{
+			name:    "preset of the edited file",
+			file:    "a.yaml",
+			content: files["a.yaml"] + "presets:\n- labels:\n    preset-b: \"true\"\n",
+		},
+		{
+			name:    "replaced preset",
+			file:    "a.yaml",
+			content: files["a.yaml"] + "presets:\n- labels:\n    preset-a: \"true\"\n  env:\n  - name: A\n    value: b\n",
+		},
+		{
+			name:        "job of another file",
+			file:        "a.yaml",
+			content:     strings.Replace(files["a.yaml"], "pull-a", "pull-b", 1),
+			expectedErr: "duplicated presubmit jobs",
+		},
+		{
+			name:        "duplicated preset",
+			file:        "a.yaml",
+			content:     files["a.yaml"] + "presets:\n- labels:\n    preset-a: \"true\"\n  env:\n  - name: A\n    value: b\n- labels:\n    preset-a: \"true\"\n",
+			expectedErr: "duplicated preset",
+		},
+		{
+			name:        "unknown field",
+			file:        "a.yaml",
+			content:     strings.Replace(files["a.yaml"], "spec:", "spce:", 1),
+			expectedErr: "failed to parse the job config",
+		},
+		{
+			name:        "invalid job",
+			file:        "b.yaml",
+			content:     strings.Replace(files["b.yaml"], "interval: 1h", "interval: 1h\n  cron: '@daily'", 1),
+			expectedErr: "periodic-b",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cfg.ValidateJobConfigFile(filepath.Join(jobConfigPath, tc.file), []byte(tc.content))
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+			if jobs := cfg.AllStaticPresubmits(nil); len(jobs) != 2 {
+				t.Errorf("expected the config to keep its 2 presubmits, got %d", len(jobs))
+			}
+			if len(cfg.Presets) != 1 {
+				t.Errorf("expected the config to keep its preset, got %d", len(cfg.Presets))
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/kube"
 )
 
 const (
@@ -109,6 +110,11 @@ type JobBase struct {
 	// If this field is unspecified or false, a new pod will be created to replace
 	// the evicted one.
 	ErrorOnEviction bool `json:"error_on_eviction,omitempty"`
+	// SchedulingGates hold the ProwJobs of this job in the waiting state
+	// until every gate is cleared through Gangway or the
+	// prow.k8s.io/cleared-scheduling-gates annotation. Requires the
+	// scheduling-gates controller of prow-controller-manager.
+	SchedulingGates []string `json:"scheduling_gates,omitempty"`
 	// SourcePath contains the path where this job is defined
 	SourcePath string `json:"-"`
 	// Spec is the Kubernetes pod spec used if Agent is kubernetes.
@@ -123,6 +129,9 @@ type JobBase struct {
 	ReporterConfig *prowapi.ReporterConfig `json:"reporter_config,omitempty"`
 	// RerunAuthConfig specifies who can rerun the job
 	RerunAuthConfig *prowapi.RerunAuthConfig `json:"rerun_auth_config,omitempty"`
+	// RerunOverrides lists the fields of the job users allowed to rerun it
+	// may override when they rerun it through Deck.
+	RerunOverrides *prowapi.RerunOverrides `json:"rerun_overrides,omitempty"`
 	// Hidden defines if the job is hidden. If set to `true`, only Deck instances
 	// that have the flag `--hiddenOnly=true or `--show-hidden=true` set will show it.
 	// Presubmits and Postsubmits can also be set to hidden by
@@ -135,10 +144,73 @@ type JobBase struct {
 	// Works in parallel with MaxConcurrency and the limit is selected from the
 	// minimal setting of those two fields.
 	JobQueueName string `json:"job_queue_name,omitempty"`
+	// Owner describes who is responsible for this job. It is propagated to
+	// the labels and annotations of the ProwJobs created for this job.
+	Owner *JobOwner `json:"owner,omitempty"`
+	// JobClass is the name of the class of this job, which must be defined
+	// in plank.job_classes. It determines the nodes the job's pod is
+	// scheduled on.
+	JobClass string `json:"job_class,omitempty"`
+	// SLO is the service level objective of this job, tracked by the SLO
+	// reporter of crier.
+	SLO *JobSLO `json:"slo,omitempty"`
 
 	UtilityConfig
 }
 
+// JobOwner holds the contact information of the team responsible for a job.
+type JobOwner struct {
+	// Team is the name of the owning team. It is added as the
+	// prow.k8s.io/owner-team label, so it must be a valid label value.
+	Team string `json:"team,omitempty"`
+	// SlackChannel is the Slack channel the owning team can be reached in.
+	// The Slack reporter sends notifications for this job to this channel
+	// unless the job sets a channel in its reporter_config.
+	SlackChannel string `json:"slack_channel,omitempty"`
+	// EscalationURL points to a page describing how to escalate problems
+	// with this job, e.g. an on-call rotation.
+	EscalationURL string `json:"escalation_url,omitempty"`
+}
+
+// Labels returns the labels carrying the owner information.
+func (o *JobOwner) Labels() map[string]string {
+	labels := map[string]string{}
+	if o != nil && o.Team != "" {
+		labels[kube.OwnerTeamLabel] = o.Team
+	}
+	return labels
+}
+
+// Annotations returns the annotations carrying the owner information.
+func (o *JobOwner) Annotations() map[string]string {
+	annotations := map[string]string{}
+	if o == nil {
+		return annotations
+	}
+	if o.SlackChannel != "" {
+		annotations[kube.OwnerSlackChannelAnnotation] = o.SlackChannel
+	}
+	if o.EscalationURL != "" {
+		annotations[kube.OwnerEscalationURLAnnotation] = o.EscalationURL
+	}
+	return annotations
+}
+
+// JobOwnerFromMeta reconstructs the owner of a job from the labels and
+// annotations of a resource created for it. It returns nil if the resource
+// carries no owner information.
+func JobOwnerFromMeta(labels, annotations map[string]string) *JobOwner {
+	owner := &JobOwner{
+		Team:          labels[kube.OwnerTeamLabel],
+		SlackChannel:  annotations[kube.OwnerSlackChannelAnnotation],
+		EscalationURL: annotations[kube.OwnerEscalationURLAnnotation],
+	}
+	if *owner == (JobOwner{}) {
+		return nil
+	}
+	return owner
+}
+
 func (jb JobBase) GetName() string {
 	return jb.Name
 }
@@ -152,7 +224,7 @@ func (jb JobBase) GetAnnotations() map[string]string {
 }
 
 func (jb JobBase) HasPipelineRunSpec() bool {
-	if jb.TektonPipelineRunSpec != nil && jb.TektonPipelineRunSpec.V1Beta1 != nil {
+	if jb.TektonPipelineRunSpec != nil && (jb.TektonPipelineRunSpec.V1Beta1 != nil || jb.TektonPipelineRunSpec.InRepoPipeline != nil) {
 		return true
 	}
 	if jb.PipelineRunSpec != nil {
@@ -169,6 +241,9 @@ func (jb JobBase) GetPipelineRunSpec() (*pipelinev1.PipelineRunSpec, error) {
 	if found == nil && jb.PipelineRunSpec != nil {
 		found = jb.PipelineRunSpec
 	}
+	if found == nil && jb.TektonPipelineRunSpec != nil && jb.TektonPipelineRunSpec.InRepoPipeline != nil {
+		found = &pipelinev1.PipelineRunSpec{}
+	}
 	if found == nil {
 		return nil, errors.New("pipeline run spec not found")
 	}
@@ -264,6 +339,11 @@ type Postsubmit struct {
 	Reporter
 
 	JenkinsSpec *JenkinsSpec `json:"jenkins_spec,omitempty"`
+
+	// RequiresManualApproval creates the ProwJobs of this job in the held
+	// state: they only run once an authorized user approves them in Deck.
+	// Meant for jobs deploying to production.
+	RequiresManualApproval bool `json:"requires_manual_approval,omitempty"`
 }
 
 // Periodic runs on a timer.
@@ -281,11 +361,65 @@ type Periodic struct {
 	Cron string `json:"cron,omitempty"`
 	// Tags for config entries
 	Tags []string `json:"tags,omitempty"`
+	// CatchUp configures whether horologium runs the job for the cron
+	// windows it missed, e.g. while it was down for maintenance. Missed
+	// windows are skipped if unset. Only valid with cron.
+	CatchUp *CatchUp `json:"catch_up,omitempty"`
+	// RequiresManualApproval creates the ProwJobs of this job in the held
+	// state: they only run once an authorized user approves them in Deck.
+	// Horologium doesn't create the next run of the job while one is held.
+	RequiresManualApproval bool `json:"requires_manual_approval,omitempty"`
 
 	interval         time.Duration
 	minimum_interval time.Duration
 }
 
+// CatchUpPolicy is what horologium does about the missed cron windows of a
+// periodic.
+type CatchUpPolicy string
+
+const (
+	// CatchUpSkip skips missed windows, which is the default.
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpRunOnce runs the job once if any window was missed.
+	CatchUpRunOnce CatchUpPolicy = "run_once"
+	// CatchUpRunAll runs the job once for each missed window, up to
+	// max_runs of the latest ones.
+	CatchUpRunAll CatchUpPolicy = "run_all"
+)
+
+// CatchUp configures the catch-up of the cron windows of a periodic that
+// passed since its last run was created without horologium creating one,
+// because it was down or the previous run was still going on.
+type CatchUp struct {
+	// Policy is one of skip, run_once or run_all.
+	Policy CatchUpPolicy `json:"policy"`
+	// MaxRuns is the most runs created at once to catch up with run_all.
+	// Required for run_all.
+	MaxRuns int `json:"max_runs,omitempty"`
+}
+
+// Runs returns how many runs catch up on the given number of missed
+// windows.
+func (c *CatchUp) Runs(missed int) int {
+	switch {
+	case c == nil || missed == 0:
+		return 0
+	case c.Policy == CatchUpRunOnce:
+		return 1
+	case c.Policy == CatchUpRunAll && missed > c.MaxRuns:
+		return c.MaxRuns
+	case c.Policy == CatchUpRunAll:
+		return missed
+	}
+	return 0
+}
+
+// Enabled returns whether missed windows are caught up on.
+func (c *CatchUp) Enabled() bool {
+	return c != nil && c.Policy != "" && c.Policy != CatchUpSkip
+}
+
 // JenkinsSpec holds optional Jenkins job config
 type JenkinsSpec struct {
 	// Job is managed by the GH branch source plugin
@@ -340,8 +474,14 @@ type RegexpChangeMatcher struct {
 	// If all files in the changeset match this regex, the job will be skipped.
 	// In other words, this is the negation of RunIfChanged.
 	// Additionally AlwaysRun is mutually exclusive with SkipIfOnlyChanged.
-	SkipIfOnlyChanged string          `json:"skip_if_only_changed,omitempty"`
-	reChanges         *CopyableRegexp // from RunIfChanged xor SkipIfOnlyChanged
+	SkipIfOnlyChanged string `json:"skip_if_only_changed,omitempty"`
+	// RunIfChangedExpression defines an expression over the changed files that
+	// selects whether this job should be triggered, for cases that cannot be
+	// expressed with a single regex. See ChangeExpression for the syntax.
+	// Additionally AlwaysRun, RunIfChanged and SkipIfOnlyChanged are mutually
+	// exclusive with RunIfChangedExpression.
+	RunIfChangedExpression *ChangeExpression `json:"run_if_changed_expression,omitempty"`
+	reChanges              *CopyableRegexp   // from RunIfChanged xor SkipIfOnlyChanged
 }
 
 type Reporter struct {
@@ -403,7 +543,7 @@ func (br Brancher) Intersects(other Brancher) bool {
 
 // CouldRun determines if its possible for a set of changes to trigger this condition
 func (cm RegexpChangeMatcher) CouldRun() bool {
-	return cm.RunIfChanged != "" || cm.SkipIfOnlyChanged != ""
+	return cm.RunIfChanged != "" || cm.SkipIfOnlyChanged != "" || cm.RunIfChangedExpression != nil
 }
 
 // ShouldRun determines if we can know for certain that the job should run. We can either
@@ -421,8 +561,12 @@ func (cm RegexpChangeMatcher) ShouldRun(changes ChangedFilesProvider) (determine
 }
 
 // RunsAgainstChanges returns true if any of the changed input paths match the run_if_changed regex;
-// OR if any of the changed input paths *don't* match the skip_if_only_changed regex.
+// OR if any of the changed input paths *don't* match the skip_if_only_changed regex;
+// OR if the changed input paths satisfy the run_if_changed_expression.
 func (cm RegexpChangeMatcher) RunsAgainstChanges(changes []string) bool {
+	if cm.RunIfChangedExpression != nil {
+		return cm.RunIfChangedExpression.Matches(changes)
+	}
 	for _, change := range changes {
 		// RunIfChanged triggers the run if *any* change matches the supplied regex.
 		if cm.RunIfChanged != "" && cm.reChanges.MatchString(change) {
@@ -707,5 +851,8 @@ func ClearCompiledRegexes(presubmits []Presubmit) {
 		presubmits[i].Brancher.re = nil
 		presubmits[i].Brancher.reSkip = nil
 		presubmits[i].RegexpChangeMatcher.reChanges = nil
+		if presubmits[i].RegexpChangeMatcher.RunIfChangedExpression != nil {
+			presubmits[i].RegexpChangeMatcher.RunIfChangedExpression.clearCompiled()
+		}
 	}
 }
@@ -800,6 +800,38 @@ func TestPresubmitShouldRun(t *testing.T) {
 			fileChanges: []string{"onefile", "two-file", "pkg/controller/three_file.go"},
 			expectedRun: true,
 		},
+		{
+			name: "job with run_if_changed_expression matching should run",
+			job: Presubmit{
+				Trigger:      `(?m)^/test (?:.*? )?foo(?: .*?)?$`,
+				RerunCommand: "/test foo",
+				RegexpChangeMatcher: RegexpChangeMatcher{
+					RunIfChangedExpression: &ChangeExpression{AllOf: []ChangeExpression{
+						{AnyChanged: []string{"api/**"}},
+						{Not: &ChangeExpression{OnlyChanged: []string{"**/*.md"}}},
+					}},
+				},
+			},
+			ref:         "master",
+			fileChanges: []string{"api/v1/types.go", "api/README.md"},
+			expectedRun: true,
+		},
+		{
+			name: "job with run_if_changed_expression not matching should not run",
+			job: Presubmit{
+				Trigger:      `(?m)^/test (?:.*? )?foo(?: .*?)?$`,
+				RerunCommand: "/test foo",
+				RegexpChangeMatcher: RegexpChangeMatcher{
+					RunIfChangedExpression: &ChangeExpression{AllOf: []ChangeExpression{
+						{AnyChanged: []string{"api/**"}},
+						{Not: &ChangeExpression{OnlyChanged: []string{"**/*.md"}}},
+					}},
+				},
+			},
+			ref:         "master",
+			fileChanges: []string{"api/README.md"},
+			expectedRun: false,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultLensMaxArtifactBytes is how many bytes of artifacts are pushed
+	// to a lens per request unless configured otherwise.
+	DefaultLensMaxArtifactBytes = 10 * 1024 * 1024
+	// DefaultLensTimeout is how long a discovered lens may take to respond
+	// unless configured otherwise.
+	DefaultLensTimeout = 30 * time.Second
+)
+
+// LensRegistry is a lens server whose lenses Deck discovers from its
+// registry endpoint.
+type LensRegistry struct {
+	// URL is the registry endpoint of the lens server, which lists the
+	// lenses it serves.
+	URL string `json:"url"`
+	// MaxArtifactBytes caps how many bytes of artifacts are pushed to a lens
+	// of the registry per request. Defaults to 10 MiB.
+	MaxArtifactBytes int64 `json:"max_artifact_bytes,omitempty"`
+	// Timeout caps how long a lens of the registry may take to respond.
+	// Defaults to 30s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+func (s *Spyglass) defaultAndValidateRemoteLenses() error {
+	for i := range s.LensRegistries {
+		registry := &s.LensRegistries[i]
+		u, err := url.Parse(registry.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("deck.spyglass.lens_registries[%d]: url %q must be an absolute http or https URL", i, registry.URL)
+		}
+		if registry.MaxArtifactBytes < 0 {
+			return fmt.Errorf("deck.spyglass.lens_registries[%d]: max_artifact_bytes can't be negative", i)
+		}
+		if registry.MaxArtifactBytes == 0 {
+			registry.MaxArtifactBytes = DefaultLensMaxArtifactBytes
+		}
+		if registry.Timeout == nil {
+			registry.Timeout = &metav1.Duration{Duration: DefaultLensTimeout}
+		}
+		if registry.Timeout.Duration <= 0 {
+			return fmt.Errorf("deck.spyglass.lens_registries[%d]: timeout must be positive", i)
+		}
+	}
+	for i := range s.Lenses {
+		lens := &s.Lenses[i]
+		remote := lens.RemoteConfig
+		if remote == nil {
+			continue
+		}
+		switch remote.ProtocolVersion {
+		case "", LensProtocolVersionV1:
+		default:
+			return fmt.Errorf("lens %q: unknown protocol_version %q", lens.Lens.Name, remote.ProtocolVersion)
+		}
+		if remote.MaxArtifactBytes < 0 {
+			return fmt.Errorf("lens %q: max_artifact_bytes can't be negative", lens.Lens.Name)
+		}
+		if remote.ProtocolVersion == LensProtocolVersionV1 && remote.MaxArtifactBytes == 0 {
+			remote.MaxArtifactBytes = DefaultLensMaxArtifactBytes
+		}
+		if remote.Timeout != nil && remote.Timeout.Duration <= 0 {
+			return fmt.Errorf("lens %q: timeout must be positive", lens.Lens.Name)
+		}
+	}
+	return nil
+}
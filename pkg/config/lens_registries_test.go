@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefaultAndValidateRemoteLenses(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spyglass    Spyglass
+		expected    Spyglass
+		expectedErr bool
+	}{
+		{
+			name: "registry and v1 lens are defaulted",
+			spyglass: Spyglass{
+				LensRegistries: []LensRegistry{{URL: "https://lenses.example.com/lenses"}},
+				Lenses:         []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{ProtocolVersion: "v1"}}},
+			},
+			expected: Spyglass{
+				LensRegistries: []LensRegistry{{URL: "https://lenses.example.com/lenses", MaxArtifactBytes: DefaultLensMaxArtifactBytes, Timeout: &metav1.Duration{Duration: DefaultLensTimeout}}},
+				Lenses:         []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{ProtocolVersion: "v1", MaxArtifactBytes: DefaultLensMaxArtifactBytes}}},
+			},
+		},
+		{
+			name: "unversioned lens isn't defaulted",
+			spyglass: Spyglass{
+				Lenses: []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{Endpoint: "http://echo"}}},
+			},
+			expected: Spyglass{
+				Lenses: []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{Endpoint: "http://echo"}}},
+			},
+		},
+		{
+			name:        "relative registry URL",
+			spyglass:    Spyglass{LensRegistries: []LensRegistry{{URL: "/lenses"}}},
+			expectedErr: true,
+		},
+		{
+			name:        "registry URL with another scheme",
+			spyglass:    Spyglass{LensRegistries: []LensRegistry{{URL: "ftp://lenses.example.com/lenses"}}},
+			expectedErr: true,
+		},
+		{
+			name:        "negative registry max_artifact_bytes",
+			spyglass:    Spyglass{LensRegistries: []LensRegistry{{URL: "https://lenses.example.com/lenses", MaxArtifactBytes: -1}}},
+			expectedErr: true,
+		},
+		{
+			name:        "zero registry timeout",
+			spyglass:    Spyglass{LensRegistries: []LensRegistry{{URL: "https://lenses.example.com/lenses", Timeout: &metav1.Duration{}}}},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown protocol version",
+			spyglass:    Spyglass{Lenses: []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{ProtocolVersion: "v2"}}}},
+			expectedErr: true,
+		},
+		{
+			name:        "negative lens timeout",
+			spyglass:    Spyglass{Lenses: []LensFileConfig{{Lens: LensConfig{Name: "echo"}, RemoteConfig: &LensRemoteConfig{Timeout: &metav1.Duration{Duration: -time.Second}}}}},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.spyglass.defaultAndValidateRemoteLenses()
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, tc.spyglass); diff != "" {
+				t.Errorf("unexpected spyglass config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
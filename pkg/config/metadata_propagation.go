@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// MetadataPropagation controls which labels and annotations of ProwJobs are
+// copied to their pods, artifacts and reports, e.g. to attach billing or
+// team tags that flow through the whole pipeline.
+//
+// Keys are matched against patterns in which * matches any sequence of
+// characters, like "billing.example.com/*".
+type MetadataPropagation struct {
+	// Pods are the labels and annotations that are copied to the pods of
+	// ProwJobs. Defaults to all of them. The labels and annotations Prow
+	// itself sets, like created-by-prow and prow.k8s.io/*, are always
+	// copied, as Prow relies on them to find the pods.
+	Pods *PropagationPolicy `json:"pods,omitempty"`
+	// Artifacts are the labels and annotations that are added to the
+	// metadata of the started.json and finished.json uploaded by crier.
+	// Defaults to none.
+	Artifacts *PropagationPolicy `json:"artifacts,omitempty"`
+	// Reports are the labels and annotations that are added to the reports
+	// of crier that carry metadata, like Pub/Sub messages. Defaults to none.
+	Reports *PropagationPolicy `json:"reports,omitempty"`
+	// Deny are the patterns of sensitive keys that are never copied,
+	// regardless of the policies. They are also removed from the
+	// prowjob.json uploaded by crier. Keys that Prow itself sets can't be
+	// denied.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// PropagationPolicy selects labels and annotations by the patterns of their
+// keys.
+type PropagationPolicy struct {
+	Labels      []string `json:"labels,omitempty"`
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// matchesKey tells whether the pattern matches the key. * in the pattern
+// matches any sequence of characters, including slashes.
+func matchesKey(pattern, key string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == key
+	}
+	if !strings.HasPrefix(key, parts[0]) {
+		return false
+	}
+	key = key[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(key, part)
+		if i < 0 {
+			return false
+		}
+		key = key[i+len(part):]
+	}
+	return strings.HasSuffix(key, parts[len(parts)-1])
+}
+
+func matchesAnyKey(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matchesKey(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProwKey tells whether Prow itself sets the label or annotation.
+func isProwKey(key string) bool {
+	return key == kube.CreatedByProw || strings.HasPrefix(key, "prow.k8s.io/")
+}
+
+// filter returns the entries of values whose key matches any of the
+// patterns and isn't denied.
+func (p MetadataPropagation) filter(values map[string]string, patterns []string, all bool) map[string]string {
+	filtered := map[string]string{}
+	for key, value := range values {
+		if !isProwKey(key) && matchesAnyKey(p.Deny, key) {
+			continue
+		}
+		if all || matchesAnyKey(patterns, key) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// selected returns the entries of values whose key matches any of the
+// patterns and isn't denied, or nil if there are none.
+func (p MetadataPropagation) selected(values map[string]string, patterns []string) map[string]string {
+	filtered := p.filter(values, patterns, false)
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+// PodLabelsAndAnnotations filters the labels and annotations of a pod built
+// for a ProwJob, keeping the ones Prow itself sets.
+func (p MetadataPropagation) PodLabelsAndAnnotations(labels, annotations map[string]string) (map[string]string, map[string]string) {
+	policy := p.Pods
+	all := policy == nil
+	if all {
+		policy = &PropagationPolicy{}
+	}
+	filteredLabels := p.filter(labels, policy.Labels, all)
+	filteredAnnotations := p.filter(annotations, policy.Annotations, all)
+	for key, value := range labels {
+		if isProwKey(key) {
+			filteredLabels[key] = value
+		}
+	}
+	for key, value := range annotations {
+		if isProwKey(key) {
+			filteredAnnotations[key] = value
+		}
+	}
+	return filteredLabels, filteredAnnotations
+}
+
+// ArtifactLabelsAndAnnotations returns the labels and annotations of the
+// ProwJob that are added to the metadata of its artifacts.
+func (p MetadataPropagation) ArtifactLabelsAndAnnotations(pj *prowapi.ProwJob) (map[string]string, map[string]string) {
+	if p.Artifacts == nil {
+		return nil, nil
+	}
+	return p.selected(pj.Labels, p.Artifacts.Labels), p.selected(pj.Annotations, p.Artifacts.Annotations)
+}
+
+// ReportLabelsAndAnnotations returns the labels and annotations of the
+// ProwJob that are added to its reports.
+func (p MetadataPropagation) ReportLabelsAndAnnotations(pj *prowapi.ProwJob) (map[string]string, map[string]string) {
+	if p.Reports == nil {
+		return nil, nil
+	}
+	return p.selected(pj.Labels, p.Reports.Labels), p.selected(pj.Annotations, p.Reports.Annotations)
+}
+
+// WithoutDenied returns a copy of the ProwJob without the denied labels and
+// annotations.
+func (p MetadataPropagation) WithoutDenied(pj *prowapi.ProwJob) *prowapi.ProwJob {
+	pj = pj.DeepCopy()
+	if len(p.Deny) == 0 {
+		return pj
+	}
+	if pj.Labels != nil {
+		pj.Labels = p.filter(pj.Labels, nil, true)
+	}
+	if pj.Annotations != nil {
+		pj.Annotations = p.filter(pj.Annotations, nil, true)
+	}
+	return pj
+}
+
+func (p MetadataPropagation) validate() error {
+	var patterns []string
+	for _, policy := range []*PropagationPolicy{p.Pods, p.Artifacts, p.Reports} {
+		if policy != nil {
+			patterns = append(append(patterns, policy.Labels...), policy.Annotations...)
+		}
+	}
+	for _, pattern := range append(patterns, p.Deny...) {
+		if pattern == "" {
+			return errors.New("prowjob_metadata_propagation: patterns must not be empty")
+		}
+	}
+	for _, pattern := range p.Deny {
+		if pattern == "*" {
+			return fmt.Errorf("prowjob_metadata_propagation: deny pattern %q would deny every key, use the policies to select keys instead", pattern)
+		}
+	}
+	return nil
+}
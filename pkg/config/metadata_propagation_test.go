@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestMatchesKey(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{pattern: "team", key: "team", expected: true},
+		{pattern: "team", key: "teams", expected: false},
+		{pattern: "billing.example.com/*", key: "billing.example.com/cost-center", expected: true},
+		{pattern: "billing.example.com/*", key: "example.com/cost-center", expected: false},
+		{pattern: "*/token", key: "vault.example.com/token", expected: true},
+		{pattern: "*secret*", key: "example.com/client-secret-name", expected: true},
+		{pattern: "a*b*c", key: "abc", expected: true},
+		{pattern: "a*b*c", key: "acb", expected: false},
+		{pattern: "*", key: "anything/at/all", expected: true},
+	}
+	for _, tc := range testCases {
+		if actual := matchesKey(tc.pattern, tc.key); actual != tc.expected {
+			t.Errorf("matchesKey(%q, %q): expected %t, got %t", tc.pattern, tc.key, tc.expected, actual)
+		}
+	}
+}
+
+func TestMetadataPropagation(t *testing.T) {
+	pj := &prowapi.ProwJob{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{
+			"created-by-prow":          "true",
+			"prow.k8s.io/job":          "job",
+			"billing.example.com/team": "infra",
+			"example.com/token":        "hunter2",
+			"other":                    "value",
+		},
+		Annotations: map[string]string{
+			"prow.k8s.io/job":                 "job",
+			"billing.example.com/cost-center": "1234",
+		},
+	}}
+	testCases := []struct {
+		name                string
+		propagation         MetadataPropagation
+		expectedPodLabels   map[string]string
+		expectedPodAnnots   map[string]string
+		expectedReportLabel map[string]string
+		expectedReportAnnot map[string]string
+		expectedJobLabels   map[string]string
+	}{
+		{
+			name:              "by default everything goes to pods and nothing to reports",
+			expectedPodLabels: pj.Labels,
+			expectedPodAnnots: pj.Annotations,
+			expectedJobLabels: pj.Labels,
+		},
+		{
+			name: "policies select keys and deny wins, except for Prow's keys",
+			propagation: MetadataPropagation{
+				Pods:    &PropagationPolicy{Labels: []string{"billing.example.com/*", "example.com/*"}},
+				Reports: &PropagationPolicy{Labels: []string{"*"}, Annotations: []string{"billing.example.com/*"}},
+				Deny:    []string{"*token*", "prow.k8s.io/*"},
+			},
+			expectedPodLabels: map[string]string{
+				"created-by-prow":          "true",
+				"prow.k8s.io/job":          "job",
+				"billing.example.com/team": "infra",
+			},
+			expectedPodAnnots: map[string]string{"prow.k8s.io/job": "job"},
+			expectedReportLabel: map[string]string{
+				"created-by-prow":          "true",
+				"prow.k8s.io/job":          "job",
+				"billing.example.com/team": "infra",
+				"other":                    "value",
+			},
+			expectedReportAnnot: map[string]string{"billing.example.com/cost-center": "1234"},
+			expectedJobLabels: map[string]string{
+				"created-by-prow":          "true",
+				"prow.k8s.io/job":          "job",
+				"billing.example.com/team": "infra",
+				"other":                    "value",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, annotations := tc.propagation.PodLabelsAndAnnotations(pj.Labels, pj.Annotations)
+			if diff := cmp.Diff(tc.expectedPodLabels, labels); diff != "" {
+				t.Errorf("unexpected pod labels (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedPodAnnots, annotations); diff != "" {
+				t.Errorf("unexpected pod annotations (-want +got):\n%s", diff)
+			}
+			labels, annotations = tc.propagation.ReportLabelsAndAnnotations(pj)
+			if diff := cmp.Diff(tc.expectedReportLabel, labels); diff != "" {
+				t.Errorf("unexpected report labels (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedReportAnnot, annotations); diff != "" {
+				t.Errorf("unexpected report annotations (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedJobLabels, tc.propagation.WithoutDenied(pj).Labels); diff != "" {
+				t.Errorf("unexpected labels of the job without denied keys (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetadataPropagationValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		propagation MetadataPropagation
+		expectErr   bool
+	}{
+		{
+			name:        "valid",
+			propagation: MetadataPropagation{Artifacts: &PropagationPolicy{Labels: []string{"team"}}, Deny: []string{"*token*"}},
+		},
+		{
+			name:        "empty pattern",
+			propagation: MetadataPropagation{Reports: &PropagationPolicy{Annotations: []string{""}}},
+			expectErr:   true,
+		},
+		{
+			name:        "deny everything",
+			propagation: MetadataPropagation{Deny: []string{"*"}},
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.propagation.validate(); (err != nil) != tc.expectErr {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
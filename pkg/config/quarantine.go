@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// Quarantine configures the tests that maintainers quarantine from Deck.
+// Tests can also be quarantined in the config with quarantined_tests in the
+// decoration config of the jobs.
+type Quarantine struct {
+	// StoragePath is where the quarantined tests of every repo are stored,
+	// like gs://bucket/quarantine. Deck writes to it and plank adds the
+	// tests quarantined for the repo of a job to its pod.
+	StoragePath string `json:"storage_path"`
+	// MaxDuration is how long tests can be quarantined from Deck at most.
+	// Defaults to 30 days.
+	MaxDuration *metav1.Duration `json:"max_duration,omitempty"`
+}
+
+// DefaultAndValidate defaults and validates the quarantine config.
+func (q *Quarantine) DefaultAndValidate() error {
+	if q.StoragePath == "" {
+		return errors.New("storage_path must be set")
+	}
+	if _, _, _, err := providers.ParseStoragePath(q.StoragePath); err != nil {
+		return fmt.Errorf("invalid storage_path: %w", err)
+	}
+	if q.MaxDuration == nil {
+		q.MaxDuration = &metav1.Duration{Duration: 30 * 24 * time.Hour}
+	}
+	if q.MaxDuration.Duration <= 0 {
+		return fmt.Errorf("max_duration must be positive, not %s", q.MaxDuration.Duration)
+	}
+	return nil
+}
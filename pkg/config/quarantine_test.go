@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuarantineDefaultAndValidate(t *testing.T) {
+	testCases := []struct {
+		name                string
+		quarantine          Quarantine
+		expectedMaxDuration time.Duration
+		expectedErr         string
+	}{
+		{
+			name:                "max duration defaults to 30 days",
+			quarantine:          Quarantine{StoragePath: "gs://bucket/quarantine"},
+			expectedMaxDuration: 30 * 24 * time.Hour,
+		},
+		{
+			name:                "max duration is kept",
+			quarantine:          Quarantine{StoragePath: "s3://bucket/quarantine", MaxDuration: &metav1.Duration{Duration: time.Hour}},
+			expectedMaxDuration: time.Hour,
+		},
+		{
+			name:        "storage path is required",
+			quarantine:  Quarantine{},
+			expectedErr: "storage_path must be set",
+		},
+		{
+			name:        "storage path needs a bucket",
+			quarantine:  Quarantine{StoragePath: "quarantine"},
+			expectedErr: `invalid storage_path: could not find bucket in storagePath "quarantine"`,
+		},
+		{
+			name:        "max duration must be positive",
+			quarantine:  Quarantine{StoragePath: "gs://bucket/quarantine", MaxDuration: &metav1.Duration{}},
+			expectedErr: "max_duration must be positive, not 0s",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.quarantine.DefaultAndValidate()
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if actualErr != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, actualErr)
+			}
+			if err == nil && tc.quarantine.MaxDuration.Duration != tc.expectedMaxDuration {
+				t.Errorf("expected max duration %s, got %s", tc.expectedMaxDuration, tc.quarantine.MaxDuration.Duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/genyaml"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// DocSources are the globs, relative to the root of the repo, of the Go files
+// documenting the config types.
+var DocSources = []string{
+	"pkg/config/*.go",
+	"pkg/apis/prowjobs/v1/*.go",
+	"pkg/plugins/*.go",
+}
+
+var rootTypes = map[Kind]reflect.Type{
+	ProwConfig:   reflect.TypeOf(config.Config{}),
+	JobConfig:    reflect.TypeOf(config.JobConfig{}),
+	PluginConfig: reflect.TypeOf(plugins.Configuration{}),
+	ProwYAML:     reflect.TypeOf(config.ProwYAML{}),
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// override returns the schema of the types whose JSON format is defined by
+// their unmarshaler rather than their structure.
+func (g *generator) override(t reflect.Type) (*Schema, bool) {
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(metav1.Time{}), reflect.TypeOf(metav1.Duration{}), reflect.TypeOf(config.TideBranchMergeType{}):
+		return &Schema{Type: "string"}, true
+	case reflect.TypeOf(prowapi.Duration{}), reflect.TypeOf(intstr.IntOrString{}):
+		return &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "integer"}}}, true
+	case reflect.TypeOf(resource.Quantity{}):
+		return &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "number"}}}, true
+	case reflect.TypeOf(plugins.ConfigUpdater{}):
+		// The unmarshaler only exists to run the validation.
+		return g.definition(t), true
+	case reflect.TypeOf(plugins.Plugins{}):
+		// The plugins of orgs and repos may still be listed in the deprecated
+		// format, without any further config.
+		return &Schema{Type: "object", AdditionalProperties: &Schema{AnyOf: []*Schema{
+			g.schemaFor(reflect.TypeOf(plugins.OrgPlugins{})),
+			{Type: "array", Items: &Schema{Type: "string"}},
+		}}}, true
+	}
+	return nil, false
+}
+
+// Generate generates the JSON Schema of the config of the kind, describing
+// the fields with their comments in the comment map, if any.
+func Generate(kind Kind, comments *genyaml.CommentMap) (*Schema, error) {
+	root, ok := rootTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+	g := &generator{comments: comments, definitions: map[string]*Schema{}}
+	s := g.schemaFor(root)
+	s.Schema = draft07
+	s.Definitions = g.definitions
+	return s, nil
+}
+
+type generator struct {
+	comments    *genyaml.CommentMap
+	definitions map[string]*Schema
+}
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := g.override(t); ok {
+		return s
+	}
+	if implements(t, jsonUnmarshaler) {
+		// Maps and slices with an unmarshaler usually accept a legacy format on
+		// top, but structs can be anything, so don't restrict them.
+		if t.Kind() == reflect.Struct {
+			return &Schema{}
+		}
+	} else if implements(t, textUnmarshaler) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Bytes are base64 encoded.
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.definition(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// definition adds the struct to the definitions, unless it already is, and
+// returns a reference to it.
+func (g *generator) definition(t reflect.Type) *Schema {
+	name := strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+	ref := &Schema{Ref: "#/definitions/" + name}
+	if _, ok := g.definitions[name]; ok {
+		return ref
+	}
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &Schema{forbidden: true}}
+	// Added before the fields, so that recursive types refer to it.
+	g.definitions[name] = s
+	g.addFields(s, t)
+	return ref
+}
+
+// addFields adds the fields of the struct, as encoding/json sees them, to
+// the properties.
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if tag == "-" {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(s, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schemaFor(field.Type)
+		if g.comments != nil {
+			property.Description = g.comments.Doc(t.Name(), name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+// Marshal generates the JSON Schema of the config of the kind, as stored with
+// Prow.
+func Marshal(kind Kind, comments *genyaml.CommentMap) ([]byte, error) {
+	s, err := Generate(kind, comments)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the schema for %q: %w", kind, err)
+	}
+	return append(raw, '\n'), nil
+}
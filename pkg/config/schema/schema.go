@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema provides JSON Schemas of the Prow config files, generated
+// from the Go types, so that editors and CI of config repos can autocomplete
+// and validate them.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Kind is a kind of config file a schema exists for.
+type Kind string
+
+const (
+	// ProwConfig is the main config of Prow, which may also contain jobs.
+	ProwConfig Kind = "prow-config"
+	// JobConfig is a file of the job config dir.
+	JobConfig Kind = "job-config"
+	// PluginConfig is the config of hook and its plugins.
+	PluginConfig Kind = "plugin-config"
+	// ProwYAML is the .prow.yaml, or a file of the .prow dir, of a repo.
+	ProwYAML Kind = "prow-yaml"
+)
+
+// Kinds are all kinds of config files a schema exists for.
+var Kinds = []Kind{ProwConfig, JobConfig, PluginConfig, ProwYAML}
+
+// FileName returns the name of the file the schema of the kind is stored in.
+func (k Kind) FileName() string {
+	return string(k) + ".schema.json"
+}
+
+//go:embed *.schema.json
+var schemas embed.FS
+
+// Raw returns the JSON Schema of the kind as stored with Prow.
+func Raw(kind Kind) ([]byte, error) {
+	raw, err := schemas.ReadFile(kind.FileName())
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %q: %w", kind, err)
+	}
+	return raw, nil
+}
+
+// Get returns the parsed JSON Schema of the kind.
+func Get(kind Kind) (*Schema, error) {
+	raw, err := Raw(kind)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the schema for %q: %w", kind, err)
+	}
+	return &s, nil
+}
+
+// ValidateYAML validates the YAML document against the schema of the kind.
+func ValidateYAML(kind Kind, raw []byte) error {
+	s, err := Get(kind)
+	if err != nil {
+		return err
+	}
+	return s.ValidateYAML(raw)
+}
+
+const draft07 = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema (draft-07), limited to the keywords needed to
+// describe the config types.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+
+	// forbidden is the false schema, which no value is valid against.
+	forbidden bool
+}
+
+type plainSchema Schema
+
+// MarshalJSON marshals the false schema as false.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.forbidden {
+		return []byte("false"), nil
+	}
+	return json.Marshal((*plainSchema)(s))
+}
+
+// UnmarshalJSON unmarshals the boolean schemas as well.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(b, &allowed); err == nil {
+		*s = Schema{forbidden: !allowed}
+		return nil
+	}
+	return json.Unmarshal(b, (*plainSchema)(s))
+}
+
+// ValidateYAML validates the YAML document against the schema and returns an
+// aggregate of all violations.
+func (s *Schema) ValidateYAML(raw []byte) error {
+	j, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(j, &value); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return utilerrors.NewAggregate(s.Validate(value))
+}
+
+// Validate validates the value, as decoded from JSON into an interface{},
+// against the schema.
+func (s *Schema) Validate(value interface{}) []error {
+	v := &validator{root: s}
+	v.validate(s, value, "")
+	return v.errs
+}
+
+type validator struct {
+	root *Schema
+	errs []error
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "<root>"
+	}
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) resolve(ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, "#/definitions/")
+	if !ok {
+		return nil
+	}
+	name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+	return v.root.Definitions[name]
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) {
+	if s.forbidden {
+		v.errorf(path, "no value is allowed")
+		return
+	}
+	if s.Ref != "" {
+		resolved := v.resolve(s.Ref)
+		if resolved == nil {
+			v.errorf(path, "unresolvable reference %q in the schema", s.Ref)
+			return
+		}
+		v.validate(resolved, value, path)
+		return
+	}
+	// Fields that are present but empty in YAML decode to nil, which the
+	// config loading accepts for any type.
+	if value == nil {
+		return
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, option := range s.AnyOf {
+			attempt := &validator{root: v.root}
+			if attempt.validate(option, value, path); len(attempt.errs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.errorf(path, "%s does not match any of the allowed formats", describe(value))
+		}
+	}
+	switch s.Type {
+	case "":
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "expected an object, got %s", describe(value))
+			return
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if property, ok := s.Properties[key]; ok {
+				v.validate(property, object[key], childPath)
+			} else if s.AdditionalProperties != nil && s.AdditionalProperties.forbidden {
+				v.errorf(path, "unknown field %q", key)
+			} else if s.AdditionalProperties != nil {
+				v.validate(s.AdditionalProperties, object[key], childPath)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			v.errorf(path, "expected an array, got %s", describe(value))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			v.errorf(path, "expected a string, got %s", describe(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.errorf(path, "expected a boolean, got %s", describe(value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			v.errorf(path, "expected a number, got %s", describe(value))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			v.errorf(path, "expected an integer, got %s", describe(value))
+		}
+	default:
+		v.errorf(path, "unsupported type %q in the schema", s.Type)
+	}
+}
+
+// describe describes a value for error messages.
+func describe(value interface{}) string {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/prow/pkg/genyaml"
+)
+
+func TestSchemasUpToDate(t *testing.T) {
+	var inputFiles []string
+	for _, goGlob := range DocSources {
+		files, err := filepath.Glob(filepath.Join("..", "..", "..", goGlob))
+		if err != nil {
+			t.Fatalf("failed to glob %s: %v", goGlob, err)
+		}
+		inputFiles = append(inputFiles, files...)
+	}
+	commentMap, err := genyaml.NewCommentMap(nil, inputFiles...)
+	if err != nil {
+		t.Fatalf("failed to construct comment map: %v", err)
+	}
+	for _, kind := range Kinds {
+		expected, err := Marshal(kind, commentMap)
+		if err != nil {
+			t.Fatalf("failed to generate schema for %s: %v", kind, err)
+		}
+		actual, err := Raw(kind)
+		if err != nil {
+			t.Fatalf("failed to read schema for %s: %v", kind, err)
+		}
+		if diff := cmp.Diff(string(expected), string(actual)); diff != "" {
+			t.Errorf("schema for %s is outdated, run hack/make-rules/update/codegen.sh: %s", kind, diff)
+		}
+	}
+}
+
+func TestValidateYAML(t *testing.T) {
+	testCases := []struct {
+		name           string
+		kind           Kind
+		yaml           string
+		expectedErrors []string
+	}{
+		{
+			name: "valid prow config",
+			kind: ProwConfig,
+			yaml: `
+tide:
+  sync_period: 2m
+  merge_method:
+    kubernetes/test-infra: squash
+  queries:
+  - repos:
+    - kubernetes/test-infra
+    labels:
+    - lgtm
+periodics:
+- name: ci-foo
+  interval: 1h
+  decorate: true
+  spec:
+    containers:
+    - image: alpine
+      resources:
+        requests:
+          cpu: 1
+          memory: 1Gi
+`,
+		},
+		{
+			name: "fields empty in YAML are allowed",
+			kind: ProwConfig,
+			yaml: `
+tide:
+  queries:
+`,
+		},
+		{
+			name: "unknown fields and mismatched types are reported",
+			kind: ProwConfig,
+			yaml: `
+tide:
+  queries:
+  - repos: kubernetes/test-infra
+    lables:
+    - lgtm
+plank:
+  max_concurrency: 1.5
+`,
+			expectedErrors: []string{
+				`plank.max_concurrency: expected an integer, got 1.5`,
+				`tide.queries[0]: unknown field "lables"`,
+				`tide.queries[0].repos: expected an array, got string "kubernetes/test-infra"`,
+			},
+		},
+		{
+			name: "durations can be integers",
+			kind: ProwYAML,
+			yaml: `
+presubmits:
+- name: pull-foo
+  decoration_config:
+    timeout: 7200000000000
+`,
+		},
+		{
+			name: "plugins can be listed in the deprecated format",
+			kind: PluginConfig,
+			yaml: `
+plugins:
+  kubernetes:
+  - lgtm
+  kubernetes/test-infra:
+    plugins:
+    - approve
+`,
+		},
+		{
+			name: "invalid plugins",
+			kind: PluginConfig,
+			yaml: `
+plugins:
+  kubernetes: lgtm
+`,
+			expectedErrors: []string{`plugins.kubernetes: string "lgtm" does not match any of the allowed formats`},
+		},
+		{
+			name: "jobs of .prow.yaml",
+			kind: ProwYAML,
+			yaml: `
+presubmits:
+- name: pull-foo
+  always_run: yes
+periodics:
+- name: ci-foo
+`,
+			expectedErrors: []string{`<root>: unknown field "periodics"`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actualErrors []string
+			if err := ValidateYAML(tc.kind, []byte(tc.yaml)); err != nil {
+				for _, err := range err.(utilerrors.Aggregate).Errors() {
+					actualErrors = append(actualErrors, err.Error())
+				}
+			}
+			if diff := cmp.Diff(tc.expectedErrors, actualErrors); diff != "" {
+				t.Errorf("unexpected errors (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	for _, kind := range Kinds {
+		s, err := Get(kind)
+		if err != nil {
+			t.Fatalf("failed to get schema for %s: %v", kind, err)
+		}
+		raw, err := Raw(kind)
+		if err != nil {
+			t.Fatalf("failed to read schema for %s: %v", kind, err)
+		}
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			t.Fatalf("failed to marshal schema for %s: %v", kind, err)
+		}
+		if diff := cmp.Diff(string(raw), string(out)+"\n"); diff != "" {
+			t.Errorf("schema for %s changed when parsed: %s", kind, diff)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+var jobConfigShardMetrics = struct {
+	stale  *prometheus.GaugeVec
+	errors *prometheus.CounterVec
+}{
+	stale: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_job_config_shard_stale",
+		Help: "Whether the last version of the job config shard that could be read is used because its files can't be read, by shard.",
+	}, []string{
+		"shard",
+	}),
+	errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_job_config_shard_errors_total",
+		Help: "Count of failures to read the files of a job config shard, by shard.",
+	}, []string{
+		"shard",
+	}),
+}
+
+func init() {
+	prometheus.MustRegister(jobConfigShardMetrics.stale)
+	prometheus.MustRegister(jobConfigShardMetrics.errors)
+}
+
+// jobConfigShard returns the shard of a job config file, which is the
+// top-level directory of the job config directory it is in. Files directly
+// in the job config directory belong to the "" shard.
+func jobConfigShard(jobConfig, path string) string {
+	rel, err := filepath.Rel(jobConfig, path)
+	if err != nil {
+		return ""
+	}
+	shard, _, found := strings.Cut(filepath.ToSlash(rel), "/")
+	if !found {
+		return ""
+	}
+	return shard
+}
+
+// copyJobConfigShard returns a deep copy of the jobs and presets of a shard.
+// Loading a config defaults its jobs and merges presets into them in place,
+// so the shards that are kept across loads must not share anything with
+// the config they are loaded into.
+func copyJobConfigShard(jc JobConfig) JobConfig {
+	c := JobConfig{}
+	for i := range jc.Presets {
+		c.Presets = append(c.Presets, *jc.Presets[i].DeepCopy())
+	}
+	if jc.PresubmitsStatic != nil {
+		c.PresubmitsStatic = make(map[string][]Presubmit, len(jc.PresubmitsStatic))
+		for repo, jobs := range jc.PresubmitsStatic {
+			copied := make([]Presubmit, 0, len(jobs))
+			for i := range jobs {
+				copied = append(copied, *jobs[i].DeepCopy())
+			}
+			c.PresubmitsStatic[repo] = copied
+		}
+	}
+	if jc.PostsubmitsStatic != nil {
+		c.PostsubmitsStatic = make(map[string][]Postsubmit, len(jc.PostsubmitsStatic))
+		for repo, jobs := range jc.PostsubmitsStatic {
+			copied := make([]Postsubmit, 0, len(jobs))
+			for i := range jobs {
+				copied = append(copied, *jobs[i].DeepCopy())
+			}
+			c.PostsubmitsStatic[repo] = copied
+		}
+	}
+	for _, periodic := range jc.Periodics {
+		periodic.JobBase = *periodic.JobBase.DeepCopy()
+		periodic.Tags = append([]string(nil), periodic.Tags...)
+		if periodic.CatchUp != nil {
+			catchUp := *periodic.CatchUp
+			periodic.CatchUp = &catchUp
+		}
+		c.Periodics = append(c.Periodics, periodic)
+	}
+	return c
+}
+
+// jobConfigShards reads the job config by shard and remembers the last
+// version of every shard that could be read, so that a shard that can't be
+// read doesn't stop the others from being reloaded. The shards are kept as
+// they were read and are copied into every config that is loaded.
+type jobConfigShards struct {
+	// good holds the shards of the last config that was loaded.
+	good map[string]JobConfig
+	// pending holds the shards of the last read, which become good once the
+	// config they are part of is loaded.
+	pending map[string]JobConfig
+	// stale holds the errors of the pending shards that can't be read.
+	stale map[string]string
+}
+
+// read reads the job config by shard. Shards that can't be read are replaced
+// by their last good version, or left out if they have none, and their errors
+// are returned. Reading fails if no config was loaded yet, so that a broken
+// config isn't silently served without some of its jobs on startup.
+func (s *jobConfigShards) read(jobConfig string, yamlOpts ...yaml.JSONOpt) (JobConfig, map[string]string, error) {
+	s.pending, s.stale = nil, nil
+	if jobConfig == "" {
+		return JobConfig{}, nil, nil
+	}
+	if stat, err := os.Stat(jobConfig); err != nil {
+		return JobConfig{}, nil, err
+	} else if !stat.IsDir() {
+		jc, err := ReadJobConfig(jobConfig, yamlOpts...)
+		return jc, nil, err
+	}
+
+	jcs, errs, err := readJobConfigFiles(jobConfig, func(path string) string { return jobConfigShard(jobConfig, path) }, yamlOpts...)
+	if err != nil {
+		return JobConfig{}, nil, err
+	}
+	if s.good == nil {
+		var all []error
+		for _, shardErrs := range errs {
+			all = append(all, shardErrs...)
+		}
+		if err := utilerrors.NewAggregate(all); err != nil {
+			return JobConfig{}, nil, err
+		}
+	}
+
+	pending := map[string]JobConfig{}
+	stale := map[string]string{}
+	for shard, jc := range jcs {
+		pending[shard] = jc
+	}
+	for shard, shardErrs := range errs {
+		err := utilerrors.NewAggregate(shardErrs)
+		if err == nil {
+			continue
+		}
+		jobConfigShardMetrics.errors.WithLabelValues(shard).Inc()
+		logrus.WithError(err).WithField("shard", shard).Error("Failed to read job config shard, using its last good version.")
+		stale[shard] = err.Error()
+		if good, ok := s.good[shard]; ok {
+			pending[shard] = good
+		} else {
+			delete(pending, shard)
+		}
+	}
+
+	shards := make([]string, 0, len(pending))
+	for shard := range pending {
+		shards = append(shards, shard)
+	}
+	sort.Strings(shards)
+	var merged JobConfig
+	for _, shard := range shards {
+		if merged, err = mergeJobConfigs(merged, copyJobConfigShard(pending[shard])); err != nil {
+			return JobConfig{}, nil, fmt.Errorf("failed to merge job config shard %q: %w", shard, err)
+		}
+	}
+	s.pending, s.stale = pending, stale
+	if len(stale) == 0 {
+		stale = nil
+	}
+	return merged, stale, nil
+}
+
+// commit marks the shards of the last read as good, once the config they are
+// part of was loaded.
+func (s *jobConfigShards) commit() {
+	if s.pending == nil {
+		return
+	}
+	jobConfigShardMetrics.stale.Reset()
+	for shard := range s.pending {
+		jobConfigShardMetrics.stale.WithLabelValues(shard).Set(0)
+	}
+	for shard := range s.stale {
+		jobConfigShardMetrics.stale.WithLabelValues(shard).Set(1)
+	}
+	s.good, s.pending, s.stale = s.pending, nil, nil
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestJobConfigShard(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/jobs/jobs.yaml", expected: ""},
+		{path: "/jobs/org/jobs.yaml", expected: "org"},
+		{path: "/jobs/org/repo/jobs.yaml", expected: "org"},
+	}
+	for _, tc := range testCases {
+		if shard := jobConfigShard("/jobs", tc.path); shard != tc.expected {
+			t.Errorf("expected shard of %s to be %q, got %q", tc.path, tc.expected, shard)
+		}
+	}
+}
+
+func TestAgentLoadShards(t *testing.T) {
+	presubmit := func(name string) string {
+		return fmt.Sprintf(`presubmits:
+  org/repo:
+  - name: %s
+    always_run: true
+    spec:
+      containers:
+      - image: alpine
+`, name)
+	}
+	const broken = "presubmits: [\n"
+	const withPreset = `presets:
+- labels:
+    preset-foo: "true"
+  env:
+  - name: FOO
+    value: foo
+presubmits:
+  org/repo:
+  - name: b1
+    always_run: true
+    labels:
+      preset-foo: "true"
+    spec:
+      containers:
+      - image: alpine
+`
+
+	type step struct {
+		name string
+		// files are written to the job config directory before loading,
+		// empty files are removed.
+		files         map[string]string
+		sharded       bool
+		expectedErr   bool
+		expectedJobs  []string
+		expectedStale []string
+	}
+	testCases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "broken shard keeps its last good version",
+			steps: []step{
+				{
+					name:         "initial load",
+					files:        map[string]string{"a/a.yaml": presubmit("a1"), "b/b.yaml": presubmit("b1"), "top.yaml": presubmit("top")},
+					sharded:      true,
+					expectedJobs: []string{"a1", "b1", "top"},
+				},
+				{
+					name:          "b is broken while a changes",
+					files:         map[string]string{"a/a.yaml": presubmit("a2"), "b/b.yaml": broken},
+					sharded:       true,
+					expectedJobs:  []string{"a2", "b1", "top"},
+					expectedStale: []string{"b"},
+				},
+				{
+					name:          "new broken shard is left out",
+					files:         map[string]string{"c/c.yaml": broken},
+					sharded:       true,
+					expectedJobs:  []string{"a2", "b1", "top"},
+					expectedStale: []string{"b", "c"},
+				},
+				{
+					name:         "fixed and removed shards",
+					files:        map[string]string{"b/b.yaml": presubmit("b2"), "c/c.yaml": "", "a/a.yaml": ""},
+					sharded:      true,
+					expectedJobs: []string{"b2", "top"},
+				},
+			},
+		},
+		{
+			name: "broken shard with presets survives several loads",
+			steps: []step{
+				{
+					name:         "initial load",
+					files:        map[string]string{"a/a.yaml": presubmit("a1"), "b/b.yaml": withPreset},
+					sharded:      true,
+					expectedJobs: []string{"a1", "b1"},
+				},
+				{
+					name:          "b is broken",
+					files:         map[string]string{"b/b.yaml": broken},
+					sharded:       true,
+					expectedJobs:  []string{"a1", "b1"},
+					expectedStale: []string{"b"},
+				},
+				{
+					name:          "b is still broken while a changes",
+					files:         map[string]string{"a/a.yaml": presubmit("a2")},
+					sharded:       true,
+					expectedJobs:  []string{"a2", "b1"},
+					expectedStale: []string{"b"},
+				},
+				{
+					name:          "b is still broken",
+					files:         map[string]string{"a/a.yaml": presubmit("a3")},
+					sharded:       true,
+					expectedJobs:  []string{"a3", "b1"},
+					expectedStale: []string{"b"},
+				},
+			},
+		},
+		{
+			name: "broken shard fails the first load",
+			steps: []step{
+				{
+					name:        "initial load",
+					files:       map[string]string{"a/a.yaml": presubmit("a1"), "b/b.yaml": broken},
+					sharded:     true,
+					expectedErr: true,
+				},
+				{
+					name:        "later loads fail until every shard was read once",
+					files:       map[string]string{"a/a.yaml": presubmit("a2")},
+					sharded:     true,
+					expectedErr: true,
+				},
+				{
+					name:         "fixed shard",
+					files:        map[string]string{"b/b.yaml": presubmit("b1")},
+					sharded:      true,
+					expectedJobs: []string{"a2", "b1"},
+				},
+			},
+		},
+		{
+			name: "broken file fails the load without sharding",
+			steps: []step{
+				{
+					name:         "initial load",
+					files:        map[string]string{"a/a.yaml": presubmit("a1"), "b/b.yaml": presubmit("b1")},
+					expectedJobs: []string{"a1", "b1"},
+				},
+				{
+					name:        "b is broken",
+					files:       map[string]string{"b/b.yaml": broken},
+					expectedErr: true,
+				},
+			},
+		},
+		{
+			name: "jobs that only collide across shards fail the load",
+			steps: []step{
+				{
+					name:         "initial load",
+					files:        map[string]string{"a/a.yaml": presubmit("a1"), "b/b.yaml": presubmit("b1")},
+					sharded:      true,
+					expectedJobs: []string{"a1", "b1"},
+				},
+				{
+					name:        "duplicate job",
+					files:       map[string]string{"b/b.yaml": presubmit("a1")},
+					sharded:     true,
+					expectedErr: true,
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			prowConfig := filepath.Join(dir, "config.yaml")
+			jobConfig := filepath.Join(dir, "jobs")
+			ca := &Agent{}
+			for _, s := range tc.steps {
+				if err := os.WriteFile(prowConfig, []byte(fmt.Sprintf("shard_job_config: %t\n", s.sharded)), 0644); err != nil {
+					t.Fatalf("%s: failed to write prow config: %v", s.name, err)
+				}
+				for name, content := range s.files {
+					path := filepath.Join(jobConfig, name)
+					if content == "" {
+						if err := os.Remove(path); err != nil {
+							t.Fatalf("%s: failed to remove %s: %v", s.name, name, err)
+						}
+						continue
+					}
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatalf("%s: failed to create directory of %s: %v", s.name, name, err)
+					}
+					if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+						t.Fatalf("%s: failed to write %s: %v", s.name, name, err)
+					}
+				}
+
+				c, err := ca.load(prowConfig, jobConfig, nil, "")
+				if (err != nil) != s.expectedErr {
+					t.Fatalf("%s: expected error %t, got %v", s.name, s.expectedErr, err)
+				}
+				if err != nil {
+					continue
+				}
+				jobs := sets.New[string]()
+				for _, presubmits := range c.PresubmitsStatic {
+					for _, presubmit := range presubmits {
+						jobs.Insert(presubmit.Name)
+					}
+				}
+				if diff := cmp.Diff(s.expectedJobs, sets.List(jobs)); diff != "" {
+					t.Errorf("%s: unexpected jobs (-want +got):\n%s", s.name, diff)
+				}
+				if diff := cmp.Diff(s.expectedStale, sets.List(sets.KeySet(c.StaleShards)), cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("%s: unexpected stale shards (-want +got):\n%s", s.name, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+const (
+	defaultSLOWindow  = 24 * time.Hour
+	defaultSLOMinRuns = 10
+)
+
+// +k8s:deepcopy-gen=true
+
+// JobSLO is the service level objective of a job, tracked by the SLO
+// reporter of crier.
+type JobSLO struct {
+	// TargetPassRate is the fraction of the runs of the job in the window
+	// that must succeed, between 0 and 1, e.g. 0.95.
+	TargetPassRate float64 `json:"target_pass_rate,omitempty"`
+	// MaxDuration is how long a run of the job may take.
+	MaxDuration *metav1.Duration `json:"max_duration,omitempty"`
+	// Window is the period the pass rate is computed over. Defaults to 24h.
+	Window *metav1.Duration `json:"window,omitempty"`
+	// MinRuns is how many runs the window must have for the pass rate to be
+	// checked against the target. Defaults to 10.
+	MinRuns int `json:"min_runs,omitempty"`
+}
+
+// GetWindow returns the window of the SLO, with its default.
+func (s *JobSLO) GetWindow() time.Duration {
+	if s.Window == nil {
+		return defaultSLOWindow
+	}
+	return s.Window.Duration
+}
+
+// GetMinRuns returns the minimum number of runs of the SLO, with its default.
+func (s *JobSLO) GetMinRuns() int {
+	if s.MinRuns == 0 {
+		return defaultSLOMinRuns
+	}
+	return s.MinRuns
+}
+
+func validateJobSLO(s *JobSLO) error {
+	if s == nil {
+		return nil
+	}
+	if s.TargetPassRate == 0 && s.MaxDuration == nil {
+		return errors.New("at least one of target_pass_rate and max_duration must be set")
+	}
+	if s.TargetPassRate < 0 || s.TargetPassRate > 1 {
+		return fmt.Errorf("target_pass_rate %v must be between 0 and 1", s.TargetPassRate)
+	}
+	if s.MaxDuration != nil && s.MaxDuration.Duration <= 0 {
+		return errors.New("max_duration must be positive")
+	}
+	if s.Window != nil && s.Window.Duration <= 0 {
+		return errors.New("window must be positive")
+	}
+	if s.MinRuns < 0 {
+		return errors.New("min_runs must not be negative")
+	}
+	return nil
+}
+
+// SLOReporter configures the SLO reporter of crier.
+type SLOReporter struct {
+	// WebhookURL is sent a JSON alert whenever a job starts or stops
+	// breaching its SLO.
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+func (r *SLOReporter) validate() error {
+	if r == nil || r.WebhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(r.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("webhook_url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook_url %q must be an http or https URL", r.WebhookURL)
+	}
+	return nil
+}
+
+// JobSLOFor returns the SLO of the job of the ProwJob, nil if it has none or
+// if the job isn't in the static job config.
+func (c *JobConfig) JobSLOFor(pj *prowapi.ProwJob) *JobSLO {
+	var repo string
+	if pj.Spec.Refs != nil {
+		repo = pj.Spec.Refs.Org + "/" + pj.Spec.Refs.Repo
+	}
+	switch pj.Spec.Type {
+	case prowapi.PresubmitJob, prowapi.BatchJob:
+		for _, job := range c.PresubmitsStatic[repo] {
+			if job.Name == pj.Spec.Job {
+				return job.SLO
+			}
+		}
+	case prowapi.PostsubmitJob:
+		for _, job := range c.PostsubmitsStatic[repo] {
+			if job.Name == pj.Spec.Job {
+				return job.SLO
+			}
+		}
+	case prowapi.PeriodicJob:
+		for _, job := range c.Periodics {
+			if job.Name == pj.Spec.Job {
+				return job.SLO
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// SpyglassRepoLenses configures the lenses for the jobs of an org or repo.
+type SpyglassRepoLenses struct {
+	// DisabledLenses are the names of the lenses that are not rendered.
+	DisabledLenses []string `json:"disabled_lenses,omitempty"`
+	// EnabledLenses are the names of lenses disabled by a less specific
+	// entry that are rendered again.
+	EnabledLenses []string `json:"enabled_lenses,omitempty"`
+	// LensConfigs maps lens names to a JSON merge patch (RFC 7386) that is
+	// applied to the lens-specific configuration, for instance to add
+	// highlight regexes to the buildlog lens.
+	LensConfigs map[string]json.RawMessage `json:"lens_configs,omitempty"`
+}
+
+// repoLenses returns the entries of RepoLenses that apply to the jobs of
+// the given org and repo, from the least to the most specific one.
+func (s Spyglass) repoLenses(org, repo string) []SpyglassRepoLenses {
+	var entries []SpyglassRepoLenses
+	keys := []string{"*"}
+	if org != "" {
+		keys = append(keys, org)
+		if repo != "" {
+			keys = append(keys, fmt.Sprintf("%s/%s", org, repo))
+		}
+	}
+	for _, key := range keys {
+		if entry, ok := s.RepoLenses[key]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// LensEnabled determines whether the lens with the given name is rendered
+// for the jobs of the given org and repo.
+func (s Spyglass) LensEnabled(name, org, repo string) bool {
+	enabled := true
+	for _, entry := range s.repoLenses(org, repo) {
+		if sets.New[string](entry.DisabledLenses...).Has(name) {
+			enabled = false
+		}
+		if sets.New[string](entry.EnabledLenses...).Has(name) {
+			enabled = true
+		}
+	}
+	return enabled
+}
+
+// LensConfig returns the configuration of the lens at the given index of
+// Lenses for the jobs of the given org and repo.
+func (s Spyglass) LensConfig(index int, org, repo string) (json.RawMessage, error) {
+	if index < 0 || index >= len(s.Lenses) {
+		return nil, fmt.Errorf("invalid lens index %d", index)
+	}
+	lens := s.Lenses[index].Lens
+	config := lens.Config
+	for _, entry := range s.repoLenses(org, repo) {
+		patch, ok := entry.LensConfigs[lens.Name]
+		if !ok {
+			continue
+		}
+		if len(config) == 0 {
+			config = json.RawMessage("{}")
+		}
+		merged, err := jsonpatch.MergePatch(config, patch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge the config of lens %q: %w", lens.Name, err)
+		}
+		config = merged
+	}
+	return config, nil
+}
+
+func (s Spyglass) validateRepoLenses() error {
+	names := sets.New[string]()
+	for _, lens := range s.Lenses {
+		names.Insert(lens.Lens.Name)
+	}
+	// Lenses discovered from the registries can be referred to as well, but
+	// they are only known once Deck polled the registries.
+	known := func(name string) bool { return len(s.LensRegistries) > 0 || names.Has(name) }
+	for key, entry := range s.RepoLenses {
+		for _, name := range append(append([]string{}, entry.DisabledLenses...), entry.EnabledLenses...) {
+			if !known(name) {
+				return fmt.Errorf("deck.spyglass.repo_lenses[%q] refers to lens %q, which is not configured", key, name)
+			}
+		}
+		for name, patch := range entry.LensConfigs {
+			if !known(name) {
+				return fmt.Errorf("deck.spyglass.repo_lenses[%q].lens_configs refers to lens %q, which is not configured", key, name)
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal(patch, &obj); err != nil {
+				return fmt.Errorf("deck.spyglass.repo_lenses[%q].lens_configs[%q] must be an object: %w", key, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSpyglassRepoLenses(t *testing.T) {
+	s := Spyglass{
+		Lenses: []LensFileConfig{
+			{Lens: LensConfig{Name: "buildlog", Config: json.RawMessage(`{"highlight_regexes":["error"],"hide_raw_log":true}`)}},
+			{Lens: LensConfig{Name: "junit"}},
+			{Lens: LensConfig{Name: "coverage"}},
+		},
+		RepoLenses: map[string]SpyglassRepoLenses{
+			"*": {DisabledLenses: []string{"coverage"}},
+			"org": {
+				DisabledLenses: []string{"junit"},
+				LensConfigs:    map[string]json.RawMessage{"buildlog": json.RawMessage(`{"highlight_regexes":["panic"]}`)},
+			},
+			"org/repo": {
+				EnabledLenses: []string{"coverage"},
+				LensConfigs:   map[string]json.RawMessage{"buildlog": json.RawMessage(`{"hide_raw_log":null}`), "junit": json.RawMessage(`{"skip":true}`)},
+			},
+		},
+	}
+	if err := s.validateRepoLenses(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	testCases := []struct {
+		name             string
+		org, repo        string
+		expectedEnabled  map[string]bool
+		expectedBuildlog string
+		expectedJunit    string
+	}{
+		{
+			name:             "global defaults",
+			org:              "other",
+			repo:             "repo",
+			expectedEnabled:  map[string]bool{"buildlog": true, "junit": true, "coverage": false},
+			expectedBuildlog: `{"highlight_regexes":["error"],"hide_raw_log":true}`,
+		},
+		{
+			name:             "org overrides",
+			org:              "org",
+			repo:             "other",
+			expectedEnabled:  map[string]bool{"buildlog": true, "junit": false, "coverage": false},
+			expectedBuildlog: `{"hide_raw_log":true,"highlight_regexes":["panic"]}`,
+		},
+		{
+			name:             "repo overrides org",
+			org:              "org",
+			repo:             "repo",
+			expectedEnabled:  map[string]bool{"buildlog": true, "junit": false, "coverage": true},
+			expectedBuildlog: `{"highlight_regexes":["panic"]}`,
+			expectedJunit:    `{"skip":true}`,
+		},
+		{
+			name:             "job without refs",
+			expectedEnabled:  map[string]bool{"buildlog": true, "junit": true, "coverage": false},
+			expectedBuildlog: `{"highlight_regexes":["error"],"hide_raw_log":true}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, expected := range tc.expectedEnabled {
+				if enabled := s.LensEnabled(name, tc.org, tc.repo); enabled != expected {
+					t.Errorf("expected lens %s to be enabled: %t, got %t", name, expected, enabled)
+				}
+			}
+			buildlog, err := s.LensConfig(0, tc.org, tc.repo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(buildlog) != tc.expectedBuildlog {
+				t.Errorf("expected buildlog config %s, got %s", tc.expectedBuildlog, buildlog)
+			}
+			junit, err := s.LensConfig(1, tc.org, tc.repo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(junit) != tc.expectedJunit {
+				t.Errorf("expected junit config %s, got %s", tc.expectedJunit, junit)
+			}
+		})
+	}
+}
+
+func TestValidateRepoLenses(t *testing.T) {
+	lenses := []LensFileConfig{{Lens: LensConfig{Name: "buildlog"}}}
+	testCases := []struct {
+		name       string
+		repoLenses map[string]SpyglassRepoLenses
+		expectErr  bool
+	}{
+		{
+			name:       "valid",
+			repoLenses: map[string]SpyglassRepoLenses{"org": {DisabledLenses: []string{"buildlog"}, LensConfigs: map[string]json.RawMessage{"buildlog": json.RawMessage(`{}`)}}},
+		},
+		{
+			name:       "unknown lens",
+			repoLenses: map[string]SpyglassRepoLenses{"org": {EnabledLenses: []string{"junit"}}},
+			expectErr:  true,
+		},
+		{
+			name:       "config for unknown lens",
+			repoLenses: map[string]SpyglassRepoLenses{"org": {LensConfigs: map[string]json.RawMessage{"junit": json.RawMessage(`{}`)}}},
+			expectErr:  true,
+		},
+		{
+			name:       "config is not an object",
+			repoLenses: map[string]SpyglassRepoLenses{"org": {LensConfigs: map[string]json.RawMessage{"buildlog": json.RawMessage(`[]`)}}},
+			expectErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Spyglass{Lenses: lenses, RepoLenses: tc.repoLenses}.validateRepoLenses()
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
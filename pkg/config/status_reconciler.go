@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusReconciler is config for the status-reconciler component.
+type StatusReconciler struct {
+	// ContextMigrations declares renamed contexts per "org/repo". Once a
+	// migration is effective, the old context is migrated to the new one on
+	// all open PRs of the repo. This is needed when status-reconciler cannot
+	// infer the rename from the config change, e.g. because the job was
+	// renamed together with its context.
+	ContextMigrations map[string][]ContextMigration `json:"context_migrations,omitempty"`
+}
+
+// ContextMigration migrates a status context to a new name.
+type ContextMigration struct {
+	// From is the old context.
+	From string `json:"from"`
+	// To is the new context.
+	To string `json:"to"`
+	// EffectiveDate is when the context is migrated. The migration is
+	// effective immediately if unset.
+	EffectiveDate *time.Time `json:"effective_date,omitempty"`
+}
+
+// Effective determines whether the migration is effective at the given time.
+func (m ContextMigration) Effective(now time.Time) bool {
+	return m.EffectiveDate == nil || !now.Before(*m.EffectiveDate)
+}
+
+// Validate validates the status-reconciler config.
+func (s *StatusReconciler) Validate() error {
+	for orgRepo, migrations := range s.ContextMigrations {
+		if parts := strings.Split(orgRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("context_migrations: %q is not in org/repo format", orgRepo)
+		}
+		from := map[string]bool{}
+		for _, migration := range migrations {
+			if migration.From == "" || migration.To == "" {
+				return fmt.Errorf("context_migrations: %s: from and to must be set", orgRepo)
+			}
+			if migration.From == migration.To {
+				return fmt.Errorf("context_migrations: %s: context %q is migrated to itself", orgRepo, migration.From)
+			}
+			if from[migration.From] {
+				return fmt.Errorf("context_migrations: %s: context %q is migrated more than once", orgRepo, migration.From)
+			}
+			from[migration.From] = true
+		}
+	}
+	return nil
+}
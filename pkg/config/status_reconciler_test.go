@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestStatusReconcilerValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    string
+		expectErr bool
+	}{
+		{
+			name: "valid migrations",
+			config: `context_migrations:
+  org/repo:
+  - from: old
+    to: new
+  - from: new
+    to: newer
+    effective_date: 2024-05-01T12:00:00Z`,
+		},
+		{
+			name: "not an org/repo",
+			config: `context_migrations:
+  org:
+  - from: old
+    to: new`,
+			expectErr: true,
+		},
+		{
+			name: "missing new context",
+			config: `context_migrations:
+  org/repo:
+  - from: old`,
+			expectErr: true,
+		},
+		{
+			name: "context migrated to itself",
+			config: `context_migrations:
+  org/repo:
+  - from: old
+    to: old`,
+			expectErr: true,
+		},
+		{
+			name: "context migrated twice",
+			config: `context_migrations:
+  org/repo:
+  - from: old
+    to: new
+  - from: old
+    to: other`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var s StatusReconciler
+			if err := yaml.Unmarshal([]byte(tc.config), &s); err != nil {
+				t.Fatalf("failed to unmarshal config: %v", err)
+			}
+			if err := s.Validate(); (err != nil) != tc.expectErr {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestContextMigrationEffective(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+	for _, tc := range []struct {
+		name      string
+		migration ContextMigration
+		expected  bool
+	}{
+		{name: "no effective date", expected: true},
+		{name: "effective now", migration: ContextMigration{EffectiveDate: &now}, expected: true},
+		{name: "effective later", migration: ContextMigration{EffectiveDate: &later}},
+	} {
+		if actual := tc.migration.Effective(now); actual != tc.expected {
+			t.Errorf("%s: expected effective %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
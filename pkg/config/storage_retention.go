@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// StorageRetention declares how long a class of objects in a bucket is kept.
+// prowctl lifecycle turns the retentions of a bucket into its lifecycle rules.
+type StorageRetention struct {
+	// Bucket is the bucket of the objects, like gs://bucket. Only GCS buckets
+	// are supported.
+	Bucket string `json:"bucket"`
+	// Prefixes are the prefixes of the objects in the bucket, like
+	// "pr-logs/". The retention applies to the whole bucket if unset.
+	Prefixes []string `json:"prefixes,omitempty"`
+	// ColdStorageAfterDays is the age in days after which the objects move
+	// to the ColdStorageClass.
+	ColdStorageAfterDays int `json:"cold_storage_after_days,omitempty"`
+	// ColdStorageClass is the storage class the objects move to, one of
+	// NEARLINE, COLDLINE and ARCHIVE. Defaults to COLDLINE.
+	ColdStorageClass string `json:"cold_storage_class,omitempty"`
+	// DeleteAfterDays is the age in days after which the objects are deleted.
+	DeleteAfterDays int `json:"delete_after_days,omitempty"`
+}
+
+// DefaultColdStorageClass is the storage class objects move to if a storage
+// retention doesn't set one.
+const DefaultColdStorageClass = "COLDLINE"
+
+var coldStorageClasses = []string{"NEARLINE", DefaultColdStorageClass, "ARCHIVE"}
+
+// DefaultAndValidate defaults and validates the storage retention.
+func (r *StorageRetention) DefaultAndValidate() error {
+	provider, bucket, path, err := providers.ParseStoragePath(r.Bucket)
+	if err != nil {
+		return fmt.Errorf("invalid bucket: %w", err)
+	}
+	if provider != providers.GS {
+		return fmt.Errorf("bucket %s: only %s buckets are supported", r.Bucket, providers.DisplayName(providers.GS))
+	}
+	if bucket == "" || path != "" {
+		return fmt.Errorf("bucket %s must be a bucket without a path, like gs://bucket", r.Bucket)
+	}
+	for _, prefix := range r.Prefixes {
+		if prefix == "" || strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("invalid prefix %q, prefixes must be relative to the bucket", prefix)
+		}
+	}
+	if r.ColdStorageAfterDays < 0 || r.DeleteAfterDays < 0 {
+		return errors.New("cold_storage_after_days and delete_after_days must not be negative")
+	}
+	if r.ColdStorageAfterDays == 0 && r.DeleteAfterDays == 0 {
+		return errors.New("cold_storage_after_days or delete_after_days must be set")
+	}
+	if r.ColdStorageAfterDays > 0 && r.DeleteAfterDays > 0 && r.ColdStorageAfterDays >= r.DeleteAfterDays {
+		return fmt.Errorf("cold_storage_after_days (%d) must be less than delete_after_days (%d)", r.ColdStorageAfterDays, r.DeleteAfterDays)
+	}
+	if r.ColdStorageAfterDays > 0 {
+		if r.ColdStorageClass == "" {
+			r.ColdStorageClass = DefaultColdStorageClass
+		}
+		if !sets.New(coldStorageClasses...).Has(r.ColdStorageClass) {
+			return fmt.Errorf("invalid cold_storage_class %q, must be one of %s", r.ColdStorageClass, strings.Join(coldStorageClasses, ", "))
+		}
+	} else if r.ColdStorageClass != "" {
+		return errors.New("cold_storage_class requires cold_storage_after_days")
+	}
+	return nil
+}
+
+func (c *ProwConfig) defaultAndValidateStorageRetention() error {
+	for i := range c.StorageRetention {
+		if err := c.StorageRetention[i].DefaultAndValidate(); err != nil {
+			return fmt.Errorf("storage_retention[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStorageRetentionDefaultAndValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		retention StorageRetention
+		expected  StorageRetention
+		expectErr bool
+	}{
+		{
+			name:      "cold storage class is defaulted",
+			retention: StorageRetention{Bucket: "gs://bucket", Prefixes: []string{"logs/"}, ColdStorageAfterDays: 30, DeleteAfterDays: 90},
+			expected:  StorageRetention{Bucket: "gs://bucket", Prefixes: []string{"logs/"}, ColdStorageAfterDays: 30, ColdStorageClass: "COLDLINE", DeleteAfterDays: 90},
+		},
+		{
+			name:      "delete only",
+			retention: StorageRetention{Bucket: "gs://bucket", DeleteAfterDays: 90},
+			expected:  StorageRetention{Bucket: "gs://bucket", DeleteAfterDays: 90},
+		},
+		{
+			name:      "s3 buckets aren't supported",
+			retention: StorageRetention{Bucket: "s3://bucket", DeleteAfterDays: 90},
+			expectErr: true,
+		},
+		{
+			name:      "bucket with a path",
+			retention: StorageRetention{Bucket: "gs://bucket/logs", DeleteAfterDays: 90},
+			expectErr: true,
+		},
+		{
+			name:      "no action",
+			retention: StorageRetention{Bucket: "gs://bucket"},
+			expectErr: true,
+		},
+		{
+			name:      "deleted before moving to cold storage",
+			retention: StorageRetention{Bucket: "gs://bucket", ColdStorageAfterDays: 90, DeleteAfterDays: 30},
+			expectErr: true,
+		},
+		{
+			name:      "unknown storage class",
+			retention: StorageRetention{Bucket: "gs://bucket", ColdStorageAfterDays: 30, ColdStorageClass: "GLACIER"},
+			expectErr: true,
+		},
+		{
+			name:      "storage class without cold storage",
+			retention: StorageRetention{Bucket: "gs://bucket", ColdStorageClass: "ARCHIVE", DeleteAfterDays: 30},
+			expectErr: true,
+		},
+		{
+			name:      "absolute prefix",
+			retention: StorageRetention{Bucket: "gs://bucket", Prefixes: []string{"/logs"}, DeleteAfterDays: 30},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.retention.DefaultAndValidate()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, tc.retention); diff != "" {
+				t.Errorf("unexpected retention (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
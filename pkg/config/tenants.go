@@ -0,0 +1,300 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// Tenant owns part of the job config. The jobs in the files of a tenant can
+// only run for the repos of the tenant, in its clusters and with its
+// secrets, so the files can be delegated to the tenant without giving it
+// access to the jobs of others.
+type Tenant struct {
+	// Name identifies the tenant in errors.
+	Name string `json:"name"`
+	// JobConfigPaths are the files and directories of the tenant, relative
+	// to the job config path. Every file belongs to at most one tenant,
+	// the files that don't belong to a tenant aren't restricted.
+	JobConfigPaths []string `json:"job_config_paths"`
+	// Repos are the orgs and org/repos the jobs of the tenant can run for,
+	// including the extra refs of the jobs.
+	Repos []string `json:"repos"`
+	// Clusters are the build clusters the jobs of the tenant can run in.
+	// Defaults to the default cluster.
+	Clusters []string `json:"clusters,omitempty"`
+	// Secrets are the names of the secrets the pods of the jobs of the
+	// tenant can use in volumes and environment variables, and the jobs can
+	// set in their decoration config, like ssh_key_secrets.
+	Secrets []string `json:"secrets,omitempty"`
+	// ServiceAccounts are the names of the service accounts the pods of the
+	// jobs of the tenant can run as, set as the serviceAccountName of their
+	// spec or the default_service_account_name of their decoration config.
+	ServiceAccounts []string `json:"service_accounts,omitempty"`
+}
+
+func (t *Tenant) allowsRepo(org, repo string) bool {
+	for _, allowed := range t.Repos {
+		if allowed == org || allowed == org+"/"+repo {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tenant) owns(file string) bool {
+	for _, p := range t.JobConfigPaths {
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTenants(tenants []Tenant) error {
+	names := sets.New[string]()
+	owners := map[string]string{}
+	for i, tenant := range tenants {
+		if tenant.Name == "" {
+			return fmt.Errorf("tenant %d has no name", i)
+		}
+		if names.Has(tenant.Name) {
+			return fmt.Errorf("tenant %q is defined more than once", tenant.Name)
+		}
+		names.Insert(tenant.Name)
+		if len(tenant.JobConfigPaths) == 0 {
+			return fmt.Errorf("tenant %q has no job_config_paths", tenant.Name)
+		}
+		if len(tenant.Repos) == 0 {
+			return fmt.Errorf("tenant %q has no repos", tenant.Name)
+		}
+		for _, p := range tenant.JobConfigPaths {
+			if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == "." || strings.HasPrefix(p, "../") {
+				return fmt.Errorf("job config path %q of tenant %q must be a clean path within the job config", p, tenant.Name)
+			}
+			for other, owner := range owners {
+				if p == other || strings.HasPrefix(p, other+"/") || strings.HasPrefix(other, p+"/") {
+					return fmt.Errorf("job config path %q of tenant %q overlaps with %q of tenant %q", p, tenant.Name, other, owner)
+				}
+			}
+			owners[p] = tenant.Name
+		}
+	}
+	return nil
+}
+
+// tenantFor returns the tenant that owns the file a job is defined in, or
+// nil if the file doesn't belong to a tenant.
+func (c *Config) tenantFor(jobConfig string, base JobBase) *Tenant {
+	if base.SourcePath == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(jobConfig, base.SourcePath)
+	if err != nil {
+		return nil
+	}
+	if rel == "." {
+		// The job config is a single file.
+		rel = filepath.Base(base.SourcePath)
+	}
+	rel = filepath.ToSlash(rel)
+	for i := range c.Tenants {
+		if c.Tenants[i].owns(rel) {
+			return &c.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// validateTenantJobs ensures that the jobs in the files of tenants don't
+// reference the repos, clusters or secrets of others.
+func (c *Config) validateTenantJobs(jobConfig string) error {
+	if len(c.Tenants) == 0 || jobConfig == "" {
+		return nil
+	}
+	var errs []error
+	validate := func(base JobBase, orgRepo string, extraRefs []prowapi.Refs) {
+		tenant := c.tenantFor(jobConfig, base)
+		if tenant == nil {
+			return
+		}
+		repo := orgRepo
+		if repo == "" && len(extraRefs) > 0 {
+			repo = extraRefs[0].Org + "/" + extraRefs[0].Repo
+		}
+		if err := tenant.validateJob(base, orgRepo, extraRefs, c.defaultDecorationConfig(repo, base.Cluster)); err != nil {
+			errs = append(errs, fmt.Errorf("job %s in %s of tenant %q: %w", base.Name, base.SourcePath, tenant.Name, err))
+		}
+	}
+	for orgRepo, presubmits := range c.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			validate(presubmit.JobBase, orgRepo, presubmit.ExtraRefs)
+		}
+	}
+	for orgRepo, postsubmits := range c.PostsubmitsStatic {
+		for _, postsubmit := range postsubmits {
+			validate(postsubmit.JobBase, orgRepo, postsubmit.ExtraRefs)
+		}
+	}
+	for _, periodic := range c.Periodics {
+		validate(periodic.JobBase, "", periodic.ExtraRefs)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// defaultDecorationConfig returns the decoration config the jobs of the repo
+// get in the cluster from the job defaults and the default decoration
+// configs, which are set by the admins of Prow rather than by tenants.
+func (c *Config) defaultDecorationConfig(repo, cluster string) *prowapi.DecorationConfig {
+	var jobDefault *prowapi.DecorationConfig
+	if defaults := c.JobDefaultsFor(repo); defaults != nil {
+		jobDefault = defaults.DecorationConfig
+	}
+	return c.Plank.mergeDefaultDecorationConfig(repo, cluster, jobDefault)
+}
+
+// validateJob checks the job against the tenant. The secrets and service
+// account of the decoration config that are the same as in its defaults
+// aren't checked, as they aren't set by the tenant.
+func (t *Tenant) validateJob(base JobBase, orgRepo string, extraRefs []prowapi.Refs, defaults *prowapi.DecorationConfig) error {
+	var errs []error
+	if orgRepo != "" {
+		org, repo, err := SplitRepoName(orgRepo)
+		if err != nil {
+			errs = append(errs, err)
+		} else if !t.allowsRepo(org, repo) {
+			errs = append(errs, fmt.Errorf("repo %s isn't one of the tenant", orgRepo))
+		}
+	}
+	for _, ref := range extraRefs {
+		if !t.allowsRepo(ref.Org, ref.Repo) {
+			errs = append(errs, fmt.Errorf("extra ref %s/%s isn't one of the tenant", ref.Org, ref.Repo))
+		}
+	}
+	clusters := t.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{kube.DefaultClusterAlias}
+	}
+	if cluster := base.Cluster; cluster != "" && !sets.New(clusters...).Has(cluster) {
+		errs = append(errs, fmt.Errorf("cluster %s isn't one of the tenant", cluster))
+	}
+	if base.RerunOverrides != nil {
+		for _, cluster := range base.RerunOverrides.Clusters {
+			if !sets.New(clusters...).Has(cluster) {
+				errs = append(errs, fmt.Errorf("rerun_overrides cluster %s isn't one of the tenant", cluster))
+			}
+		}
+	}
+	secrets := decorationSecrets(base.DecorationConfig).Difference(decorationSecrets(defaults))
+	serviceAccounts := sets.New[string]()
+	if base.Spec != nil {
+		secrets = secrets.Union(podSecrets(base.Spec))
+		if base.Spec.ServiceAccountName != "" {
+			serviceAccounts.Insert(base.Spec.ServiceAccountName)
+		}
+	}
+	if dc := base.DecorationConfig; dc != nil && dc.DefaultServiceAccountName != nil && *dc.DefaultServiceAccountName != "" {
+		if defaults == nil || defaults.DefaultServiceAccountName == nil || *defaults.DefaultServiceAccountName != *dc.DefaultServiceAccountName {
+			serviceAccounts.Insert(*dc.DefaultServiceAccountName)
+		}
+	}
+	allowedSecrets := sets.New(t.Secrets...)
+	for _, secret := range sets.List(secrets) {
+		if !allowedSecrets.Has(secret) {
+			errs = append(errs, fmt.Errorf("secret %s isn't one of the tenant", secret))
+		}
+	}
+	allowedServiceAccounts := sets.New(t.ServiceAccounts...)
+	for _, serviceAccount := range sets.List(serviceAccounts) {
+		if !allowedServiceAccounts.Has(serviceAccount) {
+			errs = append(errs, fmt.Errorf("service account %s isn't one of the tenant", serviceAccount))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// decorationSecrets returns the names of the secrets the decoration config
+// mounts in the pod, for cloning and uploading.
+func decorationSecrets(dc *prowapi.DecorationConfig) sets.Set[string] {
+	secrets := sets.New[string]()
+	if dc == nil {
+		return secrets
+	}
+	secrets.Insert(dc.SSHKeySecrets...)
+	if dc.OauthTokenSecret != nil && dc.OauthTokenSecret.Name != "" {
+		secrets.Insert(dc.OauthTokenSecret.Name)
+	}
+	if dc.GitHubAppPrivateKeySecret != nil && dc.GitHubAppPrivateKeySecret.Name != "" {
+		secrets.Insert(dc.GitHubAppPrivateKeySecret.Name)
+	}
+	if dc.GCSCredentialsSecret != nil && *dc.GCSCredentialsSecret != "" {
+		secrets.Insert(*dc.GCSCredentialsSecret)
+	}
+	if dc.S3CredentialsSecret != nil && *dc.S3CredentialsSecret != "" {
+		secrets.Insert(*dc.S3CredentialsSecret)
+	}
+	return secrets
+}
+
+// podSecrets returns the names of the secrets the pod uses in volumes and
+// environment variables.
+func podSecrets(spec *v1.PodSpec) sets.Set[string] {
+	secrets := sets.New[string]()
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			secrets.Insert(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					secrets.Insert(source.Secret.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				secrets.Insert(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				secrets.Insert(envFrom.SecretRef.Name)
+			}
+		}
+	}
+	for _, pullSecret := range spec.ImagePullSecrets {
+		secrets.Insert(pullSecret.Name)
+	}
+	return secrets
+}
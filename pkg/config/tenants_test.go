@@ -0,0 +1,380 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	const prowConfig = `
+tenants:
+- name: team-a
+  job_config_paths:
+  - team-a
+  repos:
+  - org-a
+  - shared/tools
+  clusters:
+  - build-a
+  secrets:
+  - token-a
+`
+	// The defaults of the decoration config aren't set by the tenant, so
+	// their secrets and service account aren't checked.
+	const decorationDefaults = `
+plank:
+  default_decoration_config_entries:
+  - config:
+      utility_images:
+        clonerefs: clonerefs
+        initupload: initupload
+        entrypoint: entrypoint
+        sidecar: sidecar
+      gcs_configuration:
+        bucket: bucket
+        path_strategy: explicit
+      gcs_credentials_secret: gcs-default
+      default_service_account_name: runner-default
+`
+	testCases := []struct {
+		name        string
+		prowConfig  string
+		files       map[string]string
+		expectedErr string
+	}{
+		{
+			name:       "jobs of the tenant",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"team-a/presubmits.yaml": `
+presubmits:
+  org-a/repo:
+  - name: pull-repo-test
+    cluster: build-a
+    spec:
+      containers:
+      - image: alpine
+        env:
+        - name: TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: token-a
+              key: token
+periodics:
+- name: periodic-tools
+  interval: 1h
+  cluster: build-a
+  extra_refs:
+  - org: shared
+    repo: tools
+    base_ref: main
+  spec:
+    containers:
+    - image: alpine
+`,
+			},
+		},
+		{
+			name:       "jobs outside of tenant files aren't restricted",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"admin/presubmits.yaml": `
+presubmits:
+  org-b/repo:
+  - name: pull-repo-test
+    spec:
+      containers:
+      - image: alpine
+`,
+			},
+		},
+		{
+			name:       "repo of another tenant",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"team-a/presubmits.yaml": `
+presubmits:
+  org-b/repo:
+  - name: pull-repo-test
+    cluster: build-a
+    spec:
+      containers:
+      - image: alpine
+`,
+			},
+			expectedErr: "repo org-b/repo isn't one of the tenant",
+		},
+		{
+			name:       "extra ref, cluster and secret of another tenant",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"team-a/nested/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  extra_refs:
+  - org: shared
+    repo: secrets
+    base_ref: main
+  spec:
+    containers:
+    - image: alpine
+    volumes:
+    - name: creds
+      secret:
+        secretName: token-b
+`,
+			},
+			expectedErr: "extra ref shared/secrets isn't one of the tenant\ncluster default isn't one of the tenant\nsecret token-b isn't one of the tenant",
+		},
+		{
+			name: "decoration secrets and service account of the tenant and the defaults",
+			prowConfig: prowConfig + `  - ssh-a
+  service_accounts:
+  - runner-a
+` + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    ssh_key_secrets:
+    - ssh-a
+  spec:
+    serviceAccountName: runner-a
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+		},
+		{
+			name:       "ssh key secret of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    ssh_key_secrets:
+    - ssh-b
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "secret ssh-b isn't one of the tenant",
+		},
+		{
+			name:       "oauth token secret of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    oauth_token_secret:
+      name: oauth-b
+      key: token
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "secret oauth-b isn't one of the tenant",
+		},
+		{
+			name:       "GitHub App private key secret of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    github_app_id: "123"
+    github_app_private_key_secret:
+      name: app-b
+      key: key
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "secret app-b isn't one of the tenant",
+		},
+		{
+			name:       "GCS credentials secret of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    gcs_credentials_secret: gcs-b
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "secret gcs-b isn't one of the tenant",
+		},
+		{
+			name:       "S3 credentials secret of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    s3_credentials_secret: s3-b
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "secret s3-b isn't one of the tenant",
+		},
+		{
+			name:       "default service account of another tenant",
+			prowConfig: prowConfig + decorationDefaults,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  decorate: true
+  decoration_config:
+    default_service_account_name: runner-b
+  spec:
+    containers:
+    - image: alpine
+      command: ["true"]
+`,
+			},
+			expectedErr: "service account runner-b isn't one of the tenant",
+		},
+		{
+			name:       "service account of another tenant",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  spec:
+    serviceAccountName: runner-b
+    containers:
+    - image: alpine
+`,
+			},
+			expectedErr: "service account runner-b isn't one of the tenant",
+		},
+		{
+			name:       "rerun override cluster of another tenant",
+			prowConfig: prowConfig,
+			files: map[string]string{
+				"team-a/periodics.yaml": `
+periodics:
+- name: periodic-test
+  interval: 1h
+  cluster: build-a
+  rerun_overrides:
+    clusters:
+    - build-a
+    - build-b
+  spec:
+    containers:
+    - image: alpine
+`,
+			},
+			expectedErr: "rerun_overrides cluster build-b isn't one of the tenant",
+		},
+		{
+			name: "overlapping paths",
+			prowConfig: prowConfig + `
+- name: team-b
+  job_config_paths:
+  - team-a/b
+  repos:
+  - org-b
+`,
+			expectedErr: `job config path "team-a/b" of tenant "team-b" overlaps with "team-a" of tenant "team-a"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			prowConfigPath := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(prowConfigPath, []byte(tc.prowConfig), 0644); err != nil {
+				t.Fatal(err)
+			}
+			jobConfigPath := filepath.Join(dir, "jobs")
+			if err := os.MkdirAll(jobConfigPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tc.files {
+				file := filepath.Join(jobConfigPath, name)
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := Load(prowConfigPath, jobConfigPath, nil, "")
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -193,15 +193,64 @@ type TideMergeCommitTemplate struct {
 	TitleTemplate string `json:"title,omitempty"`
 	BodyTemplate  string `json:"body,omitempty"`
 
+	// Merge overrides the templates for PRs merged with the merge method.
+	Merge *TideCommitTemplate `json:"merge,omitempty"`
+	// Squash overrides the templates for PRs merged with the squash method.
+	Squash *TideCommitTemplate `json:"squash,omitempty"`
+
 	Title *template.Template `json:"-"`
 	Body  *template.Template `json:"-"`
 }
 
+// TideCommitTemplate holds the templates of the commits of a merge method.
+type TideCommitTemplate struct {
+	TitleTemplate string `json:"title,omitempty"`
+	BodyTemplate  string `json:"body,omitempty"`
+
+	Title *template.Template `json:"-"`
+	Body  *template.Template `json:"-"`
+}
+
+// ForMergeMethod returns the templates for the title and body of the commits
+// of PRs merged with the method. Templates the method doesn't override are
+// the general ones. Either can be nil to use the default of GitHub.
+func (t TideMergeCommitTemplate) ForMergeMethod(method types.PullRequestMergeType) (title, body *template.Template) {
+	title, body = t.Title, t.Body
+	var override *TideCommitTemplate
+	switch method {
+	case types.MergeMerge:
+		override = t.Merge
+	case types.MergeSquash:
+		override = t.Squash
+	}
+	if override != nil {
+		if override.Title != nil {
+			title = override.Title
+		}
+		if override.Body != nil {
+			body = override.Body
+		}
+	}
+	return title, body
+}
+
 // TidePriority contains a list of labels used to prioritize PRs in the merge pool
 type TidePriority struct {
 	Labels []string `json:"labels,omitempty"`
 }
 
+// TideStaleResults limits how stale the results of presubmits may be for Tide
+// to merge PRs on them.
+type TideStaleResults struct {
+	// MaxAge is how long ago presubmits may have finished. Results that only
+	// exist as status contexts have no known age and aren't used if set.
+	MaxAge *metav1.Duration `json:"max_age,omitempty"`
+	// MaxCommitsBehind is how many commits the base branch may have moved on,
+	// following its first parents, since the base presubmits ran against.
+	// Defaults to 0, which requires results for the current base branch.
+	MaxCommitsBehind int `json:"max_commits_behind,omitempty"`
+}
+
 // Tide is config for the tide pool.
 type Tide struct {
 	Gerrit *TideGerritConfig `json:"gerrit,omitempty"`
@@ -223,6 +272,15 @@ type Tide struct {
 	// starting a new one requires to start new instances of all tests.
 	// Use '*' as key to set this globally. Defaults to true.
 	PrioritizeExistingBatchesMap map[string]bool `json:"prioritize_existing_batches,omitempty"`
+	// StaleResultsMap is a key/value pair of an org or org/repo as the key and
+	// limits to how stale the results of presubmits may be for Tide to merge
+	// PRs on them as the value. Use "*" as key to set a global default. PRs
+	// whose results are too stale are retested. By default, results must be
+	// for the current base branch but never expire.
+	StaleResultsMap map[string]TideStaleResults `json:"stale_results,omitempty"`
+	// HistoryRetention is how long the action history archived with
+	// --history-archive-uri can be queried for. Defaults to 720h (30 days).
+	HistoryRetention *metav1.Duration `json:"history_retention,omitempty"`
 
 	TideGitHubConfig `json:",inline"`
 }
@@ -240,9 +298,13 @@ type TideGitHubConfig struct {
 	// the default method of merge. Valid options are squash, rebase, and merge.
 	MergeType map[string]TideOrgMergeType `json:"merge_method,omitempty"`
 
-	// A key/value pair of an org/repo as the key and Go template to override
-	// the default merge commit title and/or message. Template is passed the
-	// PullRequest struct (prow/github/types.go#PullRequest)
+	// A key/value pair of an org/repo, org or "*" as the key and Go templates
+	// to override the default merge commit title and/or message, optionally
+	// per merge method. Templates are passed the PullRequest struct
+	// (prow/tide/codereview.go#CodeReviewCommon) with the additional fields
+	// Labels, the names of the labels of the PR, LinkedIssues, the numbers of
+	// the issues its body says it closes, and Authors, the distinct authors
+	// of its commits as "Name <email>".
 	MergeTemplate map[string]TideMergeCommitTemplate `json:"merge_commit_template,omitempty"`
 
 	// URL for tide status contexts.
@@ -356,6 +418,18 @@ func (t *Tide) PrioritizeExistingBatches(repo OrgRepo) bool {
 	return true
 }
 
+// StaleResults returns the limits to how stale the results of presubmits may
+// be for Tide to merge PRs of the repo on them.
+func (t *Tide) StaleResults(repo OrgRepo) TideStaleResults {
+	if limits, ok := t.StaleResultsMap[repo.String()]; ok {
+		return limits
+	}
+	if limits, ok := t.StaleResultsMap[repo.Org]; ok {
+		return limits
+	}
+	return t.StaleResultsMap["*"]
+}
+
 func (t *Tide) BatchSizeLimit(repo OrgRepo) int {
 	if limit, ok := t.BatchSizeLimitMap[repo.String()]; ok {
 		return limit
@@ -467,7 +541,10 @@ func (t *Tide) OrgRepoBranchMergeMethod(orgRepo OrgRepo, branch string) types.Pu
 func (t *Tide) MergeCommitTemplate(repo OrgRepo) TideMergeCommitTemplate {
 	v, ok := t.MergeTemplate[repo.String()]
 	if !ok {
-		return t.MergeTemplate[repo.Org]
+		v, ok = t.MergeTemplate[repo.Org]
+	}
+	if !ok {
+		return t.MergeTemplate["*"]
 	}
 
 	return v
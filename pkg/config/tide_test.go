@@ -1156,6 +1156,29 @@ func TestMergeTemplate(t *testing.T) {
 	}
 }
 
+func TestMergeTemplateGlobalDefault(t *testing.T) {
+	ti := &Tide{
+		TideGitHubConfig: TideGitHubConfig{
+			MergeTemplate: map[string]TideMergeCommitTemplate{
+				"*":               {TitleTemplate: "{{ .Title }} (#{{ .Number }})"},
+				"kubernetes":      {TitleTemplate: "{{ .Title }}"},
+				"kubernetes/kops": {},
+			},
+		},
+	}
+
+	for repo, expected := range map[OrgRepo]string{
+		{Org: "kubernetes", Repo: "kops"}:          "",
+		{Org: "kubernetes", Repo: "kubernetes"}:    "{{ .Title }}",
+		{Org: "kubernetes-sigs", Repo: "prow"}:     "{{ .Title }} (#{{ .Number }})",
+		{Org: "kubernetes-client", Repo: "python"}: "{{ .Title }} (#{{ .Number }})",
+	} {
+		if actual := ti.MergeCommitTemplate(repo).TitleTemplate; actual != expected {
+			t.Errorf("Expected title %q for %s, got %q", expected, repo.String(), actual)
+		}
+	}
+}
+
 func TestParseTideContextPolicyOptions(t *testing.T) {
 	yes := true
 	no := false
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// UtilityImageVersions configures the tags of the utility images per build
+// cluster.
+type UtilityImageVersions struct {
+	// Clusters maps the aliases of build clusters to the tag of the utility
+	// images of their jobs. The "*" entry applies to clusters without one.
+	// Clusters without a tag use the images of the decoration config as is.
+	Clusters map[string]string `json:"clusters,omitempty"`
+	// Canary makes a share of the jobs use another tag, to roll new utility
+	// images out gradually.
+	Canary *UtilityImagesCanary `json:"canary,omitempty"`
+}
+
+// UtilityImagesCanary makes a random share of the jobs use new utility
+// images. Plank labels the pods it creates while the canary is configured
+// with prow.k8s.io/utility-images set to canary or stable, and counts
+// their results and the failures of their utility containers in the
+// plank_utility_images_jobs and plank_utility_images_failures metrics, so
+// the canary can be compared with the stable images.
+type UtilityImagesCanary struct {
+	// Tag is the tag of the canary utility images.
+	Tag string `json:"tag"`
+	// Percentage is the share of the jobs that use the canary images, from 0
+	// to 100.
+	Percentage int `json:"percentage"`
+	// Clusters restricts the canary to the jobs of these build clusters.
+	// Defaults to all build clusters.
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// See https://github.com/distribution/reference/blob/main/regexp.go.
+var imageTagRegex = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// architectureRegex matches the GOARCH values used in the platforms of images
+// and the kubernetes.io/arch label of nodes.
+var architectureRegex = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+func (v UtilityImageVersions) validate() error {
+	for cluster, tag := range v.Clusters {
+		if !imageTagRegex.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q for cluster %q", tag, cluster)
+		}
+	}
+	if v.Canary == nil {
+		return nil
+	}
+	if !imageTagRegex.MatchString(v.Canary.Tag) {
+		return fmt.Errorf("invalid canary tag %q", v.Canary.Tag)
+	}
+	if v.Canary.Percentage < 0 || v.Canary.Percentage > 100 {
+		return errors.New("canary percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+// CanaryApplies returns whether jobs of the build cluster are part of the
+// canary of utility images.
+func (v UtilityImageVersions) CanaryApplies(cluster string) bool {
+	return v.Canary != nil && (len(v.Canary.Clusters) == 0 || slices.Contains(v.Canary.Clusters, cluster))
+}
+
+// Apply returns the utility images with the tag configured for the build
+// cluster, or with the canary tag if canary is set.
+func (v UtilityImageVersions) Apply(images *prowapi.UtilityImages, cluster string, canary bool) *prowapi.UtilityImages {
+	tag, ok := v.Clusters[cluster]
+	if !ok {
+		tag, ok = v.Clusters["*"]
+	}
+	if canary && v.Canary != nil {
+		tag, ok = v.Canary.Tag, true
+	}
+	if images == nil || !ok {
+		return images
+	}
+	return &prowapi.UtilityImages{
+		CloneRefs:  withImageTag(images.CloneRefs, tag),
+		InitUpload: withImageTag(images.InitUpload, tag),
+		Entrypoint: withImageTag(images.Entrypoint, tag),
+		Sidecar:    withImageTag(images.Sidecar, tag),
+	}
+}
+
+// withImageTag replaces the tag or digest of the image.
+func withImageTag(image, tag string) string {
+	if image == "" {
+		return ""
+	}
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// Colons before the last slash separate the port of the registry.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + tag
+}
+
+// BuildClusterArchitecture returns the CPU architecture of the nodes of the
+// build cluster, or an empty string if it isn't configured.
+func (p Plank) BuildClusterArchitecture(cluster string) string {
+	if arch, ok := p.BuildClusterArchitectures[cluster]; ok {
+		return arch
+	}
+	return p.BuildClusterArchitectures["*"]
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestWithImageTag(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+	}{
+		{image: "gcr.io/k8s-prow/clonerefs:v20240101-abc", expected: "gcr.io/k8s-prow/clonerefs:v2"},
+		{image: "gcr.io/k8s-prow/clonerefs", expected: "gcr.io/k8s-prow/clonerefs:v2"},
+		{image: "registry.local:5000/prow/sidecar", expected: "registry.local:5000/prow/sidecar:v2"},
+		{image: "registry.local:5000/prow/sidecar:v1", expected: "registry.local:5000/prow/sidecar:v2"},
+		{image: "gcr.io/k8s-prow/entrypoint:v1@sha256:0123abcd", expected: "gcr.io/k8s-prow/entrypoint:v2"},
+		{image: "", expected: ""},
+	}
+	for _, tc := range testCases {
+		if actual := withImageTag(tc.image, "v2"); actual != tc.expected {
+			t.Errorf("expected %q to become %q, got %q", tc.image, tc.expected, actual)
+		}
+	}
+}
+
+func TestUtilityImageVersionsApply(t *testing.T) {
+	images := &prowapi.UtilityImages{
+		CloneRefs:  "gcr.io/k8s-prow/clonerefs:v1",
+		InitUpload: "gcr.io/k8s-prow/initupload:v1",
+		Entrypoint: "gcr.io/k8s-prow/entrypoint:v1",
+		Sidecar:    "gcr.io/k8s-prow/sidecar:v1",
+	}
+	versions := UtilityImageVersions{Clusters: map[string]string{"*": "v0", "gpu": "v1.5"}, Canary: &UtilityImagesCanary{Tag: "v2", Percentage: 5}}
+
+	testCases := []struct {
+		name     string
+		cluster  string
+		canary   bool
+		expected string
+	}{
+		{name: "cluster tag", cluster: "gpu", expected: "v1.5"},
+		{name: "default tag", cluster: "default", expected: "v0"},
+		{name: "canary tag", cluster: "gpu", canary: true, expected: "v2"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := &prowapi.UtilityImages{
+				CloneRefs:  "gcr.io/k8s-prow/clonerefs:" + tc.expected,
+				InitUpload: "gcr.io/k8s-prow/initupload:" + tc.expected,
+				Entrypoint: "gcr.io/k8s-prow/entrypoint:" + tc.expected,
+				Sidecar:    "gcr.io/k8s-prow/sidecar:" + tc.expected,
+			}
+			if diff := cmp.Diff(expected, versions.Apply(images, tc.cluster, tc.canary)); diff != "" {
+				t.Errorf("unexpected utility images (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUtilityImageVersionsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		versions    UtilityImageVersions
+		expectedErr string
+	}{
+		{
+			name:     "valid",
+			versions: UtilityImageVersions{Clusters: map[string]string{"*": "v20240101-abc"}, Canary: &UtilityImagesCanary{Tag: "v20240201-def", Percentage: 5}},
+		},
+		{
+			name:        "invalid cluster tag",
+			versions:    UtilityImageVersions{Clusters: map[string]string{"gpu": "v1:latest"}},
+			expectedErr: `invalid tag "v1:latest" for cluster "gpu"`,
+		},
+		{
+			name:        "missing canary tag",
+			versions:    UtilityImageVersions{Canary: &UtilityImagesCanary{Percentage: 5}},
+			expectedErr: `invalid canary tag ""`,
+		},
+		{
+			name:        "percentage out of range",
+			versions:    UtilityImageVersions{Canary: &UtilityImagesCanary{Tag: "v2", Percentage: 101}},
+			expectedErr: "canary percentage must be between 0 and 100",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.versions.validate()
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowjobsv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
 
@@ -63,6 +64,65 @@ func (in *Brancher) DeepCopy() *Brancher {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeExpression) DeepCopyInto(out *ChangeExpression) {
+	*out = *in
+	if in.AnyChanged != nil {
+		in, out := &in.AnyChanged, &out.AnyChanged
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OnlyChanged != nil {
+		in, out := &in.OnlyChanged, &out.OnlyChanged
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinChangedFiles != nil {
+		in, out := &in.MinChangedFiles, &out.MinChangedFiles
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxChangedFiles != nil {
+		in, out := &in.MaxChangedFiles, &out.MaxChangedFiles
+		*out = new(int)
+		**out = **in
+	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]ChangeExpression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]ChangeExpression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Not != nil {
+		in, out := &in.Not, &out.Not
+		*out = new(ChangeExpression)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.re != nil {
+		in, out := &in.re, &out.re
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeExpression.
+func (in *ChangeExpression) DeepCopy() *ChangeExpression {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeExpression)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopyableRegexp.
 func (in *CopyableRegexp) DeepCopy() *CopyableRegexp {
 	if in == nil {
@@ -88,6 +148,11 @@ func (in *JobBase) DeepCopyInto(out *JobBase) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.SchedulingGates != nil {
+		in, out := &in.SchedulingGates, &out.SchedulingGates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Spec != nil {
 		in, out := &in.Spec, &out.Spec
 		*out = new(v1.PodSpec)
@@ -120,11 +185,26 @@ func (in *JobBase) DeepCopyInto(out *JobBase) {
 		*out = new(prowjobsv1.RerunAuthConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RerunOverrides != nil {
+		in, out := &in.RerunOverrides, &out.RerunOverrides
+		*out = new(prowjobsv1.RerunOverrides)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ProwJobDefault != nil {
 		in, out := &in.ProwJobDefault, &out.ProwJobDefault
 		*out = new(prowjobsv1.ProwJobDefault)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(JobOwner)
+		**out = **in
+	}
+	if in.SLO != nil {
+		in, out := &in.SLO, &out.SLO
+		*out = new(JobSLO)
+		(*in).DeepCopyInto(*out)
+	}
 	in.UtilityConfig.DeepCopyInto(&out.UtilityConfig)
 	return
 }
@@ -139,6 +219,32 @@ func (in *JobBase) DeepCopy() *JobBase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobSLO) DeepCopyInto(out *JobSLO) {
+	*out = *in
+	if in.MaxDuration != nil {
+		in, out := &in.MaxDuration, &out.MaxDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSLO.
+func (in *JobSLO) DeepCopy() *JobSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(JobSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Postsubmit) DeepCopyInto(out *Postsubmit) {
 	*out = *in
@@ -291,6 +397,11 @@ func (in *ProwYAML) DeepCopy() *ProwYAML {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegexpChangeMatcher) DeepCopyInto(out *RegexpChangeMatcher) {
 	*out = *in
+	if in.RunIfChangedExpression != nil {
+		in, out := &in.RunIfChangedExpression, &out.RunIfChangedExpression
+		*out = new(ChangeExpression)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.reChanges != nil {
 		in, out := &in.reChanges, &out.reChanges
 		*out = (*in).DeepCopy()
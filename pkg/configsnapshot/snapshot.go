@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configsnapshot takes snapshots of the effective Prow config and
+// plugins config and keeps them in object storage, so that a bad config
+// change can be diffed against and rolled back to a known good state.
+package configsnapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const (
+	// ConfigFile is the name of the merged Prow config of a snapshot.
+	ConfigFile = "config.yaml"
+	// PluginsFile is the name of the merged plugins config of a snapshot.
+	PluginsFile = "plugins.yaml"
+	// MetadataFile is the name of the metadata of a snapshot.
+	MetadataFile = "metadata.json"
+
+	// idLayout formats the time a snapshot is taken into its ID, so that IDs
+	// sort chronologically.
+	idLayout = "20060102-150405"
+)
+
+// Metadata describes a snapshot.
+type Metadata struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	// ConfigVersion is the config_version_sha of the snapshotted config.
+	ConfigVersion string `json:"config_version,omitempty"`
+	ConfigSHA256  string `json:"config_sha256"`
+	PluginsSHA256 string `json:"plugins_sha256,omitempty"`
+	// Restorable is true if the snapshot loads back into a valid config.
+	// Snapshots that don't are kept for diffing but can't be rolled back to.
+	Restorable   bool   `json:"restorable"`
+	RestoreError string `json:"restore_error,omitempty"`
+}
+
+// Snapshot is the effective config at a point in time.
+type Snapshot struct {
+	Metadata
+	// Config is the merged Prow config, including the jobs and the
+	// supplemental configs.
+	Config []byte
+	// Plugins is the merged plugins config, empty if the plugins config
+	// isn't snapshotted.
+	Plugins []byte
+}
+
+// Take snapshots the config and, if set, the plugins config.
+func Take(cfg *config.Config, pluginsCfg *plugins.Configuration, now time.Time) (*Snapshot, error) {
+	rawConfig, err := MarshalConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var rawPlugins []byte
+	if pluginsCfg != nil {
+		if rawPlugins, err = yaml.Marshal(pluginsCfg); err != nil {
+			return nil, fmt.Errorf("failed to marshal the plugins config: %w", err)
+		}
+	}
+	s := &Snapshot{
+		Metadata: Metadata{
+			ID:            now.UTC().Format(idLayout),
+			Created:       now.UTC(),
+			ConfigVersion: cfg.ConfigVersionSHA,
+			ConfigSHA256:  hash(rawConfig),
+		},
+		Config:  rawConfig,
+		Plugins: rawPlugins,
+	}
+	if rawPlugins != nil {
+		s.PluginsSHA256 = hash(rawPlugins)
+	}
+	if err := s.verify(); err != nil {
+		s.RestoreError = err.Error()
+	} else {
+		s.Restorable = true
+	}
+	return s, nil
+}
+
+// MarshalConfig serializes the effective config the way snapshots hold it.
+func MarshalConfig(cfg *config.Config) ([]byte, error) {
+	c := &config.Config{JobConfig: cfg.JobConfig, ProwConfig: cfg.ProwConfig}
+	// Loading the config converts the deprecated pubsub_subscriptions into
+	// pubsub_triggers, which refuse to be set together.
+	c.PubSubSubscriptions = nil
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the config: %w", err)
+	}
+	return raw, nil
+}
+
+// Changed returns whether the snapshot holds another config than the one
+// described by the metadata.
+func (s *Snapshot) Changed(previous *Metadata) bool {
+	return previous == nil || previous.ConfigSHA256 != s.ConfigSHA256 || previous.PluginsSHA256 != s.PluginsSHA256
+}
+
+// verify loads the snapshot back the way components load their config.
+func (s *Snapshot) verify() error {
+	dir, err := os.MkdirTemp("", "config-snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, ConfigFile)
+	if err := os.WriteFile(configPath, s.Config, 0644); err != nil {
+		return err
+	}
+	if _, err := config.Load(configPath, "", nil, ""); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if s.Plugins == nil {
+		return nil
+	}
+	pluginsPath := filepath.Join(dir, PluginsFile)
+	if err := os.WriteFile(pluginsPath, s.Plugins, 0644); err != nil {
+		return err
+	}
+	if err := (&plugins.ConfigAgent{}).Load(pluginsPath, nil, "", false, false); err != nil {
+		return fmt.Errorf("plugins config: %w", err)
+	}
+	return nil
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
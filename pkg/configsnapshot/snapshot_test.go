@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configsnapshot
+
+import (
+	"bytes"
+	"context"
+	stdio "io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// fakeOpener stores objects under their full storage path.
+type fakeOpener struct {
+	io.Opener
+	objects map[string]string
+}
+
+func (f *fakeOpener) Reader(_ context.Context, path string) (io.ReadCloser, error) {
+	content, ok := f.objects[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return stdio.NopCloser(bytes.NewBufferString(content)), nil
+}
+
+type fakeWriter struct {
+	bytes.Buffer
+	close func(string)
+}
+
+func (w *fakeWriter) Close() error {
+	w.close(w.String())
+	return nil
+}
+
+func (f *fakeOpener) Writer(_ context.Context, path string, _ ...io.WriterOptions) (io.WriteCloser, error) {
+	return &fakeWriter{close: func(content string) { f.objects[path] = content }}, nil
+}
+
+type fakeIterator []io.ObjectAttributes
+
+func (it *fakeIterator) Next(context.Context) (io.ObjectAttributes, error) {
+	if len(*it) == 0 {
+		return io.ObjectAttributes{}, stdio.EOF
+	}
+	attrs := (*it)[0]
+	*it = (*it)[1:]
+	return attrs, nil
+}
+
+func (f *fakeOpener) Iterator(_ context.Context, prefix, delimiter string) (io.ObjectIterator, error) {
+	bucket := prefix[:strings.Index(prefix[len("gs://"):], "/")+len("gs://")+1]
+	var it fakeIterator
+	dirs := sets.New[string]()
+	for path := range f.objects {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(path, bucket)
+		rest := strings.TrimPrefix(path, prefix)
+		if delimiter != "" && strings.Contains(rest, delimiter) {
+			dirs.Insert(strings.TrimPrefix(prefix, bucket) + rest[:strings.Index(rest, delimiter)+1])
+			continue
+		}
+		it = append(it, io.ObjectAttributes{Name: name, ObjName: name[strings.LastIndex(name, "/")+1:]})
+	}
+	for _, dir := range sets.List(dirs) {
+		it = append(it, io.ObjectAttributes{Name: dir, IsDir: true})
+	}
+	sort.Slice(it, func(i, j int) bool { return it[i].Name < it[j].Name })
+	return &it, nil
+}
+
+func loadConfig(t *testing.T, raw string) *config.Config {
+	t.Helper()
+	path := t.TempDir() + "/config.yaml"
+	if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path, "", nil, "")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return cfg
+}
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	cfg := loadConfig(t, `
+prowjob_namespace: prow
+periodics:
+- name: ci-periodic
+  interval: 1h
+  spec:
+    containers:
+    - image: alpine
+`)
+
+	snapshot, err := Take(cfg, &plugins.Configuration{Plugins: plugins.Plugins{"org/repo": {Plugins: []string{"lgtm"}}}}, now)
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if snapshot.ID != "20240601-123000" {
+		t.Errorf("expected ID 20240601-123000, got %s", snapshot.ID)
+	}
+	if !snapshot.Restorable {
+		t.Errorf("expected the snapshot to be restorable, got %s", snapshot.RestoreError)
+	}
+	if !strings.Contains(string(snapshot.Config), "name: ci-periodic") {
+		t.Errorf("expected the snapshot to hold the periodic, got:\n%s", snapshot.Config)
+	}
+	if snapshot.PluginsSHA256 == "" || !strings.Contains(string(snapshot.Plugins), "lgtm") {
+		t.Errorf("expected the snapshot to hold the plugins config, got:\n%s", snapshot.Plugins)
+	}
+
+	again, err := Take(cfg, &plugins.Configuration{Plugins: plugins.Plugins{"org/repo": {Plugins: []string{"lgtm"}}}}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if again.Changed(&snapshot.Metadata) {
+		t.Error("expected the snapshot of the same config to be unchanged")
+	}
+	withoutPlugins, err := Take(cfg, nil, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if !withoutPlugins.Changed(&snapshot.Metadata) {
+		t.Error("expected the snapshot without plugins config to be changed")
+	}
+}
+
+func TestTakeUnrestorable(t *testing.T) {
+	cfg := loadConfig(t, "prowjob_namespace: prow\n")
+	// Configs only get invalid through bugs of the loading code, which is
+	// what the verification of snapshots catches.
+	cfg.Periodics = []config.Periodic{{JobBase: config.JobBase{Name: "no-spec"}, Interval: "1h"}}
+
+	snapshot, err := Take(cfg, nil, time.Now())
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if snapshot.Restorable || snapshot.RestoreError == "" {
+		t.Errorf("expected the snapshot not to be restorable, got %+v", snapshot.Metadata)
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	opener := &fakeOpener{objects: map[string]string{}}
+	store := NewStore(opener, "gs://bucket/snapshots/")
+
+	latest, err := store.Latest(ctx)
+	if err != nil || latest != nil {
+		t.Fatalf("expected no latest snapshot, got %v and error %v", latest, err)
+	}
+
+	first := &Snapshot{
+		Metadata: Metadata{ID: "20240601-120000", ConfigSHA256: "a", Restorable: true},
+		Config:   []byte("prowjob_namespace: prow\n"),
+	}
+	second := &Snapshot{
+		Metadata: Metadata{ID: "20240601-130000", ConfigSHA256: "b", PluginsSHA256: "c", Restorable: true},
+		Config:   []byte("prowjob_namespace: test-pods\n"),
+		Plugins:  []byte("plugins: {}\n"),
+	}
+	for _, snapshot := range []*Snapshot{first, second} {
+		if err := store.Save(ctx, snapshot); err != nil {
+			t.Fatalf("failed to save snapshot: %v", err)
+		}
+	}
+	// A snapshot being written has no metadata yet.
+	opener.objects["gs://bucket/snapshots/20240601-140000/config.yaml"] = "prowjob_namespace: partial\n"
+
+	latest, err = store.Latest(ctx)
+	if err != nil {
+		t.Fatalf("failed to get latest snapshot: %v", err)
+	}
+	if diff := cmp.Diff(&second.Metadata, latest); diff != "" {
+		t.Errorf("unexpected latest snapshot (-want +got):\n%s", diff)
+	}
+
+	listed, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if diff := cmp.Diff([]Metadata{second.Metadata, first.Metadata}, listed); diff != "" {
+		t.Errorf("unexpected snapshots (-want +got):\n%s", diff)
+	}
+
+	for _, expected := range []*Snapshot{first, second} {
+		actual, err := store.Get(ctx, expected.ID)
+		if err != nil {
+			t.Fatalf("failed to get snapshot: %v", err)
+		}
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Errorf("unexpected snapshot (-want +got):\n%s", diff)
+		}
+	}
+	if _, err := store.Get(ctx, "20240601-150000"); !io.IsNotExist(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := &Snapshot{Metadata: Metadata{ID: "1"}, Config: []byte("a: 1\nb: 2\n"), Plugins: []byte("plugins: {}\n")}
+	to := &Snapshot{Metadata: Metadata{ID: "2"}, Config: []byte("a: 1\nb: 3\n"), Plugins: []byte("plugins: {}\n")}
+
+	diff, err := Diff(from, to)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	expected := `--- 1/config.yaml
++++ 2/config.yaml
+@@ -1,2 +1,2 @@
+ a: 1
+-b: 2
++b: 3
+`
+	if diff != expected {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+
+	if diff, err := Diff(from, from); err != nil || diff != "" {
+		t.Errorf("expected no diff, got %q and error %v", diff, err)
+	}
+}
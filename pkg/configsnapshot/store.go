@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configsnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdio "io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// latestFile holds the ID of the latest snapshot.
+const latestFile = "latest"
+
+// Store keeps snapshots under a storage path, each in a directory named
+// after its ID:
+//
+//	<path>/<id>/config.yaml
+//	<path>/<id>/plugins.yaml
+//	<path>/<id>/metadata.json
+//	<path>/latest
+type Store struct {
+	opener io.Opener
+	path   string
+}
+
+// NewStore returns a store keeping snapshots under the gs:// or s3:// path.
+func NewStore(opener io.Opener, path string) *Store {
+	return &Store{opener: opener, path: strings.TrimSuffix(path, "/")}
+}
+
+func (s *Store) object(id, name string) string {
+	return s.path + "/" + path.Join(id, name)
+}
+
+// Save writes the snapshot and marks it as the latest one. The metadata is
+// written after the content, so listed snapshots are always complete.
+func (s *Store) Save(ctx context.Context, snapshot *Snapshot) error {
+	log := logrus.WithField("snapshot", snapshot.ID)
+	metadata, err := json.Marshal(snapshot.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	objects := []struct {
+		name    string
+		content []byte
+	}{
+		{name: ConfigFile, content: snapshot.Config},
+		{name: PluginsFile, content: snapshot.Plugins},
+		{name: MetadataFile, content: metadata},
+	}
+	for _, object := range objects {
+		if object.content == nil {
+			continue
+		}
+		if err := io.WriteContent(ctx, log, s.opener, s.object(snapshot.ID, object.name), object.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", object.name, err)
+		}
+	}
+	if err := io.WriteContent(ctx, log, s.opener, s.path+"/"+latestFile, []byte(snapshot.ID)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", latestFile, err)
+	}
+	return nil
+}
+
+// Latest returns the metadata of the latest snapshot, nil if there is none.
+func (s *Store) Latest(ctx context.Context) (*Metadata, error) {
+	id, err := io.ReadContent(ctx, logrus.NewEntry(logrus.StandardLogger()), s.opener, s.path+"/"+latestFile)
+	if io.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", latestFile, err)
+	}
+	return s.metadata(ctx, strings.TrimSpace(string(id)))
+}
+
+// List returns the metadata of the snapshots, latest first.
+func (s *Store) List(ctx context.Context) ([]Metadata, error) {
+	it, err := s.opener.Iterator(ctx, s.path+"/", "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.path, err)
+	}
+	var snapshots []Metadata
+	for {
+		attrs, err := it.Next(ctx)
+		if errors.Is(err, stdio.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.path, err)
+		}
+		if !attrs.IsDir {
+			continue
+		}
+		metadata, err := s.metadata(ctx, path.Base(attrs.Name))
+		if io.IsNotExist(err) {
+			// The snapshot is still being written.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *metadata)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ID > snapshots[j].ID })
+	return snapshots, nil
+}
+
+// Get reads the snapshot with the ID.
+func (s *Store) Get(ctx context.Context, id string) (*Snapshot, error) {
+	metadata, err := s.metadata(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Metadata: *metadata}
+	log := logrus.WithField("snapshot", id)
+	if snapshot.Config, err = io.ReadContent(ctx, log, s.opener, s.object(id, ConfigFile)); err != nil {
+		return nil, fmt.Errorf("failed to read %s of snapshot %s: %w", ConfigFile, id, err)
+	}
+	if metadata.PluginsSHA256 == "" {
+		return snapshot, nil
+	}
+	if snapshot.Plugins, err = io.ReadContent(ctx, log, s.opener, s.object(id, PluginsFile)); err != nil {
+		return nil, fmt.Errorf("failed to read %s of snapshot %s: %w", PluginsFile, id, err)
+	}
+	return snapshot, nil
+}
+
+func (s *Store) metadata(ctx context.Context, id string) (*Metadata, error) {
+	raw, err := io.ReadContent(ctx, logrus.WithField("snapshot", id), s.opener, s.object(id, MetadataFile))
+	if err != nil {
+		return nil, err
+	}
+	var metadata Metadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of snapshot %s: %w", MetadataFile, id, err)
+	}
+	return &metadata, nil
+}
+
+// ValidatePath checks that the path is a gs:// or s3:// path snapshots can
+// be kept under.
+func ValidatePath(p string) error {
+	if p == "" {
+		return errors.New("the snapshot path must be set")
+	}
+	if _, _, _, err := providers.ParseStoragePath(p); err != nil {
+		return fmt.Errorf("invalid snapshot path %q: %w", p, err)
+	}
+	return nil
+}
+
+// Diff returns the unified diff of the configs and plugins configs of the
+// snapshots, empty if they are the same.
+func Diff(from, to *Snapshot) (string, error) {
+	var diff strings.Builder
+	for _, file := range []struct {
+		name     string
+		from, to []byte
+	}{
+		{name: ConfigFile, from: from.Config, to: to.Config},
+		{name: PluginsFile, from: from.Plugins, to: to.Plugins},
+	} {
+		d, err := UnifiedDiff(path.Join(from.ID, file.name), path.Join(to.ID, file.name), file.from, file.to)
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", file.name, err)
+		}
+		diff.WriteString(d)
+	}
+	return diff.String(), nil
+}
+
+// UnifiedDiff returns the unified diff of the contents, empty if they are the
+// same.
+func UnifiedDiff(fromName, toName string, from, to []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines(from),
+		B:        lines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// lines splits the content into lines, keeping their line breaks.
+func lines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	l := strings.SplitAfter(string(content), "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
@@ -20,6 +20,7 @@ package crier
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -46,6 +47,14 @@ type ReportClient interface {
 	ShouldReport(ctx context.Context, log *logrus.Entry, pj *prowv1.ProwJob) bool
 }
 
+// QueueReportClient is implemented by reporters that report the queue
+// position of triggered jobs, so that they report them again whenever plank
+// updates the position.
+type QueueReportClient interface {
+	ReportClient
+	ReportsQueue() bool
+}
+
 // reconciler struct defines how a controller should encapsulate
 // logging, client connectivity, informing (list and watching)
 // queueing, and handling of resource changes
@@ -132,7 +141,7 @@ func (r *reconciler) reconcile(ctx context.Context, log *logrus.Entry, req recon
 	}
 
 	// already reported current state
-	if pj.Status.PrevReportStates[r.reporter.GetName()] == pj.Status.State {
+	if pj.Status.PrevReportStates[r.reporter.GetName()] == pj.Status.State && !r.queueChanged(&pj) {
 		log.Trace("Already reported")
 		return nil, nil
 	}
@@ -175,6 +184,18 @@ func (r *reconciler) reconcile(ctx context.Context, log *logrus.Entry, req recon
 	return nil, lastErr
 }
 
+// queueChanged tells whether the reporter reports queue positions and hasn't
+// reported the current position of the triggered job yet.
+func (r *reconciler) queueChanged(pj *prowv1.ProwJob) bool {
+	if pj.Status.State != prowv1.TriggeredState || pj.Status.Queue == nil {
+		return false
+	}
+	if reporter, ok := r.reporter.(QueueReportClient); !ok || !reporter.ReportsQueue() {
+		return false
+	}
+	return !slices.Contains(pj.Status.Queue.ReportedBy, r.reporter.GetName())
+}
+
 func (r *reconciler) shouldHandle(pj *prowv1.ProwJob) bool {
 	refs := pj.Spec.ExtraRefs
 	if pj.Spec.Refs != nil {
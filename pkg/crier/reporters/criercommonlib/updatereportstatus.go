@@ -20,6 +20,7 @@ package criercommonlib
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -32,7 +33,7 @@ import (
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
 
-func updateReportState(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entry, reportedState prowv1.ProwJobState, pjclientset ctrlruntimeclient.Client, reporterName string) error {
+func updateReportState(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entry, reportedState prowv1.ProwJobState, reportedQueue *prowv1.QueueStatus, pjclientset ctrlruntimeclient.Client, reporterName string) error {
 	// update pj report status
 	newpj := pj.DeepCopy()
 	// we set omitempty on PrevReportStates, so here we need to init it if is nil
@@ -40,6 +41,11 @@ func updateReportState(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entr
 		newpj.Status.PrevReportStates = map[string]prowv1.ProwJobState{}
 	}
 	newpj.Status.PrevReportStates[reporterName] = reportedState
+	// Plank may have moved the job in the queue since it was reported.
+	queueReported := reportedQueue != nil && newpj.Status.Queue.SamePosition(reportedQueue)
+	if queueReported && !slices.Contains(newpj.Status.Queue.ReportedBy, reporterName) {
+		newpj.Status.Queue.ReportedBy = append(newpj.Status.Queue.ReportedBy, reporterName)
+	}
 
 	if err := pjclientset.Patch(ctx, newpj, ctrlruntimeclient.MergeFrom(pj)); err != nil {
 		return fmt.Errorf("failed to patch: %w", err)
@@ -53,6 +59,9 @@ func updateReportState(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entr
 		if err := pjclientset.Get(ctx, name, pj); err != nil {
 			return false, err
 		}
+		if queueReported && (pj.Status.Queue == nil || !slices.Contains(pj.Status.Queue.ReportedBy, reporterName)) {
+			return false, nil
+		}
 		if pj.Status.PrevReportStates != nil &&
 			pj.Status.PrevReportStates[reporterName] == reportedState {
 			return true, nil
@@ -66,6 +75,7 @@ func updateReportState(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entr
 
 func UpdateReportStateWithRetries(ctx context.Context, pj *prowv1.ProwJob, log *logrus.Entry, pjclientset ctrlruntimeclient.Client, reporterName string) error {
 	reportState := pj.Status.State
+	reportedQueue := pj.Status.Queue.DeepCopy()
 	log = log.WithFields(logrus.Fields{
 		"prowjob":   pj.Name,
 		"jobName":   pj.Spec.Job,
@@ -80,7 +90,7 @@ func UpdateReportStateWithRetries(ctx context.Context, pj *prowv1.ProwJob, log *
 		}
 		// Must not wrap until we have kube 1.19, otherwise the RetryOnConflict won't recognize conflicts
 		// correctly
-		return updateReportState(ctx, pj, log, reportState, pjclientset, reporterName)
+		return updateReportState(ctx, pj, log, reportState, reportedQueue, pjclientset, reporterName)
 	}); err != nil {
 		// Very subpar, we will report again. But even if we didn't do that now, we would do so
 		// latest when crier gets restarted. In an ideal world, all reporters are idempotent and
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exec contains a crier reporter running the commands configured in
+// exec_reporter_configs when jobs reach given states. It is an escape hatch
+// for integrations that don't warrant a reporter of their own.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+const (
+	reporterName = "execreporter"
+
+	// maxOutput is how much of the output of commands is logged.
+	maxOutput = 10 * 1024
+	// busyRequeueDelay is how long reports wait when one of their commands
+	// already runs as often as it may.
+	busyRequeueDelay = 10 * time.Second
+	// waitDelay is how long to wait for the output of killed commands, in
+	// case they left children holding it open.
+	waitDelay = 5 * time.Second
+)
+
+// Client runs the commands configured for the states of jobs.
+type Client struct {
+	config config.Getter
+	dryRun bool
+
+	lock sync.Mutex
+	// running counts the running instances of commands by name.
+	running map[string]int
+}
+
+// NewReporter returns a reporter running the commands of exec_reporter_configs.
+func NewReporter(cfg config.Getter, dryRun bool) *Client {
+	return &Client{config: cfg, dryRun: dryRun, running: map[string]int{}}
+}
+
+// GetName returns the name of the reporter.
+func (c *Client) GetName() string {
+	return reporterName
+}
+
+// ShouldReport returns whether any command is configured for the job in its
+// current state.
+func (c *Client) ShouldReport(_ context.Context, _ *logrus.Entry, pj *prowapi.ProwJob) bool {
+	return len(c.commands(pj)) > 0
+}
+
+func (c *Client) commands(pj *prowapi.ProwJob) []config.ExecReporter {
+	var commands []config.ExecReporter
+	for _, cfg := range c.config().ExecReporterConfigs {
+		if cfg.ShouldReport(pj) {
+			commands = append(commands, cfg)
+		}
+	}
+	return commands
+}
+
+// Report runs the commands configured for the job in its current state. The
+// commands aren't retried, as they may not be idempotent: their failures are
+// only logged.
+func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *prowapi.ProwJob) ([]*prowapi.ProwJob, *reconcile.Result, error) {
+	commands := c.commands(pj)
+	if !c.acquire(commands) {
+		log.Debug("Commands are running as often as they may, retrying later.")
+		return nil, &reconcile.Result{RequeueAfter: busyRequeueDelay}, nil
+	}
+	defer c.release(commands)
+
+	input, err := json.Marshal(pj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal prowjob: %w", err)
+	}
+	for _, cfg := range commands {
+		log := log.WithField("command", cfg.Name)
+		if c.dryRun {
+			log.WithField("args", cfg.Command).Info("Not running command because dry-run is enabled.")
+			continue
+		}
+		start := time.Now()
+		output, err := run(ctx, cfg, pj, input)
+		log = log.WithField("duration", time.Since(start).String()).WithField("output", output)
+		if err != nil {
+			log.WithError(err).Warn("Command failed.")
+			continue
+		}
+		log.Debug("Command succeeded.")
+	}
+	return []*prowapi.ProwJob{pj}, nil, nil
+}
+
+// acquire reserves an instance of every command, or none if any of them
+// already runs as often as it may.
+func (c *Client) acquire(commands []config.ExecReporter) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, cfg := range commands {
+		if c.running[cfg.Name] >= cfg.MaxConcurrency {
+			return false
+		}
+	}
+	for _, cfg := range commands {
+		c.running[cfg.Name]++
+	}
+	return true
+}
+
+func (c *Client) release(commands []config.ExecReporter) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, cfg := range commands {
+		c.running[cfg.Name]--
+	}
+}
+
+// run runs the command in a temporary directory with the ProwJob on stdin and
+// returns the beginning of its combined output.
+func run(ctx context.Context, cfg config.ExecReporter, pj *prowapi.ProwJob, input []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout.Duration)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "exec-reporter-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	output := &limitedBuffer{limit: maxOutput}
+	cmd := osexec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = environment(cfg, pj, dir)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = waitDelay
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output.String(), fmt.Errorf("timed out after %s", cfg.Timeout.Duration)
+	}
+	return output.String(), err
+}
+
+// environment returns the environment of the command. Only PATH is inherited
+// from crier, so that credentials in its environment aren't passed on. This
+// isn't a sandbox, the command runs as crier and can still read the files
+// crier can, like its mounted credentials.
+func environment(cfg config.ExecReporter, pj *prowapi.ProwJob, dir string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"PROW_JOB_ID=" + pj.Name,
+		"PROW_JOB_NAME=" + pj.Spec.Job,
+		"PROW_JOB_TYPE=" + string(pj.Spec.Type),
+		"PROW_JOB_STATE=" + string(pj.Status.State),
+		"PROW_JOB_URL=" + pj.Status.URL,
+	}
+	names := make([]string, 0, len(cfg.Env))
+	for name := range cfg.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+cfg.Env[name])
+	}
+	return env
+}
+
+// limitedBuffer keeps the first bytes written to it and drops the rest, so
+// verbose commands can't exhaust the memory of crier. It doesn't embed the
+// buffer, as io.Copy would bypass the limit through its ReadFrom method.
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buffer.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buffer.String() + "... (truncated)"
+	}
+	return b.buffer.String()
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func testJob() *prowapi.ProwJob {
+	return &prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "0123"},
+		Spec:       prowapi.ProwJobSpec{Job: "ci-e2e", Type: prowapi.PeriodicJob},
+		Status:     prowapi.ProwJobStatus{State: prowapi.FailureState, URL: "https://prow/view/0123"},
+	}
+}
+
+func command(name string, args ...string) config.ExecReporter {
+	cfg := config.ExecReporter{
+		Name:              name,
+		Command:           args,
+		JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState},
+	}
+	if err := cfg.DefaultAndValidate(); err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func TestShouldReport(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   config.ExecReporter
+		expected bool
+	}{
+		{
+			name:     "state matches",
+			config:   config.ExecReporter{JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState}},
+			expected: true,
+		},
+		{
+			name:   "state doesn't match",
+			config: config.ExecReporter{JobStatesToReport: []prowapi.ProwJobState{prowapi.SuccessState}},
+		},
+		{
+			name:   "type doesn't match",
+			config: config.ExecReporter{JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState}, JobTypesToReport: []prowapi.ProwJobType{prowapi.PresubmitJob}},
+		},
+		{
+			name:     "job matches pattern",
+			config:   config.ExecReporter{JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState}, Jobs: []string{"pull-*", "ci-*"}},
+			expected: true,
+		},
+		{
+			name:   "job doesn't match patterns",
+			config: config.ExecReporter{JobStatesToReport: []prowapi.ProwJobState{prowapi.FailureState}, Jobs: []string{"pull-*"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewReporter(func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{ExecReporterConfigs: []config.ExecReporter{tc.config}}}
+			}, false)
+			if actual := c.ShouldReport(context.Background(), logrus.NewEntry(logrus.New()), testJob()); actual != tc.expected {
+				t.Errorf("expected ShouldReport to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("CRIER_SECRET", "hunter2")
+	pj := testJob()
+	input, err := json.Marshal(pj)
+	if err != nil {
+		t.Fatalf("failed to marshal prowjob: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		config         config.ExecReporter
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name:           "prowjob is on stdin",
+			config:         command("stdin", "cat"),
+			expectedOutput: string(input),
+		},
+		{
+			name:           "environment describes the job and leaves out that of crier",
+			config:         command("env", "sh", "-c", `echo "$PROW_JOB_ID $PROW_JOB_NAME $PROW_JOB_TYPE $PROW_JOB_STATE $PROW_JOB_URL ${CRIER_SECRET:-unset} $EXTRA"`),
+			expectedOutput: "0123 ci-e2e periodic failure https://prow/view/0123 unset extra\n",
+		},
+		{
+			name:           "failure",
+			config:         command("fail", "sh", "-c", "echo oops; exit 3"),
+			expectedOutput: "oops\n",
+			expectedErr:    "exit status 3",
+		},
+		{
+			name:        "timeout",
+			config:      command("slow", "sleep", "10"),
+			expectedErr: "timed out after 100ms",
+		},
+		{
+			name:           "output is truncated",
+			config:         command("verbose", "sh", "-c", "yes | head -c 20000"),
+			expectedOutput: strings.Repeat("y\n", maxOutput/2) + "... (truncated)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Env = map[string]string{"EXTRA": "extra"}
+			if tc.config.Name == "slow" {
+				tc.config.Timeout = &metav1.Duration{Duration: 100 * time.Millisecond}
+			}
+			output, err := run(context.Background(), tc.config, pj, input)
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			if output != tc.expectedOutput {
+				t.Errorf("expected output %q, got %q", tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestReportConcurrency(t *testing.T) {
+	limited := command("limited", "true")
+	other := command("other", "true")
+	c := NewReporter(func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{ExecReporterConfigs: []config.ExecReporter{limited, other}}}
+	}, false)
+	log := logrus.NewEntry(logrus.New())
+
+	// Another report is running the limited command.
+	if !c.acquire([]config.ExecReporter{limited}) {
+		t.Fatal("expected to acquire the command")
+	}
+	pjs, requeue, err := c.Report(context.Background(), log, testJob())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requeue == nil || len(pjs) != 0 {
+		t.Fatalf("expected the report to be requeued, got %v and %d jobs", requeue, len(pjs))
+	}
+	if c.running["other"] != 0 {
+		t.Errorf("expected no instance of other command to be reserved, got %d", c.running["other"])
+	}
+
+	c.release([]config.ExecReporter{limited})
+	pjs, requeue, err = c.Report(context.Background(), log, testJob())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requeue != nil || len(pjs) != 1 {
+		t.Fatalf("expected the job to be reported, got %v and %d jobs", requeue, len(pjs))
+	}
+	for name, running := range c.running {
+		if running != 0 {
+			t.Errorf("expected command %s not to be running, got %d instances", name, running)
+		}
+	}
+}
@@ -143,7 +143,7 @@ func (gr *gcsReporter) reportStartedJob(ctx context.Context, log *logrus.Entry,
 		}
 	}
 	s := downwardapi.PjToStarted(pj, cloneRecord)
-	s.Metadata = metadata.Metadata{"uploader": "crier"}
+	s.Metadata = util.CrierMetadata(pj, gr.cfg().ProwJobMetadataPropagation)
 
 	output, err := json.MarshalIndent(s, "", "\t")
 	if err != nil {
@@ -160,7 +160,7 @@ func (gr *gcsReporter) reportStartedJob(ctx context.Context, log *logrus.Entry,
 
 // reportFinishedJob uploads a finished.json for the job, iff one did not already exist.
 func (gr *gcsReporter) reportFinishedJob(ctx context.Context, log *logrus.Entry, pj *prowv1.ProwJob) error {
-	output, err := util.MarshalFinishedJSON(pj)
+	output, err := util.MarshalFinishedJSON(pj, gr.cfg().ProwJobMetadataPropagation)
 	if err != nil {
 		return fmt.Errorf("failed to marshal finished metadata: %w", err)
 	}
@@ -185,7 +185,7 @@ func (gr *gcsReporter) reportFinishedJob(ctx context.Context, log *logrus.Entry,
 
 func (gr *gcsReporter) reportProwjob(ctx context.Context, log *logrus.Entry, pj *prowv1.ProwJob) error {
 	// Unconditionally dump the ProwJob to GCS, on all job updates.
-	output, err := util.MarshalProwJob(pj)
+	output, err := util.MarshalProwJob(pj, gr.cfg().ProwJobMetadataPropagation)
 	if err != nil {
 		return fmt.Errorf("failed to marshal ProwJob: %w", err)
 	}
@@ -53,6 +53,7 @@ func TestReportJobFinished(t *testing.T) {
 		jobState       prowv1.ProwJobState
 		completionTime *metav1.Time
 		passed         bool
+		abortedBy      string
 		expectErr      bool
 	}{
 		{
@@ -72,6 +73,11 @@ func TestReportJobFinished(t *testing.T) {
 			jobState:       prowv1.AbortedState,
 			completionTime: completionTime,
 		},
+		{
+			jobState:       prowv1.AbortedState,
+			completionTime: completionTime,
+			abortedBy:      "someone",
+		},
 		{
 			jobState:       prowv1.ErrorState,
 			completionTime: completionTime,
@@ -104,6 +110,9 @@ func TestReportJobFinished(t *testing.T) {
 			reporter := New(cfg, fakeOpener, false)
 
 			pj := &prowv1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{prowv1.AbortedByAnnotation: tc.abortedBy},
+				},
 				Spec: prowv1.ProwJobSpec{
 					Type: prowv1.PresubmitJob,
 					Refs: &prowv1.Refs{
@@ -157,6 +166,9 @@ func TestReportJobFinished(t *testing.T) {
 			} else if *result.Passed != tc.passed {
 				t.Errorf("Expected finished.json passed to be %v, but got %v", tc.passed, *result.Passed)
 			}
+			if abortedBy, _ := result.Metadata["aborted-by"].(string); abortedBy != tc.abortedBy {
+				t.Errorf("Expected finished.json to record the job was aborted by %q, but got %q", tc.abortedBy, abortedBy)
+			}
 		})
 	}
 }
@@ -80,13 +80,14 @@ func gcsConfig(cfg config.Getter, pj *prowv1.ProwJob) (*prowv1.GCSConfiguration,
 	return nil, fmt.Errorf("couldn't figure out a GCS config for %q", pj.Spec.Job)
 }
 
-// MarshalProwJob marshals the ProwJob in the format written to GCS.
-func MarshalProwJob(pj *prowv1.ProwJob) ([]byte, error) {
-	return json.MarshalIndent(pj, "", "\t")
+// MarshalProwJob marshals the ProwJob in the format written to GCS, without
+// the labels and annotations the propagation policy denies.
+func MarshalProwJob(pj *prowv1.ProwJob, propagation config.MetadataPropagation) ([]byte, error) {
+	return json.MarshalIndent(propagation.WithoutDenied(pj), "", "\t")
 }
 
 // MarshalFinished marshals the finished.json format written to GCS.
-func MarshalFinishedJSON(pj *prowv1.ProwJob) ([]byte, error) {
+func MarshalFinishedJSON(pj *prowv1.ProwJob, propagation config.MetadataPropagation) ([]byte, error) {
 	if !pj.Complete() {
 		return nil, errors.New("cannot report finished.json for incomplete job")
 	}
@@ -95,8 +96,28 @@ func MarshalFinishedJSON(pj *prowv1.ProwJob) ([]byte, error) {
 	f := metadata.Finished{
 		Timestamp: &completion,
 		Passed:    &passed,
-		Metadata:  metadata.Metadata{"uploader": "crier"},
+		Metadata:  CrierMetadata(pj, propagation),
 		Result:    string(pj.Status.State),
 	}
+	if abortedBy := pj.Annotations[prowv1.AbortedByAnnotation]; abortedBy != "" && pj.Status.State == prowv1.AbortedState {
+		// This matches the metadata sidecar records when it uploads the
+		// finished.json of an aborted job.
+		f.Metadata["aborted-by"] = abortedBy
+	}
 	return json.MarshalIndent(f, "", "\t")
 }
+
+// CrierMetadata returns the metadata of the started.json and finished.json
+// uploaded by crier, which holds the labels and annotations of the ProwJob
+// the propagation policy selects for artifacts.
+func CrierMetadata(pj *prowv1.ProwJob, propagation config.MetadataPropagation) metadata.Metadata {
+	md := metadata.Metadata{"uploader": "crier"}
+	labels, annotations := propagation.ArtifactLabelsAndAnnotations(pj)
+	if labels != nil {
+		md["labels"] = labels
+	}
+	if annotations != nil {
+		md["annotations"] = annotations
+	}
+	return md
+}
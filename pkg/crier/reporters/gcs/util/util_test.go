@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/GoogleCloudPlatform/testgrid/metadata"
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -184,3 +186,39 @@ func TestGetJobDestination(t *testing.T) {
 		})
 	}
 }
+
+func TestCrierMetadata(t *testing.T) {
+	pj := &prowv1.ProwJob{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"team": "infra", "secret-team": "x"},
+		Annotations: map[string]string{"cost-center": "1234"},
+	}}
+	testCases := []struct {
+		name        string
+		propagation config.MetadataPropagation
+		expected    metadata.Metadata
+	}{
+		{
+			name:     "nothing is propagated by default",
+			expected: metadata.Metadata{"uploader": "crier"},
+		},
+		{
+			name: "selected keys are propagated",
+			propagation: config.MetadataPropagation{
+				Artifacts: &config.PropagationPolicy{Labels: []string{"*team"}, Annotations: []string{"cost-center"}},
+				Deny:      []string{"secret-*"},
+			},
+			expected: metadata.Metadata{
+				"uploader":    "crier",
+				"labels":      map[string]string{"team": "infra"},
+				"annotations": map[string]string{"cost-center": "1234"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, CrierMetadata(pj, tc.propagation)); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
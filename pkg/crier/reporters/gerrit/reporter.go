@@ -28,6 +28,7 @@ import (
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/andygrunwald/go-gerrit"
 	"github.com/sirupsen/logrus"
@@ -93,6 +94,8 @@ const (
 
 var (
 	stateIcon = map[v1.ProwJobState]string{
+		v1.HeldState:      hourglass,
+		v1.WaitingState:   hourglass,
 		v1.PendingState:   hourglass,
 		v1.TriggeredState: hourglass,
 		v1.SuccessState:   tick,
@@ -105,6 +108,9 @@ type gerritClient interface {
 	SetReview(instance, id, revision, message string, labels map[string]string) error
 	GetChange(instance, id string, additionalFields ...string) (*gerrit.ChangeInfo, error)
 	ChangeExist(instance, id string) (bool, error)
+	SetHashtags(instance, id string, add, remove []string) error
+	AddToAttentionSet(instance, id string, accountID int, reason string) error
+	RemoveFromAttentionSet(instance, id string, accountID int, reason string) error
 }
 
 // Client is a gerrit reporter client
@@ -175,7 +181,7 @@ func (c *Client) ShouldReport(ctx context.Context, log *logrus.Entry, pj *v1.Pro
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if pj.Status.State == v1.TriggeredState || pj.Status.State == v1.PendingState {
+	if pj.Status.State == v1.HeldState || pj.Status.State == v1.WaitingState || pj.Status.State == v1.TriggeredState || pj.Status.State == v1.PendingState {
 		// not done yet
 		log.Info("PJ not finished")
 		return false
@@ -251,7 +257,7 @@ func (c *Client) ShouldReport(ctx context.Context, log *logrus.Entry, pj *v1.Pro
 
 	// Check all other prowjobs to see whether they agree or not
 	return allPJsAgreeToReport([]string{kube.GerritRevision, kube.ProwJobTypeLabel, kube.GerritReportLabel}, func(otherPj *v1.ProwJob) bool {
-		if otherPj.Status.State == v1.TriggeredState || otherPj.Status.State == v1.PendingState {
+		if otherPj.Status.State == v1.HeldState || otherPj.Status.State == v1.WaitingState || otherPj.Status.State == v1.TriggeredState || otherPj.Status.State == v1.PendingState {
 			// other jobs with same label are still running on this revision, skip report
 			log.Info("Other jobs with same label are still running on this revision")
 			return false
@@ -377,38 +383,66 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 		logger.Warn("Tried to report empty jobs.")
 		return nil, nil, nil
 	}
-	var reviewLabels map[string]string
+	successVote, failureVote := gerritReporterConfig(pj).Votes()
 	var change *gerrit.ChangeInfo
 	var err error
-	if reportLabel != "" {
-		var vote string
+	// vote returns the vote for passing or failing jobs, or false if the
+	// change doesn't exist anymore.
+	vote := func(passed bool) (string, bool) {
 		// Can only vote below zero before merge
 		// TODO(fejta): cannot vote below previous vote after merge
 		switch {
-		case report.Success == report.Total:
-			vote = lgtm
-		case pj.Spec.Type == v1.PresubmitJob:
-			//https://gerrit-documentation.storage.googleapis.com/Documentation/3.1.4/config-labels.html#label_allowPostSubmit
-			// If presubmit and failure vote -1...
-			vote = lbtm
-
-			change, err = c.gc.GetChange(gerritInstance, gerritID)
-			if err != nil {
+		case passed:
+			return formatVote(successVote), true
+		case pj.Spec.Type != v1.PresubmitJob:
+			return lztm, true
+		}
+		//https://gerrit-documentation.storage.googleapis.com/Documentation/3.1.4/config-labels.html#label_allowPostSubmit
+		// If presubmit and failure vote -1...
+		if change == nil {
+			var getErr error
+			change, getErr = c.gc.GetChange(gerritInstance, gerritID)
+			if getErr != nil {
 				exist, existErr := c.gc.ChangeExist(gerritInstance, gerritID)
 				if existErr == nil && !exist {
-					// PR was deleted, no reason to report or retry
-					logger.WithError(err).Info("Change doesn't exist any more, skip reporting.")
-					return nil, nil, nil
+					return "", false
 				}
-				logger.WithError(err).Warn("Unable to get change")
-			} else if change.Status == client.Merged {
-				// Unless change is already merged. Merged changes should not be voted <0
-				vote = lztm
+				logger.WithError(getErr).Warn("Unable to get change")
+				return formatVote(failureVote), true
 			}
-		default:
-			vote = lztm
 		}
-		reviewLabels = map[string]string{reportLabel: vote}
+		if change.Status == client.Merged {
+			// Unless change is already merged. Merged changes should not be voted <0
+			return lztm, true
+		}
+		return formatVote(failureVote), true
+	}
+
+	reviewLabels := map[string]string{}
+	if reportLabel != "" {
+		value, exists := vote(report.Success == report.Total)
+		if !exists {
+			// PR was deleted, no reason to report or retry
+			logger.Info("Change doesn't exist any more, skip reporting.")
+			return nil, nil, nil
+		}
+		reviewLabels[reportLabel] = value
+	}
+	if aggregateLabel := pj.ObjectMeta.Labels[kube.GerritAggregateReportLabel]; aggregateLabel != "" {
+		passed, done, aggregateErr := c.aggregateResult(newCtx, pj)
+		if aggregateErr != nil {
+			logger.WithError(aggregateErr).WithField("label", aggregateLabel).Warn("Failed to aggregate the results of the change.")
+		} else if done {
+			value, exists := vote(passed)
+			if !exists {
+				logger.Info("Change doesn't exist any more, skip reporting.")
+				return nil, nil, nil
+			}
+			reviewLabels[aggregateLabel] = value
+		}
+	}
+	if len(reviewLabels) == 0 {
+		reviewLabels = nil
 	}
 
 	logger.Infof("Reporting to instance %s on id %s with message %s", gerritInstance, gerritID, message)
@@ -448,17 +482,21 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 		}
 
 		if err != nil {
-			if reportLabel == "" {
+			if len(reviewLabels) == 0 {
 				return nil, nil, err
 			}
 			// Retry without voting on a label
-			message := fmt.Sprintf("[NOTICE]: Prow Bot cannot access %s label!\n%s", reportLabel, message)
+			message := fmt.Sprintf("[NOTICE]: Prow Bot cannot access %s label!\n%s", strings.Join(sets.List(sets.KeySet(reviewLabels)), ", "), message)
 			if err := c.gc.SetReview(gerritInstance, gerritID, gerritRevision, message, nil); err != nil {
 				return nil, nil, err
 			}
 		}
 	}
 
+	if pj.Spec.Type == v1.PresubmitJob {
+		c.updateAttention(logger, gerritReporterConfig(pj), gerritInstance, gerritID, gerritRevision, report.Success == report.Total)
+	}
+
 	logger.Infof("Review Complete, reported jobs: %s", jobNames(toReportJobs))
 
 	// If return here, the shardedLock will be released, and other threads that
@@ -491,6 +529,100 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 	return nil, nil, err
 }
 
+// updateAttention adds the configured hashtags to the change and its
+// uploader to the attention set when the presubmits failed, and removes them
+// when they passed. Results of revisions that were superseded already and of
+// merged changes are ignored. Errors are only logged, as the results were
+// reported already.
+func (c *Client) updateAttention(logger *logrus.Entry, cfg *v1.GerritReporterConfig, instance, id, revision string, passed bool) {
+	if cfg == nil || (len(cfg.Hashtags) == 0 && !cfg.AttentionSet) {
+		return
+	}
+	change, err := c.gc.GetChange(instance, id, "CURRENT_REVISION")
+	if err != nil {
+		logger.WithError(err).Warn("Unable to get change to update its hashtags and attention set.")
+		return
+	}
+	if change == nil || change.Status == client.Merged || change.CurrentRevision != revision {
+		return
+	}
+
+	if len(cfg.Hashtags) > 0 {
+		add, remove := cfg.Hashtags, []string(nil)
+		if passed {
+			add, remove = nil, cfg.Hashtags
+		}
+		if err := c.gc.SetHashtags(instance, id, add, remove); err != nil {
+			logger.WithError(err).Warn("Failed to update hashtags.")
+		}
+	}
+
+	if !cfg.AttentionSet {
+		return
+	}
+	uploader := change.Revisions[revision].Uploader.AccountID
+	if uploader == 0 {
+		logger.WithField("revision", revision).Warn("Unable to determine the uploader of the revision.")
+		return
+	}
+	if passed {
+		err = c.gc.RemoveFromAttentionSet(instance, id, uploader, "Prow jobs passed")
+	} else {
+		err = c.gc.AddToAttentionSet(instance, id, uploader, "Prow jobs failed")
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Failed to update attention set.")
+	}
+}
+
+// gerritReporterConfig returns the gerrit reporter config of the job, or
+// nil if it has none.
+func gerritReporterConfig(pj *v1.ProwJob) *v1.GerritReporterConfig {
+	if pj.Spec.ReporterConfig == nil {
+		return nil
+	}
+	return pj.Spec.ReporterConfig.Gerrit
+}
+
+func formatVote(vote int) string {
+	if vote > 0 {
+		return fmt.Sprintf("+%d", vote)
+	}
+	return strconv.Itoa(vote)
+}
+
+// aggregateResult returns whether the most recent runs of all jobs of the
+// revision with the same aggregate label as pj passed, or false for done if
+// some of them are still running.
+func (c *Client) aggregateResult(ctx context.Context, pj *v1.ProwJob) (passed, done bool, err error) {
+	selector := map[string]string{
+		kube.GerritRevision:             pj.ObjectMeta.Labels[kube.GerritRevision],
+		kube.ProwJobTypeLabel:           pj.ObjectMeta.Labels[kube.ProwJobTypeLabel],
+		kube.GerritAggregateReportLabel: pj.ObjectMeta.Labels[kube.GerritAggregateReportLabel],
+	}
+	var pjs v1.ProwJobList
+	if err := c.pjclientset.List(ctx, &pjs, ctrlruntimeclient.MatchingLabels(selector)); err != nil {
+		return false, false, fmt.Errorf("cannot list prowjobs with selector %v: %w", selector, err)
+	}
+	mostRecentJob := map[string]*v1.ProwJob{}
+	for i, other := range pjs.Items {
+		if job, ok := mostRecentJob[other.Spec.Job]; !ok || job.CreationTimestamp.Time.Before(other.CreationTimestamp.Time) {
+			mostRecentJob[other.Spec.Job] = &pjs.Items[i]
+		}
+	}
+	passed = true
+	for _, job := range mostRecentJob {
+		switch job.Status.State {
+		case v1.HeldState, v1.WaitingState, v1.TriggeredState, v1.PendingState:
+			return false, false, nil
+		case v1.SuccessState:
+		default:
+			passed = false
+		}
+	}
+	return passed, true, nil
+}
+
 func jobNames(jobs []*v1.ProwJob) []string {
 	names := make([]string, len(jobs))
 	for i, job := range jobs {
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/andygrunwald/go-gerrit"
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -53,6 +54,27 @@ type fgc struct {
 	instance      string
 	changes       map[string][]*gerrit.ChangeInfo
 	count         int
+
+	hashtagsAdded    []string
+	hashtagsRemoved  []string
+	attentionAdded   []int
+	attentionRemoved []int
+}
+
+func (f *fgc) SetHashtags(instance, id string, add, remove []string) error {
+	f.hashtagsAdded = append(f.hashtagsAdded, add...)
+	f.hashtagsRemoved = append(f.hashtagsRemoved, remove...)
+	return nil
+}
+
+func (f *fgc) AddToAttentionSet(instance, id string, accountID int, reason string) error {
+	f.attentionAdded = append(f.attentionAdded, accountID)
+	return nil
+}
+
+func (f *fgc) RemoveFromAttentionSet(instance, id string, accountID int, reason string) error {
+	f.attentionRemoved = append(f.attentionRemoved, accountID)
+	return nil
 }
 
 func (f *fgc) SetReview(instance, id, revision, message string, labels map[string]string) error {
@@ -2006,6 +2028,197 @@ func TestReport(t *testing.T) {
 			expectLabel:       map[string]string{codeReview: lgtm},
 			numExpectedReport: 0,
 		},
+		{
+			name: "1 job, failed, votes configured failure vote",
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						kube.GerritRevision:    "abc",
+						kube.ProwJobTypeLabel:  presubmit,
+						kube.GerritReportLabel: "Verified",
+					},
+					Annotations: map[string]string{
+						kube.GerritID:       "123-abc",
+						kube.GerritInstance: "gerrit",
+					},
+					Name:      "ci-foo",
+					Namespace: "test-pods",
+				},
+				Status: v1.ProwJobStatus{
+					State: v1.FailureState,
+					URL:   "guber/foo",
+				},
+				Spec: v1.ProwJobSpec{
+					Type: v1.PresubmitJob,
+					Refs: &v1.Refs{
+						Repo:  "foo",
+						Pulls: []v1.Pull{{Number: 0}},
+					},
+					Job:    "ci-foo",
+					Report: true,
+					ReporterConfig: &v1.ReporterConfig{
+						Gerrit: &v1.GerritReporterConfig{Label: "Verified", SuccessVote: intPtr(2), FailureVote: intPtr(-2)},
+					},
+				},
+			},
+			expectReport:      true,
+			reportInclude:     []string{"0 out of 1", "ci-foo", "FAILURE"},
+			expectLabel:       map[string]string{"Verified": "-2"},
+			numExpectedReport: 0,
+		},
+		{
+			name: "last job of the aggregate label passed, votes on both labels",
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						kube.GerritRevision:             "abc",
+						kube.ProwJobTypeLabel:           presubmit,
+						kube.GerritReportLabel:          "Verified",
+						kube.GerritAggregateReportLabel: "Prow-Verified",
+					},
+					Annotations: map[string]string{
+						kube.GerritID:       "123-abc",
+						kube.GerritInstance: "gerrit",
+					},
+					Name:      "ci-foo",
+					Namespace: "test-pods",
+				},
+				Status: v1.ProwJobStatus{
+					State: v1.SuccessState,
+					URL:   "guber/foo",
+				},
+				Spec: v1.ProwJobSpec{
+					Type: v1.PresubmitJob,
+					Refs: &v1.Refs{
+						Repo:  "foo",
+						Pulls: []v1.Pull{{Number: 0}},
+					},
+					Job:    "ci-foo",
+					Report: true,
+				},
+			},
+			existingPJs: []*v1.ProwJob{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							kube.GerritRevision:             "abc",
+							kube.ProwJobTypeLabel:           presubmit,
+							kube.GerritReportLabel:          "",
+							kube.GerritAggregateReportLabel: "Prow-Verified",
+						},
+						Namespace: "test-pods",
+					},
+					Status: v1.ProwJobStatus{State: v1.SuccessState},
+					Spec:   v1.ProwJobSpec{Type: v1.PresubmitJob, Job: "ci-bar"},
+				},
+			},
+			expectReport:      true,
+			reportInclude:     []string{"1 out of 1", "ci-foo"},
+			expectLabel:       map[string]string{"Verified": lgtm, "Prow-Verified": lgtm},
+			numExpectedReport: 0,
+		},
+		{
+			name: "other job of the aggregate label failed, votes against the aggregate label",
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						kube.GerritRevision:             "abc",
+						kube.ProwJobTypeLabel:           presubmit,
+						kube.GerritReportLabel:          "Verified",
+						kube.GerritAggregateReportLabel: "Prow-Verified",
+					},
+					Annotations: map[string]string{
+						kube.GerritID:       "123-abc",
+						kube.GerritInstance: "gerrit",
+					},
+					Name:      "ci-foo",
+					Namespace: "test-pods",
+				},
+				Status: v1.ProwJobStatus{
+					State: v1.SuccessState,
+					URL:   "guber/foo",
+				},
+				Spec: v1.ProwJobSpec{
+					Type: v1.PresubmitJob,
+					Refs: &v1.Refs{
+						Repo:  "foo",
+						Pulls: []v1.Pull{{Number: 0}},
+					},
+					Job:    "ci-foo",
+					Report: true,
+				},
+			},
+			existingPJs: []*v1.ProwJob{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							kube.GerritRevision:             "abc",
+							kube.ProwJobTypeLabel:           presubmit,
+							kube.GerritReportLabel:          "Code-Review",
+							kube.GerritAggregateReportLabel: "Prow-Verified",
+						},
+						Namespace: "test-pods",
+					},
+					Status: v1.ProwJobStatus{State: v1.FailureState},
+					Spec:   v1.ProwJobSpec{Type: v1.PresubmitJob, Job: "ci-bar"},
+				},
+			},
+			expectReport:      true,
+			reportInclude:     []string{"1 out of 1", "ci-foo"},
+			expectLabel:       map[string]string{"Verified": lgtm, "Prow-Verified": lbtm},
+			numExpectedReport: 0,
+		},
+		{
+			name: "other job of the aggregate label is running, only votes on the label of the job",
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						kube.GerritRevision:             "abc",
+						kube.ProwJobTypeLabel:           presubmit,
+						kube.GerritReportLabel:          "Verified",
+						kube.GerritAggregateReportLabel: "Prow-Verified",
+					},
+					Annotations: map[string]string{
+						kube.GerritID:       "123-abc",
+						kube.GerritInstance: "gerrit",
+					},
+					Name:      "ci-foo",
+					Namespace: "test-pods",
+				},
+				Status: v1.ProwJobStatus{
+					State: v1.SuccessState,
+					URL:   "guber/foo",
+				},
+				Spec: v1.ProwJobSpec{
+					Type: v1.PresubmitJob,
+					Refs: &v1.Refs{
+						Repo:  "foo",
+						Pulls: []v1.Pull{{Number: 0}},
+					},
+					Job:    "ci-foo",
+					Report: true,
+				},
+			},
+			existingPJs: []*v1.ProwJob{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							kube.GerritRevision:             "abc",
+							kube.ProwJobTypeLabel:           presubmit,
+							kube.GerritReportLabel:          "Code-Review",
+							kube.GerritAggregateReportLabel: "Prow-Verified",
+						},
+						Namespace: "test-pods",
+					},
+					Status: v1.ProwJobStatus{State: v1.PendingState},
+					Spec:   v1.ProwJobSpec{Type: v1.PresubmitJob, Job: "ci-bar"},
+				},
+			},
+			expectReport:      true,
+			reportInclude:     []string{"1 out of 1", "ci-foo"},
+			expectLabel:       map[string]string{"Verified": lgtm},
+			numExpectedReport: 0,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -2064,6 +2277,93 @@ func TestReport(t *testing.T) {
 	}
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestUpdateAttention(t *testing.T) {
+	changes := map[string][]*gerrit.ChangeInfo{
+		"gerrit": {
+			{ID: "123-abc", Status: "NEW", CurrentRevision: "def", Revisions: map[string]gerrit.RevisionInfo{"def": {Uploader: gerrit.AccountInfo{AccountID: 42}}}},
+			{ID: "merged", Status: "MERGED", CurrentRevision: "abc", Revisions: map[string]gerrit.RevisionInfo{"abc": {Uploader: gerrit.AccountInfo{AccountID: 42}}}},
+		},
+	}
+	testCases := []struct {
+		name                  string
+		config                *v1.GerritReporterConfig
+		id                    string
+		revision              string
+		passed                bool
+		expectHashtagsAdded   []string
+		expectHashtagsRemoved []string
+		expectAttentionAdd    []int
+		expectAttentionRemove []int
+	}{
+		{
+			name:     "no config",
+			id:       "123-abc",
+			revision: "def",
+		},
+		{
+			name:                "failed, adds hashtags and uploader",
+			config:              &v1.GerritReporterConfig{Hashtags: []string{"ci-failed"}, AttentionSet: true},
+			id:                  "123-abc",
+			revision:            "def",
+			expectHashtagsAdded: []string{"ci-failed"},
+			expectAttentionAdd:  []int{42},
+		},
+		{
+			name:                  "passed, removes hashtags and uploader",
+			config:                &v1.GerritReporterConfig{Hashtags: []string{"ci-failed"}, AttentionSet: true},
+			id:                    "123-abc",
+			revision:              "def",
+			passed:                true,
+			expectHashtagsRemoved: []string{"ci-failed"},
+			expectAttentionRemove: []int{42},
+		},
+		{
+			name:                "failed, only hashtags",
+			config:              &v1.GerritReporterConfig{Hashtags: []string{"ci-failed"}},
+			id:                  "123-abc",
+			revision:            "def",
+			expectHashtagsAdded: []string{"ci-failed"},
+		},
+		{
+			name:     "superseded revision is ignored",
+			config:   &v1.GerritReporterConfig{Hashtags: []string{"ci-failed"}, AttentionSet: true},
+			id:       "123-abc",
+			revision: "abc",
+		},
+		{
+			name:     "merged change is ignored",
+			config:   &v1.GerritReporterConfig{Hashtags: []string{"ci-failed"}, AttentionSet: true},
+			id:       "merged",
+			revision: "abc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fgc := &fgc{instance: "gerrit", changes: changes}
+			reporter := &Client{gc: fgc}
+			reporter.updateAttention(logrus.NewEntry(logrus.StandardLogger()), tc.config, "gerrit", tc.id, tc.revision, tc.passed)
+
+			if diff := cmp.Diff(tc.expectHashtagsAdded, fgc.hashtagsAdded); diff != "" {
+				t.Errorf("added hashtags differ (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectHashtagsRemoved, fgc.hashtagsRemoved); diff != "" {
+				t.Errorf("removed hashtags differ (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectAttentionAdd, fgc.attentionAdded); diff != "" {
+				t.Errorf("accounts added to the attention set differ (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectAttentionRemove, fgc.attentionRemoved); diff != "" {
+				t.Errorf("accounts removed from the attention set differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestMultipleWorks(t *testing.T) {
 	samplePJ := v1.ProwJob{
 		ObjectMeta: metav1.ObjectMeta{
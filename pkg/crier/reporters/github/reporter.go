@@ -22,7 +22,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -86,6 +85,12 @@ func (c *Client) ShouldReport(_ context.Context, _ *logrus.Entry, pj *v1.ProwJob
 	return true
 }
 
+// ReportsQueue tells crier to report the queue position of triggered jobs
+// on their status whenever plank updates it.
+func (c *Client) ReportsQueue() bool {
+	return true
+}
+
 // Report will report via reportlib
 func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) ([]*v1.ProwJob, *reconcile.Result, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
@@ -94,11 +99,17 @@ func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob)
 	// TODO(krzyzacy): ditch ReportTemplate, and we can drop reference to config.Getter
 	err := report.ReportStatusContext(ctx, c.gc, *pj, c.config().GitHubReporter)
 	if err != nil {
-		if strings.Contains(err.Error(), "This SHA and context has reached the maximum number of statuses") {
+		if queue, ok := c.gc.(*StatusQueue); ok && errors.Is(err, ErrStatusQueued) {
+			// Report again once the queue had the chance to send the status,
+			// the job is only marked as reported once it did.
+			log.Debug("Status update is queued, reporting again after the next batch.")
+			return nil, &reconcile.Result{RequeueAfter: queue.batchPeriod}, nil
+		}
+		if isMaxStatusesError(err) {
 			// This is completely unrecoverable, so just swallow the error to make sure we wont retry, even when crier gets restarted.
 			log.WithError(err).Debug("Encountered an error, skipping retries")
 			err = nil
-		} else if strings.Contains(err.Error(), "\"message\":\"Not Found\"") || strings.Contains(err.Error(), "\"message\":\"No commit found for SHA:") {
+		} else if isCommitNotFoundError(err) {
 			// "message":"Not Found" error occurs when someone force push, which is not a crier error
 			log.WithError(err).Debug("Could not find PR commit, skipping retries")
 			err = nil
@@ -263,6 +263,42 @@ func TestReport(t *testing.T) {
 	}
 }
 
+func TestReportQueuedStatus(t *testing.T) {
+	queue := NewStatusQueue(fakegithub.NewFakeClient(), 10*time.Second)
+	c := Client{
+		gc: queue,
+		config: func() *config.Config {
+			return &config.Config{ProwConfig: config.ProwConfig{GitHubReporter: config.GitHubReporter{JobTypesToReport: []v1.ProwJobType{v1.PostsubmitJob}}}}
+		},
+	}
+	pj := &v1.ProwJob{
+		Spec: v1.ProwJobSpec{
+			Type:   v1.PostsubmitJob,
+			Report: true,
+			Refs:   &v1.Refs{Org: "org", Repo: "repo", BaseSHA: "abc"},
+		},
+		Status: v1.ProwJobStatus{State: v1.SuccessState, CompletionTime: &metav1.Time{}},
+	}
+	log := logrus.NewEntry(logrus.StandardLogger())
+
+	pjs, requeue, err := c.Report(context.Background(), log, pj)
+	if err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if requeue == nil || requeue.RequeueAfter != 10*time.Second || len(pjs) != 0 {
+		t.Fatalf("expected the job to be requeued without being reported while its status is queued, got %v, %v", pjs, requeue)
+	}
+
+	queue.flush(context.Background())
+	pjs, requeue, err = c.Report(context.Background(), log, pj)
+	if err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if requeue != nil || len(pjs) != 1 {
+		t.Errorf("expected the job to be reported once its status was sent, got %v, %v", pjs, requeue)
+	}
+}
+
 func TestPjsToReport(t *testing.T) {
 	timeNow := time.Now().Truncate(time.Second) // Truncate so that comparison works.
 	var testcases = []struct {
@@ -0,0 +1,301 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
+)
+
+const (
+	// secondaryRateLimitBackoff is how long sending is paused when GitHub
+	// reports a secondary rate limit without telling us how long to wait.
+	secondaryRateLimitBackoff = time.Minute
+	// maxStatusAttempts is how often sending a pending status is attempted
+	// before it is dropped. Terminal states are retried until they are sent.
+	maxStatusAttempts = 3
+	// settledStatusTTL is how long the queue remembers the statuses it sent,
+	// so that reporting the same status again isn't queued again.
+	settledStatusTTL = time.Hour
+
+	deferredReasonRateLimited = "rate_limited"
+	deferredReasonError       = "error"
+)
+
+// ErrStatusQueued is returned by StatusQueue.CreateStatusWithContext when the
+// status was queued but not sent yet. Reporting the same status again once
+// the queue sent it succeeds.
+var ErrStatusQueued = errors.New("status update is queued")
+
+var statusQueueMetrics = struct {
+	depth     prometheus.Gauge
+	coalesced prometheus.Counter
+	deferred  *prometheus.CounterVec
+}{
+	depth: prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "crier_github_status_queue_depth",
+		Help: "Number of GitHub status updates waiting to be sent.",
+	}),
+	coalesced: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "crier_github_status_updates_coalesced_total",
+		Help: "Number of GitHub status updates that were replaced by a later update for the same SHA and context before being sent.",
+	}),
+	deferred: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crier_github_status_updates_deferred_total",
+		Help: "Number of GitHub status updates that were put back into the queue instead of being sent.",
+	}, []string{"reason"}),
+}
+
+func init() {
+	prometheus.MustRegister(statusQueueMetrics.depth)
+	prometheus.MustRegister(statusQueueMetrics.coalesced)
+	prometheus.MustRegister(statusQueueMetrics.deferred)
+}
+
+type statusKey struct {
+	org, repo, sha, context string
+}
+
+type queuedStatus struct {
+	key      statusKey
+	status   github.Status
+	enqueued time.Time
+	attempts int
+}
+
+func (s queuedStatus) terminal() bool {
+	return s.status.State != github.StatusPending
+}
+
+// settledStatus is a status the queue is done with, because it was sent or
+// will never be.
+type settledStatus struct {
+	status  github.Status
+	settled time.Time
+}
+
+// StatusQueue is a report.GitHubClient that queues status updates instead of
+// sending them right away. Updates for the same SHA and context are
+// coalesced, so that only the latest one is sent, and every batch period the
+// queue is drained with terminal states being sent before pending ones. When
+// GitHub rate limits the client, sending is paused for as long as GitHub
+// asks for. Queued statuses are reported as ErrStatusQueued until they were
+// sent, so that the ProwJob isn't marked as reported before its status is on
+// GitHub. All other calls are passed through to the wrapped client.
+type StatusQueue struct {
+	report.GitHubClient
+
+	batchPeriod time.Duration
+	now         func() time.Time
+	log         *logrus.Entry
+
+	lock          sync.Mutex
+	pending       map[statusKey]*queuedStatus
+	settled       map[statusKey]settledStatus
+	deferredUntil time.Time
+}
+
+// NewStatusQueue returns a StatusQueue sending status updates through gc
+// every batchPeriod.
+func NewStatusQueue(gc report.GitHubClient, batchPeriod time.Duration) *StatusQueue {
+	return &StatusQueue{
+		GitHubClient: gc,
+		batchPeriod:  batchPeriod,
+		now:          time.Now,
+		log:          logrus.WithField("component", "github-status-queue"),
+		pending:      map[statusKey]*queuedStatus{},
+		settled:      map[statusKey]settledStatus{},
+	}
+}
+
+// CreateStatusWithContext queues the status update and returns
+// ErrStatusQueued, or nil if the queue is already done with the same status.
+// Errors sending the update are handled by the queue.
+func (q *StatusQueue) CreateStatusWithContext(_ context.Context, org, repo, ref string, s github.Status) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := statusKey{org: org, repo: repo, sha: ref, context: s.Context}
+	if settled, ok := q.settled[key]; ok && settled.status == s {
+		return nil
+	}
+	if existing, ok := q.pending[key]; ok {
+		if existing.status == s {
+			return ErrStatusQueued
+		}
+		statusQueueMetrics.coalesced.Inc()
+		// Updates of the same job can be reported out of order, a pending
+		// state must not replace the final state of the run it belongs to.
+		if existing.terminal() && s.State == github.StatusPending && existing.status.TargetURL == s.TargetURL {
+			return nil
+		}
+	}
+	q.pending[key] = &queuedStatus{key: key, status: s, enqueued: q.now()}
+	statusQueueMetrics.depth.Set(float64(len(q.pending)))
+	return ErrStatusQueued
+}
+
+// Start sends the queued status updates every batch period until the
+// context is cancelled, at which point the queue is drained one last time.
+func (q *StatusQueue) Start(ctx context.Context) error {
+	ticker := time.NewTicker(q.batchPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			q.flush(drainCtx)
+			return nil
+		case <-ticker.C:
+			q.flush(ctx)
+		}
+	}
+}
+
+// flush sends all queued status updates unless sending is paused because of
+// a rate limit.
+func (q *StatusQueue) flush(ctx context.Context) {
+	batch := q.takeBatch()
+	for i, item := range batch {
+		err := q.GitHubClient.CreateStatusWithContext(ctx, item.key.org, item.key.repo, item.key.sha, item.status)
+		if err == nil {
+			q.settle(item)
+			continue
+		}
+		log := q.log.WithFields(logrus.Fields{
+			"org":     item.key.org,
+			"repo":    item.key.repo,
+			"sha":     item.key.sha,
+			"context": item.key.context,
+		}).WithError(err)
+		if retryAfter, rateLimited := rateLimitRetryAfter(err); rateLimited {
+			log.WithField("retry-after", retryAfter.String()).Warn("Rate limited by GitHub, deferring status updates.")
+			q.pause(batch[i:], retryAfter)
+			return
+		}
+		if isUnrecoverableStatusError(err) {
+			log.Debug("Dropping status update that can never succeed.")
+			q.settle(item)
+			continue
+		}
+		item.attempts++
+		// A pending state is soon replaced, but the final state of a job
+		// must not get lost.
+		if !item.terminal() && item.attempts >= maxStatusAttempts {
+			log.Error("Failed to send status update, giving up.")
+			q.settle(item)
+			continue
+		}
+		log.Info("Failed to send status update, retrying in the next batch.")
+		q.requeue(item, deferredReasonError)
+	}
+}
+
+// takeBatch removes all queued updates from the queue and returns them in
+// the order they should be sent in: terminal states first, then by age.
+func (q *StatusQueue) takeBatch() []*queuedStatus {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for key, settled := range q.settled {
+		if q.now().Sub(settled.settled) >= settledStatusTTL {
+			delete(q.settled, key)
+		}
+	}
+	if q.now().Before(q.deferredUntil) {
+		return nil
+	}
+	batch := make([]*queuedStatus, 0, len(q.pending))
+	for _, item := range q.pending {
+		batch = append(batch, item)
+	}
+	q.pending = map[statusKey]*queuedStatus{}
+	statusQueueMetrics.depth.Set(0)
+	sort.SliceStable(batch, func(i, j int) bool {
+		if batch[i].terminal() != batch[j].terminal() {
+			return batch[i].terminal()
+		}
+		return batch[i].enqueued.Before(batch[j].enqueued)
+	})
+	return batch
+}
+
+// pause puts the unsent updates back into the queue and pauses sending.
+func (q *StatusQueue) pause(unsent []*queuedStatus, retryAfter time.Duration) {
+	q.lock.Lock()
+	q.deferredUntil = q.now().Add(retryAfter)
+	q.lock.Unlock()
+	for _, item := range unsent {
+		q.requeue(item, deferredReasonRateLimited)
+	}
+}
+
+// settle records that the queue is done with the update.
+func (q *StatusQueue) settle(item *queuedStatus) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.settled[item.key] = settledStatus{status: item.status, settled: q.now()}
+}
+
+// requeue puts an update back into the queue, unless it was superseded by a
+// newer one in the meantime.
+func (q *StatusQueue) requeue(item *queuedStatus, reason string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, superseded := q.pending[item.key]; superseded {
+		return
+	}
+	q.pending[item.key] = item
+	statusQueueMetrics.deferred.WithLabelValues(reason).Inc()
+	statusQueueMetrics.depth.Set(float64(len(q.pending)))
+}
+
+// rateLimitRetryAfter returns how long to wait before sending again if the
+// error is caused by GitHub rate limiting us.
+func rateLimitRetryAfter(err error) (time.Duration, bool) {
+	var rateLimitErr github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.RetryAfter, true
+	}
+	if strings.Contains(err.Error(), "secondary rate limit") {
+		return secondaryRateLimitBackoff, true
+	}
+	return 0, false
+}
+
+// isUnrecoverableStatusError returns true for errors that will not go away
+// when sending the status again.
+func isUnrecoverableStatusError(err error) bool {
+	return isMaxStatusesError(err) || isCommitNotFoundError(err)
+}
+
+func isMaxStatusesError(err error) bool {
+	return strings.Contains(err.Error(), "This SHA and context has reached the maximum number of statuses")
+}
+
+func isCommitNotFoundError(err error) bool {
+	return strings.Contains(err.Error(), "\"message\":\"Not Found\"") || strings.Contains(err.Error(), "\"message\":\"No commit found for SHA:")
+}
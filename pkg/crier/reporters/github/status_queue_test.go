@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+)
+
+// sentStatus is a status update as seen by GitHub.
+type sentStatus struct {
+	SHA     string
+	Context string
+	State   string
+}
+
+type fakeStatusClient struct {
+	*fakegithub.FakeClient
+	sent []sentStatus
+	// errs are returned for the next calls, one per call.
+	errs []error
+}
+
+func (f *fakeStatusClient) CreateStatusWithContext(_ context.Context, _, _, sha string, s github.Status) error {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.sent = append(f.sent, sentStatus{SHA: sha, Context: s.Context, State: s.State})
+	return nil
+}
+
+func TestStatusQueueFlush(t *testing.T) {
+	type update struct {
+		sha, context, state, url string
+	}
+	testCases := []struct {
+		name     string
+		updates  []update
+		errs     []error
+		expected []sentStatus
+		// expectedPending is the number of updates left in the queue.
+		expectedPending int
+		expectedPaused  time.Duration
+	}{
+		{
+			name: "updates for the same SHA and context are coalesced",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-1"},
+				{sha: "abc", context: "unit", state: github.StatusSuccess, url: "run-1"},
+				{sha: "def", context: "unit", state: github.StatusPending, url: "run-2"},
+			},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "unit", State: github.StatusSuccess},
+				{SHA: "def", Context: "unit", State: github.StatusPending},
+			},
+		},
+		{
+			name: "terminal states are sent first",
+			updates: []update{
+				{sha: "abc", context: "lint", state: github.StatusPending, url: "run-1"},
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-2"},
+				{sha: "abc", context: "e2e", state: github.StatusFailure, url: "run-3"},
+			},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "e2e", State: github.StatusFailure},
+				{SHA: "abc", Context: "lint", State: github.StatusPending},
+				{SHA: "abc", Context: "unit", State: github.StatusPending},
+			},
+		},
+		{
+			name: "late pending update of the same run does not replace its final state",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusFailure, url: "run-1"},
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-1"},
+			},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "unit", State: github.StatusFailure},
+			},
+		},
+		{
+			name: "pending update of a retest replaces the final state of the previous run",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusFailure, url: "run-1"},
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-2"},
+			},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "unit", State: github.StatusPending},
+			},
+		},
+		{
+			name: "rate limit pauses sending and keeps unsent updates",
+			updates: []update{
+				{sha: "abc", context: "lint", state: github.StatusSuccess, url: "run-1"},
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-2"},
+				{sha: "abc", context: "e2e", state: github.StatusPending, url: "run-3"},
+			},
+			errs: []error{nil, github.RateLimitError{RetryAfter: 10 * time.Minute}},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "lint", State: github.StatusSuccess},
+			},
+			expectedPending: 2,
+			expectedPaused:  10 * time.Minute,
+		},
+		{
+			name: "secondary rate limit without retry after pauses sending",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-1"},
+			},
+			errs:            []error{errors.New("You have exceeded a secondary rate limit")},
+			expectedPending: 1,
+			expectedPaused:  secondaryRateLimitBackoff,
+		},
+		{
+			name: "unrecoverable errors are dropped",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-1"},
+				{sha: "abc", context: "lint", state: github.StatusPending, url: "run-2"},
+			},
+			errs: []error{errors.New(`{"message":"No commit found for SHA: abc"}`)},
+			expected: []sentStatus{
+				{SHA: "abc", Context: "lint", State: github.StatusPending},
+			},
+		},
+		{
+			name: "other errors are retried in the next batch",
+			updates: []update{
+				{sha: "abc", context: "unit", state: github.StatusPending, url: "run-1"},
+			},
+			errs:            []error{errors.New("injected error")},
+			expectedPending: 1,
+		},
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsc := &fakeStatusClient{FakeClient: fakegithub.NewFakeClient(), errs: tc.errs}
+			q := NewStatusQueue(fsc, time.Second)
+			clock := now
+			q.now = func() time.Time { return clock }
+			for _, u := range tc.updates {
+				clock = clock.Add(time.Second)
+				if err := q.CreateStatusWithContext(context.Background(), "org", "repo", u.sha, github.Status{Context: u.context, State: u.state, TargetURL: u.url}); err != nil && !errors.Is(err, ErrStatusQueued) {
+					t.Fatalf("failed to queue status: %v", err)
+				}
+			}
+			q.flush(context.Background())
+			if diff := cmp.Diff(tc.expected, fsc.sent); diff != "" {
+				t.Errorf("unexpected statuses sent (-want +got):\n%s", diff)
+			}
+			if len(q.pending) != tc.expectedPending {
+				t.Errorf("expected %d pending updates, got %d", tc.expectedPending, len(q.pending))
+			}
+			if paused := q.deferredUntil.Sub(clock); tc.expectedPaused != 0 && paused != tc.expectedPaused {
+				t.Errorf("expected sending to be paused for %v, got %v", tc.expectedPaused, paused)
+			}
+
+			// The next batch only sends the remaining updates once the
+			// pause is over.
+			fsc.sent = nil
+			q.flush(context.Background())
+			if tc.expectedPaused != 0 && len(fsc.sent) != 0 {
+				t.Errorf("expected no statuses to be sent while paused, got %v", fsc.sent)
+			}
+			clock = clock.Add(tc.expectedPaused)
+			q.flush(context.Background())
+			if len(q.pending) != 0 {
+				t.Errorf("expected the queue to be drained, got %d pending updates", len(q.pending))
+			}
+		})
+	}
+}
+
+func TestStatusQueueReportsQueuedStatuses(t *testing.T) {
+	fsc := &fakeStatusClient{FakeClient: fakegithub.NewFakeClient()}
+	q := NewStatusQueue(fsc, time.Second)
+	success := github.Status{Context: "unit", State: github.StatusSuccess, TargetURL: "run-1"}
+	create := func(s github.Status) error {
+		return q.CreateStatusWithContext(context.Background(), "org", "repo", "abc", s)
+	}
+
+	if err := create(success); !errors.Is(err, ErrStatusQueued) {
+		t.Fatalf("expected the status to be queued, got %v", err)
+	}
+	if err := create(success); !errors.Is(err, ErrStatusQueued) {
+		t.Fatalf("expected the status to still be queued, got %v", err)
+	}
+	q.flush(context.Background())
+	if err := create(success); err != nil {
+		t.Errorf("expected the sent status to be reported, got %v", err)
+	}
+	if len(fsc.sent) != 1 {
+		t.Errorf("expected the status to be sent once, got %v", fsc.sent)
+	}
+	if err := create(github.Status{Context: "unit", State: github.StatusPending, TargetURL: "run-2"}); !errors.Is(err, ErrStatusQueued) {
+		t.Errorf("expected a different status to be queued, got %v", err)
+	}
+}
+
+func TestStatusQueueRetries(t *testing.T) {
+	testCases := []struct {
+		name         string
+		state        string
+		expectedSent bool
+	}{
+		{
+			name:  "pending state is dropped after the last attempt",
+			state: github.StatusPending,
+		},
+		{
+			name:         "terminal state is retried until it is sent",
+			state:        github.StatusFailure,
+			expectedSent: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []error
+			for i := 0; i < maxStatusAttempts; i++ {
+				errs = append(errs, errors.New("injected error"))
+			}
+			fsc := &fakeStatusClient{FakeClient: fakegithub.NewFakeClient(), errs: errs}
+			q := NewStatusQueue(fsc, time.Second)
+			s := github.Status{Context: "unit", State: tc.state, TargetURL: "run-1"}
+			if err := q.CreateStatusWithContext(context.Background(), "org", "repo", "abc", s); !errors.Is(err, ErrStatusQueued) {
+				t.Fatalf("expected the status to be queued, got %v", err)
+			}
+			for i := 0; i <= maxStatusAttempts; i++ {
+				q.flush(context.Background())
+			}
+			if sent := len(fsc.sent) == 1; sent != tc.expectedSent {
+				t.Errorf("expected sent: %t, got %v", tc.expectedSent, fsc.sent)
+			}
+			if len(q.pending) != 0 {
+				t.Errorf("expected the queue to be drained, got %d pending updates", len(q.pending))
+			}
+			// Either way the queue is done with the status, so reporting it
+			// again succeeds.
+			if err := q.CreateStatusWithContext(context.Background(), "org", "repo", "abc", s); err != nil {
+				t.Errorf("expected the settled status to be reported, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package githubchecks reports the results of jobs to GitHub as check runs
+// with a markdown summary, annotations for the tests that failed and a
+// button to re-run the job.
+package githubchecks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/crier/reporters/gcs/util"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+const (
+	// ReporterName is the name of the GitHub checks reporter.
+	ReporterName = "github-checks-reporter"
+
+	// maxAnnotations is the number of annotations GitHub accepts in a
+	// single request.
+	maxAnnotations = 50
+	// maxListedTests is the number of failed tests listed in the summary.
+	maxListedTests = 20
+	// maxDetails is the length up to which failure output is kept in the
+	// raw details of annotations. GitHub rejects more than 64 KiB.
+	maxDetails = 16 * 1024
+)
+
+var (
+	// junitFile matches the junit files in the artifact directory.
+	junitFile = regexp.MustCompile(`^junit.*\.xml$`)
+	// location matches a file and line, like pkg/foo/foo_test.go:42, in the
+	// output of a failed test.
+	location = regexp.MustCompile(`([\w.\-/]+\.\w+):(\d+)`)
+)
+
+// GitHubClient creates and updates check runs.
+type GitHubClient interface {
+	ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error)
+	CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error)
+	UpdateCheckRun(org, repo string, checkRunId int64, checkRun github.CheckRun) error
+}
+
+// Opener lists and reads the artifacts of jobs.
+type Opener interface {
+	Reader(ctx context.Context, path string) (pkgio.ReadCloser, error)
+	Iterator(ctx context.Context, prefix, delimiter string) (pkgio.ObjectIterator, error)
+}
+
+// Reporter reports the results of jobs as check runs.
+type Reporter struct {
+	gc          GitHubClient
+	cfg         config.Getter
+	opener      Opener
+	reportAgent v1.ProwJobAgent
+}
+
+// NewReporter returns a reporter of check runs. The opener is used to read
+// the junit files of failed jobs and may be nil, in which case failed tests
+// are not annotated.
+func NewReporter(gc GitHubClient, cfg config.Getter, opener Opener, reportAgent v1.ProwJobAgent) *Reporter {
+	return &Reporter{
+		gc:          gc,
+		cfg:         cfg,
+		opener:      opener,
+		reportAgent: reportAgent,
+	}
+}
+
+// GetName returns the name of the reporter.
+func (r *Reporter) GetName() string {
+	return ReporterName
+}
+
+// ShouldReport returns whether the job is reported as a check run, which
+// is the case for the presubmits and postsubmits of GitHub repos.
+func (r *Reporter) ShouldReport(_ context.Context, _ *logrus.Entry, pj *v1.ProwJob) bool {
+	switch {
+	case !pj.Spec.Report || pj.Spec.Refs == nil:
+		return false
+	case pj.Labels[kube.GerritReportLabel] != "":
+		return false
+	case pj.Spec.Type != v1.PresubmitJob && pj.Spec.Type != v1.PostsubmitJob:
+		return false
+	case r.reportAgent != "" && pj.Spec.Agent != r.reportAgent:
+		return false
+	}
+	return true
+}
+
+// Report creates the check run of the job or updates it if it exists.
+func (r *Reporter) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) ([]*v1.ProwJob, *reconcile.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	refs := pj.Spec.Refs
+	sha := refs.BaseSHA
+	if len(refs.Pulls) > 0 {
+		sha = refs.Pulls[0].SHA
+	}
+	var results *testResults
+	if pj.Status.State == v1.FailureState && r.opener != nil {
+		var err error
+		if results, err = r.testResults(ctx, pj); err != nil {
+			log.WithError(err).Warn("Failed to read the junit files of the job, not annotating failed tests.")
+		}
+	}
+	checkRun := checkRunFor(pj, sha, results)
+
+	checkRuns, err := r.gc.ListCheckRuns(refs.Org, refs.Repo, sha)
+	if err != nil {
+		if isNotFoundError(err) {
+			// The commit is gone if the PR was force pushed, there is nothing
+			// left to report on.
+			log.WithError(err).Debug("Could not find the commit, skipping retries.")
+			err = nil
+		}
+		return []*v1.ProwJob{pj}, nil, err
+	}
+	for _, existing := range checkRuns.CheckRuns {
+		if existing.ExternalID == pj.Name {
+			checkRun.HeadSHA = ""
+			return []*v1.ProwJob{pj}, nil, r.gc.UpdateCheckRun(refs.Org, refs.Repo, existing.ID, checkRun)
+		}
+	}
+	_, err = r.gc.CreateCheckRun(refs.Org, refs.Repo, checkRun)
+	return []*v1.ProwJob{pj}, nil, err
+}
+
+func isNotFoundError(err error) bool {
+	return strings.Contains(err.Error(), "\"message\":\"Not Found\"") || strings.Contains(err.Error(), "\"message\":\"No commit found for SHA:")
+}
+
+// checkRunFor returns the check run of the job, with the failed tests listed
+// in the summary and annotated once the job completes.
+func checkRunFor(pj *v1.ProwJob, sha string, results *testResults) github.CheckRun {
+	checkRun := report.CheckRunFor(*pj, sha)
+	checkRun.Output.Summary = summary(pj, checkRun.Output.Summary, results)
+	if checkRun.Status != github.CheckRunCompleted {
+		return checkRun
+	}
+	checkRun.Actions = []github.CheckRunAction{{
+		Label:       "Re-run",
+		Description: "Run this job again in Prow.",
+		Identifier:  report.RerunCheckRunAction,
+	}}
+	if results == nil {
+		return checkRun
+	}
+	for i, test := range results.failed {
+		if i == maxAnnotations {
+			break
+		}
+		checkRun.Output.Annotations = append(checkRun.Output.Annotations, annotationFor(test, pj.Spec.Refs))
+	}
+	return checkRun
+}
+
+// summary returns the markdown summary of the check run of the job.
+func summary(pj *v1.ProwJob, tested string, results *testResults) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Job `%s` ", pj.Spec.Job)
+	switch pj.Status.State {
+	case v1.TriggeredState, v1.WaitingState, v1.HeldState:
+		b.WriteString("is waiting to start.")
+	case v1.PendingState:
+		b.WriteString("is running.")
+	case v1.SuccessState:
+		b.WriteString("succeeded")
+	case v1.AbortedState:
+		b.WriteString("was aborted")
+	default:
+		b.WriteString("failed")
+	}
+	if pj.Complete() {
+		if !pj.Status.StartTime.IsZero() {
+			fmt.Fprintf(&b, " after %s", pj.Status.CompletionTime.Sub(pj.Status.StartTime.Time).Round(time.Second))
+		}
+		b.WriteString(".")
+	}
+	b.WriteString(" " + tested + "\n")
+	if pj.Status.URL != "" {
+		fmt.Fprintf(&b, "\n[View the job in Deck](%s)\n", pj.Status.URL)
+	}
+	if results == nil || len(results.failed) == 0 {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "\n### Failed tests\n\n%d of %d tests failed:\n\n", len(results.failed), results.total)
+	for i, test := range results.failed {
+		if i == maxListedTests {
+			fmt.Fprintf(&b, "- and %d more\n", len(results.failed)-maxListedTests)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s`\n", test.name)
+	}
+	if len(results.failed) > maxAnnotations {
+		fmt.Fprintf(&b, "\nOnly the first %d failed tests are annotated.\n", maxAnnotations)
+	}
+	return b.String()
+}
+
+// annotationFor returns the annotation of a failed test. It points to the
+// first location in the repo the output of the test mentions, or to the
+// junit file the test was read from.
+func annotationFor(test failedTest, refs *v1.Refs) github.CheckRunAnnotation {
+	path, line := test.file, 1
+	for _, match := range location.FindAllStringSubmatch(test.details, -1) {
+		candidate := match[1]
+		// Absolute paths of files in the repo are usually under the clone
+		// of the repo, like /home/prow/go/src/github.com/org/repo.
+		if i := strings.Index(candidate, "/"+refs.Org+"/"+refs.Repo+"/"); strings.HasPrefix(candidate, "/") && i != -1 {
+			candidate = candidate[i+len(refs.Org)+len(refs.Repo)+3:]
+		}
+		if strings.HasPrefix(candidate, "/") || strings.HasPrefix(candidate, "../") {
+			continue
+		}
+		n, err := strconv.Atoi(match[2])
+		if err != nil || n < 1 {
+			continue
+		}
+		path, line = candidate, n
+		break
+	}
+	message := test.message
+	if message == "" {
+		message, _, _ = strings.Cut(strings.TrimSpace(test.details), "\n")
+	}
+	if message == "" {
+		message = "Test failed."
+	}
+	return github.CheckRunAnnotation{
+		Path:            path,
+		StartLine:       line,
+		EndLine:         line,
+		AnnotationLevel: "failure",
+		Title:           test.name,
+		Message:         truncate(message, maxDetails),
+		RawDetails:      truncate(test.details, maxDetails),
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// testResults are the results of the tests of a job read from its junit
+// files.
+type testResults struct {
+	total  int
+	failed []failedTest
+}
+
+type failedTest struct {
+	name    string
+	message string
+	details string
+	// file is the path of the junit file of the test in the artifacts of
+	// the job.
+	file string
+}
+
+// testResults reads the results of the tests of the job from the junit
+// files in its artifacts.
+func (r *Reporter) testResults(ctx context.Context, pj *v1.ProwJob) (*testResults, error) {
+	bucket, dir, err := util.GetJobDestination(r.cfg, pj)
+	if err != nil {
+		return nil, err
+	}
+	path, err := providers.StoragePath(bucket, dir)
+	if err != nil {
+		return nil, err
+	}
+	provider, bucketName, _, err := providers.ParseStoragePath(path)
+	if err != nil {
+		return nil, err
+	}
+	root := fmt.Sprintf("%s://%s/", provider, bucketName)
+	artifacts := strings.TrimSuffix(path, "/") + "/artifacts/"
+
+	iter, err := r.opener.Iterator(ctx, artifacts, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the artifacts of the job: %w", err)
+	}
+	var files []string
+	for {
+		f, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the artifacts of the job: %w", err)
+		}
+		if !f.IsDir && junitFile.MatchString(f.ObjName) {
+			files = append(files, root+f.Name)
+		}
+	}
+
+	results := &testResults{}
+	for _, file := range files {
+		raw, err := r.read(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		suites, err := junit.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		rel := "artifacts/" + strings.TrimPrefix(file, artifacts)
+		for _, suite := range suites.Suites {
+			walkSuite(suite, func(result junit.Result) {
+				if result.Skipped != nil {
+					return
+				}
+				results.total++
+				test := failedTest{name: result.Name, file: rel}
+				if result.ClassName != "" {
+					test.name = result.ClassName + "." + result.Name
+				}
+				switch {
+				case result.Failure != nil:
+					test.message, test.details = result.Failure.Message, result.Failure.Value
+				case result.Errored != nil:
+					test.message, test.details = result.Errored.Message, result.Errored.Value
+				default:
+					return
+				}
+				results.failed = append(results.failed, test)
+			})
+		}
+	}
+	return results, nil
+}
+
+func (r *Reporter) read(ctx context.Context, path string) ([]byte, error) {
+	reader, err := r.opener.Reader(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func walkSuite(suite junit.Suite, visit func(junit.Result)) {
+	for _, result := range suite.Results {
+		visit(result)
+	}
+	for _, child := range suite.Suites {
+		walkSuite(child, visit)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package githubchecks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/github/report"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+// fakeOpener serves the files of a bucket.
+type fakeOpener map[string]string
+
+func (f fakeOpener) Reader(_ context.Context, path string) (pkgio.ReadCloser, error) {
+	content, ok := f[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewBufferString(content)), nil
+}
+
+func (f fakeOpener) Iterator(_ context.Context, prefix, _ string) (pkgio.ObjectIterator, error) {
+	var objects []pkgio.ObjectAttributes
+	for name := range f {
+		if strings.HasPrefix(name, prefix) {
+			key := strings.TrimPrefix(name, "gs://bucket/")
+			objects = append(objects, pkgio.ObjectAttributes{Name: key, ObjName: path.Base(key)})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return &fakeIterator{objects: objects}, nil
+}
+
+type fakeIterator struct {
+	objects []pkgio.ObjectAttributes
+}
+
+func (f *fakeIterator) Next(_ context.Context) (pkgio.ObjectAttributes, error) {
+	if len(f.objects) == 0 {
+		return pkgio.ObjectAttributes{}, io.EOF
+	}
+	next := f.objects[0]
+	f.objects = f.objects[1:]
+	return next, nil
+}
+
+const junitXML = `<testsuites>
+  <testsuite name="pkg">
+    <testcase classname="pkg/foo" name="TestPass"></testcase>
+    <testcase classname="pkg/foo" name="TestSkip"><skipped/></testcase>
+    <testcase classname="pkg/foo" name="TestFail">
+      <failure message="Failed">/home/prow/go/src/github.com/org/repo/pkg/foo/foo_test.go:42: got 1, want 2</failure>
+    </testcase>
+    <testcase classname="pkg/bar" name="TestError">
+      <error>panic: oops
+goroutine 1 [running]:</error>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+func TestReport(t *testing.T) {
+	started := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	completed := metav1.NewTime(started.Add(3 * time.Minute))
+	job := func(state v1.ProwJobState) *v1.ProwJob {
+		pj := &v1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "pj"},
+			Spec: v1.ProwJobSpec{
+				Type:    v1.PresubmitJob,
+				Job:     "pull-test",
+				Context: "pull-test",
+				Report:  true,
+				Refs: &v1.Refs{
+					Org:     "org",
+					Repo:    "repo",
+					BaseSHA: "base",
+					Pulls:   []v1.Pull{{Number: 1, SHA: "head"}},
+				},
+				DecorationConfig: &v1.DecorationConfig{GCSConfiguration: &v1.GCSConfiguration{
+					Bucket:       "bucket",
+					PathStrategy: v1.PathStrategyExplicit,
+				}},
+			},
+			Status: v1.ProwJobStatus{
+				State:     state,
+				StartTime: started,
+				BuildID:   "42",
+				URL:       "https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42",
+			},
+		}
+		if state != v1.PendingState {
+			pj.Status.CompletionTime = &completed
+		}
+		return pj
+	}
+	rerun := []github.CheckRunAction{{Label: "Re-run", Description: "Run this job again in Prow.", Identifier: report.RerunCheckRunAction}}
+	opener := fakeOpener{
+		"gs://bucket/pr-logs/pull/org_repo/1/pull-test/42/artifacts/junit_01.xml": junitXML,
+		"gs://bucket/pr-logs/pull/org_repo/1/pull-test/42/artifacts/build.log":    "not junit",
+	}
+
+	testCases := []struct {
+		name     string
+		pj       *v1.ProwJob
+		existing []github.CheckRun
+		expected []github.CheckRun
+	}{
+		{
+			name: "running job creates an in progress check run",
+			pj:   job(v1.PendingState),
+			expected: []github.CheckRun{{
+				ID:         1,
+				Name:       "pull-test",
+				HeadSHA:    "head",
+				ExternalID: "pj",
+				DetailsURL: "https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42",
+				Status:     github.CheckRunInProgress,
+				StartedAt:  "2024-01-01T00:00:00Z",
+				Output: github.CheckRunOutput{
+					Title:   "pending",
+					Summary: "Job `pull-test` is running. Tested against base commit base.\n\n[View the job in Deck](https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42)\n",
+				},
+			}},
+		},
+		{
+			name:     "failed job updates the check run with annotations",
+			pj:       job(v1.FailureState),
+			existing: []github.CheckRun{{ID: 7, Name: "pull-test", HeadSHA: "head", ExternalID: "pj", Status: github.CheckRunInProgress}},
+			expected: []github.CheckRun{{
+				ID:          7,
+				Name:        "pull-test",
+				HeadSHA:     "head",
+				ExternalID:  "pj",
+				DetailsURL:  "https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42",
+				Status:      github.CheckRunCompleted,
+				Conclusion:  github.CheckRunConclusionFailure,
+				StartedAt:   "2024-01-01T00:00:00Z",
+				CompletedAt: "2024-01-01T00:03:00Z",
+				Output: github.CheckRunOutput{
+					Title: "failure",
+					Summary: "Job `pull-test` failed after 3m0s. Tested against base commit base.\n\n" +
+						"[View the job in Deck](https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42)\n\n" +
+						"### Failed tests\n\n2 of 3 tests failed:\n\n- `pkg/foo.TestFail`\n- `pkg/bar.TestError`\n",
+					Annotations: []github.CheckRunAnnotation{
+						{
+							Path:            "pkg/foo/foo_test.go",
+							StartLine:       42,
+							EndLine:         42,
+							AnnotationLevel: "failure",
+							Title:           "pkg/foo.TestFail",
+							Message:         "Failed",
+							RawDetails:      "/home/prow/go/src/github.com/org/repo/pkg/foo/foo_test.go:42: got 1, want 2",
+						},
+						{
+							Path:            "artifacts/junit_01.xml",
+							StartLine:       1,
+							EndLine:         1,
+							AnnotationLevel: "failure",
+							Title:           "pkg/bar.TestError",
+							Message:         "panic: oops",
+							RawDetails:      "panic: oops\ngoroutine 1 [running]:",
+						},
+					},
+				},
+				Actions: rerun,
+			}},
+		},
+		{
+			name: "successful job completes the check run",
+			pj:   job(v1.SuccessState),
+			expected: []github.CheckRun{{
+				ID:          1,
+				Name:        "pull-test",
+				HeadSHA:     "head",
+				ExternalID:  "pj",
+				DetailsURL:  "https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42",
+				Status:      github.CheckRunCompleted,
+				Conclusion:  github.CheckRunConclusionSuccess,
+				StartedAt:   "2024-01-01T00:00:00Z",
+				CompletedAt: "2024-01-01T00:03:00Z",
+				Output: github.CheckRunOutput{
+					Title:   "success",
+					Summary: "Job `pull-test` succeeded after 3m0s. Tested against base commit base.\n\n[View the job in Deck](https://prow.example.com/view/gs/bucket/pr-logs/pull/org_repo/1/pull-test/42)\n",
+				},
+				Actions: rerun,
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := fakegithub.NewFakeClient()
+			ghc.CheckRuns = map[string][]github.CheckRun{"head": tc.existing}
+			ghc.CheckRunID = int64(len(tc.existing))
+			cfg := func() *config.Config { return &config.Config{} }
+			r := NewReporter(ghc, cfg, opener, "")
+			if !r.ShouldReport(context.Background(), logrus.NewEntry(logrus.New()), tc.pj) {
+				t.Fatal("expected the job to be reported")
+			}
+			if _, _, err := r.Report(context.Background(), logrus.NewEntry(logrus.New()), tc.pj); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, ghc.CheckRuns["head"]); diff != "" {
+				t.Errorf("unexpected check runs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -57,6 +57,10 @@ type ReportMessage struct {
 	JobType prowapi.ProwJobType  `json:"job_type"`
 	JobName string               `json:"job_name"`
 	Message string               `json:"message,omitempty"`
+	// Labels and Annotations are the labels and annotations of the ProwJob
+	// selected by the reports policy of the ProwJob metadata propagation.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // Client is a reporter client fed to crier controller
@@ -182,16 +186,19 @@ func (c *Client) generateMessageFromPJ(pj *prowapi.ProwJob) *ReportMessage {
 
 	}
 
+	labels, annotations := c.config().ProwJobMetadataPropagation.ReportLabelsAndAnnotations(pj)
 	return &ReportMessage{
-		Project: pubSubMap[PubSubProjectLabel],
-		Topic:   pubSubMap[PubSubTopicLabel],
-		RunID:   pubSubMap[PubSubRunIDLabel],
-		Status:  pj.Status.State,
-		URL:     pj.Status.URL,
-		GCSPath: storagePath,
-		Refs:    refs,
-		JobType: pj.Spec.Type,
-		JobName: pj.Spec.Job,
-		Message: pj.Status.Description,
+		Project:     pubSubMap[PubSubProjectLabel],
+		Topic:       pubSubMap[PubSubTopicLabel],
+		RunID:       pubSubMap[PubSubRunIDLabel],
+		Status:      pj.Status.State,
+		URL:         pj.Status.URL,
+		GCSPath:     storagePath,
+		Refs:        refs,
+		JobType:     pj.Spec.Type,
+		JobName:     pj.Spec.Job,
+		Message:     pj.Status.Description,
+		Labels:      labels,
+		Annotations: annotations,
 	}
 }
@@ -106,7 +106,7 @@ func (r *Reporter) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob
 	files, err := resultstore.ArtifactFiles(ctx, r.opener, resultstore.ArtifactOpts{
 		Dir:              path,
 		ArtifactsDirOnly: r.dirOnly,
-		DefaultFiles:     defaultFiles(pj),
+		DefaultFiles:     defaultFiles(pj, r.cfg().ProwJobMetadataPropagation),
 	})
 	if err != nil {
 		// Log and continue in case of errors.
@@ -154,19 +154,19 @@ func readStartedFile(ctx context.Context, log *logrus.Entry, opener io.Opener, d
 
 // defaultFiles returns the files to ensure are uploaded to
 // ResultStore, even if not (yet) present.
-func defaultFiles(pj *v1.ProwJob) []resultstore.DefaultFile {
+func defaultFiles(pj *v1.ProwJob, propagation config.MetadataPropagation) []resultstore.DefaultFile {
 	var fs []resultstore.DefaultFile
 
 	// There is a race with the GCS reporter writing prowjob.json and
 	// finished.json, so provide these as defaults. In the unlikely
 	// case of error, skip it since the GCS reporter won't write it.
-	if bs, err := util.MarshalProwJob(pj); err == nil {
+	if bs, err := util.MarshalProwJob(pj, propagation); err == nil {
 		fs = append(fs, resultstore.DefaultFile{
 			Name: "prowjob.json",
 			Size: int64(len(bs)),
 		})
 	}
-	if bs, err := util.MarshalFinishedJSON(pj); err == nil {
+	if bs, err := util.MarshalFinishedJSON(pj, propagation); err == nil {
 		fs = append(fs, resultstore.DefaultFile{
 			Name: "finished.json",
 			Size: int64(len(bs)),
@@ -71,8 +71,24 @@ func (sr *slackReporter) Report(_ context.Context, log *logrus.Entry, pj *prowap
 	return []*prowapi.ProwJob{pj}, nil, sr.report(log, pj)
 }
 
+// withOwnerChannel routes the report to the Slack channel of the team owning
+// the job, unless the job explicitly configured a channel.
+func withOwnerChannel(jobSlackConfig *prowapi.SlackReporterConfig, pj *prowapi.ProwJob) *prowapi.SlackReporterConfig {
+	owner := config.JobOwnerFromMeta(pj.Labels, pj.Annotations)
+	if owner == nil || owner.SlackChannel == "" || (jobSlackConfig != nil && jobSlackConfig.Channel != "") {
+		return jobSlackConfig
+	}
+	routed := &prowapi.SlackReporterConfig{}
+	if jobSlackConfig != nil {
+		routed = jobSlackConfig.DeepCopy()
+	}
+	routed.Channel = owner.SlackChannel
+	return routed
+}
+
 func (sr *slackReporter) report(log *logrus.Entry, pj *prowapi.ProwJob) error {
 	globalSlackConfig, jobSlackConfig := sr.getConfig(pj)
+	jobSlackConfig = withOwnerChannel(jobSlackConfig, pj)
 	if globalSlackConfig != nil {
 		jobSlackConfig = jobSlackConfig.ApplyDefault(&globalSlackConfig.SlackReporterConfig)
 	}
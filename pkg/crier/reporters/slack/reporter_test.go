@@ -21,9 +21,11 @@ import (
 	"testing"
 
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
 )
 
 func TestShouldReport(t *testing.T) {
@@ -509,6 +511,61 @@ func TestUsesChannelOverrideFromJob(t *testing.T) {
 			wantHost:    "*",
 			wantChannel: "team-a",
 		},
+		{
+			name: "Owner channel takes precedence over global config",
+			config: func() config.Config {
+				slackCfg := map[string]config.SlackReporter{
+					"*": {
+						SlackReporterConfig: v1.SlackReporterConfig{
+							Channel: "global-default",
+						},
+					},
+				}
+				return config.Config{
+					ProwConfig: config.ProwConfig{
+						SlackReporterConfigs: slackCfg,
+					},
+				}
+			},
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{kube.OwnerSlackChannelAnnotation: "team-owner"},
+				},
+			},
+			wantHost:    "*",
+			wantChannel: "team-owner",
+		},
+		{
+			name: "Job-level channel takes precedence over owner channel",
+			config: func() config.Config {
+				slackCfg := map[string]config.SlackReporter{
+					"*": {
+						SlackReporterConfig: v1.SlackReporterConfig{
+							Channel: "global-default",
+						},
+					},
+				}
+				return config.Config{
+					ProwConfig: config.ProwConfig{
+						SlackReporterConfigs: slackCfg,
+					},
+				}
+			},
+			pj: &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{kube.OwnerSlackChannelAnnotation: "team-owner"},
+				},
+				Spec: v1.ProwJobSpec{
+					ReporterConfig: &v1.ReporterConfig{
+						Slack: &v1.SlackReporterConfig{
+							Channel: "team-a",
+						},
+					},
+				},
+			},
+			wantHost:    "*",
+			wantChannel: "team-a",
+		},
 		{
 			name: "No matching slack config",
 			config: func() config.Config {
@@ -579,6 +636,7 @@ func TestUsesChannelOverrideFromJob(t *testing.T) {
 			}
 
 			prowSlackCfg, jobSlackCfg := sr.getConfig(tc.pj)
+			jobSlackCfg = withOwnerChannel(jobSlackCfg, tc.pj)
 			jobSlackCfg = jobSlackCfg.ApplyDefault(&prowSlackCfg.SlackReporterConfig)
 			gotHost, gotChannel := hostAndChannel(jobSlackCfg)
 			if gotHost != tc.wantHost {
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slo contains a crier reporter tracking the jobs that declare an
+// slo: it records the results of their runs, exposes their pass rate and the
+// rate their error budget burns at as metrics, and alerts a webhook when a
+// job starts or stops breaching its SLO.
+package slo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+const (
+	reporterName = "sloreporter"
+
+	// AlertPassRate is the kind of the alerts about the pass rate of a job.
+	AlertPassRate = "pass_rate"
+	// AlertDuration is the kind of the alerts about a run of a job that took
+	// longer than its max_duration.
+	AlertDuration = "duration"
+
+	webhookTimeout = 10 * time.Second
+)
+
+var sloMetrics = struct {
+	passRate         *prometheus.GaugeVec
+	burnRate         *prometheus.GaugeVec
+	runs             *prometheus.GaugeVec
+	durationBreaches *prometheus.CounterVec
+}{
+	passRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_job_slo_pass_rate",
+		Help: "Fraction of the runs of a job that passed in the window of its SLO.",
+	}, []string{"job", "repo"}),
+	burnRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_job_slo_burn_rate",
+		Help: "Rate the error budget of the SLO of a job burns at in its window. Above 1 the job breaches its target pass rate.",
+	}, []string{"job", "repo"}),
+	runs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_job_slo_runs",
+		Help: "Number of runs of a job in the window of its SLO.",
+	}, []string{"job", "repo"}),
+	durationBreaches: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_job_slo_duration_breaches_total",
+		Help: "Number of runs of a job that took longer than the max_duration of its SLO.",
+	}, []string{"job", "repo"}),
+}
+
+func init() {
+	prometheus.MustRegister(sloMetrics.passRate)
+	prometheus.MustRegister(sloMetrics.burnRate)
+	prometheus.MustRegister(sloMetrics.runs)
+	prometheus.MustRegister(sloMetrics.durationBreaches)
+}
+
+// Alert is the JSON sent to the webhook of the SLO reporter.
+type Alert struct {
+	// Kind is AlertPassRate or AlertDuration.
+	Kind string `json:"kind"`
+	// Breached is true when the job starts breaching its target pass rate or
+	// a run took too long, and false when the job stops breaching its target
+	// pass rate.
+	Breached bool   `json:"breached"`
+	Job      string `json:"job"`
+	// Repo is the org/repo of the job, empty for periodics.
+	Repo           string  `json:"repo,omitempty"`
+	PassRate       float64 `json:"pass_rate,omitempty"`
+	TargetPassRate float64 `json:"target_pass_rate,omitempty"`
+	Runs           int     `json:"runs,omitempty"`
+	Duration       string  `json:"duration,omitempty"`
+	MaxDuration    string  `json:"max_duration,omitempty"`
+	// URL is the URL of the run the alert was raised for.
+	URL string `json:"url,omitempty"`
+}
+
+type jobKey struct {
+	job, repo string
+}
+
+type run struct {
+	name     string
+	finished time.Time
+	passed   bool
+}
+
+type jobState struct {
+	runs      []run
+	breaching bool
+}
+
+// Client records the runs of the jobs that declare an SLO.
+type Client struct {
+	config config.Getter
+	client *http.Client
+	now    func() time.Time
+
+	lock sync.Mutex
+	jobs map[jobKey]*jobState
+}
+
+// NewReporter returns a reporter tracking the SLOs of jobs.
+func NewReporter(cfg config.Getter) *Client {
+	return &Client{
+		config: cfg,
+		client: &http.Client{Timeout: webhookTimeout},
+		now:    time.Now,
+		jobs:   map[jobKey]*jobState{},
+	}
+}
+
+// GetName returns the name of the reporter.
+func (c *Client) GetName() string {
+	return reporterName
+}
+
+// ShouldReport returns whether the job completed and declares an SLO.
+func (c *Client) ShouldReport(_ context.Context, _ *logrus.Entry, pj *prowapi.ProwJob) bool {
+	return pj.Complete() && c.config().JobSLOFor(pj) != nil
+}
+
+// Report records the run and sends the alerts it raises. Alerts that can't
+// be sent are only logged, as reporting the run again would count it twice.
+func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *prowapi.ProwJob) ([]*prowapi.ProwJob, *reconcile.Result, error) {
+	cfg := c.config()
+	slo := cfg.JobSLOFor(pj)
+	if slo == nil {
+		return []*prowapi.ProwJob{pj}, nil, nil
+	}
+	alerts := c.record(pj, slo)
+	if cfg.SLOReporter == nil || cfg.SLOReporter.WebhookURL == "" {
+		return []*prowapi.ProwJob{pj}, nil, nil
+	}
+	for _, alert := range alerts {
+		if err := c.send(ctx, cfg.SLOReporter.WebhookURL, alert); err != nil {
+			log.WithError(err).WithField("kind", alert.Kind).Warn("Failed to send the SLO alert.")
+		}
+	}
+	return []*prowapi.ProwJob{pj}, nil, nil
+}
+
+// record adds the run to the runs of the job in the window of its SLO,
+// updates the metrics of the job and returns the alerts the run raises.
+func (c *Client) record(pj *prowapi.ProwJob, slo *config.JobSLO) []Alert {
+	key := jobKey{job: pj.Spec.Job}
+	if pj.Spec.Type != prowapi.PeriodicJob && pj.Spec.Refs != nil {
+		key.repo = pj.Spec.Refs.Org + "/" + pj.Spec.Refs.Repo
+	}
+	finished := c.now()
+	if pj.Status.CompletionTime != nil {
+		finished = pj.Status.CompletionTime.Time
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	state, ok := c.jobs[key]
+	if !ok {
+		state = &jobState{}
+		c.jobs[key] = state
+	}
+	for _, r := range state.runs {
+		if r.name == pj.Name {
+			return nil
+		}
+	}
+	state.runs = append(state.runs, run{name: pj.Name, finished: finished, passed: pj.Status.State == prowapi.SuccessState})
+	cutoff := c.now().Add(-slo.GetWindow())
+	var kept []run
+	for _, r := range state.runs {
+		if r.finished.After(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	state.runs = kept
+
+	var alerts []Alert
+	passed := 0
+	for _, r := range state.runs {
+		if r.passed {
+			passed++
+		}
+	}
+	passRate := float64(passed) / float64(len(state.runs))
+	sloMetrics.passRate.WithLabelValues(key.job, key.repo).Set(passRate)
+	sloMetrics.runs.WithLabelValues(key.job, key.repo).Set(float64(len(state.runs)))
+	if slo.TargetPassRate > 0 {
+		sloMetrics.burnRate.WithLabelValues(key.job, key.repo).Set(burnRate(passRate, slo.TargetPassRate))
+		breaching := len(state.runs) >= slo.GetMinRuns() && passRate < slo.TargetPassRate
+		if breaching != state.breaching {
+			state.breaching = breaching
+			alerts = append(alerts, Alert{
+				Kind:           AlertPassRate,
+				Breached:       breaching,
+				Job:            key.job,
+				Repo:           key.repo,
+				PassRate:       passRate,
+				TargetPassRate: slo.TargetPassRate,
+				Runs:           len(state.runs),
+				URL:            pj.Status.URL,
+			})
+		}
+	}
+	if slo.MaxDuration != nil && pj.Status.CompletionTime != nil {
+		if duration := pj.Status.CompletionTime.Sub(pj.Status.StartTime.Time); duration > slo.MaxDuration.Duration {
+			sloMetrics.durationBreaches.WithLabelValues(key.job, key.repo).Inc()
+			alerts = append(alerts, Alert{
+				Kind:        AlertDuration,
+				Breached:    true,
+				Job:         key.job,
+				Repo:        key.repo,
+				Duration:    duration.Round(time.Second).String(),
+				MaxDuration: slo.MaxDuration.Duration.String(),
+				URL:         pj.Status.URL,
+			})
+		}
+	}
+	return alerts
+}
+
+// burnRate returns the rate the error budget burns at: the fraction of runs
+// that failed over the fraction that may fail.
+func burnRate(passRate, target float64) float64 {
+	failed, budget := 1-passRate, 1-target
+	if budget == 0 {
+		if failed == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return failed / budget
+}
+
+func (c *Client) send(ctx context.Context, url string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
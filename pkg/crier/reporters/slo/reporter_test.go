@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func periodic(name string, state prowapi.ProwJobState, finished time.Time, duration time.Duration) *prowapi.ProwJob {
+	return &prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "ci-e2e"},
+		Status: prowapi.ProwJobStatus{
+			State:          state,
+			StartTime:      metav1.NewTime(finished.Add(-duration)),
+			CompletionTime: &metav1.Time{Time: finished},
+			URL:            "https://prow/view/" + name,
+		},
+	}
+}
+
+func TestReport(t *testing.T) {
+	slo := &config.JobSLO{
+		TargetPassRate: 0.5,
+		MaxDuration:    &metav1.Duration{Duration: time.Hour},
+		Window:         &metav1.Duration{Duration: 24 * time.Hour},
+		MinRuns:        2,
+	}
+	testCases := []struct {
+		name           string
+		runs           []*prowapi.ProwJob
+		expectedAlerts []Alert
+	}{
+		{
+			name: "passing runs raise no alert",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.SuccessState, now.Add(-2*time.Hour), time.Minute),
+				periodic("b", prowapi.SuccessState, now.Add(-time.Hour), time.Minute),
+			},
+		},
+		{
+			name: "failures below the minimum number of runs raise no alert",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.FailureState, now.Add(-time.Hour), time.Minute),
+			},
+		},
+		{
+			name: "job breaches and recovers",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.FailureState, now.Add(-3*time.Hour), time.Minute),
+				periodic("b", prowapi.FailureState, now.Add(-2*time.Hour), time.Minute),
+				periodic("c", prowapi.SuccessState, now.Add(-time.Hour), time.Minute),
+				periodic("d", prowapi.SuccessState, now, time.Minute),
+			},
+			expectedAlerts: []Alert{
+				{Kind: AlertPassRate, Breached: true, Job: "ci-e2e", PassRate: 0, TargetPassRate: 0.5, Runs: 2, URL: "https://prow/view/b"},
+				{Kind: AlertPassRate, Breached: false, Job: "ci-e2e", PassRate: 0.5, TargetPassRate: 0.5, Runs: 4, URL: "https://prow/view/d"},
+			},
+		},
+		{
+			name: "runs out of the window are dropped",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.FailureState, now.Add(-48*time.Hour), time.Minute),
+				periodic("b", prowapi.FailureState, now.Add(-47*time.Hour), time.Minute),
+				periodic("c", prowapi.SuccessState, now.Add(-time.Hour), time.Minute),
+			},
+		},
+		{
+			name: "a run reported twice is counted once",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.SuccessState, now.Add(-time.Hour), time.Minute),
+				periodic("b", prowapi.FailureState, now, time.Minute),
+				periodic("b", prowapi.FailureState, now, time.Minute),
+			},
+		},
+		{
+			name: "run longer than max_duration",
+			runs: []*prowapi.ProwJob{
+				periodic("a", prowapi.SuccessState, now, 90*time.Minute),
+			},
+			expectedAlerts: []Alert{
+				{Kind: AlertDuration, Breached: true, Job: "ci-e2e", Duration: "1h30m0s", MaxDuration: "1h0m0s", URL: "https://prow/view/a"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var alerts []Alert
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var alert Alert
+				if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+					t.Errorf("failed to decode the alert: %v", err)
+				}
+				lock.Lock()
+				alerts = append(alerts, alert)
+				lock.Unlock()
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{
+				JobConfig:  config.JobConfig{Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "ci-e2e", SLO: slo}}}},
+				ProwConfig: config.ProwConfig{SLOReporter: &config.SLOReporter{WebhookURL: server.URL}},
+			}
+			c := NewReporter(func() *config.Config { return cfg })
+			c.now = func() time.Time { return now }
+			for _, pj := range tc.runs {
+				if !c.ShouldReport(context.Background(), logrus.NewEntry(logrus.New()), pj) {
+					t.Fatalf("expected %s to be reported", pj.Name)
+				}
+				if _, _, err := c.Report(context.Background(), logrus.NewEntry(logrus.New()), pj); err != nil {
+					t.Fatalf("failed to report %s: %v", pj.Name, err)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedAlerts, alerts); diff != "" {
+				t.Errorf("unexpected alerts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestShouldReport(t *testing.T) {
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{
+				{JobBase: config.JobBase{Name: "ci-e2e", SLO: &config.JobSLO{TargetPassRate: 0.9}}},
+				{JobBase: config.JobBase{Name: "ci-unit"}},
+			},
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "pull-e2e", SLO: &config.JobSLO{TargetPassRate: 0.9}}}},
+			},
+		},
+	}
+	c := NewReporter(func() *config.Config { return cfg })
+	testCases := []struct {
+		name     string
+		pj       *prowapi.ProwJob
+		expected bool
+	}{
+		{
+			name:     "completed periodic with an SLO",
+			pj:       periodic("a", prowapi.SuccessState, now, time.Minute),
+			expected: true,
+		},
+		{
+			name: "running periodic with an SLO",
+			pj: &prowapi.ProwJob{
+				Spec:   prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "ci-e2e"},
+				Status: prowapi.ProwJobStatus{State: prowapi.PendingState},
+			},
+		},
+		{
+			name: "periodic without an SLO",
+			pj: &prowapi.ProwJob{
+				Spec:   prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "ci-unit"},
+				Status: prowapi.ProwJobStatus{State: prowapi.SuccessState, CompletionTime: &metav1.Time{Time: now}},
+			},
+		},
+		{
+			name: "presubmit with an SLO",
+			pj: &prowapi.ProwJob{
+				Spec:   prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Job: "pull-e2e", Refs: &prowapi.Refs{Org: "org", Repo: "repo"}},
+				Status: prowapi.ProwJobStatus{State: prowapi.FailureState, CompletionTime: &metav1.Time{Time: now}},
+			},
+			expected: true,
+		},
+		{
+			name: "presubmit of another repo",
+			pj: &prowapi.ProwJob{
+				Spec:   prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Job: "pull-e2e", Refs: &prowapi.Refs{Org: "org", Repo: "other"}},
+				Status: prowapi.ProwJobStatus{State: prowapi.FailureState, CompletionTime: &metav1.Time{Time: now}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := c.ShouldReport(context.Background(), logrus.NewEntry(logrus.New()), tc.pj); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	testCases := []struct {
+		passRate, target, expected float64
+	}{
+		{passRate: 1, target: 0.9, expected: 0},
+		{passRate: 0.8, target: 0.9, expected: 2},
+		{passRate: 0.95, target: 0.9, expected: 0.5},
+		{passRate: 1, target: 1, expected: 0},
+		{passRate: 0.5, target: 1, expected: math.Inf(1)},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v of %v", tc.passRate, tc.target), func(t *testing.T) {
+			if actual := burnRate(tc.passRate, tc.target); math.Abs(actual-tc.expected) > 1e-9 && actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	cron "gopkg.in/robfig/cron.v2" // using v2 api, doc at https://godoc.org/gopkg.in/robfig/cron.v2
@@ -183,3 +184,26 @@ func (c *Cron) removeJob(name string) error {
 	c.logger.Infof("Removed previous cron job %s.", name)
 	return nil
 }
+
+// maxWindows bounds the number of windows MissedWindows iterates over, for
+// frequent crons that were missed for a long time.
+const maxWindows = 100000
+
+// MissedWindows returns the number of windows of the cron in (since, now],
+// and the times of the latest keep of them, oldest first.
+func MissedWindows(cronStr string, since, now time.Time, keep int) (int, []time.Time, error) {
+	schedule, err := cron.Parse("TZ=UTC " + cronStr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid cron %s: %w", cronStr, err)
+	}
+	var missed int
+	var windows []time.Time
+	for t := schedule.Next(since); !t.IsZero() && !t.After(now) && missed < maxWindows; t = schedule.Next(t) {
+		missed++
+		windows = append(windows, t)
+		if len(windows) > keep {
+			windows = windows[1:]
+		}
+	}
+	return missed, windows, nil
+}
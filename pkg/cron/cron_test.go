@@ -18,7 +18,9 @@ package cron
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	cron "gopkg.in/robfig/cron.v2"
 	"sigs.k8s.io/prow/pkg/config"
 )
@@ -200,3 +202,55 @@ func TestTrigger(t *testing.T) {
 		t.Error("should have triggered job 'periodic'")
 	}
 }
+
+func TestMissedWindows(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name            string
+		cron            string
+		now             time.Time
+		keep            int
+		expectedMissed  int
+		expectedWindows []time.Time
+	}{
+		{
+			name: "no window missed",
+			cron: "0 * * * *",
+			now:  time.Date(2024, 1, 1, 0, 59, 0, 0, time.UTC),
+			keep: 3,
+		},
+		{
+			name:            "window at now is missed",
+			cron:            "0 * * * *",
+			now:             time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			keep:            3,
+			expectedMissed:  1,
+			expectedWindows: []time.Time{time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:           "latest windows are kept",
+			cron:           "0 * * * *",
+			now:            time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC),
+			keep:           2,
+			expectedMissed: 5,
+			expectedWindows: []time.Time{
+				time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			missed, windows, err := MissedWindows(tc.cron, since, tc.now, tc.keep)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if missed != tc.expectedMissed {
+				t.Errorf("expected %d missed windows, got %d", tc.expectedMissed, missed)
+			}
+			if diff := cmp.Diff(tc.expectedWindows, windows); diff != "" {
+				t.Errorf("unexpected windows (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -181,6 +181,7 @@ func (c *filteringProwJobLister) pjHasHiddenRefs(pj prowapi.ProwJob) bool {
 type JobAgent struct {
 	kc        serviceClusterClient
 	pkcs      map[string]PodLogClient
+	pcs       map[string]PipelineClient
 	config    config.Getter
 	prowJobs  []prowapi.ProwJob
 	jobs      []Job
@@ -191,10 +192,21 @@ type JobAgent struct {
 
 // Start will start the job and periodically update it.
 func (ja *JobAgent) Start() {
+	ja.StartWithTrigger(nil)
+}
+
+// StartWithTrigger will start the job and periodically update it, and also
+// update it whenever the trigger fires, e.g. when the hidden repos in the
+// config change.
+func (ja *JobAgent) StartWithTrigger(trigger <-chan struct{}) {
 	ja.tryUpdate()
 	go func() {
 		t := time.Tick(period)
-		for range t {
+		for {
+			select {
+			case <-t:
+			case <-trigger:
+			}
 			ja.tryUpdate()
 		}
 	}()
@@ -236,12 +248,17 @@ func (ja *JobAgent) GetProwJob(job, id string) (prowapi.ProwJob, error) {
 	return j, nil
 }
 
-// GetJobLog returns the job logs, works for both kubernetes and jenkins agent types.
+// GetJobLog returns the job logs, works for kubernetes, tekton-pipeline and
+// jenkins agent types. The logs of jobs with the tekton-pipeline agent are per
+// step, see StepLog.
 func (ja *JobAgent) GetJobLog(job, id string, container string) ([]byte, error) {
 	j, err := ja.GetProwJob(job, id)
 	if err != nil {
 		return nil, fmt.Errorf("error getting prowjob: %w", err)
 	}
+	if j.Spec.Agent == prowapi.TektonAgent {
+		return ja.getStepLog(j, container)
+	}
 	if j.Spec.Agent == prowapi.KubernetesAgent {
 		client, ok := ja.pkcs[j.ClusterAlias()]
 		if !ok {
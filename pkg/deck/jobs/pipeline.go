@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// PipelineClient is an interface for reading the TaskRuns of the PipelineRuns
+// of jobs with the tekton-pipeline agent and the logs of their steps.
+type PipelineClient interface {
+	ListTaskRuns(namespace, pipelineRun string) ([]pipelinev1.TaskRun, error)
+	GetLogs(namespace, pod, container string) ([]byte, error)
+}
+
+// PipelineStatus is the live status of the PipelineRun of a job.
+type PipelineStatus struct {
+	TaskRuns []TaskRunStatus `json:"task_runs"`
+}
+
+// TaskRunStatus is the status of a TaskRun of the PipelineRun of a job.
+type TaskRunStatus struct {
+	Name         string       `json:"name"`
+	PipelineTask string       `json:"pipeline_task"`
+	PodName      string       `json:"pod_name,omitempty"`
+	State        string       `json:"state"`
+	Message      string       `json:"message,omitempty"`
+	Started      *metav1.Time `json:"started,omitempty"`
+	Finished     *metav1.Time `json:"finished,omitempty"`
+	Steps        []StepStatus `json:"steps,omitempty"`
+}
+
+// StepStatus is the status of a step of a TaskRun.
+type StepStatus struct {
+	Name string `json:"name"`
+	// Log is the container to pass to GetJobLog for the log of the step.
+	Log      string `json:"log"`
+	State    string `json:"state"`
+	Reason   string `json:"reason,omitempty"`
+	ExitCode *int32 `json:"exit_code,omitempty"`
+}
+
+// StepLog returns the container to pass to GetJobLog for the log of a step of
+// a job with the tekton-pipeline agent. Names of pipeline tasks and steps
+// can't contain dots, so the log is unambiguous.
+func StepLog(pipelineTask, step string) string {
+	return pipelineTask + "." + step
+}
+
+// WithPipelineClients sets the clients used to read the PipelineRuns of jobs
+// with the tekton-pipeline agent, by build cluster.
+func (ja *JobAgent) WithPipelineClients(pcs map[string]PipelineClient) *JobAgent {
+	ja.pcs = pcs
+	return ja
+}
+
+// GetPipelineStatus returns the live status of the TaskRuns of the
+// PipelineRun of a job with the tekton-pipeline agent.
+func (ja *JobAgent) GetPipelineStatus(job, id string) (*PipelineStatus, error) {
+	j, err := ja.GetProwJob(job, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting prowjob: %w", err)
+	}
+	taskRuns, _, err := ja.listTaskRuns(j)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &PipelineStatus{TaskRuns: []TaskRunStatus{}}
+	for _, tr := range taskRuns {
+		pipelineTask := tr.Labels[pipeline.PipelineTaskLabelKey]
+		trs := TaskRunStatus{
+			Name:         tr.Name,
+			PipelineTask: pipelineTask,
+			PodName:      tr.Status.PodName,
+			State:        "Pending",
+			Started:      tr.Status.StartTime,
+			Finished:     tr.Status.CompletionTime,
+		}
+		if cond := tr.Status.GetCondition(apis.ConditionSucceeded); cond != nil {
+			trs.State = cond.Reason
+			trs.Message = cond.Message
+		}
+		for _, step := range tr.Status.Steps {
+			ss := StepStatus{Name: step.Name, Log: StepLog(pipelineTask, step.Name)}
+			switch {
+			case step.Terminated != nil:
+				ss.State = "terminated"
+				ss.Reason = step.Terminated.Reason
+				ss.ExitCode = &step.Terminated.ExitCode
+			case step.Running != nil:
+				ss.State = "running"
+			case step.Waiting != nil:
+				ss.State = "waiting"
+				ss.Reason = step.Waiting.Reason
+			}
+			trs.Steps = append(trs.Steps, ss)
+		}
+		status.TaskRuns = append(status.TaskRuns, trs)
+	}
+	return status, nil
+}
+
+// getStepLog returns the log of a step of a job with the tekton-pipeline
+// agent, read from the pod of its TaskRun in the build cluster.
+func (ja *JobAgent) getStepLog(j prowapi.ProwJob, log string) ([]byte, error) {
+	pipelineTask, stepName, ok := strings.Cut(log, ".")
+	if !ok {
+		return nil, fmt.Errorf("cannot get logs for prowjob %q with agent %q: expected the container to be <pipeline task>.<step>, got %q", j.ObjectMeta.Name, j.Spec.Agent, log)
+	}
+	taskRuns, client, err := ja.listTaskRuns(j)
+	if err != nil {
+		return nil, err
+	}
+	for _, tr := range taskRuns {
+		if tr.Labels[pipeline.PipelineTaskLabelKey] != pipelineTask {
+			continue
+		}
+		for _, step := range tr.Status.Steps {
+			if step.Name != stepName {
+				continue
+			}
+			container := step.Container
+			if container == "" {
+				container = "step-" + step.Name
+			}
+			return client.GetLogs(tr.Namespace, tr.Status.PodName, container)
+		}
+	}
+	return nil, fmt.Errorf("step %q of pipeline task %q not found in the pipeline run of prowjob %q", stepName, pipelineTask, j.ObjectMeta.Name)
+}
+
+// listTaskRuns lists the TaskRuns of the PipelineRun of the job, which is
+// named like the job, in the order they started.
+func (ja *JobAgent) listTaskRuns(j prowapi.ProwJob) ([]pipelinev1.TaskRun, PipelineClient, error) {
+	if j.Spec.Agent != prowapi.TektonAgent {
+		return nil, nil, fmt.Errorf("prowjob %q has agent %q, not %q", j.ObjectMeta.Name, j.Spec.Agent, prowapi.TektonAgent)
+	}
+	client, ok := ja.pcs[j.ClusterAlias()]
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot get the pipeline run of prowjob %q: unknown cluster alias %q", j.ObjectMeta.Name, j.ClusterAlias())
+	}
+	taskRuns, err := client.ListTaskRuns(j.Spec.Namespace, j.ObjectMeta.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing the task runs of prowjob %q: %w", j.ObjectMeta.Name, err)
+	}
+	sort.SliceStable(taskRuns, func(i, k int) bool {
+		si, sk := taskRuns[i].Status.StartTime, taskRuns[k].Status.StartTime
+		switch {
+		case (si == nil) != (sk == nil):
+			return sk == nil
+		case si != nil && !si.Equal(sk):
+			return si.Before(sk)
+		}
+		return taskRuns[i].Name < taskRuns[k].Name
+	})
+	return taskRuns, client, nil
+}
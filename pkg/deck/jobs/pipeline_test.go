@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+type fakePipelineClient struct {
+	taskRuns map[string][]pipelinev1.TaskRun
+}
+
+func (f fakePipelineClient) ListTaskRuns(namespace, pipelineRun string) ([]pipelinev1.TaskRun, error) {
+	return f.taskRuns[namespace+"/"+pipelineRun], nil
+}
+
+func (f fakePipelineClient) GetLogs(namespace, pod, container string) ([]byte, error) {
+	return []byte(fmt.Sprintf("%s/%s/%s", namespace, pod, container)), nil
+}
+
+func TestPipelineJobs(t *testing.T) {
+	started := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(started.Add(time.Minute))
+	buildTaskRun := pipelinev1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "job-build",
+			Namespace: "test-pods",
+			Labels:    map[string]string{pipeline.PipelineTaskLabelKey: "build"},
+		},
+		Status: pipelinev1.TaskRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"}}},
+			TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				PodName:        "job-build-pod",
+				StartTime:      &started,
+				CompletionTime: &later,
+				Steps: []pipelinev1.StepState{{
+					Name:           "compile",
+					Container:      "step-compile",
+					ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+				}},
+			},
+		},
+	}
+	testTaskRun := pipelinev1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "job-test",
+			Namespace: "test-pods",
+			Labels:    map[string]string{pipeline.PipelineTaskLabelKey: "test"},
+		},
+		Status: pipelinev1.TaskRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown, Reason: "Running"}}},
+			TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				PodName:   "job-test-pod",
+				StartTime: &later,
+				Steps: []pipelinev1.StepState{
+					{Name: "unit", Container: "step-unit", ContainerState: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+					{Name: "e2e", ContainerState: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+				},
+			},
+		},
+	}
+	kc := fkc{
+		prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "job-pj"},
+			Spec: prowapi.ProwJobSpec{
+				Agent:     prowapi.TektonAgent,
+				Job:       "pipeline-job",
+				Namespace: "test-pods",
+			},
+			Status: prowapi.ProwJobStatus{BuildID: "123"},
+		},
+		prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Agent: prowapi.KubernetesAgent,
+				Job:   "pod-job",
+			},
+			Status: prowapi.ProwJobStatus{PodName: "wowowow", BuildID: "123"},
+		},
+	}
+	ja := &JobAgent{
+		kc:   kc,
+		pkcs: map[string]PodLogClient{kube.DefaultClusterAlias: fpkc("clusterA")},
+		pcs: map[string]PipelineClient{kube.DefaultClusterAlias: fakePipelineClient{taskRuns: map[string][]pipelinev1.TaskRun{
+			"test-pods/job-pj": {testTaskRun, buildTaskRun},
+		}}},
+	}
+	if err := ja.update(); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+
+	status, err := ja.GetPipelineStatus("pipeline-job", "123")
+	if err != nil {
+		t.Fatalf("Failed to get pipeline status: %v", err)
+	}
+	expected := &PipelineStatus{TaskRuns: []TaskRunStatus{
+		{
+			Name:         "job-build",
+			PipelineTask: "build",
+			PodName:      "job-build-pod",
+			State:        "Succeeded",
+			Started:      &started,
+			Finished:     &later,
+			Steps:        []StepStatus{{Name: "compile", Log: "build.compile", State: "terminated", Reason: "Completed", ExitCode: new(int32)}},
+		},
+		{
+			Name:         "job-test",
+			PipelineTask: "test",
+			PodName:      "job-test-pod",
+			State:        "Running",
+			Started:      &later,
+			Steps: []StepStatus{
+				{Name: "unit", Log: "test.unit", State: "running"},
+				{Name: "e2e", Log: "test.e2e", State: "waiting", Reason: "PodInitializing"},
+			},
+		},
+	}}
+	if diff := cmp.Diff(expected, status); diff != "" {
+		t.Errorf("unexpected pipeline status (-want +got):\n%s", diff)
+	}
+	if _, err := ja.GetPipelineStatus("pod-job", "123"); err == nil {
+		t.Error("expected an error getting the pipeline status of a pod job")
+	}
+
+	for _, tc := range []struct {
+		container string
+		expected  string
+		err       bool
+	}{
+		{container: "build.compile", expected: "test-pods/job-build-pod/step-compile"},
+		{container: "test.e2e", expected: "test-pods/job-test-pod/step-e2e"},
+		{container: "test.lint", err: true},
+		{container: kube.TestContainerName, err: true},
+	} {
+		log, err := ja.GetJobLog("pipeline-job", "123", tc.container)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error getting the log of %q", tc.container)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to get the log of %q: %v", tc.container, err)
+		} else if string(log) != tc.expected {
+			t.Errorf("expected the log of %q to be %q, got %q", tc.container, tc.expected, string(log))
+		}
+	}
+}
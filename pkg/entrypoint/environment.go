@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// RedactedValue replaces the values of sensitive environment
+// variables in the environment file.
+const RedactedValue = "<redacted>"
+
+// sensitiveEnvName matches the names of environment variables that are
+// redacted even when they are not known to be populated from a secret, for
+// instance because they are baked into the image.
+var sensitiveEnvName = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|PRIVATE_?KEY|API_?KEY)`)
+
+// RunEnvironment is the content of the environment file: what
+// the entrypoint resolved and executed for the test process.
+type RunEnvironment struct {
+	// ContainerName is the name of the container of the test process.
+	ContainerName string `json:"container_name,omitempty"`
+	// Args is the command of the test process and its arguments.
+	Args []string `json:"args"`
+	// WorkingDir is the directory the test process is started in.
+	WorkingDir string `json:"working_dir,omitempty"`
+	// Env is the environment of the test process, with
+	// the values of sensitive variables redacted.
+	Env map[string]string `json:"env,omitempty"`
+	// Timeout and GracePeriod are the effective timeouts
+	// the entrypoint enforces on the test process.
+	Timeout     string `json:"timeout,omitempty"`
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+// resolveEnvironment returns the environment the test process is
+// started with, based on the entrypoint's own environment.
+func (o Options) resolveEnvironment(environ []string) RunEnvironment {
+	secretEnv := sets.New[string](o.SecretEnv...)
+	declaredEnv := sets.New[string](o.DeclaredEnv...)
+	fromSecret := func(name string) bool {
+		if declaredEnv.Has(name) {
+			return false
+		}
+		for _, prefix := range o.SecretEnvFromPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	env := map[string]string{}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		switch {
+		case name == JSONConfigEnvVar:
+			// The options are shown in a structured form already.
+			continue
+		case secretEnv.Has(name), fromSecret(name), sensitiveEnvName.MatchString(name):
+			value = RedactedValue
+		}
+		env[name] = value
+	}
+	workingDir, _ := os.Getwd()
+	return RunEnvironment{
+		ContainerName: o.ContainerName,
+		Args:          o.Args,
+		WorkingDir:    workingDir,
+		Env:           env,
+		Timeout:       optionOrDefault(o.Timeout, DefaultTimeout).String(),
+		GracePeriod:   optionOrDefault(o.GracePeriod, DefaultGracePeriod).String(),
+	}
+}
+
+// writeEnvironment writes the environment file if one is configured.
+func (o Options) writeEnvironment() error {
+	if o.EnvironmentFile == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(o.resolveEnvironment(os.Environ()), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal run environment: %w", err)
+	}
+	if err := os.WriteFile(o.EnvironmentFile, raw, 0644); err != nil {
+		return fmt.Errorf("could not write environment file(%s): %w", o.EnvironmentFile, err)
+	}
+	return nil
+}
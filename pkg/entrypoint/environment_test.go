@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	o := Options{
+		Timeout:   time.Hour,
+		SecretEnv: []string{"DB_URL"},
+		Options: &wrapper.Options{
+			Args:          []string{"make", "test"},
+			ContainerName: "test",
+		},
+	}
+	environ := []string{
+		"PATH=/usr/bin:/bin",
+		"GOFLAGS=-mod=vendor",
+		"DB_URL=postgres://user:pass@db",
+		"GITHUB_TOKEN=abc",
+		"AWS_SECRET_ACCESS_KEY=def",
+		"EMPTY=",
+		"EQUALS=a=b",
+		JSONConfigEnvVar + `={"args":["make","test"]}`,
+	}
+	expected := map[string]string{
+		"PATH":                  "/usr/bin:/bin",
+		"GOFLAGS":               "-mod=vendor",
+		"DB_URL":                RedactedValue,
+		"GITHUB_TOKEN":          RedactedValue,
+		"AWS_SECRET_ACCESS_KEY": RedactedValue,
+		"EMPTY":                 "",
+		"EQUALS":                "a=b",
+	}
+
+	env := o.resolveEnvironment(environ)
+	if diff := cmp.Diff(expected, env.Env); diff != "" {
+		t.Errorf("unexpected environment (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"make", "test"}, env.Args); diff != "" {
+		t.Errorf("unexpected args (-want +got):\n%s", diff)
+	}
+	if env.Timeout != "1h0m0s" || env.GracePeriod != DefaultGracePeriod.String() {
+		t.Errorf("expected timeout 1h0m0s and default grace period, got %s and %s", env.Timeout, env.GracePeriod)
+	}
+}
+
+func TestResolveEnvironmentFromSecrets(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin:/bin",
+		"CREDS_USER=admin",
+		"CREDS_URL=declared",
+		"DB_HOST=db",
+		"LOG_LEVEL=debug",
+	}
+	testCases := []struct {
+		name     string
+		prefixes []string
+		expected map[string]string
+	}{
+		{
+			name: "no secret from envFrom",
+			expected: map[string]string{
+				"PATH":       "/usr/bin:/bin",
+				"CREDS_USER": "admin",
+				"CREDS_URL":  "declared",
+				"DB_HOST":    "db",
+				"LOG_LEVEL":  "debug",
+			},
+		},
+		{
+			name:     "secret from envFrom with a prefix",
+			prefixes: []string{"CREDS_"},
+			expected: map[string]string{
+				"PATH":       "/usr/bin:/bin",
+				"CREDS_USER": RedactedValue,
+				"CREDS_URL":  "declared",
+				"DB_HOST":    "db",
+				"LOG_LEVEL":  "debug",
+			},
+		},
+		{
+			name:     "secret from envFrom without a prefix redacts all the variables that aren't declared",
+			prefixes: []string{""},
+			expected: map[string]string{
+				"PATH":       RedactedValue,
+				"CREDS_USER": RedactedValue,
+				"CREDS_URL":  "declared",
+				"DB_HOST":    RedactedValue,
+				"LOG_LEVEL":  "debug",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := Options{
+				SecretEnvFromPrefixes: tc.prefixes,
+				DeclaredEnv:           []string{"CREDS_URL", "LOG_LEVEL"},
+				Options:               &wrapper.Options{Args: []string{"true"}},
+			}
+			if diff := cmp.Diff(tc.expected, o.resolveEnvironment(environ).Env); diff != "" {
+				t.Errorf("unexpected environment (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SOME_PASSWORD", "hunter2")
+	o := Options{
+		EnvironmentFile: filepath.Join(dir, "run-environment.json"),
+		Options:         &wrapper.Options{Args: []string{"true"}},
+	}
+	if err := o.writeEnvironment(); err != nil {
+		t.Fatalf("failed to write environment: %v", err)
+	}
+	raw, err := os.ReadFile(o.EnvironmentFile)
+	if err != nil {
+		t.Fatalf("failed to read environment file: %v", err)
+	}
+	var env RunEnvironment
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatalf("failed to unmarshal environment file: %v", err)
+	}
+	if env.Env["SOME_PASSWORD"] != RedactedValue {
+		t.Errorf("expected SOME_PASSWORD to be redacted, got %q", env.Env["SOME_PASSWORD"])
+	}
+}
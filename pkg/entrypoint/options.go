@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/secretutil"
 )
 
 const defaultCopyDst = "/tools/entrypoint"
@@ -51,6 +52,35 @@ type Options struct {
 	// May be ignored if not using sidecar.
 	ArtifactDir string `json:"artifact_dir,omitempty"`
 
+	// EnvironmentFile has no effect when empty (default).
+	// When set, entrypoint writes the command and environment
+	// of the test process to it before starting the process,
+	// so that a run can be compared to a local reproduction.
+	EnvironmentFile string `json:"environment_file,omitempty"`
+	// SecretEnv are the names of environment variables that
+	// are populated from secrets. Their values are redacted
+	// in the EnvironmentFile.
+	SecretEnv []string `json:"secret_env,omitempty"`
+	// SecretEnvFromPrefixes are the prefixes of the environment variables
+	// populated from secrets with envFrom, whose names aren't known before
+	// the test process starts. The values of the variables with one of these
+	// prefixes that aren't in DeclaredEnv are redacted in the
+	// EnvironmentFile, all of them for an empty prefix.
+	SecretEnvFromPrefixes []string `json:"secret_env_from_prefixes,omitempty"`
+	// DeclaredEnv are the names of the environment variables set in the env
+	// of the container, which take precedence over the ones of envFrom.
+	DeclaredEnv []string `json:"declared_env,omitempty"`
+	// SecretReferences are the names of environment variables
+	// whose values are references to secrets in Cloud KMS or
+	// Vault, which are resolved before starting the test process.
+	SecretReferences []string `json:"secret_references,omitempty"`
+	// Vault configures the access to Vault for secret references.
+	Vault *secretutil.VaultOptions `json:"vault,omitempty"`
+	// QuarantinedTests are tests whose failures don't fail the test
+	// process. If the test process fails and every failed test in the junit
+	// files of the ArtifactDir is quarantined, entrypoint exits 0 instead.
+	QuarantinedTests []QuarantinedTest `json:"quarantined_tests,omitempty"`
+
 	// PreviousMarker has no effect when empty (default).
 	// When set it causes entrypoint to:
 	// a) wait until previous_marker exists
@@ -66,6 +96,11 @@ type Options struct {
 	// Primarily useful in case you want to exit with a specific error code.
 	PropagateErrorCode bool `json:"propagate_error_code,omitempty"`
 
+	// HeartbeatInterval is how often the heartbeat file of the
+	// wrapper options is written. Defaults to DefaultHeartbeatInterval
+	// if the heartbeat file is set.
+	HeartbeatInterval time.Duration `json:"heartbeat_interval,omitempty"`
+
 	CopyModeOnly bool   `json:"copy_mode_only,omitempty"`
 	CopyDst      string `json:"copy_dst,omitempty"`
 
@@ -81,6 +116,9 @@ func (o *Options) Validate() error {
 	if o.PropagateErrorCode && o.AlwaysZero {
 		return errors.New("cannot propagate error code and always exit zero")
 	}
+	if o.HeartbeatInterval < 0 {
+		return errors.New("heartbeat interval cannot be negative")
+	}
 
 	return o.Options.Validate()
 }
@@ -111,6 +149,8 @@ func (o *Options) AddFlags(flags *flag.FlagSet) {
 	flags.BoolVar(&o.CopyModeOnly, "copy-mode-only", false, "If true, copy current binary to /tools/entrypoint, dst can be overridden by --copy-destination")
 	flags.StringVar(&o.CopyDst, "copy-destination", defaultCopyDst, "Must be used with --copy-mode-only, default is /tools/entrypoint")
 	flags.BoolVar(&o.PropagateErrorCode, "propagate-error-code", false, "If true, propagate the error code from the child process")
+	flags.StringVar(&o.HeartbeatFile, "heartbeat-file", "", "file the time of the last output of the process is written to periodically")
+	flags.DurationVar(&o.HeartbeatInterval, "heartbeat-interval", DefaultHeartbeatInterval, "How often to write the heartbeat file.")
 	o.Options.AddFlags(flags)
 }
 
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// QuarantinedFailuresKey is the key of the metadata file under which the
+// failed tests that were quarantined are recorded.
+const QuarantinedFailuresKey = "quarantined-failures"
+
+// QuarantinedTest is a test whose failures don't fail the test process
+// until the quarantine expires.
+type QuarantinedTest struct {
+	// Pattern is a regular expression matching the whole name of the test,
+	// either its name or its class name and name joined by a dot.
+	Pattern string `json:"pattern"`
+	// Expires is when failures of the test are blocking again.
+	Expires time.Time `json:"expires"`
+}
+
+// junitFile matches the junit files in the artifact directory.
+var junitFile = regexp.MustCompile(`^junit.*\.xml$`)
+
+// quarantine returns 0 instead of the failing code of the test process if
+// every failed test in the junit files of the artifacts is quarantined. The
+// quarantined failures are recorded in the metadata file either way.
+func (o Options) quarantine(code int) int {
+	failed, quarantined, err := quarantinedFailures(o.ArtifactDir, o.QuarantinedTests, time.Now())
+	if err != nil {
+		logrus.WithError(err).Warn("Could not find the failed tests, failures are not quarantined")
+		return code
+	}
+	if len(quarantined) == 0 {
+		return code
+	}
+	if err := o.recordQuarantinedFailures(quarantined); err != nil {
+		logrus.WithError(err).Warn("Could not record the quarantined failures")
+	}
+	if len(quarantined) != len(failed) {
+		logrus.WithField("quarantined", quarantined).Infof("%d of %d failed tests are quarantined, the failure is blocking", len(quarantined), len(failed))
+		return code
+	}
+	logrus.WithField("quarantined", quarantined).Infof("All %d failed tests are quarantined, exiting 0 instead of %d", len(failed), code)
+	return 0
+}
+
+// quarantinedFailures returns the names of the failed tests in the junit files
+// under the directory and those of them that are quarantined at the time.
+func quarantinedFailures(dir string, tests []QuarantinedTest, now time.Time) (failed, quarantined []string, err error) {
+	var patterns []*regexp.Regexp
+	for _, test := range tests {
+		if !now.Before(test.Expires) {
+			continue
+		}
+		pattern, err := regexp.Compile("^(?:" + test.Pattern + ")$")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern %q: %w", test.Pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	failedNames := sets.New[string]()
+	quarantinedNames := sets.New[string]()
+	visit := func(result junit.Result) {
+		if result.Failure == nil && result.Errored == nil {
+			return
+		}
+		name := result.Name
+		if result.ClassName != "" {
+			name = result.ClassName + "." + result.Name
+		}
+		failedNames.Insert(name)
+		for _, pattern := range patterns {
+			if pattern.MatchString(result.Name) || pattern.MatchString(name) {
+				quarantinedNames.Insert(name)
+				return
+			}
+		}
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !junitFile.MatchString(d.Name()) {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		suites, err := junit.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %w", path, err)
+		}
+		for _, suite := range suites.Suites {
+			walkSuite(suite, visit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return sets.List(failedNames), sets.List(quarantinedNames), nil
+}
+
+func walkSuite(suite junit.Suite, visit func(junit.Result)) {
+	for _, result := range suite.Results {
+		visit(result)
+	}
+	for _, child := range suite.Suites {
+		walkSuite(child, visit)
+	}
+}
+
+// recordQuarantinedFailures adds the quarantined failures to the metadata
+// file, keeping what the test process wrote to it.
+func (o Options) recordQuarantinedFailures(quarantined []string) error {
+	if o.MetadataFile == "" {
+		return nil
+	}
+	metadata := map[string]interface{}{}
+	raw, err := os.ReadFile(o.MetadataFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if len(strings.TrimSpace(string(raw))) > 0 {
+			if err := json.Unmarshal(raw, &metadata); err != nil {
+				return fmt.Errorf("could not parse metadata file(%s): %w", o.MetadataFile, err)
+			}
+		}
+	}
+	sort.Strings(quarantined)
+	metadata[QuarantinedFailuresKey] = quarantined
+	raw, err = json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(o.MetadataFile, raw, 0644)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+const quarantineJUnit = `<testsuites>
+  <testsuite name="pkg">
+    <testcase name="TestPasses" classname="pkg"></testcase>
+    <testcase name="TestFlaky" classname="pkg"><failure message="flaked"></failure></testcase>
+    <testsuite name="nested">
+      <testcase name="TestAlsoFlaky/case"><failure message="flaked"></failure></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func TestQuarantine(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name             string
+		junit            map[string]string
+		metadata         string
+		tests            []QuarantinedTest
+		expectedCode     int
+		expectedMetadata map[string]interface{}
+	}{
+		{
+			name:  "all failures are quarantined",
+			junit: map[string]string{"junit_01.xml": quarantineJUnit},
+			tests: []QuarantinedTest{
+				{Pattern: `pkg\.TestFlaky`, Expires: now.Add(time.Hour)},
+				{Pattern: `TestAlsoFlaky/.*`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 0,
+			expectedMetadata: map[string]interface{}{
+				QuarantinedFailuresKey: []interface{}{"TestAlsoFlaky/case", "pkg.TestFlaky"},
+			},
+		},
+		{
+			name:     "quarantined failures are added to the metadata of the test",
+			junit:    map[string]string{"junit_01.xml": quarantineJUnit},
+			metadata: `{"revision": "abc"}`,
+			tests: []QuarantinedTest{
+				{Pattern: `TestFlaky|TestAlsoFlaky/case`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 0,
+			expectedMetadata: map[string]interface{}{
+				"revision":             "abc",
+				QuarantinedFailuresKey: []interface{}{"TestAlsoFlaky/case", "pkg.TestFlaky"},
+			},
+		},
+		{
+			name:  "some failures are not quarantined",
+			junit: map[string]string{"junit_01.xml": quarantineJUnit},
+			tests: []QuarantinedTest{
+				{Pattern: `pkg\.TestFlaky`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 2,
+			expectedMetadata: map[string]interface{}{
+				QuarantinedFailuresKey: []interface{}{"pkg.TestFlaky"},
+			},
+		},
+		{
+			name:  "expired quarantine is blocking",
+			junit: map[string]string{"junit_01.xml": quarantineJUnit},
+			tests: []QuarantinedTest{
+				{Pattern: `.*Flaky.*`, Expires: now.Add(-time.Hour)},
+			},
+			expectedCode: 2,
+		},
+		{
+			name:  "patterns match the whole name",
+			junit: map[string]string{"junit_01.xml": quarantineJUnit},
+			tests: []QuarantinedTest{
+				{Pattern: `Flaky`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 2,
+		},
+		{
+			name:  "failure without junit results is blocking",
+			junit: map[string]string{"build-log.txt": "failed"},
+			tests: []QuarantinedTest{
+				{Pattern: `.*`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 2,
+		},
+		{
+			name:  "unparsable junit is blocking",
+			junit: map[string]string{"junit_01.xml": quarantineJUnit, "junit_02.xml": "<testsuites"},
+			tests: []QuarantinedTest{
+				{Pattern: `.*`, Expires: now.Add(time.Hour)},
+			},
+			expectedCode: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			artifactDir := filepath.Join(dir, "artifacts")
+			if err := os.MkdirAll(artifactDir, 0755); err != nil {
+				t.Fatalf("failed to create artifact dir: %v", err)
+			}
+			for name, content := range tc.junit {
+				if err := os.WriteFile(filepath.Join(artifactDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			metadataFile := filepath.Join(dir, "metadata.json")
+			if tc.metadata != "" {
+				if err := os.WriteFile(metadataFile, []byte(tc.metadata), 0644); err != nil {
+					t.Fatalf("failed to write metadata: %v", err)
+				}
+			}
+			o := Options{
+				ArtifactDir:      artifactDir,
+				QuarantinedTests: tc.tests,
+				Options:          &wrapper.Options{MetadataFile: metadataFile},
+			}
+
+			if code := o.quarantine(2); code != tc.expectedCode {
+				t.Errorf("expected code %d, got %d", tc.expectedCode, code)
+			}
+			var metadata map[string]interface{}
+			if raw, err := os.ReadFile(metadataFile); err == nil {
+				if err := json.Unmarshal(raw, &metadata); err != nil {
+					t.Fatalf("failed to parse metadata: %v", err)
+				}
+			}
+			if tc.expectedMetadata == nil && tc.metadata == "" && metadata != nil {
+				t.Errorf("expected no metadata, got %v", metadata)
+			}
+			if tc.expectedMetadata != nil {
+				if diff := cmp.Diff(tc.expectedMetadata, metadata); diff != "" {
+					t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestFailedOnItsOwn(t *testing.T) {
+	testCases := []struct {
+		name     string
+		code     int
+		err      error
+		expected bool
+	}{
+		{name: "test failure", code: 1, err: errors.New("wrapped process failed: exit status 1"), expected: true},
+		{name: "timed out", code: InternalErrorCode, err: errTimedOut},
+		{name: "aborted with propagated code", code: 1, err: errAborted},
+		{name: "not started", code: InternalErrorCode, err: errors.New("could not start the process")},
+		{name: "previous step failed", code: PreviousErrorCode},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := failedOnItsOwn(tc.code, tc.err); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/secretutil"
 )
 
 const (
@@ -60,6 +62,10 @@ const (
 	// DefaultGracePeriod is the default timeout for the test
 	// process after SIGINT is sent before SIGKILL is sent
 	DefaultGracePeriod = 15 * time.Second
+
+	// DefaultHeartbeatInterval is the default interval between
+	// heartbeats, if the heartbeat file is set
+	DefaultHeartbeatInterval = time.Minute
 )
 
 var (
@@ -83,6 +89,9 @@ func (o Options) internalRun(interrupt chan os.Signal) int {
 	if err != nil {
 		logrus.WithError(err).Error("Error executing test process")
 	}
+	if code != 0 && len(o.QuarantinedTests) > 0 && failedOnItsOwn(code, err) {
+		code = o.quarantine(code)
+	}
 	if err := o.Mark(code); err != nil {
 		logrus.WithError(err).Error("Error writing exit code to marker file")
 		return InternalErrorCode // we need to mark the real error code to safely return AlwaysZero
@@ -93,6 +102,15 @@ func (o Options) internalRun(interrupt chan os.Signal) int {
 	return code
 }
 
+// failedOnItsOwn returns true if the test process exited with the code,
+// rather than being stopped or not started by entrypoint.
+func failedOnItsOwn(code int, err error) bool {
+	if errors.Is(err, errTimedOut) || errors.Is(err, errAborted) {
+		return false
+	}
+	return code != InternalErrorCode && code != PreviousErrorCode
+}
+
 // ExecuteProcess creates the artifact directory then executes the process as
 // configured, writing the output to the process log.
 func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
@@ -107,7 +125,8 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 	}
 	defer processLogFile.Close()
 
-	output := io.MultiWriter(os.Stdout, processLogFile)
+	tracker := newOutputTracker(io.MultiWriter(os.Stdout, processLogFile))
+	var output io.Writer = tracker
 	logrus.SetOutput(output)
 	defer logrus.SetOutput(os.Stdout)
 
@@ -118,10 +137,12 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 
 	if o.PreviousMarker != "" {
 		ctx, cancel := context.WithCancel(context.Background())
+		var interrupted atomic.Bool
 		go func() {
 			select {
 			case s := <-interrupt:
 				logrus.Errorf("Received interrupt %s, cancelling...", s)
+				interrupted.Store(true)
 				cancel()
 			case <-ctx.Done():
 			}
@@ -129,6 +150,11 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 		prevMarkerResult := wrapper.WaitForMarkers(ctx, o.PreviousMarker)[o.PreviousMarker]
 		code, err := prevMarkerResult.ReturnCode, prevMarkerResult.Err
 		cancel() // end previous go-routine when not interrupted
+		if err != nil && interrupted.Load() {
+			// The step is aborted along with the rest of the pod, so sidecar
+			// reports the job as such rather than as an internal error.
+			return AbortedErrorCode, errAborted
+		}
 		if err != nil {
 			return InternalErrorCode, fmt.Errorf("wait for previous marker %s: %w", o.PreviousMarker, err)
 		}
@@ -138,12 +164,30 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 		}
 	}
 
+	if err := o.writeEnvironment(); err != nil {
+		logrus.WithError(err).Warn("Could not write the environment of the test process")
+	}
+
 	executable := o.Args[0]
 	var arguments []string
 	if len(o.Args) > 1 {
 		arguments = o.Args[1:]
 	}
+	env, secrets, err := o.resolveSecrets(context.Background(), os.Environ())
+	if err != nil {
+		errs := []error{err}
+		if _, err := processLogFile.Write([]byte(err.Error())); err != nil {
+			errs = append(errs, err)
+		}
+		return InternalErrorCode, utilerrors.NewAggregate(errs)
+	}
+	if len(secrets) > 0 {
+		censorer := secretutil.NewCensorer()
+		censorer.Refresh(secrets...)
+		output = censoringWriter{Writer: output, censorer: censorer}
+	}
 	command := exec.Command(executable, arguments...)
+	command.Env = env
 	command.Stderr = output
 	command.Stdout = output
 	if err := command.Start(); err != nil {
@@ -162,6 +206,10 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 	go func() {
 		done <- command.Wait()
 	}()
+	if o.HeartbeatFile != "" {
+		stopHeartbeats := o.startHeartbeats(tracker)
+		defer stopHeartbeats()
+	}
 	select {
 	case err := <-done:
 		commandErr = err
@@ -209,6 +257,61 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 	return returnCode, commandErr
 }
 
+// outputTracker records when the test process last wrote output.
+type outputTracker struct {
+	io.Writer
+	last atomic.Int64
+}
+
+func newOutputTracker(w io.Writer) *outputTracker {
+	t := &outputTracker{Writer: w}
+	t.last.Store(time.Now().UnixNano())
+	return t
+}
+
+func (t *outputTracker) Write(p []byte) (int, error) {
+	t.last.Store(time.Now().UnixNano())
+	return t.Writer.Write(p)
+}
+
+func (t *outputTracker) lastOutput() time.Time {
+	return time.Unix(0, t.last.Load())
+}
+
+// startHeartbeats writes a heartbeat right away and then periodically until
+// the returned function is called, which writes the final heartbeat.
+func (o Options) startHeartbeats(tracker *outputTracker) func() {
+	write := func(finished bool) {
+		heartbeat := wrapper.Heartbeat{Timestamp: time.Now(), LastOutput: tracker.lastOutput(), Finished: finished}
+		if err := wrapper.WriteHeartbeat(o.HeartbeatFile, heartbeat); err != nil {
+			// The heartbeat is written to the log of the process, so it
+			// can't be logged to the tracked output without resetting it.
+			fmt.Fprintf(os.Stderr, "Could not write heartbeat: %v\n", err)
+		}
+	}
+	write(false)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(optionOrDefault(o.HeartbeatInterval, DefaultHeartbeatInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				write(false)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+		write(true)
+	}
+}
+
 func (o *Options) Mark(exitCode int) error {
 	content := []byte(strconv.Itoa(exitCode))
 
@@ -18,6 +18,7 @@ package entrypoint
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/secretutil"
 )
 
 func TestOptions_Run(t *testing.T) {
@@ -39,6 +41,7 @@ func TestOptions_Run(t *testing.T) {
 		propagate      bool
 		invalidMarker  bool
 		previousMarker string
+		awaitPrevious  bool
 		timeout        time.Duration
 		gracePeriod    time.Duration
 		expectedLog    string
@@ -172,6 +175,15 @@ wait`},
 			expectedMarker: "130",
 			expectedCode:   130,
 		},
+		{
+			name:           "interrupt while waiting for the previous marker",
+			awaitPrevious:  true,
+			interrupt:      true,
+			args:           []string{"sh", "-c", "exit 0"},
+			expectedLog:    "level=error msg=\"Received interrupt terminated, cancelling...\"\n",
+			expectedMarker: "130",
+			expectedCode:   AbortedErrorCode,
+		},
 		{
 			name:           "run failing command as normal if previous marker passed",
 			previousMarker: "0",
@@ -217,6 +229,10 @@ wait`},
 				}
 			}
 
+			if testCase.awaitPrevious {
+				options.PreviousMarker = path.Join(tmpDir, "previous-marker.txt")
+			}
+
 			if testCase.invalidMarker {
 				options.MarkerFile = "/this/had/better/not/be/a/real/file!@!#$%#$^#%&*&&*()*"
 			}
@@ -225,9 +241,12 @@ wait`},
 				go func() {
 					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 					defer cancel()
-					// sync with ExecuteProcess func to ensure that process has already started
-					if err := waitForFileToBeWritten(ctx, options.ProcessLog); err != nil {
-						t.Errorf("failed to wait for file: %v", err)
+					// sync with ExecuteProcess func to ensure that process has already started,
+					// unless it waits for the previous marker and won't write anything
+					if !testCase.awaitPrevious {
+						if err := waitForFileToBeWritten(ctx, options.ProcessLog); err != nil {
+							t.Errorf("failed to wait for file: %v", err)
+						}
 					}
 					time.Sleep(200 * time.Millisecond)
 					interrupt <- syscall.SIGTERM
@@ -246,6 +265,121 @@ wait`},
 	}
 }
 
+func TestHeartbeats(t *testing.T) {
+	tmpDir := t.TempDir()
+	options := Options{
+		HeartbeatInterval: 50 * time.Millisecond,
+		Options: &wrapper.Options{
+			// The process writes output once and then goes quiet.
+			Args:          []string{"sh", "-c", "echo started; sleep 1"},
+			ProcessLog:    path.Join(tmpDir, "process-log.txt"),
+			MarkerFile:    path.Join(tmpDir, "marker-file.txt"),
+			HeartbeatFile: path.Join(tmpDir, "heartbeat.json"),
+		},
+	}
+
+	var beats []wrapper.Heartbeat
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			time.Sleep(100 * time.Millisecond)
+			raw, err := os.ReadFile(options.HeartbeatFile)
+			if err != nil {
+				continue
+			}
+			var heartbeat wrapper.Heartbeat
+			if err := json.Unmarshal(raw, &heartbeat); err != nil {
+				t.Errorf("invalid heartbeat %q: %v", raw, err)
+				return
+			}
+			beats = append(beats, heartbeat)
+			if heartbeat.Finished {
+				return
+			}
+		}
+	}()
+	if code := options.internalRun(make(chan os.Signal, 1)); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	<-done
+
+	if len(beats) < 3 {
+		t.Fatalf("expected heartbeats while the process ran, got %v", beats)
+	}
+	quiet := beats[len(beats)-2]
+	if quiet.Stalled() < 500*time.Millisecond {
+		t.Errorf("expected the heartbeat to show the process stalled, got %s", quiet.Stalled())
+	}
+	if last := beats[len(beats)-1]; !last.Finished || last.Stalled() != 0 {
+		t.Errorf("expected a finished heartbeat last, got %+v", last)
+	}
+}
+
+type fakeSecretResolver map[string]string
+
+func (f fakeSecretResolver) Resolve(_ context.Context, value string) (string, error) {
+	secret, ok := f[value]
+	if !ok {
+		return "", fmt.Errorf("%s does not exist", value)
+	}
+	return secret, nil
+}
+
+func TestSecretReferences(t *testing.T) {
+	original := newSecretResolver
+	defer func() { newSecretResolver = original }()
+	newSecretResolver = func(*secretutil.VaultOptions) secretResolver {
+		return fakeSecretResolver{"vault://secret/data/ci#token": "hunter2"}
+	}
+
+	testCases := []struct {
+		name           string
+		value          string
+		references     []string
+		expectedLog    string
+		expectedMarker string
+	}{
+		{
+			name:           "reference is resolved and censored",
+			value:          "vault://secret/data/ci#token",
+			references:     []string{"TOKEN"},
+			expectedLog:    "resolved XXXXXXX\n",
+			expectedMarker: "0",
+		},
+		{
+			name:           "variables that aren't references are left alone",
+			value:          "vault://secret/data/ci#token",
+			expectedLog:    "not resolved vault://secret/data/ci#token\n",
+			expectedMarker: "1",
+		},
+		{
+			name:           "unresolvable reference fails the job",
+			value:          "vault://secret/data/ci#password",
+			references:     []string{"TOKEN"},
+			expectedLog:    "could not resolve the secret reference of $TOKEN: vault://secret/data/ci#password does not exist",
+			expectedMarker: strconv.Itoa(InternalErrorCode),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TOKEN", tc.value)
+			tmpDir := t.TempDir()
+			options := Options{
+				SecretReferences: tc.references,
+				Options: &wrapper.Options{
+					Args:       []string{"sh", "-c", `if [ "$TOKEN" = hunter2 ]; then echo resolved $TOKEN; else echo not resolved $TOKEN; exit 1; fi`},
+					ProcessLog: path.Join(tmpDir, "process-log.txt"),
+					MarkerFile: path.Join(tmpDir, "marker-file.txt"),
+				},
+			}
+			options.internalRun(make(chan os.Signal, 1))
+			compareFileContents(tc.name, options.ProcessLog, tc.expectedLog, t)
+			compareFileContents(tc.name, options.MarkerFile, tc.expectedMarker, t)
+		})
+	}
+}
+
 func compareFileContents(name, file, expected string, t *testing.T) {
 	data, err := os.ReadFile(file)
 	if err != nil {
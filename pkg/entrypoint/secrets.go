@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/secretutil"
+)
+
+type secretResolver interface {
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// newSecretResolver is overridden in tests.
+var newSecretResolver = func(vault *secretutil.VaultOptions) secretResolver {
+	return secretutil.NewResolver(vault)
+}
+
+// resolveSecrets returns the environment of the test process, in which the
+// values of the secret reference variables are replaced with the secrets
+// they reference, and the resolved secrets. The environment is nil if there
+// are no references, so the process inherits the environment of entrypoint.
+func (o Options) resolveSecrets(ctx context.Context, environ []string) ([]string, []string, error) {
+	if len(o.SecretReferences) == 0 {
+		return nil, nil, nil
+	}
+	references := sets.New[string](o.SecretReferences...)
+	resolver := newSecretResolver(o.Vault)
+	env := make([]string, 0, len(environ))
+	var secrets []string
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if references.Has(name) && secretutil.IsReference(value) {
+			secret, err := resolver.Resolve(ctx, value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not resolve the secret reference of $%s: %w", name, err)
+			}
+			secrets = append(secrets, secret)
+			kv = name + "=" + secret
+		}
+		env = append(env, kv)
+	}
+	return env, secrets, nil
+}
+
+// censoringWriter censors the resolved secrets in the output of the test
+// process before it reaches the process log. Secrets that are split across
+// writes aren't censored.
+type censoringWriter struct {
+	io.Writer
+	censorer secretutil.Censorer
+}
+
+func (w censoringWriter) Write(p []byte) (int, error) {
+	censored := make([]byte, len(p))
+	copy(censored, p)
+	w.censorer.Censor(&censored)
+	return w.Writer.Write(censored)
+}
@@ -17,11 +17,16 @@ limitations under the License.
 package flagutil
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/prow/pkg/bugzilla"
 	"sigs.k8s.io/prow/pkg/config/secret"
@@ -33,6 +38,19 @@ type BugzillaOptions struct {
 	githubExternalTrackerId uint
 	ApiKeyPath              string
 	authMethod              string
+
+	// Instances are additional Bugzilla servers, which orgs and repos can
+	// select in the configuration of the bugzilla plugin.
+	Instances       Strings
+	parsedInstances map[string]bugzillaInstance
+}
+
+// bugzillaInstance holds the options for one of several Bugzilla servers.
+type bugzillaInstance struct {
+	endpoint                string
+	githubExternalTrackerId uint
+	apiKeyPath              string
+	authMethod              string
 }
 
 // AddFlags injects Bugzilla options into the given FlagSet.
@@ -41,11 +59,15 @@ func (o *BugzillaOptions) AddFlags(fs *flag.FlagSet) {
 	fs.UintVar(&o.githubExternalTrackerId, "bugzilla-github-external-tracker-id", 0, "The ext_type_id for GitHub external bugs, optional.")
 	fs.StringVar(&o.ApiKeyPath, "bugzilla-api-key-path", "", "Path to the file containing the Bugzilla API key.")
 	fs.StringVar(&o.authMethod, "bugzilla-auth-method", "", "Which authorization method will be used. Values can be bearer, query or x-bugzilla-api-key.")
+	fs.Var(&o.Instances, "bugzilla-instance", "An additional Bugzilla instance in name=<name>,endpoint=<endpoint>[,api-key-path=<path>][,auth-method=<method>][,github-external-tracker-id=<id>] format. Can be passed multiple times.")
 }
 
 // Validate validates Bugzilla options.
 func (o *BugzillaOptions) Validate(dryRun bool) error {
 	if o.endpoint == "" {
+		if len(o.Instances.vals) > 0 {
+			return errors.New("--bugzilla-instance was passed without a default --bugzilla-endpoint")
+		}
 		logrus.Info("empty -bugzilla-endpoint, will not create Bugzilla client")
 		return nil
 	}
@@ -58,31 +80,127 @@ func (o *BugzillaOptions) Validate(dryRun bool) error {
 		logrus.Info("empty -bugzilla-api-key-path, will use anonymous Bugzilla client")
 	}
 
-	if o.authMethod != "" {
-		if o.authMethod != "bearer" && o.authMethod != "query" && o.authMethod != "x-bugzilla-api-key" {
-			return fmt.Errorf("invalid --auth-method %s. Valid values are bearer,query or x-bugzilla-api-key", o.authMethod)
-		}
+	if err := validateBugzillaAuthMethod(o.authMethod); err != nil {
+		return err
 	}
 
+	return o.parseInstances()
+}
+
+func validateBugzillaAuthMethod(authMethod string) error {
+	if authMethod != "" {
+		if authMethod != "bearer" && authMethod != "query" && authMethod != "x-bugzilla-api-key" {
+			return fmt.Errorf("invalid --auth-method %s. Valid values are bearer,query or x-bugzilla-api-key", authMethod)
+		}
+	}
 	return nil
 }
 
+func (o *BugzillaOptions) parseInstances() error {
+	if len(o.Instances.vals) == 0 {
+		return nil
+	}
+
+	o.parsedInstances = make(map[string]bugzillaInstance, len(o.Instances.vals))
+	var errs []error
+	for _, value := range o.Instances.vals {
+		var name string
+		var instance bugzillaInstance
+		var err error
+		for _, field := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(field, "=")
+			if !ok {
+				err = fmt.Errorf("%q is not in key=value format", field)
+				break
+			}
+			switch key {
+			case "name":
+				name = val
+			case "endpoint":
+				instance.endpoint = val
+			case "api-key-path":
+				instance.apiKeyPath = val
+			case "auth-method":
+				instance.authMethod = val
+			case "github-external-tracker-id":
+				var id uint64
+				id, err = strconv.ParseUint(val, 10, 0)
+				instance.githubExternalTrackerId = uint(id)
+			default:
+				err = fmt.Errorf("unknown key %q", key)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil && name == "" {
+			err = errors.New("name is required")
+		}
+		if err == nil {
+			if _, parseErr := url.ParseRequestURI(instance.endpoint); parseErr != nil {
+				err = fmt.Errorf("invalid endpoint URI: %q", instance.endpoint)
+			}
+		}
+		if err == nil {
+			err = validateBugzillaAuthMethod(instance.authMethod)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid --bugzilla-instance=%s: %w", value, err))
+			continue
+		}
+		if _, alreadyExists := o.parsedInstances[name]; alreadyExists {
+			errs = append(errs, fmt.Errorf("got multiple --bugzilla-instance for the %s instance", name))
+			continue
+		}
+		o.parsedInstances[name] = instance
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// ApiKeyPaths returns the paths to the API keys of all Bugzilla instances.
+func (o *BugzillaOptions) ApiKeyPaths() []string {
+	var paths []string
+	if o.ApiKeyPath != "" {
+		paths = append(paths, o.ApiKeyPath)
+	}
+	for _, name := range sets.List(sets.KeySet(o.parsedInstances)) {
+		if path := o.parsedInstances[name].apiKeyPath; path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // BugzillaClient returns a Bugzilla client.
 func (o *BugzillaOptions) BugzillaClient() (bugzilla.Client, error) {
 	if o.endpoint == "" {
 		return nil, fmt.Errorf("empty -bugzilla-endpoint, cannot create Bugzilla client")
 	}
 
+	return newBugzillaClient(o.endpoint, o.ApiKeyPath, o.githubExternalTrackerId, o.authMethod), nil
+}
+
+// BugzillaClients returns a Bugzilla client for every additional instance,
+// by the name of the instance.
+func (o *BugzillaOptions) BugzillaClients() map[string]bugzilla.Client {
+	clients := make(map[string]bugzilla.Client, len(o.parsedInstances))
+	for name, instance := range o.parsedInstances {
+		clients[name] = newBugzillaClient(instance.endpoint, instance.apiKeyPath, instance.githubExternalTrackerId, instance.authMethod)
+	}
+	return clients
+}
+
+func newBugzillaClient(endpoint, apiKeyPath string, githubExternalTrackerId uint, authMethod string) bugzilla.Client {
 	var generator *func() []byte
-	if o.ApiKeyPath == "" {
+	if apiKeyPath == "" {
 		generatorFunc := func() []byte {
 			return []byte{}
 		}
 		generator = &generatorFunc
 	} else {
-		generatorFunc := secret.GetTokenGenerator(o.ApiKeyPath)
+		generatorFunc := secret.GetTokenGenerator(apiKeyPath)
 		generator = &generatorFunc
 	}
 
-	return bugzilla.NewClient(*generator, o.endpoint, o.githubExternalTrackerId, o.authMethod), nil
+	return bugzilla.NewClient(*generator, endpoint, githubExternalTrackerId, authMethod)
 }
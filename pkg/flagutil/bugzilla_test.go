@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBugzillaOptionsInstances(t *testing.T) {
+	testCases := []struct {
+		name              string
+		args              []string
+		expectedInstances map[string]bugzillaInstance
+		expectedPaths     []string
+		expectedErr       bool
+	}{
+		{
+			name:          "no instances",
+			args:          []string{"--bugzilla-endpoint=https://bugzilla.example.com", "--bugzilla-api-key-path=/etc/bugzilla/key"},
+			expectedPaths: []string{"/etc/bugzilla/key"},
+		},
+		{
+			name: "several instances",
+			args: []string{
+				"--bugzilla-endpoint=https://bugzilla.example.com",
+				"--bugzilla-instance=name=product,endpoint=https://bugzilla.product.example.com,api-key-path=/etc/product/key,auth-method=bearer,github-external-tracker-id=131",
+				"--bugzilla-instance=name=anonymous,endpoint=https://bugzilla.anonymous.example.com",
+			},
+			expectedInstances: map[string]bugzillaInstance{
+				"product": {
+					endpoint:                "https://bugzilla.product.example.com",
+					apiKeyPath:              "/etc/product/key",
+					authMethod:              "bearer",
+					githubExternalTrackerId: 131,
+				},
+				"anonymous": {
+					endpoint: "https://bugzilla.anonymous.example.com",
+				},
+			},
+			expectedPaths: []string{"/etc/product/key"},
+		},
+		{
+			name:        "instance without a default endpoint",
+			args:        []string{"--bugzilla-instance=name=product,endpoint=https://bugzilla.product.example.com"},
+			expectedErr: true,
+		},
+		{
+			name:        "instance without a name",
+			args:        []string{"--bugzilla-endpoint=https://bugzilla.example.com", "--bugzilla-instance=endpoint=https://bugzilla.product.example.com"},
+			expectedErr: true,
+		},
+		{
+			name:        "instance with an invalid endpoint",
+			args:        []string{"--bugzilla-endpoint=https://bugzilla.example.com", "--bugzilla-instance=name=product,endpoint=bugzilla"},
+			expectedErr: true,
+		},
+		{
+			name:        "instance with an invalid auth method",
+			args:        []string{"--bugzilla-endpoint=https://bugzilla.example.com", "--bugzilla-instance=name=product,endpoint=https://bugzilla.product.example.com,auth-method=basic"},
+			expectedErr: true,
+		},
+		{
+			name:        "instance with an unknown key",
+			args:        []string{"--bugzilla-endpoint=https://bugzilla.example.com", "--bugzilla-instance=name=product,endpoint=https://bugzilla.product.example.com,color=red"},
+			expectedErr: true,
+		},
+		{
+			name: "duplicate instances",
+			args: []string{
+				"--bugzilla-endpoint=https://bugzilla.example.com",
+				"--bugzilla-instance=name=product,endpoint=https://bugzilla.product.example.com",
+				"--bugzilla-instance=name=product,endpoint=https://bugzilla.other.example.com",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var o BugzillaOptions
+			fs := flag.NewFlagSet(tc.name, flag.ContinueOnError)
+			o.AddFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			err := o.Validate(false)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedInstances, o.parsedInstances, cmp.AllowUnexported(bugzillaInstance{})); diff != "" {
+				t.Errorf("unexpected instances (-want +got):\n%s", diff)
+			}
+			if paths := o.ApiKeyPaths(); !reflect.DeepEqual(tc.expectedPaths, paths) {
+				t.Errorf("expected API key paths %v, got %v", tc.expectedPaths, paths)
+			}
+			if clients := o.BugzillaClients(); len(clients) != len(tc.expectedInstances) {
+				t.Errorf("expected %d clients, got %d", len(tc.expectedInstances), len(clients))
+			}
+		})
+	}
+}
@@ -17,16 +17,26 @@ limitations under the License.
 package flagutil
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/jobbundle"
 	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/interrupts"
 )
 
 const (
 	defaultConfigPathFlagName = "config-path"
 	defaultJobConfigPathFlag  = "job-config-path"
+
+	defaultJobConfigRefreshInterval = 5 * time.Minute
 )
 
 type ConfigOptions struct {
@@ -46,6 +56,20 @@ type ConfigOptions struct {
 	// Moonraker is the centralized Inrepconfig Caching Service. Using this flag
 	// overrides the use of the local InRepoConfigCache.
 	MoonrakerAddress string
+	// WatchConfig makes the config agent watch the config files for changes
+	// instead of polling their modification time.
+	WatchConfig bool
+	// JobConfigSource is an https:// URL or an oci:// reference of a signed
+	// bundle of the job config, synced instead of reading JobConfigPath.
+	JobConfigSource string
+	// JobConfigPublicKey is the path of the ed25519 public key in PEM that
+	// the bundles of JobConfigSource are verified with.
+	JobConfigPublicKey string
+	// JobConfigRefreshInterval is how often JobConfigSource is synced.
+	JobConfigRefreshInterval time.Duration
+	// JobConfigMinCreated is the RFC3339 time before which bundles of
+	// JobConfigSource are rejected, to protect from replays across restarts.
+	JobConfigMinCreated string
 }
 
 func (o *ConfigOptions) AddFlags(fs *flag.FlagSet) {
@@ -63,20 +87,58 @@ func (o *ConfigOptions) AddFlags(fs *flag.FlagSet) {
 	fs.IntVar(&o.InRepoConfigCacheSize, "in-repo-config-cache-size", 200, "Cache size for ProwYAMLs read from in-repo configs.")
 	fs.StringVar(&o.InRepoConfigCacheDirBase, "cache-dir-base", "", "Directory where the repo cache should be mounted.")
 	fs.StringVar(&o.MoonrakerAddress, "moonraker-address", "", "full HTTP address (domain and port) of moonraker service")
+	fs.BoolVar(&o.WatchConfig, "watch-config", false, "Watch the config files with inotify instead of polling them, so that changes are picked up within seconds, including changes of job configs mounted from ConfigMaps in subdirectories of the job config path.")
+	fs.StringVar(&o.JobConfigSource, "job-config-source", "", "An https:// URL or an oci:// reference of a gzipped tarball of the job config, signed with the key of --job-config-public-key, to load the job config from instead of --"+o.JobConfigPathFlagName+". The bundle is synced periodically.")
+	fs.StringVar(&o.JobConfigPublicKey, "job-config-public-key", "", "Path to the ed25519 public key in PEM that the bundles of --job-config-source are verified with.")
+	fs.DurationVar(&o.JobConfigRefreshInterval, "job-config-refresh-interval", 0, fmt.Sprintf("How often the bundle of --job-config-source is synced. Defaults to %s.", defaultJobConfigRefreshInterval))
+	fs.StringVar(&o.JobConfigMinCreated, "job-config-min-created", "", "RFC3339 time, like 2024-05-01T00:00:00Z, before which bundles of --job-config-source are rejected, so that older signed bundles can't be replayed after a restart.")
 }
 
 func (o *ConfigOptions) Validate(_ bool) error {
 	if o.ConfigPath == "" {
 		return fmt.Errorf("--%s is mandatory", o.ConfigPathFlagName)
 	}
+	return o.validateJobConfigSource()
+}
+
+func (o *ConfigOptions) validateJobConfigSource() error {
+	if o.JobConfigSource == "" {
+		return nil
+	}
+	if o.JobConfigPath != "" {
+		return fmt.Errorf("--job-config-source and --%s are mutually exclusive", o.JobConfigPathFlagName)
+	}
+	if o.WatchConfig {
+		return errors.New("--job-config-source and --watch-config are mutually exclusive")
+	}
+	if o.JobConfigPublicKey == "" {
+		return errors.New("--job-config-source requires --job-config-public-key to be set")
+	}
+	if o.JobConfigRefreshInterval < 0 {
+		return errors.New("--job-config-refresh-interval can't be negative")
+	}
+	if _, err := o.jobConfigMinCreated(); err != nil {
+		return err
+	}
 	return nil
 }
 
+func (o *ConfigOptions) jobConfigMinCreated() (time.Time, error) {
+	if o.JobConfigMinCreated == "" {
+		return time.Time{}, nil
+	}
+	minCreated, err := time.Parse(time.RFC3339, o.JobConfigMinCreated)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--job-config-min-created must be an RFC3339 time: %w", err)
+	}
+	return minCreated, nil
+}
+
 func (o *ConfigOptions) ValidateConfigOptional() error {
-	if o.JobConfigPath != "" && o.ConfigPath == "" {
+	if (o.JobConfigPath != "" || o.JobConfigSource != "") && o.ConfigPath == "" {
 		return fmt.Errorf("if --%s is given, --%s must be given as well", o.JobConfigPathFlagName, o.ConfigPathFlagName)
 	}
-	return nil
+	return o.validateJobConfigSource()
 }
 
 func (o *ConfigOptions) ConfigAgent(reuse ...*config.Agent) (*config.Agent, error) {
@@ -92,5 +154,45 @@ func (o *ConfigOptions) ConfigAgent(reuse ...*config.Agent) (*config.Agent, erro
 }
 
 func (o *ConfigOptions) ConfigAgentWithAdditionals(ca *config.Agent, additionals []func(*config.Config) error) (*config.Agent, error) {
+	if o.JobConfigSource != "" && o.JobConfigPath == "" {
+		if err := o.syncJobConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if o.WatchConfig {
+		return ca, ca.StartWatch(o.ConfigPath, o.JobConfigPath, o.SupplementalProwConfigDirs.Strings(), o.SupplementalProwConfigsFileNameSuffix, additionals...)
+	}
 	return ca, ca.Start(o.ConfigPath, o.JobConfigPath, o.SupplementalProwConfigDirs.Strings(), o.SupplementalProwConfigsFileNameSuffix, additionals...)
 }
+
+// syncJobConfig syncs the bundle of JobConfigSource into a temporary
+// directory and keeps it in sync, pointing JobConfigPath to it, so that the
+// config agent picks up new bundles when it polls the job config.
+func (o *ConfigOptions) syncJobConfig() error {
+	dir, err := os.MkdirTemp("", "job-config")
+	if err != nil {
+		return fmt.Errorf("failed to create the directory of the job config: %w", err)
+	}
+	minCreated, err := o.jobConfigMinCreated()
+	if err != nil {
+		return err
+	}
+	syncer, err := jobbundle.NewSyncer(o.JobConfigSource, o.JobConfigPublicKey, dir, minCreated)
+	if err != nil {
+		return err
+	}
+	if err := syncer.Sync(context.Background()); err != nil {
+		return fmt.Errorf("failed to sync the job config from %s: %w", o.JobConfigSource, err)
+	}
+	o.JobConfigPath = syncer.Path()
+	interval := o.JobConfigRefreshInterval
+	if interval == 0 {
+		interval = defaultJobConfigRefreshInterval
+	}
+	interrupts.TickLiteral(func() {
+		if err := syncer.Sync(context.Background()); err != nil {
+			logrus.WithField("job-config-source", o.JobConfigSource).WithError(err).Error("Failed to sync the job config, keeping the last one.")
+		}
+	}, interval)
+	return nil
+}
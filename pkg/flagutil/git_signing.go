@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+
+	"sigs.k8s.io/prow/pkg/config/secret"
+	gitv2 "sigs.k8s.io/prow/pkg/git/v2"
+)
+
+// GitSigningOptions holds options for signing the commits created by bots.
+type GitSigningOptions struct {
+	KeyPath        string
+	Format         string
+	CommitterName  string
+	CommitterEmail string
+}
+
+// AddFlags injects the commit signing options into the given FlagSet.
+func (o *GitSigningOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.KeyPath, "git-signing-key", "", "Path to the file containing the unencrypted private key to sign the commits created by the bot with. Commits are unsigned if unset.")
+	fs.StringVar(&o.Format, "git-signing-format", string(gitv2.SigningFormatSSH), fmt.Sprintf("Format of the signing key, %q or %q.", gitv2.SigningFormatSSH, gitv2.SigningFormatOpenPGP))
+	fs.StringVar(&o.CommitterName, "git-committer-name", "", "Name to create signed commits with. Must be set with --git-committer-email.")
+	fs.StringVar(&o.CommitterEmail, "git-committer-email", "", "Email to create signed commits with, which must belong to the account the signing key is registered to. Must be set with --git-committer-name.")
+}
+
+// Validate validates the commit signing options.
+func (o *GitSigningOptions) Validate(_ bool) error {
+	if (o.CommitterName == "") != (o.CommitterEmail == "") {
+		return fmt.Errorf("--git-committer-name and --git-committer-email must be specified together")
+	}
+	if o.KeyPath == "" {
+		if o.CommitterName != "" {
+			return fmt.Errorf("--git-committer-name and --git-committer-email require --git-signing-key")
+		}
+		return nil
+	}
+	switch gitv2.SigningFormat(o.Format) {
+	case gitv2.SigningFormatSSH, gitv2.SigningFormatOpenPGP:
+	default:
+		return fmt.Errorf("--git-signing-format must be %q or %q, got %q", gitv2.SigningFormatSSH, gitv2.SigningFormatOpenPGP, o.Format)
+	}
+	return nil
+}
+
+// ClientFactoryOpt returns the option making a git client factory sign
+// commits, loading the key into the secret agent. It is a no-op if no signing
+// key is configured.
+func (o *GitSigningOptions) ClientFactoryOpt() (gitv2.ClientFactoryOpt, error) {
+	if o.KeyPath == "" {
+		return func(*gitv2.ClientFactoryOpts) {}, nil
+	}
+	if err := secret.Add(o.KeyPath); err != nil {
+		return nil, fmt.Errorf("failed to load the signing key: %w", err)
+	}
+	signing := &gitv2.CommitSigningOpts{
+		Format: gitv2.SigningFormat(o.Format),
+		Key:    secret.GetTokenGenerator(o.KeyPath),
+	}
+	if o.CommitterName != "" {
+		name, email := o.CommitterName, o.CommitterEmail
+		signing.Committer = func() (string, string, error) { return name, email, nil }
+	}
+	return func(opts *gitv2.ClientFactoryOpts) { opts.CommitSigning = signing }, nil
+}
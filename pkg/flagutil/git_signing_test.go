@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestGitSigningOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr bool
+	}{
+		{
+			name: "signing is disabled by default",
+		},
+		{
+			name: "ssh key",
+			args: []string{"--git-signing-key=/etc/signing/key"},
+		},
+		{
+			name: "gpg key with committer",
+			args: []string{"--git-signing-key=/etc/signing/key", "--git-signing-format=openpgp", "--git-committer-name=bot", "--git-committer-email=bot@example.com"},
+		},
+		{
+			name:        "unsupported format",
+			args:        []string{"--git-signing-key=/etc/signing/key", "--git-signing-format=x509"},
+			expectedErr: true,
+		},
+		{
+			name:        "committer name without email",
+			args:        []string{"--git-signing-key=/etc/signing/key", "--git-committer-name=bot"},
+			expectedErr: true,
+		},
+		{
+			name:        "committer without key",
+			args:        []string{"--git-committer-name=bot", "--git-committer-email=bot@example.com"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &GitSigningOptions{}
+			fs := flag.NewFlagSet(tc.name, flag.ContinueOnError)
+			o.AddFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if err := o.Validate(false); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -332,10 +332,11 @@ func (o *GitHubOptions) GitHubClientWithAccessToken(token string) (github.Client
 }
 
 // GitClientFactory returns git.ClientFactory. Passing non-empty cookieFilePath
-// will result in git ClientFactory to work with Gerrit.
+// will result in git ClientFactory to work with Gerrit. The extraOpts, like
+// the one of GitSigningOptions, are applied last.
 // TODO(chaodaiG): move this logic to somewhere more appropriate instead of in
 // github.go.
-func (o *GitHubOptions) GitClientFactory(cookieFilePath string, cacheDir *string, dryRun, persistCache bool) (gitv2.ClientFactory, error) {
+func (o *GitHubOptions) GitClientFactory(cookieFilePath string, cacheDir *string, dryRun, persistCache bool, extraOpts ...gitv2.ClientFactoryOpt) (gitv2.ClientFactory, error) {
 	opts := gitv2.ClientFactoryOpts{
 		Censor:         secret.Censor,
 		CookieFilePath: cookieFilePath,
@@ -357,7 +358,7 @@ func (o *GitHubOptions) GitClientFactory(cookieFilePath string, cacheDir *string
 	}
 	// If the client is for Gerrit we're already set with the cookie filepath.
 
-	gitClientFactory, err := gitv2.NewClientFactory(opts.Apply)
+	gitClientFactory, err := gitv2.NewClientFactory(append([]gitv2.ClientFactoryOpt{opts.Apply}, extraOpts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create git client factory: %w", err)
 	}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	status "google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -130,11 +132,162 @@ func (gw *Gangway) GetJobExecution(ctx context.Context, gjer *GetJobExecutionReq
 	return jobExec, nil
 }
 
+// AbortJobExecution aborts a Prow job that has not completed yet. The ProwJob
+// is only marked as aborted here; tearing down the pod is left to the
+// controller that runs the job. Clients can only abort jobs they would be
+// allowed to create.
+func (gw *Gangway) AbortJobExecution(ctx context.Context, ajer *AbortJobExecutionRequest) (*JobExecution, error) {
+	err, md := getHttpRequestHeaders(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("could not find request HTTP headers")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := ajer.Validate(); err != nil {
+		logrus.WithError(err).Debug("could not validate request fields")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	mainConfig := gw.ConfigAgent.Config()
+	allowedApiClient, err := mainConfig.IdentifyAllowedClient(md)
+	if err != nil {
+		logrus.WithError(err).Debug("could not find client in allowlist")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	l, err := getDecoratedLoggerEntry(allowedApiClient, md)
+	if err != nil {
+		l = logrus.NewEntry(logrus.New())
+	}
+	l = l.WithField("name", ajer.GetId())
+
+	pj, err := gw.ProwJobClient.Get(ctx, ajer.GetId(), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		l.WithError(err).Error("failed to get ProwJob")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !ClientAuthorized(allowedApiClient, *pj) {
+		l.Debug("client is not authorized to abort the given job")
+		return nil, status.Error(codes.PermissionDenied, "client is not authorized to abort the given job")
+	}
+
+	switch pj.Status.State {
+	case prowcrd.HeldState, prowcrd.WaitingState, prowcrd.SchedulingState, prowcrd.TriggeredState, prowcrd.PendingState:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot abort job with state %q", pj.Status.State)
+	}
+
+	pj.Status.State = prowcrd.AbortedState
+	pj.Status.Description = "Aborted through the Prow API."
+	updatedPj, err := gw.ProwJobClient.Update(ctx, pj, metav1.UpdateOptions{})
+	if err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		l.WithError(err).Error("failed to update ProwJob status")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	l.Info("ProwJob aborted")
+
+	return jobExecutionFor(updatedPj), nil
+}
+
+// ClearSchedulingGate clears one scheduling gate of a waiting Prow job by
+// adding it to the cleared-scheduling-gates annotation. Releasing the job
+// once no gate is left is up to the scheduling-gates controller. Clients can
+// only clear gates of jobs they would be allowed to create.
+func (gw *Gangway) ClearSchedulingGate(ctx context.Context, csgr *ClearSchedulingGateRequest) (*JobExecution, error) {
+	err, md := getHttpRequestHeaders(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("could not find request HTTP headers")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := csgr.Validate(); err != nil {
+		logrus.WithError(err).Debug("could not validate request fields")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	mainConfig := gw.ConfigAgent.Config()
+	allowedApiClient, err := mainConfig.IdentifyAllowedClient(md)
+	if err != nil {
+		logrus.WithError(err).Debug("could not find client in allowlist")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	l, err := getDecoratedLoggerEntry(allowedApiClient, md)
+	if err != nil {
+		l = logrus.NewEntry(logrus.New())
+	}
+	l = l.WithFields(logrus.Fields{"name": csgr.GetId(), "gate": csgr.GetGate()})
+
+	pj, err := gw.ProwJobClient.Get(ctx, csgr.GetId(), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		l.WithError(err).Error("failed to get ProwJob")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !ClientAuthorized(allowedApiClient, *pj) {
+		l.Debug("client is not authorized to clear scheduling gates of the given job")
+		return nil, status.Error(codes.PermissionDenied, "client is not authorized to clear scheduling gates of the given job")
+	}
+
+	if pj.Status.State != prowcrd.WaitingState {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot clear scheduling gates of job with state %q", pj.Status.State)
+	}
+	if !slices.Contains(pj.Spec.SchedulingGates, csgr.GetGate()) {
+		return nil, status.Errorf(codes.InvalidArgument, "job has no scheduling gate %q", csgr.GetGate())
+	}
+	if !slices.Contains(pj.RemainingSchedulingGates(), csgr.GetGate()) {
+		// Already cleared, clearing it again is a no-op.
+		return jobExecutionFor(pj), nil
+	}
+
+	cleared := csgr.GetGate()
+	if previous := pj.Annotations[prowcrd.ClearedSchedulingGatesAnnotation]; previous != "" {
+		cleared = previous + "," + cleared
+	}
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	pj.Annotations[prowcrd.ClearedSchedulingGatesAnnotation] = cleared
+	updatedPj, err := gw.ProwJobClient.Update(ctx, pj, metav1.UpdateOptions{})
+	if err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		l.WithError(err).Error("failed to update ProwJob annotations")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	l.Info("Scheduling gate cleared")
+
+	return jobExecutionFor(updatedPj), nil
+}
+
+func jobExecutionFor(pj *prowcrd.ProwJob) *JobExecution {
+	return &JobExecution{
+		Id:        pj.Name,
+		JobName:   pj.Spec.Job,
+		JobStatus: TranslateProwJobStatus(&pj.Status),
+		JobType:   TranslateProwJobType(pj.Spec.Type),
+	}
+}
+
 // Translate ProwJobStatus.State in the Prow Job CR into a JobExecutionStatus.
 func TranslateProwJobStatus(prowJobStatus *prowcrd.ProwJobStatus) JobExecutionStatus {
 	var jobStatus JobExecutionStatus
 
 	switch prowJobStatus.State {
+	case prowcrd.HeldState, prowcrd.WaitingState:
+		// Held jobs are waiting for a manual approval.
+		jobStatus = JobExecutionStatus_WAITING
 	case prowcrd.TriggeredState:
 		jobStatus = JobExecutionStatus_TRIGGERED
 	case prowcrd.PendingState:
@@ -508,6 +661,10 @@ func (cjer *CreateJobExecutionRequest) Validate() error {
 		}
 	}
 
+	if dest := cjer.GetArtifactDestination(); dest != nil && dest.GetBucket() == "" {
+		return errors.New("artifact_destination.bucket cannot be empty")
+	}
+
 	// Finally perform some additional checks on the requested PodSpecOptions.
 	podSpecOptions := cjer.GetPodSpecOptions()
 	if podSpecOptions != nil {
@@ -562,6 +719,23 @@ func (bjscr *BulkJobStatusChangeRequest) Validate() error {
 	return nil
 }
 
+func (ajer *AbortJobExecutionRequest) Validate() error {
+	if len(ajer.GetId()) == 0 {
+		return errors.New("id cannot be empty")
+	}
+	return nil
+}
+
+func (csgr *ClearSchedulingGateRequest) Validate() error {
+	if len(csgr.GetId()) == 0 {
+		return errors.New("id cannot be empty")
+	}
+	if len(csgr.GetGate()) == 0 {
+		return errors.New("gate cannot be empty")
+	}
+	return nil
+}
+
 func (gitRefs *Refs) Validate() error {
 	if len(gitRefs.Org) == 0 {
 		return fmt.Errorf("gitRefs: Org cannot be empty")
@@ -610,6 +784,7 @@ type prowCfgClient interface {
 	GetPostsubmitsStatic(identifier string) []config.Postsubmit
 	GetProwJobDefault(repo, cluster string) *prowcrd.ProwJobDefault
 	GetScheduler() config.Scheduler
+	GetPlank() config.Plank
 }
 
 type ProwCfgAdapter struct {
@@ -618,6 +793,8 @@ type ProwCfgAdapter struct {
 
 func (c *ProwCfgAdapter) GetScheduler() config.Scheduler { return c.Scheduler }
 
+func (c *ProwCfgAdapter) GetPlank() config.Plank { return c.Plank }
+
 type ReporterFunc func(pj *prowcrd.ProwJob, state prowcrd.ProwJobState, err error)
 
 func (cjer *CreateJobExecutionRequest) getJobHandler() (jobHandler, error) {
@@ -699,6 +876,17 @@ func HandleProwJob(l *logrus.Entry,
 	if prowJobSpec == nil {
 		return nil, fmt.Errorf("failed getting prowjob spec") // This should not happen
 	}
+	if dest := cjer.GetArtifactDestination(); dest != nil {
+		if err := mainConfig.GetPlank().ApplyArtifactDestination(prowJobSpec, prowcrd.ArtifactDestination{Bucket: dest.GetBucket(), PathPrefix: dest.GetPathPrefix()}); err != nil {
+			l.WithError(err).WithField("name", cjer.GetJobName()).Info("Artifact destination not allowed")
+			prowJobCR = pjutil.NewProwJob(*prowJobSpec, nil, cjer.GetPodSpecOptions().GetAnnotations(),
+				pjutil.RequireScheduling(mainConfig.GetScheduler().Enabled))
+			if reporterFunc != nil {
+				reporterFunc(&prowJobCR, prowcrd.ErrorState, err)
+			}
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
 
 	combinedLabels, combinedAnnotations := mergeMapFields(cjer, labels, annotations)
 	prowJobCR = pjutil.NewProwJob(*prowJobSpec, combinedLabels, combinedAnnotations,
@@ -795,11 +983,15 @@ func HandleProwJob(l *logrus.Entry,
 	// (https://prow.k8s.io/prowjob?prowjob=c2891365-621c-11ed-88b0-da2d50b4915c)
 	// but also for naming the test pod itself (prowcrd.ProwJob.Status.pod_name
 	// field).
+	jobStatus := JobExecutionStatus_TRIGGERED
+	if prowJobCR.Status.State == prowcrd.WaitingState || prowJobCR.Status.State == prowcrd.HeldState {
+		jobStatus = JobExecutionStatus_WAITING
+	}
 	jobExec := &JobExecution{
 		Id:             prowJobCR.Name,
 		JobName:        cjer.GetJobName(),
 		JobType:        cjer.GetJobExecutionType(),
-		JobStatus:      JobExecutionStatus_TRIGGERED,
+		JobStatus:      jobStatus,
 		Refs:           cjer.GetRefs(),
 		PodSpecOptions: cjer.GetPodSpecOptions(),
 	}
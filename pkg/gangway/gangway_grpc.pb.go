@@ -23,6 +23,8 @@ const (
 	Prow_CreateJobExecution_FullMethodName  = "/Prow/CreateJobExecution"
 	Prow_GetJobExecution_FullMethodName     = "/Prow/GetJobExecution"
 	Prow_ListJobExecutions_FullMethodName   = "/Prow/ListJobExecutions"
+	Prow_AbortJobExecution_FullMethodName   = "/Prow/AbortJobExecution"
+	Prow_ClearSchedulingGate_FullMethodName = "/Prow/ClearSchedulingGate"
 	Prow_BulkJobStatusChange_FullMethodName = "/Prow/BulkJobStatusChange"
 )
 
@@ -38,6 +40,8 @@ type ProwClient interface {
 	CreateJobExecution(ctx context.Context, in *CreateJobExecutionRequest, opts ...grpc.CallOption) (*JobExecution, error)
 	GetJobExecution(ctx context.Context, in *GetJobExecutionRequest, opts ...grpc.CallOption) (*JobExecution, error)
 	ListJobExecutions(ctx context.Context, in *ListJobExecutionsRequest, opts ...grpc.CallOption) (*JobExecutions, error)
+	AbortJobExecution(ctx context.Context, in *AbortJobExecutionRequest, opts ...grpc.CallOption) (*JobExecution, error)
+	ClearSchedulingGate(ctx context.Context, in *ClearSchedulingGateRequest, opts ...grpc.CallOption) (*JobExecution, error)
 	BulkJobStatusChange(ctx context.Context, in *BulkJobStatusChangeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
@@ -76,6 +80,24 @@ func (c *prowClient) ListJobExecutions(ctx context.Context, in *ListJobExecution
 	return out, nil
 }
 
+func (c *prowClient) AbortJobExecution(ctx context.Context, in *AbortJobExecutionRequest, opts ...grpc.CallOption) (*JobExecution, error) {
+	out := new(JobExecution)
+	err := c.cc.Invoke(ctx, Prow_AbortJobExecution_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *prowClient) ClearSchedulingGate(ctx context.Context, in *ClearSchedulingGateRequest, opts ...grpc.CallOption) (*JobExecution, error) {
+	out := new(JobExecution)
+	err := c.cc.Invoke(ctx, Prow_ClearSchedulingGate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *prowClient) BulkJobStatusChange(ctx context.Context, in *BulkJobStatusChangeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, Prow_BulkJobStatusChange_FullMethodName, in, out, opts...)
@@ -97,6 +119,8 @@ type ProwServer interface {
 	CreateJobExecution(context.Context, *CreateJobExecutionRequest) (*JobExecution, error)
 	GetJobExecution(context.Context, *GetJobExecutionRequest) (*JobExecution, error)
 	ListJobExecutions(context.Context, *ListJobExecutionsRequest) (*JobExecutions, error)
+	AbortJobExecution(context.Context, *AbortJobExecutionRequest) (*JobExecution, error)
+	ClearSchedulingGate(context.Context, *ClearSchedulingGateRequest) (*JobExecution, error)
 	BulkJobStatusChange(context.Context, *BulkJobStatusChangeRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedProwServer()
 }
@@ -114,6 +138,12 @@ func (UnimplementedProwServer) GetJobExecution(context.Context, *GetJobExecution
 func (UnimplementedProwServer) ListJobExecutions(context.Context, *ListJobExecutionsRequest) (*JobExecutions, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListJobExecutions not implemented")
 }
+func (UnimplementedProwServer) AbortJobExecution(context.Context, *AbortJobExecutionRequest) (*JobExecution, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortJobExecution not implemented")
+}
+func (UnimplementedProwServer) ClearSchedulingGate(context.Context, *ClearSchedulingGateRequest) (*JobExecution, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearSchedulingGate not implemented")
+}
 func (UnimplementedProwServer) BulkJobStatusChange(context.Context, *BulkJobStatusChangeRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BulkJobStatusChange not implemented")
 }
@@ -184,6 +214,42 @@ func _Prow_ListJobExecutions_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Prow_AbortJobExecution_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortJobExecutionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProwServer).AbortJobExecution(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prow_AbortJobExecution_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProwServer).AbortJobExecution(ctx, req.(*AbortJobExecutionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Prow_ClearSchedulingGate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearSchedulingGateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProwServer).ClearSchedulingGate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prow_ClearSchedulingGate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProwServer).ClearSchedulingGate(ctx, req.(*ClearSchedulingGateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Prow_BulkJobStatusChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BulkJobStatusChangeRequest)
 	if err := dec(in); err != nil {
@@ -221,6 +287,14 @@ var Prow_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListJobExecutions",
 			Handler:    _Prow_ListJobExecutions_Handler,
 		},
+		{
+			MethodName: "AbortJobExecution",
+			Handler:    _Prow_AbortJobExecution_Handler,
+		},
+		{
+			MethodName: "ClearSchedulingGate",
+			Handler:    _Prow_ClearSchedulingGate_Handler,
+		},
 		{
 			MethodName: "BulkJobStatusChange",
 			Handler:    _Prow_BulkJobStatusChange_Handler,
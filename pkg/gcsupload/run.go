@@ -64,6 +64,21 @@ func (o Options) Run(ctx context.Context, spec *downwardapi.JobSpec, extra map[s
 	return err
 }
 
+// UploadFiles uploads the targets to the directory of the job, without the
+// items and the files that point to the latest build, for uploads while
+// the job runs.
+func (o Options) UploadFiles(ctx context.Context, spec *downwardapi.JobSpec, targets map[string]gcs.UploadFunc) error {
+	_, blobStoragePath, _ := PathsForJob(o.GCSConfiguration, spec, o.SubDir)
+	if o.LocalOutputDir != "" {
+		blobStoragePath = ""
+	}
+	uploadTargets := make(map[string]gcs.UploadFunc, len(targets))
+	for destination, upload := range targets {
+		uploadTargets[path.Join(blobStoragePath, destination)] = upload
+	}
+	return completeUpload(ctx, o, uploadTargets)
+}
+
 func completeUpload(ctx context.Context, o Options, uploadTargets map[string]gcs.UploadFunc) error {
 	if o.DryRun {
 		for destination := range uploadTargets {
@@ -73,7 +88,7 @@ func completeUpload(ctx context.Context, o Options, uploadTargets map[string]gcs
 	}
 
 	if o.LocalOutputDir == "" {
-		if err := gcs.Upload(ctx, o.Bucket, o.StorageClientOptions.GCSCredentialsFile, o.StorageClientOptions.S3CredentialsFile, o.CompressFileTypes, uploadTargets); err != nil {
+		if err := gcs.UploadWithOptions(ctx, o.Bucket, o.StorageClientOptions.GCSCredentialsFile, o.StorageClientOptions.S3CredentialsFile, o.CompressFileTypes, gcs.UploadOptionsFor(o.GCSConfiguration), uploadTargets); err != nil {
 			return fmt.Errorf("failed to upload to blob storage: %w", err)
 		}
 		logrus.Info("Finished upload to blob storage")
@@ -74,6 +74,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
@@ -126,7 +127,15 @@ func NewCommentMap(rawFiles map[string][]byte, paths ...string) (*CommentMap, er
 		packageFiles[dir].rawContents = map[string][]byte{path: content}
 	}
 
-	for pkg, files := range packageFiles {
+	// Types of different packages with the same name share their comments,
+	// so add the packages in a stable order for the result to be stable too.
+	pkgs := make([]string, 0, len(packageFiles))
+	for pkg := range packageFiles {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		files := packageFiles[pkg]
 		if err := cm.addPackage(files.paths, files.rawContents); err != nil {
 			return nil, fmt.Errorf("failed to add files in %s: %w", pkg, err)
 		}
@@ -438,6 +447,15 @@ func (cm *CommentMap) PrintComments() {
 	}
 }
 
+// Doc returns the comment of the field with the given YAML name of the type
+// with the given name, or an empty string if it is undocumented.
+func (cm *CommentMap) Doc(typeName, fieldName string) string {
+	cm.RLock()
+	defer cm.RUnlock()
+
+	return cm.comments[typeName][fieldName].Doc
+}
+
 // addPackage allow for adding to the CommentMap via a list of paths to go files in the same package
 func (cm *CommentMap) addPackage(paths []string, rawFiles map[string][]byte) error {
 	cm.Lock()
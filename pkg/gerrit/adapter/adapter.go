@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -158,6 +159,7 @@ type gerritClient interface {
 	SetReview(instance, id, revision, message string, labels map[string]string) error
 	Account(instance string) (*gerrit.AccountInfo, error)
 	HasRelatedChanges(instance, id, revision string) (bool, error)
+	QueryTopicChanges(instance, topic string) ([]gerrit.ChangeInfo, error)
 }
 
 // Controller manages gerrit changes.
@@ -595,8 +597,11 @@ func (c *Controller) triggerJobs(logger logrus.FieldLogger, instance string, cha
 		spec        prowapi.ProwJobSpec
 		labels      map[string]string
 		annotations map[string]string
+		// topic is set if the job also tests the other changes of the topic.
+		topic string
 	}
 	var jobSpecs []jobSpec
+	var testedTopicChanges int
 	baseSHAGetter := func() (string, error) { return baseSHA, nil }
 	var hasRelatedChanges *bool
 	// This headSHAGetter will return the empty string instead of the head SHA in cases where we can be certain that change does not
@@ -767,12 +772,26 @@ func (c *Controller) triggerJobs(logger logrus.FieldLogger, instance string, cha
 			}
 		}
 
+		var topicRefs []prowapi.Refs
+		if change.Topic != "" && len(toTrigger) > 0 && c.config().Gerrit.OrgReposConfig.TopicTestingEnabled(instance, change.Project) {
+			topicRefs, err = c.topicRefs(logger, instance, change)
+			if err != nil {
+				return fmt.Errorf("failed to get changes of topic %q: %w", change.Topic, err)
+			}
+			testedTopicChanges = len(topicRefs)
+		}
+
 		for _, presubmit := range toTrigger {
-			jobSpecs = append(jobSpecs, jobSpec{
+			jSpec := jobSpec{
 				spec:        pjutil.PresubmitSpec(presubmit, refs),
 				labels:      presubmit.Labels,
 				annotations: presubmit.Annotations,
-			})
+			}
+			if len(topicRefs) > 0 {
+				jSpec.spec.ExtraRefs = mergeTopicRefs(jSpec.spec.ExtraRefs, topicRefs)
+				jSpec.topic = change.Topic
+			}
+			jobSpecs = append(jobSpecs, jSpec)
 		}
 	}
 
@@ -780,6 +799,9 @@ func (c *Controller) triggerJobs(logger logrus.FieldLogger, instance string, cha
 
 	for _, jSpec := range jobSpecs {
 		labels, annotations := LabelsAndAnnotations(instance, jSpec.labels, jSpec.annotations, change)
+		if jSpec.topic != "" {
+			annotations[kube.GerritTopic] = jSpec.topic
+		}
 
 		pj := pjutil.NewProwJob(jSpec.spec, labels, annotations, pjutil.RequireScheduling(schedulerEnabled))
 
@@ -826,6 +848,9 @@ func (c *Controller) triggerJobs(logger logrus.FieldLogger, instance string, cha
 		} else {
 			message = message + jobList
 		}
+		if testedTopicChanges > 0 {
+			message += fmt.Sprintf("\nPresubmits also test the other open changes of topic %q in %d projects.", change.Topic, testedTopicChanges)
+		}
 		if err := c.gc.SetReview(instance, change.ID, change.CurrentRevision, message, nil); err != nil {
 			return err
 		}
@@ -834,6 +859,75 @@ func (c *Controller) triggerJobs(logger logrus.FieldLogger, instance string, cha
 	return nil
 }
 
+// topicRefs returns refs checking out the other open changes of the topic of
+// the change, one per project. Changes of the change's own project are left
+// out, they would be checked out into the same directory. Changes stacked
+// below the change are checked out anyway as its parents. Projects whose
+// changes target different branches are left out too, as only one branch
+// can be checked out.
+func (c *Controller) topicRefs(logger logrus.FieldLogger, instance string, change client.ChangeInfo) ([]prowapi.Refs, error) {
+	topicChanges, err := c.gc.QueryTopicChanges(instance, change.Topic)
+	if err != nil {
+		return nil, err
+	}
+	byProject := map[string][]client.ChangeInfo{}
+	for _, topicChange := range topicChanges {
+		if topicChange.Project == change.Project {
+			continue
+		}
+		byProject[topicChange.Project] = append(byProject[topicChange.Project], topicChange)
+	}
+
+	var topicRefs []prowapi.Refs
+	for _, project := range sets.List(sets.KeySet(byProject)) {
+		changes := byProject[project]
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Number < changes[j].Number })
+		branches := sets.New[string]()
+		for _, topicChange := range changes {
+			branches.Insert(topicChange.Branch)
+		}
+		if branches.Len() > 1 {
+			logger.WithFields(logrus.Fields{"topic": change.Topic, "project": project, "branches": sets.List(branches)}).Warn("Changes of the topic target different branches of the project, not testing them together.")
+			continue
+		}
+		branch := changes[0].Branch
+		baseSHA, err := c.gc.GetBranchRevision(instance, project, branch)
+		if err != nil {
+			return nil, fmt.Errorf("GetBranchRevision: %w", err)
+		}
+		refs, err := CreateRefs(instance, project, branch, baseSHA, changes...)
+		if err != nil {
+			return nil, fmt.Errorf("createRefs for %s: %w", project, err)
+		}
+		topicRefs = append(topicRefs, refs)
+	}
+	return topicRefs, nil
+}
+
+// mergeTopicRefs adds the refs of the topic changes to the extra refs of a
+// job. If the job already checks out a project, its ref is pointed at the
+// topic changes instead, keeping how it is cloned.
+func mergeTopicRefs(extraRefs, topicRefs []prowapi.Refs) []prowapi.Refs {
+	merged := append([]prowapi.Refs(nil), extraRefs...)
+	for _, topicRef := range topicRefs {
+		var found bool
+		for i := range merged {
+			if merged[i].Org == topicRef.Org && merged[i].Repo == topicRef.Repo {
+				merged[i].BaseRef = topicRef.BaseRef
+				merged[i].BaseSHA = topicRef.BaseSHA
+				merged[i].BaseLink = topicRef.BaseLink
+				merged[i].Pulls = topicRef.Pulls
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, topicRef)
+		}
+	}
+	return merged
+}
+
 // isProjectOptOutHelp returns if the project is opt-out from getting help
 // information about how to run presubmit tests on their changes.
 func isProjectOptOutHelp(projectsOptOutHelp map[string]sets.Set[string], instance, project string) bool {
@@ -69,14 +69,19 @@ func (f *fca) Config() *config.Config {
 }
 
 type fgc struct {
-	reviews     int
-	instanceMap map[string]*gerrit.AccountInfo
+	reviews      int
+	instanceMap  map[string]*gerrit.AccountInfo
+	topicChanges []gerrit.ChangeInfo
 }
 
 func (f *fgc) HasRelatedChanges(instance, id, revision string) (bool, error) {
 	return false, nil
 }
 
+func (f *fgc) QueryTopicChanges(instance, topic string) ([]gerrit.ChangeInfo, error) {
+	return f.topicChanges, nil
+}
+
 func (f *fgc) ApplyGlobalConfig(orgRepoConfigGetter func() *config.GerritOrgRepoConfigs, lastSyncTracker *client.SyncTime, cookiefilePath, tokenPathOverride string, additionalFunc func()) {
 
 }
@@ -3413,3 +3418,96 @@ func TestDeckLinkForPR(t *testing.T) {
 		})
 	}
 }
+
+func TestTopicRefs(t *testing.T) {
+	topicChange := func(number int, project, branch string) client.ChangeInfo {
+		revision := fmt.Sprintf("rev-%d", number)
+		return client.ChangeInfo{
+			Number:          number,
+			Project:         project,
+			Branch:          branch,
+			Topic:           "feature",
+			CurrentRevision: revision,
+			Revisions: map[string]client.RevisionInfo{
+				revision: {Ref: fmt.Sprintf("refs/changes/%d/1", number)},
+			},
+		}
+	}
+	var testcases = []struct {
+		name         string
+		topicChanges []client.ChangeInfo
+		expected     map[string][]int
+	}{
+		{
+			name: "changes of other projects are grouped by project",
+			topicChanges: []client.ChangeInfo{
+				topicChange(1, "test-infra", "master"),
+				topicChange(4, "other-repo", "main"),
+				topicChange(2, "other-repo", "main"),
+				topicChange(3, "docs", "master"),
+			},
+			expected: map[string][]int{"docs": {3}, "other-repo": {2, 4}},
+		},
+		{
+			name: "changes of the project itself are left out",
+			topicChanges: []client.ChangeInfo{
+				topicChange(1, "test-infra", "master"),
+				topicChange(2, "test-infra", "master"),
+			},
+		},
+		{
+			name: "projects with changes to different branches are left out",
+			topicChanges: []client.ChangeInfo{
+				topicChange(1, "test-infra", "master"),
+				topicChange(2, "other-repo", "main"),
+				topicChange(3, "other-repo", "release"),
+				topicChange(4, "docs", "master"),
+			},
+			expected: map[string][]int{"docs": {4}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Controller{gc: &fgc{topicChanges: tc.topicChanges}}
+			refs, err := c.topicRefs(logrus.WithField("test", tc.name), "https://gerrit", topicChange(1, "test-infra", "master"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := map[string][]int{}
+			for _, ref := range refs {
+				if ref.BaseSHA != "abc" {
+					t.Errorf("expected refs of %s to be based on the branch revision, got %q", ref.Repo, ref.BaseSHA)
+				}
+				for _, pull := range ref.Pulls {
+					actual[ref.Repo] = append(actual[ref.Repo], pull.Number)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected topic refs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeTopicRefs(t *testing.T) {
+	topicRefs := []prowapi.Refs{
+		{Org: "https://gerrit", Repo: "other-repo", BaseRef: "main", BaseSHA: "abc", Pulls: []prowapi.Pull{{Number: 2}}},
+		{Org: "https://gerrit", Repo: "docs", BaseRef: "master", BaseSHA: "def", Pulls: []prowapi.Pull{{Number: 3}}},
+	}
+	extraRefs := []prowapi.Refs{
+		{Org: "https://gerrit", Repo: "other-repo", BaseRef: "main", PathAlias: "example.com/other"},
+		{Org: "https://gerrit", Repo: "tools", BaseRef: "master"},
+	}
+	expected := []prowapi.Refs{
+		{Org: "https://gerrit", Repo: "other-repo", BaseRef: "main", BaseSHA: "abc", PathAlias: "example.com/other", Pulls: []prowapi.Pull{{Number: 2}}},
+		{Org: "https://gerrit", Repo: "tools", BaseRef: "master"},
+		{Org: "https://gerrit", Repo: "docs", BaseRef: "master", BaseSHA: "def", Pulls: []prowapi.Pull{{Number: 3}}},
+	}
+	actual := mergeTopicRefs(extraRefs, topicRefs)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected extra refs (-want +got):\n%s", diff)
+	}
+	if extraRefs[0].Pulls != nil {
+		t.Error("expected the extra refs of the job not to be modified")
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -104,6 +105,11 @@ type gerritRevision interface {
 	GetMergeable(changeID, revisionID string, opt *gerrit.MergableOptions) (*gerrit.MergeableInfo, *gerrit.Response, error)
 }
 
+// gerritREST calls the endpoints the gerrit library has no methods for.
+type gerritREST interface {
+	Call(method, u string, body interface{}, v interface{}) (*gerrit.Response, error)
+}
+
 // gerritInstanceHandler holds all actual gerrit handlers
 type gerritInstanceHandler struct {
 	instance string
@@ -114,6 +120,7 @@ type gerritInstanceHandler struct {
 	changeService   gerritChange
 	projectService  gerritProjects
 	revisionService gerritRevision
+	restService     gerritREST
 
 	log logrus.FieldLogger
 }
@@ -328,6 +335,7 @@ func (c *Client) newInstanceHandler(instance string, projects map[string]*config
 		accountService: gc.Accounts,
 		changeService:  gc.Changes,
 		projectService: gc.Projects,
+		restService:    gc,
 		log:            logrus.WithField("host", instance),
 	}, nil
 }
@@ -499,6 +507,74 @@ func (c *Client) SetReview(instance, id, revision, message string, labels map[st
 	return nil
 }
 
+// hashtagsInput is the input to set the hashtags of a change, see
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#hashtags-input
+type hashtagsInput struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// SetHashtags adds and removes hashtags of a change. Adding hashtags the
+// change has already or removing ones it doesn't have is a no-op.
+func (c *Client) SetHashtags(instance, id string, add, remove []string) error {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	resp, err := h.restService.Call(http.MethodPost, fmt.Sprintf("changes/%s/hashtags", id), &hashtagsInput{Add: add, Remove: remove}, nil)
+	if err != nil {
+		return fmt.Errorf("cannot set hashtags of change %s: %w", id, responseBodyError(err, resp))
+	}
+
+	return nil
+}
+
+// attentionSetInput is the input to add a user to or remove them from the
+// attention set of a change, see
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#attention-set-input
+type attentionSetInput struct {
+	User   string `json:"user,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// AddToAttentionSet adds the account to the attention set of a change.
+func (c *Client) AddToAttentionSet(instance, id string, accountID int, reason string) error {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	resp, err := h.restService.Call(http.MethodPost, fmt.Sprintf("changes/%s/attention", id), &attentionSetInput{User: strconv.Itoa(accountID), Reason: reason}, nil)
+	if err != nil {
+		return fmt.Errorf("cannot add account %d to the attention set of change %s: %w", accountID, id, responseBodyError(err, resp))
+	}
+
+	return nil
+}
+
+// RemoveFromAttentionSet removes the account from the attention set of a
+// change.
+func (c *Client) RemoveFromAttentionSet(instance, id string, accountID int, reason string) error {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	resp, err := h.restService.Call(http.MethodPost, fmt.Sprintf("changes/%s/attention/%d/delete", id, accountID), &attentionSetInput{Reason: reason}, nil)
+	if err != nil {
+		return fmt.Errorf("cannot remove account %d from the attention set of change %s: %w", accountID, id, responseBodyError(err, resp))
+	}
+
+	return nil
+}
+
 // GetBranchRevision returns SHA of HEAD of a branch
 func (c *Client) GetBranchRevision(instance, project, branch string) (string, error) {
 	c.lock.RLock()
@@ -795,6 +871,29 @@ func (h *gerritInstanceHandler) queryChangesForProjectWithoutMetrics(log logrus.
 	}
 }
 
+// QueryTopicChanges returns the open changes of the instance that are part of
+// the topic.
+func (c *Client) QueryTopicChanges(instance, topic string) ([]ChangeInfo, error) {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	var opt gerrit.QueryChangeOptions
+	opt.Query = []string{fmt.Sprintf("status:open+topic:%q", topic)}
+	opt.AdditionalFields = []string{"CURRENT_REVISION", "CURRENT_COMMIT"}
+	changes, resp, err := h.changeService.QueryChanges(&opt)
+	if err != nil {
+		return nil, fmt.Errorf("error querying changes of topic %q: %w", topic, responseBodyError(err, resp))
+	}
+	if changes == nil {
+		return nil, nil
+	}
+	return *changes, nil
+}
+
 // ChangedFilesProvider lists (in lexicographic order) the files changed as part of a Gerrit patchset.
 // It includes the original paths of renamed files.
 func ChangedFilesProvider(changeInfo *ChangeInfo) config.ChangedFilesProvider {
@@ -1180,3 +1180,48 @@ func TestQueryChange(t *testing.T) {
 		}
 	}
 }
+
+type restCall struct {
+	method, url string
+	body        interface{}
+}
+
+type fakeREST struct {
+	calls []restCall
+}
+
+func (f *fakeREST) Call(method, u string, body interface{}, v interface{}) (*gerrit.Response, error) {
+	f.calls = append(f.calls, restCall{method: method, url: u, body: body})
+	return nil, nil
+}
+
+func TestHashtagsAndAttentionSet(t *testing.T) {
+	rest := &fakeREST{}
+	client := &Client{
+		handlers: map[string]*gerritInstanceHandler{
+			"foo": {instance: "foo", restService: rest},
+		},
+	}
+
+	if err := client.SetHashtags("foo", "bar~1", []string{"ci-failed"}, []string{"ci-flaky"}); err != nil {
+		t.Fatalf("SetHashtags: %v", err)
+	}
+	if err := client.AddToAttentionSet("foo", "bar~1", 42, "jobs failed"); err != nil {
+		t.Fatalf("AddToAttentionSet: %v", err)
+	}
+	if err := client.RemoveFromAttentionSet("foo", "bar~1", 42, "jobs passed"); err != nil {
+		t.Fatalf("RemoveFromAttentionSet: %v", err)
+	}
+	if err := client.SetHashtags("unknown", "bar~1", []string{"ci-failed"}, nil); err == nil {
+		t.Error("SetHashtags on an unknown instance: expected an error")
+	}
+
+	want := []restCall{
+		{method: http.MethodPost, url: "changes/bar~1/hashtags", body: &hashtagsInput{Add: []string{"ci-failed"}, Remove: []string{"ci-flaky"}}},
+		{method: http.MethodPost, url: "changes/bar~1/attention", body: &attentionSetInput{User: "42", Reason: "jobs failed"}},
+		{method: http.MethodPost, url: "changes/bar~1/attention/42/delete", body: &attentionSetInput{Reason: "jobs passed"}},
+	}
+	if diff := cmp.Diff(want, rest.calls, cmp.AllowUnexported(restCall{})); diff != "" {
+		t.Errorf("calls differ (-want +got):\n%s", diff)
+	}
+}
@@ -76,6 +76,11 @@ const (
 	// the Authorization header will be used.
 	TokenBudgetIdentifierHeader = "X-PROW-GHCACHE-TOKEN-BUDGET-IDENTIFIER"
 
+	// OrgIdentifierHeader identifies the org a request is made for, so that
+	// API usage can be attributed to it. If unset, the org is taken from the
+	// request path where possible.
+	OrgIdentifierHeader = "X-PROW-GHCACHE-ORG"
+
 	// TokenExpiryAtHeader includes a date at which the passed token expires and all associated caches
 	// can be cleaned up. It's value must be in RFC3339 format.
 	TokenExpiryAtHeader = "X-PROW-TOKEN-EXPIRES-AT"
@@ -382,6 +387,11 @@ func (u upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 	ghmetrics.CollectGitHubTokenMetrics(tokenBudgetName, apiVersion, resp.Header, reqStartTime, responseTime)
 	ghmetrics.CollectGitHubRequestMetrics(tokenBudgetName, req.URL.Path, strconv.Itoa(resp.StatusCode), req.Header.Get("User-Agent"), roundTripTime.Seconds())
+	org := req.Header.Get(OrgIdentifierHeader)
+	if org == "" {
+		org = ghmetrics.OrgFromPath(req.URL.Path)
+	}
+	ghmetrics.CollectGitHubOrgMetrics(org, tokenBudgetName, req.URL.Path, strconv.Itoa(resp.StatusCode), resp.Header)
 
 	return resp, nil
 }
@@ -125,6 +125,8 @@ type ClientFactoryOpts struct {
 	CookieFilePath string
 	// If set, cacheDir persist. Otherwise temp dir will be used for CacheDir
 	Persist *bool
+	// If set, the commits created in the clones of repos are signed.
+	CommitSigning *CommitSigningOpts
 }
 
 // These options are scoped to the repo, not the ClientFactory level. The reason
@@ -186,6 +188,9 @@ func (cfo *ClientFactoryOpts) Apply(target *ClientFactoryOpts) {
 	if cfo.Persist != nil {
 		target.Persist = cfo.Persist
 	}
+	if cfo.CommitSigning != nil {
+		target.CommitSigning = cfo.CommitSigning
+	}
 }
 
 func defaultTempDir() *string {
@@ -241,6 +246,14 @@ func NewClientFactory(opts ...ClientFactoryOpt) (ClientFactory, error) {
 		return nil, err
 	}
 
+	var signer *CommitSigner
+	if o.CommitSigning != nil {
+		// Org names can't start with a dot, so this doesn't clash with the clones.
+		if signer, err = NewCommitSigner(*o.CommitSigning, path.Join(cacheDir, ".signing")); err != nil {
+			return nil, fmt.Errorf("invalid commit signing options: %w", err)
+		}
+	}
+
 	var remote RemoteResolverFactory
 	if o.UseSSH != nil && *o.UseSSH {
 		remote = &sshRemoteResolverFactory{
@@ -267,6 +280,7 @@ func NewClientFactory(opts ...ClientFactoryOpt) (ClientFactory, error) {
 		repoLocks:      map[string]*sync.Mutex{},
 		logger:         logrus.WithField("client", "git"),
 		cookieFilePath: o.CookieFilePath,
+		signer:         signer,
 	}, nil
 }
 
@@ -294,6 +308,8 @@ type clientFactory struct {
 	censor         Censor
 	logger         *logrus.Entry
 	cookieFilePath string
+	// signer, if set, signs the commits created in the secondary clones
+	signer *CommitSigner
 
 	// cacheDir is the root under which cached clones of repos are created
 	cacheDir string
@@ -404,6 +420,12 @@ func (c *clientFactory) ClientForWithRepoOpts(org, repo string, repoOpts RepoOpt
 	}
 	gitMetrics.secondaryCloneDuration.WithLabelValues(org, repo).Observe(time.Since(timeBeforeSecondaryClone).Seconds())
 
+	if c.signer != nil {
+		if err := c.signer.Configure(func(key, value string) error { return repoClient.Config(key, value) }); err != nil {
+			return nil, fmt.Errorf("failed to configure commit signing: %w", err)
+		}
+	}
+
 	return repoClient, nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -60,6 +61,9 @@ type Interactor interface {
 	MergeAndCheckout(baseSHA string, mergeStrategy string, headSHAs ...string) error
 	// Am calls `git am`
 	Am(path string) error
+	// AmWithConflicts calls `git am` and commits the patches that conflict
+	// with their conflict markers, returning the files that had conflicts
+	AmWithConflicts(path string) ([]string, error)
 	// Fetch calls `git fetch arg...`
 	Fetch(arg ...string) error
 	// FetchRef fetches the refspec
@@ -432,6 +436,43 @@ func (i *interactor) Am(path string) error {
 	return errors.New(string(bytes.TrimPrefix(out, []byte("The copy of the patch that failed is found in: .git/rebase-apply/patch"))))
 }
 
+// AmWithConflicts applies the patch like Am, but when patches conflict with
+// the branch, it commits the conflicting files with their conflict markers
+// and goes on with the next patch. It fails like Am if a patch can't be
+// applied at all, for example because the files it changes don't exist.
+func (i *interactor) AmWithConflicts(path string) ([]string, error) {
+	i.logger.Infof("Applying patch at %s, keeping conflicts", path)
+	out, err := i.executor.Run("am", "--3way", path)
+	abort := func() {
+		if abortOut, abortErr := i.executor.Run("am", "--abort"); abortErr != nil {
+			i.logger.WithError(abortErr).Warningf("Aborting patch apply failed with output: %s", string(abortOut))
+		}
+	}
+	conflicts := map[string]bool{}
+	for err != nil {
+		i.logger.WithError(err).Infof("Patch apply failed with output: %s", string(out))
+		unmerged, diffErr := i.executor.Run("diff", "--name-only", "--diff-filter=U")
+		if diffErr != nil || len(bytes.TrimSpace(unmerged)) == 0 {
+			abort()
+			return nil, errors.New(string(bytes.TrimPrefix(out, []byte("The copy of the patch that failed is found in: .git/rebase-apply/patch"))))
+		}
+		for _, file := range strings.Split(strings.TrimSpace(string(unmerged)), "\n") {
+			conflicts[file] = true
+		}
+		if addOut, addErr := i.executor.Run("add", "--all"); addErr != nil {
+			abort()
+			return nil, fmt.Errorf("error staging conflicts: %v. output: %s", addErr, string(addOut))
+		}
+		out, err = i.executor.Run("am", "--continue")
+	}
+	files := make([]string, 0, len(conflicts))
+	for file := range conflicts {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 // FetchCommits only fetches those commits which we want, and only if they are
 // missing.
 func (i *interactor) FetchCommits(commitSHAs []string) error {
@@ -1193,6 +1193,99 @@ func TestInteractor_Am(t *testing.T) {
 	}
 }
 
+func TestInteractor_AmWithConflicts(t *testing.T) {
+	var testCases = []struct {
+		name              string
+		responses         map[string]execResponse
+		expectedCalls     [][]string
+		expectedConflicts []string
+		expectedErr       bool
+	}{
+		{
+			name: "happy case",
+			responses: map[string]execResponse{
+				"am --3way my/changes.patch": {
+					out: []byte(`ok`),
+				},
+			},
+			expectedCalls: [][]string{
+				{"am", "--3way", "my/changes.patch"},
+			},
+			expectedConflicts: []string{},
+		},
+		{
+			name: "conflicts are committed",
+			responses: map[string]execResponse{
+				"am --3way my/changes.patch": {
+					err: errors.New("oops"),
+				},
+				"diff --name-only --diff-filter=U": {
+					out: []byte("b.go\na.go\n"),
+				},
+				"add --all": {
+					out: []byte(`ok`),
+				},
+				"am --continue": {
+					out: []byte(`ok`),
+				},
+			},
+			expectedCalls: [][]string{
+				{"am", "--3way", "my/changes.patch"},
+				{"diff", "--name-only", "--diff-filter=U"},
+				{"add", "--all"},
+				{"am", "--continue"},
+			},
+			expectedConflicts: []string{"a.go", "b.go"},
+		},
+		{
+			name: "patch that doesn't apply without conflicts is aborted",
+			responses: map[string]execResponse{
+				"am --3way my/changes.patch": {
+					err: errors.New("oops"),
+				},
+				"diff --name-only --diff-filter=U": {
+					out: []byte(""),
+				},
+				"am --abort": {
+					out: []byte(`ok`),
+				},
+			},
+			expectedCalls: [][]string{
+				{"am", "--3way", "my/changes.patch"},
+				{"diff", "--name-only", "--diff-filter=U"},
+				{"am", "--abort"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := fakeExecutor{
+				records:   [][]string{},
+				responses: testCase.responses,
+			}
+			i := interactor{
+				executor: &e,
+				logger:   logrus.WithField("test", testCase.name),
+			}
+			conflicts, actualErr := i.AmWithConflicts("my/changes.patch")
+			if testCase.expectedErr && actualErr == nil {
+				t.Errorf("%s: expected an error but got none", testCase.name)
+			}
+			if !testCase.expectedErr && actualErr != nil {
+				t.Errorf("%s: expected no error but got one: %v", testCase.name, actualErr)
+			}
+			if !reflect.DeepEqual(conflicts, testCase.expectedConflicts) {
+				t.Errorf("%s: got incorrect conflicts: %v", testCase.name, diff.ObjectReflectDiff(conflicts, testCase.expectedConflicts))
+			}
+			if actual, expected := e.records, testCase.expectedCalls; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: got incorrect git calls: %v", testCase.name, diff.ObjectReflectDiff(actual, expected))
+			}
+		})
+	}
+}
+
 func TestInteractor_RemoteUpdate(t *testing.T) {
 	var testCases = []struct {
 		name          string
@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SigningFormat is the format of the key commits are signed with, as
+// understood by the gpg.format setting of git.
+type SigningFormat string
+
+const (
+	// SigningFormatSSH signs commits with an SSH key using ssh-keygen.
+	SigningFormatSSH SigningFormat = "ssh"
+	// SigningFormatOpenPGP signs commits with a GPG key using gpg.
+	SigningFormatOpenPGP SigningFormat = "openpgp"
+)
+
+// SigningKeyGetter fetches the private key commits are signed with on-demand,
+// so that rotated keys are picked up.
+type SigningKeyGetter func() []byte
+
+// CommitSigningOpts configures the signing of the commits created in repos.
+type CommitSigningOpts struct {
+	// Format of the key, defaults to ssh.
+	Format SigningFormat
+	// Key returns the unencrypted private key, usually from the secret agent.
+	Key SigningKeyGetter
+	// Committer, if set, is the identity commits are created with. Hosts only
+	// show a signature as verified if the committer matches the owner of the
+	// key, so this is usually the bot account the key is registered to.
+	Committer GitUserGetter
+}
+
+// Validate validates the signing options.
+func (o *CommitSigningOpts) Validate() error {
+	switch o.Format {
+	case "", SigningFormatSSH, SigningFormatOpenPGP:
+	default:
+		return fmt.Errorf("unsupported signing format %q, must be %q or %q", o.Format, SigningFormatSSH, SigningFormatOpenPGP)
+	}
+	if o.Key == nil {
+		return errors.New("a signing key is required")
+	}
+	return nil
+}
+
+// CommitSigner sets up repos so that git signs the commits created in them,
+// be it by commit, am, merge or cherry-pick.
+type CommitSigner struct {
+	opts CommitSigningOpts
+	// dir holds the key material handed to git.
+	dir string
+
+	lock sync.Mutex
+	// digest identifies the key the signingKey was prepared from.
+	digest string
+	// signingKey is the path to the SSH key or the fingerprint of the GPG key.
+	signingKey string
+}
+
+// NewCommitSigner creates a signer storing the key material under dir.
+func NewCommitSigner(opts CommitSigningOpts, dir string) (*CommitSigner, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Format == "" {
+		opts.Format = SigningFormatSSH
+	}
+	return &CommitSigner{opts: opts, dir: dir}, nil
+}
+
+// Configure sets the git config of a repo, through the given function, so that
+// the commits created in it are signed.
+func (s *CommitSigner) Configure(config func(key, value string) error) error {
+	signingKey, err := s.prepare()
+	if err != nil {
+		return err
+	}
+	settings := [][2]string{
+		{"gpg.format", string(s.opts.Format)},
+		{"user.signingkey", signingKey},
+		{"commit.gpgsign", "true"},
+	}
+	if s.opts.Format == SigningFormatOpenPGP {
+		settings = append(settings, [2]string{"gpg.program", s.gpgProgram()})
+	}
+	if s.opts.Committer != nil {
+		name, email, err := s.opts.Committer()
+		if err != nil {
+			return fmt.Errorf("failed to get the committer identity: %w", err)
+		}
+		settings = append(settings, [2]string{"user.name", name}, [2]string{"user.email", email})
+	}
+	for _, setting := range settings {
+		if err := config(setting[0], setting[1]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", setting[0], err)
+		}
+	}
+	return nil
+}
+
+// prepare hands the current key to the signing program, unless that was
+// already done for it, and returns the user.signingkey referring to it.
+func (s *CommitSigner) prepare() (string, error) {
+	key := s.opts.Key()
+	if len(bytes.TrimSpace(key)) == 0 {
+		return "", errors.New("the signing key is empty")
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256(key))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if digest == s.digest {
+		return s.signingKey, nil
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create the signing dir: %w", err)
+	}
+	var signingKey string
+	var err error
+	switch s.opts.Format {
+	case SigningFormatSSH:
+		signingKey, err = s.writeSSHKey(key, digest)
+	case SigningFormatOpenPGP:
+		signingKey, err = s.importGPGKey(key)
+	}
+	if err != nil {
+		return "", err
+	}
+	s.digest, s.signingKey = digest, signingKey
+	return signingKey, nil
+}
+
+// writeSSHKey writes the key to a file of its own, so that repos configured
+// with a previous key keep working while it is rotated.
+func (s *CommitSigner) writeSSHKey(key []byte, digest string) (string, error) {
+	if !bytes.HasSuffix(key, []byte("\n")) {
+		// ssh-keygen refuses keys without a trailing newline.
+		key = append(key, '\n')
+	}
+	path := filepath.Join(s.dir, "ssh-"+digest[:16])
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return "", fmt.Errorf("failed to write the ssh signing key: %w", err)
+	}
+	return path, nil
+}
+
+func (s *CommitSigner) gpgHome() string {
+	return filepath.Join(s.dir, "gnupg")
+}
+
+func (s *CommitSigner) gpgProgram() string {
+	return filepath.Join(s.dir, "gpg")
+}
+
+// importGPGKey imports the key into a keyring of the signer and returns its
+// fingerprint. As git can't pass a keyring to gpg, it calls a wrapper script
+// pointing gpg to it.
+func (s *CommitSigner) importGPGKey(key []byte) (string, error) {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		return "", err
+	}
+	home := s.gpgHome()
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", fmt.Errorf("failed to create the gpg home: %w", err)
+	}
+	cmd := exec.Command(gpg, "--homedir", home, "--batch", "--with-colons", "--import-options", "import-show", "--import")
+	cmd.Stdin = bytes.NewReader(key)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to import the gpg signing key: %w %v", err, string(out))
+	}
+	var fingerprint string
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "fpr" && len(fields) > 9 {
+			fingerprint = fields[9]
+			break
+		}
+	}
+	if fingerprint == "" {
+		return "", errors.New("no key found in the gpg signing key")
+	}
+	script := fmt.Sprintf("#!/bin/sh\nexec '%s' --homedir '%s' \"$@\"\n", shellEscape(gpg), shellEscape(home))
+	if err := os.WriteFile(s.gpgProgram(), []byte(script), 0700); err != nil {
+		return "", fmt.Errorf("failed to write the gpg wrapper: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// shellEscape escapes a string to be put in single quotes.
+func shellEscape(s string) string {
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
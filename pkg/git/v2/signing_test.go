@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func generateSSHKey(t *testing.T, dir string) []byte {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not available")
+	}
+	path := filepath.Join(dir, "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "bot@example.com", "-f", path).CombinedOutput(); err != nil {
+		t.Fatalf("failed to generate ssh key: %v %s", err, out)
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read ssh key: %v", err)
+	}
+	// The secret agent trims the content of secrets.
+	return []byte(strings.TrimSpace(string(key)))
+}
+
+func generateGPGKey(t *testing.T, dir string) []byte {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not available")
+	}
+	home := filepath.Join(dir, "keygen")
+	if err := os.MkdirAll(home, 0700); err != nil {
+		t.Fatalf("failed to create gpg home: %v", err)
+	}
+	gpg := func(args ...string) []byte {
+		out, err := exec.Command("gpg", append([]string{"--homedir", home, "--batch", "--pinentry-mode", "loopback", "--passphrase", ""}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("failed to run gpg %v: %v", args, err)
+		}
+		return out
+	}
+	gpg("--quick-gen-key", "Bot <bot@example.com>", "ed25519", "sign", "never")
+	key := gpg("--armor", "--export-secret-keys", "bot@example.com")
+	exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+	return key
+}
+
+func TestCommitSigner(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format SigningFormat
+		key    func(*testing.T, string) []byte
+	}{
+		{
+			name: "ssh is the default format",
+			key:  generateSSHKey,
+		},
+		{
+			name:   "openpgp",
+			format: SigningFormatOpenPGP,
+			key:    generateGPGKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			key := tc.key(t, dir)
+			signer, err := NewCommitSigner(CommitSigningOpts{
+				Format:    tc.format,
+				Key:       func() []byte { return key },
+				Committer: func() (string, string, error) { return "Bot", "bot@example.com", nil },
+			}, filepath.Join(dir, "signing"))
+			if err != nil {
+				t.Fatalf("failed to create signer: %v", err)
+			}
+			if tc.format == SigningFormatOpenPGP {
+				defer exec.Command("gpgconf", "--homedir", signer.gpgHome(), "--kill", "gpg-agent").Run()
+			}
+
+			repo := filepath.Join(dir, "repo")
+			git := func(args ...string) string {
+				out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+				if err != nil {
+					t.Fatalf("failed to run git %v: %v %s", args, err, out)
+				}
+				return string(out)
+			}
+			if out, err := exec.Command("git", "init", repo).CombinedOutput(); err != nil {
+				t.Fatalf("failed to init repo: %v %s", err, out)
+			}
+			// Configuring twice must reuse the prepared key.
+			for i := 0; i < 2; i++ {
+				if err := signer.Configure(func(key, value string) error {
+					git("config", key, value)
+					return nil
+				}); err != nil {
+					t.Fatalf("failed to configure signing: %v", err)
+				}
+			}
+			git("commit", "--allow-empty", "--message", "signed")
+
+			commit := git("cat-file", "commit", "HEAD")
+			if !strings.Contains(commit, "\ngpgsig ") {
+				t.Errorf("expected the commit to be signed, got:\n%s", commit)
+			}
+			if !strings.Contains(commit, "\ncommitter Bot <bot@example.com>") {
+				t.Errorf("expected the bot to be the committer, got:\n%s", commit)
+			}
+		})
+	}
+}
+
+func TestCommitSigningOptsValidate(t *testing.T) {
+	key := func() []byte { return []byte("key") }
+	testCases := []struct {
+		name        string
+		opts        CommitSigningOpts
+		expectedErr bool
+	}{
+		{
+			name: "ssh key",
+			opts: CommitSigningOpts{Format: SigningFormatSSH, Key: key},
+		},
+		{
+			name: "format defaults to ssh",
+			opts: CommitSigningOpts{Key: key},
+		},
+		{
+			name:        "unknown format",
+			opts:        CommitSigningOpts{Format: "x509", Key: key},
+			expectedErr: true,
+		},
+		{
+			name:        "missing key",
+			opts:        CommitSigningOpts{Format: SigningFormatOpenPGP},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.opts.Validate(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
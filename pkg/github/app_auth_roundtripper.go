@@ -169,6 +169,7 @@ func (arr *appsRoundTripper) addAppInstallationAuth(r *http.Request) *appsAuthEr
 	// Token budgets are set on organization level, so include it in the identifier
 	// to not mess up metrics.
 	r.Header.Set(ghcache.TokenBudgetIdentifierHeader, slug+" - "+org)
+	r.Header.Set(ghcache.OrgIdentifierHeader, org)
 
 	return nil
 }
@@ -155,6 +155,7 @@ type CommitClient interface {
 	GetCombinedStatus(org, repo, ref string) (*CombinedStatus, error)
 	ListCheckRuns(org, repo, ref string) (*CheckRunList, error)
 	GetRef(org, repo, ref string) (string, error)
+	CreateRef(org, repo, ref, SHA string) error
 	DeleteRef(org, repo, ref string) error
 	ListFileCommits(org, repo, path string) ([]RepositoryCommit, error)
 	CreateCheckRun(org, repo string, checkRun CheckRun) (int64, error)
@@ -170,6 +171,11 @@ type RepositoryClient interface {
 	GetBranchProtection(org, repo, branch string) (*BranchProtection, error)
 	RemoveBranchProtection(org, repo, branch string) error
 	UpdateBranchProtection(org, repo, branch string, config BranchProtectionRequest) error
+	ListRepoRulesets(org, repo string) ([]Ruleset, error)
+	GetRepoRuleset(org, repo string, id int) (*Ruleset, error)
+	CreateRepoRuleset(org, repo string, ruleset Ruleset) error
+	UpdateRepoRuleset(org, repo string, id int, ruleset Ruleset) error
+	DeleteRepoRuleset(org, repo string, id int) error
 	AddRepoLabel(org, repo, label, description, color string) error
 	UpdateRepoLabel(org, repo, label, newName, description, color string) error
 	DeleteRepoLabel(org, repo, label string) error
@@ -182,6 +188,7 @@ type RepositoryClient interface {
 	RemoveLabelWithContext(ctx context.Context, org, repo string, number int, label string) error
 	WasLabelAddedByHuman(org, repo string, number int, label string) (bool, error)
 	GetFile(org, repo, filepath, commit string) ([]byte, error)
+	UpdateFile(org, repo, branch, filepath, message string, content []byte) (string, error)
 	GetDirectory(org, repo, dirpath, commit string) ([]DirectoryContent, error)
 	IsCollaborator(org, repo, user string) (bool, error)
 	ListCollaborators(org, repo string) ([]User, error)
@@ -268,12 +275,15 @@ type Client interface {
 	IsAppInstalled(org, repo string) (bool, error)
 	UsesAppAuth() bool
 	ListAppInstallationsForOrg(org string) ([]AppInstallation, error)
+	ListAppHookDeliveries(since time.Time) ([]HookDelivery, error)
+	RedeliverAppHookDelivery(id int64) error
 	GetApp() (*App, error)
 	GetAppWithContext(ctx context.Context) (*App, error)
 	GetFailedActionRunsByHeadBranch(org, repo, branchName, headSHA string) ([]WorkflowRun, error)
 
 	Throttle(hourlyTokens, burst int, org ...string) error
 	QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
+	BatchQueryWithGitHubAppsSupport(ctx context.Context, queries []BatchedQuery, org string) error
 	MutateWithGitHubAppsSupport(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}, org string) error
 
 	SetMax404Retries(int)
@@ -414,11 +424,14 @@ type gqlClient interface {
 
 // ghThrottler sets a ceiling on the rate of GitHub requests.
 // Configure with Client.Throttle().
+// GraphQL requests are additionally paced by budget according to the rate
+// limit GitHub reports for them.
 // It gets reconstructed whenever forUserAgent() is called,
-// whereas its *throttle.Throttler remains.
+// whereas its *throttle.Throttler and budget remain.
 type ghThrottler struct {
-	graph gqlClient
-	http  httpClient
+	graph  gqlClient
+	http   httpClient
+	budget *graphQLBudget
 	*throttle.Throttler
 }
 
@@ -457,6 +470,9 @@ func (t *ghThrottler) QueryWithGitHubAppsSupport(ctx context.Context, q interfac
 	if err := t.Wait(ctx, extractOrgFromContext(ctx)); err != nil {
 		return err
 	}
+	if err := t.budget.wait(ctx, org); err != nil {
+		return err
+	}
 	return t.graph.QueryWithGitHubAppsSupport(ctx, q, vars, org)
 }
 
@@ -464,12 +480,16 @@ func (t *ghThrottler) MutateWithGitHubAppsSupport(ctx context.Context, m interfa
 	if err := t.Wait(ctx, extractOrgFromContext(ctx)); err != nil {
 		return err
 	}
+	if err := t.budget.wait(ctx, org); err != nil {
+		return err
+	}
 	return t.graph.MutateWithGitHubAppsSupport(ctx, m, input, vars, org)
 }
 
 func (t *ghThrottler) forUserAgent(userAgent string) gqlClient {
 	return &ghThrottler{
 		graph:     t.graph.forUserAgent(userAgent),
+		budget:    t.budget,
 		Throttler: t.Throttler,
 	}
 }
@@ -593,6 +613,7 @@ func NewClientFromOptions(fields logrus.Fields, options ClientOptions) (TokenGen
 		Timeout:   options.MaxRequestTime,
 	}
 	graphQLTransport := newAddHeaderTransport(options.BaseRoundTripper)
+	graphQLBudget := newGraphQLBudget(options.AppID != "")
 	c := &client{
 		logger: logrus.WithFields(fields).WithField("client", "github"),
 		gqlc: &graphQLGitHubAppsAuthClientWrapper{Client: githubql.NewEnterpriseClient(
@@ -601,14 +622,14 @@ func NewClientFromOptions(fields logrus.Fields, options ClientOptions) (TokenGen
 				Timeout: options.MaxRequestTime,
 				Transport: &oauth2.Transport{
 					Source: newReloadingTokenSource(options.GetToken),
-					Base:   graphQLTransport,
+					Base:   &graphQLRateLimitTransport{upstream: graphQLTransport, budget: graphQLBudget},
 				},
 			})},
 		delegate: &delegate{
 			time:          &standardTime{},
 			client:        httpClient,
 			bases:         options.Bases,
-			throttle:      ghThrottler{Throttler: &throttle.Throttler{}},
+			throttle:      ghThrottler{Throttler: &throttle.Throttler{}, budget: graphQLBudget},
 			getToken:      options.GetToken,
 			censor:        options.Censor,
 			dry:           options.DryRun,
@@ -813,6 +834,18 @@ type request struct {
 	exitCodes   []int
 }
 
+// RateLimitError is returned when GitHub asks to wait longer than the client
+// is willing to sleep before retrying a request.
+type RateLimitError struct {
+	// RetryAfter is how long GitHub asked to wait before the next request.
+	RetryAfter time.Duration
+	message    string
+}
+
+func (e RateLimitError) Error() string {
+	return e.message
+}
+
 type requestError struct {
 	StatusCode  int
 	ClientError error
@@ -967,7 +1000,7 @@ func (c *client) requestRetryWithContext(ctx context.Context, method, path, acce
 							c.logger.WithField("backoff", sleepTime.String()).WithField("path", path).Debug("Retrying after token budget reset")
 							c.time.Sleep(sleepTime)
 						} else {
-							err = fmt.Errorf("sleep time for token reset exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)
+							err = RateLimitError{RetryAfter: sleepTime, message: fmt.Sprintf("sleep time for token reset exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)}
 							resp.Body.Close()
 							break
 						}
@@ -988,7 +1021,7 @@ func (c *client) requestRetryWithContext(ctx context.Context, method, path, acce
 							c.logger.WithField("backoff", sleepTime.String()).WithField("path", path).Debug("Retrying after abuse ratelimit reset")
 							c.time.Sleep(sleepTime)
 						} else {
-							err = fmt.Errorf("sleep time for abuse rate limit exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)
+							err = RateLimitError{RetryAfter: sleepTime, message: fmt.Sprintf("sleep time for abuse rate limit exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)}
 							resp.Body.Close()
 							break
 						}
@@ -1085,6 +1118,7 @@ func (c *client) doRequest(ctx context.Context, method, path, accept, org string
 	}
 	if org != "" {
 		req = req.WithContext(context.WithValue(req.Context(), githubOrgContextKey, org))
+		req.Header.Set(ghcache.OrgIdentifierHeader, org)
 	}
 	// Disable keep-alive so that we don't get flakes when GitHub closes the
 	// connection prematurely.
@@ -1878,6 +1912,15 @@ func (c *client) readPaginatedResultsWithValues(path string, values url.Values,
 }
 
 func (c *client) readPaginatedResultsWithValuesWithContext(ctx context.Context, path string, values url.Values, accept, org string, newObj func() interface{}, accumulate func(interface{})) error {
+	return c.readPaginatedResultsUntil(ctx, path, values, accept, org, newObj, func(obj interface{}) bool {
+		accumulate(obj)
+		return true
+	})
+}
+
+// readPaginatedResultsUntil reads pages until there are no more or until
+// accumulate returns false.
+func (c *client) readPaginatedResultsUntil(ctx context.Context, path string, values url.Values, accept, org string, newObj func() interface{}, accumulate func(interface{}) bool) error {
 	pagedPath := path
 	if len(values) > 0 {
 		pagedPath += "?" + values.Encode()
@@ -1902,7 +1945,9 @@ func (c *client) readPaginatedResultsWithValuesWithContext(ctx context.Context,
 			return err
 		}
 
-		accumulate(obj)
+		if !accumulate(obj) {
+			break
+		}
 
 		link := parseLinks(resp.Header.Get("Link"))["next"]
 		if link == "" {
@@ -2714,6 +2759,106 @@ func (c *client) UpdateBranchProtection(org, repo, branch string, config BranchP
 	return err
 }
 
+// ListRepoRulesets returns the rulesets of the repo, without the rulesets of
+// its org and without their rules.
+//
+// See https://docs.github.com/en/rest/repos/rules#get-all-repository-rulesets
+func (c *client) ListRepoRulesets(org, repo string) ([]Ruleset, error) {
+	durationLogger := c.log("ListRepoRulesets", org, repo)
+	defer durationLogger()
+
+	var rulesets []Ruleset
+	err := c.readPaginatedResultsWithValues(
+		fmt.Sprintf("/repos/%s/%s/rulesets", org, repo),
+		url.Values{
+			"includes_parents": []string{"false"},
+			"per_page":         []string{"100"},
+		},
+		acceptNone,
+		org,
+		func() interface{} { // newObj
+			return &[]Ruleset{}
+		},
+		func(obj interface{}) {
+			rulesets = append(rulesets, *(obj.(*[]Ruleset))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rulesets, nil
+}
+
+// GetRepoRuleset returns the ruleset of the repo, with its rules.
+//
+// See https://docs.github.com/en/rest/repos/rules#get-a-repository-ruleset
+func (c *client) GetRepoRuleset(org, repo string, id int) (*Ruleset, error) {
+	durationLogger := c.log("GetRepoRuleset", org, repo, id)
+	defer durationLogger()
+
+	var ruleset Ruleset
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/rulesets/%d", org, repo, id),
+		org:       org,
+		exitCodes: []int{200},
+	}, &ruleset)
+	if err != nil {
+		return nil, err
+	}
+	return &ruleset, nil
+}
+
+// CreateRepoRuleset creates a ruleset in the repo.
+//
+// See https://docs.github.com/en/rest/repos/rules#create-a-repository-ruleset
+func (c *client) CreateRepoRuleset(org, repo string, ruleset Ruleset) error {
+	durationLogger := c.log("CreateRepoRuleset", org, repo, ruleset.Name)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/repos/%s/%s/rulesets", org, repo),
+		org:         org,
+		requestBody: ruleset,
+		exitCodes:   []int{201},
+	}, nil)
+	return err
+}
+
+// UpdateRepoRuleset replaces the ruleset of the repo.
+//
+// See https://docs.github.com/en/rest/repos/rules#update-a-repository-ruleset
+func (c *client) UpdateRepoRuleset(org, repo string, id int, ruleset Ruleset) error {
+	durationLogger := c.log("UpdateRepoRuleset", org, repo, id)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:      http.MethodPut,
+		path:        fmt.Sprintf("/repos/%s/%s/rulesets/%d", org, repo, id),
+		org:         org,
+		requestBody: ruleset,
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
+// DeleteRepoRuleset deletes the ruleset of the repo.
+//
+// See https://docs.github.com/en/rest/repos/rules#delete-a-repository-ruleset
+func (c *client) DeleteRepoRuleset(org, repo string, id int) error {
+	durationLogger := c.log("DeleteRepoRuleset", org, repo, id)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:    http.MethodDelete,
+		path:      fmt.Sprintf("/repos/%s/%s/rulesets/%d", org, repo, id),
+		org:       org,
+		exitCodes: []int{204},
+	}, nil)
+	return err
+}
+
 // AddRepoLabel adds a defined label given org/repo
 //
 // See https://developer.github.com/v3/issues/labels/#create-a-label
@@ -3384,6 +3529,26 @@ type GetRefResult struct {
 	} `json:"object,omitempty"`
 }
 
+// CreateRef creates the given ref, such as "heads/branch", pointing at the SHA.
+//
+// See https://docs.github.com/en/rest/git/refs#create-a-reference
+func (c *client) CreateRef(org, repo, ref, SHA string) error {
+	durationLogger := c.log("CreateRef", org, repo, ref, SHA)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/repos/%s/%s/git/refs", org, repo),
+		org:    org,
+		requestBody: map[string]string{
+			"ref": "refs/" + ref,
+			"sha": SHA,
+		},
+		exitCodes: []int{201},
+	}, nil)
+	return err
+}
+
 // DeleteRef deletes the given ref
 //
 // See https://developer.github.com/v3/git/refs/#delete-a-reference
@@ -3539,6 +3704,58 @@ func (c *client) GetFile(org, repo, filepath, commit string) ([]byte, error) {
 	return decoded, nil
 }
 
+// UpdateFile commits the content of the file to the branch, creating the
+// file if it doesn't exist on the branch yet, and returns the SHA of the
+// commit.
+//
+// See https://docs.github.com/en/rest/repos/contents#create-or-update-file-contents
+func (c *client) UpdateFile(org, repo, branch, filepath, message string, content []byte) (string, error) {
+	durationLogger := c.log("UpdateFile", org, repo, branch, filepath)
+	defer durationLogger()
+
+	path := fmt.Sprintf("/repos/%s/%s/contents/%s", org, repo, filepath)
+	// Updating a file takes the SHA of the blob it replaces.
+	var current Content
+	code, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("%s?ref=%s", path, url.QueryEscape(branch)),
+		org:       org,
+		exitCodes: []int{200, 404},
+	}, &current)
+	if err != nil {
+		return "", err
+	}
+	data := struct {
+		Message string `json:"message"`
+		Content string `json:"content"`
+		Branch  string `json:"branch"`
+		SHA     string `json:"sha,omitempty"`
+	}{
+		Message: message,
+		Content: base64.StdEncoding.EncodeToString(content),
+		Branch:  branch,
+	}
+	if code == 200 {
+		data.SHA = current.SHA
+	}
+	var res struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	_, err = c.request(&request{
+		method:      http.MethodPut,
+		path:        path,
+		org:         org,
+		requestBody: &data,
+		exitCodes:   []int{200, 201},
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Commit.SHA, nil
+}
+
 // QueryWithGitHubAppsSupport runs a GraphQL query using shurcooL/githubql's client.
 func (c *client) QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error {
 	// Don't log query here because Query is typically called multiple times to get all pages.
@@ -4787,6 +5004,56 @@ func (c *client) ListAppInstallationsForOrg(org string) ([]AppInstallation, erro
 	return ais, nil
 }
 
+// ListAppHookDeliveries lists the webhook deliveries of the GitHub App that
+// were delivered since the given time, latest first. Will not work with a
+// Personal Access Token.
+//
+// See https://docs.github.com/en/rest/apps/webhooks#list-deliveries-for-an-app-webhook
+func (c *client) ListAppHookDeliveries(since time.Time) ([]HookDelivery, error) {
+	durationLogger := c.log("ListAppHookDeliveries", since)
+	defer durationLogger()
+
+	var deliveries []HookDelivery
+	if err := c.readPaginatedResultsUntil(
+		context.Background(),
+		"/app/hook/deliveries",
+		url.Values{"per_page": []string{"100"}},
+		acceptNone,
+		"",
+		func() interface{} {
+			return &[]HookDelivery{}
+		},
+		func(obj interface{}) bool {
+			for _, delivery := range *(obj.(*[]HookDelivery)) {
+				if delivery.DeliveredAt.Before(since) {
+					return false
+				}
+				deliveries = append(deliveries, delivery)
+			}
+			return true
+		},
+	); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RedeliverAppHookDelivery redelivers a webhook delivery of the GitHub App.
+// Will not work with a Personal Access Token.
+//
+// See https://docs.github.com/en/rest/apps/webhooks#redeliver-a-delivery-for-an-app-webhook
+func (c *client) RedeliverAppHookDelivery(id int64) error {
+	durationLogger := c.log("RedeliverAppHookDelivery", id)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:    http.MethodPost,
+		path:      fmt.Sprintf("/app/hook/deliveries/%d/attempts", id),
+		exitCodes: []int{202},
+	}, nil)
+	return err
+}
+
 func (c *client) getAppInstallationToken(installationId int64) (*AppInstallationToken, error) {
 	durationLogger := c.log("AppInstallationToken")
 	defer durationLogger()
@@ -132,6 +132,28 @@ func TestAbuseRateLimit(t *testing.T) {
 	}
 }
 
+func TestAbuseRateLimitExceedsMaxSleepTime(t *testing.T) {
+	tc := &testTime{now: time.Now()}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	c.time = tc
+	_, err := c.requestRetry(http.MethodGet, "/", "", "", nil)
+	var rateLimitErr RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected a RateLimitError, got %v", err)
+	}
+	if rateLimitErr.RetryAfter != 3601*time.Second {
+		t.Errorf("Expected to be asked to retry after %v, got %v", 3601*time.Second, rateLimitErr.RetryAfter)
+	}
+	if tc.slept != 0 {
+		t.Errorf("Expected not to sleep, slept for %v", tc.slept)
+	}
+}
+
 func TestRetry404(t *testing.T) {
 	tc := &testTime{now: time.Now()}
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -541,6 +563,34 @@ func TestDeleteRef(t *testing.T) {
 	}
 }
 
+func TestCreateRef(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/git/refs" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		}
+		if expected := map[string]string{"ref": "refs/heads/my-feature", "sha": "abcdef"}; !reflect.DeepEqual(body, expected) {
+			t.Errorf("Expected request %v, got %v", expected, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.CreateRef("k8s", "kuber", "heads/my-feature", "abcdef"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestListFileCommits(t *testing.T) {
 	githubResponse := []byte(`
 [
@@ -1817,6 +1867,72 @@ func TestGetFile(t *testing.T) {
 	}
 }
 
+func TestUpdateFile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		exists      bool
+		expectedSHA string
+	}{
+		{
+			name:        "existing file",
+			exists:      true,
+			expectedSHA: "blob",
+		},
+		{
+			name: "new file",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/k8s/kuber/contents/foo/bar.txt" {
+					t.Errorf("Bad request path: %s", r.URL.Path)
+				}
+				switch r.Method {
+				case http.MethodGet:
+					if r.URL.RawQuery != "ref=my-feature" {
+						t.Errorf("Bad request query: %s", r.URL.RawQuery)
+					}
+					if !tc.exists {
+						w.WriteHeader(http.StatusNotFound)
+						fmt.Fprint(w, `{"message": "Not Found"}`)
+						return
+					}
+					fmt.Fprint(w, `{"sha": "blob"}`)
+				case http.MethodPut:
+					var body struct {
+						Message string `json:"message"`
+						Content string `json:"content"`
+						Branch  string `json:"branch"`
+						SHA     string `json:"sha"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Errorf("Could not unmarshal request: %v", err)
+					}
+					if body.Message != "Update bar" || body.Branch != "my-feature" || body.SHA != tc.expectedSHA {
+						t.Errorf("Bad request: %+v", body)
+					}
+					if content, _ := base64.StdEncoding.DecodeString(body.Content); string(content) != "abcde" {
+						t.Errorf("Wrong content -- expect: abcde, got: %s", string(content))
+					}
+					fmt.Fprint(w, `{"commit": {"sha": "commit"}}`)
+				default:
+					t.Errorf("Bad method: %s", r.Method)
+				}
+			}))
+			defer ts.Close()
+			c := getClient(ts.URL)
+			sha, err := c.UpdateFile("k8s", "kuber", "my-feature", "foo/bar.txt", "Update bar", []byte("abcde"))
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			if sha != "commit" {
+				t.Errorf("Expected commit SHA commit, got %s", sha)
+			}
+		})
+	}
+}
+
 func TestGetFileRef(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -2308,6 +2424,59 @@ func TestGetBranchProtection404BranchNotProtected(t *testing.T) {
 	}
 }
 
+func TestListRepoRulesets(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/org/repo/rulesets" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if includesParents := r.URL.Query().Get("includes_parents"); includesParents != "false" {
+			t.Errorf("Expected includes_parents=false, got %q", includesParents)
+		}
+		fmt.Fprint(w, `[{"id": 7, "name": "prow branch master", "target": "branch", "source_type": "Repository", "enforcement": "active"}]`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	rulesets, err := c.ListRepoRulesets("org", "repo")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []Ruleset{{ID: 7, Name: "prow branch master", Target: "branch", SourceType: "Repository", Enforcement: "active"}}
+	if diff := cmp.Diff(expected, rulesets); diff != "" {
+		t.Errorf("Unexpected rulesets (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateRepoRuleset(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/org/repo/rulesets/7" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ruleset Ruleset
+		if err := json.Unmarshal(b, &ruleset); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		}
+		if len(ruleset.Rules) != 1 || ruleset.Rules[0].Type != "deletion" {
+			t.Errorf("Unexpected rules: %v", ruleset.Rules)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.UpdateRepoRuleset("org", "repo", 7, Ruleset{Name: "prow branch master", Rules: []RulesetRule{{Type: "deletion"}}}); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 // GetBranchProtection should fail on any 404 which is NOT due to
 // branch not being protected.
 func TestGetBranchProtectionFailsOnOther404(t *testing.T) {
@@ -3400,8 +3569,14 @@ func TestThrottlerRespectsContexts(t *testing.T) {
 		},
 		// Insert our custom ctx for any arg of type context.Context
 		func(typeName string) interface{} {
-			if typeName == "context.Context" {
+			switch typeName {
+			case "context.Context":
 				return ctx
+			case "[]github.BatchedQuery":
+				// An empty batch doesn't send any request.
+				return []BatchedQuery{{Query: &struct {
+					Viewer struct{ Login githubv4.String }
+				}{}}}
 			}
 			return nil
 		},
@@ -3536,3 +3711,64 @@ func TestIsAppInstalled(t *testing.T) {
 		})
 	}
 }
+
+func TestListAppHookDeliveries(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var requestedOlderPage bool
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		var deliveries []HookDelivery
+		switch r.URL.Path {
+		case "/app/hook/deliveries":
+			deliveries = []HookDelivery{{ID: 2, DeliveredAt: since.Add(time.Minute)}, {ID: 1, DeliveredAt: since}}
+			w.Header().Set("Link", fmt.Sprintf(`<https://%s/app/hook/deliveries?cursor=1>; rel="next"`, r.Host))
+			if r.URL.Query().Has("cursor") {
+				requestedOlderPage = true
+				deliveries = []HookDelivery{{ID: 0, DeliveredAt: since.Add(-time.Minute)}}
+				w.Header().Set("Link", fmt.Sprintf(`<https://%s/app/hook/deliveries?cursor=2>; rel="next"`, r.Host))
+			}
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := json.Marshal(deliveries)
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	deliveries, err := c.ListAppHookDeliveries(since)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	var ids []int64
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	if diff := cmp.Diff([]int64{2, 1}, ids); diff != "" {
+		t.Errorf("unexpected deliveries (-want +got):\n%s", diff)
+	}
+	if !requestedOlderPage {
+		t.Error("expected the second page to be requested")
+	}
+}
+
+func TestRedeliverAppHookDelivery(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/app/hook/deliveries/12/attempts" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.RedeliverAppHookDelivery(12); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
@@ -43,10 +43,13 @@ const (
 
 // FakeClient is like client, but fake.
 type FakeClient struct {
-	Issues                     map[int]*github.Issue
-	IssueID                    int
-	OrgMembers                 map[string][]string
-	Collaborators              []string
+	Issues        map[int]*github.Issue
+	IssueID       int
+	OrgMembers    map[string][]string
+	Collaborators []string
+	// Permissions are the permissions of users on all repos, by login.
+	// Collaborators without one have the write permission.
+	Permissions                map[string]string
 	IssueComments              map[int][]github.IssueComment
 	IssueCommentID             int
 	PullRequests               map[int]*github.PullRequest
@@ -58,6 +61,8 @@ type FakeClient struct {
 	Reviews                    map[int][]github.Review
 	CombinedStatuses           map[string]*github.CombinedStatus
 	CreatedStatuses            map[string][]github.Status
+	CheckRuns                  map[string][]github.CheckRun
+	CheckRunID                 int64
 	IssueEvents                map[int][]github.ListedIssueEvent
 	Commits                    map[string]github.RepositoryCommit
 
@@ -101,6 +106,9 @@ type FakeClient struct {
 	// and values map SHA to directory content
 	RemoteDirectories map[string]map[string][]github.DirectoryContent
 
+	// A list of refs that got created via CreateRef
+	RefsCreated []struct{ Org, Repo, Ref, SHA string }
+
 	// A list of refs that got deleted via DeleteRef
 	RefsDeleted []struct{ Org, Repo, Ref string }
 
@@ -127,6 +135,9 @@ type FakeClient struct {
 	// Maps repo name to the list of hooks
 	RepoHooks map[string][]github.Hook
 
+	// Maps org/repo=branch to the protection of the branch
+	BranchProtections map[string]*github.BranchProtection
+
 	// A map of invitation id to user repository invitations
 	UserRepoInvitations map[int]github.UserRepoInvitation
 	// A map of organization invitations by name
@@ -489,6 +500,14 @@ func (f *FakeClient) GetRef(owner, repo, ref string) (string, error) {
 	return TestRef, nil
 }
 
+// CreateRef records the ref as created
+func (f *FakeClient) CreateRef(owner, repo, ref, SHA string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.RefsCreated = append(f.RefsCreated, struct{ Org, Repo, Ref, SHA string }{Org: owner, Repo: repo, Ref: ref, SHA: SHA})
+	return nil
+}
+
 // DeleteRef returns an error indicating if deletion of the given ref was successful
 func (f *FakeClient) DeleteRef(owner, repo, ref string) error {
 	f.lock.Lock()
@@ -504,6 +523,53 @@ func (f *FakeClient) GetSingleCommit(org, repo, SHA string) (github.RepositoryCo
 	return f.Commits[SHA], nil
 }
 
+// ListCheckRuns returns the check runs of a commit.
+func (f *FakeClient) ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if f.Error != nil {
+		return nil, f.Error
+	}
+	checkRuns := append([]github.CheckRun(nil), f.CheckRuns[ref]...)
+	return &github.CheckRunList{Total: len(checkRuns), CheckRuns: checkRuns}, nil
+}
+
+// CreateCheckRun adds a check run to the commit of its HeadSHA.
+func (f *FakeClient) CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Error != nil {
+		return 0, f.Error
+	}
+	if f.CheckRuns == nil {
+		f.CheckRuns = make(map[string][]github.CheckRun)
+	}
+	f.CheckRunID++
+	checkRun.ID = f.CheckRunID
+	f.CheckRuns[checkRun.HeadSHA] = append(f.CheckRuns[checkRun.HeadSHA], checkRun)
+	return checkRun.ID, nil
+}
+
+// UpdateCheckRun replaces the check run with the ID, keeping its HeadSHA.
+func (f *FakeClient) UpdateCheckRun(org, repo string, checkRunId int64, checkRun github.CheckRun) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Error != nil {
+		return f.Error
+	}
+	for sha, checkRuns := range f.CheckRuns {
+		for i := range checkRuns {
+			if checkRuns[i].ID == checkRunId {
+				checkRun.ID = checkRunId
+				checkRun.HeadSHA = sha
+				checkRuns[i] = checkRun
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("check run %d not found", checkRunId)
+}
+
 // CreateStatus adds a status context to a commit.
 func (f *FakeClient) CreateStatus(owner, repo, SHA string, s github.Status) error {
 	return f.CreateStatusWithContext(context.Background(), owner, repo, SHA, s)
@@ -712,6 +778,20 @@ func (f *FakeClient) GetFile(org, repo, file, commit string) ([]byte, error) {
 	return nil, fmt.Errorf("could not find file %s with ref %s", file, commit)
 }
 
+// UpdateFile stores the content of the file at the branch in RemoteFiles.
+func (f *FakeClient) UpdateFile(org, repo, branch, file, message string, content []byte) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.RemoteFiles == nil {
+		f.RemoteFiles = map[string]map[string]string{}
+	}
+	if f.RemoteFiles[file] == nil {
+		f.RemoteFiles[file] = map[string]string{}
+	}
+	f.RemoteFiles[file][branch] = string(content)
+	return TestRef, nil
+}
+
 // ListTeams return a list of fake teams that correspond to the fake team members returned by ListTeamMembers
 func (f *FakeClient) ListTeams(org string) ([]github.Team, error) {
 	f.lock.RLock()
@@ -788,6 +868,20 @@ func (f *FakeClient) IsCollaborator(org, repo, login string) (bool, error) {
 	return false, nil
 }
 
+// GetUserPermission returns the permission of the user on the repo.
+func (f *FakeClient) GetUserPermission(org, repo, login string) (string, error) {
+	f.lock.RLock()
+	permission, ok := f.Permissions[login]
+	f.lock.RUnlock()
+	if ok {
+		return permission, nil
+	}
+	if collaborator, _ := f.IsCollaborator(org, repo, login); collaborator {
+		return string(github.Write), nil
+	}
+	return string(github.None), nil
+}
+
 // ListCollaborators lists the collaborators.
 func (f *FakeClient) ListCollaborators(org, repo string) ([]github.User, error) {
 	f.lock.RLock()
@@ -975,6 +1069,14 @@ func (f *FakeClient) GetRepos(org string, isUser bool) ([]github.Repo, error) {
 	}, nil
 }
 
+// GetBranchProtection returns the protection of the branch, or nil if the
+// branch is not protected.
+func (f *FakeClient) GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.BranchProtections[fmt.Sprintf("%s/%s=%s", org, repo, branch)], nil
+}
+
 func (f *FakeClient) GetRepo(owner, name string) (github.FullRepo, error) {
 	if f.GetRepoError != nil {
 		return github.FullRepo{}, f.GetRepoError
@@ -1072,10 +1174,15 @@ func (f *FakeClient) CreatePullRequest(org, repo, title, body, head, base string
 		}
 		f.PullRequests[i] = &github.PullRequest{
 			Number: i,
+			Title:  title,
+			Body:   body,
 			Base: github.PullRequestBranch{
 				Ref:  base,
 				Repo: github.Repo{Owner: github.User{Login: org}, Name: repo},
 			},
+			Head: github.PullRequestBranch{
+				Ref: head,
+			},
 		}
 		f.Issues[i] = &github.Issue{Number: i}
 		return i, nil
@@ -69,6 +69,27 @@ var ghRequestWaitDurationHistVec = prometheus.NewHistogramVec(
 	[]string{"token_hash", "request_type", "api"},
 )
 
+// ghRequestsByOrgCounterVec provides the 'github_requests_by_org' counter that
+// attributes GitHub requests to the org they are made for.
+var ghRequestsByOrgCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "github_requests_by_org",
+		Help: "GitHub requests by org and API path.",
+	},
+	[]string{"org", "token_hash", "path", "status"},
+)
+
+// ghTokenUsageByOrgGaugeVec provides the 'github_token_usage_by_org' gauge
+// that keeps track of the remaining rate limit of the tokens used for an org.
+// With GitHub Apps, every org has its own installation token and rate limit.
+var ghTokenUsageByOrgGaugeVec = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "github_token_usage_by_org",
+		Help: "How many GitHub token requests are remaining for the current hour, by org.",
+	},
+	[]string{"org", "token_hash", "ratelimit_resource"},
+)
+
 // cacheCounter provides the 'ghcache_responses' counter vec that is indexed
 // by the cache response mode.
 var cacheCounter = prometheus.NewCounterVec(
@@ -112,6 +133,8 @@ func init() {
 	prometheus.MustRegister(cacheCounter)
 	prometheus.MustRegister(timeoutDuration)
 	prometheus.MustRegister(cacheEntryAge)
+	prometheus.MustRegister(ghRequestsByOrgCounterVec)
+	prometheus.MustRegister(ghTokenUsageByOrgGaugeVec)
 }
 
 // CollectGitHubTokenMetrics publishes the rate limits of the github api to
@@ -156,6 +179,28 @@ func CollectGitHubRequestMetrics(tokenHash, path, statusCode, userAgent string,
 	ghRequestDurationHistVec.With(prometheus.Labels{"token_hash": tokenHash, "path": simplifier.Simplify(path), "status": statusCode, "user_agent": userAgentWithoutVersion(userAgent)}).Observe(roundTripTime)
 }
 
+// CollectGitHubOrgMetrics publishes the number of requests made for an org by
+// API path to `github_requests_by_org`, and the remaining rate limit of the
+// token used for them to `github_token_usage_by_org` on prometheus.
+func CollectGitHubOrgMetrics(org, tokenHash, path, statusCode string, headers http.Header) {
+	ghRequestsByOrgCounterVec.With(prometheus.Labels{"org": org, "token_hash": tokenHash, "path": simplifier.Simplify(path), "status": statusCode}).Inc()
+	remaining, err := strconv.ParseFloat(headers.Get("X-RateLimit-Remaining"), 64)
+	if err != nil || org == "" {
+		return
+	}
+	ghTokenUsageByOrgGaugeVec.With(prometheus.Labels{"org": org, "token_hash": tokenHash, "ratelimit_resource": headers.Get("X-RateLimit-Resource")}).Set(remaining)
+}
+
+// OrgFromPath returns the org of repository and org API paths like
+// /repos/org/repo/pulls, or an empty string for other paths.
+func OrgFromPath(path string) string {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "/"), "api/v3/"), "/")
+	if len(parts) < 2 || (parts[0] != "repos" && parts[0] != "orgs") {
+		return ""
+	}
+	return parts[1]
+}
+
 // timestampStringToTime takes a unix timestamp and returns a `time.Time`
 // from the given time.
 func timestampStringToTime(tstamp string) time.Time {
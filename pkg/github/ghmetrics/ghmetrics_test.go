@@ -0,0 +1,41 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghmetrics
+
+import "testing"
+
+func TestOrgFromPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/repos/kubernetes/test-infra/pulls/1", expected: "kubernetes"},
+		{path: "/orgs/kubernetes-sigs/teams", expected: "kubernetes-sigs"},
+		{path: "/api/v3/repos/enterprise/repo/issues", expected: "enterprise"},
+		{path: "repos/kubernetes/test-infra", expected: "kubernetes"},
+		{path: "/repos", expected: ""},
+		{path: "/user/orgs", expected: ""},
+		{path: "/graphql", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if org := OrgFromPath(tc.path); org != tc.expected {
+				t.Errorf("expected org %q, got %q", tc.expected, org)
+			}
+		})
+	}
+}
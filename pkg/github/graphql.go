@@ -0,0 +1,421 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxGraphQLBatchSize is the number of queries merged into a single
+	// GraphQL request by BatchQueryWithGitHubAppsSupport.
+	maxGraphQLBatchSize = 10
+	// graphQLPaceThreshold is the fraction of the GraphQL rate limit below
+	// which queries are spread over the time left until the limit resets.
+	graphQLPaceThreshold = 0.5
+)
+
+// BatchedQuery is one of the GraphQL queries sent together by
+// BatchQueryWithGitHubAppsSupport. Query and Vars are what would be passed to
+// QueryWithGitHubAppsSupport.
+type BatchedQuery struct {
+	Query interface{}
+	Vars  map[string]interface{}
+}
+
+// BatchQueryWithGitHubAppsSupport runs GraphQL queries for the same org,
+// merging the compatible ones into as few requests as possible. Queries are
+// compatible when all their variables are used by the fields of the top level
+// of the query struct; the others are sent on their own. Each query is filled
+// in as if it had been run by QueryWithGitHubAppsSupport. The returned error
+// aggregates the errors of all the requests, queries of failed requests may
+// be partially filled in.
+func (c *client) BatchQueryWithGitHubAppsSupport(ctx context.Context, queries []BatchedQuery, org string) error {
+	durationLogger := c.log("BatchQueryWithGitHubAppsSupport", len(queries), org)
+	defer durationLogger()
+
+	var batchable, single []BatchedQuery
+	for _, q := range queries {
+		if isBatchable(q.Query) {
+			batchable = append(batchable, q)
+		} else {
+			single = append(single, q)
+		}
+	}
+
+	var errs []error
+	for _, q := range single {
+		if err := c.gqlc.QueryWithGitHubAppsSupport(ctx, q.Query, q.Vars, org); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for len(batchable) > 0 {
+		n := len(batchable)
+		if n > maxGraphQLBatchSize {
+			n = maxGraphQLBatchSize
+		}
+		batch := batchable[:n]
+		batchable = batchable[n:]
+		if len(batch) == 1 {
+			if err := c.gqlc.QueryWithGitHubAppsSupport(ctx, batch[0].Query, batch[0].Vars, org); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		merged, vars := mergeQueries(batch)
+		// GraphQL responses with errors may still carry data for the queries
+		// that succeeded, so split the response whatever the error.
+		err := c.gqlc.QueryWithGitHubAppsSupport(ctx, merged.Interface(), vars, org)
+		splitQueries(merged, batch)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var graphQLVariableRe = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// isBatchable returns whether the query is a pointer to a struct whose
+// fields can be aliased and whose variables are only used at its top level.
+func isBatchable(q interface{}) bool {
+	v := reflect.ValueOf(q)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	t := v.Elem().Type()
+	if t.NumField() == 0 {
+		return false
+	}
+	visited := map[reflect.Type]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || !f.IsExported() || strings.HasPrefix(strings.TrimSpace(f.Tag.Get("graphql")), "...") {
+			return false
+		}
+		if usesVariables(f.Type, visited) {
+			return false
+		}
+	}
+	return true
+}
+
+// usesVariables returns whether the graphql tags of the fields of the type
+// reference variables.
+func usesVariables(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if strings.Contains(f.Tag.Get("graphql"), "$") || usesVariables(f.Type, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeQueries returns a pointer to a struct with the fields of all the
+// queries, aliased and with their variables renamed so they don't collide,
+// along with the variables of the merged query.
+func mergeQueries(queries []BatchedQuery) (reflect.Value, map[string]interface{}) {
+	var fields []reflect.StructField
+	vars := map[string]interface{}{}
+	for i, q := range queries {
+		prefix := fmt.Sprintf("q%d_", i)
+		for name, value := range q.Vars {
+			vars[prefix+name] = value
+		}
+		t := reflect.TypeOf(q.Query).Elem()
+		for j := 0; j < t.NumField(); j++ {
+			f := t.Field(j)
+			field := strings.TrimSpace(f.Tag.Get("graphql"))
+			if field == "" {
+				r, size := utf8.DecodeRuneInString(f.Name)
+				field = string(unicode.ToLower(r)) + f.Name[size:]
+			}
+			// Replace the alias of the field if it already has one.
+			head := field
+			if k := strings.Index(head, "("); k != -1 {
+				head = head[:k]
+			}
+			alias := head
+			if k := strings.Index(head, ":"); k != -1 {
+				alias = strings.TrimSpace(head[:k])
+				field = strings.TrimSpace(field[k+1:])
+			}
+			field = graphQLVariableRe.ReplaceAllStringFunc(field, func(v string) string {
+				if _, ok := q.Vars[v[1:]]; !ok {
+					return v
+				}
+				return "$" + prefix + v[1:]
+			})
+			fields = append(fields, reflect.StructField{
+				Name: fmt.Sprintf("Q%d%s", i, f.Name),
+				Type: f.Type,
+				Tag:  reflect.StructTag(fmt.Sprintf(`graphql:%q`, fmt.Sprintf("%s%s: %s", prefix, strings.TrimSpace(alias), field))),
+			})
+		}
+	}
+	return reflect.New(reflect.StructOf(fields)), vars
+}
+
+// splitQueries copies the fields of the merged query back into the queries
+// it was merged from.
+func splitQueries(merged reflect.Value, queries []BatchedQuery) {
+	m := merged.Elem()
+	k := 0
+	for _, q := range queries {
+		v := reflect.ValueOf(q.Query).Elem()
+		for j := 0; j < v.NumField(); j++ {
+			v.Field(j).Set(m.Field(k))
+			k++
+		}
+	}
+}
+
+// graphQLRateLimit is what is known of a GraphQL rate limit.
+type graphQLRateLimit struct {
+	limit     int
+	remaining int
+	resetAt   time.Time
+	// retryAt is set when GitHub asked us to back off, e.g. when hitting a
+	// secondary rate limit.
+	retryAt time.Time
+	// cost is a moving average of the costs reported by queries.
+	cost float64
+	// next is the earliest time the next query may be sent at when queries
+	// are paced.
+	next time.Time
+}
+
+// graphQLBudget tracks the GraphQL rate limits reported by GitHub and throttles
+// queries to spend them evenly instead of running out and sleeping. Rate
+// limits are tracked per org with GitHub apps auth, as every installation has
+// its own.
+type graphQLBudget struct {
+	perOrg bool
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+
+	lock   sync.Mutex
+	limits map[string]*graphQLRateLimit
+}
+
+func newGraphQLBudget(perOrg bool) *graphQLBudget {
+	return &graphQLBudget{
+		perOrg: perOrg,
+		now:    time.Now,
+		sleep:  sleepWithContext,
+		limits: map[string]*graphQLRateLimit{},
+	}
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (b *graphQLBudget) key(org string) string {
+	if b.perOrg {
+		return org
+	}
+	return ""
+}
+
+// delay returns how long to wait before sending a query for the org, and
+// reserves the expected cost of the query.
+func (b *graphQLBudget) delay(org string) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	rl, ok := b.limits[b.key(org)]
+	if !ok {
+		return 0
+	}
+	now := b.now()
+	if now.Before(rl.retryAt) {
+		return rl.retryAt.Sub(now)
+	}
+	if !now.Before(rl.resetAt) {
+		return 0
+	}
+	cost := math.Max(rl.cost, 1)
+	var until time.Time
+	switch {
+	case float64(rl.remaining) < cost:
+		until = rl.resetAt
+	case rl.limit > 0 && float64(rl.remaining) < graphQLPaceThreshold*float64(rl.limit):
+		// Spread the remaining budget over the time left until the reset.
+		interval := time.Duration(float64(rl.resetAt.Sub(now)) * cost / float64(rl.remaining))
+		until = rl.next
+		if until.Before(now) {
+			until = now
+		}
+		rl.next = until.Add(interval)
+	}
+	rl.remaining -= int(math.Ceil(cost))
+	if until.After(now) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+// wait blocks until a query for the org may be sent.
+func (b *graphQLBudget) wait(ctx context.Context, org string) error {
+	if b == nil {
+		return nil
+	}
+	d := b.delay(org)
+	if d <= 0 {
+		return nil
+	}
+	logrus.WithFields(logrus.Fields{"client": "github", "org": org, "delay": d.String()}).Debug("Throttling GraphQL query to stay within the rate limit.")
+	return b.sleep(ctx, d)
+}
+
+// graphQLResponseRateLimit is the rateLimit object of a GraphQL response, set
+// when the query asked for it.
+type graphQLResponseRateLimit struct {
+	Cost      *int       `json:"cost"`
+	Limit     *int       `json:"limit"`
+	Remaining *int       `json:"remaining"`
+	ResetAt   *time.Time `json:"resetAt"`
+}
+
+// observe records the rate limit reported by a GraphQL response.
+func (b *graphQLBudget) observe(org string, resp *http.Response, reported *graphQLResponseRateLimit) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	key := b.key(org)
+	rl, ok := b.limits[key]
+	if !ok {
+		rl = &graphQLRateLimit{}
+		b.limits[key] = rl
+	}
+	now := b.now()
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		rl.remaining = remaining
+	} else if reported != nil && reported.Remaining != nil {
+		rl.remaining = *reported.Remaining
+	}
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		rl.limit = limit
+	} else if reported != nil && reported.Limit != nil {
+		rl.limit = *reported.Limit
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.resetAt = time.Unix(reset, 0)
+	} else if reported != nil && reported.ResetAt != nil {
+		rl.resetAt = *reported.ResetAt
+	}
+	if reported != nil && reported.Cost != nil {
+		if rl.cost == 0 {
+			rl.cost = float64(*reported.Cost)
+		} else {
+			rl.cost = 0.8*rl.cost + 0.2*float64(*reported.Cost)
+		}
+	}
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && retryAfter > 0 {
+			rl.retryAt = now.Add(time.Duration(retryAfter) * time.Second)
+		} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			rl.retryAt = rl.resetAt
+		} else {
+			// Secondary rate limits without a Retry-After ask to wait at
+			// least a minute.
+			rl.retryAt = now.Add(time.Minute)
+		}
+	}
+}
+
+// graphQLRateLimitTransport reports the rate limits of GraphQL responses to
+// a graphQLBudget.
+type graphQLRateLimitTransport struct {
+	upstream http.RoundTripper
+	budget   *graphQLBudget
+}
+
+var _ http.RoundTripper = &graphQLRateLimitTransport{}
+
+func (t *graphQLRateLimitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.upstream.RoundTrip(r)
+	if err != nil {
+		return resp, err
+	}
+	var reported *graphQLResponseRateLimit
+	if resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		reported = reportedRateLimit(body)
+	}
+	t.budget.observe(extractOrgFromContext(r.Context()), resp, reported)
+	return resp, nil
+}
+
+// reportedRateLimit returns the rateLimit object of a GraphQL response, also
+// when it was aliased by BatchQueryWithGitHubAppsSupport.
+func reportedRateLimit(body []byte) *graphQLResponseRateLimit {
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	for key, raw := range response.Data {
+		if key != "rateLimit" && !strings.HasSuffix(key, "_rateLimit") {
+			continue
+		}
+		var rl graphQLResponseRateLimit
+		if err := json.Unmarshal(raw, &rl); err == nil {
+			return &rl
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+type testSearchQuery struct {
+	RateLimit struct {
+		Cost githubv4.Int
+	}
+	Search struct {
+		IssueCount githubv4.Int
+	} `graphql:"search(type: ISSUE, first: 1, query: $query)"`
+}
+
+type testIssueQuery struct {
+	Repository struct {
+		Issue struct {
+			Number githubv4.Int
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+func TestBatchQueryWithGitHubAppsSupport(t *testing.T) {
+	testCases := []struct {
+		name             string
+		searches         []string
+		issues           []int
+		expectedRequests int
+	}{
+		{
+			name:             "single search",
+			searches:         []string{"a"},
+			expectedRequests: 1,
+		},
+		{
+			name:             "searches are merged",
+			searches:         []string{"a", "bb", "ccc"},
+			expectedRequests: 1,
+		},
+		{
+			name:             "searches are merged in batches",
+			searches:         []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"},
+			expectedRequests: 2,
+		},
+		{
+			name:             "queries using variables below their top level are sent on their own",
+			searches:         []string{"a", "bb"},
+			issues:           []int{1, 2},
+			expectedRequests: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Query     string                 `json:"query"`
+					Variables map[string]interface{} `json:"variables"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode the request: %v", err)
+				}
+				lock.Lock()
+				requests++
+				lock.Unlock()
+				data := map[string]interface{}{}
+				if number, ok := req.Variables["number"]; ok {
+					data["repository"] = map[string]interface{}{"issue": map[string]interface{}{"number": number}}
+				}
+				if query, ok := req.Variables["query"]; ok {
+					data["rateLimit"] = map[string]interface{}{"cost": 1}
+					data["search"] = map[string]interface{}{"issueCount": len(query.(string))}
+				}
+				for name, value := range req.Variables {
+					prefix, variable, ok := strings.Cut(name, "_")
+					if !ok || variable != "query" {
+						continue
+					}
+					if !strings.Contains(req.Query, fmt.Sprintf("%s_search: search(type: ISSUE, first: 1, query: $%s)", prefix, name)) {
+						t.Errorf("the merged query doesn't use %s: %s", name, req.Query)
+					}
+					data[prefix+"_rateLimit"] = map[string]interface{}{"cost": 1}
+					data[prefix+"_search"] = map[string]interface{}{"issueCount": len(value.(string))}
+				}
+				if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+					t.Errorf("failed to encode the response: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			c := getClient(server.URL)
+			c.gqlc = &graphQLGitHubAppsAuthClientWrapper{Client: githubv4.NewEnterpriseClient(server.URL, server.Client())}
+
+			var queries []BatchedQuery
+			searches := make([]testSearchQuery, len(tc.searches))
+			for i, q := range tc.searches {
+				queries = append(queries, BatchedQuery{Query: &searches[i], Vars: map[string]interface{}{"query": githubv4.String(q)}})
+			}
+			issues := make([]testIssueQuery, len(tc.issues))
+			for i, number := range tc.issues {
+				queries = append(queries, BatchedQuery{Query: &issues[i], Vars: map[string]interface{}{
+					"owner":  githubv4.String("org"),
+					"name":   githubv4.String("repo"),
+					"number": githubv4.Int(number),
+				}})
+			}
+			if err := c.BatchQueryWithGitHubAppsSupport(context.Background(), queries, "org"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if requests != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+			for i, q := range tc.searches {
+				if int(searches[i].Search.IssueCount) != len(q) || searches[i].RateLimit.Cost != 1 {
+					t.Errorf("search %q wasn't filled in: %+v", q, searches[i])
+				}
+			}
+			for i, number := range tc.issues {
+				if int(issues[i].Repository.Issue.Number) != number {
+					t.Errorf("issue %d wasn't filled in: %+v", number, issues[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsBatchable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    interface{}
+		expected bool
+	}{
+		{
+			name:     "variables at the top level",
+			query:    &testSearchQuery{},
+			expected: true,
+		},
+		{
+			name:  "variables below the top level",
+			query: &testIssueQuery{},
+		},
+		{
+			name:  "not a pointer",
+			query: testSearchQuery{},
+		},
+		{
+			name: "inline fragment",
+			query: &struct {
+				Node struct{ ID githubv4.ID } `graphql:"... on Node"`
+			}{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isBatchable(tc.query); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGraphQLBudgetDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reset := fmt.Sprint(now.Add(1000 * time.Second).Unix())
+	header := func(limit, remaining string) http.Header {
+		return http.Header{
+			"X-Ratelimit-Limit":     []string{limit},
+			"X-Ratelimit-Remaining": []string{remaining},
+			"X-Ratelimit-Reset":     []string{reset},
+		}
+	}
+	testCases := []struct {
+		name      string
+		perOrg    bool
+		responses []*http.Response
+		cost      int
+		org       string
+		expected  []time.Duration
+	}{
+		{
+			name:     "nothing known about the rate limit",
+			expected: []time.Duration{0, 0},
+		},
+		{
+			name:      "plenty of budget left",
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "4000")}},
+			expected:  []time.Duration{0, 0},
+		},
+		{
+			name:      "queries are paced once half of the budget is spent",
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "1000")}},
+			// Every query reserves its cost, so the interval grows as the
+			// remaining budget shrinks.
+			expected: []time.Duration{0, time.Second, time.Second + 1001001001*time.Nanosecond},
+		},
+		{
+			name:      "pacing accounts for the cost of queries",
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "1000")}},
+			cost:      10,
+			expected:  []time.Duration{0, 10 * time.Second},
+		},
+		{
+			name:      "budget spent waits for the reset",
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "1")}},
+			expected:  []time.Duration{0, 1000 * time.Second},
+		},
+		{
+			name: "secondary rate limit",
+			responses: []*http.Response{{StatusCode: http.StatusForbidden, Header: http.Header{
+				"Retry-After": []string{"30"},
+			}}},
+			expected: []time.Duration{30 * time.Second},
+		},
+		{
+			name:      "rate limits of other orgs are ignored with apps auth",
+			perOrg:    true,
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "0")}},
+			org:       "other",
+			expected:  []time.Duration{0},
+		},
+		{
+			name:      "rate limits are shared by all orgs without apps auth",
+			responses: []*http.Response{{StatusCode: http.StatusOK, Header: header("5000", "0")}},
+			org:       "other",
+			expected:  []time.Duration{1000 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newGraphQLBudget(tc.perOrg)
+			b.now = func() time.Time { return now }
+			for _, resp := range tc.responses {
+				var reported *graphQLResponseRateLimit
+				if tc.cost != 0 {
+					reported = &graphQLResponseRateLimit{Cost: &tc.cost}
+				}
+				b.observe("org", resp, reported)
+			}
+			org := tc.org
+			if org == "" {
+				org = "org"
+			}
+			for i, expected := range tc.expected {
+				if actual := b.delay(org); actual != expected {
+					t.Errorf("query %d: expected a delay of %s, got %s", i, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphQLRateLimitTransport(t *testing.T) {
+	body := `{"data":{"q3_rateLimit":{"cost":5,"remaining":100},"q3_search":{"issueCount":1}}}`
+	budget := newGraphQLBudget(false)
+	transport := &graphQLRateLimitTransport{
+		upstream: testRoundTripper{func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}},
+		budget: budget,
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://api.github.com/graphql", nil)
+	if err != nil {
+		t.Fatalf("failed to create the request: %v", err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	read, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read the body: %v", err)
+	}
+	if string(read) != body {
+		t.Errorf("expected the body to be left intact, got %s", read)
+	}
+	rl := budget.limits[""]
+	if rl == nil || rl.cost != 5 || rl.remaining != 100 {
+		t.Errorf("expected a cost of 5 and 100 remaining, got %+v", rl)
+	}
+}
@@ -19,9 +19,11 @@ package github
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"strings"
 	"time"
 
@@ -33,26 +35,69 @@ import (
 type HMACToken struct {
 	Value     string    `json:"value"`
 	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the token stops validating webhooks, usually the end
+	// of the overlap with the token that replaced it. The token doesn't
+	// expire if unset.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired returns whether the token expired at the given time.
+func (t HMACToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
 }
 
 // HMACsForRepo contains all hmac tokens configured for a repo, org or globally.
 type HMACsForRepo []HMACToken
 
+// The algorithms of webhook signatures.
+const (
+	HMACAlgorithmSHA1   = "sha1"
+	HMACAlgorithmSHA256 = "sha256"
+)
+
+// HMACMatch identifies the token that validated a payload, without its value.
+type HMACMatch struct {
+	// Scope is the key the token is configured under: "org/repo", "org" or
+	// "*". Tokens of the legacy single token format have the scope "*".
+	Scope string
+	// CreatedAt is when the token was created.
+	CreatedAt time.Time
+	// Algorithm is the algorithm of the signature, sha1 or sha256.
+	Algorithm string
+}
+
 // ValidatePayload ensures that the request payload signature matches the key.
 func ValidatePayload(payload []byte, sig string, tokenGenerator func() []byte) bool {
+	_, ok := ValidatePayloadSignature(payload, sig, tokenGenerator)
+	return ok
+}
+
+// ValidatePayloadSignature ensures that the request payload signature, either
+// a sha1= or a sha256= signature, matches one of the unexpired tokens of its
+// org or repo, and returns the token that matched.
+func ValidatePayloadSignature(payload []byte, sig string, tokenGenerator func() []byte) (*HMACMatch, bool) {
 	var event GenericEvent
 	if err := json.Unmarshal(payload, &event); err != nil {
 		logrus.WithError(err).Info("validatePayload couldn't unmarshal the github event payload")
-		return false
+		return nil, false
 	}
 
-	if !strings.HasPrefix(sig, "sha1=") {
-		return false
+	algorithm, sig, found := strings.Cut(sig, "=")
+	if !found {
+		return nil, false
+	}
+	var newHash func() hash.Hash
+	switch algorithm {
+	case HMACAlgorithmSHA1:
+		newHash = sha1.New
+	case HMACAlgorithmSHA256:
+		newHash = sha256.New
+	default:
+		return nil, false
 	}
-	sig = sig[5:]
 	sb, err := hex.DecodeString(sig)
 	if err != nil {
-		return false
+		return nil, false
 	}
 
 	orgRepo := event.Repo.FullName
@@ -60,22 +105,26 @@ func ValidatePayload(payload []byte, sig string, tokenGenerator func() []byte) b
 	if orgRepo == "" {
 		orgRepo = event.Org.Login
 	}
-	hmacs, err := extractHMACs(orgRepo, tokenGenerator)
+	scope, tokens, err := extractHMACs(orgRepo, tokenGenerator)
 	if err != nil {
 		logrus.WithError(err).Warning("failed to get an appropriate hmac secret")
-		return false
+		return nil, false
 	}
 
 	// If we have a match with any valid hmac, we can validate successfully.
-	for _, key := range hmacs {
-		mac := hmac.New(sha1.New, key)
+	now := time.Now()
+	for _, token := range tokens {
+		if token.Expired(now) {
+			continue
+		}
+		mac := hmac.New(newHash, []byte(token.Value))
 		mac.Write(payload)
 		expected := mac.Sum(nil)
 		if hmac.Equal(sb, expected) {
-			return true
+			return &HMACMatch{Scope: scope, CreatedAt: token.CreatedAt, Algorithm: algorithm}, true
 		}
 	}
-	return false
+	return nil, false
 }
 
 // PayloadSignature returns the signature that matches the payload.
@@ -86,43 +135,42 @@ func PayloadSignature(payload []byte, key []byte) string {
 	return "sha1=" + hex.EncodeToString(sum)
 }
 
-// extractHMACs returns all *valid* HMAC tokens for given repository/organization.
-// It considers only the tokens at the most specific level configured for the given repo.
-// For example : if a token for repo is present and it doesn't match the repo, we will
-// not try to find a match with org level token. However if no token is present for repo,
-// we will try to match with org level.
-func extractHMACs(orgRepo string, tokenGenerator func() []byte) ([][]byte, error) {
-	t := tokenGenerator()
-	repoToTokenMap := map[string]HMACsForRepo{}
+// PayloadSignature256 returns the SHA-256 signature that matches the payload.
+func PayloadSignature256(payload []byte, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
 
-	if err := yaml.Unmarshal(t, &repoToTokenMap); err != nil {
+// ParseHMACTokens parses the hmac tokens of every scope. A file in the legacy
+// single token format is returned as the only token of the "*" scope.
+func ParseHMACTokens(raw []byte) map[string]HMACsForRepo {
+	repoToTokenMap := map[string]HMACsForRepo{}
+	if err := yaml.Unmarshal(raw, &repoToTokenMap); err != nil {
 		// To keep backward compatibility, we are going to assume that in case of error,
 		// whole file is a single line hmac token.
 		// TODO: Once this code has been released and file has been moved to new format,
 		// we should delete this code and return error.
 		logrus.WithError(err).Trace("Couldn't unmarshal the hmac secret as hierarchical file. Parsing as single token format")
-		return [][]byte{t}, nil
+		return map[string]HMACsForRepo{"*": {{Value: string(raw)}}}
 	}
+	return repoToTokenMap
+}
 
-	orgName := strings.Split(orgRepo, "/")[0]
+// extractHMACs returns the scope and the HMAC tokens for given repository/organization.
+// It considers only the tokens at the most specific level configured for the given repo.
+// For example : if a token for repo is present and it doesn't match the repo, we will
+// not try to find a match with org level token. However if no token is present for repo,
+// we will try to match with org level.
+func extractHMACs(orgRepo string, tokenGenerator func() []byte) (string, HMACsForRepo, error) {
+	repoToTokenMap := ParseHMACTokens(tokenGenerator())
 
-	if val, ok := repoToTokenMap[orgRepo]; ok {
-		return extractTokens(val), nil
-	}
-	if val, ok := repoToTokenMap[orgName]; ok {
-		return extractTokens(val), nil
-	}
-	if val, ok := repoToTokenMap["*"]; ok {
-		return extractTokens(val), nil
-	}
-	return nil, fmt.Errorf("no hmac is configured for the org/repo %q and no legacy global token is configured", orgRepo)
-}
+	orgName := strings.Split(orgRepo, "/")[0]
 
-// extractTokens return tokens for any given level of tree.
-func extractTokens(allTokens HMACsForRepo) [][]byte {
-	validTokens := make([][]byte, len(allTokens))
-	for i := range allTokens {
-		validTokens[i] = []byte(allTokens[i].Value)
+	for _, scope := range []string{orgRepo, orgName, "*"} {
+		if val, ok := repoToTokenMap[scope]; ok {
+			return scope, val, nil
+		}
 	}
-	return validTokens
+	return "", nil, fmt.Errorf("no hmac is configured for the org/repo %q and no legacy global token is configured", orgRepo)
 }
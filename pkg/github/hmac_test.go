@@ -17,7 +17,12 @@ limitations under the License.
 package github
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
 )
 
 var tokens = `
@@ -101,3 +106,74 @@ func TestValidatePayload(t *testing.T) {
 		}
 	}
 }
+
+func TestValidatePayloadSignature(t *testing.T) {
+	created := time.Date(2020, 10, 2, 15, 0, 0, 0, time.UTC)
+	expired := time.Now().Add(-time.Hour)
+	expiring := time.Now().Add(time.Hour)
+	tokens := map[string]HMACsForRepo{
+		"org": {
+			{Value: "old", CreatedAt: created.Add(-time.Hour), ExpiresAt: &expiring},
+			{Value: "new", CreatedAt: created},
+		},
+		"org/repo": {
+			{Value: "old", CreatedAt: created.Add(-time.Hour), ExpiresAt: &expired},
+			{Value: "new", CreatedAt: created},
+		},
+	}
+	raw, err := yaml.Marshal(tokens)
+	if err != nil {
+		t.Fatalf("failed to marshal tokens: %v", err)
+	}
+	tokenGenerator := func() []byte { return raw }
+	orgPayload := []byte(`{"organization": {"login": "org"}}`)
+	repoPayload := []byte(`{"repository": {"full_name": "org/repo"}}`)
+
+	testCases := []struct {
+		name     string
+		payload  []byte
+		sig      string
+		expected *HMACMatch
+	}{
+		{
+			name:     "sha256 signature of the new token",
+			payload:  orgPayload,
+			sig:      PayloadSignature256(orgPayload, []byte("new")),
+			expected: &HMACMatch{Scope: "org", CreatedAt: created, Algorithm: HMACAlgorithmSHA256},
+		},
+		{
+			name:     "sha1 signature of the old token during the overlap",
+			payload:  orgPayload,
+			sig:      PayloadSignature(orgPayload, []byte("old")),
+			expected: &HMACMatch{Scope: "org", CreatedAt: created.Add(-time.Hour), Algorithm: HMACAlgorithmSHA1},
+		},
+		{
+			name:    "expired token",
+			payload: repoPayload,
+			sig:     PayloadSignature256(repoPayload, []byte("old")),
+		},
+		{
+			name:     "new token of the repo",
+			payload:  repoPayload,
+			sig:      PayloadSignature(repoPayload, []byte("new")),
+			expected: &HMACMatch{Scope: "org/repo", CreatedAt: created, Algorithm: HMACAlgorithmSHA1},
+		},
+		{
+			name:    "unknown algorithm",
+			payload: orgPayload,
+			sig:     "md5=" + strings.TrimPrefix(PayloadSignature(orgPayload, []byte("new")), "sha1="),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match, ok := ValidatePayloadSignature(tc.payload, tc.sig, tokenGenerator)
+			if ok != (tc.expected != nil) {
+				t.Fatalf("expected valid: %t, got: %t", tc.expected != nil, ok)
+			}
+			if diff := cmp.Diff(tc.expected, match); diff != "" {
+				t.Errorf("unexpected match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
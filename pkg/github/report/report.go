@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
@@ -37,6 +38,11 @@ import (
 const (
 	// CommentTag is the tag which identifies an issue comment containing a test report
 	CommentTag = "<!-- test report -->"
+
+	// RerunCheckRunAction identifies the action of check runs reported by
+	// Prow that re-runs their job. The external ID of the check run is the
+	// name of the ProwJob.
+	RerunCheckRunAction = "rerun"
 )
 
 // GitHubClient provides a client interface to report job status updates
@@ -48,6 +54,9 @@ type GitHubClient interface {
 	CreateCommentWithContext(ctx context.Context, org, repo string, number int, comment string) error
 	DeleteCommentWithContext(ctx context.Context, org, repo string, ID int) error
 	EditCommentWithContext(ctx context.Context, org, repo string, ID int, comment string) error
+	ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error)
+	CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error)
+	UpdateCheckRun(org, repo string, checkRunId int64, checkRun github.CheckRun) error
 }
 
 // prowjobStateToGitHubStatus maps prowjob status to github states.
@@ -55,7 +64,7 @@ type GitHubClient interface {
 // https://developer.github.com/v3/repos/statuses/#create-a-status
 func prowjobStateToGitHubStatus(pjState prowapi.ProwJobState) (string, error) {
 	switch pjState {
-	case prowapi.TriggeredState:
+	case prowapi.HeldState, prowapi.WaitingState, prowapi.TriggeredState:
 		return github.StatusPending, nil
 	case prowapi.PendingState:
 		return github.StatusPending, nil
@@ -83,9 +92,12 @@ func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob) err
 		if len(refs.Pulls) > 0 {
 			sha = refs.Pulls[0].SHA
 		}
+		if pj.Spec.ReporterConfig != nil && pj.Spec.ReporterConfig.GitHub.ReportsCheckRun() {
+			return reportCheckRun(ghc, pj, sha)
+		}
 		if err := ghc.CreateStatusWithContext(ctx, refs.Org, refs.Repo, sha, github.Status{
 			State:       contextState,
-			Description: config.ContextDescriptionWithBaseSha(pj.Status.Description, refs.BaseSHA),
+			Description: config.ContextDescriptionWithBaseSha(statusDescription(pj), refs.BaseSHA),
 			Context:     pj.Spec.Context, // consider truncating this too
 			TargetURL:   pj.Status.URL,
 		}); err != nil {
@@ -95,6 +107,89 @@ func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob) err
 	return nil
 }
 
+// reportCheckRun reports the status of the job as a check run of the commit.
+// The check run is identified by the name of the job, so it's created when
+// the job is first reported and updated afterwards.
+func reportCheckRun(ghc GitHubClient, pj prowapi.ProwJob, sha string) error {
+	refs := pj.Spec.Refs
+	checkRun := CheckRunFor(pj, sha)
+	checkRuns, err := ghc.ListCheckRuns(refs.Org, refs.Repo, sha)
+	if err != nil {
+		return fmt.Errorf("failed to list check runs: %w", err)
+	}
+	for _, existing := range checkRuns.CheckRuns {
+		if existing.ExternalID == pj.Name {
+			checkRun.HeadSHA = ""
+			return ghc.UpdateCheckRun(refs.Org, refs.Repo, existing.ID, checkRun)
+		}
+	}
+	_, err = ghc.CreateCheckRun(refs.Org, refs.Repo, checkRun)
+	return err
+}
+
+// CheckRunFor returns the check run reporting the status of the job. Failures
+// of optional presubmits conclude as neutral by default, so they don't look
+// like they block merging.
+func CheckRunFor(pj prowapi.ProwJob, sha string) github.CheckRun {
+	optional := pj.Labels[kube.IsOptionalLabel] == "true"
+	title := statusDescription(pj)
+	if title == "" {
+		title = string(pj.Status.State)
+	}
+	summary := fmt.Sprintf("Tested against base commit %s.", pj.Spec.Refs.BaseSHA)
+	if optional {
+		title = "Optional: " + title
+		summary += " This job is optional, its failures do not block merging."
+	}
+	checkRun := github.CheckRun{
+		Name:       pj.Spec.Context,
+		HeadSHA:    sha,
+		ExternalID: pj.Name,
+		DetailsURL: pj.Status.URL,
+		Output:     github.CheckRunOutput{Title: title, Summary: summary},
+	}
+	if !pj.Status.StartTime.IsZero() {
+		checkRun.StartedAt = pj.Status.StartTime.UTC().Format(time.RFC3339)
+	}
+	switch pj.Status.State {
+	case prowapi.HeldState, prowapi.WaitingState, prowapi.TriggeredState:
+		checkRun.Status = github.CheckRunQueued
+		return checkRun
+	case prowapi.PendingState:
+		checkRun.Status = github.CheckRunInProgress
+		return checkRun
+	case prowapi.SuccessState:
+		checkRun.Conclusion = github.CheckRunConclusionSuccess
+	case prowapi.AbortedState:
+		checkRun.Conclusion = github.CheckRunConclusionCancelled
+	default:
+		checkRun.Conclusion = github.CheckRunConclusionFailure
+		if cfg := pj.Spec.ReporterConfig; cfg != nil && cfg.GitHub != nil && cfg.GitHub.FailureConclusion != "" {
+			checkRun.Conclusion = cfg.GitHub.FailureConclusion
+		} else if optional {
+			checkRun.Conclusion = github.CheckRunConclusionNeutral
+		}
+	}
+	checkRun.Status = github.CheckRunCompleted
+	if pj.Status.CompletionTime != nil {
+		checkRun.CompletedAt = pj.Status.CompletionTime.UTC().Format(time.RFC3339)
+	}
+	return checkRun
+}
+
+// statusDescription returns the description to report for the job, which
+// includes where the job waits if it is held back by a limit, or the reason
+// the pod is stuck if the job is still pending.
+func statusDescription(pj prowapi.ProwJob) string {
+	switch {
+	case pj.Status.State == prowapi.TriggeredState && pj.Status.Queue != nil:
+		return pj.Status.Queue.Describe(time.Now())
+	case pj.Status.State == prowapi.PendingState && pj.Status.PodPendingReason != nil:
+		return fmt.Sprintf("Pod pending: %s", pj.Status.PodPendingReason)
+	}
+	return pj.Status.Description
+}
+
 // TODO(krzyzacy):
 // Move this logic into github/reporter, once we unify all reporting logic to crier
 func ShouldReport(pj prowapi.ProwJob, validTypes []prowapi.ProwJobType) bool {
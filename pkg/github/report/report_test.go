@@ -23,6 +23,7 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -225,8 +226,9 @@ func createReportEntry(context string, isRequired bool) string {
 }
 
 type fakeGhClient struct {
-	status   []github.Status
-	comments []string
+	status    []github.Status
+	comments  []string
+	checkRuns []github.CheckRun
 }
 
 func (gh fakeGhClient) BotUserCheckerWithContext(_ context.Context) (func(string) bool, error) {
@@ -258,6 +260,25 @@ func (gh fakeGhClient) DeleteCommentWithContext(_ context.Context, org, repo str
 func (gh fakeGhClient) EditCommentWithContext(_ context.Context, org, repo string, ID int, comment string) error {
 	return nil
 }
+func (gh *fakeGhClient) ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error) {
+	return &github.CheckRunList{CheckRuns: gh.checkRuns}, nil
+}
+func (gh *fakeGhClient) CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error) {
+	checkRun.ID = int64(len(gh.checkRuns) + 1)
+	gh.checkRuns = append(gh.checkRuns, checkRun)
+	return checkRun.ID, nil
+}
+func (gh *fakeGhClient) UpdateCheckRun(org, repo string, checkRunId int64, checkRun github.CheckRun) error {
+	for i := range gh.checkRuns {
+		if gh.checkRuns[i].ID == checkRunId {
+			checkRun.ID = checkRunId
+			checkRun.HeadSHA = gh.checkRuns[i].HeadSHA
+			gh.checkRuns[i] = checkRun
+			return nil
+		}
+	}
+	return fmt.Errorf("check run %d not found", checkRunId)
+}
 
 func shout(i int) string {
 	if i == 0 {
@@ -276,6 +297,8 @@ func TestReportStatus(t *testing.T) {
 		state            prowapi.ProwJobState
 		report           bool
 		desc             string // override default msg
+		pendingReason    *prowapi.PodPendingReason
+		queue            *prowapi.QueueStatus
 		pjType           prowapi.ProwJobType
 		expectedStatuses []string
 		expectedDesc     string
@@ -304,6 +327,16 @@ func TestReportStatus(t *testing.T) {
 			pjType:           prowapi.PresubmitJob,
 			expectedStatuses: []string{"pending"},
 		},
+		{
+			name: "Pending prowjob with a pod pending reason includes it in the description",
+
+			state:            prowapi.PendingState,
+			report:           true,
+			pendingReason:    &prowapi.PodPendingReason{Reason: prowapi.PodImagePullBackOff, Container: "test"},
+			pjType:           prowapi.PresubmitJob,
+			expectedStatuses: []string{"pending"},
+			expectedDesc:     "Pod pending: ImagePullBackOff (container test)",
+		},
 		{
 			name: "Aborted presubmit job with report true should set failure status",
 
@@ -320,6 +353,16 @@ func TestReportStatus(t *testing.T) {
 			pjType:           prowapi.PresubmitJob,
 			expectedStatuses: []string{"pending"},
 		},
+		{
+			name: "Triggered prowjob with a queue status describes its position",
+
+			state:            prowapi.TriggeredState,
+			report:           true,
+			queue:            &prowapi.QueueStatus{Limit: "max_concurrency", Position: 2, Length: 5},
+			pjType:           prowapi.PresubmitJob,
+			expectedStatuses: []string{"pending"},
+			expectedDesc:     "Queued: 2 of 5 waiting for max_concurrency.",
+		},
 		{
 			name: "really long description is truncated",
 
@@ -353,9 +396,11 @@ func TestReportStatus(t *testing.T) {
 			}
 			pj := prowapi.ProwJob{
 				Status: prowapi.ProwJobStatus{
-					State:       tc.state,
-					Description: tc.desc,
-					URL:         "http://mytest.com",
+					State:            tc.state,
+					Description:      tc.desc,
+					URL:              "http://mytest.com",
+					PodPendingReason: tc.pendingReason,
+					Queue:            tc.queue,
 				},
 				Spec: prowapi.ProwJobSpec{
 					Job:     "job-name",
@@ -395,6 +440,122 @@ func TestReportStatus(t *testing.T) {
 	}
 }
 
+func TestReportCheckRun(t *testing.T) {
+	started := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	completed := metav1.NewTime(started.Add(time.Hour))
+	tests := []struct {
+		name              string
+		state             prowapi.ProwJobState
+		optional          bool
+		failureConclusion string
+		existing          []github.CheckRun
+		expected          []github.CheckRun
+	}{
+		{
+			name:  "pending job creates an in progress check run",
+			state: prowapi.PendingState,
+			expected: []github.CheckRun{{
+				ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z",
+				Status: "in_progress",
+				Output: github.CheckRunOutput{Title: "running", Summary: "Tested against base commit base."},
+			}},
+		},
+		{
+			name:  "finished job updates its check run",
+			state: prowapi.SuccessState,
+			existing: []github.CheckRun{
+				{ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "previous-pj", Status: "completed", Conclusion: "failure"},
+				{ID: 2, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", Status: "in_progress"},
+			},
+			expected: []github.CheckRun{
+				{ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "previous-pj", Status: "completed", Conclusion: "failure"},
+				{
+					ID: 2, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z", CompletedAt: "2024-05-01T11:00:00Z",
+					Status: "completed", Conclusion: "success",
+					Output: github.CheckRunOutput{Title: "running", Summary: "Tested against base commit base."},
+				},
+			},
+		},
+		{
+			name:  "failed job concludes as failure",
+			state: prowapi.FailureState,
+			expected: []github.CheckRun{{
+				ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z", CompletedAt: "2024-05-01T11:00:00Z",
+				Status: "completed", Conclusion: "failure",
+				Output: github.CheckRunOutput{Title: "running", Summary: "Tested against base commit base."},
+			}},
+		},
+		{
+			name:     "failed optional job concludes as neutral",
+			state:    prowapi.ErrorState,
+			optional: true,
+			expected: []github.CheckRun{{
+				ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z", CompletedAt: "2024-05-01T11:00:00Z",
+				Status: "completed", Conclusion: "neutral",
+				Output: github.CheckRunOutput{Title: "Optional: running", Summary: "Tested against base commit base. This job is optional, its failures do not block merging."},
+			}},
+		},
+		{
+			name:              "failure conclusion overrides the default of optional jobs",
+			state:             prowapi.FailureState,
+			optional:          true,
+			failureConclusion: "failure",
+			expected: []github.CheckRun{{
+				ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z", CompletedAt: "2024-05-01T11:00:00Z",
+				Status: "completed", Conclusion: "failure",
+				Output: github.CheckRunOutput{Title: "Optional: running", Summary: "Tested against base commit base. This job is optional, its failures do not block merging."},
+			}},
+		},
+		{
+			name:  "aborted job concludes as cancelled",
+			state: prowapi.AbortedState,
+			expected: []github.CheckRun{{
+				ID: 1, Name: "ci/test", HeadSHA: "abcdef", ExternalID: "pj", DetailsURL: "https://prow/pj", StartedAt: "2024-05-01T10:00:00Z", CompletedAt: "2024-05-01T11:00:00Z",
+				Status: "completed", Conclusion: "cancelled",
+				Output: github.CheckRunOutput{Title: "running", Summary: "Tested against base commit base."},
+			}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := &fakeGhClient{checkRuns: tc.existing}
+			pj := prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "pj", Labels: map[string]string{kube.IsOptionalLabel: strconv.FormatBool(tc.optional)}},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PresubmitJob,
+					Context: "ci/test",
+					Report:  true,
+					Refs: &prowapi.Refs{
+						Org:     "k8s",
+						Repo:    "test-infra",
+						BaseSHA: "base",
+						Pulls:   []prowapi.Pull{{Number: 1, SHA: "abcdef"}},
+					},
+					ReporterConfig: &prowapi.ReporterConfig{GitHub: &prowapi.GitHubReporterConfig{CheckRun: true, FailureConclusion: tc.failureConclusion}},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:       tc.state,
+					Description: "running",
+					URL:         "https://prow/pj",
+					StartTime:   started,
+				},
+			}
+			if tc.state != prowapi.PendingState {
+				pj.Status.CompletionTime = &completed
+			}
+			if err := reportStatus(context.Background(), ghc, pj); err != nil {
+				t.Fatalf("reportStatus failed: %v", err)
+			}
+			if len(ghc.status) > 0 {
+				t.Errorf("expected no status contexts, got %v", ghc.status)
+			}
+			if diff := cmp.Diff(tc.expected, ghc.checkRuns); diff != "" {
+				t.Errorf("unexpected check runs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestShouldReport(t *testing.T) {
 	var testcases = []struct {
 		name       string
@@ -67,6 +67,35 @@ const (
 	StatusFailure = "failure"
 )
 
+// These are possible Status entries for a CheckRun.
+const (
+	CheckRunQueued     = "queued"
+	CheckRunInProgress = "in_progress"
+	CheckRunCompleted  = "completed"
+)
+
+// These are possible Conclusion entries for a completed CheckRun.
+// https://docs.github.com/en/rest/checks/runs#create-a-check-run
+const (
+	CheckRunConclusionSuccess   = "success"
+	CheckRunConclusionFailure   = "failure"
+	CheckRunConclusionNeutral   = "neutral"
+	CheckRunConclusionCancelled = "cancelled"
+	CheckRunConclusionSkipped   = "skipped"
+)
+
+// CheckRunEventAction enumerates the triggers for a CheckRunEvent.
+type CheckRunEventAction string
+
+// These are the possible actions of a CheckRunEvent.
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads#check_run
+const (
+	CheckRunActionCreated         CheckRunEventAction = "created"
+	CheckRunActionCompleted       CheckRunEventAction = "completed"
+	CheckRunActionRerequested     CheckRunEventAction = "rerequested"
+	CheckRunActionRequestedAction CheckRunEventAction = "requested_action"
+)
+
 // Possible contents for reactions.
 const (
 	ReactionThumbsUp                  = "+1"
@@ -681,6 +710,83 @@ type RestrictionsRequest struct {
 	Teams *[]string `json:"teams,omitempty"`
 }
 
+// Ruleset is a repository ruleset.
+// See https://docs.github.com/en/rest/repos/rules
+type Ruleset struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Target is branch, tag or push.
+	Target string `json:"target"`
+	// SourceType is Repository or Organization.
+	SourceType string `json:"source_type,omitempty"`
+	// Enforcement is active, evaluate or disabled.
+	Enforcement  string               `json:"enforcement"`
+	BypassActors []RulesetBypassActor `json:"bypass_actors"`
+	Conditions   *RulesetConditions   `json:"conditions,omitempty"`
+	// Rules are not set when listing rulesets.
+	Rules []RulesetRule `json:"rules"`
+}
+
+// RulesetBypassActor can bypass the rules of a ruleset.
+type RulesetBypassActor struct {
+	// ActorID is nil for deploy keys.
+	ActorID *int `json:"actor_id"`
+	// ActorType is Integration, OrganizationAdmin, RepositoryRole, Team or
+	// DeployKey.
+	ActorType string `json:"actor_type"`
+	// BypassMode is always or pull_request.
+	BypassMode string `json:"bypass_mode"`
+}
+
+// RulesetConditions select the refs a ruleset applies to.
+type RulesetConditions struct {
+	RefName *RulesetRefNameCondition `json:"ref_name,omitempty"`
+}
+
+// RulesetRefNameCondition includes and excludes refs by name.
+type RulesetRefNameCondition struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// RulesetRule is a rule of a ruleset, like deletion, non_fast_forward,
+// pull_request or required_status_checks.
+type RulesetRule struct {
+	Type       string                 `json:"type"`
+	Parameters *RulesetRuleParameters `json:"parameters,omitempty"`
+}
+
+// RulesetRuleParameters are the parameters of the rules prow manages. Each
+// type of rule only uses some of them.
+type RulesetRuleParameters struct {
+	// pull_request
+	RequiredApprovingReviewCount   *int  `json:"required_approving_review_count,omitempty"`
+	DismissStaleReviewsOnPush      *bool `json:"dismiss_stale_reviews_on_push,omitempty"`
+	RequireCodeOwnerReview         *bool `json:"require_code_owner_review,omitempty"`
+	RequireLastPushApproval        *bool `json:"require_last_push_approval,omitempty"`
+	RequiredReviewThreadResolution *bool `json:"required_review_thread_resolution,omitempty"`
+
+	// required_status_checks
+	RequiredStatusChecks             []RulesetStatusCheck `json:"required_status_checks,omitempty"`
+	StrictRequiredStatusChecksPolicy *bool                `json:"strict_required_status_checks_policy,omitempty"`
+
+	// file_path_restriction
+	RestrictedFilePaths []string `json:"restricted_file_paths,omitempty"`
+	// file_extension_restriction
+	RestrictedFileExtensions []string `json:"restricted_file_extensions,omitempty"`
+	// max_file_path_length
+	MaxFilePathLength *int `json:"max_file_path_length,omitempty"`
+	// max_file_size, in MB
+	MaxFileSize *int `json:"max_file_size,omitempty"`
+}
+
+// RulesetStatusCheck is a status check required by a ruleset.
+type RulesetStatusCheck struct {
+	Context string `json:"context"`
+	// IntegrationID is the ID of the app that must report the check, if set.
+	IntegrationID *int `json:"integration_id,omitempty"`
+}
+
 // HookConfig holds the endpoint and its secret.
 type HookConfig struct {
 	URL         string  `json:"url"`
@@ -915,6 +1021,28 @@ type StatusEvent struct {
 	GUID string
 }
 
+// CheckRunEvent fires when a check run is created, completed, rerequested
+// or one of its actions is requested.
+//
+// See https://docs.github.com/en/webhooks/webhook-events-and-payloads#check_run
+type CheckRunEvent struct {
+	Action   CheckRunEventAction `json:"action"`
+	CheckRun CheckRun            `json:"check_run"`
+	// RequestedAction is only set for the requested_action action.
+	RequestedAction *CheckRunRequestedAction `json:"requested_action,omitempty"`
+	Repo            Repo                     `json:"repository"`
+	Sender          User                     `json:"sender"`
+
+	// GUID is included in the header of the request received by GitHub.
+	GUID string
+}
+
+// CheckRunRequestedAction identifies the action of a check run that a user
+// requested.
+type CheckRunRequestedAction struct {
+	Identifier string `json:"identifier"`
+}
+
 // IssuesSearchResult represents the result of an issues search.
 type IssuesSearchResult struct {
 	Total  int     `json:"total_count,omitempty"`
@@ -1439,6 +1567,17 @@ type CheckRun struct {
 	CheckSuite   CheckSuite     `json:"check_suite,omitempty"`
 	App          App            `json:"app,omitempty"`
 	PullRequests []PullRequest  `json:"pull_requests,omitempty"`
+	// Actions are the buttons shown on the check run to users with write
+	// access to the repo. At most three are allowed.
+	Actions []CheckRunAction `json:"actions,omitempty"`
+}
+
+// CheckRunAction is a button of a check run. Clicking it sends a
+// CheckRunEvent with the requested_action action and its identifier.
+type CheckRunAction struct {
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Identifier  string `json:"identifier"`
 }
 
 type CheckRunOutput struct {
@@ -1552,6 +1691,26 @@ type AppInstallation struct {
 	UpdatedAt           string                  `json:"updated_at,omitempty"`
 }
 
+// HookDelivery is an attempt to deliver a webhook of the GitHub App. A
+// redelivery is another attempt with the same GUID.
+type HookDelivery struct {
+	ID             int64     `json:"id"`
+	GUID           string    `json:"guid"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+	Redelivery     bool      `json:"redelivery"`
+	Status         string    `json:"status,omitempty"`
+	StatusCode     int       `json:"status_code"`
+	Event          string    `json:"event"`
+	Action         string    `json:"action,omitempty"`
+	InstallationID int64     `json:"installation_id,omitempty"`
+	RepositoryID   int64     `json:"repository_id,omitempty"`
+}
+
+// Succeeded returns whether the webhook was received.
+func (d HookDelivery) Succeeded() bool {
+	return d.StatusCode >= 200 && d.StatusCode < 300
+}
+
 // AppInstallationList represents the result of an AppInstallationList search.
 type AppInstallationList struct {
 	Total         int               `json:"total_count,omitempty"`
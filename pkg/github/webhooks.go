@@ -29,45 +29,58 @@ import (
 // the payload of the request, whether the webhook is valid or not,
 // and finally the resultant HTTP status code
 func ValidateWebhook(w http.ResponseWriter, r *http.Request, tokenGenerator func() []byte) (string, string, []byte, bool, int) {
+	eventType, eventGUID, payload, match, status := ValidateWebhookHMAC(w, r, tokenGenerator)
+	return eventType, eventGUID, payload, match != nil, status
+}
+
+// ValidateWebhookHMAC is like ValidateWebhook, but returns the token that
+// validated the webhook instead of whether it's valid. The SHA-256 signature
+// of the webhook is validated if GitHub sent one, otherwise its SHA-1
+// signature is.
+func ValidateWebhookHMAC(w http.ResponseWriter, r *http.Request, tokenGenerator func() []byte) (string, string, []byte, *HMACMatch, int) {
 	defer r.Body.Close()
 
 	// Header checks: It must be a POST with an event type and a signature.
 	if r.Method != http.MethodPost {
 		responseHTTPError(w, http.StatusMethodNotAllowed, "405 Method not allowed")
-		return "", "", nil, false, http.StatusMethodNotAllowed
+		return "", "", nil, nil, http.StatusMethodNotAllowed
 	}
 	eventType := r.Header.Get("X-GitHub-Event")
 	if eventType == "" {
 		responseHTTPError(w, http.StatusBadRequest, "400 Bad Request: Missing X-GitHub-Event Header")
-		return "", "", nil, false, http.StatusBadRequest
+		return "", "", nil, nil, http.StatusBadRequest
 	}
 	eventGUID := r.Header.Get("X-GitHub-Delivery")
 	if eventGUID == "" {
 		responseHTTPError(w, http.StatusBadRequest, "400 Bad Request: Missing X-GitHub-Delivery Header")
-		return "", "", nil, false, http.StatusBadRequest
+		return "", "", nil, nil, http.StatusBadRequest
+	}
+	sig := r.Header.Get("X-Hub-Signature-256")
+	if sig == "" {
+		sig = r.Header.Get("X-Hub-Signature")
 	}
-	sig := r.Header.Get("X-Hub-Signature")
 	if sig == "" {
 		responseHTTPError(w, http.StatusForbidden, "403 Forbidden: Missing X-Hub-Signature")
-		return "", "", nil, false, http.StatusForbidden
+		return "", "", nil, nil, http.StatusForbidden
 	}
 	contentType := r.Header.Get("content-type")
 	if contentType != "application/json" {
 		responseHTTPError(w, http.StatusBadRequest, "400 Bad Request: Hook only accepts content-type: application/json - please reconfigure this hook on GitHub")
-		return "", "", nil, false, http.StatusBadRequest
+		return "", "", nil, nil, http.StatusBadRequest
 	}
 	payload, err := io.ReadAll(r.Body)
 	if err != nil {
 		responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: Failed to read request body")
-		return "", "", nil, false, http.StatusInternalServerError
+		return "", "", nil, nil, http.StatusInternalServerError
 	}
 	// Validate the payload with our HMAC secret.
-	if !ValidatePayload(payload, sig, tokenGenerator) {
+	match, ok := ValidatePayloadSignature(payload, sig, tokenGenerator)
+	if !ok {
 		responseHTTPError(w, http.StatusForbidden, "403 Forbidden: Invalid X-Hub-Signature")
-		return "", "", nil, false, http.StatusForbidden
+		return "", "", nil, nil, http.StatusForbidden
 	}
 
-	return eventType, eventGUID, payload, true, http.StatusOK
+	return eventType, eventGUID, payload, match, http.StatusOK
 }
 
 func responseHTTPError(w http.ResponseWriter, statusCode int, response string) {
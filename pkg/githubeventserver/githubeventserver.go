@@ -459,7 +459,7 @@ func (s *serveMuxHandler) handleEvent(eventType, eventGUID string, payload []byt
 
 // ServeHTTP validates an incoming webhook and puts it into the event channel.
 func (s *serveMuxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	eventType, eventGUID, payload, ok, resp := github.ValidateWebhook(w, r, s.hmacTokenGenerator)
+	eventType, eventGUID, payload, match, resp := github.ValidateWebhookHMAC(w, r, s.hmacTokenGenerator)
 	if counter, err := s.metrics.ResponseCounter.GetMetricWithLabelValues(strconv.Itoa(resp)); err != nil {
 		logrus.WithFields(logrus.Fields{
 			"status-code": resp,
@@ -468,9 +468,10 @@ func (s *serveMuxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		counter.Inc()
 	}
 
-	if !ok {
+	if match == nil {
 		return
 	}
+	s.metrics.CountHMACValidation(match)
 	fmt.Fprint(w, "Event received. Have a nice day.")
 
 	if err := s.handleEvent(eventType, eventGUID, payload, r.Header); err != nil {
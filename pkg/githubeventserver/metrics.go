@@ -17,8 +17,12 @@ limitations under the License.
 package githubeventserver
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
 
+	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
 
@@ -41,6 +45,10 @@ var (
 		Name: "prow_plugin_handle_errors",
 		Help: "Prow errors handling an event by plugin, event type and action.",
 	}, []string{"event_type", "action", "plugin", "took_action"})
+	hmacValidationCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_webhook_hmac_validations",
+		Help: "A counter of the webhooks validated by each hmac token, by the scope and creation time of the token and the signature algorithm.",
+	}, []string{"scope", "token_created_at", "algorithm"})
 )
 
 func init() {
@@ -48,6 +56,7 @@ func init() {
 	prometheus.MustRegister(responseCounter)
 	prometheus.MustRegister(pluginHandleDuration)
 	prometheus.MustRegister(pluginHandleErrors)
+	prometheus.MustRegister(hmacValidationCounter)
 }
 
 // Metrics is a set of metrics gathered by hook.
@@ -56,6 +65,9 @@ type Metrics struct {
 	ResponseCounter      *prometheus.CounterVec
 	PluginHandleDuration *prometheus.HistogramVec
 	PluginHandleErrors   *prometheus.CounterVec
+	// HMACValidationCounter counts the webhooks validated by each hmac
+	// token, so that rotations can be followed.
+	HMACValidationCounter *prometheus.CounterVec
 	*plugins.Metrics
 }
 
@@ -69,10 +81,25 @@ type PluginMetrics struct {
 // NewMetrics creates a new set of metrics for the hook server.
 func NewMetrics() *Metrics {
 	return &Metrics{
-		WebhookCounter:       webhookCounter,
-		ResponseCounter:      responseCounter,
-		PluginHandleDuration: pluginHandleDuration,
-		PluginHandleErrors:   pluginHandleErrors,
-		Metrics:              plugins.NewMetrics(),
+		WebhookCounter:        webhookCounter,
+		ResponseCounter:       responseCounter,
+		PluginHandleDuration:  pluginHandleDuration,
+		PluginHandleErrors:    pluginHandleErrors,
+		HMACValidationCounter: hmacValidationCounter,
+		Metrics:               plugins.NewMetrics(),
+	}
+}
+
+// CountHMACValidation counts the webhook validated by the hmac token.
+func (m *Metrics) CountHMACValidation(match *github.HMACMatch) {
+	var createdAt string
+	if !match.CreatedAt.IsZero() {
+		createdAt = match.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	counter, err := m.HMACValidationCounter.GetMetricWithLabelValues(match.Scope, createdAt, match.Algorithm)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get metric for the hmac validation.")
+		return
 	}
+	counter.Inc()
 }
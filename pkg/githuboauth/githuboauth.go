@@ -177,6 +177,20 @@ func (ga *Agent) HandleLogin(client OAuthClient, secure bool) http.HandlerFunc {
 
 // GetLogin returns the username of the already authenticated GitHub user.
 func (ga *Agent) GetLogin(r *http.Request, identifier AuthenticatedUserIdentifier) (string, error) {
+	accessToken, err := ga.GetAccessToken(r)
+	if err != nil {
+		return "", err
+	}
+	login, err := identifier.LoginForRequester("rerun", accessToken)
+	if err != nil {
+		return "", err
+	}
+	return login, nil
+}
+
+// GetAccessToken returns the access token of the already authenticated GitHub
+// user, to act on GitHub under the identity of the user.
+func (ga *Agent) GetAccessToken(r *http.Request) (string, error) {
 	session, err := ga.gc.CookieStore.Get(r, tokenSession)
 	if err != nil {
 		return "", err
@@ -185,11 +199,7 @@ func (ga *Agent) GetLogin(r *http.Request, identifier AuthenticatedUserIdentifie
 	if !ok || !token.Valid() {
 		return "", fmt.Errorf("Could not find GitHub token")
 	}
-	login, err := identifier.LoginForRequester("rerun", token.AccessToken)
-	if err != nil {
-		return "", err
-	}
-	return login, nil
+	return token.AccessToken, nil
 }
 
 // HandleLogout handles GitHub logout request from front-end. It invalidates cookie sessions and
@@ -17,6 +17,7 @@ limitations under the License.
 package hook
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
 	"strconv"
@@ -24,14 +25,22 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/client/clientset/versioned/typed/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
 
 const FailedCommentCoerceFmt = "Could not coerce %s event to a GenericCommentEvent. Unknown 'action': %q."
 
-const eventTypeField = "event-type"
+const (
+	eventTypeField = "event-type"
+	// eventReceivedField carries the time at which hook received the event.
+	eventReceivedField = "event-received"
+)
 
 var (
 	nonCommentIssueActions = map[github.IssueEventAction]bool{
@@ -69,6 +78,39 @@ var (
 	}
 )
 
+// newAgent returns the agent of a plugin handling the event of the logger. The
+// ProwJobs the plugin creates are annotated with the time the event was
+// received and its type, so that the latency of triggering jobs can be
+// measured.
+func (s *Server) newAgent(l *logrus.Entry, org, plugin string) plugins.Agent {
+	agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, org, s.Metrics.Metrics, l, plugin)
+	if received, ok := l.Data[eventReceivedField].(time.Time); ok && agent.ProwJobClient != nil {
+		eventType, _ := l.Data[eventTypeField].(string)
+		agent.ProwJobClient = &eventReceivedProwJobClient{ProwJobInterface: agent.ProwJobClient, received: received, eventType: eventType}
+	}
+	return agent
+}
+
+// eventReceivedProwJobClient adds the kube.EventReceivedAnnotation and the
+// kube.EventTypeAnnotation to the ProwJobs it creates.
+type eventReceivedProwJobClient struct {
+	prowv1.ProwJobInterface
+	received  time.Time
+	eventType string
+}
+
+func (c *eventReceivedProwJobClient) Create(ctx context.Context, pj *prowapi.ProwJob, opts metav1.CreateOptions) (*prowapi.ProwJob, error) {
+	pj = pj.DeepCopy()
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	pj.Annotations[kube.EventReceivedAnnotation] = c.received.UTC().Format(time.RFC3339Nano)
+	if c.eventType != "" {
+		pj.Annotations[kube.EventTypeAnnotation] = c.eventType
+	}
+	return c.ProwJobInterface.Create(ctx, pj, opts)
+}
+
 func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
@@ -84,7 +126,7 @@ func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.ReviewEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, re.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, re.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				re.Repo.Owner.Login,
 				re.Repo.Name,
@@ -130,7 +172,7 @@ func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewComm
 		s.wg.Add(1)
 		go func(p string, h plugins.ReviewCommentEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, rce.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, rce.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				rce.Repo.Owner.Login,
 				rce.Repo.Name,
@@ -175,7 +217,7 @@ func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEv
 		s.wg.Add(1)
 		go func(p string, h plugins.PullRequestHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, pr.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, pr.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				pr.Repo.Owner.Login,
 				pr.Repo.Name,
@@ -221,7 +263,7 @@ func (s *Server) handlePushEvent(l *logrus.Entry, pe github.PushEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.PushEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, pe.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, pe.Repo.Owner.Login, p)
 			start := time.Now()
 			err := errorOnPanic(func() error { return h(agent, pe) })
 			labels := prometheus.Labels{"event_type": l.Data[eventTypeField].(string), "action": "none", "plugin": p, "took_action": strconv.FormatBool(agent.TookAction())}
@@ -248,7 +290,7 @@ func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.IssueHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, i.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, i.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				i.Repo.Owner.Login,
 				i.Repo.Name,
@@ -295,7 +337,7 @@ func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueComment
 		s.wg.Add(1)
 		go func(p string, h plugins.IssueCommentHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, ic.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, ic.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				ic.Repo.Owner.Login,
 				ic.Repo.Name,
@@ -341,7 +383,7 @@ func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.StatusEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, se.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, se.Repo.Owner.Login, p)
 			start := time.Now()
 			err := errorOnPanic(func() error { return h(agent, se) })
 			labels := prometheus.Labels{"event_type": l.Data[eventTypeField].(string), "action": "none", "plugin": p, "took_action": strconv.FormatBool(agent.TookAction())}
@@ -354,12 +396,39 @@ func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
 	}
 }
 
+func (s *Server) handleCheckRunEvent(l *logrus.Entry, cre github.CheckRunEvent) {
+	defer s.wg.Done()
+	l = l.WithFields(logrus.Fields{
+		github.OrgLogField:  cre.Repo.Owner.Login,
+		github.RepoLogField: cre.Repo.Name,
+		"check_run":         cre.CheckRun.Name,
+		"id":                cre.CheckRun.ID,
+		"action":            cre.Action,
+	})
+	l.Infof("Check run %s.", cre.Action)
+	for p, h := range s.Plugins.CheckRunEventHandlers(cre.Repo.Owner.Login, cre.Repo.Name) {
+		s.wg.Add(1)
+		go func(p string, h plugins.CheckRunEventHandler) {
+			defer s.wg.Done()
+			agent := s.newAgent(l, cre.Repo.Owner.Login, p)
+			start := time.Now()
+			err := errorOnPanic(func() error { return h(agent, cre) })
+			labels := prometheus.Labels{"event_type": l.Data[eventTypeField].(string), "action": string(cre.Action), "plugin": p, "took_action": strconv.FormatBool(agent.TookAction())}
+			if err != nil {
+				agent.Logger.WithError(err).Error("Error handling CheckRunEvent.")
+				s.Metrics.PluginHandleErrors.With(labels).Inc()
+			}
+			s.Metrics.PluginHandleDuration.With(labels).Observe(time.Since(start).Seconds())
+		}(p, h)
+	}
+}
+
 func (s *Server) handleGenericComment(l *logrus.Entry, ce *github.GenericCommentEvent) {
 	for p, h := range s.Plugins.GenericCommentHandlers(ce.Repo.Owner.Login, ce.Repo.Name) {
 		s.wg.Add(1)
 		go func(p string, h plugins.GenericCommentHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, ce.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			agent := s.newAgent(l, ce.Repo.Owner.Login, p)
 			agent.InitializeCommentPruner(
 				ce.Repo.Owner.Login,
 				ce.Repo.Name,
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// HMACTokenStatus is the rotation status of an hmac token, without its value.
+type HMACTokenStatus struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired"`
+	// LastValidated is when the token last validated a webhook received by
+	// this replica of hook.
+	LastValidated *time.Time `json:"last_validated,omitempty"`
+	// Validations is the number of webhooks the token validated since this
+	// replica of hook started.
+	Validations int `json:"validations"`
+}
+
+type hmacUsageKey struct {
+	scope     string
+	createdAt time.Time
+}
+
+type hmacTokenUsage struct {
+	lastValidated time.Time
+	validations   int
+}
+
+// hmacUsage records which hmac tokens validated webhooks, so that rotations
+// can be followed: once the new token validates all webhooks of a scope, the
+// old one can expire.
+type hmacUsage struct {
+	lock   sync.Mutex
+	tokens map[hmacUsageKey]*hmacTokenUsage
+}
+
+func (u *hmacUsage) record(match *github.HMACMatch, now time.Time) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.tokens == nil {
+		u.tokens = map[hmacUsageKey]*hmacTokenUsage{}
+	}
+	key := hmacUsageKey{scope: match.Scope, createdAt: match.CreatedAt}
+	usage, ok := u.tokens[key]
+	if !ok {
+		usage = &hmacTokenUsage{}
+		u.tokens[key] = usage
+	}
+	usage.lastValidated = now
+	usage.validations++
+}
+
+// status returns the rotation status of the tokens of every scope.
+func (u *hmacUsage) status(tokens map[string]github.HMACsForRepo, now time.Time) map[string][]HMACTokenStatus {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	statuses := make(map[string][]HMACTokenStatus, len(tokens))
+	for scope, scopeTokens := range tokens {
+		for _, token := range scopeTokens {
+			status := HMACTokenStatus{
+				CreatedAt: token.CreatedAt,
+				ExpiresAt: token.ExpiresAt,
+				Expired:   token.Expired(now),
+			}
+			if usage, ok := u.tokens[hmacUsageKey{scope: scope, createdAt: token.CreatedAt}]; ok {
+				lastValidated := usage.lastValidated
+				status.LastValidated = &lastValidated
+				status.Validations = usage.validations
+			}
+			statuses[scope] = append(statuses[scope], status)
+		}
+	}
+	return statuses
+}
+
+// ServeHMACStatus serves the rotation status of the hmac tokens of every
+// scope as JSON. The values of the tokens aren't included.
+func (s *Server) ServeHMACStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := s.hmacUsage.status(github.ParseHMACTokens(s.TokenGenerator()), time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logrus.WithError(err).Error("Failed to write the hmac status.")
+	}
+}
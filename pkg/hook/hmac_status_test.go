@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func TestServeHMACStatus(t *testing.T) {
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expired := created.Add(time.Hour)
+	validated := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	tokens := `
+org:
+- value: old
+  created_at: 2024-02-01T00:00:00Z
+  expires_at: 2024-03-01T01:00:00Z
+- value: new
+  created_at: 2024-03-01T00:00:00Z
+`
+	s := &Server{TokenGenerator: func() []byte { return []byte(tokens) }}
+	s.hmacUsage.record(&github.HMACMatch{Scope: "org", CreatedAt: created, Algorithm: github.HMACAlgorithmSHA256}, validated.Add(-time.Hour))
+	s.hmacUsage.record(&github.HMACMatch{Scope: "org", CreatedAt: created, Algorithm: github.HMACAlgorithmSHA256}, validated)
+
+	rr := httptest.NewRecorder()
+	s.ServeHMACStatus(rr, httptest.NewRequest(http.MethodGet, "/hmac-status", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var status map[string][]HMACTokenStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to unmarshal the status: %v", err)
+	}
+	expected := map[string][]HMACTokenStatus{
+		"org": {
+			{CreatedAt: created.AddDate(0, -1, 0), ExpiresAt: &expired, Expired: true},
+			{CreatedAt: created, LastValidated: &validated, Validations: 2},
+		},
+	}
+	if diff := cmp.Diff(expected, status); diff != "" {
+		t.Errorf("unexpected status (-want +got):\n%s", diff)
+	}
+	if body := rr.Body.String(); strings.Contains(body, `"old"`) || strings.Contains(body, `"new"`) {
+		t.Errorf("the status must not contain the values of the tokens: %s", rr.Body.String())
+	}
+}
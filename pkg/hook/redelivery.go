@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+var redeliveredWebhooks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "prow_webhook_redeliveries",
+	Help: "A counter of the failed webhook deliveries of the GitHub App that hook asked GitHub to redeliver, by event type.",
+}, []string{"event_type"})
+
+func init() {
+	prometheus.MustRegister(redeliveredWebhooks)
+}
+
+// redeliveryClient is the part of the GitHub client the redelivery poller
+// needs.
+type redeliveryClient interface {
+	ListAppHookDeliveries(since time.Time) ([]github.HookDelivery, error)
+	RedeliverAppHookDelivery(id int64) error
+}
+
+// RedeliveryPoller redelivers the webhooks of the GitHub App that failed to
+// be delivered, usually because hook was down, so that the events aren't
+// lost once hook recovers.
+type RedeliveryPoller struct {
+	client redeliveryClient
+	// window is how old failed deliveries can be to be redelivered.
+	window time.Duration
+	// maxAttempts is how many times a webhook is delivered at most.
+	maxAttempts int
+	// requested are the GUIDs of the webhooks whose redelivery was requested,
+	// by when, as the redelivery is only listed once GitHub attempted it.
+	requested map[string]time.Time
+}
+
+// NewRedeliveryPoller returns a poller that redelivers the webhooks that
+// failed within the window, up to maxAttempts deliveries per webhook.
+func NewRedeliveryPoller(client redeliveryClient, window time.Duration, maxAttempts int) *RedeliveryPoller {
+	return &RedeliveryPoller{
+		client:      client,
+		window:      window,
+		maxAttempts: maxAttempts,
+		requested:   map[string]time.Time{},
+	}
+}
+
+// Poll requests the redelivery of every webhook delivered within the window
+// whose deliveries all failed.
+func (p *RedeliveryPoller) Poll(now time.Time) {
+	since := now.Add(-p.window)
+	for guid, requestedAt := range p.requested {
+		if requestedAt.Before(since) {
+			delete(p.requested, guid)
+		}
+	}
+
+	deliveries, err := p.client.ListAppHookDeliveries(since)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list the webhook deliveries of the GitHub App.")
+		return
+	}
+	// Deliveries are listed latest first, so the first delivery of a GUID
+	// is its latest attempt.
+	type webhook struct {
+		latest    github.HookDelivery
+		attempts  int
+		succeeded bool
+	}
+	webhooks := map[string]*webhook{}
+	var guids []string
+	for _, delivery := range deliveries {
+		w, ok := webhooks[delivery.GUID]
+		if !ok {
+			w = &webhook{latest: delivery}
+			webhooks[delivery.GUID] = w
+			guids = append(guids, delivery.GUID)
+		}
+		w.attempts++
+		w.succeeded = w.succeeded || delivery.Succeeded()
+	}
+
+	for _, guid := range guids {
+		w := webhooks[guid]
+		if w.succeeded || w.attempts >= p.maxAttempts {
+			continue
+		}
+		// A requested redelivery that GitHub attempted is listed after the
+		// request, so the webhook can be redelivered again if it failed.
+		if requestedAt, ok := p.requested[guid]; ok && !w.latest.DeliveredAt.After(requestedAt) {
+			continue
+		}
+		l := logrus.WithFields(logrus.Fields{
+			github.EventGUID: guid,
+			eventTypeField:   w.latest.Event,
+			"status-code":    w.latest.StatusCode,
+			"attempts":       w.attempts,
+		})
+		if err := p.client.RedeliverAppHookDelivery(w.latest.ID); err != nil {
+			l.WithError(err).Warn("Failed to redeliver the webhook.")
+			continue
+		}
+		l.Info("Requested the redelivery of a failed webhook.")
+		p.requested[guid] = now
+		redeliveredWebhooks.WithLabelValues(w.latest.Event).Inc()
+	}
+}
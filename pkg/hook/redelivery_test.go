@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+type fakeRedeliveryClient struct {
+	deliveries  []github.HookDelivery
+	redelivered []int64
+}
+
+func (f *fakeRedeliveryClient) ListAppHookDeliveries(since time.Time) ([]github.HookDelivery, error) {
+	var deliveries []github.HookDelivery
+	for _, delivery := range f.deliveries {
+		if !delivery.DeliveredAt.Before(since) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (f *fakeRedeliveryClient) RedeliverAppHookDelivery(id int64) error {
+	f.redelivered = append(f.redelivered, id)
+	return nil
+}
+
+func TestRedeliveryPoller(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		deliveries []github.HookDelivery
+		requested  map[string]time.Time
+		expected   []int64
+	}{
+		{
+			name: "failed webhooks are redelivered",
+			deliveries: []github.HookDelivery{
+				{ID: 3, GUID: "c", DeliveredAt: now.Add(-time.Minute), StatusCode: 200},
+				{ID: 2, GUID: "b", DeliveredAt: now.Add(-2 * time.Minute), StatusCode: 502},
+				{ID: 1, GUID: "a", DeliveredAt: now.Add(-3 * time.Minute)},
+			},
+			expected: []int64{2, 1},
+		},
+		{
+			name: "webhooks whose redelivery succeeded aren't redelivered",
+			deliveries: []github.HookDelivery{
+				{ID: 2, GUID: "a", DeliveredAt: now.Add(-time.Minute), StatusCode: 200, Redelivery: true},
+				{ID: 1, GUID: "a", DeliveredAt: now.Add(-2 * time.Minute), StatusCode: 502},
+			},
+		},
+		{
+			name: "the latest failed attempt is redelivered until the max attempts",
+			deliveries: []github.HookDelivery{
+				{ID: 4, GUID: "b", DeliveredAt: now.Add(-time.Minute), StatusCode: 502, Redelivery: true},
+				{ID: 3, GUID: "a", DeliveredAt: now.Add(-time.Minute), StatusCode: 502, Redelivery: true},
+				{ID: 2, GUID: "a", DeliveredAt: now.Add(-2 * time.Minute), StatusCode: 502, Redelivery: true},
+				{ID: 1, GUID: "a", DeliveredAt: now.Add(-3 * time.Minute), StatusCode: 502},
+				{ID: 0, GUID: "b", DeliveredAt: now.Add(-3 * time.Minute), StatusCode: 502},
+			},
+			expected: []int64{4},
+		},
+		{
+			name: "webhooks whose redelivery wasn't attempted yet aren't redelivered again",
+			deliveries: []github.HookDelivery{
+				{ID: 2, GUID: "b", DeliveredAt: now.Add(-time.Minute), StatusCode: 502, Redelivery: true},
+				{ID: 1, GUID: "a", DeliveredAt: now.Add(-10 * time.Minute), StatusCode: 502},
+				{ID: 0, GUID: "b", DeliveredAt: now.Add(-10 * time.Minute), StatusCode: 502},
+			},
+			requested: map[string]time.Time{
+				"a": now.Add(-5 * time.Minute),
+				"b": now.Add(-5 * time.Minute),
+			},
+			expected: []int64{2},
+		},
+		{
+			name: "deliveries older than the window are ignored",
+			deliveries: []github.HookDelivery{
+				{ID: 1, GUID: "a", DeliveredAt: now.Add(-2 * time.Hour), StatusCode: 502},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeRedeliveryClient{deliveries: tc.deliveries}
+			poller := NewRedeliveryPoller(client, time.Hour, 3)
+			for guid, requestedAt := range tc.requested {
+				poller.requested[guid] = requestedAt
+			}
+			poller.Poll(now)
+			if diff := cmp.Diff(tc.expected, client.redelivered); diff != "" {
+				t.Errorf("unexpected redeliveries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
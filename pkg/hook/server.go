@@ -51,11 +51,13 @@ type Server struct {
 	c http.Client
 	// Tracks running handlers for graceful shutdown
 	wg sync.WaitGroup
+	// hmacUsage tracks which hmac tokens validated webhooks.
+	hmacUsage hmacUsage
 }
 
 // ServeHTTP validates an incoming webhook and puts it into the event channel.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	eventType, eventGUID, payload, ok, resp := github.ValidateWebhook(w, r, s.TokenGenerator)
+	eventType, eventGUID, payload, match, resp := github.ValidateWebhookHMAC(w, r, s.TokenGenerator)
 	if counter, err := s.Metrics.ResponseCounter.GetMetricWithLabelValues(strconv.Itoa(resp)); err != nil {
 		logrus.WithFields(logrus.Fields{
 			"status-code": resp,
@@ -64,9 +66,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		counter.Inc()
 	}
 
-	if !ok {
+	if match == nil {
 		return
 	}
+	s.Metrics.CountHMACValidation(match)
+	s.hmacUsage.record(match, time.Now())
 	fmt.Fprint(w, "Event received. Have a nice day.")
 
 	if err := s.demuxEvent(eventType, eventGUID, payload, r.Header); err != nil {
@@ -77,8 +81,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.Header) error {
 	l := logrus.WithFields(
 		logrus.Fields{
-			eventTypeField:   eventType,
-			github.EventGUID: eventGUID,
+			eventTypeField:     eventType,
+			github.EventGUID:   eventGUID,
+			eventReceivedField: time.Now(),
 		},
 	)
 	// We don't want to fail the webhook due to a metrics error.
@@ -166,6 +171,17 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 			s.wg.Add(1)
 			go s.handleStatusEvent(l, se)
 		}
+	case "check_run":
+		var cre github.CheckRunEvent
+		if err := json.Unmarshal(payload, &cre); err != nil {
+			return err
+		}
+		cre.GUID = eventGUID
+		srcRepo = cre.Repo.FullName
+		if s.RepoEnabled(cre.Repo.Owner.Login, cre.Repo.Name) {
+			s.wg.Add(1)
+			go s.handleCheckRunEvent(l, cre)
+		}
 	default:
 		var ge github.GenericEvent
 		if err := json.Unmarshal(payload, &ge); err != nil {
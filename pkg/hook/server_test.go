@@ -18,6 +18,7 @@ package hook
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -25,11 +26,16 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
 	"sigs.k8s.io/prow/pkg/githubeventserver"
+	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
 
@@ -578,3 +584,24 @@ foo/bar:
 		})
 	}
 }
+
+func TestEventReceivedProwJobClient(t *testing.T) {
+	received := time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC)
+	cs := fake.NewSimpleClientset()
+	client := &eventReceivedProwJobClient{ProwJobInterface: cs.ProwV1().ProwJobs("prowjobs"), received: received, eventType: "pull_request"}
+	pj := &prowapi.ProwJob{ObjectMeta: metav1.ObjectMeta{Name: "job", Annotations: map[string]string{"foo": "bar"}}}
+	if _, err := client.Create(context.Background(), pj, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create ProwJob: %v", err)
+	}
+	created, err := cs.ProwV1().ProwJobs("prowjobs").Get(context.Background(), "job", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ProwJob: %v", err)
+	}
+	expected := map[string]string{"foo": "bar", kube.EventReceivedAnnotation: "2024-05-01T10:00:00.123Z", kube.EventTypeAnnotation: "pull_request"}
+	if diff := cmp.Diff(expected, created.Annotations); diff != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+	}
+	if _, ok := pj.Annotations[kube.EventReceivedAnnotation]; ok {
+		t.Error("the ProwJob passed to Create was modified")
+	}
+}
@@ -208,6 +208,13 @@ func shutdown(server Shutdownable, gracePeriod time.Duration) func() {
 // expected to exit only after WaitForGracefulShutdown returns to
 // ensure all workers have had time to shut down.
 func Tick(work func(), interval func() time.Duration) {
+	TickWithTrigger(work, interval, nil)
+}
+
+// TickWithTrigger runs Tick, but also does work right away whenever the
+// trigger fires, e.g. when the config changes. The next tick is scheduled
+// relative to the triggered work.
+func TickWithTrigger(work func(), interval func() time.Duration, trigger <-chan struct{}) {
 	before := time.Time{} // we want to do work right away
 	sig := make(chan int, 1)
 	single.wg.Add(1)
@@ -226,6 +233,10 @@ func Tick(work func(), interval func() time.Duration) {
 			case <-time.After(sleep):
 				before = time.Now()
 				work()
+			case <-trigger:
+				logrus.Debug("Triggered work before the next tick.")
+				before = time.Now()
+				work()
 			case <-sig:
 				logrus.Info("Worker shutting down...")
 				return
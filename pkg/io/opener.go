@@ -93,6 +93,7 @@ type Opener interface {
 	SignedURL(ctx context.Context, path string, opts SignedURLOptions) (string, error)
 	Iterator(ctx context.Context, prefix, delimiter string) (ObjectIterator, error)
 	UpdateAttributes(context.Context, string, ObjectAttrsToUpdate) (*Attributes, error)
+	Delete(ctx context.Context, path string) error
 }
 
 type opener struct {
@@ -391,6 +392,64 @@ func (o *opener) UpdateAttributes(ctx context.Context, path string, attrs Object
 	}, nil
 }
 
+// Delete deletes the object at the path, returning an IsNotExist() error
+// when it's missing.
+func (o *opener) Delete(ctx context.Context, path string) error {
+	if strings.HasPrefix(path, providers.GS+"://") {
+		g, err := o.openGCS(path)
+		if err != nil {
+			return fmt.Errorf("bad gcs path: %w", err)
+		}
+		return g.Delete(ctx)
+	}
+
+	bucket, relativePath, err := o.getBucket(ctx, path)
+	if err != nil {
+		return err
+	}
+	return bucket.Delete(ctx, relativePath)
+}
+
+// Composer can concatenate objects into one object.
+type Composer interface {
+	// Compose writes the concatenation of the source objects to the
+	// destination object, applying the options to it.
+	Compose(ctx context.Context, dest string, sources []string, opts ...WriterOptions) error
+}
+
+var _ Composer = &opener{}
+
+// Compose concatenates GCS objects of the same bucket. Other storage
+// providers can't compose objects.
+func (o *opener) Compose(ctx context.Context, dest string, sources []string, opts ...WriterOptions) error {
+	if !strings.HasPrefix(dest, providers.GS+"://") {
+		return fmt.Errorf("composing objects is only supported on GCS, not %q", dest)
+	}
+	d, err := o.openGCS(dest)
+	if err != nil {
+		return fmt.Errorf("bad gcs path: %w", err)
+	}
+	var srcs []*storage.ObjectHandle
+	for _, source := range sources {
+		src, err := o.openGCS(source)
+		if err != nil || src == nil {
+			return fmt.Errorf("bad gcs path %q: %v", source, err)
+		}
+		if src.BucketName() != d.BucketName() {
+			return fmt.Errorf("can't compose %q from another bucket", source)
+		}
+		srcs = append(srcs, src)
+	}
+	options := &WriterOptions{}
+	for _, opt := range opts {
+		opt.Apply(options)
+	}
+	composer := d.ComposerFrom(srcs...)
+	options.applyAttrs(&composer.ObjectAttrs)
+	_, err = composer.Run(ctx)
+	return err
+}
+
 const (
 	GSAnonHost   = "storage.googleapis.com"
 	GSCookieHost = "storage.cloud.google.com"
@@ -401,6 +460,10 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 	if err != nil {
 		return "", fmt.Errorf("could not get bucket: %w", err)
 	}
+	expiry := 10 * time.Minute
+	if opts.Expiry > 0 {
+		expiry = opts.Expiry
+	}
 	if strings.HasPrefix(p, providers.GS+"://") {
 		// We specifically want to use cookie auth, see:
 		// https://cloud.google.com/storage/docs/access-control/cookie-based-authentication
@@ -444,7 +507,7 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 		}
 		return storage.SignedURL(bucketName, relativePath, &storage.SignedURLOptions{
 			Method:         "GET",
-			Expires:        time.Now().Add(10 * time.Minute),
+			Expires:        time.Now().Add(expiry),
 			GoogleAccessID: auth.ClientEmail,
 			PrivateKey:     []byte(auth.PrivateKey),
 		})
@@ -456,7 +519,7 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 	}
 	return bucket.SignedURL(ctx, relativePath, &blob.SignedURLOptions{
 		Method: "GET",
-		Expiry: 10 * time.Minute,
+		Expiry: expiry,
 	})
 }
 
@@ -478,7 +541,7 @@ func (o *opener) Iterator(ctx context.Context, prefix, delimiter string) (Object
 		}
 		if delimiter == "" {
 			// query.SetAttrSelection cannot be used in directory-like mode (when delimiter != "").
-			if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+			if err := query.SetAttrSelection([]string{"Name", "Size", "Updated"}); err != nil {
 				return nil, err
 			}
 		}
@@ -17,6 +17,8 @@ limitations under the License.
 package io
 
 import (
+	"time"
+
 	"cloud.google.com/go/storage"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"gocloud.dev/blob"
@@ -63,18 +65,7 @@ func (wo WriterOptions) apply(writer *storage.Writer, o *blob.WriterOptions) {
 				writer.ChunkSize = int(*wo.BufferSize)
 			}
 		}
-		if wo.ContentEncoding != nil {
-			writer.ObjectAttrs.ContentEncoding = *wo.ContentEncoding
-		}
-		if wo.ContentType != nil {
-			writer.ObjectAttrs.ContentType = *wo.ContentType
-		}
-		if wo.Metadata != nil {
-			writer.ObjectAttrs.Metadata = wo.Metadata
-		}
-		if wo.CacheControl != nil {
-			writer.ObjectAttrs.CacheControl = *wo.CacheControl
-		}
+		wo.applyAttrs(&writer.ObjectAttrs)
 	}
 
 	if o == nil {
@@ -104,9 +95,27 @@ func (wo WriterOptions) apply(writer *storage.Writer, o *blob.WriterOptions) {
 	}
 }
 
+// applyAttrs applies the WriterOptions to the attributes of a GCS object.
+func (wo WriterOptions) applyAttrs(attrs *storage.ObjectAttrs) {
+	if wo.ContentEncoding != nil {
+		attrs.ContentEncoding = *wo.ContentEncoding
+	}
+	if wo.ContentType != nil {
+		attrs.ContentType = *wo.ContentType
+	}
+	if wo.Metadata != nil {
+		attrs.Metadata = wo.Metadata
+	}
+	if wo.CacheControl != nil {
+		attrs.CacheControl = *wo.CacheControl
+	}
+}
+
 // SignedURLOptions are options for the opener SignedURL method
 type SignedURLOptions struct {
 	// UseGSCookieAuth defines if we should use cookie auth for GCS, see:
 	// https://cloud.google.com/storage/docs/access-control/cookie-based-authentication
 	UseGSCookieAuth bool
+	// Expiry is how long the signed URL is valid for. Defaults to 10 minutes.
+	Expiry time.Duration
 }
@@ -60,12 +60,17 @@ type syncFn func(prowapi.ProwJob, chan<- prowapi.ProwJob, map[string]Build) erro
 // Controller manages ProwJobs.
 type Controller struct {
 	prowJobClient prowJobClient
-	jc            jenkinsClient
-	ghc           githubClient
-	log           *logrus.Entry
-	cfg           config.Getter
-	node          *snowflake.Node
-	totURL        string
+	// jc is the client for the default Jenkins master.
+	jc jenkinsClient
+	// masters returns the clients for the other Jenkins masters.
+	masters func(master *config.JenkinsMaster, job string) (jenkinsClient, error)
+	// logClient returns the client to read logs from Jenkins masters with.
+	logClient func(master *config.JenkinsMaster, job string) (*Client, error)
+	ghc       githubClient
+	log       *logrus.Entry
+	cfg       config.Getter
+	node      *snowflake.Node
+	totURL    string
 	// if skip report job results to github
 	skipReport bool
 	// selector that will be applied on prowjobs.
@@ -91,30 +96,39 @@ func NewController(prowJobClient prowv1.ProwJobInterface, jc *Client, ghc github
 	if logger == nil {
 		logger = logrus.NewEntry(logrus.StandardLogger())
 	}
+	masters := newMasterClients(jc)
 	return &Controller{
 		prowJobClient: prowJobClient,
 		jc:            jc,
-		ghc:           ghc,
-		log:           logger,
-		cfg:           cfg,
-		selector:      selector,
-		node:          n,
-		totURL:        totURL,
-		skipReport:    skipReport,
-		pendingJobs:   make(map[string]int),
-		clock:         clock.RealClock{},
+		masters: func(master *config.JenkinsMaster, job string) (jenkinsClient, error) {
+			return masters.client(master, job)
+		},
+		logClient:   masters.client,
+		ghc:         ghc,
+		log:         logger,
+		cfg:         cfg,
+		selector:    selector,
+		node:        n,
+		totURL:      totURL,
+		skipReport:  skipReport,
+		pendingJobs: make(map[string]int),
+		clock:       clock.RealClock{},
 	}, nil
 }
 
 func (c *Controller) config() config.Controller {
+	return c.operatorConfig().Controller
+}
+
+func (c *Controller) operatorConfig() config.JenkinsOperator {
 	operators := c.cfg().JenkinsOperators
 	if len(operators) == 1 {
-		return operators[0].Controller
+		return operators[0]
 	}
 	configured := make([]string, 0, len(operators))
 	for _, cfg := range operators {
 		if cfg.LabelSelectorString == c.selector {
-			return cfg.Controller
+			return cfg
 		}
 		configured = append(configured, cfg.LabelSelectorString)
 	}
@@ -123,7 +137,49 @@ func (c *Controller) config() config.Controller {
 	} else {
 		c.log.Panicf("No config exists for --label-selector=%s.", c.selector)
 	}
-	return config.Controller{}
+	return config.JenkinsOperator{}
+}
+
+// master returns the Jenkins master of the ProwJob, or nil for the default
+// master. ProwJobs that were started already stay on their master.
+func (c *Controller) master(pj *prowapi.ProwJob) (*config.JenkinsMaster, error) {
+	if name, ok := pj.Annotations[MasterAnnotation]; ok {
+		if name == "" {
+			return nil, nil
+		}
+		return c.operatorConfig().Master(name)
+	}
+	return c.operatorConfig().MasterFor(pj), nil
+}
+
+// clientFor returns the client for the Jenkins master of the ProwJob and
+// the master, which is nil for the default master.
+func (c *Controller) clientFor(pj *prowapi.ProwJob) (jenkinsClient, *config.JenkinsMaster, error) {
+	if c.masters == nil {
+		return c.jc, nil, nil
+	}
+	master, err := c.master(pj)
+	if err != nil {
+		return nil, nil, err
+	}
+	if master == nil {
+		return c.jc, nil, nil
+	}
+	jc, err := c.masters(master, pj.Spec.Job)
+	return jc, master, err
+}
+
+// MasterClient returns the client for the Jenkins job with the given name
+// on the master with the given name, or the default master if it's empty.
+func (c *Controller) MasterClient(name, job string) (*Client, error) {
+	if name == "" {
+		return c.logClient(nil, job)
+	}
+	master, err := c.operatorConfig().Master(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.logClient(master, job)
 }
 
 // canExecuteConcurrently checks whether the provided ProwJob can
@@ -184,12 +240,37 @@ func (c *Controller) Sync() error {
 			jenkinsJobs = append(jenkinsJobs, pj)
 		}
 	}
-	jbs, err := c.jc.ListBuilds(getJenkinsJobs(jenkinsJobs))
-	if err != nil {
-		return fmt.Errorf("error listing jenkins builds: %w", err)
-	}
 
 	var syncErrs []error
+	// List the builds of every master once.
+	var clients []jenkinsClient
+	jobsByClient := map[jenkinsClient][]prowapi.ProwJob{}
+	for _, pj := range jenkinsJobs {
+		jc, _, err := c.clientFor(&pj)
+		if err != nil {
+			c.log.WithError(err).WithFields(pjutil.ProwJobFields(&pj)).Warn("Cannot determine the Jenkins master.")
+			syncErrs = append(syncErrs, err)
+			continue
+		}
+		if _, ok := jobsByClient[jc]; !ok {
+			clients = append(clients, jc)
+		}
+		jobsByClient[jc] = append(jobsByClient[jc], pj)
+	}
+	if len(clients) == 0 {
+		clients = append(clients, c.jc)
+	}
+	jbs := map[string]Build{}
+	for _, jc := range clients {
+		builds, err := jc.ListBuilds(getJenkinsJobs(jobsByClient[jc]))
+		if err != nil {
+			return fmt.Errorf("error listing jenkins builds: %w", err)
+		}
+		for id, build := range builds {
+			jbs[id] = build
+		}
+	}
+
 	if err := c.terminateDupes(jenkinsJobs, jbs); err != nil {
 		syncErrs = append(syncErrs, err)
 	}
@@ -294,7 +375,11 @@ func (c *Controller) terminateDupes(pjs []prowapi.ProwJob, jbs map[string]Build)
 		}
 		// Otherwise, abort it.
 		if buildExists {
-			if err := c.jc.Abort(getJobName(&toCancel.Spec), &build); err != nil {
+			jc, _, err := c.clientFor(&toCancel)
+			if err == nil {
+				err = jc.Abort(getJobName(&toCancel.Spec), &build)
+			}
+			if err != nil {
 				c.log.WithError(err).WithFields(pjutil.ProwJobFields(&toCancel)).Warn("Cannot cancel Jenkins build")
 			}
 		}
@@ -414,7 +499,11 @@ func (c *Controller) syncAbortedJob(pj prowapi.ProwJob, _ chan<- prowapi.ProwJob
 	}
 
 	if build, exists := jbs[pj.Name]; exists {
-		if err := c.jc.Abort(getJobName(&pj.Spec), &build); err != nil {
+		jc, _, err := c.clientFor(&pj)
+		if err != nil {
+			return fmt.Errorf("failed to abort Jenkins build: %w", err)
+		}
+		if err := jc.Abort(getJobName(&pj.Spec), &build); err != nil {
 			return fmt.Errorf("failed to abort Jenkins build: %w", err)
 		}
 	}
@@ -438,8 +527,18 @@ func (c *Controller) syncTriggeredJob(pj prowapi.ProwJob, reports chan<- prowapi
 		if err != nil {
 			return fmt.Errorf("error getting build ID: %w", err)
 		}
+		jc, master, err := c.clientFor(&pj)
+		if err != nil {
+			return fmt.Errorf("error getting the Jenkins client: %w", err)
+		}
+		if master != nil {
+			if pj.Annotations == nil {
+				pj.Annotations = map[string]string{}
+			}
+			pj.Annotations[MasterAnnotation] = master.Name
+		}
 		// Start the Jenkins job.
-		if err := c.jc.Build(&pj, buildID); err != nil {
+		if err := jc.Build(&pj, buildID); err != nil {
 			c.log.WithError(err).WithFields(pjutil.ProwJobFields(&pj)).Warn("Cannot start Jenkins build")
 			pj.SetComplete()
 			pj.Status.State = prowapi.ErrorState
@@ -186,6 +186,15 @@ func (f *fghc) EditCommentWithContext(_ context.Context, org, repo string, ID in
 	defer f.Unlock()
 	return nil
 }
+func (f *fghc) ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error) {
+	return &github.CheckRunList{}, nil
+}
+func (f *fghc) CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error) {
+	return 0, nil
+}
+func (f *fghc) UpdateCheckRun(org, repo string, checkRunId int64, checkRun github.CheckRun) error {
+	return nil
+}
 
 func TestSyncTriggeredJobs(t *testing.T) {
 	fakeClock := clocktesting.NewFakeClock(time.Now().Truncate(1 * time.Second))
@@ -1130,3 +1139,114 @@ func TestSyncAbortedJob(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncTriggeredJobMasters(t *testing.T) {
+	masters := []config.JenkinsMaster{
+		{Name: "team", URL: "https://team.jenkins.example.com", Repos: []string{"team-org"}},
+		{Name: "infra", URL: "https://infra.jenkins.example.com", Repos: []string{"org/infra"}},
+	}
+	testCases := []struct {
+		name        string
+		refs        *prowapi.Refs
+		annotations map[string]string
+
+		expectedMaster     string
+		expectedAnnotation bool
+	}{
+		{
+			name: "job of an org with a master",
+			refs: &prowapi.Refs{Org: "team-org", Repo: "repo"},
+
+			expectedMaster:     "team",
+			expectedAnnotation: true,
+		},
+		{
+			name: "job of a repo with a master",
+			refs: &prowapi.Refs{Org: "org", Repo: "infra"},
+
+			expectedMaster:     "infra",
+			expectedAnnotation: true,
+		},
+		{
+			name: "job of another repo runs on the default master",
+			refs: &prowapi.Refs{Org: "org", Repo: "other"},
+		},
+		{
+			name:        "annotated job stays on its master",
+			refs:        &prowapi.Refs{Org: "org", Repo: "other"},
+			annotations: map[string]string{MasterAnnotation: "team"},
+
+			expectedMaster:     "team",
+			expectedAnnotation: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			totServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "42")
+			}))
+			defer totServ.Close()
+			pj := prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "prowjobs",
+					Annotations: tc.annotations,
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type: prowapi.PostsubmitJob,
+					Job:  "job",
+					Refs: tc.refs,
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			}
+			fakeProwJobClient := fake.NewSimpleClientset(&pj)
+			ca := newFakeConfigAgent(t, 0, nil)
+			ca.c.JenkinsOperators[0].Masters = masters
+
+			defaultClient := &fjc{}
+			masterClients := map[string]*fjc{}
+			c := Controller{
+				prowJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				jc:            defaultClient,
+				masters: func(master *config.JenkinsMaster, job string) (jenkinsClient, error) {
+					if _, ok := masterClients[master.Name]; !ok {
+						masterClients[master.Name] = &fjc{}
+					}
+					return masterClients[master.Name], nil
+				},
+				log:         logrus.NewEntry(logrus.StandardLogger()),
+				cfg:         ca.Config,
+				totURL:      totServ.URL,
+				pendingJobs: make(map[string]int),
+				clock:       clocktesting.NewFakeClock(time.Now()),
+			}
+
+			if err := c.syncTriggeredJob(pj, make(chan prowapi.ProwJob, 1), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			builtOn := ""
+			if !defaultClient.built {
+				for name, client := range masterClients {
+					if client.built {
+						builtOn = name
+					}
+				}
+			}
+			if builtOn != tc.expectedMaster {
+				t.Errorf("expected the build to start on master %q, started on %q", tc.expectedMaster, builtOn)
+			}
+
+			actual, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").Get(context.Background(), pj.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get prowjob: %v", err)
+			}
+			annotation, ok := actual.Annotations[MasterAnnotation]
+			if ok != tc.expectedAnnotation || annotation != tc.expectedMaster {
+				t.Errorf("expected master annotation %q, got %q", tc.expectedMaster, annotation)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkins
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/secret"
+)
+
+// MasterAnnotation records the name of the Jenkins master a ProwJob was
+// started on, so it's synced with the same master if the config changes.
+// ProwJobs started on the default master don't have it.
+const MasterAnnotation = "prow.k8s.io/jenkins-master"
+
+// masterClients creates and caches the clients for the Jenkins masters and
+// folders of the config.
+type masterClients struct {
+	// defaultClient is the client for the master given with --jenkins-url.
+	defaultClient *Client
+	// addSecrets starts loading the given credential files.
+	addSecrets func(paths ...string) error
+	// getToken returns a getter for the content of a credential file.
+	getToken func(path string) func() []byte
+
+	lock    sync.Mutex
+	clients map[string]*Client
+}
+
+func newMasterClients(defaultClient *Client) *masterClients {
+	return &masterClients{
+		defaultClient: defaultClient,
+		addSecrets:    secret.Add,
+		getToken:      secret.GetTokenGenerator,
+		clients:       map[string]*Client{},
+	}
+}
+
+// client returns the client for the given Jenkins job on the master, with
+// the credentials of the folder of the job if it has any.
+func (m *masterClients) client(master *config.JenkinsMaster, job string) (*Client, error) {
+	if master == nil {
+		return m.defaultClient, nil
+	}
+	folder, credentials := master.CredentialsFor(job)
+	// Keying by the URL and credentials too creates a new client
+	// when they are changed in the config.
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%+v", master.Name, folder, master.URL, credentials)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if client, ok := m.clients[key]; ok {
+		return client, nil
+	}
+
+	authConfig := &AuthConfig{CSRFProtect: credentials.CSRFProtect}
+	switch {
+	case credentials.TokenFile != "":
+		if err := m.addSecrets(credentials.TokenFile); err != nil {
+			return nil, fmt.Errorf("failed to load the token of Jenkins master %q: %w", master.Name, err)
+		}
+		authConfig.Basic = &BasicAuthConfig{User: credentials.User, GetToken: m.getToken(credentials.TokenFile)}
+	case credentials.BearerTokenFile != "":
+		if err := m.addSecrets(credentials.BearerTokenFile); err != nil {
+			return nil, fmt.Errorf("failed to load the bearer token of Jenkins master %q: %w", master.Name, err)
+		}
+		authConfig.BearerToken = &BearerTokenAuthConfig{GetToken: m.getToken(credentials.BearerTokenFile)}
+	}
+
+	d := m.defaultClient
+	client := &Client{
+		logger:     d.logger.WithField("master", master.Name),
+		dryRun:     d.dryRun,
+		baseURL:    master.URL,
+		authConfig: authConfig,
+		client: &http.Client{
+			Timeout:   d.client.Timeout,
+			Transport: d.client.Transport,
+		},
+		metrics: d.metrics,
+	}
+	if authConfig.CSRFProtect {
+		if err := client.CrumbRequest(); err != nil {
+			return nil, fmt.Errorf("cannot get Jenkins crumb from master %q: %w", master.Name, err)
+		}
+	}
+	m.clients[key] = client
+	return client, nil
+}
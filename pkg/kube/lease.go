@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodLeaseAnnotation is set on the pod of a ProwJob by the plank replica that
+// is updating the ProwJob based on the pod, so that other plank replicas and
+// sinker leave the pod alone until the update reached their caches. It holds
+// the holder of the lease and the RFC3339 time it expires, separated by a
+// space.
+const PodLeaseAnnotation = "prow.k8s.io/lease"
+
+// PodLease is the lease held on a pod.
+type PodLease struct {
+	Holder  string
+	Expires time.Time
+}
+
+// PodLeaseOf returns the lease held on the pod, if any. Leases that can't be
+// parsed are ignored, as they can't be renewed either.
+func PodLeaseOf(pod *corev1.Pod) (PodLease, bool) {
+	holder, expires, ok := strings.Cut(pod.Annotations[PodLeaseAnnotation], " ")
+	if !ok {
+		return PodLease{}, false
+	}
+	expiry, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return PodLease{}, false
+	}
+	return PodLease{Holder: holder, Expires: expiry}, true
+}
+
+// Active returns whether the lease hasn't expired yet.
+func (l PodLease) Active(now time.Time) bool {
+	return now.Before(l.Expires)
+}
+
+// String returns the value of the PodLeaseAnnotation holding the lease.
+func (l PodLease) String() string {
+	return fmt.Sprintf("%s %s", l.Holder, l.Expires.UTC().Format(time.RFC3339))
+}
+
+// PodLeased returns whether someone but the holder holds an active lease on
+// the pod.
+func PodLeased(pod *corev1.Pod, holder string, now time.Time) bool {
+	lease, ok := PodLeaseOf(pod)
+	return ok && lease.Holder != holder && lease.Active(now)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodLeased(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		annotation string
+		holder     string
+		expected   bool
+	}{
+		{
+			name: "no lease",
+		},
+		{
+			name:       "active lease of someone else",
+			annotation: PodLease{Holder: "other", Expires: now.Add(time.Second)}.String(),
+			holder:     "me",
+			expected:   true,
+		},
+		{
+			name:       "active lease of the holder",
+			annotation: PodLease{Holder: "me", Expires: now.Add(time.Second)}.String(),
+			holder:     "me",
+		},
+		{
+			name:       "expired lease",
+			annotation: PodLease{Holder: "other", Expires: now}.String(),
+			holder:     "me",
+		},
+		{
+			name:       "invalid lease",
+			annotation: "other tomorrow",
+			holder:     "me",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if tc.annotation != "" {
+				pod.Annotations[PodLeaseAnnotation] = tc.annotation
+			}
+			if actual := PodLeased(pod, tc.holder, now); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -52,6 +52,19 @@ var (
 		Name: "prowjob_state_transitions",
 		Help: "Number of prowjobs transitioning states.",
 	}, metricLabels)
+	// prowJobsPendingByJobClass is meant to drive the autoscaling of the node
+	// pools job classes are scheduled on, so it is kept small on purpose.
+	prowJobsPendingByJobClass = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prowjobs_pending_by_job_class",
+		Help: "Number of triggered and pending prowjobs per job class.",
+	}, []string{
+		// the job class of the prowjob
+		"job_class",
+		// the cluster the job runs on
+		"cluster",
+		// state of the prowjob: triggered or pending
+		"state",
+	})
 )
 
 type jobLabel struct {
@@ -73,6 +86,7 @@ func (jl *jobLabel) values() []string {
 func init() {
 	prometheus.MustRegister(prowJobs)
 	prometheus.MustRegister(prowJobTransitions)
+	prometheus.MustRegister(prowJobsPendingByJobClass)
 }
 
 func getJobLabelMap(pjs []prowapi.ProwJob) map[jobLabel]float64 {
@@ -106,6 +120,29 @@ func getJobLabel(pj prowapi.ProwJob) jobLabel {
 	return jl
 }
 
+type jobClassLabel struct {
+	jobClass string
+	cluster  string
+	state    string
+}
+
+// getPendingByJobClass counts the prowjobs that are waiting for or running
+// on the nodes of their job class.
+func getPendingByJobClass(pjs []prowapi.ProwJob) map[jobClassLabel]float64 {
+	counts := map[jobClassLabel]float64{}
+	for _, pj := range pjs {
+		jobClass, ok := pj.Labels[JobClassLabel]
+		if !ok {
+			continue
+		}
+		if pj.Status.State != prowapi.TriggeredState && pj.Status.State != prowapi.PendingState {
+			continue
+		}
+		counts[jobClassLabel{jobClass: jobClass, cluster: pj.Spec.Cluster, state: string(pj.Status.State)}]++
+	}
+	return counts
+}
+
 type jobIdentifier struct {
 	jobLabel
 	buildId string
@@ -133,6 +170,11 @@ func GatherProwJobMetrics(l *logrus.Entry, current []prowapi.ProwJob) {
 		prowJobs.WithLabelValues(jl.values()...).Set(count)
 	}
 
+	prowJobsPendingByJobClass.Reset()
+	for key, count := range getPendingByJobClass(current) {
+		prowJobsPendingByJobClass.WithLabelValues(key.jobClass, key.cluster, key.state).Set(count)
+	}
+
 	// record state transitions since the last time we were called
 	currentStates := map[jobIdentifier]prowapi.ProwJobState{}
 	for _, pj := range current {
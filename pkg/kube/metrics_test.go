@@ -138,3 +138,34 @@ func TestGetJobLabelMap(t *testing.T) {
 		t.Errorf("Unexpected mis-match: %s", diff.ObjectReflectDiff(expected, jobLabelMap))
 	}
 }
+
+func TestGetPendingByJobClass(t *testing.T) {
+	newProwJob := func(jobClass, cluster string, state prowapi.ProwJobState) prowapi.ProwJob {
+		pj := prowapi.ProwJob{
+			Spec:   prowapi.ProwJobSpec{Cluster: cluster},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+		if jobClass != "" {
+			pj.Labels = map[string]string{JobClassLabel: jobClass}
+		}
+		return pj
+	}
+	pjs := []prowapi.ProwJob{
+		newProwJob("e2e", "default", prowapi.TriggeredState),
+		newProwJob("e2e", "default", prowapi.TriggeredState),
+		newProwJob("e2e", "default", prowapi.PendingState),
+		newProwJob("e2e", "default", prowapi.SuccessState),
+		newProwJob("e2e", "other", prowapi.PendingState),
+		newProwJob("unit", "default", prowapi.PendingState),
+		newProwJob("", "default", prowapi.PendingState),
+	}
+	expected := map[jobClassLabel]float64{
+		{jobClass: "e2e", cluster: "default", state: "triggered"}: 2,
+		{jobClass: "e2e", cluster: "default", state: "pending"}:   1,
+		{jobClass: "e2e", cluster: "other", state: "pending"}:     1,
+		{jobClass: "unit", cluster: "default", state: "pending"}:  1,
+	}
+	if actual := getPendingByJobClass(pjs); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Unexpected mis-match: %s", diff.ObjectReflectDiff(expected, actual))
+	}
+}
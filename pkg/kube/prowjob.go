@@ -69,6 +69,45 @@ const (
 	// IsOptionalLabel is added in resources created by prow and
 	// carries the Optional from a Presubmit job.
 	IsOptionalLabel = "prow.k8s.io/is-optional"
+	// OwnerTeamLabel is added in resources created by prow and
+	// carries the name of the team owning the job.
+	OwnerTeamLabel = "prow.k8s.io/owner-team"
+	// OwnerSlackChannelAnnotation is added in resources created by prow
+	// and carries the Slack channel of the team owning the job.
+	OwnerSlackChannelAnnotation = "prow.k8s.io/owner-slack-channel"
+	// OwnerEscalationURLAnnotation is added in resources created by prow
+	// and carries the URL describing how to escalate problems with the job.
+	OwnerEscalationURLAnnotation = "prow.k8s.io/owner-escalation-url"
+	// JobClassLabel is added in resources created by prow and carries the
+	// job class the job belongs to.
+	JobClassLabel = "prow.k8s.io/job-class"
+	// AutoDisableNotifiedAnnotation is added by horologium to the latest
+	// run of a periodic that keeps failing and carries the RFC3339 time
+	// at which the owner was notified that the periodic will be disabled.
+	AutoDisableNotifiedAnnotation = "prow.k8s.io/auto-disable-notified"
+	// AutoDisabledAnnotation is added by horologium to the latest run of a
+	// periodic it stopped scheduling and carries the RFC3339 time at which
+	// that happened.
+	AutoDisabledAnnotation = "prow.k8s.io/auto-disabled"
+	// CatchUpWindowAnnotation is added by horologium to the runs of a
+	// periodic it creates for missed cron windows and carries the RFC3339
+	// time of the window the run catches up on.
+	CatchUpWindowAnnotation = "prow.k8s.io/catch-up-window"
+	// IdempotencyKeyLabel is added by sub to the ProwJobs it creates and
+	// carries a hash of the idempotency key of the Pub/Sub message, so
+	// redelivered messages don't create the job again.
+	IdempotencyKeyLabel = "prow.k8s.io/idempotency-key"
+	// UtilityImagesLabel is added by plank to the pods of jobs subject to
+	// the canary of utility images and is either canary or stable,
+	// depending on the utility images of the pod.
+	UtilityImagesLabel = "prow.k8s.io/utility-images"
+	// EventReceivedAnnotation is added by hook to the ProwJobs plugins
+	// create while handling a webhook and carries the RFC3339Nano time at
+	// which the webhook was received.
+	EventReceivedAnnotation = "prow.k8s.io/event-received"
+	// EventTypeAnnotation is added by hook next to the EventReceivedAnnotation
+	// and carries the type of the webhook, e.g. pull_request.
+	EventTypeAnnotation = "prow.k8s.io/event-type"
 
 	// Gerrit related labels that are used by Prow
 
@@ -82,4 +121,10 @@ const (
 	GerritPatchset = "prow.k8s.io/gerrit-patchset"
 	// GerritReportLabel is the gerrit label prow will cast vote on, fallback to CodeReview label if unset
 	GerritReportLabel = "prow.k8s.io/gerrit-report-label"
+	// GerritAggregateReportLabel is the gerrit label prow votes on with the
+	// combined result of all jobs of a change that have the same value
+	GerritAggregateReportLabel = "prow.k8s.io/gerrit-aggregate-report-label"
+	// GerritTopic is the topic of the gerrit change, set if the job also
+	// tests the other changes of the topic
+	GerritTopic = "prow.k8s.io/gerrit-topic"
 )
@@ -51,6 +51,7 @@ const (
 	ReleaseNoteActionRequired   = "release-note-action-required"
 	Shrug                       = "¯\\_(ツ)_/¯"
 	TriageAccepted              = "triage/accepted"
+	UnsupportedBranch           = "do-not-merge/unsupported-branch"
 	WorkInProgress              = "do-not-merge/work-in-progress"
 	ValidBug                    = "bugzilla/valid-bug"
 )
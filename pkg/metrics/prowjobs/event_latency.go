@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+const (
+	// EventLatencyMetric is the name of the histograms of the latency from
+	// hook receiving a webhook until the jobs it triggered reach a stage.
+	EventLatencyMetric = "prow_webhook_event_latency_seconds"
+
+	// StageProwJobCreated is reached once the ProwJob shows up in the informer.
+	StageProwJobCreated = "prowjob_created"
+	// StagePodStarted is reached once plank started the pod of the job, as
+	// recorded by the pending time of the job.
+	StagePodStarted = "pod_started"
+)
+
+// NewEventLatencyHistogramVec creates histograms which track the time from
+// hook receiving a webhook until the ProwJobs triggered by it are created and
+// until their pods are started. The histograms are based on the stage, org,
+// repo and type of the webhook.
+// Only jobs created after the informer started are observed, so jobs are not
+// recorded again after a reboot.
+func NewEventLatencyHistogramVec(informer cache.SharedIndexInformer) *prometheus.HistogramVec {
+	histogramVec := newEventLatencyHistogramVec()
+	started := time.Now().Truncate(time.Second)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			observeEventLatency(histogramVec, nil, obj.(*prowapi.ProwJob), started, time.Now())
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			observeEventLatency(histogramVec, oldObj.(*prowapi.ProwJob), newObj.(*prowapi.ProwJob), started, time.Now())
+		},
+	})
+	return histogramVec
+}
+
+// observeEventLatency observes the stages the job reached since its previous
+// version, which is nil for jobs that were just added.
+func observeEventLatency(histogramVec *prometheus.HistogramVec, oldJob, newJob *prowapi.ProwJob, started, now time.Time) {
+	value, ok := newJob.Annotations[kube.EventReceivedAnnotation]
+	if !ok || newJob.CreationTimestamp.Time.Before(started) {
+		return
+	}
+	received, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		logrus.WithError(err).WithField("prowjob", newJob.Name).Debug("Failed to parse the time the event was received.")
+		return
+	}
+
+	observe := func(stage string, reached time.Time) {
+		var org, repo string
+		if newJob.Spec.Refs != nil {
+			org, repo = newJob.Spec.Refs.Org, newJob.Spec.Refs.Repo
+		}
+		histogram, err := histogramVec.GetMetricWithLabelValues(stage, org, repo, newJob.Annotations[kube.EventTypeAnnotation])
+		if err != nil {
+			logrus.WithError(err).Error("Failed to get a histogram for a prowjob")
+			return
+		}
+		histogram.Observe(reached.Sub(received).Seconds())
+	}
+	if oldJob == nil {
+		observe(StageProwJobCreated, now)
+	}
+	if newJob.Status.PendingTime != nil && (oldJob == nil || oldJob.Status.PendingTime == nil) {
+		observe(StagePodStarted, newJob.Status.PendingTime.Time)
+	}
+}
+
+func newEventLatencyHistogramVec() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: EventLatencyMetric,
+			Help: "Time from hook receiving a webhook until the ProwJobs it triggered reach a stage.",
+			Buckets: []float64{
+				0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800,
+			},
+		},
+		[]string{
+			// the stage reached: prowjob_created or pod_started
+			"stage",
+			// the org of the prowjob's repo
+			"org",
+			// the prowjob's repo
+			"repo",
+			// the type of the webhook, e.g. pull_request
+			"event_type",
+		},
+	)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventLatencyObjective is the latency SLO of a stage of triggering jobs: the
+// share of webhooks for which the stage is reached within the threshold.
+type EventLatencyObjective struct {
+	Stage string
+	// Threshold must be one of the buckets of the histograms.
+	Threshold time.Duration
+	Objective float64
+}
+
+// DefaultEventLatencyObjectives are the objectives the recording rules
+// shipped with Prow are built for.
+var DefaultEventLatencyObjectives = []EventLatencyObjective{
+	{Stage: StageProwJobCreated, Threshold: 10 * time.Second, Objective: 0.99},
+	{Stage: StagePodStarted, Threshold: 2 * time.Minute, Objective: 0.99},
+}
+
+// burnRateWindows are the windows the burn rates are recorded over, which
+// allow multi-window alerts on fast and slow burns of the error budget.
+var burnRateWindows = []string{"5m", "30m", "1h", "6h", "1d"}
+
+// RuleGroups are Prometheus rule groups, in the format of rule files.
+type RuleGroups struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+// RuleGroup is a group of Prometheus rules that are evaluated together.
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a Prometheus recording rule.
+type Rule struct {
+	Record string            `json:"record"`
+	Expr   string            `json:"expr"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// EventLatencyRecordingRules returns the rules recording, for every
+// objective, the ratio of webhooks that didn't reach the stage within the
+// threshold and the rate at which that burns the error budget, by org, repo
+// and type of the webhook. A burn rate of 1 spends the error budget exactly
+// over the period of the SLO.
+func EventLatencyRecordingRules(objectives []EventLatencyObjective) RuleGroups {
+	const by = "org, repo, event_type"
+	var rules []Rule
+	for _, objective := range objectives {
+		le := strconv.FormatFloat(objective.Threshold.Seconds(), 'f', -1, 64)
+		labels := map[string]string{"stage": objective.Stage}
+		for _, window := range burnRateWindows {
+			errorRatio := fmt.Sprintf("prow:webhook_event_latency:error_ratio_rate%s", window)
+			rules = append(rules,
+				Rule{
+					Record: errorRatio,
+					Expr: fmt.Sprintf(`1 - (sum by (%s) (rate(%s_bucket{stage=%q,le=%q}[%s])) / sum by (%s) (rate(%s_count{stage=%q}[%s])))`,
+						by, EventLatencyMetric, objective.Stage, le, window, by, EventLatencyMetric, objective.Stage, window),
+					Labels: labels,
+				},
+				Rule{
+					Record: fmt.Sprintf("prow:webhook_event_latency:burn_rate%s", window),
+					Expr:   fmt.Sprintf(`%s{stage=%q} / %s`, errorRatio, objective.Stage, strconv.FormatFloat(1-objective.Objective, 'g', 6, 64)),
+					Labels: labels,
+				},
+			)
+		}
+	}
+	return RuleGroups{Groups: []RuleGroup{{Name: "prow-webhook-event-latency", Rules: rules}}}
+}
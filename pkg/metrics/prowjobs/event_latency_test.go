@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+func TestObserveEventLatency(t *testing.T) {
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	received := started.Add(time.Minute)
+	now := received.Add(1500 * time.Millisecond)
+	pending := v1.NewTime(received.Add(30 * time.Second))
+	annotations := map[string]string{
+		kube.EventReceivedAnnotation: received.Format(time.RFC3339Nano),
+		kube.EventTypeAnnotation:     "pull_request",
+	}
+	job := func(created time.Time, annotations map[string]string, pendingTime *v1.Time) *prowapi.ProwJob {
+		return &prowapi.ProwJob{
+			ObjectMeta: v1.ObjectMeta{
+				Name:              "job",
+				CreationTimestamp: v1.NewTime(created),
+				Annotations:       annotations,
+			},
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.PresubmitJob,
+				Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+			},
+			Status: prowapi.ProwJobStatus{PendingTime: pendingTime},
+		}
+	}
+	testCases := []struct {
+		name     string
+		oldJob   *prowapi.ProwJob
+		newJob   *prowapi.ProwJob
+		expected map[string]float64
+	}{
+		{
+			name:     "created job is observed",
+			newJob:   job(received.Add(time.Second), annotations, nil),
+			expected: map[string]float64{StageProwJobCreated: 1.5},
+		},
+		{
+			name:     "started pod is observed",
+			oldJob:   job(received.Add(time.Second), annotations, nil),
+			newJob:   job(received.Add(time.Second), annotations, &pending),
+			expected: map[string]float64{StagePodStarted: 30},
+		},
+		{
+			name:   "pod is observed only when it starts",
+			oldJob: job(received.Add(time.Second), annotations, &pending),
+			newJob: job(received.Add(time.Second), annotations, &pending),
+		},
+		{
+			name:     "both stages are observed for jobs added with a started pod",
+			newJob:   job(received.Add(time.Second), annotations, &pending),
+			expected: map[string]float64{StageProwJobCreated: 1.5, StagePodStarted: 30},
+		},
+		{
+			name:   "job not triggered by a webhook is ignored",
+			newJob: job(received.Add(time.Second), nil, &pending),
+		},
+		{
+			name:   "job created before the informer started is ignored",
+			newJob: job(started.Add(-time.Hour), annotations, nil),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histogramVec := newEventLatencyHistogramVec()
+			observeEventLatency(histogramVec, tc.oldJob, tc.newJob, started, now)
+			actual := map[string]float64{}
+			for _, metric := range collect(histogramVec) {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["event_type"] != "pull_request" || labels["org"] != "org" || labels["repo"] != "repo" {
+					t.Errorf("unexpected labels: %v", labels)
+				}
+				if count := metric.GetHistogram().GetSampleCount(); count != 1 {
+					t.Errorf("expected a single observation of %s, got %d", labels["stage"], count)
+				}
+				actual[labels["stage"]] = metric.GetHistogram().GetSampleSum()
+			}
+			if tc.expected == nil {
+				tc.expected = map[string]float64{}
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected observations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const eventLatencyRulesFile = "../../../config/prow/cluster/monitoring/webhook-event-latency-rules.yaml"
+
+const eventLatencyRulesHeader = `# Generated from sigs.k8s.io/prow/pkg/metrics/prowjobs.EventLatencyRecordingRules,
+# update by running the tests of the package with UPDATE=true.
+`
+
+func TestEventLatencyRecordingRules(t *testing.T) {
+	rules, err := yaml.Marshal(EventLatencyRecordingRules(DefaultEventLatencyObjectives))
+	if err != nil {
+		t.Fatalf("failed to marshal the rules: %v", err)
+	}
+	generated := eventLatencyRulesHeader + string(rules)
+	if os.Getenv("UPDATE") != "" {
+		if err := os.WriteFile(eventLatencyRulesFile, []byte(generated), 0644); err != nil {
+			t.Fatalf("failed to update the rules: %v", err)
+		}
+	}
+	shipped, err := os.ReadFile(eventLatencyRulesFile)
+	if err != nil {
+		t.Fatalf("failed to read the rules: %v", err)
+	}
+	if diff := cmp.Diff(string(shipped), generated); diff != "" {
+		t.Errorf("the shipped rules are out of date, run the tests with UPDATE=true (-shipped +generated):\n%s", diff)
+	}
+}
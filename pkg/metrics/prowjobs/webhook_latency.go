@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// NewWebhookLatencyHistogramVec creates histograms which track the time from
+// hook receiving a webhook until the ProwJobs triggered by it show up in the
+// informer. The histograms are based on the job type, org and repo.
+// Only jobs created after the informer started are observed, so jobs are not
+// recorded again after a reboot.
+func NewWebhookLatencyHistogramVec(informer cache.SharedIndexInformer) *prometheus.HistogramVec {
+	histogramVec := newWebhookLatencyHistogramVec()
+	started := time.Now().Truncate(time.Second)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			observeWebhookLatency(histogramVec, obj.(*prowapi.ProwJob), started, time.Now())
+		},
+	})
+	return histogramVec
+}
+
+func observeWebhookLatency(histogramVec *prometheus.HistogramVec, pj *prowapi.ProwJob, started, now time.Time) {
+	value, ok := pj.Annotations[kube.EventReceivedAnnotation]
+	if !ok || pj.CreationTimestamp.Time.Before(started) {
+		return
+	}
+	received, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		logrus.WithError(err).WithField("prowjob", pj.Name).Debug("Failed to parse the time the event was received.")
+		return
+	}
+	var org, repo string
+	if pj.Spec.Refs != nil {
+		org, repo = pj.Spec.Refs.Org, pj.Spec.Refs.Repo
+	}
+	histogram, err := histogramVec.GetMetricWithLabelValues(string(pj.Spec.Type), org, repo)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get a histogram for a prowjob")
+		return
+	}
+	histogram.Observe(now.Sub(received).Seconds())
+}
+
+func newWebhookLatencyHistogramVec() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "prow_webhook_to_prowjob_latency_seconds",
+			Help: "Time from hook receiving a webhook until the ProwJobs it triggered are created.",
+			Buckets: []float64{
+				0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600,
+			},
+		},
+		[]string{
+			// type of the prowjob: presubmit, postsubmit, batch
+			"type",
+			// the org of the prowjob's repo
+			"org",
+			// the prowjob's repo
+			"repo",
+		},
+	)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+func TestObserveWebhookLatency(t *testing.T) {
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	received := started.Add(time.Minute)
+	now := received.Add(1500 * time.Millisecond)
+	job := func(created time.Time, annotations map[string]string) *prowapi.ProwJob {
+		return &prowapi.ProwJob{
+			ObjectMeta: v1.ObjectMeta{
+				Name:              "job",
+				CreationTimestamp: v1.NewTime(created),
+				Annotations:       annotations,
+			},
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.PresubmitJob,
+				Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+			},
+		}
+	}
+	testCases := []struct {
+		name          string
+		job           *prowapi.ProwJob
+		expectedCount uint64
+		expectedSum   float64
+	}{
+		{
+			name:          "job triggered by a webhook is observed",
+			job:           job(received.Add(time.Second), map[string]string{kube.EventReceivedAnnotation: received.Format(time.RFC3339Nano)}),
+			expectedCount: 1,
+			expectedSum:   1.5,
+		},
+		{
+			name: "job not triggered by a webhook is ignored",
+			job:  job(received.Add(time.Second), nil),
+		},
+		{
+			name: "job created before the informer started is ignored",
+			job:  job(started.Add(-time.Hour), map[string]string{kube.EventReceivedAnnotation: received.Format(time.RFC3339Nano)}),
+		},
+		{
+			name: "job with an invalid annotation is ignored",
+			job:  job(received.Add(time.Second), map[string]string{kube.EventReceivedAnnotation: "yesterday"}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			histogramVec := newWebhookLatencyHistogramVec()
+			observeWebhookLatency(histogramVec, tc.job, started, now)
+			var count uint64
+			var sum float64
+			for _, metric := range collect(histogramVec) {
+				count += metric.GetHistogram().GetSampleCount()
+				sum += metric.GetHistogram().GetSampleSum()
+			}
+			if count != tc.expectedCount {
+				t.Errorf("expected %d observations, got %d", tc.expectedCount, count)
+			}
+			if sum != tc.expectedSum {
+				t.Errorf("expected a sum of %v, got %v", tc.expectedSum, sum)
+			}
+		})
+	}
+}
@@ -19,25 +19,34 @@ package moonraker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/git/v2"
 )
 
 const (
 	PathGetInrepoconfig = "inrepoconfig"
 	PathPing            = "ping"
+	PathResolve         = "resolve"
+	PathCache           = "cache"
+	PathCacheInvalidate = "cache/invalidate"
 )
 
 type Moonraker struct {
 	ConfigAgent       *config.Agent
 	InRepoConfigCache *config.InRepoConfigCache
+	// GitClient resolves the refs given to the resolve endpoint.
+	GitClient git.ClientFactory
 }
 
 type configSectionsToWatch struct {
@@ -104,6 +113,156 @@ func (mr *Moonraker) ServeGetInrepoconfig(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// ResolveResponse is the response of the resolve endpoint.
+type ResolveResponse struct {
+	// Refs holds the SHA the ref resolved to.
+	Refs     prowapi.Refs     `json:"refs"`
+	ProwYAML *config.ProwYAML `json:"prow_yaml"`
+}
+
+// ServeResolve resolves the ref query parameter, a branch, tag, other ref or
+// SHA of the repo given by the org and repo query parameters, and returns the
+// ProwYAML of the commit it points to, going through the cache like any other
+// lookup.
+func (mr *Moonraker) ServeResolve(w http.ResponseWriter, r *http.Request) {
+	org, repo, ref := r.URL.Query().Get("org"), r.URL.Query().Get("repo"), r.URL.Query().Get("ref")
+	if org == "" || repo == "" || ref == "" {
+		http.Error(w, "the org, repo and ref query parameters are required", http.StatusBadRequest)
+		return
+	}
+	log := logrus.WithFields(logrus.Fields{"org": org, "repo": repo, "ref": ref})
+
+	sha, err := mr.resolveRef(org, repo, ref)
+	if err != nil {
+		log.WithError(err).Info("unable to resolve ref")
+		http.Error(w, fmt.Sprintf("unable to resolve ref %q: %v", ref, err), http.StatusNotFound)
+		return
+	}
+	refs := prowapi.Refs{Org: org, Repo: repo, BaseRef: branchOf(ref, sha), BaseSHA: sha}
+
+	prowYAML, err := mr.InRepoConfigCache.GetProwYAMLWithoutDefaults(org+"/"+repo, refs.BaseRef, func() (string, error) { return sha, nil })
+	if err != nil {
+		log.WithError(err).Error("unable to retrieve inrepoconfig ProwYAML")
+		http.Error(w, fmt.Sprintf("unable to retrieve inrepoconfig ProwYAML: %v", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, ResolveResponse{Refs: refs, ProwYAML: prowYAML})
+}
+
+// resolveRef fetches the ref from the remote of the repo and returns the SHA
+// it points to.
+func (mr *Moonraker) resolveRef(org, repo, ref string) (string, error) {
+	if mr.GitClient == nil {
+		return "", errors.New("no git client to resolve refs with")
+	}
+	repoClient, err := mr.GitClient.ClientFor(org, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to clone %s/%s: %w", org, repo, err)
+	}
+	defer func() {
+		if err := repoClient.Clean(); err != nil {
+			logrus.WithError(err).Error("Failed to clean up repo.")
+		}
+	}()
+	if err := repoClient.FetchRef(ref); err != nil {
+		return "", err
+	}
+	sha, err := repoClient.RevParse("FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sha), nil
+}
+
+// branchOf returns the branch the ref names, if it names one. Other refs,
+// like tags, and SHAs are checked out as they are instead of being retargeted
+// to the SHA.
+func branchOf(ref, sha string) string {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return strings.TrimPrefix(ref, "refs/heads/")
+	case strings.HasPrefix(ref, "refs/"), strings.HasPrefix(sha, ref):
+		return ""
+	}
+	return ref
+}
+
+// CacheEntry is an entry of the cache served by the cache endpoint.
+type CacheEntry struct {
+	config.InRepoConfigCacheEntry
+	// Age is how long ago the entry was added.
+	Age string `json:"age"`
+}
+
+// ServeCache returns the entries of the cache, from the least to the most
+// recently used, optionally only those of the repo given by the repo query
+// parameter as org/repo.
+func (mr *Moonraker) ServeCache(w http.ResponseWriter, r *http.Request) {
+	identifier := r.URL.Query().Get("repo")
+	now := time.Now()
+	entries := []CacheEntry{}
+	for _, entry := range mr.InRepoConfigCache.Entries() {
+		if identifier != "" && entry.Key.Identifier != identifier {
+			continue
+		}
+		entries = append(entries, CacheEntry{InRepoConfigCacheEntry: entry, Age: now.Sub(entry.Added).Round(time.Second).String()})
+	}
+	writeJSON(w, entries)
+}
+
+// invalidatePayload selects the entries of the cache to invalidate.
+type invalidatePayload struct {
+	// Keys are invalidated as they are.
+	Keys []config.CacheKeyParts `json:"keys,omitempty"`
+	// Repos have all their keys invalidated, given as org/repo.
+	Repos []string `json:"repos,omitempty"`
+}
+
+// InvalidateResponse is the response of the cache invalidation endpoint.
+type InvalidateResponse struct {
+	// Invalidated is how many of the selected entries were cached.
+	Invalidated int `json:"invalidated"`
+}
+
+// ServeCacheInvalidate removes the entries selected by the payload from the
+// cache, so that the next lookups read the ProwYAMLs from the repos again.
+func (mr *Moonraker) ServeCacheInvalidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload := &invalidatePayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		logrus.WithError(err).Info("unable to unmarshal cache invalidation request")
+		http.Error(w, fmt.Sprintf("unable to unmarshal cache invalidation request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var response InvalidateResponse
+	for _, key := range payload.Keys {
+		invalidated, err := mr.InRepoConfigCache.Invalidate(key)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid key %v: %v", key, err), http.StatusBadRequest)
+			return
+		}
+		if invalidated {
+			response.Invalidated++
+		}
+	}
+	for _, identifier := range payload.Repos {
+		response.Invalidated += mr.InRepoConfigCache.InvalidateRepo(identifier)
+	}
+	logrus.WithFields(logrus.Fields{"keys": len(payload.Keys), "repos": payload.Repos, "invalidated": response.Invalidated}).Info("Invalidated cache entries.")
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Error("unable to encode response into JSON")
+	}
+}
+
 func (mr *Moonraker) RunConfigWatcher(ctx context.Context) error {
 	configEvent := make(chan config.Delta, 2)
 	mr.ConfigAgent.Subscribe(configEvent)
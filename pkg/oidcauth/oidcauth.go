@@ -0,0 +1,492 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oidcauth authenticates users of Deck with an OpenID Connect
+// provider, such as Okta or Azure AD, and tells the groups they belong to.
+package oidcauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go/v4"
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	stateSessionCookie    = "oidc-state-session"
+	identitySessionCookie = "oidc-identity-session"
+	stateKey              = "state"
+	nonceKey              = "nonce"
+	destKey               = "dest"
+	userKey               = "user"
+	groupsKey             = "groups"
+	issuedKey             = "issued"
+
+	defaultUsernameClaim   = "email"
+	defaultGroupsClaim     = "groups"
+	defaultSessionDuration = 12 * time.Hour
+)
+
+// Config configures the OpenID Connect provider Deck authenticates users with.
+type Config struct {
+	// IssuerURL is the URL of the provider. Its discovery document must be
+	// served at <issuer_url>/.well-known/openid-configuration.
+	IssuerURL    string `json:"issuer_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL is where the provider sends users back to, usually
+	// https://<deck>/oidc-login/redirect.
+	RedirectURL string `json:"redirect_url"`
+	// Scopes requested in addition to openid. Most providers need a scope,
+	// like groups, to include the groups of the user in the ID token.
+	Scopes []string `json:"scopes,omitempty"`
+	// UsernameClaim is the claim of the ID token identifying the user.
+	// Defaults to email.
+	UsernameClaim string `json:"username_claim,omitempty"`
+	// GroupsClaim is the claim of the ID token listing the groups of the
+	// user. Defaults to groups.
+	GroupsClaim string `json:"groups_claim,omitempty"`
+	// GroupMapping renames the values of the groups claim, for instance to
+	// give the object IDs of Azure AD groups readable names. Groups missing
+	// from the mapping keep their name.
+	GroupMapping map[string]string `json:"group_mapping,omitempty"`
+	// SessionDuration is how long users stay logged in, like 8h.
+	// Defaults to 12h.
+	SessionDuration string `json:"session_duration,omitempty"`
+}
+
+// Validate checks the config and sets its defaults.
+func (c *Config) Validate() error {
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"issuer_url", c.IssuerURL},
+		{"client_id", c.ClientID},
+		{"client_secret", c.ClientSecret},
+		{"redirect_url", c.RedirectURL},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	if c.UsernameClaim == "" {
+		c.UsernameClaim = defaultUsernameClaim
+	}
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = defaultGroupsClaim
+	}
+	if c.SessionDuration != "" {
+		if d, err := time.ParseDuration(c.SessionDuration); err != nil || d <= 0 {
+			return fmt.Errorf("invalid session_duration %q: must be a positive duration", c.SessionDuration)
+		}
+	}
+	return nil
+}
+
+func (c *Config) sessionDuration() time.Duration {
+	if d, err := time.ParseDuration(c.SessionDuration); err == nil && d > 0 {
+		return d
+	}
+	return defaultSessionDuration
+}
+
+// Identity is a user authenticated with the OpenID Connect provider.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// discovery holds the fields of the discovery document of the provider
+// that are needed to authenticate users.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jwk is a JSON Web Key as served by the jwks_uri of the provider. Only RSA
+// keys are used, as RS256 is the algorithm every provider has to support.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Agent logs users in with the OpenID Connect provider and tells who the
+// users of requests are.
+type Agent struct {
+	config  Config
+	oauth   *oauth2.Config
+	issuer  string
+	jwksURI string
+	cookies *sessions.CookieStore
+	client  *http.Client
+	logger  *logrus.Entry
+	now     func() time.Time
+
+	lock sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+// NewAgent returns an Agent for the provider, read from its discovery
+// document. The config must have been validated. The cookie store must not
+// be shared, its cookies are made to expire with the sessions.
+func NewAgent(ctx context.Context, config Config, cookies *sessions.CookieStore, client *http.Client, logger *logrus.Entry) (*Agent, error) {
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	wellKnown := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	var d discovery
+	if err := getJSON(client, req, &d); err != nil {
+		return nil, fmt.Errorf("failed to get the discovery document of %s: %w", config.IssuerURL, err)
+	}
+	if d.Issuer != config.IssuerURL {
+		return nil, fmt.Errorf("the discovery document is for issuer %q, not %q", d.Issuer, config.IssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("the discovery document lacks the authorization_endpoint, token_endpoint or jwks_uri")
+	}
+	// Besides the expiry of the cookies in the browser, this makes the
+	// store reject cookies older than a session.
+	cookies.MaxAge(int(config.sessionDuration().Seconds()))
+	return &Agent{
+		config: config,
+		oauth: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       append([]string{"openid"}, config.Scopes...),
+			Endpoint:     oauth2.Endpoint{AuthURL: d.AuthorizationEndpoint, TokenURL: d.TokenEndpoint},
+		},
+		issuer:  d.Issuer,
+		jwksURI: d.JWKSURI,
+		cookies: cookies,
+		client:  client,
+		logger:  logger,
+		now:     time.Now,
+		keys:    map[string]*rsa.PublicKey{},
+	}, nil
+}
+
+func getJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", req.URL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// HandleLogin redirects users to the provider to log in. Once logged in,
+// users are sent back to the page given by the dest query parameter.
+func (a *Agent) HandleLogin(secure bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := randomString()
+		if err != nil {
+			a.serverError(w, "Generate state", err)
+			return
+		}
+		nonce, err := randomString()
+		if err != nil {
+			a.serverError(w, "Generate nonce", err)
+			return
+		}
+		session, err := a.cookies.New(r, stateSessionCookie)
+		if err != nil {
+			a.serverError(w, "Create state session", err)
+			return
+		}
+		session.Options.Secure = secure
+		session.Options.HttpOnly = true
+		session.Options.MaxAge = 10 * 60
+		session.Values[stateKey] = state
+		session.Values[nonceKey] = nonce
+		session.Values[destKey] = r.URL.Query().Get("dest")
+		if err := session.Save(r, w); err != nil {
+			a.serverError(w, "Save state session", err)
+			return
+		}
+		http.Redirect(w, r, a.oauth.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)), http.StatusFound)
+	}
+}
+
+// HandleRedirect handles users sent back by the provider. It exchanges the
+// code for an ID token, verifies it and saves the identity of the user in a
+// session cookie.
+func (a *Agent) HandleRedirect(secure bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stateSession, err := a.cookies.Get(r, stateSessionCookie)
+		if err != nil {
+			a.serverError(w, "Get state session", err)
+			return
+		}
+		secretState, _ := stateSession.Values[stateKey].(string)
+		nonce, _ := stateSession.Values[nonceKey].(string)
+		dest, _ := stateSession.Values[destKey].(string)
+		state := r.FormValue("state")
+		if state == "" || secretState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(secretState)) != 1 {
+			http.Error(w, "Invalid or expired login state, please log in again.", http.StatusBadRequest)
+			return
+		}
+		if oidcError := r.FormValue("error"); oidcError != "" {
+			a.logger.WithFields(logrus.Fields{
+				"oidc_error":             oidcError,
+				"oidc_error_description": r.FormValue("error_description"),
+			}).Debug("The OIDC provider passed an error in the callback.")
+			http.Error(w, fmt.Sprintf("Login failed: %s", oidcError), http.StatusUnauthorized)
+			return
+		}
+
+		token, err := a.oauth.Exchange(context.WithValue(r.Context(), oauth2.HTTPClient, a.client), r.FormValue("code"))
+		if err != nil {
+			a.serverError(w, "Exchange code for token", err)
+			return
+		}
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			a.serverError(w, "Get ID token", errors.New("the token response has no id_token"))
+			return
+		}
+		identity, err := a.verify(rawIDToken, nonce)
+		if err != nil {
+			a.logger.WithError(err).Info("Rejected ID token.")
+			http.Error(w, "Login failed: invalid ID token.", http.StatusUnauthorized)
+			return
+		}
+
+		session, err := a.cookies.New(r, identitySessionCookie)
+		if err != nil {
+			a.serverError(w, "Create identity session", err)
+			return
+		}
+		session.Options.Secure = secure
+		session.Options.HttpOnly = true
+		session.Options.MaxAge = int(a.config.sessionDuration().Seconds())
+		session.Values[userKey] = identity.User
+		session.Values[groupsKey] = strings.Join(identity.Groups, "\n")
+		session.Values[issuedKey] = a.now().Unix()
+		if err := session.Save(r, w); err != nil {
+			a.serverError(w, "Save identity session", err)
+			return
+		}
+		stateSession.Options.MaxAge = -1
+		if err := stateSession.Save(r, w); err != nil {
+			a.serverError(w, "Clear state session", err)
+			return
+		}
+		// This is string manipulation for clarity, and to avoid surprising parse mismatches.
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		http.Redirect(w, r, scheme+"://"+r.Host+"/"+strings.TrimPrefix(dest, "/"), http.StatusFound)
+	}
+}
+
+// HandleLogout forgets the identity of the user and redirects to the front
+// page.
+func (a *Agent) HandleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := a.cookies.Get(r, identitySessionCookie)
+		if err != nil {
+			a.serverError(w, "Get identity session", err)
+			return
+		}
+		session.Options.MaxAge = -1
+		if err := session.Save(r, w); err != nil {
+			a.serverError(w, "Clear identity session", err)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+// Identity returns the user logged in by the request, or an error if no
+// user is or the session expired.
+func (a *Agent) Identity(r *http.Request) (*Identity, error) {
+	session, err := a.cookies.Get(r, identitySessionCookie)
+	if err != nil {
+		return nil, err
+	}
+	user, ok := session.Values[userKey].(string)
+	if !ok || user == "" {
+		return nil, errors.New("not logged in with OIDC")
+	}
+	// The expiry of the cookie is up to the browser, so the session is
+	// checked against the time it was issued at as well.
+	issued, ok := session.Values[issuedKey].(int64)
+	if !ok {
+		return nil, errors.New("the OIDC session lacks the time it was issued at")
+	}
+	if a.now().Sub(time.Unix(issued, 0)) >= a.config.sessionDuration() {
+		return nil, errors.New("the OIDC session expired")
+	}
+	identity := &Identity{User: user}
+	if groups, _ := session.Values[groupsKey].(string); groups != "" {
+		identity.Groups = strings.Split(groups, "\n")
+	}
+	return identity, nil
+}
+
+// verify verifies the signature, issuer, audience, expiry and nonce of the
+// ID token and returns the identity it asserts.
+func (a *Agent) verify(rawIDToken, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithAudience(a.config.ClientID),
+		jwt.WithIssuer(a.issuer),
+	)
+	if _, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.key(kid)
+	}); err != nil {
+		return nil, err
+	}
+	// The parser skips absent claims, but ID tokens must have them.
+	for _, claim := range []string{"aud", "exp"} {
+		if _, ok := claims[claim]; !ok {
+			return nil, fmt.Errorf("the ID token has no %s claim", claim)
+		}
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return nil, errors.New("the nonce of the ID token does not match")
+	}
+	user, _ := claims[a.config.UsernameClaim].(string)
+	if user == "" {
+		return nil, fmt.Errorf("the ID token has no %s claim", a.config.UsernameClaim)
+	}
+	return &Identity{User: user, Groups: a.groups(claims[a.config.GroupsClaim])}, nil
+}
+
+// groups returns the groups of the groups claim, which is either a list of
+// groups or a single one, renamed through the group mapping.
+func (a *Agent) groups(claim interface{}) []string {
+	var raw []string
+	switch v := claim.(type) {
+	case string:
+		raw = []string{v}
+	case []interface{}:
+		for _, group := range v {
+			if s, ok := group.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	var groups []string
+	for _, group := range raw {
+		if mapped, ok := a.config.GroupMapping[group]; ok {
+			group = mapped
+		}
+		if group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// key returns the signing key with the given ID, refreshing the keys of the
+// provider when it is unknown as they may have been rotated.
+func (a *Agent) key(kid string) (*rsa.PublicKey, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, a.jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(a.client, req, &set); err != nil {
+		return nil, fmt.Errorf("failed to get the signing keys: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			a.logger.WithError(err).WithField("kid", k.Kid).Warn("Ignoring invalid signing key.")
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	a.keys = keys
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 2 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (a *Agent) serverError(w http.ResponseWriter, action string, err error) {
+	a.logger.WithError(err).Errorf("Error %s.", strings.ToLower(action))
+	http.Error(w, fmt.Sprintf("%s failed.", action), http.StatusInternalServerError)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidcauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go/v4"
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testClientID = "deck"
+	testKeyID    = "key-1"
+)
+
+type fakeProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	// idToken is returned by the token endpoint.
+	idToken string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	p := &fakeProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                p.URL,
+			AuthorizationEndpoint: p.URL + "/authorize",
+			TokenEndpoint:         p.URL + "/token",
+			JWKSURI:               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string][]jwk{"keys": {{
+			Kty: "RSA",
+			Kid: testKeyID,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     p.idToken,
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeProvider) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func (p *fakeProvider) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    p.URL,
+		"aud":    testClientID,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"nonce":  nonce,
+		"email":  "alice@example.com",
+		"groups": []string{"0b6e-prow-admins", "developers"},
+	}
+}
+
+func newTestAgent(t *testing.T, p *fakeProvider) *Agent {
+	config := Config{
+		IssuerURL:    p.URL,
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "https://deck.example.com/oidc-login/redirect",
+		GroupMapping: map[string]string{"0b6e-prow-admins": "prow-admins"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	agent, err := NewAgent(context.Background(), config, sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef")), p.Client(), logrus.WithField("test", t.Name()))
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	return agent
+}
+
+func TestVerify(t *testing.T) {
+	p := newFakeProvider(t)
+	agent := newTestAgent(t, p)
+
+	testCases := []struct {
+		name     string
+		kid      string
+		modify   func(jwt.MapClaims)
+		expected *Identity
+	}{
+		{
+			name:     "valid token, groups are mapped",
+			expected: &Identity{User: "alice@example.com", Groups: []string{"prow-admins", "developers"}},
+		},
+		{
+			name:     "single group",
+			modify:   func(c jwt.MapClaims) { c["groups"] = "developers" },
+			expected: &Identity{User: "alice@example.com", Groups: []string{"developers"}},
+		},
+		{
+			name:     "no groups",
+			modify:   func(c jwt.MapClaims) { delete(c, "groups") },
+			expected: &Identity{User: "alice@example.com"},
+		},
+		{
+			name:   "unknown key",
+			kid:    "key-2",
+			modify: func(c jwt.MapClaims) {},
+		},
+		{
+			name:   "wrong audience",
+			modify: func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		},
+		{
+			name:   "no audience",
+			modify: func(c jwt.MapClaims) { delete(c, "aud") },
+		},
+		{
+			name:   "wrong issuer",
+			modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "expired",
+			modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		},
+		{
+			name:   "no expiry",
+			modify: func(c jwt.MapClaims) { delete(c, "exp") },
+		},
+		{
+			name:   "wrong nonce",
+			modify: func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		},
+		{
+			name:   "no username",
+			modify: func(c jwt.MapClaims) { delete(c, "email") },
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := p.claims("nonce")
+			if tc.modify != nil {
+				tc.modify(claims)
+			}
+			kid := testKeyID
+			if tc.kid != "" {
+				kid = tc.kid
+			}
+			identity, err := agent.verify(p.sign(t, kid, claims), "nonce")
+			if tc.expected == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got identity %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, identity); diff != "" {
+				t.Errorf("unexpected identity (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	p := newFakeProvider(t)
+	agent := newTestAgent(t, p)
+
+	req := httptest.NewRequest(http.MethodGet, "https://deck.example.com/oidc-login?dest=view/job/1", nil)
+	rr := httptest.NewRecorder()
+	agent.HandleLogin(true)(rr, req)
+	if rr.Code != http.StatusFound {
+		t.Fatalf("expected login to redirect, got status %d", rr.Code)
+	}
+	authURL, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	if authURL.Path != "/authorize" {
+		t.Errorf("expected redirect to the authorization endpoint, got %s", authURL)
+	}
+	state, nonce := authURL.Query().Get("state"), authURL.Query().Get("nonce")
+	if state == "" || nonce == "" {
+		t.Fatalf("expected state and nonce in %s", authURL)
+	}
+	stateCookies := rr.Result().Cookies()
+
+	if _, err := agent.Identity(req); err == nil {
+		t.Error("expected no identity before logging in")
+	}
+
+	// A request with another state is rejected.
+	req = httptest.NewRequest(http.MethodGet, "https://deck.example.com/oidc-login/redirect?code=code&state=forged", nil)
+	for _, cookie := range stateCookies {
+		req.AddCookie(cookie)
+	}
+	rr = httptest.NewRecorder()
+	agent.HandleRedirect(true)(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected forged state to be rejected, got status %d", rr.Code)
+	}
+
+	p.idToken = p.sign(t, testKeyID, p.claims(nonce))
+	req = httptest.NewRequest(http.MethodGet, "https://deck.example.com/oidc-login/redirect?code=code&state="+state, nil)
+	for _, cookie := range stateCookies {
+		req.AddCookie(cookie)
+	}
+	rr = httptest.NewRecorder()
+	agent.HandleRedirect(true)(rr, req)
+	if rr.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got status %d: %s", rr.Code, rr.Body.String())
+	}
+	if location := rr.Header().Get("Location"); location != "https://deck.example.com/view/job/1" {
+		t.Errorf("expected redirect to the destination, got %s", location)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "https://deck.example.com/rerun", nil)
+	for _, cookie := range rr.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	identity, err := agent.Identity(req)
+	if err != nil {
+		t.Fatalf("expected an identity after logging in: %v", err)
+	}
+	expected := &Identity{User: "alice@example.com", Groups: []string{"prow-admins", "developers"}}
+	if diff := cmp.Diff(expected, identity); diff != "" {
+		t.Errorf("unexpected identity (-want +got):\n%s", diff)
+	}
+
+	agent.now = func() time.Time { return time.Now().Add(defaultSessionDuration) }
+	if _, err := agent.Identity(req); err == nil {
+		t.Error("expected no identity once the session expired")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		expected    Config
+		expectedErr string
+	}{
+		{
+			name:        "missing fields",
+			config:      Config{ClientID: "deck"},
+			expectedErr: "missing required fields: issuer_url, client_secret, redirect_url",
+		},
+		{
+			name:     "defaults",
+			config:   Config{IssuerURL: "https://idp", ClientID: "deck", ClientSecret: "s", RedirectURL: "https://deck/oidc-login/redirect"},
+			expected: Config{IssuerURL: "https://idp", ClientID: "deck", ClientSecret: "s", RedirectURL: "https://deck/oidc-login/redirect", UsernameClaim: "email", GroupsClaim: "groups"},
+		},
+		{
+			name:        "invalid session duration",
+			config:      Config{IssuerURL: "https://idp", ClientID: "deck", ClientSecret: "s", RedirectURL: "https://deck/oidc-login/redirect", SessionDuration: "a day"},
+			expectedErr: `invalid session_duration "a day": must be a positive duration`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tc.expected, tc.config); diff != "" {
+					t.Errorf("unexpected config (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
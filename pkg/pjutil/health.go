@@ -51,6 +51,12 @@ func NewHealthOnPort(port int) *Health {
 	}
 }
 
+// Handle serves the handler for the pattern on the health port, which unlike
+// the ports of the components isn't exposed publicly, for internal endpoints.
+func (h *Health) Handle(pattern string, handler http.Handler) {
+	h.healthMux.Handle(pattern, handler)
+}
+
 type ReadinessCheck func() bool
 
 // ServeReady starts serving the readiness endpoint
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 
 	uuid "github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -88,10 +89,47 @@ func NewProwJob(spec prowapi.ProwJobSpec, extraLabels, extraAnnotations map[stri
 		modifier(&defModifiers)
 	}
 	pj.Status.State = defModifiers.state
+	if gates := pj.RemainingSchedulingGates(); len(gates) > 0 {
+		pj.Status.State = prowapi.WaitingState
+		pj.Status.Description = WaitingDescription(gates)
+	}
+	if pj.AwaitingApproval() {
+		pj.Status.State = prowapi.HeldState
+		pj.Status.Description = HeldDescription
+	}
 
 	return pj
 }
 
+// HeldDescription describes a job held back until it is manually approved.
+const HeldDescription = "Waiting for a manual approval."
+
+// Approve records the approval of a held job by the given user and moves it
+// on: to the waiting state if some of its scheduling gates are left, and to
+// the state new jobs start in otherwise.
+func Approve(pj *prowapi.ProwJob, approver string, modifiers ...Modifier) {
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	pj.Annotations[prowapi.ApprovedByAnnotation] = approver
+
+	defModifiers := defaultModifiers()
+	for _, modifier := range modifiers {
+		modifier(&defModifiers)
+	}
+	pj.Status.State = defModifiers.state
+	pj.Status.Description = fmt.Sprintf("Approved by %s.", approver)
+	if gates := pj.RemainingSchedulingGates(); len(gates) > 0 {
+		pj.Status.State = prowapi.WaitingState
+		pj.Status.Description = WaitingDescription(gates)
+	}
+}
+
+// WaitingDescription describes a job held back by the given scheduling gates.
+func WaitingDescription(gates []string) string {
+	return fmt.Sprintf("Waiting for scheduling gates: %s.", strings.Join(gates, ", "))
+}
+
 // setReportDefault sets Slack to false when states to report is an empty slice.
 //
 // `omitempty` is required for fields that are optional, otherwise strict prowjob CRD
@@ -193,6 +231,7 @@ func PostsubmitSpec(p config.Postsubmit, refs prowapi.Refs) prowapi.ProwJobSpec
 	pjs.Context = p.Context
 	pjs.Report = !p.SkipReport
 	pjs.Refs = CompletePrimaryRefs(refs, p.JobBase)
+	pjs.RequiresManualApproval = p.RequiresManualApproval
 	if p.JenkinsSpec != nil {
 		pjs.JenkinsSpec = &prowapi.JenkinsSpec{
 			GitHubBranchSourceJob: p.JenkinsSpec.GitHubBranchSourceJob,
@@ -208,6 +247,7 @@ func PeriodicSpec(p config.Periodic) prowapi.ProwJobSpec {
 	// It is currently not possible to disable reporting for individual periodics.
 	pjs.Report = true
 	pjs.Type = prowapi.PeriodicJob
+	pjs.RequiresManualApproval = p.RequiresManualApproval
 
 	return pjs
 }
@@ -234,6 +274,7 @@ func specFromJobBase(jb config.JobBase) prowapi.ProwJobSpec {
 		Namespace:       namespace,
 		MaxConcurrency:  jb.MaxConcurrency,
 		ErrorOnEviction: jb.ErrorOnEviction,
+		SchedulingGates: jb.SchedulingGates,
 
 		ExtraRefs:        DecorateExtraRefs(jb.ExtraRefs, jb),
 		DecorationConfig: jb.DecorationConfig,
@@ -244,6 +285,7 @@ func specFromJobBase(jb config.JobBase) prowapi.ProwJobSpec {
 
 		ReporterConfig:  jb.ReporterConfig,
 		RerunAuthConfig: jb.RerunAuthConfig,
+		RerunOverrides:  jb.RerunOverrides,
 		Hidden:          jb.Hidden,
 		ProwJobDefault:  jb.ProwJobDefault,
 		JobQueueName:    jb.JobQueueName,
@@ -269,6 +311,12 @@ func DecorateRefs(refs prowapi.Refs, jb config.JobBase) *prowapi.Refs {
 	if refs.BloblessFetch == nil {
 		refs.BloblessFetch = dc.BloblessFetch
 	}
+	if refs.ShallowSince == "" && dc.ShallowSince != nil {
+		refs.ShallowSince = *dc.ShallowSince
+	}
+	if refs.LFSFetch == nil {
+		refs.LFSFetch = dc.LFSFetch
+	}
 	return &refs
 }
 
@@ -984,6 +984,92 @@ func TestNewProwJobWithModifiers(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "scheduling gates hold the job back",
+			spec: &prowapi.ProwJobSpec{
+				Job:             "job",
+				Context:         "job-context",
+				Type:            prowapi.PeriodicJob,
+				SchedulingGates: []string{"change-approval", "release-window"},
+			},
+			labels:      map[string]string{"extra-label": "foo"},
+			annotations: map[string]string{prowapi.ClearedSchedulingGatesAnnotation: "release-window"},
+			options:     []Modifier{RequireScheduling(true)},
+			wantProwJob: prowapi.ProwJob{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "prow.k8s.io/v1",
+					Kind:       "ProwJob",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fakeName,
+					Labels: map[string]string{
+						kube.CreatedByProw:     "true",
+						kube.ProwJobAnnotation: "job",
+						kube.ContextAnnotation: "job-context",
+						kube.ProwJobTypeLabel:  string(prowapi.PeriodicJob),
+						"extra-label":          "foo",
+					},
+					Annotations: map[string]string{
+						kube.ProwJobAnnotation:                   "job",
+						kube.ContextAnnotation:                   "job-context",
+						prowapi.ClearedSchedulingGatesAnnotation: "release-window",
+					},
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:             "job",
+					Context:         "job-context",
+					Type:            prowapi.PeriodicJob,
+					SchedulingGates: []string{"change-approval", "release-window"},
+				},
+				Status: prowapi.ProwJobStatus{
+					StartTime:   fakeStartTime,
+					State:       prowapi.WaitingState,
+					Description: "Waiting for scheduling gates: change-approval.",
+				},
+			},
+		},
+		{
+			name: "jobs requiring a manual approval are held",
+			spec: &prowapi.ProwJobSpec{
+				Job:                    "job",
+				Context:                "job-context",
+				Type:                   prowapi.PostsubmitJob,
+				SchedulingGates:        []string{"release-window"},
+				RequiresManualApproval: true,
+			},
+			options: []Modifier{RequireScheduling(true)},
+			wantProwJob: prowapi.ProwJob{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "prow.k8s.io/v1",
+					Kind:       "ProwJob",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fakeName,
+					Labels: map[string]string{
+						kube.CreatedByProw:     "true",
+						kube.ProwJobAnnotation: "job",
+						kube.ContextAnnotation: "job-context",
+						kube.ProwJobTypeLabel:  string(prowapi.PostsubmitJob),
+					},
+					Annotations: map[string]string{
+						kube.ProwJobAnnotation: "job",
+						kube.ContextAnnotation: "job-context",
+					},
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:                    "job",
+					Context:                "job-context",
+					Type:                   prowapi.PostsubmitJob,
+					SchedulingGates:        []string{"release-window"},
+					RequiresManualApproval: true,
+				},
+				Status: prowapi.ProwJobStatus{
+					StartTime:   fakeStartTime,
+					State:       prowapi.HeldState,
+					Description: HeldDescription,
+				},
+			},
+		},
 	} {
 		t.Run(testCase.name, func(t *testing.T) {
 			pj := NewProwJob(*testCase.spec, testCase.labels, testCase.annotations, testCase.options...)
@@ -1643,3 +1729,66 @@ func TestPeriodicSpec(t *testing.T) {
 		}
 	}
 }
+
+func TestApprove(t *testing.T) {
+	testCases := []struct {
+		name                string
+		gates               []string
+		cleared             string
+		options             []Modifier
+		expectedState       prowapi.ProwJobState
+		expectedDescription string
+	}{
+		{
+			name:                "approved job is triggered",
+			expectedState:       prowapi.TriggeredState,
+			expectedDescription: "Approved by alice.",
+		},
+		{
+			name:                "approved job is scheduled with the scheduler",
+			options:             []Modifier{RequireScheduling(true)},
+			expectedState:       prowapi.SchedulingState,
+			expectedDescription: "Approved by alice.",
+		},
+		{
+			name:                "approved job waits for its scheduling gates",
+			gates:               []string{"change-approval", "release-window"},
+			cleared:             "release-window",
+			expectedState:       prowapi.WaitingState,
+			expectedDescription: "Waiting for scheduling gates: change-approval.",
+		},
+		{
+			name:                "approved job with cleared scheduling gates is triggered",
+			gates:               []string{"release-window"},
+			cleared:             "release-window",
+			expectedState:       prowapi.TriggeredState,
+			expectedDescription: "Approved by alice.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := NewProwJob(prowapi.ProwJobSpec{
+				Job:                    "deploy",
+				Type:                   prowapi.PeriodicJob,
+				SchedulingGates:        tc.gates,
+				RequiresManualApproval: true,
+			}, nil, map[string]string{prowapi.ClearedSchedulingGatesAnnotation: tc.cleared})
+			if pj.Status.State != prowapi.HeldState {
+				t.Fatalf("expected the job to be held, got %q", pj.Status.State)
+			}
+			Approve(&pj, "alice", tc.options...)
+			if pj.Status.State != tc.expectedState {
+				t.Errorf("expected state %q, got %q", tc.expectedState, pj.Status.State)
+			}
+			if pj.Status.Description != tc.expectedDescription {
+				t.Errorf("expected description %q, got %q", tc.expectedDescription, pj.Status.Description)
+			}
+			if approver := pj.Annotations[prowapi.ApprovedByAnnotation]; approver != "alice" {
+				t.Errorf("expected the approver to be recorded, got %q", approver)
+			}
+			if pj.AwaitingApproval() {
+				t.Error("expected the job not to await an approval anymore")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+const (
+	// backlogPhaseCleanup holds the aborted and pending jobs of the backlog,
+	// whose pods are cleaned up or finished first.
+	backlogPhaseCleanup = "cleanup"
+	// backlogPhaseTriggered holds the triggered jobs of the backlog.
+	backlogPhaseTriggered = "triggered"
+)
+
+// backlogPhase is a set of ProwJobs of the backlog, in the order in which
+// they are processed.
+type backlogPhase struct {
+	name string
+	jobs []prowv1.ProwJob
+}
+
+// backlog processes the ProwJobs that exist when plank starts in a safe
+// order. The controller skips the jobs of the backlog until the backlog
+// processed them, as the informer would otherwise hand them to the workers
+// in an arbitrary order.
+type backlog struct {
+	lock sync.Mutex
+	// listed is set once the jobs of the backlog are known. Until then the
+	// controller can't tell which jobs to skip.
+	listed bool
+	held   sets.Set[string]
+
+	// requeue hands processed jobs back to the controller, so that events
+	// it skipped while they were held aren't lost.
+	requeue chan event.GenericEvent
+}
+
+func newBacklog() *backlog {
+	return &backlog{held: sets.New[string](), requeue: make(chan event.GenericEvent)}
+}
+
+// hold tells whether the controller must skip the request, and the result
+// to return for it.
+func (b *backlog) hold(name string) (reconcile.Result, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.listed {
+		return reconcile.Result{RequeueAfter: time.Second}, true
+	}
+	return reconcile.Result{}, b.held.Has(name)
+}
+
+func (b *backlog) start(phases []backlogPhase) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, phase := range phases {
+		for _, pj := range phase.jobs {
+			b.held.Insert(pj.Name)
+		}
+	}
+	b.listed = true
+}
+
+func (b *backlog) release(name string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.held.Delete(name)
+}
+
+// backlogPhases orders the jobs of the backlog. Aborted and pending jobs
+// come first, as cleaning them up frees their pods and concurrency slots for
+// the triggered jobs, which follow in the configured order.
+func backlogPhases(pjs []prowv1.ProwJob, triggeredOrder string) []backlogPhase {
+	var aborted, pending, triggered []prowv1.ProwJob
+	for _, pj := range pjs {
+		switch pj.Status.State {
+		case prowv1.AbortedState:
+			if !pj.Complete() {
+				aborted = append(aborted, pj)
+			}
+		case prowv1.PendingState:
+			pending = append(pending, pj)
+		case prowv1.TriggeredState:
+			triggered = append(triggered, pj)
+		}
+	}
+	byCreation := func(pjs []prowv1.ProwJob, newestFirst bool) {
+		sort.SliceStable(pjs, func(i, j int) bool {
+			if newestFirst {
+				return pjs[j].CreationTimestamp.Before(&pjs[i].CreationTimestamp)
+			}
+			return pjs[i].CreationTimestamp.Before(&pjs[j].CreationTimestamp)
+		})
+	}
+	byCreation(aborted, false)
+	byCreation(pending, false)
+	byCreation(triggered, triggeredOrder != config.StartupBacklogOldestFirst)
+	return []backlogPhase{
+		{name: backlogPhaseCleanup, jobs: append(aborted, pending...)},
+		{name: backlogPhaseTriggered, jobs: triggered},
+	}
+}
+
+// run reconciles the jobs of the phases in order with the given number of
+// workers. A phase starts once every job of the previous phase has been
+// reconciled.
+func (b *backlog) run(ctx context.Context, log *logrus.Entry, phases []backlogPhase, workers int, reconcileFunc reconcile.Func) {
+	b.start(phases)
+	for _, phase := range phases {
+		startupBacklog.WithLabelValues(phase.name).Set(float64(len(phase.jobs)))
+	}
+	for _, phase := range phases {
+		log := log.WithField("phase", phase.name)
+		log.WithField("jobs", len(phase.jobs)).Info("Processing the startup backlog.")
+		jobs := make(chan prowv1.ProwJob)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for pj := range jobs {
+					request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pj.Namespace, Name: pj.Name}}
+					if _, err := reconcileFunc(ctx, request); err != nil {
+						log.WithError(err).WithFields(pjutil.ProwJobFields(&pj)).Warn("Failed to reconcile job of the startup backlog, handing it back to the controller.")
+					}
+					b.release(pj.Name)
+					startupBacklog.WithLabelValues(phase.name).Dec()
+					select {
+					case b.requeue <- event.GenericEvent{Object: pj.DeepCopy()}:
+					case <-ctx.Done():
+					}
+				}
+			}()
+		}
+	feed:
+		for _, pj := range phase.jobs {
+			select {
+			case jobs <- pj:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	log.Info("The startup backlog drained.")
+}
+
+// processBacklog processes the ProwJobs that exist when plank starts.
+func (r *reconciler) processBacklog(workers int) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var phases []backlogPhase
+		pjs := &prowv1.ProwJobList{}
+		if err := r.pjClient.List(ctx, pjs, optAllProwJobs()); err != nil {
+			// Without the backlog, the controller handles all jobs as usual.
+			r.log.WithError(err).Error("Failed to list the startup backlog, processing jobs in any order.")
+		} else {
+			order := config.StartupBacklogNewestFirst
+			if backlog := r.config().Plank.StartupBacklog; backlog != nil {
+				order = backlog.TriggeredOrder
+			}
+			phases = backlogPhases(pjs.Items, order)
+		}
+		r.backlog.run(ctx, r.log.WithField("component", "startup-backlog"), phases, workers, r.defaultReconcile)
+		return nil
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func backlogJobs() []prowv1.ProwJob {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pj := func(name string, state prowv1.ProwJobState, created int, complete bool) prowv1.ProwJob {
+		job := prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prowjobs", CreationTimestamp: metav1.NewTime(start.Add(time.Duration(created) * time.Minute))},
+			Status:     prowv1.ProwJobStatus{State: state},
+		}
+		if complete {
+			job.SetComplete()
+		}
+		return job
+	}
+	return []prowv1.ProwJob{
+		pj("triggered-old", prowv1.TriggeredState, 1, false),
+		pj("pending", prowv1.PendingState, 2, false),
+		pj("succeeded", prowv1.SuccessState, 3, true),
+		pj("triggered-new", prowv1.TriggeredState, 5, false),
+		pj("aborted", prowv1.AbortedState, 4, false),
+		pj("aborted-complete", prowv1.AbortedState, 0, true),
+		pj("triggered-middle", prowv1.TriggeredState, 3, false),
+	}
+}
+
+func phaseNames(phases []backlogPhase) map[string][]string {
+	names := map[string][]string{}
+	for _, phase := range phases {
+		for _, pj := range phase.jobs {
+			names[phase.name] = append(names[phase.name], pj.Name)
+		}
+	}
+	return names
+}
+
+func TestBacklogPhases(t *testing.T) {
+	testCases := []struct {
+		name     string
+		order    string
+		expected map[string][]string
+	}{
+		{
+			name:  "newest triggered jobs first",
+			order: config.StartupBacklogNewestFirst,
+			expected: map[string][]string{
+				backlogPhaseCleanup:   {"aborted", "pending"},
+				backlogPhaseTriggered: {"triggered-new", "triggered-middle", "triggered-old"},
+			},
+		},
+		{
+			name:  "oldest triggered jobs first",
+			order: config.StartupBacklogOldestFirst,
+			expected: map[string][]string{
+				backlogPhaseCleanup:   {"aborted", "pending"},
+				backlogPhaseTriggered: {"triggered-old", "triggered-middle", "triggered-new"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, phaseNames(backlogPhases(backlogJobs(), tc.order))); diff != "" {
+				t.Errorf("unexpected phases (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBacklogRun(t *testing.T) {
+	b := newBacklog()
+	if res, held := b.hold("new"); !held || res.RequeueAfter == 0 {
+		t.Errorf("expected jobs to be requeued until the backlog is listed, got held %t and %+v", held, res)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requeued []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range b.requeue {
+			requeued = append(requeued, e.Object.GetName())
+		}
+	}()
+
+	phases := backlogPhases(backlogJobs(), config.StartupBacklogNewestFirst)
+	var reconciled []string
+	reconcileFunc := func(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+		if _, held := b.hold(request.Name); !held {
+			t.Errorf("expected %s to be held until it is reconciled", request.Name)
+		}
+		if _, held := b.hold("new"); held {
+			t.Error("expected jobs that aren't part of the backlog not to be held")
+		}
+		reconciled = append(reconciled, request.Name)
+		if request.Name == "pending" {
+			return reconcile.Result{}, errors.New("injected error")
+		}
+		return reconcile.Result{}, nil
+	}
+	b.run(ctx, logrus.WithField("test", t.Name()), phases, 1, reconcileFunc)
+	close(b.requeue)
+	<-done
+
+	expected := []string{"aborted", "pending", "triggered-new", "triggered-middle", "triggered-old"}
+	if diff := cmp.Diff(expected, reconciled); diff != "" {
+		t.Errorf("unexpected reconciliation order (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(expected, requeued); diff != "" {
+		t.Errorf("unexpected requeued jobs (-want +got):\n%s", diff)
+	}
+	for _, name := range expected {
+		if _, held := b.hold(name); held {
+			t.Errorf("expected %s to be released", name)
+		}
+	}
+}
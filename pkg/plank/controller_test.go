@@ -18,12 +18,16 @@ package plank
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"text/template"
@@ -47,8 +51,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	prowio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 	"sigs.k8s.io/prow/pkg/testutil"
 )
 
@@ -847,6 +853,8 @@ func TestSyncPendingJob(t *testing.T) {
 		PJ   prowapi.ProwJob
 		Pods []v1.Pod
 		Err  error
+		// Heartbeats are the uploaded heartbeats by storage path.
+		Heartbeats map[string]string
 
 		expectedReconcileResult       *reconcile.Result
 		ExpectedState                 prowapi.ProwJobState
@@ -977,6 +985,74 @@ func TestSyncPendingJob(t *testing.T) {
 			ExpectedCreatedPJs: 0,
 			ExpectedURL:        "boop-42/success",
 		},
+		{
+			Name: "succeeded pod leased by another replica",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "boop-42",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.BatchJob,
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+					Refs:    &prowapi.Refs{Org: "fejtaverse"},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "boop-42",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "boop-42",
+						Namespace:   "pods",
+						Annotations: map[string]string{kube.PodLeaseAnnotation: kube.PodLease{Holder: "other", Expires: time.Now().Add(time.Minute)}.String()},
+					},
+					Status: v1.PodStatus{
+						Phase: v1.PodSucceeded,
+					},
+				},
+			},
+			expectedReconcileResult: &reconcile.Result{},
+			ExpectedState:           prowapi.PendingState,
+			ExpectedNumPods:         1,
+		},
+		{
+			Name: "succeeded pod with an expired lease",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "boop-42",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.BatchJob,
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+					Refs:    &prowapi.Refs{Org: "fejtaverse"},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "boop-42",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "boop-42",
+						Namespace:   "pods",
+						Annotations: map[string]string{kube.PodLeaseAnnotation: kube.PodLease{Holder: "other", Expires: time.Now().Add(-time.Minute)}.String()},
+					},
+					Status: v1.PodStatus{
+						Phase: v1.PodSucceeded,
+					},
+				},
+			},
+			ExpectedComplete:   true,
+			ExpectedState:      prowapi.SuccessState,
+			ExpectedNumPods:    1,
+			ExpectedCreatedPJs: 0,
+			ExpectedURL:        "boop-42/success",
+		},
 		{
 			Name: "succeeded pod with unfinished containers",
 			PJ: prowapi.ProwJob{
@@ -1398,6 +1474,215 @@ func TestSyncPendingJob(t *testing.T) {
 			ExpectedURL:               "endless/aborted",
 			ExpectedPodRunningTimeout: &metav1.Duration{Duration: 1 * time.Hour},
 		},
+		{
+			Name: "running prow job with output is requeued to check its heartbeats",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "quiet",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "ci-quiet",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test"}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						HangTimeout:      &metav1.Duration{Duration: 20 * time.Minute},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "quiet",
+					BuildID: "1",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "quiet",
+						Namespace:         "pods",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)},
+					},
+					Status: v1.PodStatus{
+						Phase:     v1.PodRunning,
+						StartTime: startTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+			Heartbeats: map[string]string{
+				"gs://bucket/logs/ci-quiet/1/heartbeat.json": heartbeatJSON(time.Now(), time.Now().Add(-time.Minute), false),
+			},
+			expectedReconcileResult: &reconcile.Result{RequeueAfter: time.Minute},
+			ExpectedState:           prowapi.PendingState,
+			ExpectedNumPods:         1,
+		},
+		{
+			Name: "hung prow job",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "quiet",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "ci-quiet",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test"}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						HangTimeout:      &metav1.Duration{Duration: 20 * time.Minute},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "quiet",
+					BuildID: "1",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "quiet",
+						Namespace:         "pods",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)},
+					},
+					Status: v1.PodStatus{
+						Phase:     v1.PodRunning,
+						StartTime: startTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+			Heartbeats: map[string]string{
+				"gs://bucket/logs/ci-quiet/1/heartbeat.json": heartbeatJSON(time.Now(), time.Now().Add(-30*time.Minute), false),
+			},
+			ExpectedState:    prowapi.AbortedState,
+			ExpectedNumPods:  0,
+			ExpectedComplete: true,
+			ExpectedURL:      "quiet/aborted",
+		},
+		{
+			Name: "prow job whose heartbeats stopped",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "quiet",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "ci-quiet",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test"}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						HangTimeout:      &metav1.Duration{Duration: 20 * time.Minute},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "quiet",
+					BuildID: "1",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "quiet",
+						Namespace:         "pods",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)},
+					},
+					Status: v1.PodStatus{
+						Phase:     v1.PodRunning,
+						StartTime: startTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+			Heartbeats: map[string]string{
+				"gs://bucket/logs/ci-quiet/1/heartbeat.json": heartbeatJSON(time.Now().Add(-25*time.Minute), time.Now().Add(-25*time.Minute), false),
+			},
+			ExpectedState:    prowapi.AbortedState,
+			ExpectedNumPods:  0,
+			ExpectedComplete: true,
+			ExpectedURL:      "quiet/aborted",
+		},
+		{
+			Name: "prow job whose test process finished isn't hung",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "quiet",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "ci-quiet",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test"}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						HangTimeout:      &metav1.Duration{Duration: 20 * time.Minute},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "quiet",
+					BuildID: "1",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "quiet",
+						Namespace:         "pods",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)},
+					},
+					Status: v1.PodStatus{
+						Phase:     v1.PodRunning,
+						StartTime: startTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+			Heartbeats: map[string]string{
+				"gs://bucket/logs/ci-quiet/1/heartbeat.json": heartbeatJSON(time.Now().Add(-25*time.Minute), time.Now().Add(-30*time.Minute), true),
+			},
+			expectedReconcileResult: &reconcile.Result{RequeueAfter: time.Minute},
+			ExpectedState:           prowapi.PendingState,
+			ExpectedNumPods:         1,
+		},
+		{
+			Name: "running prow job without heartbeats yet",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "quiet",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "ci-quiet",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test"}}},
+					DecorationConfig: &prowapi.DecorationConfig{
+						HangTimeout:      &metav1.Duration{Duration: 20 * time.Minute},
+						GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State:   prowapi.PendingState,
+					PodName: "quiet",
+					BuildID: "1",
+				},
+			},
+			Pods: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "quiet",
+						Namespace:         "pods",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)},
+					},
+					Status: v1.PodStatus{
+						Phase:     v1.PodRunning,
+						StartTime: startTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+			expectedReconcileResult: &reconcile.Result{RequeueAfter: time.Minute},
+			ExpectedState:           prowapi.PendingState,
+			ExpectedNumPods:         1,
+		},
 		{
 			Name: "stale unschedulable prow job",
 			PJ: prowapi.ProwJob{
@@ -1692,6 +1977,8 @@ func TestSyncPendingJob(t *testing.T) {
 				config:       config,
 				totURL:       totServ.URL,
 				clock:        clock.RealClock{},
+				opener:       heartbeatOpener(tc.Heartbeats),
+				leaseHolder:  "plank",
 			}
 			reconcileResult, err := r.syncPendingJob(ctx, &tc.PJ)
 			if err != nil {
@@ -1745,6 +2032,9 @@ func TestSyncPendingJob(t *testing.T) {
 				if !podWouldBeGone(pod) {
 					t.Errorf("pod %s was deleted but still had finalizers: %v", pod.Name, pod.Finalizers)
 				}
+				if lease, ok := kube.PodLeaseOf(&pod); ok && lease.Holder == r.leaseHolder {
+					t.Errorf("pod %s is still leased after the sync", pod.Name)
+				}
 			}
 			if actual := actual.Complete(); actual != tc.ExpectedComplete {
 				t.Errorf("expected complete: %t, got complete: %t", tc.ExpectedComplete, actual)
@@ -1753,6 +2043,29 @@ func TestSyncPendingJob(t *testing.T) {
 	}
 }
 
+// fakeHeartbeatOpener serves heartbeats by storage path.
+type fakeHeartbeatOpener struct {
+	prowio.Opener
+	heartbeats map[string]string
+}
+
+func heartbeatOpener(heartbeats map[string]string) prowio.Opener {
+	return &fakeHeartbeatOpener{heartbeats: heartbeats}
+}
+
+func (o *fakeHeartbeatOpener) Reader(_ context.Context, path string) (prowio.ReadCloser, error) {
+	content, ok := o.heartbeats[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func heartbeatJSON(timestamp, lastOutput time.Time, finished bool) string {
+	raw, _ := json.Marshal(wrapper.Heartbeat{Timestamp: timestamp, LastOutput: lastOutput, Finished: finished})
+	return string(raw)
+}
+
 func podWouldBeGone(pod corev1.Pod) bool {
 	if pod.DeletionTimestamp != nil {
 		return true
@@ -2086,11 +2399,13 @@ func TestMaxConcurrency(t *testing.T) {
 	type pendingJob struct {
 		Duplicates int
 		JobQueue   string
+		Refs       *prowapi.Refs
 	}
 
 	type testCase struct {
 		Name               string
 		JobQueueCapacities map[string]int
+		ConcurrencyBudgets map[string]int
 		ProwJob            prowapi.ProwJob
 		ExistingProwJobs   []prowapi.ProwJob
 		PendingJobs        map[string]pendingJob
@@ -2229,6 +2544,57 @@ func TestMaxConcurrency(t *testing.T) {
 			PendingJobs:        map[string]pendingJob{"my-pj": {Duplicates: 10, JobQueue: "queue"}},
 			ExpectedResult:     false,
 		},
+		{
+			Name: "Concurrency budget 0 never runs",
+			ProwJob: prowapi.ProwJob{Spec: prowapi.ProwJobSpec{
+				Job:  "my-pj",
+				Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+			}},
+			ConcurrencyBudgets: map[string]int{"org/repo": 0},
+			ExpectedResult:     false,
+		},
+		{
+			Name: "Num pending of the repo exceeds repo budget",
+			ProwJob: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+				Spec: prowapi.ProwJobSpec{
+					Job:  "my-pj",
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+				},
+			},
+			ConcurrencyBudgets: map[string]int{"org/repo": 2},
+			PendingJobs:        map[string]pendingJob{"other-pj": {Duplicates: 2, Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}},
+			ExpectedResult:     false,
+		},
+		{
+			Name: "Num pending of other repos of the org exceeds org budget",
+			ProwJob: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+				Spec: prowapi.ProwJobSpec{
+					Job:       "my-pj",
+					ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo"}},
+				},
+			},
+			ConcurrencyBudgets: map[string]int{"org": 3, "org/repo": 10},
+			PendingJobs: map[string]pendingJob{
+				"other-pj":   {Duplicates: 2, Refs: &prowapi.Refs{Org: "org", Repo: "other"}},
+				"another-pj": {Duplicates: 1, Refs: &prowapi.Refs{Org: "org", Repo: "another"}},
+			},
+			ExpectedResult: false,
+		},
+		{
+			Name: "Num pending of other orgs does not count against budget",
+			ProwJob: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+				Spec: prowapi.ProwJobSpec{
+					Job:  "my-pj",
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+				},
+			},
+			ConcurrencyBudgets: map[string]int{"org": 1},
+			PendingJobs:        map[string]pendingJob{"other-pj": {Duplicates: 5, Refs: &prowapi.Refs{Org: "other-org", Repo: "repo"}}},
+			ExpectedResult:     true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2256,6 +2622,7 @@ func TestMaxConcurrency(t *testing.T) {
 							Agent:        prowapi.KubernetesAgent,
 							Job:          jobName,
 							JobQueueName: jobsToCreateParams.JobQueue,
+							Refs:         jobsToCreateParams.Refs,
 						},
 						Status: prowapi.ProwJobStatus{
 							State: prowapi.PendingState,
@@ -2265,7 +2632,9 @@ func TestMaxConcurrency(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			config := newFakeConfigAgent(t, 0, tc.JobQueueCapacities).Config
+			configAgent := newFakeConfigAgent(t, 0, tc.JobQueueCapacities)
+			configAgent.c.Plank.ConcurrencyBudgets = tc.ConcurrencyBudgets
+			config := configAgent.Config
 
 			fakeMgr, err := testutil.NewFakeManager(
 				ctx,
@@ -2316,6 +2685,8 @@ type deleteTrackingFakeClient struct {
 	deleteError error
 	ctrlruntimeclient.Client
 	deleted sets.Set[string]
+	// abortedBy records the aborted-by annotation pods had when they got deleted.
+	abortedBy map[string]string
 }
 
 func (c *deleteTrackingFakeClient) Delete(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.DeleteOption) error {
@@ -2324,6 +2695,11 @@ func (c *deleteTrackingFakeClient) Delete(ctx context.Context, obj ctrlruntimecl
 	}
 	if c.deleted == nil {
 		c.deleted = sets.Set[string]{}
+		c.abortedBy = map[string]string{}
+	}
+	pod := &v1.Pod{}
+	if err := c.Client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(obj), pod); err == nil {
+		c.abortedBy[obj.GetName()] = pod.Annotations[prowapi.AbortedByAnnotation]
 	}
 	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
 		return err
@@ -2356,12 +2732,14 @@ func TestSyncAbortedJob(t *testing.T) {
 	t.Parallel()
 
 	type testCase struct {
-		Name           string
-		Pod            *v1.Pod
-		DeleteError    error
-		ExpectSyncFail bool
-		ExpectDelete   bool
-		ExpectComplete bool
+		Name              string
+		Pod               *v1.Pod
+		AbortedBy         string
+		DeleteError       error
+		ExpectSyncFail    bool
+		ExpectDelete      bool
+		ExpectComplete    bool
+		ExpectedAbortedBy string
 	}
 
 	testCases := []testCase{
@@ -2371,11 +2749,25 @@ func TestSyncAbortedJob(t *testing.T) {
 			ExpectDelete:   true,
 			ExpectComplete: true,
 		},
+		{
+			Name:              "Pod is annotated with the user who aborted the job before it is deleted",
+			Pod:               &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "my-pj"}},
+			AbortedBy:         "someone",
+			ExpectDelete:      true,
+			ExpectComplete:    true,
+			ExpectedAbortedBy: "someone",
+		},
 		{
 			Name:           "No pod there",
 			ExpectDelete:   false,
 			ExpectComplete: true,
 		},
+		{
+			Name:           "No pod there to annotate",
+			AbortedBy:      "someone",
+			ExpectDelete:   false,
+			ExpectComplete: true,
+		},
 		{
 			Name:           "NotFound on delete is tolerated",
 			Pod:            &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "my-pj"}},
@@ -2407,6 +2799,9 @@ func TestSyncAbortedJob(t *testing.T) {
 					State: prowapi.AbortedState,
 				},
 			}
+			if tc.AbortedBy != "" {
+				pj.Annotations = map[string]string{prowapi.AbortedByAnnotation: tc.AbortedBy}
+			}
 
 			builder := fakectrlruntimeclient.NewClientBuilder()
 			if tc.Pod != nil {
@@ -2457,6 +2852,9 @@ func TestSyncAbortedJob(t *testing.T) {
 			if tc.ExpectDelete != podClient.deleted.Has(pj.Name) {
 				t.Errorf("expected delete: %t, got delete: %t", tc.ExpectDelete, podClient.deleted.Has(pj.Name))
 			}
+			if abortedBy := podClient.abortedBy[pj.Name]; abortedBy != tc.ExpectedAbortedBy {
+				t.Errorf("expected the pod to be aborted by %q, got %q", tc.ExpectedAbortedBy, abortedBy)
+			}
 		})
 	}
 }
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/gcsupload"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+// stalledFor returns the longest time a test process of the job went
+// without output, according to the heartbeats the sidecar uploaded. If the
+// heartbeats of a process stopped while it still ran, the process counts as
+// stalled since its last output. Processes that didn't upload a heartbeat
+// yet are skipped.
+func (r *reconciler) stalledFor(ctx context.Context, pj *prowv1.ProwJob, hangTimeout time.Duration) (time.Duration, error) {
+	dc := pj.Spec.DecorationConfig
+	if r.opener == nil || dc == nil || dc.GCSConfiguration == nil || pj.Spec.PodSpec == nil {
+		return 0, nil
+	}
+	spec := downwardapi.NewJobSpec(pj.Spec, pj.Status.BuildID, pj.Name)
+	_, dir, _ := gcsupload.PathsForJob(dc.GCSConfiguration, &spec, "")
+	now := r.clock.Now()
+	var stalled time.Duration
+	for _, container := range pj.Spec.PodSpec.Containers {
+		storagePath, err := providers.StoragePath(dc.GCSConfiguration.Bucket, path.Join(dir, wrapper.HeartbeatName(len(pj.Spec.PodSpec.Containers), container.Name)))
+		if err != nil {
+			return 0, fmt.Errorf("resolve heartbeat path: %w", err)
+		}
+		raw, err := io.ReadContent(ctx, r.log, r.opener, storagePath)
+		if err != nil {
+			if io.IsNotExist(err) {
+				continue
+			}
+			return 0, fmt.Errorf("read heartbeat %s: %w", storagePath, err)
+		}
+		var heartbeat wrapper.Heartbeat
+		if err := json.Unmarshal(raw, &heartbeat); err != nil {
+			return 0, fmt.Errorf("unmarshal heartbeat %s: %w", storagePath, err)
+		}
+		if !heartbeat.Finished && now.Sub(heartbeat.Timestamp) >= hangTimeout {
+			heartbeat.Timestamp = now
+		}
+		if s := heartbeat.Stalled(); s > stalled {
+			stalled = s
+		}
+	}
+	return stalled, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// resolvedImageTTL is how long the digest an image tag resolved to is
+// reused, so tags that move are picked up eventually without asking the
+// registry for every pod.
+const resolvedImageTTL = 10 * time.Minute
+
+// imageResolver pins images to the digest of their variant for a CPU
+// architecture.
+type imageResolver interface {
+	Resolve(ctx context.Context, image, arch string) (string, error)
+}
+
+// registryResolver resolves images through their registry, using the
+// credentials of the docker config and cloud providers if there are any.
+type registryResolver struct {
+	lock    sync.Mutex
+	cache   map[string]resolvedImage
+	now     func() time.Time
+	options []remote.Option
+}
+
+type resolvedImage struct {
+	image    string
+	resolved time.Time
+}
+
+func newRegistryResolver() *registryResolver {
+	return &registryResolver{
+		cache:   map[string]resolvedImage{},
+		now:     time.Now,
+		options: []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+	}
+}
+
+// Resolve returns the image pinned to the digest of the linux variant for
+// the architecture, keeping its tag for readability. Images that are not
+// multi-arch indexes are returned as is, as there is nothing to choose from.
+func (r *registryResolver) Resolve(ctx context.Context, image, arch string) (string, error) {
+	key := image + "|" + arch
+	r.lock.Lock()
+	cached, ok := r.cache[key]
+	r.lock.Unlock()
+	if ok && r.now().Sub(cached.resolved) < resolvedImageTTL {
+		return cached.image, nil
+	}
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", fmt.Errorf("parse image %q: %w", image, err)
+	}
+	desc, err := remote.Get(ref, append([]remote.Option{remote.WithContext(ctx)}, r.options...)...)
+	if err != nil {
+		return "", fmt.Errorf("get manifest of %s: %w", image, err)
+	}
+	resolved := image
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return "", fmt.Errorf("read index of %s: %w", image, err)
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return "", fmt.Errorf("read index of %s: %w", image, err)
+		}
+		resolved = ""
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == arch {
+				resolved = pinnedImage(ref, m.Digest.String())
+				break
+			}
+		}
+		if resolved == "" {
+			return "", fmt.Errorf("image %s has no variant for linux/%s", image, arch)
+		}
+	}
+
+	r.lock.Lock()
+	r.cache[key] = resolvedImage{image: resolved, resolved: r.now()}
+	r.lock.Unlock()
+	return resolved, nil
+}
+
+// pinnedImage returns the reference with the given digest, keeping its tag.
+func pinnedImage(ref name.Reference, digest string) string {
+	if tag, ok := ref.(name.Tag); ok {
+		return fmt.Sprintf("%s:%s@%s", tag.Context().Name(), tag.TagStr(), digest)
+	}
+	return fmt.Sprintf("%s@%s", ref.Context().Name(), digest)
+}
+
+// jobArchitecture returns the CPU architecture the pod of the job runs on,
+// which is the kubernetes.io/arch node selector of the pod spec of the job
+// or else the architecture of its build cluster.
+func (r *reconciler) jobArchitecture(pj *prowv1.ProwJob) string {
+	if pj.Spec.PodSpec != nil {
+		if arch := pj.Spec.PodSpec.NodeSelector[corev1.LabelArchStable]; arch != "" {
+			return arch
+		}
+	}
+	return r.config().Plank.BuildClusterArchitecture(pj.ClusterAlias())
+}
+
+// withArchitectureImages returns a copy of the job with its utility images
+// pinned to the digests for the architecture of the job. Images that can't
+// be resolved are left to the container runtime, which picks the variant of
+// multi-arch images on its own.
+func (r *reconciler) withArchitectureImages(ctx context.Context, pj *prowv1.ProwJob) *prowv1.ProwJob {
+	if r.imageResolver == nil || pj.Spec.DecorationConfig == nil || pj.Spec.DecorationConfig.UtilityImages == nil {
+		return pj
+	}
+	arch := r.jobArchitecture(pj)
+	if arch == "" {
+		return pj
+	}
+	pj = pj.DeepCopy()
+	images := pj.Spec.DecorationConfig.UtilityImages
+	for _, image := range []*string{&images.CloneRefs, &images.InitUpload, &images.Entrypoint, &images.Sidecar} {
+		if *image == "" {
+			continue
+		}
+		resolved, err := r.imageResolver.Resolve(ctx, *image, arch)
+		if err != nil {
+			r.log.WithError(err).WithField("arch", arch).Warn("Failed to resolve the utility image for the architecture of the job.")
+			continue
+		}
+		*image = resolved
+	}
+	return pj
+}
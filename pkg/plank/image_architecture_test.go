@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestRegistryResolver(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	amd64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arm64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+	clonerefs, err := name.ParseReference(host + "/prow/clonerefs:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(clonerefs, index); err != nil {
+		t.Fatal(err)
+	}
+	sidecar, err := name.ParseReference(host + "/prow/sidecar:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(sidecar, amd64); err != nil {
+		t.Fatal(err)
+	}
+	armDigest, err := arm64.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDigest, err := index.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		image         string
+		arch          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "tag of a multi-arch image",
+			image:    host + "/prow/clonerefs:v1",
+			arch:     "arm64",
+			expected: host + "/prow/clonerefs:v1@" + armDigest.String(),
+		},
+		{
+			name:     "digest of a multi-arch image",
+			image:    host + "/prow/clonerefs@" + indexDigest.String(),
+			arch:     "arm64",
+			expected: host + "/prow/clonerefs@" + armDigest.String(),
+		},
+		{
+			name:     "single-arch image is kept",
+			image:    host + "/prow/sidecar:v1",
+			arch:     "arm64",
+			expected: host + "/prow/sidecar:v1",
+		},
+		{
+			name:          "architecture without a variant",
+			image:         host + "/prow/clonerefs:v1",
+			arch:          "s390x",
+			expectedError: "has no variant for linux/s390x",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := newRegistryResolver()
+			resolver.options = nil
+			resolved, err := resolver.Resolve(context.Background(), tc.image, tc.arch)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, resolved); diff != "" {
+				t.Errorf("unexpected image (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("resolutions are cached", func(t *testing.T) {
+		resolver := newRegistryResolver()
+		resolver.options = nil
+		now := time.Now()
+		resolver.now = func() time.Time { return now }
+		image := host + "/prow/clonerefs:v1"
+		if _, err := resolver.Resolve(context.Background(), image, "arm64"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		server.Close()
+		resolved, err := resolver.Resolve(context.Background(), image, "arm64")
+		if err != nil {
+			t.Fatalf("expected cached resolution, got %v", err)
+		}
+		if diff := cmp.Diff(image+"@"+armDigest.String(), resolved); diff != "" {
+			t.Errorf("unexpected image (-want +got):\n%s", diff)
+		}
+		now = now.Add(resolvedImageTTL)
+		if _, err := resolver.Resolve(context.Background(), image, "arm64"); err == nil {
+			t.Error("expected the expired resolution to be refreshed from the registry")
+		}
+	})
+}
+
+type fakeImageResolver map[string]string
+
+func (f fakeImageResolver) Resolve(_ context.Context, image, arch string) (string, error) {
+	if resolved, ok := f[image+"|"+arch]; ok {
+		return resolved, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestWithArchitectureImages(t *testing.T) {
+	resolver := fakeImageResolver{
+		"clonerefs:v1|arm64":  "clonerefs:v1@sha256:arm64",
+		"entrypoint:v1|arm64": "entrypoint:v1@sha256:arm64",
+		"clonerefs:v1|s390x":  "clonerefs:v1@sha256:s390x",
+	}
+	images := func(clonerefs, entrypoint string) *prowv1.ProwJob {
+		return &prowv1.ProwJob{
+			Spec: prowv1.ProwJobSpec{
+				Cluster: "arm",
+				PodSpec: &corev1.PodSpec{},
+				DecorationConfig: &prowv1.DecorationConfig{UtilityImages: &prowv1.UtilityImages{
+					CloneRefs:  clonerefs,
+					InitUpload: "initupload:v1",
+					Entrypoint: entrypoint,
+				}},
+			},
+		}
+	}
+	testCases := []struct {
+		name          string
+		architectures map[string]string
+		nodeSelector  map[string]string
+		expected      *prowv1.ProwJob
+	}{
+		{
+			name:     "unknown architecture",
+			expected: images("clonerefs:v1", "entrypoint:v1"),
+		},
+		{
+			name:          "architecture of the build cluster",
+			architectures: map[string]string{"arm": "arm64", "*": "amd64"},
+			expected:      images("clonerefs:v1@sha256:arm64", "entrypoint:v1@sha256:arm64"),
+		},
+		{
+			name:          "node selector of the job",
+			architectures: map[string]string{"*": "arm64"},
+			nodeSelector:  map[string]string{corev1.LabelArchStable: "s390x"},
+			expected:      images("clonerefs:v1@sha256:s390x", "entrypoint:v1"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &reconciler{
+				config: func() *config.Config {
+					return &config.Config{ProwConfig: config.ProwConfig{Plank: config.Plank{BuildClusterArchitectures: tc.architectures}}}
+				},
+				imageResolver: resolver,
+				log:           logrus.NewEntry(logrus.StandardLogger()),
+			}
+			pj := images("clonerefs:v1", "entrypoint:v1")
+			pj.Spec.PodSpec.NodeSelector = tc.nodeSelector
+			tc.expected.Spec.PodSpec.NodeSelector = tc.nodeSelector
+			if diff := cmp.Diff(tc.expected, r.withArchitectureImages(context.Background(), pj)); diff != "" {
+				t.Errorf("unexpected job (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
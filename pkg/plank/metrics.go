@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+var (
+	concurrencyBudget = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prowjobs_concurrency_budget",
+		Help: "Number of prowjobs of an org or repo allowed to run at the same time.",
+	}, []string{
+		// the org or org/repo of the budget
+		"budget",
+	})
+	concurrencyBudgetUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prowjobs_concurrency_budget_usage",
+		Help: "Number of triggered and pending prowjobs subject to a concurrency budget.",
+	}, []string{
+		// the org or org/repo of the budget
+		"budget",
+		// state of the prowjob: triggered or pending
+		"state",
+	})
+	startupBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "plank_startup_backlog",
+		Help: "Number of prowjobs that existed when plank started and were not processed yet.",
+	}, []string{
+		// the phase of the backlog: cleanup of aborted and pending jobs, or triggered
+		"phase",
+	})
+	utilityImagesJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "plank_utility_images_jobs",
+		Help: "Number of completed prowjobs subject to the canary of utility images.",
+	}, []string{
+		// the build cluster of the prowjob
+		"cluster",
+		// the utility images of the pod: canary or stable
+		"images",
+		// the state the prowjob completed in
+		"state",
+	})
+	utilityImagesFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "plank_utility_images_failures",
+		Help: "Number of prowjobs subject to the canary of utility images that failed in a utility container.",
+	}, []string{
+		// the build cluster of the prowjob
+		"cluster",
+		// the utility images of the pod: canary or stable
+		"images",
+		// the utility container that failed
+		"container",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(concurrencyBudget)
+	prometheus.MustRegister(concurrencyBudgetUsage)
+	prometheus.MustRegister(startupBacklog)
+	prometheus.MustRegister(utilityImagesJobs)
+	prometheus.MustRegister(utilityImagesFailures)
+}
+
+func gatherConcurrencyBudgetMetrics(usage []config.ConcurrencyBudgetUsage) {
+	// Budgets removed from the config must not be reported anymore.
+	concurrencyBudget.Reset()
+	concurrencyBudgetUsage.Reset()
+	for _, budget := range usage {
+		concurrencyBudget.WithLabelValues(budget.Budget).Set(float64(budget.Limit))
+		concurrencyBudgetUsage.WithLabelValues(budget.Budget, string(prowv1.PendingState)).Set(float64(budget.Pending))
+		concurrencyBudgetUsage.WithLabelValues(budget.Budget, string(prowv1.TriggeredState)).Set(float64(budget.Triggered))
+	}
+}
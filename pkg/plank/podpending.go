@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// waitingReasons maps the reasons a container can be waiting for to the
+// pending reason that is surfaced on the ProwJob. Reasons not listed here
+// (e.g. ContainerCreating) are considered transient and are not reported.
+var waitingReasons = map[string]prowv1.PodPendingReasonType{
+	"ErrImagePull":               prowv1.PodImagePullBackOff,
+	"ImagePullBackOff":           prowv1.PodImagePullBackOff,
+	"InvalidImageName":           prowv1.PodImagePullBackOff,
+	"ErrImageNeverPull":          prowv1.PodImagePullBackOff,
+	"CreateContainerConfigError": prowv1.PodContainerConfigError,
+	"CreateContainerError":       prowv1.PodContainerConfigError,
+}
+
+// diagnosePendingPod inspects the conditions and container statuses of a
+// pending pod and returns the most likely explanation for why it has not
+// started yet, or nil if no problem could be identified.
+func diagnosePendingPod(pod *corev1.Pod) *prowv1.PodPendingReason {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		reason := prowv1.PodUnschedulable
+		if strings.Contains(strings.ToLower(condition.Message), "quota") {
+			reason = prowv1.PodQuotaExceeded
+		}
+		return &prowv1.PodPendingReason{
+			Reason:             reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime,
+		}
+	}
+
+	// Init containers run first, so if one of them is stuck it is the culprit.
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		reason, ok := waitingReasons[status.State.Waiting.Reason]
+		if !ok {
+			continue
+		}
+		message := status.State.Waiting.Message
+		if message == "" {
+			message = status.State.Waiting.Reason
+		}
+		return &prowv1.PodPendingReason{
+			Reason:    reason,
+			Message:   message,
+			Container: status.Name,
+		}
+	}
+
+	return nil
+}
+
+// updatePodPendingReason records the given diagnosis on the ProwJob. The
+// transition time of an already known reason is preserved so that it reflects
+// when the problem was first observed.
+func updatePodPendingReason(pj *prowv1.ProwJob, diagnosis *prowv1.PodPendingReason, now metav1.Time) {
+	if diagnosis == nil {
+		pj.Status.PodPendingReason = nil
+		return
+	}
+	if previous := pj.Status.PodPendingReason; previous != nil && previous.Reason == diagnosis.Reason && previous.Container == diagnosis.Container {
+		diagnosis.LastTransitionTime = previous.LastTransitionTime
+	}
+	if diagnosis.LastTransitionTime.IsZero() {
+		diagnosis.LastTransitionTime = now
+	}
+	pj.Status.PodPendingReason = diagnosis
+}
+
+// describePendingTimeout returns the description for a job whose pod timed
+// out while pending, including the pending reason if one is known.
+func describePendingTimeout(description string, reason *prowv1.PodPendingReason) string {
+	if reason == nil {
+		return description + "."
+	}
+	return fmt.Sprintf("%s: %s", description, reason)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestDiagnosePendingPod(t *testing.T) {
+	transition := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected *prowv1.PodPendingReason
+	}{
+		{
+			name:   "no signal",
+			status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		{
+			name: "unschedulable",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					Message:            "0/3 nodes are available: 3 Insufficient cpu.",
+					LastTransitionTime: transition,
+				}},
+			},
+			expected: &prowv1.PodPendingReason{
+				Reason:             prowv1.PodUnschedulable,
+				Message:            "0/3 nodes are available: 3 Insufficient cpu.",
+				LastTransitionTime: transition,
+			},
+		},
+		{
+			name: "quota",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Message: "exceeded quota: compute-resources",
+				}},
+			},
+			expected: &prowv1.PodPendingReason{
+				Reason:  prowv1.PodQuotaExceeded,
+				Message: "exceeded quota: compute-resources",
+			},
+		},
+		{
+			name: "scheduled pod is not reported as unschedulable",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:   corev1.PodScheduled,
+					Status: corev1.ConditionTrue,
+				}},
+			},
+		},
+		{
+			name: "image pull backoff",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+					{Name: "test", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image \"busybox:nope\""}}},
+				},
+			},
+			expected: &prowv1.PodPendingReason{
+				Reason:    prowv1.PodImagePullBackOff,
+				Message:   "Back-off pulling image \"busybox:nope\"",
+				Container: "test",
+			},
+		},
+		{
+			name: "init container config error takes precedence",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "clonerefs", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CreateContainerConfigError"}}},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "test", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}}},
+				},
+			},
+			expected: &prowv1.PodPendingReason{
+				Reason:    prowv1.PodContainerConfigError,
+				Message:   "CreateContainerConfigError",
+				Container: "clonerefs",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := diagnosePendingPod(&corev1.Pod{Status: tc.status})
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected pending reason (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdatePodPendingReason(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		name      string
+		previous  *prowv1.PodPendingReason
+		diagnosis *prowv1.PodPendingReason
+		expected  *prowv1.PodPendingReason
+	}{
+		{
+			name:     "diagnosis cleared",
+			previous: &prowv1.PodPendingReason{Reason: prowv1.PodUnschedulable, LastTransitionTime: earlier},
+		},
+		{
+			name:      "new diagnosis without time uses now",
+			diagnosis: &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test"},
+			expected:  &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test", LastTransitionTime: now},
+		},
+		{
+			name:      "unchanged diagnosis keeps transition time",
+			previous:  &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test", Message: "old", LastTransitionTime: earlier},
+			diagnosis: &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test", Message: "new"},
+			expected:  &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test", Message: "new", LastTransitionTime: earlier},
+		},
+		{
+			name:      "changed diagnosis resets transition time",
+			previous:  &prowv1.PodPendingReason{Reason: prowv1.PodUnschedulable, LastTransitionTime: earlier},
+			diagnosis: &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test"},
+			expected:  &prowv1.PodPendingReason{Reason: prowv1.PodImagePullBackOff, Container: "test", LastTransitionTime: now},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &prowv1.ProwJob{Status: prowv1.ProwJobStatus{PodPendingReason: tc.previous}}
+			updatePodPendingReason(pj, tc.diagnosis, now)
+			if diff := cmp.Diff(tc.expected, pj.Status.PodPendingReason); diff != "" {
+				t.Errorf("unexpected pending reason (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
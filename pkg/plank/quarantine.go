@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/quarantine"
+)
+
+// withQuarantine returns a copy of the job that also quarantines the tests
+// quarantined from Deck for its repo. Failing to read them doesn't keep the
+// job from starting, its failures just aren't quarantined.
+func (r *reconciler) withQuarantine(ctx context.Context, pj *prowv1.ProwJob) *prowv1.ProwJob {
+	cfg := r.config().Quarantine
+	if cfg == nil || r.opener == nil || pj.Spec.DecorationConfig == nil || pj.Spec.Refs == nil {
+		return pj
+	}
+	stored, err := quarantine.NewStore(r.opener, cfg.StoragePath).Get(ctx, pj.Spec.Refs.Org, pj.Spec.Refs.Repo)
+	if err != nil {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to read the quarantined tests, starting the job without them.")
+		return pj
+	}
+	if len(stored) == 0 {
+		return pj
+	}
+	pj = pj.DeepCopy()
+	pj.Spec.DecorationConfig.QuarantinedTests = quarantine.Merge(pj.Spec.DecorationConfig.QuarantinedTests, stored)
+	return pj
+}
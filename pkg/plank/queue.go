@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// queueHistorySize is how many of the latest completed runs sharing a limit
+// the duration of the jobs waiting for it is estimated from.
+const queueHistorySize = 20
+
+// throttle is the limit that keeps a triggered job from starting.
+type throttle struct {
+	// limit names the limit for humans.
+	limit string
+	// capacity is how many jobs of the limit may run at the same time.
+	capacity int
+	// jobs are the pending and triggered jobs sharing the limit. They are
+	// unknown for plank's global max_concurrency, as plank doesn't start
+	// jobs in order for it.
+	jobs []prowv1.ProwJob
+	// matches tells whether a job shares the limit.
+	matches func(prowv1.ProwJob) bool
+}
+
+// updateQueueStatus records where the job waits for the throttle to let it
+// start, if queue statuses are enabled and the last update is old enough.
+func (r *reconciler) updateQueueStatus(ctx context.Context, pj *prowv1.ProwJob, t *throttle) error {
+	interval := r.config().Plank.QueueStatusInterval
+	if interval == nil || t.jobs == nil {
+		return nil
+	}
+	now := r.clock.Now()
+	if prev := pj.Status.Queue; prev != nil && now.Sub(prev.UpdateTime.Time) < interval.Duration {
+		return nil
+	}
+
+	all := &prowv1.ProwJobList{}
+	if err := r.pjClient.List(ctx, all, optAllProwJobs()); err != nil {
+		return fmt.Errorf("failed to list prowjobs: %w", err)
+	}
+	queue := queueStatusFor(*pj, t, historicalDuration(all.Items, t.matches), now)
+	if queue.SamePosition(pj.Status.Queue) {
+		// Reporters already told this position, so they don't need to again.
+		queue.ReportedBy = pj.Status.Queue.ReportedBy
+	}
+
+	prevPJ := pj.DeepCopy()
+	pj.Status.Queue = queue
+	return r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ))
+}
+
+// queueStatusFor returns where the job waits among the jobs of the throttle.
+// Jobs of a limit start in the order they were created, so the job waits
+// for the triggered jobs created before it.
+func queueStatusFor(pj prowv1.ProwJob, t *throttle, duration time.Duration, now time.Time) *prowv1.QueueStatus {
+	queue := &prowv1.QueueStatus{Limit: t.limit, Position: 1, Length: 1, UpdateTime: metav1.NewTime(now)}
+	var running []time.Time
+	for _, other := range t.jobs {
+		if other.UID == pj.UID {
+			continue
+		}
+		switch other.Status.State {
+		case prowv1.PendingState:
+			started := other.CreationTimestamp.Time
+			if other.Status.PendingTime != nil {
+				started = other.Status.PendingTime.Time
+			}
+			running = append(running, started)
+		case prowv1.TriggeredState:
+			queue.Length++
+			if other.CreationTimestamp.Before(&pj.CreationTimestamp) {
+				queue.Position++
+			}
+		}
+	}
+	if duration > 0 && t.capacity > 0 {
+		start := metav1.NewTime(estimateStart(now, t.capacity, running, queue.Position-1, duration))
+		queue.EstimatedStartTime = &start
+	}
+	return queue
+}
+
+// historicalDuration returns the median duration of the latest completed
+// runs of the jobs matching the filter, or 0 without any.
+func historicalDuration(pjs []prowv1.ProwJob, matches func(prowv1.ProwJob) bool) time.Duration {
+	var completed []prowv1.ProwJob
+	for _, pj := range pjs {
+		if pj.Status.PendingTime == nil || pj.Status.CompletionTime == nil || matches == nil || !matches(pj) {
+			continue
+		}
+		if pj.Status.State != prowv1.SuccessState && pj.Status.State != prowv1.FailureState {
+			continue
+		}
+		completed = append(completed, pj)
+	}
+	if len(completed) == 0 {
+		return 0
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].Status.CompletionTime.After(completed[j].Status.CompletionTime.Time)
+	})
+	if len(completed) > queueHistorySize {
+		completed = completed[:queueHistorySize]
+	}
+	durations := make([]time.Duration, 0, len(completed))
+	for _, pj := range completed {
+		durations = append(durations, pj.Status.CompletionTime.Sub(pj.Status.PendingTime.Time))
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[len(durations)/2]
+}
+
+// estimateStart estimates when a job starts that waits for the given number
+// of jobs ahead of it, while the running jobs started at the given times, if
+// every job takes the duration and at most capacity of them run at once.
+func estimateStart(now time.Time, capacity int, running []time.Time, ahead int, duration time.Duration) time.Time {
+	finishes := &timeHeap{}
+	for _, started := range running {
+		finish := started.Add(duration)
+		if finish.Before(now) {
+			finish = now
+		}
+		heap.Push(finishes, finish)
+	}
+	start := now
+	for i := 0; ; i++ {
+		for finishes.Len() >= capacity {
+			if finish := heap.Pop(finishes).(time.Time); finish.After(start) {
+				start = finish
+			}
+		}
+		if i == ahead {
+			return start
+		}
+		heap.Push(finishes, start.Add(duration))
+	}
+}
+
+// timeHeap is a min-heap of times.
+type timeHeap []time.Time
+
+func (h timeHeap) Len() int           { return len(h) }
+func (h timeHeap) Less(i, j int) bool { return h[i].Before(h[j]) }
+func (h timeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *timeHeap) Push(x any)        { *h = append(*h, x.(time.Time)) }
+func (h *timeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestEstimateStart(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		capacity int
+		running  []time.Duration
+		ahead    int
+		expected time.Duration
+	}{
+		{
+			name:     "first in line waits for the oldest running job",
+			capacity: 2,
+			running:  []time.Duration{-40 * time.Minute, -10 * time.Minute},
+			expected: 20 * time.Minute,
+		},
+		{
+			name:     "jobs ahead take the slots that free up first",
+			capacity: 2,
+			running:  []time.Duration{-40 * time.Minute, -10 * time.Minute},
+			ahead:    2,
+			expected: 80 * time.Minute,
+		},
+		{
+			name:     "overdue jobs are expected to finish now",
+			capacity: 1,
+			running:  []time.Duration{-3 * time.Hour},
+			ahead:    1,
+			expected: time.Hour,
+		},
+		{
+			name:     "more jobs running than the capacity all have to finish",
+			capacity: 1,
+			running:  []time.Duration{-50 * time.Minute, -30 * time.Minute},
+			expected: 30 * time.Minute,
+		},
+		{
+			name:     "free slots start jobs now",
+			capacity: 3,
+			running:  []time.Duration{-10 * time.Minute},
+			ahead:    1,
+			expected: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var running []time.Time
+			for _, offset := range tc.running {
+				running = append(running, now.Add(offset))
+			}
+			if actual := estimateStart(now, tc.capacity, running, tc.ahead, time.Hour).Sub(now); actual != tc.expected {
+				t.Errorf("expected the job to start in %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQueueStatusFor(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	job := func(name string, state prowv1.ProwJobState, created time.Duration) prowv1.ProwJob {
+		pj := prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name), CreationTimestamp: metav1.NewTime(now.Add(created))},
+			Status:     prowv1.ProwJobStatus{State: state},
+		}
+		if state == prowv1.PendingState {
+			pj.Status.PendingTime = &pj.CreationTimestamp
+		}
+		return pj
+	}
+	pj := job("self", prowv1.TriggeredState, -5*time.Minute)
+	jobs := []prowv1.ProwJob{
+		pj,
+		job("running", prowv1.PendingState, -30*time.Minute),
+		job("older", prowv1.TriggeredState, -10*time.Minute),
+		job("newer", prowv1.TriggeredState, -time.Minute),
+	}
+	start := metav1.NewTime(now.Add(90 * time.Minute))
+
+	testCases := []struct {
+		name     string
+		duration time.Duration
+		expected *prowv1.QueueStatus
+	}{
+		{
+			name:     "estimate the start from the duration",
+			duration: time.Hour,
+			expected: &prowv1.QueueStatus{Limit: "job queue gpu", Position: 2, Length: 3, EstimatedStartTime: &start, UpdateTime: metav1.NewTime(now)},
+		},
+		{
+			name:     "no estimate without history",
+			expected: &prowv1.QueueStatus{Limit: "job queue gpu", Position: 2, Length: 3, UpdateTime: metav1.NewTime(now)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := queueStatusFor(pj, &throttle{limit: "job queue gpu", capacity: 1, jobs: jobs}, tc.duration, now)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected queue status (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHistoricalDuration(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	run := func(job string, state prowv1.ProwJobState, took time.Duration) prowv1.ProwJob {
+		pending := metav1.NewTime(now.Add(-took))
+		completion := metav1.NewTime(now)
+		return prowv1.ProwJob{
+			Spec:   prowv1.ProwJobSpec{Job: job},
+			Status: prowv1.ProwJobStatus{State: state, PendingTime: &pending, CompletionTime: &completion},
+		}
+	}
+	pjs := []prowv1.ProwJob{
+		run("a", prowv1.SuccessState, 10*time.Minute),
+		run("a", prowv1.FailureState, 30*time.Minute),
+		run("a", prowv1.SuccessState, 20*time.Minute),
+		run("a", prowv1.AbortedState, time.Minute),
+		run("b", prowv1.SuccessState, time.Hour),
+		{Spec: prowv1.ProwJobSpec{Job: "a"}, Status: prowv1.ProwJobStatus{State: prowv1.PendingState}},
+	}
+	matchesA := func(pj prowv1.ProwJob) bool { return pj.Spec.Job == "a" }
+	if actual := historicalDuration(pjs, matchesA); actual != 20*time.Minute {
+		t.Errorf("expected the median duration of 20m, got %s", actual)
+	}
+	if actual := historicalDuration(pjs, func(prowv1.ProwJob) bool { return false }); actual != 0 {
+		t.Errorf("expected no duration without runs, got %s", actual)
+	}
+}
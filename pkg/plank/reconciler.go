@@ -21,12 +21,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -138,6 +141,13 @@ func add(
 		WithOptions(controller.Options{MaxConcurrentReconciles: numWorkers})
 
 	r := newReconciler(ctx, mgr.GetClient(), overwriteReconcile, cfg, opener, totURL)
+	if cfg().Plank.StartupBacklog != nil {
+		r.backlog = newBacklog()
+		blder.WatchesRawSource(source.Channel(r.backlog.requeue, &handler.EnqueueRequestForObject{}))
+		if err := mgr.Add(manager.RunnableFunc(r.processBacklog(numWorkers))); err != nil {
+			return fmt.Errorf("failed to add startup backlog runnable to manager: %w", err)
+		}
+	}
 	for buildClusterName, buildCluster := range buildClusters {
 		r.log.WithFields(logrus.Fields{
 			"buildCluster": buildClusterName,
@@ -196,6 +206,9 @@ func newReconciler(ctx context.Context, pjClient ctrlruntimeclient.Client, overw
 		opener:             opener,
 		totURL:             totURL,
 		clock:              clock.RealClock{},
+		percentile:         func() int { return rand.Intn(100) },
+		imageResolver:      newRegistryResolver(),
+		leaseHolder:        leaseHolder(),
 		maxConcurrencySerializationLocks: &shardedLock{
 			mapLock: &sync.Mutex{},
 			locks:   map[string]*sync.Mutex{},
@@ -204,6 +217,10 @@ func newReconciler(ctx context.Context, pjClient ctrlruntimeclient.Client, overw
 			mapLock: &sync.Mutex{},
 			locks:   map[string]*sync.Mutex{},
 		},
+		concurrencyBudgetSerializationLocks: &shardedLock{
+			mapLock: &sync.Mutex{},
+			locks:   map[string]*sync.Mutex{},
+		},
 	}
 }
 
@@ -216,23 +233,36 @@ type reconciler struct {
 	opener             io.Opener
 	totURL             string
 	clock              clock.WithTickerAndDelayedExecution
-	/* maxConcurrencySerializationLocks and jobQueueSerializationLocks are used to serialize
-	   reconciliation of ProwJobs that have concurrency limits that might affect eachother.
+	// percentile returns a random number in [0, 100) to pick the jobs that
+	// use the canary utility images.
+	percentile func() int
+	// imageResolver pins the utility images to the digests for the
+	// architecture of the job, see Plank.BuildClusterArchitectures.
+	imageResolver imageResolver
+	// leaseHolder identifies this replica in the leases it holds on pods
+	// while it updates their ProwJobs, see kube.PodLeaseAnnotation.
+	leaseHolder string
+	/* maxConcurrencySerializationLocks, jobQueueSerializationLocks and concurrencyBudgetSerializationLocks
+	   are used to serialize reconciliation of ProwJobs that have concurrency limits that might affect eachother.
 
 	   The concurrency management strategy has 3 basic parts. Each part is skipped if the ProwJob
-	   does not specify a MaxConcurrency or JobQueueName.
+	   does not specify a MaxConcurrency or JobQueueName and is not subject to a concurrency budget.
 
-	   1. Serialize per the job and/or queue name as needed using these locks. This prevents
+	   1. Serialize per the job name, queue name and/or budget as needed using these locks. This prevents
 	      concurrent reconciliation threads from triggering jobs beyond the concurrency limit.
-	   2. Compare against the ProwJob index to see how many jobs there are for the job and job queue
-	      and only trigger the job if it won't exceed the concurrency limit(s).
+	   2. Compare against the ProwJob index to see how many jobs there are for the job, job queue and
+	      budgets and only trigger the job if it won't exceed the concurrency limit(s).
 	   3. Once the ProwJob is updated, wait until we see it updated in our cache before completing
 	      processing and releasing the serialization lock(s) acquired in step 1. This is necessary
 	      to prevent reconciliation threads from processing subsequent jobs before the ProwJob index
 	      used in step 2 is up to date.
 	*/
-	maxConcurrencySerializationLocks *shardedLock
-	jobQueueSerializationLocks       *shardedLock
+	maxConcurrencySerializationLocks    *shardedLock
+	jobQueueSerializationLocks          *shardedLock
+	concurrencyBudgetSerializationLocks *shardedLock
+	// backlog is set if the jobs that exist on startup are processed in
+	// order, see Plank.StartupBacklog.
+	backlog *backlog
 }
 
 type shardedLock struct {
@@ -268,6 +298,7 @@ func (r *reconciler) syncMetrics(ctx context.Context) error {
 				continue
 			}
 			kube.GatherProwJobMetrics(r.log, pjs.Items)
+			gatherConcurrencyBudgetMetrics(r.config().Plank.ConcurrencyBudgetUsage(pjs.Items))
 		}
 	}
 }
@@ -347,6 +378,11 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	if r.overwriteReconcile != nil {
 		return r.overwriteReconcile(ctx, request)
 	}
+	if r.backlog != nil {
+		if res, held := r.backlog.hold(request.Name); held {
+			return res, nil
+		}
+	}
 	return r.defaultReconcile(ctx, request)
 }
 
@@ -411,6 +447,16 @@ func (r *reconciler) serializeIfNeeded(ctx context.Context, pj *prowv1.ProwJob)
 		}
 		defer lock.Unlock()
 	}
+
+	for _, budget := range r.config().Plank.ConcurrencyBudgetKeys(pj.Spec) {
+		// We need to serialize handling of jobs subject to this budget.
+		lock := r.concurrencyBudgetSerializationLocks.getLock(budget)
+		// Use TryAcquire to avoid blocking workers waiting for the lock
+		if !lock.TryLock() {
+			return &reconcile.Result{RequeueAfter: time.Second}, nil
+		}
+		defer lock.Unlock()
+	}
 	return r.reconcile(ctx, pj)
 }
 
@@ -450,6 +496,12 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 	if err != nil {
 		return nil, err
 	}
+	if podExists && kube.PodLeased(pod, r.leaseHolder, r.clock.Now()) {
+		// Another replica is updating the job, check on it again once the
+		// update reached our cache or the lease expired.
+		r.log.WithFields(pjutil.ProwJobFields(pj)).Debug("Pod is leased by another replica.")
+		return &reconcile.Result{RequeueAfter: podLeaseRetryInterval}, nil
+	}
 
 	if !podExists {
 		// Pod is missing. This can happen in case the previous pod was deleted manually or by
@@ -516,6 +568,9 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 
 		return nil, nil
 	} else {
+		if pod.Status.Phase != corev1.PodPending {
+			pj.Status.PodPendingReason = nil
+		}
 		switch pod.Status.Phase {
 		case corev1.PodUnknown:
 			// Pod is in Unknown state. This can happen if there is a problem with
@@ -558,6 +613,7 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 			pj.Status.Description = "Job failed."
 
 		case corev1.PodPending:
+			updatePodPendingReason(pj, diagnosePendingPod(pod), metav1.NewTime(r.clock.Now()))
 			var requeueAfter time.Duration
 			maxPodPending := r.config().Plank.PodPendingTimeout.Duration
 			if pj.Spec.DecorationConfig != nil && pj.Spec.DecorationConfig.PodPendingTimeout != nil {
@@ -573,7 +629,7 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 					// abort the job, and talk to GitHub
 					pj.SetComplete()
 					pj.Status.State = prowv1.ErrorState
-					pj.Status.Description = "Pod scheduling timeout."
+					pj.Status.Description = describePendingTimeout("Pod scheduling timeout", pj.Status.PodPendingReason)
 					r.log.WithFields(pjutil.ProwJobFields(pj)).Info("Marked job for stale unscheduled pod as errored.")
 					if err := r.deletePod(ctx, pj); err != nil {
 						return nil, fmt.Errorf("failed to delete pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
@@ -590,7 +646,7 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 					// abort the job, and talk to GitHub
 					pj.SetComplete()
 					pj.Status.State = prowv1.ErrorState
-					pj.Status.Description = "Pod pending timeout."
+					pj.Status.Description = describePendingTimeout("Pod pending timeout", pj.Status.PodPendingReason)
 					r.log.WithFields(pjutil.ProwJobFields(pj)).Info("Marked job for stale pending pod as errored.")
 					if err := r.deletePod(ctx, pj); err != nil {
 						return nil, fmt.Errorf("failed to delete pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
@@ -603,8 +659,11 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 				}
 			}
 			// Pod didn't start but didn't reach the scheduling or pending timeout yet,
-			// do nothing but check on it again once the timeout is reached.
+			// only record why and check on it again once the timeout is reached.
 			if pod.DeletionTimestamp == nil {
+				if err := r.patchPodPendingReason(ctx, prevPJ, pj); err != nil {
+					return nil, err
+				}
 				return &reconcile.Result{RequeueAfter: requeueAfter}, nil
 			}
 		case corev1.PodRunning:
@@ -615,9 +674,37 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 			if pj.Spec.DecorationConfig != nil && pj.Spec.DecorationConfig.PodRunningTimeout != nil {
 				maxPodRunning = pj.Spec.DecorationConfig.PodRunningTimeout.Duration
 			}
+			if pj.Spec.DecorationConfig != nil && pj.Spec.DecorationConfig.HangTimeout != nil {
+				hangTimeout := pj.Spec.DecorationConfig.HangTimeout.Duration
+				stalled, err := r.stalledFor(ctx, pj, hangTimeout)
+				if err != nil {
+					// Heartbeats are best effort, the job still times out eventually.
+					r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to read heartbeats.")
+				}
+				if stalled >= hangTimeout {
+					// The test process hangs, abort the job rather than waiting
+					// for its timeout.
+					pj.SetComplete()
+					pj.Status.State = prowv1.AbortedState
+					pj.Status.Description = fmt.Sprintf("Job hung: no output for %s.", stalled.Round(time.Second))
+					r.log.WithFields(pjutil.ProwJobFields(pj)).WithField("stalled", stalled).Info("Aborting hung job.")
+					if err := r.deletePod(ctx, pj); err != nil {
+						return nil, fmt.Errorf("failed to delete pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
+					}
+					break
+				}
+				if pod.Status.StartTime.IsZero() || time.Since(pod.Status.StartTime.Time) < maxPodRunning {
+					// Pod is still running, check on its heartbeats again once
+					// the next one is uploaded.
+					if err := r.patchPodPendingReason(ctx, prevPJ, pj); err != nil {
+						return nil, err
+					}
+					return &reconcile.Result{RequeueAfter: decorate.HeartbeatInterval(pj.Spec.DecorationConfig)}, nil
+				}
+			}
 			if pod.Status.StartTime.IsZero() || time.Since(pod.Status.StartTime.Time) < maxPodRunning {
-				// Pod is still running. Do nothing.
-				return nil, nil
+				// Pod is still running. Do nothing apart from clearing a stale pending reason.
+				return nil, r.patchPodPendingReason(ctx, prevPJ, pj)
 			}
 
 			// Pod is stuck in running state longer than maxPodRunning
@@ -653,11 +740,26 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 		r.log.WithFields(pjutil.ProwJobFields(pj)).
 			WithField("from", prevPJ.Status.State).
 			WithField("to", pj.Status.State).Info("Transitioning states.")
+		if pod != nil {
+			// Sinker and other replicas must not act on the pod until the
+			// transition reached their caches.
+			leased, err := r.leasePod(ctx, pj, pod)
+			if err != nil {
+				return nil, err
+			}
+			if !leased {
+				return &reconcile.Result{RequeueAfter: podLeaseRetryInterval}, nil
+			}
+			defer r.releasePodLease(ctx, pj, pod)
+		}
 	}
 
 	if err := r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
 		return nil, fmt.Errorf("patching prowjob: %w", err)
 	}
+	if !prevPJ.Complete() && pj.Complete() && pod != nil {
+		recordUtilityImagesResult(pj, pod)
+	}
 
 	// If the ProwJob state has changed, we must ensure that the update reaches the cache before
 	// processing the key again. Without this we might accidentally replace intentionally deleted pods
@@ -679,6 +781,85 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 	return nil, nil
 }
 
+const (
+	// podLeaseDuration is how long the lease on a pod is held at most while
+	// its ProwJob is updated. It covers patching the ProwJob and waiting for
+	// the update to reach the cache.
+	podLeaseDuration = time.Minute
+	// podLeaseRetryInterval is how long to wait before syncing a job again
+	// whose pod is leased by someone else.
+	podLeaseRetryInterval = 5 * time.Second
+)
+
+// leaseHolder returns the identity of this replica in the leases on pods.
+func leaseHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "plank"
+	}
+	return fmt.Sprintf("%s-%d", hostname, rand.Int63())
+}
+
+// leasePod acquires a lease on the pod of the job and returns whether it did.
+// Leases are only acquired if the pod didn't change since it was read, so
+// only one replica holds it and sinker's deletion of the pod fails if it
+// listed the pod before it was leased.
+func (r *reconciler) leasePod(ctx context.Context, pj *prowv1.ProwJob, pod *corev1.Pod) (bool, error) {
+	client, ok := r.buildClients[pj.ClusterAlias()]
+	if !ok {
+		return false, TerminalError(fmt.Errorf("no build client found for cluster %q", pj.ClusterAlias()))
+	}
+	original := pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[kube.PodLeaseAnnotation] = kube.PodLease{Holder: r.leaseHolder, Expires: r.clock.Now().Add(podLeaseDuration)}.String()
+	err := client.Patch(ctx, pod, ctrlruntimeclient.MergeFromWithOptions(original, ctrlruntimeclient.MergeFromWithOptimisticLock{}))
+	switch {
+	case err == nil:
+		return true, nil
+	case kerrors.IsNotFound(err):
+		// The pod was deleted, by this replica or by someone else, so it
+		// needs no protection anymore.
+		*pod = *original
+		return true, nil
+	case kerrors.IsConflict(err):
+		r.log.WithFields(pjutil.ProwJobFields(pj)).Debug("Pod changed while leasing it.")
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to lease pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
+	}
+}
+
+// releasePodLease releases the lease on the pod of the job, if it still holds
+// it. Failing to do so is not an issue, as the lease expires.
+func (r *reconciler) releasePodLease(ctx context.Context, pj *prowv1.ProwJob, pod *corev1.Pod) {
+	if lease, ok := kube.PodLeaseOf(pod); !ok || lease.Holder != r.leaseHolder {
+		return
+	}
+	client, ok := r.buildClients[pj.ClusterAlias()]
+	if !ok {
+		return
+	}
+	original := pod.DeepCopy()
+	delete(pod.Annotations, kube.PodLeaseAnnotation)
+	if err := client.Patch(ctx, pod, ctrlruntimeclient.MergeFromWithOptions(original, ctrlruntimeclient.MergeFromWithOptimisticLock{})); ctrlruntimeclient.IgnoreNotFound(err) != nil {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Debug("Failed to release the lease on the pod.")
+	}
+}
+
+// patchPodPendingReason persists a changed pod pending reason on code paths that
+// otherwise leave the ProwJob untouched.
+func (r *reconciler) patchPodPendingReason(ctx context.Context, prevPJ, pj *prowv1.ProwJob) error {
+	if equality.Semantic.DeepEqual(prevPJ.Status.PodPendingReason, pj.Status.PodPendingReason) {
+		return nil
+	}
+	if err := r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
+		return fmt.Errorf("patching prowjob: %w", err)
+	}
+	return nil
+}
+
 // syncTriggeredJob syncs jobs that do not yet have an associated test workload running
 func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (*reconcile.Result, error) {
 	prevPJ := pj.DeepCopy()
@@ -698,11 +879,14 @@ func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (
 		pn = pod.ObjectMeta.Name
 	} else {
 		// Do not start more jobs than specified and check again later.
-		canExecuteConcurrently, err := r.canExecuteConcurrently(ctx, pj)
+		throttle, err := r.throttle(ctx, pj)
 		if err != nil {
 			return nil, fmt.Errorf("canExecuteConcurrently: %w", err)
 		}
-		if !canExecuteConcurrently {
+		if throttle != nil {
+			if err := r.updateQueueStatus(ctx, pj, throttle); err != nil {
+				r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to update the queue status.")
+			}
 			return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
 		}
 		// We haven't started the pod yet. Do so.
@@ -725,6 +909,7 @@ func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (
 		pj.Status.PendingTime = &now
 		pj.Status.State = prowv1.PendingState
 		pj.Status.PodName = pn
+		pj.Status.Queue = nil
 		pj.Status.Description = "Job triggered."
 		pj.Status.URL, err = pjutil.JobURL(r.config().Plank, *pj, r.log)
 		if err != nil {
@@ -741,10 +926,10 @@ func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (
 		return nil, fmt.Errorf("patch prowjob: %w", err)
 	}
 
-	// If the job has either MaxConcurrency or JobQueueName configured, we must block here until we observe the state transition in our cache,
-	// otherwise subequent reconciliations for a different run of the same job might incorrectly conclude that they
-	// can run because that decision is made based on the data in the cache.
-	if pj.Spec.MaxConcurrency == 0 && pj.Spec.JobQueueName == "" {
+	// If the job has either MaxConcurrency or JobQueueName configured or is subject to a concurrency budget, we must block
+	// here until we observe the state transition in our cache, otherwise subequent reconciliations for a different run of
+	// the same job might incorrectly conclude that they can run because that decision is made based on the data in the cache.
+	if pj.Spec.MaxConcurrency == 0 && pj.Spec.JobQueueName == "" && len(r.config().Plank.ConcurrencyBudgetKeys(pj.Spec)) == 0 {
 		return nil, nil
 	}
 	nn := types.NamespacedName{Namespace: pj.Namespace, Name: pj.Name}
@@ -770,11 +955,25 @@ func (r *reconciler) syncAbortedJob(ctx context.Context, pj *prowv1.ProwJob) err
 		return TerminalError(fmt.Errorf("no build client available for cluster %s", pj.ClusterAlias()))
 	}
 
-	// Just optimistically delete and swallow the potential 404
 	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
 		Name:      pj.Name,
 		Namespace: r.config().PodNamespace,
 	}}
+	if abortedBy := pj.Annotations[prowv1.AbortedByAnnotation]; abortedBy != "" {
+		// The annotation is projected into the sidecar container, which records
+		// it in the finished.json of the job once the test process stopped.
+		patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{
+			"annotations": map[string]string{prowv1.AbortedByAnnotation: abortedBy},
+		}})
+		if err != nil {
+			return fmt.Errorf("failed to marshal the patch of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if err := ctrlruntimeclient.IgnoreNotFound(buildClient.Patch(ctx, pod, ctrlruntimeclient.RawPatch(types.MergePatchType, patch))); err != nil {
+			return fmt.Errorf("failed to annotate pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
+		}
+	}
+
+	// Just optimistically delete and swallow the potential 404
 	if err := ctrlruntimeclient.IgnoreNotFound(buildClient.Delete(ctx, pod)); err != nil {
 		return fmt.Errorf("failed to delete pod %s/%s in cluster %s: %w", pod.Namespace, pod.Name, pj.ClusterAlias(), err)
 	}
@@ -835,10 +1034,17 @@ func (r *reconciler) startPod(ctx context.Context, pj *prowv1.ProwJob) (string,
 	}
 
 	pj.Status.BuildID = buildID
-	pod, err := decorate.ProwJobToPod(*pj)
+	podPJ, utilityImages := r.withUtilityImages(pj)
+	podPJ = r.withArchitectureImages(ctx, podPJ)
+	podPJ = r.withQuarantine(ctx, podPJ)
+	pod, err := decorate.ProwJobToPod(*podPJ)
 	if err != nil {
 		return "", "", err
 	}
+	if utilityImages != "" {
+		pod.Labels[kube.UtilityImagesLabel] = utilityImages
+	}
+	pod.Labels, pod.Annotations = r.config().ProwJobMetadataPropagation.PodLabelsAndAnnotations(pod.Labels, pod.Annotations)
 	pod.Namespace = r.config().PodNamespace
 	// Add prow version as a label for better debugging prowjobs.
 	pod.ObjectMeta.Labels[kube.PlankVersionLabel] = version.Version
@@ -880,33 +1086,44 @@ func (r *reconciler) getBuildID(name string) (string, error) {
 // first. This allows us to get away without any global locking by just looking
 // at the jobs in the cluster.
 func (r *reconciler) canExecuteConcurrently(ctx context.Context, pj *prowv1.ProwJob) (bool, error) {
+	throttle, err := r.throttle(ctx, pj)
+	return throttle == nil, err
+}
+
+// throttle returns the limit that keeps the job from starting, or nil if it
+// can start.
+func (r *reconciler) throttle(ctx context.Context, pj *prowv1.ProwJob) (*throttle, error) {
 	if max := r.config().Plank.MaxConcurrency; max > 0 {
 		pjs := &prowv1.ProwJobList{}
 		if err := r.pjClient.List(ctx, pjs, optPendingProwJobs()); err != nil {
-			return false, fmt.Errorf("failed to list prowjobs: %w", err)
+			return nil, fmt.Errorf("failed to list prowjobs: %w", err)
 		}
 
 		if running := len(pjs.Items); running >= max {
 			r.log.WithFields(pjutil.ProwJobFields(pj)).Infof("Not starting another job, already %d running.", running)
-			return false, nil
+			return &throttle{limit: "plank max_concurrency", capacity: max}, nil
 		}
 	}
 
-	if canExecute, err := r.canExecuteConcurrentlyPerJob(ctx, pj); err != nil || !canExecute {
-		return canExecute, err
+	if throttle, err := r.throttlePerJob(ctx, pj); err != nil || throttle != nil {
+		return throttle, err
+	}
+
+	if throttle, err := r.throttlePerQueue(ctx, pj); err != nil || throttle != nil {
+		return throttle, err
 	}
 
-	return r.canExecuteConcurrentlyPerQueue(ctx, pj)
+	return r.throttlePerBudget(ctx, pj)
 }
 
-func (r *reconciler) canExecuteConcurrentlyPerJob(ctx context.Context, pj *prowv1.ProwJob) (bool, error) {
+func (r *reconciler) throttlePerJob(ctx context.Context, pj *prowv1.ProwJob) (*throttle, error) {
 	if pj.Spec.MaxConcurrency == 0 {
-		return true, nil
+		return nil, nil
 	}
 
 	pjs := &prowv1.ProwJobList{}
 	if err := r.pjClient.List(ctx, pjs, optPendingTriggeredJobsNamed(pj.Spec.Job)); err != nil {
-		return false, fmt.Errorf("failed listing prowjobs: %w:", err)
+		return nil, fmt.Errorf("failed listing prowjobs: %w:", err)
 	}
 	r.log.Infof("got %d not completed with same name", len(pjs.Items))
 
@@ -915,32 +1132,34 @@ func (r *reconciler) canExecuteConcurrentlyPerJob(ctx context.Context, pj *prowv
 		r.log.WithFields(pjutil.ProwJobFields(pj)).
 			Debugf("Not starting another instance of %s, have %d instances that are pending or older, %d is the limit",
 				pj.Spec.Job, pendingOrOlderMatchingPJs, pj.Spec.MaxConcurrency)
-		return false, nil
+		return &throttle{
+			limit:    fmt.Sprintf("max_concurrency of %s", pj.Spec.Job),
+			capacity: pj.Spec.MaxConcurrency,
+			jobs:     pjs.Items,
+			matches:  func(other prowv1.ProwJob) bool { return other.Spec.Job == pj.Spec.Job },
+		}, nil
 	}
 
-	return true, nil
+	return nil, nil
 }
 
-func (r *reconciler) canExecuteConcurrentlyPerQueue(ctx context.Context, pj *prowv1.ProwJob) (bool, error) {
+func (r *reconciler) throttlePerQueue(ctx context.Context, pj *prowv1.ProwJob) (*throttle, error) {
 	queueName := pj.Spec.JobQueueName
 	if queueName == "" {
-		return true, nil
+		return nil, nil
 	}
 
 	queueConcurrency, queueDefined := r.config().Plank.JobQueueCapacities[queueName]
 	if !queueDefined {
-		return false, fmt.Errorf("failed to match queue name '%s' with Plank configuration", queueName)
-	}
-	if queueConcurrency == 0 {
-		return false, nil
+		return nil, fmt.Errorf("failed to match queue name '%s' with Plank configuration", queueName)
 	}
 	if queueConcurrency < 0 {
-		return true, nil
+		return nil, nil
 	}
 
 	pjs := &prowv1.ProwJobList{}
 	if err := r.pjClient.List(ctx, pjs, optPendingTriggeredJobsInQueue(queueName)); err != nil {
-		return false, fmt.Errorf("failed listing prowjobs in queue %s: %w", queueName, err)
+		return nil, fmt.Errorf("failed listing prowjobs in queue %s: %w", queueName, err)
 	}
 	r.log.Infof("got %d not completed within queue %s", len(pjs.Items), queueName)
 
@@ -949,10 +1168,47 @@ func (r *reconciler) canExecuteConcurrentlyPerQueue(ctx context.Context, pj *pro
 		r.log.WithFields(pjutil.ProwJobFields(pj)).
 			Debugf("Not starting another instance of %s, have %d instances in queue %s that are pending or older, %d is the limit",
 				pj.Spec.Job, pendingOrOlderMatchingPJs, queueName, queueConcurrency)
-		return false, nil
+		return &throttle{
+			limit:    fmt.Sprintf("job queue %s", queueName),
+			capacity: queueConcurrency,
+			jobs:     pjs.Items,
+			matches:  func(other prowv1.ProwJob) bool { return other.Spec.JobQueueName == queueName },
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// throttlePerBudget checks the concurrency budgets of the org and repo of
+// the job.
+func (r *reconciler) throttlePerBudget(ctx context.Context, pj *prowv1.ProwJob) (*throttle, error) {
+	plank := r.config().Plank
+	for _, budget := range plank.ConcurrencyBudgetKeys(pj.Spec) {
+		limit := plank.ConcurrencyBudgets[budget]
+
+		pjs := &prowv1.ProwJobList{}
+		if err := r.pjClient.List(ctx, pjs, optPendingTriggeredJobsInConcurrencyBudget(budget)); err != nil {
+			return nil, fmt.Errorf("failed listing prowjobs of concurrency budget %s: %w", budget, err)
+		}
+
+		pendingOrOlderMatchingPJs := countPendingOrOlderTriggeredMatchingPJs(*pj, pjs.Items)
+		if pendingOrOlderMatchingPJs >= limit {
+			r.log.WithFields(pjutil.ProwJobFields(pj)).
+				Debugf("Not starting another instance of %s, have %d instances of %s that are pending or older, %d is the budget",
+					pj.Spec.Job, pendingOrOlderMatchingPJs, budget, limit)
+			budget := budget
+			return &throttle{
+				limit:    fmt.Sprintf("concurrency budget of %s", budget),
+				capacity: limit,
+				jobs:     pjs.Items,
+				matches: func(other prowv1.ProwJob) bool {
+					return sets.New(plank.ConcurrencyBudgetKeys(other.Spec)...).Has(budget)
+				},
+			}, nil
+		}
 	}
 
-	return true, nil
+	return nil, nil
 }
 
 func prowJobPredicate(callback func(bool)) predicate.Predicate {
@@ -1027,6 +1283,10 @@ func pendingTriggeredIndexKeyByJobQueueName(jobQueueName string) string {
 	return fmt.Sprintf("pending-triggered-with-job-queue-name-%s", jobQueueName)
 }
 
+func pendingTriggeredIndexKeyByConcurrencyBudget(budget string) string {
+	return fmt.Sprintf("pending-triggered-in-concurrency-budget-%s", budget)
+}
+
 func prowJobIndexer(prowJobNamespace string) ctrlruntimeclient.IndexerFunc {
 	return func(o ctrlruntimeclient.Object) []string {
 		pj := o.(*prowv1.ProwJob)
@@ -1046,6 +1306,18 @@ func prowJobIndexer(prowJobNamespace string) ctrlruntimeclient.IndexerFunc {
 			if pj.Spec.JobQueueName != "" {
 				indexes = append(indexes, pendingTriggeredIndexKeyByJobQueueName(pj.Spec.JobQueueName))
 			}
+
+			// Concurrency budgets can change with the config, so every job is
+			// indexed by both the org and the repo it could have a budget for.
+			refs := pj.Spec.Refs
+			if refs == nil && len(pj.Spec.ExtraRefs) > 0 {
+				refs = &pj.Spec.ExtraRefs[0]
+			}
+			if refs != nil {
+				indexes = append(indexes,
+					pendingTriggeredIndexKeyByConcurrencyBudget(refs.Org),
+					pendingTriggeredIndexKeyByConcurrencyBudget(refs.Org+"/"+refs.Repo))
+			}
 		}
 
 		return indexes
@@ -1068,6 +1340,10 @@ func optPendingTriggeredJobsInQueue(queueName string) ctrlruntimeclient.ListOpti
 	return ctrlruntimeclient.MatchingFields{prowJobIndexName: pendingTriggeredIndexKeyByJobQueueName(queueName)}
 }
 
+func optPendingTriggeredJobsInConcurrencyBudget(budget string) ctrlruntimeclient.ListOption {
+	return ctrlruntimeclient.MatchingFields{prowJobIndexName: pendingTriggeredIndexKeyByConcurrencyBudget(budget)}
+}
+
 func didPodSucceed(p *corev1.Pod) bool {
 	if p.Status.Phase != corev1.PodSucceeded {
 		return false
@@ -361,6 +361,18 @@ func TestProwJobIndexer(t *testing.T) {
 				pendingTriggeredIndexKeyByJobQueueName(pjJobQueue),
 			},
 		},
+		{
+			name:   "Refs add pendingTriggeredIndexKeyByConcurrencyBudget indexes for org and repo",
+			modify: func(pj *prowv1.ProwJob) { pj.Spec.Refs = &prowv1.Refs{Org: "org", Repo: "repo"} },
+			expected: []string{
+				prowJobIndexKeyAll,
+				prowJobIndexKeyPending,
+				pendingTriggeredIndexKeyByName(pjName),
+				pendingTriggeredIndexKeyByJobQueueName(pjJobQueue),
+				pendingTriggeredIndexKeyByConcurrencyBudget("org"),
+				pendingTriggeredIndexKeyByConcurrencyBudget("org/repo"),
+			},
+		},
 		{
 			name:   "Changing job queue name changes pendingTriggeredIndexKeyByJobQueueName index",
 			modify: func(pj *prowv1.ProwJob) { pj.Spec.JobQueueName = "some-name" },
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
+)
+
+const (
+	canaryUtilityImages = "canary"
+	stableUtilityImages = "stable"
+)
+
+// podWaitingFailures are the reasons of waiting containers that point at
+// their image rather than at the job.
+var podWaitingFailures = sets.New[string]("ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError")
+
+// withUtilityImages returns a copy of the job using the utility images
+// configured for its build cluster, and the value of the
+// kube.UtilityImagesLabel of its pod, which is empty if the job isn't
+// subject to the canary.
+func (r *reconciler) withUtilityImages(pj *prowv1.ProwJob) (*prowv1.ProwJob, string) {
+	versions := r.config().Plank.UtilityImageVersions
+	if versions == nil || pj.Spec.DecorationConfig == nil {
+		return pj, ""
+	}
+	var canary bool
+	var label string
+	if versions.CanaryApplies(pj.ClusterAlias()) {
+		canary = r.percentile() < versions.Canary.Percentage
+		label = stableUtilityImages
+		if canary {
+			label = canaryUtilityImages
+		}
+	}
+	images := versions.Apply(pj.Spec.DecorationConfig.UtilityImages, pj.ClusterAlias(), canary)
+	if images == pj.Spec.DecorationConfig.UtilityImages {
+		return pj, label
+	}
+	pj = pj.DeepCopy()
+	pj.Spec.DecorationConfig.UtilityImages = images
+	return pj, label
+}
+
+// recordUtilityImagesResult counts the result of a completed job whose pod
+// is subject to the canary of utility images.
+func recordUtilityImagesResult(pj *prowv1.ProwJob, pod *corev1.Pod) {
+	images := pod.Labels[kube.UtilityImagesLabel]
+	if images == "" {
+		return
+	}
+	utilityImagesJobs.WithLabelValues(pj.ClusterAlias(), images, string(pj.Status.State)).Inc()
+	if container := failedUtilityContainer(pod); container != "" {
+		utilityImagesFailures.WithLabelValues(pj.ClusterAlias(), images, container).Inc()
+	}
+}
+
+// failedUtilityContainer returns the name of the utility container that made
+// the pod fail, if any. As the sidecar also fails when the test fails, its
+// failures only count when all test containers succeeded.
+func failedUtilityContainer(pod *corev1.Pod) string {
+	utilityContainers := decorate.PodUtilsContainerNames()
+	failed := func(status corev1.ContainerStatus) bool {
+		if waiting := status.State.Waiting; waiting != nil {
+			return podWaitingFailures.Has(waiting.Reason)
+		}
+		return status.State.Terminated != nil && status.State.Terminated.ExitCode != 0
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if utilityContainers.Has(status.Name) && failed(status) {
+			return status.Name
+		}
+	}
+	var failedSidecar string
+	for _, status := range pod.Status.ContainerStatuses {
+		if !failed(status) {
+			continue
+		}
+		if !utilityContainers.Has(status.Name) {
+			return ""
+		}
+		failedSidecar = status.Name
+	}
+	return failedSidecar
+}
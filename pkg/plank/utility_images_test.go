@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestWithUtilityImages(t *testing.T) {
+	stable := &prowv1.UtilityImages{
+		CloneRefs:  "gcr.io/k8s-prow/clonerefs:v1",
+		InitUpload: "gcr.io/k8s-prow/initupload:v1",
+		Entrypoint: "gcr.io/k8s-prow/entrypoint:v1",
+		Sidecar:    "gcr.io/k8s-prow/sidecar:v1",
+	}
+	tagged := func(tag string) *prowv1.UtilityImages {
+		return &prowv1.UtilityImages{
+			CloneRefs:  "gcr.io/k8s-prow/clonerefs:" + tag,
+			InitUpload: "gcr.io/k8s-prow/initupload:" + tag,
+			Entrypoint: "gcr.io/k8s-prow/entrypoint:" + tag,
+			Sidecar:    "gcr.io/k8s-prow/sidecar:" + tag,
+		}
+	}
+	versions := &config.UtilityImageVersions{
+		Clusters: map[string]string{"legacy": "v0"},
+		Canary:   &config.UtilityImagesCanary{Tag: "v2", Percentage: 10, Clusters: []string{"default", "legacy"}},
+	}
+
+	testCases := []struct {
+		name           string
+		versions       *config.UtilityImageVersions
+		cluster        string
+		undecorated    bool
+		percentile     int
+		expectedImages *prowv1.UtilityImages
+		expectedLabel  string
+	}{
+		{
+			name:           "no versions configured",
+			cluster:        "default",
+			expectedImages: stable,
+		},
+		{
+			name:           "pinned cluster without canary",
+			versions:       &config.UtilityImageVersions{Clusters: map[string]string{"legacy": "v0"}},
+			cluster:        "legacy",
+			expectedImages: tagged("v0"),
+		},
+		{
+			name:           "job picked for the canary",
+			versions:       versions,
+			cluster:        "default",
+			percentile:     9,
+			expectedImages: tagged("v2"),
+			expectedLabel:  "canary",
+		},
+		{
+			name:           "canary overrides the pinned tag",
+			versions:       versions,
+			cluster:        "legacy",
+			percentile:     0,
+			expectedImages: tagged("v2"),
+			expectedLabel:  "canary",
+		},
+		{
+			name:           "job not picked for the canary",
+			versions:       versions,
+			cluster:        "legacy",
+			percentile:     10,
+			expectedImages: tagged("v0"),
+			expectedLabel:  "stable",
+		},
+		{
+			name:           "cluster outside of the canary",
+			versions:       versions,
+			cluster:        "gpu",
+			percentile:     0,
+			expectedImages: stable,
+		},
+		{
+			name:        "undecorated job",
+			versions:    versions,
+			cluster:     "default",
+			undecorated: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &reconciler{
+				config: func() *config.Config {
+					return &config.Config{ProwConfig: config.ProwConfig{Plank: config.Plank{UtilityImageVersions: tc.versions}}}
+				},
+				percentile: func() int { return tc.percentile },
+			}
+			pj := &prowv1.ProwJob{Spec: prowv1.ProwJobSpec{Cluster: tc.cluster}}
+			if !tc.undecorated {
+				pj.Spec.DecorationConfig = &prowv1.DecorationConfig{UtilityImages: stable.DeepCopy()}
+			}
+			original := pj.DeepCopy()
+
+			podPJ, label := r.withUtilityImages(pj)
+			if label != tc.expectedLabel {
+				t.Errorf("expected label %q, got %q", tc.expectedLabel, label)
+			}
+			var images *prowv1.UtilityImages
+			if podPJ.Spec.DecorationConfig != nil {
+				images = podPJ.Spec.DecorationConfig.UtilityImages
+			}
+			if diff := cmp.Diff(tc.expectedImages, images); diff != "" {
+				t.Errorf("unexpected utility images (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(original, pj); diff != "" {
+				t.Errorf("the prowjob was modified (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFailedUtilityContainer(t *testing.T) {
+	succeeded := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	failed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}
+	pullFailed := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}
+
+	testCases := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected string
+	}{
+		{
+			name: "succeeded",
+			status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "clonerefs", State: succeeded}},
+				ContainerStatuses:     []corev1.ContainerStatus{{Name: "test", State: succeeded}, {Name: "sidecar", State: succeeded}},
+			},
+		},
+		{
+			name: "clonerefs failed",
+			status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "initupload", State: succeeded}, {Name: "clonerefs", State: failed}},
+			},
+			expected: "clonerefs",
+		},
+		{
+			name: "image of init container can't be pulled",
+			status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "place-entrypoint", State: pullFailed}},
+			},
+			expected: "place-entrypoint",
+		},
+		{
+			name: "sidecar failed because the test failed",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "sidecar", State: failed}, {Name: "test", State: failed}},
+			},
+		},
+		{
+			name: "sidecar failed on its own",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "test", State: succeeded}, {Name: "sidecar", State: failed}},
+			},
+			expected: "sidecar",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := failedUtilityContainer(&corev1.Pod{Status: tc.status}); actual != tc.expected {
+				t.Errorf("expected failed container %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
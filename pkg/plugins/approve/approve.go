@@ -19,6 +19,7 @@ package approve
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
@@ -42,6 +43,7 @@ const (
 
 	approveCommand       = "APPROVE"
 	cancelArgument       = "cancel"
+	filesArgument        = "files"
 	lgtmCommand          = "LGTM"
 	noIssueArgument      = "no-issue"
 	removeApproveCommand = "REMOVE-APPROVE"
@@ -138,11 +140,11 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		Snippet: yamlSnippet,
 	}
 	pluginHelp.AddCommand(pluginhelp.Command{
-		Usage:       "/[remove-]approve [no-issue|cancel]",
-		Description: "Approves a pull request",
+		Usage:       "/[remove-]approve [no-issue|cancel|files <path>...]",
+		Description: "Approves a pull request. If partial approval is enabled, 'files' approves only the given files and directories.",
 		Featured:    true,
 		WhoCanUse:   "Users listed as 'approvers' in appropriate OWNERS files.",
-		Examples:    []string{"/approve", "/approve no-issue", "/remove-approve"},
+		Examples:    []string{"/approve", "/approve no-issue", "/approve files pkg/foo docs/README.md", "/remove-approve"},
 	})
 	return pluginHelp, nil
 }
@@ -434,6 +436,7 @@ func handle(log *logrus.Entry, ghc githubClient, repo approvers.Repo, githubConf
 		log.WithError(err).Errorf("Failed to find associated issue from PR body: %v", err)
 	}
 	approversHandler.RequireIssue = opts.IssueRequired
+	approversHandler.PartialApproval = opts.PartialApproval
 	approversHandler.ManuallyApproved = humanAddedApproved(ghc, log, pr.org, pr.repo, pr.number, hasApprovedLabel)
 
 	// Author implicitly approves their own PR if config allows it
@@ -453,7 +456,7 @@ func handle(log *logrus.Entry, ghc githubClient, repo approvers.Repo, githubConf
 		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
 	})
 	approveComments := filterComments(comments, approvalMatcher(botUserChecker, opts.LgtmActsAsApprove, opts.ConsiderReviewState()))
-	addApprovers(&approversHandler, approveComments, pr.author, opts.ConsiderReviewState())
+	addApprovers(&approversHandler, approveComments, pr.author, opts.ConsiderReviewState(), opts.PartialApproval)
 	log.WithField("duration", time.Since(start).String()).Debug("Completed filtering approval comments in handle")
 
 	for _, user := range pr.assignees {
@@ -583,8 +586,9 @@ func updateNotification(linkURL *url.URL, commandHelpLink, prProcessLink, org, r
 // and identifies all of the people that have said /approve and adds
 // them to the Approvers.  The function uses the latest approve or cancel comment
 // to determine the Users intention. A review in requested changes state is
-// considered a cancel.
-func addApprovers(approversHandler *approvers.Approvers, approveComments []*comment, author string, reviewActsAsApprove bool) {
+// considered a cancel. If partialApproval is set, "/approve files <path>..."
+// approves only the given files and directories.
+func addApprovers(approversHandler *approvers.Approvers, approveComments []*comment, author string, reviewActsAsApprove, partialApproval bool) {
 	for _, c := range approveComments {
 		if c.Author == "" {
 			continue
@@ -610,6 +614,14 @@ func addApprovers(approversHandler *approvers.Approvers, approveComments []*comm
 			if name != approveCommand && name != lgtmCommand {
 				continue
 			}
+			if name == approveCommand && partialApproval {
+				if paths, ok := parseApprovedPaths(match[2]); ok {
+					if len(paths) > 0 {
+						approversHandler.AddPartialApprover(c.Author, c.HTMLURL, paths)
+					}
+					continue
+				}
+			}
 			args := strings.ToLower(strings.TrimSpace(match[2]))
 			if strings.Contains(args, cancelArgument) {
 				approversHandler.RemoveApprover(c.Author)
@@ -642,6 +654,25 @@ func addApprovers(approversHandler *approvers.Approvers, approveComments []*comm
 	}
 }
 
+// parseApprovedPaths returns the files and directories of a
+// "/approve files <path>..." command, relative to the root of the repo.
+// Paths outside of the repo are ignored.
+func parseApprovedPaths(args string) ([]string, bool) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], filesArgument) {
+		return nil, false
+	}
+	var paths []string
+	for _, field := range fields[1:] {
+		cleaned := path.Clean(strings.Trim(field, "/"))
+		if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			continue
+		}
+		paths = append(paths, cleaned)
+	}
+	return paths, true
+}
+
 type comment struct {
 	Body        string
 	Author      string
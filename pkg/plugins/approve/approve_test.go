@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -1946,6 +1947,82 @@ func TestHandlePullRequest(t *testing.T) {
 	}
 }
 
+func TestAddApproversPartialApproval(t *testing.T) {
+	tests := []struct {
+		name            string
+		partialApproval bool
+		comments        []*comment
+		expected        []approvers.Approval
+	}{
+		{
+			name:            "files approves the given paths",
+			partialApproval: true,
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve files pkg/foo/ ./docs/README.md", HTMLURL: "url1"},
+			},
+			expected: []approvers.Approval{
+				{Login: "Alice", How: "Approved files", Reference: "url1", Paths: []string{"docs/README.md", "pkg/foo"}},
+			},
+		},
+		{
+			name:            "later partial approvals extend the paths",
+			partialApproval: true,
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve files pkg/foo", HTMLURL: "url1"},
+				{Author: "alice", Body: "/approve FILES pkg/bar", HTMLURL: "url2"},
+			},
+			expected: []approvers.Approval{
+				{Login: "alice", How: "Approved files", Reference: "url2", Paths: []string{"pkg/bar", "pkg/foo"}},
+			},
+		},
+		{
+			name:            "partial approval doesn't narrow a full approval",
+			partialApproval: true,
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve", HTMLURL: "url1"},
+				{Author: "Alice", Body: "/approve files pkg/foo", HTMLURL: "url2"},
+			},
+			expected: []approvers.Approval{
+				{Login: "Alice", How: "Approved", Reference: "url1"},
+			},
+		},
+		{
+			name:            "paths outside of the repo are ignored",
+			partialApproval: true,
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve files ../cancel /", HTMLURL: "url1"},
+			},
+		},
+		{
+			name:            "cancel removes a partial approval",
+			partialApproval: true,
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve files pkg/foo", HTMLURL: "url1"},
+				{Author: "Alice", Body: "/approve cancel", HTMLURL: "url2"},
+			},
+		},
+		{
+			name: "files is a full approval if partial approval is disabled",
+			comments: []*comment{
+				{Author: "Alice", Body: "/approve files pkg/foo", HTMLURL: "url1"},
+			},
+			expected: []approvers.Approval{
+				{Login: "Alice", How: "Approved", Reference: "url1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ap := approvers.NewApprovers(approvers.NewOwners(logrus.WithField("plugin", "approve"), nil, fakeRepo{}, 0))
+			addApprovers(&ap, test.comments, "author", false, test.partialApproval)
+			if diff := cmp.Diff(test.expected, ap.ListApprovals(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected approvals (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestHelpProvider(t *testing.T) {
 	enabledRepos := []config.OrgRepo{
 		{Org: "org1", Repo: "repo"},
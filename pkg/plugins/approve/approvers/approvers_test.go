@@ -120,6 +120,74 @@ func TestUnapprovedFiles(t *testing.T) {
 	}
 }
 
+func TestUnapprovedFilesPartialApproval(t *testing.T) {
+	FakeRepoMap := map[string]sets.Set[string]{
+		"":  sets.New[string]("Alice"),
+		"a": sets.New[string]("Art"),
+		"b": sets.New[string]("Bill"),
+	}
+	tests := []struct {
+		testName           string
+		filenames          []string
+		partialApprovals   map[string][]string
+		fullApprovers      []string
+		expectedUnapproved sets.Set[string]
+	}{
+		{
+			testName:           "Root approver approves a directory",
+			filenames:          []string{"a/test.go", "b/test.go"},
+			partialApprovals:   map[string][]string{"Alice": {"a"}},
+			expectedUnapproved: sets.New[string]("b"),
+		},
+		{
+			testName:           "Root approver approves every directory",
+			filenames:          []string{"a/test.go", "b/test.go"},
+			partialApprovals:   map[string][]string{"Alice": {"a", "b/test.go"}},
+			expectedUnapproved: sets.New[string](),
+		},
+		{
+			testName:           "Approval of a directory doesn't cover directories with the same prefix",
+			filenames:          []string{"a/test.go", "abc/test.go"},
+			partialApprovals:   map[string][]string{"Alice": {"a"}},
+			expectedUnapproved: sets.New[string](""),
+		},
+		{
+			testName:           "Partial approver doesn't own the approved directory",
+			filenames:          []string{"a/test.go"},
+			partialApprovals:   map[string][]string{"Bill": {"a"}},
+			expectedUnapproved: sets.New[string]("a"),
+		},
+		{
+			testName:           "Partial and full approvers",
+			filenames:          []string{"a/test.go", "b/test.go"},
+			partialApprovals:   map[string][]string{"Alice": {"a"}},
+			fullApprovers:      []string{"Bill"},
+			expectedUnapproved: sets.New[string](),
+		},
+		{
+			testName:           "Full approval isn't limited by a later partial approval",
+			filenames:          []string{"a/test.go", "b/test.go"},
+			partialApprovals:   map[string][]string{"alice": {"a"}},
+			fullApprovers:      []string{"Alice"},
+			expectedUnapproved: sets.New[string](),
+		},
+	}
+
+	for _, test := range tests {
+		testApprovers := NewApprovers(Owners{filenames: test.filenames, repo: createFakeRepo(FakeRepoMap), seed: TestSeed, log: logrus.WithField("plugin", "some_plugin")})
+		for _, approver := range test.fullApprovers {
+			testApprovers.AddApprover(approver, "REFERENCE", false)
+		}
+		for approver, paths := range test.partialApprovals {
+			testApprovers.AddPartialApprover(approver, "REFERENCE", paths)
+		}
+		calculated := testApprovers.UnapprovedFiles()
+		if !test.expectedUnapproved.Equal(calculated) {
+			t.Errorf("Failed for test %v.  Expected unapproved files: %v. Found %v", test.testName, test.expectedUnapproved, calculated)
+		}
+	}
+}
+
 func TestGetFiles(t *testing.T) {
 	rootApprovers := sets.New[string]("Alice", "Bob")
 	aApprovers := sets.New[string]("Art", "Anne")
@@ -174,13 +174,17 @@ func (o Owners) temporaryUnapprovedFiles(approvers sets.Set[string]) sets.Set[st
 // KeepCoveringApprovers finds who we should keep as suggested approvers given a pre-selection
 // knownApprovers must be a subset of potentialApprovers.
 func (o Owners) KeepCoveringApprovers(reverseMap map[string]sets.Set[string], knownApprovers sets.Set[string], potentialApprovers []string) sets.Set[string] {
+	return o.keepCoveringApprovers(reverseMap, o.temporaryUnapprovedFiles(knownApprovers), potentialApprovers)
+}
+
+// keepCoveringApprovers keeps the suggested approvers that can approve any
+// of the unapproved files.
+func (o Owners) keepCoveringApprovers(reverseMap map[string]sets.Set[string], unapproved sets.Set[string], potentialApprovers []string) sets.Set[string] {
 	if len(potentialApprovers) == 0 {
 		o.log.Debug("No potential approvers exist to filter for relevance. Does this repo have OWNERS files?")
 	}
 	keptApprovers := sets.New[string]()
 
-	unapproved := o.temporaryUnapprovedFiles(knownApprovers)
-
 	for _, suggestedApprover := range sets.List(o.GetSuggestedApprovers(reverseMap, potentialApprovers)) {
 		if reverseMap[suggestedApprover].Intersection(unapproved).Len() != 0 {
 			keptApprovers.Insert(suggestedApprover)
@@ -268,20 +272,38 @@ func (o Owners) removeSubdirs(dirs sets.Set[string]) {
 
 // Approval has the information about each approval on a PR
 type Approval struct {
-	Login     string // Login of the approver (can include uppercase)
-	How       string // How did the approver approved
-	Reference string // Where did the approver approved
-	NoIssue   bool   // Approval also accepts missing associated issue
+	Login     string   // Login of the approver (can include uppercase)
+	How       string   // How did the approver approved
+	Reference string   // Where did the approver approved
+	NoIssue   bool     // Approval also accepts missing associated issue
+	Paths     []string // Files and directories the approval is limited to, all files if empty
 }
 
 // String creates a link for the approval. Use `Login` if you just want the name.
 func (a Approval) String() string {
-	return fmt.Sprintf(
+	link := fmt.Sprintf(
 		`*<a href="%s" title="%s">%s</a>*`,
 		a.Reference,
 		a.How,
 		a.Login,
 	)
+	if len(a.Paths) > 0 {
+		link += fmt.Sprintf(" (%s)", strings.Join(a.Paths, ", "))
+	}
+	return link
+}
+
+// covers determines whether the approval applies to the file.
+func (a Approval) covers(file string) bool {
+	if len(a.Paths) == 0 {
+		return true
+	}
+	for _, path := range a.Paths {
+		if file == path || strings.HasPrefix(file, path+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // Approvers is struct that provide functionality with regard to approvals of a specific
@@ -292,6 +314,8 @@ type Approvers struct {
 	assignees       sets.Set[string]
 	AssociatedIssue int
 	RequireIssue    bool
+	// PartialApproval is set if approvers can approve some files only.
+	PartialApproval bool
 
 	ManuallyApproved func() bool
 }
@@ -377,6 +401,22 @@ func (ap *Approvers) AddAuthorSelfApprover(login, reference string, noIssue bool
 	}
 }
 
+// AddPartialApprover adds an approver that approves only the given files
+// and directories. If they approved some files already, the approval is
+// extended to the given ones.
+func (ap *Approvers) AddPartialApprover(login, reference string, paths []string) {
+	approval, alreadyApproved := ap.approvers[strings.ToLower(login)]
+	if alreadyApproved && len(approval.Paths) == 0 {
+		return
+	}
+	ap.approvers[strings.ToLower(login)] = Approval{
+		Login:     login,
+		How:       "Approved files",
+		Reference: reference,
+		Paths:     sets.List(sets.New[string](approval.Paths...).Insert(paths...)),
+	}
+}
+
 // RemoveApprover removes an approver from the list.
 func (ap *Approvers) RemoveApprover(login string) {
 	delete(ap.approvers, strings.ToLower(login))
@@ -423,11 +463,33 @@ func (ap Approvers) GetNoIssueApproversSet() sets.Set[string] {
 	return approvers
 }
 
+// approversFor returns the logins of the approvers whose approval applies
+// to the file, with the original cases.
+func (ap Approvers) approversFor(file string) sets.Set[string] {
+	approvers := sets.New[string]()
+	for _, approval := range ap.approvers {
+		if approval.covers(file) {
+			approvers.Insert(approval.Login)
+		}
+	}
+	return approvers
+}
+
 // GetFilesApprovers returns a map from files -> list of current approvers.
 func (ap Approvers) GetFilesApprovers() map[string]sets.Set[string] {
 	filesApprovers := map[string]sets.Set[string]{}
-	currentApprovers := ap.GetCurrentApproversSetCased()
+	// Partial approvals only count for the OWNERS files of the files
+	// they apply to.
+	ownersApprovers := map[string]sets.Set[string]{}
+	for _, file := range ap.owners.filenames {
+		ownersFile := ap.owners.repo.FindApproverOwnersForFile(file)
+		if _, ok := ownersApprovers[ownersFile]; !ok {
+			ownersApprovers[ownersFile] = sets.New[string]()
+		}
+		ownersApprovers[ownersFile] = ownersApprovers[ownersFile].Union(ap.approversFor(file))
+	}
 	for ownersFilename, potentialApprovers := range ap.owners.GetApprovers() {
+		currentApprovers := ownersApprovers[ownersFilename]
 		// The order of parameter matters here:
 		// - currentApprovers is the list of github handles that have approved
 		// - potentialApprovers is the list of handles in the OWNER
@@ -468,7 +530,6 @@ func (ap Approvers) NoIssueApprovers() map[string]Approval {
 func (ap Approvers) UnapprovedFiles() sets.Set[string] {
 	unapproved := sets.New[string]()
 	ownersSet := ap.owners.GetOwnersSet()
-	currentApprovers := ap.GetCurrentApproversSetCased()
 
 	for _, toApprove := range ap.owners.filenames {
 		ownersFile := ap.owners.repo.FindApproverOwnersForFile(toApprove)
@@ -476,7 +537,7 @@ func (ap Approvers) UnapprovedFiles() sets.Set[string] {
 			continue
 		}
 
-		if CaseInsensitiveIntersection(ap.owners.repo.Approvers(toApprove).Set(), currentApprovers).Len() == 0 {
+		if CaseInsensitiveIntersection(ap.owners.repo.Approvers(toApprove).Set(), ap.approversFor(toApprove)).Len() == 0 {
 			unapproved.Insert(ownersFile)
 		}
 	}
@@ -533,15 +594,30 @@ func (ap Approvers) GetCCs() []string {
 	currentApprovers := ap.GetCurrentApproversSet()
 	approversAndAssignees := currentApprovers.Union(ap.assignees)
 	leafReverseMap := ap.owners.GetReverseMap(ap.owners.GetLeafApprovers())
-	suggested := ap.owners.KeepCoveringApprovers(leafReverseMap, approversAndAssignees, randomizedApprovers)
+	suggested := ap.owners.keepCoveringApprovers(leafReverseMap, ap.unapprovedFilesWith(approversAndAssignees), randomizedApprovers)
 	approversAndSuggested := currentApprovers.Union(suggested)
 	everyone := approversAndSuggested.Union(ap.assignees)
 	fullReverseMap := ap.owners.GetReverseMap(ap.owners.GetApprovers())
-	keepAssignees := ap.owners.KeepCoveringApprovers(fullReverseMap, approversAndSuggested, sets.List(everyone))
+	keepAssignees := ap.owners.keepCoveringApprovers(fullReverseMap, ap.unapprovedFilesWith(approversAndSuggested), sets.List(everyone))
 
 	return sets.List(suggested.Union(keepAssignees))
 }
 
+// unapprovedFilesWith returns the OWNERS files that wouldn't be approved if
+// the given users approved all files, keeping the partial approvals of the
+// current approvers.
+func (ap Approvers) unapprovedFilesWith(logins sets.Set[string]) sets.Set[string] {
+	temporary := NewApprovers(ap.owners)
+	for login := range logins {
+		if approval, ok := ap.approvers[login]; ok && len(approval.Paths) > 0 {
+			temporary.approvers[login] = approval
+			continue
+		}
+		temporary.AddApprover(login, "", false)
+	}
+	return temporary.UnapprovedFiles()
+}
+
 // AreFilesApproved returns a bool indicating whether or not OWNERS files associated with
 // the PR are approved.  A PR with no OWNERS files is not considered approved. If this
 // returns true, the PR may still not be fully approved depending on the associated issue
@@ -719,6 +795,9 @@ Needs approval from an approver in each of these files:
 
 {{range .ap.GetFiles .baseURL .branch}}{{.}}{{end}}
 Approvers can indicate their approval by writing `+"`/approve`"+` in a comment
+{{if .ap.PartialApproval -}}
+Approvers can approve only some files or directories by writing `+"`/approve files <path>...`"+` in a comment
+{{end -}}
 Approvers can cancel approval by writing `+"`/approve cancel`"+` in a comment
 </details>`, "message", map[string]interface{}{"ap": ap, "baseURL": linkURL, "commandHelpLink": commandHelpLink, "prProcessLink": prProcessLink, "org": org, "repo": repo, "branch": branch})
 	if err != nil {
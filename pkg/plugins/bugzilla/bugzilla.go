@@ -337,8 +337,12 @@ func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) (err e
 		return err
 	}
 	if event != nil {
+		bc, err := bugzillaClient(pc, event.org, event.repo)
+		if err != nil {
+			return err
+		}
 		options := pc.PluginConfig.Bugzilla.OptionsForBranch(event.org, event.repo, event.baseRef)
-		return handle(*event, pc.GitHubClient, pc.BugzillaClient, options, pc.Logger, pc.Config.AllRepos)
+		return handle(*event, pc.GitHubClient, bc, options, pc.Logger, pc.Config.AllRepos)
 	}
 	return nil
 }
@@ -355,11 +359,29 @@ func handlePullRequest(pc plugins.Agent, pre github.PullRequestEvent) (err error
 		return err
 	}
 	if event != nil {
-		return handle(*event, pc.GitHubClient, pc.BugzillaClient, options, pc.Logger, pc.Config.AllRepos)
+		bc, err := bugzillaClient(pc, event.org, event.repo)
+		if err != nil {
+			return err
+		}
+		return handle(*event, pc.GitHubClient, bc, options, pc.Logger, pc.Config.AllRepos)
 	}
 	return nil
 }
 
+// bugzillaClient returns the client of the Bugzilla instance the bugs of the
+// repo are tracked in.
+func bugzillaClient(pc plugins.Agent, org, repo string) (bugzilla.Client, error) {
+	instance := pc.PluginConfig.Bugzilla.InstanceForRepo(org, repo)
+	if instance == "" {
+		return pc.BugzillaClient, nil
+	}
+	bc, ok := pc.BugzillaClients[instance]
+	if !ok {
+		return nil, fmt.Errorf("the Bugzilla instance %q of %s/%s is not configured", instance, org, repo)
+	}
+	return bc, nil
+}
+
 func getCherryPickMatch(pre github.PullRequestEvent) (bool, int, string, error) {
 	cherrypickMatch := cherrypickPRMatch.FindStringSubmatch(pre.PullRequest.Body)
 	if cherrypickMatch != nil {
@@ -2341,3 +2341,81 @@ func TestIsBugAllowed(t *testing.T) {
 		}
 	}
 }
+
+func TestBugzillaClient(t *testing.T) {
+	defaultClient := &bugzilla.Fake{EndpointString: "https://bugzilla.example.com"}
+	productClient := &bugzilla.Fake{EndpointString: "https://bugzilla.product.example.com"}
+	otherClient := &bugzilla.Fake{EndpointString: "https://bugzilla.other.example.com"}
+	pc := plugins.Agent{
+		BugzillaClient:  defaultClient,
+		BugzillaClients: map[string]bugzilla.Client{"product": productClient, "other": otherClient},
+		PluginConfig: &plugins.Configuration{Bugzilla: plugins.Bugzilla{
+			Orgs: map[string]plugins.BugzillaOrgOptions{
+				"product-org": {
+					Instance: "product",
+					Repos: map[string]plugins.BugzillaRepoOptions{
+						"other-repo":   {Instance: "other"},
+						"missing-repo": {Instance: "missing"},
+					},
+				},
+				"org": {
+					Repos: map[string]plugins.BugzillaRepoOptions{"product-repo": {Instance: "product"}},
+				},
+			},
+		}},
+	}
+	testCases := []struct {
+		name        string
+		org, repo   string
+		expected    bugzilla.Client
+		expectedErr bool
+	}{
+		{
+			name:     "unconfigured org uses the default instance",
+			org:      "unconfigured",
+			repo:     "repo",
+			expected: defaultClient,
+		},
+		{
+			name:     "org without an instance uses the default instance",
+			org:      "org",
+			repo:     "repo",
+			expected: defaultClient,
+		},
+		{
+			name:     "instance of the org",
+			org:      "product-org",
+			repo:     "repo",
+			expected: productClient,
+		},
+		{
+			name:     "instance of the repo",
+			org:      "org",
+			repo:     "product-repo",
+			expected: productClient,
+		},
+		{
+			name:     "instance of the repo overrides the org",
+			org:      "product-org",
+			repo:     "other-repo",
+			expected: otherClient,
+		},
+		{
+			name:        "instance that isn't configured",
+			org:         "product-org",
+			repo:        "missing-repo",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bc, err := bugzillaClient(pc, tc.org, tc.repo)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if bc != tc.expected {
+				t.Errorf("expected the client of %v, got %v", tc.expected, bc)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"slices"
@@ -63,6 +64,12 @@ type Configuration struct {
 	// external plugins.
 	ExternalPlugins map[string][]ExternalPlugin `json:"external_plugins,omitempty"`
 
+	// RepoOverrides is a map of repositories (eg "o/r") to the config of
+	// approve, lgtm, triage, triggers and welcome that they override, on top
+	// of the config of their organization in those sections. Only the fields
+	// that differ from the config of the organization need to be set.
+	RepoOverrides map[string]RepoOverride `json:"repo_overrides,omitempty"`
+
 	// Owners contains configuration related to handling OWNERS files.
 	Owners Owners `json:"owners,omitempty"`
 
@@ -93,6 +100,8 @@ type Configuration struct {
 	SigMention           SigMention                   `json:"sigmention,omitempty"`
 	Size                 Size                         `json:"size,omitempty"`
 	Triggers             []Trigger                    `json:"triggers,omitempty"`
+	Triage               []Triage                     `json:"triage,omitempty"`
+	UnsupportedBranches  []UnsupportedBranches        `json:"unsupported_branches,omitempty"`
 	Welcome              []Welcome                    `json:"welcome,omitempty"`
 	Override             Override                     `json:"override,omitempty"`
 	Help                 Help                         `json:"help,omitempty"`
@@ -325,6 +334,10 @@ type Approve struct {
 	// * an APPROVE github review is equivalent to leaving an "/approve" message.
 	// * A REQUEST_CHANGES github review is equivalent to leaving an /approve cancel" message.
 	IgnoreReviewState *bool `json:"ignore_review_state,omitempty"`
+	// PartialApproval allows approvers to approve only some files or directories
+	// of a PR with "/approve files <path>...". The PR is approved once every file
+	// it changes is approved by an approver of the file.
+	PartialApproval bool `json:"partial_approval,omitempty"`
 	// CommandHelpLink is the link to the help page which shows the available commands for each repo.
 	// The default value is "https://go.k8s.io/bot-commands". The command help page is served by Deck
 	// and available under https://<deck-url>/command-help, e.g. "https://prow.k8s.io/command-help"
@@ -367,6 +380,13 @@ type Lgtm struct {
 	// StoreTreeHash indicates if tree_hash should be stored inside a comment to detect
 	// squashed commits before removing lgtm labels
 	StoreTreeHash bool `json:"store_tree_hash,omitempty"`
+	// StoreDiffHash indicates if a hash of the changes of the pull request should be
+	// stored inside a comment, so that pushes which don't change the diff, like rebases
+	// and empty force pushes, don't remove lgtm labels.
+	StoreDiffHash bool `json:"store_diff_hash,omitempty"`
+	// TrustedUsers is a list of GitHub users whose pushes never remove the lgtm label,
+	// like bots that rebase pull requests.
+	TrustedUsers []string `json:"trusted_users_for_sticky_lgtm,omitempty"`
 	// WARNING: This disables the security mechanism that prevents a malicious member (or
 	// compromised GitHub account) from merging arbitrary code. Use with caution.
 	//
@@ -469,7 +489,46 @@ type Trigger struct {
 	IgnoreOkToTest bool `json:"ignore_ok_to_test,omitempty"`
 	// TriggerGitHubWorkflows enables workflows run by github to be triggered by prow.
 	TriggerGitHubWorkflows bool `json:"trigger_github_workflows,omitempty"`
-}
+	// TrustPolicy decides whose PRs are tested automatically instead of the
+	// org membership and collaborator checks above. /ok-to-test still lets
+	// members of the trusted org test the PRs of everyone else.
+	TrustPolicy *TrustPolicy `json:"trust_policy,omitempty"`
+	// TestImpactMapping is the path of a file in the repos mapping their
+	// presubmits to the files they depend on. Presubmits that the mapping
+	// at the base of a PR proves unaffected by its changes aren't run
+	// automatically, and are reported as successful if they report to
+	// GitHub. /test still runs them. See the testimpact package for the
+	// format of the file.
+	TestImpactMapping string `json:"test_impact_mapping,omitempty"`
+}
+
+// TrustPolicy trusts the author of a PR if any of its rules matches.
+type TrustPolicy struct {
+	// Rules are the ways in which an author can be trusted.
+	Rules []TrustRule `json:"rules"`
+	// DryRun evaluates the policy and logs when it disagrees with the org
+	// membership and collaborator checks, which keep deciding which PRs are
+	// trusted.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// TrustRule matches if all of its conditions that are set hold.
+type TrustRule struct {
+	// OrgMember requires the author to be a member of the org.
+	OrgMember string `json:"org_member,omitempty"`
+	// CollaboratorPermission requires the author to have at least the
+	// permission on the repo: read, write or admin.
+	CollaboratorPermission string `json:"collaborator_permission,omitempty"`
+	// Team requires the author to be a member of the team, as org/team-slug.
+	Team string `json:"team,omitempty"`
+	// StatusContext requires the status of the context, like the check of a
+	// CLA bot, to be successful on the head commit of the PR.
+	StatusContext string `json:"status_context,omitempty"`
+}
+
+// CollaboratorPermissions are the permission levels of collaborators, from
+// the lowest to the highest.
+var CollaboratorPermissions = []string{"read", "write", "admin"}
 
 // Heart contains the configuration for the heart plugin.
 type Heart struct {
@@ -693,6 +752,77 @@ func (w Welcome) getRepos() []string {
 	return w.Repos
 }
 
+// Triage is config for the triage plugin, which assigns new issues to the
+// owners of the files and components they mention.
+type Triage struct {
+	// Repos is either of the form org/repos or just org. Issues are only
+	// triaged in the listed repos.
+	Repos []string `json:"repos,omitempty"`
+	// Components maps labels to the paths of the components they stand for.
+	// Issues with these labels are triaged as if they mentioned the paths.
+	Components map[string][]string `json:"components,omitempty"`
+	// MaxAssignees is the maximum number of approvers assigned to an issue.
+	// Defaults to 1.
+	MaxAssignees int `json:"max_assignees,omitempty"`
+	// MaxCCs is the maximum number of other owners that are mentioned on
+	// the issue. Defaults to 0.
+	MaxCCs int `json:"max_ccs,omitempty"`
+	// MaxIssuesPerOwner is the maximum number of issues an owner is assigned
+	// to or mentioned on per RateLimitPeriod. Defaults to 5.
+	MaxIssuesPerOwner int `json:"max_issues_per_owner,omitempty"`
+	// RateLimitPeriod is the period MaxIssuesPerOwner applies to, like 24h.
+	// Defaults to 24h.
+	RateLimitPeriod string `json:"rate_limit_period,omitempty"`
+	// RateLimitPeriodDuration is RateLimitPeriod parsed into a time.Duration.
+	RateLimitPeriodDuration time.Duration `json:"-"`
+}
+
+// UnsupportedBranches is the config for the unsupported-branches plugin, which
+// comments on, labels and optionally closes PRs opened against branches that
+// don't take changes, like branches that reached their end of life or are
+// frozen for a release.
+type UnsupportedBranches struct {
+	// Repos is either of the form org/repo or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Branches are the branches of the repos that don't take changes.
+	Branches []UnsupportedBranch `json:"branches,omitempty"`
+}
+
+// UnsupportedBranch describes branches that don't take changes, optionally
+// only from or until some date.
+type UnsupportedBranch struct {
+	// BranchRegexp matches the names of the branches.
+	BranchRegexp string         `json:"branch_regexp"`
+	BranchRe     *regexp.Regexp `json:"-"`
+	// From is the date, like 2024-06-30, from which on the branches don't
+	// take changes. The branches don't take changes right away if unset.
+	From     string    `json:"from,omitempty"`
+	FromTime time.Time `json:"-"`
+	// Until is the date the branches take changes again, like the end of a
+	// freeze. The branches don't take changes for good if unset.
+	Until     string    `json:"until,omitempty"`
+	UntilTime time.Time `json:"-"`
+	// Reason completes the sentence "This PR targets the <branch> branch,
+	// which ...". Defaults to "doesn't take changes".
+	Reason string `json:"reason,omitempty"`
+	// Guidance tells authors what to do instead, like which branch to open
+	// their PR against.
+	Guidance string `json:"guidance,omitempty"`
+	// Close closes the PRs after commenting on them.
+	Close bool `json:"close,omitempty"`
+}
+
+// Unsupported returns whether the branch doesn't take changes at the time.
+func (b UnsupportedBranch) Unsupported(branch string, now time.Time) bool {
+	if b.BranchRe == nil || !b.BranchRe.MatchString(branch) {
+		return false
+	}
+	if !b.FromTime.IsZero() && now.Before(b.FromTime) {
+		return false
+	}
+	return b.UntilTime.IsZero() || now.Before(b.UntilTime)
+}
+
 // Dco is config for the DCO (https://developercertificate.org/) checker plugin.
 type Dco struct {
 	// SkipDCOCheckForMembers is used to skip DCO check for trusted org members
@@ -907,6 +1037,43 @@ func (c *Configuration) LgtmFor(org, repo string) *Lgtm {
 	return &Lgtm{}
 }
 
+// TriageFor finds the Triage for a repo. It returns nil if issues in the repo
+// aren't triaged.
+func (c *Configuration) TriageFor(org, repo string) *Triage {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.Triage {
+		if sets.New[string](c.Triage[i].Repos...).Has(fullName) {
+			return &c.Triage[i]
+		}
+	}
+	for i := range c.Triage {
+		if sets.New[string](c.Triage[i].Repos...).Has(org) {
+			return &c.Triage[i]
+		}
+	}
+	return nil
+}
+
+// UnsupportedBranchFor finds the unsupported branch config matching a branch
+// of a repo at the time, if any. Configs for the repo take precedence over
+// configs for its org.
+func (c *Configuration) UnsupportedBranchFor(org, repo, branch string, now time.Time) *UnsupportedBranch {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for _, name := range []string{fullName, org} {
+		for i := range c.UnsupportedBranches {
+			if !sets.New[string](c.UnsupportedBranches[i].Repos...).Has(name) {
+				continue
+			}
+			for j := range c.UnsupportedBranches[i].Branches {
+				if c.UnsupportedBranches[i].Branches[j].Unsupported(branch, now) {
+					return &c.UnsupportedBranches[i].Branches[j]
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // TriggerFor finds the Trigger for a repo, if one exists
 // a trigger can be listed for the repo itself or for the
 // owning organization
@@ -1075,6 +1242,17 @@ func (c *Configuration) setDefaults() {
 	for i := range c.Triggers {
 		c.Triggers[i].SetDefaults()
 	}
+	for i := range c.Triage {
+		if c.Triage[i].MaxAssignees == 0 {
+			c.Triage[i].MaxAssignees = 1
+		}
+		if c.Triage[i].MaxIssuesPerOwner == 0 {
+			c.Triage[i].MaxIssuesPerOwner = 5
+		}
+		if c.Triage[i].RateLimitPeriod == "" {
+			c.Triage[i].RateLimitPeriod = "24h"
+		}
+	}
 	if c.SigMention.Regexp == "" {
 		c.SigMention.Regexp = `(?m)@kubernetes/sig-([\w-]*)-(misc|test-failures|bugs|feature-requests|proposals|pr-reviews|api-reviews)`
 	}
@@ -1323,6 +1501,63 @@ func validateTrigger(triggers []Trigger) error {
 		if trigger.TrustedOrg != "" {
 			logrusutil.ThrottledWarnf(&warnTriggerTrustedOrg, 5*time.Minute, "trusted_org functionality is deprecated. Please ensure your configuration is updated before the end of December 2019.")
 		}
+		if trigger.TrustPolicy != nil {
+			if err := trigger.TrustPolicy.validate(); err != nil {
+				return fmt.Errorf("trigger for %v: trust_policy: %w", trigger.Repos, err)
+			}
+		}
+		if trigger.TestImpactMapping != "" && (!filepath.IsLocal(trigger.TestImpactMapping) || path.Clean(trigger.TestImpactMapping) != trigger.TestImpactMapping) {
+			return fmt.Errorf("trigger for %v: test_impact_mapping: %q must be a clean path relative to the root of the repo", trigger.Repos, trigger.TestImpactMapping)
+		}
+	}
+	return nil
+}
+
+func (p TrustPolicy) validate() error {
+	if len(p.Rules) == 0 {
+		return errors.New("rules: at least one rule is required")
+	}
+	for i, rule := range p.Rules {
+		if rule == (TrustRule{}) {
+			return fmt.Errorf("rules[%d]: at least one condition is required", i)
+		}
+		if rule.CollaboratorPermission != "" && !slices.Contains(CollaboratorPermissions, rule.CollaboratorPermission) {
+			return fmt.Errorf("rules[%d]: collaborator_permission: %q is not one of %s", i, rule.CollaboratorPermission, strings.Join(CollaboratorPermissions, ", "))
+		}
+		if rule.Team != "" {
+			if org, team, ok := strings.Cut(rule.Team, "/"); !ok || org == "" || team == "" || strings.Contains(team, "/") {
+				return fmt.Errorf("rules[%d]: team: %q is not of the form org/team-slug", i, rule.Team)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTriage(triage []Triage) error {
+	for _, t := range triage {
+		if t.MaxAssignees < 0 || t.MaxCCs < 0 || t.MaxIssuesPerOwner < 0 {
+			return fmt.Errorf("triage config for %v: max_assignees, max_ccs and max_issues_per_owner must not be negative", t.Repos)
+		}
+		if t.RateLimitPeriodDuration <= 0 {
+			return fmt.Errorf("triage config for %v: rate_limit_period must be positive", t.Repos)
+		}
+	}
+	return nil
+}
+
+func validateUnsupportedBranches(configs []UnsupportedBranches) error {
+	for _, c := range configs {
+		if len(c.Repos) == 0 {
+			return errors.New("unsupported_branches config without repos")
+		}
+		for _, b := range c.Branches {
+			if b.BranchRegexp == "" {
+				return fmt.Errorf("unsupported_branches config for %v: branch_regexp must not be empty", c.Repos)
+			}
+			if !b.FromTime.IsZero() && !b.UntilTime.IsZero() && !b.FromTime.Before(b.UntilTime) {
+				return fmt.Errorf("unsupported_branches config for %v: from (%s) must be before until (%s)", c.Repos, b.From, b.Until)
+			}
+		}
 	}
 	return nil
 }
@@ -1390,6 +1625,35 @@ func compileRegexpsAndDurations(pc *Configuration) error {
 		}
 		rs[i].GracePeriodDuration = dur
 	}
+
+	for i := range pc.Triage {
+		dur, err := time.ParseDuration(pc.Triage[i].RateLimitPeriod)
+		if err != nil {
+			return fmt.Errorf("failed to parse triage rate limit period: %q, error: %w", pc.Triage[i].RateLimitPeriod, err)
+		}
+		pc.Triage[i].RateLimitPeriodDuration = dur
+	}
+
+	for i := range pc.UnsupportedBranches {
+		branches := pc.UnsupportedBranches[i].Branches
+		for j := range branches {
+			re, err := regexp.Compile(branches[j].BranchRegexp)
+			if err != nil {
+				return fmt.Errorf("failed to compile unsupported branch regexp: %q, error: %w", branches[j].BranchRegexp, err)
+			}
+			branches[j].BranchRe = re
+			if branches[j].From != "" {
+				if branches[j].FromTime, err = time.Parse(time.DateOnly, branches[j].From); err != nil {
+					return fmt.Errorf("failed to parse unsupported branch date: %q, error: %w", branches[j].From, err)
+				}
+			}
+			if branches[j].Until != "" {
+				if branches[j].UntilTime, err = time.Parse(time.DateOnly, branches[j].Until); err != nil {
+					return fmt.Errorf("failed to parse unsupported branch date: %q, error: %w", branches[j].Until, err)
+				}
+			}
+		}
+	}
 	return nil
 }
 
@@ -1435,6 +1699,12 @@ func (c *Configuration) Validate() error {
 	if err := validateRepoDupes(c.Welcome); err != nil {
 		return err
 	}
+	if err := validateTriage(c.Triage); err != nil {
+		return err
+	}
+	if err := validateUnsupportedBranches(c.UnsupportedBranches); err != nil {
+		return err
+	}
 	validateRepoMilestone(c.RepoMilestone)
 
 	return nil
@@ -1515,6 +1785,10 @@ type Bugzilla struct {
 
 // BugzillaOrgOptions holds options for checking Bugzilla bugs for an org.
 type BugzillaOrgOptions struct {
+	// Instance is the name of the Bugzilla instance, as passed to hook with
+	// --bugzilla-instance, the bugs of the org are tracked in. Bugs are
+	// tracked in the instance of --bugzilla-endpoint if unset.
+	Instance string `json:"instance,omitempty"`
 	// Default settings mapped by branch in any repo in this org.
 	// The `*` wildcard will apply to all branches.
 	Default map[string]BugzillaBranchOptions `json:"default,omitempty"`
@@ -1524,6 +1798,9 @@ type BugzillaOrgOptions struct {
 
 // BugzillaRepoOptions holds options for checking Bugzilla bugs for a repo.
 type BugzillaRepoOptions struct {
+	// Instance is the name of the Bugzilla instance the bugs of the repo are
+	// tracked in, overriding the instance of the org.
+	Instance string `json:"instance,omitempty"`
 	// Options for specific branches in this repo.
 	// The `*` wildcard will apply to all branches.
 	Branches map[string]BugzillaBranchOptions `json:"branches,omitempty"`
@@ -1930,6 +2207,16 @@ func (b *Bugzilla) OptionsForBranch(org, repo, branch string) BugzillaBranchOpti
 	return options
 }
 
+// InstanceForRepo returns the name of the Bugzilla instance the bugs of a repo
+// are tracked in, or the empty string for the default instance.
+func (b *Bugzilla) InstanceForRepo(org, repo string) string {
+	orgOptions := b.Orgs[org]
+	if repoOptions := orgOptions.Repos[repo]; repoOptions.Instance != "" {
+		return repoOptions.Instance
+	}
+	return orgOptions.Instance
+}
+
 // OptionsForRepo determines the criteria for a valid Bugzilla bug on branches of a repo
 // by defaulting in a cascading way, in the following order (later entries override earlier
 // ones), always searching for the wildcard as well as the branch name: global, then org,
@@ -2021,7 +2308,7 @@ func (c *Configuration) mergeFrom(other *Configuration) error {
 
 	diff := cmp.Diff(other, &Configuration{Approve: other.Approve, Bugzilla: other.Bugzilla,
 		ExternalPlugins: other.ExternalPlugins, Label: Label{RestrictedLabels: other.Label.RestrictedLabels},
-		Lgtm: other.Lgtm, Plugins: other.Plugins, Triggers: other.Triggers, Welcome: other.Welcome},
+		Lgtm: other.Lgtm, Plugins: other.Plugins, RepoOverrides: other.RepoOverrides, Triage: other.Triage, Triggers: other.Triggers, Welcome: other.Welcome},
 		config.DefaultDiffOpts...)
 
 	if diff != "" {
@@ -2041,6 +2328,7 @@ func (c *Configuration) mergeFrom(other *Configuration) error {
 
 	c.Approve = append(c.Approve, other.Approve...)
 	c.Lgtm = append(c.Lgtm, other.Lgtm...)
+	c.Triage = append(c.Triage, other.Triage...)
 	c.Triggers = append(c.Triggers, other.Triggers...)
 	c.Welcome = append(c.Welcome, other.Welcome...)
 
@@ -2048,6 +2336,17 @@ func (c *Configuration) mergeFrom(other *Configuration) error {
 		errs = append(errs, fmt.Errorf("failed to merge .external-plugins from supplemental config: %w", err))
 	}
 
+	if c.RepoOverrides == nil && other.RepoOverrides != nil {
+		c.RepoOverrides = make(map[string]RepoOverride)
+	}
+	for repo, override := range other.RepoOverrides {
+		if _, ok := c.RepoOverrides[repo]; ok {
+			errs = append(errs, fmt.Errorf("found duplicate config for repo_overrides.%s", repo))
+			continue
+		}
+		c.RepoOverrides[repo] = override
+	}
+
 	if err := c.Label.mergeFrom(&other.Label); err != nil {
 		errs = append(errs, fmt.Errorf("failed to merge .label from supplemental config: %w", err))
 	}
@@ -2122,6 +2421,15 @@ func (p *Bugzilla) mergeFrom(other *Bugzilla) error {
 			newConfig.Default = orgConfig.Default
 			p.Orgs[org] = newConfig
 		}
+		if orgConfig.Instance != "" {
+			if p.Orgs[org].Instance != "" {
+				errs = append(errs, fmt.Errorf("found duplicate instance config for bugzilla.%s", org))
+				continue
+			}
+			newConfig := p.Orgs[org]
+			newConfig.Instance = orgConfig.Instance
+			p.Orgs[org] = newConfig
+		}
 		if len(orgConfig.Repos) != 0 && p.Orgs[org].Repos == nil {
 			newConfig := p.Orgs[org]
 			newConfig.Repos = make(map[string]BugzillaRepoOptions)
@@ -2174,7 +2482,7 @@ func (c *Configuration) HasConfigFor() (global bool, orgs sets.Set[string], repo
 	equals := reflect.DeepEqual(c,
 		&Configuration{Approve: c.Approve, Bugzilla: c.Bugzilla, ExternalPlugins: c.ExternalPlugins,
 			Label: Label{RestrictedLabels: c.Label.RestrictedLabels}, Lgtm: c.Lgtm, Plugins: c.Plugins,
-			Triggers: c.Triggers, Welcome: c.Welcome})
+			RepoOverrides: c.RepoOverrides, Triage: c.Triage, Triggers: c.Triggers, Welcome: c.Welcome})
 
 	if !equals || c.Bugzilla.Default != nil {
 		global = true
@@ -2190,7 +2498,7 @@ func (c *Configuration) HasConfigFor() (global bool, orgs sets.Set[string], repo
 	}
 
 	for org, orgConfig := range c.Bugzilla.Orgs {
-		if orgConfig.Default != nil {
+		if orgConfig.Default != nil || orgConfig.Instance != "" {
 			orgs.Insert(org)
 		}
 		for repo := range orgConfig.Repos {
@@ -2231,6 +2539,16 @@ func (c *Configuration) HasConfigFor() (global bool, orgs sets.Set[string], repo
 		}
 	}
 
+	for _, triage := range c.Triage {
+		for _, orgOrRepo := range triage.Repos {
+			if strings.Contains(orgOrRepo, "/") {
+				repos.Insert(orgOrRepo)
+			} else {
+				orgs.Insert(orgOrRepo)
+			}
+		}
+	}
+
 	for _, trigger := range c.Triggers {
 		for _, orgOrRepo := range trigger.Repos {
 			if strings.Contains(orgOrRepo, "/") {
@@ -2259,5 +2577,9 @@ func (c *Configuration) HasConfigFor() (global bool, orgs sets.Set[string], repo
 		}
 	}
 
+	for repo := range c.RepoOverrides {
+		repos.Insert(repo)
+	}
+
 	return global, orgs, repos
 }
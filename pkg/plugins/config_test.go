@@ -275,6 +275,53 @@ func TestTriggerFor(t *testing.T) {
 	}
 }
 
+func TestTriageFor(t *testing.T) {
+	config := Configuration{
+		Triage: []Triage{
+			{Repos: []string{"org"}, MaxAssignees: 2},
+			{Repos: []string{"org/repo"}, RateLimitPeriod: "1h"},
+		},
+	}
+	if err := compileRegexpsAndDurations(&config); err == nil {
+		t.Fatal("expected an error for the missing rate limit period before defaulting")
+	}
+	config.setDefaults()
+	if err := compileRegexpsAndDurations(&config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		org, repo string
+		expected  *Triage
+	}{
+		{
+			name:     "repo config takes precedence",
+			org:      "org",
+			repo:     "repo",
+			expected: &Triage{Repos: []string{"org/repo"}, MaxAssignees: 1, MaxIssuesPerOwner: 5, RateLimitPeriod: "1h", RateLimitPeriodDuration: time.Hour},
+		},
+		{
+			name:     "org config",
+			org:      "org",
+			repo:     "other",
+			expected: &Triage{Repos: []string{"org"}, MaxAssignees: 2, MaxIssuesPerOwner: 5, RateLimitPeriod: "24h", RateLimitPeriodDuration: 24 * time.Hour},
+		},
+		{
+			name: "repos are not triaged by default",
+			org:  "other",
+			repo: "repo",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, config.TriageFor(tc.org, tc.repo)); diff != "" {
+				t.Errorf("unexpected triage config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestSetApproveDefaults(t *testing.T) {
 	c := &Configuration{
 		Approve: []Approve{
@@ -1356,6 +1403,53 @@ func TestStatesMatch(t *testing.T) {
 	}
 }
 
+func TestValidateTrustPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      TrustPolicy
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			policy: TrustPolicy{Rules: []TrustRule{
+				{OrgMember: "org"},
+				{CollaboratorPermission: "write", StatusContext: "cla"},
+				{Team: "org/contributors"},
+			}},
+		},
+		{
+			name:        "no rules",
+			expectedErr: "rules: at least one rule is required",
+		},
+		{
+			name:        "rule without conditions",
+			policy:      TrustPolicy{Rules: []TrustRule{{OrgMember: "org"}, {}}},
+			expectedErr: "rules[1]: at least one condition is required",
+		},
+		{
+			name:        "unknown permission",
+			policy:      TrustPolicy{Rules: []TrustRule{{CollaboratorPermission: "maintain"}}},
+			expectedErr: `rules[0]: collaborator_permission: "maintain" is not one of read, write, admin`,
+		},
+		{
+			name:        "team without org",
+			policy:      TrustPolicy{Rules: []TrustRule{{Team: "contributors"}}},
+			expectedErr: `rules[0]: team: "contributors" is not of the form org/team-slug`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errMsg string
+			if err := tc.policy.validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, errMsg); diff != "" {
+				t.Errorf("unexpected error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateConfigUpdater(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -2063,9 +2157,11 @@ func TestHasConfigFor(t *testing.T) {
 				fuzzedConfig.Approve = nil
 				fuzzedConfig.Label.RestrictedLabels = nil
 				fuzzedConfig.Lgtm = nil
+				fuzzedConfig.Triage = nil
 				fuzzedConfig.Triggers = nil
 				fuzzedConfig.Welcome = nil
 				fuzzedConfig.ExternalPlugins = nil
+				fuzzedConfig.RepoOverrides = nil
 				return fuzzedConfig, !reflect.DeepEqual(fuzzedConfig, &Configuration{}), nil, nil
 			},
 		},
@@ -2092,7 +2188,7 @@ func TestHasConfigFor(t *testing.T) {
 				fuzzedConfig = &Configuration{Bugzilla: fuzzedConfig.Bugzilla}
 				expectOrgs, expectRepos = sets.Set[string]{}, sets.Set[string]{}
 				for org, orgConfig := range fuzzedConfig.Bugzilla.Orgs {
-					if orgConfig.Default != nil {
+					if orgConfig.Default != nil || orgConfig.Instance != "" {
 						expectOrgs.Insert(org)
 					}
 					for repo := range orgConfig.Repos {
@@ -2140,6 +2236,25 @@ func TestHasConfigFor(t *testing.T) {
 				return fuzzedConfig, false, expectOrgs, expectRepos
 			},
 		},
+		{
+			name: "Any config with triage is considered to be for the orgs and repos references there",
+			resultGenerator: func(fuzzedConfig *Configuration) (toCheck *Configuration, expectGlobal bool, expectOrgs sets.Set[string], expectRepos sets.Set[string]) {
+				fuzzedConfig = &Configuration{Triage: fuzzedConfig.Triage}
+				expectOrgs, expectRepos = sets.Set[string]{}, sets.Set[string]{}
+
+				for _, triage := range fuzzedConfig.Triage {
+					for _, orgOrRepo := range triage.Repos {
+						if strings.Contains(orgOrRepo, "/") {
+							expectRepos.Insert(orgOrRepo)
+						} else {
+							expectOrgs.Insert(orgOrRepo)
+						}
+					}
+				}
+
+				return fuzzedConfig, false, expectOrgs, expectRepos
+			},
+		},
 		{
 			name: "Any config with triggers is considered to be for the orgs and repos references there",
 			resultGenerator: func(fuzzedConfig *Configuration) (toCheck *Configuration, expectGlobal bool, expectOrgs sets.Set[string], expectRepos sets.Set[string]) {
@@ -2338,6 +2453,21 @@ func TestMergeFrom(t *testing.T) {
 			supplementalConfigs: []Configuration{{ExternalPlugins: map[string][]ExternalPlugin{"foo/bar": {{Name: "refresh", Endpoint: "http://refresh", Events: []string{"issue_comment"}}}}}},
 			errorExpected:       true,
 		},
+		{
+			name:                "RepoOverrides get merged",
+			in:                  Configuration{RepoOverrides: map[string]RepoOverride{"foo/bar": {Lgtm: &Lgtm{ReviewActsAsLgtm: true}}}},
+			supplementalConfigs: []Configuration{{RepoOverrides: map[string]RepoOverride{"foo/baz": {Lgtm: &Lgtm{StickyLgtmTeam: "team"}}}}},
+			expected: Configuration{RepoOverrides: map[string]RepoOverride{
+				"foo/bar": {Lgtm: &Lgtm{ReviewActsAsLgtm: true}},
+				"foo/baz": {Lgtm: &Lgtm{StickyLgtmTeam: "team"}},
+			}},
+		},
+		{
+			name:                "RepoOverrides can't merge duplicated configs",
+			in:                  Configuration{RepoOverrides: map[string]RepoOverride{"foo/bar": {Lgtm: &Lgtm{ReviewActsAsLgtm: true}}}},
+			supplementalConfigs: []Configuration{{RepoOverrides: map[string]RepoOverride{"foo/bar": {Lgtm: &Lgtm{StickyLgtmTeam: "team"}}}}},
+			errorExpected:       true,
+		},
 	}
 
 	for _, tc := range testCases {
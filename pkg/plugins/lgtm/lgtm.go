@@ -18,8 +18,11 @@ limitations under the License.
 package lgtm
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -41,8 +44,13 @@ const (
 var (
 	addLGTMLabelNotification   = "LGTM label has been added.  <details>Git tree hash: %s</details>"
 	addLGTMLabelNotificationRe = regexp.MustCompile(fmt.Sprintf(addLGTMLabelNotification, "(.*)"))
+	// diffHashNotification is appended to the notification on its own line,
+	// so that addLGTMLabelNotificationRe still matches only the tree hash.
+	diffHashNotification       = "\n<details>Diff hash: %s</details>"
+	diffHashNotificationRe     = regexp.MustCompile(fmt.Sprintf(diffHashNotification, `(\S+)`))
 	configInfoReviewActsAsLgtm = `Reviews of "approve" or "request changes" act as adding or removing LGTM.`
 	configInfoStoreTreeHash    = `Squashing commits does not remove LGTM.`
+	configInfoStoreDiffHash    = `Pushes that do not change the diff, like rebases, do not remove LGTM.`
 	// LGTMLabel is the name of the lgtm label applied by the lgtm plugin
 	LGTMLabel = labels.LGTM
 	// LGTMRe is the regex that matches lgtm comments
@@ -56,6 +64,10 @@ func configInfoStickyLgtmTeam(team string) string {
 	return fmt.Sprintf(`Commits from "%s" do not remove LGTM.`, team)
 }
 
+func configInfoTrustedUsers(users []string) string {
+	return fmt.Sprintf(`Pushes by %s do not remove LGTM.`, strings.Join(users, ", "))
+}
+
 type commentPruner interface {
 	PruneComments(shouldPrune func(github.IssueComment) bool)
 }
@@ -83,6 +95,14 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 			configInfoStrings = append(configInfoStrings, "<li>"+configInfoStoreTreeHash+"</li>")
 			isConfigured = true
 		}
+		if opts.StoreDiffHash {
+			configInfoStrings = append(configInfoStrings, "<li>"+configInfoStoreDiffHash+"</li>")
+			isConfigured = true
+		}
+		if len(opts.TrustedUsers) > 0 {
+			configInfoStrings = append(configInfoStrings, "<li>"+configInfoTrustedUsers(opts.TrustedUsers)+"</li>")
+			isConfigured = true
+		}
 		if opts.StickyLgtmTeam != "" {
 			configInfoStrings = append(configInfoStrings, "<li>"+configInfoStickyLgtmTeam(opts.StickyLgtmTeam)+"</li>")
 			isConfigured = true
@@ -99,6 +119,8 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 				ReviewActsAsLgtm: true,
 				StickyLgtmTeam:   "team1",
 				StoreTreeHash:    true,
+				StoreDiffHash:    true,
+				TrustedUsers:     []string{"k8s-ci-robot"},
 			},
 		},
 	})
@@ -335,10 +357,10 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 	opts := config.LgtmFor(rc.repo.Owner.Login, rc.repo.Name)
 	if hasLGTM && !wantLGTM {
 		log.Info("Removing LGTM label.")
-		if err := removeLGTMAndRequestReview(gc, org, repoName, number, getLogins(assignees), opts.StoreTreeHash); err != nil {
+		if err := removeLGTMAndRequestReview(gc, org, repoName, number, getLogins(assignees), opts.StoreTreeHash || opts.StoreDiffHash); err != nil {
 			return err
 		}
-		if opts.StoreTreeHash {
+		if opts.StoreTreeHash || opts.StoreDiffHash {
 			cp.PruneComments(func(comment github.IssueComment) bool {
 				return addLGTMLabelNotificationRe.MatchString(comment.Body)
 			})
@@ -349,7 +371,7 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 			return err
 		}
 		if !stickyLgtm(log, gc, config, opts, issueAuthor, org) {
-			if opts.StoreTreeHash {
+			if opts.StoreTreeHash || opts.StoreDiffHash {
 				pr, err := gc.GetPullRequest(org, repoName, number)
 				if err != nil {
 					log.WithError(err).Error("Failed to get pull request.")
@@ -359,8 +381,16 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 					log.WithField("sha", pr.Head.SHA).WithError(err).Error("Failed to get commit.")
 				}
 				treeHash := commit.Commit.Tree.SHA
+				notification := fmt.Sprintf(addLGTMLabelNotification, treeHash)
+				if opts.StoreDiffHash {
+					if changes, err := gc.GetPullRequestChanges(org, repoName, number); err != nil {
+						log.WithError(err).Error("Failed to get pull request changes.")
+					} else {
+						notification += fmt.Sprintf(diffHashNotification, diffHash(changes))
+					}
+				}
 				log.WithField("tree", treeHash).Info("Adding comment to store tree-hash.")
-				if err := gc.CreateComment(org, repoName, number, fmt.Sprintf(addLGTMLabelNotification, treeHash)); err != nil {
+				if err := gc.CreateComment(org, repoName, number, notification); err != nil {
 					log.WithError(err).Error("Failed to add comment.")
 				}
 			}
@@ -422,6 +452,12 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 		// If the author is trusted, skip tree hash verification and LGTM removal.
 		return nil
 	}
+	for _, user := range opts.TrustedUsers {
+		if github.NormLogin(user) == github.NormLogin(pe.Sender.Login) {
+			log.Infof("Keeping LGTM label as %s is trusted to push without removing it.", pe.Sender.Login)
+			return nil
+		}
+	}
 
 	// If we don't have the lgtm label, we don't need to check anything
 	labels, err := gc.GetIssueLabels(org, repo, number)
@@ -432,9 +468,9 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 		return nil
 	}
 
-	if opts.StoreTreeHash {
+	if opts.StoreTreeHash || opts.StoreDiffHash {
 		// Check if we have a tree-hash comment
-		var lastLgtmTreeHash string
+		var lastLgtmTreeHash, lastLgtmDiffHash string
 		botUserChecker, err := gc.BotUserChecker()
 		if err != nil {
 			return err
@@ -450,10 +486,13 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 			m := addLGTMLabelNotificationRe.FindStringSubmatch(comment.Body)
 			if botUserChecker(comment.User.Login) && m != nil && comment.UpdatedAt.Equal(comment.CreatedAt) {
 				lastLgtmTreeHash = m[1]
+				if d := diffHashNotificationRe.FindStringSubmatch(comment.Body); d != nil {
+					lastLgtmDiffHash = d[1]
+				}
 				break
 			}
 		}
-		if lastLgtmTreeHash != "" {
+		if opts.StoreTreeHash && lastLgtmTreeHash != "" {
 			// Get the current tree-hash
 			commit, err := gc.GetSingleCommit(org, repo, pe.PullRequest.Head.SHA)
 			if err != nil {
@@ -466,9 +505,19 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 				return nil
 			}
 		}
+		if opts.StoreDiffHash && lastLgtmDiffHash != "" {
+			changes, err := gc.GetPullRequestChanges(org, repo, number)
+			if err != nil {
+				log.WithError(err).Error("Failed to get pull request changes.")
+			} else if hash := diffHash(changes); hash == lastLgtmDiffHash {
+				// Don't remove the label, the push was a rebase or an empty force push
+				log.Infof("Keeping LGTM label as the diff-hash remained the same: %s", hash)
+				return nil
+			}
+		}
 	}
 
-	if err := removeLGTMAndRequestReview(gc, org, repo, number, getLogins(pe.PullRequest.Assignees), opts.StoreTreeHash); err != nil {
+	if err := removeLGTMAndRequestReview(gc, org, repo, number, getLogins(pe.PullRequest.Assignees), opts.StoreTreeHash || opts.StoreDiffHash); err != nil {
 		return fmt.Errorf("failed removing lgtm label: %w", err)
 	}
 
@@ -478,6 +527,34 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 	return gc.CreateComment(org, repo, number, removeLGTMLabelNoti)
 }
 
+// diffHash identifies the changes of a pull request regardless of the commits
+// they were pushed in and of the base they were rebased on, like git patch-id.
+// The changed lines and the context lines around them are hashed, so moving a
+// change within a file changes the hash, but the hunk headers aren't, since
+// their line numbers change when the base changes.
+func diffHash(changes []github.PullRequestChange) string {
+	sorted := make([]github.PullRequestChange, len(changes))
+	copy(sorted, changes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Filename < sorted[j].Filename
+	})
+	h := sha256.New()
+	for _, change := range sorted {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", change.Filename, change.PreviousFilename, change.Status)
+		if change.Patch == "" {
+			// GitHub omits the patch of binary and large files.
+			fmt.Fprintf(h, "%s\x00", change.SHA)
+			continue
+		}
+		for _, line := range strings.Split(change.Patch, "\n") {
+			if !strings.HasPrefix(line, "@@") {
+				fmt.Fprintf(h, "%s\n", line)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func removeLGTMAndRequestReview(gc githubClient, org, repo string, number int, logins []string, storeTreeHash bool) error {
 	if err := gc.RemoveLabel(org, repo, number, LGTMLabel); err != nil {
 		return fmt.Errorf("failed removing lgtm label: %w", err)
@@ -1215,12 +1215,182 @@ func TestHandlePullRequest(t *testing.T) {
 	}
 }
 
-func TestAddTreeHashComment(t *testing.T) {
+func TestHandlePullRequestDiffHash(t *testing.T) {
+	SHA := "0bd3ed50c88cd53a09316bf7a298f900e9371652"
+	treeSHA := "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	approved := []github.PullRequestChange{
+		{Filename: "main.go", Status: "modified", Patch: "@@ -1,3 +1,3 @@\n package main\n-var a = 1\n+var a = 2"},
+	}
+	rebased := []github.PullRequestChange{
+		{Filename: "main.go", Status: "modified", Patch: "@@ -10,3 +10,3 @@\n package main\n-var a = 1\n+var a = 2"},
+	}
+	changed := []github.PullRequestChange{
+		{Filename: "main.go", Status: "modified", Patch: "@@ -1,3 +1,3 @@\n package main\n-var a = 1\n+var a = 3"},
+	}
+	notification := fmt.Sprintf(addLGTMLabelNotification, "old-tree") + fmt.Sprintf(diffHashNotification, diffHash(approved))
 	cases := []struct {
 		name          string
-		author        string
-		trustedTeam   string
-		expectTreeSha bool
+		sender        string
+		changes       []github.PullRequestChange
+		notification  string
+		trustedUsers  []string
+		expectRemoved bool
+	}{
+		{
+			name:         "rebase keeps the label",
+			sender:       "author",
+			changes:      rebased,
+			notification: notification,
+		},
+		{
+			name:          "changed diff removes the label",
+			sender:        "author",
+			changes:       changed,
+			notification:  notification,
+			expectRemoved: true,
+		},
+		{
+			name:          "notification without a diff hash removes the label",
+			sender:        "author",
+			changes:       rebased,
+			notification:  fmt.Sprintf(addLGTMLabelNotification, "old-tree"),
+			expectRemoved: true,
+		},
+		{
+			name:         "push by a trusted user keeps the label",
+			sender:       "Rebase-Bot",
+			changes:      changed,
+			notification: notification,
+			trustedUsers: []string{"rebase-bot"},
+		},
+		{
+			name:          "push by another user removes the label",
+			sender:        "author",
+			changes:       changed,
+			notification:  notification,
+			trustedUsers:  []string{"rebase-bot"},
+			expectRemoved: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fakeGitHub := fakegithub.NewFakeClient()
+			fakeGitHub.IssueComments = map[int][]github.IssueComment{
+				101: {
+					{
+						Body: c.notification,
+						User: github.User{Login: fakegithub.Bot},
+					},
+				},
+			}
+			fakeGitHub.IssueLabelsAdded = []string{"kubernetes/kubernetes#101:lgtm"}
+			fakeGitHub.PullRequestChanges = map[int][]github.PullRequestChange{101: c.changes}
+			commit := github.RepositoryCommit{}
+			commit.Commit.Tree.SHA = treeSHA
+			fakeGitHub.Commits = map[string]github.RepositoryCommit{SHA: commit}
+			pc := &plugins.Configuration{}
+			pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
+				Repos:         []string{"kubernetes/kubernetes"},
+				StoreTreeHash: true,
+				StoreDiffHash: true,
+				TrustedUsers:  c.trustedUsers,
+			})
+			event := github.PullRequestEvent{
+				Action: github.PullRequestActionSynchronize,
+				Sender: github.User{Login: c.sender},
+				PullRequest: github.PullRequest{
+					Number: 101,
+					User:   github.User{Login: "author"},
+					Base: github.PullRequestBranch{
+						Repo: github.Repo{
+							Owner: github.User{Login: "kubernetes"},
+							Name:  "kubernetes",
+						},
+					},
+					Head: github.PullRequestBranch{SHA: SHA},
+				},
+			}
+			if err := handlePullRequest(logrus.WithField("plugin", PluginName), fakeGitHub, pc, &event); err != nil {
+				t.Fatalf("handlePullRequest error: %v", err)
+			}
+			if removed := len(fakeGitHub.IssueLabelsRemoved) > 0; removed != c.expectRemoved {
+				t.Errorf("expected the label to be removed: %t, got labels removed: %v", c.expectRemoved, fakeGitHub.IssueLabelsRemoved)
+			}
+		})
+	}
+}
+
+func TestDiffHash(t *testing.T) {
+	base := []github.PullRequestChange{
+		{Filename: "a.go", Status: "modified", Patch: "@@ -1,2 +1,2 @@\n context\n-old\n+new"},
+		{Filename: "logo.png", Status: "added", SHA: "abc"},
+	}
+	cases := []struct {
+		name    string
+		changes []github.PullRequestChange
+		same    bool
+	}{
+		{
+			name: "different order and hunk positions",
+			changes: []github.PullRequestChange{
+				{Filename: "logo.png", Status: "added", SHA: "abc"},
+				{Filename: "a.go", Status: "modified", Patch: "@@ -7,2 +7,2 @@\n context\n-old\n+new"},
+			},
+			same: true,
+		},
+		{
+			name: "different context",
+			changes: []github.PullRequestChange{
+				{Filename: "a.go", Status: "modified", Patch: "@@ -7,2 +7,2 @@\n other context\n-old\n+new"},
+				{Filename: "logo.png", Status: "added", SHA: "abc"},
+			},
+		},
+		{
+			name: "moved added line",
+			changes: []github.PullRequestChange{
+				{Filename: "a.go", Status: "modified", Patch: "@@ -1,2 +1,2 @@\n-old\n+new\n context"},
+				{Filename: "logo.png", Status: "added", SHA: "abc"},
+			},
+		},
+		{
+			name: "different changed line",
+			changes: []github.PullRequestChange{
+				{Filename: "a.go", Status: "modified", Patch: "@@ -1,2 +1,2 @@\n context\n-old\n+newer"},
+				{Filename: "logo.png", Status: "added", SHA: "abc"},
+			},
+		},
+		{
+			name: "different binary file",
+			changes: []github.PullRequestChange{
+				{Filename: "a.go", Status: "modified", Patch: "@@ -1,2 +1,2 @@\n context\n-old\n+new"},
+				{Filename: "logo.png", Status: "added", SHA: "def"},
+			},
+		},
+		{
+			name: "renamed file",
+			changes: []github.PullRequestChange{
+				{Filename: "b.go", PreviousFilename: "a.go", Status: "renamed", Patch: "@@ -1,2 +1,2 @@\n context\n-old\n+new"},
+				{Filename: "logo.png", Status: "added", SHA: "abc"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if same := diffHash(base) == diffHash(c.changes); same != c.same {
+				t.Errorf("expected the same hash: %t, got %t", c.same, same)
+			}
+		})
+	}
+}
+
+func TestAddTreeHashComment(t *testing.T) {
+	cases := []struct {
+		name           string
+		author         string
+		trustedTeam    string
+		storeDiffHash  bool
+		expectTreeSha  bool
+		expectDiffHash bool
 	}{
 		{
 			name:          "Tree SHA added",
@@ -1232,6 +1402,13 @@ func TestAddTreeHashComment(t *testing.T) {
 			author:        "sig-lead",
 			expectTreeSha: true,
 		},
+		{
+			name:           "Diff hash added",
+			author:         "Bob",
+			storeDiffHash:  true,
+			expectTreeSha:  true,
+			expectDiffHash: true,
+		},
 		{
 			name:          "No Tree SHA if sticky lgtm",
 			author:        "sig-lead",
@@ -1249,6 +1426,7 @@ func TestAddTreeHashComment(t *testing.T) {
 			pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 				Repos:          []string{"kubernetes/kubernetes"},
 				StoreTreeHash:  true,
+				StoreDiffHash:  c.storeDiffHash,
 				StickyLgtmTeam: c.trustedTeam,
 			})
 			rc := reviewCtx{
@@ -1277,17 +1455,27 @@ func TestAddTreeHashComment(t *testing.T) {
 				},
 			}
 			fc.Collaborators = []string{"collab1", "collab2"}
+			fc.PullRequestChanges = map[int][]github.PullRequestChange{
+				101: {{Filename: "main.go", Status: "modified", Patch: "@@ -1 +1 @@\n-a\n+b"}},
+			}
 			commit := github.RepositoryCommit{}
 			commit.Commit.Tree.SHA = treeSHA
 			fc.Commits[SHA] = commit
 			handle(true, pc, &fakeOwnersClient{}, rc, fc, logrus.WithField("plugin", PluginName), &fakePruner{})
-			found := false
+			found, foundDiffHash := false, false
 			for _, body := range fc.IssueCommentsAdded {
-				if addLGTMLabelNotificationRe.MatchString(body) {
+				if m := addLGTMLabelNotificationRe.FindStringSubmatch(body); m != nil {
 					found = true
+					if m[1] != treeSHA {
+						t.Errorf("expected tree hash %s, got %s", treeSHA, m[1])
+					}
+					foundDiffHash = diffHashNotificationRe.MatchString(body)
 					break
 				}
 			}
+			if foundDiffHash != c.expectDiffHash {
+				t.Errorf("expected a diff hash: %t, got %t", c.expectDiffHash, foundDiffHash)
+			}
 			if c.expectTreeSha {
 				if !found {
 					t.Fatalf("expected tree_hash comment but got none")
@@ -1397,6 +1585,21 @@ func TestHelpProvider(t *testing.T) {
 			enabledRepos:       enabledRepos,
 			configInfoIncludes: []string{configInfoReviewActsAsLgtm, configInfoStoreTreeHash, configInfoStickyLgtmTeam("team1")},
 		},
+		{
+			name: "StoreDiffHash and TrustedUsers enabled",
+			config: &plugins.Configuration{
+				Lgtm: []plugins.Lgtm{
+					{
+						Repos:         []string{"org2/repo"},
+						StoreDiffHash: true,
+						TrustedUsers:  []string{"bot1", "bot2"},
+					},
+				},
+			},
+			enabledRepos:       enabledRepos,
+			configInfoExcludes: []string{configInfoStoreTreeHash},
+			configInfoIncludes: []string{configInfoStoreDiffHash, configInfoTrustedUsers([]string{"bot1", "bot2"})},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// RepoOverride is the plugin config of a repo that inherits the config of
+// its org. Each section is merged into the entry listed for the org in the
+// section of the same name, and the fields it sets override those of the
+// org: maps are merged, other values, including lists, are replaced, and
+// fields set to null are unset.
+type RepoOverride struct {
+	Approve  *Approve `json:"approve,omitempty"`
+	Lgtm     *Lgtm    `json:"lgtm,omitempty"`
+	Triage   *Triage  `json:"triage,omitempty"`
+	Triggers *Trigger `json:"triggers,omitempty"`
+	Welcome  *Welcome `json:"welcome,omitempty"`
+
+	// Fields are the raw sections, to tell the fields set to their zero
+	// value, like false, from those that aren't set.
+	Fields map[string]json.RawMessage `json:"-"`
+}
+
+func (o *RepoOverride) UnmarshalJSON(b []byte) error {
+	type plain RepoOverride
+	if err := json.Unmarshal(b, (*plain)(o)); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &o.Fields)
+}
+
+func (l Lgtm) getRepos() []string {
+	return l.Repos
+}
+
+func (t Triage) getRepos() []string {
+	return t.Repos
+}
+
+func (t Trigger) getRepos() []string {
+	return t.Repos
+}
+
+// applyRepoOverrides adds the config of the repos of RepoOverrides to the
+// sections they override, merged into the config of their org.
+func (c *Configuration) applyRepoOverrides() error {
+	var errs []error
+	for _, fullName := range sets.List(sets.KeySet(c.RepoOverrides)) {
+		org, _, found := strings.Cut(fullName, "/")
+		if !found {
+			errs = append(errs, fmt.Errorf("repo_overrides: %q is not an org/repo", fullName))
+			continue
+		}
+		fields := c.RepoOverrides[fullName].Fields
+		var err error
+		if c.Approve, err = overrideSection(c.Approve, "approve", org, fullName, fields); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Lgtm, err = overrideSection(c.Lgtm, "lgtm", org, fullName, fields); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Triage, err = overrideSection(c.Triage, "triage", org, fullName, fields); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Triggers, err = overrideSection(c.Triggers, "triggers", org, fullName, fields); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Welcome, err = overrideSection(c.Welcome, "welcome", org, fullName, fields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// overrideSection appends the entry of the repo to the entries of a section,
+// made of the entry of its org with the fields of the override of the repo.
+func overrideSection[T ListableRepos](entries []T, section, org, fullName string, fields map[string]json.RawMessage) ([]T, error) {
+	var override map[string]interface{}
+	if err := json.Unmarshal(fields[section], &override); err != nil || override == nil {
+		return entries, nil
+	}
+	if _, ok := override["repos"]; ok {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: repos can't be set, the override only applies to %s", fullName, section, fullName)
+	}
+	if _, found := entryFor(entries, org, fullName); found == fullName {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: %s is already listed in %s, so it can't override the config of its org", fullName, section, fullName, section)
+	}
+	base, _ := entryFor(entries, org, "")
+	raw, err := json.Marshal(base)
+	if err != nil {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: %w", fullName, section, err)
+	}
+	merged := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: %w", fullName, section, err)
+	}
+	mergeFields(merged, override)
+	merged["repos"] = []string{fullName}
+	if raw, err = json.Marshal(merged); err != nil {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: %w", fullName, section, err)
+	}
+	var entry T
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entries, fmt.Errorf("repo_overrides.%s.%s: %w", fullName, section, err)
+	}
+	return append(entries, entry), nil
+}
+
+// mergeFields sets the fields of the override in the fields of the base,
+// merging maps and unsetting null fields.
+func mergeFields(base, override map[string]interface{}) {
+	for key, value := range override {
+		if value == nil {
+			delete(base, key)
+			continue
+		}
+		overrideMap, isMap := value.(map[string]interface{})
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		if isMap && baseIsMap {
+			mergeFields(baseMap, overrideMap)
+			continue
+		}
+		base[key] = value
+	}
+}
+
+// entryFor returns the entry of a section that applies to a repo, listed for
+// the repo itself or else for its org, and what it is listed for. Only the
+// entries of the org are looked up if fullName is empty.
+func entryFor[T ListableRepos](entries []T, org, fullName string) (T, string) {
+	for _, name := range []string{fullName, org} {
+		if name == "" {
+			continue
+		}
+		for _, entry := range entries {
+			if sets.New(entry.getRepos()...).Has(name) {
+				return entry, name
+			}
+		}
+	}
+	var zero T
+	return zero, ""
+}
+
+// EffectiveConfig is the plugin config that applies to a repo.
+type EffectiveConfig struct {
+	Plugins         []string         `json:"plugins,omitempty"`
+	ExternalPlugins []ExternalPlugin `json:"external_plugins,omitempty"`
+	Approve         *Approve         `json:"approve,omitempty"`
+	Lgtm            *Lgtm            `json:"lgtm,omitempty"`
+	Triage          *Triage          `json:"triage,omitempty"`
+	Triggers        *Trigger         `json:"triggers,omitempty"`
+	Welcome         *Welcome         `json:"welcome,omitempty"`
+	Dco             *Dco             `json:"dco,omitempty"`
+
+	// Sources tell what each of the sections that are set is listed for in
+	// the config, like the org or the repo.
+	Sources map[string]string `json:"-"`
+}
+
+// EnabledPlugins returns the plugins enabled for a repo, those of its org
+// unless the repo is excluded, and those of the repo.
+func (c *Configuration) EnabledPlugins(org, repo string) []string {
+	var plugins []string
+	if !sets.New(c.Plugins[org].ExcludedRepos...).Has(repo) {
+		plugins = append(plugins, c.Plugins[org].Plugins...)
+	}
+	return append(plugins, c.Plugins[org+"/"+repo].Plugins...)
+}
+
+// EffectiveConfigFor returns the plugin config that applies to a repo, with
+// its repo overrides applied, for the sections that are configured for it.
+func (c *Configuration) EffectiveConfigFor(org, repo string) EffectiveConfig {
+	fullName := org + "/" + repo
+	effective := EffectiveConfig{
+		Plugins:         c.EnabledPlugins(org, repo),
+		ExternalPlugins: append(append([]ExternalPlugin(nil), c.ExternalPlugins[org]...), c.ExternalPlugins[fullName]...),
+		Sources:         map[string]string{},
+	}
+	source := func(section, listedFor string) {
+		if _, overridden := c.RepoOverrides[fullName].Fields[section]; overridden && listedFor == fullName {
+			listedFor = fmt.Sprintf("%s, with repo_overrides.%s", org, fullName)
+		}
+		effective.Sources[section] = listedFor
+	}
+	if _, listedFor := entryFor(c.Approve, org, fullName); listedFor != "" {
+		effective.Approve = c.ApproveFor(org, repo)
+		source("approve", listedFor)
+	}
+	if _, listedFor := entryFor(c.Lgtm, org, fullName); listedFor != "" {
+		effective.Lgtm = c.LgtmFor(org, repo)
+		source("lgtm", listedFor)
+	}
+	if _, listedFor := entryFor(c.Triage, org, fullName); listedFor != "" {
+		effective.Triage = c.TriageFor(org, repo)
+		source("triage", listedFor)
+	}
+	if _, listedFor := entryFor(c.Triggers, org, fullName); listedFor != "" {
+		trigger := c.TriggerFor(org, repo)
+		effective.Triggers = &trigger
+		source("triggers", listedFor)
+	}
+	if welcome, listedFor := entryFor(c.Welcome, org, fullName); listedFor != "" {
+		effective.Welcome = &welcome
+		source("welcome", listedFor)
+	}
+	for _, name := range []string{fullName, org, "*"} {
+		if c.Dco[name] != nil {
+			effective.Dco = c.Dco[name]
+			effective.Sources["dco"] = name
+			break
+		}
+	}
+	return effective
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplyRepoOverrides(t *testing.T) {
+	testCases := []struct {
+		name             string
+		config           string
+		expectedApprove  []Approve
+		expectedLgtm     []Lgtm
+		expectedTriggers []Trigger
+		expectedErr      string
+	}{
+		{
+			name: "repo inherits the config of its org",
+			config: `
+approve:
+- repos: [org]
+  lgtm_acts_as_approve: true
+  require_self_approval: true
+  commandHelpLink: https://help
+repo_overrides:
+  org/repo:
+    approve:
+      require_self_approval: false
+      pr_process_link: https://process
+`,
+			expectedApprove: []Approve{
+				{Repos: []string{"org"}, LgtmActsAsApprove: true, RequireSelfApproval: ptr.To(true), CommandHelpLink: "https://help"},
+				{Repos: []string{"org/repo"}, LgtmActsAsApprove: true, RequireSelfApproval: ptr.To(false), CommandHelpLink: "https://help", PrProcessLink: "https://process"},
+			},
+		},
+		{
+			name: "null unsets a field and false overrides true",
+			config: `
+lgtm:
+- repos: [org]
+  review_acts_as_lgtm: true
+  trusted_team_for_sticky_lgtm: team
+repo_overrides:
+  org/repo:
+    lgtm:
+      review_acts_as_lgtm: false
+      trusted_team_for_sticky_lgtm: null
+`,
+			expectedLgtm: []Lgtm{
+				{Repos: []string{"org"}, ReviewActsAsLgtm: true, StickyLgtmTeam: "team"},
+				{Repos: []string{"org/repo"}},
+			},
+		},
+		{
+			name: "lists are replaced and maps are merged",
+			config: `
+triggers:
+- repos: [org]
+  trusted_apps: [a, b]
+  trust_policy:
+    dry_run: true
+    rules:
+    - org_member: org
+repo_overrides:
+  org/repo:
+    triggers:
+      trusted_apps: [c]
+      trust_policy:
+        dry_run: false
+`,
+			expectedTriggers: []Trigger{
+				{Repos: []string{"org"}, TrustedApps: []string{"a", "b"}, TrustPolicy: &TrustPolicy{DryRun: true, Rules: []TrustRule{{OrgMember: "org"}}}},
+				{Repos: []string{"org/repo"}, TrustedApps: []string{"c"}, TrustPolicy: &TrustPolicy{Rules: []TrustRule{{OrgMember: "org"}}}},
+			},
+		},
+		{
+			name: "org without config",
+			config: `
+repo_overrides:
+  org/repo:
+    lgtm:
+      review_acts_as_lgtm: true
+`,
+			expectedLgtm: []Lgtm{{Repos: []string{"org/repo"}, ReviewActsAsLgtm: true}},
+		},
+		{
+			name: "repos can't be overridden",
+			config: `
+repo_overrides:
+  org/repo:
+    lgtm:
+      repos: [org/other]
+`,
+			expectedErr: "repos can't be set",
+		},
+		{
+			name: "repo already listed in the section",
+			config: `
+lgtm:
+- repos: [org/repo]
+repo_overrides:
+  org/repo:
+    lgtm:
+      review_acts_as_lgtm: true
+`,
+			expectedErr: "org/repo is already listed in lgtm",
+		},
+		{
+			name: "key isn't a repo",
+			config: `
+repo_overrides:
+  org:
+    lgtm:
+      review_acts_as_lgtm: true
+`,
+			expectedErr: `"org" is not an org/repo`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c Configuration
+			if err := yaml.Unmarshal([]byte(tc.config), &c); err != nil {
+				t.Fatalf("failed to unmarshal the config: %v", err)
+			}
+			err := c.applyRepoOverrides()
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedApprove, c.Approve); diff != "" {
+				t.Errorf("unexpected approve config (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedLgtm, c.Lgtm); diff != "" {
+				t.Errorf("unexpected lgtm config (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedTriggers, c.Triggers); diff != "" {
+				t.Errorf("unexpected triggers config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEffectiveConfigFor(t *testing.T) {
+	const config = `
+plugins:
+  org:
+    excluded_repos: [excluded]
+    plugins: [approve, lgtm]
+  org/repo:
+    plugins: [dco]
+  org/excluded:
+    plugins: [dco]
+external_plugins:
+  org:
+  - name: refresh
+    endpoint: http://refresh
+  org/repo:
+  - name: needs-rebase
+    endpoint: http://needs-rebase
+approve:
+- repos: [org]
+  lgtm_acts_as_approve: true
+lgtm:
+- repos: [org]
+  review_acts_as_lgtm: true
+- repos: [org/lgtm]
+dco:
+  '*':
+    skip_dco_check_for_members: true
+  org/repo:
+    trusted_apps: [bot]
+repo_overrides:
+  org/repo:
+    approve:
+      lgtm_acts_as_approve: false
+`
+	var c Configuration
+	if err := yaml.Unmarshal([]byte(config), &c); err != nil {
+		t.Fatalf("failed to unmarshal the config: %v", err)
+	}
+	if err := c.applyRepoOverrides(); err != nil {
+		t.Fatalf("failed to apply the repo overrides: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		org      string
+		repo     string
+		expected EffectiveConfig
+	}{
+		{
+			name: "repo with overrides",
+			org:  "org",
+			repo: "repo",
+			expected: EffectiveConfig{
+				Plugins: []string{"approve", "lgtm", "dco"},
+				ExternalPlugins: []ExternalPlugin{
+					{Name: "refresh", Endpoint: "http://refresh"},
+					{Name: "needs-rebase", Endpoint: "http://needs-rebase"},
+				},
+				Approve: &Approve{Repos: []string{"org/repo"}, CommandHelpLink: "https://go.k8s.io/bot-commands", PrProcessLink: "https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process"},
+				Lgtm:    &Lgtm{Repos: []string{"org"}, ReviewActsAsLgtm: true},
+				Dco:     &Dco{TrustedApps: []string{"bot"}},
+				Sources: map[string]string{
+					"approve": "org, with repo_overrides.org/repo",
+					"lgtm":    "org",
+					"dco":     "org/repo",
+				},
+			},
+		},
+		{
+			name: "repo with its own entry and excluded from the plugins of its org",
+			org:  "org",
+			repo: "excluded",
+			expected: EffectiveConfig{
+				Plugins:         []string{"dco"},
+				ExternalPlugins: []ExternalPlugin{{Name: "refresh", Endpoint: "http://refresh"}},
+				Approve:         &Approve{Repos: []string{"org"}, LgtmActsAsApprove: true, CommandHelpLink: "https://go.k8s.io/bot-commands", PrProcessLink: "https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process"},
+				Lgtm:            &Lgtm{Repos: []string{"org"}, ReviewActsAsLgtm: true},
+				Dco:             &Dco{SkipDCOCheckForMembers: true},
+				Sources: map[string]string{
+					"approve": "org",
+					"lgtm":    "org",
+					"dco":     "*",
+				},
+			},
+		},
+		{
+			name: "repo listed in a section",
+			org:  "org",
+			repo: "lgtm",
+			expected: EffectiveConfig{
+				Plugins:         []string{"approve", "lgtm"},
+				ExternalPlugins: []ExternalPlugin{{Name: "refresh", Endpoint: "http://refresh"}},
+				Approve:         &Approve{Repos: []string{"org"}, LgtmActsAsApprove: true, CommandHelpLink: "https://go.k8s.io/bot-commands", PrProcessLink: "https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process"},
+				Lgtm:            &Lgtm{Repos: []string{"org/lgtm"}},
+				Dco:             &Dco{SkipDCOCheckForMembers: true},
+				Sources: map[string]string{
+					"approve": "org",
+					"lgtm":    "org/lgtm",
+					"dco":     "*",
+				},
+			},
+		},
+		{
+			name: "unknown org",
+			org:  "other",
+			repo: "repo",
+			expected: EffectiveConfig{
+				Dco:     &Dco{SkipDCOCheckForMembers: true},
+				Sources: map[string]string{"dco": "*"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := c.EffectiveConfigFor(tc.org, tc.repo)
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected effective config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -28,8 +28,6 @@ import (
 	"sync"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/sets"
-
 	"sigs.k8s.io/prow/pkg/genyaml"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -62,6 +60,7 @@ var (
 	reviewEventHandlers        = map[string]ReviewEventHandler{}
 	reviewCommentEventHandlers = map[string]ReviewCommentEventHandler{}
 	statusEventHandlers        = map[string]StatusEventHandler{}
+	checkRunEventHandlers      = map[string]CheckRunEventHandler{}
 	// CommentMap is used by many plugins for printing help messages defined in
 	// config.go.
 	CommentMap, _ = genyaml.NewCommentMap(nil)
@@ -131,6 +130,15 @@ func RegisterStatusEventHandler(name string, fn StatusEventHandler, help HelpPro
 	statusEventHandlers[name] = fn
 }
 
+// CheckRunEventHandler defines the function contract for a github.CheckRunEvent handler.
+type CheckRunEventHandler func(Agent, github.CheckRunEvent) error
+
+// RegisterCheckRunEventHandler registers a plugin's github.CheckRunEvent handler.
+func RegisterCheckRunEventHandler(name string, fn CheckRunEventHandler, help HelpProvider) {
+	pluginHelp[name] = help
+	checkRunEventHandlers[name] = fn
+}
+
 // PushEventHandler defines the function contract for a github.PushEvent handler.
 type PushEventHandler func(Agent, github.PushEvent) error
 
@@ -181,6 +189,7 @@ type Agent struct {
 	GitClient                 git.ClientFactory
 	SlackClient               *slack.Client
 	BugzillaClient            bugzilla.Client
+	BugzillaClients           map[string]bugzilla.Client
 	JiraClient                jira.Client
 
 	OwnersClient repoowners.Interface
@@ -210,6 +219,10 @@ func NewAgent(configAgent *config.Agent, pluginConfigAgent *ConfigAgent, clientA
 	if jiraClient != nil {
 		jiraClient = clientAgent.JiraClient.WithFields(logger.Data).ForPlugin(plugin)
 	}
+	bugzillaClients := make(map[string]bugzilla.Client, len(clientAgent.BugzillaClients))
+	for name, client := range clientAgent.BugzillaClients {
+		bugzillaClients[name] = client.WithFields(logger.Data).ForPlugin(plugin)
+	}
 	return Agent{
 		GitHubClient:              gitHubClient,
 		KubernetesClient:          clientAgent.KubernetesClient,
@@ -219,6 +232,7 @@ func NewAgent(configAgent *config.Agent, pluginConfigAgent *ConfigAgent, clientA
 		SlackClient:               clientAgent.SlackClient,
 		OwnersClient:              clientAgent.OwnersClient.WithFields(logger.Data).WithGitHubClient(gitHubClient).ForPlugin(plugin),
 		BugzillaClient:            clientAgent.BugzillaClient.WithFields(logger.Data).ForPlugin(plugin),
+		BugzillaClients:           bugzillaClients,
 		JiraClient:                jiraClient,
 		Metrics:                   metrics,
 		Config:                    prowConfig,
@@ -242,7 +256,11 @@ func (a *Agent) TookAction() bool {
 	if a.JiraClient != nil {
 		jiraClientTookAction = a.JiraClient.Used()
 	}
-	return a.GitHubClient.Used() || a.OwnersClient.Used() || a.BugzillaClient.Used() || jiraClientTookAction
+	bugzillaClientsTookAction := false
+	for _, client := range a.BugzillaClients {
+		bugzillaClientsTookAction = bugzillaClientsTookAction || client.Used()
+	}
+	return a.GitHubClient.Used() || a.OwnersClient.Used() || a.BugzillaClient.Used() || bugzillaClientsTookAction || jiraClientTookAction
 }
 
 // CommentPruner will return the commentpruner.EventClient attached to the agent or an error
@@ -264,6 +282,7 @@ type ClientAgent struct {
 	SlackClient               *slack.Client
 	OwnersClient              repoowners.Interface
 	BugzillaClient            bugzilla.Client
+	BugzillaClients           map[string]bugzilla.Client
 	JiraClient                jira.Client
 }
 
@@ -343,6 +362,9 @@ func (pa *ConfigAgent) Load(path string, supplementalPluginConfigDirs []string,
 		return err
 	}
 
+	if err := np.applyRepoOverrides(); err != nil {
+		return err
+	}
 	if err := np.Validate(); err != nil {
 		return err
 	}
@@ -500,6 +522,21 @@ func (pa *ConfigAgent) StatusEventHandlers(owner, repo string) map[string]Status
 	return hs
 }
 
+// CheckRunEventHandlers returns a map of plugin names to handlers for the repo.
+func (pa *ConfigAgent) CheckRunEventHandlers(owner, repo string) map[string]CheckRunEventHandler {
+	pa.mut.Lock()
+	defer pa.mut.Unlock()
+
+	hs := map[string]CheckRunEventHandler{}
+	for _, p := range pa.getPlugins(owner, repo) {
+		if h, ok := checkRunEventHandlers[p]; ok {
+			hs[p] = h
+		}
+	}
+
+	return hs
+}
+
 // PushEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEventHandler {
 	pa.mut.Lock()
@@ -517,15 +554,7 @@ func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEven
 
 // getPlugins returns a list of plugins that are enabled on a given (org, repository).
 func (pa *ConfigAgent) getPlugins(owner, repo string) []string {
-	var plugins []string
-
-	fullName := fmt.Sprintf("%s/%s", owner, repo)
-	if !sets.NewString(pa.configuration.Plugins[owner].ExcludedRepos...).Has(repo) {
-		plugins = append(plugins, pa.configuration.Plugins[owner].Plugins...)
-	}
-	plugins = append(plugins, pa.configuration.Plugins[fullName].Plugins...)
-
-	return plugins
+	return pa.configuration.EnabledPlugins(owner, repo)
 }
 
 // EventsForPlugin returns the registered events for the passed plugin.
@@ -552,6 +581,9 @@ func EventsForPlugin(name string) []string {
 	if _, ok := statusEventHandlers[name]; ok {
 		events = append(events, "status")
 	}
+	if _, ok := checkRunEventHandlers[name]; ok {
+		events = append(events, "check_run")
+	}
 	if _, ok := genericCommentHandlers[name]; ok {
 		events = append(events, "GenericCommentEvent (any event for user text)")
 	}
@@ -15,7 +15,7 @@ limitations under the License.
 */
 
 // Package skip implements the `/skip` command which allows users
-// to clean up commit statuses of non-blocking presubmits on PRs.
+// to clean up commit statuses and check runs of non-blocking presubmits on PRs.
 package skip
 
 import (
@@ -40,6 +40,8 @@ var (
 type githubClient interface {
 	CreateComment(owner, repo string, number int, comment string) error
 	CreateStatus(org, repo, ref string, s github.Status) error
+	CreateCheckRun(org, repo string, checkRun github.CheckRun) (int64, error)
+	ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error)
 	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
 	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
@@ -109,7 +111,20 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 		log.Warn(resp)
 		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
 	}
-	if combinedStatus.State == github.StatusSuccess {
+	var checkRuns []github.CheckRun
+	for _, job := range presubmits {
+		if reportsCheckRun(job) {
+			list, err := gc.ListCheckRuns(org, repo, pr.Head.SHA)
+			if err != nil {
+				resp := fmt.Sprintf("Cannot get check runs for PR #%d in %s/%s: %v", number, org, repo, err)
+				log.Warn(resp)
+				return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+			}
+			checkRuns = list.CheckRuns
+			break
+		}
+	}
+	if combinedStatus.State == github.StatusSuccess && checkRuns == nil {
 		return nil
 	}
 	statuses := combinedStatus.Statuses
@@ -131,7 +146,12 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 
 	for _, job := range presubmits {
 		// Only consider jobs that have already posted a failed status
-		if !statusExists(job, statuses) || isSuccess(job, statuses) {
+		// or check run
+		if reportsCheckRun(job) {
+			if !checkRunFailed(job, checkRuns) {
+				continue
+			}
+		} else if !statusExists(job, statuses) || isSuccess(job, statuses) {
 			continue
 		}
 		// Ignore jobs that will be handled by the trigger plugin
@@ -148,6 +168,21 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 			continue
 		}
 		context := job.Context
+		if reportsCheckRun(job) {
+			checkRun := github.CheckRun{
+				Name:       context,
+				HeadSHA:    pr.Head.SHA,
+				Status:     github.CheckRunCompleted,
+				Conclusion: github.CheckRunConclusionSkipped,
+				Output:     github.CheckRunOutput{Title: "Skipped", Summary: "The job is not required and was skipped with /skip."},
+			}
+			if _, err := gc.CreateCheckRun(org, repo, checkRun); err != nil {
+				resp := fmt.Sprintf("Cannot create skipped check run %s: %v", context, err)
+				log.Warn(resp)
+				return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+			}
+			continue
+		}
 		status := github.Status{
 			State:       github.StatusSuccess,
 			Description: "Skipped",
@@ -171,6 +206,29 @@ func statusExists(job config.Presubmit, statuses []github.Status) bool {
 	return false
 }
 
+func reportsCheckRun(job config.Presubmit) bool {
+	return job.ReporterConfig != nil && job.ReporterConfig.GitHub.ReportsCheckRun()
+}
+
+// checkRunFailed returns whether the latest check run of the job concluded
+// with a conclusion that blocks merging.
+func checkRunFailed(job config.Presubmit, checkRuns []github.CheckRun) bool {
+	var latest *github.CheckRun
+	for i := range checkRuns {
+		if checkRuns[i].Name == job.Context && (latest == nil || checkRuns[i].ID > latest.ID) {
+			latest = &checkRuns[i]
+		}
+	}
+	if latest == nil || latest.Status != github.CheckRunCompleted {
+		return false
+	}
+	switch latest.Conclusion {
+	case github.CheckRunConclusionSuccess, github.CheckRunConclusionNeutral, github.CheckRunConclusionSkipped:
+		return false
+	}
+	return true
+}
+
 func isSuccess(job config.Presubmit, statuses []github.Status) bool {
 	for _, status := range statuses {
 		if status.Context == job.Context && status.State == github.StatusSuccess {
@@ -20,8 +20,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/github/fakegithub"
@@ -351,3 +353,68 @@ func TestSkipStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestSkipCheckRun(t *testing.T) {
+	checkRunJob := func(context string, optional bool) config.Presubmit {
+		return config.Presubmit{
+			JobBase: config.JobBase{
+				ReporterConfig: &prowapi.ReporterConfig{GitHub: &prowapi.GitHubReporterConfig{CheckRun: true}},
+			},
+			Optional: optional,
+			Reporter: config.Reporter{Context: context},
+		}
+	}
+	presubmits := []config.Presubmit{
+		checkRunJob("failed-optional", true),
+		checkRunJob("retried-optional", true),
+		checkRunJob("neutral-optional", true),
+		checkRunJob("failed-required", false),
+	}
+	if err := config.SetPresubmitRegexes(presubmits); err != nil {
+		t.Fatalf("could not set presubmit regexes: %v", err)
+	}
+	fghc := fakegithub.NewFakeClient()
+	fghc.IssueComments = make(map[int][]github.IssueComment)
+	fghc.PullRequests = map[int]*github.PullRequest{1: {Head: github.PullRequestBranch{SHA: "sha"}}}
+	// Check runs don't show up in the combined status.
+	fghc.CombinedStatuses = map[string]*github.CombinedStatus{"sha": {State: github.StatusSuccess}}
+	fghc.CheckRuns = map[string][]github.CheckRun{"sha": {
+		{ID: 1, Name: "failed-optional", Status: github.CheckRunCompleted, Conclusion: github.CheckRunConclusionFailure},
+		{ID: 2, Name: "retried-optional", Status: github.CheckRunCompleted, Conclusion: github.CheckRunConclusionFailure},
+		{ID: 3, Name: "retried-optional", Status: github.CheckRunCompleted, Conclusion: github.CheckRunConclusionSuccess},
+		{ID: 4, Name: "neutral-optional", Status: github.CheckRunCompleted, Conclusion: github.CheckRunConclusionNeutral},
+		{ID: 5, Name: "failed-required", Status: github.CheckRunCompleted, Conclusion: github.CheckRunConclusionFailure},
+	}}
+	fghc.CheckRunID = 5
+	c := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{"org/repo": presubmits},
+		},
+	}
+	event := &github.GenericCommentEvent{
+		IsPR:       true,
+		IssueState: "open",
+		Action:     github.GenericCommentActionCreated,
+		Body:       "/skip",
+		Number:     1,
+		Repo:       github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+	}
+	if err := handle(fghc, logrus.WithField("plugin", pluginName), event, c, nil, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []github.CheckRun{{
+		ID:         6,
+		Name:       "failed-optional",
+		HeadSHA:    "sha",
+		Status:     github.CheckRunCompleted,
+		Conclusion: github.CheckRunConclusionSkipped,
+		Output:     github.CheckRunOutput{Title: "Skipped", Summary: "The job is not required and was skipped with /skip."},
+	}}
+	if diff := cmp.Diff(expected, fghc.CheckRuns["sha"][5:]); diff != "" {
+		t.Errorf("unexpected check runs (-want +got):\n%s", diff)
+	}
+	if len(fghc.CreatedStatuses["sha"]) > 0 {
+		t.Errorf("expected no statuses, got %v", fghc.CreatedStatuses["sha"])
+	}
+}
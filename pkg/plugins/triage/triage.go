@@ -0,0 +1,301 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package triage assigns new issues to the owners of the files and
+// components they mention, according to the OWNERS files of the repo.
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/pluginhelp"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const (
+	// PluginName defines this plugin's registered name.
+	PluginName = "triage"
+
+	// maxPaths bounds how many paths of an issue body are looked up, so
+	// pasted logs don't make the plugin walk the whole tree.
+	maxPaths = 20
+)
+
+// pathRe matches relative paths with at least one directory, like
+// pkg/foo/bar.go or `cmd/hook`, that aren't part of a URL.
+var pathRe = regexp.MustCompile("(?:^|[\\s`'\"(])((?:[\\w.-]+/)+[\\w.-]*)")
+
+// recentIssues is shared by all repos, so owners of several repos aren't
+// mentioned more often than any single repo allows.
+var recentIssues = newOwnerLimiter()
+
+func init() {
+	plugins.RegisterIssueHandler(PluginName, handleIssueEvent, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		triage := config.TriageFor(repo.Org, repo.Repo)
+		if triage == nil {
+			configInfo[repo.String()] = "Issues are not triaged in this repository."
+			continue
+		}
+		configInfo[repo.String()] = fmt.Sprintf("Up to %d approvers are assigned to new issues and up to %d other owners are mentioned. Owners are not triaged to more than %d issues per %s.", triage.MaxAssignees, triage.MaxCCs, triage.MaxIssuesPerOwner, triage.RateLimitPeriod)
+	}
+	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
+		Triage: []plugins.Triage{
+			{
+				Repos:             []string{"org/repo"},
+				Components:        map[string][]string{"area/hook": {"pkg/hook", "cmd/hook"}},
+				MaxAssignees:      1,
+				MaxCCs:            2,
+				MaxIssuesPerOwner: 5,
+				RateLimitPeriod:   "24h",
+			},
+		},
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf("cannot generate comments for %s plugin", PluginName)
+	}
+	return &pluginhelp.PluginHelp{
+		Description: "The triage plugin assigns new issues to the approvers of the files they mention, and of the components their labels stand for, according to the OWNERS files of the repository. Other owners are mentioned on the issue. Issues that are already assigned are left alone, and owners aren't triaged to more issues than the configured rate limit allows.",
+		Config:      configInfo,
+		Snippet:     yamlSnippet,
+	}, nil
+}
+
+type githubClient interface {
+	AssignIssue(org, repo string, number int, logins []string) error
+	CreateComment(org, repo string, number int, comment string) error
+}
+
+type ownersClient interface {
+	FindApproverOwnersForFile(path string) string
+	LeafApprovers(path string) sets.Set[string]
+	LeafReviewers(path string) sets.Set[string]
+}
+
+func handleIssueEvent(pc plugins.Agent, ie github.IssueEvent) error {
+	triage := pc.PluginConfig.TriageFor(ie.Repo.Owner.Login, ie.Repo.Name)
+	if triage == nil || ie.Issue.IsPullRequest() || len(ie.Issue.Assignees) > 0 {
+		return nil
+	}
+	if ie.Action != github.IssueActionOpened && ie.Action != github.IssueActionLabeled {
+		return nil
+	}
+	paths := referencedPaths(*triage, ie)
+	if len(paths) == 0 {
+		return nil
+	}
+	oc, err := pc.OwnersClient.LoadRepoOwners(ie.Repo.Owner.Login, ie.Repo.Name, ie.Repo.DefaultBranch)
+	if err != nil {
+		return fmt.Errorf("error loading RepoOwners: %w", err)
+	}
+	return handle(pc.GitHubClient, oc, pc.Logger, *triage, recentIssues, time.Now(), ie, paths)
+}
+
+// referencedPaths returns the paths the issue is about. New issues are about
+// the paths in their body and the components of their labels, while labeled
+// issues are only about the component of the new label, so that other
+// labels don't cause the same owners to be triaged again.
+func referencedPaths(triage plugins.Triage, ie github.IssueEvent) []string {
+	var paths []string
+	if ie.Action == github.IssueActionLabeled {
+		return append(paths, triage.Components[ie.Label.Name]...)
+	}
+	for _, label := range ie.Issue.Labels {
+		paths = append(paths, triage.Components[label.Name]...)
+	}
+	seen := sets.New[string](paths...)
+	for _, match := range pathRe.FindAllStringSubmatch(ie.Issue.Body, -1) {
+		path := strings.TrimPrefix(strings.TrimRight(match[1], ".-"), "./")
+		if path == "" || seen.Has(path) {
+			continue
+		}
+		seen.Insert(path)
+		paths = append(paths, path)
+		if len(paths) == maxPaths {
+			break
+		}
+	}
+	return paths
+}
+
+func handle(ghc githubClient, oc ownersClient, log *logrus.Entry, triage plugins.Triage, limiter *ownerLimiter, now time.Time, ie github.IssueEvent, paths []string) error {
+	org, repo, number := ie.Repo.Owner.Login, ie.Repo.Name, ie.Issue.Number
+	author := github.NormLogin(ie.Issue.User.Login)
+
+	// The top-level OWNERS don't tell who is responsible for a path, and
+	// any string that looks like a path falls back to them.
+	var ownedPaths []string
+	ownersFiles := sets.New[string]()
+	for _, path := range paths {
+		ownersFile := oc.FindApproverOwnersForFile(path)
+		if ownersFile == "" || ownersFiles.Has(ownersFile) {
+			continue
+		}
+		ownersFiles.Insert(ownersFile)
+		ownedPaths = append(ownedPaths, path)
+	}
+	if len(ownedPaths) == 0 {
+		return nil
+	}
+
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	since := now.Add(-triage.RateLimitPeriodDuration)
+	picked := sets.New[string](author)
+	pick := func(candidates sets.Set[string]) string {
+		var available []string
+		for _, login := range sets.List(candidates) {
+			login = github.NormLogin(login)
+			if !picked.Has(login) && limiter.count(login, since) < triage.MaxIssuesPerOwner {
+				available = append(available, login)
+			}
+		}
+		if len(available) == 0 {
+			return ""
+		}
+		// Spread issues over the owners by picking the least triaged.
+		sort.SliceStable(available, func(i, j int) bool {
+			return limiter.count(available[i], since) < limiter.count(available[j], since)
+		})
+		picked.Insert(available[0])
+		return available[0]
+	}
+
+	// Every path gets an owner before any path gets a second one.
+	var assignees, ccs []string
+	for len(assignees) < triage.MaxAssignees {
+		added := false
+		for _, path := range ownedPaths {
+			if len(assignees) == triage.MaxAssignees {
+				break
+			}
+			if login := pick(oc.LeafApprovers(path)); login != "" {
+				assignees = append(assignees, login)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for len(ccs) < triage.MaxCCs {
+		added := false
+		for _, path := range ownedPaths {
+			if len(ccs) == triage.MaxCCs {
+				break
+			}
+			if login := pick(oc.LeafReviewers(path).Union(oc.LeafApprovers(path))); login != "" {
+				ccs = append(ccs, login)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	if len(assignees) == 0 && len(ccs) == 0 {
+		log.WithField("paths", ownedPaths).Info("All owners of the paths have reached their triage rate limit.")
+		return nil
+	}
+
+	if len(assignees) > 0 {
+		if err := ghc.AssignIssue(org, repo, number, assignees); err != nil {
+			// Owners that can't be assigned, e.g. because they aren't
+			// collaborators, are still mentioned.
+			if _, ok := err.(github.MissingUsers); !ok {
+				return fmt.Errorf("failed to assign %v: %w", assignees, err)
+			}
+			log.WithError(err).Info("Some owners could not be assigned.")
+		}
+	}
+	for _, login := range append(assignees, ccs...) {
+		limiter.add(login, now)
+	}
+	log.WithFields(logrus.Fields{"assignees": assignees, "ccs": ccs, "paths": ownedPaths}).Info("Triaged issue.")
+	return ghc.CreateComment(org, repo, number, triageComment(ownedPaths, assignees, ccs))
+}
+
+func triageComment(paths, assignees, ccs []string) string {
+	quoted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		quoted = append(quoted, "`"+path+"`")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "This issue is about %s, according to its description and labels.", strings.Join(quoted, ", "))
+	if len(assignees) > 0 {
+		fmt.Fprintf(&b, "\n\nAssigning %s, who own these files, to triage it.", mentions(assignees))
+	}
+	if len(ccs) > 0 {
+		fmt.Fprintf(&b, "\n\ncc %s", mentions(ccs))
+	}
+	b.WriteString("\n\n<details>\n\nOwners are picked from the OWNERS files of the mentioned paths. If they aren't the right people, please reassign the issue.\n</details>")
+	return b.String()
+}
+
+func mentions(logins []string) string {
+	at := make([]string, 0, len(logins))
+	for _, login := range logins {
+		at = append(at, "@"+login)
+	}
+	return strings.Join(at, " ")
+}
+
+// ownerLimiter remembers when owners were triaged to issues.
+type ownerLimiter struct {
+	lock   sync.Mutex
+	issues map[string][]time.Time
+}
+
+func newOwnerLimiter() *ownerLimiter {
+	return &ownerLimiter{issues: map[string][]time.Time{}}
+}
+
+// count returns how often the owner was triaged since the given time. It
+// must be called with the lock held.
+func (l *ownerLimiter) count(login string, since time.Time) int {
+	var recent []time.Time
+	for _, t := range l.issues[login] {
+		if t.After(since) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(l.issues, login)
+	} else {
+		l.issues[login] = recent
+	}
+	return len(recent)
+}
+
+// add records that the owner was triaged. It must be called with the lock
+// held.
+func (l *ownerLimiter) add(login string, now time.Time) {
+	l.issues[login] = append(l.issues[login], now)
+}
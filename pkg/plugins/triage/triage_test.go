@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// fakeOwners maps directories to their approvers and reviewers. Paths that
+// aren't under any directory are owned by the root OWNERS.
+type fakeOwners struct {
+	approvers map[string][]string
+	reviewers map[string][]string
+}
+
+func (f fakeOwners) FindApproverOwnersForFile(path string) string {
+	for dir := path; dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if _, ok := f.approvers[dir]; ok {
+			return dir
+		}
+	}
+	return ""
+}
+
+func (f fakeOwners) LeafApprovers(path string) sets.Set[string] {
+	return sets.New[string](f.approvers[f.FindApproverOwnersForFile(path)]...)
+}
+
+func (f fakeOwners) LeafReviewers(path string) sets.Set[string] {
+	return sets.New[string](f.reviewers[f.FindApproverOwnersForFile(path)]...)
+}
+
+func TestReferencedPaths(t *testing.T) {
+	triage := plugins.Triage{Components: map[string][]string{"area/hook": {"pkg/hook"}, "area/tide": {"pkg/tide"}}}
+	testCases := []struct {
+		name     string
+		event    github.IssueEvent
+		expected []string
+	}{
+		{
+			name: "paths in the body and components of labels",
+			event: github.IssueEvent{
+				Action: github.IssueActionOpened,
+				Issue: github.Issue{
+					Body:   "The test in `pkg/tide/tide_test.go` flakes, see ./pkg/tide/history and pkg/hook/.\nhttps://github.com/org/repo/blob/main/pkg/other/file.go",
+					Labels: []github.Label{{Name: "area/hook"}, {Name: "kind/flake"}},
+				},
+			},
+			expected: []string{"pkg/hook", "pkg/tide/tide_test.go", "pkg/tide/history", "pkg/hook/"},
+		},
+		{
+			name: "labeled issues are only about the new label",
+			event: github.IssueEvent{
+				Action: github.IssueActionLabeled,
+				Label:  github.Label{Name: "area/tide"},
+				Issue: github.Issue{
+					Body:   "Something in pkg/hook/server.go",
+					Labels: []github.Label{{Name: "area/hook"}, {Name: "area/tide"}},
+				},
+			},
+			expected: []string{"pkg/tide"},
+		},
+		{
+			name: "words with slashes inside other words aren't paths",
+			event: github.IssueEvent{
+				Action: github.IssueActionOpened,
+				Issue:  github.Issue{Body: "It fails on and/or after retries, see https://example.com/a/b"},
+			},
+			expected: []string{"and/or"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, referencedPaths(triage, tc.event)); diff != "" {
+				t.Errorf("unexpected paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	owners := fakeOwners{
+		approvers: map[string][]string{
+			"pkg/hook": {"alice", "bob"},
+			"pkg/tide": {"carol"},
+		},
+		reviewers: map[string][]string{
+			"pkg/hook": {"dave"},
+			"pkg/tide": {"erin"},
+		},
+	}
+	testCases := []struct {
+		name              string
+		maxAssignees      int
+		maxCCs            int
+		author            string
+		paths             []string
+		recent            map[string][]time.Time
+		expectedAssignees []string
+		expectedComment   []string
+	}{
+		{
+			name:              "approver of the path is assigned",
+			maxAssignees:      1,
+			paths:             []string{"pkg/hook/server.go"},
+			expectedAssignees: []string{"alice"},
+			expectedComment:   []string{"`pkg/hook/server.go`", "Assigning @alice"},
+		},
+		{
+			name:         "root OWNERS are not triaged",
+			maxAssignees: 1,
+			paths:        []string{"and/or", "README.md"},
+		},
+		{
+			name:              "every path gets an owner first, then others are mentioned",
+			maxAssignees:      2,
+			maxCCs:            2,
+			paths:             []string{"pkg/hook/server.go", "pkg/hook/events.go", "pkg/tide/tide.go"},
+			expectedAssignees: []string{"alice", "carol"},
+			expectedComment:   []string{"`pkg/hook/server.go`, `pkg/tide/tide.go`", "Assigning @alice @carol", "cc @bob @erin"},
+		},
+		{
+			name:              "author is not triaged",
+			maxAssignees:      1,
+			author:            "Alice",
+			paths:             []string{"pkg/hook"},
+			expectedAssignees: []string{"bob"},
+			expectedComment:   []string{"Assigning @bob"},
+		},
+		{
+			name:         "least recently triaged owner is picked",
+			maxAssignees: 1,
+			paths:        []string{"pkg/hook"},
+			recent: map[string][]time.Time{
+				"alice": {now.Add(-time.Hour)},
+				"bob":   {now.Add(-48 * time.Hour), now.Add(-36 * time.Hour)},
+			},
+			expectedAssignees: []string{"bob"},
+			expectedComment:   []string{"Assigning @bob"},
+		},
+		{
+			name:         "owners over the rate limit are not triaged",
+			maxAssignees: 1,
+			maxCCs:       1,
+			paths:        []string{"pkg/tide"},
+			recent: map[string][]time.Time{
+				"carol": {now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)},
+				"erin":  {now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fghc := fakegithub.NewFakeClient()
+			limiter := newOwnerLimiter()
+			for login, times := range tc.recent {
+				limiter.issues[login] = times
+			}
+			triage := plugins.Triage{
+				MaxAssignees:            tc.maxAssignees,
+				MaxCCs:                  tc.maxCCs,
+				MaxIssuesPerOwner:       2,
+				RateLimitPeriodDuration: 24 * time.Hour,
+			}
+			event := github.IssueEvent{
+				Action: github.IssueActionOpened,
+				Repo:   github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+				Issue:  github.Issue{Number: 1, User: github.User{Login: tc.author}},
+			}
+			if err := handle(fghc, owners, logrus.WithField("plugin", PluginName), triage, limiter, now, event, tc.paths); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var assignees []string
+			for _, added := range fghc.AssigneesAdded {
+				assignees = append(assignees, strings.TrimPrefix(added, "org/repo#1:"))
+			}
+			if diff := cmp.Diff(tc.expectedAssignees, assignees); diff != "" {
+				t.Errorf("unexpected assignees (-want +got):\n%s", diff)
+			}
+			if len(tc.expectedComment) == 0 {
+				if len(fghc.IssueComments[1]) > 0 {
+					t.Errorf("expected no comment, got %q", fghc.IssueComments[1][0].Body)
+				}
+				return
+			}
+			if len(fghc.IssueComments[1]) != 1 {
+				t.Fatalf("expected one comment, got %d", len(fghc.IssueComments[1]))
+			}
+			for _, expected := range tc.expectedComment {
+				if !strings.Contains(fghc.IssueComments[1][0].Body, expected) {
+					t.Errorf("expected comment to contain %q, got %q", expected, fghc.IssueComments[1][0].Body)
+				}
+			}
+			for _, login := range tc.expectedAssignees {
+				if count := limiter.count(login, now.Add(-time.Minute)); count != 1 {
+					t.Errorf("expected %s to be counted once, got %d", login, count)
+				}
+			}
+		})
+	}
+}
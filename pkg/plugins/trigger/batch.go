@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// testPRsRe matches /test-prs <job> [all|#1 #2...|<search qualifiers>].
+var testPRsRe = regexp.MustCompile(`(?m)^/test-prs[ \t]+(\S+)[ \t]*(.*?)\s*$`)
+
+// maxTestPRs is how many PRs /test-prs tests at most, to keep a mistyped
+// query from starting the job on every PR of a large repo.
+const maxTestPRs = 100
+
+// handleTestPRs starts a presubmit on several PRs of the repo when an admin
+// of the repo comments /test-prs on an issue, like after fixing a flaky
+// required job, and replies which PRs it was started on.
+func handleTestPRs(c Client, trigger plugins.Trigger, gc github.GenericCommentEvent) error {
+	match := testPRsRe.FindStringSubmatch(gc.Body)
+	if gc.Action != github.GenericCommentActionCreated || gc.IsPR || match == nil {
+		return nil
+	}
+	org, repo, author := gc.Repo.Owner.Login, gc.Repo.Name, gc.User.Login
+	job, selector := match[1], match[2]
+	respond := func(reply string) error {
+		return c.GitHubClient.CreateComment(org, repo, gc.Number, plugins.FormatResponseRaw(gc.Body, gc.HTMLURL, author, reply))
+	}
+
+	permission, err := c.GitHubClient.GetUserPermission(org, repo, author)
+	if err != nil {
+		return fmt.Errorf("failed to get the permission of %s: %w", author, err)
+	}
+	if permission != string(github.Admin) {
+		return respond("Only admins of the repo can test several PRs at once.")
+	}
+
+	numbers, err := testPRsNumbers(c.GitHubClient, org, repo, selector)
+	if err != nil {
+		return err
+	}
+	if len(numbers) > maxTestPRs {
+		return respond(fmt.Sprintf("%d PRs match, but at most %d can be tested at once. Narrow them down with search qualifiers, like `label:lgtm`.", len(numbers), maxTestPRs))
+	}
+	if len(numbers) == 0 {
+		return respond("No open PRs match.")
+	}
+	sort.Ints(numbers)
+
+	var started, skipped []string
+	for _, number := range numbers {
+		reason, err := testPR(c, trigger, org, repo, number, job, gc.GUID)
+		if err != nil {
+			c.Logger.WithError(err).WithField("pr", number).Warnf("Failed to start %s.", job)
+			reason = "failed to start the job"
+		}
+		if reason != "" {
+			skipped = append(skipped, fmt.Sprintf("#%d (%s)", number, reason))
+		} else {
+			started = append(started, fmt.Sprintf("#%d", number))
+		}
+	}
+	reply := fmt.Sprintf("Started `%s` on %d PRs", job, len(started))
+	if len(started) > 0 {
+		reply += ": " + strings.Join(started, ", ")
+	}
+	reply += "."
+	if len(skipped) > 0 {
+		reply += fmt.Sprintf("\n\nSkipped %d PRs: %s.", len(skipped), strings.Join(skipped, ", "))
+	}
+	return respond(reply)
+}
+
+// testPRsNumbers returns the numbers of the PRs the selector of /test-prs
+// picks: PR numbers, all open PRs, or the open PRs matching GitHub search
+// qualifiers, like label:lgtm base:main.
+func testPRsNumbers(ghc githubClient, org, repo, selector string) ([]int, error) {
+	fields := strings.Fields(selector)
+	var numbers []int
+	for _, field := range fields {
+		number, err := strconv.Atoi(strings.TrimPrefix(field, "#"))
+		if err != nil {
+			numbers = nil
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	if numbers == nil {
+		query := fmt.Sprintf("repo:%s/%s type:pr state:open", org, repo)
+		if selector != "all" && selector != "" {
+			query += " " + selector
+		}
+		issues, err := ghc.FindIssues(query, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for the PRs: %w", err)
+		}
+		for _, issue := range issues {
+			numbers = append(numbers, issue.Number)
+		}
+	}
+	return numbers, nil
+}
+
+// testPR starts the job on the PR, or returns why it doesn't.
+func testPR(c Client, trigger plugins.Trigger, org, repo string, number int, job, eventGUID string) (string, error) {
+	pr, err := c.GitHubClient.GetPullRequest(org, repo, number)
+	if err != nil {
+		return "", err
+	}
+	if pr.State != github.PullRequestStateOpen {
+		return "not open", nil
+	}
+	// The PRs are tested as if the job was requested on them, so untrusted
+	// PRs still need an /ok-to-test.
+	if _, trusted, err := TrustedPullRequest(c.GitHubClient, trigger, pr.User.Login, org, repo, number, nil); err != nil {
+		return "", err
+	} else if !trusted {
+		return "not trusted", nil
+	}
+	baseSHA, err := c.GitHubClient.GetRef(org, repo, "heads/"+pr.Base.Ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to get baseSHA: %w", err)
+	}
+	presubmits := getPresubmits(c.Logger, c.GitClient, c.Config, org+"/"+repo, func() (string, error) { return baseSHA, nil }, func() (string, error) { return pr.Head.SHA, nil })
+	for _, presubmit := range presubmits {
+		if presubmit.Name == job && presubmit.CouldRun(pr.Base.Ref) {
+			return "", RunRequested(c, pr, baseSHA, []config.Presubmit{presubmit}, eventGUID)
+		}
+	}
+	return "the job doesn't run on it", nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func TestHandleTestPRs(t *testing.T) {
+	testCases := []struct {
+		name            string
+		body            string
+		commenter       string
+		isPR            bool
+		expectedStarted []int
+		expectedReply   string
+	}{
+		{
+			name:            "listed PRs",
+			body:            "/test-prs pull-foo #3 #1 2",
+			commenter:       "admin",
+			expectedStarted: []int{1},
+			expectedReply:   "Started `pull-foo` on 1 PRs: #1.\n\nSkipped 2 PRs: #2 (not trusted), #3 (not open).",
+		},
+		{
+			name:            "all PRs",
+			body:            "Flake fixed.\n/test-prs pull-foo all",
+			commenter:       "admin",
+			expectedStarted: []int{1},
+			expectedReply:   "Started `pull-foo` on 1 PRs: #1.\n\nSkipped 2 PRs: #2 (not trusted), #3 (not open).",
+		},
+		{
+			name:          "unknown job",
+			body:          "/test-prs pull-bar #1",
+			commenter:     "admin",
+			expectedReply: "Started `pull-bar` on 0 PRs.\n\nSkipped 1 PRs: #1 (the job doesn't run on it).",
+		},
+		{
+			name:          "not an admin",
+			body:          "/test-prs pull-foo all",
+			commenter:     "member",
+			expectedReply: "Only admins of the repo can test several PRs at once.",
+		},
+		{
+			name:      "comments on PRs are ignored",
+			body:      "/test-prs pull-foo all",
+			commenter: "admin",
+			isPR:      true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := fakegithub.NewFakeClient()
+			g.OrgMembers = map[string][]string{"org": {"admin", "member"}}
+			g.Permissions = map[string]string{"admin": "admin", "member": "write"}
+			pr := func(number int, author, state string) *github.PullRequest {
+				return &github.PullRequest{
+					Number: number,
+					State:  state,
+					User:   github.User{Login: author},
+					Base: github.PullRequestBranch{
+						Ref:  "master",
+						Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+					},
+					Head: github.PullRequestBranch{SHA: "head"},
+				}
+			}
+			g.PullRequests = map[int]*github.PullRequest{
+				1: pr(1, "member", github.PullRequestStateOpen),
+				2: pr(2, "stranger", github.PullRequestStateOpen),
+				3: pr(3, "member", github.PullRequestStateClosed),
+			}
+			fakeProwJobClient := fake.NewSimpleClientset()
+			c := Client{
+				GitHubClient:  g,
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("namespace"),
+				Config:        &config.Config{},
+				Logger:        logrus.WithField("plugin", PluginName),
+			}
+			if err := c.Config.SetPresubmits(map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "pull-foo"}, Reporter: config.Reporter{Context: "pull-foo"}}},
+			}); err != nil {
+				t.Fatalf("failed to set presubmits: %v", err)
+			}
+			gc := github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				IsPR:   tc.isPR,
+				Body:   tc.body,
+				Number: 100,
+				User:   github.User{Login: tc.commenter},
+				Repo:   github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+			}
+			if err := handleTestPRs(c, plugins.Trigger{}, gc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("namespace").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list prowjobs: %v", err)
+			}
+			var started []int
+			for _, pj := range pjs.Items {
+				started = append(started, pj.Spec.Refs.Pulls[0].Number)
+			}
+			sort.Ints(started)
+			if diff := cmp.Diff(tc.expectedStarted, started); diff != "" {
+				t.Errorf("unexpected PRs tested (-want +got): %s", diff)
+			}
+			var reply string
+			if comments := g.IssueComments[100]; len(comments) > 0 {
+				reply = comments[0].Body
+			}
+			if tc.expectedReply == "" {
+				if reply != "" {
+					t.Errorf("expected no reply, got %q", reply)
+				}
+			} else if !strings.Contains(reply, tc.expectedReply) {
+				t.Errorf("expected the reply to contain %q, got %q", tc.expectedReply, reply)
+			}
+		})
+	}
+}
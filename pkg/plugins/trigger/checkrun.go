@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+// handleCheckRun re-runs the job of a check run reported by crier when its
+// re-run action is requested, like Deck's rerun endpoint does. GitHub only
+// shows the action to users with write access to the repo.
+func handleCheckRun(c Client, cre github.CheckRunEvent) error {
+	if cre.Action != github.CheckRunActionRequestedAction || cre.RequestedAction == nil || cre.RequestedAction.Identifier != report.RerunCheckRunAction {
+		return nil
+	}
+	name := cre.CheckRun.ExternalID
+	if name == "" {
+		return nil
+	}
+	log := c.Logger.WithField("prowjob", name)
+	pj, err := c.ProwJobClient.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			log.Info("Not re-running the job of the check run, its ProwJob is gone.")
+			return nil
+		}
+		return fmt.Errorf("failed to get prowjob %q: %w", name, err)
+	}
+	org, repo := cre.Repo.Owner.Login, cre.Repo.Name
+	if refs := pj.Spec.Refs; refs == nil || refs.Org != org || refs.Repo != repo {
+		return fmt.Errorf("prowjob %q of check run %d is not a job of %s/%s", name, cre.CheckRun.ID, org, repo)
+	}
+
+	labels := map[string]string{}
+	for k, v := range pj.Labels {
+		labels[k] = v
+	}
+	labels[github.EventGUID] = cre.GUID
+	rerun := pjutil.NewProwJob(pj.Spec, labels, pj.Annotations, pjutil.RequireScheduling(c.Config.Scheduler.Enabled))
+	if rerun.Status.Description == "" {
+		rerun.Status.Description = fmt.Sprintf("%s reran %s from GitHub.", cre.Sender.Login, name)
+	}
+	log.WithField("user", cre.Sender.Login).WithField("new-prowjob", rerun.Name).Info("Re-running the job of the check run.")
+	if _, err := c.ProwJobClient.Create(context.TODO(), &rerun, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create the rerun of prowjob %q: %w", name, err)
+	}
+	return nil
+}
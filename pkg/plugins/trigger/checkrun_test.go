@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
+)
+
+func TestHandleCheckRun(t *testing.T) {
+	rerun := &github.CheckRunRequestedAction{Identifier: report.RerunCheckRunAction}
+	testCases := []struct {
+		name          string
+		action        github.CheckRunEventAction
+		requested     *github.CheckRunRequestedAction
+		externalID    string
+		repo          string
+		expectedRerun bool
+		expectedErr   bool
+	}{
+		{
+			name:          "re-run action reruns the job",
+			action:        github.CheckRunActionRequestedAction,
+			requested:     rerun,
+			externalID:    "job",
+			repo:          "repo",
+			expectedRerun: true,
+		},
+		{
+			name:       "other actions are ignored",
+			action:     github.CheckRunActionRequestedAction,
+			requested:  &github.CheckRunRequestedAction{Identifier: "fix"},
+			externalID: "job",
+			repo:       "repo",
+		},
+		{
+			name:       "rerequested check runs are ignored",
+			action:     github.CheckRunActionRerequested,
+			externalID: "job",
+			repo:       "repo",
+		},
+		{
+			name:       "check runs of other apps are ignored",
+			action:     github.CheckRunActionRequestedAction,
+			requested:  rerun,
+			externalID: "missing",
+			repo:       "repo",
+		},
+		{
+			name:        "jobs of other repos are not rerun",
+			action:      github.CheckRunActionRequestedAction,
+			requested:   rerun,
+			externalID:  "job",
+			repo:        "other",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "prowjobs", Labels: map[string]string{"created-by-prow": "true"}},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PresubmitJob,
+					Job:     "pull-test",
+					Context: "pull-test",
+					Refs:    &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 1, SHA: "head"}}},
+				},
+			}
+			fakeProwJobClient := fake.NewSimpleClientset(pj)
+			c := Client{
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				Config:        &config.Config{},
+				Logger:        logrus.WithField("plugin", PluginName),
+			}
+			cre := github.CheckRunEvent{
+				Action:          tc.action,
+				CheckRun:        github.CheckRun{ID: 1, ExternalID: tc.externalID},
+				RequestedAction: tc.requested,
+				Repo:            github.Repo{Owner: github.User{Login: "org"}, Name: tc.repo},
+				Sender:          github.User{Login: "writer"},
+				GUID:            "guid",
+			}
+			err := handleCheckRun(c, cre)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list prowjobs: %v", err)
+			}
+			if rerun := len(pjs.Items) == 2; rerun != tc.expectedRerun {
+				t.Fatalf("expected the job to be rerun to be %t, got %d prowjobs", tc.expectedRerun, len(pjs.Items))
+			}
+			for _, created := range pjs.Items {
+				if created.Name == "job" {
+					continue
+				}
+				if created.Spec.Job != "pull-test" || created.Labels[github.EventGUID] != "guid" {
+					t.Errorf("unexpected rerun: %+v", created)
+				}
+				if created.Status.Description != "writer reran job from GitHub." {
+					t.Errorf("unexpected description of the rerun: %q", created.Status.Description)
+				}
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +37,7 @@ import (
 	"sigs.k8s.io/prow/pkg/labels"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/testimpact"
 )
 
 const (
@@ -76,8 +78,7 @@ func handlePR(c Client, trigger plugins.Trigger, pr github.PullRequestEvent) err
 		// When a PR is opened, if the author is in the org then build it.
 		// Otherwise, ask for "/ok-to-test". There's no need to look for previous
 		// "/ok-to-test" comments since the PR was just opened!
-		trustedResponse, err := TrustedUser(c.GitHubClient, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, author, org, repo)
-		member := trustedResponse.IsTrusted
+		member, err := trustedAuthor(c.GitHubClient, trigger, author, org, repo, num)
 		if err != nil {
 			return fmt.Errorf("could not check membership: %s", err)
 		}
@@ -88,7 +89,7 @@ func handlePR(c Client, trigger plugins.Trigger, pr github.PullRequestEvent) err
 				return draftMsg(c.GitHubClient, pr.PullRequest)
 			}
 			c.Logger.Info("Starting all jobs for new PR.")
-			return buildAllButDrafts(c, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
+			return buildAllButDrafts(c, trigger, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
 		}
 		c.Logger.Infof("Welcome message to PR author %q.", author)
 		if err := welcomeMsg(c.GitHubClient, trigger, pr.PullRequest); err != nil {
@@ -132,7 +133,7 @@ func handlePR(c Client, trigger plugins.Trigger, pr github.PullRequestEvent) err
 				return fmt.Errorf("could not validate PR: %s", err)
 			} else if !trusted {
 				c.Logger.Info("Starting all jobs for untrusted PR with LGTM.")
-				return buildAllButDrafts(c, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
+				return buildAllButDrafts(c, trigger, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
 			}
 		}
 		if pr.Label.Name == labels.OkToTest {
@@ -147,7 +148,7 @@ func handlePR(c Client, trigger plugins.Trigger, pr github.PullRequestEvent) err
 				c.Logger.Debug("Label added by the bot, skipping.")
 				return nil
 			}
-			return buildAllButDrafts(c, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
+			return buildAllButDrafts(c, trigger, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
 		}
 	case github.PullRequestActionClosed:
 		if err := abortAllJobs(c, &pr.PullRequest); err != nil {
@@ -242,7 +243,7 @@ func buildAllIfTrusted(c Client, trigger plugins.Trigger, pr github.PullRequestE
 			}
 		}
 		c.Logger.Info("Starting all jobs for updated PR.")
-		return buildAllButDrafts(c, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
+		return buildAllButDrafts(c, trigger, &pr.PullRequest, pr.GUID, baseSHA, presubmits)
 	}
 	return nil
 }
@@ -326,10 +327,10 @@ func draftMsg(ghc githubClient, pr github.PullRequest) error {
 // It first checks if the author is in the org, then looks for "ok-to-test" label.
 // If already known, GitHub labels should be provided to save tokens. Otherwise, it fetches them.
 func TrustedPullRequest(tprc trustedPullRequestClient, trigger plugins.Trigger, author, org, repo string, num int, l []github.Label) ([]github.Label, bool, error) {
-	// First check if the author is a member of the org.
-	if trustedResponse, err := TrustedUser(tprc, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, author, org, repo); err != nil {
-		return l, false, fmt.Errorf("error checking %s for trust: %w", author, err)
-	} else if trustedResponse.IsTrusted {
+	// First check if the author is trusted, e.g. a member of the org.
+	if trusted, err := trustedAuthor(tprc, trigger, author, org, repo, num); err != nil {
+		return l, false, err
+	} else if trusted {
 		return l, true, nil
 	}
 	// Then check if PR has ok-to-test label
@@ -344,21 +345,80 @@ func TrustedPullRequest(tprc trustedPullRequestClient, trigger plugins.Trigger,
 }
 
 // buildAllButDrafts ensures that all builds that should run and will be required are built, but skips draft PRs
-func buildAllButDrafts(c Client, pr *github.PullRequest, eventGUID string, baseSHA string, presubmits []config.Presubmit) error {
+func buildAllButDrafts(c Client, trigger plugins.Trigger, pr *github.PullRequest, eventGUID string, baseSHA string, presubmits []config.Presubmit) error {
 	if pr.Draft {
 		c.Logger.Info("Skipping all jobs for draft PR.")
 		return nil
 	}
-	return buildAll(c, pr, eventGUID, baseSHA, presubmits)
+	return buildAll(c, trigger, pr, eventGUID, baseSHA, presubmits)
 }
 
 // buildAll ensures that all builds that should run and will be required are built
-func buildAll(c Client, pr *github.PullRequest, eventGUID string, baseSHA string, presubmits []config.Presubmit) error {
+func buildAll(c Client, trigger plugins.Trigger, pr *github.PullRequest, eventGUID string, baseSHA string, presubmits []config.Presubmit) error {
 	org, repo, number, branch := pr.Base.Repo.Owner.Login, pr.Base.Repo.Name, pr.Number, pr.Base.Ref
 	changes := config.NewGitHubDeferredChangedFilesProvider(c.GitHubClient, org, repo, number)
 	toTest, err := pjutil.FilterPresubmits(pjutil.NewTestAllFilter(), changes, branch, presubmits, c.Logger)
 	if err != nil {
 		return err
 	}
+	if trigger.TestImpactMapping != "" {
+		toTest = skipUnaffected(c, trigger.TestImpactMapping, pr, baseSHA, changes, toTest)
+	}
 	return RunRequested(c, pr, baseSHA, toTest, eventGUID)
 }
+
+// skipUnaffected leaves out the presubmits that the test impact mapping of the
+// repo at the base of the PR proves unaffected by its changes, and reports
+// those that report to GitHub as successful so that they don't block merging.
+// The mapping is read at the base so that a PR can't mark its own jobs as
+// unaffected, and changing it runs every job. If the mapping can't be read,
+// every job runs.
+func skipUnaffected(c Client, mappingFile string, pr *github.PullRequest, baseSHA string, changes config.ChangedFilesProvider, presubmits []config.Presubmit) []config.Presubmit {
+	org, repo := pr.Base.Repo.Owner.Login, pr.Base.Repo.Name
+	log := c.Logger.WithField("test-impact-mapping", mappingFile)
+	raw, err := c.GitHubClient.GetFile(org, repo, mappingFile, baseSHA)
+	if err != nil {
+		var notFound *github.FileNotFound
+		if errors.As(err, &notFound) {
+			log.Debug("The repo has no test impact mapping.")
+		} else {
+			log.WithError(err).Warn("Failed to get the test impact mapping, running all jobs.")
+		}
+		return presubmits
+	}
+	mapping, err := testimpact.Parse(raw)
+	if err != nil {
+		log.WithError(err).Warn("Invalid test impact mapping, running all jobs.")
+		return presubmits
+	}
+	changed, err := changes()
+	if err != nil {
+		log.WithError(err).Warn("Failed to get the changes of the PR, running all jobs.")
+		return presubmits
+	}
+	if slices.Contains(changed, mappingFile) {
+		return presubmits
+	}
+
+	var toRun []config.Presubmit
+	for _, presubmit := range presubmits {
+		if !mapping.Unaffected(presubmit.Name, changed) {
+			toRun = append(toRun, presubmit)
+			continue
+		}
+		log.WithField("job", presubmit.Name).Info("Skipping job not affected by the changes.")
+		if presubmit.SkipReport {
+			continue
+		}
+		if err := c.GitHubClient.CreateStatus(org, repo, pr.Head.SHA, github.Status{
+			State:       github.StatusSuccess,
+			Context:     presubmit.Context,
+			Description: "Skipped: not affected by the changes.",
+		}); err != nil {
+			// The job has to run for its status to be reported.
+			log.WithError(err).WithField("job", presubmit.Name).Warn("Failed to report the skipped job, running it.")
+			toRun = append(toRun, presubmit)
+		}
+	}
+	return toRun
+}
@@ -19,6 +19,7 @@ package trigger
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"strconv"
 	"testing"
 
@@ -631,3 +632,97 @@ func TestAbortAllJobs(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildAllSkipsUnaffected(t *testing.T) {
+	mapping := "always:\n- go.mod\njobs:\n  pull-foo:\n  - pkg/foo/*\n  pull-bar:\n  - pkg/bar/*\n  pull-baz:\n  - pkg/baz/*\n"
+	testCases := []struct {
+		name             string
+		mappingFile      string
+		changes          []string
+		expectedJobs     []string
+		expectedStatuses []github.Status
+	}{
+		{
+			name:         "without a mapping every job runs",
+			changes:      []string{"pkg/foo/foo.go"},
+			expectedJobs: []string{"pull-bar", "pull-baz", "pull-foo", "pull-other"},
+		},
+		{
+			name:         "unaffected jobs are skipped",
+			mappingFile:  ".prow/test-impact.yaml",
+			changes:      []string{"pkg/foo/foo.go"},
+			expectedJobs: []string{"pull-foo", "pull-other"},
+			expectedStatuses: []github.Status{
+				{State: github.StatusSuccess, Context: "pull-bar", Description: "Skipped: not affected by the changes."},
+			},
+		},
+		{
+			name:         "changes affecting every job",
+			mappingFile:  ".prow/test-impact.yaml",
+			changes:      []string{"go.mod"},
+			expectedJobs: []string{"pull-bar", "pull-baz", "pull-foo", "pull-other"},
+		},
+		{
+			name:         "changes of the mapping",
+			mappingFile:  ".prow/test-impact.yaml",
+			changes:      []string{".prow/test-impact.yaml"},
+			expectedJobs: []string{"pull-bar", "pull-baz", "pull-foo", "pull-other"},
+		},
+		{
+			name:         "missing mapping",
+			mappingFile:  ".prow/missing.yaml",
+			changes:      []string{"pkg/foo/foo.go"},
+			expectedJobs: []string{"pull-bar", "pull-baz", "pull-foo", "pull-other"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := fakegithub.NewFakeClient()
+			g.RemoteFiles = map[string]map[string]string{".prow/test-impact.yaml": {"base": mapping}}
+			for _, file := range tc.changes {
+				g.PullRequestChanges[1] = append(g.PullRequestChanges[1], github.PullRequestChange{Filename: file})
+			}
+			fakeProwJobClient := fake.NewSimpleClientset()
+			c := Client{
+				GitHubClient:  g,
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("namespace"),
+				Config:        &config.Config{},
+				Logger:        logrus.WithField("plugin", PluginName),
+			}
+			var presubmits []config.Presubmit
+			for _, name := range []string{"pull-foo", "pull-bar", "pull-baz", "pull-other"} {
+				presubmits = append(presubmits, config.Presubmit{
+					JobBase:   config.JobBase{Name: name},
+					AlwaysRun: true,
+					Reporter:  config.Reporter{Context: name, SkipReport: name == "pull-baz"},
+				})
+			}
+			pr := &github.PullRequest{
+				Number: 1,
+				Base: github.PullRequestBranch{
+					Ref:  "master",
+					Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+				},
+				Head: github.PullRequestBranch{SHA: "head"},
+			}
+			if err := buildAll(c, plugins.Trigger{TestImpactMapping: tc.mappingFile}, pr, "guid", "base", presubmits); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("namespace").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list prowjobs: %v", err)
+			}
+			var jobs []string
+			for _, pj := range pjs.Items {
+				jobs = append(jobs, pj.Spec.Job)
+			}
+			sort.Strings(jobs)
+			if diff := cmp.Diff(tc.expectedJobs, jobs); diff != "" {
+				t.Errorf("unexpected jobs (-want +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedStatuses, g.CreatedStatuses["head"]); diff != "" {
+				t.Errorf("unexpected statuses (-want +got): %s", diff)
+			}
+		})
+	}
+}
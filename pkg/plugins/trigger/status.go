@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// handleStatus tests the open PRs whose head is the commit when a status
+// context that the trust policy requires succeeds on it, like the check of a
+// CLA bot, if that made their authors trusted. PRs that were trusted without
+// the status were tested when they were opened or pushed to already.
+func handleStatus(c Client, trigger plugins.Trigger, se github.StatusEvent) error {
+	policy := trigger.TrustPolicy
+	if se.State != github.StatusSuccess || policy == nil || policy.DryRun || !requiresStatus(*policy, se.Context) {
+		return nil
+	}
+	org, repo := se.Repo.Owner.Login, se.Repo.Name
+	issues, err := c.GitHubClient.FindIssues(fmt.Sprintf("%s repo:%s/%s type:pr state:open", se.SHA, org, repo), "", false)
+	if err != nil {
+		return fmt.Errorf("error searching for PRs of commit %s: %w", se.SHA, err)
+	}
+
+	var errs []error
+	for _, issue := range issues {
+		log := c.Logger.WithField("pr", issue.Number)
+		pr, err := c.GitHubClient.GetPullRequest(org, repo, issue.Number)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting %s/%s#%d: %w", org, repo, issue.Number, err))
+			continue
+		}
+		if pr.Head.SHA != se.SHA || github.HasLabel(labels.OkToTest, issue.Labels) {
+			continue
+		}
+		author := pr.User.Login
+		trusted, err := EvaluateTrustPolicy(c.GitHubClient, *policy, author, org, repo, se.SHA)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !trusted.Trusted {
+			continue
+		}
+		trustedBefore, err := evaluateTrustPolicy(c.GitHubClient, *policy, author, org, repo, se.SHA, se.Context)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if trustedBefore.Trusted {
+			continue
+		}
+
+		if github.HasLabel(labels.NeedsOkToTest, issue.Labels) {
+			if err := c.GitHubClient.RemoveLabel(org, repo, pr.Number, labels.NeedsOkToTest); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		baseSHA, err := c.GitHubClient.GetRef(org, repo, "heads/"+pr.Base.Ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get baseSHA: %w", err))
+			continue
+		}
+		presubmits := getPresubmits(log, c.GitClient, c.Config, org+"/"+repo, func() (string, error) { return baseSHA, nil }, func() (string, error) { return pr.Head.SHA, nil })
+		if len(presubmits) == 0 {
+			continue
+		}
+		log.Infof("Starting all jobs for PR whose author is trusted since %s succeeded.", se.Context)
+		if err := buildAllButDrafts(c, trigger, pr, se.GUID, baseSHA, presubmits); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -75,6 +75,8 @@ func init() {
 	plugins.RegisterGenericCommentHandler(PluginName, handleGenericCommentEvent, helpProvider)
 	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
 	plugins.RegisterPushEventHandler(PluginName, handlePush, helpProvider)
+	plugins.RegisterStatusEventHandler(PluginName, handleStatusEvent, helpProvider)
+	plugins.RegisterCheckRunEventHandler(PluginName, handleCheckRunEvent, helpProvider)
 }
 
 func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
@@ -86,6 +88,9 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 			org = trigger.TrustedOrg
 		}
 		configInfo[repo.String()] = fmt.Sprintf("The trusted GitHub organization for this repository is %q.", org)
+		if trigger.TrustPolicy != nil && !trigger.TrustPolicy.DryRun {
+			configInfo[repo.String()] = fmt.Sprintf("The authors of PRs are trusted by a trust policy of %d rules. Members of the trusted GitHub organization %q can /ok-to-test the PRs of other authors.", len(trigger.TrustPolicy.Rules), org)
+		}
 	}
 	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
 		Triggers: []plugins.Trigger{
@@ -110,6 +115,7 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 <br>Trigger will not automatically start jobs for a PR in draft state, and if a PR is changed to draft it cancels pending jobs.
 <br>If jobs are not run automatically for a PR because it is not trusted or is in draft state, a trusted user can still start jobs manually via the '/test' command.
 <br>The '/retest' command can be used to rerun jobs that have reported failure.
+<br>Jobs reported as GitHub check runs by crier can be rerun with the 'Re-run' button of their check run.
 <br>Trigger starts postsubmit jobs when commits are pushed if the filters on the job match files and branches affected by that push.`,
 		Config:  configInfo,
 		Snippet: yamlSnippet,
@@ -135,6 +141,13 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		WhoCanUse:   "Anyone can trigger this command on a trusted PR.",
 		Examples:    []string{"/retest"},
 	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/test-prs <job name> [all|#<number>...|<search qualifiers>]",
+		Description: "Starts a job on the listed open PRs, or on those matching the GitHub search qualifiers, or on all open PRs, like after fixing a flaky required job. Must be commented on an issue. Untrusted PRs are skipped.",
+		Featured:    false,
+		WhoCanUse:   "Admins of the repo.",
+		Examples:    []string{"/test-prs pull-unit all", "/test-prs pull-unit #123 #124", "/test-prs pull-unit label:lgtm base:main"},
+	})
 	pluginHelp.AddCommand(pluginhelp.Command{
 		Usage:       "/test ?",
 		Description: "List available test job(s) for a trusted PR.",
@@ -158,19 +171,25 @@ type githubClient interface {
 	CreateStatus(owner, repo, ref string, status github.Status) error
 	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
+	GetFile(org, repo, filepath, commit string) ([]byte, error)
 	RemoveLabel(org, repo string, number int, label string) error
 	TriggerGitHubWorkflow(org, repo string, id int) error
 	TriggerFailedGitHubWorkflow(org, repo string, id int) error
 	DeleteStaleComments(org, repo string, number int, comments []github.IssueComment, isStale func(github.IssueComment) bool) error
 	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
+	GetUserPermission(org, repo, user string) (string, error)
+	TeamBySlugHasMember(org string, teamSlug string, memberLogin string) (bool, error)
+	FindIssues(query, sort string, asc bool) ([]github.Issue, error)
 }
 
 type trustedPullRequestClient interface {
 	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
-	trustedUserClient
+	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	trustPolicyClient
 }
 
 type prowJobClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*prowapi.ProwJob, error)
 	Create(context.Context, *prowapi.ProwJob, metav1.CreateOptions) (*prowapi.ProwJob, error)
 	List(ctx context.Context, opts metav1.ListOptions) (*prowapi.ProwJobList, error)
 	Update(context.Context, *prowapi.ProwJob, metav1.UpdateOptions) (*prowapi.ProwJob, error)
@@ -210,13 +229,25 @@ func handlePullRequest(pc plugins.Agent, pr github.PullRequestEvent) error {
 }
 
 func handleGenericCommentEvent(pc plugins.Agent, gc github.GenericCommentEvent) error {
-	return handleGenericComment(getClient(pc), pc.PluginConfig.TriggerFor(gc.Repo.Owner.Login, gc.Repo.Name), gc)
+	trigger := pc.PluginConfig.TriggerFor(gc.Repo.Owner.Login, gc.Repo.Name)
+	if !gc.IsPR {
+		return handleTestPRs(getClient(pc), trigger, gc)
+	}
+	return handleGenericComment(getClient(pc), trigger, gc)
 }
 
 func handlePush(pc plugins.Agent, pe github.PushEvent) error {
 	return handlePE(getClient(pc), pe)
 }
 
+func handleStatusEvent(pc plugins.Agent, se github.StatusEvent) error {
+	return handleStatus(getClient(pc), pc.PluginConfig.TriggerFor(se.Repo.Owner.Login, se.Repo.Name), se)
+}
+
+func handleCheckRunEvent(pc plugins.Agent, cre github.CheckRunEvent) error {
+	return handleCheckRun(getClient(pc), cre)
+}
+
 // TrustedUserResponse is a response from TrustedUser. It contains the boolean response for trust as well
 // a reason for denial if the user is not trusted.
 type TrustedUserResponse struct {
@@ -0,0 +1,283 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// trustPolicyClient is used to evaluate the rules of trust policies.
+type trustPolicyClient interface {
+	trustedUserClient
+	GetUserPermission(org, repo, user string) (string, error)
+	TeamBySlugHasMember(org string, teamSlug string, memberLogin string) (bool, error)
+	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
+}
+
+// TrustCondition is the outcome of one condition of a trust rule.
+type TrustCondition struct {
+	Condition string `json:"condition"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// TrustRuleResult is the outcome of a trust rule. Its conditions are
+// evaluated up to the first one that doesn't hold.
+type TrustRuleResult struct {
+	Rule       plugins.TrustRule `json:"rule"`
+	Matched    bool              `json:"matched"`
+	Conditions []TrustCondition  `json:"conditions"`
+}
+
+// TrustExplanation explains whether a trust policy trusts the author of a
+// PR. Its rules are evaluated up to the first one that matches.
+type TrustExplanation struct {
+	Author  string            `json:"author"`
+	Trusted bool              `json:"trusted"`
+	Reason  string            `json:"reason,omitempty"`
+	Rules   []TrustRuleResult `json:"rules,omitempty"`
+}
+
+// EvaluateTrustPolicy evaluates the trust policy for the author of a PR of
+// the repo, whose head is headSHA. The bot is always trusted.
+func EvaluateTrustPolicy(ghc trustPolicyClient, policy plugins.TrustPolicy, author, org, repo, headSHA string) (*TrustExplanation, error) {
+	return evaluateTrustPolicy(ghc, policy, author, org, repo, headSHA, "")
+}
+
+// evaluateTrustPolicy evaluates the trust policy as if the status of the
+// ignored context wasn't successful.
+func evaluateTrustPolicy(ghc trustPolicyClient, policy plugins.TrustPolicy, author, org, repo, headSHA, ignoredContext string) (*TrustExplanation, error) {
+	explanation := &TrustExplanation{Author: author}
+	isBot, err := ghc.BotUserChecker()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if the author is the bot: %w", err)
+	}
+	if isBot(author) {
+		explanation.Trusted = true
+		explanation.Reason = "The author is the bot."
+		return explanation, nil
+	}
+
+	var successful map[string]bool
+	statusSucceeded := func(context string) (bool, error) {
+		if headSHA == "" || context == ignoredContext {
+			return false, nil
+		}
+		if successful == nil {
+			combined, err := ghc.GetCombinedStatus(org, repo, headSHA)
+			if err != nil {
+				return false, fmt.Errorf("error in GetCombinedStatus(%s): %w", headSHA, err)
+			}
+			successful = map[string]bool{}
+			for _, status := range combined.Statuses {
+				successful[status.Context] = status.State == github.StatusSuccess
+			}
+		}
+		return successful[context], nil
+	}
+
+	for _, rule := range policy.Rules {
+		result := TrustRuleResult{Rule: rule, Matched: true}
+		for _, condition := range trustConditions(ghc, rule, author, org, repo, statusSucceeded) {
+			satisfied, err := condition.satisfied()
+			if err != nil {
+				return nil, err
+			}
+			result.Conditions = append(result.Conditions, TrustCondition{Condition: condition.name, Satisfied: satisfied})
+			if !satisfied {
+				result.Matched = false
+				break
+			}
+		}
+		explanation.Rules = append(explanation.Rules, result)
+		if result.Matched {
+			explanation.Trusted = true
+			return explanation, nil
+		}
+	}
+	explanation.Reason = "No rule of the trust policy matches."
+	return explanation, nil
+}
+
+type trustCondition struct {
+	name      string
+	satisfied func() (bool, error)
+}
+
+// trustConditions returns the conditions of the rule.
+func trustConditions(ghc trustPolicyClient, rule plugins.TrustRule, author, org, repo string, statusSucceeded func(string) (bool, error)) []trustCondition {
+	var conditions []trustCondition
+	if rule.OrgMember != "" {
+		conditions = append(conditions, trustCondition{name: "member of the " + rule.OrgMember + " org", satisfied: func() (bool, error) {
+			return ghc.IsMember(rule.OrgMember, author)
+		}})
+	}
+	if rule.CollaboratorPermission != "" {
+		conditions = append(conditions, trustCondition{name: rule.CollaboratorPermission + " permission on " + org + "/" + repo, satisfied: func() (bool, error) {
+			permission, err := ghc.GetUserPermission(org, repo, author)
+			if err != nil {
+				return false, fmt.Errorf("error in GetUserPermission: %w", err)
+			}
+			return slices.Index(plugins.CollaboratorPermissions, permission) >= slices.Index(plugins.CollaboratorPermissions, rule.CollaboratorPermission), nil
+		}})
+	}
+	if rule.Team != "" {
+		conditions = append(conditions, trustCondition{name: "member of the " + rule.Team + " team", satisfied: func() (bool, error) {
+			teamOrg, team, _ := strings.Cut(rule.Team, "/")
+			return ghc.TeamBySlugHasMember(teamOrg, team, author)
+		}})
+	}
+	if rule.StatusContext != "" {
+		conditions = append(conditions, trustCondition{name: "successful " + rule.StatusContext + " status", satisfied: func() (bool, error) {
+			return statusSucceeded(rule.StatusContext)
+		}})
+	}
+	return conditions
+}
+
+// requiresStatus returns whether a rule of the policy requires the status
+// context to be successful.
+func requiresStatus(policy plugins.TrustPolicy, context string) bool {
+	for _, rule := range policy.Rules {
+		if rule.StatusContext != "" && (context == "" || rule.StatusContext == context) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedAuthor returns whether the author of the PR is trusted, by the
+// trust policy of the trigger if it has one that isn't a dry run.
+func trustedAuthor(tprc trustedPullRequestClient, trigger plugins.Trigger, author, org, repo string, num int) (bool, error) {
+	policy := trigger.TrustPolicy
+	if policy != nil && !policy.DryRun {
+		explanation, err := explainPullRequest(tprc, *policy, author, org, repo, num)
+		if err != nil {
+			return false, fmt.Errorf("error evaluating the trust policy for %s: %w", author, err)
+		}
+		return explanation.Trusted, nil
+	}
+
+	trustedResponse, err := TrustedUser(tprc, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, author, org, repo)
+	if err != nil {
+		return false, fmt.Errorf("error checking %s for trust: %w", author, err)
+	}
+	if policy != nil {
+		log := logrus.WithFields(logrus.Fields{"org": org, "repo": repo, "pr": num, "author": author})
+		if explanation, err := explainPullRequest(tprc, *policy, author, org, repo, num); err != nil {
+			log.WithError(err).Warn("Failed to evaluate the trust policy in dry-run mode.")
+		} else if explanation.Trusted != trustedResponse.IsTrusted {
+			log.WithFields(logrus.Fields{"policy_trusted": explanation.Trusted, "trusted": trustedResponse.IsTrusted}).Info("The trust policy in dry-run mode disagrees with the membership checks.")
+		}
+	}
+	return trustedResponse.IsTrusted, nil
+}
+
+// explainPullRequest evaluates the trust policy for the author of the PR,
+// reading the head of the PR if a rule needs its statuses.
+func explainPullRequest(tprc trustedPullRequestClient, policy plugins.TrustPolicy, author, org, repo string, num int) (*TrustExplanation, error) {
+	var headSHA string
+	if requiresStatus(policy, "") {
+		pr, err := tprc.GetPullRequest(org, repo, num)
+		if err != nil {
+			return nil, fmt.Errorf("error in GetPullRequest: %w", err)
+		}
+		headSHA = pr.Head.SHA
+	}
+	return EvaluateTrustPolicy(tprc, policy, author, org, repo, headSHA)
+}
+
+// TrustPolicyExplanation is served by the trust policy explainer.
+type TrustPolicyExplanation struct {
+	// Policy is the explanation of the trust policy of the repo, also if it
+	// is a dry run.
+	Policy *TrustExplanation `json:"policy,omitempty"`
+	// DryRun is whether the policy is a dry run.
+	DryRun bool `json:"dry_run,omitempty"`
+	// MembershipChecks is the outcome of the org membership and collaborator
+	// checks, which decide without a policy or if it is a dry run.
+	MembershipChecks *TrustedUserResponse `json:"membership_checks,omitempty"`
+}
+
+type trustPolicyExplainer struct {
+	pluginConfig func() *plugins.Configuration
+	ghc          trustedPullRequestClient
+}
+
+// NewTrustPolicyExplainer returns a handler explaining whether the author of
+// the PR given by the org, repo and pull query parameters is trusted, and
+// which rules of the trust policy of the repo match, without testing the PR.
+// Only the repos trigger is enabled for are explained.
+func NewTrustPolicyExplainer(pluginConfig func() *plugins.Configuration, ghc trustedPullRequestClient) http.Handler {
+	return &trustPolicyExplainer{pluginConfig: pluginConfig, ghc: ghc}
+}
+
+func (e *trustPolicyExplainer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	org, repo := r.URL.Query().Get("org"), r.URL.Query().Get("repo")
+	num, err := strconv.Atoi(r.URL.Query().Get("pull"))
+	if org == "" || repo == "" || err != nil {
+		http.Error(w, "The org, repo and pull query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	pluginConfig := e.pluginConfig()
+	if !slices.Contains(pluginConfig.EnabledPlugins(org, repo), PluginName) {
+		http.Error(w, fmt.Sprintf("The %s plugin isn't enabled for %s/%s.", PluginName, org, repo), http.StatusNotFound)
+		return
+	}
+	pr, err := e.ghc.GetPullRequest(org, repo, num)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get %s/%s#%d: %v", org, repo, num, err), http.StatusBadGateway)
+		return
+	}
+	author := pr.User.Login
+	trigger := pluginConfig.TriggerFor(org, repo)
+
+	var explanation TrustPolicyExplanation
+	if policy := trigger.TrustPolicy; policy != nil {
+		explanation.DryRun = policy.DryRun
+		explanation.Policy, err = EvaluateTrustPolicy(e.ghc, *policy, author, org, repo, pr.Head.SHA)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to evaluate the trust policy: %v", err), http.StatusBadGateway)
+			return
+		}
+	}
+	if trigger.TrustPolicy == nil || trigger.TrustPolicy.DryRun {
+		response, err := TrustedUser(e.ghc, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, author, org, repo)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to check the membership of %s: %v", author, err), http.StatusBadGateway)
+			return
+		}
+		explanation.MembershipChecks = &response
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(explanation); err != nil {
+		logrus.WithError(err).Error("Failed to write the trust policy explanation.")
+	}
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func newTrustPolicyClient() *fakegithub.FakeClient {
+	ghc := fakegithub.NewFakeClient()
+	ghc.OrgMembers = map[string][]string{"org": {"member"}}
+	ghc.Collaborators = []string{"writer", "admin"}
+	ghc.Permissions = map[string]string{"admin": "admin", "reader": "read"}
+	ghc.Teams = map[string]map[string]fakegithub.TeamWithMembers{
+		"org": {"contributors": {Members: sets.New[string]("contributor")}},
+	}
+	ghc.CombinedStatuses = map[string]*github.CombinedStatus{
+		"signed":   {Statuses: []github.Status{{Context: "cla", State: github.StatusSuccess}}},
+		"unsigned": {Statuses: []github.Status{{Context: "cla", State: github.StatusFailure}}},
+	}
+	return ghc
+}
+
+func TestEvaluateTrustPolicy(t *testing.T) {
+	policy := plugins.TrustPolicy{Rules: []plugins.TrustRule{
+		{OrgMember: "org"},
+		{CollaboratorPermission: "write", StatusContext: "cla"},
+		{Team: "org/contributors", StatusContext: "cla"},
+	}}
+	testCases := []struct {
+		name     string
+		author   string
+		headSHA  string
+		expected *TrustExplanation
+	}{
+		{
+			name:   "bot",
+			author: "k8s-ci-robot",
+			expected: &TrustExplanation{
+				Author:  "k8s-ci-robot",
+				Trusted: true,
+				Reason:  "The author is the bot.",
+			},
+		},
+		{
+			name:   "org member",
+			author: "member",
+			expected: &TrustExplanation{
+				Author:  "member",
+				Trusted: true,
+				Rules: []TrustRuleResult{{
+					Rule:       policy.Rules[0],
+					Matched:    true,
+					Conditions: []TrustCondition{{Condition: "member of the org org", Satisfied: true}},
+				}},
+			},
+		},
+		{
+			name:    "admin who signed the CLA",
+			author:  "admin",
+			headSHA: "signed",
+			expected: &TrustExplanation{
+				Author:  "admin",
+				Trusted: true,
+				Rules: []TrustRuleResult{
+					{
+						Rule:       policy.Rules[0],
+						Conditions: []TrustCondition{{Condition: "member of the org org"}},
+					},
+					{
+						Rule:    policy.Rules[1],
+						Matched: true,
+						Conditions: []TrustCondition{
+							{Condition: "write permission on org/repo", Satisfied: true},
+							{Condition: "successful cla status", Satisfied: true},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "team member who didn't sign the CLA",
+			author:  "contributor",
+			headSHA: "unsigned",
+			expected: &TrustExplanation{
+				Author: "contributor",
+				Reason: "No rule of the trust policy matches.",
+				Rules: []TrustRuleResult{
+					{
+						Rule:       policy.Rules[0],
+						Conditions: []TrustCondition{{Condition: "member of the org org"}},
+					},
+					{
+						Rule:       policy.Rules[1],
+						Conditions: []TrustCondition{{Condition: "write permission on org/repo"}},
+					},
+					{
+						Rule: policy.Rules[2],
+						Conditions: []TrustCondition{
+							{Condition: "member of the org/contributors team", Satisfied: true},
+							{Condition: "successful cla status"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "reader",
+			author:  "reader",
+			headSHA: "signed",
+			expected: &TrustExplanation{
+				Author: "reader",
+				Reason: "No rule of the trust policy matches.",
+				Rules: []TrustRuleResult{
+					{
+						Rule:       policy.Rules[0],
+						Conditions: []TrustCondition{{Condition: "member of the org org"}},
+					},
+					{
+						Rule:       policy.Rules[1],
+						Conditions: []TrustCondition{{Condition: "write permission on org/repo"}},
+					},
+					{
+						Rule:       policy.Rules[2],
+						Conditions: []TrustCondition{{Condition: "member of the org/contributors team"}},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			explanation, err := EvaluateTrustPolicy(newTrustPolicyClient(), policy, tc.author, "org", "repo", tc.headSHA)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, explanation); diff != "" {
+				t.Errorf("unexpected explanation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTrustedPullRequestWithPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		author   string
+		dryRun   bool
+		expected bool
+	}{
+		{
+			name:     "policy trusts the team member",
+			author:   "contributor",
+			expected: true,
+		},
+		{
+			name:     "policy doesn't trust the collaborator",
+			author:   "writer",
+			expected: false,
+		},
+		{
+			name:     "membership checks decide in dry-run mode",
+			author:   "writer",
+			dryRun:   true,
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := newTrustPolicyClient()
+			ghc.PullRequests = map[int]*github.PullRequest{1: {Number: 1, Head: github.PullRequestBranch{SHA: "signed"}}}
+			trigger := plugins.Trigger{TrustPolicy: &plugins.TrustPolicy{
+				Rules:  []plugins.TrustRule{{Team: "org/contributors", StatusContext: "cla"}},
+				DryRun: tc.dryRun,
+			}}
+			_, trusted, err := TrustedPullRequest(ghc, trigger, tc.author, "org", "repo", 1, []github.Label{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if trusted != tc.expected {
+				t.Errorf("expected trusted to be %t, got %t", tc.expected, trusted)
+			}
+		})
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	testCases := []struct {
+		name        string
+		author      string
+		context     string
+		state       string
+		labels      []github.Label
+		dryRun      bool
+		shouldBuild bool
+	}{
+		{
+			name:        "author trusted once the CLA is signed",
+			author:      "contributor",
+			context:     "cla",
+			state:       github.StatusSuccess,
+			labels:      []github.Label{{Name: labels.NeedsOkToTest}},
+			shouldBuild: true,
+		},
+		{
+			name:    "author trusted before the CLA was signed",
+			author:  "member",
+			context: "cla",
+			state:   github.StatusSuccess,
+		},
+		{
+			name:    "author still not trusted",
+			author:  "reader",
+			context: "cla",
+			state:   github.StatusSuccess,
+		},
+		{
+			name:    "failed status",
+			author:  "contributor",
+			context: "cla",
+			state:   github.StatusFailure,
+		},
+		{
+			name:    "status the policy doesn't require",
+			author:  "contributor",
+			context: "lint",
+			state:   github.StatusSuccess,
+		},
+		{
+			name:    "PR tested with /ok-to-test already",
+			author:  "contributor",
+			context: "cla",
+			state:   github.StatusSuccess,
+			labels:  []github.Label{{Name: labels.OkToTest}},
+		},
+		{
+			name:    "dry run",
+			author:  "contributor",
+			context: "cla",
+			state:   github.StatusSuccess,
+			dryRun:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := newTrustPolicyClient()
+			ghc.PullRequests = map[int]*github.PullRequest{1: {
+				Number: 1,
+				User:   github.User{Login: tc.author},
+				Base:   github.PullRequestBranch{Ref: "main", Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"}},
+				Head:   github.PullRequestBranch{SHA: "signed"},
+			}}
+			ghc.IssueLabelsExisting = nil
+			for _, label := range tc.labels {
+				ghc.IssueLabelsExisting = append(ghc.IssueLabelsExisting, "org/repo#1:"+label.Name)
+			}
+			// The search returns the PR along with its labels.
+			ghc.PullRequests[1].Labels = tc.labels
+			fakeProwJobClient := fake.NewSimpleClientset()
+			c := Client{
+				GitHubClient:  &labelledSearchClient{FakeClient: ghc},
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				Config:        &config.Config{},
+				Logger:        logrus.WithField("plugin", PluginName),
+			}
+			if err := c.Config.SetPresubmits(map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "test"}, AlwaysRun: true}},
+			}); err != nil {
+				t.Fatalf("failed to set presubmits: %v", err)
+			}
+			trigger := plugins.Trigger{TrustPolicy: &plugins.TrustPolicy{
+				Rules: []plugins.TrustRule{
+					{OrgMember: "org"},
+					{Team: "org/contributors", StatusContext: "cla"},
+				},
+				DryRun: tc.dryRun,
+			}}
+			se := github.StatusEvent{
+				SHA:     "signed",
+				State:   tc.state,
+				Context: tc.context,
+				Repo:    github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+			}
+			if err := handleStatus(c, trigger, se); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var built bool
+			for _, action := range fakeProwJobClient.Actions() {
+				built = built || action.GetVerb() == "create"
+			}
+			if built != tc.shouldBuild {
+				t.Errorf("expected build to be %t, got %t", tc.shouldBuild, built)
+			}
+			if tc.shouldBuild && len(ghc.IssueLabelsRemoved) != 1 {
+				t.Errorf("expected %s to be removed, got %v", labels.NeedsOkToTest, ghc.IssueLabelsRemoved)
+			}
+		})
+	}
+}
+
+// labelledSearchClient returns the labels of PRs from searches like GitHub.
+type labelledSearchClient struct {
+	*fakegithub.FakeClient
+}
+
+func (c *labelledSearchClient) FindIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	var issues []github.Issue
+	for _, pr := range c.PullRequests {
+		issues = append(issues, github.Issue{Number: pr.Number, User: pr.User, Labels: pr.Labels})
+	}
+	return issues, nil
+}
+
+func TestTrustPolicyExplainer(t *testing.T) {
+	ghc := newTrustPolicyClient()
+	ghc.PullRequests = map[int]*github.PullRequest{1: {
+		Number: 1,
+		User:   github.User{Login: "writer"},
+		Head:   github.PullRequestBranch{SHA: "unsigned"},
+	}}
+	pluginConfig := &plugins.Configuration{
+		Plugins: plugins.Plugins{"org": {Plugins: []string{PluginName}, ExcludedRepos: []string{"excluded"}}},
+		Triggers: []plugins.Trigger{{
+			Repos:       []string{"org/repo"},
+			TrustPolicy: &plugins.TrustPolicy{Rules: []plugins.TrustRule{{CollaboratorPermission: "write", StatusContext: "cla"}}, DryRun: true},
+		}},
+	}
+	explainer := NewTrustPolicyExplainer(func() *plugins.Configuration { return pluginConfig }, ghc)
+
+	for _, query := range []string{"", "?org=org&repo=repo", "?org=org&repo=repo&pull=one"} {
+		recorder := httptest.NewRecorder()
+		explainer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/trust-policy"+query, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected %q to be a bad request, got %d", query, recorder.Code)
+		}
+	}
+
+	for _, query := range []string{"?org=other&repo=repo&pull=1", "?org=org&repo=excluded&pull=1"} {
+		recorder := httptest.NewRecorder()
+		explainer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/trust-policy"+query, nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("expected %q to be rejected as trigger isn't enabled for it, got %d", query, recorder.Code)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	explainer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/trust-policy?org=org&repo=repo&pull=1", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var explanation TrustPolicyExplanation
+	if err := json.Unmarshal(recorder.Body.Bytes(), &explanation); err != nil {
+		t.Fatalf("failed to decode the explanation: %v", err)
+	}
+	expected := TrustPolicyExplanation{
+		Policy: &TrustExplanation{
+			Author: "writer",
+			Reason: "No rule of the trust policy matches.",
+			Rules: []TrustRuleResult{{
+				Rule: plugins.TrustRule{CollaboratorPermission: "write", StatusContext: "cla"},
+				Conditions: []TrustCondition{
+					{Condition: "write permission on org/repo", Satisfied: true},
+					{Condition: "successful cla status"},
+				},
+			}},
+		},
+		DryRun:           true,
+		MembershipChecks: &TrustedUserResponse{IsTrusted: true},
+	}
+	if diff := cmp.Diff(expected, explanation); diff != "" {
+		t.Errorf("unexpected explanation (-want +got):\n%s", diff)
+	}
+}
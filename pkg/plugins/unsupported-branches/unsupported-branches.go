@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package unsupportedbranches comments on, labels and optionally closes PRs
+// that are opened against branches that don't take changes.
+package unsupportedbranches
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/pluginhelp"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// PluginName defines this plugin's registered name.
+const PluginName = "unsupported-branches"
+
+func init() {
+	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		var descs []string
+		for _, c := range config.UnsupportedBranches {
+			if repos := sets.New[string](c.Repos...); !repos.Has(repo.String()) && !repos.Has(repo.Org) {
+				continue
+			}
+			for _, b := range c.Branches {
+				descs = append(descs, describe(b))
+			}
+		}
+		if len(descs) > 0 {
+			configInfo[repo.String()] = fmt.Sprintf("PRs against the following branches are handled:\n<ul><li>%s</li></ul>", strings.Join(descs, "</li><li>"))
+		}
+	}
+	// Only the 'Description', 'Config' and 'Snippet' fields are necessary
+	// because this plugin does not react to any commands.
+	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
+		UnsupportedBranches: []plugins.UnsupportedBranches{
+			{
+				Repos: []string{"org/repo"},
+				Branches: []plugins.UnsupportedBranch{
+					{
+						BranchRegexp: `^release-1\.2$`,
+						From:         "2024-06-30",
+						Reason:       "reached its end of life on 2024-06-30",
+						Guidance:     "Please open your PR against `main` instead.",
+						Close:        true,
+					},
+					{
+						BranchRegexp: `^release-1\.4$`,
+						From:         "2024-08-01",
+						Until:        "2024-08-15",
+						Reason:       "is frozen for the 1.4.0 release until 2024-08-15",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf("cannot generate comments for %s plugin", PluginName)
+	}
+	return &pluginhelp.PluginHelp{
+		Description: fmt.Sprintf("The unsupported-branches plugin comments on PRs opened against branches that don't take changes, like branches that reached their end of life or are frozen, and adds the %s label to them. It can close the PRs as well. The label is removed once the branch takes changes again or the base branch of the PR is changed to one that does.", labels.UnsupportedBranch),
+		Config:      configInfo,
+		Snippet:     yamlSnippet,
+	}, nil
+}
+
+func describe(b plugins.UnsupportedBranch) string {
+	desc := fmt.Sprintf("branches matching %q", b.BranchRegexp)
+	if b.From != "" {
+		desc += fmt.Sprintf(" from %s", b.From)
+	}
+	if b.Until != "" {
+		desc += fmt.Sprintf(" until %s", b.Until)
+	}
+	if b.Close {
+		desc += ", which are closed"
+	}
+	return desc
+}
+
+type githubClient interface {
+	AddLabel(org, repo string, number int, label string) error
+	RemoveLabel(org, repo string, number int, label string) error
+	CreateComment(org, repo string, number int, comment string) error
+	ClosePullRequest(org, repo string, number int) error
+}
+
+func handlePullRequest(pc plugins.Agent, pre github.PullRequestEvent) error {
+	return handle(pc.GitHubClient, pc.PluginConfig, pc.Logger, pre, time.Now())
+}
+
+func handle(ghc githubClient, config *plugins.Configuration, log *logrus.Entry, pre github.PullRequestEvent, now time.Time) error {
+	if pre.Action != github.PullRequestActionOpened &&
+		pre.Action != github.PullRequestActionReopened &&
+		pre.Action != github.PullRequestActionEdited {
+		return nil
+	}
+	pr := pre.PullRequest
+	if pr.State != github.PullRequestStateOpen {
+		return nil
+	}
+	org, repo, number, branch := pr.Base.Repo.Owner.Login, pr.Base.Repo.Name, pr.Number, pr.Base.Ref
+
+	hasLabel := github.HasLabel(labels.UnsupportedBranch, pr.Labels)
+	unsupported := config.UnsupportedBranchFor(org, repo, branch, now)
+	if unsupported == nil {
+		if hasLabel {
+			log.Infof("Removing %q label from %s/%s#%d", labels.UnsupportedBranch, org, repo, number)
+			return ghc.RemoveLabel(org, repo, number, labels.UnsupportedBranch)
+		}
+		return nil
+	}
+	// PRs that are labeled already were handled before, so PRs that were
+	// reopened by hand aren't closed again.
+	if hasLabel {
+		return nil
+	}
+
+	log.Infof("Adding %q label to %s/%s#%d", labels.UnsupportedBranch, org, repo, number)
+	if err := ghc.AddLabel(org, repo, number, labels.UnsupportedBranch); err != nil {
+		return err
+	}
+	reason := unsupported.Reason
+	if reason == "" {
+		reason = "doesn't take changes"
+	}
+	message := fmt.Sprintf("This PR targets the `%s` branch, which %s.", branch, reason)
+	if unsupported.Guidance != "" {
+		message += "\n\n" + unsupported.Guidance
+	}
+	if unsupported.Close {
+		message += "\n\nI'm closing this PR."
+	}
+	if err := ghc.CreateComment(org, repo, number, plugins.FormatResponse(pr.User.Login, message, fmt.Sprintf("PRs against branches that don't take changes get the `%s` label.", labels.UnsupportedBranch))); err != nil {
+		return err
+	}
+	if unsupported.Close {
+		log.Infof("Closing %s/%s#%d", org, repo, number)
+		return ghc.ClosePullRequest(org, repo, number)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unsupportedbranches
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/yaml"
+)
+
+type fakeGitHubClient struct {
+	added, removed []string
+	comments       []string
+	closed         bool
+}
+
+func (f *fakeGitHubClient) AddLabel(org, repo string, number int, label string) error {
+	f.added = append(f.added, label)
+	return nil
+}
+
+func (f *fakeGitHubClient) RemoveLabel(org, repo string, number int, label string) error {
+	f.removed = append(f.removed, label)
+	return nil
+}
+
+func (f *fakeGitHubClient) CreateComment(org, repo string, number int, comment string) error {
+	f.comments = append(f.comments, comment)
+	return nil
+}
+
+func (f *fakeGitHubClient) ClosePullRequest(org, repo string, number int) error {
+	f.closed = true
+	return nil
+}
+
+const pluginConfig = `
+unsupported_branches:
+- repos:
+  - org
+  branches:
+  - branch_regexp: ^release-1\.2$
+    from: "2024-06-30"
+    reason: reached its end of life on 2024-06-30
+    guidance: Please open your PR against main instead.
+    close: true
+  - branch_regexp: ^release-1\.4$
+    from: "2024-08-01"
+    until: "2024-08-15"
+    reason: is frozen for the 1.4.0 release
+- repos:
+  - org/repo
+  branches:
+  - branch_regexp: ^legacy$
+`
+
+func TestHandle(t *testing.T) {
+	var cfg plugins.Configuration
+	if err := yaml.Unmarshal([]byte(pluginConfig), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		action   github.PullRequestEventAction
+		repo     string
+		branch   string
+		state    string
+		labeled  bool
+		now      time.Time
+		added    bool
+		removed  bool
+		comment  []string
+		closed   bool
+		noChange bool
+	}{
+		{
+			name:     "PR against a supported branch",
+			action:   github.PullRequestActionOpened,
+			branch:   "main",
+			noChange: true,
+		},
+		{
+			name:    "PR against a branch that reached its end of life is closed",
+			action:  github.PullRequestActionOpened,
+			branch:  "release-1.2",
+			added:   true,
+			comment: []string{"`release-1.2` branch, which reached its end of life on 2024-06-30.", "Please open your PR against main instead.", "I'm closing this PR."},
+			closed:  true,
+		},
+		{
+			name:     "PR against a branch before its end of life",
+			action:   github.PullRequestActionOpened,
+			branch:   "release-1.2",
+			now:      time.Date(2024, 6, 29, 23, 0, 0, 0, time.UTC),
+			noChange: true,
+		},
+		{
+			name:    "PR against a frozen branch is labeled",
+			action:  github.PullRequestActionOpened,
+			branch:  "release-1.4",
+			added:   true,
+			comment: []string{"`release-1.4` branch, which is frozen for the 1.4.0 release."},
+		},
+		{
+			name:    "label is removed once the freeze is over",
+			action:  github.PullRequestActionEdited,
+			branch:  "release-1.4",
+			labeled: true,
+			now:     time.Date(2024, 8, 15, 0, 0, 0, 0, time.UTC),
+			removed: true,
+		},
+		{
+			name:    "label is removed when the base is changed to a supported branch",
+			action:  github.PullRequestActionEdited,
+			branch:  "main",
+			labeled: true,
+			removed: true,
+		},
+		{
+			name:     "PR reopened after it was closed is left alone",
+			action:   github.PullRequestActionReopened,
+			branch:   "release-1.2",
+			labeled:  true,
+			noChange: true,
+		},
+		{
+			name:    "repo config applies with the default reason",
+			action:  github.PullRequestActionOpened,
+			repo:    "repo",
+			branch:  "legacy",
+			added:   true,
+			comment: []string{"`legacy` branch, which doesn't take changes."},
+		},
+		{
+			name:     "repo config only applies to its repo",
+			action:   github.PullRequestActionOpened,
+			repo:     "other",
+			branch:   "legacy",
+			noChange: true,
+		},
+		{
+			name:     "closed PR is ignored",
+			action:   github.PullRequestActionEdited,
+			branch:   "release-1.2",
+			state:    github.PullRequestStateClosed,
+			noChange: true,
+		},
+		{
+			name:     "synchronized PR is ignored",
+			action:   github.PullRequestActionSynchronize,
+			branch:   "release-1.2",
+			noChange: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.repo == "" {
+				tc.repo = "repo"
+			}
+			if tc.state == "" {
+				tc.state = github.PullRequestStateOpen
+			}
+			if tc.now.IsZero() {
+				tc.now = now
+			}
+			pr := github.PullRequest{
+				Number: 1,
+				State:  tc.state,
+				User:   github.User{Login: "author"},
+				Base: github.PullRequestBranch{
+					Ref:  tc.branch,
+					Repo: github.Repo{Owner: github.User{Login: "org"}, Name: tc.repo},
+				},
+			}
+			if tc.labeled {
+				pr.Labels = []github.Label{{Name: labels.UnsupportedBranch}}
+			}
+			ghc := &fakeGitHubClient{}
+			if err := handle(ghc, &cfg, logrus.WithField("plugin", PluginName), github.PullRequestEvent{Action: tc.action, PullRequest: pr}, tc.now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.noChange {
+				if len(ghc.added) != 0 || len(ghc.removed) != 0 || len(ghc.comments) != 0 || ghc.closed {
+					t.Errorf("expected no change, got %+v", ghc)
+				}
+				return
+			}
+			if added := len(ghc.added) == 1 && ghc.added[0] == labels.UnsupportedBranch; added != tc.added {
+				t.Errorf("expected the label to be added: %t, got labels %v", tc.added, ghc.added)
+			}
+			if removed := len(ghc.removed) == 1 && ghc.removed[0] == labels.UnsupportedBranch; removed != tc.removed {
+				t.Errorf("expected the label to be removed: %t, got labels %v", tc.removed, ghc.removed)
+			}
+			if len(tc.comment) == 0 && len(ghc.comments) != 0 {
+				t.Errorf("expected no comment, got %v", ghc.comments)
+			}
+			if len(tc.comment) != 0 {
+				if len(ghc.comments) != 1 {
+					t.Fatalf("expected one comment, got %v", ghc.comments)
+				}
+				for _, part := range tc.comment {
+					if !strings.Contains(ghc.comments[0], part) {
+						t.Errorf("expected the comment to contain %q, got %q", part, ghc.comments[0])
+					}
+				}
+			}
+			if ghc.closed != tc.closed {
+				t.Errorf("expected the PR to be closed: %t, got %t", tc.closed, ghc.closed)
+			}
+		})
+	}
+}
+
+func TestHelpProvider(t *testing.T) {
+	var cfg plugins.Configuration
+	if err := yaml.Unmarshal([]byte(pluginConfig), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	help, err := helpProvider(&cfg, []config.OrgRepo{{Org: "org", Repo: "repo"}, {Org: "other", Repo: "repo"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, ok := help.Config["org/repo"]
+	if !ok {
+		t.Fatalf("expected config info for org/repo, got %v", help.Config)
+	}
+	for _, branch := range []string{`^release-1\\.2$`, `^release-1\\.4$`, `^legacy$`} {
+		if !strings.Contains(info, branch) {
+			t.Errorf("expected the config info to mention %s, got %q", branch, info)
+		}
+	}
+	if _, ok := help.Config["other/repo"]; ok {
+		t.Errorf("expected no config info for other/repo, got %v", help.Config)
+	}
+}
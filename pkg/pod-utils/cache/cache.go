@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cache restores and saves the caches of decorated jobs. Clonerefs
+// restores the caches into their volumes before the test starts and sidecar
+// saves the caches that weren't restored after the test passed.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// Options configures where the caches of a job are stored.
+type Options struct {
+	// Caches are the caches of the job.
+	Caches []prowapi.Cache `json:"caches"`
+	// Job is the name of the job, which keys can refer to.
+	Job string `json:"job"`
+	// Bucket is the bucket the caches are stored in, like gs://bucket or
+	// s3://bucket. Buckets without a scheme are GCS buckets.
+	Bucket string `json:"bucket"`
+	// Dir is the directory the volumes of the caches are mounted in. The
+	// volume of every path of a cache is mounted at <dir>/<name>/<index>.
+	Dir string `json:"dir"`
+	// StateFile is the file clonerefs records the keys of the caches in, so
+	// sidecar saves them under the same keys.
+	StateFile string `json:"state_file"`
+
+	prowflagutil.StorageClientOptions
+}
+
+// State is the outcome of restoring a cache.
+type State struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// Restored is true if the cache was found under the key, in which case
+	// it isn't saved again.
+	Restored bool `json:"restored"`
+}
+
+// Validate ensures the options are complete.
+func (o *Options) Validate() error {
+	if o.Bucket == "" {
+		return errors.New("no bucket specified for caches")
+	}
+	if o.Dir == "" {
+		return errors.New("no directory specified for caches")
+	}
+	if o.StateFile == "" {
+		return errors.New("no state file specified for caches")
+	}
+	for i := range o.Caches {
+		if err := o.Caches[i].Validate(); err != nil {
+			return fmt.Errorf("cache %d is invalid: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// VolumeDir returns the directory the volume of the path with the index in
+// the paths of the cache is mounted at.
+func (o *Options) VolumeDir(name string, index int) string {
+	return filepath.Join(o.Dir, name, fmt.Sprint(index))
+}
+
+func (o *Options) objectPath(name, key string) (string, error) {
+	if strings.HasPrefix(o.Bucket, "/") {
+		return filepath.Join(o.Bucket, "caches", name, key+".tar.gz"), nil
+	}
+	bucket, err := url.Parse(o.Bucket)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse bucket name %s: %w", o.Bucket, err)
+	}
+	if bucket.Scheme == "" {
+		bucket.Scheme = providers.GS
+	}
+	return fmt.Sprintf("%s/caches/%s/%s.tar.gz", strings.TrimSuffix(bucket.String(), "/"), name, key), nil
+}
+
+var invalidKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Key executes the key template of the cache for the refs of the job, which
+// are cloned to repoDir.
+func (o *Options) Key(cache prowapi.Cache, refs prowapi.Refs, repoDir string) (string, error) {
+	tmpl, err := template.New(cache.Name).Option("missingkey=error").Funcs(template.FuncMap{
+		"hashFiles": func(patterns ...string) (string, error) {
+			return hashFiles(repoDir, patterns...)
+		},
+	}).Parse(cache.Key)
+	if err != nil {
+		return "", fmt.Errorf("parse key: %w", err)
+	}
+	var buf bytes.Buffer
+	data := struct{ Job, Org, Repo, BaseRef string }{Job: o.Job, Org: refs.Org, Repo: refs.Repo, BaseRef: refs.BaseRef}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute key: %w", err)
+	}
+	key := strings.Trim(invalidKeyChars.ReplaceAllString(buf.String(), "-"), "-.")
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	return key, nil
+}
+
+// hashFiles returns the SHA-256 of the contents of the files in dir that
+// match the glob patterns.
+func hashFiles(dir string, patterns ...string) (string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files match %q", patterns)
+	}
+	sort.Strings(files)
+	hash := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", file, err)
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Restore restores the caches that were saved under their keys into their
+// volumes and records the keys in the state file. Caches that can't be
+// restored are left empty, so the test runs without them.
+func (o *Options) Restore(ctx context.Context, refs prowapi.Refs, repoDir string) error {
+	opener, err := o.StorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	var states []State
+	var errs []error
+	for _, cache := range o.Caches {
+		log := logrus.WithField("cache", cache.Name)
+		key, err := o.Key(cache, refs, repoDir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key of cache %q: %w", cache.Name, err))
+			continue
+		}
+		log = log.WithField("key", key)
+		state := State{Name: cache.Name, Key: key}
+		if state.Restored, err = o.restore(ctx, opener, cache, key); err != nil {
+			errs = append(errs, fmt.Errorf("restore cache %q: %w", cache.Name, err))
+		} else if state.Restored {
+			log.Info("Restored cache.")
+		} else {
+			log.Info("Cache not found, it will be saved once the test passes.")
+		}
+		states = append(states, state)
+	}
+	raw, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshal cache states: %w", err)
+	}
+	if err := os.WriteFile(o.StateFile, raw, 0644); err != nil {
+		errs = append(errs, fmt.Errorf("write cache states: %w", err))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *Options) restore(ctx context.Context, opener pkgio.Opener, cache prowapi.Cache, key string) (bool, error) {
+	object, err := o.objectPath(cache.Name, key)
+	if err != nil {
+		return false, err
+	}
+	reader, err := opener.Reader(ctx, object)
+	if pkgio.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", object, err)
+	}
+	defer reader.Close()
+	if err := extract(reader, filepath.Join(o.Dir, cache.Name)); err != nil {
+		// Don't leave a partially restored cache behind.
+		for i := range cache.Paths {
+			if clearErr := clearDir(o.VolumeDir(cache.Name, i)); clearErr != nil {
+				return false, errors.Join(err, clearErr)
+			}
+		}
+		return false, fmt.Errorf("extract %s: %w", object, err)
+	}
+	return true, nil
+}
+
+// Save saves the caches that weren't restored under the keys recorded in
+// the state file.
+func (o *Options) Save(ctx context.Context) error {
+	raw, err := os.ReadFile(o.StateFile)
+	if err != nil {
+		return fmt.Errorf("read cache states: %w", err)
+	}
+	var states []State
+	if err := json.Unmarshal(raw, &states); err != nil {
+		return fmt.Errorf("unmarshal cache states: %w", err)
+	}
+	opener, err := o.StorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, state := range states {
+		if state.Restored {
+			continue
+		}
+		object, err := o.objectPath(state.Name, state.Key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := o.save(ctx, opener, state.Name, object); err != nil {
+			errs = append(errs, fmt.Errorf("save cache %q: %w", state.Name, err))
+			continue
+		}
+		logrus.WithFields(logrus.Fields{"cache": state.Name, "key": state.Key}).Info("Saved cache.")
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *Options) save(ctx context.Context, opener pkgio.Opener, name, object string) error {
+	writer, err := opener.Writer(ctx, object)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", object, err)
+	}
+	if err := archive(filepath.Join(o.Dir, name), writer); err != nil {
+		writer.Close()
+		return fmt.Errorf("archive %s: %w", object, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", object, err)
+	}
+	return nil
+}
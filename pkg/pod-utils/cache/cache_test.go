@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+func TestKey(t *testing.T) {
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "go.sum"), []byte("sum"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	refs := prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "release/1.0"}
+	o := &Options{Job: "pull-repo-test"}
+
+	testCases := []struct {
+		name        string
+		key         string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "job fields",
+			key:      "{{.Job}}-{{.Org}}-{{.Repo}}-{{.BaseRef}}",
+			expected: "pull-repo-test-org-repo-release-1.0",
+		},
+		{
+			name:     "hashed files",
+			key:      `go-{{hashFiles "go.sum" "*.mod"}}`,
+			expected: "go-09f5ffef28309853265c4a98d0e56e1be522b6b402d8193594fd05103064fc6a",
+		},
+		{
+			name:        "no files match",
+			key:         `go-{{hashFiles "package-lock.json"}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "empty key",
+			key:         "/",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := o.Key(prowapi.Cache{Name: "go", Key: tc.key}, refs, repoDir)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, key); diff != "" {
+				t.Errorf("unexpected key (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRestoreAndSave(t *testing.T) {
+	root := t.TempDir()
+	newOptions := func(run string) *Options {
+		o := &Options{
+			Caches: []prowapi.Cache{
+				{Name: "go", Paths: []string{"/root/go/pkg/mod", "/root/.cache/go-build"}, Key: "{{.Repo}}"},
+				{Name: "node", Paths: []string{"/src/node_modules"}, Key: "node"},
+			},
+			Bucket:    filepath.Join(root, "bucket"),
+			Dir:       filepath.Join(root, run, "caches"),
+			StateFile: filepath.Join(root, run, "caches.json"),
+		}
+		for _, cache := range o.Caches {
+			for i := range cache.Paths {
+				if err := os.MkdirAll(o.VolumeDir(cache.Name, i), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		return o
+	}
+	refs := prowapi.Refs{Org: "org", Repo: "repo"}
+	readStates := func(o *Options) []State {
+		raw, err := os.ReadFile(o.StateFile)
+		if err != nil {
+			t.Fatalf("failed to read states: %v", err)
+		}
+		var states []State
+		if err := json.Unmarshal(raw, &states); err != nil {
+			t.Fatalf("failed to unmarshal states: %v", err)
+		}
+		return states
+	}
+
+	first := newOptions("first")
+	if err := first.Restore(context.Background(), refs, root); err != nil {
+		t.Fatalf("failed to restore caches of the first run: %v", err)
+	}
+	expected := []State{{Name: "go", Key: "repo"}, {Name: "node", Key: "node"}}
+	if diff := cmp.Diff(expected, readStates(first)); diff != "" {
+		t.Errorf("unexpected states of the first run (-want +got):\n%s", diff)
+	}
+	modDir := filepath.Join(first.VolumeDir("go", 0), "example.com", "mod@v1.0.0")
+	if err := os.MkdirAll(modDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modDir, "go.mod"), []byte("module example.com/mod"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("mod@v1.0.0", filepath.Join(first.VolumeDir("go", 0), "example.com", "latest")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(first.VolumeDir("go", 1), "build"), []byte("object"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Save(context.Background()); err != nil {
+		t.Fatalf("failed to save caches of the first run: %v", err)
+	}
+
+	second := newOptions("second")
+	if err := second.Restore(context.Background(), refs, root); err != nil {
+		t.Fatalf("failed to restore caches of the second run: %v", err)
+	}
+	expected = []State{{Name: "go", Key: "repo", Restored: true}, {Name: "node", Key: "node", Restored: true}}
+	if diff := cmp.Diff(expected, readStates(second)); diff != "" {
+		t.Errorf("unexpected states of the second run (-want +got):\n%s", diff)
+	}
+	for file, content := range map[string]string{
+		filepath.Join(second.VolumeDir("go", 0), "example.com", "latest", "go.mod"): "module example.com/mod",
+		filepath.Join(second.VolumeDir("go", 1), "build"):                           "object",
+	} {
+		actual, err := os.ReadFile(file)
+		if err != nil {
+			t.Errorf("failed to read restored file: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(content, string(actual)); diff != "" {
+			t.Errorf("unexpected content of %s (-want +got):\n%s", file, diff)
+		}
+	}
+}
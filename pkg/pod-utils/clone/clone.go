@@ -43,7 +43,7 @@ type runnable interface {
 // configures the git username and email in the repository as well.
 func Run(refs prowapi.Refs, dir, gitUserName, gitUserEmail, cookiePath string, env []string, userGenerator github.UserGenerator, tokenGenerator github.TokenGenerator) Record {
 	startTime := time.Now()
-	record := Record{Refs: refs}
+	record := Record{Refs: refs, Strategy: strategyFor(refs)}
 
 	var (
 		user  string
@@ -113,6 +113,11 @@ func Run(refs prowapi.Refs, dir, gitUserName, gitUserEmail, cookiePath string, e
 	if err := runCommands(g.commandsForPullRefs(refs, timestamp)); err != nil {
 		return record
 	}
+	if refs.LFSFetch != nil && *refs.LFSFetch {
+		if err := runCommands(g.commandsForLFS()); err != nil {
+			return record
+		}
+	}
 
 	finalSHA, err := g.gitRevParse()
 	if err != nil {
@@ -134,6 +139,25 @@ func censorToken(msg, token string) string {
 	return string(censored)
 }
 
+// strategyFor returns how the refs are fetched, or nil if the whole
+// repository is fetched.
+func strategyFor(refs prowapi.Refs) *Strategy {
+	var strategy Strategy
+	if refs.CloneDepth > 0 {
+		strategy.Depth = refs.CloneDepth
+	} else {
+		strategy.ShallowSince = refs.ShallowSince
+	}
+	if refs.BloblessFetch != nil && *refs.BloblessFetch {
+		strategy.Filter = "blob:none"
+	}
+	strategy.LFS = refs.LFSFetch != nil && *refs.LFSFetch
+	if strategy == (Strategy{}) {
+		return nil
+	}
+	return &strategy
+}
+
 // PathForRefs determines the full path to where
 // refs should be cloned
 func PathForRefs(baseDir string, refs prowapi.Refs) string {
@@ -159,20 +183,10 @@ type gitCtx struct {
 
 // gitCtxForRefs creates a gitCtx based on the provide refs and baseDir.
 func gitCtxForRefs(refs prowapi.Refs, baseDir string, env []string, user, token string) gitCtx {
-	var repoURI string
-	if refs.RepoLink != "" {
-		repoURI = fmt.Sprintf("%s.git", refs.RepoLink)
-	} else {
-		repoURI = fmt.Sprintf("https://github.com/%s/%s.git", refs.Org, refs.Repo)
-	}
-
 	g := gitCtx{
 		cloneDir:      PathForRefs(baseDir, refs),
 		env:           env,
-		repositoryURI: repoURI,
-	}
-	if refs.CloneURI != "" {
-		g.repositoryURI = refs.CloneURI
+		repositoryURI: repositoryURI(refs),
 	}
 
 	if token != "" {
@@ -193,6 +207,17 @@ func gitCtxForRefs(refs prowapi.Refs, baseDir string, env []string, user, token
 	return g
 }
 
+// repositoryURI returns the remote the refs are fetched from.
+func repositoryURI(refs prowapi.Refs) string {
+	if refs.CloneURI != "" {
+		return refs.CloneURI
+	}
+	if refs.RepoLink != "" {
+		return fmt.Sprintf("%s.git", refs.RepoLink)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s.git", refs.Org, refs.Repo)
+}
+
 func (g *gitCtx) gitCommand(args ...string) cloneCommand {
 	return cloneCommand{dir: g.cloneDir, env: g.env, command: "git", args: args}
 }
@@ -238,10 +263,17 @@ func (g *gitCtx) commandsForBaseRef(refs prowapi.Refs, gitUserName, gitUserEmail
 	if cookiePath != "" && refs.SkipSubmodules {
 		commands = append(commands, g.gitCommand("config", "http.cookiefile", cookiePath))
 	}
+	if refs.LFSFetch != nil && *refs.LFSFetch {
+		// LFS objects are pulled once the final state is checked out, instead
+		// of one at a time by the smudge filter during every checkout and merge.
+		commands = append(commands, g.gitCommand("lfs", "install", "--local", "--skip-smudge"))
+	}
 
 	var depthArgs []string
 	if d := refs.CloneDepth; d > 0 {
 		depthArgs = append(depthArgs, "--depth", strconv.Itoa(d))
+	} else if refs.ShallowSince != "" {
+		depthArgs = append(depthArgs, "--shallow-since="+refs.ShallowSince)
 	}
 	var filterArgs []string
 	if refs.BloblessFetch != nil && *refs.BloblessFetch {
@@ -369,6 +401,19 @@ func (g *gitCtx) commandsForPullRefs(refs prowapi.Refs, fakeTimestamp int) []run
 	return commands
 }
 
+// commandsForLFS returns the commands needed to fetch the Git LFS objects of
+// the checked out state. These commands should be run after the commands
+// provided by commandsForPullRefs.
+func (g *gitCtx) commandsForLFS() []runnable {
+	return []runnable{
+		retryCommand{
+			runnable: g.gitCommand("lfs", "fetch", g.repositoryURI, "HEAD"),
+			retries:  fetchRetries,
+		},
+		g.gitCommand("lfs", "checkout"),
+	}
+}
+
 type retryCommand struct {
 	runnable
 	retries []time.Duration
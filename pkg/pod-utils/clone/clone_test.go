@@ -768,6 +768,71 @@ func TestCommandsForRefs(t *testing.T) {
 				cloneCommand{dir: "/go/src/github.enterprise.com/org/repo", command: "git", args: []string{"submodule", "update", "--init", "--recursive"}},
 			},
 		},
+		{
+			name: "shallow since date with LFS",
+			refs: prowapi.Refs{
+				Org:          "org",
+				Repo:         "repo",
+				BaseRef:      "master",
+				Pulls:        []prowapi.Pull{{Number: 1, SHA: "abcdef"}},
+				ShallowSince: "2024-01-01",
+				LFSFetch:     boolPtr(true),
+			},
+			dir: "/go",
+			expectedBase: []runnable{
+				cloneCommand{dir: "/", command: "mkdir", args: []string{"-p", "/go/src/github.com/org/repo"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"init"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"lfs", "install", "--local", "--skip-smudge"}},
+				retryCommand{
+					cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"fetch", "--shallow-since=2024-01-01", "https://github.com/org/repo.git", "--tags", "--prune"}},
+					fetchRetries,
+				},
+				retryCommand{
+					cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"fetch", "--shallow-since=2024-01-01", "https://github.com/org/repo.git", "master"}},
+					fetchRetries,
+				},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"checkout", "FETCH_HEAD"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"branch", "--force", "master", "FETCH_HEAD"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"checkout", "master"}},
+			},
+			expectedPull: []runnable{
+				retryCommand{
+					cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"fetch", "https://github.com/org/repo.git", "abcdef"}},
+					fetchRetries,
+				},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"merge", "--no-ff", "abcdef"}, env: gitTimestampEnvs(fakeTimestamp + 1)},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"submodule", "update", "--init", "--recursive"}},
+			},
+		},
+		{
+			name: "clone depth takes precedence over shallow since date",
+			refs: prowapi.Refs{
+				Org:          "org",
+				Repo:         "repo",
+				BaseRef:      "master",
+				CloneDepth:   1,
+				ShallowSince: "2024-01-01",
+			},
+			dir: "/go",
+			expectedBase: []runnable{
+				cloneCommand{dir: "/", command: "mkdir", args: []string{"-p", "/go/src/github.com/org/repo"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"init"}},
+				retryCommand{
+					cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"fetch", "--depth", "1", "https://github.com/org/repo.git", "--tags", "--prune"}},
+					fetchRetries,
+				},
+				retryCommand{
+					cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"fetch", "--depth", "1", "https://github.com/org/repo.git", "master"}},
+					fetchRetries,
+				},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"checkout", "FETCH_HEAD"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"branch", "--force", "master", "FETCH_HEAD"}},
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"checkout", "master"}},
+			},
+			expectedPull: []runnable{
+				cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"submodule", "update", "--init", "--recursive"}},
+			},
+		},
 	}
 
 	allow := cmp.AllowUnexported(retryCommand{}, cloneCommand{})
@@ -787,6 +852,50 @@ func TestCommandsForRefs(t *testing.T) {
 	}
 }
 
+func TestStrategyFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		refs     prowapi.Refs
+		expected *Strategy
+	}{
+		{
+			name: "full clone has no strategy",
+			refs: prowapi.Refs{BloblessFetch: boolPtr(false), LFSFetch: boolPtr(false)},
+		},
+		{
+			name:     "depth takes precedence over the date",
+			refs:     prowapi.Refs{CloneDepth: 1, ShallowSince: "2024-01-01"},
+			expected: &Strategy{Depth: 1},
+		},
+		{
+			name:     "blobless partial clone since a date with LFS",
+			refs:     prowapi.Refs{ShallowSince: "2024-01-01", BloblessFetch: boolPtr(true), LFSFetch: boolPtr(true)},
+			expected: &Strategy{ShallowSince: "2024-01-01", Filter: "blob:none", LFS: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, strategyFor(tc.refs)); diff != "" {
+				t.Errorf("unexpected strategy (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommandsForLFS(t *testing.T) {
+	g := gitCtxForRefs(prowapi.Refs{Org: "org", Repo: "repo"}, "/go", nil, "", "")
+	expected := []runnable{
+		retryCommand{
+			cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"lfs", "fetch", "https://github.com/org/repo.git", "HEAD"}},
+			fetchRetries,
+		},
+		cloneCommand{dir: "/go/src/github.com/org/repo", command: "git", args: []string{"lfs", "checkout"}},
+	}
+	if diff := cmp.Diff(expected, g.commandsForLFS(), cmp.AllowUnexported(retryCommand{}, cloneCommand{})); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%s", diff)
+	}
+}
+
 func TestGitHeadTimestamp(t *testing.T) {
 	fakeTimestamp := 987654321
 	fakeGitDir, err := makeFakeGitRepo(t, fakeTimestamp)
@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// diagnosisTimeout bounds every check, so an unreachable host doesn't hold
+// the job up for long.
+const diagnosisTimeout = 10 * time.Second
+
+// Diagnostics are the network checks run against the git host after a
+// clone failed. Checks that don't apply to the protocol of the remote, or
+// that can't run because an earlier check failed, are unset.
+type Diagnostics struct {
+	// Remote is the remote that was cloned, without credentials.
+	Remote string `json:"remote"`
+	Host   string `json:"host,omitempty"`
+	// Error is set if the remote can't be checked at all.
+	Error string `json:"error,omitempty"`
+	// DNS resolves the host.
+	DNS *Check `json:"dns,omitempty"`
+	// TCP connects to the port of the remote.
+	TCP *Check `json:"tcp,omitempty"`
+	// TLS performs the TLS handshake with HTTPS remotes.
+	TLS *Check `json:"tls,omitempty"`
+	// HTTP requests the refs of HTTP(S) remotes like git does.
+	HTTP *Check `json:"http,omitempty"`
+}
+
+// Check is the outcome of a diagnostic check.
+type Check struct {
+	// Result describes what the check found, like the resolved addresses.
+	Result   string        `json:"result,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Failed returns whether the check ran and failed.
+func (c *Check) Failed() bool {
+	return c != nil && c.Error != ""
+}
+
+// diagnoser runs the checks. The fields are only overridden in tests.
+type diagnoser struct {
+	resolver  *net.Resolver
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+// Diagnose checks whether the git host of the refs can be resolved and
+// reached, to tell network problems apart from problems with the repo.
+func Diagnose(ctx context.Context, refs prowapi.Refs) *Diagnostics {
+	d := diagnoser{resolver: net.DefaultResolver, timeout: diagnosisTimeout}
+	return d.diagnose(ctx, repositoryURI(refs))
+}
+
+func (d diagnoser) diagnose(ctx context.Context, remote string) *Diagnostics {
+	scheme, host, port, err := parseRemote(remote)
+	diagnostics := &Diagnostics{Remote: remote, Host: host}
+	if err != nil {
+		diagnostics.Error = err.Error()
+		return diagnostics
+	}
+	if u, err := url.Parse(remote); err == nil && u.User != nil {
+		u.User = nil
+		diagnostics.Remote = u.String()
+	}
+
+	diagnostics.DNS = d.check(ctx, func(ctx context.Context) (string, error) {
+		if net.ParseIP(host) != nil {
+			return "IP address, not resolved", nil
+		}
+		addrs, err := d.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(addrs, ", "), nil
+	})
+	if diagnostics.DNS.Failed() {
+		return diagnostics
+	}
+
+	address := net.JoinHostPort(host, port)
+	diagnostics.TCP = d.check(ctx, func(ctx context.Context) (string, error) {
+		conn, err := (&net.Dialer{Resolver: d.resolver}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		return "connected to " + conn.RemoteAddr().String(), nil
+	})
+	if diagnostics.TCP.Failed() || (scheme != "https" && scheme != "http") {
+		return diagnostics
+	}
+
+	tlsConfig := d.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if scheme == "https" {
+		diagnostics.TLS = d.check(ctx, func(ctx context.Context) (string, error) {
+			config := tlsConfig.Clone()
+			config.ServerName = host
+			conn, err := (&tls.Dialer{NetDialer: &net.Dialer{Resolver: d.resolver}, Config: config}).DialContext(ctx, "tcp", address)
+			if err != nil {
+				return "", err
+			}
+			defer conn.Close()
+			state := conn.(*tls.Conn).ConnectionState()
+			result := tls.VersionName(state.Version)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				result += fmt.Sprintf(", certificate for %s issued by %s, expires %s", cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+			}
+			return result, nil
+		})
+		if diagnostics.TLS.Failed() {
+			return diagnostics
+		}
+	}
+
+	diagnostics.HTTP = d.check(ctx, func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(diagnostics.Remote, "/")+"/info/refs?service=git-upload-pack", nil)
+		if err != nil {
+			return "", err
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		// Any response means the host is reachable, authentication errors
+		// are expected since the check doesn't send credentials.
+		return resp.Status, nil
+	})
+	return diagnostics
+}
+
+func (d diagnoser) check(ctx context.Context, run func(context.Context) (string, error)) *Check {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := run(ctx)
+	check := &Check{Result: result, Duration: time.Since(start)}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// parseRemote returns the scheme, host and port of a git remote, which is
+// either a URL or an scp-like address such as git@github.com:org/repo.
+func parseRemote(remote string) (scheme, host, port string, err error) {
+	if !strings.Contains(remote, "://") {
+		at := strings.LastIndex(remote, "@")
+		colon := strings.Index(remote, ":")
+		if colon <= at+1 {
+			return "", "", "", fmt.Errorf("cannot determine the host of %q", remote)
+		}
+		return "ssh", remote[at+1 : colon], "22", nil
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return "", "", "", fmt.Errorf("cannot parse remote: %w", err)
+	}
+	if u.Hostname() == "" {
+		return "", "", "", fmt.Errorf("cannot determine the host of %q", remote)
+	}
+	port = u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		case "ssh":
+			port = "22"
+		case "git":
+			port = "9418"
+		default:
+			return u.Scheme, u.Hostname(), "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+	}
+	return u.Scheme, u.Hostname(), port, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRemote(t *testing.T) {
+	testCases := []struct {
+		remote        string
+		scheme        string
+		host          string
+		port          string
+		expectedError bool
+	}{
+		{remote: "https://github.com/org/repo.git", scheme: "https", host: "github.com", port: "443"},
+		{remote: "http://git.example.com:8080/repo", scheme: "http", host: "git.example.com", port: "8080"},
+		{remote: "ssh://git@example.com/repo", scheme: "ssh", host: "example.com", port: "22"},
+		{remote: "git://example.com/repo", scheme: "git", host: "example.com", port: "9418"},
+		{remote: "git@github.com:org/repo.git", scheme: "ssh", host: "github.com", port: "22"},
+		{remote: "/local/path/repo", expectedError: true},
+		{remote: "file:///local/path/repo", expectedError: true},
+		{remote: "ftp://example.com/repo", expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.remote, func(t *testing.T) {
+			scheme, host, port, err := parseRemote(tc.remote)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error, got %s %s %s", scheme, host, port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scheme != tc.scheme || host != tc.host || port != tc.port {
+				t.Errorf("expected %s %s %s, got %s %s %s", tc.scheme, tc.host, tc.port, scheme, host, port)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/repo/info/refs" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	d := diagnoser{resolver: net.DefaultResolver, tlsConfig: &tls.Config{RootCAs: pool}, timeout: 5 * time.Second}
+
+	t.Run("reachable host", func(t *testing.T) {
+		remote := strings.Replace(server.URL, "https://", "https://user:token@", 1) + "/org/repo"
+		diagnostics := d.diagnose(context.Background(), remote)
+		if strings.Contains(diagnostics.Remote, "token") {
+			t.Errorf("expected credentials to be removed from the remote, got %s", diagnostics.Remote)
+		}
+		for name, check := range map[string]*Check{"dns": diagnostics.DNS, "tcp": diagnostics.TCP, "tls": diagnostics.TLS, "http": diagnostics.HTTP} {
+			if check == nil || check.Failed() {
+				t.Errorf("expected %s check to pass, got %+v", name, check)
+			}
+		}
+		if diagnostics.HTTP != nil && diagnostics.HTTP.Result != "401 Unauthorized" {
+			t.Errorf("expected the HTTP status as result, got %q", diagnostics.HTTP.Result)
+		}
+	})
+
+	t.Run("unreachable host", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		address := listener.Addr().String()
+		listener.Close()
+		diagnostics := d.diagnose(context.Background(), "https://"+address+"/org/repo")
+		if diagnostics.DNS.Failed() {
+			t.Errorf("expected DNS check to pass, got %+v", diagnostics.DNS)
+		}
+		if !diagnostics.TCP.Failed() {
+			t.Errorf("expected TCP check to fail, got %+v", diagnostics.TCP)
+		}
+		if diagnostics.TLS != nil || diagnostics.HTTP != nil {
+			t.Errorf("expected checks to stop after the TCP check, got TLS %+v and HTTP %+v", diagnostics.TLS, diagnostics.HTTP)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		untrusting := diagnoser{resolver: net.DefaultResolver, timeout: 5 * time.Second}
+		diagnostics := untrusting.diagnose(context.Background(), server.URL+"/org/repo")
+		if !diagnostics.TLS.Failed() {
+			t.Errorf("expected TLS check to fail, got %+v", diagnostics.TLS)
+		}
+		if diagnostics.HTTP != nil {
+			t.Errorf("expected no HTTP check, got %+v", diagnostics.HTTP)
+		}
+	})
+
+	t.Run("invalid remote", func(t *testing.T) {
+		diagnostics := d.diagnose(context.Background(), "/local/path")
+		if diagnostics.Error == "" || diagnostics.DNS != nil {
+			t.Errorf("expected only an error, got %+v", diagnostics)
+		}
+	})
+}
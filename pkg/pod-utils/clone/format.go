@@ -19,6 +19,7 @@ package clone
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 // FormatRecord describes the record in a human-readable
@@ -47,6 +48,9 @@ func FormatRecord(record Record) string {
 			fmt.Fprint(&output, "\n")
 		}
 	}
+	if record.Strategy != nil {
+		fmt.Fprintf(&output, "# Fetching %s\n", formatStrategy(*record.Strategy))
+	}
 	for _, command := range record.Commands {
 		runtime := ""
 		if command.Duration != 0 {
@@ -63,3 +67,22 @@ func FormatRecord(record Record) string {
 
 	return output.String()
 }
+
+func formatStrategy(strategy Strategy) string {
+	var parts []string
+	switch {
+	case strategy.Depth > 0:
+		parts = append(parts, fmt.Sprintf("the last %d commits", strategy.Depth))
+	case strategy.ShallowSince != "":
+		parts = append(parts, fmt.Sprintf("the history since %s", strategy.ShallowSince))
+	default:
+		parts = append(parts, "the full history")
+	}
+	if strategy.Filter != "" {
+		parts = append(parts, fmt.Sprintf("with filter %s", strategy.Filter))
+	}
+	if strategy.LFS {
+		parts = append(parts, "and LFS objects")
+	}
+	return strings.Join(parts, " ")
+}
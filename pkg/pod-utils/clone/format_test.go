@@ -61,6 +61,13 @@ func TestFormatRecord(t *testing.T) {
 			},
 			require: []string{"abcdef"},
 		},
+		{
+			name: "include the fetch strategy",
+			r: Record{
+				Strategy: &Strategy{ShallowSince: "2024-01-01", Filter: "blob:none", LFS: true},
+			},
+			require: []string{"Fetching the history since 2024-01-01 with filter blob:none and LFS objects"},
+		},
 		{
 			name: "include passing commands",
 			r: Record{
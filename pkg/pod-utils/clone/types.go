@@ -31,12 +31,33 @@ type Record struct {
 	Commands []Command    `json:"commands,omitempty"`
 	Failed   bool         `json:"failed,omitempty"`
 
+	// Strategy is how the history and objects of the refs were fetched.
+	Strategy *Strategy `json:"strategy,omitempty"`
+
 	// FinalSHA is the SHA from ultimate state of a cloned ref
 	// This is used to populate RepoCommit in started.json properly
 	FinalSHA string `json:"final_sha,omitempty"`
 
 	// Duration is the total runtime for the clone.
 	Duration time.Duration `json:"duration,omitempty"`
+
+	// Diagnostics are the network checks of the git host, which are only
+	// run if the clone failed.
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// Strategy describes how much of the repository was fetched. Unset fields
+// mean the default of fetching everything.
+type Strategy struct {
+	// Depth is the number of commits of the base ref that were fetched.
+	Depth int `json:"depth,omitempty"`
+	// ShallowSince is the date after which the history of the base ref
+	// was fetched.
+	ShallowSince string `json:"shallow_since,omitempty"`
+	// Filter is the partial clone filter, like blob:none.
+	Filter string `json:"filter,omitempty"`
+	// LFS is whether Git LFS objects were fetched.
+	LFS bool `json:"lfs,omitempty"`
 }
 
 // Command is a trace of a command executed
@@ -38,9 +38,11 @@ import (
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/initupload"
 	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pod-utils/cache"
 	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/secretutil"
 	"sigs.k8s.io/prow/pkg/sidecar"
 )
 
@@ -60,6 +62,11 @@ const (
 	s3CredentialsMountPath  = "/secrets/s3-storage"
 	outputMountName         = "output"
 	outputMountPath         = "/output"
+	cachesMountPath         = "/caches"
+	podInfoMountName        = "prow-pod-info"
+	podInfoMountPath        = "/etc/prow-pod-info"
+	abortedByFileName       = "aborted-by"
+	cacheStatePath          = "caches.json"
 )
 
 // Labels returns a string slice with label consts from kube.
@@ -69,7 +76,7 @@ func Labels() []string {
 
 // VolumeMounts returns a string set with *MountName consts in it.
 func VolumeMounts(dc *prowapi.DecorationConfig) sets.Set[string] {
-	ret := sets.New[string](logMountName, codeMountName, toolsMountName, gcsCredentialsMountName, s3CredentialsMountName)
+	ret := sets.New[string](logMountName, codeMountName, toolsMountName, gcsCredentialsMountName, s3CredentialsMountName, podInfoMountName)
 	if dc == nil {
 		return ret
 	}
@@ -408,7 +415,9 @@ func cookiefileVolume(secret string) (coreapi.Volume, coreapi.VolumeMount, strin
 //
 // The container may need to mount SSH keys and/or cookiefiles in order to access private refs.
 // CloneRefs returns a list of volumes containing these secrets required by the container.
-func CloneRefs(pj prowapi.ProwJob, codeMount, logMount coreapi.VolumeMount) (*coreapi.Container, []prowapi.Refs, []coreapi.Volume, error) {
+//
+// If caches is set, the container also restores the caches of the job once it checked out the refs.
+func CloneRefs(pj prowapi.ProwJob, codeMount, logMount coreapi.VolumeMount, caches *cache.Options) (*coreapi.Container, []prowapi.Refs, []coreapi.Volume, error) {
 	if pj.Spec.DecorationConfig == nil {
 		return nil, nil, nil, nil
 	}
@@ -489,6 +498,7 @@ func CloneRefs(pj prowapi.ProwJob, codeMount, logMount coreapi.VolumeMount) (*co
 		GitHubAPIEndpoints:      githubAPIEndpoints,
 		GitHubAppID:             pj.Spec.DecorationConfig.GitHubAppID,
 		GitHubAppPrivateKeyFile: githubAppPrivateKeyMountPath,
+		Caches:                  caches,
 	})
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("clone env: %w", err)
@@ -530,6 +540,113 @@ func metadataFile(log coreapi.VolumeMount, prefix string) string {
 	return filepath.Join(ad, fmt.Sprintf("%s-metadata.json", prefix))
 }
 
+func heartbeatFile(log coreapi.VolumeMount, prefix string) string {
+	if prefix == "" {
+		return filepath.Join(log.MountPath, "heartbeat.json")
+	}
+	return filepath.Join(log.MountPath, fmt.Sprintf("%s-heartbeat.json", prefix))
+}
+
+// HeartbeatInterval is how often the test processes of a job with a hang
+// timeout write and upload heartbeats. The heartbeats are frequent enough to
+// notice a hang soon after the hang timeout, but not more frequent than
+// needed for long hang timeouts.
+func HeartbeatInterval(dc *prowapi.DecorationConfig) time.Duration {
+	if dc == nil || dc.HangTimeout == nil {
+		return 0
+	}
+	interval := dc.HangTimeout.Duration / 4
+	if interval > time.Minute {
+		return time.Minute
+	}
+	if interval < time.Second {
+		return time.Second
+	}
+	return interval
+}
+
+func environmentFile(log coreapi.VolumeMount, prefix string) string {
+	ad := artifactsDir(log)
+	if prefix == "" {
+		return filepath.Join(ad, "run-environment.json")
+	}
+	return filepath.Join(ad, fmt.Sprintf("%s-run-environment.json", prefix))
+}
+
+// secretEnv returns the names of the environment variables of the container
+// that are populated from secrets.
+func secretEnv(c *coreapi.Container) []string {
+	var names []string
+	for _, env := range c.Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			names = append(names, env.Name)
+		}
+	}
+	return names
+}
+
+// secretEnvFromPrefixes returns the prefixes of the environment variables of
+// the container that are populated from secrets with envFrom.
+func secretEnvFromPrefixes(c *coreapi.Container) []string {
+	var prefixes []string
+	for _, envFrom := range c.EnvFrom {
+		if envFrom.SecretRef != nil {
+			prefixes = append(prefixes, envFrom.Prefix)
+		}
+	}
+	return prefixes
+}
+
+// declaredEnv returns the names of the environment variables set in the env
+// of the container.
+func declaredEnv(c *coreapi.Container) []string {
+	var names []string
+	for _, env := range c.Env {
+		names = append(names, env.Name)
+	}
+	return names
+}
+
+// secretReferences returns the names of the environment variables of the
+// container whose values are secret references.
+func secretReferences(c *coreapi.Container) []string {
+	var names []string
+	for _, env := range c.Env {
+		if env.ValueFrom == nil && secretutil.IsReference(env.Value) {
+			names = append(names, env.Name)
+		}
+	}
+	return names
+}
+
+// vaultOptions returns the options entrypoint needs to resolve Vault
+// references in the environment of the container.
+func vaultOptions(c *coreapi.Container, vault *prowapi.VaultConfig) *secretutil.VaultOptions {
+	if vault == nil {
+		return nil
+	}
+	for _, env := range c.Env {
+		if env.ValueFrom == nil && strings.HasPrefix(env.Value, secretutil.VaultScheme) {
+			return &secretutil.VaultOptions{Address: vault.Address, AuthMount: vault.AuthMount, Role: vault.Role}
+		}
+	}
+	return nil
+}
+
+// entrypointQuarantinedTests converts the quarantined tests into entrypoint
+// options, leaving out the ones that already expired.
+func entrypointQuarantinedTests(tests []prowapi.QuarantinedTest) []entrypoint.QuarantinedTest {
+	var quarantined []entrypoint.QuarantinedTest
+	now := time.Now()
+	for _, test := range tests {
+		if test.Expired(now) {
+			continue
+		}
+		quarantined = append(quarantined, entrypoint.QuarantinedTest{Pattern: test.Pattern, Expires: test.Expires.Time})
+	}
+	return quarantined
+}
+
 func artifactsDir(log coreapi.VolumeMount) string {
 	return filepath.Join(log.MountPath, "artifacts")
 }
@@ -539,7 +656,8 @@ func entrypointLocation(tools coreapi.VolumeMount) string {
 }
 
 // InjectEntrypoint will make the entrypoint binary in the tools volume the container's entrypoint, which will output to the log volume.
-func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration, prefix, previousMarker string, propagateErrorCode bool, exitZero bool, log, tools coreapi.VolumeMount) (*wrapper.Options, error) {
+// If recordEnvironment is set, the entrypoint records the environment of the test process in the artifacts.
+func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod, heartbeatInterval time.Duration, prefix, previousMarker string, propagateErrorCode bool, exitZero bool, recordEnvironment bool, vault *prowapi.VaultConfig, quarantinedTests []prowapi.QuarantinedTest, log, tools coreapi.VolumeMount) (*wrapper.Options, error) {
 	wrapperOptions := &wrapper.Options{
 		Args:          append(c.Command, c.Args...),
 		ContainerName: c.Name,
@@ -547,16 +665,30 @@ func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration,
 		MarkerFile:    markerFile(log, prefix),
 		MetadataFile:  metadataFile(log, prefix),
 	}
+	if heartbeatInterval > 0 {
+		wrapperOptions.HeartbeatFile = heartbeatFile(log, prefix)
+	}
 	// TODO(fejta): use flags
-	entrypointConfigEnv, err := entrypoint.Encode(entrypoint.Options{
+	options := entrypoint.Options{
 		ArtifactDir:        artifactsDir(log),
+		SecretReferences:   secretReferences(c),
+		Vault:              vaultOptions(c, vault),
+		QuarantinedTests:   entrypointQuarantinedTests(quarantinedTests),
 		GracePeriod:        gracePeriod,
 		Options:            wrapperOptions,
 		Timeout:            timeout,
 		PropagateErrorCode: propagateErrorCode,
 		AlwaysZero:         exitZero,
 		PreviousMarker:     previousMarker,
-	})
+		HeartbeatInterval:  heartbeatInterval,
+	}
+	if recordEnvironment {
+		options.EnvironmentFile = environmentFile(log, prefix)
+		options.SecretEnv = secretEnv(c)
+		options.SecretEnvFromPrefixes = secretEnvFromPrefixes(c)
+		options.DeclaredEnv = declaredEnv(c)
+	}
+	entrypointConfigEnv, err := entrypoint.Encode(options)
 	if err != nil {
 		return nil, err
 	}
@@ -630,6 +762,40 @@ func BlobStorageOptions(dc prowapi.DecorationConfig, localMode bool) ([]coreapi.
 	return volumes, mounts, opt
 }
 
+// Caches returns the options to restore and save the caches of the job, the
+// emptyDir volumes of the cached paths, the mounts of the volumes in the
+// clonerefs and sidecar containers and their mounts in the test containers.
+// The options are nil if the job has no caches.
+func Caches(pj prowapi.ProwJob, blobStorageOptions gcsupload.Options, logMount coreapi.VolumeMount) (*cache.Options, []coreapi.Volume, []coreapi.VolumeMount, []coreapi.VolumeMount) {
+	if pj.Spec.DecorationConfig == nil || len(pj.Spec.DecorationConfig.Caches) == 0 || blobStorageOptions.GCSConfiguration == nil {
+		return nil, nil, nil, nil
+	}
+	options := &cache.Options{
+		Caches:               pj.Spec.DecorationConfig.Caches,
+		Job:                  pj.Spec.Job,
+		Bucket:               blobStorageOptions.Bucket,
+		Dir:                  cachesMountPath,
+		StateFile:            filepath.Join(logMount.MountPath, cacheStatePath),
+		StorageClientOptions: blobStorageOptions.StorageClientOptions,
+	}
+	var volumes []coreapi.Volume
+	var utilityMounts, testMounts []coreapi.VolumeMount
+	for _, c := range pj.Spec.DecorationConfig.Caches {
+		for i, path := range c.Paths {
+			name := fmt.Sprintf("cache-%s-%d", c.Name, i)
+			volumes = append(volumes, coreapi.Volume{
+				Name: name,
+				VolumeSource: coreapi.VolumeSource{
+					EmptyDir: &coreapi.EmptyDirVolumeSource{},
+				},
+			})
+			utilityMounts = append(utilityMounts, coreapi.VolumeMount{Name: name, MountPath: options.VolumeDir(c.Name, i)})
+			testMounts = append(testMounts, coreapi.VolumeMount{Name: name, MountPath: path})
+		}
+	}
+	return options, volumes, utilityMounts, testMounts
+}
+
 func InitUpload(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options, blobStorageMounts []coreapi.VolumeMount, cloneLogMount *coreapi.VolumeMount, outputMount *coreapi.VolumeMount, encodedJobSpec string) (*coreapi.Container, error) {
 	// TODO(fejta): remove encodedJobSpec
 	initUploadOptions := initupload.Options{
@@ -677,6 +843,27 @@ func LogMountAndVolume() (coreapi.VolumeMount, coreapi.Volume) {
 		}
 }
 
+// PodInfoMountAndVolume returns the canonical volume and mount used to expose
+// the user who aborted the job to the sidecar, as the annotation of the pod
+// can change after the pod started.
+func PodInfoMountAndVolume() (coreapi.VolumeMount, coreapi.Volume) {
+	return coreapi.VolumeMount{
+			Name:      podInfoMountName,
+			MountPath: podInfoMountPath,
+			ReadOnly:  true,
+		}, coreapi.Volume{
+			Name: podInfoMountName,
+			VolumeSource: coreapi.VolumeSource{
+				DownwardAPI: &coreapi.DownwardAPIVolumeSource{
+					Items: []coreapi.DownwardAPIVolumeFile{{
+						Path:     abortedByFileName,
+						FieldRef: &coreapi.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", prowapi.AbortedByAnnotation)},
+					}},
+				},
+			},
+		}
+}
+
 // CodeMountAndVolume returns the canonical volume and mount used to share code under test
 func CodeMountAndVolume() (coreapi.VolumeMount, coreapi.Volume) {
 	return coreapi.VolumeMount{
@@ -734,12 +921,29 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 
 	blobStorageVolumes, blobStorageMounts, blobStorageOptions := BlobStorageOptions(*pj.Spec.DecorationConfig, localMode)
 
-	cloner, refs, cloneVolumes, err := CloneRefs(*pj, codeMount, logMount)
+	// Caches aren't stored when the artifacts are copied to a local directory.
+	var caches *cache.Options
+	var cacheVolumes []coreapi.Volume
+	var cacheMounts, cacheTestMounts []coreapi.VolumeMount
+	if !localMode {
+		caches, cacheVolumes, cacheMounts, cacheTestMounts = Caches(*pj, blobStorageOptions, logMount)
+	}
+
+	cloner, refs, cloneVolumes, err := CloneRefs(*pj, codeMount, logMount, caches)
 	if err != nil {
 		return fmt.Errorf("create clonerefs container: %w", err)
 	}
+	if cloner == nil {
+		// Caches are restored by clonerefs, so jobs that don't clone refs
+		// don't use them.
+		caches = nil
+	}
 	var cloneLogMount *coreapi.VolumeMount
 	if cloner != nil {
+		if caches != nil {
+			cloner.VolumeMounts = append(cloner.VolumeMounts, blobStorageMounts...)
+			cloner.VolumeMounts = append(cloner.VolumeMounts, cacheMounts...)
+		}
 		spec.InitContainers = append([]coreapi.Container{*cloner}, spec.InitContainers...)
 		cloneLogMount = &logMount
 	}
@@ -776,6 +980,7 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 		exitZero           = false
 		propagateErrorCode = false
 	)
+	recordEnvironment := pj.Spec.DecorationConfig.RecordEnvironment != nil && *pj.Spec.DecorationConfig.RecordEnvironment
 	var secretVolumeMounts []coreapi.VolumeMount
 	var wrappers []wrapper.Options
 
@@ -784,7 +989,7 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 		if len(spec.Containers) == 1 {
 			prefix = ""
 		}
-		wrapperOptions, err := InjectEntrypoint(&spec.Containers[i], pj.Spec.DecorationConfig.Timeout.Get(), pj.Spec.DecorationConfig.GracePeriod.Get(), prefix, previous, propagateErrorCode, exitZero, logMount, toolsMount)
+		wrapperOptions, err := InjectEntrypoint(&spec.Containers[i], pj.Spec.DecorationConfig.Timeout.Get(), pj.Spec.DecorationConfig.GracePeriod.Get(), HeartbeatInterval(pj.Spec.DecorationConfig), prefix, previous, propagateErrorCode, exitZero, recordEnvironment, pj.Spec.DecorationConfig.Vault, pj.Spec.DecorationConfig.QuarantinedTests, logMount, toolsMount)
 		if err != nil {
 			return fmt.Errorf("wrap container: %w", err)
 		}
@@ -798,12 +1003,20 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 
 	ignoreInterrupts := pj.Spec.DecorationConfig.UploadIgnoresInterrupts != nil && *pj.Spec.DecorationConfig.UploadIgnoresInterrupts
 
-	sidecar, err := Sidecar(pj.Spec.DecorationConfig, blobStorageOptions, blobStorageMounts, logMount, outputMount, encodedJobSpec, !RequirePassingEntries, ignoreInterrupts, secretVolumeMounts, wrappers...)
+	sidecar, err := Sidecar(pj.Spec.DecorationConfig, blobStorageOptions, blobStorageMounts, logMount, outputMount, encodedJobSpec, !RequirePassingEntries, ignoreInterrupts, secretVolumeMounts, caches, wrappers...)
 	if err != nil {
 		return fmt.Errorf("create sidecar: %w", err)
 	}
+	if caches != nil {
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, cacheMounts...)
+		for i, container := range spec.Containers {
+			spec.Containers[i].VolumeMounts = append(container.VolumeMounts, cacheTestMounts...)
+		}
+		spec.Volumes = append(spec.Volumes, cacheVolumes...)
+	}
 
-	spec.Volumes = append(spec.Volumes, logVolume, toolsVolume)
+	_, podInfoVolume := PodInfoMountAndVolume()
+	spec.Volumes = append(spec.Volumes, logVolume, toolsVolume, podInfoVolume)
 	spec.Volumes = append(spec.Volumes, blobStorageVolumes...)
 	if outputVolume != nil {
 		spec.Volumes = append(spec.Volumes, *outputVolume)
@@ -903,7 +1116,7 @@ const (
 	RequirePassingEntries = true
 )
 
-func Sidecar(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options, blobStorageMounts []coreapi.VolumeMount, logMount coreapi.VolumeMount, outputMount *coreapi.VolumeMount, encodedJobSpec string, requirePassingEntries, ignoreInterrupts bool, secretVolumeMounts []coreapi.VolumeMount, wrappers ...wrapper.Options) (*coreapi.Container, error) {
+func Sidecar(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options, blobStorageMounts []coreapi.VolumeMount, logMount coreapi.VolumeMount, outputMount *coreapi.VolumeMount, encodedJobSpec string, requirePassingEntries, ignoreInterrupts bool, secretVolumeMounts []coreapi.VolumeMount, caches *cache.Options, wrappers ...wrapper.Options) (*coreapi.Container, error) {
 	var secretVolumePaths []string
 	for _, volumeMount := range secretVolumeMounts {
 		secretVolumePaths = append(secretVolumePaths, volumeMount.MountPath)
@@ -922,14 +1135,19 @@ func Sidecar(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options, blo
 		GcsOptions:       &gcsOptions,
 		Entries:          wrappers,
 		EntryError:       requirePassingEntries,
-		IgnoreInterrupts: ignoreInterrupts,
-		CensoringOptions: censoringOptions,
+		IgnoreInterrupts:     ignoreInterrupts,
+		InterruptGracePeriod: config.GracePeriod.Get(),
+		AbortedByFile:        filepath.Join(podInfoMountPath, abortedByFileName),
+		CensoringOptions:     censoringOptions,
+		HeartbeatInterval:    HeartbeatInterval(config),
+		Caches:               caches,
 	})
 
 	if err != nil {
 		return nil, err
 	}
-	mounts := []coreapi.VolumeMount{logMount}
+	podInfoMount, _ := PodInfoMountAndVolume()
+	mounts := []coreapi.VolumeMount{logMount, podInfoMount}
 	mounts = append(mounts, blobStorageMounts...)
 	mounts = append(mounts, secretVolumeMounts...)
 	if outputMount != nil {
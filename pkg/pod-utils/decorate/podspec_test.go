@@ -542,7 +542,7 @@ func TestCloneRefs(t *testing.T) {
 			if tc.codeMountOverride != nil {
 				cm = *tc.codeMountOverride
 			}
-			actual, refs, volumes, err := CloneRefs(tc.pj, cm, lm)
+			actual, refs, volumes, err := CloneRefs(tc.pj, cm, lm, nil)
 			switch {
 			case err != nil:
 				if !tc.err {
@@ -1425,7 +1425,7 @@ func TestSidecar(t *testing.T) {
 				testCase.blobStorageMounts, testCase.logMount, testCase.outputMount,
 				testCase.encodedJobSpec,
 				testCase.requirePassingEntries, testCase.ignoreInterrupts,
-				testCase.secretVolumeMounts, testCase.wrappers...,
+				testCase.secretVolumeMounts, nil, testCase.wrappers...,
 			)
 			if err != nil {
 				t.Fatalf("%s: got an error from Sidecar(): %v", testCase.name, err)
@@ -1440,6 +1440,7 @@ func TestDecorate(t *testing.T) {
 	defaultServiceAccountName := "default-sa"
 	censor := true
 	ignoreInterrupts := true
+	recordEnvironment := true
 	resourcePtr := func(s string) *resource.Quantity {
 		q := resource.MustParse(s)
 		return &q
@@ -1497,6 +1498,161 @@ func TestDecorate(t *testing.T) {
 			},
 			rawEnv: map[string]string{"custom": "env"},
 		},
+		{
+			name: "caches",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/ls"}, Args: []string{"-l", "-a"}},
+				},
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					Job: "pull-repo-test",
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+						Caches: []prowapi.Cache{
+							{Name: "go", Paths: []string{"/root/go/pkg/mod", "/root/.cache/go-build"}, Key: `{{.Repo}}-{{hashFiles "go.sum"}}`},
+						},
+					},
+					Refs: &prowapi.Refs{
+						Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234",
+						Pulls: []prowapi.Pull{{Number: 1, SHA: "aksdjhfkds"}},
+					},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "hang timeout",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/ls"}, Args: []string{"-l", "-a"}},
+				},
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					Job: "ci-test",
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Hour},
+						GracePeriod: &prowapi.Duration{Duration: time.Minute},
+						HangTimeout: &metav1.Duration{Duration: 20 * time.Minute},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "secret references",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{
+						Name:    "test",
+						Command: []string{"/bin/ls"},
+						Args:    []string{"-l", "-a"},
+						Env: []coreapi.EnvVar{
+							{Name: "TOKEN", Value: "vault://secret/data/ci#token"},
+							{Name: "KEY", Value: "kms://projects/p/locations/global/keyRings/ci/cryptoKeys/jobs:c2VjcmV0"},
+							{Name: "PLAIN", Value: "value"},
+						},
+					},
+				},
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					Job: "ci-test",
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Hour},
+						GracePeriod: &prowapi.Duration{Duration: time.Minute},
+						Vault:       &prowapi.VaultConfig{Address: "https://vault.example.com", Role: "ci"},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "record environment",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{
+						Name:    "test",
+						Command: []string{"/bin/ls"},
+						Args:    []string{"-l", "-a"},
+						Env: []coreapi.EnvVar{
+							{Name: "PLAIN", Value: "value"},
+							{Name: "PASS", ValueFrom: &coreapi.EnvVarSource{SecretKeyRef: &coreapi.SecretKeySelector{LocalObjectReference: coreapi.LocalObjectReference{Name: "creds"}, Key: "pass"}}},
+						},
+						EnvFrom: []coreapi.EnvFromSource{
+							{Prefix: "CREDS_", SecretRef: &coreapi.SecretEnvSource{LocalObjectReference: coreapi.LocalObjectReference{Name: "creds"}}},
+							{ConfigMapRef: &coreapi.ConfigMapEnvSource{LocalObjectReference: coreapi.LocalObjectReference{Name: "settings"}}},
+						},
+					},
+				},
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					Job: "ci-test",
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:           &prowapi.Duration{Duration: time.Hour},
+						GracePeriod:       &prowapi.Duration{Duration: time.Minute},
+						RecordEnvironment: &recordEnvironment,
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
 		{
 			name: "enforcing memory limit",
 			spec: &coreapi.PodSpec{
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/ptr"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// partWriter is a dataWriter that can upload a file in parts concurrently.
+type partWriter interface {
+	// usesParts returns true if a file of the size is uploaded in parts.
+	usesParts(size int64) bool
+	// uploadParts uploads the file in parts and composes them into the
+	// object, applying the options to it.
+	uploadParts(file string, size int64, opts pkgio.WriterOptions) error
+}
+
+var _ partWriter = &openerObjectWriter{}
+
+func (w *openerObjectWriter) usesParts(size int64) bool {
+	if w.uploadOptions.PartThreshold <= 0 || size < w.uploadOptions.PartThreshold {
+		return false
+	}
+	// Compressed files can't be split, as their size isn't known upfront.
+	if w.compressFileType {
+		return false
+	}
+	_, ok := w.Opener.(pkgio.Composer)
+	return ok && strings.HasPrefix(w.Bucket, providers.GS+"://")
+}
+
+// partSize returns the size of the parts of a file of the size, so that it
+// has no more parts than can be composed at once.
+func partSize(size, configured int64) int64 {
+	if configured <= 0 {
+		configured = defaultPartSize
+	}
+	if min := (size + maxParts - 1) / maxParts; configured < min {
+		return min
+	}
+	return configured
+}
+
+func (w *openerObjectWriter) uploadParts(file string, size int64, opts pkgio.WriterOptions) error {
+	dest := w.fullUploadPath()
+	log := logrus.WithField("dest", dest)
+	partSize := partSize(size, w.uploadOptions.PartSize)
+	count := int((size + partSize - 1) / partSize)
+	parts := make([]string, count)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s.part-%d-of-%d", dest, i+1, count)
+	}
+	log.WithField("parts", count).Info("Uploading in parts")
+	defer w.deleteParts(parts, log)
+
+	sem := semaphore.NewWeighted(int64(w.uploadOptions.concurrency()))
+	group := &sync.WaitGroup{}
+	errs := make([]error, count)
+	for i := range parts {
+		offset := int64(i) * partSize
+		length := partSize
+		if offset+length > size {
+			length = size - offset
+		}
+		group.Add(1)
+		go func(i int, offset, length int64) {
+			defer group.Done()
+			// Every part is retried on its own, so that a flaky connection
+			// doesn't start the whole file over.
+			for retryIndex := 1; retryIndex <= retryCount; retryIndex++ {
+				errs[i] = func() error {
+					if err := sem.Acquire(w.Context, 1); err != nil {
+						return err
+					}
+					defer sem.Release(1)
+					return w.uploadPart(file, parts[i], offset, length)
+				}()
+				if errs[i] == nil {
+					return
+				}
+				log.WithError(errs[i]).WithField("part", parts[i]).WithField("retry_attempt", retryIndex).Debug("Failed to upload part")
+				if retryIndex < retryCount {
+					time.Sleep(time.Duration(retryIndex*retryIndex) * time.Second)
+				}
+			}
+		}(i, offset, length)
+	}
+	group.Wait()
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return fmt.Errorf("upload parts: %w", err)
+	}
+
+	attrs := append(append([]pkgio.WriterOptions{}, w.opts...), opts)
+	if err := w.Opener.(pkgio.Composer).Compose(w.Context, dest, parts, attrs...); err != nil {
+		return fmt.Errorf("compose parts: %w", err)
+	}
+	return nil
+}
+
+func (w *openerObjectWriter) uploadPart(file, part string, offset, length int64) (e error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	writer, err := w.Opener.Writer(w.Context, part, pkgio.WriterOptions{BufferSize: ptr.To(length)})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := writer.Close(); err != nil && e == nil {
+			e = err
+		}
+	}()
+	var out io.Writer = writer
+	if w.limiter != nil {
+		out = &rateLimitedWriter{ctx: w.Context, writer: writer, limiter: w.limiter}
+	}
+	_, err = io.Copy(out, io.NewSectionReader(f, offset, length))
+	return err
+}
+
+// deleteParts deletes the uploaded parts, which are no longer needed once
+// they are composed or failed.
+func (w *openerObjectWriter) deleteParts(parts []string, log *logrus.Entry) {
+	for _, part := range parts {
+		if err := w.Opener.Delete(w.Context, part); err != nil && !pkgio.IsNotExist(err) {
+			log.WithError(err).WithField("part", part).Warn("Failed to delete part")
+		}
+	}
+}
+
+// rateLimitedWriter waits for the limiter before writing, in chunks of at
+// most the burst of the limiter.
+type rateLimitedWriter struct {
+	ctx     context.Context
+	writer  io.Writer
+	limiter *rate.Limiter
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if burst := w.limiter.Burst(); len(chunk) > burst {
+			chunk = chunk[:burst]
+		}
+		if err := w.limiter.WaitN(w.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := w.writer.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
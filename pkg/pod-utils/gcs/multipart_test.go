@@ -0,0 +1,261 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/time/rate"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/fakeopener"
+)
+
+// composingOpener keeps the objects in memory and composes them.
+type composingOpener struct {
+	pkgio.Opener
+	sync.Mutex
+	objects map[string][]byte
+	attrs   map[string]pkgio.WriterOptions
+	// failures is how many times writing the object fails before it works.
+	failures map[string]int
+}
+
+type memoryWriter struct {
+	bytes.Buffer
+	path   string
+	opener *composingOpener
+}
+
+func (w *memoryWriter) Close() error {
+	w.opener.Lock()
+	defer w.opener.Unlock()
+	if w.opener.failures[w.path] > 0 {
+		w.opener.failures[w.path]--
+		return errors.New("connection reset")
+	}
+	w.opener.objects[w.path] = w.Bytes()
+	return nil
+}
+
+func (o *composingOpener) Writer(_ context.Context, path string, _ ...pkgio.WriterOptions) (pkgio.WriteCloser, error) {
+	return &memoryWriter{path: path, opener: o}, nil
+}
+
+func (o *composingOpener) Delete(_ context.Context, path string) error {
+	o.Lock()
+	defer o.Unlock()
+	if _, ok := o.objects[path]; !ok {
+		return os.ErrNotExist
+	}
+	delete(o.objects, path)
+	return nil
+}
+
+func (o *composingOpener) Compose(_ context.Context, dest string, sources []string, opts ...pkgio.WriterOptions) error {
+	o.Lock()
+	defer o.Unlock()
+	var composed []byte
+	for _, source := range sources {
+		object, ok := o.objects[source]
+		if !ok {
+			return os.ErrNotExist
+		}
+		composed = append(composed, object...)
+	}
+	o.objects[dest] = composed
+	attrs := pkgio.WriterOptions{}
+	for _, opt := range opts {
+		opt.Apply(&attrs)
+	}
+	o.attrs[dest] = attrs
+	return nil
+}
+
+func TestPartSize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		size       int64
+		configured int64
+		expected   int64
+	}{
+		{name: "default", size: 100 * 1024 * 1024, expected: defaultPartSize},
+		{name: "configured", size: 100, configured: 10, expected: 10},
+		{name: "grown to at most 32 parts", size: 1000, configured: 10, expected: 32},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := partSize(tc.size, tc.configured); actual != tc.expected {
+				t.Errorf("expected part size %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUsesParts(t *testing.T) {
+	opts := UploadOptions{PartThreshold: 100}
+	testCases := []struct {
+		name     string
+		writer   *openerObjectWriter
+		size     int64
+		expected bool
+	}{
+		{
+			name:     "large file on GCS",
+			writer:   &openerObjectWriter{Opener: &composingOpener{}, Bucket: "gs://bucket", uploadOptions: opts},
+			size:     100,
+			expected: true,
+		},
+		{
+			name:   "small file",
+			writer: &openerObjectWriter{Opener: &composingOpener{}, Bucket: "gs://bucket", uploadOptions: opts},
+			size:   99,
+		},
+		{
+			name:   "disabled",
+			writer: &openerObjectWriter{Opener: &composingOpener{}, Bucket: "gs://bucket"},
+			size:   100,
+		},
+		{
+			name:   "compressed file",
+			writer: &openerObjectWriter{Opener: &composingOpener{}, Bucket: "gs://bucket", uploadOptions: opts, compressFileType: true},
+			size:   100,
+		},
+		{
+			name:   "S3",
+			writer: &openerObjectWriter{Opener: &composingOpener{}, Bucket: "s3://bucket", uploadOptions: opts},
+			size:   100,
+		},
+		{
+			name:   "opener can't compose",
+			writer: &openerObjectWriter{Opener: &fakeopener.FakeOpener{}, Bucket: "gs://bucket", uploadOptions: opts},
+			size:   100,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.writer.usesParts(tc.size); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFileUploadInParts(t *testing.T) {
+	content := []byte("0123456789")
+	file := filepath.Join(t.TempDir(), "artifact.bin")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	testCases := []struct {
+		name        string
+		failures    map[string]int
+		expectedErr bool
+	}{
+		{
+			name: "parts are composed",
+		},
+		{
+			name:     "flaky part is retried on its own",
+			failures: map[string]int{"gs://bucket/artifact.bin.part-2-of-3": 1},
+		},
+		{
+			name:        "failing part fails the upload",
+			failures:    map[string]int{"gs://bucket/artifact.bin.part-3-of-3": retryCount},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opener := &composingOpener{objects: map[string][]byte{}, attrs: map[string]pkgio.WriterOptions{}, failures: tc.failures}
+			writer := &openerObjectWriter{
+				Opener:        opener,
+				Context:       context.Background(),
+				Bucket:        "gs://bucket",
+				Dest:          "artifact.bin",
+				limiter:       rate.NewLimiter(rate.Inf, 2),
+				uploadOptions: UploadOptions{PartThreshold: 5, PartSize: 4},
+			}
+			contentType := "application/octet-stream"
+			err := FileUploadWithOptions(file, pkgio.WriterOptions{ContentType: &contentType})(writer)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+
+			var objects []string
+			for object := range opener.objects {
+				objects = append(objects, object)
+			}
+			sort.Strings(objects)
+			if tc.expectedErr {
+				if len(objects) != 0 {
+					t.Errorf("expected the parts to be deleted, got %v", objects)
+				}
+				return
+			}
+			if diff := cmp.Diff([]string{"gs://bucket/artifact.bin"}, objects); diff != "" {
+				t.Errorf("unexpected objects (-want +got):\n%s", diff)
+			}
+			if actual := opener.objects["gs://bucket/artifact.bin"]; !bytes.Equal(content, actual) {
+				t.Errorf("expected the composed object to be %q, got %q", content, actual)
+			}
+			if actual := opener.attrs["gs://bucket/artifact.bin"].ContentType; actual == nil || *actual != contentType {
+				t.Errorf("expected the composed object to have content type %s, got %v", contentType, actual)
+			}
+		})
+	}
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	var chunks []string
+	out := writerFunc(func(p []byte) (int, error) {
+		chunks = append(chunks, string(p))
+		return len(p), nil
+	})
+	w := &rateLimitedWriter{ctx: context.Background(), writer: out, limiter: rate.NewLimiter(rate.Inf, 4)}
+	n, err := w.Write([]byte("0123456789"))
+	if err != nil || n != 10 {
+		t.Fatalf("expected to write 10 bytes, wrote %d: %v", n, err)
+	}
+	if diff := cmp.Diff([]string{"0123", "4567", "89"}, chunks); diff != "" {
+		t.Errorf("unexpected chunks (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = &rateLimitedWriter{ctx: ctx, writer: out, limiter: rate.NewLimiter(1, 1)}
+	w.limiter.AllowN(time.Now(), 1)
+	if _, err := w.Write([]byte(strings.Repeat("x", 2))); err == nil {
+		t.Error("expected the write to fail once the context is done")
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
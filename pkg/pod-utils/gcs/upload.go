@@ -35,8 +35,10 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
 )
@@ -50,10 +52,72 @@ type destToWriter func(dest string) dataWriter
 
 const retryCount = 4
 
+const (
+	defaultConcurrency = 4
+	defaultPartSize    = 32 * 1024 * 1024
+	// maxParts is how many objects GCS composes at once.
+	maxParts = 32
+	// maxBurst is how many bytes are written at once when the bandwidth is
+	// limited.
+	maxBurst = 1024 * 1024
+)
+
+// UploadOptions tune how the targets are uploaded. The zero value uploads 4
+// targets at once without limiting the bandwidth nor splitting files.
+type UploadOptions struct {
+	// Concurrency is how many targets, or parts of a file, are uploaded at
+	// once.
+	Concurrency int
+	// BytesPerSecond caps the bandwidth of all uploads together.
+	BytesPerSecond int64
+	// PartThreshold is the size from which files are uploaded in parts to
+	// GCS, which are composed into the object.
+	PartThreshold int64
+	// PartSize is the size of the parts, grown so that no file has more
+	// than 32 parts.
+	PartSize int64
+}
+
+// UploadOptionsFor returns the upload options of the GCS configuration.
+func UploadOptionsFor(gcsConfig *prowapi.GCSConfiguration) UploadOptions {
+	if gcsConfig == nil {
+		return UploadOptions{}
+	}
+	return UploadOptions{
+		Concurrency:    gcsConfig.UploadConcurrency,
+		BytesPerSecond: gcsConfig.MaxUploadBytesPerSecond,
+		PartThreshold:  gcsConfig.MultipartUploadThreshold,
+		PartSize:       gcsConfig.MultipartUploadPartSize,
+	}
+}
+
+func (o UploadOptions) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return defaultConcurrency
+}
+
+func (o UploadOptions) limiter() *rate.Limiter {
+	if o.BytesPerSecond <= 0 {
+		return nil
+	}
+	burst := o.BytesPerSecond
+	if burst > maxBurst {
+		burst = maxBurst
+	}
+	return rate.NewLimiter(rate.Limit(o.BytesPerSecond), int(burst))
+}
+
 // Upload uploads all the data in the uploadTargets map to blob storage in parallel.
 // The map is keyed on blob storage path under the bucket.
 // Files with an extension in the compressFileTypes list will be compressed prior to uploading
 func Upload(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile string, compressFileTypes []string, uploadTargets map[string]UploadFunc) error {
+	return UploadWithOptions(ctx, bucket, gcsCredentialsFile, s3CredentialsFile, compressFileTypes, UploadOptions{}, uploadTargets)
+}
+
+// UploadWithOptions uploads like Upload, tuned by the options.
+func UploadWithOptions(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile string, compressFileTypes []string, opts UploadOptions, uploadTargets map[string]UploadFunc) error {
 	parsedBucket, err := url.Parse(bucket)
 	if err != nil {
 		return fmt.Errorf("cannot parse bucket name %s: %w", bucket, err)
@@ -66,11 +130,12 @@ func Upload(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile s
 	if err != nil {
 		return fmt.Errorf("new opener: %w", err)
 	}
+	limiter := opts.limiter()
 	dtw := func(dest string) dataWriter {
 		compressFileType := shouldCompressFileType(dest, sets.New[string](compressFileTypes...))
-		return &openerObjectWriter{Opener: opener, Context: ctx, Bucket: parsedBucket.String(), Dest: dest, compressFileType: compressFileType}
+		return &openerObjectWriter{Opener: opener, Context: ctx, Bucket: parsedBucket.String(), Dest: dest, compressFileType: compressFileType, limiter: limiter, uploadOptions: opts}
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, opts.concurrency(), uploadTargets)
 }
 
 func shouldCompressFileType(dest string, compressFileTypes sets.Set[string]) bool {
@@ -91,13 +156,13 @@ func LocalExport(ctx context.Context, exportDir string, uploadTargets map[string
 	dtw := func(dest string) dataWriter {
 		return &openerObjectWriter{Opener: opener, Context: ctx, Bucket: exportDir, Dest: dest}
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, defaultConcurrency, uploadTargets)
 }
 
-func upload(dtw destToWriter, uploadTargets map[string]UploadFunc) error {
+func upload(dtw destToWriter, concurrency int, uploadTargets map[string]UploadFunc) error {
 	errCh := make(chan error, len(uploadTargets))
 	group := &sync.WaitGroup{}
-	sem := semaphore.NewWeighted(4)
+	sem := semaphore.NewWeighted(int64(concurrency))
 	group.Add(len(uploadTargets))
 	for dest, upload := range uploadTargets {
 		writer := dtw(dest)
@@ -158,6 +223,12 @@ func FileUpload(file string) UploadFunc {
 func FileUploadWithOptions(file string, opts pkgio.WriterOptions) UploadFunc {
 	return func(writer dataWriter) error {
 		if fi, err := os.Stat(file); err == nil {
+			if pw, ok := writer.(partWriter); ok && pw.usesParts(fi.Size()) {
+				if err := pw.uploadParts(file, fi.Size(), opts); err != nil {
+					return fmt.Errorf("upload error: %w", err)
+				}
+				return nil
+			}
 			opts.BufferSize = ptr.To(fi.Size())
 			if *opts.BufferSize > 25*1024*1024 {
 				*opts.BufferSize = 25 * 1024 * 1024
@@ -239,9 +310,12 @@ type openerObjectWriter struct {
 	Bucket           string
 	Dest             string
 	compressFileType bool
-	opts             []pkgio.WriterOptions
-	writer           pkgio.Writer
-	closers          []pkgio.Closer
+	// limiter is shared by all writers to cap their bandwidth, if set.
+	limiter       *rate.Limiter
+	uploadOptions UploadOptions
+	opts          []pkgio.WriterOptions
+	writer        pkgio.Writer
+	closers       []pkgio.Closer
 }
 
 func (w *openerObjectWriter) Write(p []byte) (n int, err error) {
@@ -266,12 +340,16 @@ func (w *openerObjectWriter) Write(p []byte) (n int, err error) {
 		if err != nil {
 			return 0, err
 		}
+		var out io.Writer = storageWriter
+		if w.limiter != nil {
+			out = &rateLimitedWriter{ctx: w.Context, writer: storageWriter, limiter: w.limiter}
+		}
 		if shouldCompressFile {
-			zipWriter := gzip.NewWriter(storageWriter)
+			zipWriter := gzip.NewWriter(out)
 			w.writer = zipWriter
 			w.closers = append(w.closers, zipWriter)
 		} else {
-			w.writer = storageWriter
+			w.writer = out
 		}
 		// The storage closer needs to be last in the list to close in the correct order
 		w.closers = append(w.closers, storageWriter)
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HeartbeatFileName is the name the sidecar uploads the latest heartbeat of
+// the test process under, if the job has a hang timeout.
+const HeartbeatFileName = "heartbeat.json"
+
+// Heartbeat shows that the entrypoint is alive and whether the test
+// process still makes progress.
+type Heartbeat struct {
+	// Timestamp is when the heartbeat was written.
+	Timestamp time.Time `json:"timestamp"`
+	// LastOutput is when the test process last wrote to stdout or stderr,
+	// or when it started if it didn't write anything yet.
+	LastOutput time.Time `json:"last_output"`
+	// Finished is set once the test process exited, after which no more
+	// heartbeats are written.
+	Finished bool `json:"finished,omitempty"`
+}
+
+// Stalled returns how long the test process went without output when
+// the heartbeat was written.
+func (h Heartbeat) Stalled() time.Duration {
+	if h.Finished {
+		return 0
+	}
+	return h.Timestamp.Sub(h.LastOutput)
+}
+
+// HeartbeatName is the name of the uploaded heartbeat of a container, in
+// the same way build logs are named.
+func HeartbeatName(containers int, container string) string {
+	if containers > 1 {
+		return fmt.Sprintf("%s-%s", container, HeartbeatFileName)
+	}
+	return HeartbeatFileName
+}
+
+// WriteHeartbeat replaces the heartbeat file atomically, so readers never
+// see a partial heartbeat.
+func WriteHeartbeat(path string, heartbeat Heartbeat) error {
+	raw, err := json.Marshal(heartbeat)
+	if err != nil {
+		return fmt.Errorf("marshal heartbeat: %w", err)
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("create temp heartbeat file: %w", err)
+	}
+	if _, err := tempFile.Write(raw); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("write temp heartbeat file %s: %w", tempFile.Name(), err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("close temp heartbeat file %s: %w", tempFile.Name(), err)
+	}
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("move heartbeat file to %s: %w", path, err)
+	}
+	return nil
+}
@@ -57,6 +57,10 @@ type Options struct {
 	// Prow will parse the file and merge it into
 	// the `metadata` field in finished.json
 	MetadataFile string `json:"metadata_file"`
+
+	// HeartbeatFile is written periodically by the entrypoint
+	// with the time the test process last wrote output, if set.
+	HeartbeatFile string `json:"heartbeat_file,omitempty"`
 }
 
 type MarkerResult struct {
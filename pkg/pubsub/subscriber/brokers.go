@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// brokerClientInterface opens the subscriptions of Kafka and AMQP triggers.
+type brokerClientInterface interface {
+	kafkaSubscription(trigger config.KafkaTrigger, topic string) (subscriptionInterface, error)
+	amqpSubscription(trigger config.AMQPTrigger, queue string) (subscriptionInterface, error)
+}
+
+// brokerClient connects to the Kafka and AMQP brokers of triggers.
+type brokerClient struct{}
+
+// kafkaSubscription consumes a topic as a member of the consumer group of its
+// trigger. Messages are handled one after the other, and their offset is
+// committed once they were handled, so messages are redelivered if sub stops
+// while handling them.
+type kafkaSubscription struct {
+	name   string
+	config kafka.ReaderConfig
+}
+
+func (c *brokerClient) kafkaSubscription(trigger config.KafkaTrigger, topic string) (subscriptionInterface, error) {
+	dialer := &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true}
+	var err error
+	if trigger.TLS != nil {
+		if dialer.TLS, err = tlsConfig(trigger.TLS); err != nil {
+			return nil, err
+		}
+	}
+	if trigger.SASL != nil {
+		if dialer.SASLMechanism, err = saslMechanism(trigger.SASL); err != nil {
+			return nil, err
+		}
+	}
+	return &kafkaSubscription{
+		name: fmt.Sprintf("kafka:%s/%s", trigger.ConsumerGroup, topic),
+		config: kafka.ReaderConfig{
+			Brokers: trigger.Brokers,
+			GroupID: trigger.ConsumerGroup,
+			Topic:   topic,
+			Dialer:  dialer,
+			// Commit synchronously once messages are handled.
+			CommitInterval: 0,
+		},
+	}, nil
+}
+
+func saslMechanism(cfg *config.KafkaSASL) (sasl.Mechanism, error) {
+	username, err := readSecret(cfg.UsernameFile)
+	if err != nil {
+		return nil, err
+	}
+	password, err := readSecret(cfg.PasswordFile)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Mechanism {
+	case config.KafkaSASLPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case config.KafkaSASLSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case config.KafkaSASLSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", cfg.Mechanism)
+}
+
+func (s *kafkaSubscription) string() string {
+	return s.name
+}
+
+func (s *kafkaSubscription) receive(ctx context.Context, f func(context.Context, messageInterface)) error {
+	reader := kafka.NewReader(s.config)
+	defer reader.Close()
+	for {
+		fetched, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+		msg := &kafkaMessage{Message: fetched}
+		f(ctx, msg)
+		if !msg.acked {
+			continue
+		}
+		if err := reader.CommitMessages(ctx, fetched); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to commit offset %d of partition %d: %w", fetched.Offset, fetched.Partition, err)
+		}
+	}
+}
+
+// kafkaMessage is a Kafka message whose headers are its attributes.
+type kafkaMessage struct {
+	kafka.Message
+	acked bool
+}
+
+func (m *kafkaMessage) getAttributes() map[string]string {
+	attributes := make(map[string]string, len(m.Headers))
+	for _, header := range m.Headers {
+		attributes[header.Key] = string(header.Value)
+	}
+	return attributes
+}
+
+func (m *kafkaMessage) getPayload() []byte {
+	return m.Value
+}
+
+func (m *kafkaMessage) getID() string {
+	return fmt.Sprintf("%s/%d/%d", m.Topic, m.Partition, m.Offset)
+}
+
+func (m *kafkaMessage) ack() {
+	m.acked = true
+}
+
+// nack leaves the offset of the message uncommitted. As the offsets of later
+// messages of the partition are committed, Kafka only redelivers it if sub
+// stops before handling another message.
+func (m *kafkaMessage) nack() {}
+
+// amqpSubscription consumes a queue. Up to the prefetch count of the trigger
+// messages are handled at a time, and messages are acknowledged once they
+// were handled, so the broker redelivers them if sub stops while handling
+// them.
+type amqpSubscription struct {
+	name     string
+	url      string
+	config   amqp.Config
+	queue    string
+	prefetch int
+}
+
+func (c *brokerClient) amqpSubscription(trigger config.AMQPTrigger, queue string) (subscriptionInterface, error) {
+	cfg := amqp.Config{Locale: "en_US", Properties: amqp.NewConnectionProperties()}
+	cfg.Properties.SetClientConnectionName("prow-sub")
+	if trigger.UsernameFile != "" {
+		username, err := readSecret(trigger.UsernameFile)
+		if err != nil {
+			return nil, err
+		}
+		password, err := readSecret(trigger.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: username, Password: password}}
+	}
+	if trigger.TLS != nil {
+		var err error
+		if cfg.TLSClientConfig, err = tlsConfig(trigger.TLS); err != nil {
+			return nil, err
+		}
+	}
+	return &amqpSubscription{
+		name:     "amqp:" + queue,
+		url:      trigger.URL,
+		config:   cfg,
+		queue:    queue,
+		prefetch: trigger.Prefetch,
+	}, nil
+}
+
+func (s *amqpSubscription) string() string {
+	return s.name
+}
+
+func (s *amqpSubscription) receive(ctx context.Context, f func(context.Context, messageInterface)) error {
+	conn, err := amqp.DialConfig(s.url, s.config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the broker: %w", err)
+	}
+	defer conn.Close()
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := channel.Qos(s.prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch count: %w", err)
+	}
+	deliveries, err := channel.Consume(s.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %w", s.queue, err)
+	}
+
+	// Let the messages being handled be acknowledged before closing the
+	// connection.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return errors.New("the broker closed the channel")
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f(ctx, &amqpMessage{Delivery: delivery})
+			}()
+		}
+	}
+}
+
+// amqpMessage is an AMQP message whose string headers are its attributes.
+type amqpMessage struct {
+	amqp.Delivery
+}
+
+func (m *amqpMessage) getAttributes() map[string]string {
+	attributes := make(map[string]string, len(m.Headers))
+	for key, value := range m.Headers {
+		switch v := value.(type) {
+		case string:
+			attributes[key] = v
+		case []byte:
+			attributes[key] = string(v)
+		}
+	}
+	return attributes
+}
+
+func (m *amqpMessage) getPayload() []byte {
+	return m.Body
+}
+
+func (m *amqpMessage) getID() string {
+	if m.MessageId != "" {
+		return m.MessageId
+	}
+	return strconv.FormatUint(m.DeliveryTag, 10)
+}
+
+func (m *amqpMessage) ack() {
+	if err := m.Ack(false); err != nil {
+		logrus.WithError(err).WithField("amqp-id", m.getID()).Warn("Failed to acknowledge message, it will be redelivered.")
+	}
+}
+
+func (m *amqpMessage) nack() {
+	if err := m.Nack(false, true); err != nil {
+		logrus.WithError(err).WithField("amqp-id", m.getID()).Warn("Failed to reject message.")
+	}
+}
+
+func readSecret(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func tlsConfig(cfg *config.BrokerTLS) (*tls.Config, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
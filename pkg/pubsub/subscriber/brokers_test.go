@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+type fakeBrokerClient struct {
+	messageChan   chan fakeMessage
+	subscriptions []string
+}
+
+func (c *fakeBrokerClient) kafkaSubscription(trigger config.KafkaTrigger, topic string) (subscriptionInterface, error) {
+	c.subscriptions = append(c.subscriptions, "kafka:"+topic)
+	return &fakeSubscription{name: "kafka:" + topic, messageChan: c.messageChan}, nil
+}
+
+func (c *fakeBrokerClient) amqpSubscription(trigger config.AMQPTrigger, queue string) (subscriptionInterface, error) {
+	c.subscriptions = append(c.subscriptions, "amqp:"+queue)
+	return &fakeSubscription{name: "amqp:" + queue, messageChan: c.messageChan}, nil
+}
+
+func TestKafkaMessage(t *testing.T) {
+	msg := &kafkaMessage{Message: kafka.Message{
+		Topic:     "jobs",
+		Partition: 2,
+		Offset:    42,
+		Value:     []byte(`{"name":"job"}`),
+		Headers: []kafka.Header{
+			{Key: ProwEventType, Value: []byte(PeriodicProwJobEvent)},
+			{Key: IdempotencyKey, Value: []byte("release-1.2")},
+		},
+	}}
+	expectedAttributes := map[string]string{ProwEventType: PeriodicProwJobEvent, IdempotencyKey: "release-1.2"}
+	if diff := cmp.Diff(expectedAttributes, msg.getAttributes()); diff != "" {
+		t.Errorf("unexpected attributes (-want +got):\n%s", diff)
+	}
+	if id := msg.getID(); id != "jobs/2/42" {
+		t.Errorf("expected ID jobs/2/42, got %s", id)
+	}
+	if payload := string(msg.getPayload()); payload != `{"name":"job"}` {
+		t.Errorf("unexpected payload %s", payload)
+	}
+	msg.nack()
+	if msg.acked {
+		t.Error("expected nacked message not to be committed")
+	}
+	msg.ack()
+	if !msg.acked {
+		t.Error("expected acked message to be committed")
+	}
+}
+
+func TestAMQPMessage(t *testing.T) {
+	testCases := []struct {
+		name               string
+		delivery           amqp.Delivery
+		expectedAttributes map[string]string
+		expectedID         string
+	}{
+		{
+			name: "string headers are attributes",
+			delivery: amqp.Delivery{
+				MessageId:   "msg-1",
+				DeliveryTag: 7,
+				Headers:     amqp.Table{ProwEventType: PresubmitProwJobEvent, IdempotencyKey: []byte("pr-1"), "x-death": int32(1)},
+			},
+			expectedAttributes: map[string]string{ProwEventType: PresubmitProwJobEvent, IdempotencyKey: "pr-1"},
+			expectedID:         "msg-1",
+		},
+		{
+			name:               "delivery tag identifies messages without ID",
+			delivery:           amqp.Delivery{DeliveryTag: 7},
+			expectedAttributes: map[string]string{},
+			expectedID:         "7",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &amqpMessage{Delivery: tc.delivery}
+			if diff := cmp.Diff(tc.expectedAttributes, msg.getAttributes()); diff != "" {
+				t.Errorf("unexpected attributes (-want +got):\n%s", diff)
+			}
+			if id := msg.getID(); id != tc.expectedID {
+				t.Errorf("expected ID %s, got %s", tc.expectedID, id)
+			}
+		})
+	}
+}
+
+func TestPullServer_RunBrokers(t *testing.T) {
+	s := &Subscriber{
+		ConfigAgent:   &config.Agent{},
+		ProwJobClient: fake.NewSimpleClientset().ProwV1().ProwJobs("prowjobs"),
+		Metrics:       NewMetrics(),
+	}
+	s.ConfigAgent.Set(&config.Config{
+		ProwConfig: config.ProwConfig{
+			KafkaTriggers: []config.KafkaTrigger{{
+				Brokers:         []string{"kafka:9092"},
+				Topics:          []string{"jobs", "releases"},
+				AllowedClusters: []string{"*"},
+			}},
+			AMQPTriggers: []config.AMQPTrigger{{
+				URL:             "amqp://rabbitmq",
+				Queues:          []string{"jobs"},
+				AllowedClusters: []string{"*"},
+			}},
+		},
+	})
+	// Every subscription handles a message.
+	messageChan := make(chan fakeMessage, 3)
+	brokers := &fakeBrokerClient{messageChan: messageChan}
+	pullServer := PullServer{
+		Subscriber: s,
+		Client:     &pubSubTestClient{messageChan: messageChan},
+		Brokers:    brokers,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		messageChan <- fakeMessage{
+			Attributes: map[string]string{},
+			ID:         "test",
+		}
+	}
+	err := pullServer.Run(ctx)
+	if err == nil || !strings.HasPrefix(err.Error(), "message processed") {
+		t.Errorf("expected the message to be processed, got %v", err)
+	}
+	if diff := cmp.Diff([]string{"kafka:jobs", "kafka:releases", "amqp:jobs"}, brokers.subscriptions); diff != "" {
+		t.Errorf("unexpected subscriptions (-want +got):\n%s", diff)
+	}
+}
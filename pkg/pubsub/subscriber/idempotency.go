@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// IdempotencyKey is the attribute of a message that identifies the job it
+// requests. Messages with the same key only create one ProwJob within the
+// idempotency window of the subscription. Messages without the attribute
+// are identified by their payload.
+const IdempotencyKey = "prow.k8s.io/pubsub.IdempotencyKey"
+
+// idempotencyKey returns the value of the kube.IdempotencyKeyLabel for the
+// message. Keys are hashed so any key fits in a label value.
+func idempotencyKey(msg messageInterface, eventType string) string {
+	key, ok := msg.getAttributes()[IdempotencyKey]
+	if !ok {
+		key = string(msg.getPayload())
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(eventType+"\x00"+key)))[:40]
+}
+
+// claimIdempotencyKey reserves the key for the message being handled. It
+// returns false if a ProwJob with the key was created within the window or
+// another message with the key is being handled. The key must be released
+// once the ProwJob was created.
+func (s *Subscriber) claimIdempotencyKey(ctx context.Context, key string, window time.Duration) (bool, error) {
+	s.idempotencyLock.Lock()
+	if s.inFlightKeys == nil {
+		s.inFlightKeys = map[string]bool{}
+	}
+	if s.inFlightKeys[key] {
+		s.idempotencyLock.Unlock()
+		return false, nil
+	}
+	s.inFlightKeys[key] = true
+	s.idempotencyLock.Unlock()
+
+	pjs, err := s.ProwJobClient.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{kube.IdempotencyKeyLabel: key}.String(),
+	})
+	if err != nil {
+		s.releaseIdempotencyKey(key)
+		return false, fmt.Errorf("failed to list ProwJobs with idempotency key %s: %w", key, err)
+	}
+	oldest := time.Now().Add(-window)
+	for _, pj := range pjs.Items {
+		if pj.Status.StartTime.Time.After(oldest) {
+			s.releaseIdempotencyKey(key)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *Subscriber) releaseIdempotencyKey(key string) {
+	s.idempotencyLock.Lock()
+	defer s.idempotencyLock.Unlock()
+	delete(s.inFlightKeys, key)
+}
@@ -40,6 +40,10 @@ var (
 		Name: "prow_pubsub_error_counter",
 		Help: "A counter of the webhooks made to prow.",
 	}, []string{subscriptionLabel, errorTypeLabel})
+	duplicateCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_pubsub_duplicate_counter",
+		Help: "A counter of the messages dropped because a ProwJob with the same idempotency key was created recently.",
+	}, []string{subscriptionLabel})
 
 	// Pull Server
 	ackedMessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -62,14 +66,16 @@ func init() {
 	prometheus.MustRegister(messageCounter)
 	prometheus.MustRegister(responseCounter)
 	prometheus.MustRegister(errorCounter)
+	prometheus.MustRegister(duplicateCounter)
 	prometheus.MustRegister(ackedMessagesCounter)
 	prometheus.MustRegister(nackedMessagesCounter)
 }
 
 type Metrics struct {
 	// Common
-	MessageCounter *prometheus.CounterVec
-	ErrorCounter   *prometheus.CounterVec
+	MessageCounter   *prometheus.CounterVec
+	ErrorCounter     *prometheus.CounterVec
+	DuplicateCounter *prometheus.CounterVec
 
 	// Pull Server
 	ACKMessageCounter  *prometheus.CounterVec
@@ -84,6 +90,7 @@ func NewMetrics() *Metrics {
 		MessageCounter:     messageCounter,
 		ResponseCounter:    responseCounter,
 		ErrorCounter:       errorCounter,
+		DuplicateCounter:   duplicateCounter,
 		ACKMessageCounter:  ackedMessagesCounter,
 		NACKMessageCounter: nackedMessagesCounter,
 	}
@@ -19,26 +19,42 @@ package subscriber
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"cloud.google.com/go/pubsub"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/prow/pkg/config"
 )
 
 type configToWatch struct {
 	config.PubSubTriggers
 	config.PubsubSubscriptions
+	KafkaTriggers []config.KafkaTrigger
+	AMQPTriggers  []config.AMQPTrigger
 }
 
-// PullServer listen to Pull Pub/Sub subscriptions and handle them.
+func newConfigToWatch(c *config.Config) configToWatch {
+	return configToWatch{
+		PubSubTriggers:      c.PubSubTriggers,
+		PubsubSubscriptions: c.PubSubSubscriptions,
+		KafkaTriggers:       c.KafkaTriggers,
+		AMQPTriggers:        c.AMQPTriggers,
+	}
+}
+
+// PullServer listen to Pull Pub/Sub subscriptions, Kafka topics and AMQP
+// queues and handle them.
 type PullServer struct {
 	Subscriber *Subscriber
 	Client     pubsubClientInterface
+	Brokers    brokerClientInterface
 }
 
 // NewPullServer creates a new PullServer
@@ -46,6 +62,7 @@ func NewPullServer(s *Subscriber) *PullServer {
 	return &PullServer{
 		Subscriber: s,
 		Client:     &pubSubClient{},
+		Brokers:    &brokerClient{},
 	}
 }
 
@@ -106,12 +123,13 @@ func (c *pubSubClient) subscription(id string, maxOutstandingMessages int) subsc
 	}
 }
 
-// handlePulls pull for Pub/Sub subscriptions and handle them.
-func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions config.PubSubTriggers) (*errgroup.Group, context.Context, error) {
+// handlePulls pull for Pub/Sub subscriptions and consumes the Kafka topics
+// and AMQP queues of the config, and handle their messages.
+func (s *PullServer) handlePulls(ctx context.Context, cfg configToWatch) (*errgroup.Group, context.Context, error) {
 	// Since config might change we need be able to cancel the current run
 	errGroup, derivedCtx := errgroup.WithContext(ctx)
-	for _, topics := range projectSubscriptions {
-		project, subscriptions, allowedClusters := topics.Project, topics.Topics, topics.AllowedClusters
+	for _, topics := range cfg.PubSubTriggers {
+		project, subscriptions := topics.Project, topics.Topics
 		client, err := s.Client.new(ctx, project)
 		if err != nil {
 			return errGroup, derivedCtx, err
@@ -122,36 +140,64 @@ func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions confi
 				"subscription": sub.string(),
 				"project":      project,
 			})
-			errGroup.Go(func() error {
-				logger.Info("Listening for subscription")
-				defer logger.Warn("Stopped Listening for subscription")
-				err := sub.receive(derivedCtx, func(ctx context.Context, msg messageInterface) {
-					if err = s.Subscriber.handleMessage(msg, sub.string(), allowedClusters); err != nil {
-						s.Subscriber.Metrics.ACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
-					} else {
-						s.Subscriber.Metrics.NACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
-					}
-					msg.ack()
-				})
-				if err != nil {
-					if errors.Is(derivedCtx.Err(), context.Canceled) {
-						logger.WithError(err).Debug("Exiting as context cancelled")
-						return nil
-					}
-					if strings.Contains(err.Error(), "code = PermissionDenied") {
-						logger.WithError(err).Warn("Seems like missing permission.")
-						return nil
-					}
-					logger.WithError(err).Error("Failed to listen for subscription")
-					return err
-				}
-				return nil
-			})
+			s.listen(errGroup, derivedCtx, logger, sub, topics.AllowedClusters, topics.IdempotencyWindow)
+		}
+	}
+	for _, trigger := range cfg.KafkaTriggers {
+		for _, topic := range trigger.Topics {
+			sub, err := s.Brokers.kafkaSubscription(trigger, topic)
+			if err != nil {
+				return errGroup, derivedCtx, fmt.Errorf("failed to set up Kafka topic %s: %w", topic, err)
+			}
+			s.listen(errGroup, derivedCtx, logrus.WithField("subscription", sub.string()), sub, trigger.AllowedClusters, trigger.IdempotencyWindow)
+		}
+	}
+	for _, trigger := range cfg.AMQPTriggers {
+		for _, queue := range trigger.Queues {
+			sub, err := s.Brokers.amqpSubscription(trigger, queue)
+			if err != nil {
+				return errGroup, derivedCtx, fmt.Errorf("failed to set up AMQP queue %s: %w", queue, err)
+			}
+			s.listen(errGroup, derivedCtx, logrus.WithField("subscription", sub.string()), sub, trigger.AllowedClusters, trigger.IdempotencyWindow)
 		}
 	}
 	return errGroup, derivedCtx, nil
 }
 
+// listen handles the messages of the subscription until the context is
+// cancelled or receiving fails.
+func (s *PullServer) listen(errGroup *errgroup.Group, derivedCtx context.Context, logger *logrus.Entry, sub subscriptionInterface, allowedClusters []string, window *metav1.Duration) {
+	var idempotencyWindow time.Duration
+	if window != nil {
+		idempotencyWindow = window.Duration
+	}
+	errGroup.Go(func() error {
+		logger.Info("Listening for subscription")
+		defer logger.Warn("Stopped Listening for subscription")
+		err := sub.receive(derivedCtx, func(ctx context.Context, msg messageInterface) {
+			if err := s.Subscriber.handleMessage(msg, sub.string(), allowedClusters, idempotencyWindow); err != nil {
+				s.Subscriber.Metrics.ACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
+			} else {
+				s.Subscriber.Metrics.NACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
+			}
+			msg.ack()
+		})
+		if err != nil {
+			if errors.Is(derivedCtx.Err(), context.Canceled) {
+				logger.WithError(err).Debug("Exiting as context cancelled")
+				return nil
+			}
+			if strings.Contains(err.Error(), "code = PermissionDenied") {
+				logger.WithError(err).Warn("Seems like missing permission.")
+				return nil
+			}
+			logger.WithError(err).Error("Failed to listen for subscription")
+			return err
+		}
+		return nil
+	})
+}
+
 // Run will block listening to all subscriptions and return once the context is cancelled
 // or one of the subscription has a unrecoverable error.
 func (s *PullServer) Run(ctx context.Context) error {
@@ -165,11 +211,8 @@ func (s *PullServer) Run(ctx context.Context) error {
 		}
 		logrus.Debug("Pull server shutting down.")
 	}()
-	currentConfig := configToWatch{
-		s.Subscriber.ConfigAgent.Config().PubSubTriggers,
-		s.Subscriber.ConfigAgent.Config().PubSubSubscriptions,
-	}
-	errGroup, derivedCtx, err := s.handlePulls(ctx, currentConfig.PubSubTriggers)
+	currentConfig := newConfigToWatch(s.Subscriber.ConfigAgent.Config())
+	errGroup, derivedCtx, err := s.handlePulls(ctx, currentConfig)
 	if err != nil {
 		return err
 	}
@@ -185,17 +228,14 @@ func (s *PullServer) Run(ctx context.Context) error {
 			return err
 		// Checking for update config
 		case event := <-configEvent:
-			newConfig := configToWatch{
-				event.After.PubSubTriggers,
-				event.After.PubSubSubscriptions,
-			}
+			newConfig := newConfigToWatch(&event.After)
 			logrus.Info("Received new config")
 			if !reflect.DeepEqual(currentConfig, newConfig) {
 				logrus.Info("New config found, reloading pull Server")
 				// Making sure the current thread finishes before starting a new one.
 				errGroup.Wait()
 				// Starting a new thread with new config
-				errGroup, derivedCtx, err = s.handlePulls(ctx, newConfig.PubSubTriggers)
+				errGroup, derivedCtx, err = s.handlePulls(ctx, newConfig)
 				if err != nil {
 					return err
 				}
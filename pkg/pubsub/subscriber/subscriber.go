@@ -21,6 +21,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 
@@ -48,6 +50,9 @@ type ProwJobEvent struct {
 	Envs        map[string]string `json:"envs,omitempty"`
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
+	// ArtifactDestination uploads the artifacts of the run to another bucket
+	// or path prefix than those of the job.
+	ArtifactDestination *prowcrd.ArtifactDestination `json:"artifact_destination,omitempty"`
 }
 
 // FromPayload set the ProwJobEvent from the PubSub message payload.
@@ -87,6 +92,10 @@ type Subscriber struct {
 	ProwJobClient      gangway.ProwJobClient
 	Reporter           reportClient
 	InRepoConfigGetter config.InRepoConfigGetter
+
+	idempotencyLock sync.Mutex
+	// inFlightKeys are the idempotency keys of the messages being handled.
+	inFlightKeys map[string]bool
 }
 
 type messageInterface interface {
@@ -148,7 +157,10 @@ func (s *Subscriber) getReporterFunc(l *logrus.Entry) gangway.ReporterFunc {
 	}
 }
 
-func (s *Subscriber) handleMessage(msg messageInterface, subscription string, allowedClusters []string) error {
+// handleMessage creates the ProwJob requested by the message. If the
+// idempotencyWindow isn't zero, messages with the idempotency key of a
+// ProwJob created within the window are dropped as duplicates.
+func (s *Subscriber) handleMessage(msg messageInterface, subscription string, allowedClusters []string, idempotencyWindow time.Duration) error {
 
 	msgID := msg.getID()
 	l := logrus.WithFields(logrus.Fields{
@@ -161,6 +173,26 @@ func (s *Subscriber) handleMessage(msg messageInterface, subscription string, al
 		return err
 	}
 
+	if idempotencyWindow > 0 {
+		key := idempotencyKey(msg, msg.getAttributes()[ProwEventType])
+		claimed, err := s.claimIdempotencyKey(context.TODO(), key, idempotencyWindow)
+		if err != nil {
+			l.WithError(err).Error("failed to check for duplicate messages")
+			s.Metrics.ErrorCounter.With(prometheus.Labels{
+				subscriptionLabel: subscription,
+				errorTypeLabel:    "failed-check-idempotency-key",
+			}).Inc()
+			return err
+		}
+		if !claimed {
+			l.WithField("idempotency-key", key).Info("Dropping duplicate message")
+			s.Metrics.DuplicateCounter.With(prometheus.Labels{subscriptionLabel: subscription}).Inc()
+			return nil
+		}
+		defer s.releaseIdempotencyKey(key)
+		cjer.PodSpecOptions.Labels[kube.IdempotencyKeyLabel] = key
+	}
+
 	// Do not check for HTTP client authorization, because we're handling a
 	// PubSub message.
 	var allowedApiClient *config.AllowedApiClient = nil
@@ -261,6 +293,13 @@ func (s *Subscriber) peToCjer(l *logrus.Entry, pe *ProwJobEvent, eType, subscrip
 
 	cjer.PodSpecOptions = &pso
 
+	if pe.ArtifactDestination != nil {
+		cjer.ArtifactDestination = &gangway.ArtifactDestination{
+			Bucket:     pe.ArtifactDestination.Bucket,
+			PathPrefix: pe.ArtifactDestination.PathPrefix,
+		}
+	}
+
 	var err error
 
 	if pe.Refs != nil {
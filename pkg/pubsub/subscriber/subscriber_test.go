@@ -27,8 +27,10 @@ import (
 
 	"cloud.google.com/go/pubsub"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -354,7 +356,7 @@ func TestHandleMessage(t *testing.T) {
 				m.ID = "id"
 				tc.msg = &pubSubMessage{*m}
 			}
-			if err := s.handleMessage(tc.msg, "", []string{"*"}); err != nil {
+			if err := s.handleMessage(tc.msg, "", []string{"*"}, 0); err != nil {
 				if err.Error() != tc.err {
 					t1.Errorf("Expected error '%v' got '%v'", tc.err, err.Error())
 				} else if tc.err == "" {
@@ -381,6 +383,86 @@ func TestHandleMessage(t *testing.T) {
 	}
 }
 
+func TestHandleMessageIdempotency(t *testing.T) {
+	message := func(payload, key string) *fakeMessage {
+		msg := &fakeMessage{
+			ID:         "id",
+			Data:       []byte(payload),
+			Attributes: map[string]string{ProwEventType: PeriodicProwJobEvent},
+		}
+		if key != "" {
+			msg.Attributes[IdempotencyKey] = key
+		}
+		return msg
+	}
+	for _, tc := range []struct {
+		name            string
+		window          time.Duration
+		messages        []*fakeMessage
+		expectedCreated int
+	}{
+		{
+			name:            "redelivered message is dropped",
+			window:          time.Hour,
+			messages:        []*fakeMessage{message(`{"name":"test"}`, ""), message(`{"name":"test"}`, "")},
+			expectedCreated: 1,
+		},
+		{
+			name:            "different payloads create jobs",
+			window:          time.Hour,
+			messages:        []*fakeMessage{message(`{"name":"test"}`, ""), message(`{"name":"test","envs":{"FOO":"bar"}}`, "")},
+			expectedCreated: 2,
+		},
+		{
+			name:            "idempotency key takes precedence over the payload",
+			window:          time.Hour,
+			messages:        []*fakeMessage{message(`{"name":"test"}`, "run-1"), message(`{"name":"test"}`, "run-2"), message(`{"name":"test","envs":{"FOO":"bar"}}`, "run-1")},
+			expectedCreated: 2,
+		},
+		{
+			name:            "duplicates are created without a window",
+			messages:        []*fakeMessage{message(`{"name":"test"}`, ""), message(`{"name":"test"}`, "")},
+			expectedCreated: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeProwJobClient := fake.NewSimpleClientset()
+			ca := &config.Agent{}
+			ca.Set(&config.Config{
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "test"}}},
+				},
+				ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs", PodNamespace: namespace},
+			})
+			s := Subscriber{
+				Metrics:       NewMetrics(),
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				ConfigAgent:   ca,
+				Reporter:      &fakeReporter{},
+			}
+			for _, msg := range tc.messages {
+				if err := s.handleMessage(msg, "", []string{"*"}, tc.window); err != nil {
+					t.Fatalf("failed to handle message: %v", err)
+				}
+			}
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list ProwJobs: %v", err)
+			}
+			if len(pjs.Items) != tc.expectedCreated {
+				t.Errorf("expected %d ProwJobs to be created, got %d", tc.expectedCreated, len(pjs.Items))
+			}
+			if tc.window > 0 {
+				for _, pj := range pjs.Items {
+					if pj.Labels[kube.IdempotencyKeyLabel] == "" {
+						t.Errorf("ProwJob %s is missing the idempotency key label", pj.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
 func CheckProwJob(pe *ProwJobEvent, pj *prowapi.ProwJob) error {
 	// checking labels
 	for label, value := range pe.Labels {
@@ -408,6 +490,18 @@ func CheckProwJob(pe *ProwJobEvent, pj *prowapi.ProwJob) error {
 			}
 		}
 	}
+	if dest := pe.ArtifactDestination; dest != nil {
+		if pj.Spec.DecorationConfig == nil || pj.Spec.DecorationConfig.GCSConfiguration == nil {
+			return errors.New("artifact destination is set but the job is not decorated")
+		}
+		gcs := pj.Spec.DecorationConfig.GCSConfiguration
+		if gcs.Bucket != dest.Bucket || gcs.PathPrefix != dest.PathPrefix {
+			return fmt.Errorf("artifacts should be uploaded to %s/%s, found %s/%s instead", dest.Bucket, dest.PathPrefix, gcs.Bucket, gcs.PathPrefix)
+		}
+		if !reflect.DeepEqual(pj.Spec.ArtifactDestination, dest) {
+			return fmt.Errorf("artifact destination should be recorded as %v, found %v instead", dest, pj.Spec.ArtifactDestination)
+		}
+	}
 
 	return nil
 }
@@ -482,6 +576,59 @@ func TestHandlePeriodicJob(t *testing.T) {
 			allowedClusters: []string{"*"},
 			reported:        true,
 		},
+		{
+			name: "ArtifactDestinationAllowed",
+			pe: &ProwJobEvent{
+				Name:                "test",
+				ArtifactDestination: &prowapi.ArtifactDestination{Bucket: "gs://experiments", PathPrefix: "team/run-1"},
+			},
+			config: &config.Config{
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{
+						{
+							JobBase: config.JobBase{
+								Name: "test",
+								UtilityConfig: config.UtilityConfig{
+									Decorate:         ptr.To(true),
+									DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "ci"}},
+								},
+							},
+						},
+					},
+				},
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{AllowedArtifactDestinations: []config.AllowedArtifactDestination{{Bucket: "experiments", PathPrefixes: []string{"team"}}}},
+				},
+			},
+			allowedClusters: []string{"*"},
+		},
+		{
+			name: "ArtifactDestinationNotAllowed",
+			pe: &ProwJobEvent{
+				Name:                "test",
+				ArtifactDestination: &prowapi.ArtifactDestination{Bucket: "gs://experiments", PathPrefix: "other-team"},
+			},
+			config: &config.Config{
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{
+						{
+							JobBase: config.JobBase{
+								Name: "test",
+								UtilityConfig: config.UtilityConfig{
+									Decorate:         ptr.To(true),
+									DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "ci"}},
+								},
+							},
+						},
+					},
+				},
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{AllowedArtifactDestinations: []config.AllowedArtifactDestination{{Bucket: "experiments", PathPrefixes: []string{"team"}}}},
+				},
+			},
+			allowedClusters: []string{"*"},
+			err:             "rpc error: code = InvalidArgument desc = artifact destination gs://experiments/other-team is not allowed by plank.allowed_artifact_destinations",
+		},
 		{
 			name: "ClusterNotAllowed",
 			pe: &ProwJobEvent{
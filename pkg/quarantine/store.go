@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quarantine stores the tests that maintainers quarantine from Deck.
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+// Store keeps the quarantined tests of every repo in a JSON file under a
+// storage path:
+//
+//	<path>/<org>/<repo>.json
+type Store struct {
+	opener io.Opener
+	path   string
+}
+
+// NewStore returns a store keeping quarantined tests under the gs:// or s3://
+// path.
+func NewStore(opener io.Opener, path string) *Store {
+	return &Store{opener: opener, path: strings.TrimSuffix(path, "/")}
+}
+
+func (s *Store) object(org, repo string) string {
+	return fmt.Sprintf("%s/%s/%s.json", s.path, org, repo)
+}
+
+// Get returns the tests quarantined for the repo, sorted by their pattern.
+func (s *Store) Get(ctx context.Context, org, repo string) ([]prowapi.QuarantinedTest, error) {
+	raw, err := io.ReadContent(ctx, logrus.WithField("repo", org+"/"+repo), s.opener, s.object(org, repo))
+	if io.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the quarantined tests of %s/%s: %w", org, repo, err)
+	}
+	var tests []prowapi.QuarantinedTest
+	if err := json.Unmarshal(raw, &tests); err != nil {
+		return nil, fmt.Errorf("failed to parse the quarantined tests of %s/%s: %w", org, repo, err)
+	}
+	return tests, nil
+}
+
+// Add quarantines the test for the repo, replacing the quarantine of a test
+// with the same pattern.
+func (s *Store) Add(ctx context.Context, org, repo string, test prowapi.QuarantinedTest) error {
+	if err := test.Validate(); err != nil {
+		return err
+	}
+	tests, err := s.Get(ctx, org, repo)
+	if err != nil {
+		return err
+	}
+	tests = append(remove(tests, test.Pattern), test)
+	return s.put(ctx, org, repo, tests)
+}
+
+// Remove lifts the quarantine of the test with the pattern. It returns false
+// if no test of the repo has the pattern.
+func (s *Store) Remove(ctx context.Context, org, repo, pattern string) (bool, error) {
+	tests, err := s.Get(ctx, org, repo)
+	if err != nil {
+		return false, err
+	}
+	remaining := remove(tests, pattern)
+	if len(remaining) == len(tests) {
+		return false, nil
+	}
+	return true, s.put(ctx, org, repo, remaining)
+}
+
+func (s *Store) put(ctx context.Context, org, repo string, tests []prowapi.QuarantinedTest) error {
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Pattern < tests[j].Pattern })
+	if tests == nil {
+		tests = []prowapi.QuarantinedTest{}
+	}
+	raw, err := json.Marshal(tests)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the quarantined tests: %w", err)
+	}
+	noCache := "no-cache"
+	if err := io.WriteContent(ctx, logrus.WithField("repo", org+"/"+repo), s.opener, s.object(org, repo), raw, io.WriterOptions{CacheControl: &noCache}); err != nil {
+		return fmt.Errorf("failed to write the quarantined tests of %s/%s: %w", org, repo, err)
+	}
+	return nil
+}
+
+func remove(tests []prowapi.QuarantinedTest, pattern string) []prowapi.QuarantinedTest {
+	var remaining []prowapi.QuarantinedTest
+	for _, test := range tests {
+		if test.Pattern != pattern {
+			remaining = append(remaining, test)
+		}
+	}
+	return remaining
+}
+
+// Merge returns the tests quarantined in the config followed by the stored
+// ones whose pattern isn't quarantined in the config already.
+func Merge(configured, stored []prowapi.QuarantinedTest) []prowapi.QuarantinedTest {
+	if len(stored) == 0 {
+		return configured
+	}
+	merged := append([]prowapi.QuarantinedTest{}, configured...)
+	patterns := map[string]bool{}
+	for _, test := range configured {
+		patterns[test.Pattern] = true
+	}
+	for _, test := range stored {
+		if !patterns[test.Pattern] {
+			merged = append(merged, test)
+		}
+	}
+	return merged
+}
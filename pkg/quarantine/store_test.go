@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/io/fakeopener"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	expires := metav1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	store := NewStore(&fakeopener.FakeOpener{}, "gs://bucket/quarantine/")
+
+	tests, err := store.Get(ctx, "org", "repo")
+	if err != nil {
+		t.Fatalf("failed to get the tests of a repo without any: %v", err)
+	}
+	if len(tests) != 0 {
+		t.Errorf("expected no tests, got %v", tests)
+	}
+
+	for _, test := range []prowapi.QuarantinedTest{
+		{Pattern: "TestB", Reason: "flaky", Expires: expires},
+		{Pattern: "TestA", Expires: expires},
+		{Pattern: "TestB", Reason: "still flaky", Expires: expires, Author: "bob"},
+	} {
+		if err := store.Add(ctx, "org", "repo", test); err != nil {
+			t.Fatalf("failed to add %s: %v", test.Pattern, err)
+		}
+	}
+	if err := store.Add(ctx, "org", "repo", prowapi.QuarantinedTest{Pattern: "TestC"}); err == nil {
+		t.Error("expected a test that never expires to be refused")
+	}
+	if err := store.Add(ctx, "org", "other", prowapi.QuarantinedTest{Pattern: "TestD", Expires: expires}); err != nil {
+		t.Fatalf("failed to add TestD: %v", err)
+	}
+
+	tests, err = store.Get(ctx, "org", "repo")
+	if err != nil {
+		t.Fatalf("failed to get the tests: %v", err)
+	}
+	expected := []prowapi.QuarantinedTest{
+		{Pattern: "TestA", Expires: expires},
+		{Pattern: "TestB", Reason: "still flaky", Expires: expires, Author: "bob"},
+	}
+	if diff := cmp.Diff(expected, tests); diff != "" {
+		t.Errorf("unexpected tests (-want +got):\n%s", diff)
+	}
+
+	if removed, err := store.Remove(ctx, "org", "repo", "TestA"); err != nil || !removed {
+		t.Fatalf("expected TestA to be removed, got %t, %v", removed, err)
+	}
+	if removed, err := store.Remove(ctx, "org", "repo", "TestA"); err != nil || removed {
+		t.Fatalf("expected TestA not to be removed twice, got %t, %v", removed, err)
+	}
+	tests, err = store.Get(ctx, "org", "repo")
+	if err != nil {
+		t.Fatalf("failed to get the tests: %v", err)
+	}
+	if diff := cmp.Diff(expected[1:], tests); diff != "" {
+		t.Errorf("unexpected tests after removal (-want +got):\n%s", diff)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	expires := metav1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		name       string
+		configured []prowapi.QuarantinedTest
+		stored     []prowapi.QuarantinedTest
+		expected   []prowapi.QuarantinedTest
+	}{
+		{
+			name:       "nothing stored",
+			configured: []prowapi.QuarantinedTest{{Pattern: "TestA", Expires: expires}},
+			expected:   []prowapi.QuarantinedTest{{Pattern: "TestA", Expires: expires}},
+		},
+		{
+			name:     "nothing configured",
+			stored:   []prowapi.QuarantinedTest{{Pattern: "TestA", Expires: expires}},
+			expected: []prowapi.QuarantinedTest{{Pattern: "TestA", Expires: expires}},
+		},
+		{
+			name:       "config takes precedence",
+			configured: []prowapi.QuarantinedTest{{Pattern: "TestA", Reason: "config", Expires: expires}},
+			stored: []prowapi.QuarantinedTest{
+				{Pattern: "TestA", Reason: "deck", Expires: expires},
+				{Pattern: "TestB", Expires: expires},
+			},
+			expected: []prowapi.QuarantinedTest{
+				{Pattern: "TestA", Reason: "config", Expires: expires},
+				{Pattern: "TestB", Expires: expires},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Merge(tc.configured, tc.stored)); diff != "" {
+				t.Errorf("unexpected tests (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
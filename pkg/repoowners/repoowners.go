@@ -126,6 +126,12 @@ func (c *cache) setEntry(key string, data cacheEntry) {
 	c.dataLock.Unlock()
 }
 
+func (c *cache) clear() {
+	c.dataLock.Lock()
+	c.data = map[string]cacheEntry{}
+	c.dataLock.Unlock()
+}
+
 type cacheEntry struct {
 	sha     string
 	aliases RepoAliases
@@ -206,6 +212,12 @@ func (c *Client) forKeyValue(key, value string) Interface {
 	}
 }
 
+// ClearCache drops all cached OWNERS, so that they are loaded again with the
+// current configuration the next time they are requested.
+func (c *Client) ClearCache() {
+	c.cache.clear()
+}
+
 // Used determines whether the client has been used
 func (c *Client) Used() bool {
 	return c.used
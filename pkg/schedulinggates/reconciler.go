@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedulinggates releases ProwJobs waiting for their scheduling
+// gates once every gate has been cleared.
+package schedulinggates
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+const ControllerName = "scheduling-gates"
+
+// Add adds the controller to the manager.
+func Add(mgr controllerruntime.Manager, cfg config.Getter, numWorkers int) error {
+	predicates := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		pj, isPJ := object.(*prowv1.ProwJob)
+		return isPJ && pj.Status.State == prowv1.WaitingState
+	})
+
+	if err := controllerruntime.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&prowv1.ProwJob{}).
+		WithEventFilter(predicates).
+		WithOptions(controller.Options{MaxConcurrentReconciles: numWorkers}).
+		Complete(NewReconciler(mgr.GetClient(), cfg)); err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+
+	return nil
+}
+
+type Reconciler struct {
+	pjClient client.Client
+	log      *logrus.Entry
+	cfg      config.Getter
+}
+
+func NewReconciler(pjClient client.Client, cfg config.Getter) *Reconciler {
+	return &Reconciler{
+		pjClient: pjClient,
+		log:      logrus.NewEntry(logrus.StandardLogger()).WithField("controller", ControllerName),
+		cfg:      cfg,
+	}
+}
+
+// Reconcile moves a waiting ProwJob on once all of its scheduling gates are
+// cleared and keeps its description in sync with the gates left otherwise.
+func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithField("request", request)
+
+	pj := &prowv1.ProwJob{}
+	if err := r.pjClient.Get(ctx, request.NamespacedName, pj); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("get prowjob %s: %w", request.Name, err)
+		}
+		return reconcile.Result{}, nil
+	}
+	if pj.Status.State != prowv1.WaitingState {
+		return reconcile.Result{}, nil
+	}
+
+	released := pj.DeepCopy()
+	if gates := pj.RemainingSchedulingGates(); len(gates) > 0 {
+		released.Status.Description = pjutil.WaitingDescription(gates)
+	} else {
+		released.Status.State = prowv1.TriggeredState
+		if r.cfg().Scheduler.Enabled {
+			released.Status.State = prowv1.SchedulingState
+		}
+		released.Status.Description = ""
+		log.WithField("job", pj.Spec.Job).Info("Scheduling gates cleared")
+	}
+	if released.Status.State == pj.Status.State && released.Status.Description == pj.Status.Description {
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.pjClient.Patch(ctx, released, client.MergeFrom(pj)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("patch prowjob: %w", err)
+	}
+
+	return reconcile.Result{}, nil
+}
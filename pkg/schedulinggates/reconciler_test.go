@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedulinggates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestReconcile(t *testing.T) {
+	gates := []string{"change-approval", "release-window"}
+	for _, tc := range []struct {
+		name             string
+		state            prowv1.ProwJobState
+		cleared          string
+		schedulerEnabled bool
+
+		wantState       prowv1.ProwJobState
+		wantDescription string
+	}{
+		{
+			name:            "no gate cleared",
+			state:           prowv1.WaitingState,
+			wantState:       prowv1.WaitingState,
+			wantDescription: "Waiting for scheduling gates: change-approval, release-window.",
+		},
+		{
+			name:            "one gate cleared",
+			state:           prowv1.WaitingState,
+			cleared:         "release-window",
+			wantState:       prowv1.WaitingState,
+			wantDescription: "Waiting for scheduling gates: change-approval.",
+		},
+		{
+			name:      "all gates cleared",
+			state:     prowv1.WaitingState,
+			cleared:   "release-window,change-approval",
+			wantState: prowv1.TriggeredState,
+		},
+		{
+			name:             "all gates cleared with the scheduler enabled",
+			state:            prowv1.WaitingState,
+			cleared:          "release-window,change-approval",
+			schedulerEnabled: true,
+			wantState:        prowv1.SchedulingState,
+		},
+		{
+			name:            "job no longer waiting is left alone",
+			state:           prowv1.AbortedState,
+			wantState:       prowv1.AbortedState,
+			wantDescription: "Waiting for scheduling gates: change-approval, release-window.",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &prowv1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "job",
+					Namespace:   "prowjobs",
+					Annotations: map[string]string{},
+				},
+				Spec: prowv1.ProwJobSpec{Job: "deploy", SchedulingGates: gates},
+				Status: prowv1.ProwJobStatus{
+					State:       tc.state,
+					Description: "Waiting for scheduling gates: change-approval, release-window.",
+				},
+			}
+			if tc.cleared != "" {
+				pj.Annotations[prowv1.ClearedSchedulingGatesAnnotation] = tc.cleared
+			}
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Scheduler: config.Scheduler{Enabled: tc.schedulerEnabled}}}
+			pjClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(pj).Build()
+			r := NewReconciler(pjClient, func() *config.Config { return cfg })
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "prowjobs", Name: "job"}}
+			if _, err := r.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			got := &prowv1.ProwJob{}
+			if err := pjClient.Get(context.Background(), request.NamespacedName, got); err != nil {
+				t.Fatalf("Failed to get ProwJob: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantState, got.Status.State); diff != "" {
+				t.Errorf("unexpected state (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDescription, got.Status.Description); diff != "" {
+				t.Errorf("unexpected description (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
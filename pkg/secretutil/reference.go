@@ -0,0 +1,286 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// KMSScheme prefixes references to a ciphertext that is decrypted with
+	// a Google Cloud KMS key, like
+	// kms://projects/p/locations/l/keyRings/r/cryptoKeys/k:<base64 ciphertext>
+	KMSScheme = "kms://"
+	// VaultScheme prefixes references to a field of a secret stored in
+	// HashiCorp Vault, like vault://secret/data/ci/github#token
+	VaultScheme = "vault://"
+
+	defaultVaultAuthMount   = "kubernetes"
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+var kmsKeyName = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// Reference is a parsed reference to a secret value.
+type Reference struct {
+	// KMSKey is the name of the key decrypting Ciphertext.
+	KMSKey     string
+	Ciphertext string
+	// VaultPath is the API path of the secret in Vault, which holds
+	// VaultField.
+	VaultPath  string
+	VaultField string
+}
+
+// IsReference returns whether the value is meant to be a secret reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, KMSScheme) || strings.HasPrefix(value, VaultScheme)
+}
+
+// ParseReference parses a secret reference.
+func ParseReference(value string) (*Reference, error) {
+	switch {
+	case strings.HasPrefix(value, KMSScheme):
+		key, ciphertext, found := strings.Cut(strings.TrimPrefix(value, KMSScheme), ":")
+		if !found || ciphertext == "" {
+			return nil, errors.New("KMS reference does not specify a ciphertext after the key name")
+		}
+		if !kmsKeyName.MatchString(key) {
+			return nil, fmt.Errorf("%q is not a KMS key name of the form projects/*/locations/*/keyRings/*/cryptoKeys/*", key)
+		}
+		if _, err := base64.StdEncoding.DecodeString(ciphertext); err != nil {
+			return nil, fmt.Errorf("KMS ciphertext is not base64 encoded: %w", err)
+		}
+		return &Reference{KMSKey: key, Ciphertext: ciphertext}, nil
+	case strings.HasPrefix(value, VaultScheme):
+		path, field, found := strings.Cut(strings.TrimPrefix(value, VaultScheme), "#")
+		if !found || field == "" {
+			return nil, errors.New("Vault reference does not specify a field after #")
+		}
+		if path == "" || strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("%q is not a relative Vault secret path", path)
+		}
+		return &Reference{VaultPath: path, VaultField: field}, nil
+	default:
+		return nil, fmt.Errorf("secret references must start with %s or %s", KMSScheme, VaultScheme)
+	}
+}
+
+// VaultOptions configure how Vault is accessed.
+type VaultOptions struct {
+	// Address is the URL of the Vault server.
+	Address string `json:"address"`
+	// AuthMount is the path the Kubernetes auth method is mounted at.
+	// Defaults to kubernetes.
+	AuthMount string `json:"auth_mount,omitempty"`
+	// Role is the role to log in as with the service account token of
+	// the pod. If unset, the token in $VAULT_TOKEN is used instead.
+	Role string `json:"role,omitempty"`
+}
+
+// Resolver resolves secret references. KMS is accessed with the credentials
+// of the GCE metadata server, i.e. Workload Identity, and Vault according to
+// its options. Both are accessed through their HTTP APIs, so that the
+// binaries resolving references stay small.
+type Resolver struct {
+	vault *VaultOptions
+
+	client           *http.Client
+	kmsEndpoint      string
+	metadataEndpoint string
+	tokenFile        string
+
+	lock       sync.Mutex
+	kmsToken   string
+	vaultToken string
+}
+
+// NewResolver returns a Resolver using the given Vault options, which may
+// be nil if no Vault references are resolved.
+func NewResolver(vault *VaultOptions) *Resolver {
+	metadataHost := "metadata.google.internal"
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		metadataHost = host
+	}
+	return &Resolver{
+		vault:            vault,
+		client:           &http.Client{Timeout: time.Minute},
+		kmsEndpoint:      "https://cloudkms.googleapis.com",
+		metadataEndpoint: "http://" + metadataHost,
+		tokenFile:        serviceAccountTokenFile,
+		vaultToken:       os.Getenv("VAULT_TOKEN"),
+	}
+}
+
+// Resolve returns the secret value the reference points to.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, err := ParseReference(value)
+	if err != nil {
+		return "", err
+	}
+	if ref.KMSKey != "" {
+		return r.decrypt(ctx, ref)
+	}
+	return r.readVault(ctx, ref)
+}
+
+func (r *Resolver) decrypt(ctx context.Context, ref *Reference) (string, error) {
+	token, err := r.googleToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials for KMS: %w", err)
+	}
+	var response struct {
+		Plaintext string `json:"plaintext"`
+	}
+	request := map[string]string{"ciphertext": ref.Ciphertext}
+	if err := r.do(ctx, http.MethodPost, fmt.Sprintf("%s/v1/%s:decrypt", r.kmsEndpoint, ref.KMSKey), map[string]string{"Authorization": "Bearer " + token}, request, &response); err != nil {
+		return "", fmt.Errorf("failed to decrypt with %s: %w", ref.KMSKey, err)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(response.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("KMS returned invalid plaintext: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func (r *Resolver) googleToken(ctx context.Context) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.kmsToken != "" {
+		return r.kmsToken, nil
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := r.do(ctx, http.MethodGet, r.metadataEndpoint+"/computeMetadata/v1/instance/service-accounts/default/token", map[string]string{"Metadata-Flavor": "Google"}, nil, &response); err != nil {
+		return "", err
+	}
+	r.kmsToken = response.AccessToken
+	return r.kmsToken, nil
+}
+
+func (r *Resolver) readVault(ctx context.Context, ref *Reference) (string, error) {
+	if r.vault == nil || r.vault.Address == "" {
+		return "", errors.New("Vault is not configured")
+	}
+	token, err := r.vaultLogin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to Vault: %w", err)
+	}
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := r.do(ctx, http.MethodGet, r.vaultURL(ref.VaultPath), map[string]string{"X-Vault-Token": token}, nil, &response); err != nil {
+		return "", fmt.Errorf("failed to read %s from Vault: %w", ref.VaultPath, err)
+	}
+	data := response.Data
+	// Version 2 of the KV secrets engine nests the secret in data.data.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	value, ok := data[ref.VaultField]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %q", ref.VaultPath, ref.VaultField)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q of secret %s is not a string", ref.VaultField, ref.VaultPath)
+	}
+	return s, nil
+}
+
+func (r *Resolver) vaultLogin(ctx context.Context) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.vaultToken != "" {
+		return r.vaultToken, nil
+	}
+	if r.vault.Role == "" {
+		return "", errors.New("neither a role nor $VAULT_TOKEN is set")
+	}
+	jwt, err := os.ReadFile(r.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	mount := r.vault.AuthMount
+	if mount == "" {
+		mount = defaultVaultAuthMount
+	}
+	var response struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	request := map[string]string{"role": r.vault.Role, "jwt": strings.TrimSpace(string(jwt))}
+	if err := r.do(ctx, http.MethodPost, r.vaultURL("auth/"+mount+"/login"), nil, request, &response); err != nil {
+		return "", err
+	}
+	r.vaultToken = response.Auth.ClientToken
+	return r.vaultToken, nil
+}
+
+func (r *Resolver) vaultURL(path string) string {
+	return strings.TrimSuffix(r.vault.Address, "/") + "/v1/" + path
+}
+
+func (r *Resolver) do(ctx context.Context, method, url string, headers map[string]string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.Unmarshal(b, response)
+}
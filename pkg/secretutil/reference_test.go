@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReference(t *testing.T) {
+	const key = "projects/p/locations/global/keyRings/ci/cryptoKeys/jobs"
+	testCases := []struct {
+		name        string
+		value       string
+		expected    *Reference
+		expectedErr bool
+	}{
+		{
+			name:     "KMS reference",
+			value:    "kms://" + key + ":c2VjcmV0+/==",
+			expected: &Reference{KMSKey: key, Ciphertext: "c2VjcmV0+/=="},
+		},
+		{
+			name:        "KMS reference without ciphertext",
+			value:       "kms://" + key,
+			expectedErr: true,
+		},
+		{
+			name:        "KMS reference with invalid key",
+			value:       "kms://projects/p/keyRings/ci:c2VjcmV0",
+			expectedErr: true,
+		},
+		{
+			name:        "KMS reference with invalid ciphertext",
+			value:       "kms://" + key + ":not base64",
+			expectedErr: true,
+		},
+		{
+			name:     "Vault reference",
+			value:    "vault://secret/data/ci/github#token",
+			expected: &Reference{VaultPath: "secret/data/ci/github", VaultField: "token"},
+		},
+		{
+			name:        "Vault reference without field",
+			value:       "vault://secret/data/ci/github",
+			expectedErr: true,
+		},
+		{
+			name:        "Vault reference with absolute path",
+			value:       "vault:///secret/data/ci/github#token",
+			expectedErr: true,
+		},
+		{
+			name:        "not a reference",
+			value:       "plain",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := ParseReference(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, ref); diff != "" {
+				t.Errorf("unexpected reference (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const key = "projects/p/locations/global/keyRings/ci/cryptoKeys/jobs"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing header", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token": "google-token"}`))
+	})
+	mux.HandleFunc("/v1/"+key+":decrypt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer google-token" {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		var request struct{ Ciphertext string }
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.Ciphertext != base64.StdEncoding.EncodeToString([]byte("encrypted")) {
+			http.Error(w, "decryption failed", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"plaintext": base64.StdEncoding.EncodeToString([]byte("decrypted"))})
+	})
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		var request struct{ Role, JWT string }
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.Role != "ci" || request.JWT != "sa-token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"auth": {"client_token": "vault-token"}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/ci/github", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"token": "kv2-secret", "count": 1}, "metadata": {"version": 3}}}`))
+	})
+	mux.HandleFunc("/v1/kv/ci/github", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"token": "kv1-secret"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		vault       *VaultOptions
+		value       string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "KMS ciphertext is decrypted",
+			value:    "kms://" + key + ":" + base64.StdEncoding.EncodeToString([]byte("encrypted")),
+			expected: "decrypted",
+		},
+		{
+			name:        "KMS error is returned",
+			value:       "kms://" + key + ":" + base64.StdEncoding.EncodeToString([]byte("other")),
+			expectedErr: true,
+		},
+		{
+			name:     "Vault KV v2 secret is read",
+			vault:    &VaultOptions{Address: server.URL, Role: "ci"},
+			value:    "vault://secret/data/ci/github#token",
+			expected: "kv2-secret",
+		},
+		{
+			name:     "Vault KV v1 secret is read",
+			vault:    &VaultOptions{Address: server.URL, Role: "ci"},
+			value:    "vault://kv/ci/github#token",
+			expected: "kv1-secret",
+		},
+		{
+			name:        "missing Vault field",
+			vault:       &VaultOptions{Address: server.URL, Role: "ci"},
+			value:       "vault://secret/data/ci/github#password",
+			expectedErr: true,
+		},
+		{
+			name:        "non-string Vault field",
+			vault:       &VaultOptions{Address: server.URL, Role: "ci"},
+			value:       "vault://secret/data/ci/github#count",
+			expectedErr: true,
+		},
+		{
+			name:        "Vault login with the wrong role fails",
+			vault:       &VaultOptions{Address: server.URL, Role: "other"},
+			value:       "vault://secret/data/ci/github#token",
+			expectedErr: true,
+		},
+		{
+			name:        "Vault is not configured",
+			value:       "vault://secret/data/ci/github#token",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewResolver(tc.vault)
+			r.kmsEndpoint = server.URL
+			r.metadataEndpoint = server.URL
+			r.tokenFile = tokenFile
+			r.vaultToken = ""
+			value, err := r.Resolve(context.Background(), tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if value != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, value)
+			}
+		})
+	}
+}
@@ -21,8 +21,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"time"
 
 	"sigs.k8s.io/prow/pkg/gcsupload"
+	"sigs.k8s.io/prow/pkg/pod-utils/cache"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 )
 
@@ -75,6 +77,20 @@ type Options struct {
 	// taken by `sidecar` to upload all relevant artifacts.
 	IgnoreInterrupts bool `json:"ignore_interrupts,omitempty"`
 
+	// InterruptGracePeriod is how long `sidecar` waits for the entries to
+	// stop after it received an interrupt signal before it uploads whatever
+	// logs and artifacts exist. It should match the `grace_period` of the
+	// `entrypoint` processes: they write their markers once the test process
+	// stopped or got killed, which lets `sidecar` upload everything at once.
+	// If unset, the best-effort upload starts as soon as the interrupt is
+	// received.
+	InterruptGracePeriod time.Duration `json:"interrupt_grace_period,omitempty"`
+
+	// AbortedByFile is a file holding the user who aborted the job, as
+	// projected from the annotations of the pod by the downward API. If the
+	// job was aborted, the user is recorded in the metadata of finished.json.
+	AbortedByFile string `json:"aborted_by_file,omitempty"`
+
 	// WriteMemoryProfile makes the program write a memory profile periodically while
 	// it runs. Use the sigs.k8s.io/prow/hack/analyze-memory-profiles.py script to
 	// load the data into time series and plot it for analysis.
@@ -89,6 +105,21 @@ type Options struct {
 	CensoringConcurrency *int64 `json:"censoring_concurrency,omitempty"`
 	// CensoringBufferSize is deprecated, use censoring_options.censoring_buffer_size instead.
 	CensoringBufferSize *int `json:"censoring_buffer_size,omitempty"`
+
+	// HeartbeatInterval is how often the heartbeat files of the entries
+	// are uploaded while they run, so the controller can tell whether the
+	// test processes hang. Heartbeats aren't uploaded if it's unset.
+	HeartbeatInterval time.Duration `json:"heartbeat_interval,omitempty"`
+
+	// Caches are saved once the entries passed, if clonerefs didn't
+	// restore them.
+	Caches *cache.Options `json:"caches,omitempty"`
+
+	// PipelineStatus is the aggregate status of the tasks of a Tekton
+	// pipeline, as in $(tasks.status), when sidecar runs in its finally
+	// task. The job passed if it is Succeeded or Completed, and sidecar
+	// uploads right away instead of waiting for entries.
+	PipelineStatus string `json:"pipeline_status,omitempty"`
 }
 
 type CensoringOptions struct {
@@ -151,7 +182,7 @@ func (o *Options) Validate() error {
 	}
 
 	ents := o.entries()
-	if len(ents) == 0 {
+	if len(ents) == 0 && o.PipelineStatus == "" {
 		return errors.New("no wrapper.Option entries")
 	}
 	for i, e := range ents {
@@ -160,6 +191,12 @@ func (o *Options) Validate() error {
 		}
 	}
 
+	if o.Caches != nil {
+		if err := o.Caches.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return o.GcsOptions.Validate()
 }
 
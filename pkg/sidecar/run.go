@@ -32,6 +32,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/flagutil"
+	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pjutil/pprof"
 
@@ -86,6 +87,12 @@ func wait(ctx context.Context, entries []wrapper.Options) (bool, bool, int) {
 
 }
 
+// pipelinePassed returns whether the aggregate status of the tasks of a
+// Tekton pipeline means they passed. Completed means some were skipped.
+func pipelinePassed(status string) bool {
+	return status == "Succeeded" || status == "Completed"
+}
+
 // Run will watch for the process being wrapped to exit
 // and then post the status of that process and any artifacts
 // to cloud storage.
@@ -111,19 +118,32 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 			if o.IgnoreInterrupts {
 				logrus.Warnf("Received an interrupt: %s, ignoring...", s)
 			} else {
+				// The entrypoint processes got the interrupt as well, and
+				// give the test processes their grace period to stop before
+				// they write their markers. Uploading once they did means
+				// the artifacts the test processes wrote while stopping
+				// are uploaded too.
+				logrus.Errorf("Received an interrupt: %s, waiting up to %s for the test processes to stop...", s, o.InterruptGracePeriod)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(o.InterruptGracePeriod):
+				}
+
 				// If we are being asked to terminate by the kubelet but we have
 				// NOT seen the test process exit cleanly, we need a to start
 				// uploading artifacts to GCS immediately. If we notice the process
 				// exit while doing this best-effort upload, we can race with the
 				// second upload but we can tolerate this as we'd rather get SOME
 				// data into GCS than attempt to cancel these uploads and get none.
-				logrus.Errorf("Received an interrupt: %s, cancelling...", s)
+				logrus.Errorf("Test processes did not stop within %s, cancelling...", o.InterruptGracePeriod)
 
 				// perform pre upload tasks
 				o.preUpload()
 
 				buildLogs := logReadersFuncs(entries)
 				metadata := combineMetadata(entries)
+				o.recordAbortion(metadata)
 
 				// perform best-effort upload
 				err := o.doUpload(ctx, spec, false, true, metadata, buildLogs, logFile, &once)
@@ -137,7 +157,17 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 		}
 	}()
 
-	passed, aborted, failures := wait(ctx, entries)
+	if o.HeartbeatInterval > 0 {
+		go o.uploadHeartbeats(ctx, spec, entries)
+	}
+
+	var passed, aborted bool
+	var failures int
+	if o.PipelineStatus != "" {
+		passed = pipelinePassed(o.PipelineStatus)
+	} else {
+		passed, aborted, failures = wait(ctx, entries)
+	}
 
 	cancel()
 	// If we are being asked to terminate by the kubelet but we have
@@ -151,7 +181,51 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 
 	buildLogs := logReadersFuncs(entries)
 	metadata := combineMetadata(entries)
-	return failures, o.doUpload(context.Background(), spec, passed, aborted, metadata, buildLogs, logFile, &once)
+	if aborted {
+		o.recordAbortion(metadata)
+	}
+	err = o.doUpload(context.Background(), spec, passed, aborted, metadata, buildLogs, logFile, &once)
+
+	// Caches are saved after the artifacts were uploaded, so a slow or
+	// failing save doesn't hold back the logs and results of the job.
+	if o.Caches != nil && passed && !aborted {
+		if cacheErr := o.Caches.Save(context.Background()); cacheErr != nil {
+			logrus.WithError(cacheErr).Warn("Failed to save caches.")
+		}
+	}
+	return failures, err
+}
+
+// uploadHeartbeats periodically uploads the heartbeat files of the entries
+// until the context is cancelled.
+func (o Options) uploadHeartbeats(ctx context.Context, spec *downwardapi.JobSpec, entries []wrapper.Options) {
+	noCache := "no-cache"
+	ticker := time.NewTicker(o.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		targets := map[string]gcs.UploadFunc{}
+		for _, entry := range entries {
+			if entry.HeartbeatFile == "" {
+				continue
+			}
+			// The entrypoint may not have started yet.
+			if _, err := os.Stat(entry.HeartbeatFile); err != nil {
+				continue
+			}
+			targets[wrapper.HeartbeatName(len(entries), entry.ContainerName)] = gcs.FileUploadWithOptions(entry.HeartbeatFile, pkgio.WriterOptions{CacheControl: &noCache})
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if err := o.GcsOptions.UploadFiles(ctx, spec, targets); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Failed to upload heartbeats.")
+		}
+	}
 }
 
 const errorKey = "sidecar-errors"
@@ -216,6 +290,28 @@ func combineMetadata(entries []wrapper.Options) map[string]interface{} {
 	return metadata
 }
 
+// abortedByKey is the key of the metadata of finished.json holding the user
+// who aborted the job.
+const abortedByKey = "aborted-by"
+
+// recordAbortion records the user who aborted the job in the metadata, if it
+// is known.
+func (o Options) recordAbortion(metadata map[string]interface{}) {
+	if o.AbortedByFile == "" {
+		return
+	}
+	raw, err := os.ReadFile(o.AbortedByFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).Warnf("Failed to read %s", o.AbortedByFile)
+		}
+		return
+	}
+	if user := strings.TrimSpace(string(raw)); user != "" {
+		metadata[abortedByKey] = user
+	}
+}
+
 // preUpload performs steps required before actual upload
 func (o Options) preUpload() {
 	if o.DeprecatedWrapperOptions != nil {
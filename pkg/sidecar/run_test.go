@@ -149,6 +149,19 @@ func TestWait(t *testing.T) {
 	}
 }
 
+func TestPipelinePassed(t *testing.T) {
+	for status, expected := range map[string]bool{
+		"Succeeded": true,
+		"Completed": true,
+		"Failed":    false,
+		"None":      false,
+	} {
+		if passed := pipelinePassed(status); passed != expected {
+			t.Errorf("pipelinePassed(%q) = %t, expected %t", status, passed, expected)
+		}
+	}
+}
+
 func TestWaitParallelContainers(t *testing.T) {
 	aborted := strconv.Itoa(entrypoint.AbortedErrorCode)
 	skip := strconv.Itoa(entrypoint.PreviousErrorCode)
@@ -383,6 +396,55 @@ func TestCombineMetadata(t *testing.T) {
 	}
 }
 
+func TestRecordAbortion(t *testing.T) {
+	cases := []struct {
+		name      string
+		noFile    bool
+		abortedBy string
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "no file configured",
+			noFile:   true,
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "file is not there",
+			expected: map[string]interface{}{},
+		},
+		{
+			name:      "annotation is not set",
+			abortedBy: "\n",
+			expected:  map[string]interface{}{},
+		},
+		{
+			name:      "user is recorded",
+			abortedBy: "someone",
+			expected:  map[string]interface{}{abortedByKey: "someone"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var o Options
+			if !tc.noFile {
+				o.AbortedByFile = path.Join(t.TempDir(), "aborted-by")
+			}
+			if tc.abortedBy != "" {
+				if err := os.WriteFile(o.AbortedByFile, []byte(tc.abortedBy), 0600); err != nil {
+					t.Fatalf("could not create %s: %v", o.AbortedByFile, err)
+				}
+			}
+
+			actual := map[string]interface{}{}
+			o.recordAbortion(actual)
+			if !equality.Semantic.DeepEqual(tc.expected, actual) {
+				t.Errorf("maps do not match:\n%s", diff.ObjectReflectDiff(tc.expected, actual))
+			}
+		})
+	}
+}
+
 func name(idx int) string {
 	return nameEntry(idx, wrapper.Options{})
 }
@@ -574,3 +636,44 @@ func TestSideCarLogsUpload(t *testing.T) {
 	}
 
 }
+
+func TestUploadHeartbeats(t *testing.T) {
+	tmpDir, localOutputDir := t.TempDir(), t.TempDir()
+	heartbeatFile := filepath.Join(tmpDir, "heartbeat.json")
+	heartbeat := wrapper.Heartbeat{Timestamp: time.Now(), LastOutput: time.Now()}
+	if err := wrapper.WriteHeartbeat(heartbeatFile, heartbeat); err != nil {
+		t.Fatalf("failed to write heartbeat: %v", err)
+	}
+	options := Options{
+		GcsOptions: &gcsupload.Options{
+			GCSConfiguration: &prowapi.GCSConfiguration{
+				PathStrategy:   prowapi.PathStrategyExplicit,
+				Bucket:         "bucket",
+				LocalOutputDir: localOutputDir,
+			},
+		},
+		HeartbeatInterval: 10 * time.Millisecond,
+	}
+	spec := &downwardapi.JobSpec{Job: "job", Type: prowapi.PeriodicJob, BuildID: "build"}
+	entries := []wrapper.Options{
+		{ContainerName: "test", HeartbeatFile: heartbeatFile},
+		// The heartbeat of an entrypoint that didn't start yet is skipped.
+		{ContainerName: "other", HeartbeatFile: filepath.Join(tmpDir, "missing.json")},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	options.uploadHeartbeats(ctx, spec, entries)
+
+	files, err := os.ReadDir(localOutputDir)
+	if err != nil {
+		t.Fatalf("failed to read output directory: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if expected := []string{"test-heartbeat.json"}; !equality.Semantic.DeepEqual(expected, names) {
+		t.Errorf("expected uploads %v, got %v", expected, names)
+	}
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"encoding/json"
+	"io"
 
 	"sigs.k8s.io/prow/pkg/config"
 )
@@ -62,6 +63,25 @@ type Artifact interface {
 	UpdateMetadata(map[string]string) error
 }
 
+// HistoricalArtifact is an Artifact that can find the artifact at the same
+// path in previous runs of its job.
+type HistoricalArtifact interface {
+	Artifact
+	// PreviousRuns returns the artifact at the same path in up to n runs
+	// preceding the run this artifact belongs to, newest first. The returned
+	// artifacts may not exist if a run did not produce them.
+	PreviousRuns(n int) ([]Artifact, error)
+}
+
+// StreamingArtifact is an Artifact that can be read as a stream, so that it
+// doesn't have to be held in memory at once.
+type StreamingArtifact interface {
+	Artifact
+	// NewReader returns a reader of the whole artifact. Like ReadAll, it
+	// fails for artifacts larger than the limit of the artifact.
+	NewReader() (io.ReadCloser, error)
+}
+
 // RequestAction defines the action for a request
 type RequestAction string
 
@@ -91,4 +111,72 @@ type LensRequest struct {
 	// LensIndex is the index by which the lens config can be found
 	// TODO: Replace with something proper or avoid needing this
 	LensIndex int `json:"index"`
+	// ProtocolVersion is the version of the lens serving protocol the
+	// request follows. Requests without a version leave fetching the
+	// artifacts to the lens.
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+	// Manifest holds the requested artifacts that were found, pushed by Deck
+	// with protocol version v1 so that lenses don't need access to the
+	// storage of the artifacts.
+	Manifest []ManifestArtifact `json:"manifest,omitempty"`
+}
+
+// ProtocolVersionV1 is the version of the lens serving protocol in which
+// Deck pushes the artifacts to the lens and lenses register themselves.
+const ProtocolVersionV1 = "v1"
+
+// ManifestArtifact is an artifact pushed to a lens.
+type ManifestArtifact struct {
+	// Name is the path of the artifact within the job.
+	Name string `json:"name"`
+	// Link is a link to the artifact in its storage.
+	Link string `json:"link,omitempty"`
+	// Size is the size of the artifact in bytes.
+	Size int64 `json:"size"`
+	// Content is the beginning of the artifact, as much of it as the limits
+	// of the lens allow.
+	Content []byte `json:"content,omitempty"`
+}
+
+// Truncated returns true if the content is only part of the artifact.
+func (a ManifestArtifact) Truncated() bool {
+	return int64(len(a.Content)) < a.Size
+}
+
+// LensRegistration describes a lens served by a lens server. Lens servers
+// list the lenses they serve at their registry endpoint, which Deck polls to
+// discover them.
+type LensRegistration struct {
+	// Name is the name of the lens, which must be unique across lenses.
+	Name string `json:"name"`
+	// Title is the human-readable title of the lens.
+	Title string `json:"title"`
+	// Priority orders the lenses, lowest priority first.
+	Priority uint `json:"priority,omitempty"`
+	// HideTitle hides the title once the lens is loaded.
+	HideTitle bool `json:"hideTitle,omitempty"`
+	// RequiredFiles and OptionalFiles are regexes of the artifacts the lens
+	// renders, like in the lens config of Deck.
+	RequiredFiles []string `json:"requiredFiles"`
+	OptionalFiles []string `json:"optionalFiles,omitempty"`
+	// Endpoint is where Deck sends the requests of the lens, relative to the
+	// registry endpoint unless it is an absolute URL.
+	Endpoint string `json:"endpoint"`
+	// StaticRoot is where browsers load the resources of the lens from,
+	// relative to the registry endpoint unless it is an absolute URL.
+	StaticRoot string `json:"staticRoot,omitempty"`
+	// ProtocolVersion is the version of the lens serving protocol the lens
+	// understands.
+	ProtocolVersion string `json:"protocolVersion"`
+	// Limits are the resources the lens asks for.
+	Limits LensLimits `json:"limits,omitempty"`
+}
+
+// LensLimits are the resources a lens is given per request. Deck caps them
+// with its own limits.
+type LensLimits struct {
+	// MaxArtifactBytes is how many bytes of artifacts are pushed in total.
+	MaxArtifactBytes int64 `json:"maxArtifactBytes,omitempty"`
+	// TimeoutSeconds is how long the lens may take to respond.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
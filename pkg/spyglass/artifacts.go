@@ -18,12 +18,17 @@ package spyglass
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/io/providers"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
@@ -32,23 +37,9 @@ import (
 
 // ListArtifacts gets the names of all artifacts available from the given source
 func (s *Spyglass) ListArtifacts(ctx context.Context, src string) ([]string, error) {
-	keyType, key, err := splitSrc(src)
+	gcsKey, err := s.storageKey(src)
 	if err != nil {
-		return []string{}, fmt.Errorf("error parsing src: %w", err)
-	}
-	gcsKey := ""
-	switch keyType {
-	case prowKeyType:
-		storageProvider, key, err := s.prowToGCS(key)
-		if err != nil {
-			logrus.Debugf("Failed to get gcs source for prow job: %v", err)
-		}
-		gcsKey = fmt.Sprintf("%s://%s", storageProvider, key)
-	default:
-		if keyType == gcsKeyType {
-			keyType = providers.GS
-		}
-		gcsKey = fmt.Sprintf("%s://%s", keyType, key)
+		return []string{}, err
 	}
 
 	artifactNames, err := s.StorageArtifactFetcher.artifacts(ctx, gcsKey)
@@ -88,10 +79,119 @@ func (s *Spyglass) ListArtifacts(ctx context.Context, src string) ([]string, err
 			artifactNamesSet.Insert(logName)
 		}
 	}
+	if job.Spec.Agent == prowapi.TektonAgent {
+		status, err := s.JobAgent.GetPipelineStatus(jobName, buildID)
+		if err != nil {
+			logrus.WithError(err).Debug("Failed to get the pipeline status of the job.")
+			return sets.List(artifactNamesSet), nil
+		}
+		for _, taskRun := range status.TaskRuns {
+			for _, step := range taskRun.Steps {
+				artifactNamesSet.Insert(fmt.Sprintf("%s-%s", step.Log, singleLogName))
+			}
+		}
+	}
 
 	return sets.List(artifactNamesSet), nil
 }
 
+// storageKey returns the key of the artifacts of the given source in storage.
+func (s *Spyglass) storageKey(src string) (string, error) {
+	keyType, key, err := splitSrc(src)
+	if err != nil {
+		return "", fmt.Errorf("error parsing src: %w", err)
+	}
+	switch keyType {
+	case prowKeyType:
+		storageProvider, key, err := s.prowToGCS(key)
+		if err != nil {
+			logrus.Debugf("Failed to get gcs source for prow job: %v", err)
+		}
+		return fmt.Sprintf("%s://%s", storageProvider, key), nil
+	case gcsKeyType:
+		keyType = providers.GS
+	}
+	return fmt.Sprintf("%s://%s", keyType, key), nil
+}
+
+// ArtifactEntry is a file or directory in a listing of the artifacts of a job.
+type ArtifactEntry struct {
+	// Name is the name of the entry in the directory listed. Names of
+	// directories end with a slash.
+	Name  string `json:"name"`
+	IsDir bool   `json:"is_dir,omitempty"`
+	// Size is the size of the file, or the total size of the files in the
+	// directory, in bytes.
+	Size int64 `json:"size"`
+	// Objects is the number of files in the directory, or 1 for files.
+	Objects int `json:"objects"`
+	// Updated is the time the file, or the last file in the directory, was
+	// updated.
+	Updated time.Time `json:"updated"`
+}
+
+// ErrInvalidArtifactQuery is returned by ListArtifactDir for directories and
+// sort orders it can't list.
+var ErrInvalidArtifactQuery = errors.New("invalid artifact query")
+
+// The orders artifact listings can be sorted in.
+const (
+	ArtifactSortName    = "name"
+	ArtifactSortSize    = "size"
+	ArtifactSortUpdated = "updated"
+)
+
+// ListArtifactDir lists the files and directories directly in the directory
+// dir of the artifacts of the given source, so that large artifact trees can
+// be browsed one directory at a time. Listings are sorted by name, with
+// directories first, by size or by update time, largest and latest first.
+func (s *Spyglass) ListArtifactDir(ctx context.Context, src, dir, sortBy string) ([]ArtifactEntry, error) {
+	if dir != "" {
+		dir = strings.Trim(dir, "/")
+		if cleaned := path.Clean(dir); cleaned != dir || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, fmt.Errorf("%w: invalid directory %q", ErrInvalidArtifactQuery, dir)
+		}
+		dir += "/"
+	}
+	var less func(a, b ArtifactEntry) bool
+	switch sortBy {
+	case "", ArtifactSortName:
+		less = func(a, b ArtifactEntry) bool {
+			if a.IsDir != b.IsDir {
+				return a.IsDir
+			}
+			return a.Name < b.Name
+		}
+	case ArtifactSortSize:
+		less = func(a, b ArtifactEntry) bool {
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+			return a.Name < b.Name
+		}
+	case ArtifactSortUpdated:
+		less = func(a, b ArtifactEntry) bool {
+			if !a.Updated.Equal(b.Updated) {
+				return a.Updated.After(b.Updated)
+			}
+			return a.Name < b.Name
+		}
+	default:
+		return nil, fmt.Errorf("%w: invalid sort order %q", ErrInvalidArtifactQuery, sortBy)
+	}
+
+	gcsKey, err := s.storageKey(src)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.StorageArtifactFetcher.artifactDir(ctx, gcsKey, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing artifacts: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return entries, nil
+}
+
 // prowToGCS returns the GCS key corresponding to the given prow key
 func (s *Spyglass) prowToGCS(prowKey string) (string, string, error) {
 	return common.ProwToGCS(s.JobAgent, s.config, prowKey)
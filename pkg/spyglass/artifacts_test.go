@@ -18,9 +18,12 @@ package spyglass
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -107,3 +110,94 @@ func TestSpyglass_ListArtifacts(t *testing.T) {
 		})
 	}
 }
+
+func TestSpyglass_ListArtifactDir(t *testing.T) {
+	buildLog := ArtifactEntry{Name: "build-log.txt", Size: 3, Objects: 1, Updated: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	artifacts := ArtifactEntry{Name: "artifacts/", IsDir: true, Size: 14, Objects: 2, Updated: time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC)}
+	aTxt := ArtifactEntry{Name: "a.txt", Size: 4, Objects: 1, Updated: time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC)}
+	nested := ArtifactEntry{Name: "nested/", IsDir: true, Size: 10, Objects: 1, Updated: time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC)}
+	tests := []struct {
+		name    string
+		src     string
+		dir     string
+		sort    string
+		want    []ArtifactEntry
+		wantErr bool
+	}{
+		{
+			name: "root sorted by name lists directories first",
+			src:  "gs/test-bucket/logs/artifact-tree/1",
+			want: []ArtifactEntry{artifacts, buildLog},
+		},
+		{
+			name: "root sorted by size",
+			src:  "gs/test-bucket/logs/artifact-tree/1",
+			sort: ArtifactSortSize,
+			want: []ArtifactEntry{artifacts, buildLog},
+		},
+		{
+			name: "subdirectory sorted by name",
+			src:  "gs/test-bucket/logs/artifact-tree/1",
+			dir:  "artifacts/",
+			want: []ArtifactEntry{nested, aTxt},
+		},
+		{
+			name: "subdirectory sorted by size",
+			src:  "gs/test-bucket/logs/artifact-tree/1",
+			dir:  "artifacts",
+			sort: ArtifactSortSize,
+			want: []ArtifactEntry{nested, aTxt},
+		},
+		{
+			name: "subdirectory sorted by update time",
+			src:  "gcs/test-bucket/logs/artifact-tree/1",
+			dir:  "artifacts",
+			sort: ArtifactSortUpdated,
+			want: []ArtifactEntry{nested, aTxt},
+		},
+		{
+			name: "missing directory is empty",
+			src:  "gs/test-bucket/logs/artifact-tree/1",
+			dir:  "missing",
+			want: []ArtifactEntry{},
+		},
+		{
+			name:    "directory outside of the job",
+			src:     "gs/test-bucket/logs/artifact-tree/1",
+			dir:     "../10",
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort order",
+			src:     "gs/test-bucket/logs/artifact-tree/1",
+			sort:    "color",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ca := &config.Agent{}
+			ca.Set(&config.Config{
+				ProwConfig: config.ProwConfig{
+					Deck: config.Deck{
+						AllKnownStorageBuckets: sets.New[string]("test-bucket"),
+					},
+				},
+			})
+			sg := New(context.Background(), fakeJa, ca.Config, io.NewGCSOpener(fakeGCSServer.Client()), false)
+			got, err := sg.ListArtifactDir(context.Background(), tt.src, tt.dir, tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListArtifactDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArtifactQuery) {
+					t.Errorf("expected an invalid query error, got %v", err)
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ListArtifactDir() differs from the expected listing (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
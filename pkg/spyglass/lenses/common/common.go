@@ -121,33 +121,45 @@ func newLensHandler(lens api.Lens, opts lensHandlerOpts) http.HandlerFunc {
 			return
 		}
 
-		switch request.Action {
-		case api.RequestActionInitial:
-			w.Header().Set("Content-Type", "text/html; encoding=utf-8")
-			lensTemplate.Execute(w, struct {
-				Title   string
-				BaseURL string
-				Head    template.HTML
-				Body    template.HTML
-			}{
-				opts.LensTitle,
-				request.ResourceRoot,
-				template.HTML(lens.Header(artifacts, opts.LensResourcesDir, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)),
-				template.HTML(lens.Body(artifacts, opts.LensResourcesDir, "", opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)),
-			})
-
-		case api.RequestActionRerender:
-			w.Header().Set("Content-Type", "text/html; encoding=utf-8")
-			w.Write([]byte(lens.Body(artifacts, opts.LensResourcesDir, request.Data, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)))
-
-		case api.RequestActionCallBack:
-			w.Write([]byte(lens.Callback(artifacts, opts.LensResourcesDir, request.Data, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)))
-
-		default:
-			w.WriteHeader(http.StatusBadRequest)
-			// This is a bit weird as we proxy this and the request we are complaining about was issued by Deck, not by the original client that sees this error
-			w.Write([]byte(fmt.Sprintf("Invalid action %q", request.Action)))
+		// Deck sends the config resolved for the repo of the run.
+		lensConfig := request.Config
+		if lensConfig == nil {
+			lensConfig = opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config
 		}
+
+		RenderLens(w, lens, artifacts, request, opts.LensTitle, opts.LensResourcesDir, lensConfig, opts.ConfigGetter().Deck.Spyglass)
+	}
+}
+
+// RenderLens writes the response of the lens to the request, which depends on
+// the action of the request.
+func RenderLens(w http.ResponseWriter, lens api.Lens, artifacts []api.Artifact, request *api.LensRequest, title, resourcesDir string, lensConfig json.RawMessage, spyglassConfig config.Spyglass) {
+	switch request.Action {
+	case api.RequestActionInitial:
+		w.Header().Set("Content-Type", "text/html; encoding=utf-8")
+		lensTemplate.Execute(w, struct {
+			Title   string
+			BaseURL string
+			Head    template.HTML
+			Body    template.HTML
+		}{
+			title,
+			request.ResourceRoot,
+			template.HTML(lens.Header(artifacts, resourcesDir, lensConfig, spyglassConfig)),
+			template.HTML(lens.Body(artifacts, resourcesDir, "", lensConfig, spyglassConfig)),
+		})
+
+	case api.RequestActionRerender:
+		w.Header().Set("Content-Type", "text/html; encoding=utf-8")
+		w.Write([]byte(lens.Body(artifacts, resourcesDir, request.Data, lensConfig, spyglassConfig)))
+
+	case api.RequestActionCallBack:
+		w.Write([]byte(lens.Callback(artifacts, resourcesDir, request.Data, lensConfig, spyglassConfig)))
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		// This is a bit weird as we proxy this and the request we are complaining about was issued by Deck, not by the original client that sees this error
+		w.Write([]byte(fmt.Sprintf("Invalid action %q", request.Action)))
 	}
 }
 
@@ -0,0 +1,397 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package explain provides a Spyglass lens that asks an analyzer service,
+// rule-based or backed by an LLM, for explanations of a failed run.
+package explain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "explain"
+	title    = "Explain this failure"
+	priority = 12
+
+	defaultLogLines         = 200
+	defaultMaxFailures      = 20
+	defaultMaxMessageLength = 2000
+	defaultTimeout          = 2 * time.Minute
+	defaultReplacement      = "[REDACTED]"
+	// maxResponseSize limits the response of the analyzer.
+	maxResponseSize = 1 << 20
+)
+
+var junitFile = regexp.MustCompile(`(^|/)junit.*\.xml$`)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens sends the build log excerpt and the failed tests of a run to an
+// analyzer and renders the hypotheses it returns.
+type Lens struct{}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// lensConfig is the lens-specific configuration. Repos can override it with
+// deck.spyglass.repo_lenses, for instance to add redactions.
+type lensConfig struct {
+	// Endpoint is the URL of the analyzer the failures are POSTed to.
+	Endpoint string `json:"endpoint"`
+	// LogLines is the number of lines at the end of the build log that are
+	// sent. Defaults to 200.
+	LogLines int64 `json:"log_lines,omitempty"`
+	// MaxFailures is the maximum number of failed tests that are sent.
+	// Defaults to 20.
+	MaxFailures int `json:"max_failures,omitempty"`
+	// MaxMessageLength is the length the messages and outputs of failed tests
+	// are truncated to. Defaults to 2000.
+	MaxMessageLength int `json:"max_message_length,omitempty"`
+	// Timeout is how long the analyzer may take. Defaults to 2m.
+	Timeout *prowapi.Duration `json:"timeout,omitempty"`
+	// Redactions replace the matches of regexes in everything sent to the
+	// analyzer, so that no secrets or personal data leave the cluster.
+	Redactions []redaction `json:"redactions,omitempty"`
+}
+
+type redaction struct {
+	// Regex is the regex whose matches are redacted.
+	Regex string `json:"regex"`
+	// Replacement replaces the matches, defaults to [REDACTED]. It may refer
+	// to submatches like $1.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type parsedConfig struct {
+	lensConfig
+	timeout    time.Duration
+	redactions []*regexp.Regexp
+}
+
+func parseConfig(raw json.RawMessage) (*parsedConfig, error) {
+	c := &parsedConfig{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &c.lensConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse the config: %w", err)
+		}
+	}
+	if c.Endpoint == "" {
+		return nil, errors.New("no analyzer endpoint is configured")
+	}
+	if c.LogLines <= 0 {
+		c.LogLines = defaultLogLines
+	}
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = defaultMaxFailures
+	}
+	if c.MaxMessageLength <= 0 {
+		c.MaxMessageLength = defaultMaxMessageLength
+	}
+	c.timeout = defaultTimeout
+	if c.Timeout != nil {
+		c.timeout = c.Timeout.Duration
+	}
+	for i, r := range c.Redactions {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of redaction %d: %w", i, err)
+		}
+		if r.Replacement == "" {
+			c.Redactions[i].Replacement = defaultReplacement
+		}
+		c.redactions = append(c.redactions, re)
+	}
+	return c, nil
+}
+
+// redact applies the redactions to the text.
+func (c *parsedConfig) redact(text string) string {
+	for i, re := range c.redactions {
+		text = re.ReplaceAllString(text, c.Redactions[i].Replacement)
+	}
+	return text
+}
+
+func (c *parsedConfig) truncate(text string) string {
+	if len(text) <= c.MaxMessageLength {
+		return text
+	}
+	return text[:c.MaxMessageLength] + "..."
+}
+
+// AnalysisRequest is POSTed to the analyzer as JSON.
+type AnalysisRequest struct {
+	// Job is the name of the job.
+	Job string `json:"job,omitempty"`
+	// BuildID is the ID of the run.
+	BuildID string `json:"build_id,omitempty"`
+	// Type is the type of the job, like presubmit.
+	Type prowapi.ProwJobType `json:"type,omitempty"`
+	// State is the state the run ended in, like failure.
+	State prowapi.ProwJobState `json:"state,omitempty"`
+	// Refs are the refs the job ran against, if any.
+	Refs *prowapi.Refs `json:"refs,omitempty"`
+	// LogExcerpt is the end of the build log.
+	LogExcerpt string `json:"log_excerpt,omitempty"`
+	// Failures are the failed tests reported in junit files.
+	Failures []TestFailure `json:"failures,omitempty"`
+	// Tests is the number of tests reported in junit files.
+	Tests int `json:"tests"`
+}
+
+// TestFailure is a failed test case.
+type TestFailure struct {
+	Suite   string `json:"suite,omitempty"`
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// AnalysisResponse is returned by the analyzer as JSON.
+type AnalysisResponse struct {
+	// Hypotheses are the possible explanations of the failure, the most
+	// likely first.
+	Hypotheses []Hypothesis `json:"hypotheses"`
+}
+
+// Hypothesis is a possible explanation of a failure.
+type Hypothesis struct {
+	// Summary explains the failure in a sentence.
+	Summary string `json:"summary"`
+	// Details may elaborate on the summary and suggest fixes.
+	Details string `json:"details,omitempty"`
+	// Confidence is between 0 and 1, if the analyzer is able to tell.
+	Confidence *float64 `json:"confidence,omitempty"`
+	// Links point to related issues, docs or similar failures.
+	Links []Link `json:"links,omitempty"`
+}
+
+// ConfidencePercent formats the confidence for the template.
+func (h Hypothesis) ConfidencePercent() string {
+	if h.Confidence == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", *h.Confidence*100)
+}
+
+// Link is a link of a hypothesis.
+type Link struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+type bodyView struct {
+	// Notice is shown instead of the button if the run can't be explained.
+	Notice string
+}
+
+type resultView struct {
+	Error      string
+	Hypotheses []Hypothesis
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return executeTemplate(resourceDir, "header", nil)
+}
+
+// Body renders the button requesting an analysis, which isn't done on load
+// as analyzers can be slow and expensive.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	var view bodyView
+	if _, err := parseConfig(rawConfig); err != nil {
+		view.Notice = fmt.Sprintf("The lens is misconfigured: %v", err)
+	} else if pj := prowJob(artifacts); pj != nil && pj.Status.State == prowapi.SuccessState {
+		view.Notice = "Only failed runs can be explained."
+	}
+	return executeTemplate(resourceDir, "body", view)
+}
+
+// Callback requests the analysis and renders its result.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	var view resultView
+	c, err := parseConfig(rawConfig)
+	if err == nil {
+		view.Hypotheses, err = analyze(context.Background(), http.DefaultClient, c, artifacts)
+	}
+	if err != nil {
+		logrus.WithError(err).Info("Failed to explain the failure.")
+		view.Error = err.Error()
+	}
+	return executeTemplate(resourceDir, "result", view)
+}
+
+func prowJob(artifacts []api.Artifact) *prowapi.ProwJob {
+	for _, artifact := range artifacts {
+		if artifact.JobPath() != prowapi.ProwJobFile {
+			continue
+		}
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).Warn("Couldn't read the prowjob.json.")
+			return nil
+		}
+		var pj prowapi.ProwJob
+		if err := json.Unmarshal(content, &pj); err != nil {
+			logrus.WithError(err).Warn("Couldn't unmarshal the prowjob.json.")
+			return nil
+		}
+		return &pj
+	}
+	return nil
+}
+
+// buildRequest gathers what is sent to the analyzer from the artifacts.
+func buildRequest(c *parsedConfig, artifacts []api.Artifact) (*AnalysisRequest, error) {
+	req := &AnalysisRequest{}
+	if pj := prowJob(artifacts); pj != nil {
+		req.Job = pj.Spec.Job
+		req.BuildID = pj.Status.BuildID
+		req.Type = pj.Spec.Type
+		req.State = pj.Status.State
+		req.Refs = pj.Spec.Refs
+	}
+	for _, artifact := range artifacts {
+		jobPath := artifact.JobPath()
+		switch {
+		case path.Base(jobPath) == "build-log.txt" && req.LogExcerpt == "":
+			lines, err := lenses.LastNLines(artifact, c.LogLines)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", jobPath, err)
+			}
+			req.LogExcerpt = c.redact(strings.Join(lines, "\n"))
+		case junitFile.MatchString(jobPath):
+			content, err := artifact.ReadAll()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", jobPath, err)
+			}
+			suites, err := junit.Parse(content)
+			if err != nil {
+				logrus.WithError(err).WithField("artifact", jobPath).Info("Skipping unparsable junit file.")
+				continue
+			}
+			addFailures(c, req, suites.Suites)
+		}
+	}
+	return req, nil
+}
+
+func addFailures(c *parsedConfig, req *AnalysisRequest, suites []junit.Suite) {
+	for _, suite := range suites {
+		addFailures(c, req, suite.Suites)
+		for _, result := range suite.Results {
+			if result.Skipped != nil {
+				continue
+			}
+			req.Tests++
+			var message, output string
+			switch {
+			case result.Failure != nil:
+				message, output = result.Failure.Message, result.Failure.Value
+			case result.Errored != nil:
+				message, output = result.Errored.Message, result.Errored.Value
+			default:
+				continue
+			}
+			if len(req.Failures) >= c.MaxFailures {
+				continue
+			}
+			req.Failures = append(req.Failures, TestFailure{
+				Suite:   suite.Name,
+				Name:    result.Name,
+				Message: c.truncate(c.redact(message)),
+				Output:  c.truncate(c.redact(output)),
+			})
+		}
+	}
+}
+
+// analyze POSTs the failures of the run to the analyzer and returns the
+// hypotheses it came up with.
+func analyze(ctx context.Context, client *http.Client, c *parsedConfig, artifacts []api.Artifact) ([]Hypothesis, error) {
+	req, err := buildRequest(c, artifacts)
+	if err != nil {
+		return nil, err
+	}
+	if req.LogExcerpt == "" && len(req.Failures) == 0 {
+		return nil, errors.New("neither a build log nor failed tests were found")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the request: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("the analyzer couldn't be reached: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the analyzer responded with status %d", resp.StatusCode)
+	}
+	var analysis AnalysisResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&analysis); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of the analyzer: %w", err)
+	}
+	return analysis.Hypotheses, nil
+}
+
+func executeTemplate(resourceDir, templateName string, data interface{}) string {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return fmt.Sprintf("<!-- FAILED LOADING TEMPLATE: %v -->", err)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, templateName, data); err != nil {
+		return fmt.Sprintf("<!-- FAILED EXECUTING %s TEMPLATE: %v -->", strings.ToUpper(templateName), err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,21 @@
+const handleExplain = async (button: HTMLButtonElement): Promise<void> => {
+  const result = document.getElementById('explain-result')!;
+  button.disabled = true;
+  button.innerText = 'Analyzing...';
+  try {
+    result.innerHTML = await spyglass.request('');
+    button.remove();
+  } catch (e) {
+    result.innerText = `Failed to explain the failure: ${e}`;
+    button.disabled = false;
+    button.innerText = 'Explain this failure';
+  }
+  spyglass.contentUpdated();
+};
+
+window.addEventListener('DOMContentLoaded', () => {
+  const button = document.getElementById('explain-button') as HTMLButtonElement | null;
+  if (button) {
+    button.addEventListener('click', () => handleExplain(button));
+  }
+});
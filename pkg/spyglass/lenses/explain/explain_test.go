@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func testArtifacts() []api.Artifact {
+	return []api.Artifact{
+		&fake.Artifact{
+			Path:    "prowjob.json",
+			Content: []byte(`{"spec":{"type":"presubmit","job":"pull-foo","refs":{"org":"org","repo":"repo","base_ref":"main"}},"status":{"state":"failure","build_id":"123"}}`),
+		},
+		&fake.Artifact{
+			Path:    "build-log.txt",
+			Content: []byte("cloning\nlogin with token=abc123\nFAIL: TestFoo\nexit status 1\n"),
+		},
+		&fake.Artifact{
+			Path: "artifacts/junit_01.xml",
+			Content: []byte(`<testsuites><testsuite name="pkg">
+<testcase name="TestFoo"><failure message="expected 1, got 2">foo_test.go:12: token=abc123</failure></testcase>
+<testcase name="TestBar"></testcase>
+<testcase name="TestBaz"><skipped/></testcase>
+</testsuite></testsuites>`),
+		},
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	c, err := parseConfig(json.RawMessage(`{"endpoint":"http://analyzer","log_lines":3,"redactions":[{"regex":"token=\\w+","replacement":"token=***"}]}`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	req, err := buildRequest(c, testArtifacts())
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	expected := &AnalysisRequest{
+		Job:        "pull-foo",
+		BuildID:    "123",
+		Type:       prowapi.PresubmitJob,
+		State:      prowapi.FailureState,
+		Refs:       &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main"},
+		LogExcerpt: "login with token=***\nFAIL: TestFoo\nexit status 1",
+		Failures: []TestFailure{
+			{Suite: "pkg", Name: "TestFoo", Message: "expected 1, got 2", Output: "foo_test.go:12: token=***"},
+		},
+		Tests: 2,
+	}
+	if diff := cmp.Diff(expected, req); diff != "" {
+		t.Errorf("unexpected request (-want +got): %s", diff)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		expectedErr bool
+	}{
+		{
+			name: "defaults",
+			raw:  `{"endpoint":"http://analyzer"}`,
+		},
+		{
+			name:        "endpoint is required",
+			raw:         `{"log_lines":10}`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid redaction",
+			raw:         `{"endpoint":"http://analyzer","redactions":[{"regex":"("}]}`,
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseConfig(json.RawMessage(tc.raw)); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	testCases := []struct {
+		name               string
+		status             int
+		response           string
+		expectedHypotheses []Hypothesis
+		expectedErr        string
+	}{
+		{
+			name:     "hypotheses are returned",
+			status:   http.StatusOK,
+			response: `{"hypotheses":[{"summary":"TestFoo is flaky","confidence":0.8,"links":[{"url":"https://example.com/issues/1"}]}]}`,
+			expectedHypotheses: []Hypothesis{
+				{Summary: "TestFoo is flaky", Confidence: func() *float64 { c := 0.8; return &c }(), Links: []Link{{URL: "https://example.com/issues/1"}}},
+			},
+		},
+		{
+			name:        "analyzer failure",
+			status:      http.StatusInternalServerError,
+			expectedErr: "the analyzer responded with status 500",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req AnalysisRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if req.Job != "pull-foo" || len(req.Failures) != 1 {
+					t.Errorf("unexpected request: %+v", req)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+			c, err := parseConfig(json.RawMessage(`{"endpoint":"` + server.URL + `"}`))
+			if err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+			hypotheses, err := analyze(context.Background(), server.Client(), c, testArtifacts())
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedHypotheses, hypotheses); diff != "" {
+				t.Errorf("unexpected hypotheses (-want +got): %s", diff)
+			}
+		})
+	}
+}
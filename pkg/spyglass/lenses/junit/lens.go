@@ -20,15 +20,20 @@ package junit
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html/template"
+	"io"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
 	"github.com/sirupsen/logrus"
 
+	"sigs.k8s.io/prow/pkg/cache"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
@@ -41,8 +46,29 @@ const (
 	passedStatus  testStatus = "Passed"
 	failedStatus  testStatus = "Failed"
 	skippedStatus testStatus = "Skipped"
+	flakyStatus   testStatus = "Flaky"
+
+	// Verdicts for failed tests based on their history.
+	likelyFlakeVerdict       = "Likely flake"
+	consistentFailureVerdict = "Consistent failure"
+	newFailureVerdict        = "New failure"
+
+	// defaultPageSize is the number of tests of each status shown at once.
+	defaultPageSize = 100
+	// parsedCacheSize is the number of parsed sets of artifacts that are
+	// kept, so paging through and searching large results doesn't parse
+	// them again.
+	parsedCacheSize = 20
 )
 
+var parsed = func() *cache.LRUCache {
+	c, err := cache.NewLRUCache(parsedCacheSize, cache.Callbacks{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create the junit lens cache.")
+	}
+	return c
+}()
+
 func init() {
 	lenses.RegisterLens(Lens{})
 }
@@ -52,6 +78,17 @@ type testStatus string
 // Lens is the implementation of a JUnit-rendering Spyglass lens.
 type Lens struct{}
 
+// lensConfig is the configuration of the lens.
+type lensConfig struct {
+	// FlakeHistoryRuns is the number of previous runs of the job that are
+	// looked up to show the history of failed tests. Zero disables the
+	// history.
+	FlakeHistoryRuns int `json:"flake_history_runs,omitempty"`
+	// PageSize is the number of tests of each status that are shown at
+	// once. Defaults to 100.
+	PageSize int `json:"page_size,omitempty"`
+}
+
 type JVD struct {
 	NumTests int
 	Passed   []TestResult
@@ -117,11 +154,69 @@ func (jr JunitResult) SkippedReason() string {
 type TestResult struct {
 	Junit []JunitResult
 	Link  string
+	// History holds the results of a failed test in previous runs of the
+	// job, if the flake history is enabled.
+	History *TestHistory
+}
+
+// TestHistory holds the statuses of a test in previous runs of the job,
+// newest first. Runs that did not record the test are left out.
+type TestHistory struct {
+	Statuses []testStatus
+}
+
+// Verdict classifies a failed test by its history.
+func (h *TestHistory) Verdict() string {
+	passed, failed, flaky := h.count()
+	switch {
+	case flaky > 0 || (passed > 0 && failed > 0):
+		return likelyFlakeVerdict
+	case failed > 0:
+		return consistentFailureVerdict
+	case passed > 0:
+		return newFailureVerdict
+	}
+	return ""
+}
+
+// LikelyFlake is true if the test both passed and failed in previous runs.
+func (h *TestHistory) LikelyFlake() bool {
+	return h.Verdict() == likelyFlakeVerdict
 }
 
-// Body renders the <body> for JUnit tests
+func (h *TestHistory) count() (passed, failed, flaky int) {
+	for _, status := range h.Statuses {
+		switch status {
+		case passedStatus:
+			passed++
+		case failedStatus:
+			failed++
+		case flakyStatus:
+			flaky++
+		}
+	}
+	return passed, failed, flaky
+}
+
+// Body renders the <body> for JUnit tests. The front-end passes the search
+// query and the pages to show as data.
 func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
-	jvd := lens.getJvd(artifacts)
+	var conf lensConfig
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &conf); err != nil {
+			logrus.WithError(err).Warn("Failed to unmarshal junit lens config.")
+		}
+	}
+	if conf.PageSize <= 0 {
+		conf.PageSize = defaultPageSize
+	}
+	var request bodyRequest
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &request); err != nil {
+			logrus.WithError(err).Warn("Failed to unmarshal junit lens request.")
+		}
+	}
+	jvd := lens.cachedJvd(artifacts, conf.FlakeHistoryRuns)
 
 	junitTemplate, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
 	if err != nil {
@@ -130,74 +225,293 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 	}
 
 	var buf bytes.Buffer
-	if err := junitTemplate.ExecuteTemplate(&buf, "body", jvd); err != nil {
+	if err := junitTemplate.ExecuteTemplate(&buf, "body", paginate(jvd, request, conf.PageSize)); err != nil {
 		logrus.WithError(err).Error("Error executing template.")
 	}
 
 	return buf.String()
 }
 
-func (lens Lens) getJvd(artifacts []api.Artifact) JVD {
-	type testResults struct {
-		// Group results based on their full path name
-		junit [][]JunitResult
-		link  string
-		path  string
-		err   error
+// bodyRequest selects the tests the body shows.
+type bodyRequest struct {
+	// Query filters the tests by a case-insensitive substring of their
+	// class and name.
+	Query string `json:"query,omitempty"`
+	// Status only shows the tests with the status if set.
+	Status testStatus `json:"status,omitempty"`
+	// Pages are the zero-based pages shown of each status.
+	Pages map[testStatus]int `json:"pages,omitempty"`
+}
+
+// view is a page of each status of the tests matching a request.
+type view struct {
+	JVD
+	Request  bodyRequest
+	Sections map[testStatus]section
+}
+
+// section describes the page shown of the tests of a status.
+type section struct {
+	Status testStatus
+	// Total is the number of tests matching the request.
+	Total int
+	// Page is the zero-based page shown out of Pages, between the
+	// Previous and Next pages.
+	Page     int
+	Pages    int
+	Previous int
+	Next     int
+	// First and Last are the one-based positions of the shown tests.
+	First int
+	Last  int
+	// Expanded is true if the tests are shown without clicking the header.
+	Expanded bool
+}
+
+// Section returns the section of the status.
+func (v view) Section(status testStatus) section {
+	return v.Sections[status]
+}
+
+// Statuses are the statuses the tests can be filtered by.
+func (v view) Statuses() []testStatus {
+	return []testStatus{failedStatus, flakyStatus, passedStatus, skippedStatus}
+}
+
+// RequestJSON is the request of the view, for the front-end to modify.
+func (v view) RequestJSON() string {
+	raw, _ := json.Marshal(v.Request)
+	return string(raw)
+}
+
+// Matched returns the number of tests matching the request.
+func (v view) Matched() int {
+	var matched int
+	for _, section := range v.Sections {
+		matched += section.Total
 	}
-	type testIdentifier struct {
-		suite string
-		class string
-		name  string
+	return matched
+}
+
+// paginate returns the requested page of each status of the tests matching
+// the request.
+func paginate(jvd JVD, request bodyRequest, pageSize int) view {
+	v := view{JVD: JVD{NumTests: jvd.NumTests}, Request: request, Sections: map[testStatus]section{}}
+	query := strings.ToLower(request.Query)
+	for _, list := range []struct {
+		status testStatus
+		all    []TestResult
+		page   *[]TestResult
+	}{
+		{failedStatus, jvd.Failed, &v.Failed},
+		{flakyStatus, jvd.Flaky, &v.Flaky},
+		{passedStatus, jvd.Passed, &v.Passed},
+		{skippedStatus, jvd.Skipped, &v.Skipped},
+	} {
+		if request.Status != "" && request.Status != list.status {
+			continue
+		}
+		var matching []TestResult
+		for _, test := range list.all {
+			if query == "" || strings.Contains(strings.ToLower(test.Junit[0].ClassName+": "+test.Junit[0].Name), query) {
+				matching = append(matching, test)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+		pages := (len(matching) + pageSize - 1) / pageSize
+		page := request.Pages[list.status]
+		if page < 0 || page >= pages {
+			page = 0
+		}
+		first, last := page*pageSize, min((page+1)*pageSize, len(matching))
+		*list.page = matching[first:last]
+		_, paged := request.Pages[list.status]
+		v.Sections[list.status] = section{
+			Status:   list.status,
+			Total:    len(matching),
+			Page:     page,
+			Pages:    pages,
+			Previous: page - 1,
+			Next:     page + 1,
+			First:    first + 1,
+			Last:     last,
+			Expanded: list.status == failedStatus || list.status == flakyStatus || paged || request.Query != "" || request.Status != "",
+		}
 	}
-	resultChan := make(chan testResults)
+	return v
+}
+
+// cachedJvd returns the results of the artifacts, parsing them only if they
+// aren't cached or changed since they were cached.
+func (lens Lens) cachedJvd(artifacts []api.Artifact, historyRuns int) JVD {
+	key := fmt.Sprintf("history=%d", historyRuns)
 	for _, artifact := range artifacts {
-		go func(artifact api.Artifact) {
-			groups := make(map[testIdentifier][]JunitResult)
-			var testsSequence []testIdentifier
-			result := testResults{
-				link: artifact.CanonicalLink(),
-				path: artifact.JobPath(),
+		size, err := artifact.Size()
+		if err != nil {
+			return lens.getJvd(artifacts, historyRuns)
+		}
+		key += fmt.Sprintf(" %s@%d", artifact.CanonicalLink(), size)
+	}
+	jvd, _, _ := parsed.GetOrAdd(key, func() (interface{}, error) {
+		return lens.getJvd(artifacts, historyRuns), nil
+	})
+	return jvd.(JVD)
+}
+
+type testIdentifier struct {
+	suite string
+	class string
+	name  string
+}
+
+// parseArtifact reads the junit results from an artifact, grouping multiple
+// results of the same test in the order the tests first appear. The results
+// are parsed one at a time, so large files don't have to be held in memory
+// at once if the artifact can be streamed.
+func parseArtifact(artifact api.Artifact) ([]testIdentifier, [][]JunitResult, error) {
+	reader, err := openArtifact(artifact)
+	if err != nil {
+		logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+		return nil, nil, err
+	}
+	defer reader.Close()
+	groups := make(map[testIdentifier][]JunitResult)
+	var testsSequence []testIdentifier
+	err = walkResults(reader, func(suite string, test junit.Result) {
+		// There are cases where multiple entries of exactly the same
+		// testcase in a single junit result file, this could result
+		// from reruns of test cases by `go test --count=N` where N>1.
+		// Deduplicate them here in this case, and classify a test as being
+		// flaky if it both succeeded and failed
+		k := testIdentifier{suite, test.ClassName, test.Name}
+		groups[k] = append(groups[k], JunitResult{Result: test})
+		if len(groups[k]) == 1 {
+			testsSequence = append(testsSequence, k)
+		}
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing junit file.")
+		return nil, nil, err
+	}
+	var results [][]JunitResult
+	for _, identifier := range testsSequence {
+		results = append(results, groups[identifier])
+	}
+	return testsSequence, results, nil
+}
+
+func openArtifact(artifact api.Artifact) (io.ReadCloser, error) {
+	if streaming, ok := artifact.(api.StreamingArtifact); ok {
+		return streaming.NewReader()
+	}
+	contents, err := artifact.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+// walkResults decodes the test cases of a junit file one at a time and
+// visits them with the name of the suite they belong to.
+func walkResults(r io.Reader, visit func(suite string, test junit.Result)) error {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch charset {
+		case "UTF-8", "utf8", "":
+			return input, nil
+		default:
+			return nil, fmt.Errorf("unknown charset: %s", charset)
+		}
+	}
+	var suites []string
+	var depth int
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 && t.Name.Local != "testsuites" && t.Name.Local != "testsuite" {
+				return fmt.Errorf("bad element name: %q", t.Name)
 			}
-			var contents []byte
-			contents, result.err = artifact.ReadAll()
-			if result.err != nil {
-				logrus.WithError(result.err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
-				resultChan <- result
-				return
+			switch {
+			case t.Name.Local == "testsuite":
+				var name string
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						name = attr.Value
+					}
+				}
+				suites = append(suites, name)
+			case t.Name.Local == "testcase" && len(suites) > 0:
+				var test junit.Result
+				if err := decoder.DecodeElement(&test, &t); err != nil {
+					return err
+				}
+				visit(suites[len(suites)-1], test)
+				continue
 			}
-			var suites *junit.Suites
-			suites, result.err = junit.Parse(contents)
-			if result.err != nil {
-				logrus.WithError(result.err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing junit file.")
-				resultChan <- result
-				return
+			depth++
+		case xml.EndElement:
+			depth--
+			if t.Name.Local == "testsuite" && len(suites) > 0 {
+				suites = suites[:len(suites)-1]
 			}
-			var record func(suite junit.Suite)
-			record = func(suite junit.Suite) {
-				for _, subSuite := range suite.Suites {
-					record(subSuite)
-				}
+		}
+	}
+}
 
-				for _, test := range suite.Results {
-					// There are cases where multiple entries of exactly the same
-					// testcase in a single junit result file, this could result
-					// from reruns of test cases by `go test --count=N` where N>1.
-					// Deduplicate them here in this case, and classify a test as being
-					// flaky if it both succeeded and failed
-					k := testIdentifier{suite.Name, test.ClassName, test.Name}
-					groups[k] = append(groups[k], JunitResult{Result: test})
-					if len(groups[k]) == 1 {
-						testsSequence = append(testsSequence, k)
-					}
-				}
+// classify determines the outcome of the results of a single test.
+func classify(tests []JunitResult) (skipped, passed, failed, flaky bool) {
+	for _, test := range tests {
+		// skipped test has no reason to rerun, so no deduplication
+		if test.Status() == skippedStatus {
+			skipped = true
+		} else if test.Status() == failedStatus {
+			if passed {
+				passed = false
+				failed = false
+				flaky = true
 			}
-			for _, suite := range suites.Suites {
-				record(suite)
+			if !flaky {
+				failed = true
 			}
-			for _, identifier := range testsSequence {
-				result.junit = append(result.junit, groups[identifier])
+		} else if failed { // Test succeeded but marked failed previously
+			passed = false
+			failed = false
+			flaky = true
+		} else if !flaky { // Test succeeded and not marked as flaky
+			passed = true
+		}
+	}
+	return skipped, passed, failed, flaky
+}
+
+func (lens Lens) getJvd(artifacts []api.Artifact, historyRuns int) JVD {
+	type testResults struct {
+		// Group results based on their full path name
+		ids      []testIdentifier
+		junit    [][]JunitResult
+		artifact api.Artifact
+		link     string
+		path     string
+		err      error
+	}
+	resultChan := make(chan testResults)
+	for _, artifact := range artifacts {
+		go func(artifact api.Artifact) {
+			result := testResults{
+				artifact: artifact,
+				link:     artifact.CanonicalLink(),
+				path:     artifact.JobPath(),
 			}
+			result.ids, result.junit, result.err = parseArtifact(artifact)
 			resultChan <- result
 		}(artifact)
 	}
@@ -209,43 +523,22 @@ func (lens Lens) getJvd(artifacts []api.Artifact) JVD {
 
 	var jvd JVD
 	var duplicates int
+	var failures []artifactFailures
 
 	for _, result := range results {
 		if result.err != nil {
 			continue
 		}
-		for _, tests := range result.junit {
-			var (
-				skipped bool
-				passed  bool
-				failed  bool
-				flaky   bool
-			)
-			for _, test := range tests {
-				// skipped test has no reason to rerun, so no deduplication
-				if test.Status() == skippedStatus {
-					skipped = true
-				} else if test.Status() == failedStatus {
-					if passed {
-						passed = false
-						failed = false
-						flaky = true
-					}
-					if !flaky {
-						failed = true
-					}
-				} else if failed { // Test succeeded but marked failed previously
-					passed = false
-					failed = false
-					flaky = true
-				} else if !flaky { // Test succeeded and not marked as flaky
-					passed = true
-				}
+		artifactFailed := artifactFailures{artifact: result.artifact, indexes: map[testIdentifier]int{}}
+		for i, tests := range result.junit {
+			skipped, _, failed, flaky := classify(tests)
+			if failed {
+				artifactFailed.indexes[result.ids[i]] = len(jvd.Failed)
 			}
 
 			if skipped {
 				jvd.Skipped = append(jvd.Skipped, TestResult{
-					Junit: tests,
+					Junit: withoutOutput(tests),
 					Link:  result.link,
 				})
 				// if the skipped test is a rerun of a failed test
@@ -270,13 +563,91 @@ func (lens Lens) getJvd(artifacts []api.Artifact) JVD {
 				})
 			} else {
 				jvd.Passed = append(jvd.Passed, TestResult{
-					Junit: tests,
+					Junit: withoutOutput(tests),
 					Link:  result.link,
 				})
 			}
 		}
+		if len(artifactFailed.indexes) > 0 {
+			failures = append(failures, artifactFailed)
+		}
 	}
 
 	jvd.NumTests = len(jvd.Passed) + len(jvd.Failed) + len(jvd.Flaky) + len(jvd.Skipped) - duplicates
+	if historyRuns > 0 {
+		addHistory(&jvd, failures, historyRuns)
+	}
 	return jvd
 }
+
+// withoutOutput drops the output of tests whose output isn't shown, which
+// makes up most of the size of large results.
+func withoutOutput(tests []JunitResult) []JunitResult {
+	stripped := make([]JunitResult, 0, len(tests))
+	for _, test := range tests {
+		test.Output, test.Error, test.Properties = nil, nil, nil
+		stripped = append(stripped, test)
+	}
+	return stripped
+}
+
+// artifactFailures maps the failed tests of an artifact to their index in
+// JVD.Failed.
+type artifactFailures struct {
+	artifact api.Artifact
+	indexes  map[testIdentifier]int
+}
+
+// addHistory looks up the failed tests in the same artifacts of up to runs
+// previous runs of the job and records their statuses.
+func addHistory(jvd *JVD, failures []artifactFailures, runs int) {
+	for _, failed := range failures {
+		historical, ok := failed.artifact.(api.HistoricalArtifact)
+		if !ok {
+			continue
+		}
+		previous, err := historical.PreviousRuns(runs)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", failed.artifact.JobPath()).Info("Failed to find previous runs.")
+			continue
+		}
+		for _, index := range failed.indexes {
+			jvd.Failed[index].History = &TestHistory{}
+		}
+		var wg sync.WaitGroup
+		statuses := make([]map[testIdentifier]testStatus, len(previous))
+		for i, run := range previous {
+			wg.Add(1)
+			go func(i int, run api.Artifact) {
+				defer wg.Done()
+				runIDs, results, err := parseArtifact(run)
+				if err != nil {
+					// The run may have failed before recording any results.
+					return
+				}
+				runStatuses := map[testIdentifier]testStatus{}
+				for j, tests := range results {
+					switch skipped, passed, failed, flaky := classify(tests); {
+					case failed:
+						runStatuses[runIDs[j]] = failedStatus
+					case flaky:
+						runStatuses[runIDs[j]] = flakyStatus
+					case passed:
+						runStatuses[runIDs[j]] = passedStatus
+					case skipped:
+						runStatuses[runIDs[j]] = skippedStatus
+					}
+				}
+				statuses[i] = runStatuses
+			}(i, run)
+		}
+		wg.Wait()
+		for _, runStatuses := range statuses {
+			for id, index := range failed.indexes {
+				if status, ok := runStatuses[id]; ok {
+					jvd.Failed[index].History.Statuses = append(jvd.Failed[index].History.Statuses, status)
+				}
+			}
+		}
+	}
+}
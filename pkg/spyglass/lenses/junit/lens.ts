@@ -51,10 +51,56 @@ const addStdoutStderrOpeners = (): void => {
   }
 };
 
+interface BodyRequest {
+  query?: string;
+  status?: string;
+  pages?: {[status: string]: number};
+}
+
+// showPage re-renders the body on the server with the given request, which
+// parses large results only once and shows a page of them.
+const showPage = async (request: BodyRequest): Promise<void> => {
+  const body = document.getElementById('junit-body')!;
+  body.outerHTML = await spyglass.requestPage(JSON.stringify(request));
+  loaded();
+  spyglass.contentUpdated();
+};
+
+const currentRequest = (): BodyRequest => {
+  const body = document.getElementById('junit-body')!;
+  return JSON.parse(body.dataset.request || '{}');
+};
+
+const addSearch = (): void => {
+  const form = document.querySelector<HTMLFormElement>('#junit-search');
+  if (!form) {
+    return;
+  }
+  form.onsubmit = (e) => {
+    e.preventDefault();
+    const data = new FormData(form);
+    showPage({query: String(data.get('query') || ''), status: String(data.get('status') || '')});
+  };
+};
+
+const addPageButtons = (): void => {
+  const buttons = document.querySelectorAll<HTMLButtonElement>('button.page-button');
+  for (const button of Array.from(buttons)) {
+    button.onclick = (e) => {
+      e.stopPropagation();
+      const request = currentRequest();
+      request.pages = {...request.pages, [button.dataset.status!]: Number(button.dataset.page)};
+      showPage(request);
+    };
+  }
+};
+
 const loaded = (): void => {
   addTestExpanders();
   addStdoutStderrOpeners();
   addSectionExpanders();
+  addSearch();
+  addPageButtons();
 };
 
 window.addEventListener('DOMContentLoaded', loaded);
@@ -849,7 +849,7 @@ func TestGetJvd(t *testing.T) {
 				})
 			}
 			l := Lens{}
-			got := l.getJvd(artifacts)
+			got := l.getJvd(artifacts, 0)
 			if diff := cmp.Diff(tt.exp, got); diff != "" {
 				t.Fatalf("JVD mismatch, want(-), got(+): \n%s", diff)
 			}
@@ -857,6 +857,251 @@ func TestGetJvd(t *testing.T) {
 	}
 }
 
+// fakeHistoricalArtifact is a FakeArtifact with previous runs.
+type fakeHistoricalArtifact struct {
+	FakeArtifact
+	previous []api.Artifact
+}
+
+func (fa *fakeHistoricalArtifact) PreviousRuns(n int) ([]api.Artifact, error) {
+	if len(fa.previous) > n {
+		return fa.previous[:n], nil
+	}
+	return fa.previous, nil
+}
+
+func TestGetJvdHistory(t *testing.T) {
+	result := func(tests ...string) []byte {
+		var buf bytes.Buffer
+		buf.WriteString(`<testsuites><testsuite name="suite">`)
+		for _, test := range tests {
+			name, status, _ := strings.Cut(test, "=")
+			buf.WriteString(`<testcase classname="class" name="` + name + `">`)
+			switch status {
+			case "failed":
+				buf.WriteString(`<failure>failure</failure>`)
+			case "skipped":
+				buf.WriteString(`<skipped/>`)
+			}
+			buf.WriteString(`</testcase>`)
+		}
+		buf.WriteString(`</testsuite></testsuites>`)
+		return buf.Bytes()
+	}
+	run := func(contents []byte) api.Artifact {
+		return &FakeArtifact{path: "junit.xml", content: contents, sizeLimit: 500e6}
+	}
+	artifact := &fakeHistoricalArtifact{
+		FakeArtifact: FakeArtifact{
+			path:      "junit.xml",
+			content:   result("flake=failed", "broken=failed", "regression=failed", "new=failed", "ok=passed"),
+			sizeLimit: 500e6,
+		},
+		previous: []api.Artifact{
+			run(result("flake=passed", "broken=failed", "regression=passed", "ok=passed")),
+			run(result("flake=failed", "broken=failed", "regression=passed", "ok=passed")),
+			// A run that did not record any results.
+			run(nil),
+			run(result("flake=passed", "broken=failed", "regression=skipped", "ok=passed")),
+		},
+	}
+
+	jvd := Lens{}.getJvd([]api.Artifact{artifact}, 3)
+
+	expected := map[string]*TestHistory{
+		"flake":      {Statuses: []testStatus{passedStatus, failedStatus}},
+		"broken":     {Statuses: []testStatus{failedStatus, failedStatus}},
+		"regression": {Statuses: []testStatus{passedStatus, passedStatus}},
+		"new":        {},
+	}
+	actual := map[string]*TestHistory{}
+	for _, failed := range jvd.Failed {
+		actual[failed.Junit[0].Name] = failed.History
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected history (-want +got):\n%s", diff)
+	}
+
+	expectedVerdicts := map[string]string{
+		"flake":      likelyFlakeVerdict,
+		"broken":     consistentFailureVerdict,
+		"regression": newFailureVerdict,
+		"new":        "",
+	}
+	for name, history := range actual {
+		if verdict := history.Verdict(); verdict != expectedVerdicts[name] {
+			t.Errorf("expected verdict %q for %s, got %q", expectedVerdicts[name], name, verdict)
+		}
+	}
+}
+
+// fakeStreamingArtifact is a FakeArtifact that can be streamed.
+type fakeStreamingArtifact struct {
+	FakeArtifact
+}
+
+func (fa *fakeStreamingArtifact) NewReader() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(fa.content)), nil
+}
+
+func TestParseArtifact(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expectedIDs []testIdentifier
+		expectedErr bool
+	}{
+		{
+			name:        "single suite",
+			content:     `<testsuite name="suite"><testcase classname="c" name="a"/><testcase classname="c" name="b"/></testsuite>`,
+			expectedIDs: []testIdentifier{{"suite", "c", "a"}, {"suite", "c", "b"}},
+		},
+		{
+			name: "nested suites",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="outer">
+    <properties><property name="go.version" value="go1.22"/></properties>
+    <testsuite name="inner"><testcase classname="c" name="a"/></testsuite>
+    <testcase classname="c" name="b"><system-out>output</system-out></testcase>
+  </testsuite>
+  <testsuite name="other"><testcase classname="c" name="a"/><testcase classname="c" name="a"/></testsuite>
+</testsuites>`,
+			expectedIDs: []testIdentifier{{"inner", "c", "a"}, {"outer", "c", "b"}, {"other", "c", "a"}},
+		},
+		{
+			name:    "empty file",
+			content: "",
+		},
+		{
+			name:        "not junit",
+			content:     `<html><testcase name="a"/></html>`,
+			expectedErr: true,
+		},
+		{
+			name:        "truncated file",
+			content:     `<testsuite name="suite"><testcase classname="c" name="a"/><testcase classname="c"`,
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The size limit shows the artifact is streamed rather than read at once.
+			artifact := &fakeStreamingArtifact{FakeArtifact{path: "junit.xml", content: []byte(tc.content), sizeLimit: 1}}
+			ids, _, err := parseArtifact(artifact)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedIDs, ids, cmp.AllowUnexported(testIdentifier{})); diff != "" {
+				t.Errorf("unexpected tests (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	test := func(name string) TestResult {
+		return TestResult{Junit: []JunitResult{{Result: junit.Result{ClassName: "class", Name: name}}}}
+	}
+	names := func(tests []TestResult) []string {
+		var names []string
+		for _, test := range tests {
+			names = append(names, test.Junit[0].Name)
+		}
+		return names
+	}
+	jvd := JVD{
+		NumTests: 6,
+		Failed:   []TestResult{test("TestFailed")},
+		Passed:   []TestResult{test("TestA"), test("TestB"), test("TestC"), test("TestFoo")},
+		Skipped:  []TestResult{test("TestSkippedFoo")},
+	}
+
+	testCases := []struct {
+		name             string
+		request          bodyRequest
+		expectedFailed   []string
+		expectedPassed   []string
+		expectedSkipped  []string
+		expectedSections map[testStatus]section
+	}{
+		{
+			name:            "first pages",
+			expectedFailed:  []string{"TestFailed"},
+			expectedPassed:  []string{"TestA", "TestB"},
+			expectedSkipped: []string{"TestSkippedFoo"},
+			expectedSections: map[testStatus]section{
+				failedStatus:  {Status: failedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1, Expanded: true},
+				passedStatus:  {Status: passedStatus, Total: 4, Pages: 2, Previous: -1, Next: 1, First: 1, Last: 2},
+				skippedStatus: {Status: skippedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1},
+			},
+		},
+		{
+			name:            "second page of passed tests",
+			request:         bodyRequest{Pages: map[testStatus]int{passedStatus: 1}},
+			expectedFailed:  []string{"TestFailed"},
+			expectedPassed:  []string{"TestC", "TestFoo"},
+			expectedSkipped: []string{"TestSkippedFoo"},
+			expectedSections: map[testStatus]section{
+				failedStatus:  {Status: failedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1, Expanded: true},
+				passedStatus:  {Status: passedStatus, Total: 4, Page: 1, Pages: 2, Previous: 0, Next: 2, First: 3, Last: 4, Expanded: true},
+				skippedStatus: {Status: skippedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1},
+			},
+		},
+		{
+			name:           "page out of range",
+			request:        bodyRequest{Status: passedStatus, Pages: map[testStatus]int{passedStatus: 5}},
+			expectedPassed: []string{"TestA", "TestB"},
+			expectedSections: map[testStatus]section{
+				passedStatus: {Status: passedStatus, Total: 4, Pages: 2, Previous: -1, Next: 1, First: 1, Last: 2, Expanded: true},
+			},
+		},
+		{
+			name:            "search by name",
+			request:         bodyRequest{Query: "foo"},
+			expectedPassed:  []string{"TestFoo"},
+			expectedSkipped: []string{"TestSkippedFoo"},
+			expectedSections: map[testStatus]section{
+				passedStatus:  {Status: passedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1, Expanded: true},
+				skippedStatus: {Status: skippedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1, Expanded: true},
+			},
+		},
+		{
+			name:            "search by name and status",
+			request:         bodyRequest{Query: "CLASS: test", Status: skippedStatus},
+			expectedSkipped: []string{"TestSkippedFoo"},
+			expectedSections: map[testStatus]section{
+				skippedStatus: {Status: skippedStatus, Total: 1, Pages: 1, Previous: -1, Next: 1, First: 1, Last: 1, Expanded: true},
+			},
+		},
+		{
+			name:             "no match",
+			request:          bodyRequest{Query: "bar"},
+			expectedSections: map[testStatus]section{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := paginate(jvd, tc.request, 2)
+			if v.NumTests != 6 {
+				t.Errorf("expected the number of all tests, got %d", v.NumTests)
+			}
+			if diff := cmp.Diff(tc.expectedFailed, names(v.Failed)); diff != "" {
+				t.Errorf("unexpected failed tests (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedPassed, names(v.Passed)); diff != "" {
+				t.Errorf("unexpected passed tests (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSkipped, names(v.Skipped)); diff != "" {
+				t.Errorf("unexpected skipped tests (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSections, v.Sections); diff != "" {
+				t.Errorf("unexpected sections (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestTemplate(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -908,6 +1153,32 @@ func TestTemplate(t *testing.T) {
 				`error`,
 			},
 		},
+		{
+			name: "History of failed tests gets rendered",
+			input: JVD{NumTests: 1, Failed: []TestResult{{
+				Junit:   []JunitResult{{}},
+				History: &TestHistory{Statuses: []testStatus{passedStatus, failedStatus}},
+			}}},
+			expectedSubstrings: []string{
+				`<span class="history-run Passed" title="Passed"></span><span class="history-run Failed" title="Failed"></span>`,
+				`<span class="verdict likely-flake">Likely flake</span>`,
+			},
+		},
+		{
+			name: "Pager gets rendered for more tests than fit on a page",
+			input: func() JVD {
+				jvd := JVD{NumTests: defaultPageSize + 1}
+				for i := 0; i <= defaultPageSize; i++ {
+					jvd.Passed = append(jvd.Passed, TestResult{Junit: []JunitResult{{}}})
+				}
+				return jvd
+			}(),
+			expectedSubstrings: []string{
+				`<h6>101/101 Tests Passed!</h6>`,
+				`<button class="mdl-button page-button" data-status="Passed" data-page="1">Next</button>`,
+				`Showing 1&ndash;100 of 101`,
+			},
+		},
 		{
 			name: "Both stdout and stderr get rendered for flaky tests",
 			input: JVD{NumTests: 1, Flaky: []TestResult{{
@@ -934,7 +1205,7 @@ func TestTemplate(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			var buf bytes.Buffer
-			if err := tmpl.ExecuteTemplate(&buf, "body", tc.input); err != nil {
+			if err := tmpl.ExecuteTemplate(&buf, "body", paginate(tc.input, bodyRequest{}, defaultPageSize)); err != nil {
 				t.Fatalf("failed to execute template: %v", err)
 			}
 			result := buf.String()
@@ -82,7 +82,7 @@ func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data str
 }
 
 // Body creates a view for prow job metadata.
-func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, lensConfig json.RawMessage, spyglassConfig config.Spyglass) string {
 	var buf bytes.Buffer
 	type MetadataViewData struct {
 		StartTime    time.Time
@@ -92,6 +92,7 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 		Errored      bool
 		Elapsed      time.Duration
 		Hint         string
+		Owner        *config.JobOwner
 		Metadata     map[string]interface{}
 	}
 	metadataViewData := MetadataViewData{}
@@ -132,6 +133,7 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 		case "podinfo.json":
 			metadataViewData.Hint = hintFromPodInfo(read)
 		case prowv1.ProwJobFile:
+			metadataViewData.Owner = ownerFromProwJob(read)
 			// Only show the prowjob-based hint if we don't have a pod-based one
 			// (the pod-based ones are probably more useful when they exist)
 			if metadataViewData.Hint == "" {
@@ -279,6 +281,15 @@ func hintFromProwJob(buf []byte) (string, bool) {
 	return "", false
 }
 
+func ownerFromProwJob(buf []byte) *config.JobOwner {
+	var pj prowv1.ProwJob
+	if err := json.Unmarshal(buf, &pj); err != nil {
+		logrus.WithError(err).Infof("Failed to decode %s", prowv1.ProwJobFile)
+		return nil
+	}
+	return config.JobOwnerFromMeta(pj.Labels, pj.Annotations)
+}
+
 // flattenMetadata flattens the metadata for use by Body.
 func (lens Lens) flattenMetadata(metadata map[string]interface{}) map[string]string {
 	results := map[string]string{}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/prow/pkg/config"
 	k8sreporter "sigs.k8s.io/prow/pkg/crier/reporters/gcs/kubernetes"
 	"sigs.k8s.io/prow/pkg/entrypoint"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 	"sigs.k8s.io/yaml"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -106,6 +107,7 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 
 	var p k8sreporter.PodReport
 	var pj prowapi.ProwJob
+	var failedClones []clone.Record
 	for _, artifact := range artifacts {
 		switch artifact.JobPath() {
 		case "podinfo.json":
@@ -132,6 +134,24 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 				logrus.WithError(err).Infof("Error unmarshalling prowjob")
 				return fmt.Sprintf("Couldn't unmarshal prowjob.json: %v", err)
 			}
+		case prowapi.CloneRecordFile:
+			// The clone records are optional, they are only shown if a
+			// clone failed.
+			content, err := artifact.ReadAll()
+			if err != nil {
+				logrus.WithError(err).Warn("Couldn't read a clone records file that should exist.")
+				continue
+			}
+			var records []clone.Record
+			if err := json.Unmarshal(content, &records); err != nil {
+				logrus.WithError(err).Info("Error unmarshalling clone records")
+				continue
+			}
+			for _, record := range records {
+				if record.Failed {
+					failedClones = append(failedClones, record)
+				}
+			}
 		default:
 			logrus.WithField("artifact", artifact.JobPath()).Debug("Unsupported artifact by podinfo lens.")
 		}
@@ -162,13 +182,15 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 	}
 
 	t := struct {
-		PodReport  k8sreporter.PodReport
-		PodLink    string
-		Containers []containerInfo
+		PodReport    k8sreporter.PodReport
+		PodLink      string
+		FailedClones []clone.Record
+		Containers   []containerInfo
 	}{
-		PodReport:  p,
-		PodLink:    podLink,
-		Containers: append(assembleContainers(p.Pod.Spec.InitContainers, p.Pod.Status.InitContainerStatuses), assembleContainers(p.Pod.Spec.Containers, p.Pod.Status.ContainerStatuses)...),
+		PodReport:    p,
+		PodLink:      podLink,
+		FailedClones: failedClones,
+		Containers:   append(assembleContainers(p.Pod.Spec.InitContainers, p.Pod.Status.InitContainerStatuses), assembleContainers(p.Pod.Spec.Containers, p.Pod.Status.ContainerStatuses)...),
 	}
 
 	var buf bytes.Buffer
@@ -221,6 +243,11 @@ func assembleContainers(containers []v1.Container, containerStatuses []v1.Contai
 	return assembled
 }
 
+type namedCheck struct {
+	Name  string
+	Check *clone.Check
+}
+
 func loadTemplate(path string) (*template.Template, error) {
 	return template.New("template.html").Funcs(template.FuncMap{
 		"isProw": func(s string) bool {
@@ -233,6 +260,26 @@ func loadTemplate(path string) (*template.Template, error) {
 			}
 			return string(result), nil
 		},
+		// failedCommand returns the command that failed the clone, if any.
+		"failedCommand": func(record clone.Record) *clone.Command {
+			for i := len(record.Commands) - 1; i >= 0; i-- {
+				if record.Commands[i].Error != "" {
+					return &record.Commands[i]
+				}
+			}
+			return nil
+		},
+		// checks lists the diagnostic checks that ran, in the order they
+		// ran in.
+		"checks": func(d *clone.Diagnostics) []namedCheck {
+			var checks []namedCheck
+			for _, c := range []namedCheck{{"DNS resolution", d.DNS}, {"TCP connection", d.TCP}, {"TLS handshake", d.TLS}, {"HTTP request", d.HTTP}} {
+				if c.Check != nil {
+					checks = append(checks, c)
+				}
+			}
+			return checks
+		},
 		"toAge": func(t time.Time) string {
 			d := time.Since(t)
 			if d < time.Minute {
@@ -112,6 +112,42 @@ func TestBody(t *testing.T) {
 			},
 			ownConfig: nil,
 		},
+		{
+			name: "failed-clone",
+			artifacts: []api.Artifact{
+				&fake.Artifact{
+					Path: "podinfo.json",
+					Content: []byte(`{
+  "pod": {
+      "metadata": {
+        "name": "abc-123"
+      }
+  }
+}`),
+				},
+				&fake.Artifact{
+					Path: "clone-records.json",
+					Content: []byte(`[
+  {"refs": {"org": "", "repo": ""}},
+  {"refs": {"org": "org", "repo": "fine", "base_ref": "main"}},
+  {
+    "refs": {"org": "org", "repo": "repo", "base_ref": "main"},
+    "commands": [
+      {"command": "git init", "output": "Initialized empty Git repository"},
+      {"command": "git fetch https://git.example.com/org/repo.git main", "output": "fatal: unable to access", "error": "exit status 128"}
+    ],
+    "failed": true,
+    "diagnostics": {
+      "remote": "https://git.example.com/org/repo.git",
+      "host": "git.example.com",
+      "dns": {"result": "192.0.2.1", "duration": 2000000},
+      "tcp": {"error": "dial tcp 192.0.2.1:443: i/o timeout", "duration": 10000000000}
+    }
+  }
+]`),
+				},
+			},
+		},
 	}
 
 	logHook := test.NewGlobal()
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runenv provides a viewer for the resolved environment of a run for Spyglass
+package runenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	k8sreporter "sigs.k8s.io/prow/pkg/crier/reporters/gcs/kubernetes"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "runenv"
+	title    = "Run Environment"
+	priority = 21
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// environmentFile matches the environment files written by the entrypoint
+// of every test container.
+var environmentFile = regexp.MustCompile(`^artifacts/(?:.+-)?run-environment\.json$`)
+
+// Lens is the implementation of a run environment-rendering Spyglass lens.
+type Lens struct{}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	t, err := loadTemplate(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return fmt.Sprintf("<!-- FAILED LOADING HEADER: %v -->", err)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "header", nil); err != nil {
+		return fmt.Sprintf("<!-- FAILED EXECUTING HEADER TEMPLATE: %v -->", err)
+	}
+	return buf.String()
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+// containerImage is the image a container of the pod ran with.
+type containerImage struct {
+	Container string
+	Image     string
+	// ImageID is the image digest the image was resolved to.
+	ImageID string
+}
+
+type runEnvironment struct {
+	Environments []entrypoint.RunEnvironment
+	Images       []containerImage
+	Decoration   *prowapi.DecorationConfig
+}
+
+// Body renders the <body>
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	env, err := runEnvironmentFromArtifacts(artifacts)
+	if err != nil {
+		return err.Error()
+	}
+
+	t, err := loadTemplate(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		logrus.WithError(err).Error("Error loading template.")
+		return fmt.Sprintf("Failed to load template file: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "body", env); err != nil {
+		logrus.WithError(err).Error("Error executing template.")
+	}
+	return buf.String()
+}
+
+func runEnvironmentFromArtifacts(artifacts []api.Artifact) (*runEnvironment, error) {
+	env := &runEnvironment{}
+	for _, artifact := range artifacts {
+		path := artifact.JobPath()
+		switch {
+		case environmentFile.MatchString(path):
+			content, err := artifact.ReadAll()
+			if err != nil {
+				logrus.WithError(err).Warnf("Couldn't read %s.", path)
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			var e entrypoint.RunEnvironment
+			if err := json.Unmarshal(content, &e); err != nil {
+				return nil, fmt.Errorf("couldn't unmarshal %s: %w", path, err)
+			}
+			env.Environments = append(env.Environments, e)
+		case path == "podinfo.json":
+			content, err := artifact.ReadAll()
+			if err != nil {
+				logrus.WithError(err).Warn("Couldn't read a podinfo file that should exist.")
+				continue
+			}
+			var report k8sreporter.PodReport
+			if err := json.Unmarshal(content, &report); err != nil {
+				// Image digests are nice to have, the podinfo lens reports broken files.
+				logrus.WithError(err).Info("Failed to decode podinfo.json")
+				continue
+			}
+			env.Images = imagesFromPod(report.Pod)
+		case path == prowapi.ProwJobFile:
+			content, err := artifact.ReadAll()
+			if err != nil {
+				logrus.WithError(err).Warn("Couldn't read a prowjob file that should exist.")
+				continue
+			}
+			var pj prowapi.ProwJob
+			if err := json.Unmarshal(content, &pj); err != nil {
+				logrus.WithError(err).Infof("Failed to decode %s", prowapi.ProwJobFile)
+				continue
+			}
+			env.Decoration = pj.Spec.DecorationConfig
+		default:
+			logrus.WithField("artifact", path).Debug("Unsupported artifact by runenv lens.")
+		}
+	}
+	sort.Slice(env.Environments, func(i, j int) bool {
+		return env.Environments[i].ContainerName < env.Environments[j].ContainerName
+	})
+	return env, nil
+}
+
+// imagesFromPod returns the images of all containers of the pod, in the order
+// they were started in, with the digests reported by the kubelet.
+func imagesFromPod(pod *v1.Pod) []containerImage {
+	if pod == nil {
+		return nil
+	}
+	imageIDs := map[string]string{}
+	for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		imageIDs[s.Name] = s.ImageID
+	}
+	var images []containerImage
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		images = append(images, containerImage{Container: c.Name, Image: c.Image, ImageID: imageIDs[c.Name]})
+	}
+	return images
+}
+
+func loadTemplate(path string) (*template.Template, error) {
+	return template.New("template.html").Funcs(template.FuncMap{
+		"toYaml": func(o interface{}) (string, error) {
+			result, err := yaml.Marshal(o)
+			if err != nil {
+				return "", err
+			}
+			return string(result), nil
+		},
+		"redacted": func(value string) bool {
+			return value == entrypoint.RedactedValue
+		},
+	}).ParseFiles(path)
+}
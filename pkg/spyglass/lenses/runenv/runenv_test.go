@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runenv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func TestRunEnvironmentFromArtifacts(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{
+			Path:    "artifacts/test-run-environment.json",
+			Content: []byte(`{"container_name":"test","args":["make","test"],"env":{"GOFLAGS":"-mod=vendor","TOKEN":"<redacted>"},"timeout":"2h0m0s","grace_period":"15s"}`),
+		},
+		&fake.Artifact{
+			Path:    "artifacts/lint-run-environment.json",
+			Content: []byte(`{"container_name":"lint","args":["make","lint"]}`),
+		},
+		&fake.Artifact{
+			Path: "podinfo.json",
+			Content: []byte(`{"pod":{
+  "spec":{"initContainers":[{"name":"clonerefs","image":"clonerefs:v1"}],"containers":[{"name":"test","image":"golang:1.22"}]},
+  "status":{"initContainerStatuses":[{"name":"clonerefs","imageID":"clonerefs@sha256:aaa"}],"containerStatuses":[{"name":"test","imageID":"golang@sha256:bbb"}]}
+}}`),
+		},
+		&fake.Artifact{
+			Path:    "prowjob.json",
+			Content: []byte(`{"spec":{"decoration_config":{"timeout":"2h","utility_images":{"clonerefs":"clonerefs:v1"}}}}`),
+		},
+	}
+
+	env, err := runEnvironmentFromArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedEnvironments := []entrypoint.RunEnvironment{
+		{ContainerName: "lint", Args: []string{"make", "lint"}},
+		{
+			ContainerName: "test",
+			Args:          []string{"make", "test"},
+			Env:           map[string]string{"GOFLAGS": "-mod=vendor", "TOKEN": entrypoint.RedactedValue},
+			Timeout:       "2h0m0s",
+			GracePeriod:   "15s",
+		},
+	}
+	if diff := cmp.Diff(expectedEnvironments, env.Environments); diff != "" {
+		t.Errorf("unexpected environments (-want +got):\n%s", diff)
+	}
+	expectedImages := []containerImage{
+		{Container: "clonerefs", Image: "clonerefs:v1", ImageID: "clonerefs@sha256:aaa"},
+		{Container: "test", Image: "golang:1.22", ImageID: "golang@sha256:bbb"},
+	}
+	if diff := cmp.Diff(expectedImages, env.Images); diff != "" {
+		t.Errorf("unexpected images (-want +got):\n%s", diff)
+	}
+	if env.Decoration == nil || env.Decoration.UtilityImages == nil || env.Decoration.UtilityImages.CloneRefs != "clonerefs:v1" {
+		t.Errorf("expected the decoration config of the prowjob, got %+v", env.Decoration)
+	}
+
+	body := Lens{}.Body(artifacts, ".", "", nil, config.Spyglass{})
+	for _, expected := range []string{"make", "golang@sha256:bbb", `<span class="redacted">`, "clonerefs:v1"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected body to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRunEnvironmentFromArtifactsBrokenFile(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "artifacts/run-environment.json", Content: []byte(`{`)},
+		&fake.Artifact{Path: prowapi.ProwJobFile, Content: []byte(`{}`)},
+	}
+	if _, err := runEnvironmentFromArtifacts(artifacts); err == nil {
+		t.Error("expected an error for a broken environment file")
+	}
+}
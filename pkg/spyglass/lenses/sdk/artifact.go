@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+// ManifestArtifact is an artifact pushed by Deck. Reads beyond the pushed
+// content of a truncated artifact fail with lenses.ErrFileTooLarge.
+type ManifestArtifact struct {
+	artifact api.ManifestArtifact
+}
+
+var _ api.Artifact = &ManifestArtifact{}
+
+// NewManifestArtifact returns the artifact of the manifest entry.
+func NewManifestArtifact(artifact api.ManifestArtifact) *ManifestArtifact {
+	return &ManifestArtifact{artifact: artifact}
+}
+
+// ReadAt reads len(p) bytes of the artifact at offset off.
+func (a *ManifestArtifact) ReadAt(p []byte, off int64) (int, error) {
+	n, err := bytes.NewReader(a.artifact.Content).ReadAt(p, off)
+	if err == io.EOF && a.artifact.Truncated() {
+		return n, lenses.ErrFileTooLarge
+	}
+	return n, err
+}
+
+// ReadAtMost reads at most n bytes from the beginning of the artifact.
+func (a *ManifestArtifact) ReadAtMost(n int64) ([]byte, error) {
+	content := a.artifact.Content
+	if n < int64(len(content)) {
+		return content[:n], nil
+	}
+	if a.artifact.Truncated() {
+		return content, lenses.ErrFileTooLarge
+	}
+	return content, io.EOF
+}
+
+// ReadAll reads the whole artifact, failing if it was truncated.
+func (a *ManifestArtifact) ReadAll() ([]byte, error) {
+	if a.artifact.Truncated() {
+		return nil, lenses.ErrFileTooLarge
+	}
+	return a.artifact.Content, nil
+}
+
+// ReadTail reads the last n bytes of the artifact, failing if they weren't
+// pushed.
+func (a *ManifestArtifact) ReadTail(n int64) ([]byte, error) {
+	if a.artifact.Truncated() {
+		return nil, lenses.ErrFileTooLarge
+	}
+	content := a.artifact.Content
+	if n > int64(len(content)) {
+		n = int64(len(content))
+	}
+	return content[int64(len(content))-n:], nil
+}
+
+// CanonicalLink gets a link to the artifact in its storage.
+func (a *ManifestArtifact) CanonicalLink() string {
+	return a.artifact.Link
+}
+
+// JobPath is the path of the artifact within the job.
+func (a *ManifestArtifact) JobPath() string {
+	return a.artifact.Name
+}
+
+// Size is the size of the whole artifact, even if it was truncated.
+func (a *ManifestArtifact) Size() (int64, error) {
+	return a.artifact.Size, nil
+}
+
+// Metadata isn't pushed, so there is none.
+func (a *ManifestArtifact) Metadata() (map[string]string, error) {
+	return nil, nil
+}
+
+// UpdateMetadata isn't supported for pushed artifacts.
+func (a *ManifestArtifact) UpdateMetadata(map[string]string) error {
+	return errors.New("pushed artifacts are read-only")
+}
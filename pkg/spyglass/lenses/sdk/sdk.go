@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sdk serves lenses out of tree with the v1 lens serving protocol.
+// Deck discovers the lenses from the registry endpoint of the handler and
+// pushes the artifacts they render with each request, so the lens server
+// needs no access to the storage of the artifacts.
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/common"
+)
+
+const (
+	// RegistryPath is the path of the registry endpoint, which lists the
+	// served lenses. Deck's lens_registries point at it.
+	RegistryPath = "/lenses"
+	lensPrefix   = RegistryPath + "/"
+	staticPrefix = "/static/"
+)
+
+// Registration is a lens served by the handler.
+type Registration struct {
+	// Lens renders the artifacts.
+	Lens api.Lens
+	// Name is the name of the lens, which must be unique across the lenses
+	// known to Deck.
+	Name string
+	// Title is the human-readable title of the lens.
+	Title string
+	// Priority orders the lenses, lowest priority first.
+	Priority uint
+	// HideTitle hides the title once the lens is loaded.
+	HideTitle bool
+	// RequiredFiles and OptionalFiles are regexes of the artifacts the lens
+	// renders. At least one file must be required.
+	RequiredFiles []string
+	OptionalFiles []string
+	// ResourcesDir is passed to the lens and served as its static resources
+	// if set.
+	ResourcesDir string
+	// Limits are the resources the lens asks Deck for. Deck caps them with
+	// the limits of the registry.
+	Limits api.LensLimits
+	// SpyglassConfig is passed to the lens, as Deck doesn't send its own.
+	SpyglassConfig config.Spyglass
+}
+
+// NewHandler returns a handler serving the lenses and their registry.
+func NewHandler(registrations ...Registration) (http.Handler, error) {
+	lenses := map[string]Registration{}
+	mux := http.NewServeMux()
+	for _, reg := range registrations {
+		if err := reg.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := lenses[reg.Name]; ok {
+			return nil, fmt.Errorf("duplicate lens named %q", reg.Name)
+		}
+		lenses[reg.Name] = reg
+		if reg.ResourcesDir != "" {
+			prefix := staticPrefix + reg.Name + "/"
+			mux.Handle(prefix, http.StripPrefix(prefix, http.FileServer(http.Dir(reg.ResourcesDir))))
+		}
+	}
+	mux.HandleFunc(RegistryPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(registry(lenses)); err != nil {
+			logrus.WithError(err).Debug("Failed to write the lens registry.")
+		}
+	})
+	mux.HandleFunc(lensPrefix, func(w http.ResponseWriter, r *http.Request) {
+		reg, ok := lenses[strings.TrimPrefix(r.URL.Path, lensPrefix)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		reg.serve(w, r)
+	})
+	return mux, nil
+}
+
+func (reg Registration) validate() error {
+	if reg.Lens == nil {
+		return errors.New("lens is required")
+	}
+	if reg.Name == "" || strings.Contains(reg.Name, "/") {
+		return fmt.Errorf("lens name %q must be non-empty and must not contain '/'", reg.Name)
+	}
+	if len(reg.RequiredFiles) == 0 {
+		return fmt.Errorf("lens %q must require at least one file", reg.Name)
+	}
+	for _, file := range append(append([]string{}, reg.RequiredFiles...), reg.OptionalFiles...) {
+		if _, err := regexp.Compile(file); err != nil {
+			return fmt.Errorf("lens %q: invalid file regex %q: %w", reg.Name, file, err)
+		}
+	}
+	if reg.Limits.MaxArtifactBytes < 0 || reg.Limits.TimeoutSeconds < 0 {
+		return fmt.Errorf("lens %q: limits can't be negative", reg.Name)
+	}
+	return nil
+}
+
+// registry lists the lenses sorted by name. Their endpoints are relative to
+// the registry endpoint, so that the handler can be served under any prefix.
+func registry(lenses map[string]Registration) []api.LensRegistration {
+	registrations := []api.LensRegistration{}
+	for _, reg := range lenses {
+		registration := api.LensRegistration{
+			Name:            reg.Name,
+			Title:           reg.Title,
+			Priority:        reg.Priority,
+			HideTitle:       reg.HideTitle,
+			RequiredFiles:   reg.RequiredFiles,
+			OptionalFiles:   reg.OptionalFiles,
+			Endpoint:        strings.TrimPrefix(lensPrefix, "/") + reg.Name,
+			ProtocolVersion: api.ProtocolVersionV1,
+			Limits:          reg.Limits,
+		}
+		if reg.ResourcesDir != "" {
+			registration.StaticRoot = strings.TrimPrefix(staticPrefix, "/") + reg.Name + "/"
+		}
+		registrations = append(registrations, registration)
+	}
+	sort.Slice(registrations, func(i, j int) bool { return registrations[i].Name < registrations[j].Name })
+	return registrations
+}
+
+func (reg Registration) serve(w http.ResponseWriter, r *http.Request) {
+	request := &api.LensRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, fmt.Sprintf("failed to unmarshal request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.ProtocolVersion != api.ProtocolVersionV1 {
+		http.Error(w, fmt.Sprintf("unsupported protocol version %q, expected %q", request.ProtocolVersion, api.ProtocolVersionV1), http.StatusBadRequest)
+		return
+	}
+	if len(request.Manifest) == 0 {
+		http.Error(w, "no artifacts found", http.StatusNotFound)
+		return
+	}
+	artifacts := make([]api.Artifact, 0, len(request.Manifest))
+	for _, artifact := range request.Manifest {
+		artifacts = append(artifacts, NewManifestArtifact(artifact))
+	}
+	common.RenderLens(w, reg.Lens, artifacts, request, reg.Title, reg.ResourcesDir, request.Config, reg.SpyglassConfig)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+// echoLens renders the names and contents of the artifacts.
+type echoLens struct{}
+
+func (echoLens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return "<title>echo</title>"
+}
+
+func (echoLens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var out []string
+	for _, artifact := range artifacts {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			content = []byte(err.Error())
+		}
+		out = append(out, artifact.JobPath()+"="+string(content))
+	}
+	return data + strings.Join(out, ",") + string(config)
+}
+
+func (echoLens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return "callback:" + data
+}
+
+func TestNewHandlerValidation(t *testing.T) {
+	valid := Registration{Lens: echoLens{}, Name: "echo", RequiredFiles: []string{"build-log.txt"}}
+	testCases := []struct {
+		name          string
+		registrations []Registration
+		expectedErr   bool
+	}{
+		{
+			name:          "valid",
+			registrations: []Registration{valid},
+		},
+		{
+			name:          "duplicate lens",
+			registrations: []Registration{valid, valid},
+			expectedErr:   true,
+		},
+		{
+			name:          "no lens",
+			registrations: []Registration{{Name: "echo", RequiredFiles: []string{"build-log.txt"}}},
+			expectedErr:   true,
+		},
+		{
+			name:          "name with a slash",
+			registrations: []Registration{{Lens: echoLens{}, Name: "echo/lens", RequiredFiles: []string{"build-log.txt"}}},
+			expectedErr:   true,
+		},
+		{
+			name:          "no required files",
+			registrations: []Registration{{Lens: echoLens{}, Name: "echo"}},
+			expectedErr:   true,
+		},
+		{
+			name:          "invalid regex",
+			registrations: []Registration{{Lens: echoLens{}, Name: "echo", RequiredFiles: []string{"build-log.txt"}, OptionalFiles: []string{"("}}},
+			expectedErr:   true,
+		},
+		{
+			name:          "negative limits",
+			registrations: []Registration{{Lens: echoLens{}, Name: "echo", RequiredFiles: []string{"build-log.txt"}, Limits: api.LensLimits{TimeoutSeconds: -1}}},
+			expectedErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHandler(tc.registrations...)
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	resourcesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(resourcesDir, "script.js"), []byte("alert(1)"), 0644); err != nil {
+		t.Fatalf("failed to write resource: %v", err)
+	}
+	handler, err := NewHandler(
+		Registration{Lens: echoLens{}, Name: "echo", Title: "Echo", Priority: 3, RequiredFiles: []string{"build-log.txt"}, ResourcesDir: resourcesDir, Limits: api.LensLimits{MaxArtifactBytes: 1024}},
+		Registration{Lens: echoLens{}, Name: "another", RequiredFiles: []string{"junit.*"}},
+	)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	request := func(r api.LensRequest) string {
+		raw, _ := json.Marshal(r)
+		return string(raw)
+	}
+	manifest := []api.ManifestArtifact{
+		{Name: "build-log.txt", Size: 5, Content: []byte("hello")},
+		{Name: "artifacts/big.log", Size: 10, Content: []byte("trunc")},
+	}
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "registry",
+			method:       http.MethodGet,
+			path:         "/lenses",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"name":"another","title":"","requiredFiles":["junit.*"],"endpoint":"lenses/another","protocolVersion":"v1","limits":{}},` +
+				`{"name":"echo","title":"Echo","priority":3,"requiredFiles":["build-log.txt"],"endpoint":"lenses/echo","staticRoot":"static/echo/","protocolVersion":"v1","limits":{"maxArtifactBytes":1024}}]` + "\n",
+		},
+		{
+			name:         "rerender",
+			method:       http.MethodPost,
+			path:         "/lenses/echo",
+			body:         request(api.LensRequest{ProtocolVersion: api.ProtocolVersionV1, Action: api.RequestActionRerender, Data: "data:", Config: json.RawMessage(`{"a":1}`), Manifest: manifest}),
+			expectedCode: http.StatusOK,
+			expectedBody: `data:build-log.txt=hello,artifacts/big.log=` + lenses.ErrFileTooLarge.Error() + `{"a":1}`,
+		},
+		{
+			name:         "callback",
+			method:       http.MethodPost,
+			path:         "/lenses/echo",
+			body:         request(api.LensRequest{ProtocolVersion: api.ProtocolVersionV1, Action: api.RequestActionCallBack, Data: "ping", Manifest: manifest}),
+			expectedCode: http.StatusOK,
+			expectedBody: "callback:ping",
+		},
+		{
+			name:         "request without protocol version",
+			method:       http.MethodPost,
+			path:         "/lenses/echo",
+			body:         request(api.LensRequest{Action: api.RequestActionRerender, Manifest: manifest}),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "request without artifacts",
+			method:       http.MethodPost,
+			path:         "/lenses/echo",
+			body:         request(api.LensRequest{ProtocolVersion: api.ProtocolVersionV1, Action: api.RequestActionRerender}),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown lens",
+			method:       http.MethodPost,
+			path:         "/lenses/unknown",
+			body:         request(api.LensRequest{ProtocolVersion: api.ProtocolVersionV1, Action: api.RequestActionRerender, Manifest: manifest}),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "lens only accepts POST",
+			method:       http.MethodGet,
+			path:         "/lenses/echo",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "static resources",
+			method:       http.MethodGet,
+			path:         "/static/echo/script.js",
+			expectedCode: http.StatusOK,
+			expectedBody: "alert(1)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body)))
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedBody == "" {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedBody, rr.Body.String()); diff != "" {
+				t.Errorf("unexpected body (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestManifestArtifact(t *testing.T) {
+	complete := NewManifestArtifact(api.ManifestArtifact{Name: "a.txt", Size: 5, Content: []byte("hello")})
+	truncated := NewManifestArtifact(api.ManifestArtifact{Name: "b.txt", Size: 10, Content: []byte("hello")})
+
+	if content, err := complete.ReadAll(); err != nil || string(content) != "hello" {
+		t.Errorf("expected ReadAll of a complete artifact to return its content, got %q, %v", content, err)
+	}
+	if _, err := truncated.ReadAll(); !errors.Is(err, lenses.ErrFileTooLarge) {
+		t.Errorf("expected ReadAll of a truncated artifact to fail with ErrFileTooLarge, got %v", err)
+	}
+	if content, err := truncated.ReadAtMost(3); err != nil || string(content) != "hel" {
+		t.Errorf("expected ReadAtMost within the content to succeed, got %q, %v", content, err)
+	}
+	if content, err := complete.ReadAtMost(8); !errors.Is(err, io.EOF) || string(content) != "hello" {
+		t.Errorf("expected ReadAtMost beyond a complete artifact to return io.EOF, got %q, %v", content, err)
+	}
+	if _, err := truncated.ReadAtMost(8); !errors.Is(err, lenses.ErrFileTooLarge) {
+		t.Errorf("expected ReadAtMost beyond the content of a truncated artifact to fail with ErrFileTooLarge, got %v", err)
+	}
+	if content, err := complete.ReadTail(3); err != nil || string(content) != "llo" {
+		t.Errorf("expected ReadTail of a complete artifact to succeed, got %q, %v", content, err)
+	}
+	if _, err := truncated.ReadTail(3); !errors.Is(err, lenses.ErrFileTooLarge) {
+		t.Errorf("expected ReadTail of a truncated artifact to fail with ErrFileTooLarge, got %v", err)
+	}
+	p := make([]byte, 4)
+	if n, err := truncated.ReadAt(p, 3); !errors.Is(err, lenses.ErrFileTooLarge) || n != 2 {
+		t.Errorf("expected ReadAt beyond the content of a truncated artifact to fail with ErrFileTooLarge, got %d, %v", n, err)
+	}
+	if size, _ := truncated.Size(); size != 10 {
+		t.Errorf("expected the size of the whole artifact, got %d", size)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/gcsupload"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+)
+
+// ResolveBuild returns the storage path of the artifacts of a build, like
+// gs://bucket/logs/job/123. Builds that were uploaded to a bucket that has
+// an alias are resolved to the bucket the alias points to, so the path
+// keeps working after the artifacts were migrated.
+//
+// The build is looked up by its ProwJob first. Once the ProwJob is garbage
+// collected, builds of periodics and postsubmits are found with the
+// decoration config of the job.
+func (sg *Spyglass) ResolveBuild(job, build string) (string, error) {
+	provider, key, err := sg.prowToGCS(job + "/" + build)
+	if err != nil {
+		var fallbackErr error
+		if provider, key, fallbackErr = sg.buildFromConfig(job, build); fallbackErr != nil {
+			return "", fmt.Errorf("couldn't find build %s of job %s: %w", build, job, errors.Join(err, fallbackErr))
+		}
+	}
+	bucket, rest, _ := strings.Cut(key, "/")
+	if alias, ok := sg.config().Deck.Spyglass.BucketAliases[bucket]; ok {
+		bucket = alias
+	}
+	if err := sg.config().ValidateStorageBucket(bucket); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s/%s", provider, bucket, rest), nil
+}
+
+// buildFromConfig returns the storage provider and path of a build of a
+// periodic or postsubmit from its decoration config. Presubmit paths depend
+// on the PR, so they can't be determined without the ProwJob.
+func (sg *Spyglass) buildFromConfig(job, build string) (string, string, error) {
+	cfg := sg.config()
+	var jobType prowapi.ProwJobType
+	var decoration *prowapi.DecorationConfig
+	for _, periodic := range cfg.AllPeriodics() {
+		if periodic.Name == job {
+			jobType, decoration = prowapi.PeriodicJob, periodic.DecorationConfig
+		}
+	}
+	for _, postsubmit := range cfg.AllStaticPostsubmits(nil) {
+		if postsubmit.Name == job {
+			jobType, decoration = prowapi.PostsubmitJob, postsubmit.DecorationConfig
+		}
+	}
+	if jobType == "" {
+		return "", "", fmt.Errorf("no periodic or postsubmit named %q is configured", job)
+	}
+	if decoration == nil || decoration.GCSConfiguration == nil {
+		return "", "", fmt.Errorf("job %q doesn't upload to a bucket", job)
+	}
+	bucket, err := prowapi.ParsePath(decoration.GCSConfiguration.Bucket)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid bucket of job %q: %w", job, err)
+	}
+	spec := &downwardapi.JobSpec{Type: jobType, Job: job, BuildID: build}
+	jobPath, _, _ := gcsupload.PathsForJob(decoration.GCSConfiguration, spec, "")
+	return bucket.StorageProvider(), path.Join(bucket.FullPath(), jobPath), nil
+}
+
+// SignArtifactURL returns a URL to download the artifact at the storage path.
+// If expiry is zero, the URL is the one Spyglass links the artifact with.
+// Otherwise it's a signed URL that's valid for the expiry, so the artifact
+// can be shared with users that don't have access to the bucket.
+func (sg *Spyglass) SignArtifactURL(ctx context.Context, storagePath string, expiry time.Duration) (string, error) {
+	if expiry == 0 {
+		return sg.StorageArtifactFetcher.signURL(ctx, storagePath)
+	}
+	return sg.opener.SignedURL(ctx, storagePath, pkgio.SignedURLOptions{Expiry: expiry})
+}
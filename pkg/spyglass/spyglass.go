@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/common"
 )
 
 // Key types specify the way Spyglass will fetch artifact handles
@@ -405,6 +406,53 @@ func (sg *Spyglass) RunToPR(src string) (string, string, int, error) {
 	}
 }
 
+// RunToRepo returns the org and repo that the run of the given source string
+// tested, or empty strings if it didn't test a repo. Periodics are associated
+// with the repo of their first extra ref.
+func (sg *Spyglass) RunToRepo(ctx context.Context, src string) (string, string, error) {
+	src = strings.TrimSuffix(src, "/")
+	if jobName, buildID, err := common.KeyToJob(src); err == nil {
+		if job, err := sg.jobAgent.GetProwJob(jobName, buildID); err == nil {
+			org, repo := jobRepo(job.Spec)
+			return org, repo, nil
+		}
+	}
+	if org, repo, _, err := sg.RunToPR(src); err == nil {
+		return org, repo, nil
+	}
+
+	// The job is gone from the cluster and isn't a presubmit, fall back to
+	// the ProwJob that was uploaded with the artifacts.
+	artifacts, err := sg.FetchArtifacts(ctx, src, "", sg.config().Deck.Spyglass.SizeLimit, []string{prowapi.ProwJobFile})
+	if err != nil {
+		return "", "", err
+	}
+	if len(artifacts) == 0 {
+		return "", "", fmt.Errorf("couldn't find %s for %q", prowapi.ProwJobFile, src)
+	}
+	content, err := artifacts[0].ReadAll()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", prowapi.ProwJobFile, err)
+	}
+	var job prowapi.ProwJob
+	if err := json.Unmarshal(content, &job); err != nil {
+		return "", "", fmt.Errorf("failed to unmarshal %s: %w", prowapi.ProwJobFile, err)
+	}
+	org, repo := jobRepo(job.Spec)
+	return org, repo, nil
+}
+
+func jobRepo(spec prowapi.ProwJobSpec) (string, string) {
+	switch {
+	case spec.Refs != nil:
+		return spec.Refs.Org, spec.Refs.Repo
+	case len(spec.ExtraRefs) > 0:
+		return spec.ExtraRefs[0].Org, spec.ExtraRefs[0].Repo
+	default:
+		return "", ""
+	}
+}
+
 // ExtraLinks fetches started.json and extracts links from metadata.links.
 func (sg *Spyglass) ExtraLinks(ctx context.Context, src string) ([]ExtraLink, error) {
 	artifacts, err := sg.FetchArtifacts(ctx, src, "", 1000000, []string{prowapi.StartedStatusFile})
@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -156,6 +157,34 @@ test/e2e/e2e.go:137 BeforeSuite on Node 1 failed test/e2e/e2e.go:137
 			Name:       "logs/job/123/test-1-build-log.txt",
 			Content:    []byte("this log exists in gcs!"),
 		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/artifact-tree/1/build-log.txt",
+			Content:    []byte("log"),
+			Updated:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/artifact-tree/1/artifacts/a.txt",
+			Content:    []byte("aaaa"),
+			Updated:    time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/artifact-tree/1/artifacts/nested/b.txt",
+			Content:    []byte("bbbbbbbbbb"),
+			Updated:    time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/artifact-tree/10/other.txt",
+			Content:    []byte("other"),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/example-postsubmit/500/prowjob.json",
+			Content:    []byte(`{"spec":{"type":"postsubmit","job":"example-postsubmit","refs":{"org":"postsubmit-org","repo":"postsubmit-repo"}}}`),
+		},
 	})
 	defer fakeGCSServer.Stop()
 	kc := fkc{
@@ -939,6 +968,109 @@ func TestRunToPR(t *testing.T) {
 	}
 }
 
+func TestRunToRepo(t *testing.T) {
+	kc := fkc{
+		prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.PeriodicJob,
+				Job:  "example-periodic-job",
+				ExtraRefs: []prowapi.Refs{
+					{Org: "extra-org", Repo: "extra-repo"},
+				},
+			},
+			Status: prowapi.ProwJobStatus{
+				PodName: "flying-whales",
+				BuildID: "1111",
+			},
+		},
+		prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.PresubmitJob,
+				Job:  "example-presubmit-job",
+				Refs: &prowapi.Refs{
+					Org:   "some-org",
+					Repo:  "some-repo",
+					Pulls: []prowapi.Pull{{Number: 42}},
+				},
+			},
+			Status: prowapi.ProwJobStatus{
+				PodName: "flying-whales",
+				BuildID: "2222",
+			},
+		},
+	}
+	fakeJa = jobs.NewJobAgent(context.Background(), kc, false, true, []string{}, map[string]jobs.PodLogClient{kube.DefaultClusterAlias: fpkc("clusterA"), "trusted": fpkc("clusterB")}, fca{}.Config)
+	fakeJa.Start()
+	testCases := []struct {
+		name     string
+		src      string
+		expOrg   string
+		expRepo  string
+		expError bool
+	}{
+		{
+			name:    "Prow presubmit job",
+			src:     "prowjob/example-presubmit-job/2222",
+			expOrg:  "some-org",
+			expRepo: "some-repo",
+		},
+		{
+			name:    "Prow periodic job uses its first extra ref",
+			src:     "prowjob/example-periodic-job/1111",
+			expOrg:  "extra-org",
+			expRepo: "extra-repo",
+		},
+		{
+			name:    "presubmit job in GCS",
+			src:     "gcs/kubernetes-jenkins/pr-logs/pull/Katharine_test-infra/1234/example-job-name/314159",
+			expOrg:  "Katharine",
+			expRepo: "test-infra",
+		},
+		{
+			name:    "postsubmit job in GCS",
+			src:     "gcs/test-bucket/logs/example-postsubmit/500",
+			expOrg:  "postsubmit-org",
+			expRepo: "postsubmit-repo",
+		},
+		{
+			name:     "GCS job without a prowjob errors",
+			src:      "gcs/test-bucket/logs/example-ci-run/403",
+			expError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fca := config.Agent{}
+			fca.Set(&config.Config{
+				ProwConfig: config.ProwConfig{
+					Deck: config.Deck{Spyglass: config.Spyglass{SizeLimit: 500e6}},
+					Plank: config.Plank{
+						DefaultDecorationConfigs: config.DefaultDecorationMapToSliceTesting(
+							map[string]*prowapi.DecorationConfig{
+								"*": {
+									GCSConfiguration: &prowapi.GCSConfiguration{
+										Bucket:       "kubernetes-jenkins",
+										DefaultOrg:   "kubernetes",
+										DefaultRepo:  "kubernetes",
+										PathStrategy: "legacy",
+									},
+								},
+							}),
+					},
+				},
+			})
+			sg := New(context.Background(), fakeJa, fca.Config, io.NewGCSOpener(fakeGCSServer.Client()), false)
+			org, repo, err := sg.RunToRepo(context.Background(), tc.src)
+			if tc.expError != (err != nil) {
+				t.Fatalf("expected error: %t, got %v", tc.expError, err)
+			}
+			if org != tc.expOrg || repo != tc.expRepo {
+				t.Errorf("expected %s/%s, got %s/%s", tc.expOrg, tc.expRepo, org, repo)
+			}
+		})
+	}
+}
+
 func TestProwToGCS(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -1633,3 +1765,103 @@ func TestExtraLinks(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveBuild(t *testing.T) {
+	kc := fkc{
+		prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.PeriodicJob,
+				Job:  "example-periodic-job",
+				DecorationConfig: &prowapi.DecorationConfig{
+					GCSConfiguration: &prowapi.GCSConfiguration{
+						Bucket: "chum-bucket",
+					},
+				},
+			},
+			Status: prowapi.ProwJobStatus{
+				PodName: "flying-whales",
+				BuildID: "1111",
+				URL:     "http://magic/view/gcs/chum-bucket/logs/example-periodic-job/1111",
+			},
+		},
+	}
+	fakeJa = jobs.NewJobAgent(context.Background(), kc, false, true, []string{}, map[string]jobs.PodLogClient{kube.DefaultClusterAlias: fpkc("clusterA"), "trusted": fpkc("clusterB")}, fca{}.Config)
+	fakeJa.Start()
+
+	decoration := func(bucket string) *prowapi.DecorationConfig {
+		return &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: bucket, PathPrefix: "prefix"}}
+	}
+	fca := config.Agent{}
+	fca.Set(&config.Config{
+		ProwConfig: config.ProwConfig{
+			Plank: config.Plank{
+				JobURLPrefixConfig: map[string]string{"*": "http://magic/view/gcs/"},
+			},
+			Deck: config.Deck{
+				Spyglass: config.Spyglass{
+					BucketAliases: map[string]string{"chum-bucket": "migrated-bucket"},
+				},
+			},
+		},
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{
+				{JobBase: config.JobBase{Name: "gone-periodic-job", UtilityConfig: config.UtilityConfig{DecorationConfig: decoration("s3://some-bucket")}}},
+				{JobBase: config.JobBase{Name: "undecorated-job"}},
+			},
+			PostsubmitsStatic: map[string][]config.Postsubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "gone-postsubmit-job", UtilityConfig: config.UtilityConfig{DecorationConfig: decoration("chum-bucket")}}}},
+			},
+		},
+	})
+	sg := New(context.Background(), fakeJa, fca.Config, io.NewGCSOpener(fakeGCSServer.Client()), false)
+
+	testCases := []struct {
+		name     string
+		job      string
+		build    string
+		expected string
+		expError bool
+	}{
+		{
+			name:     "ProwJob in a migrated bucket",
+			job:      "example-periodic-job",
+			build:    "1111",
+			expected: "gs://migrated-bucket/logs/example-periodic-job/1111",
+		},
+		{
+			name:     "periodic without a ProwJob",
+			job:      "gone-periodic-job",
+			build:    "42",
+			expected: "s3://some-bucket/prefix/logs/gone-periodic-job/42",
+		},
+		{
+			name:     "postsubmit without a ProwJob in a migrated bucket",
+			job:      "gone-postsubmit-job",
+			build:    "42",
+			expected: "gs://migrated-bucket/prefix/logs/gone-postsubmit-job/42",
+		},
+		{
+			name:     "job that doesn't upload",
+			job:      "undecorated-job",
+			build:    "42",
+			expError: true,
+		},
+		{
+			name:     "unknown job",
+			job:      "unknown-job",
+			build:    "42",
+			expError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := sg.ResolveBuild(tc.job, tc.build)
+			if tc.expError != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.expError, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
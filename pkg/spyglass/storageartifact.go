@@ -18,11 +18,20 @@ package spyglass
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
 
 	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
 )
 
@@ -129,6 +138,133 @@ func (a *StorageArtifact) CanonicalLink() string {
 	return a.link
 }
 
+const (
+	// runCacheSize is how many job directories the runs listed by
+	// PreviousRuns are cached for.
+	runCacheSize = 500
+	// runCacheTTL is how long the runs listed in a job directory are reused.
+	runCacheTTL = 5 * time.Minute
+)
+
+// runCache caches the build IDs listed in job directories by PreviousRuns,
+// so that rendering runs of a job with a long history doesn't list all of
+// its runs every time. A nil runCache caches nothing.
+type runCache struct {
+	cache *lru.Cache
+	now   func() time.Time
+}
+
+type cachedRuns struct {
+	// ids are sorted newest first.
+	ids    []uint64
+	listed time.Time
+}
+
+func newRunCache() *runCache {
+	// lru.New only fails for sizes below one.
+	cache, _ := lru.New(runCacheSize)
+	return &runCache{cache: cache, now: time.Now}
+}
+
+// get returns the build IDs listed in the job directory, if they were listed
+// within runCacheTTL and include the current run. Listings that don't include
+// it were made before it started, so they may lack the runs preceding it.
+func (c *runCache) get(jobDir string, currentID uint64) ([]uint64, bool) {
+	if c == nil {
+		return nil, false
+	}
+	value, ok := c.cache.Get(jobDir)
+	if !ok {
+		return nil, false
+	}
+	runs := value.(cachedRuns)
+	if c.now().Sub(runs.listed) >= runCacheTTL {
+		c.cache.Remove(jobDir)
+		return nil, false
+	}
+	for _, id := range runs.ids {
+		if id == currentID {
+			return runs.ids, true
+		}
+	}
+	return nil, false
+}
+
+func (c *runCache) add(jobDir string, ids []uint64) {
+	if c == nil {
+		return
+	}
+	c.cache.Add(jobDir, cachedRuns{ids: ids, listed: c.now()})
+}
+
+// PreviousRuns returns the artifact at the same path in up to n runs that
+// precede the run of this artifact, newest first. The runs of a job are the
+// numerically named siblings of the run's directory, so for presubmits only
+// the runs for the same pull request are considered. The runs listed are
+// cached by the fetcher of the artifact.
+func (a *StorageArtifact) PreviousRuns(n int) ([]api.Artifact, error) {
+	handle, ok := a.handle.(*storageArtifactHandle)
+	if !ok {
+		return nil, errors.New("artifact does not support listing previous runs")
+	}
+	runDir := strings.TrimSuffix(handle.Name, "/"+a.path)
+	if runDir == handle.Name {
+		return nil, fmt.Errorf("artifact %q is not located at %q within its run", handle.Name, a.path)
+	}
+	jobDir, run := path.Split(runDir)
+	currentID, err := strconv.ParseUint(run, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("run directory %q is not a build ID: %w", runDir, err)
+	}
+
+	all, ok := handle.runs.get(jobDir, currentID)
+	if !ok {
+		if all, err = listRuns(a.ctx, handle.Opener, jobDir); err != nil {
+			return nil, err
+		}
+		handle.runs.add(jobDir, all)
+	}
+	var artifacts []api.Artifact
+	for _, id := range all {
+		if id >= currentID {
+			continue
+		}
+		if len(artifacts) == n {
+			break
+		}
+		name := fmt.Sprintf("%s%d/%s", jobDir, id, a.path)
+		artifacts = append(artifacts, NewStorageArtifact(a.ctx, &storageArtifactHandle{Opener: handle.Opener, Name: name, runs: handle.runs}, name, a.path, a.sizeLimit))
+	}
+	return artifacts, nil
+}
+
+// listRuns returns the build IDs of the runs in the job directory, newest
+// first.
+func listRuns(ctx context.Context, opener pkgio.Opener, jobDir string) ([]uint64, error) {
+	it, err := opener.Iterator(ctx, jobDir, "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs in %q: %w", jobDir, err)
+	}
+	var ids []uint64
+	for {
+		attrs, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runs in %q: %w", jobDir, err)
+		}
+		if !attrs.IsDir {
+			continue
+		}
+		if id, err := strconv.ParseUint(path.Base(attrs.Name), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	return ids, nil
+}
+
 // ReadAt reads len(p) bytes from a file in GCS at offset off
 func (a *StorageArtifact) ReadAt(p []byte, off int64) (n int, err error) {
 	if int64(len(p)) > a.sizeLimit {
@@ -255,6 +391,23 @@ func (a *StorageArtifact) ReadAll() ([]byte, error) {
 	return p, nil
 }
 
+// NewReader returns a reader of the whole file, or an error if the file is
+// too big to be read with ReadAll.
+func (a *StorageArtifact) NewReader() (io.ReadCloser, error) {
+	size, err := a.Size()
+	if err != nil {
+		return nil, fmt.Errorf("error getting artifact size: %w", err)
+	}
+	if size > a.sizeLimit {
+		return nil, lenses.ErrFileTooLarge
+	}
+	reader, err := a.handle.NewReader(a.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting artifact reader: %w", err)
+	}
+	return reader, nil
+}
+
 // ReadTail reads the last n bytes from a file in GCS
 func (a *StorageArtifact) ReadTail(n int64) ([]byte, error) {
 	if n > a.sizeLimit {
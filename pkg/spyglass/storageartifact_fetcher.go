@@ -44,6 +44,7 @@ type StorageArtifactFetcher struct {
 	opener        pkgio.Opener
 	cfg           config.Getter
 	useCookieAuth bool
+	runs          *runCache
 }
 
 // storageJobSource is a location in GCS where Prow job-specific artifacts are stored. This implementation assumes
@@ -64,6 +65,7 @@ func NewStorageArtifactFetcher(opener pkgio.Opener, cfg config.Getter, useCookie
 		opener:        opener,
 		cfg:           cfg,
 		useCookieAuth: useCookieAuth,
+		runs:          newRunCache(),
 	}
 }
 
@@ -140,35 +142,87 @@ func (af *StorageArtifactFetcher) artifacts(ctx context.Context, key string) ([]
 	listStart := time.Now()
 	_, prefix := extractBucketPrefixPair(src.jobPath())
 	artifacts := []string{}
-
-	it, err := af.opener.Iterator(ctx, src.source, "")
+	err = af.iterate(ctx, src, src.source, func(oAttrs pkgio.ObjectAttributes) {
+		artifacts = append(artifacts, strings.TrimPrefix(oAttrs.Name, prefix))
+	})
 	if err != nil {
+		if err == context.Canceled {
+			return nil, err
+		}
 		return artifacts, err
 	}
+	logrus.WithField("duration", time.Since(listStart).String()).Infof("Listed %d artifacts.", len(artifacts))
+	return artifacts, nil
+}
+
+// artifactDir lists the files and directories directly in the directory dir
+// of the artifacts of the given job source. Directories are listed with the
+// total size, number of objects and latest update of everything in them.
+func (af *StorageArtifactFetcher) artifactDir(ctx context.Context, key, dir string) ([]ArtifactEntry, error) {
+	src, err := af.newStorageJobSource(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get GCS job source from %s: %w", key, err)
+	}
+
+	_, prefix := extractBucketPrefixPair(src.jobPath())
+	prefix += dir
+	entries := map[string]*ArtifactEntry{}
+	err = af.iterate(ctx, src, strings.TrimSuffix(src.source, "/")+"/"+dir, func(oAttrs pkgio.ObjectAttributes) {
+		name := strings.TrimPrefix(oAttrs.Name, prefix)
+		isDir := false
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, isDir = name[:i+1], true
+		}
+		entry, ok := entries[name]
+		if !ok {
+			entry = &ArtifactEntry{Name: name, IsDir: isDir}
+			entries[name] = entry
+		}
+		entry.Size += oAttrs.Size
+		entry.Objects++
+		if oAttrs.Updated.After(entry.Updated) {
+			entry.Updated = oAttrs.Updated
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	listing := make([]ArtifactEntry, 0, len(entries))
+	for _, entry := range entries {
+		listing = append(listing, *entry)
+	}
+	return listing, nil
+}
+
+// iterate calls fn with every object under the given prefix, retrying
+// transient errors with a backoff.
+func (af *StorageArtifactFetcher) iterate(ctx context.Context, src *storageJobSource, prefix string, fn func(pkgio.ObjectAttributes)) error {
+	it, err := af.opener.Iterator(ctx, prefix, "")
+	if err != nil {
+		return err
+	}
 
 	wait := []time.Duration{16, 32, 64, 128, 256, 256, 512, 512}
 	for i := 0; ; {
 		oAttrs, err := it.Next(ctx)
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
 			if err == context.Canceled {
-				return nil, err
+				return err
 			}
 			logrus.WithFields(fieldsForJob(src)).WithError(err).Error("Error accessing GCS artifact.")
 			if i >= len(wait) {
-				return artifacts, fmt.Errorf("timed out: error accessing GCS artifact: %w", err)
+				return fmt.Errorf("timed out: error accessing GCS artifact: %w", err)
 			}
 			time.Sleep((wait[i] + time.Duration(rand.Intn(10))) * time.Millisecond)
 			i++
 			continue
 		}
-		artifacts = append(artifacts, strings.TrimPrefix(oAttrs.Name, prefix))
+		fn(oAttrs)
 		i = 0
 	}
-	logrus.WithField("duration", time.Since(listStart).String()).Infof("Listed %d artifacts.", len(artifacts))
-	return artifacts, nil
 }
 
 func (af *StorageArtifactFetcher) signURL(ctx context.Context, key string) (string, error) {
@@ -180,6 +234,8 @@ func (af *StorageArtifactFetcher) signURL(ctx context.Context, key string) (stri
 type storageArtifactHandle struct {
 	pkgio.Opener
 	Name string
+	// runs caches the runs listed by PreviousRuns, if set.
+	runs *runCache
 }
 
 func (h *storageArtifactHandle) NewReader(ctx context.Context) (io.ReadCloser, error) {
@@ -212,7 +268,7 @@ func (af *StorageArtifactFetcher) Artifact(ctx context.Context, key string, arti
 
 	_, prefix := extractBucketPrefixPair(src.jobPath())
 	objName := path.Join(prefix, artifactName)
-	obj := &storageArtifactHandle{Opener: af.opener, Name: fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, objName)}
+	obj := &storageArtifactHandle{Opener: af.opener, Name: fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, objName), runs: af.runs}
 	signedURL, err := af.signURL(ctx, fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, objName))
 	if err != nil {
 		return nil, err
@@ -23,6 +23,10 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	pkgio "sigs.k8s.io/prow/pkg/io"
@@ -423,6 +427,35 @@ func TestReadAll(t *testing.T) {
 	}
 }
 
+func TestNewReader(t *testing.T) {
+	contents := []byte("Oh wow\nlogs\nthis is\ncrazy")
+	for _, sizeLimit := range []int64{500e6, 20} {
+		artifact := NewStorageArtifact(context.Background(), &fakeArtifactHandle{
+			contents: contents,
+			oAttrs:   pkgio.Attributes{Size: int64(len(contents))},
+		}, "", "build-log.txt", sizeLimit)
+
+		reader, err := artifact.NewReader()
+		if sizeLimit < int64(len(contents)) {
+			if err != lenses.ErrFileTooLarge {
+				t.Errorf("expected the file to be too large for limit %d, got %v", sizeLimit, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to get reader: %v", err)
+		}
+		actual, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if !bytes.Equal(actual, contents) {
+			t.Errorf("expected %q, got %q", contents, actual)
+		}
+	}
+}
+
 func TestSize_GCS(t *testing.T) {
 	fakeGCSClient := fakeGCSServer.Client()
 	fakeOpener := pkgio.NewGCSOpener(fakeGCSClient)
@@ -562,3 +595,121 @@ func TestStorageArtifact_RespectsSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestPreviousRuns(t *testing.T) {
+	var objects []fakestorage.Object
+	for _, run := range []string{"1", "2", "3", "5", "latest"} {
+		objects = append(objects, fakestorage.Object{
+			BucketName: "test-bucket",
+			Name:       fmt.Sprintf("logs/example-ci-run/%s/artifacts/junit.xml", run),
+			Content:    []byte(run),
+		})
+	}
+	gcsServer := fakestorage.NewServer(objects)
+	defer gcsServer.Stop()
+	af := NewStorageArtifactFetcher(pkgio.NewGCSOpener(gcsServer.Client()), createConfigGetter("test-bucket"), false)
+
+	testCases := []struct {
+		name     string
+		source   string
+		n        int
+		expected []string
+	}{
+		{
+			name:     "newest runs before the current one",
+			source:   "gs://test-bucket/logs/example-ci-run/5",
+			n:        2,
+			expected: []string{"3", "2"},
+		},
+		{
+			name:     "fewer runs than requested",
+			source:   "gs://test-bucket/logs/example-ci-run/3",
+			n:        5,
+			expected: []string{"2", "1"},
+		},
+		{
+			name:   "first run",
+			source: "gs://test-bucket/logs/example-ci-run/1",
+			n:      5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			artifact, err := af.Artifact(context.Background(), tc.source, "artifacts/junit.xml", 500e6)
+			if err != nil {
+				t.Fatalf("failed to get artifact: %v", err)
+			}
+			previous, err := artifact.(api.HistoricalArtifact).PreviousRuns(tc.n)
+			if err != nil {
+				t.Fatalf("failed to get previous runs: %v", err)
+			}
+			var actual []string
+			for _, artifact := range previous {
+				if artifact.JobPath() != "artifacts/junit.xml" {
+					t.Errorf("expected artifact of previous run at artifacts/junit.xml, got %s", artifact.JobPath())
+				}
+				contents, err := artifact.ReadAll()
+				if err != nil {
+					t.Fatalf("failed to read artifact of previous run: %v", err)
+				}
+				actual = append(actual, string(contents))
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected previous runs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPreviousRunsCache(t *testing.T) {
+	object := func(run string) fakestorage.Object {
+		return fakestorage.Object{
+			BucketName: "test-bucket",
+			Name:       fmt.Sprintf("logs/example-ci-run/%s/artifacts/junit.xml", run),
+			Content:    []byte(run),
+		}
+	}
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{object("1"), object("3")})
+	defer gcsServer.Stop()
+	af := NewStorageArtifactFetcher(pkgio.NewGCSOpener(gcsServer.Client()), createConfigGetter("test-bucket"), false)
+	now := time.Now()
+	af.runs.now = func() time.Time { return now }
+
+	previousRuns := func(run string) []string {
+		artifact, err := af.Artifact(context.Background(), "gs://test-bucket/logs/example-ci-run/"+run, "artifacts/junit.xml", 500e6)
+		if err != nil {
+			t.Fatalf("failed to get artifact: %v", err)
+		}
+		previous, err := artifact.(api.HistoricalArtifact).PreviousRuns(5)
+		if err != nil {
+			t.Fatalf("failed to get previous runs: %v", err)
+		}
+		var ids []string
+		for _, artifact := range previous {
+			contents, err := artifact.ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read artifact of previous run: %v", err)
+			}
+			ids = append(ids, string(contents))
+		}
+		return ids
+	}
+
+	if diff := cmp.Diff([]string{"1"}, previousRuns("3")); diff != "" {
+		t.Errorf("unexpected previous runs (-want +got):\n%s", diff)
+	}
+	gcsServer.CreateObject(object("2"))
+	if diff := cmp.Diff([]string{"1"}, previousRuns("3")); diff != "" {
+		t.Errorf("expected the cached runs to be reused (-want +got):\n%s", diff)
+	}
+	gcsServer.CreateObject(object("4"))
+	if diff := cmp.Diff([]string{"3", "2", "1"}, previousRuns("4")); diff != "" {
+		t.Errorf("expected the runs to be listed again for a run that isn't cached (-want +got):\n%s", diff)
+	}
+	gcsServer.CreateObject(object("0"))
+	now = now.Add(runCacheTTL)
+	if diff := cmp.Diff([]string{"2", "1", "0"}, previousRuns("3")); diff != "" {
+		t.Errorf("expected the runs to be listed again once the cache expired (-want +got):\n%s", diff)
+	}
+}
@@ -37,6 +37,10 @@ import (
 	"sigs.k8s.io/prow/pkg/statusreconciler/migrator"
 )
 
+// contextMigrationCheckPeriod is how often the context migrations declared in
+// the config are checked for having become effective.
+const contextMigrationCheckPeriod = 10 * time.Minute
+
 // NewController constructs a new controller to reconcile stauses on config change
 func NewController(continueOnError bool, addedPresubmitDenylist, addedPresubmitDenylistAll sets.Set[string], opener io.Opener, configOpts configflagutil.ConfigOptions, statusURI string, prowJobClient prowv1.ProwJobInterface, githubClient github.Client, pluginAgent *plugins.ConfigAgent) *Controller {
 	sc := &statusController{
@@ -154,6 +158,14 @@ type Controller struct {
 	statusMigrator            statusMigrator
 	trustedChecker            trustedChecker
 	statusClient              statusClient
+
+	// appliedContextMigrations are the declared context migrations that
+	// were already applied.
+	appliedContextMigrations sets.Set[declaredContextMigration]
+}
+
+type declaredContextMigration struct {
+	orgRepo, from, to string
 }
 
 // Run monitors the incoming configuration changes to determine when statuses need to be
@@ -165,6 +177,11 @@ func (c *Controller) Run(ctx context.Context) {
 		return
 	}
 
+	// Context migrations may become effective without the config changing.
+	ticker := time.NewTicker(contextMigrationCheckPeriod)
+	defer ticker.Stop()
+	var current *config.Config
+
 	for {
 		select {
 		case change := <-changes:
@@ -175,6 +192,15 @@ func (c *Controller) Run(ctx context.Context) {
 			}
 			log.WithField("duration", fmt.Sprintf("%v", time.Since(start))).Info("Statuses reconciled")
 			c.statusClient.Save()
+			current = &change.After
+		case <-ticker.C:
+			if current == nil {
+				continue
+			}
+			log := logrus.WithField("config_revision", current.ConfigVersionSHA)
+			if err := c.applyContextMigrations(current.StatusReconciler.ContextMigrations, time.Now(), log); err != nil {
+				log.WithError(err).Error("Error applying context migrations.")
+			}
 		case <-ctx.Done():
 			logrus.Info("status-reconciler is shutting down...")
 			return
@@ -205,6 +231,13 @@ func (c *Controller) reconcile(delta config.Delta, log *logrus.Entry) error {
 		}
 	}
 
+	if err := c.applyContextMigrations(delta.After.StatusReconciler.ContextMigrations, time.Now(), log); err != nil {
+		errors = append(errors, err)
+		if !c.continueOnError {
+			return utilerrors.NewAggregate(errors)
+		}
+	}
+
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -348,6 +381,49 @@ func (c *Controller) updateMigratedContexts(migrations map[string][]presubmitMig
 	return utilerrors.NewAggregate(migrateErrors)
 }
 
+// applyContextMigrations migrates the contexts declared in the config on all
+// open PRs once the migrations are effective. Every migration is applied once
+// while status-reconciler is running and again after it restarts, which is
+// harmless as PRs that already had their context migrated are left alone.
+func (c *Controller) applyContextMigrations(declared map[string][]config.ContextMigration, now time.Time, log *logrus.Entry) error {
+	if c.appliedContextMigrations == nil {
+		c.appliedContextMigrations = sets.New[declaredContextMigration]()
+	}
+	var migrateErrors []error
+	for orgrepo, migrations := range declared {
+		parts := strings.SplitN(orgrepo, "/", 2)
+		if n := len(parts); n != 2 {
+			migrateErrors = append(migrateErrors, fmt.Errorf("string %q can not be interpreted as org/repo", orgrepo))
+			continue
+		}
+		org, repo := parts[0], parts[1]
+		if c.addedPresubmitDenylistAll.Has(org) || c.addedPresubmitDenylistAll.Has(orgrepo) {
+			continue
+		}
+		for _, migration := range migrations {
+			key := declaredContextMigration{orgRepo: orgrepo, from: migration.From, to: migration.To}
+			if c.appliedContextMigrations.Has(key) || !migration.Effective(now) {
+				continue
+			}
+			log.WithFields(logrus.Fields{
+				"org":  org,
+				"repo": repo,
+				"from": migration.From,
+				"to":   migration.To,
+			}).Info("Migrating declared context.")
+			if err := c.statusMigrator.migrate(org, repo, migration.From, migration.To, func(string) bool { return true }); err != nil {
+				if c.continueOnError {
+					migrateErrors = append(migrateErrors, err)
+					continue
+				}
+				return err
+			}
+			c.appliedContextMigrations.Insert(key)
+		}
+	}
+	return utilerrors.NewAggregate(migrateErrors)
+}
+
 // addedBlockingPresubmits determines new blocking presubmits based on a
 // config update. New blocking presubmits are either brand-new presubmits
 // or extant presubmits that are now reporting. Previous presubmits that
@@ -372,7 +448,8 @@ func addedBlockingPresubmits(old, new map[string][]config.Presubmit, log *logrus
 							"name": oldPresubmit.Name,
 						}).Debug("Identified a newly-reporting blocking presubmit.")
 					}
-					if oldPresubmit.RunIfChanged != newPresubmit.RunIfChanged || oldPresubmit.SkipIfOnlyChanged != newPresubmit.SkipIfOnlyChanged {
+					if oldPresubmit.RunIfChanged != newPresubmit.RunIfChanged || oldPresubmit.SkipIfOnlyChanged != newPresubmit.SkipIfOnlyChanged ||
+						oldPresubmit.RunIfChangedExpression.String() != newPresubmit.RunIfChangedExpression.String() {
 						added[repo] = append(added[repo], newPresubmit)
 						log.WithFields(logrus.Fields{
 							"repo": repo,
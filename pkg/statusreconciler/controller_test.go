@@ -19,6 +19,7 @@ package statusreconciler
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -1205,6 +1206,86 @@ func TestControllerReconcile(t *testing.T) {
 	}
 }
 
+func TestApplyContextMigrations(t *testing.T) {
+	orgRepoKey := orgRepo{org: "org", repo: "repo"}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	declared := map[string][]config.ContextMigration{
+		"org/repo": {
+			{From: "immediate", To: "immediate-new"},
+			{From: "past", To: "past-new", EffectiveDate: &past},
+			{From: "future", To: "future-new", EffectiveDate: &future},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		applied         sets.Set[declaredContextMigration]
+		denylist        sets.Set[string]
+		migrateErrors   migrationSet
+		expected        migrationSet
+		expectedApplied sets.Set[declaredContextMigration]
+		expectErr       bool
+	}{
+		{
+			name:     "effective migrations are applied",
+			expected: migrationSet{{from: "immediate", to: "immediate-new"}: nil, {from: "past", to: "past-new"}: nil},
+			expectedApplied: sets.New[declaredContextMigration](
+				declaredContextMigration{orgRepo: "org/repo", from: "immediate", to: "immediate-new"},
+				declaredContextMigration{orgRepo: "org/repo", from: "past", to: "past-new"},
+			),
+		},
+		{
+			name:     "migrations are only applied once",
+			applied:  sets.New[declaredContextMigration](declaredContextMigration{orgRepo: "org/repo", from: "immediate", to: "immediate-new"}),
+			expected: migrationSet{{from: "past", to: "past-new"}: nil},
+			expectedApplied: sets.New[declaredContextMigration](
+				declaredContextMigration{orgRepo: "org/repo", from: "immediate", to: "immediate-new"},
+				declaredContextMigration{orgRepo: "org/repo", from: "past", to: "past-new"},
+			),
+		},
+		{
+			name:            "denylisted repos are not migrated",
+			denylist:        sets.New[string]("org"),
+			expected:        migrationSet{},
+			expectedApplied: sets.New[declaredContextMigration](),
+		},
+		{
+			name:            "failed migrations are retried",
+			migrateErrors:   migrationSet{{from: "immediate", to: "immediate-new"}: nil},
+			expected:        migrationSet{{from: "past", to: "past-new"}: nil},
+			expectedApplied: sets.New[declaredContextMigration](declaredContextMigration{orgRepo: "org/repo", from: "past", to: "past-new"}),
+			expectErr:       true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fsm := newFakeMigrator(orgRepoKey)
+			if testCase.migrateErrors != nil {
+				fsm.migrateErrors[orgRepoKey] = testCase.migrateErrors
+			}
+			controller := Controller{
+				continueOnError:           true,
+				addedPresubmitDenylistAll: testCase.denylist,
+				statusMigrator:            &fsm,
+				appliedContextMigrations:  testCase.applied,
+			}
+			err := controller.applyContextMigrations(declared, now, logrusEntry())
+			if err == nil && testCase.expectErr {
+				t.Errorf("expected an error, but got none")
+			}
+			if err != nil && !testCase.expectErr {
+				t.Errorf("expected no error, but got one: %v", err)
+			}
+			checkMigrator(t, fsm, map[orgRepo]sets.Set[string]{orgRepoKey: sets.New[string]()}, map[orgRepo]migrationSet{orgRepoKey: testCase.expected})
+			if diff := cmp.Diff(testCase.expectedApplied, controller.appliedContextMigrations, cmp.AllowUnexported(declaredContextMigration{})); diff != "" {
+				t.Errorf("did not record applied migrations correctly: %s", diff)
+			}
+		})
+	}
+}
+
 func logrusEntry() *logrus.Entry {
 	return logrus.NewEntry(logrus.StandardLogger())
 }
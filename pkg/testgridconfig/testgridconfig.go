@@ -0,0 +1,310 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testgridconfig generates TestGrid configuration from the testgrid-*
+// annotations of Prow jobs.
+package testgridconfig
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgconf "github.com/GoogleCloudPlatform/testgrid/config"
+	"github.com/GoogleCloudPlatform/testgrid/config/yamlcfg"
+	configpb "github.com/GoogleCloudPlatform/testgrid/pb/config"
+	"google.golang.org/protobuf/proto"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// The annotations of jobs read to generate their test groups and dashboard tabs.
+const (
+	// DashboardsAnnotation is a comma-separated list of the dashboards the job is a tab of.
+	DashboardsAnnotation = "testgrid-dashboards"
+	// TabNameAnnotation is the name of the tabs of the job. Defaults to the job name.
+	TabNameAnnotation = "testgrid-tab-name"
+	// CreateTestGroupAnnotation set to "true" creates a test group for a job
+	// that is on no dashboard, to be referenced by the TestGrid YAML, and set
+	// to "false" creates none.
+	CreateTestGroupAnnotation = "testgrid-create-test-group"
+	// AlertEmailAnnotation is the address alerted when the job fails, set on the
+	// tab of the first dashboard.
+	AlertEmailAnnotation             = "testgrid-alert-email"
+	NumFailuresToAlertAnnotation     = "testgrid-num-failures-to-alert"
+	AlertStaleResultsHoursAnnotation = "testgrid-alert-stale-results-hours"
+	NumColumnsRecentAnnotation       = "testgrid-num-columns-recent"
+	DaysOfResultsAnnotation          = "testgrid-days-of-results"
+	InCellMetricAnnotation           = "testgrid-in-cell-metric"
+	DisableProwJobAnalysisAnnotation = "testgrid-disable-prowjob-analysis"
+	BaseOptionsAnnotation            = "testgrid-base-options"
+	BrokenColumnThresholdAnnotation  = "testgrid-broken-column-threshold"
+	DescriptionAnnotation            = "description"
+)
+
+const (
+	presubmitLogsDirectory             = "pr-logs/directory"
+	postsubmitAndPeriodicLogsDirectory = "logs"
+)
+
+// Options tunes how the TestGrid configuration is generated.
+type Options struct {
+	// Defaults are applied to the test groups and dashboard tabs of jobs.
+	Defaults yamlcfg.DefaultConfiguration
+	// DefaultDashboards, by job type, are dashboards every job of the type
+	// is a tab of, in addition to those in its annotations.
+	DefaultDashboards map[prowapi.ProwJobType]string
+	// CreateMissingDashboards creates the dashboards jobs are tabs of instead
+	// of failing when they are not in the configuration.
+	CreateMissingDashboards bool
+	// ProwJobURLPrefix, if set, links the tabs of jobs to their config, like
+	// https://github.com/org/repo/tree/main/config/jobs/.
+	ProwJobURLPrefix string
+	// UpdateDescription adds the type and repo of jobs to the description of
+	// their tabs.
+	UpdateDescription bool
+}
+
+// Generate returns the TestGrid configuration of base with the test groups and
+// dashboard tabs of the jobs of the Prow configuration added. The result is
+// validated. The base configuration is left untouched.
+func Generate(base *configpb.Configuration, cfg *config.Config, opts Options) (*configpb.Configuration, error) {
+	result := &configpb.Configuration{}
+	if base != nil {
+		result = proto.Clone(base).(*configpb.Configuration)
+	}
+	g := generator{config: result, prow: cfg, opts: opts}
+
+	for _, repo := range sortedKeys(cfg.PresubmitsStatic) {
+		for _, job := range cfg.PresubmitsStatic[repo] {
+			if err := g.addJob(job.JobBase, prowapi.PresubmitJob, repo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, repo := range sortedKeys(cfg.PostsubmitsStatic) {
+		for _, job := range cfg.PostsubmitsStatic[repo] {
+			if err := g.addJob(job.JobBase, prowapi.PostsubmitJob, repo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	periodics := cfg.AllPeriodics()
+	sort.Slice(periodics, func(i, j int) bool { return periodics[i].Name < periodics[j].Name })
+	for _, job := range periodics {
+		repo := ""
+		if len(job.ExtraRefs) > 0 {
+			repo = job.ExtraRefs[0].OrgRepoString()
+		}
+		if err := g.addJob(job.JobBase, prowapi.PeriodicJob, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tgconf.Validate(result); err != nil {
+		return nil, fmt.Errorf("generated TestGrid configuration is invalid: %w", err)
+	}
+	return result, nil
+}
+
+type generator struct {
+	config *configpb.Configuration
+	prow   *config.Config
+	opts   Options
+}
+
+// addJob adds the test group and dashboard tabs of the job. Jobs only get a
+// test group when they are on a dashboard or ask for one, as TestGrid rejects
+// test groups no tab refers to.
+func (g *generator) addJob(job config.JobBase, jobType prowapi.ProwJobType, repo string) error {
+	var dashboards []string
+	if d := g.opts.DefaultDashboards[jobType]; d != "" {
+		dashboards = append(dashboards, d)
+	}
+	for _, d := range strings.Split(job.Annotations[DashboardsAnnotation], ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dashboards = append(dashboards, d)
+		}
+	}
+	createGroup := job.Annotations[CreateTestGroupAnnotation]
+	if createGroup != "" && createGroup != "true" && createGroup != "false" {
+		return fmt.Errorf("job %q: %s must be true or false, not %q", job.Name, CreateTestGroupAnnotation, createGroup)
+	}
+	if createGroup == "false" || (createGroup != "true" && len(dashboards) == 0) {
+		if len(dashboards) > 0 && createGroup == "false" {
+			return fmt.Errorf("job %q: is on dashboards %v but %s is false", job.Name, dashboards, CreateTestGroupAnnotation)
+		}
+		return nil
+	}
+
+	if tgconf.FindTestGroup(job.Name, g.config) != nil {
+		if createGroup == "true" {
+			return fmt.Errorf("job %q: a test group with the name of the job already exists", job.Name)
+		}
+	} else {
+		testGroup, err := g.testGroup(job, jobType, repo)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		g.config.TestGroups = append(g.config.TestGroups, testGroup)
+	}
+
+	for i, name := range dashboards {
+		dashboard := tgconf.FindDashboard(name, g.config)
+		if dashboard == nil {
+			if !g.opts.CreateMissingDashboards {
+				return fmt.Errorf("job %q: dashboard %q does not exist", job.Name, name)
+			}
+			dashboard = &configpb.Dashboard{Name: name}
+			g.config.Dashboards = append(g.config.Dashboards, dashboard)
+		}
+		tab, err := g.dashboardTab(job, jobType, repo, i == 0)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		dashboard.DashboardTab = append(dashboard.DashboardTab, tab)
+	}
+	return nil
+}
+
+func (g *generator) testGroup(job config.JobBase, jobType prowapi.ProwJobType, repo string) (*configpb.TestGroup, error) {
+	prefix, err := g.gcsPrefix(job, jobType, repo)
+	if err != nil {
+		return nil, err
+	}
+	testGroup := &configpb.TestGroup{Name: job.Name, GcsPrefix: prefix, IsExternal: true, UseKubernetesClient: true}
+	for _, field := range []struct {
+		annotation string
+		value      *int32
+	}{
+		{NumColumnsRecentAnnotation, &testGroup.NumColumnsRecent},
+		{AlertStaleResultsHoursAnnotation, &testGroup.AlertStaleResultsHours},
+		{NumFailuresToAlertAnnotation, &testGroup.NumFailuresToAlert},
+		{DaysOfResultsAnnotation, &testGroup.DaysOfResults},
+	} {
+		if err := parseInt32(job.Annotations, field.annotation, field.value); err != nil {
+			return nil, err
+		}
+	}
+	testGroup.ShortTextMetric = job.Annotations[InCellMetricAnnotation]
+	if v, ok := job.Annotations[DisableProwJobAnalysisAnnotation]; ok {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean: %w", DisableProwJobAnalysisAnnotation, err)
+		}
+		testGroup.DisableProwjobAnalysis = disable
+	}
+	if g.opts.Defaults.DefaultTestGroup != nil {
+		yamlcfg.ReconcileTestGroup(testGroup, g.opts.Defaults.DefaultTestGroup)
+	}
+	return testGroup, nil
+}
+
+func (g *generator) dashboardTab(job config.JobBase, jobType prowapi.ProwJobType, repo string, first bool) (*configpb.DashboardTab, error) {
+	tab := &configpb.DashboardTab{
+		Name:          job.Name,
+		TestGroupName: job.Name,
+		Description:   job.Annotations[DescriptionAnnotation],
+		BaseOptions:   job.Annotations[BaseOptionsAnnotation],
+	}
+	if name := job.Annotations[TabNameAnnotation]; name != "" {
+		tab.Name = name
+	}
+	if g.opts.UpdateDescription {
+		info := fmt.Sprintf("%s job", jobType)
+		if repo != "" {
+			info += " of " + repo
+		}
+		if tab.Description == "" {
+			tab.Description = info
+		} else {
+			tab.Description = fmt.Sprintf("%s (%s)", tab.Description, info)
+		}
+	}
+	if g.opts.ProwJobURLPrefix != "" && job.SourcePath != "" {
+		tab.AboutDashboardUrl = strings.TrimSuffix(g.opts.ProwJobURLPrefix, "/") + "/" + path.Base(job.SourcePath)
+	}
+	if v, ok := job.Annotations[BrokenColumnThresholdAnnotation]; ok {
+		threshold, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number: %w", BrokenColumnThresholdAnnotation, err)
+		}
+		tab.BrokenColumnThreshold = float32(threshold)
+	}
+	// Only alert from the first dashboard, not to get several mails per failure.
+	if email := job.Annotations[AlertEmailAnnotation]; email != "" && first {
+		tab.AlertOptions = &configpb.DashboardTabAlertOptions{AlertMailToAddresses: email}
+		if err := parseInt32(job.Annotations, AlertStaleResultsHoursAnnotation, &tab.AlertOptions.AlertStaleResultsHours); err != nil {
+			return nil, err
+		}
+		if err := parseInt32(job.Annotations, NumFailuresToAlertAnnotation, &tab.AlertOptions.NumFailuresToAlert); err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.Defaults.DefaultDashboardTab != nil {
+		yamlcfg.ReconcileDashboardTab(tab, g.opts.Defaults.DefaultDashboardTab)
+	}
+	return tab, nil
+}
+
+// gcsPrefix returns where the results of the job are uploaded, from its
+// decoration config or, for undecorated jobs, the default one.
+func (g *generator) gcsPrefix(job config.JobBase, jobType prowapi.ProwJobType, repo string) (string, error) {
+	dc := job.DecorationConfig
+	if dc == nil || dc.GCSConfiguration == nil {
+		dc = g.prow.Plank.GuessDefaultDecorationConfig(repo, job.Cluster)
+	}
+	if dc == nil || dc.GCSConfiguration == nil || dc.GCSConfiguration.Bucket == "" {
+		return "", fmt.Errorf("no GCS bucket is configured for the results of the job")
+	}
+	bucket := dc.GCSConfiguration.Bucket
+	if strings.Contains(bucket, "://") {
+		if !strings.HasPrefix(bucket, "gs://") {
+			return "", fmt.Errorf("TestGrid only reads results from GCS, not %s", bucket)
+		}
+		bucket = strings.TrimPrefix(bucket, "gs://")
+	}
+	directory := postsubmitAndPeriodicLogsDirectory
+	if jobType == prowapi.PresubmitJob {
+		directory = presubmitLogsDirectory
+	}
+	return path.Join(bucket, dc.GCSConfiguration.PathPrefix, directory, job.Name), nil
+}
+
+func parseInt32(annotations map[string]string, annotation string, field *int32) error {
+	v, ok := annotations[annotation]
+	if !ok {
+		return nil
+	}
+	i, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%s must be an integer: %w", annotation, err)
+	}
+	*field = int32(i)
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
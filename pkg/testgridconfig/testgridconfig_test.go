@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testgridconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/testgrid/config/yamlcfg"
+	configpb "github.com/GoogleCloudPlatform/testgrid/pb/config"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func decorated(bucket string) *prowapi.DecorationConfig {
+	return &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: bucket}}
+}
+
+func TestGenerate(t *testing.T) {
+	base := func() *configpb.Configuration {
+		return &configpb.Configuration{
+			TestGroups: []*configpb.TestGroup{{Name: "existing", GcsPrefix: "bucket/logs/existing", DaysOfResults: 1, NumColumnsRecent: 1}},
+			Dashboards: []*configpb.Dashboard{{Name: "sig-testing", DashboardTab: []*configpb.DashboardTab{{Name: "existing", TestGroupName: "existing"}}}},
+		}
+	}
+	defaults := yamlcfg.DefaultConfiguration{
+		DefaultTestGroup:    &configpb.TestGroup{DaysOfResults: 7, NumColumnsRecent: 10},
+		DefaultDashboardTab: &configpb.DashboardTab{NumColumnsRecent: 10},
+	}
+	testGroup := func(name, prefix string) *configpb.TestGroup {
+		return &configpb.TestGroup{Name: name, GcsPrefix: prefix, DaysOfResults: 7, NumColumnsRecent: 10, IsExternal: true, UseKubernetesClient: true}
+	}
+	existingTab := &configpb.DashboardTab{Name: "existing", TestGroupName: "existing"}
+	prowConfig := func(presubmits []config.Presubmit, postsubmits []config.Postsubmit, periodics []config.Periodic) *config.Config {
+		return &config.Config{
+			JobConfig: config.JobConfig{
+				PresubmitsStatic:  map[string][]config.Presubmit{"org/repo": presubmits},
+				PostsubmitsStatic: map[string][]config.Postsubmit{"org/repo": postsubmits},
+				Periodics:         periodics,
+			},
+			ProwConfig: config.ProwConfig{
+				Plank: config.Plank{DefaultDecorationConfigs: []*config.DefaultDecorationConfigEntry{
+					{OrgRepo: "*", Config: decorated("gs://default-bucket")},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		config      *config.Config
+		opts        Options
+		expected    *configpb.Configuration
+		expectedErr string
+	}{
+		{
+			name: "only jobs on dashboards get test groups",
+			config: prowConfig(
+				[]config.Presubmit{{JobBase: config.JobBase{Name: "pull-unit", UtilityConfig: config.UtilityConfig{DecorationConfig: decorated("bucket")}}}},
+				[]config.Postsubmit{{JobBase: config.JobBase{
+					Name:          "post-unit",
+					UtilityConfig: config.UtilityConfig{DecorationConfig: decorated("bucket")},
+					Annotations:   map[string]string{DashboardsAnnotation: "sig-testing"},
+				}}},
+				[]config.Periodic{
+					{JobBase: config.JobBase{Name: "ci-unit", Annotations: map[string]string{DashboardsAnnotation: "sig-testing"}}},
+					{JobBase: config.JobBase{Name: "ci-other"}},
+				},
+			),
+			opts: Options{Defaults: defaults},
+			expected: &configpb.Configuration{
+				TestGroups: []*configpb.TestGroup{
+					base().TestGroups[0],
+					testGroup("post-unit", "bucket/logs/post-unit"),
+					testGroup("ci-unit", "default-bucket/logs/ci-unit"),
+				},
+				Dashboards: []*configpb.Dashboard{{Name: "sig-testing", DashboardTab: []*configpb.DashboardTab{
+					existingTab,
+					{Name: "post-unit", TestGroupName: "post-unit", NumColumnsRecent: 10},
+					{Name: "ci-unit", TestGroupName: "ci-unit", NumColumnsRecent: 10},
+				}}},
+			},
+		},
+		{
+			name: "annotations configure test groups and tabs",
+			config: prowConfig(
+				[]config.Presubmit{{JobBase: config.JobBase{
+					Name:          "pull-e2e",
+					UtilityConfig: config.UtilityConfig{DecorationConfig: decorated("bucket")},
+					Annotations: map[string]string{
+						DashboardsAnnotation:             "sig-testing, sig-release",
+						TabNameAnnotation:                "e2e",
+						AlertEmailAnnotation:             "sig-testing@example.com",
+						NumFailuresToAlertAnnotation:     "3",
+						NumColumnsRecentAnnotation:       "5",
+						DescriptionAnnotation:            "Runs the e2e tests.",
+						BaseOptionsAnnotation:            "width=10",
+						DisableProwJobAnalysisAnnotation: "true",
+					},
+				}}},
+				nil,
+				[]config.Periodic{{JobBase: config.JobBase{Name: "ci-unit", Annotations: map[string]string{CreateTestGroupAnnotation: "false"}}}},
+			),
+			opts: Options{Defaults: defaults, CreateMissingDashboards: true},
+			expected: &configpb.Configuration{
+				TestGroups: []*configpb.TestGroup{base().TestGroups[0], {
+					Name:                   "pull-e2e",
+					GcsPrefix:              "bucket/pr-logs/directory/pull-e2e",
+					DaysOfResults:          7,
+					NumColumnsRecent:       5,
+					NumFailuresToAlert:     3,
+					DisableProwjobAnalysis: true,
+					IsExternal:             true,
+					UseKubernetesClient:    true,
+				}},
+				Dashboards: []*configpb.Dashboard{
+					{Name: "sig-testing", DashboardTab: []*configpb.DashboardTab{existingTab, {
+						Name:             "e2e",
+						TestGroupName:    "pull-e2e",
+						Description:      "Runs the e2e tests.",
+						BaseOptions:      "width=10",
+						NumColumnsRecent: 10,
+						AlertOptions:     &configpb.DashboardTabAlertOptions{AlertMailToAddresses: "sig-testing@example.com", NumFailuresToAlert: 3},
+					}}},
+					{Name: "sig-release", DashboardTab: []*configpb.DashboardTab{{
+						Name:             "e2e",
+						TestGroupName:    "pull-e2e",
+						Description:      "Runs the e2e tests.",
+						BaseOptions:      "width=10",
+						NumColumnsRecent: 10,
+					}}},
+				},
+			},
+		},
+		{
+			name: "default dashboards and descriptions",
+			config: prowConfig(nil, nil, []config.Periodic{{JobBase: config.JobBase{
+				Name:          "ci-e2e",
+				UtilityConfig: config.UtilityConfig{DecorationConfig: decorated("bucket")},
+				Annotations:   map[string]string{DescriptionAnnotation: "Runs the e2e tests."},
+			}}}),
+			opts: Options{
+				Defaults:          defaults,
+				DefaultDashboards: map[prowapi.ProwJobType]string{prowapi.PeriodicJob: "sig-testing"},
+				UpdateDescription: true,
+			},
+			expected: &configpb.Configuration{
+				TestGroups: []*configpb.TestGroup{base().TestGroups[0], testGroup("ci-e2e", "bucket/logs/ci-e2e")},
+				Dashboards: []*configpb.Dashboard{{Name: "sig-testing", DashboardTab: []*configpb.DashboardTab{
+					existingTab,
+					{Name: "ci-e2e", TestGroupName: "ci-e2e", Description: "Runs the e2e tests. (periodic job)", NumColumnsRecent: 10},
+				}}},
+			},
+		},
+		{
+			name: "missing dashboard",
+			config: prowConfig(nil, nil, []config.Periodic{{JobBase: config.JobBase{
+				Name:        "ci-e2e",
+				Annotations: map[string]string{DashboardsAnnotation: "sig-typo"},
+			}}}),
+			opts:        Options{Defaults: defaults},
+			expectedErr: `job "ci-e2e": dashboard "sig-typo" does not exist`,
+		},
+		{
+			name: "invalid annotation",
+			config: prowConfig(nil, nil, []config.Periodic{{JobBase: config.JobBase{
+				Name:        "ci-e2e",
+				Annotations: map[string]string{DashboardsAnnotation: "sig-testing", NumColumnsRecentAnnotation: "many"},
+			}}}),
+			opts:        Options{Defaults: defaults},
+			expectedErr: `job "ci-e2e": testgrid-num-columns-recent must be an integer`,
+		},
+		{
+			name: "duplicate tab",
+			config: prowConfig(nil, nil, []config.Periodic{
+				{JobBase: config.JobBase{Name: "ci-a", Annotations: map[string]string{DashboardsAnnotation: "sig-testing", TabNameAnnotation: "tab"}}},
+				{JobBase: config.JobBase{Name: "ci-b", Annotations: map[string]string{DashboardsAnnotation: "sig-testing", TabNameAnnotation: "tab"}}},
+			}),
+			opts:        Options{Defaults: defaults},
+			expectedErr: "generated TestGrid configuration is invalid",
+		},
+		{
+			name: "S3 bucket",
+			config: prowConfig(nil, nil, []config.Periodic{{JobBase: config.JobBase{
+				Name:          "ci-e2e",
+				UtilityConfig: config.UtilityConfig{DecorationConfig: decorated("s3://bucket")},
+				Annotations:   map[string]string{DashboardsAnnotation: "sig-testing"},
+			}}}),
+			opts:        Options{Defaults: defaults},
+			expectedErr: `job "ci-e2e": TestGrid only reads results from GCS, not s3://bucket`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := base()
+			generated, err := Generate(b, tc.config, tc.opts)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, generated, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected config (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(base(), b, protocmp.Transform()); diff != "" {
+				t.Errorf("base config was modified (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
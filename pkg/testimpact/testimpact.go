@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testimpact tells which presubmits the changes of a PR affect, going
+// by a mapping of the jobs to the files they depend on that is kept in the
+// repo. The mapping can be written by hand or generated from the dependencies
+// that go list or bazel query find.
+package testimpact
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Mapping maps the jobs of a repo to the files they depend on. The files are
+// given by patterns relative to the root of the repo: a path ending with a
+// slash matches all files under the directory, any other pattern is matched
+// with path.Match, so pkg/foo/* matches the files of the directory only.
+type Mapping struct {
+	// Always are the patterns of the files that affect every job, like go.mod
+	// or WORKSPACE.
+	Always []string `json:"always,omitempty"`
+	// Jobs maps the names of jobs to the patterns of the files they depend on.
+	// Jobs that aren't listed are affected by every change.
+	Jobs map[string][]string `json:"jobs,omitempty"`
+}
+
+// Parse parses and validates a YAML mapping.
+func Parse(raw []byte) (*Mapping, error) {
+	var m Mapping
+	if err := yaml.UnmarshalStrict(raw, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse the mapping: %w", err)
+	}
+	if err := validatePatterns(m.Always); err != nil {
+		return nil, fmt.Errorf("always: %w", err)
+	}
+	for job, patterns := range m.Jobs {
+		if err := validatePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("jobs.%s: %w", job, err)
+		}
+	}
+	return &m, nil
+}
+
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("pattern %q must be a path relative to the root of the repo", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func matches(pattern, file string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(file, pattern)
+	}
+	matched, _ := path.Match(pattern, file)
+	return matched
+}
+
+func matchesAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matches(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unaffected tells whether the mapping proves that none of the changed files
+// affect the job. Jobs the mapping doesn't list are always affected, and so
+// is every job when nothing is known to have changed.
+func (m *Mapping) Unaffected(job string, changes []string) bool {
+	patterns, ok := m.Jobs[job]
+	if !ok || len(changes) == 0 {
+		return false
+	}
+	for _, file := range changes {
+		if matchesAny(m.Always, file) || matchesAny(patterns, file) {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner runs the command in the directory and returns its standard output.
+type Runner func(dir, name string, args ...string) ([]byte, error)
+
+// ExecRunner runs the commands with os/exec.
+func ExecRunner(dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// GoDeps returns the patterns of the files of the packages in the repo at
+// root that the Go packages depend on, including themselves. Dependencies
+// outside of the repo are left out, the go.mod and go.sum files they come
+// from belong to Always.
+func GoDeps(run Runner, root string, packages []string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"list", "-deps", "-f", "{{if not .Standard}}{{.Dir}}{{end}}"}, packages...)
+	out, err := run(root, "go", args...)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, dir := range strings.Split(string(out), "\n") {
+		if dir == "" {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		patterns = append(patterns, path.Join(escape(filepath.ToSlash(rel)), "*"))
+	}
+	return sortedUnique(patterns), nil
+}
+
+// BazelDeps returns the source and BUILD files in the workspace at root that
+// the Bazel targets depend on. Files of external repositories are left out,
+// the WORKSPACE or MODULE.bazel files they come from belong to Always.
+func BazelDeps(run Runner, root string, targets []string) ([]string, error) {
+	set := "set(" + strings.Join(targets, " ") + ")"
+	query := fmt.Sprintf(`kind("source file", deps(%s)) + buildfiles(deps(%s))`, set, set)
+	out, err := run(root, "bazel", "query", "--output=label", query)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, label := range strings.Split(string(out), "\n") {
+		pkg, name, ok := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+		if !ok || !strings.HasPrefix(label, "//") {
+			continue
+		}
+		patterns = append(patterns, escape(path.Join(pkg, name)))
+	}
+	return sortedUnique(patterns), nil
+}
+
+// escape makes the path match itself only as a pattern.
+func escape(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedUnique(patterns []string) []string {
+	sort.Strings(patterns)
+	var unique []string
+	for i, pattern := range patterns {
+		if i == 0 || pattern != patterns[i-1] {
+			unique = append(unique, pattern)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testimpact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		expectedErr bool
+	}{
+		{
+			name: "valid mapping",
+			raw:  "always:\n- go.mod\njobs:\n  pull-foo:\n  - pkg/foo/\n  - cmd/foo/*.go\n",
+		},
+		{
+			name:        "absolute pattern",
+			raw:         "jobs:\n  pull-foo:\n  - /pkg/foo/\n",
+			expectedErr: true,
+		},
+		{
+			name:        "malformed pattern",
+			raw:         "always:\n- \"[\"\n",
+			expectedErr: true,
+		},
+		{
+			name:        "unknown field",
+			raw:         "job:\n  pull-foo:\n  - pkg/foo/\n",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tc.raw)); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestUnaffected(t *testing.T) {
+	m := &Mapping{
+		Always: []string{"go.mod"},
+		Jobs: map[string][]string{
+			"pull-foo": {"pkg/foo/*", "hack/"},
+		},
+	}
+	testCases := []struct {
+		name     string
+		job      string
+		changes  []string
+		expected bool
+	}{
+		{
+			name:     "changes outside the dependencies",
+			job:      "pull-foo",
+			changes:  []string{"pkg/bar/bar.go", "pkg/foo/sub/sub.go"},
+			expected: true,
+		},
+		{
+			name:    "change of a dependency",
+			job:     "pull-foo",
+			changes: []string{"pkg/bar/bar.go", "pkg/foo/foo.go"},
+		},
+		{
+			name:    "change under a directory",
+			job:     "pull-foo",
+			changes: []string{"hack/tools/verify.sh"},
+		},
+		{
+			name:    "change affecting every job",
+			job:     "pull-foo",
+			changes: []string{"go.mod"},
+		},
+		{
+			name:    "unknown job",
+			job:     "pull-bar",
+			changes: []string{"pkg/bar/bar.go"},
+		},
+		{
+			name: "no changes",
+			job:  "pull-foo",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := m.Unaffected(tc.job, tc.changes); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGoDeps(t *testing.T) {
+	run := func(dir, name string, args ...string) ([]byte, error) {
+		if name != "go" || dir != "/repo" || args[len(args)-1] != "./cmd/foo" {
+			t.Errorf("unexpected command %s %s in %s", name, strings.Join(args, " "), dir)
+		}
+		return []byte("/repo/cmd/foo\n/repo/pkg/foo\n/home/go/pkg/mod/github.com/dep@v1.0.0\n/repo\n"), nil
+	}
+	actual, err := GoDeps(run, "/repo", []string{"./cmd/foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"*", "cmd/foo/*", "pkg/foo/*"}, actual); diff != "" {
+		t.Errorf("unexpected patterns (-want +got): %s", diff)
+	}
+}
+
+func TestBazelDeps(t *testing.T) {
+	run := func(dir, name string, args ...string) ([]byte, error) {
+		if name != "bazel" || args[0] != "query" {
+			t.Errorf("unexpected command %s %s", name, strings.Join(args, " "))
+		}
+		return []byte("//cmd/foo:main.go\n//cmd/foo:BUILD.bazel\n//pkg/foo:foo[1].go\n@io_k8s_api//core:types.go\n//:BUILD.bazel\n"), nil
+	}
+	actual, err := BazelDeps(run, "/repo", []string{"//cmd/foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"BUILD.bazel", "cmd/foo/BUILD.bazel", "cmd/foo/main.go", `pkg/foo/foo\[1].go`}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected patterns (-want +got): %s", diff)
+	}
+	m := &Mapping{Jobs: map[string][]string{"pull-foo": actual}}
+	if m.Unaffected("pull-foo", []string{"pkg/foo/foo[1].go"}) {
+		t.Error("expected the escaped pattern to match the file")
+	}
+}
@@ -21,12 +21,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/ptr"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -43,6 +45,8 @@ import (
 
 type querier func(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
 
+type batchQuerier func(ctx context.Context, queries []github.BatchedQuery, org string) error
+
 func datedQuery(q string, start, end time.Time) string {
 	return fmt.Sprintf("%s %s", q, dateToken(start, end))
 }
@@ -98,52 +102,59 @@ func (gi *GitHubProvider) blockers() (blockers.Blockers, error) {
 	return blockers.FindAll(gi.ghc, gi.logger, label, orgRepoQuery, gi.usesGitHubAppsAuth)
 }
 
-// Query gets all open PRs based on tide configuration.
+// Query gets all open PRs based on tide configuration. The queries for the
+// same org are sent together to spare GraphQL requests.
 func (gi *GitHubProvider) Query() (map[string]CodeReviewCommon, error) {
-	lock := sync.Mutex{}
-	wg := sync.WaitGroup{}
-	prs := make(map[string]CodeReviewCommon)
-	var errs []error
+	// Use org-sharded queries only when GitHub apps auth is in use
+	queriesByOrg := map[string][]string{}
+	indexesByOrg := map[string][]int{}
 	for i, query := range gi.cfg().Tide.Queries {
-
-		// Use org-sharded queries only when GitHub apps auth is in use
-		var queries map[string]string
+		queries := map[string]string{"": query.Query()}
 		if gi.usesGitHubAppsAuth {
 			queries = query.OrgQueries()
-		} else {
-			queries = map[string]string{"": query.Query()}
 		}
-
 		for org, q := range queries {
-			org, q, i := org, q, i
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				results, err := gi.search(gi.ghc.QueryWithGitHubAppsSupport, gi.logger, q, time.Time{}, time.Now(), org)
+			queriesByOrg[org] = append(queriesByOrg[org], q)
+			indexesByOrg[org] = append(indexesByOrg[org], i)
+		}
+	}
 
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	prs := make(map[string]CodeReviewCommon)
+	var errs []error
+	for org, qs := range queriesByOrg {
+		org, qs := org, qs
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, searchErrs := gi.searchAll(gi.ghc.BatchQueryWithGitHubAppsSupport, gi.logger, qs, time.Time{}, time.Now(), org)
+
+			lock.Lock()
+			defer lock.Unlock()
+			for j, q := range qs {
+				i, err := indexesByOrg[org][j], searchErrs[j]
 				resultString := "success"
 				if err != nil {
 					resultString = "error"
 				}
 				tideMetrics.queryResults.WithLabelValues(strconv.Itoa(i), org, resultString).Inc()
 
-				lock.Lock()
-				defer lock.Unlock()
-				if err != nil && len(results) == 0 {
+				if err != nil && len(results[j]) == 0 {
 					gi.logger.WithField("query", q).WithError(err).Warn("Failed to execute query.")
 					errs = append(errs, fmt.Errorf("query %d, err: %w", i, err))
-					return
+					continue
 				}
 				if err != nil {
 					gi.logger.WithError(err).WithField("query", q).Warning("found partial results")
 				}
 
-				for _, pr := range results {
+				for _, pr := range results[j] {
 					crc := CodeReviewCommonFromPullRequest(&pr)
 					prs[prKey(crc)] = *crc
 				}
-			}()
-		}
+			}
+		}()
 	}
 	wg.Wait()
 
@@ -163,52 +174,89 @@ func (gi *GitHubProvider) prMergeMethod(crc *CodeReviewCommon) *types.PullReques
 }
 
 func (gi *GitHubProvider) search(query querier, log *logrus.Entry, q string, start, end time.Time, org string) ([]PullRequest, error) {
+	results, errs := gi.searchAll(func(ctx context.Context, queries []github.BatchedQuery, org string) error {
+		for _, bq := range queries {
+			if err := query(ctx, bq.Query, bq.Vars, org); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, log, []string{q}, start, end, org)
+	return results[0], errs[0]
+}
+
+// searchAll runs the searches for the org together: the next pages of all the
+// searches that aren't exhausted yet are fetched by a single batch of queries.
+// It returns the results and the error of every search, the results of
+// failed searches are the ones found until they failed.
+func (gi *GitHubProvider) searchAll(query batchQuerier, log *logrus.Entry, qs []string, start, end time.Time, org string) ([][]PullRequest, []error) {
 	start = floor(start)
 	end = floor(end)
 	log = log.WithFields(logrus.Fields{
-		"query": q,
 		"start": start.String(),
 		"end":   end.String(),
 	})
 	requestStart := time.Now()
-	var cursor *githubql.String
-	vars := map[string]interface{}{
-		"query":        githubql.String(datedQuery(q, start, end)),
-		"searchCursor": cursor,
+	results := make([][]PullRequest, len(qs))
+	errs := make([]error, len(qs))
+	cursors := make([]*githubql.String, len(qs))
+	active := make([]int, 0, len(qs))
+	for i := range qs {
+		active = append(active, i)
 	}
 
 	var totalCost, remaining int
-	var ret []PullRequest
-	var sq searchQuery
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
-	for {
-		log.Debug("Sending query")
-		if err := query(ctx, &sq, vars, org); err != nil {
-			if cursor != nil {
-				err = fmt.Errorf("cursor: %q, err: %w", *cursor, err)
+	for len(active) > 0 {
+		sqs := make([]searchQuery, len(active))
+		queries := make([]github.BatchedQuery, len(active))
+		for j, i := range active {
+			queries[j] = github.BatchedQuery{
+				Query: &sqs[j],
+				Vars: map[string]interface{}{
+					"query":        githubql.String(datedQuery(qs[i], start, end)),
+					"searchCursor": cursors[i],
+				},
 			}
-			return ret, err
-		}
-		totalCost += int(sq.RateLimit.Cost)
-		remaining = int(sq.RateLimit.Remaining)
-		for _, n := range sq.Search.Nodes {
-			ret = append(ret, n.PullRequest)
 		}
-		if !sq.Search.PageInfo.HasNextPage {
+		log.WithField("queries", len(queries)).Debug("Sending queries")
+		if err := query(ctx, queries, org); err != nil {
+			for _, i := range active {
+				if cursors[i] != nil {
+					errs[i] = fmt.Errorf("cursor: %q, err: %w", *cursors[i], err)
+				} else {
+					errs[i] = err
+				}
+			}
 			break
 		}
-		cursor = &sq.Search.PageInfo.EndCursor
-		vars["searchCursor"] = cursor
-		log = log.WithField("searchCursor", *cursor)
-	}
-	log.WithFields(logrus.Fields{
-		"duration":       time.Since(requestStart).String(),
-		"pr_found_count": len(ret),
-		"cost":           totalCost,
-		"remaining":      remaining,
-	}).Debug("Finished query")
-	return ret, nil
+		// Queries sent together all report the cost of their request.
+		totalCost += int(sqs[0].RateLimit.Cost)
+		remaining = int(sqs[0].RateLimit.Remaining)
+		var next []int
+		for j, i := range active {
+			for _, n := range sqs[j].Search.Nodes {
+				results[i] = append(results[i], n.PullRequest)
+			}
+			if sqs[j].Search.PageInfo.HasNextPage {
+				cursor := sqs[j].Search.PageInfo.EndCursor
+				cursors[i] = &cursor
+				next = append(next, i)
+			}
+		}
+		active = next
+	}
+	for i, q := range qs {
+		log.WithFields(logrus.Fields{
+			"query":          q,
+			"duration":       time.Since(requestStart).String(),
+			"pr_found_count": len(results[i]),
+			"cost":           totalCost,
+			"remaining":      remaining,
+		}).Debug("Finished query")
+	}
+	return results, errs
 }
 
 func (gi *GitHubProvider) prepareMergeDetails(commitTemplates config.TideMergeCommitTemplate, pr CodeReviewCommon, mergeMethod types.PullRequestMergeType) github.MergeDetails {
@@ -217,20 +265,23 @@ func (gi *GitHubProvider) prepareMergeDetails(commitTemplates config.TideMergeCo
 		MergeMethod: string(mergeMethod),
 	}
 
-	if commitTemplates.Title != nil {
+	title, body := commitTemplates.ForMergeMethod(mergeMethod)
+	data := newMergeCommitTemplateData(pr, gi.ghc)
+
+	if title != nil {
 		var b bytes.Buffer
 
-		if err := commitTemplates.Title.Execute(&b, pr); err != nil {
+		if err := title.Execute(&b, data); err != nil {
 			gi.logger.Errorf("error executing commit title template: %v", err)
 		} else {
 			ghMergeDetails.CommitTitle = b.String()
 		}
 	}
 
-	if commitTemplates.Body != nil {
+	if body != nil {
 		var b bytes.Buffer
 
-		if err := commitTemplates.Body.Execute(&b, pr); err != nil {
+		if err := body.Execute(&b, data); err != nil {
 			gi.logger.Errorf("error executing commit body template: %v", err)
 		} else {
 			ghMergeDetails.CommitMessage = b.String()
@@ -240,6 +291,70 @@ func (gi *GitHubProvider) prepareMergeDetails(commitTemplates config.TideMergeCo
 	return ghMergeDetails
 }
 
+// closingKeywordRe matches the references to issues GitHub closes when the PR
+// with them in its body merges, like "Fixes #123" or "closes org/repo#123".
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+([\w.-]+/[\w.-]+)?#(\d+)\b`)
+
+// mergeCommitTemplateData is what merge commit templates are executed on. It
+// embeds the PR, for templates to keep referring to its fields directly.
+type mergeCommitTemplateData struct {
+	CodeReviewCommon
+	// Labels are the names of the labels of the PR.
+	Labels []string
+	// LinkedIssues are the numbers of the issues of the repo that the body of
+	// the PR says it closes.
+	LinkedIssues []int
+
+	ghc     githubClient
+	authors []string
+}
+
+func newMergeCommitTemplateData(pr CodeReviewCommon, ghc githubClient) *mergeCommitTemplateData {
+	data := &mergeCommitTemplateData{CodeReviewCommon: pr, ghc: ghc}
+	if labels := pr.GitHubLabels(); labels != nil {
+		for _, label := range labels.Nodes {
+			data.Labels = append(data.Labels, string(label.Name))
+		}
+	}
+	seen := sets.New[int]()
+	for _, match := range closingKeywordRe.FindAllStringSubmatch(pr.Body, -1) {
+		if match[1] != "" && !strings.EqualFold(match[1], pr.Org+"/"+pr.Repo) {
+			continue
+		}
+		number, err := strconv.Atoi(match[2])
+		if err != nil || seen.Has(number) {
+			continue
+		}
+		seen.Insert(number)
+		data.LinkedIssues = append(data.LinkedIssues, number)
+	}
+	return data
+}
+
+// Authors returns the distinct authors of the commits of the PR as
+// "Name <email>", like for Co-authored-by trailers. They are only fetched if
+// a template uses them.
+func (d *mergeCommitTemplateData) Authors() ([]string, error) {
+	if d.authors != nil {
+		return d.authors, nil
+	}
+	commits, err := d.ghc.ListPullRequestCommits(d.Org, d.Repo, d.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	d.authors = []string{}
+	seen := sets.New[string]()
+	for _, commit := range commits {
+		author := fmt.Sprintf("%s <%s>", commit.Commit.Author.Name, commit.Commit.Author.Email)
+		if commit.Commit.Author.Email == "" || seen.Has(author) {
+			continue
+		}
+		seen.Insert(author)
+		d.authors = append(d.authors, author)
+	}
+	return d.authors, nil
+}
+
 func (gi *GitHubProvider) mergePRs(sp subpool, prs []CodeReviewCommon, dontUpdateStatus *threadSafePRSet) ([]CodeReviewCommon, error) {
 	var merged []CodeReviewCommon
 	var failed []int
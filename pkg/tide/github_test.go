@@ -179,6 +179,57 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchAll(t *testing.T) {
+	// pages maps the queries to the numbers of the PRs of each of their pages.
+	pages := map[string][][]int{
+		"one page":    {{1, 2}},
+		"three pages": {{3}, {4}, {5}},
+		"two pages":   {{6}, {7}},
+	}
+	qs := []string{"one page", "three pages", "two pages"}
+	var batchSizes []int
+	querier := func(_ context.Context, queries []github.BatchedQuery, _ string) error {
+		batchSizes = append(batchSizes, len(queries))
+		for _, bq := range queries {
+			q := string(bq.Vars["query"].(githubql.String))
+			q = q[:len(q)-len(dateToken(floor(time.Time{}), floor(time.Time{})))-1]
+			page := 0
+			if cursor := bq.Vars["searchCursor"].(*githubql.String); cursor != nil {
+				page = int((*cursor)[0] - '0')
+			}
+			sq := bq.Query.(*searchQuery)
+			for _, n := range pages[q][page] {
+				sq.Search.Nodes = append(sq.Search.Nodes, PRNode{PullRequest{Number: githubql.Int(n)}})
+			}
+			if page+1 < len(pages[q]) {
+				sq.Search.PageInfo.HasNextPage = true
+				sq.Search.PageInfo.EndCursor = githubql.String(string(rune('0' + page + 1)))
+			}
+		}
+		return nil
+	}
+
+	client := &GitHubProvider{}
+	results, errs := client.searchAll(querier, logrus.WithField("test", "TestSearchAll"), qs, time.Time{}, time.Time{}, "")
+	for i, q := range qs {
+		if errs[i] != nil {
+			t.Errorf("unexpected error for %q: %v", q, errs[i])
+		}
+		var expected []PullRequest
+		for _, page := range pages[q] {
+			for _, n := range page {
+				expected = append(expected, PullRequest{Number: githubql.Int(n)})
+			}
+		}
+		if !reflect.DeepEqual(expected, results[i]) {
+			t.Errorf("prs of %q do not match:\n%s", q, diff.ObjectReflectDiff(expected, results[i]))
+		}
+	}
+	if expected := []int{3, 2, 1}; !slices.Equal(expected, batchSizes) {
+		t.Errorf("expected batches of %v queries, got %v", expected, batchSizes)
+	}
+}
+
 func TestPrepareMergeDetails(t *testing.T) {
 	pr := PullRequest{
 		Number:     githubql.Int(1),
@@ -243,6 +294,43 @@ func TestPrepareMergeDetails(t *testing.T) {
 			CommitTitle:   "1: my commit title",
 			CommitMessage: "SHA - my commit body",
 		},
+	}, {
+		name: "Commit template uses labels, linked issues and authors",
+		tpl: config.TideMergeCommitTemplate{
+			Title: getTemplate("CommitTitle", "{{ .Title }} (#{{ .Number }})"),
+			Body:  getTemplate("CommitBody", "{{ range .Labels }}[{{ . }}]{{ end }}{{ range .LinkedIssues }} Closes #{{ . }}{{ end }}{{ range .Authors }}\nCo-authored-by: {{ . }}{{ end }}"),
+		},
+		pr: func() PullRequest {
+			pr := pr
+			pr.Repository.Owner.Login = "org"
+			pr.Repository.Name = "repo"
+			pr.Body = "Fixes #2, fixes other/repo#3, resolves org/repo#4 and closes #2 again.\nSee also #5."
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: "kind/bug"}, struct{ Name githubql.String }{Name: "lgtm"})
+			return pr
+		}(),
+		mergeMethod: "merge",
+		expected: github.MergeDetails{
+			SHA:           "SHA",
+			MergeMethod:   "merge",
+			CommitTitle:   "my commit title (#1)",
+			CommitMessage: "[kind/bug][lgtm] Closes #2 Closes #4\nCo-authored-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>",
+		},
+	}, {
+		name: "Merge method overrides commit template",
+		tpl: config.TideMergeCommitTemplate{
+			Title:  getTemplate("CommitTitle", "merged {{ .Title }}"),
+			Body:   getTemplate("CommitBody", "{{ .Body }}"),
+			Squash: &config.TideCommitTemplate{Title: getTemplate("CommitTitle", "squashed {{ .Title }}")},
+			Merge:  &config.TideCommitTemplate{Body: getTemplate("CommitBody", "not squashed")},
+		},
+		pr:          pr,
+		mergeMethod: "squash",
+		expected: github.MergeDetails{
+			SHA:           "SHA",
+			MergeMethod:   "squash",
+			CommitTitle:   "squashed my commit title",
+			CommitMessage: "my commit body",
+		},
 	}, {
 		name: "Commit template uses nonexistent fields",
 		tpl: config.TideMergeCommitTemplate{
@@ -263,8 +351,12 @@ func TestPrepareMergeDetails(t *testing.T) {
 			cfgAgent := &config.Agent{}
 			cfgAgent.Set(cfg)
 			provider := &GitHubProvider{
-				cfg:    cfgAgent.Config,
-				ghc:    &fgc{},
+				cfg: cfgAgent.Config,
+				ghc: &fgc{prCommits: map[int][]github.RepositoryCommit{1: {
+					{Commit: github.GitCommit{Author: github.CommitAuthor{Name: "Alice", Email: "alice@example.com"}}},
+					{Commit: github.GitCommit{Author: github.CommitAuthor{Name: "Bob", Email: "bob@example.com"}}},
+					{Commit: github.GitCommit{Author: github.CommitAuthor{Name: "Alice", Email: "alice@example.com"}}},
+				}}},
 				logger: logrus.WithContext(context.Background()),
 			}
 
@@ -0,0 +1,324 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	stdio "io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+const dayLayout = "2006-01-02"
+
+// ArchivedRecord is a Record together with the key of the pool it was
+// recorded for.
+type ArchivedRecord struct {
+	Pool string `json:"pool"`
+	Record
+}
+
+// Filter selects archived records. Empty fields match every record.
+type Filter struct {
+	// Org matches the org of the pool.
+	Org string
+	// Repo matches the org/repo of the pool.
+	Repo string
+	// Branch matches the branch of the pool.
+	Branch string
+	// Action matches the action of the record, like MERGE or TRIGGER_BATCH.
+	Action string
+	// Since and Until restrict the time of the records.
+	Since, Until time.Time
+}
+
+// splitPoolKey splits a pool key of the form org/repo:branch. Gerrit orgs
+// are URLs, so the branch starts after the last colon.
+func splitPoolKey(pool string) (org, repo, branch string) {
+	if i := strings.LastIndex(pool, ":"); i >= 0 {
+		repo, branch = pool[:i], pool[i+1:]
+	} else {
+		repo = pool
+	}
+	org = repo
+	if i := strings.LastIndex(repo, "/"); i >= 0 {
+		org = repo[:i]
+	}
+	return org, repo, branch
+}
+
+func (f Filter) matches(rec *ArchivedRecord) bool {
+	org, repo, branch := splitPoolKey(rec.Pool)
+	switch {
+	case f.Org != "" && f.Org != org,
+		f.Repo != "" && f.Repo != repo,
+		f.Branch != "" && f.Branch != branch,
+		f.Action != "" && f.Action != rec.Action,
+		!f.Since.IsZero() && rec.Time.Before(f.Since),
+		!f.Until.IsZero() && rec.Time.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// archive stores the records in one object per day under a prefix, so they
+// are kept for longer than the most recent records of every pool.
+type archive struct {
+	opener    opener
+	prefix    string
+	retention func() time.Duration
+
+	lock sync.Mutex
+	// days holds the records of the days that were recorded since
+	// tide started, until they are flushed after the day ended.
+	days  map[string][]*ArchivedRecord
+	dirty sets.Set[string]
+}
+
+func (a *archive) path(day string) string {
+	return fmt.Sprintf("%s/%s.json", strings.TrimSuffix(a.prefix, "/"), day)
+}
+
+func (a *archive) read(ctx context.Context, day string) ([]*ArchivedRecord, error) {
+	reader, err := a.opener.Reader(ctx, a.path(day))
+	if io.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer io.LogClose(reader)
+	raw, err := stdio.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	var records []*ArchivedRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	return records, nil
+}
+
+// load reads the records of the day from the archive if they aren't in
+// memory already, so flushing the day doesn't drop the records that were
+// archived before tide restarted.
+func (a *archive) load(day string) error {
+	if _, ok := a.days[day]; ok {
+		return nil
+	}
+	records, err := a.read(context.Background(), day)
+	if err != nil {
+		return err
+	}
+	a.days[day] = records
+	return nil
+}
+
+func (a *archive) add(pool string, rec *Record) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	day := rec.Time.UTC().Format(dayLayout)
+	if err := a.load(day); err != nil {
+		logrus.WithError(err).WithField("path", a.path(day)).Error("Error reading archived action history.")
+	}
+	a.days[day] = append(a.days[day], &ArchivedRecord{Pool: pool, Record: *rec})
+	a.dirty.Insert(day)
+}
+
+// flush writes the days with new records to the archive and forgets the
+// days that ended.
+func (a *archive) flush() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	today := now().UTC().Format(dayLayout)
+	for _, day := range sets.List(a.dirty) {
+		if err := writeArchive(a.opener, a.path(day), a.days[day]); err != nil {
+			return fmt.Errorf("error writing %s: %w", a.path(day), err)
+		}
+		a.dirty.Delete(day)
+	}
+	for day := range a.days {
+		if day < today && !a.dirty.Has(day) {
+			delete(a.days, day)
+		}
+	}
+	return nil
+}
+
+func writeArchive(opener opener, path string, records []*ArchivedRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	writer, err := opener.Writer(ctx, path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		io.LogClose(writer)
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if _, err := writer.Write(b); err != nil {
+		io.LogClose(writer)
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+// query returns the archived records that match the filter and aren't
+// older than the retention, newest first.
+func (a *archive) query(ctx context.Context, filter Filter) ([]*ArchivedRecord, error) {
+	current := now()
+	if oldest := current.Add(-a.retention()); filter.Since.Before(oldest) {
+		filter.Since = oldest
+	}
+	until := current
+	if !filter.Until.IsZero() && filter.Until.Before(until) {
+		until = filter.Until
+	}
+
+	var res []*ArchivedRecord
+	for day := filter.Since.UTC().Truncate(24 * time.Hour); !day.After(until); day = day.Add(24 * time.Hour) {
+		key := day.Format(dayLayout)
+		a.lock.Lock()
+		records, ok := a.days[key]
+		a.lock.Unlock()
+		if !ok {
+			var err error
+			if records, err = a.read(ctx, key); err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", a.path(key), err)
+			}
+		}
+		for _, rec := range records {
+			if filter.matches(rec) {
+				res = append(res, rec)
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time.After(res[j].Time)
+	})
+	return res, nil
+}
+
+// EnableArchive makes the history also store its records in one object per
+// day under the given prefix, which can be a /local/path, gs://path or
+// s3://path. Records older than the retention aren't queried anymore. The
+// objects of old days aren't deleted though, use the lifecycle rules of the
+// bucket for that.
+func (h *History) EnableArchive(prefix string, retention func() time.Duration) error {
+	if h.opener == nil {
+		return fmt.Errorf("cannot archive action history to %q without an opener", prefix)
+	}
+	a := &archive{
+		opener:    h.opener,
+		prefix:    prefix,
+		retention: retention,
+		days:      map[string][]*ArchivedRecord{},
+		dirty:     sets.New[string](),
+	}
+	// Load the records of today so the next flush doesn't overwrite them.
+	if err := a.load(now().UTC().Format(dayLayout)); err != nil {
+		return fmt.Errorf("error reading archived action history from %q: %w", prefix, err)
+	}
+	h.Lock()
+	defer h.Unlock()
+	h.archive = a
+	return nil
+}
+
+// Query returns the records that match the filter, newest first. The
+// records are read from the archive if it's enabled, otherwise only the
+// most recent records of every pool are available.
+func (h *History) Query(ctx context.Context, filter Filter) ([]*ArchivedRecord, error) {
+	h.Lock()
+	a := h.archive
+	h.Unlock()
+	if a != nil {
+		return a.query(ctx, filter)
+	}
+
+	var res []*ArchivedRecord
+	for pool, records := range h.AllRecords() {
+		for _, rec := range records {
+			archived := &ArchivedRecord{Pool: pool, Record: *rec}
+			if filter.matches(archived) {
+				res = append(res, archived)
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time.After(res[j].Time)
+	})
+	return res, nil
+}
+
+// QueryHandler serves the records that match the org, repo, branch, action,
+// since and until query parameters as JSON. since and until are RFC3339
+// times.
+func (h *History) QueryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		filter := Filter{
+			Org:    params.Get("org"),
+			Repo:   params.Get("repo"),
+			Branch: params.Get("branch"),
+			Action: params.Get("action"),
+		}
+		for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			if value := params.Get(name); value != "" {
+				parsed, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s: %v", name, err), http.StatusBadRequest)
+					return
+				}
+				*t = parsed
+			}
+		}
+		records, err := h.Query(r.Context(), filter)
+		if err != nil {
+			logrus.WithError(err).Error("Querying action history.")
+			http.Error(w, "error querying action history", http.StatusInternalServerError)
+			return
+		}
+		if records == nil {
+			records = []*ArchivedRecord{}
+		}
+		b, err := json.Marshal(records)
+		if err != nil {
+			logrus.WithError(err).Error("Encoding JSON history.")
+			http.Error(w, "error encoding action history", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err = w.Write(b); err != nil {
+			logrus.WithError(err).Debug("Writing JSON history response.")
+		}
+	})
+}
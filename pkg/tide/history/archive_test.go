@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+// objectOpener stores objects in memory.
+type objectOpener map[string][]byte
+
+type objectWriter struct {
+	bytes.Buffer
+	objects objectOpener
+	path    string
+}
+
+func (w *objectWriter) Close() error {
+	w.objects[w.path] = w.Bytes()
+	return nil
+}
+
+func (o objectOpener) Reader(ctx context.Context, path string) (pkgio.ReadCloser, error) {
+	content, ok := o[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (o objectOpener) Writer(ctx context.Context, path string, opts ...pkgio.WriterOptions) (pkgio.WriteCloser, error) {
+	return &objectWriter{objects: o, path: path}, nil
+}
+
+func TestArchive(t *testing.T) {
+	nowTime := time.Date(2024, 3, 10, 23, 50, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return nowTime }
+	defer func() { now = oldNow }()
+
+	objects := objectOpener{
+		"gs://bucket/history/2024-03-10.json": []byte(`[{"pool":"org/repo:main","time":"2024-03-10T08:00:00Z","action":"MERGE","tenantids":null}]`),
+		"gs://bucket/history/2024-01-01.json": []byte(`[{"pool":"org/repo:main","time":"2024-01-01T08:00:00Z","action":"MERGE","tenantids":null}]`),
+	}
+	hist := &History{logs: map[string]*recordLog{}, logSizeLimit: 1, opener: objects}
+	if err := hist.EnableArchive("gs://bucket/history/", func() time.Duration { return 7 * 24 * time.Hour }); err != nil {
+		t.Fatalf("failed to enable the archive: %v", err)
+	}
+
+	hist.Record("org/repo:main", "TRIGGER", "sha1", "", nil, nil)
+	nowTime = nowTime.Add(time.Minute)
+	hist.Record("org/other:main", "MERGE", "sha2", "", nil, nil)
+	nowTime = nowTime.Add(20 * time.Minute)
+	hist.Record("org/repo:release", "MERGE_BATCH", "sha3", "", nil, nil)
+	hist.Flush()
+
+	var day1 []ArchivedRecord
+	if err := json.Unmarshal(objects["gs://bucket/history/2024-03-10.json"], &day1); err != nil {
+		t.Fatalf("failed to read archive of the first day: %v", err)
+	}
+	if len(day1) != 3 {
+		t.Errorf("expected the archive of the first day to have 3 records, got %d", len(day1))
+	}
+	if _, ok := objects["gs://bucket/history/2024-03-11.json"]; !ok {
+		t.Error("expected the archive of the second day to be written")
+	}
+	if _, ok := hist.archive.days["2024-03-10"]; ok {
+		t.Error("expected the first day to be dropped from memory after it was flushed")
+	}
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{
+			name:     "all records within the retention",
+			expected: []string{"org/repo:release MERGE_BATCH", "org/other:main MERGE", "org/repo:main TRIGGER", "org/repo:main MERGE"},
+		},
+		{
+			name:     "repo",
+			filter:   Filter{Repo: "org/repo"},
+			expected: []string{"org/repo:release MERGE_BATCH", "org/repo:main TRIGGER", "org/repo:main MERGE"},
+		},
+		{
+			name:     "branch and action",
+			filter:   Filter{Branch: "main", Action: "MERGE"},
+			expected: []string{"org/other:main MERGE", "org/repo:main MERGE"},
+		},
+		{
+			name:     "org and time",
+			filter:   Filter{Org: "org", Until: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)},
+			expected: []string{"org/repo:main MERGE"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := hist.Query(context.Background(), tc.filter)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			var actual []string
+			for _, rec := range records {
+				actual = append(actual, rec.Pool+" "+rec.Action)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected records (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	nowTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return nowTime }
+	defer func() { now = oldNow }()
+
+	hist, err := New(10, nil, "")
+	if err != nil {
+		t.Fatalf("failed to create history: %v", err)
+	}
+	hist.Record("org/repo:main", "TRIGGER", "sha1", "", nil, nil)
+	hist.Record("https://gerrit.example.com/project:main", "MERGE", "sha2", "", nil, nil)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedPools  []string
+	}{
+		{
+			name:           "gerrit repo",
+			query:          "?repo=https://gerrit.example.com/project&branch=main",
+			expectedStatus: http.StatusOK,
+			expectedPools:  []string{"https://gerrit.example.com/project:main"},
+		},
+		{
+			name:           "no match",
+			query:          "?action=MERGE_BATCH",
+			expectedStatus: http.StatusOK,
+			expectedPools:  []string{},
+		},
+		{
+			name:           "invalid time",
+			query:          "?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			hist.QueryHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tide-history"+tc.query, nil))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var records []ArchivedRecord
+			if err := json.Unmarshal(rr.Body.Bytes(), &records); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			pools := []string{}
+			for _, rec := range records {
+				pools = append(pools, rec.Pool)
+			}
+			if diff := cmp.Diff(tc.expectedPools, pools); diff != "" {
+				t.Errorf("unexpected pools (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -47,6 +47,8 @@ type History struct {
 
 	opener opener
 	path   string
+	// archive keeps the records for longer if it's enabled.
+	archive *archive
 }
 
 // opener has methods to read and write paths
@@ -172,6 +174,9 @@ func (h *History) addRecord(poolKey string, rec *Record) {
 		h.logs[poolKey] = newRecordLog(h.logSizeLimit)
 	}
 	h.logs[poolKey].add(rec)
+	if h.archive != nil {
+		h.archive.add(poolKey, rec)
+	}
 }
 
 // ServeHTTP serves a JSON mapping from pool key -> sorted records for the pool.
@@ -188,6 +193,7 @@ func (h *History) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // Flush writes the action history to persistent storage if configured to do so.
 func (h *History) Flush() {
+	h.flushArchive()
 	if h.path == "" {
 		return
 	}
@@ -205,6 +211,22 @@ func (h *History) Flush() {
 	}
 }
 
+func (h *History) flushArchive() {
+	h.Lock()
+	a := h.archive
+	h.Unlock()
+	if a == nil {
+		return
+	}
+	start := time.Now()
+	log := logrus.WithField("prefix", a.prefix)
+	if err := a.flush(); err != nil {
+		log.WithError(err).Error("Error flushing archived action history.")
+		return
+	}
+	log.WithField("duration", time.Since(start).String()).Debug("Successfully flushed archived action history.")
+}
+
 // AllRecords generates a map from pool key -> sorted records for the pool.
 func (h *History) AllRecords() map[string][]*Record {
 	h.Lock()
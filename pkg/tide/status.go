@@ -305,6 +305,9 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 			}
 			return github.StatusError, fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Merging is blocked by issue%s %s.", s, strings.Join(numbers, ", "))), nil
 		}
+		if missingApprovingReviews(sc.config(), crc) {
+			return github.StatusPending, fmt.Sprintf(statusNotInPool, " PullRequest is missing the approving GitHub review(s) required by branch protection."), nil
+		}
 
 		// hasFulfilledQuery is a weird state, it means that the PR is not in the pool but should be. It happens when all requirements were fulfilled
 		// at the time the status controller queried GitHub but not at the time the sync controller queried GitHub.
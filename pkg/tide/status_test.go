@@ -69,6 +69,8 @@ func TestExpectedStatus(t *testing.T) {
 		additionalTideQueries []config.TideQuery
 		hasApprovingReview    bool
 		singleQuery           bool
+		// reviewsRequired makes branch protection require approving reviews.
+		reviewsRequired bool
 
 		state string
 		desc  string
@@ -729,6 +731,25 @@ func TestExpectedStatus(t *testing.T) {
 			inPool:                false,
 			hasApprovingReview:    true,
 
+			state: github.StatusSuccess,
+			desc:  "In merge pool.",
+		},
+		{
+			name:            "Missing approving review required by branch protection",
+			labels:          neededLabels,
+			milestone:       "v1.0",
+			reviewsRequired: true,
+
+			state: github.StatusPending,
+			desc:  "Not mergeable. PullRequest is missing the approving GitHub review(s) required by branch protection.",
+		},
+		{
+			name:               "Approving review required by branch protection is present",
+			labels:             neededLabels,
+			milestone:          "v1.0",
+			reviewsRequired:    true,
+			hasApprovingReview: true,
+
 			state: github.StatusSuccess,
 			desc:  "In merge pool.",
 		},
@@ -822,13 +843,20 @@ func TestExpectedStatus(t *testing.T) {
 			}
 			blocks.Repo[blockers.OrgRepo{Org: "", Repo: ""}] = items
 
-			ca := &config.Agent{}
-			ca.Set(&config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{
 				TideGitHubConfig: config.TideGitHubConfig{
 					DisplayAllQueriesInStatus: tc.displayAllTideQueries,
 					MergeLabel:                mergeLabel,
 					SquashLabel:               squashLabel,
-				}}}})
+				}}}}
+			if tc.reviewsRequired {
+				yes, approvals := true, 1
+				cfg.Tide.ContextOptions.FromBranchProtection = &yes
+				cfg.BranchProtection.Protect = &yes
+				cfg.BranchProtection.RequiredPullRequestReviews = &config.ReviewPolicy{Approvals: &approvals}
+			}
+			ca := &config.Agent{}
+			ca.Set(cfg)
 			mmc := newMergeChecker(ca.Config, &fgc{})
 
 			ctx := context.Background()
@@ -62,9 +62,11 @@ type githubClient interface {
 	GetRepo(owner, name string) (github.FullRepo, error)
 	Merge(string, string, int, github.MergeDetails) error
 	QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
+	BatchQueryWithGitHubAppsSupport(ctx context.Context, queries []github.BatchedQuery, org string) error
 	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
 	BotUserChecker() (func(candidate string) bool, error)
 	DeleteComment(org, repo string, id int) error
+	ListPullRequestCommits(org, repo string, number int) ([]github.RepositoryCommit, error)
 }
 
 type contextChecker interface {
@@ -82,6 +84,9 @@ type syncController struct {
 	prowJobClient ctrlruntimeclient.Client
 	provider      provider
 	pickNewBatch  func(sp subpool, candidates []CodeReviewCommon, maxBatchSize int) ([]CodeReviewCommon, error)
+	// baseAncestors returns up to n first-parent ancestors of the base of the
+	// subpool, nearest first.
+	baseAncestors func(sp subpool, n int) ([]string, error)
 
 	m     sync.Mutex
 	pools []Pool
@@ -430,6 +435,7 @@ func newSyncController(
 		config:        cfg,
 		provider:      provider,
 		pickNewBatch:  pickNewBatch(gc, cfg, provider),
+		baseAncestors: baseAncestors(gc),
 		changedFiles: &changedFilesAgent{
 			provider:        provider,
 			nextChangeCache: make(map[changeCacheKey][]string),
@@ -509,7 +515,7 @@ func (c *syncController) Sync() error {
 	if err != nil {
 		return err
 	}
-	filteredPools := c.filterSubpools(c.provider.isAllowedToMerge, rawPools)
+	filteredPools := c.filterSubpools(c.mergeAllowed, rawPools)
 
 	// Notify statusController about the new pool.
 	c.statusUpdate.Lock()
@@ -594,6 +600,19 @@ func subpoolsInParallel(goroutines int, sps map[string]*subpool, process func(*s
 	wg.Wait()
 }
 
+// mergeAllowed checks that the provider allows merging the PR and that the PR
+// has the approving reviews branch protection requires. If there is no error it
+// returns a string explanation if not allowed or "" if allowed.
+func (c *syncController) mergeAllowed(crc *CodeReviewCommon) (string, error) {
+	if reason, err := c.provider.isAllowedToMerge(crc); err != nil || reason != "" {
+		return reason, err
+	}
+	if missingApprovingReviews(c.config(), crc) {
+		return "PR is missing the approving GitHub reviews required by branch protection.", nil
+	}
+	return "", nil
+}
+
 // filterSubpools filters non-pool PRs out of the initially identified subpools,
 // deleting any pools that become empty.
 // See filterSubpool for filtering details.
@@ -975,7 +994,7 @@ func (c *syncController) accumulateBatch(sp subpool) (successBatch []CodeReviewC
 // prowJobsFromContexts constructs ProwJob objects from all successful presubmit contexts that include a baseSHA.
 // This is needed because otherwise we would always need retesting for results that are older than sinkers
 // max_prowjob_age.
-func (c *syncController) prowJobsFromContexts(pr *CodeReviewCommon, baseSHA string) ([]prowapi.ProwJob, error) {
+func (c *syncController) prowJobsFromContexts(pr *CodeReviewCommon, baseSHAs sets.Set[string]) ([]prowapi.ProwJob, error) {
 	headContexts, err := c.provider.headContexts(pr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get head contexts: %w", err)
@@ -985,7 +1004,7 @@ func (c *syncController) prowJobsFromContexts(pr *CodeReviewCommon, baseSHA stri
 		if headContext.State != githubql.StatusStateSuccess {
 			continue
 		}
-		if baseSHAForContext := config.BaseSHAFromContextDescription(string(headContext.Description)); baseSHAForContext != "" && baseSHAForContext == baseSHA {
+		if baseSHAForContext := config.BaseSHAFromContextDescription(string(headContext.Description)); baseSHAForContext != "" && baseSHAs.Has(baseSHAForContext) {
 			passingCurrentContexts = append(passingCurrentContexts, string((headContext.Context)))
 		}
 	}
@@ -1008,20 +1027,25 @@ func (c *syncController) prowJobsFromContexts(pr *CodeReviewCommon, baseSHA stri
 }
 
 // accumulate returns the supplied PRs sorted into three buckets based on their
-// accumulated state across the presubmits.
-func (c *syncController) accumulate(presubmits map[int][]config.Presubmit, prs []CodeReviewCommon, pjs []prowapi.ProwJob, baseSHA string) (successes, pendings, missings []CodeReviewCommon, missingTests map[int][]config.Presubmit) {
+// accumulated state across the presubmits. Results for other bases than
+// baseSHAs and results that finished longer than maxAge ago, if set, are
+// ignored.
+func (c *syncController) accumulate(presubmits map[int][]config.Presubmit, prs []CodeReviewCommon, pjs []prowapi.ProwJob, baseSHAs sets.Set[string], maxAge time.Duration) (successes, pendings, missings []CodeReviewCommon, missingTests map[int][]config.Presubmit) {
 	log := c.logger
 	missingTests = map[int][]config.Presubmit{}
 	for _, pr := range prs {
-
-		if prowjobsFromContexts, err := c.prowJobsFromContexts(&pr, baseSHA); err != nil {
-			log.WithError(err).Error("failed to get prowjobs from contexts")
-		} else {
-			pjs = append(pjs, prowjobsFromContexts...)
+		// Contexts don't tell when their job finished, so they can't be used
+		// if results expire.
+		if maxAge == 0 {
+			if prowjobsFromContexts, err := c.prowJobsFromContexts(&pr, baseSHAs); err != nil {
+				log.WithError(err).Error("failed to get prowjobs from contexts")
+			} else {
+				pjs = append(pjs, prowjobsFromContexts...)
+			}
 		}
 
 		// Accumulate the best result for each job (Passing > Pending > Failing/Unknown)
-		// We can ignore the baseSHA here because the subPool only contains ProwJobs with the correct baseSHA
+		// We can ignore the baseSHA here because the ProwJobs are only those for the baseSHAs
 		psStates := make(map[string]simpleState)
 		for _, pj := range pjs {
 			if pj.Spec.Type != prowapi.PresubmitJob {
@@ -1033,6 +1057,10 @@ func (c *syncController) accumulate(presubmits map[int][]config.Presubmit, prs [
 			if pj.Spec.Refs.Pulls[0].SHA != pr.HeadRefOID {
 				continue
 			}
+			if maxAge > 0 && pj.Complete() && time.Since(pj.Status.CompletionTime.Time) > maxAge {
+				log.WithFields(pr.logFields()).Debugf("presubmit %s finished too long ago", pj.Spec.Context)
+				continue
+			}
 
 			name := pj.Spec.Context
 			psStates[name] = getBetterSimpleState(psStates[name], toSimpleState(pj.Status.State))
@@ -1125,6 +1153,86 @@ func pickNewBatch(gc git.ClientFactory, cfg config.Getter, provider provider) fu
 	}
 }
 
+// baseAncestors returns a function listing up to n first-parent ancestors of
+// the base of a subpool, nearest first. Fewer are returned if the history of
+// the branch is shorter. The ancestors of the last base of every pool are
+// cached, as they never change.
+func baseAncestors(gc git.ClientFactory) func(sp subpool, n int) ([]string, error) {
+	type cached struct {
+		sha       string
+		ancestors []string
+		complete  bool
+	}
+	var lock sync.Mutex
+	cache := map[string]cached{}
+	return func(sp subpool, n int) ([]string, error) {
+		key := poolKey(sp.org, sp.repo, sp.branch)
+		lock.Lock()
+		entry, ok := cache[key]
+		lock.Unlock()
+		if ok && entry.sha == sp.sha && (entry.complete || len(entry.ancestors) >= n) {
+			if len(entry.ancestors) > n {
+				return entry.ancestors[:n], nil
+			}
+			return entry.ancestors, nil
+		}
+
+		r, err := gc.ClientFor(sp.org, sp.repo)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Clean()
+		entry = cached{sha: sp.sha}
+		for i := 1; i <= n; i++ {
+			sha, err := r.RevParse(fmt.Sprintf("%s~%d", sp.sha, i))
+			if err != nil {
+				// The branch has no more history.
+				entry.complete = true
+				break
+			}
+			entry.ancestors = append(entry.ancestors, strings.TrimSpace(sha))
+		}
+		lock.Lock()
+		cache[key] = entry
+		lock.Unlock()
+		return entry.ancestors, nil
+	}
+}
+
+// presubmitResults returns the ProwJobs for the base of the subpool and, if
+// results may be up to maxCommitsBehind commits behind it, the presubmits for
+// the bases it moved on from, along with the bases they are for.
+func (c *syncController) presubmitResults(sp subpool, maxCommitsBehind int) ([]prowapi.ProwJob, sets.Set[string]) {
+	pjs, baseSHAs := sp.pjs, sets.New(sp.sha)
+	if maxCommitsBehind == 0 {
+		return pjs, baseSHAs
+	}
+	ancestors, err := c.baseAncestors(sp, maxCommitsBehind)
+	if err != nil {
+		sp.log.WithError(err).Warn("Failed to determine the previous bases, only using results for the current base.")
+		return pjs, baseSHAs
+	}
+	pjs = append([]prowapi.ProwJob(nil), pjs...)
+	for _, sha := range ancestors {
+		previous := &prowapi.ProwJobList{}
+		if err := c.prowJobClient.List(
+			c.ctx,
+			previous,
+			ctrlruntimeclient.MatchingFields{cacheIndexName: cacheIndexKey(sp.org, sp.repo, sp.branch, sha)},
+			ctrlruntimeclient.InNamespace(c.config().ProwJobNamespace)); err != nil {
+			sp.log.WithError(err).WithField("previous-base-sha", sha).Warn("Failed to list the prowjobs of a previous base, ignoring its results.")
+			continue
+		}
+		for _, pj := range previous.Items {
+			if pj.Spec.Type == prowapi.PresubmitJob {
+				pjs = append(pjs, pj)
+			}
+		}
+		baseSHAs.Insert(sha)
+	}
+	return pjs, baseSHAs
+}
+
 type newBatchFunc func(sp subpool, candidates []CodeReviewCommon, maxBatchSize int) ([]CodeReviewCommon, error)
 
 // pickBatch picks PRs to form a batch.
@@ -1655,7 +1763,13 @@ func (c *syncController) presubmitsForBatch(prs []CodeReviewCommon, org, repo, b
 
 func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, error) {
 	sp.log.WithField("num_prs", len(sp.prs)).WithField("num_prowjobs", len(sp.pjs)).Info("Syncing subpool")
-	successes, pendings, missings, missingSerialTests := c.accumulate(sp.presubmits, sp.prs, sp.pjs, sp.sha)
+	staleResults := c.config().Tide.StaleResults(config.OrgRepo{Org: sp.org, Repo: sp.repo})
+	pjs, baseSHAs := c.presubmitResults(sp, staleResults.MaxCommitsBehind)
+	var maxAge time.Duration
+	if staleResults.MaxAge != nil {
+		maxAge = staleResults.MaxAge.Duration
+	}
+	successes, pendings, missings, missingSerialTests := c.accumulate(sp.presubmits, sp.prs, pjs, baseSHAs, maxAge)
 	batchMerge, batchPending := c.accumulateBatch(sp)
 	sp.log.WithFields(logrus.Fields{
 		"prs-passing":   prNumbers(successes),
@@ -2242,6 +2356,37 @@ func getBetterSimpleState(a, b simpleState) simpleState {
 	return a
 }
 
+// requireApprovingReviews returns whether the branch protection policy of the
+// branch requires approving reviews, also from code owners, if Tide takes its
+// requirements from branch protection.
+func requireApprovingReviews(c *config.Config, org, repo, branch string) bool {
+	options := config.ParseTideContextPolicyOptions(org, repo, branch, c.Tide.ContextOptions)
+	if options.FromBranchProtection == nil || !*options.FromBranchProtection {
+		return false
+	}
+	b, err := c.BranchProtection.GetOrg(org).GetRepo(repo).GetBranch(branch)
+	if err != nil {
+		return false
+	}
+	policy, err := c.GetPolicy(org, repo, branch, *b, []config.Presubmit{}, nil)
+	if err != nil || policy == nil || policy.Protect == nil || !*policy.Protect || policy.RequiredPullRequestReviews == nil {
+		return false
+	}
+	reviews := policy.RequiredPullRequestReviews
+	return (reviews.Approvals != nil && *reviews.Approvals > 0) || (reviews.RequireOwners != nil && *reviews.RequireOwners)
+}
+
+// missingApprovingReviews returns whether the GitHub PR lacks the approving
+// reviews its branch protection requires. GitHub decides whether the reviews
+// are sufficient, so stale reviews it dismissed on new commits and missing
+// reviews of code owners count.
+func missingApprovingReviews(c *config.Config, crc *CodeReviewCommon) bool {
+	if crc.GitHub == nil || !requireApprovingReviews(c, crc.Org, crc.Repo, crc.BaseRefName) {
+		return false
+	}
+	return crc.GitHub.ReviewDecision != githubql.PullRequestReviewDecisionApproved
+}
+
 func requireManuallyTriggeredJobs(c *config.Config, org, repo, branch string) bool {
 	options := config.ParseTideContextPolicyOptions(org, repo, branch, c.Tide.ContextOptions)
 	if options.FromBranchProtection != nil && *options.FromBranchProtection {
@@ -686,7 +686,7 @@ func TestAccumulate(t *testing.T) {
 				})
 			}
 
-			successes, pendings, nones, _ := syncCtrl.accumulate(test.presubmits, pulls, pjs, baseSHA)
+			successes, pendings, nones, _ := syncCtrl.accumulate(test.presubmits, pulls, pjs, sets.New(baseSHA), 0)
 
 			t.Logf("test run %d", i)
 			testPullsMatchList(t, "successes", successes, test.successes)
@@ -696,6 +696,269 @@ func TestAccumulate(t *testing.T) {
 	}
 }
 
+func TestAccumulateStaleResults(t *testing.T) {
+	const baseSHA = "8d287a3aeae90fd0aef4a70009c715712ff302cd"
+	const previousBaseSHA = "2af2ab2bd5b7c3db0aaa5a3f7ee1c0b3a8c5e1fb"
+	presubmits := map[int][]config.Presubmit{1: {{Reporter: config.Reporter{Context: "job1"}}}}
+	presubmit := func(state prowapi.ProwJobState, finished *time.Duration) prowapi.ProwJob {
+		pj := prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Job:     "job1",
+				Context: "job1",
+				Type:    prowapi.PresubmitJob,
+				Refs:    &prowapi.Refs{Pulls: []prowapi.Pull{{Number: 1, SHA: "headsha"}}},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+		if finished != nil {
+			pj.Status.CompletionTime = &metav1.Time{Time: time.Now().Add(-*finished)}
+		}
+		return pj
+	}
+	hours := func(h int) *time.Duration {
+		d := time.Duration(h) * time.Hour
+		return &d
+	}
+	successfulContext := func(base string) func(pr *PullRequest) {
+		return func(pr *PullRequest) {
+			pr.Commits.Nodes = []struct{ Commit Commit }{{
+				Commit: Commit{
+					OID: githubql.String("headsha"),
+					Status: CommitStatus{Contexts: []Context{{
+						Context:     githubql.String("job1"),
+						Description: githubql.String("Job succeeded. BaseSHA:" + base),
+						State:       githubql.StatusStateSuccess,
+					}}}},
+			}}
+		}
+	}
+
+	tests := []struct {
+		name                string
+		pjs                 []prowapi.ProwJob
+		pullRequestModifier func(*PullRequest)
+		baseSHAs            sets.Set[string]
+		maxAge              time.Duration
+
+		successes []int
+		pendings  []int
+		none      []int
+	}{
+		{
+			name:      "results don't expire by default",
+			pjs:       []prowapi.ProwJob{presubmit(prowapi.SuccessState, hours(24*7))},
+			successes: []int{1},
+		},
+		{
+			name:      "recent result",
+			pjs:       []prowapi.ProwJob{presubmit(prowapi.SuccessState, hours(1))},
+			maxAge:    2 * time.Hour,
+			successes: []int{1},
+		},
+		{
+			name:   "expired result",
+			pjs:    []prowapi.ProwJob{presubmit(prowapi.SuccessState, hours(3))},
+			maxAge: 2 * time.Hour,
+			none:   []int{1},
+		},
+		{
+			name:      "expired result and recent retest",
+			pjs:       []prowapi.ProwJob{presubmit(prowapi.SuccessState, hours(3)), presubmit(prowapi.SuccessState, hours(1))},
+			maxAge:    2 * time.Hour,
+			successes: []int{1},
+		},
+		{
+			name:     "pending retest of an expired result",
+			pjs:      []prowapi.ProwJob{presubmit(prowapi.SuccessState, hours(3)), presubmit(prowapi.PendingState, nil)},
+			maxAge:   2 * time.Hour,
+			pendings: []int{1},
+		},
+		{
+			name:                "successful context without a prowjob",
+			pullRequestModifier: successfulContext(baseSHA),
+			successes:           []int{1},
+		},
+		{
+			name:                "successful context without a prowjob has no known age",
+			pullRequestModifier: successfulContext(baseSHA),
+			maxAge:              2 * time.Hour,
+			none:                []int{1},
+		},
+		{
+			name:                "successful context for a previous base",
+			pullRequestModifier: successfulContext(previousBaseSHA),
+			none:                []int{1},
+		},
+		{
+			name:                "successful context for a previous base within the commits behind",
+			pullRequestModifier: successfulContext(previousBaseSHA),
+			baseSHAs:            sets.New(baseSHA, previousBaseSHA),
+			successes:           []int{1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			syncCtrl := &syncController{
+				provider: &GitHubProvider{ghc: &fgc{}, logger: logrus.NewEntry(logrus.New())},
+				logger:   logrus.NewEntry(logrus.New()),
+			}
+			pr := PullRequest{Number: 1, HeadRefOID: "headsha"}
+			if tc.pullRequestModifier != nil {
+				tc.pullRequestModifier(&pr)
+			}
+			baseSHAs := tc.baseSHAs
+			if baseSHAs == nil {
+				baseSHAs = sets.New(baseSHA)
+			}
+
+			successes, pendings, nones, _ := syncCtrl.accumulate(presubmits, []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&pr)}, tc.pjs, baseSHAs, tc.maxAge)
+
+			testPullsMatchList(t, "successes", successes, tc.successes)
+			testPullsMatchList(t, "pendings", pendings, tc.pendings)
+			testPullsMatchList(t, "nones", nones, tc.none)
+		})
+	}
+}
+
+func TestPresubmitResults(t *testing.T) {
+	job := func(name string, jobType prowapi.ProwJobType, baseSHA string) runtime.Object {
+		return &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: prowapi.ProwJobSpec{
+				Type: jobType,
+				Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: baseSHA},
+			},
+		}
+	}
+	current := job("current", prowapi.PresubmitJob, "sha3")
+	pjs := []runtime.Object{
+		current,
+		job("one-behind", prowapi.PresubmitJob, "sha2"),
+		job("one-behind-batch", prowapi.BatchJob, "sha2"),
+		job("two-behind", prowapi.PresubmitJob, "sha1"),
+	}
+
+	testCases := []struct {
+		name             string
+		maxCommitsBehind int
+		ancestorsErr     error
+
+		expectedJobs     []string
+		expectedBaseSHAs []string
+	}{
+		{
+			name:             "only the current base by default",
+			expectedJobs:     []string{"current"},
+			expectedBaseSHAs: []string{"sha3"},
+		},
+		{
+			name:             "presubmits of previous bases within the commits behind",
+			maxCommitsBehind: 1,
+			expectedJobs:     []string{"current", "one-behind"},
+			expectedBaseSHAs: []string{"sha2", "sha3"},
+		},
+		{
+			name:             "history shorter than the commits behind",
+			maxCommitsBehind: 5,
+			expectedJobs:     []string{"current", "one-behind", "two-behind"},
+			expectedBaseSHAs: []string{"sha1", "sha2", "sha3"},
+		},
+		{
+			name:             "previous bases can't be determined",
+			maxCommitsBehind: 1,
+			ancestorsErr:     errors.New("injected error"),
+			expectedJobs:     []string{"current"},
+			expectedBaseSHAs: []string{"sha3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := &syncController{
+				ctx:           ctx,
+				prowJobClient: newFakeManager(t, ctx, pjs...).GetClient(),
+				config: func() *config.Config {
+					return &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "default"}}
+				},
+				baseAncestors: func(sp subpool, n int) ([]string, error) {
+					ancestors := []string{"sha2", "sha1"}
+					if n < len(ancestors) {
+						ancestors = ancestors[:n]
+					}
+					return ancestors, tc.ancestorsErr
+				},
+			}
+			sp := subpool{
+				log:    logrus.WithField("test", tc.name),
+				org:    "org",
+				repo:   "repo",
+				branch: "main",
+				sha:    "sha3",
+				pjs:    []prowapi.ProwJob{*current.(*prowapi.ProwJob)},
+			}
+
+			results, baseSHAs := c.presubmitResults(sp, tc.maxCommitsBehind)
+
+			var names []string
+			for _, pj := range results {
+				names = append(names, pj.Name)
+			}
+			if diff := cmp.Diff(tc.expectedJobs, names); diff != "" {
+				t.Errorf("unexpected prowjobs (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedBaseSHAs, sets.List(baseSHAs)); diff != "" {
+				t.Errorf("unexpected base SHAs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBaseAncestorsV2(t *testing.T) {
+	lg, gc, err := localgit.NewV2()
+	if err != nil {
+		t.Fatalf("Error making local git: %v", err)
+	}
+	defer gc.Clean()
+	defer lg.Clean()
+	if err := lg.MakeFakeRepo("o", "r"); err != nil {
+		t.Fatalf("Error making fake repo: %v", err)
+	}
+	var shas []string
+	for i := 0; i < 3; i++ {
+		if err := lg.AddCommit("o", "r", map[string][]byte{"file": []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatalf("Adding commit: %v", err)
+		}
+		sha, err := lg.RevParse("o", "r", "HEAD")
+		if err != nil {
+			t.Fatalf("Parsing HEAD: %v", err)
+		}
+		shas = append(shas, strings.TrimSpace(sha))
+	}
+	sp := subpool{org: "o", repo: "r", branch: defaultBranch, sha: shas[2]}
+	ancestors := baseAncestors(gc)
+
+	got, err := ancestors(sp, 1)
+	if err != nil {
+		t.Fatalf("Getting ancestors: %v", err)
+	}
+	if diff := cmp.Diff([]string{shas[1]}, got); diff != "" {
+		t.Errorf("unexpected ancestors (-want +got):\n%s", diff)
+	}
+	// The fake repo starts with an initial commit of its own.
+	got, err = ancestors(sp, 10)
+	if err != nil {
+		t.Fatalf("Getting ancestors: %v", err)
+	}
+	if len(got) < 2 || got[0] != shas[1] || got[1] != shas[0] {
+		t.Errorf("expected the ancestors to start with %v, got %v", []string{shas[1], shas[0]}, got)
+	}
+	if len(got) >= 10 {
+		t.Errorf("expected the history to be shorter than 10 commits, got %d ancestors", len(got))
+	}
+}
+
 type fgc struct {
 	err  error
 	lock sync.Mutex
@@ -708,6 +971,7 @@ type fgc struct {
 	mergeErrs     map[int]error
 	queryCalls    int
 	issueComments map[int][]github.IssueComment
+	prCommits     map[int][]github.RepositoryCommit
 
 	expectedSHA          string
 	skipExpectedShaCheck bool
@@ -754,6 +1018,15 @@ func (f *fgc) QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, var
 	return nil
 }
 
+func (f *fgc) BatchQueryWithGitHubAppsSupport(ctx context.Context, queries []github.BatchedQuery, org string) error {
+	for _, q := range queries {
+		if err := f.QueryWithGitHubAppsSupport(ctx, q.Query, q.Vars, org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *fgc) Merge(org, repo string, number int, details github.MergeDetails) error {
 	if err, ok := f.mergeErrs[number]; ok {
 		return err
@@ -817,6 +1090,10 @@ func (f *fgc) ListIssueComments(org, repo string, number int) ([]github.IssueCom
 	return f.issueComments[number], nil
 }
 
+func (f *fgc) ListPullRequestCommits(org, repo string, number int) ([]github.RepositoryCommit, error) {
+	return f.prCommits[number], nil
+}
+
 func (f *fgc) BotUserChecker() (func(candidate string) bool, error) {
 	return func(candidate string) bool { return candidate == "foo-bot" }, nil
 }
@@ -3669,7 +3946,7 @@ func TestAccumulateReturnsCorrectMissingTests(t *testing.T) {
 				},
 				logger: log,
 			}
-			_, _, _, missingSerialTests := syncCtrl.accumulate(tc.presubmits, crcs, tc.pjs, baseSHA)
+			_, _, _, missingSerialTests := syncCtrl.accumulate(tc.presubmits, crcs, tc.pjs, sets.New(baseSHA), 0)
 			// Apiequality treats nil slices/maps equal to a zero length slice/map, keeping us from
 			// the burden of having to always initialize them
 			if !apiequality.Semantic.DeepEqual(tc.expectedPresubmits, missingSerialTests) {
@@ -5330,3 +5607,67 @@ func TestSerialRetestingConsidersPRThatIsCurrentlyBeingSRetested(t *testing.T) {
 	}
 
 }
+
+func TestMergeAllowedWithReviewsRequiredByBranchProtection(t *testing.T) {
+	yes, no, approvals := true, false, 1
+	testCases := []struct {
+		name                 string
+		fromBranchProtection bool
+		reviews              *config.ReviewPolicy
+		reviewDecision       githubql.PullRequestReviewDecision
+		expected             string
+	}{
+		{
+			name:                 "approvals required, not approved",
+			fromBranchProtection: true,
+			reviews:              &config.ReviewPolicy{Approvals: &approvals},
+			reviewDecision:       githubql.PullRequestReviewDecisionReviewRequired,
+			expected:             "PR is missing the approving GitHub reviews required by branch protection.",
+		},
+		{
+			name:                 "code owner reviews required, not approved",
+			fromBranchProtection: true,
+			reviews:              &config.ReviewPolicy{RequireOwners: &yes},
+			expected:             "PR is missing the approving GitHub reviews required by branch protection.",
+		},
+		{
+			name:                 "approvals required and approved",
+			fromBranchProtection: true,
+			reviews:              &config.ReviewPolicy{Approvals: &approvals},
+			reviewDecision:       githubql.PullRequestReviewDecisionApproved,
+		},
+		{
+			name:                 "no reviews required",
+			fromBranchProtection: true,
+			reviews:              &config.ReviewPolicy{DismissStale: &yes, RequireOwners: &no},
+		},
+		{
+			name:    "requirements not taken from branch protection",
+			reviews: &config.ReviewPolicy{Approvals: &approvals},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Tide.ContextOptions.FromBranchProtection = &tc.fromBranchProtection
+			cfg.BranchProtection.Protect = &yes
+			cfg.BranchProtection.RequiredPullRequestReviews = tc.reviews
+			configGetter := func() *config.Config { return cfg }
+			c := &syncController{
+				config:   configGetter,
+				provider: &GitHubProvider{mergeChecker: newMergeChecker(configGetter, &fgc{})},
+			}
+			pr := &PullRequest{ReviewDecision: tc.reviewDecision}
+			pr.Repository.Owner.Login = "org"
+			pr.Repository.Name = "repo"
+			pr.BaseRef.Name = "main"
+			reason, err := c.mergeAllowed(CodeReviewCommonFromPullRequest(pr))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reason != tc.expected {
+				t.Errorf("expected reason %q, got %q", tc.expected, reason)
+			}
+		})
+	}
+}
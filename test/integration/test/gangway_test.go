@@ -543,6 +543,100 @@ func TestGangwayBulkJobStatusChange(t *testing.T) {
 
 }
 
+func TestGangwayAbortJobExecution(t *testing.T) {
+	c, err := gangwayGoogleClient.NewInsecure(":32000", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	ctx = c.EmbedProjectNumber(ctx)
+	cleanup(t, ctx)
+
+	jobExecution, err := c.GRPC.CreateJobExecution(ctx, &gangway.CreateJobExecutionRequest{
+		JobName:          "sleep-periodic",
+		JobExecutionType: gangway.JobExecutionType_PERIODIC,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create job execution: %v", err)
+	}
+	timeout := 120 * time.Second
+	pollInterval := 500 * time.Millisecond
+	if err := c.WaitForJobExecutionStatus(ctx, jobExecution.Id, pollInterval, timeout, gangway.JobExecutionStatus_PENDING); err != nil {
+		t.Fatal(err)
+	}
+
+	aborted, err := c.GRPC.AbortJobExecution(ctx, &gangway.AbortJobExecutionRequest{Id: jobExecution.Id})
+	if err != nil {
+		t.Fatalf("Failed to abort job execution: %v", err)
+	}
+	if aborted.JobStatus != gangway.JobExecutionStatus_ABORTED {
+		t.Fatalf("Expected job status %v, got %v", gangway.JobExecutionStatus_ABORTED, aborted.JobStatus)
+	}
+	if err := c.WaitForJobExecutionStatus(ctx, jobExecution.Id, pollInterval, timeout, gangway.JobExecutionStatus_ABORTED); err != nil {
+		t.Fatal(err)
+	}
+
+	// Aborting a job that is already complete is rejected.
+	_, err = c.GRPC.AbortJobExecution(ctx, &gangway.AbortJobExecutionRequest{Id: jobExecution.Id})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected error code %v, got %v", codes.FailedPrecondition, err)
+	}
+
+	_, err = c.GRPC.AbortJobExecution(ctx, &gangway.AbortJobExecutionRequest{Id: "does-not-exist"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected error code %v, got %v", codes.NotFound, err)
+	}
+
+	cleanup(t, ctx)
+}
+
+func TestGangwayClearSchedulingGate(t *testing.T) {
+	c, err := gangwayGoogleClient.NewInsecure(":32000", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	ctx = c.EmbedProjectNumber(ctx)
+	cleanup(t, ctx)
+
+	jobExecution, err := c.GRPC.CreateJobExecution(ctx, &gangway.CreateJobExecutionRequest{
+		JobName:          "gated-periodic",
+		JobExecutionType: gangway.JobExecutionType_PERIODIC,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create job execution: %v", err)
+	}
+	if jobExecution.JobStatus != gangway.JobExecutionStatus_WAITING {
+		t.Fatalf("Expected job status %v, got %v", gangway.JobExecutionStatus_WAITING, jobExecution.JobStatus)
+	}
+
+	_, err = c.GRPC.ClearSchedulingGate(ctx, &gangway.ClearSchedulingGateRequest{Id: jobExecution.Id, Gate: "unknown-gate"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected error code %v, got %v", codes.InvalidArgument, err)
+	}
+
+	if _, err := c.GRPC.ClearSchedulingGate(ctx, &gangway.ClearSchedulingGateRequest{Id: jobExecution.Id, Gate: "change-approval"}); err != nil {
+		t.Fatalf("Failed to clear scheduling gate: %v", err)
+	}
+	timeout := 120 * time.Second
+	pollInterval := 500 * time.Millisecond
+	if err := c.WaitForJobExecutionStatus(ctx, jobExecution.Id, pollInterval, timeout, gangway.JobExecutionStatus_SUCCESS); err != nil {
+		t.Fatal(err)
+	}
+
+	// The job is no longer waiting for its gates.
+	_, err = c.GRPC.ClearSchedulingGate(ctx, &gangway.ClearSchedulingGateRequest{Id: jobExecution.Id, Gate: "change-approval"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected error code %v, got %v", codes.FailedPrecondition, err)
+	}
+
+	cleanup(t, ctx)
+}
+
 func cleanup(t *testing.T, ctx context.Context) {
 	clusterContext := getClusterContext()
 	t.Logf("Creating client for cluster: %s", clusterContext)